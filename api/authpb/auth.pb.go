@@ -30,18 +30,23 @@ const (
 	READ      Permission_Type = 0
 	WRITE     Permission_Type = 1
 	READWRITE Permission_Type = 2
+	// LEASE permits revoking and keeping alive the leases granted by other
+	// users. It applies to all leases, and carries no key range.
+	LEASE Permission_Type = 3
 )
 
 var Permission_Type_name = map[int32]string{
 	0: "READ",
 	1: "WRITE",
 	2: "READWRITE",
+	3: "LEASE",
 }
 
 var Permission_Type_value = map[string]int32{
 	"READ":      0,
 	"WRITE":     1,
 	"READWRITE": 2,
+	"LEASE":     3,
 }
 
 func (x Permission_Type) String() string {
@@ -232,29 +237,29 @@ func init() {
 func init() { proto.RegisterFile("auth.proto", fileDescriptor_8bbd6f3875b0e874) }
 
 var fileDescriptor_8bbd6f3875b0e874 = []byte{
-	// 338 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0xcf, 0x4e, 0xea, 0x40,
-	0x14, 0xc6, 0x3b, 0xb4, 0x70, 0xdb, 0xc3, 0x85, 0x90, 0x13, 0x72, 0x6f, 0x83, 0x49, 0x6d, 0xba,
-	0x6a, 0x5c, 0x54, 0x85, 0x8d, 0x5b, 0x8c, 0x2c, 0x5c, 0x49, 0x26, 0x18, 0x97, 0xa4, 0xa4, 0x13,
-	0x24, 0xc0, 0x4c, 0x33, 0x83, 0x31, 0x6c, 0x7c, 0x0e, 0x17, 0x3e, 0x10, 0x4b, 0x1e, 0x41, 0xf0,
-	0x45, 0x4c, 0x67, 0xf8, 0x13, 0xa2, 0xbb, 0xef, 0x7c, 0xe7, 0xfb, 0x66, 0x7e, 0x99, 0x01, 0x48,
-	0x5f, 0x16, 0xcf, 0x49, 0x2e, 0xc5, 0x42, 0x60, 0xa5, 0xd0, 0xf9, 0xa8, 0xd5, 0x1c, 0x8b, 0xb1,
-	0xd0, 0xd6, 0x65, 0xa1, 0xcc, 0x36, 0xba, 0x86, 0xfa, 0xa3, 0x62, 0xb2, 0x9b, 0x65, 0x0f, 0xf9,
-	0x62, 0x22, 0xb8, 0xc2, 0x73, 0xa8, 0x72, 0x31, 0xcc, 0x53, 0xa5, 0x5e, 0x85, 0xcc, 0x7c, 0x12,
-	0x92, 0xd8, 0xa5, 0xc0, 0x45, 0x7f, 0xe7, 0x44, 0x6f, 0xe0, 0x14, 0x15, 0x44, 0x70, 0x78, 0x3a,
-	0x67, 0x3a, 0xf1, 0x97, 0x6a, 0x8d, 0x2d, 0x70, 0x0f, 0xcd, 0x92, 0xf6, 0x0f, 0x33, 0x36, 0xa1,
-	0x2c, 0xc5, 0x8c, 0x29, 0xdf, 0x0e, 0xed, 0xd8, 0xa3, 0x66, 0xc0, 0x2b, 0xf8, 0x23, 0xcc, 0xcd,
-	0xbe, 0x13, 0x92, 0xb8, 0xda, 0xfe, 0x97, 0x18, 0xe0, 0xe4, 0x94, 0x8b, 0xee, 0x63, 0xd1, 0x07,
-	0x01, 0xe8, 0x33, 0x39, 0x9f, 0x28, 0x35, 0x11, 0x1c, 0x3b, 0xe0, 0xe6, 0x4c, 0xce, 0x07, 0xcb,
-	0xdc, 0xa0, 0xd4, 0xdb, 0xff, 0xf7, 0x27, 0x1c, 0x53, 0x49, 0xb1, 0xa6, 0x87, 0x20, 0x36, 0xc0,
-	0x9e, 0xb2, 0xe5, 0x0e, 0xb1, 0x90, 0x78, 0x06, 0x9e, 0x4c, 0xf9, 0x98, 0x0d, 0x19, 0xcf, 0x7c,
-	0xdb, 0xa0, 0x6b, 0xa3, 0xc7, 0xb3, 0xe8, 0x02, 0x1c, 0x5d, 0x73, 0xc1, 0xa1, 0xbd, 0xee, 0x5d,
-	0xc3, 0x42, 0x0f, 0xca, 0x4f, 0xf4, 0x7e, 0xd0, 0x6b, 0x10, 0xac, 0x81, 0x57, 0x98, 0x66, 0x2c,
-	0x45, 0x03, 0x70, 0xa8, 0x98, 0xb1, 0x5f, 0x9f, 0xe7, 0x06, 0x6a, 0x53, 0xb6, 0x3c, 0x62, 0xf9,
-	0xa5, 0xd0, 0x8e, 0xab, 0x6d, 0xfc, 0x09, 0x4c, 0x4f, 0x83, 0xb7, 0xfe, 0x6a, 0x13, 0x58, 0xeb,
-	0x4d, 0x60, 0xad, 0xb6, 0x01, 0x59, 0x6f, 0x03, 0xf2, 0xb9, 0x0d, 0xc8, 0xfb, 0x57, 0x60, 0x8d,
-	0x2a, 0xfa, 0x23, 0x3b, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x61, 0x66, 0xc6, 0x9d, 0xf4, 0x01,
-	0x00, 0x00,
+	// 341 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0xdf, 0x4a, 0xc3, 0x30,
+	0x18, 0xc5, 0x9b, 0xb5, 0x9b, 0xed, 0x37, 0x37, 0x4a, 0x18, 0x5a, 0x26, 0xd4, 0xd2, 0xab, 0x5e,
+	0x55, 0xdd, 0x10, 0xbc, 0x9d, 0xd8, 0x0b, 0x41, 0x70, 0xc4, 0x89, 0x97, 0xa3, 0xa3, 0x61, 0x8e,
+	0x6d, 0x49, 0x69, 0x26, 0xb2, 0x1b, 0x9f, 0xc3, 0x37, 0xf0, 0x55, 0x76, 0xb9, 0x47, 0x70, 0xf3,
+	0x45, 0x24, 0xc9, 0xfe, 0x30, 0xf4, 0xee, 0x7c, 0xe7, 0x3b, 0x27, 0xf9, 0x91, 0x00, 0xa4, 0x6f,
+	0xb3, 0xd7, 0x38, 0x2f, 0xf8, 0x8c, 0xe3, 0x8a, 0xd4, 0xf9, 0xa0, 0xd9, 0x18, 0xf2, 0x21, 0x57,
+	0xd6, 0x85, 0x54, 0x7a, 0x1b, 0x5e, 0x41, 0xfd, 0x59, 0xd0, 0xa2, 0x93, 0x65, 0x8f, 0xf9, 0x6c,
+	0xc4, 0x99, 0xc0, 0xe7, 0x50, 0x65, 0xbc, 0x9f, 0xa7, 0x42, 0xbc, 0xf3, 0x22, 0xf3, 0x50, 0x80,
+	0x22, 0x9b, 0x00, 0xe3, 0xdd, 0x8d, 0x13, 0x7e, 0x80, 0x25, 0x2b, 0x18, 0x83, 0xc5, 0xd2, 0x29,
+	0x55, 0x89, 0x63, 0xa2, 0x34, 0x6e, 0x82, 0xbd, 0x6b, 0x96, 0x94, 0xbf, 0x9b, 0x71, 0x03, 0xca,
+	0x05, 0x9f, 0x50, 0xe1, 0x99, 0x81, 0x19, 0x39, 0x44, 0x0f, 0xf8, 0x12, 0x8e, 0xb8, 0xbe, 0xd9,
+	0xb3, 0x02, 0x14, 0x55, 0x5b, 0x27, 0xb1, 0x06, 0x8e, 0x0f, 0xb9, 0xc8, 0x36, 0x16, 0x7e, 0x21,
+	0x80, 0x2e, 0x2d, 0xa6, 0x23, 0x21, 0x46, 0x9c, 0xe1, 0x36, 0xd8, 0x39, 0x2d, 0xa6, 0xbd, 0x79,
+	0xae, 0x51, 0xea, 0xad, 0xd3, 0xed, 0x09, 0xfb, 0x54, 0x2c, 0xd7, 0x64, 0x17, 0xc4, 0x2e, 0x98,
+	0x63, 0x3a, 0xdf, 0x20, 0x4a, 0x89, 0xcf, 0xc0, 0x29, 0x52, 0x36, 0xa4, 0x7d, 0xca, 0x32, 0xcf,
+	0xd4, 0xe8, 0xca, 0x48, 0x58, 0x16, 0x5e, 0x83, 0xa5, 0x6a, 0x36, 0x58, 0x24, 0xe9, 0xdc, 0xb9,
+	0x06, 0x76, 0xa0, 0xfc, 0x42, 0xee, 0x7b, 0x89, 0x8b, 0x70, 0x0d, 0x1c, 0x69, 0xea, 0xb1, 0x24,
+	0x37, 0x0f, 0x49, 0xe7, 0x29, 0x71, 0xcd, 0xb0, 0x07, 0x16, 0xe1, 0x13, 0xfa, 0xef, 0x4b, 0xdd,
+	0x40, 0x6d, 0x4c, 0xe7, 0x7b, 0x42, 0xaf, 0x14, 0x98, 0x51, 0xb5, 0x85, 0xff, 0xb2, 0x93, 0xc3,
+	0xe0, 0xad, 0xb7, 0x58, 0xf9, 0xc6, 0x72, 0xe5, 0x1b, 0x8b, 0xb5, 0x8f, 0x96, 0x6b, 0x1f, 0x7d,
+	0xaf, 0x7d, 0xf4, 0xf9, 0xe3, 0x1b, 0x83, 0x8a, 0xfa, 0xd3, 0xf6, 0xef, 0x00, 0x3c, 0xc3, 0xca,
+	0x3c, 0xff, 0x01, 0x00, 0x00,
 }
 
 func (m *UserAddOptions) Marshal() (dAtA []byte, err error) {
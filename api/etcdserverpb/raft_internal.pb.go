@@ -31,10 +31,20 @@ type RequestHeader struct {
 	// username is a username that is associated with an auth token of gRPC connection
 	Username string `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
 	// auth_revision is a revision number of auth.authStore. It is not related to mvcc
-	AuthRevision         uint64   `protobuf:"varint,3,opt,name=auth_revision,json=authRevision,proto3" json:"auth_revision,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	AuthRevision uint64 `protobuf:"varint,3,opt,name=auth_revision,json=authRevision,proto3" json:"auth_revision,omitempty"`
+	// mirror marks the writes of a standby cluster mirroring its primary cluster,
+	// which are not rejected as client writes are.
+	Mirror bool `protobuf:"varint,4,opt,name=mirror,proto3" json:"mirror,omitempty"`
+	// roles are the roles of a user authenticated by an external identity
+	// provider, which are mapped from the claims of its token in place of
+	// the roles of a user of the auth store.
+	Roles []string `protobuf:"bytes,5,rep,name=roles,proto3" json:"roles,omitempty"`
+	// trace_context holds the W3C trace context and baggage of the request,
+	// propagated to its apply on each member and to the events of its writes.
+	TraceContext         map[string]string `protobuf:"bytes,6,rep,name=trace_context,json=traceContext,proto3" json:"trace_context,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *RequestHeader) Reset()         { *m = RequestHeader{} }
@@ -73,18 +83,24 @@ var xxx_messageInfo_RequestHeader proto.InternalMessageInfo
 // An InternalRaftRequest is the union of all requests which can be
 // sent via raft.
 type InternalRaftRequest struct {
-	Header                   *RequestHeader                            `protobuf:"bytes,100,opt,name=header,proto3" json:"header,omitempty"`
-	ID                       uint64                                    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
-	V2                       *Request                                  `protobuf:"bytes,2,opt,name=v2,proto3" json:"v2,omitempty"`
-	Range                    *RangeRequest                             `protobuf:"bytes,3,opt,name=range,proto3" json:"range,omitempty"`
-	Put                      *PutRequest                               `protobuf:"bytes,4,opt,name=put,proto3" json:"put,omitempty"`
-	DeleteRange              *DeleteRangeRequest                       `protobuf:"bytes,5,opt,name=delete_range,json=deleteRange,proto3" json:"delete_range,omitempty"`
-	Txn                      *TxnRequest                               `protobuf:"bytes,6,opt,name=txn,proto3" json:"txn,omitempty"`
-	Compaction               *CompactionRequest                        `protobuf:"bytes,7,opt,name=compaction,proto3" json:"compaction,omitempty"`
-	LeaseGrant               *LeaseGrantRequest                        `protobuf:"bytes,8,opt,name=lease_grant,json=leaseGrant,proto3" json:"lease_grant,omitempty"`
-	LeaseRevoke              *LeaseRevokeRequest                       `protobuf:"bytes,9,opt,name=lease_revoke,json=leaseRevoke,proto3" json:"lease_revoke,omitempty"`
-	Alarm                    *AlarmRequest                             `protobuf:"bytes,10,opt,name=alarm,proto3" json:"alarm,omitempty"`
-	LeaseCheckpoint          *LeaseCheckpointRequest                   `protobuf:"bytes,11,opt,name=lease_checkpoint,json=leaseCheckpoint,proto3" json:"lease_checkpoint,omitempty"`
+	Header          *RequestHeader          `protobuf:"bytes,100,opt,name=header,proto3" json:"header,omitempty"`
+	ID              uint64                  `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	V2              *Request                `protobuf:"bytes,2,opt,name=v2,proto3" json:"v2,omitempty"`
+	Range           *RangeRequest           `protobuf:"bytes,3,opt,name=range,proto3" json:"range,omitempty"`
+	Put             *PutRequest             `protobuf:"bytes,4,opt,name=put,proto3" json:"put,omitempty"`
+	DeleteRange     *DeleteRangeRequest     `protobuf:"bytes,5,opt,name=delete_range,json=deleteRange,proto3" json:"delete_range,omitempty"`
+	Txn             *TxnRequest             `protobuf:"bytes,6,opt,name=txn,proto3" json:"txn,omitempty"`
+	Compaction      *CompactionRequest      `protobuf:"bytes,7,opt,name=compaction,proto3" json:"compaction,omitempty"`
+	LeaseGrant      *LeaseGrantRequest      `protobuf:"bytes,8,opt,name=lease_grant,json=leaseGrant,proto3" json:"lease_grant,omitempty"`
+	LeaseRevoke     *LeaseRevokeRequest     `protobuf:"bytes,9,opt,name=lease_revoke,json=leaseRevoke,proto3" json:"lease_revoke,omitempty"`
+	Alarm           *AlarmRequest           `protobuf:"bytes,10,opt,name=alarm,proto3" json:"alarm,omitempty"`
+	LeaseCheckpoint *LeaseCheckpointRequest `protobuf:"bytes,11,opt,name=lease_checkpoint,json=leaseCheckpoint,proto3" json:"lease_checkpoint,omitempty"`
+	Purge           *PurgeRequest           `protobuf:"bytes,12,opt,name=purge,proto3" json:"purge,omitempty"`
+	PinRevision     *PinRevisionRequest     `protobuf:"bytes,13,opt,name=pin_revision,json=pinRevision,proto3" json:"pin_revision,omitempty"`
+	UnpinRevision   *UnpinRevisionRequest   `protobuf:"bytes,14,opt,name=unpin_revision,json=unpinRevision,proto3" json:"unpin_revision,omitempty"`
+	// compressed_request is the zstd compressed InternalRaftRequest of a large
+	// proposal, which sets no other field, decompressed on apply.
+	CompressedRequest        []byte                                    `protobuf:"bytes,15,opt,name=compressed_request,json=compressedRequest,proto3" json:"compressed_request,omitempty"`
 	AuthEnable               *AuthEnableRequest                        `protobuf:"bytes,1000,opt,name=auth_enable,json=authEnable,proto3" json:"auth_enable,omitempty"`
 	AuthDisable              *AuthDisableRequest                       `protobuf:"bytes,1011,opt,name=auth_disable,json=authDisable,proto3" json:"auth_disable,omitempty"`
 	AuthStatus               *AuthStatusRequest                        `protobuf:"bytes,1013,opt,name=auth_status,json=authStatus,proto3" json:"auth_status,omitempty"`
@@ -230,6 +246,7 @@ var xxx_messageInfo_InternalAuthenticateRequest proto.InternalMessageInfo
 
 func init() {
 	proto.RegisterType((*RequestHeader)(nil), "etcdserverpb.RequestHeader")
+	proto.RegisterMapType((map[string]string)(nil), "etcdserverpb.RequestHeader.TraceContextEntry")
 	proto.RegisterType((*InternalRaftRequest)(nil), "etcdserverpb.InternalRaftRequest")
 	proto.RegisterType((*EmptyResponse)(nil), "etcdserverpb.EmptyResponse")
 	proto.RegisterType((*InternalAuthenticateRequest)(nil), "etcdserverpb.InternalAuthenticateRequest")
@@ -238,73 +255,85 @@ func init() {
 func init() { proto.RegisterFile("raft_internal.proto", fileDescriptor_b4c9a9be0cfca103) }
 
 var fileDescriptor_b4c9a9be0cfca103 = []byte{
-	// 1054 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x56, 0x5d, 0x6f, 0x1b, 0x45,
-	0x14, 0xad, 0xd3, 0x34, 0x89, 0xc7, 0x49, 0x9a, 0x4e, 0x52, 0x3a, 0x38, 0x92, 0x71, 0x03, 0x2d,
-	0x01, 0x8a, 0x53, 0x1c, 0x78, 0xe1, 0x05, 0x5c, 0x3b, 0x4a, 0x83, 0x4a, 0x15, 0x6d, 0x0b, 0xaa,
-	0x84, 0xd0, 0x32, 0xde, 0xbd, 0xb1, 0xb7, 0x59, 0xef, 0x2e, 0x33, 0x63, 0x37, 0x7d, 0xe5, 0x91,
-	0x67, 0x40, 0xfc, 0x0c, 0x3e, 0xff, 0x43, 0x85, 0xf8, 0x28, 0xf0, 0x07, 0x20, 0xbc, 0xf0, 0x0e,
-	0xbc, 0xa3, 0xf9, 0xd8, 0x5d, 0xaf, 0x3d, 0xce, 0xdb, 0xfa, 0xde, 0x73, 0xcf, 0x39, 0x33, 0x73,
-	0xef, 0x78, 0xd0, 0x3a, 0xa3, 0x47, 0xc2, 0x0d, 0x22, 0x01, 0x2c, 0xa2, 0x61, 0x23, 0x61, 0xb1,
-	0x88, 0xf1, 0x32, 0x08, 0xcf, 0xe7, 0xc0, 0x46, 0xc0, 0x92, 0x6e, 0x75, 0xa3, 0x17, 0xf7, 0x62,
-	0x95, 0xd8, 0x91, 0x5f, 0x1a, 0x53, 0x5d, 0xcb, 0x31, 0x26, 0x52, 0x66, 0x89, 0x67, 0x3e, 0xeb,
-	0x32, 0xb9, 0x43, 0x93, 0x60, 0x67, 0x04, 0x8c, 0x07, 0x71, 0x94, 0x74, 0xd3, 0x2f, 0x83, 0xb8,
-	0x9e, 0x21, 0x06, 0x30, 0xe8, 0x02, 0xe3, 0xfd, 0x20, 0x49, 0xba, 0x63, 0x3f, 0x34, 0x6e, 0x8b,
-	0xa1, 0x15, 0x07, 0x3e, 0x1e, 0x02, 0x17, 0xb7, 0x81, 0xfa, 0xc0, 0xf0, 0x2a, 0x9a, 0x3b, 0xe8,
-	0x90, 0x52, 0xbd, 0xb4, 0x3d, 0xef, 0xcc, 0x1d, 0x74, 0x70, 0x15, 0x2d, 0x0d, 0xb9, 0x34, 0x3f,
-	0x00, 0x32, 0x57, 0x2f, 0x6d, 0x97, 0x9d, 0xec, 0x37, 0xbe, 0x81, 0x56, 0xe8, 0x50, 0xf4, 0x5d,
-	0x06, 0xa3, 0x40, 0x6a, 0x93, 0xf3, 0xb2, 0xec, 0xd6, 0xe2, 0xa7, 0xdf, 0x93, 0xf3, 0xbb, 0x8d,
-	0xd7, 0x9c, 0x65, 0x99, 0x75, 0x4c, 0xf2, 0xcd, 0xc5, 0x4f, 0x54, 0xf8, 0xe6, 0xd6, 0x0f, 0x18,
-	0xad, 0x1f, 0x98, 0x1d, 0x71, 0xe8, 0x91, 0x30, 0x06, 0xf0, 0x2e, 0x5a, 0xe8, 0x2b, 0x13, 0xc4,
-	0xaf, 0x97, 0xb6, 0x2b, 0xcd, 0xcd, 0xc6, 0xf8, 0x3e, 0x35, 0x0a, 0x3e, 0x1d, 0x03, 0x9d, 0xf2,
-	0x7b, 0x0d, 0xcd, 0x8d, 0x9a, 0xca, 0x69, 0xa5, 0x79, 0xd9, 0x4a, 0xe0, 0xcc, 0x8d, 0x9a, 0xf8,
-	0x26, 0xba, 0xc0, 0x68, 0xd4, 0x03, 0x65, 0xb9, 0xd2, 0xac, 0x4e, 0x20, 0x65, 0x2a, 0x85, 0x6b,
-	0x20, 0x7e, 0x19, 0x9d, 0x4f, 0x86, 0x82, 0xcc, 0x2b, 0x3c, 0x29, 0xe2, 0x0f, 0x87, 0xe9, 0x22,
-	0x1c, 0x09, 0xc2, 0x6d, 0xb4, 0xec, 0x43, 0x08, 0x02, 0x5c, 0x2d, 0x72, 0x41, 0x15, 0xd5, 0x8b,
-	0x45, 0x1d, 0x85, 0x28, 0x48, 0x55, 0xfc, 0x3c, 0x26, 0x05, 0xc5, 0x49, 0x44, 0x16, 0x6c, 0x82,
-	0xf7, 0x4f, 0xa2, 0x4c, 0x50, 0x9c, 0x44, 0xf8, 0x2d, 0x84, 0xbc, 0x78, 0x90, 0x50, 0x4f, 0xc8,
-	0x63, 0x58, 0x54, 0x25, 0xcf, 0x15, 0x4b, 0xda, 0x59, 0x3e, 0xad, 0x1c, 0x2b, 0xc1, 0x6f, 0xa3,
-	0x4a, 0x08, 0x94, 0x83, 0xdb, 0x63, 0x34, 0x12, 0x64, 0xc9, 0xc6, 0x70, 0x47, 0x02, 0xf6, 0x65,
-	0x3e, 0x63, 0x08, 0xb3, 0x90, 0x5c, 0xb3, 0x66, 0x60, 0x30, 0x8a, 0x8f, 0x81, 0x94, 0x6d, 0x6b,
-	0x56, 0x14, 0x8e, 0x02, 0x64, 0x6b, 0x0e, 0xf3, 0x98, 0x3c, 0x16, 0x1a, 0x52, 0x36, 0x20, 0xc8,
-	0x76, 0x2c, 0x2d, 0x99, 0xca, 0x8e, 0x45, 0x01, 0xf1, 0x03, 0xb4, 0xa6, 0x65, 0xbd, 0x3e, 0x78,
-	0xc7, 0x49, 0x1c, 0x44, 0x82, 0x54, 0x54, 0xf1, 0x0b, 0x16, 0xe9, 0x76, 0x06, 0x32, 0x34, 0x69,
-	0xb3, 0xbe, 0xee, 0x5c, 0x0c, 0x8b, 0x00, 0xdc, 0x42, 0x15, 0xd5, 0xdd, 0x10, 0xd1, 0x6e, 0x08,
-	0xe4, 0x6f, 0xeb, 0xae, 0xb6, 0x86, 0xa2, 0xbf, 0xa7, 0x00, 0xd9, 0x9e, 0xd0, 0x2c, 0x84, 0x3b,
-	0x48, 0x8d, 0x80, 0xeb, 0x07, 0x5c, 0x71, 0xfc, 0xb3, 0x68, 0xdb, 0x14, 0xc9, 0xd1, 0xd1, 0x88,
-	0x6c, 0x53, 0x68, 0x1e, 0xc3, 0xef, 0x18, 0x23, 0x5c, 0x50, 0x31, 0xe4, 0xe4, 0xbf, 0x99, 0x46,
-	0xee, 0x29, 0xc0, 0xc4, 0xca, 0xde, 0xd0, 0x8e, 0x74, 0x0e, 0xdf, 0xd5, 0x8e, 0x20, 0x12, 0x81,
-	0x47, 0x05, 0x90, 0x7f, 0x35, 0xd9, 0x4b, 0x45, 0xb2, 0x74, 0x3a, 0x5b, 0x63, 0xd0, 0xd4, 0x5a,
-	0xa1, 0x1e, 0xef, 0x99, 0x2b, 0x40, 0xde, 0x09, 0x2e, 0xf5, 0x7d, 0xf2, 0xe3, 0xd2, 0xac, 0x25,
-	0xbe, 0xc7, 0x81, 0xb5, 0x7c, 0xbf, 0xb0, 0x44, 0x13, 0xc3, 0x77, 0xd1, 0x5a, 0x4e, 0xa3, 0x87,
-	0x80, 0xfc, 0xa4, 0x99, 0x9e, 0xb7, 0x33, 0x99, 0xe9, 0x31, 0x64, 0xab, 0xb4, 0x10, 0x2e, 0xda,
-	0xea, 0x81, 0x20, 0x3f, 0x9f, 0x69, 0x6b, 0x1f, 0xc4, 0x94, 0xad, 0x7d, 0x10, 0xb8, 0x87, 0x9e,
-	0xcd, 0x69, 0xbc, 0xbe, 0x1c, 0x4b, 0x37, 0xa1, 0x9c, 0x3f, 0x8a, 0x99, 0x4f, 0x7e, 0xd1, 0x94,
-	0xaf, 0xd8, 0x29, 0xdb, 0x0a, 0x7d, 0x68, 0xc0, 0x29, 0xfb, 0x33, 0xd4, 0x9a, 0xc6, 0x0f, 0xd0,
-	0xc6, 0x98, 0x5f, 0x39, 0x4f, 0x2e, 0x8b, 0x43, 0x20, 0x4f, 0xb5, 0xc6, 0xf5, 0x19, 0xb6, 0xd5,
-	0x2c, 0xc6, 0x79, 0xdb, 0x5c, 0xa2, 0x93, 0x19, 0xfc, 0x01, 0xba, 0x9c, 0x33, 0xeb, 0xd1, 0xd4,
-	0xd4, 0xbf, 0x6a, 0xea, 0x17, 0xed, 0xd4, 0x66, 0x46, 0xc7, 0xb8, 0x31, 0x9d, 0x4a, 0xe1, 0xdb,
-	0x68, 0x35, 0x27, 0x0f, 0x03, 0x2e, 0xc8, 0x6f, 0x9a, 0xf5, 0xaa, 0x9d, 0xf5, 0x4e, 0xc0, 0x45,
-	0xa1, 0x8f, 0xd2, 0x60, 0xc6, 0x24, 0xad, 0x69, 0xa6, 0xdf, 0x67, 0x32, 0x49, 0xe9, 0x29, 0xa6,
-	0x34, 0x98, 0x1d, 0xbd, 0x62, 0x92, 0x1d, 0xf9, 0x55, 0x79, 0xd6, 0xd1, 0xcb, 0x9a, 0xc9, 0x8e,
-	0x34, 0xb1, 0xac, 0x23, 0x15, 0x8d, 0xe9, 0xc8, 0xaf, 0xcb, 0xb3, 0x3a, 0x52, 0x56, 0x59, 0x3a,
-	0x32, 0x0f, 0x17, 0x6d, 0xc9, 0x8e, 0xfc, 0xe6, 0x4c, 0x5b, 0x93, 0x1d, 0x69, 0x62, 0xf8, 0x21,
-	0xaa, 0x8e, 0xd1, 0xa8, 0x46, 0x49, 0x80, 0x0d, 0x02, 0xae, 0xfe, 0x7f, 0xbf, 0xd5, 0x9c, 0x37,
-	0x66, 0x70, 0x4a, 0xf8, 0x61, 0x86, 0x4e, 0xf9, 0xaf, 0x50, 0x7b, 0x1e, 0x0f, 0xd0, 0x66, 0xae,
-	0x65, 0x5a, 0x67, 0x4c, 0xec, 0x3b, 0x2d, 0xf6, 0xaa, 0x5d, 0x4c, 0x77, 0xc9, 0xb4, 0x1a, 0xa1,
-	0x33, 0x00, 0xf8, 0x23, 0xb4, 0xee, 0x85, 0x43, 0x2e, 0x80, 0xb9, 0xe6, 0x2d, 0xe3, 0x72, 0x10,
-	0xe4, 0x33, 0x64, 0x46, 0x60, 0xfc, 0x21, 0xd3, 0x68, 0x6b, 0xe4, 0xfb, 0x1a, 0x78, 0x0f, 0xc4,
-	0xd4, 0xad, 0x77, 0xc9, 0x9b, 0x84, 0xe0, 0x87, 0xe8, 0x4a, 0xaa, 0xa0, 0xc9, 0x5c, 0x2a, 0x04,
-	0x53, 0x2a, 0x9f, 0x23, 0x73, 0x0f, 0xda, 0x54, 0xde, 0x55, 0xb1, 0x96, 0x10, 0xcc, 0x26, 0xb4,
-	0xe1, 0x59, 0x50, 0xf8, 0x43, 0x84, 0xfd, 0xf8, 0x51, 0xd4, 0x63, 0xd4, 0x07, 0x37, 0x88, 0x8e,
-	0x62, 0x25, 0xf3, 0x85, 0x96, 0xb9, 0x56, 0x94, 0xe9, 0xa4, 0xc0, 0x83, 0xe8, 0x28, 0xb6, 0x49,
-	0xac, 0xf9, 0x13, 0x88, 0xfc, 0x31, 0x75, 0x11, 0xad, 0xec, 0x0d, 0x12, 0xf1, 0xd8, 0x01, 0x9e,
-	0xc4, 0x11, 0x87, 0xad, 0xc7, 0x68, 0xf3, 0x8c, 0xeb, 0x1b, 0x63, 0x34, 0xaf, 0xde, 0x72, 0x25,
-	0xf5, 0x96, 0x53, 0xdf, 0xf2, 0x8d, 0x97, 0xdd, 0x6a, 0xe6, 0x8d, 0x97, 0xfe, 0xc6, 0x57, 0xd1,
-	0x32, 0x0f, 0x06, 0x49, 0x08, 0xae, 0x88, 0x8f, 0x41, 0x3f, 0xf1, 0xca, 0x4e, 0x45, 0xc7, 0xee,
-	0xcb, 0x50, 0xe6, 0xe5, 0xd6, 0xc6, 0x93, 0x3f, 0x6b, 0xe7, 0x9e, 0x9c, 0xd6, 0x4a, 0x4f, 0x4f,
-	0x6b, 0xa5, 0x3f, 0x4e, 0x6b, 0xa5, 0x2f, 0xff, 0xaa, 0x9d, 0xeb, 0x2e, 0xa8, 0x97, 0xe6, 0xee,
-	0xff, 0x01, 0x00, 0x00, 0xff, 0xff, 0x30, 0x36, 0x53, 0xc6, 0x0b, 0x0b, 0x00, 0x00,
+	// 1244 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x97, 0x5d, 0x73, 0xdb, 0x44,
+	0x17, 0xc7, 0x6b, 0xbb, 0x76, 0xe2, 0x95, 0x9d, 0x26, 0x5b, 0xf7, 0xe9, 0x3e, 0xee, 0x90, 0xba,
+	0x81, 0x16, 0x03, 0xad, 0x5b, 0x5c, 0xe8, 0x40, 0x6f, 0x8a, 0x6b, 0x67, 0xda, 0x30, 0xa1, 0x93,
+	0x51, 0x53, 0xa6, 0x33, 0x1d, 0x46, 0xac, 0xa5, 0x8d, 0xad, 0x46, 0x96, 0xc4, 0xee, 0xca, 0x4d,
+	0x6e, 0xb9, 0xe4, 0x1a, 0x18, 0x3e, 0x06, 0xaf, 0xdf, 0xa1, 0x17, 0xbc, 0x04, 0xf8, 0x00, 0x40,
+	0xb8, 0xe1, 0x1e, 0xb8, 0x67, 0x76, 0x57, 0xaf, 0xb6, 0x9c, 0x3b, 0xe9, 0x9c, 0xff, 0xf9, 0x9d,
+	0xb3, 0xbb, 0x47, 0xc7, 0x6b, 0x70, 0x96, 0xe2, 0x3d, 0x6e, 0xd8, 0x2e, 0x27, 0xd4, 0xc5, 0x4e,
+	0xc7, 0xa7, 0x1e, 0xf7, 0x60, 0x8d, 0x70, 0xd3, 0x62, 0x84, 0x4e, 0x09, 0xf5, 0x87, 0xcd, 0xc6,
+	0xc8, 0x1b, 0x79, 0xd2, 0x71, 0x5d, 0x3c, 0x29, 0x4d, 0x73, 0x35, 0xd1, 0x84, 0x96, 0x2a, 0xf5,
+	0xcd, 0xf0, 0xb1, 0x25, 0x9c, 0xd7, 0xb1, 0x6f, 0x5f, 0x9f, 0x12, 0xca, 0x6c, 0xcf, 0xf5, 0x87,
+	0xd1, 0x53, 0xa8, 0xb8, 0x12, 0x2b, 0x26, 0x64, 0x32, 0x24, 0x94, 0x8d, 0x6d, 0xdf, 0x1f, 0xa6,
+	0x5e, 0x94, 0x6e, 0xe3, 0xa8, 0x08, 0xea, 0x3a, 0xf9, 0x28, 0x20, 0x8c, 0xdf, 0x27, 0xd8, 0x22,
+	0x14, 0xae, 0x80, 0xe2, 0xd6, 0x00, 0x15, 0x5a, 0x85, 0xf6, 0x69, 0xbd, 0xb8, 0x35, 0x80, 0x4d,
+	0xb0, 0x1c, 0x30, 0x51, 0xfd, 0x84, 0xa0, 0x62, 0xab, 0xd0, 0xae, 0xea, 0xf1, 0x3b, 0xbc, 0x0a,
+	0xea, 0x38, 0xe0, 0x63, 0x83, 0x92, 0xa9, 0x2d, 0x92, 0xa3, 0x92, 0x08, 0xbb, 0xbb, 0xf4, 0xc9,
+	0x77, 0xa8, 0x74, 0xb3, 0xf3, 0xba, 0x5e, 0x13, 0x5e, 0x3d, 0x74, 0xc2, 0x8b, 0xa0, 0x32, 0xb1,
+	0x29, 0xf5, 0x28, 0x3a, 0xdd, 0x2a, 0xb4, 0x97, 0x23, 0xd9, 0x2d, 0x3d, 0x34, 0xc3, 0x17, 0x40,
+	0x99, 0x7a, 0x0e, 0x61, 0xa8, 0xdc, 0x2a, 0xb5, 0xab, 0x89, 0x5f, 0x59, 0xe1, 0x13, 0x50, 0xe7,
+	0x14, 0x9b, 0xc4, 0x30, 0x3d, 0x97, 0x93, 0x03, 0x8e, 0x2a, 0xad, 0x52, 0x5b, 0xeb, 0x5e, 0xeb,
+	0xa4, 0xb7, 0xb3, 0x93, 0x59, 0x4d, 0x67, 0x57, 0x04, 0xf4, 0x95, 0x7e, 0xd3, 0xe5, 0xf4, 0x30,
+	0xa1, 0xd6, 0x78, 0xca, 0xd7, 0xbc, 0x03, 0xd6, 0xe6, 0xb4, 0x70, 0x15, 0x94, 0xf6, 0xc9, 0xa1,
+	0xdc, 0x8c, 0xaa, 0x2e, 0x1e, 0x61, 0x03, 0x94, 0xa7, 0xd8, 0x09, 0xa2, 0xad, 0x50, 0x2f, 0xb7,
+	0x8b, 0x6f, 0x15, 0x6e, 0x2f, 0x7d, 0x2c, 0xb9, 0x37, 0x36, 0x7e, 0x6b, 0x80, 0xb3, 0x5b, 0xe1,
+	0x81, 0xeb, 0x78, 0x8f, 0x87, 0x05, 0xc1, 0x9b, 0xa0, 0x32, 0x96, 0x45, 0x21, 0xab, 0x55, 0x68,
+	0x6b, 0xdd, 0x0b, 0x27, 0xd4, 0xad, 0x57, 0xc6, 0xf9, 0xa7, 0x71, 0x19, 0x14, 0xa7, 0x5d, 0x99,
+	0x5c, 0xeb, 0x9e, 0xcb, 0x05, 0xe8, 0xc5, 0x69, 0x17, 0xde, 0x00, 0x65, 0x8a, 0xdd, 0x11, 0x91,
+	0x07, 0xa2, 0x75, 0x9b, 0x33, 0x4a, 0xe1, 0x8a, 0xe4, 0x4a, 0x08, 0x5f, 0x05, 0x25, 0x3f, 0xe0,
+	0xf2, 0x64, 0xb4, 0x2e, 0xca, 0xea, 0x77, 0x82, 0x68, 0x11, 0xba, 0x10, 0xc1, 0x3e, 0xa8, 0x59,
+	0xc4, 0x21, 0x9c, 0x18, 0x2a, 0x49, 0x59, 0x06, 0xb5, 0xb2, 0x41, 0x03, 0xa9, 0xc8, 0xa4, 0xd2,
+	0xac, 0xc4, 0x26, 0x12, 0xf2, 0x03, 0x17, 0x55, 0xf2, 0x12, 0xee, 0x1e, 0xb8, 0x71, 0x42, 0x7e,
+	0xe0, 0xc2, 0x3b, 0x00, 0x98, 0xde, 0xc4, 0xc7, 0x26, 0x17, 0x4d, 0xb6, 0x24, 0x43, 0x2e, 0x66,
+	0x43, 0xfa, 0xb1, 0x3f, 0x8a, 0x4c, 0x85, 0xc0, 0x77, 0x80, 0xe6, 0x10, 0xcc, 0x88, 0x31, 0xa2,
+	0xd8, 0xe5, 0x68, 0x39, 0x8f, 0xb0, 0x2d, 0x04, 0xf7, 0x84, 0x3f, 0x26, 0x38, 0xb1, 0x49, 0xac,
+	0x59, 0x11, 0x28, 0x99, 0x7a, 0xfb, 0x04, 0x55, 0xf3, 0xd6, 0x2c, 0x11, 0xba, 0x14, 0xc4, 0x6b,
+	0x76, 0x12, 0x9b, 0x38, 0x16, 0xec, 0x60, 0x3a, 0x41, 0x20, 0xef, 0x58, 0x7a, 0xc2, 0x15, 0x1f,
+	0x8b, 0x14, 0xc2, 0xc7, 0x60, 0x55, 0xa5, 0x35, 0xc7, 0xc4, 0xdc, 0xf7, 0x3d, 0xdb, 0xe5, 0x48,
+	0x93, 0xc1, 0x2f, 0xe5, 0xa4, 0xee, 0xc7, 0xa2, 0x10, 0x13, 0x75, 0xfb, 0x1b, 0xfa, 0x19, 0x27,
+	0x2b, 0x80, 0x6f, 0x83, 0xb2, 0x1f, 0xd0, 0x11, 0x41, 0xb5, 0xbc, 0x5a, 0x76, 0x84, 0x6b, 0x06,
+	0x72, 0x4b, 0x57, 0x11, 0x70, 0x1b, 0xd4, 0x7c, 0xdb, 0x4d, 0xbe, 0xfa, 0x7a, 0xde, 0x5e, 0xec,
+	0xd8, 0x6e, 0xf4, 0xe5, 0xcf, 0x71, 0x34, 0x3f, 0x71, 0x42, 0x1d, 0xac, 0x04, 0x6e, 0x86, 0xb7,
+	0x22, 0x79, 0x1b, 0x59, 0xde, 0x23, 0xd7, 0x3f, 0x81, 0x58, 0x0f, 0xd2, 0x6e, 0x78, 0x0b, 0x40,
+	0x71, 0xfa, 0x94, 0x30, 0x46, 0x2c, 0x83, 0x2a, 0x35, 0x3a, 0xd3, 0x2a, 0xb4, 0x6b, 0x49, 0xcc,
+	0x5a, 0x22, 0x89, 0xbe, 0xd1, 0x1e, 0xd0, 0xe4, 0x40, 0x23, 0x2e, 0x1e, 0x3a, 0x04, 0xfd, 0x95,
+	0xdb, 0x6a, 0xbd, 0x80, 0x8f, 0x37, 0xa5, 0x20, 0x6e, 0x14, 0x1c, 0x9b, 0xe0, 0x00, 0xc8, 0xa9,
+	0x67, 0x58, 0x36, 0x93, 0x8c, 0xbf, 0x97, 0xf2, 0x76, 0x47, 0x30, 0x06, 0x36, 0x4b, 0x43, 0x34,
+	0x9c, 0xd8, 0xe0, 0xbb, 0x61, 0x21, 0x8c, 0x63, 0x1e, 0x30, 0xf4, 0xef, 0xc2, 0x42, 0x1e, 0x4a,
+	0xc1, 0xcc, 0x7e, 0xbc, 0xa9, 0x2a, 0x52, 0x3e, 0xf8, 0x40, 0x55, 0x44, 0x5c, 0x6e, 0x9b, 0x98,
+	0x13, 0xf4, 0x8f, 0x82, 0xbd, 0x92, 0x85, 0x45, 0x23, 0xab, 0x97, 0x92, 0x46, 0xa5, 0x65, 0xe2,
+	0xe1, 0x66, 0x38, 0xf5, 0x03, 0x46, 0xa8, 0x81, 0x2d, 0x0b, 0x7d, 0xbf, 0xbc, 0x68, 0x89, 0x8f,
+	0x18, 0xa1, 0x3d, 0xcb, 0xca, 0x2c, 0x31, 0xb4, 0xc1, 0x07, 0x60, 0x35, 0xc1, 0xa8, 0xc9, 0x80,
+	0x7e, 0x50, 0xa4, 0x17, 0xf3, 0x49, 0xe1, 0x48, 0x09, 0x61, 0x2b, 0x38, 0x63, 0xce, 0x96, 0x35,
+	0x22, 0x1c, 0xfd, 0x78, 0x62, 0x59, 0xf7, 0x08, 0x9f, 0x2b, 0xeb, 0x1e, 0xe1, 0x70, 0x04, 0xfe,
+	0x9f, 0x60, 0xcc, 0xb1, 0x98, 0x55, 0x86, 0x8f, 0x19, 0x7b, 0xe6, 0x51, 0x0b, 0xfd, 0xa4, 0x90,
+	0xaf, 0xe5, 0x23, 0xfb, 0x52, 0xbd, 0x13, 0x8a, 0x23, 0xfa, 0xff, 0x70, 0xae, 0x1b, 0x3e, 0x06,
+	0x8d, 0x54, 0xbd, 0x62, 0xc8, 0x18, 0xe2, 0x77, 0x0e, 0x1d, 0xa9, 0x1c, 0x57, 0x16, 0x94, 0x2d,
+	0x84, 0xba, 0x97, 0xb4, 0xcd, 0x1a, 0x9e, 0xf5, 0xc0, 0x27, 0xe0, 0x5c, 0x42, 0x56, 0xf3, 0x4a,
+	0xa1, 0x7f, 0x56, 0xe8, 0x97, 0xf3, 0xd1, 0xe1, 0xe0, 0x4a, 0xb1, 0x21, 0x9e, 0x73, 0xc1, 0xfb,
+	0x60, 0x25, 0x81, 0x3b, 0x36, 0xe3, 0xe8, 0x17, 0x45, 0xbd, 0x94, 0x4f, 0xdd, 0xb6, 0x19, 0xcf,
+	0xf4, 0x51, 0x64, 0x8c, 0x49, 0xa2, 0x34, 0x45, 0xfa, 0x75, 0x21, 0x49, 0xa4, 0x9e, 0x23, 0x45,
+	0xc6, 0xf8, 0xe8, 0x25, 0x49, 0x74, 0xe4, 0x97, 0xd5, 0x45, 0x47, 0x2f, 0x62, 0x66, 0x3b, 0x32,
+	0xb4, 0xc5, 0x1d, 0x29, 0x31, 0x61, 0x47, 0x7e, 0x55, 0x5d, 0xd4, 0x91, 0x22, 0x2a, 0xa7, 0x23,
+	0x13, 0x73, 0xb6, 0x2c, 0xd1, 0x91, 0x5f, 0x9f, 0x58, 0xd6, 0x6c, 0x47, 0x86, 0x36, 0xf8, 0x14,
+	0x34, 0x53, 0x18, 0xd9, 0x28, 0x3e, 0xa1, 0x13, 0x9b, 0xc9, 0x61, 0xf9, 0x8d, 0x62, 0x5e, 0x5d,
+	0xc0, 0x14, 0xf2, 0x9d, 0x58, 0x1d, 0xf1, 0xcf, 0xe3, 0x7c, 0x3f, 0x9c, 0x80, 0x0b, 0x49, 0xae,
+	0xb0, 0x75, 0x52, 0xc9, 0xbe, 0x55, 0xc9, 0xae, 0xe5, 0x27, 0x53, 0x5d, 0x32, 0x9f, 0x0d, 0xe1,
+	0x05, 0x02, 0xf8, 0x21, 0x38, 0x6b, 0x3a, 0x01, 0xe3, 0x84, 0x1a, 0xe1, 0xfd, 0xd5, 0x60, 0x84,
+	0xa3, 0x4f, 0x41, 0xf8, 0x09, 0xa4, 0x2f, 0xaf, 0x9d, 0xbe, 0x52, 0xbe, 0xaf, 0x84, 0x0f, 0x09,
+	0x9f, 0x9b, 0x7a, 0x6b, 0xe6, 0xac, 0x04, 0x3e, 0x05, 0xe7, 0xa3, 0x0c, 0x0a, 0x66, 0x60, 0xce,
+	0xa9, 0xcc, 0xf2, 0x19, 0x08, 0xe7, 0x60, 0x5e, 0x96, 0xf7, 0xa4, 0xad, 0xc7, 0x39, 0xcd, 0x4b,
+	0xd4, 0x30, 0x73, 0x54, 0xf0, 0x03, 0x00, 0x2d, 0xef, 0x99, 0x3b, 0xa2, 0xd8, 0x22, 0x86, 0xed,
+	0xee, 0x79, 0x32, 0xcd, 0xe7, 0x2a, 0xcd, 0xe5, 0x6c, 0x9a, 0x41, 0x24, 0xdc, 0x72, 0xf7, 0xbc,
+	0xbc, 0x14, 0xab, 0xd6, 0x8c, 0x22, 0xb9, 0x61, 0x9e, 0x01, 0xf5, 0xcd, 0x89, 0xcf, 0x0f, 0x75,
+	0xc2, 0x7c, 0xcf, 0x65, 0x64, 0xe3, 0x10, 0x5c, 0x38, 0x61, 0x7c, 0x43, 0x08, 0x4e, 0xcb, 0xeb,
+	0xbb, 0xba, 0xc7, 0xca, 0x67, 0x71, 0xad, 0x8f, 0xa7, 0x5a, 0x78, 0xad, 0x8f, 0xde, 0xe1, 0x25,
+	0x50, 0x63, 0xf6, 0xc4, 0x77, 0x88, 0xc1, 0xbd, 0x7d, 0xa2, 0x6e, 0xf5, 0x55, 0x5d, 0x53, 0xb6,
+	0x5d, 0x61, 0x8a, 0x6b, 0xb9, 0xdb, 0x78, 0xfe, 0xc7, 0xfa, 0xa9, 0xe7, 0xc7, 0xeb, 0x85, 0xa3,
+	0xe3, 0xf5, 0xc2, 0xef, 0xc7, 0xeb, 0x85, 0x2f, 0xfe, 0x5c, 0x3f, 0x35, 0xac, 0xc8, 0x7f, 0x17,
+	0x37, 0xff, 0x1b, 0x00, 0x36, 0x4e, 0xe5, 0xf6, 0xff, 0x0c, 0x00, 0x00,
 }
 
 func (m *RequestHeader) Marshal() (dAtA []byte, err error) {
@@ -331,6 +360,44 @@ func (m *RequestHeader) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.TraceContext) > 0 {
+		for k := range m.TraceContext {
+			v := m.TraceContext[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintRaftInternal(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintRaftInternal(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintRaftInternal(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x32
+		}
+	}
+	if len(m.Roles) > 0 {
+		for iNdEx := len(m.Roles) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Roles[iNdEx])
+			copy(dAtA[i:], m.Roles[iNdEx])
+			i = encodeVarintRaftInternal(dAtA, i, uint64(len(m.Roles[iNdEx])))
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if m.Mirror {
+		i--
+		if m.Mirror {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
 	if m.AuthRevision != 0 {
 		i = encodeVarintRaftInternal(dAtA, i, uint64(m.AuthRevision))
 		i--
@@ -669,6 +736,49 @@ func (m *InternalRaftRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0xa2
 	}
+	if len(m.CompressedRequest) > 0 {
+		i -= len(m.CompressedRequest)
+		copy(dAtA[i:], m.CompressedRequest)
+		i = encodeVarintRaftInternal(dAtA, i, uint64(len(m.CompressedRequest)))
+		i--
+		dAtA[i] = 0x7a
+	}
+	if m.UnpinRevision != nil {
+		{
+			size, err := m.UnpinRevision.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x72
+	}
+	if m.PinRevision != nil {
+		{
+			size, err := m.PinRevision.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x6a
+	}
+	if m.Purge != nil {
+		{
+			size, err := m.Purge.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x62
+	}
 	if m.LeaseCheckpoint != nil {
 		{
 			size, err := m.LeaseCheckpoint.MarshalToSizedBuffer(dAtA[:i])
@@ -899,6 +1009,23 @@ func (m *RequestHeader) Size() (n int) {
 	if m.AuthRevision != 0 {
 		n += 1 + sovRaftInternal(uint64(m.AuthRevision))
 	}
+	if m.Mirror {
+		n += 2
+	}
+	if len(m.Roles) > 0 {
+		for _, s := range m.Roles {
+			l = len(s)
+			n += 1 + l + sovRaftInternal(uint64(l))
+		}
+	}
+	if len(m.TraceContext) > 0 {
+		for k, v := range m.TraceContext {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovRaftInternal(uint64(len(k))) + 1 + len(v) + sovRaftInternal(uint64(len(v)))
+			n += mapEntrySize + 1 + sovRaftInternal(uint64(mapEntrySize))
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -954,6 +1081,22 @@ func (m *InternalRaftRequest) Size() (n int) {
 		l = m.LeaseCheckpoint.Size()
 		n += 1 + l + sovRaftInternal(uint64(l))
 	}
+	if m.Purge != nil {
+		l = m.Purge.Size()
+		n += 1 + l + sovRaftInternal(uint64(l))
+	}
+	if m.PinRevision != nil {
+		l = m.PinRevision.Size()
+		n += 1 + l + sovRaftInternal(uint64(l))
+	}
+	if m.UnpinRevision != nil {
+		l = m.UnpinRevision.Size()
+		n += 1 + l + sovRaftInternal(uint64(l))
+	}
+	l = len(m.CompressedRequest)
+	if l > 0 {
+		n += 1 + l + sovRaftInternal(uint64(l))
+	}
 	if m.Header != nil {
 		l = m.Header.Size()
 		n += 2 + l + sovRaftInternal(uint64(l))
@@ -1185,6 +1328,185 @@ func (m *RequestHeader) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mirror", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Mirror = bool(v != 0)
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Roles", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Roles = append(m.Roles, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TraceContext", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.TraceContext == nil {
+				m.TraceContext = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowRaftInternal
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowRaftInternal
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthRaftInternal
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthRaftInternal
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowRaftInternal
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthRaftInternal
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthRaftInternal
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipRaftInternal(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthRaftInternal
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.TraceContext[mapkey] = mapvalue
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRaftInternal(dAtA[iNdEx:])
@@ -1615,6 +1937,148 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Purge", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Purge == nil {
+				m.Purge = &PurgeRequest{}
+			}
+			if err := m.Purge.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PinRevision", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.PinRevision == nil {
+				m.PinRevision = &PinRevisionRequest{}
+			}
+			if err := m.PinRevision.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UnpinRevision", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.UnpinRevision == nil {
+				m.UnpinRevision = &UnpinRevisionRequest{}
+			}
+			if err := m.UnpinRevision.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 15:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CompressedRequest", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.CompressedRequest = append(m.CompressedRequest[:0], dAtA[iNdEx:postIndex]...)
+			if m.CompressedRequest == nil {
+				m.CompressedRequest = []byte{}
+			}
+			iNdEx = postIndex
 		case 100:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Header", wireType)
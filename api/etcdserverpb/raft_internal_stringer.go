@@ -101,11 +101,15 @@ func (as *txnRequestStringer) String() string {
 	for _, f := range as.Request.Failure {
 		failure = append(failure, newLoggableRequestOp(f).String())
 	}
-	return fmt.Sprintf("compare:<%s> success:<%s> failure:<%s>",
+	s := fmt.Sprintf("compare:<%s> success:<%s> failure:<%s>",
 		strings.Join(compare, " "),
 		strings.Join(success, " "),
 		strings.Join(failure, " "),
 	)
+	if as.Request.ReadYourWrites {
+		s += " read_your_writes:true"
+	}
+	return s
 }
 
 // requestOpStringer implements a custom proto String to replace value bytes fields with value
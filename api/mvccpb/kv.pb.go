@@ -65,7 +65,18 @@ type KeyValue struct {
 	// lease is the ID of the lease that attached to key.
 	// When the attached lease expires, the key will be deleted.
 	// If lease is 0, then no lease is attached to the key.
-	Lease                int64    `protobuf:"varint,6,opt,name=lease,proto3" json:"lease,omitempty"`
+	Lease int64 `protobuf:"varint,6,opt,name=lease,proto3" json:"lease,omitempty"`
+	// value_truncated is set when value holds only the first bytes of the value of the key,
+	// as requested by the max_value_size of a range request. It is never stored.
+	ValueTruncated bool `protobuf:"varint,7,opt,name=value_truncated,json=valueTruncated,proto3" json:"value_truncated,omitempty"`
+	// metadata is a small blob describing the value, such as its content type,
+	// schema version or owner, set by the put of the value. It is returned even
+	// when the value is left out of a range response. A serialized
+	// google.protobuf.Any can be used to store typed metadata.
+	Metadata []byte `protobuf:"bytes,8,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// ttl is the time to live in seconds the key was put with. The key is deleted
+	// once it expires. If ttl is 0, the key does not expire on its own.
+	Ttl                  int64    `protobuf:"varint,9,opt,name=ttl,proto3" json:"ttl,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -116,10 +127,16 @@ type Event struct {
 	// its modification revision set to the revision of deletion.
 	Kv *KeyValue `protobuf:"bytes,2,opt,name=kv,proto3" json:"kv,omitempty"`
 	// prev_kv holds the key-value pair before the event happens.
-	PrevKv               *KeyValue `protobuf:"bytes,3,opt,name=prev_kv,json=prevKv,proto3" json:"prev_kv,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+	PrevKv *KeyValue `protobuf:"bytes,3,opt,name=prev_kv,json=prevKv,proto3" json:"prev_kv,omitempty"`
+	// trace_context holds the W3C trace context and baggage of the request
+	// writing the event, as "traceparent", "tracestate" and "baggage", when
+	// the request was traced by a server with distributed tracing enabled.
+	// It is not stored, so events replayed from the backend to a watcher
+	// catching up do not carry it.
+	TraceContext         map[string]string `protobuf:"bytes,4,rep,name=trace_context,json=traceContext,proto3" json:"trace_context,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *Event) Reset()         { *m = Event{} }
@@ -159,31 +176,39 @@ func init() {
 	proto.RegisterEnum("mvccpb.Event_EventType", Event_EventType_name, Event_EventType_value)
 	proto.RegisterType((*KeyValue)(nil), "mvccpb.KeyValue")
 	proto.RegisterType((*Event)(nil), "mvccpb.Event")
+	proto.RegisterMapType((map[string]string)(nil), "mvccpb.Event.TraceContextEntry")
 }
 
 func init() { proto.RegisterFile("kv.proto", fileDescriptor_2216fe83c9c12408) }
 
 var fileDescriptor_2216fe83c9c12408 = []byte{
-	// 303 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x90, 0x41, 0x4e, 0xc2, 0x40,
-	0x14, 0x86, 0x3b, 0x14, 0x0a, 0x3e, 0x08, 0x36, 0x13, 0x12, 0x27, 0x2e, 0x26, 0x95, 0x8d, 0x18,
-	0x13, 0x4c, 0xf0, 0x06, 0xc6, 0xae, 0x70, 0x61, 0x1a, 0x74, 0x4b, 0x4a, 0x79, 0x21, 0xa4, 0x94,
-	0x69, 0x4a, 0x9d, 0xa4, 0x37, 0x71, 0xef, 0xde, 0x73, 0xb0, 0xe4, 0x08, 0x52, 0x2f, 0x62, 0xfa,
-	0xc6, 0xe2, 0xc6, 0xcd, 0xe4, 0xfd, 0xff, 0xff, 0x65, 0xe6, 0x7f, 0x03, 0x9d, 0x58, 0x8f, 0xd3,
-	0x4c, 0xe5, 0x8a, 0x3b, 0x89, 0x8e, 0xa2, 0x74, 0x71, 0x39, 0x58, 0xa9, 0x95, 0x22, 0xeb, 0xae,
-	0x9a, 0x4c, 0x3a, 0xfc, 0x64, 0xd0, 0x99, 0x62, 0xf1, 0x1a, 0x6e, 0xde, 0x90, 0xbb, 0x60, 0xc7,
-	0x58, 0x08, 0xe6, 0xb1, 0x51, 0x2f, 0xa8, 0x46, 0x7e, 0x0d, 0xe7, 0x51, 0x86, 0x61, 0x8e, 0xf3,
-	0x0c, 0xf5, 0x7a, 0xb7, 0x56, 0x5b, 0xd1, 0xf0, 0xd8, 0xc8, 0x0e, 0xfa, 0xc6, 0x0e, 0x7e, 0x5d,
-	0x7e, 0x05, 0xbd, 0x44, 0x2d, 0xff, 0x28, 0x9b, 0xa8, 0x6e, 0xa2, 0x96, 0x27, 0x44, 0x40, 0x5b,
-	0x63, 0x46, 0x69, 0x93, 0xd2, 0x5a, 0xf2, 0x01, 0xb4, 0x74, 0x55, 0x40, 0xb4, 0xe8, 0x65, 0x23,
-	0x2a, 0x77, 0x83, 0xe1, 0x0e, 0x85, 0x43, 0xb4, 0x11, 0xc3, 0x0f, 0x06, 0x2d, 0x5f, 0xe3, 0x36,
-	0xe7, 0xb7, 0xd0, 0xcc, 0x8b, 0x14, 0xa9, 0x6e, 0x7f, 0x72, 0x31, 0x36, 0x7b, 0x8e, 0x29, 0x34,
-	0xe7, 0xac, 0x48, 0x31, 0x20, 0x88, 0x7b, 0xd0, 0x88, 0x35, 0x75, 0xef, 0x4e, 0xdc, 0x1a, 0xad,
-	0x17, 0x0f, 0x1a, 0xb1, 0xe6, 0x37, 0xd0, 0x4e, 0x33, 0xd4, 0xf3, 0x58, 0x53, 0xf9, 0xff, 0x30,
-	0xa7, 0x02, 0xa6, 0x7a, 0xe8, 0xc1, 0xd9, 0xe9, 0x7e, 0xde, 0x06, 0xfb, 0xf9, 0x65, 0xe6, 0x5a,
-	0x1c, 0xc0, 0x79, 0xf4, 0x9f, 0xfc, 0x99, 0xef, 0xb2, 0x07, 0xb1, 0x3f, 0x4a, 0xeb, 0x70, 0x94,
-	0xd6, 0xbe, 0x94, 0xec, 0x50, 0x4a, 0xf6, 0x55, 0x4a, 0xf6, 0xfe, 0x2d, 0xad, 0x85, 0x43, 0xff,
-	0x7e, 0xff, 0x13, 0x00, 0x00, 0xff, 0xff, 0xb5, 0x45, 0x92, 0x5d, 0xa1, 0x01, 0x00, 0x00,
+	// 409 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x92, 0x41, 0x8e, 0xd3, 0x30,
+	0x14, 0x86, 0xeb, 0xa4, 0x4d, 0xd3, 0xd7, 0x52, 0x8a, 0x35, 0x12, 0x56, 0x17, 0x21, 0x74, 0x43,
+	0x11, 0x52, 0x91, 0xca, 0x06, 0xb1, 0x41, 0x82, 0xc9, 0x6a, 0x58, 0xa0, 0x28, 0xb0, 0xad, 0x3c,
+	0xe9, 0xd3, 0x68, 0x94, 0x36, 0x8e, 0x3c, 0x1e, 0x8b, 0x1c, 0x80, 0x3b, 0x70, 0x06, 0x4e, 0x32,
+	0xcb, 0x39, 0x02, 0x53, 0x2e, 0x82, 0xfc, 0x4c, 0xc3, 0x20, 0xc4, 0xa6, 0x7a, 0xff, 0xff, 0x7f,
+	0x72, 0x9f, 0x7f, 0x07, 0xe2, 0xca, 0xae, 0x1a, 0xad, 0x8c, 0xe2, 0xd1, 0xde, 0x96, 0x65, 0x73,
+	0x3e, 0x3f, 0xb9, 0x50, 0x17, 0x8a, 0xac, 0x97, 0x6e, 0xf2, 0xe9, 0xe2, 0x6b, 0x00, 0xf1, 0x19,
+	0xb6, 0x9f, 0xe5, 0xee, 0x1a, 0xf9, 0x0c, 0xc2, 0x0a, 0x5b, 0xc1, 0x52, 0xb6, 0x9c, 0xe4, 0x6e,
+	0xe4, 0xcf, 0xe0, 0x61, 0xa9, 0x51, 0x1a, 0xdc, 0x68, 0xb4, 0x97, 0x57, 0x97, 0xaa, 0x16, 0x41,
+	0xca, 0x96, 0x61, 0x3e, 0xf5, 0x76, 0xfe, 0xdb, 0xe5, 0x4f, 0x61, 0xb2, 0x57, 0xdb, 0x3f, 0x54,
+	0x48, 0xd4, 0x78, 0xaf, 0xb6, 0x1d, 0x22, 0x60, 0x68, 0x51, 0x53, 0xda, 0xa7, 0xf4, 0x28, 0xf9,
+	0x09, 0x0c, 0xac, 0x5b, 0x40, 0x0c, 0xe8, 0x9f, 0xbd, 0x70, 0xee, 0x0e, 0xe5, 0x15, 0x8a, 0x88,
+	0x68, 0x2f, 0xdc, 0x46, 0x14, 0x6f, 0x8c, 0xbe, 0xae, 0x4b, 0x69, 0x70, 0x2b, 0x86, 0x29, 0x5b,
+	0xc6, 0xf9, 0x94, 0xec, 0xe2, 0xe8, 0xf2, 0x39, 0xc4, 0x7b, 0x34, 0x72, 0x2b, 0x8d, 0x14, 0x31,
+	0x9d, 0xdb, 0x69, 0x77, 0x51, 0x63, 0x76, 0x62, 0x44, 0x07, 0xbb, 0x71, 0xf1, 0x3d, 0x80, 0x41,
+	0x66, 0xb1, 0x36, 0xfc, 0x05, 0xf4, 0x4d, 0xdb, 0x20, 0xb5, 0x30, 0x5d, 0x3f, 0x5e, 0xf9, 0xfa,
+	0x56, 0x14, 0xfa, 0xdf, 0xa2, 0x6d, 0x30, 0x27, 0x88, 0xa7, 0x10, 0x54, 0x96, 0x2a, 0x19, 0xaf,
+	0x67, 0x47, 0xf4, 0xd8, 0x67, 0x1e, 0x54, 0x96, 0x3f, 0x87, 0x61, 0xa3, 0xd1, 0x6e, 0x2a, 0x2b,
+	0xc2, 0xff, 0x60, 0x91, 0x03, 0xce, 0x2c, 0x3f, 0x85, 0x07, 0x46, 0xcb, 0x12, 0x37, 0xa5, 0xaa,
+	0x0d, 0x7e, 0x31, 0xa2, 0x9f, 0x86, 0xcb, 0xf1, 0xfa, 0xc9, 0xdf, 0x2b, 0x14, 0x0e, 0x79, 0xef,
+	0x89, 0xac, 0x36, 0xba, 0xcd, 0x27, 0xe6, 0x9e, 0x35, 0x7f, 0x0b, 0x8f, 0xfe, 0x41, 0xee, 0xbf,
+	0xec, 0xc8, 0xbf, 0x6c, 0xd7, 0x79, 0x40, 0x9e, 0x17, 0x6f, 0x82, 0xd7, 0x6c, 0x91, 0xc2, 0xa8,
+	0xbb, 0x26, 0x1f, 0x42, 0xf8, 0xf1, 0x53, 0x31, 0xeb, 0x71, 0x80, 0xe8, 0x34, 0xfb, 0x90, 0x15,
+	0xd9, 0x8c, 0xbd, 0x13, 0x37, 0x77, 0x49, 0xef, 0xf6, 0x2e, 0xe9, 0xdd, 0x1c, 0x12, 0x76, 0x7b,
+	0x48, 0xd8, 0x8f, 0x43, 0xc2, 0xbe, 0xfd, 0x4c, 0x7a, 0xe7, 0x11, 0x7d, 0x55, 0xaf, 0x7e, 0x0d,
+	0x00, 0x15, 0xdc, 0xe9, 0xb9, 0x7f, 0x02, 0x00, 0x00,
 }
 
 func (m *KeyValue) Marshal() (dAtA []byte, err error) {
@@ -210,6 +235,28 @@ func (m *KeyValue) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Ttl != 0 {
+		i = encodeVarintKv(dAtA, i, uint64(m.Ttl))
+		i--
+		dAtA[i] = 0x48
+	}
+	if len(m.Metadata) > 0 {
+		i -= len(m.Metadata)
+		copy(dAtA[i:], m.Metadata)
+		i = encodeVarintKv(dAtA, i, uint64(len(m.Metadata)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if m.ValueTruncated {
+		i--
+		if m.ValueTruncated {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x38
+	}
 	if m.Lease != 0 {
 		i = encodeVarintKv(dAtA, i, uint64(m.Lease))
 		i--
@@ -271,6 +318,25 @@ func (m *Event) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.TraceContext) > 0 {
+		for k := range m.TraceContext {
+			v := m.TraceContext[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintKv(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintKv(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintKv(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x22
+		}
+	}
 	if m.PrevKv != nil {
 		{
 			size, err := m.PrevKv.MarshalToSizedBuffer(dAtA[:i])
@@ -340,6 +406,16 @@ func (m *KeyValue) Size() (n int) {
 	if m.Lease != 0 {
 		n += 1 + sovKv(uint64(m.Lease))
 	}
+	if m.ValueTruncated {
+		n += 2
+	}
+	l = len(m.Metadata)
+	if l > 0 {
+		n += 1 + l + sovKv(uint64(l))
+	}
+	if m.Ttl != 0 {
+		n += 1 + sovKv(uint64(m.Ttl))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -363,6 +439,14 @@ func (m *Event) Size() (n int) {
 		l = m.PrevKv.Size()
 		n += 1 + l + sovKv(uint64(l))
 	}
+	if len(m.TraceContext) > 0 {
+		for k, v := range m.TraceContext {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovKv(uint64(len(k))) + 1 + len(v) + sovKv(uint64(len(v)))
+			n += mapEntrySize + 1 + sovKv(uint64(mapEntrySize))
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -548,6 +632,79 @@ func (m *KeyValue) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ValueTruncated", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowKv
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.ValueTruncated = bool(v != 0)
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowKv
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthKv
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthKv
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metadata = append(m.Metadata[:0], dAtA[iNdEx:postIndex]...)
+			if m.Metadata == nil {
+				m.Metadata = []byte{}
+			}
+			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ttl", wireType)
+			}
+			m.Ttl = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowKv
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Ttl |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipKv(dAtA[iNdEx:])
@@ -690,6 +847,133 @@ func (m *Event) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TraceContext", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowKv
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthKv
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthKv
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.TraceContext == nil {
+				m.TraceContext = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowKv
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowKv
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthKv
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthKv
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowKv
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthKv
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthKv
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipKv(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthKv
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.TraceContext[mapkey] = mapvalue
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipKv(dAtA[iNdEx:])
@@ -25,19 +25,30 @@ var (
 	ErrGRPCKeyNotFound             = status.New(codes.InvalidArgument, "etcdserver: key not found").Err()
 	ErrGRPCValueProvided           = status.New(codes.InvalidArgument, "etcdserver: value is provided").Err()
 	ErrGRPCLeaseProvided           = status.New(codes.InvalidArgument, "etcdserver: lease is provided").Err()
+	ErrGRPCMetadataProvided        = status.New(codes.InvalidArgument, "etcdserver: metadata is provided").Err()
+	ErrGRPCMetadataTooLarge        = status.New(codes.InvalidArgument, "etcdserver: metadata is too large").Err()
+	ErrGRPCInvalidKeyTTL           = status.New(codes.InvalidArgument, "etcdserver: invalid key ttl").Err()
 	ErrGRPCTooManyOps              = status.New(codes.InvalidArgument, "etcdserver: too many operations in txn request").Err()
 	ErrGRPCDuplicateKey            = status.New(codes.InvalidArgument, "etcdserver: duplicate key given in txn request").Err()
 	ErrGRPCInvalidClientAPIVersion = status.New(codes.InvalidArgument, "etcdserver: invalid client api version").Err()
 	ErrGRPCInvalidSortOption       = status.New(codes.InvalidArgument, "etcdserver: invalid sort option").Err()
+	ErrGRPCInvalidFieldMask        = status.New(codes.InvalidArgument, "etcdserver: invalid field mask").Err()
 	ErrGRPCCompacted               = status.New(codes.OutOfRange, "etcdserver: mvcc: required revision has been compacted").Err()
 	ErrGRPCFutureRev               = status.New(codes.OutOfRange, "etcdserver: mvcc: required revision is a future revision").Err()
 	ErrGRPCNoSpace                 = status.New(codes.ResourceExhausted, "etcdserver: mvcc: database space exceeded").Err()
+	ErrGRPCTooManyKeys             = status.New(codes.ResourceExhausted, "etcdserver: mvcc: key quota exceeded").Err()
 
-	ErrGRPCLeaseNotFound    = status.New(codes.NotFound, "etcdserver: requested lease not found").Err()
-	ErrGRPCLeaseExist       = status.New(codes.FailedPrecondition, "etcdserver: lease already exists").Err()
-	ErrGRPCLeaseTTLTooLarge = status.New(codes.OutOfRange, "etcdserver: too large lease TTL").Err()
+	ErrGRPCLeaseNotFound                   = status.New(codes.NotFound, "etcdserver: requested lease not found").Err()
+	ErrGRPCLeaseExist                      = status.New(codes.FailedPrecondition, "etcdserver: lease already exists").Err()
+	ErrGRPCLeaseTTLTooLarge                = status.New(codes.OutOfRange, "etcdserver: too large lease TTL").Err()
+	ErrGRPCLeaseCheckpointIntervalTooSmall = status.New(codes.OutOfRange, "etcdserver: too small lease checkpoint interval").Err()
 
-	ErrGRPCWatchCanceled = status.New(codes.Canceled, "etcdserver: watch canceled").Err()
+	ErrGRPCPinNotFound = status.New(codes.NotFound, "etcdserver: requested pin not found").Err()
+
+	ErrGRPCWatchCanceled   = status.New(codes.Canceled, "etcdserver: watch canceled").Err()
+	ErrGRPCTooManyWatchers = status.New(codes.ResourceExhausted, "etcdserver: too many watchers").Err()
+
+	ErrGRPCStaleFencingToken = status.New(codes.FailedPrecondition, "etcdserver: stale fencing token").Err()
 
 	ErrGRPCMemberExist            = status.New(codes.FailedPrecondition, "etcdserver: member ID already exist").Err()
 	ErrGRPCPeerURLExist           = status.New(codes.FailedPrecondition, "etcdserver: Peer URLs already exists").Err()
@@ -47,9 +58,12 @@ var (
 	ErrGRPCMemberNotLearner       = status.New(codes.FailedPrecondition, "etcdserver: can only promote a learner member").Err()
 	ErrGRPCLearnerNotReady        = status.New(codes.FailedPrecondition, "etcdserver: can only promote a learner member which is in sync with leader").Err()
 	ErrGRPCTooManyLearners        = status.New(codes.FailedPrecondition, "etcdserver: too many learner members in cluster").Err()
+	ErrGRPCClusterEpochMismatch   = status.New(codes.FailedPrecondition, "etcdserver: cluster epoch mismatch").Err()
 
 	ErrGRPCRequestTooLarge        = status.New(codes.InvalidArgument, "etcdserver: request is too large").Err()
 	ErrGRPCRequestTooManyRequests = status.New(codes.ResourceExhausted, "etcdserver: too many requests").Err()
+	ErrGRPCOverloaded             = status.New(codes.ResourceExhausted, "etcdserver: server is overloaded, retry later").Err()
+	ErrGRPCRateLimited            = status.New(codes.ResourceExhausted, "etcdserver: too many requests to the key prefix").Err()
 
 	ErrGRPCRootUserNotExist     = status.New(codes.FailedPrecondition, "etcdserver: root user does not exist").Err()
 	ErrGRPCRootRoleNotExist     = status.New(codes.FailedPrecondition, "etcdserver: root user does not have root role").Err()
@@ -73,6 +87,7 @@ var (
 	ErrGRPCNotLeader                  = status.New(codes.FailedPrecondition, "etcdserver: not leader").Err()
 	ErrGRPCLeaderChanged              = status.New(codes.Unavailable, "etcdserver: leader changed").Err()
 	ErrGRPCNotCapable                 = status.New(codes.FailedPrecondition, "etcdserver: not capable").Err()
+	ErrGRPCAPIVersionUnsupported      = status.New(codes.FailedPrecondition, "etcdserver: request uses fields not supported by the cluster API version").Err()
 	ErrGRPCStopped                    = status.New(codes.Unavailable, "etcdserver: server stopped").Err()
 	ErrGRPCTimeout                    = status.New(codes.Unavailable, "etcdserver: request timed out").Err()
 	ErrGRPCTimeoutDueToLeaderFail     = status.New(codes.Unavailable, "etcdserver: request timed out, possibly due to previous leader failure").Err()
@@ -80,8 +95,11 @@ var (
 	ErrGRPCTimeoutWaitAppliedIndex    = status.New(codes.Unavailable, "etcdserver: request timed out, waiting for the applied index took too long").Err()
 	ErrGRPCUnhealthy                  = status.New(codes.Unavailable, "etcdserver: unhealthy cluster").Err()
 	ErrGRPCCorrupt                    = status.New(codes.DataLoss, "etcdserver: corrupt cluster").Err()
+	ErrGRPCStandby                    = status.New(codes.FailedPrecondition, "etcdserver: cluster is a standby").Err()
 	ErrGRPCNotSupportedForLearner     = status.New(codes.FailedPrecondition, "etcdserver: rpc not supported for learner").Err()
 	ErrGRPCBadLeaderTransferee        = status.New(codes.FailedPrecondition, "etcdserver: bad leader transferee").Err()
+	ErrGRPCInvalidSnapshotSettings    = status.New(codes.InvalidArgument, "etcdserver: snapshot catch-up entries exceed the snapshot count").Err()
+	ErrGRPCInvalidRateLimit           = status.New(codes.InvalidArgument, "etcdserver: rate limit must be a finite non-negative number").Err()
 
 	ErrGRPCWrongDowngradeVersionFormat   = status.New(codes.InvalidArgument, "etcdserver: wrong downgrade target version format").Err()
 	ErrGRPCInvalidDowngradeTargetVersion = status.New(codes.InvalidArgument, "etcdserver: invalid downgrade target version").Err()
@@ -98,16 +116,29 @@ var (
 		ErrorDesc(ErrGRPCValueProvided): ErrGRPCValueProvided,
 		ErrorDesc(ErrGRPCLeaseProvided): ErrGRPCLeaseProvided,
 
+		ErrorDesc(ErrGRPCMetadataProvided): ErrGRPCMetadataProvided,
+		ErrorDesc(ErrGRPCMetadataTooLarge): ErrGRPCMetadataTooLarge,
+		ErrorDesc(ErrGRPCInvalidKeyTTL):    ErrGRPCInvalidKeyTTL,
+
 		ErrorDesc(ErrGRPCTooManyOps):        ErrGRPCTooManyOps,
 		ErrorDesc(ErrGRPCDuplicateKey):      ErrGRPCDuplicateKey,
 		ErrorDesc(ErrGRPCInvalidSortOption): ErrGRPCInvalidSortOption,
+		ErrorDesc(ErrGRPCInvalidFieldMask):  ErrGRPCInvalidFieldMask,
 		ErrorDesc(ErrGRPCCompacted):         ErrGRPCCompacted,
 		ErrorDesc(ErrGRPCFutureRev):         ErrGRPCFutureRev,
 		ErrorDesc(ErrGRPCNoSpace):           ErrGRPCNoSpace,
+		ErrorDesc(ErrGRPCTooManyKeys):       ErrGRPCTooManyKeys,
+
+		ErrorDesc(ErrGRPCLeaseNotFound):                   ErrGRPCLeaseNotFound,
+		ErrorDesc(ErrGRPCLeaseExist):                      ErrGRPCLeaseExist,
+		ErrorDesc(ErrGRPCLeaseTTLTooLarge):                ErrGRPCLeaseTTLTooLarge,
+		ErrorDesc(ErrGRPCLeaseCheckpointIntervalTooSmall): ErrGRPCLeaseCheckpointIntervalTooSmall,
+
+		ErrorDesc(ErrGRPCPinNotFound): ErrGRPCPinNotFound,
 
-		ErrorDesc(ErrGRPCLeaseNotFound):    ErrGRPCLeaseNotFound,
-		ErrorDesc(ErrGRPCLeaseExist):       ErrGRPCLeaseExist,
-		ErrorDesc(ErrGRPCLeaseTTLTooLarge): ErrGRPCLeaseTTLTooLarge,
+		ErrorDesc(ErrGRPCTooManyWatchers): ErrGRPCTooManyWatchers,
+
+		ErrorDesc(ErrGRPCStaleFencingToken): ErrGRPCStaleFencingToken,
 
 		ErrorDesc(ErrGRPCMemberExist):            ErrGRPCMemberExist,
 		ErrorDesc(ErrGRPCPeerURLExist):           ErrGRPCPeerURLExist,
@@ -117,9 +148,12 @@ var (
 		ErrorDesc(ErrGRPCMemberNotLearner):       ErrGRPCMemberNotLearner,
 		ErrorDesc(ErrGRPCLearnerNotReady):        ErrGRPCLearnerNotReady,
 		ErrorDesc(ErrGRPCTooManyLearners):        ErrGRPCTooManyLearners,
+		ErrorDesc(ErrGRPCClusterEpochMismatch):   ErrGRPCClusterEpochMismatch,
 
 		ErrorDesc(ErrGRPCRequestTooLarge):        ErrGRPCRequestTooLarge,
 		ErrorDesc(ErrGRPCRequestTooManyRequests): ErrGRPCRequestTooManyRequests,
+		ErrorDesc(ErrGRPCOverloaded):             ErrGRPCOverloaded,
+		ErrorDesc(ErrGRPCRateLimited):            ErrGRPCRateLimited,
 
 		ErrorDesc(ErrGRPCRootUserNotExist):     ErrGRPCRootUserNotExist,
 		ErrorDesc(ErrGRPCRootRoleNotExist):     ErrGRPCRootRoleNotExist,
@@ -142,14 +176,18 @@ var (
 		ErrorDesc(ErrGRPCNotLeader):                  ErrGRPCNotLeader,
 		ErrorDesc(ErrGRPCLeaderChanged):              ErrGRPCLeaderChanged,
 		ErrorDesc(ErrGRPCNotCapable):                 ErrGRPCNotCapable,
+		ErrorDesc(ErrGRPCAPIVersionUnsupported):      ErrGRPCAPIVersionUnsupported,
 		ErrorDesc(ErrGRPCStopped):                    ErrGRPCStopped,
 		ErrorDesc(ErrGRPCTimeout):                    ErrGRPCTimeout,
 		ErrorDesc(ErrGRPCTimeoutDueToLeaderFail):     ErrGRPCTimeoutDueToLeaderFail,
 		ErrorDesc(ErrGRPCTimeoutDueToConnectionLost): ErrGRPCTimeoutDueToConnectionLost,
 		ErrorDesc(ErrGRPCUnhealthy):                  ErrGRPCUnhealthy,
 		ErrorDesc(ErrGRPCCorrupt):                    ErrGRPCCorrupt,
+		ErrorDesc(ErrGRPCStandby):                    ErrGRPCStandby,
 		ErrorDesc(ErrGRPCNotSupportedForLearner):     ErrGRPCNotSupportedForLearner,
 		ErrorDesc(ErrGRPCBadLeaderTransferee):        ErrGRPCBadLeaderTransferee,
+		ErrorDesc(ErrGRPCInvalidSnapshotSettings):    ErrGRPCInvalidSnapshotSettings,
+		ErrorDesc(ErrGRPCInvalidRateLimit):           ErrGRPCInvalidRateLimit,
 
 		ErrorDesc(ErrGRPCClusterVersionUnavailable):     ErrGRPCClusterVersionUnavailable,
 		ErrorDesc(ErrGRPCWrongDowngradeVersionFormat):   ErrGRPCWrongDowngradeVersionFormat,
@@ -165,16 +203,28 @@ var (
 	ErrKeyNotFound       = Error(ErrGRPCKeyNotFound)
 	ErrValueProvided     = Error(ErrGRPCValueProvided)
 	ErrLeaseProvided     = Error(ErrGRPCLeaseProvided)
+	ErrMetadataProvided  = Error(ErrGRPCMetadataProvided)
+	ErrMetadataTooLarge  = Error(ErrGRPCMetadataTooLarge)
+	ErrInvalidKeyTTL     = Error(ErrGRPCInvalidKeyTTL)
 	ErrTooManyOps        = Error(ErrGRPCTooManyOps)
 	ErrDuplicateKey      = Error(ErrGRPCDuplicateKey)
 	ErrInvalidSortOption = Error(ErrGRPCInvalidSortOption)
+	ErrInvalidFieldMask  = Error(ErrGRPCInvalidFieldMask)
 	ErrCompacted         = Error(ErrGRPCCompacted)
 	ErrFutureRev         = Error(ErrGRPCFutureRev)
 	ErrNoSpace           = Error(ErrGRPCNoSpace)
+	ErrTooManyKeys       = Error(ErrGRPCTooManyKeys)
+
+	ErrLeaseNotFound                   = Error(ErrGRPCLeaseNotFound)
+	ErrLeaseExist                      = Error(ErrGRPCLeaseExist)
+	ErrLeaseTTLTooLarge                = Error(ErrGRPCLeaseTTLTooLarge)
+	ErrLeaseCheckpointIntervalTooSmall = Error(ErrGRPCLeaseCheckpointIntervalTooSmall)
+
+	ErrPinNotFound = Error(ErrGRPCPinNotFound)
+
+	ErrTooManyWatchers = Error(ErrGRPCTooManyWatchers)
 
-	ErrLeaseNotFound    = Error(ErrGRPCLeaseNotFound)
-	ErrLeaseExist       = Error(ErrGRPCLeaseExist)
-	ErrLeaseTTLTooLarge = Error(ErrGRPCLeaseTTLTooLarge)
+	ErrStaleFencingToken = Error(ErrGRPCStaleFencingToken)
 
 	ErrMemberExist            = Error(ErrGRPCMemberExist)
 	ErrPeerURLExist           = Error(ErrGRPCPeerURLExist)
@@ -184,9 +234,12 @@ var (
 	ErrMemberNotLearner       = Error(ErrGRPCMemberNotLearner)
 	ErrMemberLearnerNotReady  = Error(ErrGRPCLearnerNotReady)
 	ErrTooManyLearners        = Error(ErrGRPCTooManyLearners)
+	ErrClusterEpochMismatch   = Error(ErrGRPCClusterEpochMismatch)
 
 	ErrRequestTooLarge = Error(ErrGRPCRequestTooLarge)
 	ErrTooManyRequests = Error(ErrGRPCRequestTooManyRequests)
+	ErrOverloaded      = Error(ErrGRPCOverloaded)
+	ErrRateLimited     = Error(ErrGRPCRateLimited)
 
 	ErrRootUserNotExist     = Error(ErrGRPCRootUserNotExist)
 	ErrRootRoleNotExist     = Error(ErrGRPCRootRoleNotExist)
@@ -209,6 +262,7 @@ var (
 	ErrNotLeader                  = Error(ErrGRPCNotLeader)
 	ErrLeaderChanged              = Error(ErrGRPCLeaderChanged)
 	ErrNotCapable                 = Error(ErrGRPCNotCapable)
+	ErrAPIVersionUnsupported      = Error(ErrGRPCAPIVersionUnsupported)
 	ErrStopped                    = Error(ErrGRPCStopped)
 	ErrTimeout                    = Error(ErrGRPCTimeout)
 	ErrTimeoutDueToLeaderFail     = Error(ErrGRPCTimeoutDueToLeaderFail)
@@ -216,7 +270,10 @@ var (
 	ErrTimeoutWaitAppliedIndex    = Error(ErrGRPCTimeoutWaitAppliedIndex)
 	ErrUnhealthy                  = Error(ErrGRPCUnhealthy)
 	ErrCorrupt                    = Error(ErrGRPCCorrupt)
+	ErrStandby                    = Error(ErrGRPCStandby)
 	ErrBadLeaderTransferee        = Error(ErrGRPCBadLeaderTransferee)
+	ErrInvalidSnapshotSettings    = Error(ErrGRPCInvalidSnapshotSettings)
+	ErrInvalidRateLimit           = Error(ErrGRPCInvalidRateLimit)
 
 	ErrClusterVersionUnavailable     = Error(ErrGRPCClusterVersionUnavailable)
 	ErrWrongDowngradeVersionFormat   = Error(ErrGRPCWrongDowngradeVersionFormat)
@@ -19,4 +19,21 @@ var (
 	MetadataHasLeader        = "true"
 
 	MetadataClientAPIVersionKey = "client-api-version"
+
+	// MetadataClusterAPIVersionKey carries the API version of the cluster
+	// in the response headers.
+	MetadataClusterAPIVersionKey = "cluster-api-version"
+
+	// MetadataClusterEpochKey carries the cluster epoch known to the client.
+	MetadataClusterEpochKey = "cluster-epoch"
+
+	// MetadataLeaseTTLFloorKey carries the minimum TTL, in seconds, a client
+	// of a gRPC proxy requires its leases to have left once their keepalives
+	// are answered.
+	MetadataLeaseTTLFloorKey = "lease-ttl-floor"
+
+	// MetadataRetryAfterKey carries, in the response headers of the writes
+	// rejected by an overloaded member, the number of milliseconds after
+	// which they may be retried.
+	MetadataRetryAfterKey = "retry-after-ms"
 )
@@ -0,0 +1,135 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// descriptorVersions caches the etcd version annotations of the descriptors
+// by full name. Descriptors without annotation map to a nil version.
+var descriptorVersions sync.Map
+
+// MessageVersion returns the minimal etcd version able to interpret the
+// message: the highest etcd version annotation of the message and of the
+// fields, nested messages, enums and enum values set in it, or nil if none
+// of them is annotated.
+func MessageVersion(m proto.Message) (*semver.Version, error) {
+	return messageVersion(proto.MessageReflect(m))
+}
+
+func messageVersion(m protoreflect.Message) (ver *semver.Version, err error) {
+	if ver, err = descriptorVersion(m.Descriptor()); err != nil {
+		return nil, err
+	}
+	m.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		var fver *semver.Version
+		if fver, err = descriptorVersion(fd); err != nil {
+			return false
+		}
+		ver = maxVersion(ver, fver)
+		switch {
+		case fd.IsList():
+			list := value.List()
+			for i := 0; i < list.Len() && err == nil; i++ {
+				fver, err = valueVersion(fd, list.Get(i))
+				ver = maxVersion(ver, fver)
+			}
+		case fd.IsMap():
+			value.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				fver, err = valueVersion(fd.MapValue(), v)
+				ver = maxVersion(ver, fver)
+				return err == nil
+			})
+		default:
+			fver, err = valueVersion(fd, value)
+			ver = maxVersion(ver, fver)
+		}
+		return err == nil
+	})
+	return ver, err
+}
+
+// valueVersion returns the version of a message or enum value of the field.
+func valueVersion(fd protoreflect.FieldDescriptor, value protoreflect.Value) (*semver.Version, error) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageVersion(value.Message())
+	case protoreflect.EnumKind:
+		ver, err := descriptorVersion(fd.Enum())
+		if err != nil {
+			return nil, err
+		}
+		if ev := fd.Enum().Values().ByNumber(value.Enum()); ev != nil {
+			evver, err := descriptorVersion(ev)
+			if err != nil {
+				return nil, err
+			}
+			ver = maxVersion(ver, evver)
+		}
+		return ver, nil
+	}
+	return nil, nil
+}
+
+func descriptorVersion(d protoreflect.Descriptor) (*semver.Version, error) {
+	if ver, ok := descriptorVersions.Load(d.FullName()); ok {
+		return ver.(*semver.Version), nil
+	}
+	opts, ok := d.Options().(fmt.Stringer)
+	if !ok {
+		return nil, nil
+	}
+	ver, err := versionFromOptions(opts.String())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", d.FullName(), err)
+	}
+	descriptorVersions.Store(d.FullName(), ver)
+	return ver, nil
+}
+
+// versionFromOptions parses the etcd version annotation of the text format
+// of descriptor options.
+// TODO: Use proto.GetExtension when gogo/protobuf is usable with protoreflect
+func versionFromOptions(opts string) (*semver.Version, error) {
+	for _, ext := range []string{"[versionpb.etcd_version_msg]:", "[versionpb.etcd_version_field]:", "[versionpb.etcd_version_enum]:", "[versionpb.etcd_version_enum_value]:"} {
+		i := strings.Index(opts, ext)
+		if i == -1 {
+			continue
+		}
+		var s string
+		if _, err := fmt.Sscanf(opts[i+len(ext):], "%q", &s); err != nil {
+			return nil, err
+		}
+		if strings.Count(s, ".") == 1 {
+			s += ".0"
+		}
+		return semver.NewVersion(s)
+	}
+	return nil, nil
+}
+
+func maxVersion(a, b *semver.Version) *semver.Version {
+	if a != nil && (b == nil || b.LessThan(*a)) {
+		return a
+	}
+	return b
+}
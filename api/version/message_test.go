@@ -0,0 +1,66 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"testing"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestMessageVersion(t *testing.T) {
+	tcs := []struct {
+		name   string
+		msg    proto.Message
+		expect *semver.Version
+	}{
+		{
+			name:   "empty range",
+			msg:    &pb.RangeRequest{},
+			expect: &V3_0,
+		},
+		{
+			name:   "range with field of v3.1",
+			msg:    &pb.RangeRequest{Key: []byte("foo"), MinModRevision: 1},
+			expect: &V3_1,
+		},
+		{
+			name:   "range with field of v3.6",
+			msg:    &pb.RangeRequest{Key: []byte("foo"), MaxValueSize: 1},
+			expect: &V3_6,
+		},
+		{
+			name:   "put in txn with field of v3.6",
+			msg:    &pb.TxnRequest{Success: []*pb.RequestOp{{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("foo"), IgnoreMetadata: true}}}}},
+			expect: &V3_6,
+		},
+		{
+			name:   "compare with enum value of v3.1",
+			msg:    &pb.TxnRequest{Compare: []*pb.Compare{{Result: pb.Compare_NOT_EQUAL, Key: []byte("foo")}}},
+			expect: &V3_1,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ver, err := MessageVersion(tc.msg)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, ver)
+		})
+	}
+}
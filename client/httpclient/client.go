@@ -0,0 +1,297 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoAvailableEndpoints = errors.New("etcdclient: no available endpoints")
+)
+
+// errMsgInvalidAuthToken is the message of the error returned by the gateway
+// when the auth token of a request expired.
+const errMsgInvalidAuthToken = "etcdserver: invalid auth token"
+
+// codeUnknown is the gRPC status code of the errors the gateway did not
+// describe.
+const codeUnknown = 2
+
+type Config struct {
+	// Endpoints is a list of URLs of the gRPC gateway of etcd members. The
+	// endpoints without scheme use https if TLS is set, and http otherwise.
+	Endpoints []string `json:"endpoints"`
+
+	// DialTimeout is the timeout for failing to establish a connection.
+	DialTimeout time.Duration `json:"dial-timeout"`
+
+	// TLS holds the client secure credentials, if any.
+	TLS *tls.Config
+
+	// Username is a user name for authentication.
+	Username string `json:"username"`
+
+	// Password is a password for authentication.
+	Password string `json:"password"`
+}
+
+// Client provides and manages an etcd v3 client session over the gRPC
+// gateway.
+type Client struct {
+	cfg Config
+	eps []string
+	tr  *http.Transport
+	hc  *http.Client
+
+	mu sync.Mutex
+	// ep is the index of the endpoint of the latest successful request.
+	ep int
+	// token is the auth token of the requests, if authenticated.
+	token string
+	// authMu serializes the authentication of the client.
+	authMu sync.Mutex
+}
+
+// Error is an error returned by the gateway.
+type Error struct {
+	// Code is the gRPC status code of the error.
+	Code int `json:"code"`
+	// Message describes the error, as the errors of clientv3 do.
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// New creates a new client from a given configuration.
+func New(cfg Config) (*Client, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, ErrNoAvailableEndpoints
+	}
+	eps := make([]string, len(cfg.Endpoints))
+	for i, ep := range cfg.Endpoints {
+		u, err := endpointURL(ep, cfg.TLS != nil)
+		if err != nil {
+			return nil, err
+		}
+		eps[i] = u
+	}
+	tr := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   cfg.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:     cfg.TLS,
+		TLSHandshakeTimeout: cfg.DialTimeout,
+		MaxIdleConnsPerHost: 16,
+	}
+	return &Client{cfg: cfg, eps: eps, tr: tr, hc: &http.Client{Transport: tr}}, nil
+}
+
+// endpointURL returns the base URL of the gateway of an endpoint.
+func endpointURL(ep string, secure bool) (string, error) {
+	if !strings.Contains(ep, "://") {
+		if secure {
+			ep = "https://" + ep
+		} else {
+			ep = "http://" + ep
+		}
+	}
+	u, err := url.Parse(ep)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("etcdclient: unsupported scheme %q of endpoint %q", u.Scheme, ep)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// Endpoints lists the registered endpoints for the client.
+func (c *Client) Endpoints() []string {
+	return append([]string(nil), c.cfg.Endpoints...)
+}
+
+// Close closes the idle connections of the client.
+func (c *Client) Close() error {
+	c.tr.CloseIdleConnections()
+	return nil
+}
+
+// call sends the request to the gateway path and decodes the response.
+func (c *Client) call(ctx context.Context, path string, req, resp interface{}) error {
+	hresp, err := c.post(ctx, path, req)
+	if err != nil {
+		return err
+	}
+	defer hresp.Body.Close()
+	return json.NewDecoder(hresp.Body).Decode(resp)
+}
+
+// post sends the request to the gateway path, authenticating again once if
+// the auth token of the client expired, and returns the response if its
+// status is OK.
+func (c *Client) post(ctx context.Context, path string, req interface{}) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	token, err := c.authToken(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.postBody(ctx, path, body, token)
+	var e *Error
+	if errors.As(err, &e) && e.Message == errMsgInvalidAuthToken && token != "" {
+		if token, err = c.authToken(ctx, token); err != nil {
+			return nil, err
+		}
+		resp, err = c.postBody(ctx, path, body, token)
+	}
+	return resp, err
+}
+
+// postBody sends the JSON body to the gateway path of the endpoint of the
+// latest successful request, and of the next endpoints while it is
+// unreachable.
+func (c *Client) postBody(ctx context.Context, path string, body []byte, token string) (*http.Response, error) {
+	c.mu.Lock()
+	start := c.ep
+	c.mu.Unlock()
+
+	var lastErr error
+	for i := range c.eps {
+		ep := (start + i) % len(c.eps)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.eps[ep]+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := c.hc.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		c.mu.Lock()
+		c.ep = ep
+		c.mu.Unlock()
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			return nil, decodeError(resp)
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
+
+// decodeError returns the error described by a response of the gateway.
+func decodeError(resp *http.Response) error {
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	e := &Error{}
+	if json.Unmarshal(b, e) == nil && e.Message != "" {
+		return e
+	}
+	// streams failing before their first response carry a stream error
+	var chunk streamChunk
+	if json.Unmarshal(b, &chunk) == nil && chunk.Error != nil {
+		return chunk.Error.err()
+	}
+	return &Error{Code: codeUnknown, Message: fmt.Sprintf("etcdclient: unexpected response %q (%s)", b, resp.Status)}
+}
+
+// streamChunk is a message of a response stream of the gateway.
+type streamChunk struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *streamError    `json:"error,omitempty"`
+}
+
+// streamError is the error ending a response stream of the gateway.
+type streamError struct {
+	GRPCCode int    `json:"grpc_code"`
+	Message  string `json:"message"`
+}
+
+func (e *streamError) err() *Error {
+	return &Error{Code: e.GRPCCode, Message: e.Message}
+}
+
+// authToken returns the auth token of the requests, authenticating the
+// client if it has no token or only the given expired one.
+func (c *Client) authToken(ctx context.Context, expired string) (string, error) {
+	if c.cfg.Username == "" {
+		return "", nil
+	}
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+	if token != "" && token != expired {
+		return token, nil
+	}
+
+	req := &authenticateRequest{Name: c.cfg.Username, Password: c.cfg.Password}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	hresp, err := c.postBody(ctx, "/v3/auth/authenticate", body, "")
+	if err != nil {
+		return "", err
+	}
+	defer hresp.Body.Close()
+	resp := &authenticateResponse{}
+	if err = json.NewDecoder(hresp.Body).Decode(resp); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.token = resp.Token
+	c.mu.Unlock()
+	return resp.Token, nil
+}
+
+type authenticateRequest struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+type authenticateResponse struct {
+	Header ResponseHeader `json:"header"`
+	Token  string         `json:"token"`
+}
@@ -0,0 +1,204 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEndpointURL(t *testing.T) {
+	tests := []struct {
+		ep     string
+		secure bool
+		want   string
+		err    bool
+	}{
+		{ep: "localhost:2379", want: "http://localhost:2379"},
+		{ep: "localhost:2379", secure: true, want: "https://localhost:2379"},
+		{ep: "https://10.0.0.1:2379/", want: "https://10.0.0.1:2379"},
+		{ep: "unix://localhost:m0", err: true},
+	}
+	for i, tt := range tests {
+		got, err := endpointURL(tt.ep, tt.secure)
+		if (err != nil) != tt.err {
+			t.Fatalf("#%d: expected error %v, got %v", i, tt.err, err)
+		}
+		if got != tt.want {
+			t.Errorf("#%d: expected %q, got %q", i, tt.want, got)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	var req map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/kv/range" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"header":{"cluster_id":"14841639068965178418","revision":"5"},"kvs":[{"key":"Zm9vMQ==","create_revision":"2","mod_revision":"4","version":"2","value":"YmFy"}],"count":"1"}`))
+	}))
+	defer srv.Close()
+
+	cli, err := New(Config{Endpoints: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	resp, err := cli.Get(context.Background(), "foo", WithPrefix(), WithRev(5), WithLimit(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wreq := map[string]interface{}{"key": "Zm9v", "range_end": "Zm9w", "limit": "10", "revision": "5"}
+	if !reflect.DeepEqual(req, wreq) {
+		t.Errorf("expected request %v, got %v", wreq, req)
+	}
+	wresp := &GetResponse{
+		Header: ResponseHeader{ClusterID: 14841639068965178418, Revision: 5},
+		Kvs:    []*KeyValue{{Key: []byte("foo1"), CreateRevision: 2, ModRevision: 4, Version: 2, Value: []byte("bar")}},
+		Count:  1,
+	}
+	if !reflect.DeepEqual(resp, wresp) {
+		t.Errorf("expected response %+v, got %+v", wresp, resp)
+	}
+}
+
+func TestError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"etcdserver: key is not provided","code":3,"message":"etcdserver: key is not provided"}`))
+	}))
+	defer srv.Close()
+
+	cli, err := New(Config{Endpoints: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	_, err = cli.Put(context.Background(), "", "bar")
+	var e *Error
+	if !errors.As(err, &e) || e.Code != 3 || e.Message != "etcdserver: key is not provided" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+// TestFailover ensures requests go to the next endpoints while the endpoint
+// of the latest successful request is unreachable.
+func TestFailover(t *testing.T) {
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&n, 1)
+		w.Write([]byte(`{"header":{"revision":"2"}}`))
+	}))
+	defer srv.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	cli, err := New(Config{Endpoints: []string{down.URL, srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	for i := 0; i < 2; i++ {
+		resp, err := cli.Put(context.Background(), "foo", "bar")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Header.Revision != 2 {
+			t.Fatalf("expected revision 2, got %d", resp.Header.Revision)
+		}
+	}
+	if cli.ep != 1 {
+		t.Errorf("expected endpoint 1, got %d", cli.ep)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 requests, got %d", n)
+	}
+}
+
+// TestAuth ensures requests carry the auth token of the client and that the
+// client authenticates again once its token expired.
+func TestAuth(t *testing.T) {
+	var tokens int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/auth/authenticate":
+			var req authenticateRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+			if req.Name != "root" || req.Password != "pass" {
+				t.Errorf("unexpected credentials %+v", req)
+			}
+			if atomic.AddInt32(&tokens, 1) == 1 {
+				w.Write([]byte(`{"token":"expired"}`))
+			} else {
+				w.Write([]byte(`{"token":"valid"}`))
+			}
+		default:
+			if r.Header.Get("Authorization") != "valid" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"etcdserver: invalid auth token","code":16,"message":"etcdserver: invalid auth token"}`))
+				return
+			}
+			w.Write([]byte(`{"header":{"revision":"2"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	cli, err := New(Config{Endpoints: []string{srv.URL}, Username: "root", Password: "pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err = cli.Put(context.Background(), "foo", "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tokens != 2 {
+		t.Errorf("expected 2 authentications, got %d", tokens)
+	}
+}
+
+func TestTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"header":{"revision":"2"}}`))
+	}))
+	defer srv.Close()
+
+	cli, err := New(Config{Endpoints: []string{srv.Listener.Addr().String()}, TLS: srv.Client().Transport.(*http.Transport).TLSClientConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	if _, err = cli.Put(context.Background(), "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+}
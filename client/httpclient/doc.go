@@ -0,0 +1,49 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpclient implements a minimal etcd v3 client speaking JSON to the
+// gRPC gateway of etcd members over HTTP, for tools that cannot depend on
+// gRPC. It only depends on the standard library.
+//
+// It supports getting, putting and deleting keys, and watching them:
+//
+//	cli, err := httpclient.New(httpclient.Config{
+//		Endpoints:   []string{"https://10.0.0.1:2379", "https://10.0.0.2:2379"},
+//		DialTimeout: 5 * time.Second,
+//		TLS:         tlsConfig,
+//		Username:    "user",
+//		Password:    "password",
+//	})
+//	if err != nil {
+//		// handle error!
+//	}
+//	defer cli.Close()
+//
+//	if _, err = cli.Put(ctx, "foo", "bar"); err != nil {
+//		// handle error!
+//	}
+//	for wresp := range cli.Watch(ctx, "foo", httpclient.WithPrefix()) {
+//		if err := wresp.Err(); err != nil {
+//			// handle error!
+//		}
+//		for _, ev := range wresp.Events {
+//			fmt.Printf("%s %q : %q\n", ev.Type, ev.Kv.Key, ev.Kv.Value)
+//		}
+//	}
+//
+// Requests are sent to the endpoint of the latest successful request, and to
+// the next endpoints while it is unreachable. Errors returned by the gateway
+// are of type *Error. Watches resume after the latest revision they got when
+// their stream breaks, until their context is canceled.
+package httpclient
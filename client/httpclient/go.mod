@@ -0,0 +1,3 @@
+module go.etcd.io/etcd/client/httpclient/v3
+
+go 1.19
@@ -0,0 +1,125 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import "context"
+
+// The types of the requests and responses follow the JSON mapping of their
+// protobuf messages by the gateway: bytes fields are encoded in base64 and
+// 64-bit integers in strings, and fields with default values are omitted.
+
+type ResponseHeader struct {
+	ClusterID uint64 `json:"cluster_id,string,omitempty"`
+	MemberID  uint64 `json:"member_id,string,omitempty"`
+	Revision  int64  `json:"revision,string,omitempty"`
+	RaftTerm  uint64 `json:"raft_term,string,omitempty"`
+}
+
+type KeyValue struct {
+	Key            []byte `json:"key,omitempty"`
+	CreateRevision int64  `json:"create_revision,string,omitempty"`
+	ModRevision    int64  `json:"mod_revision,string,omitempty"`
+	Version        int64  `json:"version,string,omitempty"`
+	Value          []byte `json:"value,omitempty"`
+	Lease          int64  `json:"lease,string,omitempty"`
+}
+
+type GetResponse struct {
+	Header ResponseHeader `json:"header"`
+	Kvs    []*KeyValue    `json:"kvs,omitempty"`
+	More   bool           `json:"more,omitempty"`
+	Count  int64          `json:"count,string,omitempty"`
+}
+
+type PutResponse struct {
+	Header ResponseHeader `json:"header"`
+	PrevKv *KeyValue      `json:"prev_kv,omitempty"`
+}
+
+type DeleteResponse struct {
+	Header  ResponseHeader `json:"header"`
+	Deleted int64          `json:"deleted,string,omitempty"`
+	PrevKvs []*KeyValue    `json:"prev_kvs,omitempty"`
+}
+
+type rangeRequest struct {
+	Key          []byte `json:"key,omitempty"`
+	RangeEnd     []byte `json:"range_end,omitempty"`
+	Limit        int64  `json:"limit,string,omitempty"`
+	Revision     int64  `json:"revision,string,omitempty"`
+	Serializable bool   `json:"serializable,omitempty"`
+	KeysOnly     bool   `json:"keys_only,omitempty"`
+	CountOnly    bool   `json:"count_only,omitempty"`
+}
+
+type putRequest struct {
+	Key    []byte `json:"key,omitempty"`
+	Value  []byte `json:"value,omitempty"`
+	Lease  int64  `json:"lease,string,omitempty"`
+	PrevKv bool   `json:"prev_kv,omitempty"`
+}
+
+type deleteRangeRequest struct {
+	Key      []byte `json:"key,omitempty"`
+	RangeEnd []byte `json:"range_end,omitempty"`
+	PrevKv   bool   `json:"prev_kv,omitempty"`
+}
+
+// Get retrieves keys.
+// By default, Get will return the value for "key", if any.
+// When passed WithRange(end), Get will return the keys in the range [key, end).
+// When passed WithFromKey(), Get returns keys greater than or equal to key.
+// When passed WithRev(rev) with rev > 0, Get retrieves keys at the given revision;
+// if the required revision is compacted, the request fails.
+// When passed WithLimit(limit), the number of returned keys is bounded by limit.
+func (c *Client) Get(ctx context.Context, key string, opts ...OpOption) (*GetResponse, error) {
+	op := newOp(key, opts)
+	req := &rangeRequest{
+		Key:          op.key,
+		RangeEnd:     op.end,
+		Limit:        op.limit,
+		Revision:     op.rev,
+		Serializable: op.serializable,
+		KeysOnly:     op.keysOnly,
+		CountOnly:    op.countOnly,
+	}
+	resp := &GetResponse{}
+	if err := c.call(ctx, "/v3/kv/range", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Put puts a key-value pair into etcd.
+func (c *Client) Put(ctx context.Context, key, val string, opts ...OpOption) (*PutResponse, error) {
+	op := newOp(key, opts)
+	req := &putRequest{Key: op.key, Value: []byte(val), Lease: op.lease, PrevKv: op.prevKV}
+	resp := &PutResponse{}
+	if err := c.call(ctx, "/v3/kv/put", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Delete deletes a key, or optionally using WithRange(end), [key, end).
+func (c *Client) Delete(ctx context.Context, key string, opts ...OpOption) (*DeleteResponse, error) {
+	op := newOp(key, opts)
+	req := &deleteRangeRequest{Key: op.key, RangeEnd: op.end, PrevKv: op.prevKV}
+	resp := &DeleteResponse{}
+	if err := c.call(ctx, "/v3/kv/deleterange", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+// Op holds the options of a request.
+type Op struct {
+	key []byte
+	end []byte
+
+	// prefix and fromKey resolve end once the key is known.
+	prefix  bool
+	fromKey bool
+
+	rev            int64
+	limit          int64
+	lease          int64
+	serializable   bool
+	keysOnly       bool
+	countOnly      bool
+	prevKV         bool
+	progressNotify bool
+}
+
+// OpOption configures an Op.
+type OpOption func(*Op)
+
+func newOp(key string, opts []OpOption) *Op {
+	op := &Op{key: []byte(key)}
+	for _, opt := range opts {
+		opt(op)
+	}
+	switch {
+	case op.prefix:
+		op.end = prefixEnd(op.key)
+	case op.fromKey:
+		if len(op.key) == 0 {
+			op.key = []byte{0}
+		}
+		op.end = []byte{0}
+	}
+	return op
+}
+
+// prefixEnd returns the end of the range of the keys with the given prefix.
+func prefixEnd(key []byte) []byte {
+	end := make([]byte, len(key))
+	copy(end, key)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i] = end[i] + 1
+			return end[:i+1]
+		}
+	}
+	// next prefix does not exist (e.g., 0xffff);
+	// default to WithFromKey policy
+	return []byte{0}
+}
+
+// WithRange specifies the range of 'Get', 'Delete' and 'Watch' requests:
+// the keys in [key, end).
+func WithRange(end string) OpOption {
+	return func(op *Op) { op.end = []byte(end) }
+}
+
+// WithPrefix enables 'Get', 'Delete' and 'Watch' requests to operate on the
+// keys with matching prefix.
+func WithPrefix() OpOption {
+	return func(op *Op) { op.prefix = true }
+}
+
+// WithFromKey specifies the range of 'Get', 'Delete' and 'Watch' requests to
+// be the keys equal or greater than the key.
+func WithFromKey() OpOption {
+	return func(op *Op) { op.fromKey = true }
+}
+
+// WithRev specifies the revision of a 'Get' request, or the start revision
+// of a 'Watch' request.
+func WithRev(rev int64) OpOption {
+	return func(op *Op) { op.rev = rev }
+}
+
+// WithLimit limits the number of results to return from a 'Get' request.
+func WithLimit(n int64) OpOption {
+	return func(op *Op) { op.limit = n }
+}
+
+// WithLease attaches a lease ID to a key in a 'Put' request.
+func WithLease(id int64) OpOption {
+	return func(op *Op) { op.lease = id }
+}
+
+// WithSerializable makes a 'Get' request serializable. By default, it is
+// linearizable.
+func WithSerializable() OpOption {
+	return func(op *Op) { op.serializable = true }
+}
+
+// WithKeysOnly makes a 'Get' request return only the keys.
+func WithKeysOnly() OpOption {
+	return func(op *Op) { op.keysOnly = true }
+}
+
+// WithCountOnly makes a 'Get' request return only the count of keys.
+func WithCountOnly() OpOption {
+	return func(op *Op) { op.countOnly = true }
+}
+
+// WithPrevKV gets the previous key-value pairs of 'Put' and 'Delete'
+// requests, and the previous key-value pairs of the events of a 'Watch'
+// request.
+func WithPrevKV() OpOption {
+	return func(op *Op) { op.prevKV = true }
+}
+
+// WithProgressNotify makes a 'Watch' request receive a periodic response
+// without events while the watched keys do not change.
+func WithProgressNotify() OpOption {
+	return func(op *Op) { op.progressNotify = true }
+}
@@ -0,0 +1,210 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	EventTypePut    = "PUT"
+	EventTypeDelete = "DELETE"
+)
+
+var (
+	ErrCompacted = errors.New("etcdserver: mvcc: required revision has been compacted")
+)
+
+const (
+	// watchRetryMinInterval and watchRetryMaxInterval bound the interval
+	// between two attempts to resume a broken watch stream.
+	watchRetryMinInterval = 100 * time.Millisecond
+	watchRetryMaxInterval = 5 * time.Second
+)
+
+// codeUnavailable is the gRPC status code of the errors of unavailable
+// members, after which watches are resumed.
+const codeUnavailable = 14
+
+type Event struct {
+	// Type is EventTypePut or EventTypeDelete.
+	Type   string    `json:"type,omitempty"`
+	Kv     *KeyValue `json:"kv,omitempty"`
+	PrevKv *KeyValue `json:"prev_kv,omitempty"`
+}
+
+type WatchResponse struct {
+	Header ResponseHeader `json:"header"`
+	Events []*Event       `json:"events,omitempty"`
+
+	// CompactRevision is the minimum revision the watcher may receive, set
+	// when the watcher is canceled because its start revision was compacted.
+	CompactRevision int64 `json:"compact_revision,string,omitempty"`
+
+	// Canceled is set when the watcher is canceled by the server, or when
+	// its stream fails for good.
+	Canceled bool `json:"canceled,omitempty"`
+
+	Created      bool   `json:"created,omitempty"`
+	CancelReason string `json:"cancel_reason,omitempty"`
+
+	closeErr error
+}
+
+type WatchChan <-chan WatchResponse
+
+type watchRequest struct {
+	CreateRequest *watchCreateRequest `json:"create_request"`
+}
+
+type watchCreateRequest struct {
+	Key            []byte `json:"key,omitempty"`
+	RangeEnd       []byte `json:"range_end,omitempty"`
+	StartRevision  int64  `json:"start_revision,string,omitempty"`
+	ProgressNotify bool   `json:"progress_notify,omitempty"`
+	PrevKv         bool   `json:"prev_kv,omitempty"`
+}
+
+// Err is the error value if this WatchResponse holds an error.
+func (wr *WatchResponse) Err() error {
+	switch {
+	case wr.closeErr != nil:
+		return wr.closeErr
+	case wr.CompactRevision != 0:
+		return ErrCompacted
+	case wr.Canceled:
+		if len(wr.CancelReason) != 0 {
+			return &Error{Code: codeUnknown, Message: wr.CancelReason}
+		}
+		return errors.New("etcdclient: watch canceled")
+	}
+	return nil
+}
+
+// IsProgressNotify returns true if the WatchResponse is progress notification.
+func (wr *WatchResponse) IsProgressNotify() bool {
+	return len(wr.Events) == 0 && !wr.Canceled && !wr.Created && wr.CompactRevision == 0 && wr.Header.Revision != 0
+}
+
+// Watch watches on a key or prefix. The watched events will be returned
+// through the returned channel. If revisions waiting to be sent over the
+// watch are compacted, then the watch will be canceled by the server, the
+// client will post a compacted error watch response, and the channel will
+// close. The watch resumes after the latest revision it got when its stream
+// breaks, and the channel closes once the context is canceled or the watch
+// fails for good.
+func (c *Client) Watch(ctx context.Context, key string, opts ...OpOption) WatchChan {
+	op := newOp(key, opts)
+	ch := make(chan WatchResponse)
+	go c.watch(ctx, op, ch)
+	return ch
+}
+
+func (c *Client) watch(ctx context.Context, op *Op, ch chan<- WatchResponse) {
+	defer close(ch)
+
+	req := &watchRequest{CreateRequest: &watchCreateRequest{
+		Key:            op.key,
+		RangeEnd:       op.end,
+		StartRevision:  op.rev,
+		ProgressNotify: op.progressNotify,
+		PrevKv:         op.prevKV,
+	}}
+	retry := watchRetryMinInterval
+	for {
+		nextRev, err := c.watchStream(ctx, req, ch)
+		if ctx.Err() != nil {
+			return
+		}
+		var e *Error
+		if errors.As(err, &e) && e.Code != codeUnavailable {
+			// the server rejected the watch
+			select {
+			case ch <- WatchResponse{Canceled: true, closeErr: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+		if err == nil {
+			// the watcher was canceled by the server
+			return
+		}
+		if nextRev != 0 {
+			req.CreateRequest.StartRevision = nextRev
+			retry = watchRetryMinInterval
+		}
+		select {
+		case <-time.After(retry):
+		case <-ctx.Done():
+			return
+		}
+		if retry *= 2; retry > watchRetryMaxInterval {
+			retry = watchRetryMaxInterval
+		}
+	}
+}
+
+// watchStream creates the watcher on a stream and sends its responses to ch
+// until the watcher is canceled, returning a nil error, or the stream
+// breaks. It returns the revision to resume the watcher from, or 0 if it got
+// no response.
+func (c *Client) watchStream(ctx context.Context, req *watchRequest, ch chan<- WatchResponse) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	hresp, err := c.post(ctx, "/v3/watch", req)
+	if err != nil {
+		return 0, err
+	}
+	defer hresp.Body.Close()
+
+	var nextRev int64
+	dec := json.NewDecoder(hresp.Body)
+	for {
+		var chunk streamChunk
+		if err = dec.Decode(&chunk); err != nil {
+			return nextRev, err
+		}
+		if chunk.Error != nil {
+			return nextRev, chunk.Error.err()
+		}
+		var wr WatchResponse
+		if err = json.Unmarshal(chunk.Result, &wr); err != nil {
+			return nextRev, err
+		}
+		if wr.Created && !wr.Canceled {
+			continue
+		}
+		for _, ev := range wr.Events {
+			if ev.Type == "" {
+				ev.Type = EventTypePut
+			}
+		}
+		select {
+		case ch <- wr:
+		case <-ctx.Done():
+			return nextRev, ctx.Err()
+		}
+		if wr.Canceled {
+			return nextRev, nil
+		}
+		if wr.Header.Revision != 0 {
+			nextRev = wr.Header.Revision + 1
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWatchResume ensures a watch resumes after the latest revision it got
+// when its stream breaks.
+func TestWatchResume(t *testing.T) {
+	var streams int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req watchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"result":{"header":{"revision":"1"},"created":true}}` + "\n"))
+		switch atomic.AddInt32(&streams, 1) {
+		case 1:
+			if req.CreateRequest.StartRevision != 2 {
+				t.Errorf("expected start revision 2, got %d", req.CreateRequest.StartRevision)
+			}
+			w.Write([]byte(`{"result":{"header":{"revision":"3"},"events":[{"kv":{"key":"Zm9v","mod_revision":"3","value":"YmFy"}}]}}` + "\n"))
+		case 2:
+			if req.CreateRequest.StartRevision != 4 {
+				t.Errorf("expected start revision 4, got %d", req.CreateRequest.StartRevision)
+			}
+			w.Write([]byte(`{"result":{"header":{"revision":"4"},"events":[{"type":"DELETE","kv":{"key":"Zm9v","mod_revision":"4"}}]}}` + "\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	cli, err := New(Config{Endpoints: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wch := cli.Watch(ctx, "foo", WithRev(2))
+	for _, want := range []struct {
+		typ string
+		rev int64
+	}{{EventTypePut, 3}, {EventTypeDelete, 4}} {
+		select {
+		case wr := <-wch:
+			if err = wr.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(wr.Events) != 1 || wr.Events[0].Type != want.typ || wr.Events[0].Kv.ModRevision != want.rev {
+				t.Fatalf("expected %s event at revision %d, got %+v", want.typ, want.rev, wr.Events)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for watch response")
+		}
+	}
+
+	cancel()
+	select {
+	case _, ok := <-wch:
+		if ok {
+			t.Fatal("expected watch channel to close")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for watch channel to close")
+	}
+}
+
+func TestWatchCompacted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":{"header":{"revision":"5"},"created":true}}` + "\n"))
+		w.Write([]byte(`{"result":{"header":{"revision":"5"},"canceled":true,"compact_revision":"3"}}` + "\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	cli, err := New(Config{Endpoints: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	wch := cli.Watch(context.Background(), "foo", WithRev(2))
+	wr, ok := <-wch
+	if !ok || wr.Err() != ErrCompacted || wr.CompactRevision != 3 {
+		t.Fatalf("expected compacted response, got %+v", wr)
+	}
+	if _, ok = <-wch; ok {
+		t.Fatal("expected watch channel to close")
+	}
+}
@@ -69,7 +69,7 @@ func newListener(addr, scheme string, opts ...ListenerOption) (net.Listener, err
 		fallthrough
 	case lnOpts.IsTimeout(), lnOpts.IsSocketOpts():
 		// timeout listener with socket options.
-		ln, err := newKeepAliveListener(&lnOpts.ListenConfig, addr)
+		ln, err := newKeepAliveListener(&lnOpts.ListenConfig, lnOpts.network, addr)
 		if err != nil {
 			return nil, err
 		}
@@ -79,7 +79,7 @@ func newListener(addr, scheme string, opts ...ListenerOption) (net.Listener, err
 			writeTimeout: lnOpts.writeTimeout,
 		}
 	case lnOpts.IsTimeout():
-		ln, err := newKeepAliveListener(nil, addr)
+		ln, err := newKeepAliveListener(nil, lnOpts.network, addr)
 		if err != nil {
 			return nil, err
 		}
@@ -89,7 +89,7 @@ func newListener(addr, scheme string, opts ...ListenerOption) (net.Listener, err
 			writeTimeout: lnOpts.writeTimeout,
 		}
 	default:
-		ln, err := newKeepAliveListener(nil, addr)
+		ln, err := newKeepAliveListener(nil, lnOpts.network, addr)
 		if err != nil {
 			return nil, err
 		}
@@ -103,11 +103,11 @@ func newListener(addr, scheme string, opts ...ListenerOption) (net.Listener, err
 	return wrapTLS(scheme, lnOpts.tlsInfo, lnOpts.Listener)
 }
 
-func newKeepAliveListener(cfg *net.ListenConfig, addr string) (ln net.Listener, err error) {
+func newKeepAliveListener(cfg *net.ListenConfig, network, addr string) (ln net.Listener, err error) {
 	if cfg != nil {
-		ln, err = cfg.Listen(context.TODO(), "tcp", addr)
+		ln, err = cfg.Listen(context.TODO(), network, addr)
 	} else {
-		ln, err = net.Listen("tcp", addr)
+		ln, err = net.Listen(network, addr)
 	}
 	if err != nil {
 		return
@@ -23,6 +23,7 @@ type ListenerOptions struct {
 	Listener     net.Listener
 	ListenConfig net.ListenConfig
 
+	network          string
 	socketOpts       *SocketOpts
 	tlsInfo          *TLSInfo
 	skipTLSInfoCheck bool
@@ -31,7 +32,7 @@ type ListenerOptions struct {
 }
 
 func newListenOpts(opts ...ListenerOption) *ListenerOptions {
-	lnOpts := &ListenerOptions{}
+	lnOpts := &ListenerOptions{network: "tcp"}
 	lnOpts.applyOpts(opts)
 	return lnOpts
 }
@@ -72,6 +73,14 @@ func WithTimeout(read, write time.Duration) ListenerOption {
 	}
 }
 
+// WithNetwork sets the network the listener binds to, "tcp" by default.
+// Use "tcp4" or "tcp6" to accept connections of a single address family,
+// for example to listen on the IPv4 and IPv6 wildcard addresses of the
+// same port with separate listeners.
+func WithNetwork(network string) ListenerOption {
+	return func(lo *ListenerOptions) { lo.network = network }
+}
+
 // WithSocketOpts defines socket options that will be applied to the listener.
 func WithSocketOpts(s *SocketOpts) ListenerOption {
 	return func(lo *ListenerOptions) { lo.socketOpts = s }
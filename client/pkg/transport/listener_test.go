@@ -219,6 +219,26 @@ func TestNewListenerWithSocketOpts(t *testing.T) {
 	}
 }
 
+// TestNewListenerWithNetwork ensures IPv4 and IPv6 wildcard listeners
+// can share a port when bound to a single address family each.
+func TestNewListenerWithNetwork(t *testing.T) {
+	ln4, err := NewListenerWithOpts("0.0.0.0:0", "http", WithNetwork("tcp4"))
+	if err != nil {
+		t.Fatalf("unexpected NewListenerWithOpts error: %v", err)
+	}
+	defer ln4.Close()
+
+	_, port, err := net.SplitHostPort(ln4.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln6, err := NewListenerWithOpts(net.JoinHostPort("::", port), "http", WithNetwork("tcp6"))
+	if err != nil {
+		t.Skipf("IPv6 is not available: %v", err)
+	}
+	ln6.Close()
+}
+
 func testNewListenerTLSInfoAccept(t *testing.T, tlsInfo TLSInfo) {
 	ln, err := NewListener("127.0.0.1:0", "https", &tlsInfo)
 	if err != nil {
@@ -0,0 +1,99 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/golang/protobuf/proto"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/api/v3/version"
+	"go.uber.org/zap"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ClusterAPIVersion returns the API version last advertised by the cluster,
+// or nil if none was advertised yet. Members from before v3.6, or which have
+// not yet decided the cluster version, do not advertise it.
+func (c *Client) ClusterAPIVersion() *semver.Version {
+	ver, _ := c.clusterAPIVersion.Load().(*semver.Version)
+	return ver
+}
+
+// apiVersion returns the API version the client declares to the cluster.
+func (c *Client) apiVersion() string {
+	if c.cfg.MaxAPIVersion != "" {
+		return c.cfg.MaxAPIVersion
+	}
+	return version.APIVersion
+}
+
+// apiVersionInvoker fails the requests using fields not supported by either
+// the client or the cluster, and records the API version advertised by the
+// cluster in the responses.
+func (c *Client) apiVersionInvoker(invoker grpc.UnaryInvoker) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if err := c.checkAPIVersion(req); err != nil {
+			return err
+		}
+		var md metadata.MD
+		if err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&md))...); err != nil {
+			return err
+		}
+		if vs := md.Get(rpctypes.MetadataClusterAPIVersionKey); len(vs) > 0 {
+			if ver, err := parseAPIVersion(vs[0]); err == nil {
+				c.clusterAPIVersion.Store(ver)
+			}
+		}
+		return nil
+	}
+}
+
+func (c *Client) checkAPIVersion(req interface{}) error {
+	limit := c.maxAPIVersion
+	if ver := c.ClusterAPIVersion(); ver != nil && (limit == nil || ver.LessThan(*limit)) {
+		limit = ver
+	}
+	if limit == nil || !limit.LessThan(*semver.Must(semver.NewVersion(version.Version))) {
+		// all the fields known to the client are supported
+		return nil
+	}
+	m, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+	ver, err := version.MessageVersion(m)
+	if err != nil {
+		c.GetLogger().Warn("failed to get the API version of request", zap.Error(err))
+		return nil
+	}
+	if ver != nil && limit.LessThan(*ver) {
+		return rpctypes.ErrGRPCAPIVersionUnsupported
+	}
+	return nil
+}
+
+// parseAPIVersion parses an API version as "major.minor".
+func parseAPIVersion(s string) (*semver.Version, error) {
+	var major, minor int64
+	if _, err := fmt.Sscanf(s, "%d.%d", &major, &minor); err != nil {
+		return nil, err
+	}
+	return &semver.Version{Major: major, Minor: minor}, nil
+}
@@ -42,6 +42,7 @@ type (
 	AuthRoleDeleteResponse           pb.AuthRoleDeleteResponse
 	AuthUserListResponse             pb.AuthUserListResponse
 	AuthRoleListResponse             pb.AuthRoleListResponse
+	AuthCheckPermissionsResponse     pb.AuthCheckPermissionsResponse
 
 	PermissionType authpb.Permission_Type
 	Permission     authpb.Permission
@@ -51,10 +52,22 @@ const (
 	PermRead      = authpb.READ
 	PermWrite     = authpb.WRITE
 	PermReadWrite = authpb.READWRITE
+	// PermLease permits revoking and keeping alive the leases granted by
+	// other users. It is granted with an empty key and range end.
+	PermLease = authpb.LEASE
 )
 
 type UserAddOptions authpb.UserAddOptions
 
+// PermissionCheck asks whether User has PermType on the key, or on the
+// range [Key, RangeEnd) if RangeEnd is set.
+type PermissionCheck struct {
+	User     string
+	Key      string
+	RangeEnd string
+	PermType PermissionType
+}
+
 type Auth interface {
 	// Authenticate login and get token
 	Authenticate(ctx context.Context, name string, password string) (*AuthenticateResponse, error)
@@ -109,6 +122,11 @@ type Auth interface {
 
 	// RoleDelete deletes a role.
 	RoleDelete(ctx context.Context, role string) (*AuthRoleDeleteResponse, error)
+
+	// CheckPermissions evaluates the given permission checks in one request.
+	// The i-th entry of Allowed in the response is the result of the i-th
+	// check. Users without the root role may only check their own permissions.
+	CheckPermissions(ctx context.Context, checks ...PermissionCheck) (*AuthCheckPermissionsResponse, error)
 }
 
 type authClient struct {
@@ -227,6 +245,20 @@ func (auth *authClient) RoleDelete(ctx context.Context, role string) (*AuthRoleD
 	return (*AuthRoleDeleteResponse)(resp), toErr(ctx, err)
 }
 
+func (auth *authClient) CheckPermissions(ctx context.Context, checks ...PermissionCheck) (*AuthCheckPermissionsResponse, error) {
+	req := &pb.AuthCheckPermissionsRequest{Checks: make([]*pb.AuthPermissionCheck, len(checks))}
+	for i, c := range checks {
+		req.Checks[i] = &pb.AuthPermissionCheck{
+			User:     c.User,
+			Key:      []byte(c.Key),
+			RangeEnd: []byte(c.RangeEnd),
+			PermType: authpb.Permission_Type(c.PermType),
+		}
+	}
+	resp, err := auth.remote.CheckPermissions(ctx, req, auth.callOpts...)
+	return (*AuthCheckPermissionsResponse)(resp), toErr(ctx, err)
+}
+
 func StrToPermissionType(s string) (PermissionType, error) {
 	val, ok := authpb.Permission_Type_value[strings.ToUpper(s)]
 	if ok {
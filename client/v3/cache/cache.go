@@ -0,0 +1,383 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache serves the reads of keys from an in-memory cache kept
+// coherent with etcd by a watch, for read-heavy clients tolerating slightly
+// stale reads.
+//
+// A cache is a clientv3.KV over the keys under a prefix. It serves the Gets
+// of single keys under the prefix from memory, and sends all other requests
+// to etcd. The cache watches the prefix and drops the keys changed since they
+// were read:
+//
+//	kv := cache.New(cli, "/config/", cache.Config{
+//		MaxStaleness: time.Second,
+//		MaxBytes:     64 * 1024 * 1024,
+//	})
+//	defer kv.Close()
+//	resp, err := kv.Get(ctx, "/config/feature")
+//
+// A read served from the cache is at most MaxStaleness behind etcd, and never
+// older than the writes made through the cache. While the watch is down or
+// lagging, reads are served by etcd.
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// DefaultMaxStaleness is the staleness bound of the reads of a cache
+	// configured without one.
+	DefaultMaxStaleness = time.Second
+
+	// watchRetryInterval is the delay before watching again after a failure.
+	watchRetryInterval = 500 * time.Millisecond
+	// watchStreamKey is the metadata key giving the watch of a cache its own
+	// stream, so the other watchers of the client do not receive its
+	// progress notifications.
+	watchStreamKey = "cache-prefix"
+)
+
+// Config configures a cache.
+type Config struct {
+	// MaxStaleness bounds how long ago the watch of the cache last reported
+	// its progress for reads to be served from the cache. Zero defaults to
+	// DefaultMaxStaleness.
+	MaxStaleness time.Duration
+	// MaxBytes bounds the size of the cached keys and values, evicting the
+	// least recently read keys above it. Zero does not bound the size.
+	MaxBytes int64
+}
+
+// Stats are the statistics of a cache.
+type Stats struct {
+	// Hits is the number of reads served from the cache.
+	Hits int64
+	// Misses is the number of reads of single keys under the prefix
+	// served by etcd.
+	Misses int64
+	// Keys is the number of cached keys, including the keys read as
+	// missing.
+	Keys int
+	// Bytes is the size of the cached keys and values.
+	Bytes int64
+}
+
+// Cache is a clientv3.KV serving the reads of single keys under a prefix
+// from an in-memory cache.
+type Cache struct {
+	clientv3.KV
+
+	c            *clientv3.Client
+	prefix       string
+	maxStaleness time.Duration
+	lg           *zap.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	donec  chan struct{}
+
+	hits, misses int64 // must use atomic operations to access
+
+	mu      sync.Mutex
+	entries *lru
+	// rev is the revision the watch is up to date with, 0 while it is not
+	// watching.
+	rev int64
+	// progressAt is when the watch last reported its progress.
+	progressAt time.Time
+	// writeRev is the revision of the latest write made through the cache.
+	writeRev int64
+
+	now func() time.Time
+}
+
+// New creates a cache of the keys with prefix and starts watching them.
+// Close stops the watch.
+func New(c *clientv3.Client, prefix string, cfg Config) *Cache {
+	lg := c.GetLogger()
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	if cfg.MaxStaleness == 0 {
+		cfg.MaxStaleness = DefaultMaxStaleness
+	}
+	ctx, cancel := context.WithCancel(c.Ctx())
+	ca := &Cache{
+		KV:           c.KV,
+		c:            c,
+		prefix:       prefix,
+		maxStaleness: cfg.MaxStaleness,
+		lg:           lg,
+		ctx:          ctx,
+		cancel:       cancel,
+		donec:        make(chan struct{}),
+		entries:      newLRU(cfg.MaxBytes),
+		now:          time.Now,
+	}
+	go ca.run()
+	return ca
+}
+
+// Close stops the watch of the cache. The requests made afterwards are all
+// sent to etcd.
+func (ca *Cache) Close() {
+	ca.cancel()
+	<-ca.donec
+}
+
+// Stats returns the statistics of the cache.
+func (ca *Cache) Stats() Stats {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	return Stats{
+		Hits:   atomic.LoadInt64(&ca.hits),
+		Misses: atomic.LoadInt64(&ca.misses),
+		Keys:   ca.entries.len(),
+		Bytes:  ca.entries.bytes,
+	}
+}
+
+func (ca *Cache) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	r, err := ca.Do(ctx, clientv3.OpGet(key, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(), nil
+}
+
+func (ca *Cache) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	r, err := ca.Do(ctx, clientv3.OpPut(key, val, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return r.Put(), nil
+}
+
+func (ca *Cache) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	r, err := ca.Do(ctx, clientv3.OpDelete(key, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return r.Del(), nil
+}
+
+func (ca *Cache) Txn(ctx context.Context) clientv3.Txn {
+	return &txnCache{Txn: ca.KV.Txn(ctx), ca: ca}
+}
+
+func (ca *Cache) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	if !ca.cacheable(op) {
+		r, err := ca.KV.Do(ctx, op)
+		if err == nil && !op.IsGet() {
+			ca.wrote(r)
+		}
+		return r, err
+	}
+	key := string(op.KeyBytes())
+	if resp, ok := ca.lookup(key); ok {
+		atomic.AddInt64(&ca.hits, 1)
+		return resp.OpResponse(), nil
+	}
+	atomic.AddInt64(&ca.misses, 1)
+	r, err := ca.KV.Do(ctx, op)
+	if err != nil {
+		return r, err
+	}
+	ca.fill(key, r.Get())
+	return r, nil
+}
+
+// cacheable returns true if op reads a single key under the prefix, at the
+// latest revision and in full.
+func (ca *Cache) cacheable(op clientv3.Op) bool {
+	return op.IsGet() &&
+		len(op.RangeBytes()) == 0 &&
+		strings.HasPrefix(string(op.KeyBytes()), ca.prefix) &&
+		op.Rev() == 0 &&
+		!op.IsKeysOnly() && !op.IsCountOnly() &&
+		op.MinModRev() == 0 && op.MaxModRev() == 0 &&
+		op.MinCreateRev() == 0 && op.MaxCreateRev() == 0 &&
+		len(op.ExcludeFields()) == 0 && op.MaxValueSize() == 0 &&
+		op.PageHandler() == nil
+}
+
+// lookup returns the cached read of key if the cache is fresh enough.
+func (ca *Cache) lookup(key string) (*clientv3.GetResponse, bool) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	if ca.rev == 0 || ca.rev < ca.writeRev || ca.now().Sub(ca.progressAt) > ca.maxStaleness {
+		return nil, false
+	}
+	e := ca.entries.get(key)
+	if e == nil {
+		return nil, false
+	}
+	hdr := e.hdr
+	if ca.rev > hdr.Revision {
+		hdr.Revision = ca.rev
+	}
+	resp := &clientv3.GetResponse{Header: &hdr}
+	if e.kv != nil {
+		kv := *e.kv
+		resp.Kvs, resp.Count = []*mvccpb.KeyValue{&kv}, 1
+	}
+	return resp, true
+}
+
+// fill caches the read of key by etcd.
+func (ca *Cache) fill(key string, resp *clientv3.GetResponse) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	// a read older than the watch would miss the changes of the key in
+	// between, which the watch already went past.
+	if ca.rev == 0 || resp.Header.Revision < ca.rev {
+		return
+	}
+	e := &entry{key: key, hdr: *resp.Header}
+	if len(resp.Kvs) > 0 {
+		e.kv = resp.Kvs[0]
+	}
+	ca.entries.add(e)
+}
+
+// wrote records the revision of a write made through the cache, so the
+// following reads are not served from the cache before it is up to date
+// with the write.
+func (ca *Cache) wrote(r clientv3.OpResponse) {
+	var rev int64
+	switch {
+	case r.Put() != nil:
+		rev = r.Put().Header.Revision
+	case r.Del() != nil:
+		rev = r.Del().Header.Revision
+	case r.Txn() != nil:
+		rev = r.Txn().Header.Revision
+	}
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	if rev > ca.writeRev {
+		ca.writeRev = rev
+	}
+}
+
+// run watches the prefix until the cache is closed.
+func (ca *Cache) run() {
+	defer close(ca.donec)
+	for ca.ctx.Err() == nil {
+		ca.watch()
+		ca.reset()
+		select {
+		case <-time.After(watchRetryInterval):
+		case <-ca.ctx.Done():
+		}
+	}
+}
+
+// watch drops the cached keys changed under the prefix until the watch
+// fails or the cache is closed. It requests the progress of the watch every
+// half of the staleness bound, so the reads are served from the cache while
+// the keys do not change.
+func (ca *Cache) watch() {
+	ctx := metadata.AppendToOutgoingContext(clientv3.WithRequireLeader(ca.ctx), watchStreamKey, ca.prefix)
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wch := ca.c.Watch(wctx, ca.prefix, clientv3.WithPrefix(), clientv3.WithCreatedNotify())
+	ticker := time.NewTicker(ca.maxStaleness / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case wresp, ok := <-wch:
+			if !ok {
+				return
+			}
+			if err := wresp.Err(); err != nil {
+				ca.lg.Warn("cache watch failed, dropping cached keys", zap.String("prefix", ca.prefix), zap.Error(err))
+				return
+			}
+			ca.apply(wresp)
+		case <-ticker.C:
+			if err := ca.c.RequestProgress(wctx); err != nil && ca.ctx.Err() == nil {
+				ca.lg.Warn("failed to request cache watch progress", zap.String("prefix", ca.prefix), zap.Error(err))
+			}
+		case <-ca.ctx.Done():
+			return
+		}
+	}
+}
+
+// apply drops the cached keys changed by the events of wresp, and records
+// the progress of the watch.
+func (ca *Cache) apply(wresp clientv3.WatchResponse) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	for _, ev := range wresp.Events {
+		key := string(ev.Kv.Key)
+		if e := ca.entries.peek(key); e != nil && ev.Kv.ModRevision > e.hdr.Revision {
+			ca.entries.remove(key)
+		}
+	}
+	if wresp.Header.Revision > ca.rev {
+		ca.rev = wresp.Header.Revision
+	}
+	ca.progressAt = ca.now()
+}
+
+// reset drops all cached keys, which are no longer kept coherent.
+func (ca *Cache) reset() {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	ca.entries = newLRU(ca.entries.maxBytes)
+	ca.rev = 0
+}
+
+// txnCache records the revisions of the txns committed through a cache.
+type txnCache struct {
+	clientv3.Txn
+	ca *Cache
+}
+
+func (txn *txnCache) If(cs ...clientv3.Cmp) clientv3.Txn {
+	txn.Txn = txn.Txn.If(cs...)
+	return txn
+}
+
+func (txn *txnCache) Then(ops ...clientv3.Op) clientv3.Txn {
+	txn.Txn = txn.Txn.Then(ops...)
+	return txn
+}
+
+func (txn *txnCache) Else(ops ...clientv3.Op) clientv3.Txn {
+	txn.Txn = txn.Txn.Else(ops...)
+	return txn
+}
+
+func (txn *txnCache) Commit() (*clientv3.TxnResponse, error) {
+	resp, err := txn.Txn.Commit()
+	if err == nil {
+		txn.ca.wrote(resp.OpResponse())
+	}
+	return resp, err
+}
@@ -0,0 +1,145 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/clientv3test"
+)
+
+// waitWatching waits until the cache watches its prefix.
+func waitWatching(t *testing.T, ca *Cache) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		ca.mu.Lock()
+		defer ca.mu.Unlock()
+		return ca.rev != 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// get reads key through the cache and returns its value, "" if it is missing.
+func get(t *testing.T, ca *Cache, key string, opts ...clientv3.OpOption) string {
+	t.Helper()
+	resp, err := ca.Get(context.TODO(), key, opts...)
+	require.NoError(t, err)
+	if len(resp.Kvs) == 0 {
+		return ""
+	}
+	return string(resp.Kvs[0].Value)
+}
+
+func TestCache(t *testing.T) {
+	_, cli := clientv3test.New(t)
+	ctx := context.TODO()
+	_, err := cli.Put(ctx, "/a", "1")
+	require.NoError(t, err)
+
+	ca := New(cli, "/", Config{})
+	defer ca.Close()
+	waitWatching(t, ca)
+
+	assert.Equal(t, "1", get(t, ca, "/a"))
+	assert.Equal(t, "1", get(t, ca, "/a"))
+	assert.Equal(t, "", get(t, ca, "/b"))
+	assert.Equal(t, "", get(t, ca, "/b"))
+	st := ca.Stats()
+	assert.Equal(t, Stats{Hits: 2, Misses: 2, Keys: 2, Bytes: int64(len("/a1/b"))}, st)
+
+	// the reads of ranges, past revisions and keys outside the prefix are not cached
+	_, err = ca.Get(ctx, "/", clientv3.WithPrefix())
+	require.NoError(t, err)
+	get(t, ca, "/a", clientv3.WithRev(1))
+	_, err = cli.Put(ctx, "c", "1")
+	require.NoError(t, err)
+	get(t, ca, "c")
+	assert.Equal(t, st, ca.Stats())
+
+	// the keys written by other clients are dropped once the watch sees the writes
+	_, err = cli.Put(ctx, "/a", "2")
+	require.NoError(t, err)
+	_, err = cli.Put(ctx, "/b", "2")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return get(t, ca, "/a") == "2" && get(t, ca, "/b") == "2"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the reads following a write through the cache see the write
+	for i := 0; i < 10; i++ {
+		_, err = ca.Put(ctx, "/a", "3")
+		require.NoError(t, err)
+		assert.Equal(t, "3", get(t, ca, "/a"))
+		_, err = ca.Txn(ctx).Then(clientv3.OpDelete("/a")).Commit()
+		require.NoError(t, err)
+		assert.Equal(t, "", get(t, ca, "/a"))
+	}
+}
+
+func TestCacheStaleness(t *testing.T) {
+	_, cli := clientv3test.New(t)
+	ctx := context.TODO()
+	_, err := cli.Put(ctx, "/a", "1")
+	require.NoError(t, err)
+
+	ca := New(cli, "/", Config{MaxStaleness: time.Hour})
+	defer ca.Close()
+	waitWatching(t, ca)
+	get(t, ca, "/a")
+	get(t, ca, "/a")
+	require.Equal(t, int64(1), ca.Stats().Hits)
+
+	ca.mu.Lock()
+	ca.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	ca.mu.Unlock()
+	get(t, ca, "/a")
+	assert.Equal(t, Stats{Hits: 1, Misses: 2, Keys: 1, Bytes: int64(len("/a1"))}, ca.Stats())
+}
+
+func TestCacheFill(t *testing.T) {
+	ca := &Cache{entries: newLRU(0), maxStaleness: time.Hour, now: time.Now}
+	hdr := func(rev int64) *pb.ResponseHeader { return &pb.ResponseHeader{Revision: rev} }
+	kv := func(v string, rev int64) []*mvccpb.KeyValue {
+		return []*mvccpb.KeyValue{{Key: []byte("a"), Value: []byte(v), ModRevision: rev}}
+	}
+
+	// nothing is cached while the cache is not watching
+	ca.fill("a", &clientv3.GetResponse{Header: hdr(2), Kvs: kv("1", 2)})
+	assert.Equal(t, 0, ca.entries.len())
+
+	ca.apply(clientv3.WatchResponse{Header: *hdr(5), Created: true})
+	// a read older than the watch would miss the changes in between
+	ca.fill("a", &clientv3.GetResponse{Header: hdr(4), Kvs: kv("1", 2)})
+	assert.Equal(t, 0, ca.entries.len())
+
+	// a read ahead of the watch ignores the events it already reflects
+	ca.fill("a", &clientv3.GetResponse{Header: hdr(7), Kvs: kv("2", 6)})
+	ca.apply(clientv3.WatchResponse{Header: *hdr(6), Events: []*clientv3.Event{{Type: mvccpb.PUT, Kv: kv("2", 6)[0]}}})
+	resp, ok := ca.lookup("a")
+	require.True(t, ok)
+	assert.Equal(t, int64(7), resp.Header.Revision)
+	assert.Equal(t, "2", string(resp.Kvs[0].Value))
+
+	ca.apply(clientv3.WatchResponse{Header: *hdr(8), Events: []*clientv3.Event{{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte("a"), ModRevision: 8}}}})
+	_, ok = ca.lookup("a")
+	assert.False(t, ok)
+}
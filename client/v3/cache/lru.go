@@ -0,0 +1,103 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// entry is the cached read of a key.
+type entry struct {
+	key string
+	// kv is nil if the key did not exist.
+	kv *mvccpb.KeyValue
+	// hdr is the header of the read, whose revision is the revision the
+	// entry is up to date with.
+	hdr pb.ResponseHeader
+
+	elem *list.Element
+}
+
+func (e *entry) size() int64 {
+	if e.kv == nil {
+		return int64(len(e.key))
+	}
+	return int64(len(e.key) + len(e.kv.Value))
+}
+
+// lru holds the cached entries, evicting the least recently read ones when
+// their size exceeds maxBytes. A maxBytes of 0 does not bound the size.
+type lru struct {
+	maxBytes int64
+	bytes    int64
+	entries  map[string]*entry
+	// ll lists the entries from the most to the least recently read.
+	ll *list.List
+}
+
+func newLRU(maxBytes int64) *lru {
+	return &lru{maxBytes: maxBytes, entries: make(map[string]*entry), ll: list.New()}
+}
+
+// get returns the entry of key, if any, marking it as the most recently read.
+func (l *lru) get(key string) *entry {
+	e, ok := l.entries[key]
+	if !ok {
+		return nil
+	}
+	l.ll.MoveToFront(e.elem)
+	return e
+}
+
+// peek returns the entry of key, if any.
+func (l *lru) peek(key string) *entry {
+	return l.entries[key]
+}
+
+// add adds e, unless a more recent entry of its key is cached or e alone
+// exceeds the size bound.
+func (l *lru) add(e *entry) {
+	if old := l.entries[e.key]; old != nil {
+		if old.hdr.Revision > e.hdr.Revision {
+			return
+		}
+		l.remove(e.key)
+	}
+	if l.maxBytes > 0 && e.size() > l.maxBytes {
+		return
+	}
+	e.elem = l.ll.PushFront(e)
+	l.entries[e.key] = e
+	l.bytes += e.size()
+	for l.maxBytes > 0 && l.bytes > l.maxBytes {
+		l.remove(l.ll.Back().Value.(*entry).key)
+	}
+}
+
+// remove removes the entry of key, if any.
+func (l *lru) remove(key string) {
+	e, ok := l.entries[key]
+	if !ok {
+		return
+	}
+	l.ll.Remove(e.elem)
+	delete(l.entries, key)
+	l.bytes -= e.size()
+}
+
+func (l *lru) len() int { return len(l.entries) }
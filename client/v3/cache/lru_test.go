@@ -0,0 +1,59 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func TestLRU(t *testing.T) {
+	newEntry := func(key, val string, rev int64) *entry {
+		return &entry{key: key, kv: &mvccpb.KeyValue{Key: []byte(key), Value: []byte(val)}, hdr: pb.ResponseHeader{Revision: rev}}
+	}
+	l := newLRU(10)
+
+	l.add(newEntry("a", "111", 1))
+	l.add(newEntry("b", "111", 1))
+	assert.Equal(t, int64(8), l.bytes)
+	// reading a makes b the least recently read
+	l.get("a")
+	l.add(newEntry("c", "111", 1))
+	assert.Nil(t, l.peek("b"))
+	assert.NotNil(t, l.peek("a"))
+	assert.Equal(t, 2, l.len())
+	assert.Equal(t, int64(8), l.bytes)
+
+	// an older read does not replace a newer one
+	l.add(newEntry("a", "0", 0))
+	assert.Equal(t, "111", string(l.peek("a").kv.Value))
+	l.add(newEntry("a", "2", 2))
+	assert.Equal(t, "2", string(l.peek("a").kv.Value))
+	assert.Equal(t, int64(6), l.bytes)
+
+	// an entry larger than the bound is not cached
+	l.add(newEntry("d", "1234567890", 1))
+	assert.Nil(t, l.peek("d"))
+	assert.Equal(t, 2, l.len())
+
+	l.remove("a")
+	l.remove("c")
+	assert.Equal(t, 0, l.len())
+	assert.Equal(t, int64(0), l.bytes)
+}
@@ -21,8 +21,10 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/coreos/go-semver/semver"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/pkg/v3/logutil"
 	"go.etcd.io/etcd/client/v3/credentials"
@@ -43,6 +45,9 @@ var (
 
 // Client provides and manages an etcd v3 client session.
 type Client struct {
+	// clusterEpoch is accessed atomically; keep it 64-bit aligned.
+	clusterEpoch uint64
+
 	Cluster
 	KV
 	Lease
@@ -51,6 +56,8 @@ type Client struct {
 	Maintenance
 
 	conn *grpc.ClientConn
+	// readConn is the connection to Config.ReadEndpoints, if any.
+	readConn *grpc.ClientConn
 
 	cfg      Config
 	creds    grpccredentials.TransportCredentials
@@ -70,6 +77,17 @@ type Client struct {
 
 	callOpts []grpc.CallOption
 
+	// retryPolicyOpts are the retry options of the configured retry policies,
+	// by the retry policy of the requests.
+	retryPolicyOpts map[retryPolicy][]retryOption
+
+	// maxAPIVersion is the parsed Config.MaxAPIVersion, if any.
+	maxAPIVersion *semver.Version
+	// clusterAPIVersion holds the *semver.Version last advertised by the cluster.
+	clusterAPIVersion atomic.Value
+
+	states stateWatchers
+
 	lgMu *sync.RWMutex
 	lg   *zap.Logger
 }
@@ -149,6 +167,9 @@ func (c *Client) Close() error {
 	if c.Lease != nil {
 		c.Lease.Close()
 	}
+	if c.readConn != nil {
+		c.readConn.Close()
+	}
 	if c.conn != nil {
 		return toErr(c.ctx, c.conn.Close())
 	}
@@ -173,10 +194,27 @@ func (c *Client) Endpoints() []string {
 // SetEndpoints updates client's endpoints.
 func (c *Client) SetEndpoints(eps ...string) {
 	c.epMu.Lock()
-	defer c.epMu.Unlock()
+	changed := !equalEndpoints(c.endpoints, eps)
 	c.endpoints = eps
 
 	c.resolver.SetEndpoints(eps)
+	c.epMu.Unlock()
+
+	if changed {
+		c.notifyState(StateSwitchingEndpoints)
+	}
+}
+
+func equalEndpoints(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
 // Sync synchronizes client's endpoints with the known endpoints from the etcd membership.
@@ -226,6 +264,9 @@ func (c *Client) dialSetupOpts(creds grpccredentials.TransportCredentials, dopts
 		}
 		opts = append(opts, grpc.WithKeepaliveParams(params))
 	}
+	if c.cfg.DialFallbackDelay != 0 {
+		opts = append(opts, grpc.WithContextDialer(endpoint.Dialer(c.cfg.DialFallbackDelay)))
+	}
 	opts = append(opts, dopts...)
 
 	if creds != nil {
@@ -264,6 +305,7 @@ func (c *Client) getToken(ctx context.Context) error {
 		return nil
 	}
 
+	c.notifyState(StateReauthenticating)
 	resp, err := c.Auth.Authenticate(ctx, c.Username, c.Password)
 	if err != nil {
 		if err == rpctypes.ErrAuthNotEnabled {
@@ -284,6 +326,14 @@ func (c *Client) dialWithBalancer(dopts ...grpc.DialOption) (*grpc.ClientConn, e
 	return c.dial(creds, opts...)
 }
 
+// dialReadEndpoints dials the read endpoints of the client with a resolver
+// of their own, so that they are balanced apart from its endpoints.
+func (c *Client) dialReadEndpoints() (*grpc.ClientConn, error) {
+	eps := c.cfg.ReadEndpoints
+	creds := c.credentialsForEndpoint(eps[0])
+	return c.dial(creds, grpc.WithResolvers(resolver.New(eps...)))
+}
+
 // dial configures and dials any grpc balancer target.
 func (c *Client) dial(creds grpccredentials.TransportCredentials, dopts ...grpc.DialOption) (*grpc.ClientConn, error) {
 	opts, err := c.dialSetupOpts(creds, dopts...)
@@ -383,6 +433,17 @@ func newClient(cfg *Config) (*Client, error) {
 		return nil, err
 	}
 
+	if cfg.MaxAPIVersion != "" {
+		if client.maxAPIVersion, err = parseAPIVersion(cfg.MaxAPIVersion); err != nil {
+			return nil, fmt.Errorf("invalid max API version %q: %v", cfg.MaxAPIVersion, err)
+		}
+	}
+
+	if err = client.setRetryPolicies(cfg.RetryPolicy, cfg.NonRepeatableRetryPolicy); err != nil {
+		client.cancel()
+		return nil, err
+	}
+
 	if cfg.Username != "" && cfg.Password != "" {
 		client.Username = cfg.Username
 		client.Password = cfg.Password
@@ -406,7 +467,14 @@ func newClient(cfg *Config) (*Client, error) {
 		client.callOpts = callOpts
 	}
 
+	for ep, weight := range cfg.EndpointWeights {
+		if weight <= 0 {
+			client.cancel()
+			return nil, fmt.Errorf("invalid weight %d of endpoint %q: must be positive", weight, ep)
+		}
+	}
 	client.resolver = resolver.New(cfg.Endpoints...)
+	client.resolver.SetBalancing(cfg.EndpointWeights, cfg.EndpointPrimaryFallback)
 
 	if len(cfg.Endpoints) < 1 {
 		client.cancel()
@@ -424,6 +492,15 @@ func newClient(cfg *Config) (*Client, error) {
 		return nil, err
 	}
 	client.conn = conn
+	if len(cfg.ReadEndpoints) > 0 {
+		if client.readConn, err = client.dialReadEndpoints(); err != nil {
+			client.cancel()
+			client.resolver.Close()
+			conn.Close()
+			return nil, err
+		}
+	}
+	go client.watchConnState()
 
 	client.Cluster = NewCluster(client)
 	client.KV = NewKV(client)
@@ -0,0 +1,35 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package clientv3test provides an in-memory fake of an etcd server for unit
+// testing applications built on clientv3, without starting a real cluster.
+//
+// The fake serves the KV, Watch and Lease APIs with etcd semantics: every
+// write increments the store revision, past revisions can be read and
+// watched until they are compacted, and transactions are applied
+// atomically. Leases expire on a fake clock that only moves forward when
+// the test calls Advance.
+//
+// First, start a fake server and a client connected to it:
+//
+//	srv, cli := clientv3test.New(t)
+//
+// Then use the client as usual, and move the fake clock to expire leases:
+//
+//	lresp, _ := cli.Grant(ctx, 10)
+//	cli.Put(ctx, "foo", "bar", clientv3.WithLease(lresp.ID))
+//	srv.Advance(10 * time.Second) // "foo" is deleted
+//
+// The fake does not implement the cluster, maintenance and auth APIs.
+package clientv3test
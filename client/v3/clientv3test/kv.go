@@ -0,0 +1,432 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3test
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+type kvServer struct {
+	pb.UnimplementedKVServer
+	s *store
+}
+
+func (ks *kvServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, error) {
+	ks.s.mu.Lock()
+	defer ks.s.mu.Unlock()
+	return ks.s.rangeRequest(r)
+}
+
+func (ks *kvServer) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse, error) {
+	ks.s.mu.Lock()
+	defer ks.s.mu.Unlock()
+	if err := ks.s.checkPut(r); err != nil {
+		return nil, err
+	}
+	var resp *pb.PutResponse
+	ks.s.write(func(rev int64) { resp = ks.s.putRequest(rev, r) })
+	resp.Header = ks.s.header()
+	return resp, nil
+}
+
+func (ks *kvServer) DeleteRange(ctx context.Context, r *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	if len(r.Key) == 0 {
+		return nil, rpctypes.ErrGRPCEmptyKey
+	}
+	ks.s.mu.Lock()
+	defer ks.s.mu.Unlock()
+	var resp *pb.DeleteRangeResponse
+	ks.s.write(func(rev int64) { resp = ks.s.deleteRangeRequest(rev, r) })
+	resp.Header = ks.s.header()
+	return resp, nil
+}
+
+func (ks *kvServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, error) {
+	ks.s.mu.Lock()
+	defer ks.s.mu.Unlock()
+	return ks.s.txn(r)
+}
+
+// RangeStream streams the kvs of the range one per chunk.
+func (ks *kvServer) RangeStream(r *pb.RangeRequest, stream pb.KV_RangeStreamServer) error {
+	ks.s.mu.Lock()
+	resp, err := ks.s.rangeRequest(r)
+	ks.s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if len(resp.Kvs) == 0 {
+		return stream.Send(&pb.RangeStreamResponse{Header: resp.Header, More: resp.More, Count: resp.Count})
+	}
+	for i, kv := range resp.Kvs {
+		chunk := &pb.RangeStreamResponse{Header: resp.Header, Kvs: []*mvccpb.KeyValue{kv}, Count: resp.Count}
+		chunk.More = resp.More && i == len(resp.Kvs)-1
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ks *kvServer) TxnStream(r *pb.TxnRequest, stream pb.KV_TxnStreamServer) error {
+	ks.s.mu.Lock()
+	resp, err := ks.s.txn(r)
+	ks.s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if len(resp.Responses) == 0 {
+		return stream.Send(&pb.TxnStreamResponse{Header: resp.Header, Succeeded: resp.Succeeded})
+	}
+	for i, op := range resp.Responses {
+		if err := stream.Send(&pb.TxnStreamResponse{Header: resp.Header, Succeeded: resp.Succeeded, Index: int64(i), Response: op}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ks *kvServer) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error) {
+	ks.s.mu.Lock()
+	defer ks.s.mu.Unlock()
+	if err := ks.s.compact(r.Revision); err != nil {
+		return nil, err
+	}
+	return &pb.CompactionResponse{Header: ks.s.header()}, nil
+}
+
+func (s *store) rangeRequest(r *pb.RangeRequest) (*pb.RangeResponse, error) {
+	if len(r.Key) == 0 {
+		return nil, rpctypes.ErrGRPCEmptyKey
+	}
+	kvs, err := s.rangeAt(r.Key, r.RangeEnd, r.Revision)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.RangeResponse{Header: s.header(), Count: int64(len(kvs))}
+	kvs = filterRange(kvs, r)
+	sortRange(kvs, r.SortOrder, r.SortTarget)
+	if r.Limit > 0 && int64(len(kvs)) > r.Limit {
+		kvs = kvs[:r.Limit]
+		resp.More = true
+	}
+	if r.CountOnly {
+		return resp, nil
+	}
+	for _, kv := range kvs {
+		if r.KeysOnly || len(r.ExcludeFields) > 0 || r.MaxValueSize > 0 {
+			kv = maskKV(kv, r)
+		}
+		resp.Kvs = append(resp.Kvs, kv)
+	}
+	return resp, nil
+}
+
+// maskKV returns a copy of kv without the fields left out by r.
+func maskKV(kv *mvccpb.KeyValue, r *pb.RangeRequest) *mvccpb.KeyValue {
+	m := &mvccpb.KeyValue{Key: kv.Key, CreateRevision: kv.CreateRevision, ModRevision: kv.ModRevision, Version: kv.Version, Lease: kv.Lease, Metadata: kv.Metadata}
+	if !r.KeysOnly {
+		m.Value = kv.Value
+	}
+	for _, f := range r.ExcludeFields {
+		switch f {
+		case pb.RangeRequest_EXCLUDE_VALUE:
+			m.Value = nil
+		case pb.RangeRequest_EXCLUDE_CREATE_REVISION:
+			m.CreateRevision = 0
+		case pb.RangeRequest_EXCLUDE_MOD_REVISION:
+			m.ModRevision = 0
+		case pb.RangeRequest_EXCLUDE_VERSION:
+			m.Version = 0
+		case pb.RangeRequest_EXCLUDE_LEASE:
+			m.Lease = 0
+		case pb.RangeRequest_EXCLUDE_METADATA:
+			m.Metadata = nil
+		}
+	}
+	if r.MaxValueSize > 0 && int64(len(m.Value)) > r.MaxValueSize {
+		m.Value = m.Value[:r.MaxValueSize]
+		m.ValueTruncated = true
+	}
+	return m
+}
+
+func filterRange(kvs []*mvccpb.KeyValue, r *pb.RangeRequest) []*mvccpb.KeyValue {
+	var result []*mvccpb.KeyValue
+	for _, kv := range kvs {
+		if r.MinModRevision != 0 && kv.ModRevision < r.MinModRevision ||
+			r.MaxModRevision != 0 && kv.ModRevision > r.MaxModRevision ||
+			r.MinCreateRevision != 0 && kv.CreateRevision < r.MinCreateRevision ||
+			r.MaxCreateRevision != 0 && kv.CreateRevision > r.MaxCreateRevision {
+			continue
+		}
+		result = append(result, kv)
+	}
+	return result
+}
+
+func sortRange(kvs []*mvccpb.KeyValue, order pb.RangeRequest_SortOrder, target pb.RangeRequest_SortTarget) {
+	if order == pb.RangeRequest_NONE {
+		if target == pb.RangeRequest_KEY {
+			// already sorted by key
+			return
+		}
+		order = pb.RangeRequest_ASCEND
+	}
+	var less func(a, b *mvccpb.KeyValue) bool
+	switch target {
+	case pb.RangeRequest_KEY:
+		less = func(a, b *mvccpb.KeyValue) bool { return bytes.Compare(a.Key, b.Key) < 0 }
+	case pb.RangeRequest_VERSION:
+		less = func(a, b *mvccpb.KeyValue) bool { return a.Version < b.Version }
+	case pb.RangeRequest_CREATE:
+		less = func(a, b *mvccpb.KeyValue) bool { return a.CreateRevision < b.CreateRevision }
+	case pb.RangeRequest_MOD:
+		less = func(a, b *mvccpb.KeyValue) bool { return a.ModRevision < b.ModRevision }
+	case pb.RangeRequest_VALUE:
+		less = func(a, b *mvccpb.KeyValue) bool { return bytes.Compare(a.Value, b.Value) < 0 }
+	}
+	if order == pb.RangeRequest_DESCEND {
+		sort.SliceStable(kvs, func(i, j int) bool { return less(kvs[j], kvs[i]) })
+	} else {
+		sort.SliceStable(kvs, func(i, j int) bool { return less(kvs[i], kvs[j]) })
+	}
+}
+
+func (s *store) checkPut(r *pb.PutRequest) error {
+	if len(r.Key) == 0 {
+		return rpctypes.ErrGRPCEmptyKey
+	}
+	if r.IgnoreValue && len(r.Value) != 0 {
+		return rpctypes.ErrGRPCValueProvided
+	}
+	if r.IgnoreLease && r.Lease != 0 {
+		return rpctypes.ErrGRPCLeaseProvided
+	}
+	if r.IgnoreMetadata && len(r.Metadata) != 0 {
+		return rpctypes.ErrGRPCMetadataProvided
+	}
+	if (r.IgnoreValue || r.IgnoreLease || r.IgnoreMetadata) && s.kvs[string(r.Key)] == nil {
+		return rpctypes.ErrGRPCKeyNotFound
+	}
+	if _, ok := s.leases[r.Lease]; r.Lease != 0 && !ok {
+		return rpctypes.ErrGRPCLeaseNotFound
+	}
+	return nil
+}
+
+func (s *store) putRequest(rev int64, r *pb.PutRequest) *pb.PutResponse {
+	value, leaseID, metadata := r.Value, r.Lease, r.Metadata
+	if cur := s.kvs[string(r.Key)]; cur != nil {
+		if r.IgnoreValue {
+			value = cur.Value
+		}
+		if r.IgnoreLease {
+			leaseID = cur.Lease
+		}
+		if r.IgnoreMetadata {
+			metadata = cur.Metadata
+		}
+	}
+	resp := &pb.PutResponse{}
+	if prev := s.put(rev, r.Key, value, metadata, leaseID); r.PrevKv {
+		resp.PrevKv = prev
+	}
+	return resp
+}
+
+func (s *store) deleteRangeRequest(rev int64, r *pb.DeleteRangeRequest) *pb.DeleteRangeResponse {
+	kvs := s.deleteRange(rev, r.Key, r.RangeEnd)
+	resp := &pb.DeleteRangeResponse{Deleted: int64(len(kvs))}
+	if r.PrevKv {
+		resp.PrevKvs = kvs
+	}
+	return resp
+}
+
+// txn evaluates all the compares of r against the current keyspace, checks
+// the operations of the selected branches and then applies them at a single
+// revision.
+func (s *store) txn(r *pb.TxnRequest) (*pb.TxnResponse, error) {
+	var path []bool
+	if err := s.txnPath(r, &path); err != nil {
+		return nil, err
+	}
+	var (
+		resp *pb.TxnResponse
+		err  error
+	)
+	s.write(func(rev int64) { resp, err = s.applyTxn(rev, r, &path) })
+	if err != nil {
+		return nil, err
+	}
+	setTxnHeader(resp, s.header())
+	return resp, nil
+}
+
+func (s *store) txnPath(r *pb.TxnRequest, path *[]bool) error {
+	succeeded := true
+	for _, c := range r.Compare {
+		if !s.compare(c) {
+			succeeded = false
+			break
+		}
+	}
+	*path = append(*path, succeeded)
+	ops := r.Success
+	if !succeeded {
+		ops = r.Failure
+	}
+	for _, op := range ops {
+		switch req := op.Request.(type) {
+		case *pb.RequestOp_RequestRange:
+			rr := req.RequestRange
+			switch {
+			case len(rr.Key) == 0:
+				return rpctypes.ErrGRPCEmptyKey
+			case rr.Revision > s.rev:
+				return rpctypes.ErrGRPCFutureRev
+			case rr.Revision > 0 && rr.Revision < s.compactRev:
+				return rpctypes.ErrGRPCCompacted
+			}
+		case *pb.RequestOp_RequestPut:
+			if err := s.checkPut(req.RequestPut); err != nil {
+				return err
+			}
+		case *pb.RequestOp_RequestDeleteRange:
+			if len(req.RequestDeleteRange.Key) == 0 {
+				return rpctypes.ErrGRPCEmptyKey
+			}
+		case *pb.RequestOp_RequestTxn:
+			if err := s.txnPath(req.RequestTxn, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *store) applyTxn(rev int64, r *pb.TxnRequest, path *[]bool) (*pb.TxnResponse, error) {
+	resp := &pb.TxnResponse{Succeeded: (*path)[0]}
+	*path = (*path)[1:]
+	ops := r.Success
+	if !resp.Succeeded {
+		ops = r.Failure
+	}
+	for _, op := range ops {
+		var rop pb.ResponseOp
+		switch req := op.Request.(type) {
+		case *pb.RequestOp_RequestRange:
+			rr, err := s.rangeRequest(req.RequestRange)
+			if err != nil {
+				return nil, err
+			}
+			rop.Response = &pb.ResponseOp_ResponseRange{ResponseRange: rr}
+		case *pb.RequestOp_RequestPut:
+			rop.Response = &pb.ResponseOp_ResponsePut{ResponsePut: s.putRequest(rev, req.RequestPut)}
+		case *pb.RequestOp_RequestDeleteRange:
+			rop.Response = &pb.ResponseOp_ResponseDeleteRange{ResponseDeleteRange: s.deleteRangeRequest(rev, req.RequestDeleteRange)}
+		case *pb.RequestOp_RequestTxn:
+			tr, err := s.applyTxn(rev, req.RequestTxn, path)
+			if err != nil {
+				return nil, err
+			}
+			rop.Response = &pb.ResponseOp_ResponseTxn{ResponseTxn: tr}
+		}
+		resp.Responses = append(resp.Responses, &rop)
+	}
+	return resp, nil
+}
+
+func setTxnHeader(resp *pb.TxnResponse, h *pb.ResponseHeader) {
+	resp.Header = h
+	for _, rop := range resp.Responses {
+		switch op := rop.Response.(type) {
+		case *pb.ResponseOp_ResponseRange:
+			op.ResponseRange.Header = h
+		case *pb.ResponseOp_ResponsePut:
+			op.ResponsePut.Header = h
+		case *pb.ResponseOp_ResponseDeleteRange:
+			op.ResponseDeleteRange.Header = h
+		case *pb.ResponseOp_ResponseTxn:
+			setTxnHeader(op.ResponseTxn, h)
+		}
+	}
+}
+
+func (s *store) compare(c *pb.Compare) bool {
+	kvs, _ := s.rangeAt(c.Key, c.RangeEnd, 0)
+	for _, iv := range c.Intervals {
+		ikvs, _ := s.rangeAt(iv.Key, iv.RangeEnd, 0)
+		kvs = append(kvs, ikvs...)
+	}
+	if len(kvs) == 0 {
+		if c.Target == pb.Compare_VALUE {
+			// a missing key has no value to compare
+			return false
+		}
+		return compareKV(c, &mvccpb.KeyValue{})
+	}
+	for _, kv := range kvs {
+		if !compareKV(c, kv) {
+			return false
+		}
+	}
+	return true
+}
+
+func compareKV(c *pb.Compare, kv *mvccpb.KeyValue) bool {
+	var result int
+	switch c.Target {
+	case pb.Compare_VALUE:
+		result = bytes.Compare(kv.Value, c.GetValue())
+	case pb.Compare_VERSION:
+		result = compareInt64(kv.Version, c.GetVersion())
+	case pb.Compare_CREATE:
+		result = compareInt64(kv.CreateRevision, c.GetCreateRevision())
+	case pb.Compare_MOD:
+		result = compareInt64(kv.ModRevision, c.GetModRevision())
+	case pb.Compare_LEASE:
+		result = compareInt64(kv.Lease, c.GetLease())
+	}
+	switch c.Result {
+	case pb.Compare_EQUAL:
+		return result == 0
+	case pb.Compare_NOT_EQUAL:
+		return result != 0
+	case pb.Compare_GREATER:
+		return result > 0
+	case pb.Compare_LESS:
+		return result < 0
+	}
+	return false
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
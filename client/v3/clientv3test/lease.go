@@ -0,0 +1,177 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3test
+
+import (
+	"context"
+	"io"
+	"sort"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+type lease struct {
+	id  int64
+	ttl int64
+	// expiry is the time on the fake clock at which the lease expires.
+	expiry time.Duration
+	keys   map[string]struct{}
+}
+
+type leaseServer struct {
+	pb.UnimplementedLeaseServer
+	s *store
+}
+
+func (ls *leaseServer) LeaseGrant(ctx context.Context, r *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
+	ls.s.mu.Lock()
+	defer ls.s.mu.Unlock()
+	id := r.ID
+	if id == 0 {
+		for ls.s.leases[ls.s.nextLeaseID] != nil {
+			ls.s.nextLeaseID++
+		}
+		id = ls.s.nextLeaseID
+		ls.s.nextLeaseID++
+	}
+	if _, ok := ls.s.leases[id]; ok {
+		return nil, rpctypes.ErrGRPCLeaseExist
+	}
+	l := &lease{id: id, ttl: r.TTL, keys: make(map[string]struct{})}
+	l.refresh(ls.s.elapsed)
+	ls.s.leases[id] = l
+	return &pb.LeaseGrantResponse{Header: ls.s.header(), ID: id, TTL: r.TTL}, nil
+}
+
+func (ls *leaseServer) LeaseRevoke(ctx context.Context, r *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse, error) {
+	ls.s.mu.Lock()
+	defer ls.s.mu.Unlock()
+	if _, ok := ls.s.leases[r.ID]; !ok {
+		return nil, rpctypes.ErrGRPCLeaseNotFound
+	}
+	ls.s.revoke(r.ID)
+	return &pb.LeaseRevokeResponse{Header: ls.s.header()}, nil
+}
+
+func (ls *leaseServer) LeaseKeepAlive(stream pb.Lease_LeaseKeepAliveServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ls.s.mu.Lock()
+		resp := &pb.LeaseKeepAliveResponse{Header: ls.s.header(), ID: req.ID}
+		if l, ok := ls.s.leases[req.ID]; ok {
+			l.refresh(ls.s.elapsed)
+			resp.TTL = l.ttl
+		}
+		ls.s.mu.Unlock()
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (ls *leaseServer) LeaseTimeToLive(ctx context.Context, r *pb.LeaseTimeToLiveRequest) (*pb.LeaseTimeToLiveResponse, error) {
+	ls.s.mu.Lock()
+	defer ls.s.mu.Unlock()
+	l, ok := ls.s.leases[r.ID]
+	if !ok {
+		return &pb.LeaseTimeToLiveResponse{Header: ls.s.header(), ID: r.ID, TTL: -1}, nil
+	}
+	resp := &pb.LeaseTimeToLiveResponse{
+		Header:     ls.s.header(),
+		ID:         l.id,
+		TTL:        int64((l.expiry - ls.s.elapsed) / time.Second),
+		GrantedTTL: l.ttl,
+	}
+	if r.Keys {
+		for k := range l.keys {
+			resp.Keys = append(resp.Keys, []byte(k))
+		}
+		sort.Slice(resp.Keys, func(i, j int) bool { return string(resp.Keys[i]) < string(resp.Keys[j]) })
+	}
+	return resp, nil
+}
+
+func (ls *leaseServer) LeaseLeases(ctx context.Context, r *pb.LeaseLeasesRequest) (*pb.LeaseLeasesResponse, error) {
+	ls.s.mu.Lock()
+	defer ls.s.mu.Unlock()
+	resp := &pb.LeaseLeasesResponse{Header: ls.s.header()}
+	for id := range ls.s.leases {
+		resp.Leases = append(resp.Leases, &pb.LeaseStatus{ID: id})
+	}
+	sort.Slice(resp.Leases, func(i, j int) bool { return resp.Leases[i].ID < resp.Leases[j].ID })
+	return resp, nil
+}
+
+func (ls *leaseServer) LeaseTop(ctx context.Context, r *pb.LeaseTopRequest) (*pb.LeaseTopResponse, error) {
+	ls.s.mu.Lock()
+	defer ls.s.mu.Unlock()
+	resp := &pb.LeaseTopResponse{Header: ls.s.header()}
+	for id, l := range ls.s.leases {
+		resp.Leases = append(resp.Leases, &pb.LeaseTopStatus{ID: id, GrantedTTL: l.ttl, KeyCount: int64(len(l.keys))})
+	}
+	sort.Slice(resp.Leases, func(i, j int) bool {
+		if resp.Leases[i].KeyCount != resp.Leases[j].KeyCount {
+			return resp.Leases[i].KeyCount > resp.Leases[j].KeyCount
+		}
+		return resp.Leases[i].ID < resp.Leases[j].ID
+	})
+	if r.Limit > 0 && int64(len(resp.Leases)) > r.Limit {
+		resp.Leases = resp.Leases[:r.Limit]
+	}
+	return resp, nil
+}
+
+func (l *lease) refresh(now time.Duration) {
+	l.expiry = now + time.Duration(l.ttl)*time.Second
+}
+
+// revoke removes the lease and deletes its keys at a single revision.
+func (s *store) revoke(id int64) {
+	l := s.leases[id]
+	delete(s.leases, id)
+	keys := make([]string, 0, len(l.keys))
+	for k := range l.keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s.write(func(rev int64) {
+		for _, k := range keys {
+			s.deleteRange(rev, []byte(k), nil)
+		}
+	})
+}
+
+// advance moves the fake clock forward by d and revokes the expired leases.
+func (s *store) advance(d time.Duration) {
+	s.elapsed += d
+	var expired []int64
+	for id, l := range s.leases {
+		if l.expiry <= s.elapsed {
+			expired = append(expired, id)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i] < expired[j] })
+	for _, id := range expired {
+		s.revoke(id)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"google.golang.org/grpc"
+)
+
+// Server is an in-memory fake etcd server serving the KV, Watch and Lease
+// APIs over gRPC on a local port.
+type Server struct {
+	s    *store
+	ln   net.Listener
+	grpc *grpc.Server
+}
+
+// NewServer starts a fake server with an empty keyspace at revision 1.
+func NewServer() (*Server, error) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen %v", err)
+	}
+	srv := &Server{s: newStore(), ln: ln, grpc: grpc.NewServer()}
+	pb.RegisterKVServer(srv.grpc, &kvServer{s: srv.s})
+	pb.RegisterWatchServer(srv.grpc, &watchServer{s: srv.s})
+	pb.RegisterLeaseServer(srv.grpc, &leaseServer{s: srv.s})
+	go srv.grpc.Serve(ln)
+	return srv, nil
+}
+
+// New starts a fake server and returns it with a client connected to it.
+// Both are closed when the test completes.
+func New(tb testing.TB) (*Server, *clientv3.Client) {
+	tb.Helper()
+	srv, err := NewServer()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(srv.Close)
+	cli, err := srv.NewClient(clientv3.Config{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { cli.Close() })
+	return srv, cli
+}
+
+// Endpoint returns the address clients connect to.
+func (srv *Server) Endpoint() string {
+	return srv.ln.Addr().String()
+}
+
+// NewClient creates a client connected to the server with the given
+// configuration. The endpoints of cfg are ignored.
+func (srv *Server) NewClient(cfg clientv3.Config) (*clientv3.Client, error) {
+	cfg.Endpoints = []string{srv.Endpoint()}
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+	return clientv3.New(cfg)
+}
+
+// Advance moves the fake clock forward by d. The leases that were not kept
+// alive during that time expire, and their keys are deleted.
+func (srv *Server) Advance(d time.Duration) {
+	srv.s.mu.Lock()
+	defer srv.s.mu.Unlock()
+	srv.s.advance(d)
+}
+
+// Revision returns the current revision of the keyspace.
+func (srv *Server) Revision() int64 {
+	srv.s.mu.Lock()
+	defer srv.s.mu.Unlock()
+	return srv.s.rev
+}
+
+// CompactRevision returns the revision of the last compaction, or 0.
+func (srv *Server) CompactRevision() int64 {
+	srv.s.mu.Lock()
+	defer srv.s.mu.Unlock()
+	return srv.s.compactRev
+}
+
+// Close stops the server and closes all the client connections.
+func (srv *Server) Close() {
+	srv.grpc.Stop()
+}
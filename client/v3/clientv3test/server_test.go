@@ -0,0 +1,216 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestKV(t *testing.T) {
+	srv, cli := New(t)
+	ctx := context.Background()
+
+	_, err := cli.Put(ctx, "foo", "bar")
+	require.NoError(t, err)
+	_, err = cli.Put(ctx, "foo", "baz")
+	require.NoError(t, err)
+	_, err = cli.Put(ctx, "fop", "qux")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), srv.Revision())
+
+	resp, err := cli.Get(ctx, "fo", clientv3.WithPrefix())
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 2)
+	assert.Equal(t, int64(4), resp.Header.Revision)
+	assert.Equal(t, "baz", string(resp.Kvs[0].Value))
+	assert.Equal(t, int64(2), resp.Kvs[0].CreateRevision)
+	assert.Equal(t, int64(3), resp.Kvs[0].ModRevision)
+	assert.Equal(t, int64(2), resp.Kvs[0].Version)
+
+	resp, err = cli.Get(ctx, "fo", clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByModRevision, clientv3.SortDescend), clientv3.WithLimit(1))
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "fop", string(resp.Kvs[0].Key))
+	assert.True(t, resp.More)
+	assert.Equal(t, int64(2), resp.Count)
+
+	resp, err = cli.Get(ctx, "foo", clientv3.WithRev(2))
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "bar", string(resp.Kvs[0].Value))
+
+	dresp, err := cli.Delete(ctx, "fo", clientv3.WithPrefix(), clientv3.WithPrevKV())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), dresp.Deleted)
+	assert.Len(t, dresp.PrevKvs, 2)
+	assert.Equal(t, int64(5), dresp.Header.Revision)
+
+	_, err = cli.Get(ctx, "foo", clientv3.WithRev(6))
+	assert.Equal(t, rpctypes.ErrFutureRev, err)
+}
+
+func TestCompact(t *testing.T) {
+	_, cli := New(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := cli.Put(ctx, "foo", "bar")
+		require.NoError(t, err)
+	}
+	_, err := cli.Compact(ctx, 3)
+	require.NoError(t, err)
+	_, err = cli.Compact(ctx, 3)
+	assert.Equal(t, rpctypes.ErrCompacted, err)
+
+	_, err = cli.Get(ctx, "foo", clientv3.WithRev(2))
+	assert.Equal(t, rpctypes.ErrCompacted, err)
+	resp, err := cli.Get(ctx, "foo", clientv3.WithRev(3))
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, int64(3), resp.Kvs[0].ModRevision)
+}
+
+func TestTxn(t *testing.T) {
+	_, cli := New(t)
+	ctx := context.Background()
+
+	_, err := cli.Put(ctx, "foo", "bar")
+	require.NoError(t, err)
+
+	resp, err := cli.Txn(ctx).
+		If(clientv3.Compare(clientv3.Value("foo"), "=", "bar"), clientv3.Compare(clientv3.Version("missing"), "=", 0)).
+		Then(clientv3.OpPut("foo", "baz"), clientv3.OpPut("missing", "x"), clientv3.OpGet("foo")).
+		Else(clientv3.OpDelete("foo")).
+		Commit()
+	require.NoError(t, err)
+	assert.True(t, resp.Succeeded)
+	assert.Equal(t, int64(3), resp.Header.Revision)
+	get := resp.Responses[2].GetResponseRange()
+	require.Len(t, get.Kvs, 1)
+	assert.Equal(t, "baz", string(get.Kvs[0].Value))
+	assert.Equal(t, int64(3), get.Kvs[0].ModRevision)
+
+	resp, err = cli.Txn(ctx).
+		If(clientv3.Compare(clientv3.Value("foo"), "=", "bar")).
+		Then(clientv3.OpDelete("foo")).
+		Else(clientv3.OpGet("foo")).
+		Commit()
+	require.NoError(t, err)
+	assert.False(t, resp.Succeeded)
+	assert.Equal(t, int64(3), resp.Header.Revision)
+
+	_, err = cli.Txn(ctx).Then(clientv3.OpPut("foo", "bar"), clientv3.OpPut("bar", "x", clientv3.WithLease(100))).Commit()
+	assert.Equal(t, rpctypes.ErrLeaseNotFound, err)
+	get2, err := cli.Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "baz", string(get2.Kvs[0].Value))
+}
+
+func TestWatch(t *testing.T) {
+	_, cli := New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := cli.Put(ctx, "foo", "bar")
+	require.NoError(t, err)
+
+	wch := cli.Watch(ctx, "foo", clientv3.WithPrefix(), clientv3.WithRev(1), clientv3.WithPrevKV())
+	_, err = cli.Put(ctx, "foo", "baz")
+	require.NoError(t, err)
+	_, err = cli.Delete(ctx, "foo")
+	require.NoError(t, err)
+
+	var evs []*clientv3.Event
+	for len(evs) < 3 {
+		wresp := <-wch
+		require.NoError(t, wresp.Err())
+		evs = append(evs, wresp.Events...)
+	}
+	assert.Equal(t, mvccpb.PUT, evs[0].Type)
+	assert.Nil(t, evs[0].PrevKv)
+	assert.Equal(t, "baz", string(evs[1].Kv.Value))
+	assert.Equal(t, "bar", string(evs[1].PrevKv.Value))
+	assert.Equal(t, mvccpb.DELETE, evs[2].Type)
+	assert.Equal(t, int64(4), evs[2].Kv.ModRevision)
+}
+
+func TestWatchCompacted(t *testing.T) {
+	_, cli := New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		_, err := cli.Put(ctx, "foo", "bar")
+		require.NoError(t, err)
+	}
+	_, err := cli.Compact(ctx, 3)
+	require.NoError(t, err)
+
+	wresp := <-cli.Watch(ctx, "foo", clientv3.WithRev(2))
+	assert.Equal(t, rpctypes.ErrCompacted, wresp.Err())
+	assert.Equal(t, int64(3), wresp.CompactRevision)
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	srv, cli := New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lresp, err := cli.Grant(ctx, 10)
+	require.NoError(t, err)
+	_, err = cli.Put(ctx, "foo", "bar", clientv3.WithLease(lresp.ID))
+	require.NoError(t, err)
+	_, err = cli.Put(ctx, "bar", "foo", clientv3.WithLease(lresp.ID))
+	require.NoError(t, err)
+
+	srv.Advance(4 * time.Second)
+	ttl, err := cli.TimeToLive(ctx, lresp.ID, clientv3.WithAttachedKeys())
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), ttl.TTL)
+	assert.Equal(t, int64(10), ttl.GrantedTTL)
+	assert.Len(t, ttl.Keys, 2)
+
+	_, err = cli.KeepAliveOnce(ctx, lresp.ID)
+	require.NoError(t, err)
+	srv.Advance(9 * time.Second)
+	resp, err := cli.Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Len(t, resp.Kvs, 1)
+
+	wch := cli.Watch(ctx, "", clientv3.WithPrefix(), clientv3.WithRev(srv.Revision()+1))
+	srv.Advance(time.Second)
+	wresp := <-wch
+	require.NoError(t, wresp.Err())
+	require.Len(t, wresp.Events, 2)
+	assert.Equal(t, wresp.Events[0].Kv.ModRevision, wresp.Events[1].Kv.ModRevision)
+
+	resp, err = cli.Get(ctx, "", clientv3.WithPrefix())
+	require.NoError(t, err)
+	assert.Empty(t, resp.Kvs)
+	ttl, err = cli.TimeToLive(ctx, lresp.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), ttl.TTL)
+	_, err = cli.Revoke(ctx, lresp.ID)
+	assert.Equal(t, rpctypes.ErrLeaseNotFound, err)
+}
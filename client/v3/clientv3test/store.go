@@ -0,0 +1,178 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3test
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+const (
+	clusterID = 0x1000
+	memberID  = 0x2000
+)
+
+// store is an in-memory multi-version key-value store.
+type store struct {
+	mu sync.Mutex
+
+	rev        int64
+	compactRev int64
+	kvs        map[string]*mvccpb.KeyValue
+	// history holds the events with a revision not lower than compactRev.
+	history []*mvccpb.Event
+
+	leases      map[int64]*lease
+	nextLeaseID int64
+	// elapsed is the time on the fake clock driving lease expiry.
+	elapsed time.Duration
+
+	watchers map[*watcher]struct{}
+}
+
+func newStore() *store {
+	return &store{
+		rev:         1,
+		kvs:         make(map[string]*mvccpb.KeyValue),
+		leases:      make(map[int64]*lease),
+		nextLeaseID: 1,
+		watchers:    make(map[*watcher]struct{}),
+	}
+}
+
+func (s *store) header() *pb.ResponseHeader {
+	return &pb.ResponseHeader{ClusterId: clusterID, MemberId: memberID, Revision: s.rev, RaftTerm: 1}
+}
+
+// inRange returns true if k is in the range [key, end) as interpreted by etcd:
+// an empty end selects key only and "\x00" selects every key from key.
+func inRange(k, key, end []byte) bool {
+	switch {
+	case len(end) == 0:
+		return bytes.Equal(k, key)
+	case len(end) == 1 && end[0] == 0:
+		return bytes.Compare(k, key) >= 0
+	default:
+		return bytes.Compare(k, key) >= 0 && bytes.Compare(k, end) < 0
+	}
+}
+
+// rangeAt returns the key-values in the range [key, end) at revision rev,
+// sorted by key. A zero rev reads the latest revision.
+func (s *store) rangeAt(key, end []byte, rev int64) ([]*mvccpb.KeyValue, error) {
+	if rev > s.rev {
+		return nil, rpctypes.ErrGRPCFutureRev
+	}
+	if rev > 0 && rev < s.compactRev {
+		return nil, rpctypes.ErrGRPCCompacted
+	}
+	kvs := make(map[string]*mvccpb.KeyValue)
+	for k, kv := range s.kvs {
+		if inRange(kv.Key, key, end) {
+			kvs[k] = kv
+		}
+	}
+	if rev > 0 {
+		// undo the events that happened after rev
+		for i := len(s.history) - 1; i >= 0 && s.history[i].Kv.ModRevision > rev; i-- {
+			ev := s.history[i]
+			if !inRange(ev.Kv.Key, key, end) {
+				continue
+			}
+			if ev.PrevKv == nil {
+				delete(kvs, string(ev.Kv.Key))
+			} else {
+				kvs[string(ev.Kv.Key)] = ev.PrevKv
+			}
+		}
+	}
+	result := make([]*mvccpb.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		result = append(result, kv)
+	}
+	sort.Slice(result, func(i, j int) bool { return bytes.Compare(result[i].Key, result[j].Key) < 0 })
+	return result, nil
+}
+
+// write runs f with the revision of a new write and commits that revision
+// if f changed the keyspace.
+func (s *store) write(f func(rev int64)) {
+	n := len(s.history)
+	rev := s.rev + 1
+	f(rev)
+	if evs := s.history[n:]; len(evs) > 0 {
+		s.rev = rev
+		s.notify(evs)
+	}
+}
+
+// put sets key to value at revision rev and returns the previous key-value, if any.
+func (s *store) put(rev int64, key, value, metadata []byte, leaseID int64) *mvccpb.KeyValue {
+	prev := s.kvs[string(key)]
+	kv := &mvccpb.KeyValue{Key: key, Value: value, Metadata: metadata, CreateRevision: rev, ModRevision: rev, Version: 1, Lease: leaseID}
+	if prev != nil {
+		kv.CreateRevision = prev.CreateRevision
+		kv.Version = prev.Version + 1
+		s.detach(prev)
+	}
+	s.attach(kv)
+	s.kvs[string(key)] = kv
+	s.history = append(s.history, &mvccpb.Event{Type: mvccpb.PUT, Kv: kv, PrevKv: prev})
+	return prev
+}
+
+// deleteRange deletes the keys in the range [key, end) at revision rev and
+// returns the deleted key-values.
+func (s *store) deleteRange(rev int64, key, end []byte) []*mvccpb.KeyValue {
+	kvs, _ := s.rangeAt(key, end, 0)
+	for _, kv := range kvs {
+		delete(s.kvs, string(kv.Key))
+		s.detach(kv)
+		tombstone := &mvccpb.KeyValue{Key: kv.Key, ModRevision: rev}
+		s.history = append(s.history, &mvccpb.Event{Type: mvccpb.DELETE, Kv: tombstone, PrevKv: kv})
+	}
+	return kvs
+}
+
+func (s *store) compact(rev int64) error {
+	if rev > s.rev {
+		return rpctypes.ErrGRPCFutureRev
+	}
+	if rev <= s.compactRev {
+		return rpctypes.ErrGRPCCompacted
+	}
+	s.compactRev = rev
+	i := sort.Search(len(s.history), func(i int) bool { return s.history[i].Kv.ModRevision >= rev })
+	s.history = append([]*mvccpb.Event(nil), s.history[i:]...)
+	return nil
+}
+
+func (s *store) attach(kv *mvccpb.KeyValue) {
+	if l, ok := s.leases[kv.Lease]; ok {
+		l.keys[string(kv.Key)] = struct{}{}
+	}
+}
+
+func (s *store) detach(kv *mvccpb.KeyValue) {
+	if l, ok := s.leases[kv.Lease]; ok {
+		delete(l.keys, string(kv.Key))
+	}
+}
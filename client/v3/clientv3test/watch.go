@@ -0,0 +1,193 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3test
+
+import (
+	"io"
+	"sort"
+	"sync"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+type watchServer struct {
+	pb.UnimplementedWatchServer
+	s *store
+}
+
+type watcher struct {
+	id       int64
+	key, end []byte
+	prevKV   bool
+	noPut    bool
+	noDelete bool
+	ws       *watchStream
+}
+
+// watchStream queues the responses of a watch stream, so the store never
+// blocks on a slow receiver.
+type watchStream struct {
+	mu    sync.Mutex
+	queue []*pb.WatchResponse
+	ready chan struct{}
+
+	// watchers and nextID are guarded by the store mutex.
+	watchers map[int64]*watcher
+	nextID   int64
+}
+
+func (ws *watchStream) send(resp *pb.WatchResponse) {
+	ws.mu.Lock()
+	ws.queue = append(ws.queue, resp)
+	ws.mu.Unlock()
+	select {
+	case ws.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (ws *watchStream) sendLoop(stream pb.Watch_WatchServer) {
+	// seq numbers the responses like etcd does
+	var seq uint64
+	for {
+		select {
+		case <-ws.ready:
+		case <-stream.Context().Done():
+			return
+		}
+		ws.mu.Lock()
+		queue := ws.queue
+		ws.queue = nil
+		ws.mu.Unlock()
+		for _, resp := range queue {
+			seq++
+			resp.Seq = seq
+			if err := stream.Send(resp); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (wsrv *watchServer) Watch(stream pb.Watch_WatchServer) error {
+	ws := &watchStream{ready: make(chan struct{}, 1), watchers: make(map[int64]*watcher)}
+	defer wsrv.s.closeWatchStream(ws)
+	go ws.sendLoop(stream)
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch uv := req.RequestUnion.(type) {
+		case *pb.WatchRequest_CreateRequest:
+			wsrv.s.watch(ws, uv.CreateRequest)
+		case *pb.WatchRequest_CancelRequest:
+			wsrv.s.cancelWatch(ws, uv.CancelRequest.WatchId)
+		case *pb.WatchRequest_ProgressRequest:
+			wsrv.s.mu.Lock()
+			ws.send(&pb.WatchResponse{Header: wsrv.s.header(), WatchId: -1})
+			wsrv.s.mu.Unlock()
+		}
+	}
+}
+
+func (s *store) watch(ws *watchStream, r *pb.WatchCreateRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := r.WatchId
+	if id == 0 {
+		for ws.watchers[ws.nextID] != nil {
+			ws.nextID++
+		}
+		id = ws.nextID
+		ws.nextID++
+	} else if ws.watchers[id] != nil {
+		ws.send(&pb.WatchResponse{Header: s.header(), WatchId: id, Created: true, Canceled: true, CancelReason: "duplicate watch ID"})
+		return
+	}
+	w := &watcher{id: id, key: r.Key, end: r.RangeEnd, prevKV: r.PrevKv, ws: ws}
+	for _, f := range r.Filters {
+		switch f {
+		case pb.WatchCreateRequest_NOPUT:
+			w.noPut = true
+		case pb.WatchCreateRequest_NODELETE:
+			w.noDelete = true
+		}
+	}
+	ws.send(&pb.WatchResponse{Header: s.header(), WatchId: id, Created: true})
+	if r.StartRevision != 0 && r.StartRevision < s.compactRev {
+		ws.send(&pb.WatchResponse{Header: s.header(), WatchId: id, CompactRevision: s.compactRev, Canceled: true})
+		return
+	}
+	if r.StartRevision != 0 {
+		i := sort.Search(len(s.history), func(i int) bool { return s.history[i].Kv.ModRevision >= r.StartRevision })
+		if evs := w.filter(s.history[i:]); len(evs) > 0 {
+			ws.send(&pb.WatchResponse{Header: s.header(), WatchId: id, Events: evs})
+		}
+	}
+	ws.watchers[id] = w
+	s.watchers[w] = struct{}{}
+}
+
+func (s *store) cancelWatch(ws *watchStream, id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := ws.watchers[id]
+	if !ok {
+		return
+	}
+	delete(ws.watchers, id)
+	delete(s.watchers, w)
+	ws.send(&pb.WatchResponse{Header: s.header(), WatchId: id, Canceled: true})
+}
+
+func (s *store) closeWatchStream(ws *watchStream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range ws.watchers {
+		delete(s.watchers, w)
+	}
+}
+
+// notify sends the events of a committed write to the matching watchers.
+func (s *store) notify(evs []*mvccpb.Event) {
+	for w := range s.watchers {
+		if wevs := w.filter(evs); len(wevs) > 0 {
+			w.ws.send(&pb.WatchResponse{Header: s.header(), WatchId: w.id, Events: wevs})
+		}
+	}
+}
+
+// filter returns the events selected by the watcher, without their previous
+// key-values unless requested.
+func (w *watcher) filter(evs []*mvccpb.Event) []*mvccpb.Event {
+	var result []*mvccpb.Event
+	for _, ev := range evs {
+		if !inRange(ev.Kv.Key, w.key, w.end) ||
+			w.noPut && ev.Type == mvccpb.PUT ||
+			w.noDelete && ev.Type == mvccpb.DELETE {
+			continue
+		}
+		if !w.prevKV {
+			ev = &mvccpb.Event{Type: ev.Type, Kv: ev.Kv}
+		}
+		result = append(result, ev)
+	}
+	return result
+}
@@ -119,6 +119,27 @@ func (cmp Cmp) WithPrefix() Cmp {
 	return cmp
 }
 
+// WithInterval sets the comparison to also scan the range [key, end), or
+// the key alone if end is empty, along with the other keys of the comparison
+// and at the same revision.
+func (cmp Cmp) WithInterval(key, end string) Cmp {
+	ivs := make([]*pb.KeyInterval, len(cmp.Intervals), len(cmp.Intervals)+1)
+	copy(ivs, cmp.Intervals)
+	var rangeEnd []byte
+	if end != "" {
+		rangeEnd = []byte(end)
+	}
+	cmp.Intervals = append(ivs, &pb.KeyInterval{Key: []byte(key), RangeEnd: rangeEnd})
+	return cmp
+}
+
+// WithPrefixInterval sets the comparison to also scan all keys prefixed by
+// prefix, along with the other keys of the comparison and at the same
+// revision.
+func (cmp Cmp) WithPrefixInterval(prefix string) Cmp {
+	return cmp.WithInterval(prefix, string(getPrefix([]byte(prefix))))
+}
+
 // mustInt64 panics if val isn't an int or int64. It returns an int64 otherwise.
 func mustInt64(val interface{}) int64 {
 	if v, ok := val.(int64); ok {
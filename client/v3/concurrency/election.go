@@ -38,11 +38,40 @@ type Election struct {
 	leaderRev     int64
 	leaderSession *Session
 	hdr           *pb.ResponseHeader
+	// val is the value of the leader, campaigned again on reacquisition.
+	val string
+
+	reacquirer
+}
+
+// ElectionOption configures an Election.
+type ElectionOption func(*Election)
+
+// WithElectionReacquire makes an elected leader campaign again in a new
+// session, with the TTL and context of its session and its last value,
+// whenever its session ends other than by being closed, such as when its
+// lease is revoked or expires. invalidated is called with the creation
+// revision of the lost leader key, which serves as its fencing token, and
+// why its session ended, before campaigning again in the background. The
+// leadership is held again once Rev returns a new revision.
+//
+// Resign stops the reacquisition of the leadership and closes the session
+// the election created, and Campaign campaigns in a new session if the
+// session of the election ended. The methods of the election may be called
+// concurrently with the reacquisition.
+func WithElectionReacquire(invalidated func(rev int64, reason error)) ElectionOption {
+	return func(e *Election) {
+		e.invalidated = invalidated
+	}
 }
 
 // NewElection returns a new election on a given key prefix.
-func NewElection(s *Session, pfx string) *Election {
-	return &Election{session: s, keyPrefix: pfx + "/"}
+func NewElection(s *Session, pfx string, opts ...ElectionOption) *Election {
+	e := &Election{session: s, keyPrefix: pfx + "/"}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 // ResumeElection initializes an election with a known leader.
@@ -67,6 +96,21 @@ func ResumeElection(s *Session, pfx string, leaderKey string, leaderRev int64) *
 // Otherwise, until the context is not cancelled or timed-out, Campaign will
 // continue to be blocked until it becomes the leader.
 func (e *Election) Campaign(ctx context.Context, val string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s, err := e.renewSession(ctx, e.session)
+	if err != nil {
+		return err
+	}
+	e.session = s
+	if err = e.campaign(ctx, val); err != nil {
+		return err
+	}
+	e.startReacquire()
+	return nil
+}
+
+func (e *Election) campaign(ctx context.Context, val string) error {
 	s := e.session
 	client := e.session.Client()
 
@@ -79,12 +123,13 @@ func (e *Election) Campaign(ctx context.Context, val string) error {
 		return err
 	}
 	e.leaderKey, e.leaderRev, e.leaderSession = k, resp.Header.Revision, s
+	e.val = val
 	if !resp.Succeeded {
 		kv := resp.Responses[0].GetResponseRange().Kvs[0]
 		e.leaderRev = kv.CreateRevision
 		if string(kv.Value) != val {
-			if err = e.Proclaim(ctx, val); err != nil {
-				e.Resign(ctx)
+			if err = e.proclaim(ctx, val); err != nil {
+				e.resign(ctx)
 				return err
 			}
 		}
@@ -95,7 +140,7 @@ func (e *Election) Campaign(ctx context.Context, val string) error {
 		// clean up in case of context cancel
 		select {
 		case <-ctx.Done():
-			e.Resign(client.Ctx())
+			e.resign(client.Ctx())
 		default:
 			e.leaderSession = nil
 		}
@@ -106,8 +151,41 @@ func (e *Election) Campaign(ctx context.Context, val string) error {
 	return nil
 }
 
+// startReacquire campaigns again in a new session once the session of the
+// leader ends, in reacquire mode.
+func (e *Election) startReacquire() {
+	e.start(e.session, func() int64 {
+		rev := e.leaderRev
+		e.leaderKey, e.leaderSession = "", nil
+		return rev
+	}, func(ctx context.Context, s *Session) error {
+		e.mu.Lock()
+		val := e.val
+		e.mu.Unlock()
+		ne := &Election{session: s, keyPrefix: e.keyPrefix}
+		if err := ne.campaign(ctx, val); err != nil {
+			return err
+		}
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.session, e.owned = s, true
+		e.leaderKey, e.leaderRev, e.leaderSession, e.hdr = ne.leaderKey, ne.leaderRev, ne.leaderSession, ne.hdr
+		return nil
+	})
+}
+
 // Proclaim lets the leader announce a new value without another election.
 func (e *Election) Proclaim(ctx context.Context, val string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.proclaim(ctx, val); err != nil {
+		return err
+	}
+	e.val = val
+	return nil
+}
+
+func (e *Election) proclaim(ctx context.Context, val string) error {
 	if e.leaderSession == nil {
 		return ErrElectionNotLeader
 	}
@@ -130,6 +208,18 @@ func (e *Election) Proclaim(ctx context.Context, val string) error {
 
 // Resign lets a leader start a new election.
 func (e *Election) Resign(ctx context.Context) (err error) {
+	e.halt()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	err = e.resign(ctx)
+	if e.owned {
+		// the lease of the session is not needed until the next campaign
+		e.session.Close()
+	}
+	return err
+}
+
+func (e *Election) resign(ctx context.Context) (err error) {
 	if e.leaderSession == nil {
 		return nil
 	}
@@ -146,7 +236,7 @@ func (e *Election) Resign(ctx context.Context) (err error) {
 
 // Leader returns the leader value for the current election.
 func (e *Election) Leader(ctx context.Context) (*v3.GetResponse, error) {
-	client := e.session.Client()
+	client := e.client()
 	resp, err := client.Get(ctx, e.keyPrefix, v3.WithFirstCreate()...)
 	if err != nil {
 		return nil, err
@@ -171,7 +261,7 @@ func (e *Election) Observe(ctx context.Context) <-chan v3.GetResponse {
 }
 
 func (e *Election) observe(ctx context.Context, ch chan<- v3.GetResponse) {
-	client := e.session.Client()
+	client := e.client()
 
 	defer close(ch)
 	for {
@@ -244,11 +334,29 @@ func (e *Election) observe(ctx context.Context, ch chan<- v3.GetResponse) {
 	}
 }
 
+func (e *Election) client() *v3.Client {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.session.Client()
+}
+
 // Key returns the leader key if elected, empty string otherwise.
-func (e *Election) Key() string { return e.leaderKey }
+func (e *Election) Key() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leaderKey
+}
 
 // Rev returns the leader key's creation revision, if elected.
-func (e *Election) Rev() int64 { return e.leaderRev }
+func (e *Election) Rev() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leaderRev
+}
 
 // Header is the response header from the last successful election proposal.
-func (e *Election) Header() *pb.ResponseHeader { return e.hdr }
+func (e *Election) Header() *pb.ResponseHeader {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.hdr
+}
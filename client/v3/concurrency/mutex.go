@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	v3 "go.etcd.io/etcd/client/v3"
@@ -36,16 +37,56 @@ type Mutex struct {
 	myKey string
 	myRev int64
 	hdr   *pb.ResponseHeader
+
+	reacquirer
+}
+
+// MutexOption configures a Mutex.
+type MutexOption func(*Mutex)
+
+// WithMutexReacquire makes a locked mutex lock itself again in a new
+// session, with the TTL and context of its session, whenever its session
+// ends other than by being closed, such as when its lease is revoked or
+// expires. invalidated is called with the fencing token of the lost lock
+// and why its session ended, before the lock is reacquired in the
+// background, so that the resources guarded by the lock can reject the
+// lost token. The lock is held again once FencingToken returns a new token.
+//
+// Unlock stops the reacquisition of the lock and closes the session the
+// mutex created, and Lock and TryLock lock the mutex in a new session if its
+// session ended. The methods of the mutex may be called concurrently with
+// the reacquisition.
+func WithMutexReacquire(invalidated func(token int64, reason error)) MutexOption {
+	return func(m *Mutex) {
+		m.invalidated = invalidated
+	}
 }
 
-func NewMutex(s *Session, pfx string) *Mutex {
-	return &Mutex{s, pfx + "/", "", -1, nil}
+func NewMutex(s *Session, pfx string, opts ...MutexOption) *Mutex {
+	m := &Mutex{s: s, pfx: pfx + "/", myRev: -1}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // TryLock locks the mutex if not already locked by another session.
 // If lock is held by another session, return immediately after attempting necessary cleanup
 // The ctx argument is used for the sending/receiving Txn RPC.
 func (m *Mutex) TryLock(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.renew(ctx); err != nil {
+		return err
+	}
+	if err := m.tryLock(ctx); err != nil {
+		return err
+	}
+	m.startReacquire()
+	return nil
+}
+
+func (m *Mutex) tryLock(ctx context.Context) error {
 	resp, err := m.tryAcquire(ctx)
 	if err != nil {
 		return err
@@ -69,6 +110,19 @@ func (m *Mutex) TryLock(ctx context.Context) error {
 // Lock locks the mutex with a cancelable context. If the context is canceled
 // while trying to acquire the lock, the mutex tries to clean its stale lock entry.
 func (m *Mutex) Lock(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.renew(ctx); err != nil {
+		return err
+	}
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	m.startReacquire()
+	return nil
+}
+
+func (m *Mutex) lock(ctx context.Context) error {
 	resp, err := m.tryAcquire(ctx)
 	if err != nil {
 		return err
@@ -85,14 +139,14 @@ func (m *Mutex) Lock(ctx context.Context) error {
 	_, werr := waitDeletes(ctx, client, m.pfx, m.myRev-1)
 	// release lock key if wait failed
 	if werr != nil {
-		m.Unlock(client.Ctx())
+		m.unlock(client.Ctx())
 		return werr
 	}
 
 	// make sure the session is not expired, and the owner key still exists.
 	gresp, werr := client.Get(ctx, m.myKey)
 	if werr != nil {
-		m.Unlock(client.Ctx())
+		m.unlock(client.Ctx())
 		return werr
 	}
 
@@ -104,6 +158,57 @@ func (m *Mutex) Lock(ctx context.Context) error {
 	return nil
 }
 
+// TryLockUntil locks the mutex, waiting for the lock until deadline at most,
+// after which it returns ErrLocked.
+func (m *Mutex) TryLockUntil(ctx context.Context, deadline time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.renew(ctx); err != nil {
+		return err
+	}
+	dctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	if err := m.lock(dctx); err != nil {
+		if dctx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return ErrLocked
+		}
+		return err
+	}
+	m.startReacquire()
+	return nil
+}
+
+// renew locks the mutex in a new session from now on if its session ended,
+// in reacquire mode.
+func (m *Mutex) renew(ctx context.Context) error {
+	s, err := m.renewSession(ctx, m.s)
+	if err != nil {
+		return err
+	}
+	m.s = s
+	return nil
+}
+
+// startReacquire reacquires the lock in a new session once its session
+// ends, in reacquire mode.
+func (m *Mutex) startReacquire() {
+	m.start(m.s, func() int64 {
+		token := m.myRev
+		m.myKey, m.myRev, m.hdr = "\x00", -1, nil
+		return token
+	}, func(ctx context.Context, s *Session) error {
+		nm := &Mutex{s: s, pfx: m.pfx, myRev: -1}
+		if err := nm.lock(ctx); err != nil {
+			return err
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.s, m.owned = s, true
+		m.myKey, m.myRev, m.hdr = nm.myKey, nm.myRev, nm.hdr
+		return nil
+	})
+}
+
 func (m *Mutex) tryAcquire(ctx context.Context) (*v3.TxnResponse, error) {
 	s := m.s
 	client := m.s.Client()
@@ -128,6 +233,20 @@ func (m *Mutex) tryAcquire(ctx context.Context) (*v3.TxnResponse, error) {
 }
 
 func (m *Mutex) Unlock(ctx context.Context) error {
+	m.halt()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.unlock(ctx); err != nil {
+		return err
+	}
+	if m.owned {
+		// the lease of the session is not needed until the next lock
+		m.s.Close()
+	}
+	return nil
+}
+
+func (m *Mutex) unlock(ctx context.Context) error {
 	client := m.s.Client()
 	if _, err := client.Delete(ctx, m.myKey); err != nil {
 		return err
@@ -138,13 +257,32 @@ func (m *Mutex) Unlock(ctx context.Context) error {
 }
 
 func (m *Mutex) IsOwner() v3.Cmp {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return v3.Compare(v3.CreateRevision(m.myKey), "=", m.myRev)
 }
 
-func (m *Mutex) Key() string { return m.myKey }
+func (m *Mutex) Key() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.myKey
+}
+
+// FencingToken is the create revision of the key of the lock. It increases
+// with every acquisition of the lock, so resources outside etcd can reject
+// stale lock holders; IsOwner guards updates of etcd itself.
+func (m *Mutex) FencingToken() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.myRev
+}
 
 // Header is the response header received from etcd on acquiring the lock.
-func (m *Mutex) Header() *pb.ResponseHeader { return m.hdr }
+func (m *Mutex) Header() *pb.ResponseHeader {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hdr
+}
 
 type lockerMutex struct{ *Mutex }
 
@@ -0,0 +1,117 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// reacquireRetryInterval is the time waited between the attempts to
+// reacquire a lock or leadership lost with its session.
+var reacquireRetryInterval = time.Second
+
+// reacquirer reacquires a lock or leadership in a new session in the
+// background, once the session holding it ended other than by being closed.
+type reacquirer struct {
+	// mu guards the state of the holder of the lock or leadership against
+	// its reacquisition.
+	mu sync.Mutex
+	// invalidated is called with the fencing token of a lost lock or
+	// leadership and why its session ended, nil if it is not reacquired.
+	invalidated func(token int64, reason error)
+	// owned is true if the session of the holder was created to reacquire
+	// its lock or leadership.
+	owned bool
+	// stop stops the reacquisition, nil if it is not started.
+	stop func()
+}
+
+// renewSession returns a new session with the options of s if s ended, or
+// if s was created by the reacquirer and closed since, and s otherwise. It
+// is called with r.mu held.
+func (r *reacquirer) renewSession(ctx context.Context, s *Session) (*Session, error) {
+	if r.invalidated == nil || !s.ended() && !(r.owned && s.Err() != nil) {
+		return s, nil
+	}
+	ns, err := newSession(ctx, s.Client(), &sessionOptions{ttl: s.opts.ttl, ctx: s.opts.ctx})
+	if err != nil {
+		return nil, err
+	}
+	r.owned = true
+	return ns, nil
+}
+
+// start reacquires the lock or leadership held in s once s ends. lost is
+// called with r.mu held to forget the lock or leadership, and returns its
+// fencing token. acquire acquires it in a new session, and is called
+// without r.mu held until it succeeds. start is called with r.mu held.
+func (r *reacquirer) start(s *Session, lost func() int64, acquire func(context.Context, *Session) error) {
+	if r.invalidated == nil || r.stop != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	donec := make(chan struct{})
+	r.stop = func() {
+		cancel()
+		<-donec
+	}
+	go func() {
+		defer close(donec)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.Done():
+			}
+			if !s.ended() {
+				return
+			}
+			r.mu.Lock()
+			token := lost()
+			r.mu.Unlock()
+			r.invalidated(token, s.Err())
+
+			for {
+				ns, err := newSession(ctx, s.Client(), &sessionOptions{ttl: s.opts.ttl, ctx: s.opts.ctx})
+				if err == nil {
+					if err = acquire(ctx, ns); err == nil {
+						s = ns
+						break
+					}
+					ns.Close()
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(reacquireRetryInterval):
+				}
+			}
+		}
+	}()
+}
+
+// halt stops the reacquisition, if it is started, and waits for it to
+// return. It is called without r.mu held.
+func (r *reacquirer) halt() {
+	r.mu.Lock()
+	stop := r.stop
+	r.stop = nil
+	r.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
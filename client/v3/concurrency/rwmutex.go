@@ -0,0 +1,340 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	v3 "go.etcd.io/etcd/client/v3"
+)
+
+// ErrAlreadyLocked is returned by RWMutex and FairMutex when locked again
+// before being unlocked.
+var ErrAlreadyLocked = errors.New("mutex: already locked")
+
+const (
+	rwMutexReadPrefix  = "read/"
+	rwMutexWritePrefix = "write/"
+)
+
+// waiterSeq tells apart the keys of the waiters of a session.
+var waiterSeq uint64
+
+// LockOwner is a holder of a lock.
+type LockOwner struct {
+	// Key is the key of the holder under the prefix of the lock.
+	Key string
+	// Lease is the lease of the session of the holder.
+	Lease v3.LeaseID
+	// CreateRevision is the create revision of the key, the fencing token
+	// of the holder.
+	CreateRevision int64
+	// Info is the metadata the holder locked with, see WithOwnerInfo.
+	Info string
+	// Read is true if the holder holds a read lock.
+	Read bool
+}
+
+// RWMutex is a reader/writer mutual exclusion lock with etcd. Every lock
+// request queues a key of its own under the prefix, so that the lock is
+// granted in the order it was requested: a read lock once the writers queued
+// before it unlocked, and a write lock once every holder queued before it
+// unlocked. Readers queued after a writer wait for it, which cannot starve.
+//
+// An RWMutex holds one lock at a time, a read or a write lock; concurrent
+// holders use RWMutexes of their own, which may share a session.
+type RWMutex struct {
+	s    *Session
+	pfx  string
+	info string
+
+	mu    sync.Mutex
+	myKey string
+	myRev int64
+	hdr   *pb.ResponseHeader
+}
+
+// RWMutexOption configures an RWMutex or a FairMutex.
+type RWMutexOption func(*RWMutex)
+
+// WithOwnerInfo stores info, such as the identity of the process, as the
+// value of the key of the lock requests so that Owners tells who holds it.
+func WithOwnerInfo(info string) RWMutexOption {
+	return func(rw *RWMutex) {
+		rw.info = info
+	}
+}
+
+func NewRWMutex(s *Session, pfx string, opts ...RWMutexOption) *RWMutex {
+	rw := &RWMutex{s: s, pfx: pfx + "/", myKey: "\x00", myRev: -1}
+	for _, opt := range opts {
+		opt(rw)
+	}
+	return rw
+}
+
+// RLock locks the mutex for reading with a cancelable context. If the
+// context is canceled while waiting for the lock, the lock request is removed.
+func (rw *RWMutex) RLock(ctx context.Context) error {
+	return rw.lock(ctx, rwMutexReadPrefix)
+}
+
+// TryRLock locks the mutex for reading if not locked for writing and no
+// writer is waiting for it, or returns ErrLocked.
+func (rw *RWMutex) TryRLock(ctx context.Context) error {
+	return rw.tryLock(ctx, rwMutexReadPrefix)
+}
+
+// TryRLockUntil locks the mutex for reading, waiting for it until deadline at
+// most, after which it returns ErrLocked.
+func (rw *RWMutex) TryRLockUntil(ctx context.Context, deadline time.Time) error {
+	return rw.lockUntil(ctx, rwMutexReadPrefix, deadline)
+}
+
+// RUnlock unlocks the mutex locked for reading.
+func (rw *RWMutex) RUnlock(ctx context.Context) error { return rw.unlock(ctx) }
+
+// Lock locks the mutex for writing with a cancelable context. If the context
+// is canceled while waiting for the lock, the lock request is removed.
+func (rw *RWMutex) Lock(ctx context.Context) error {
+	return rw.lock(ctx, rwMutexWritePrefix)
+}
+
+// TryLock locks the mutex for writing if not locked and no one is waiting for
+// it, or returns ErrLocked.
+func (rw *RWMutex) TryLock(ctx context.Context) error {
+	return rw.tryLock(ctx, rwMutexWritePrefix)
+}
+
+// TryLockUntil locks the mutex for writing, waiting for it until deadline at
+// most, after which it returns ErrLocked.
+func (rw *RWMutex) TryLockUntil(ctx context.Context, deadline time.Time) error {
+	return rw.lockUntil(ctx, rwMutexWritePrefix, deadline)
+}
+
+// Unlock unlocks the mutex locked for writing.
+func (rw *RWMutex) Unlock(ctx context.Context) error { return rw.unlock(ctx) }
+
+func (rw *RWMutex) lock(ctx context.Context, kind string) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	blocked, err := rw.enqueue(ctx, kind)
+	if err != nil || !blocked {
+		return err
+	}
+	client := rw.s.Client()
+	// wait for the deletion of the blocking keys prior to myKey
+	if _, werr := waitDeletes(ctx, client, rw.blockers(kind), rw.myRev-1); werr != nil {
+		rw.dequeue(client.Ctx())
+		return werr
+	}
+	// make sure the session is not expired, and the key still exists.
+	gresp, err := client.Get(ctx, rw.myKey)
+	if err != nil {
+		rw.dequeue(client.Ctx())
+		return err
+	}
+	if len(gresp.Kvs) == 0 {
+		rw.myKey, rw.myRev = "\x00", -1
+		return ErrSessionExpired
+	}
+	rw.hdr = gresp.Header
+	return nil
+}
+
+func (rw *RWMutex) tryLock(ctx context.Context, kind string) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	blocked, err := rw.enqueue(ctx, kind)
+	if err != nil || !blocked {
+		return err
+	}
+	if err = rw.dequeue(ctx); err != nil {
+		return err
+	}
+	return ErrLocked
+}
+
+func (rw *RWMutex) lockUntil(ctx context.Context, kind string, deadline time.Time) error {
+	dctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	err := rw.lock(dctx, kind)
+	if err != nil && dctx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return ErrLocked
+	}
+	return err
+}
+
+// enqueue puts the key of a lock request of the given kind under the prefix,
+// and returns whether it waits for keys queued before it.
+func (rw *RWMutex) enqueue(ctx context.Context, kind string) (blocked bool, err error) {
+	if rw.myRev != -1 {
+		return false, ErrAlreadyLocked
+	}
+	s := rw.s
+	client := s.Client()
+	blockers := rw.blockers(kind)
+	for {
+		key := fmt.Sprintf("%s%s%x-%x", rw.pfx, kind, s.Lease(), atomic.AddUint64(&waiterSeq, 1))
+		cmp := v3.Compare(v3.CreateRevision(key), "=", 0)
+		put := v3.OpPut(key, rw.info, v3.WithLease(s.Lease()))
+		// fetch the first blocking key to complete the uncontended path
+		// with only one RPC
+		getBlocker := v3.OpGet(blockers, v3.WithFirstCreate()...)
+		resp, err := client.Txn(ctx).If(cmp).Then(put, getBlocker).Commit()
+		if err != nil {
+			return false, err
+		}
+		if !resp.Succeeded {
+			continue
+		}
+		rw.myKey, rw.myRev, rw.hdr = key, resp.Header.Revision, resp.Header
+		kvs := resp.Responses[1].GetResponseRange().Kvs
+		return len(kvs) > 0 && kvs[0].CreateRevision < rw.myRev, nil
+	}
+}
+
+// dequeue removes the key of the lock request.
+func (rw *RWMutex) dequeue(ctx context.Context) error {
+	if _, err := rw.s.Client().Delete(ctx, rw.myKey); err != nil {
+		return err
+	}
+	rw.myKey, rw.myRev, rw.hdr = "\x00", -1, nil
+	return nil
+}
+
+// blockers returns the prefix of the keys a lock request of the given kind
+// waits for: the writers for a read lock, all requests for a write lock.
+func (rw *RWMutex) blockers(kind string) string {
+	if kind == rwMutexReadPrefix {
+		return rw.pfx + rwMutexWritePrefix
+	}
+	return rw.pfx
+}
+
+func (rw *RWMutex) unlock(ctx context.Context) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.dequeue(ctx)
+}
+
+// Owners returns the holders of the lock, a writer or readers, or none if not
+// locked.
+func (rw *RWMutex) Owners(ctx context.Context) ([]LockOwner, error) {
+	resp, err := rw.s.Client().Get(ctx, rw.pfx, v3.WithPrefix(), v3.WithSort(v3.SortByCreateRevision, v3.SortAscend))
+	if err != nil {
+		return nil, err
+	}
+	var owners []LockOwner
+	for i, kv := range resp.Kvs {
+		read := strings.HasPrefix(string(kv.Key), rw.pfx+rwMutexReadPrefix)
+		if !read && i > 0 {
+			// readers queued after a writer wait for it
+			break
+		}
+		owners = append(owners, LockOwner{
+			Key:            string(kv.Key),
+			Lease:          v3.LeaseID(kv.Lease),
+			CreateRevision: kv.CreateRevision,
+			Info:           string(kv.Value),
+			Read:           read,
+		})
+		if !read {
+			break
+		}
+	}
+	return owners, nil
+}
+
+func (rw *RWMutex) IsOwner() v3.Cmp {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return v3.Compare(v3.CreateRevision(rw.myKey), "=", rw.myRev)
+}
+
+func (rw *RWMutex) Key() string {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.myKey
+}
+
+// FencingToken is the create revision of the key of the lock.
+func (rw *RWMutex) FencingToken() int64 {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.myRev
+}
+
+// Header is the response header received from etcd on acquiring the lock.
+func (rw *RWMutex) Header() *pb.ResponseHeader {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.hdr
+}
+
+// FairMutex is a mutex granting the lock in the order it was requested. Unlike
+// Mutex, whose lock is shared by the mutexes of a session on the same prefix,
+// every FairMutex requests the lock for itself, so that those of a session
+// also exclude each other and wait in turn. It is the write lock of the
+// RWMutex of the same prefix.
+type FairMutex struct {
+	rw *RWMutex
+}
+
+func NewFairMutex(s *Session, pfx string, opts ...RWMutexOption) *FairMutex {
+	return &FairMutex{rw: NewRWMutex(s, pfx, opts...)}
+}
+
+// Lock locks the mutex with a cancelable context. If the context is canceled
+// while waiting for the lock, the lock request is removed.
+func (m *FairMutex) Lock(ctx context.Context) error { return m.rw.Lock(ctx) }
+
+// TryLock locks the mutex if not locked and no one is waiting for it, or
+// returns ErrLocked.
+func (m *FairMutex) TryLock(ctx context.Context) error { return m.rw.TryLock(ctx) }
+
+// TryLockUntil locks the mutex, waiting for it until deadline at most, after
+// which it returns ErrLocked.
+func (m *FairMutex) TryLockUntil(ctx context.Context, deadline time.Time) error {
+	return m.rw.TryLockUntil(ctx, deadline)
+}
+
+func (m *FairMutex) Unlock(ctx context.Context) error { return m.rw.Unlock(ctx) }
+
+// Owner returns the holder of the lock, or nil if not locked.
+func (m *FairMutex) Owner(ctx context.Context) (*LockOwner, error) {
+	owners, err := m.rw.Owners(ctx)
+	if err != nil || len(owners) == 0 {
+		return nil, err
+	}
+	return &owners[0], nil
+}
+
+func (m *FairMutex) IsOwner() v3.Cmp { return m.rw.IsOwner() }
+
+func (m *FairMutex) Key() string { return m.rw.Key() }
+
+// FencingToken is the create revision of the key of the lock.
+func (m *FairMutex) FencingToken() int64 { return m.rw.FencingToken() }
+
+// Header is the response header received from etcd on acquiring the lock.
+func (m *FairMutex) Header() *pb.ResponseHeader { return m.rw.Header() }
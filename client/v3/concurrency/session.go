@@ -16,6 +16,7 @@ package concurrency
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	v3 "go.etcd.io/etcd/client/v3"
@@ -23,6 +24,33 @@ import (
 
 const defaultSessionTTL = 60
 
+// sessionEndProbeTimeout bounds the time spent asking the cluster whether
+// the lease of a session expired, once no keep alive response was received
+// within its TTL.
+var sessionEndProbeTimeout = time.Second
+
+var (
+	// ErrSessionClosed is returned by Err once the session was closed or
+	// orphaned, or its context canceled.
+	ErrSessionClosed = errors.New("session: closed")
+	// ErrSessionClientClosed is returned by Err once the client of the
+	// session was closed.
+	ErrSessionClientClosed = errors.New("session: client closed")
+	// ErrSessionLeaseRevoked is returned by Err once the lease of the
+	// session was found missing before its TTL elapsed since it was last
+	// renewed, such as when it is revoked by another client.
+	ErrSessionLeaseRevoked = errors.New("session: lease revoked")
+	// ErrSessionLeaseExpired is returned by Err once the lease of the
+	// session expired, such as when its keep alives were delayed beyond its
+	// TTL.
+	ErrSessionLeaseExpired = errors.New("session: lease expired")
+	// ErrSessionClusterUnreachable is returned by Err once no keep alive
+	// response was received within the TTL of the lease of the session,
+	// and the cluster could not tell whether it expired. The lease should
+	// be considered expired.
+	ErrSessionClusterUnreachable = errors.New("session: cluster unreachable")
+)
+
 // Session represents a lease kept alive for the lifetime of a client.
 // Fault-tolerant applications may use sessions to reason about liveness.
 type Session struct {
@@ -32,6 +60,8 @@ type Session struct {
 
 	cancel context.CancelFunc
 	donec  <-chan struct{}
+	// err is why the session ended, set before donec is closed.
+	err error
 }
 
 // NewSession gets the leased session for a client.
@@ -40,18 +70,24 @@ func NewSession(client *v3.Client, opts ...SessionOption) (*Session, error) {
 	for _, opt := range opts {
 		opt(ops)
 	}
+	return newSession(ops.ctx, client, ops)
+}
 
+// newSession gets a leased session for a client, granting its lease with
+// the given context.
+func newSession(ctx context.Context, client *v3.Client, ops *sessionOptions) (*Session, error) {
 	id := ops.leaseID
+	ttl := time.Duration(ops.ttl) * time.Second
 	if id == v3.NoLease {
-		resp, err := client.Grant(ops.ctx, int64(ops.ttl))
+		resp, err := client.Grant(ctx, int64(ops.ttl))
 		if err != nil {
 			return nil, err
 		}
 		id = resp.ID
 	}
 
-	ctx, cancel := context.WithCancel(ops.ctx)
-	keepAlive, err := client.KeepAlive(ctx, id)
+	kctx, cancel := context.WithCancel(ops.ctx)
+	keepAlive, err := client.KeepAlive(kctx, id)
 	if err != nil || keepAlive == nil {
 		cancel()
 		return nil, err
@@ -63,14 +99,38 @@ func NewSession(client *v3.Client, opts ...SessionOption) (*Session, error) {
 	// keep the lease alive until client error or cancelled context
 	go func() {
 		defer close(donec)
-		for range keepAlive {
-			// eat messages until keep alive channel closes
+		var renewed time.Time
+		for resp := range keepAlive {
+			renewed, ttl = time.Now(), time.Duration(resp.TTL)*time.Second
 		}
+		s.err = s.endReason(kctx, renewed, ttl)
 	}()
 
 	return s, nil
 }
 
+// endReason returns why the keep alives of the session stopped, given the
+// time of the last keep alive response received, if any, and the TTL it
+// renewed the lease with.
+func (s *Session) endReason(ctx context.Context, renewed time.Time, ttl time.Duration) error {
+	switch {
+	case s.client.Ctx().Err() != nil:
+		return ErrSessionClientClosed
+	case ctx.Err() != nil:
+		return ErrSessionClosed
+	case !renewed.IsZero() && time.Since(renewed) < ttl:
+		// the cluster reported the lease missing while it was still alive
+		return ErrSessionLeaseRevoked
+	}
+	pctx, cancel := context.WithTimeout(s.client.Ctx(), sessionEndProbeTimeout)
+	defer cancel()
+	resp, err := s.client.TimeToLive(pctx, s.id)
+	if err != nil || resp.TTL > 0 {
+		return ErrSessionClusterUnreachable
+	}
+	return ErrSessionLeaseExpired
+}
+
 // Client is the etcd client that is attached to the session.
 func (s *Session) Client() *v3.Client {
 	return s.client
@@ -83,6 +143,27 @@ func (s *Session) Lease() v3.LeaseID { return s.id }
 // is otherwise no longer being refreshed.
 func (s *Session) Done() <-chan struct{} { return s.donec }
 
+// Err returns nil until Done is closed, and then why the session ended:
+// ErrSessionClosed, ErrSessionClientClosed, ErrSessionLeaseRevoked,
+// ErrSessionLeaseExpired or ErrSessionClusterUnreachable. When no keep
+// alive response was received within the TTL of the lease, the cluster is
+// asked for the lease for up to a second before Done is closed.
+func (s *Session) Err() error {
+	select {
+	case <-s.donec:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// ended returns true if the session ended other than by being closed by
+// its owner or its client.
+func (s *Session) ended() bool {
+	err := s.Err()
+	return err != nil && err != ErrSessionClosed && err != ErrSessionClientClosed
+}
+
 // Orphan ends the refresh for the session lease. This is useful
 // in case the state of the client connection is indeterminate (revoke
 // would fail) or when transferring lease ownership.
@@ -33,6 +33,25 @@ type Config struct {
 	// 0 disables auto-sync. By default auto-sync is disabled.
 	AutoSyncInterval time.Duration `json:"auto-sync-interval"`
 
+	// EndpointWeights maps endpoints, as listed in Endpoints, to weights.
+	// Requests are spread over the endpoints in proportion to their weights
+	// with a weighted round-robin, for members of uneven capacity. Endpoints
+	// without a weight have weight 1. By default, requests are spread evenly.
+	EndpointWeights map[string]int `json:"endpoint-weights"`
+
+	// EndpointPrimaryFallback when set sends all requests to the first
+	// available endpoint in the order of the endpoints, falling back to the
+	// next ones while the earlier ones are unavailable. Endpoint weights are
+	// ignored.
+	EndpointPrimaryFallback bool `json:"endpoint-primary-fallback"`
+
+	// ReadEndpoints is a list of URLs, such as those of learners, serving the
+	// serializable gets of the client in place of Endpoints, so that read-heavy
+	// workloads can be directed to read-only replicas. Learners serve range
+	// streams with --experimental-learner-serializable-reads. Gets are spread
+	// evenly over the read endpoints; other requests use Endpoints.
+	ReadEndpoints []string `json:"read-endpoints"`
+
 	// DialTimeout is the timeout for failing to establish a connection.
 	DialTimeout time.Duration `json:"dial-timeout"`
 
@@ -44,6 +63,13 @@ type Config struct {
 	// keep-alive probe. If the response is not received in this time, the connection is closed.
 	DialKeepAliveTimeout time.Duration `json:"dial-keep-alive-timeout"`
 
+	// DialFallbackDelay is the time to wait for a connection to the preferred
+	// address family of an endpoint whose host resolves to both IPv6 and IPv4
+	// addresses before racing a connection to the other family (RFC 6555).
+	// If 0, the gRPC default dialer is used. If negative, the fallback is disabled.
+	// Setting it bypasses any HTTP proxy configured in the environment.
+	DialFallbackDelay time.Duration `json:"dial-fallback-delay"`
+
 	// MaxCallSendMsgSize is the client-side request send limit in bytes.
 	// If 0, it defaults to 2.0 MiB (2 * 1024 * 1024).
 	// Make sure that "MaxCallSendMsgSize" < server-side default send/recv limit.
@@ -69,6 +95,36 @@ type Config struct {
 	// RejectOldCluster when set will refuse to create a client against an outdated cluster.
 	RejectOldCluster bool `json:"reject-old-cluster"`
 
+	// FenceClusterEpoch when set pins the client to the epoch of the cluster
+	// it first receives a response from. Members of another epoch, such as
+	// members left over from before the cluster was restored from a snapshot,
+	// reject its requests with rpctypes.ErrClusterEpochMismatch.
+	FenceClusterEpoch bool `json:"fence-cluster-epoch"`
+
+	// MaxAPIVersion is the highest API version, as "major.minor", whose
+	// semantics the client understands. It is declared to the cluster in
+	// place of the API version of the client library. Requests using fields
+	// introduced after this version, or after the API version advertised by
+	// the cluster, fail with rpctypes.ErrAPIVersionUnsupported instead of
+	// being sent.
+	MaxAPIVersion string `json:"max-api-version"`
+
+	// RetryPolicy configures the retries of the unary requests safe to
+	// repeat, such as ranges and lease requests. If nil, they are retried up
+	// to 100 times on codes.Unavailable, waiting 25ms after each round over a
+	// quorum of the endpoints.
+	RetryPolicy *RetryPolicy `json:"retry-policy"`
+
+	// NonRepeatableRetryPolicy configures the retries of the unary requests
+	// not safe to repeat, such as puts, deletes and txns, which are only
+	// retried when no endpoint could be reached. If nil, RetryPolicy applies.
+	NonRepeatableRetryPolicy *RetryPolicy `json:"non-repeatable-retry-policy"`
+
+	// MetricsHooks when set receives the telemetry of the client: the start
+	// and the outcome of its requests, the events received by its watches
+	// and the keepalives missed by its leases.
+	MetricsHooks MetricsHooks
+
 	// DialOptions is a list of dial options for the grpc client (e.g., for interceptors).
 	// For example, pass "grpc.WithBlock()" to block until the underlying connection is up.
 	// Without this, Dial returns immediately and connecting the server happens in background.
@@ -18,7 +18,6 @@ import (
 	"context"
 
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
-	"go.etcd.io/etcd/api/v3/version"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -37,14 +36,14 @@ func WithRequireLeader(ctx context.Context) context.Context {
 }
 
 // embeds client version
-func withVersion(ctx context.Context) context.Context {
+func withVersion(ctx context.Context, ver string) context.Context {
 	md, ok := metadata.FromOutgoingContext(ctx)
 	if !ok { // no outgoing metadata ctx key, create one
-		md = metadata.Pairs(rpctypes.MetadataClientAPIVersionKey, version.APIVersion)
+		md = metadata.Pairs(rpctypes.MetadataClientAPIVersionKey, ver)
 		return metadata.NewOutgoingContext(ctx, md)
 	}
 	copied := md.Copy() // avoid racey updates
 	// overwrite/add version key/value
-	copied.Set(rpctypes.MetadataClientAPIVersionKey, version.APIVersion)
+	copied.Set(rpctypes.MetadataClientAPIVersionKey, ver)
 	return metadata.NewOutgoingContext(ctx, copied)
 }
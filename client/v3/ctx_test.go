@@ -52,7 +52,7 @@ func TestMetadataWithRequireLeader(t *testing.T) {
 }
 
 func TestMetadataWithClientAPIVersion(t *testing.T) {
-	ctx := withVersion(WithRequireLeader(context.TODO()))
+	ctx := withVersion(WithRequireLeader(context.TODO()), version.APIVersion)
 
 	md, ok := metadata.FromOutgoingContext(ctx)
 	if !ok {
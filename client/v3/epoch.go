@@ -0,0 +1,77 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ClusterEpoch returns the epoch of the cluster the client is pinned to, or 0
+// if Config.FenceClusterEpoch is not set or no member reported its epoch yet.
+func (c *Client) ClusterEpoch() uint64 {
+	return atomic.LoadUint64(&c.clusterEpoch)
+}
+
+// withClusterEpoch attaches the pinned cluster epoch to the outgoing
+// metadata of ctx, so members of another epoch reject the request.
+func (c *Client) withClusterEpoch(ctx context.Context) context.Context {
+	epoch := c.ClusterEpoch()
+	if epoch == 0 {
+		return ctx
+	}
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	} else {
+		md = md.Copy() // avoid racey updates
+	}
+	md.Set(rpctypes.MetadataClusterEpochKey, strconv.FormatUint(epoch, 10))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// clusterEpochInvoker pins the client to the cluster epoch of the first
+// response, and rejects the responses of another epoch.
+func (c *Client) clusterEpochInvoker(invoker grpc.UnaryInvoker) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+		return c.pinClusterEpoch(reply)
+	}
+}
+
+func (c *Client) pinClusterEpoch(reply interface{}) error {
+	r, ok := reply.(interface{ GetHeader() *pb.ResponseHeader })
+	if !ok {
+		return nil
+	}
+	epoch := r.GetHeader().GetClusterEpoch()
+	if epoch == 0 {
+		// member from before v3.6 or not yet caught up with the cluster
+		return nil
+	}
+	if atomic.CompareAndSwapUint64(&c.clusterEpoch, 0, epoch) || c.ClusterEpoch() == epoch {
+		return nil
+	}
+	return rpctypes.ErrGRPCClusterEpochMismatch
+}
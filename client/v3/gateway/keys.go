@@ -0,0 +1,162 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gateway helps the clients of the etcd gRPC gateway to work with
+// binary keys in JSON bodies.
+//
+// The gateway encodes the keys in standard base64, as required by the
+// protobuf JSON mapping of bytes fields. A client may negotiate another key
+// encoding by sending its media type in the Content-Type header, for the
+// keys of the request, and in the Accept header, for the keys of the
+// response:
+//
+//	req.Header.Set("Content-Type", gateway.KeyEncodingHex.MIMEType())
+//	req.Header.Set("Accept", gateway.KeyEncodingHex.MIMEType())
+//
+// The keys are the "key", "range_end" and "keys" fields of any object.
+// The values and the other bytes fields keep the standard base64 encoding.
+package gateway
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// KeyEncoding is an encoding of the keys in the JSON bodies of the gateway.
+type KeyEncoding string
+
+const (
+	// KeyEncodingBase64 is the standard base64 encoding, used by default.
+	KeyEncodingBase64 KeyEncoding = "base64"
+	// KeyEncodingBase64URL is the unpadded URL-safe base64 encoding.
+	KeyEncodingBase64URL KeyEncoding = "base64url"
+	// KeyEncodingHex is the lowercase hexadecimal encoding.
+	KeyEncodingHex KeyEncoding = "hex"
+)
+
+// KeyEncodings lists the supported key encodings.
+var KeyEncodings = []KeyEncoding{KeyEncodingBase64, KeyEncodingBase64URL, KeyEncodingHex}
+
+// keyFields are the JSON fields holding keys, by their original and
+// lowerCamelCase protobuf names.
+var keyFields = map[string]bool{
+	"key":       true,
+	"range_end": true,
+	"rangeEnd":  true,
+	"keys":      true,
+}
+
+// MIMEType returns the media type negotiating the key encoding with the gateway.
+func (e KeyEncoding) MIMEType() string {
+	if e == KeyEncodingBase64 {
+		return "application/json"
+	}
+	return "application/vnd.etcd.keys." + string(e) + "+json"
+}
+
+// EncodeKey returns the encoded key.
+func (e KeyEncoding) EncodeKey(key []byte) string {
+	switch e {
+	case KeyEncodingBase64URL:
+		return base64.RawURLEncoding.EncodeToString(key)
+	case KeyEncodingHex:
+		return hex.EncodeToString(key)
+	default:
+		return base64.StdEncoding.EncodeToString(key)
+	}
+}
+
+// DecodeKey returns the key encoded in s.
+func (e KeyEncoding) DecodeKey(s string) ([]byte, error) {
+	switch e {
+	case KeyEncodingBase64:
+		return base64.StdEncoding.DecodeString(s)
+	case KeyEncodingBase64URL:
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	case KeyEncodingHex:
+		return hex.DecodeString(s)
+	default:
+		return nil, fmt.Errorf("unknown key encoding %q", e)
+	}
+}
+
+// Transcode returns the JSON body with its keys re-encoded from one key
+// encoding to another.
+func Transcode(body []byte, from, to KeyEncoding) ([]byte, error) {
+	if from == to {
+		return body, nil
+	}
+	d := json.NewDecoder(bytes.NewReader(body))
+	// keep the precision of the integers
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	if err := transcode(v, from, to); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func transcode(v interface{}, from, to KeyEncoding) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, field := range v {
+			if !keyFields[name] {
+				if err := transcode(field, from, to); err != nil {
+					return err
+				}
+				continue
+			}
+			switch field := field.(type) {
+			case string:
+				key, err := transcodeKey(name, field, from, to)
+				if err != nil {
+					return err
+				}
+				v[name] = key
+			case []interface{}:
+				for i, k := range field {
+					if s, ok := k.(string); ok {
+						key, err := transcodeKey(name, s, from, to)
+						if err != nil {
+							return err
+						}
+						field[i] = key
+					}
+				}
+			}
+		}
+	case []interface{}:
+		for _, elem := range v {
+			if err := transcode(elem, from, to); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func transcodeKey(name, s string, from, to KeyEncoding) (string, error) {
+	key, err := from.DecodeKey(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s key in %q: %v", from, name, err)
+	}
+	return to.EncodeKey(key), nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKeyEncodingRoundTrip(t *testing.T) {
+	key := []byte{0, 0xff, '/', 'a', 0xfe}
+	tests := []struct {
+		enc      KeyEncoding
+		wencoded string
+	}{
+		{KeyEncodingBase64, "AP8vYf4="},
+		{KeyEncodingBase64URL, "AP8vYf4"},
+		{KeyEncodingHex, "00ff2f61fe"},
+	}
+	for _, tt := range tests {
+		if s := tt.enc.EncodeKey(key); s != tt.wencoded {
+			t.Errorf("%s: encoded key = %q, want %q", tt.enc, s, tt.wencoded)
+		}
+		k, err := tt.enc.DecodeKey(tt.wencoded)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.enc, err)
+		}
+		if !bytes.Equal(k, key) {
+			t.Errorf("%s: decoded key = %q, want %q", tt.enc, k, key)
+		}
+	}
+	if _, err := KeyEncodingBase64URL.DecodeKey("AP8vYf4="); err != nil {
+		t.Errorf("unexpected error decoding a padded base64url key: %v", err)
+	}
+	if _, err := KeyEncoding("base32").DecodeKey("AA"); err == nil {
+		t.Error("expected an error decoding a key in an unknown encoding")
+	}
+}
+
+func TestTranscode(t *testing.T) {
+	body := []byte(`{"header":{"revision":"9007199254740993"},"kvs":[{"key":"AP8=","value":"AP8="}],"range_end":"AQ==","keys":["AP8=","AQ=="],"lease":9007199254740993}`)
+	whex := `{"header":{"revision":"9007199254740993"},"keys":["00ff","01"],"kvs":[{"key":"00ff","value":"AP8="}],"lease":9007199254740993,"range_end":"01"}`
+
+	got, err := Transcode(body, KeyEncodingBase64, KeyEncodingHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != whex {
+		t.Errorf("transcoded body = %s, want %s", got, whex)
+	}
+
+	back, err := Transcode(got, KeyEncodingHex, KeyEncodingBase64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wback := `{"header":{"revision":"9007199254740993"},"keys":["AP8=","AQ=="],"kvs":[{"key":"AP8=","value":"AP8="}],"lease":9007199254740993,"range_end":"AQ=="}`
+	if string(back) != wback {
+		t.Errorf("transcoded body = %s, want %s", back, wback)
+	}
+
+	if _, err := Transcode([]byte(`{"key":"zz"}`), KeyEncodingHex, KeyEncodingBase64); err == nil {
+		t.Error("expected an error transcoding an invalid key")
+	}
+}
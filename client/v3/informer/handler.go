@@ -0,0 +1,116 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informer
+
+import (
+	"context"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// EventHandler handles the changes of the key-values cached by an informer.
+// The calls to one handler are serialized and made in the order of the
+// changes.
+type EventHandler interface {
+	// OnAdd is called when a key is added to the cache.
+	OnAdd(kv *mvccpb.KeyValue)
+	// OnUpdate is called when a cached key is modified, and for every
+	// cached key on resync, in which case oldKV and newKV are equal.
+	OnUpdate(oldKV, newKV *mvccpb.KeyValue)
+	// OnDelete is called when a key is removed from the cache.
+	OnDelete(kv *mvccpb.KeyValue)
+}
+
+// EventHandlerFuncs is an EventHandler calling its non-nil functions.
+type EventHandlerFuncs struct {
+	AddFunc    func(kv *mvccpb.KeyValue)
+	UpdateFunc func(oldKV, newKV *mvccpb.KeyValue)
+	DeleteFunc func(kv *mvccpb.KeyValue)
+}
+
+func (f EventHandlerFuncs) OnAdd(kv *mvccpb.KeyValue) {
+	if f.AddFunc != nil {
+		f.AddFunc(kv)
+	}
+}
+
+func (f EventHandlerFuncs) OnUpdate(oldKV, newKV *mvccpb.KeyValue) {
+	if f.UpdateFunc != nil {
+		f.UpdateFunc(oldKV, newKV)
+	}
+}
+
+func (f EventHandlerFuncs) OnDelete(kv *mvccpb.KeyValue) {
+	if f.DeleteFunc != nil {
+		f.DeleteFunc(kv)
+	}
+}
+
+type notification struct {
+	oldKV, newKV *mvccpb.KeyValue
+}
+
+func (n notification) dispatch(h EventHandler) {
+	switch {
+	case n.oldKV == nil:
+		h.OnAdd(n.newKV)
+	case n.newKV == nil:
+		h.OnDelete(n.oldKV)
+	default:
+		h.OnUpdate(n.oldKV, n.newKV)
+	}
+}
+
+// listener buffers the notifications of a handler, so a slow handler
+// delays neither the cache nor the other handlers.
+type listener struct {
+	h EventHandler
+
+	mu      sync.Mutex
+	pending []notification
+	ready   chan struct{}
+}
+
+func newListener(h EventHandler) *listener {
+	return &listener{h: h, ready: make(chan struct{}, 1)}
+}
+
+func (l *listener) add(n notification) {
+	l.mu.Lock()
+	l.pending = append(l.pending, n)
+	l.mu.Unlock()
+	select {
+	case l.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (l *listener) run(ctx context.Context) {
+	for {
+		select {
+		case <-l.ready:
+		case <-ctx.Done():
+			return
+		}
+		l.mu.Lock()
+		pending := l.pending
+		l.pending = nil
+		l.mu.Unlock()
+		for _, n := range pending {
+			n.dispatch(l.h)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package informer keeps a local cache of the keys under a prefix in sync
+// with etcd and notifies event handlers of their changes.
+//
+// An informer lists the prefix, then watches it from the revision of the
+// list. When the watch fails, for example because its revision was
+// compacted, the informer lists the prefix again and notifies the handlers
+// of the differences with its cache. Several handlers share one informer,
+// its cache and its watch:
+//
+//	inf := informer.New(cli, "/services/", informer.Config{
+//		ResyncPeriod: time.Minute,
+//		Indexers: informer.Indexers{
+//			"value": func(kv *mvccpb.KeyValue) []string { return []string{string(kv.Value)} },
+//		},
+//	})
+//	inf.AddEventHandler(informer.EventHandlerFuncs{
+//		AddFunc: func(kv *mvccpb.KeyValue) { fmt.Printf("added %q\n", kv.Key) },
+//	})
+//	go inf.Run(ctx)
+//	if err := inf.WaitForSync(ctx); err != nil {
+//		// handle error!
+//	}
+//	kvs, err := inf.Store().ByIndex("value", "up")
+package informer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"go.uber.org/zap"
+)
+
+const (
+	batchLimit = 1000
+	// listRetryInterval is the delay before listing again after a failure.
+	listRetryInterval = 500 * time.Millisecond
+)
+
+// Config configures an informer.
+type Config struct {
+	// ResyncPeriod is the interval at which OnUpdate is called for every
+	// cached key, so handlers can reconcile periodically. Zero disables resync.
+	ResyncPeriod time.Duration
+	// Indexers are the secondary indexes of the cache.
+	Indexers Indexers
+}
+
+// Informer is a shared informer over a key prefix.
+type Informer struct {
+	c      *clientv3.Client
+	prefix string
+	cfg    Config
+	lg     *zap.Logger
+	cache  *cache
+
+	// mu serializes the changes of the cache with the registration of
+	// handlers, so each handler sees every change exactly once.
+	mu        sync.Mutex
+	listeners []*listener
+	ctx       context.Context
+	synced    chan struct{}
+}
+
+// New creates an informer over the keys with prefix, or over all keys if prefix
+// is empty. It does nothing until Run is called.
+func New(c *clientv3.Client, prefix string, cfg Config) *Informer {
+	lg := c.GetLogger()
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	return &Informer{
+		c:      c,
+		prefix: prefix,
+		cfg:    cfg,
+		lg:     lg,
+		cache:  newCache(cfg.Indexers),
+		synced: make(chan struct{}),
+	}
+}
+
+// Store returns the local cache of the informer.
+func (inf *Informer) Store() Store {
+	return inf.cache
+}
+
+// HasSynced returns true once the cache holds the initial list of keys.
+func (inf *Informer) HasSynced() bool {
+	select {
+	case <-inf.synced:
+		return true
+	default:
+		return false
+	}
+}
+
+// WaitForSync waits until the cache holds the initial list of keys or ctx is done.
+func (inf *Informer) WaitForSync(ctx context.Context) error {
+	select {
+	case <-inf.synced:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// AddEventHandler registers h. If the informer already has keys in its
+// cache, h is first notified of their addition.
+func (inf *Informer) AddEventHandler(h EventHandler) {
+	inf.mu.Lock()
+	defer inf.mu.Unlock()
+	l := newListener(h)
+	for _, kv := range inf.cache.List() {
+		l.add(notification{newKV: kv})
+	}
+	inf.listeners = append(inf.listeners, l)
+	if inf.ctx != nil {
+		go l.run(inf.ctx)
+	}
+}
+
+// Run keeps the cache in sync and notifies the handlers until ctx is done.
+func (inf *Informer) Run(ctx context.Context) {
+	inf.mu.Lock()
+	inf.ctx = ctx
+	for _, l := range inf.listeners {
+		go l.run(ctx)
+	}
+	inf.mu.Unlock()
+
+	var resyncc <-chan time.Time
+	if inf.cfg.ResyncPeriod > 0 {
+		ticker := time.NewTicker(inf.cfg.ResyncPeriod)
+		defer ticker.Stop()
+		resyncc = ticker.C
+	}
+	for ctx.Err() == nil {
+		rev, err := inf.list(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				inf.lg.Warn("failed to list keys", zap.String("prefix", inf.prefix), zap.Error(err))
+			}
+			select {
+			case <-time.After(listRetryInterval):
+			case <-ctx.Done():
+			}
+			continue
+		}
+		inf.watch(ctx, rev, resyncc)
+	}
+}
+
+// list reads the keys with the prefix and replaces the cache with them.
+// It returns the revision of the read.
+func (inf *Informer) list(ctx context.Context) (int64, error) {
+	var (
+		kvs []*mvccpb.KeyValue
+		rev int64
+	)
+	key, end := inf.prefix, clientv3.GetPrefixRangeEnd(inf.prefix)
+	if key == "" {
+		// the empty prefix is the range of all keys, from "\x00" on
+		key = "\x00"
+	}
+	for {
+		opts := []clientv3.OpOption{clientv3.WithRange(end), clientv3.WithLimit(batchLimit)}
+		if rev != 0 {
+			opts = append(opts, clientv3.WithRev(rev))
+		}
+		resp, err := inf.c.Get(ctx, key, opts...)
+		if err != nil {
+			return 0, err
+		}
+		if rev == 0 {
+			rev = resp.Header.Revision
+		}
+		kvs = append(kvs, resp.Kvs...)
+		if !resp.More {
+			break
+		}
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+	inf.replace(kvs, rev)
+	return rev, nil
+}
+
+// watch applies the changes under the prefix after rev to the cache until
+// the watch fails or ctx is done.
+func (inf *Informer) watch(ctx context.Context, rev int64, resyncc <-chan time.Time) {
+	wctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+	wch := inf.c.Watch(wctx, inf.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1), clientv3.WithPrevKV())
+	for {
+		select {
+		case wresp, ok := <-wch:
+			if !ok {
+				return
+			}
+			if err := wresp.Err(); err != nil {
+				inf.lg.Warn("watch failed, listing keys again", zap.String("prefix", inf.prefix), zap.Error(err))
+				return
+			}
+			inf.apply(wresp.Events, wresp.Header.Revision)
+		case <-resyncc:
+			inf.resync()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// replace replaces the cache with kvs read at revision rev and notifies
+// the handlers of the differences.
+func (inf *Informer) replace(kvs []*mvccpb.KeyValue, rev int64) {
+	inf.mu.Lock()
+	defer inf.mu.Unlock()
+	keys := make(map[string]struct{}, len(kvs))
+	for _, kv := range kvs {
+		keys[string(kv.Key)] = struct{}{}
+		old, ok := inf.cache.Get(string(kv.Key))
+		if ok && old.ModRevision == kv.ModRevision {
+			continue
+		}
+		inf.cache.put(kv)
+		inf.notify(notification{oldKV: old, newKV: kv})
+	}
+	for _, k := range inf.cache.ListKeys() {
+		if _, ok := keys[k]; !ok {
+			inf.notify(notification{oldKV: inf.cache.delete(k)})
+		}
+	}
+	inf.cache.setRevision(rev)
+	select {
+	case <-inf.synced:
+	default:
+		close(inf.synced)
+	}
+}
+
+// apply applies watch events to the cache and notifies the handlers.
+func (inf *Informer) apply(evs []*clientv3.Event, rev int64) {
+	inf.mu.Lock()
+	defer inf.mu.Unlock()
+	for _, ev := range evs {
+		switch ev.Type {
+		case mvccpb.PUT:
+			old := inf.cache.put(ev.Kv)
+			inf.notify(notification{oldKV: old, newKV: ev.Kv})
+		case mvccpb.DELETE:
+			if old := inf.cache.delete(string(ev.Kv.Key)); old != nil {
+				inf.notify(notification{oldKV: old})
+			}
+		}
+	}
+	inf.cache.setRevision(rev)
+}
+
+func (inf *Informer) resync() {
+	inf.mu.Lock()
+	defer inf.mu.Unlock()
+	for _, kv := range inf.cache.List() {
+		inf.notify(notification{oldKV: kv, newKV: kv})
+	}
+}
+
+func (inf *Informer) notify(n notification) {
+	for _, l := range inf.listeners {
+		l.add(n)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/v3/clientv3test"
+)
+
+// recorder is an EventHandler describing the events it receives.
+type recorder chan string
+
+func (r recorder) OnAdd(kv *mvccpb.KeyValue) { r <- fmt.Sprintf("add %s=%s", kv.Key, kv.Value) }
+
+func (r recorder) OnUpdate(oldKV, newKV *mvccpb.KeyValue) {
+	r <- fmt.Sprintf("update %s=%s->%s", newKV.Key, oldKV.Value, newKV.Value)
+}
+
+func (r recorder) OnDelete(kv *mvccpb.KeyValue) { r <- fmt.Sprintf("delete %s=%s", kv.Key, kv.Value) }
+
+func (r recorder) expect(t *testing.T, events ...string) {
+	t.Helper()
+	for _, want := range events {
+		select {
+		case got := <-r:
+			assert.Equal(t, want, got)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+}
+
+func TestInformer(t *testing.T) {
+	_, cli := clientv3test.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := cli.Put(ctx, "/a/1", "x")
+	require.NoError(t, err)
+	_, err = cli.Put(ctx, "/b/1", "x")
+	require.NoError(t, err)
+
+	inf := New(cli, "/a/", Config{Indexers: Indexers{
+		"value": func(kv *mvccpb.KeyValue) []string { return []string{string(kv.Value)} },
+	}})
+	rec := make(recorder, 10)
+	inf.AddEventHandler(rec)
+	go inf.Run(ctx)
+	require.NoError(t, inf.WaitForSync(ctx))
+	rec.expect(t, "add /a/1=x")
+
+	_, err = cli.Put(ctx, "/a/2", "y")
+	require.NoError(t, err)
+	_, err = cli.Put(ctx, "/a/1", "y")
+	require.NoError(t, err)
+	_, err = cli.Delete(ctx, "/a/2")
+	require.NoError(t, err)
+	rec.expect(t, "add /a/2=y", "update /a/1=x->y", "delete /a/2=y")
+
+	store := inf.Store()
+	assert.Equal(t, []string{"/a/1"}, store.ListKeys())
+	assert.Equal(t, int64(6), store.Revision())
+	kvs, err := store.ByIndex("value", "y")
+	require.NoError(t, err)
+	require.Len(t, kvs, 1)
+	assert.Equal(t, "/a/1", string(kvs[0].Key))
+
+	// a late handler is told about the cached keys first
+	late := make(recorder, 10)
+	inf.AddEventHandler(late)
+	late.expect(t, "add /a/1=y")
+}
+
+func TestInformerEmptyPrefix(t *testing.T) {
+	_, cli := clientv3test.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := cli.Put(ctx, "/a/1", "x")
+	require.NoError(t, err)
+
+	inf := New(cli, "", Config{})
+	rec := make(recorder, 10)
+	inf.AddEventHandler(rec)
+	go inf.Run(ctx)
+	require.NoError(t, inf.WaitForSync(ctx))
+	rec.expect(t, "add /a/1=x")
+
+	_, err = cli.Put(ctx, "/b/1", "y")
+	require.NoError(t, err)
+	rec.expect(t, "add /b/1=y")
+}
+
+func TestInformerResync(t *testing.T) {
+	_, cli := clientv3test.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := cli.Put(ctx, "/a/1", "x")
+	require.NoError(t, err)
+
+	inf := New(cli, "/a/", Config{ResyncPeriod: 10 * time.Millisecond})
+	rec := make(recorder, 10)
+	inf.AddEventHandler(rec)
+	go inf.Run(ctx)
+	rec.expect(t, "add /a/1=x", "update /a/1=x->x")
+}
+
+func TestInformerReplace(t *testing.T) {
+	_, cli := clientv3test.New(t)
+	inf := New(cli, "/a/", Config{})
+	rec := make(recorder, 10)
+	inf.AddEventHandler(rec)
+
+	kv := func(key, value string, rev int64) *mvccpb.KeyValue {
+		return &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), ModRevision: rev}
+	}
+	assert.False(t, inf.HasSynced())
+	inf.replace([]*mvccpb.KeyValue{kv("/a/1", "x", 2), kv("/a/2", "x", 3)}, 3)
+	assert.True(t, inf.HasSynced())
+	inf.replace([]*mvccpb.KeyValue{kv("/a/1", "x", 2), kv("/a/2", "y", 4), kv("/a/3", "x", 5)}, 6)
+	inf.replace([]*mvccpb.KeyValue{kv("/a/3", "x", 5)}, 7)
+	assert.Equal(t, int64(7), inf.Store().Revision())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// run the listeners without watching etcd
+	for _, l := range inf.listeners {
+		go l.run(ctx)
+	}
+	rec.expect(t,
+		"add /a/1=x", "add /a/2=x",
+		"update /a/2=x->y", "add /a/3=x",
+		"delete /a/1=x", "delete /a/2=y",
+	)
+}
@@ -0,0 +1,182 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informer
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// ErrUnknownIndex is returned when querying an index the informer was not configured with.
+var ErrUnknownIndex = errors.New("informer: unknown index")
+
+// IndexFunc computes the indexed values of a key-value.
+type IndexFunc func(kv *mvccpb.KeyValue) []string
+
+// Indexers maps index names to the functions computing their values.
+type Indexers map[string]IndexFunc
+
+// Store is a read-only view of the local cache of an informer.
+type Store interface {
+	// Get returns the cached key-value of key.
+	Get(key string) (*mvccpb.KeyValue, bool)
+	// List returns all the cached key-values, sorted by key.
+	List() []*mvccpb.KeyValue
+	// ListKeys returns all the cached keys, sorted.
+	ListKeys() []string
+	// ByIndex returns the cached key-values whose index indexName
+	// includes indexedValue, sorted by key.
+	ByIndex(indexName, indexedValue string) ([]*mvccpb.KeyValue, error)
+	// Revision returns the etcd revision the cache is up to date with.
+	Revision() int64
+}
+
+// cache is a Store with secondary indexes.
+type cache struct {
+	mu       sync.RWMutex
+	kvs      map[string]*mvccpb.KeyValue
+	rev      int64
+	indexers Indexers
+	// indices maps an index name to its indexed values and their keys.
+	indices map[string]map[string]map[string]struct{}
+}
+
+func newCache(indexers Indexers) *cache {
+	c := &cache{
+		kvs:      make(map[string]*mvccpb.KeyValue),
+		indexers: indexers,
+		indices:  make(map[string]map[string]map[string]struct{}),
+	}
+	for name := range indexers {
+		c.indices[name] = make(map[string]map[string]struct{})
+	}
+	return c
+}
+
+func (c *cache) Get(key string) (*mvccpb.KeyValue, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	kv, ok := c.kvs[key]
+	return kv, ok
+}
+
+func (c *cache) List() []*mvccpb.KeyValue {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	kvs := make([]*mvccpb.KeyValue, 0, len(c.kvs))
+	for _, kv := range c.kvs {
+		kvs = append(kvs, kv)
+	}
+	sortKVs(kvs)
+	return kvs
+}
+
+func (c *cache) ListKeys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	keys := make([]string, 0, len(c.kvs))
+	for k := range c.kvs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *cache) ByIndex(indexName, indexedValue string) ([]*mvccpb.KeyValue, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	index, ok := c.indices[indexName]
+	if !ok {
+		return nil, ErrUnknownIndex
+	}
+	var kvs []*mvccpb.KeyValue
+	for k := range index[indexedValue] {
+		kvs = append(kvs, c.kvs[k])
+	}
+	sortKVs(kvs)
+	return kvs, nil
+}
+
+func (c *cache) Revision() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rev
+}
+
+// put stores kv and returns the key-value it replaced, if any.
+func (c *cache) put(kv *mvccpb.KeyValue) *mvccpb.KeyValue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := string(kv.Key)
+	old := c.kvs[k]
+	if old != nil {
+		c.unindex(old)
+	}
+	c.kvs[k] = kv
+	c.index(kv)
+	return old
+}
+
+// delete removes key and returns the removed key-value, if any.
+func (c *cache) delete(key string) *mvccpb.KeyValue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := c.kvs[key]
+	if old != nil {
+		c.unindex(old)
+		delete(c.kvs, key)
+	}
+	return old
+}
+
+func (c *cache) setRevision(rev int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rev > c.rev {
+		c.rev = rev
+	}
+}
+
+func (c *cache) index(kv *mvccpb.KeyValue) {
+	for name, f := range c.indexers {
+		for _, v := range f(kv) {
+			keys := c.indices[name][v]
+			if keys == nil {
+				keys = make(map[string]struct{})
+				c.indices[name][v] = keys
+			}
+			keys[string(kv.Key)] = struct{}{}
+		}
+	}
+}
+
+func (c *cache) unindex(kv *mvccpb.KeyValue) {
+	for name, f := range c.indexers {
+		for _, v := range f(kv) {
+			keys := c.indices[name][v]
+			delete(keys, string(kv.Key))
+			if len(keys) == 0 {
+				delete(c.indices[name], v)
+			}
+		}
+	}
+}
+
+func sortKVs(kvs []*mvccpb.KeyValue) {
+	sort.Slice(kvs, func(i, j int) bool { return string(kvs[i].Key) < string(kvs[j].Key) })
+}
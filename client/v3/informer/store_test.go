@@ -0,0 +1,61 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func TestCacheIndex(t *testing.T) {
+	c := newCache(Indexers{
+		"labels": func(kv *mvccpb.KeyValue) []string { return strings.Split(string(kv.Value), ",") },
+	})
+	c.put(&mvccpb.KeyValue{Key: []byte("b"), Value: []byte("x,y")})
+	c.put(&mvccpb.KeyValue{Key: []byte("a"), Value: []byte("y")})
+
+	keys := func(label string) []string {
+		kvs, err := c.ByIndex("labels", label)
+		require.NoError(t, err)
+		var keys []string
+		for _, kv := range kvs {
+			keys = append(keys, string(kv.Key))
+		}
+		return keys
+	}
+	assert.Equal(t, []string{"b"}, keys("x"))
+	assert.Equal(t, []string{"a", "b"}, keys("y"))
+
+	old := c.put(&mvccpb.KeyValue{Key: []byte("b"), Value: []byte("z")})
+	assert.Equal(t, "x,y", string(old.Value))
+	assert.Nil(t, keys("x"))
+	assert.Equal(t, []string{"a"}, keys("y"))
+	assert.Equal(t, []string{"b"}, keys("z"))
+
+	old = c.delete("a")
+	assert.Equal(t, "y", string(old.Value))
+	assert.Nil(t, c.delete("a"))
+	assert.Nil(t, keys("y"))
+	assert.Equal(t, []string{"b"}, c.ListKeys())
+	assert.Len(t, c.indices["labels"], 1)
+
+	_, err := c.ByIndex("missing", "x")
+	assert.Equal(t, ErrUnknownIndex, err)
+}
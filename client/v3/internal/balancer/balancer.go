@@ -0,0 +1,143 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package balancer implements the gRPC load balancing policies of the
+// client spreading requests over endpoints by weight or sending them to
+// the first available endpoint.
+package balancer
+
+import (
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	// WeightedRoundRobin spreads requests over the ready endpoints in
+	// proportion to their weights.
+	WeightedRoundRobin = "etcd_weighted_round_robin"
+	// PrimaryFallback sends requests to the first ready endpoint in the
+	// order of the endpoints.
+	PrimaryFallback = "etcd_primary_fallback"
+)
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(WeightedRoundRobin, &wrrPickerBuilder{}, base.Config{HealthCheck: true}))
+	balancer.Register(base.NewBalancerBuilder(PrimaryFallback, &pfPickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+type endpointInfoKey struct{}
+
+// endpointInfo is the position and weight of the endpoint of an address.
+type endpointInfo struct {
+	order  int
+	weight int
+}
+
+// SetEndpointInfo returns the address holding the position of its endpoint
+// in the list of endpoints and its weight, for the balancers to pick from.
+// They are set as attributes of the address rather than balancer
+// attributes, so that a new SubConn replaces the one of an endpoint whose
+// position or weight changes.
+func SetEndpointInfo(addr resolver.Address, order, weight int) resolver.Address {
+	addr.Attributes = addr.Attributes.WithValue(endpointInfoKey{}, endpointInfo{order: order, weight: weight})
+	return addr
+}
+
+func getEndpointInfo(addr resolver.Address) endpointInfo {
+	info, ok := addr.Attributes.Value(endpointInfoKey{}).(endpointInfo)
+	if !ok {
+		return endpointInfo{weight: 1}
+	}
+	return info
+}
+
+// weightedSubConn is a ready SubConn and the position and weight of its
+// endpoint.
+type weightedSubConn struct {
+	sc balancer.SubConn
+	endpointInfo
+	// current is the running weight of the smooth weighted round-robin.
+	current int
+}
+
+// sortedSubConns returns the ready SubConns in the order of their
+// endpoints, so that the picks are deterministic.
+func sortedSubConns(info base.PickerBuildInfo) []*weightedSubConn {
+	scs := make([]*weightedSubConn, 0, len(info.ReadySCs))
+	for sc, sci := range info.ReadySCs {
+		scs = append(scs, &weightedSubConn{sc: sc, endpointInfo: getEndpointInfo(sci.Address)})
+	}
+	sort.SliceStable(scs, func(i, j int) bool { return scs[i].order < scs[j].order })
+	return scs
+}
+
+type wrrPickerBuilder struct{}
+
+func (*wrrPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	p := &wrrPicker{scs: sortedSubConns(info)}
+	for _, sc := range p.scs {
+		p.total += sc.weight
+	}
+	return p
+}
+
+// wrrPicker is a smooth weighted round-robin picker: every endpoint is
+// picked in proportion to its weight, and the picks of an endpoint are
+// interleaved with the picks of the others rather than done in a row.
+type wrrPicker struct {
+	mu    sync.Mutex
+	scs   []*weightedSubConn
+	total int
+}
+
+func (p *wrrPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var picked *weightedSubConn
+	for _, sc := range p.scs {
+		sc.current += sc.weight
+		if picked == nil || sc.current > picked.current {
+			picked = sc
+		}
+	}
+	picked.current -= p.total
+	return balancer.PickResult{SubConn: picked.sc}, nil
+}
+
+type pfPickerBuilder struct{}
+
+func (*pfPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	return &pfPicker{sc: sortedSubConns(info)[0].sc}
+}
+
+// pfPicker picks the ready SubConn of the first endpoint. The picker is
+// rebuilt as SubConns become ready or not, moving requests back to an
+// earlier endpoint once it recovers.
+type pfPicker struct {
+	sc balancer.SubConn
+}
+
+func (p *pfPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	return balancer.PickResult{SubConn: p.sc}, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balancer
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+type testSubConn struct {
+	balancer.SubConn
+	name string
+}
+
+func buildInfo(weights ...int) base.PickerBuildInfo {
+	info := base.PickerBuildInfo{ReadySCs: make(map[balancer.SubConn]base.SubConnInfo)}
+	for i, w := range weights {
+		sc := &testSubConn{name: string(rune('a' + i))}
+		info.ReadySCs[sc] = base.SubConnInfo{Address: SetEndpointInfo(resolver.Address{Addr: sc.name}, i, w)}
+	}
+	return info
+}
+
+func picks(t *testing.T, p balancer.Picker, n int) string {
+	var s string
+	for i := 0; i < n; i++ {
+		res, err := p.Pick(balancer.PickInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s += res.SubConn.(*testSubConn).name
+	}
+	return s
+}
+
+func TestWeightedRoundRobinPicker(t *testing.T) {
+	tests := []struct {
+		weights []int
+		wpicks  string
+	}{
+		{[]int{1, 1, 1}, "abcabc"},
+		{[]int{5, 1, 1}, "aabacaa"},
+		{[]int{2, 1}, "abaaba"},
+	}
+	for _, tt := range tests {
+		p := (&wrrPickerBuilder{}).Build(buildInfo(tt.weights...))
+		if s := picks(t, p, len(tt.wpicks)); s != tt.wpicks {
+			t.Errorf("weights %v: expected picks %q, got %q", tt.weights, tt.wpicks, s)
+		}
+	}
+}
+
+func TestPrimaryFallbackPicker(t *testing.T) {
+	info := buildInfo(1, 1, 1)
+	p := (&pfPickerBuilder{}).Build(info)
+	if s := picks(t, p, 3); s != "aaa" {
+		t.Fatalf("expected picks %q, got %q", "aaa", s)
+	}
+
+	// the primary is no longer ready
+	for sc := range info.ReadySCs {
+		if sc.(*testSubConn).name == "a" {
+			delete(info.ReadySCs, sc)
+		}
+	}
+	p = (&pfPickerBuilder{}).Build(info)
+	if s := picks(t, p, 3); s != "bbb" {
+		t.Fatalf("expected picks %q, got %q", "bbb", s)
+	}
+
+	if _, err := (&pfPickerBuilder{}).Build(base.PickerBuildInfo{}).Pick(balancer.PickInfo{}); err != balancer.ErrNoSubConnAvailable {
+		t.Fatalf("expected %v, got %v", balancer.ErrNoSubConnAvailable, err)
+	}
+}
@@ -15,11 +15,13 @@
 package endpoint
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/url"
 	"path"
 	"strings"
+	"time"
 )
 
 type CredsRequirement int
@@ -136,3 +138,18 @@ func Interpret(ep string) (address string, serverName string) {
 	addr, serverName, _ := translateEndpoint(ep)
 	return addr, serverName
 }
+
+// Dialer returns a gRPC context dialer for the addresses returned by Interpret.
+// Hosts resolving to both IPv6 and IPv4 addresses are dialed with the given
+// fallback delay between the address families, as in net.Dialer.
+func Dialer(fallbackDelay time.Duration) func(ctx context.Context, addr string) (net.Conn, error) {
+	d := &net.Dialer{FallbackDelay: fallbackDelay}
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		if strings.HasPrefix(addr, "unix:") {
+			// "unix://absolute-path" or "unix:relative-path"
+			path := strings.TrimPrefix(strings.TrimPrefix(addr, "unix:"), "//")
+			return d.DialContext(ctx, "unix", path)
+		}
+		return d.DialContext(ctx, "tcp", addr)
+	}
+}
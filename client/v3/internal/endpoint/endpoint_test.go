@@ -15,7 +15,11 @@
 package endpoint
 
 import (
+	"context"
+	"net"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func Test_interpret(t *testing.T) {
@@ -97,3 +101,40 @@ func Test_extractHostFromHostPort(t *testing.T) {
 		})
 	}
 }
+
+func Test_dialer(t *testing.T) {
+	tcpLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcpLn.Close()
+	unixLn, err := net.Listen("unix", filepath.Join(t.TempDir(), "etcd.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unixLn.Close()
+
+	tests := []struct {
+		endpoint string
+		ln       net.Listener
+	}{
+		{"http://" + tcpLn.Addr().String(), tcpLn},
+		{"unix://" + unixLn.Addr().String(), unixLn},
+	}
+	dial := Dialer(-1)
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			addr, _ := Interpret(tt.endpoint)
+			conn, err := dial(ctx, addr)
+			if err != nil {
+				t.Fatalf("failed to dial %q: %v", addr, err)
+			}
+			defer conn.Close()
+			if got, want := conn.RemoteAddr().String(), tt.ln.Addr().String(); got != want {
+				t.Errorf("remote address = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -15,6 +15,9 @@
 package resolver
 
 import (
+	"fmt"
+
+	"go.etcd.io/etcd/client/v3/internal/balancer"
 	"go.etcd.io/etcd/client/v3/internal/endpoint"
 	"google.golang.org/grpc/resolver"
 	"google.golang.org/grpc/resolver/manual"
@@ -31,16 +34,35 @@ type EtcdManualResolver struct {
 	*manual.Resolver
 	endpoints     []string
 	serviceConfig *serviceconfig.ParseResult
+
+	// policy is the load balancing policy of the endpoints, and weights
+	// their weights under the weighted round-robin policy.
+	policy  string
+	weights map[string]int
 }
 
 func New(endpoints ...string) *EtcdManualResolver {
 	r := manual.NewBuilderWithScheme(Schema)
-	return &EtcdManualResolver{Resolver: r, endpoints: endpoints, serviceConfig: nil}
+	return &EtcdManualResolver{Resolver: r, endpoints: endpoints, serviceConfig: nil, policy: "round_robin"}
+}
+
+// SetBalancing selects the load balancing policy of the endpoints, before
+// the resolver is built. Weighted endpoints are balanced by a weighted
+// round-robin unless primaryFallback is set, in which case requests go to
+// the first available endpoint in the order of the endpoints.
+func (r *EtcdManualResolver) SetBalancing(weights map[string]int, primaryFallback bool) {
+	switch {
+	case primaryFallback:
+		r.policy = balancer.PrimaryFallback
+	case len(weights) > 0:
+		r.policy = balancer.WeightedRoundRobin
+	}
+	r.weights = weights
 }
 
 // Build returns itself for Resolver, because it's both a builder and a resolver.
 func (r *EtcdManualResolver) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
-	r.serviceConfig = cc.ParseServiceConfig(`{"loadBalancingPolicy": "round_robin"}`)
+	r.serviceConfig = cc.ParseServiceConfig(fmt.Sprintf(`{"loadBalancingPolicy": %q}`, r.policy))
 	if r.serviceConfig.Err != nil {
 		return nil, r.serviceConfig.Err
 	}
@@ -64,6 +86,13 @@ func (r EtcdManualResolver) updateState() {
 		for i, ep := range r.endpoints {
 			addr, serverName := endpoint.Interpret(ep)
 			addresses[i] = resolver.Address{Addr: addr, ServerName: serverName}
+			if r.policy != "round_robin" {
+				weight, ok := r.weights[ep]
+				if !ok {
+					weight = 1
+				}
+				addresses[i] = balancer.SetEndpointInfo(addresses[i], i, weight)
+			}
 		}
 		state := resolver.State{
 			Addresses:     addresses,
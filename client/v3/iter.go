@@ -0,0 +1,126 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package clientv3
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	v3rpc "go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+const (
+	// keysPageSize is the number of keys fetched by each request of Keys.
+	keysPageSize = 1000
+	// eventsRetryDelay is how long Events waits before re-establishing a closed watch.
+	eventsRetryDelay = 500 * time.Millisecond
+)
+
+// errStopIteration stops the pages of Keys once the loop body breaks out.
+var errStopIteration = errors.New("etcdclient: iteration stopped")
+
+// Keys returns an iterator over the key-value pairs with the given prefix,
+// sorted by key and fetched in pages at the revision of the first page.
+// Further options, such as WithRev or WithKeysOnly, apply to every page.
+// An error ends the iteration after being yielded with a nil key-value pair:
+//
+//	for kv, err := range cli.Keys(ctx, "foo/") {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Client) Keys(ctx context.Context, prefix string, opts ...OpOption) iter.Seq2[*mvccpb.KeyValue, error] {
+	return func(yield func(*mvccpb.KeyValue, error) bool) {
+		getOpts := append([]OpOption{WithPrefix(), WithAutoPaging(keysPageSize)}, opts...)
+		getOpts = append(getOpts, WithPageHandler(func(page *GetResponse) error {
+			for _, kv := range page.Kvs {
+				if !yield(kv, nil) {
+					return errStopIteration
+				}
+			}
+			return nil
+		}))
+		if _, err := c.KV.Get(ctx, prefix, getOpts...); err != nil && err != errStopIteration {
+			yield(nil, err)
+		}
+	}
+}
+
+// Events returns an iterator over the events of the given key, or of the
+// range given by the options as for Watch. The watch is re-established from
+// the revision following the last event whenever it is closed, for instance
+// on leader loss, so that no event is missed or repeated. An error, such as
+// ErrCompacted or the error of the context once done, ends the iteration after
+// being yielded with a nil event.
+func (c *Client) Events(ctx context.Context, key string, opts ...OpOption) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		rev := opWatch(key, opts...).rev
+		for {
+			stop := c.watchEvents(ctx, key, &rev, yield, opts)
+			if stop {
+				return
+			}
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			if err := c.ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			select {
+			case <-time.After(eventsRetryDelay):
+			case <-ctx.Done():
+			}
+		}
+	}
+}
+
+// watchEvents yields the events of a single watch starting at *rev, which it
+// advances past every event yielded. It returns true once the iteration is over.
+func (c *Client) watchEvents(ctx context.Context, key string, rev *int64, yield func(*Event, error) bool, opts []OpOption) bool {
+	wctx, cancel := context.WithCancel(WithRequireLeader(ctx))
+	defer cancel()
+
+	wopts := append([]OpOption{WithCreatedNotify()}, opts...)
+	if *rev != 0 {
+		wopts = append(wopts, WithRev(*rev))
+	}
+	for wresp := range c.Watcher.Watch(wctx, key, wopts...) {
+		if err := wresp.Err(); err != nil {
+			if err == v3rpc.ErrNoLeader {
+				return false
+			}
+			yield(nil, err)
+			return true
+		}
+		if *rev == 0 || wresp.IsProgressNotify() {
+			*rev = wresp.Header.Revision + 1
+		}
+		for _, ev := range wresp.Events {
+			*rev = ev.Kv.ModRevision + 1
+			if !yield(ev, nil) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -16,10 +16,13 @@ package clientv3
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	"google.golang.org/grpc"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 )
 
@@ -31,6 +34,10 @@ type (
 	TxnResponse     pb.TxnResponse
 )
 
+// ErrInvalidAutoPagingSort is returned by auto paging 'Get' requests sorted by
+// anything but ascending keys, which cannot be split into pages.
+var ErrInvalidAutoPagingSort = errors.New("etcdclient: auto paging requires a range sorted by ascending key")
+
 type KV interface {
 	// Put puts a key-value pair into etcd.
 	// Note that key,value can be plain bytes array and string is
@@ -46,8 +53,17 @@ type KV interface {
 	// if the required revision is compacted, the request will fail with ErrCompacted .
 	// When passed WithLimit(limit), the number of returned keys is bounded by limit.
 	// When passed WithSort(), the keys will be sorted.
+	// When passed WithAutoPaging(pageSize), the keys are fetched in pages of at most
+	// pageSize keys at the revision of the first page.
 	Get(ctx context.Context, key string, opts ...OpOption) (*GetResponse, error)
 
+	// GetStream retrieves keys like Get, but receives them in chunks over the
+	// RangeStream RPC, which reads them page by page at the revision of the
+	// first page. Large ranges are thus bounded neither by the maximum message
+	// size nor by the memory of the server. GetStream returns once the first
+	// chunk is received; the iterator must be closed once done with.
+	GetStream(ctx context.Context, key string, opts ...OpOption) (GetIterator, error)
+
 	// Delete deletes a key, or optionally using WithRange(end), [key, end).
 	Delete(ctx context.Context, key string, opts ...OpOption) (*DeleteResponse, error)
 
@@ -65,6 +81,28 @@ type KV interface {
 	Txn(ctx context.Context) Txn
 }
 
+// GetIterator iterates over the key-value pairs of a range received in chunks.
+type GetIterator interface {
+	// Next advances to the next key-value pair, receiving the next chunk of
+	// the range when needed. It returns false once the range is exhausted or
+	// on error.
+	Next() bool
+	// KV returns the current key-value pair.
+	KV() *mvccpb.KeyValue
+	// Header returns the header of the range, whose revision all the
+	// key-value pairs are read at.
+	Header() *pb.ResponseHeader
+	// Count returns the number of keys within the range.
+	Count() int64
+	// More reports, once Next returned false, if there are more keys to
+	// return in the requested range than its limit.
+	More() bool
+	// Err returns the error that ended the iteration, if any.
+	Err() error
+	// Close stops receiving the range.
+	Close()
+}
+
 type OpResponse struct {
 	put *PutResponse
 	get *GetResponse
@@ -91,14 +129,19 @@ func (resp *TxnResponse) OpResponse() OpResponse {
 }
 
 type kv struct {
-	remote   pb.KVClient
-	callOpts []grpc.CallOption
+	remote pb.KVClient
+	// readRemote serves the serializable gets, if set.
+	readRemote pb.KVClient
+	callOpts   []grpc.CallOption
 }
 
 func NewKV(c *Client) KV {
 	api := &kv{remote: RetryKVClient(c)}
 	if c != nil {
 		api.callOpts = c.callOpts
+		if c.readConn != nil {
+			api.readRemote = &retryKVClient{kc: pb.NewKVClient(c.readConn)}
+		}
 	}
 	return api
 }
@@ -121,6 +164,25 @@ func (kv *kv) Get(ctx context.Context, key string, opts ...OpOption) (*GetRespon
 	return r.get, toErr(ctx, err)
 }
 
+func (kv *kv) GetStream(ctx context.Context, key string, opts ...OpOption) (GetIterator, error) {
+	op := OpGet(key, opts...)
+	if !op.IsSortOptionValid() {
+		return nil, rpctypes.ErrInvalidSortOption
+	}
+	sctx, cancel := context.WithCancel(ctx)
+	stream, err := kv.rangeRemote(op).RangeStream(sctx, op.toRangeRequest(), kv.callOpts...)
+	if err != nil {
+		cancel()
+		return nil, toErr(ctx, err)
+	}
+	it := &getIterator{ctx: ctx, cancel: cancel, stream: stream, hdr: &pb.ResponseHeader{}}
+	if err = it.recv(); err != nil {
+		cancel()
+		return nil, err
+	}
+	return it, nil
+}
+
 func (kv *kv) Delete(ctx context.Context, key string, opts ...OpOption) (*DeleteResponse, error) {
 	r, err := kv.Do(ctx, OpDelete(key, opts...))
 	return r.del, toErr(ctx, err)
@@ -146,18 +208,24 @@ func (kv *kv) Do(ctx context.Context, op Op) (OpResponse, error) {
 	var err error
 	switch op.t {
 	case tRange:
-		if op.IsSortOptionValid() {
+		if !op.IsSortOptionValid() {
+			err = rpctypes.ErrInvalidSortOption
+		} else if op.pageSize > 0 {
+			var resp *GetResponse
+			resp, err = kv.getPages(ctx, op)
+			if err == nil {
+				return OpResponse{get: resp}, nil
+			}
+		} else {
 			var resp *pb.RangeResponse
-			resp, err = kv.remote.Range(ctx, op.toRangeRequest(), kv.callOpts...)
+			resp, err = kv.rangeRemote(op).Range(ctx, op.toRangeRequest(), kv.callOpts...)
 			if err == nil {
 				return OpResponse{get: (*GetResponse)(resp)}, nil
 			}
-		} else {
-			err = rpctypes.ErrInvalidSortOption
 		}
 	case tPut:
 		var resp *pb.PutResponse
-		r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID), PrevKv: op.prevKV, IgnoreValue: op.ignoreValue, IgnoreLease: op.ignoreLease}
+		r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID), PrevKv: op.prevKV, IgnoreValue: op.ignoreValue, IgnoreLease: op.ignoreLease, Metadata: op.metadata, IgnoreMetadata: op.ignoreMetadata, Ttl: op.ttl}
 		resp, err = kv.remote.Put(ctx, r, kv.callOpts...)
 		if err == nil {
 			return OpResponse{put: (*PutResponse)(resp)}, nil
@@ -180,3 +248,120 @@ func (kv *kv) Do(ctx context.Context, op Op) (OpResponse, error) {
 	}
 	return OpResponse{}, toErr(ctx, err)
 }
+
+// rangeRemote returns the client serving the get op.
+func (kv *kv) rangeRemote(op Op) pb.KVClient {
+	if op.serializable && kv.readRemote != nil {
+		return kv.readRemote
+	}
+	return kv.remote
+}
+
+// getPages serves an auto paging range with successive ranges of at most
+// op.pageSize keys, each starting after the last key of the previous one and
+// pinned at the revision of the first one.
+func (kv *kv) getPages(ctx context.Context, op Op) (*GetResponse, error) {
+	if op.sort != nil && (op.sort.Target != SortByKey || op.sort.Order == SortDescend) {
+		return nil, ErrInvalidAutoPagingSort
+	}
+	req, remote := op.toRangeRequest(), kv.rangeRemote(op)
+	if len(req.RangeEnd) == 0 || req.CountOnly {
+		resp, err := remote.Range(ctx, req, kv.callOpts...)
+		if err != nil {
+			return nil, err
+		}
+		return (*GetResponse)(resp), nil
+	}
+
+	var (
+		ret *GetResponse
+		n   int64
+	)
+	for {
+		req.Limit = op.pageSize
+		if op.limit > 0 && op.limit-n < req.Limit {
+			req.Limit = op.limit - n
+		}
+		resp, err := remote.Range(ctx, req, kv.callOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if ret == nil {
+			ret = &GetResponse{Header: resp.Header, Count: resp.Count}
+			req.Revision = resp.Header.Revision
+		}
+		n += int64(len(resp.Kvs))
+		var next []byte
+		if len(resp.Kvs) > 0 {
+			// the handler may modify the page
+			last := resp.Kvs[len(resp.Kvs)-1].Key
+			next = append(append(make([]byte, 0, len(last)+1), last...), 0)
+		}
+		if op.pageHandler != nil {
+			if err = op.pageHandler((*GetResponse)(resp)); err != nil {
+				return nil, err
+			}
+		} else {
+			ret.Kvs = append(ret.Kvs, resp.Kvs...)
+		}
+		if !resp.More || len(resp.Kvs) == 0 || (op.limit > 0 && n >= op.limit) {
+			ret.More = resp.More
+			return ret, nil
+		}
+		req.Key = next
+	}
+}
+
+// getIterator iterates over the chunks of a RangeStream RPC.
+type getIterator struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	stream pb.KV_RangeStreamClient
+
+	hdr   *pb.ResponseHeader
+	count int64
+	more  bool
+	// kvs are the key-value pairs of the last chunk not iterated over yet.
+	kvs []*mvccpb.KeyValue
+	kv  *mvccpb.KeyValue
+	// done is set once the stream is over.
+	done bool
+	err  error
+}
+
+// recv receives the next chunk of the stream.
+func (it *getIterator) recv() error {
+	chunk, err := it.stream.Recv()
+	if err == io.EOF {
+		it.done = true
+		return nil
+	}
+	if err != nil {
+		return toErr(it.ctx, err)
+	}
+	if chunk.Header != nil {
+		it.hdr = chunk.Header
+	}
+	it.count, it.more, it.kvs = chunk.Count, chunk.More, chunk.Kvs
+	return nil
+}
+
+func (it *getIterator) Next() bool {
+	for len(it.kvs) == 0 {
+		if it.done || it.err != nil {
+			it.kv = nil
+			it.cancel()
+			return false
+		}
+		it.err = it.recv()
+	}
+	it.kv, it.kvs = it.kvs[0], it.kvs[1:]
+	return true
+}
+
+func (it *getIterator) KV() *mvccpb.KeyValue       { return it.kv }
+func (it *getIterator) Header() *pb.ResponseHeader { return it.hdr }
+func (it *getIterator) Count() int64               { return it.count }
+func (it *getIterator) More() bool                 { return it.more }
+func (it *getIterator) Err() error                 { return it.err }
+func (it *getIterator) Close()                     { it.cancel() }
@@ -74,6 +74,23 @@ type LeaseLeasesResponse struct {
 	Leases []LeaseStatus `json:"leases"`
 }
 
+// LeaseTopStatus represents a lease and the number of keys attached to it.
+type LeaseTopStatus struct {
+	ID LeaseID `json:"id"`
+
+	// GrantedTTL is the initial granted time in seconds upon lease creation/renewal.
+	GrantedTTL int64 `json:"granted-ttl"`
+
+	// KeyCount is the number of keys attached to the lease.
+	KeyCount int64 `json:"key-count"`
+}
+
+// LeaseTopResponse wraps the protobuf message LeaseTopResponse.
+type LeaseTopResponse struct {
+	*pb.ResponseHeader
+	Leases []LeaseTopStatus `json:"leases"`
+}
+
 const (
 	// defaultTTL is the assumed lease TTL used for the first keepalive
 	// deadline before the actual TTL is known to the client.
@@ -107,7 +124,7 @@ func (e ErrKeepAliveHalted) Error() string {
 
 type Lease interface {
 	// Grant creates a new lease.
-	Grant(ctx context.Context, ttl int64) (*LeaseGrantResponse, error)
+	Grant(ctx context.Context, ttl int64, opts ...LeaseOption) (*LeaseGrantResponse, error)
 
 	// Revoke revokes the given lease.
 	Revoke(ctx context.Context, id LeaseID) (*LeaseRevokeResponse, error)
@@ -118,6 +135,11 @@ type Lease interface {
 	// Leases retrieves all leases.
 	Leases(ctx context.Context) (*LeaseLeasesResponse, error)
 
+	// Top retrieves the leases with the most attached keys, by descending
+	// number of keys. At most limit leases are retrieved, or all of them
+	// if limit is 0.
+	Top(ctx context.Context, limit int64) (*LeaseTopResponse, error)
+
 	// KeepAlive attempts to keep the given lease alive forever. If the keepalive responses posted
 	// to the channel are not consumed promptly the channel may become full. When full, the lease
 	// client will continue sending keep alive requests to the etcd server, but will drop responses
@@ -173,7 +195,8 @@ type lessor struct {
 
 	callOpts []grpc.CallOption
 
-	lg *zap.Logger
+	lg    *zap.Logger
+	hooks MetricsHooks
 }
 
 // keepAlive multiplexes a keepalive for a lease over multiple channels
@@ -184,6 +207,10 @@ type keepAlive struct {
 	deadline time.Time
 	// nextKeepAlive is when to send the next keep alive message
 	nextKeepAlive time.Time
+	// nextMiss is when the keep alive misses its response, and interval the
+	// keep alive interval after which it misses it again
+	nextMiss time.Time
+	interval time.Duration
 	// donec is closed on lease revoke, expiration, or cancel.
 	donec chan struct{}
 }
@@ -205,14 +232,21 @@ func NewLeaseFromLeaseClient(remote pb.LeaseClient, c *Client, keepAliveTimeout
 	}
 	if c != nil {
 		l.callOpts = c.callOpts
+		l.hooks = c.cfg.MetricsHooks
 	}
 	reqLeaderCtx := WithRequireLeader(context.Background())
 	l.stopCtx, l.stopCancel = context.WithCancel(reqLeaderCtx)
 	return l
 }
 
-func (l *lessor) Grant(ctx context.Context, ttl int64) (*LeaseGrantResponse, error) {
-	r := &pb.LeaseGrantRequest{TTL: ttl}
+func (l *lessor) Grant(ctx context.Context, ttl int64, opts ...LeaseOption) (*LeaseGrantResponse, error) {
+	op := &LeaseOp{}
+	op.applyOpts(opts)
+	r := &pb.LeaseGrantRequest{
+		TTL:                ttl,
+		KeepaliveOnWrite:   op.keepaliveOnWrite,
+		CheckpointInterval: int64((op.checkpointInterval + time.Second - 1) / time.Second),
+	}
 	resp, err := l.remote.LeaseGrant(ctx, r, l.callOpts...)
 	if err == nil {
 		gresp := &LeaseGrantResponse{
@@ -263,6 +297,18 @@ func (l *lessor) Leases(ctx context.Context) (*LeaseLeasesResponse, error) {
 	return nil, toErr(ctx, err)
 }
 
+func (l *lessor) Top(ctx context.Context, limit int64) (*LeaseTopResponse, error) {
+	resp, err := l.remote.LeaseTop(ctx, &pb.LeaseTopRequest{Limit: limit}, l.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	leases := make([]LeaseTopStatus, len(resp.Leases))
+	for i, s := range resp.Leases {
+		leases[i] = LeaseTopStatus{ID: LeaseID(s.ID), GrantedTTL: s.GrantedTTL, KeyCount: s.KeyCount}
+	}
+	return &LeaseTopResponse{ResponseHeader: resp.GetHeader(), Leases: leases}, nil
+}
+
 func (l *lessor) KeepAlive(ctx context.Context, id LeaseID) (<-chan *LeaseKeepAliveResponse, error) {
 	ch := make(chan *LeaseKeepAliveResponse, LeaseResponseChSize)
 
@@ -284,6 +330,8 @@ func (l *lessor) KeepAlive(ctx context.Context, id LeaseID) (<-chan *LeaseKeepAl
 			ctxs:          []context.Context{ctx},
 			deadline:      time.Now().Add(l.firstKeepAliveTimeout),
 			nextKeepAlive: time.Now(),
+			nextMiss:      time.Now().Add(l.firstKeepAliveTimeout),
+			interval:      l.firstKeepAliveTimeout,
 			donec:         make(chan struct{}),
 		}
 		l.keepAlives[id] = ka
@@ -528,6 +576,8 @@ func (l *lessor) recvKeepAlive(resp *pb.LeaseKeepAliveResponse) {
 	// send update to all channels
 	nextKeepAlive := time.Now().Add((time.Duration(karesp.TTL) * time.Second) / 3.0)
 	ka.deadline = time.Now().Add(time.Duration(karesp.TTL) * time.Second)
+	ka.interval = (time.Duration(karesp.TTL) * time.Second) / 3.0
+	ka.nextMiss = nextKeepAlive.Add(ka.interval)
 	for _, ch := range ka.chs {
 		select {
 		case ch <- karesp:
@@ -554,8 +604,13 @@ func (l *lessor) deadlineLoop() {
 			return
 		}
 		now := time.Now()
+		var missed []LeaseID
 		l.mu.Lock()
 		for id, ka := range l.keepAlives {
+			for l.hooks != nil && ka.interval > 0 && !ka.nextMiss.After(now) {
+				missed = append(missed, id)
+				ka.nextMiss = ka.nextMiss.Add(ka.interval)
+			}
 			if ka.deadline.Before(now) {
 				// waited too long for response; lease may be expired
 				ka.close()
@@ -563,6 +618,9 @@ func (l *lessor) deadlineLoop() {
 			}
 		}
 		l.mu.Unlock()
+		for _, id := range missed {
+			l.hooks.LeaseKeepAliveMissed(id)
+		}
 	}
 }
 
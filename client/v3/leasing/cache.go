@@ -136,7 +136,7 @@ func (lc *leaseCache) Add(key string, resp *v3.GetResponse, op v3.Op) *v3.GetRes
 	return ret
 }
 
-func (lc *leaseCache) Update(key, val []byte, respHeader *v3pb.ResponseHeader) {
+func (lc *leaseCache) Update(key, val, metadata []byte, respHeader *v3pb.ResponseHeader) {
 	li := lc.entries[string(key)]
 	if li == nil {
 		return
@@ -155,6 +155,7 @@ func (lc *leaseCache) Update(key, val []byte, respHeader *v3pb.ResponseHeader) {
 		cacheResp.Header = respHeader
 		cacheResp.Kvs[0].ModRevision = respHeader.Revision
 		cacheResp.Kvs[0].Value = val
+		cacheResp.Kvs[0].Metadata = metadata
 	}
 }
 
@@ -182,18 +183,30 @@ func (lc *leaseCache) Evict(key string) (rev int64) {
 	return rev
 }
 
+// EvictBefore evicts key if its leasing key was created before rev.
+func (lc *leaseCache) EvictBefore(key string, rev int64) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if li := lc.entries[key]; li != nil && li.rev < rev {
+		delete(lc.entries, key)
+		lc.revokes[key] = time.Now()
+	}
+}
+
 func (lc *leaseCache) EvictRange(key, end string) {
 	lc.mu.Lock()
 	defer lc.mu.Unlock()
 	for k := range lc.entries {
 		if inRange(k, key, end) {
-			delete(lc.entries, key)
-			lc.revokes[key] = time.Now()
+			delete(lc.entries, k)
+			lc.revokes[k] = time.Now()
 		}
 	}
 }
 
-func isBadOp(op v3.Op) bool { return op.Rev() > 0 || len(op.RangeBytes()) > 0 }
+func isBadOp(op v3.Op) bool {
+	return op.Rev() > 0 || len(op.RangeBytes()) > 0 || len(op.ExcludeFields()) > 0 || op.MaxValueSize() > 0
+}
 
 func (lc *leaseCache) Get(ctx context.Context, op v3.Op) (*v3.GetResponse, bool) {
 	if isBadOp(op) {
@@ -267,7 +280,7 @@ func (lc *leaseCache) clearOldRevokes(ctx context.Context) {
 
 func (lc *leaseCache) evalCmp(cmps []v3.Cmp) (cmpVal bool, ok bool) {
 	for _, cmp := range cmps {
-		if len(cmp.RangeEnd) > 0 {
+		if len(cmp.RangeEnd) > 0 || len(cmp.Intervals) > 0 {
 			return false, false
 		}
 		lk := lc.entries[string(cmp.Key)]
@@ -42,4 +42,22 @@
 //	}
 //	lkv2.Put(context.TODO(), "abc", "456")
 //	resp, err = lkv.Get("abc")
+//
+// The owner stops serving a key from its cache as soon as the leasing key is deleted on the
+// server, such as when the lease of its session is revoked or expires.
+//
+// A leasing KV created with options can restrict the leased keys to some prefixes, such as the
+// prefix of read-mostly configuration keys, and acquire the lease of the keys it writes so they
+// are read from its cache afterwards:
+//
+//	lkv3, closeLKV3, err := leasing.NewKVWithOptions(cli, "leasing-prefix",
+//	    leasing.WithLeaseScope("config/"),
+//	    leasing.WithWriteThrough(),
+//	)
+//	if err != nil {
+//	    // handle error
+//	}
+//	defer closeLKV3()
+//	lkv3.Put(context.TODO(), "config/abc", "789")
+//	resp, err = lkv3.Get(context.TODO(), "config/abc")
 package leasing
@@ -40,9 +40,9 @@ type leasingKV struct {
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 
-	sessionOpts []concurrency.SessionOption
-	session     *concurrency.Session
-	sessionc    chan struct{}
+	opts     leasingOptions
+	session  *concurrency.Session
+	sessionc chan struct{}
 }
 
 var closedCh chan struct{}
@@ -54,16 +54,23 @@ func init() {
 
 // NewKV wraps a KV instance so that all requests are wired through a leasing protocol.
 func NewKV(cl *v3.Client, pfx string, opts ...concurrency.SessionOption) (v3.KV, func(), error) {
+	return NewKVWithOptions(cl, pfx, WithSessionOptions(opts...))
+}
+
+// NewKVWithOptions wraps a KV instance like NewKV, configured by the given options.
+func NewKVWithOptions(cl *v3.Client, pfx string, opts ...Option) (v3.KV, func(), error) {
 	cctx, cancel := context.WithCancel(cl.Ctx())
 	lkv := &leasingKV{
-		cl:          cl,
-		kv:          cl.KV,
-		pfx:         pfx,
-		leases:      leaseCache{revokes: make(map[string]time.Time)},
-		ctx:         cctx,
-		cancel:      cancel,
-		sessionOpts: opts,
-		sessionc:    make(chan struct{}),
+		cl:       cl,
+		kv:       cl.KV,
+		pfx:      pfx,
+		leases:   leaseCache{revokes: make(map[string]time.Time)},
+		ctx:      cctx,
+		cancel:   cancel,
+		sessionc: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&lkv.opts)
 	}
 	lkv.wg.Add(2)
 	go func() {
@@ -86,6 +93,12 @@ func (lkv *leasingKV) Get(ctx context.Context, key string, opts ...v3.OpOption)
 	return lkv.get(ctx, v3.OpGet(key, opts...))
 }
 
+// GetStream receives the keys from the server, bypassing the cache of the
+// leased keys, which holds the same values.
+func (lkv *leasingKV) GetStream(ctx context.Context, key string, opts ...v3.OpOption) (v3.GetIterator, error) {
+	return lkv.kv.GetStream(ctx, key, opts...)
+}
+
 func (lkv *leasingKV) Put(ctx context.Context, key, val string, opts ...v3.OpOption) (*v3.PutResponse, error) {
 	return lkv.put(ctx, v3.OpPut(key, val, opts...))
 }
@@ -107,7 +120,8 @@ func (lkv *leasingKV) Do(ctx context.Context, op v3.Op) (v3.OpResponse, error) {
 		return resp.OpResponse(), err
 	case op.IsTxn():
 		cmps, thenOps, elseOps := op.Txn()
-		resp, err := lkv.Txn(ctx).If(cmps...).Then(thenOps...).Else(elseOps...).Commit()
+		txn := &txnLeasing{Txn: lkv.kv.Txn(ctx), lkv: lkv, ctx: ctx, readYourWrites: op.IsReadYourWrites()}
+		resp, err := txn.If(cmps...).Then(thenOps...).Else(elseOps...).Commit()
 		return resp.OpResponse(), err
 	}
 	return v3.OpResponse{}, nil
@@ -139,7 +153,7 @@ func (lkv *leasingKV) monitorSession() {
 		lkv.leases.entries = make(map[string]*leaseKey)
 		lkv.leases.mu.Unlock()
 
-		s, err := concurrency.NewSession(lkv.cl, lkv.sessionOpts...)
+		s, err := concurrency.NewSession(lkv.cl, lkv.opts.sessionOpts...)
 		if err != nil {
 			continue
 		}
@@ -151,12 +165,16 @@ func (lkv *leasingKV) monitorSession() {
 	}
 }
 
-func (lkv *leasingKV) monitorLease(ctx context.Context, key string, rev int64) {
+// monitorLease watches the leasing key of key, rescinding the lease when
+// another client revokes it and evicting the key when the leasing key is
+// deleted on the cluster side, such as when the lease of the session is
+// revoked or expires.
+func (lkv *leasingKV) monitorLease(key string, rev int64) {
 	cctx, cancel := context.WithCancel(lkv.ctx)
 	defer cancel()
 	for cctx.Err() == nil {
 		if rev == 0 {
-			resp, err := lkv.kv.Get(ctx, lkv.pfx+key)
+			resp, err := lkv.kv.Get(cctx, lkv.pfx+key)
 			if err != nil {
 				continue
 			}
@@ -169,6 +187,10 @@ func (lkv *leasingKV) monitorLease(ctx context.Context, key string, rev int64) {
 		wch := lkv.cl.Watch(cctx, lkv.pfx+key, v3.WithRev(rev+1))
 		for resp := range wch {
 			for _, ev := range resp.Events {
+				if ev.Type == v3.EventTypeDelete {
+					lkv.leases.EvictBefore(key, ev.Kv.ModRevision)
+					return
+				}
 				if string(ev.Kv.Value) != "REVOKE" {
 					continue
 				}
@@ -213,6 +235,10 @@ func (lkv *leasingKV) waitRescind(ctx context.Context, key string, rev int64) er
 func (lkv *leasingKV) tryModifyOp(ctx context.Context, op v3.Op) (*v3.TxnResponse, chan<- struct{}, error) {
 	key := string(op.KeyBytes())
 	wc, rev := lkv.leases.Lock(key)
+	if wc == nil {
+		// not the owner, the write must revoke the lease of the owner if any
+		return nil, nil, nil
+	}
 	cmp := v3.Compare(v3.CreateRevision(lkv.pfx+key), "<", rev+1)
 	resp, err := lkv.kv.Txn(ctx).If(cmp).Then(op).Commit()
 	switch {
@@ -232,17 +258,25 @@ func (lkv *leasingKV) put(ctx context.Context, op v3.Op) (pr *v3.PutResponse, er
 	if err := lkv.waitSession(ctx); err != nil {
 		return nil, err
 	}
+	key := string(op.KeyBytes())
 	for ctx.Err() == nil {
 		resp, wc, err := lkv.tryModifyOp(ctx, op)
+		if err == nil && wc == nil && lkv.mayWriteThrough(key) {
+			if resp, err = lkv.writeThrough(ctx, key, op); err == nil && resp != nil {
+				pr = (*v3.PutResponse)(resp.Responses[0].GetResponsePut())
+				pr.Header = resp.Header
+				return pr, nil
+			}
+		}
 		if err != nil || wc == nil {
-			resp, err = lkv.revoke(ctx, string(op.KeyBytes()), op)
+			resp, err = lkv.revoke(ctx, key, op)
 		}
 		if err != nil {
 			return nil, err
 		}
 		if resp.Succeeded {
 			lkv.leases.mu.Lock()
-			lkv.leases.Update(op.KeyBytes(), op.ValueBytes(), resp.Header)
+			lkv.leases.Update(op.KeyBytes(), op.ValueBytes(), op.MetadataBytes(), resp.Header)
 			lkv.leases.mu.Unlock()
 			pr = (*v3.PutResponse)(resp.Responses[0].GetResponsePut())
 			pr.Header = resp.Header
@@ -257,6 +291,40 @@ func (lkv *leasingKV) put(ctx context.Context, op v3.Op) (pr *v3.PutResponse, er
 	return nil, ctx.Err()
 }
 
+func (lkv *leasingKV) mayWriteThrough(key string) bool {
+	return lkv.opts.writeThrough && lkv.opts.inScope(key) && lkv.leases.MayAcquire(key) && lkv.readySession()
+}
+
+// writeThrough applies the put op while acquiring the lease of key if no
+// client holds it, caching the written key. It returns a nil response if
+// the lease is held by a client.
+func (lkv *leasingKV) writeThrough(ctx context.Context, key string, op v3.Op) (*v3.TxnResponse, error) {
+	resp, err := lkv.kv.Txn(ctx).If(
+		v3.Compare(v3.CreateRevision(lkv.pfx+key), "=", 0),
+	).Then(
+		op,
+		v3.OpPut(lkv.pfx+key, "", v3.WithLease(lkv.leaseID())),
+		v3.OpGet(key),
+	).Commit()
+	if err != nil || !resp.Succeeded {
+		return nil, err
+	}
+	getResp := (*v3.GetResponse)(resp.Responses[2].GetResponseRange())
+	getResp.Header = resp.Header
+	if len(getResp.Kvs) > 0 && getResp.Kvs[0].Lease != 0 {
+		// keys attached to a lease expire without notice, do not cache them
+		lkv.rescind(ctx, key, resp.Header.Revision+1)
+		return resp, nil
+	}
+	lkv.leases.Add(key, getResp, v3.OpGet(key))
+	lkv.wg.Add(1)
+	go func() {
+		defer lkv.wg.Done()
+		lkv.monitorLease(key, resp.Header.Revision)
+	}()
+	return resp, nil
+}
+
 func (lkv *leasingKV) acquire(ctx context.Context, key string, op v3.Op) (*v3.TxnResponse, error) {
 	for ctx.Err() == nil {
 		if err := lkv.waitSession(ctx); err != nil {
@@ -309,7 +377,7 @@ func (lkv *leasingKV) get(ctx context.Context, op v3.Op) (*v3.GetResponse, error
 	}
 
 	key := string(op.KeyBytes())
-	if !lkv.leases.MayAcquire(key) {
+	if !lkv.opts.inScope(key) || !lkv.leases.MayAcquire(key) {
 		resp, err := lkv.kv.Do(ctx, op)
 		return resp.Get(), err
 	}
@@ -325,7 +393,7 @@ func (lkv *leasingKV) get(ctx context.Context, op v3.Op) (*v3.GetResponse, error
 		lkv.wg.Add(1)
 		go func() {
 			defer lkv.wg.Done()
-			lkv.monitorLease(ctx, key, resp.Header.Revision)
+			lkv.monitorLease(key, resp.Header.Revision)
 		}()
 	}
 	return getResp, nil
@@ -0,0 +1,70 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leasing
+
+import (
+	"strings"
+
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+type leasingOptions struct {
+	sessionOpts  []concurrency.SessionOption
+	writeThrough bool
+	scope        []string
+}
+
+// Option configures the leasing KV.
+type Option func(*leasingOptions)
+
+// WithSessionOptions configures the session whose lease the leasing keys
+// are attached to.
+func WithSessionOptions(opts ...concurrency.SessionOption) Option {
+	return func(lo *leasingOptions) {
+		lo.sessionOpts = append(lo.sessionOpts, opts...)
+	}
+}
+
+// WithWriteThrough makes a put of a key leased by no client acquire its
+// lease along with the write, so the written key is then read from the cache,
+// instead of only acquiring leases on reads.
+func WithWriteThrough() Option {
+	return func(lo *leasingOptions) {
+		lo.writeThrough = true
+	}
+}
+
+// WithLeaseScope restricts the keys leased and cached to the ones with any
+// of the given prefixes, such as the prefix of read-mostly configuration
+// keys. The other keys are read from the server, and their writes still
+// revoke the leases of the other clients. All keys are in scope by default.
+func WithLeaseScope(prefixes ...string) Option {
+	return func(lo *leasingOptions) {
+		lo.scope = append(lo.scope, prefixes...)
+	}
+}
+
+// inScope returns whether key is leased by the client.
+func (lo *leasingOptions) inScope(key string) bool {
+	if len(lo.scope) == 0 {
+		return true
+	}
+	for _, pfx := range lo.scope {
+		if strings.HasPrefix(key, pfx) {
+			return true
+		}
+	}
+	return false
+}
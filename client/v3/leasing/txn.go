@@ -29,6 +29,8 @@ type txnLeasing struct {
 	cs   []v3.Cmp
 	opst []v3.Op
 	opse []v3.Op
+
+	readYourWrites bool
 }
 
 func (txn *txnLeasing) If(cs ...v3.Cmp) v3.Txn {
@@ -172,7 +174,7 @@ func (txn *txnLeasing) commitToCache(txnResp *v3pb.TxnResponse, userTxn v3.Op) {
 			txn.lkv.leases.delete(key, txnResp.Header)
 		}
 		if op.IsPut() {
-			txn.lkv.leases.Update(op.KeyBytes(), op.ValueBytes(), txnResp.Header)
+			txn.lkv.leases.Update(op.KeyBytes(), op.ValueBytes(), op.MetadataBytes(), txnResp.Header)
 		}
 	}
 	txn.lkv.leases.mu.Unlock()
@@ -197,19 +199,26 @@ func (txn *txnLeasing) serverTxn() (*v3.TxnResponse, error) {
 	userTxn := v3.OpTxn(txn.cs, txn.opst, txn.opse)
 	fbOps := txn.fallback(userOps)
 
+	var opts []v3.OpOption
+	if txn.readYourWrites {
+		opts = append(opts, v3.WithReadYourWrites())
+	}
+
 	defer closeAll(txn.lkv.leases.LockWriteOps(userOps))
 	for {
 		cmps, err := txn.guard(userOps)
 		if err != nil {
 			return nil, err
 		}
-		resp, err := txn.lkv.kv.Txn(txn.ctx).If(cmps...).Then(userTxn).Else(fbOps...).Commit()
+		// the server only considers the option of the outermost txn
+		opResp, err := txn.lkv.kv.Do(txn.ctx, v3.OpTxn(cmps, []v3.Op{userTxn}, fbOps, opts...))
 		if err != nil {
 			for _, cmp := range cmps {
 				txn.lkv.leases.Evict(strings.TrimPrefix(string(cmp.Key), txn.lkv.pfx))
 			}
 			return nil, err
 		}
+		resp := opResp.Txn()
 		if resp.Succeeded {
 			txn.commitToCache((*v3pb.TxnResponse)(resp), userTxn)
 			userResp := resp.Responses[0].GetResponseTxn()
@@ -26,13 +26,22 @@ import (
 )
 
 type (
-	DefragmentResponse pb.DefragmentResponse
-	AlarmResponse      pb.AlarmResponse
-	AlarmMember        pb.AlarmMember
-	StatusResponse     pb.StatusResponse
-	HashKVResponse     pb.HashKVResponse
-	MoveLeaderResponse pb.MoveLeaderResponse
-	DowngradeResponse  pb.DowngradeResponse
+	DefragmentResponse   pb.DefragmentResponse
+	AlarmResponse        pb.AlarmResponse
+	AlarmMember          pb.AlarmMember
+	StatusResponse       pb.StatusResponse
+	HashKVResponse       pb.HashKVResponse
+	HashKVStreamResponse pb.HashKVStreamResponse
+	MoveLeaderResponse   pb.MoveLeaderResponse
+	DowngradeResponse    pb.DowngradeResponse
+	PurgeResponse        pb.PurgeResponse
+
+	SnapshotSettingsResponse pb.SnapshotSettingsResponse
+	PinRevisionResponse      pb.PinRevisionResponse
+	UnpinRevisionResponse    pb.UnpinRevisionResponse
+	RateLimitsResponse       pb.RateLimitsResponse
+	RateLimit                pb.RateLimit
+	EffectiveConfigResponse  pb.EffectiveConfigResponse
 
 	DowngradeAction pb.DowngradeRequest_DowngradeAction
 )
@@ -47,7 +56,9 @@ type Maintenance interface {
 	// AlarmList gets all active alarms.
 	AlarmList(ctx context.Context) (*AlarmResponse, error)
 
-	// AlarmDisarm disarms a given alarm.
+	// AlarmDisarm disarms a given alarm. An empty alarm member disarms all
+	// alarms but STANDBY, which turns a standby cluster into a primary one
+	// and must be disarmed explicitly.
 	AlarmDisarm(ctx context.Context, m *AlarmMember) (*AlarmResponse, error)
 
 	// Defragment releases wasted space from internal fragmentation on a given etcd member.
@@ -59,6 +70,12 @@ type Maintenance interface {
 	// times with different endpoints.
 	Defragment(ctx context.Context, endpoint string) (*DefragmentResponse, error)
 
+	// DefragmentOnline defragments the etcd member at the given endpoint like
+	// Defragment, but copies its storage in small batches while the member keeps
+	// serving requests, only blocking them to copy the keys written meanwhile.
+	// Supported since etcd 3.6.
+	DefragmentOnline(ctx context.Context, endpoint string) (*DefragmentResponse, error)
+
 	// Status gets the status of the endpoint.
 	Status(ctx context.Context, endpoint string) (*StatusResponse, error)
 
@@ -67,6 +84,23 @@ type Maintenance interface {
 	// is non-zero, the hash is computed on all keys at or below the given revision.
 	HashKV(ctx context.Context, endpoint string, rev int64) (*HashKVResponse, error)
 
+	// HashKVRange returns a hash of the MVCC revisions of a range of keys of
+	// the member at the given endpoint, as HashKV does for all keys. Use
+	// WithRange, WithPrefix or WithFromKey to hash a range of keys, and
+	// WithRev to hash their revisions up to a given revision.
+	// Supported since etcd 3.6.
+	HashKVRange(ctx context.Context, endpoint, key string, opts ...OpOption) (*HashKVResponse, error)
+
+	// HashKVStream hashes the keys of a range of the member at the given
+	// endpoint at a revision, calling f with the hashes of consecutive chunks
+	// of up to chunkSize keys of the range as they are streamed. The last
+	// chunk carries the hash of the whole range. Use WithRange, WithPrefix
+	// or WithFromKey to hash a range of keys, and WithRev to hash them at a
+	// revision other than the current one. HashKVStream stops at the first
+	// error returned by f.
+	// Supported since etcd 3.6.
+	HashKVStream(ctx context.Context, endpoint, key string, chunkSize int64, f func(*HashKVStreamResponse) error, opts ...OpOption) error
+
 	// SnapshotWithVersion returns a reader for a point-in-time snapshot and version of etcd that created it.
 	// If the context "ctx" is canceled or timed out, reading from returned
 	// "io.ReadCloser" would error out (e.g. context.Canceled, context.DeadlineExceeded).
@@ -86,6 +120,48 @@ type Maintenance interface {
 	// on the cluster version.
 	// Supported since etcd 3.5.
 	Downgrade(ctx context.Context, action DowngradeAction, version string) (*DowngradeResponse, error)
+
+	// Purge removes the revisions of keys that were deleted at or before the
+	// given revision, without compacting the rest of the keyspace.
+	// Use WithRange or WithPrefix to purge a range of keys, and WithRev to
+	// bound the tombstones removed. If no revision is given, the current
+	// revision is used.
+	// Supported since etcd 3.6.
+	Purge(ctx context.Context, key string, opts ...OpOption) (*PurgeResponse, error)
+
+	// SnapshotSettings updates the snapshot count and the number of raft log
+	// entries kept for slow followers of the member at the given endpoint,
+	// and returns the settings in effect. Zero values leave a setting unchanged.
+	// The settings are reset to the configured ones when the member restarts.
+	// Supported since etcd 3.6.
+	SnapshotSettings(ctx context.Context, endpoint string, snapshotCount, snapshotCatchUpEntries uint64) (*SnapshotSettingsResponse, error)
+
+	// PinRevision keeps the automatic compaction from compacting past the
+	// given revision until the pin is removed with UnpinRevision or ttl
+	// seconds elapse, for long reads of the revision such as backups. If id
+	// is zero, the server chooses the ID of the pin; pinning an existing ID
+	// replaces the pin, which refreshes its TTL. If rev is zero, the current
+	// revision is pinned. If ttl is zero, the pin lasts until it is removed.
+	// Supported since etcd 3.6.
+	PinRevision(ctx context.Context, id, rev, ttl int64) (*PinRevisionResponse, error)
+
+	// UnpinRevision removes the pin with the given ID.
+	// Supported since etcd 3.6.
+	UnpinRevision(ctx context.Context, id int64) (*UnpinRevisionResponse, error)
+
+	// RateLimits sets the given limits of the rates of the requests to key
+	// prefixes of the member at the given endpoint, and returns the limits in
+	// effect. A limit replaces the one with the same prefix and per user
+	// setting, and a limit with a zero rate removes it. The limits are reset
+	// to the configured ones when the member restarts.
+	// Supported since etcd 3.6.
+	RateLimits(ctx context.Context, endpoint string, limits ...RateLimit) (*RateLimitsResponse, error)
+
+	// EffectiveConfig gets the configuration of the member at the given
+	// endpoint, as resolved from its flags, environment variables,
+	// configuration file and defaults, with the secrets redacted.
+	// Supported since etcd 3.6.
+	EffectiveConfig(ctx context.Context, endpoint string) (*EffectiveConfigResponse, error)
 }
 
 // SnapshotResponse is aggregated response from the snapshot stream.
@@ -183,6 +259,9 @@ func (m *maintenance) AlarmDisarm(ctx context.Context, am *AlarmMember) (*AlarmR
 		}
 		ret := AlarmResponse{}
 		for _, am := range ar.Alarms {
+			if am.Alarm == pb.AlarmType_STANDBY {
+				continue
+			}
 			dresp, derr := m.AlarmDisarm(ctx, (*AlarmMember)(am))
 			if derr != nil {
 				return nil, toErr(ctx, derr)
@@ -200,12 +279,20 @@ func (m *maintenance) AlarmDisarm(ctx context.Context, am *AlarmMember) (*AlarmR
 }
 
 func (m *maintenance) Defragment(ctx context.Context, endpoint string) (*DefragmentResponse, error) {
+	return m.defragment(ctx, endpoint, &pb.DefragmentRequest{})
+}
+
+func (m *maintenance) DefragmentOnline(ctx context.Context, endpoint string) (*DefragmentResponse, error) {
+	return m.defragment(ctx, endpoint, &pb.DefragmentRequest{Online: true})
+}
+
+func (m *maintenance) defragment(ctx context.Context, endpoint string, r *pb.DefragmentRequest) (*DefragmentResponse, error) {
 	remote, cancel, err := m.dial(endpoint)
 	if err != nil {
 		return nil, toErr(ctx, err)
 	}
 	defer cancel()
-	resp, err := remote.Defragment(ctx, &pb.DefragmentRequest{}, m.callOpts...)
+	resp, err := remote.Defragment(ctx, r, m.callOpts...)
 	if err != nil {
 		return nil, toErr(ctx, err)
 	}
@@ -239,6 +326,48 @@ func (m *maintenance) HashKV(ctx context.Context, endpoint string, rev int64) (*
 	return (*HashKVResponse)(resp), nil
 }
 
+func (m *maintenance) HashKVRange(ctx context.Context, endpoint, key string, opts ...OpOption) (*HashKVResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	op := OpGet(key, opts...)
+	resp, err := remote.HashKV(ctx, &pb.HashKVRequest{Key: op.key, RangeEnd: op.end, Revision: op.rev}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*HashKVResponse)(resp), nil
+}
+
+func (m *maintenance) HashKVStream(ctx context.Context, endpoint, key string, chunkSize int64, f func(*HashKVStreamResponse) error, opts ...OpOption) error {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return toErr(ctx, err)
+	}
+	defer cancel()
+	op := OpGet(key, opts...)
+	sctx, scancel := context.WithCancel(ctx)
+	defer scancel()
+	r := &pb.HashKVStreamRequest{Key: op.key, RangeEnd: op.end, Revision: op.rev, ChunkSize: chunkSize}
+	stream, err := remote.HashKVStream(sctx, r, m.callOpts...)
+	if err != nil {
+		return toErr(ctx, err)
+	}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return toErr(ctx, err)
+		}
+		if err = f((*HashKVStreamResponse)(resp)); err != nil {
+			return err
+		}
+	}
+}
+
 func (m *maintenance) SnapshotWithVersion(ctx context.Context) (*SnapshotResponse, error) {
 	ss, err := m.remote.Snapshot(ctx, &pb.SnapshotRequest{}, append(m.callOpts, withMax(defaultStreamMaxRetries))...)
 	if err != nil {
@@ -357,3 +486,71 @@ func (m *maintenance) Downgrade(ctx context.Context, action DowngradeAction, ver
 	resp, err := m.remote.Downgrade(ctx, &pb.DowngradeRequest{Action: actionType, Version: version}, m.callOpts...)
 	return (*DowngradeResponse)(resp), toErr(ctx, err)
 }
+
+func (m *maintenance) Purge(ctx context.Context, key string, opts ...OpOption) (*PurgeResponse, error) {
+	op := OpGet(key, opts...)
+	r := &pb.PurgeRequest{Key: op.key, RangeEnd: op.end, Revision: op.rev}
+	resp, err := m.remote.Purge(ctx, r, m.callOpts...)
+	return (*PurgeResponse)(resp), toErr(ctx, err)
+}
+
+func (m *maintenance) SnapshotSettings(ctx context.Context, endpoint string, snapshotCount, snapshotCatchUpEntries uint64) (*SnapshotSettingsResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	r := &pb.SnapshotSettingsRequest{SnapshotCount: snapshotCount, SnapshotCatchupEntries: snapshotCatchUpEntries}
+	resp, err := remote.SnapshotSettings(ctx, r, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*SnapshotSettingsResponse)(resp), nil
+}
+
+func (m *maintenance) PinRevision(ctx context.Context, id, rev, ttl int64) (*PinRevisionResponse, error) {
+	r := &pb.PinRevisionRequest{ID: id, Revision: rev, TTL: ttl}
+	resp, err := m.remote.PinRevision(ctx, r, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*PinRevisionResponse)(resp), nil
+}
+
+func (m *maintenance) UnpinRevision(ctx context.Context, id int64) (*UnpinRevisionResponse, error) {
+	resp, err := m.remote.UnpinRevision(ctx, &pb.UnpinRevisionRequest{ID: id}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*UnpinRevisionResponse)(resp), nil
+}
+
+func (m *maintenance) RateLimits(ctx context.Context, endpoint string, limits ...RateLimit) (*RateLimitsResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	r := &pb.RateLimitsRequest{Limits: make([]*pb.RateLimit, len(limits))}
+	for i := range limits {
+		r.Limits[i] = (*pb.RateLimit)(&limits[i])
+	}
+	resp, err := remote.RateLimits(ctx, r, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*RateLimitsResponse)(resp), nil
+}
+
+func (m *maintenance) EffectiveConfig(ctx context.Context, endpoint string) (*EffectiveConfigResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	resp, err := remote.EffectiveConfig(ctx, &pb.EffectiveConfigRequest{}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*EffectiveConfigResponse)(resp), nil
+}
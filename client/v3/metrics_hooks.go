@@ -0,0 +1,113 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// MetricsHooks receives the telemetry of a client, set by Config.MetricsHooks.
+// The hooks are called synchronously from the goroutines of the client, so
+// they must be safe for concurrent use and return quickly.
+type MetricsHooks interface {
+	// RequestStarted is called before a request is sent with the full gRPC
+	// method name of the request. A request retried by the client is
+	// reported once per attempt.
+	RequestStarted(method string)
+	// RequestFinished is called once a request started with RequestStarted
+	// completed, with the address of the endpoint which served it, empty if
+	// none did, the gRPC code of its outcome and its latency. The requests of
+	// streams, such as watches and lease keepalives, complete as their stream
+	// ends.
+	RequestFinished(method, endpoint string, code codes.Code, latency time.Duration)
+	// WatchEventsReceived is called with the number of events of a watch
+	// response received for the watch of the given key.
+	WatchEventsReceived(key string, events int)
+	// LeaseKeepAliveMissed is called every time a keepalive interval of the
+	// lease elapsed without the response to its keepalive.
+	LeaseKeepAliveMissed(id LeaseID)
+}
+
+// metricsInvoker reports the requests of the invoker to the metrics hooks.
+func (c *Client) metricsInvoker(invoker grpc.UnaryInvoker) grpc.UnaryInvoker {
+	hooks := c.cfg.MetricsHooks
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		hooks.RequestStarted(method)
+		start := time.Now()
+		var p peer.Peer
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+		hooks.RequestFinished(method, peerEndpoint(&p), status.Code(err), time.Since(start))
+		return err
+	}
+}
+
+// metricsStreamer reports the streams of the streamer to the metrics hooks.
+func (c *Client) metricsStreamer(streamer grpc.Streamer) grpc.Streamer {
+	hooks := c.cfg.MetricsHooks
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		hooks.RequestStarted(method)
+		ms := &metricsClientStream{hooks: hooks, method: method, start: time.Now()}
+		cs, err := streamer(ctx, desc, cc, method, append(opts, grpc.Peer(&ms.peer))...)
+		if err != nil {
+			ms.finish(err)
+			return nil, err
+		}
+		ms.ClientStream = cs
+		return ms, nil
+	}
+}
+
+// metricsClientStream reports the end of its stream to the metrics hooks.
+type metricsClientStream struct {
+	grpc.ClientStream
+	hooks  MetricsHooks
+	method string
+	start  time.Time
+	peer   peer.Peer
+	once   sync.Once
+}
+
+func (ms *metricsClientStream) RecvMsg(m interface{}) error {
+	err := ms.ClientStream.RecvMsg(m)
+	if err != nil {
+		ms.finish(err)
+	}
+	return err
+}
+
+func (ms *metricsClientStream) finish(err error) {
+	ms.once.Do(func() {
+		code := status.Code(err)
+		if err == io.EOF {
+			code = codes.OK
+		}
+		ms.hooks.RequestFinished(ms.method, peerEndpoint(&ms.peer), code, time.Since(ms.start))
+	})
+}
+
+func peerEndpoint(p *peer.Peer) string {
+	if p.Addr == nil {
+		return ""
+	}
+	return p.Addr.String()
+}
@@ -183,6 +183,14 @@ func (m *mockKVServer) Txn(context.Context, *pb.TxnRequest) (*pb.TxnResponse, er
 	return &pb.TxnResponse{}, nil
 }
 
+func (m *mockKVServer) RangeStream(r *pb.RangeRequest, stream pb.KV_RangeStreamServer) error {
+	return stream.Send(&pb.RangeStreamResponse{})
+}
+
+func (m *mockKVServer) TxnStream(r *pb.TxnRequest, stream pb.KV_TxnStreamServer) error {
+	return stream.Send(&pb.TxnStreamResponse{})
+}
+
 func (m *mockKVServer) Compact(context.Context, *pb.CompactionRequest) (*pb.CompactionResponse, error) {
 	return &pb.CompactionResponse{}, nil
 }
@@ -212,3 +220,7 @@ func (s *mockLeaseServer) LeaseTimeToLive(context.Context, *pb.LeaseTimeToLiveRe
 func (s *mockLeaseServer) LeaseLeases(context.Context, *pb.LeaseLeasesRequest) (*pb.LeaseLeasesResponse, error) {
 	return &pb.LeaseLeasesResponse{}, nil
 }
+
+func (s *mockLeaseServer) LeaseTop(context.Context, *pb.LeaseTopRequest) (*pb.LeaseTopResponse, error) {
+	return &pb.LeaseTopResponse{}, nil
+}
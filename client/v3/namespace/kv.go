@@ -64,6 +64,19 @@ func (kv *kvPrefix) Get(ctx context.Context, key string, opts ...clientv3.OpOpti
 	return get, nil
 }
 
+func (kv *kvPrefix) GetStream(ctx context.Context, key string, opts ...clientv3.OpOption) (clientv3.GetIterator, error) {
+	if len(key) == 0 && !(clientv3.IsOptsWithFromKey(opts) || clientv3.IsOptsWithPrefix(opts)) {
+		return nil, rpctypes.ErrEmptyKey
+	}
+	getOp := clientv3.OpGet(key, opts...)
+	begin, end := kv.prefixInterval(getOp.KeyBytes(), getOp.RangeBytes())
+	it, err := kv.KV.GetStream(ctx, string(begin), append(opts, clientv3.WithRange(string(end)))...)
+	if err != nil {
+		return nil, err
+	}
+	return &getIteratorPrefix{GetIterator: it, pfx: kv.pfx}, nil
+}
+
 func (kv *kvPrefix) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
 	if len(key) == 0 && !(clientv3.IsOptsWithFromKey(opts) || clientv3.IsOptsWithPrefix(opts)) {
 		return nil, rpctypes.ErrEmptyKey
@@ -131,15 +144,55 @@ func (txn *txnPrefix) Commit() (*clientv3.TxnResponse, error) {
 	return resp, nil
 }
 
+// CommitStream commits the transaction like Commit, over the TxnStream RPC
+// if the wrapped Txn implements clientv3.CommitStreamer.
+func (txn *txnPrefix) CommitStream() (*clientv3.TxnResponse, error) {
+	cs, ok := txn.Txn.(clientv3.CommitStreamer)
+	if !ok {
+		return txn.Commit()
+	}
+	resp, err := cs.CommitStream()
+	if err != nil {
+		return nil, err
+	}
+	txn.kv.unprefixTxnResponse(resp)
+	return resp, nil
+}
+
+// getIteratorPrefix strips the prefix of the keys of a GetIterator.
+type getIteratorPrefix struct {
+	clientv3.GetIterator
+	pfx string
+}
+
+func (it *getIteratorPrefix) Next() bool {
+	if !it.GetIterator.Next() {
+		return false
+	}
+	kv := it.GetIterator.KV()
+	kv.Key = kv.Key[len(it.pfx):]
+	return true
+}
+
 func (kv *kvPrefix) prefixOp(op clientv3.Op) clientv3.Op {
 	if !op.IsTxn() {
 		begin, end := kv.prefixInterval(op.KeyBytes(), op.RangeBytes())
 		op.WithKeyBytes(begin)
 		op.WithRangeBytes(end)
+		if fn := op.PageHandler(); fn != nil {
+			clientv3.WithPageHandler(func(page *clientv3.GetResponse) error {
+				kv.unprefixGetResponse(page)
+				return fn(page)
+			})(&op)
+		}
 		return op
 	}
 	cmps, thenOps, elseOps := op.Txn()
-	return clientv3.OpTxn(kv.prefixCmps(cmps), kv.prefixOps(thenOps), kv.prefixOps(elseOps))
+	var opts []clientv3.OpOption
+	if op.IsReadYourWrites() {
+		opts = append(opts, clientv3.WithReadYourWrites())
+	}
+	return clientv3.OpTxn(kv.prefixCmps(cmps), kv.prefixOps(thenOps), kv.prefixOps(elseOps), opts...)
 }
 
 func (kv *kvPrefix) unprefixGetResponse(resp *clientv3.GetResponse) {
@@ -197,6 +250,13 @@ func (kv *kvPrefix) prefixCmps(cs []clientv3.Cmp) []clientv3.Cmp {
 		if len(cs[i].RangeEnd) != 0 {
 			newCmps[i].RangeEnd = endKey
 		}
+		if len(cs[i].Intervals) != 0 {
+			newCmps[i].Intervals = make([]*pb.KeyInterval, len(cs[i].Intervals))
+			for j, iv := range cs[i].Intervals {
+				pfxKey, endKey := kv.prefixInterval(iv.Key, iv.RangeEnd)
+				newCmps[i].Intervals[j] = &pb.KeyInterval{Key: pfxKey, RangeEnd: endKey}
+			}
+		}
 	}
 	return newCmps
 }
@@ -14,7 +14,11 @@
 
 package clientv3
 
-import pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+import (
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
 
 type opType int
 
@@ -45,6 +49,18 @@ type Op struct {
 	minCreateRev int64
 	maxCreateRev int64
 
+	// field mask for range
+	excludeFields []ExcludeField
+	maxValueSize  int64
+
+	// for linearizable range falling back to serializable
+	fallbackMaxStaleness time.Duration
+	fallbackTimeout      time.Duration
+
+	// for auto paging range
+	pageSize    int64
+	pageHandler func(*GetResponse) error
+
 	// for range, watch
 	rev int64
 
@@ -58,25 +74,32 @@ type Op struct {
 	fragment bool
 
 	// for put
-	ignoreValue bool
-	ignoreLease bool
+	ignoreValue    bool
+	ignoreLease    bool
+	metadata       []byte
+	ignoreMetadata bool
+	ttl            int64
 
 	// progressNotify is for progress updates.
 	progressNotify bool
+	// progressNotifyInterval is the interval of the progress updates.
+	progressNotifyInterval time.Duration
 	// createdNotify is for created event
 	createdNotify bool
 	// filters for watchers
 	filterPut    bool
 	filterDelete bool
+	valueFilters []ValueFilter
 
 	// for put
 	val     []byte
 	leaseID LeaseID
 
 	// txn
-	cmps    []Cmp
-	thenOps []Op
-	elseOps []Op
+	cmps           []Cmp
+	thenOps        []Op
+	elseOps        []Op
+	readYourWrites bool
 
 	isOptsWithFromKey bool
 	isOptsWithPrefix  bool
@@ -94,6 +117,11 @@ func (op Op) Txn() ([]Cmp, []Op, []Op) {
 	return op.cmps, op.thenOps, op.elseOps
 }
 
+// IsReadYourWrites returns true if the "Op" is a transaction whose
+// operations may write overlapping keys, each observing the effects of
+// the operations before it.
+func (op Op) IsReadYourWrites() bool { return op.readYourWrites }
+
 // KeyBytes returns the byte slice holding the Op's key.
 func (op Op) KeyBytes() []byte { return op.key }
 
@@ -136,12 +164,30 @@ func (op Op) MinCreateRev() int64 { return op.minCreateRev }
 // MaxCreateRev returns the operation's maximum create revision.
 func (op Op) MaxCreateRev() int64 { return op.maxCreateRev }
 
+// ExcludeFields returns the fields left unset in the key-value pairs returned by the operation.
+func (op Op) ExcludeFields() []ExcludeField { return op.excludeFields }
+
+// MaxValueSize returns the size the operation truncates the returned values to, if any.
+func (op Op) MaxValueSize() int64 { return op.maxValueSize }
+
+// SerializableFallback returns the staleness bound and the timeout of the
+// fallback of the linearizable range to a serializable read, if any.
+func (op Op) SerializableFallback() (maxStaleness, timeout time.Duration) {
+	return op.fallbackMaxStaleness, op.fallbackTimeout
+}
+
+// PageHandler returns the function auto paging ranges pass their pages to, if any.
+func (op Op) PageHandler() func(*GetResponse) error { return op.pageHandler }
+
 // WithRangeBytes sets the byte slice for the Op's range end.
 func (op *Op) WithRangeBytes(end []byte) { op.end = end }
 
 // ValueBytes returns the byte slice holding the Op's value, if any.
 func (op Op) ValueBytes() []byte { return op.val }
 
+// MetadataBytes returns the byte slice holding the Op's metadata, if any.
+func (op Op) MetadataBytes() []byte { return op.metadata }
+
 // WithValueBytes sets the byte slice for the Op's value.
 func (op *Op) WithValueBytes(v []byte) { op.val = v }
 
@@ -161,6 +207,14 @@ func (op Op) toRangeRequest() *pb.RangeRequest {
 		MaxModRevision:    op.maxModRev,
 		MinCreateRevision: op.minCreateRev,
 		MaxCreateRevision: op.maxCreateRev,
+		MaxValueSize:      op.maxValueSize,
+	}
+	if op.fallbackMaxStaleness > 0 {
+		r.FallbackMaxStalenessMs = op.fallbackMaxStaleness.Milliseconds()
+		r.FallbackTimeoutMs = op.fallbackTimeout.Milliseconds()
+	}
+	for _, f := range op.excludeFields {
+		r.ExcludeFields = append(r.ExcludeFields, pb.RangeRequest_ExcludeField(f))
 	}
 	if op.sort != nil {
 		r.SortOrder = pb.RangeRequest_SortOrder(op.sort.Order)
@@ -182,7 +236,7 @@ func (op Op) toTxnRequest() *pb.TxnRequest {
 	for i := range op.cmps {
 		cmps[i] = (*pb.Compare)(&op.cmps[i])
 	}
-	return &pb.TxnRequest{Compare: cmps, Success: thenOps, Failure: elseOps}
+	return &pb.TxnRequest{Compare: cmps, Success: thenOps, Failure: elseOps, ReadYourWrites: op.readYourWrites}
 }
 
 func (op Op) toRequestOp() *pb.RequestOp {
@@ -190,7 +244,7 @@ func (op Op) toRequestOp() *pb.RequestOp {
 	case tRange:
 		return &pb.RequestOp{Request: &pb.RequestOp_RequestRange{RequestRange: op.toRangeRequest()}}
 	case tPut:
-		r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID), PrevKv: op.prevKV, IgnoreValue: op.ignoreValue, IgnoreLease: op.ignoreLease}
+		r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID), PrevKv: op.prevKV, IgnoreValue: op.ignoreValue, IgnoreLease: op.ignoreLease, Metadata: op.metadata, IgnoreMetadata: op.ignoreMetadata, Ttl: op.ttl}
 		return &pb.RequestOp{Request: &pb.RequestOp_RequestPut{RequestPut: r}}
 	case tDeleteRange:
 		r := &pb.DeleteRangeRequest{Key: op.key, RangeEnd: op.end, PrevKv: op.prevKV}
@@ -259,7 +313,7 @@ func OpDelete(key string, opts ...OpOption) Op {
 		panic("unexpected mod revision filter in delete")
 	case ret.minCreateRev != 0, ret.maxCreateRev != 0:
 		panic("unexpected create revision filter in delete")
-	case ret.filterDelete, ret.filterPut:
+	case ret.filterDelete, ret.filterPut, len(ret.valueFilters) != 0:
 		panic("unexpected filter in delete")
 	case ret.createdNotify:
 		panic("unexpected createdNotify in delete")
@@ -288,7 +342,7 @@ func OpPut(key, val string, opts ...OpOption) Op {
 		panic("unexpected mod revision filter in put")
 	case ret.minCreateRev != 0, ret.maxCreateRev != 0:
 		panic("unexpected create revision filter in put")
-	case ret.filterDelete, ret.filterPut:
+	case ret.filterDelete, ret.filterPut, len(ret.valueFilters) != 0:
 		panic("unexpected filter in put")
 	case ret.createdNotify:
 		panic("unexpected createdNotify in put")
@@ -297,8 +351,10 @@ func OpPut(key, val string, opts ...OpOption) Op {
 }
 
 // OpTxn returns "txn" operation based on given transaction conditions.
-func OpTxn(cmps []Cmp, thenOps []Op, elseOps []Op) Op {
-	return Op{t: tTxn, cmps: cmps, thenOps: thenOps, elseOps: elseOps}
+func OpTxn(cmps []Cmp, thenOps []Op, elseOps []Op, opts ...OpOption) Op {
+	ret := Op{t: tTxn, cmps: cmps, thenOps: thenOps, elseOps: elseOps}
+	ret.applyOpts(opts)
+	return ret
 }
 
 func opWatch(key string, opts ...OpOption) Op {
@@ -425,6 +481,20 @@ func WithSerializable() OpOption {
 	return func(op *Op) { op.serializable = true }
 }
 
+// WithSerializableFallback lets the linearizable 'Get' request fall back to a
+// serializable read if the member cannot confirm it is up to date with the
+// cluster within timeout, such as when the cluster lost its quorum, provided
+// the member was last confirmed up to date at most maxStaleness ago. Otherwise
+// the request keeps waiting to be linearizable. The header of the response
+// of a request which fell back sets DegradedRead. A timeout of 0 stands for
+// the default of the server, one second.
+func WithSerializableFallback(maxStaleness, timeout time.Duration) OpOption {
+	return func(op *Op) {
+		op.fallbackMaxStaleness = maxStaleness
+		op.fallbackTimeout = timeout
+	}
+}
+
 // WithKeysOnly makes the 'Get' request return only the keys and the corresponding
 // values will be omitted.
 func WithKeysOnly() OpOption {
@@ -436,6 +506,46 @@ func WithCountOnly() OpOption {
 	return func(op *Op) { op.countOnly = true }
 }
 
+// ExcludeField is a key-value field that a 'Get' request can leave unset.
+type ExcludeField int
+
+const (
+	ExcludeValue ExcludeField = iota
+	ExcludeCreateRevision
+	ExcludeModRevision
+	ExcludeVersion
+	ExcludeLease
+	ExcludeMetadata
+)
+
+// WithExcludeFields makes the 'Get' request leave the given fields unset in the
+// returned key-value pairs, so that they are not sent. Keys are always returned.
+func WithExcludeFields(fields ...ExcludeField) OpOption {
+	return func(op *Op) { op.excludeFields = append(op.excludeFields, fields...) }
+}
+
+// WithMaxValueSize makes the 'Get' request truncate the returned values to size bytes.
+// The key-value pairs with a truncated value have ValueTruncated set.
+func WithMaxValueSize(size int64) OpOption {
+	return func(op *Op) { op.maxValueSize = size }
+}
+
+// WithAutoPaging makes the 'Get' request fetch the range in successive requests
+// of at most pageSize keys, all served at the revision of the first one, so
+// that large ranges do not fail on response size limits. The context of the
+// 'Get' request bounds all of its pages. The range must be sorted by key.
+func WithAutoPaging(pageSize int64) OpOption {
+	return func(op *Op) { op.pageSize = pageSize }
+}
+
+// WithPageHandler makes an auto paging 'Get' request pass each page to fn
+// instead of gathering the keys into its response. The response of the
+// 'Get' request then holds no keys. The request stops at the first error
+// returned by fn and returns it.
+func WithPageHandler(fn func(page *GetResponse) error) OpOption {
+	return func(op *Op) { op.pageHandler = fn }
+}
+
 // WithMinModRev filters out keys for Get with modification revisions less than the given revision.
 func WithMinModRev(rev int64) OpOption { return func(op *Op) { op.minModRev = rev } }
 
@@ -480,6 +590,17 @@ func WithProgressNotify() OpOption {
 	}
 }
 
+// WithProgressNotifyInterval makes watch server send periodic progress updates
+// every given interval when there is no incoming events, instead of the
+// interval of the server. The server raises intervals shorter than its
+// minimum of 100 milliseconds to the minimum. It implies WithProgressNotify.
+func WithProgressNotifyInterval(interval time.Duration) OpOption {
+	return func(op *Op) {
+		op.progressNotify = true
+		op.progressNotifyInterval = interval
+	}
+}
+
 // WithCreatedNotify makes watch server sends the created event.
 func WithCreatedNotify() OpOption {
 	return func(op *Op) {
@@ -497,6 +618,37 @@ func WithFilterDelete() OpOption {
 	return func(op *Op) { op.filterDelete = true }
 }
 
+// ValueFilter matches the values of the PUT events of a watcher.
+type ValueFilter pb.WatchValueFilter
+
+// ValuePrefix matches the values starting with prefix.
+func ValuePrefix(prefix string) ValueFilter {
+	return ValueFilter{Match: pb.WatchValueFilter_PREFIX, Pattern: []byte(prefix)}
+}
+
+// ValueRegex matches the values containing a match of the RE2 regular
+// expression expr.
+func ValueRegex(expr string) ValueFilter {
+	return ValueFilter{Match: pb.WatchValueFilter_REGEX, Pattern: []byte(expr)}
+}
+
+// ValueJSONField matches the JSON object values whose field at the
+// dot-separated path field is equal to value: to the string itself for
+// string fields, and to the compact JSON encoding of other fields, such as
+// "3" or "true".
+func ValueJSONField(field, value string) ValueFilter {
+	return ValueFilter{Match: pb.WatchValueFilter_JSON_FIELD, Pattern: []byte(value), JsonField: field}
+}
+
+// WithValueFilter discards from the watcher the PUT events whose value does
+// not match f. Given several times, the values must match all the filters.
+// DELETE events carry no value and are not discarded. The server rejects the
+// watch if f is not valid, such as an invalid regular expression.
+// Supported since etcd 3.6.
+func WithValueFilter(f ValueFilter) OpOption {
+	return func(op *Op) { op.valueFilters = append(op.valueFilters, f) }
+}
+
 // WithPrevKV gets the previous key-value pair before the event happens. If the previous KV is already compacted,
 // nothing will be returned.
 func WithPrevKV() OpOption {
@@ -534,12 +686,47 @@ func WithIgnoreLease() OpOption {
 	}
 }
 
+// WithMetadata stores the given metadata with the key-value pair of a 'Put'
+// request. Metadata is returned even by 'Get' requests leaving values out.
+func WithMetadata(metadata []byte) OpOption {
+	return func(op *Op) { op.metadata = metadata }
+}
+
+// WithIgnoreMetadata updates the key using its current metadata.
+// This option can not be combined with WithMetadata.
+// Returns an error if the key does not exist.
+func WithIgnoreMetadata() OpOption {
+	return func(op *Op) {
+		op.ignoreMetadata = true
+	}
+}
+
+// WithTTL makes the server delete the key of a 'Put' request ttl seconds
+// after the put, without the need of a lease. A put without it clears the
+// TTL of the key. This option can not be combined with WithLease or
+// WithIgnoreLease.
+func WithTTL(ttl int64) OpOption {
+	return func(op *Op) { op.ttl = ttl }
+}
+
+// WithReadYourWrites lets the operations of an 'OpTxn' branch write
+// overlapping keys, each observing the effects of the operations applied
+// before it, such as deleting a prefix then putting fresh keys under it.
+// Only the option of the outermost transaction is considered.
+func WithReadYourWrites() OpOption {
+	return func(op *Op) { op.readYourWrites = true }
+}
+
 // LeaseOp represents an Operation that lease can execute.
 type LeaseOp struct {
 	id LeaseID
 
 	// for TimeToLive
 	attachedKeys bool
+
+	// for Grant
+	keepaliveOnWrite   bool
+	checkpointInterval time.Duration
 }
 
 // LeaseOption configures lease operations.
@@ -556,6 +743,22 @@ func WithAttachedKeys() LeaseOption {
 	return func(op *LeaseOp) { op.attachedKeys = true }
 }
 
+// WithKeepaliveOnWrite makes the writes of the keys attached to the granted
+// lease renew it, like keep alive requests.
+func WithKeepaliveOnWrite() LeaseOption {
+	return func(op *LeaseOp) { op.keepaliveOnWrite = true }
+}
+
+// WithCheckpointInterval sets the interval, rounded up to seconds, at which
+// the leader checkpoints the remaining TTL of the granted lease, so that a
+// leader change resets its TTL to at most its remaining TTL at the last
+// checkpoint, in place of the lease checkpoint interval of the server.
+// Grant fails with rpctypes.ErrLeaseCheckpointIntervalTooSmall if the interval
+// is below 5 seconds and the lease checkpoint interval of the server.
+func WithCheckpointInterval(interval time.Duration) LeaseOption {
+	return func(op *LeaseOp) { op.checkpointInterval = interval }
+}
+
 func toLeaseTimeToLiveRequest(id LeaseID, opts ...LeaseOption) *pb.LeaseTimeToLiveRequest {
 	ret := &LeaseOp{id: id}
 	ret.applyOpts(opts)
@@ -75,6 +75,27 @@ func (kv *kvOrdering) Get(ctx context.Context, key string, opts ...clientv3.OpOp
 	}
 }
 
+func (kv *kvOrdering) GetStream(ctx context.Context, key string, opts ...clientv3.OpOption) (clientv3.GetIterator, error) {
+	prevRev := kv.getPrevRev()
+	op := clientv3.OpGet(key, opts...)
+	for {
+		it, err := kv.KV.GetStream(ctx, key, opts...)
+		if err != nil {
+			return nil, err
+		}
+		rev := it.Header().Revision
+		if rev >= prevRev {
+			kv.setPrevRev(rev)
+			return it, nil
+		}
+		it.Close()
+		err = kv.orderViolationFunc(op, (&clientv3.GetResponse{Header: it.Header()}).OpResponse(), prevRev)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
 func (kv *kvOrdering) Txn(ctx context.Context) clientv3.Txn {
 	return &txnOrdering{
 		kv.KV.Txn(ctx),
@@ -0,0 +1,42 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package partition is a clientv3 wrapper that routes key-value requests to
+// the partitions of an etcd process hosting several independent raft groups.
+// Each partition has a keyspace, revisions and leases of its own, so a
+// request is served by the single partition holding its key. Ranges are
+// served by the partition of their start key; keep them within a partition,
+// for instance by partitioning on key prefixes. Transactions must only touch
+// keys of one partition.
+//
+// First, create a client for every partition:
+//
+//	clis := make(map[string]*clientv3.Client)
+//	for name, endpoint := range map[string]string{"a": "localhost:2379", "b": "localhost:22379"} {
+//		cli, err := clientv3.New(clientv3.Config{Endpoints: []string{endpoint}})
+//		if err != nil {
+//			// handle error!
+//		}
+//		clis[name] = cli
+//	}
+//
+// Next, route the requests of a KV to the partitions:
+//
+//	kvs := map[string]clientv3.KV{"a": clis["a"].KV, "b": clis["b"].KV}
+//	kv := partition.NewKV(kvs, partition.NewHashPartitioner("a", "b"))
+//
+// Now calls using 'kv' are sent to the partition holding their key.
+//
+// Experimental: this package may change or be removed in a future release.
+package partition
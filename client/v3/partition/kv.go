@@ -0,0 +1,183 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package partition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.etcd.io/etcd/client/v3"
+)
+
+var (
+	ErrCrossPartition = errors.New("partition: transaction touches keys of several partitions")
+	ErrCompact        = errors.New("partition: revisions are per partition; compact the KV of a partition")
+)
+
+type kvPartition struct {
+	kvs map[string]clientv3.KV
+	p   Partitioner
+}
+
+// NewKV routes the requests of a KV to the KVs of the partitions, keyed by
+// the names returned by the partitioner.
+func NewKV(kvs map[string]clientv3.KV, p Partitioner) clientv3.KV {
+	return &kvPartition{kvs: kvs, p: p}
+}
+
+func (kv *kvPartition) partition(key []byte) (clientv3.KV, error) {
+	name := kv.p.Partition(string(key))
+	pkv, ok := kv.kvs[name]
+	if !ok {
+		return nil, fmt.Errorf("partition: no KV for partition %q of key %q", name, key)
+	}
+	return pkv, nil
+}
+
+func (kv *kvPartition) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	pkv, err := kv.partition([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	return pkv.Put(ctx, key, val, opts...)
+}
+
+func (kv *kvPartition) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	pkv, err := kv.partition([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	return pkv.Get(ctx, key, opts...)
+}
+
+func (kv *kvPartition) GetStream(ctx context.Context, key string, opts ...clientv3.OpOption) (clientv3.GetIterator, error) {
+	pkv, err := kv.partition([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	return pkv.GetStream(ctx, key, opts...)
+}
+
+func (kv *kvPartition) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	pkv, err := kv.partition([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	return pkv.Delete(ctx, key, opts...)
+}
+
+func (kv *kvPartition) Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (*clientv3.CompactResponse, error) {
+	return nil, ErrCompact
+}
+
+func (kv *kvPartition) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	var key []byte
+	if op.IsTxn() {
+		var err error
+		if key, err = kv.txnKey(op.Txn()); err != nil {
+			return clientv3.OpResponse{}, err
+		}
+	} else {
+		key = op.KeyBytes()
+	}
+	pkv, err := kv.partition(key)
+	if err != nil {
+		return clientv3.OpResponse{}, err
+	}
+	return pkv.Do(ctx, op)
+}
+
+// txnKey returns a key of the transaction, checking that all its keys are
+// held by the same partition.
+func (kv *kvPartition) txnKey(cmps []clientv3.Cmp, thenOps, elseOps []clientv3.Op) ([]byte, error) {
+	var keys [][]byte
+	for i := range cmps {
+		keys = append(keys, cmps[i].KeyBytes())
+	}
+	for _, ops := range [][]clientv3.Op{thenOps, elseOps} {
+		for _, op := range ops {
+			if !op.IsTxn() {
+				keys = append(keys, op.KeyBytes())
+				continue
+			}
+			key, err := kv.txnKey(op.Txn())
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	name := kv.p.Partition(string(keys[0]))
+	for _, key := range keys[1:] {
+		if kv.p.Partition(string(key)) != name {
+			return nil, ErrCrossPartition
+		}
+	}
+	return keys[0], nil
+}
+
+func (kv *kvPartition) Txn(ctx context.Context) clientv3.Txn {
+	return &txnPartition{kv: kv, ctx: ctx}
+}
+
+// txnPartition buffers the comparisons and operations of a transaction
+// until it is committed, as the partition serving it depends on all of
+// its keys.
+type txnPartition struct {
+	kv      *kvPartition
+	ctx     context.Context
+	cmps    []clientv3.Cmp
+	thenOps []clientv3.Op
+	elseOps []clientv3.Op
+}
+
+func (txn *txnPartition) If(cs ...clientv3.Cmp) clientv3.Txn {
+	txn.cmps = append(txn.cmps, cs...)
+	return txn
+}
+
+func (txn *txnPartition) Then(ops ...clientv3.Op) clientv3.Txn {
+	txn.thenOps = append(txn.thenOps, ops...)
+	return txn
+}
+
+func (txn *txnPartition) Else(ops ...clientv3.Op) clientv3.Txn {
+	txn.elseOps = append(txn.elseOps, ops...)
+	return txn
+}
+
+func (txn *txnPartition) txn() (clientv3.Txn, error) {
+	key, err := txn.kv.txnKey(txn.cmps, txn.thenOps, txn.elseOps)
+	if err != nil {
+		return nil, err
+	}
+	pkv, err := txn.kv.partition(key)
+	if err != nil {
+		return nil, err
+	}
+	return pkv.Txn(txn.ctx).If(txn.cmps...).Then(txn.thenOps...).Else(txn.elseOps...), nil
+}
+
+func (txn *txnPartition) Commit() (*clientv3.TxnResponse, error) {
+	t, err := txn.txn()
+	if err != nil {
+		return nil, err
+	}
+	return t.Commit()
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package partition
+
+import (
+	"context"
+	"testing"
+
+	"go.etcd.io/etcd/client/v3"
+)
+
+func TestPrefixPartitioner(t *testing.T) {
+	p := NewPrefixPartitioner(map[string]string{"/a/": "a", "/a/b/": "b"}, "c")
+	tests := map[string]string{
+		"/a/x":   "a",
+		"/a/b/x": "b",
+		"/a":     "c",
+		"":       "c",
+	}
+	for key, w := range tests {
+		if name := p.Partition(key); name != w {
+			t.Errorf("Partition(%q) = %q, expected %q", key, name, w)
+		}
+	}
+}
+
+func TestHashPartitioner(t *testing.T) {
+	p := NewHashPartitioner("a", "b")
+	seen := map[string]bool{}
+	for _, key := range []string{"foo", "bar", "baz", "qux", "quux", "corge"} {
+		name := p.Partition(key)
+		if name != p.Partition(key) {
+			t.Fatalf("Partition(%q) is not stable", key)
+		}
+		seen[name] = true
+	}
+	if !seen["a"] || !seen["b"] {
+		t.Fatalf("expected keys in both partitions, got %v", seen)
+	}
+}
+
+// txnKV records the transactions committed on a partition.
+type txnKV struct {
+	clientv3.KV
+	name    string
+	commits *[]string
+}
+
+func (kv *txnKV) Txn(ctx context.Context) clientv3.Txn {
+	return &recordTxn{kv: kv}
+}
+
+type recordTxn struct {
+	clientv3.Txn
+	kv *txnKV
+}
+
+func (txn *recordTxn) If(cs ...clientv3.Cmp) clientv3.Txn   { return txn }
+func (txn *recordTxn) Then(ops ...clientv3.Op) clientv3.Txn { return txn }
+func (txn *recordTxn) Else(ops ...clientv3.Op) clientv3.Txn { return txn }
+func (txn *recordTxn) Commit() (*clientv3.TxnResponse, error) {
+	*txn.kv.commits = append(*txn.kv.commits, txn.kv.name)
+	return &clientv3.TxnResponse{}, nil
+}
+
+func TestTxnRouting(t *testing.T) {
+	var commits []string
+	kv := NewKV(map[string]clientv3.KV{
+		"a": &txnKV{name: "a", commits: &commits},
+		"b": &txnKV{name: "b", commits: &commits},
+	}, NewPrefixPartitioner(map[string]string{"/a/": "a", "/b/": "b"}, "a"))
+
+	_, err := kv.Txn(context.TODO()).
+		If(clientv3.Compare(clientv3.Version("/b/x"), "=", 0)).
+		Then(clientv3.OpPut("/b/x", "1"), clientv3.OpTxn(nil, []clientv3.Op{clientv3.OpGet("/b/y")}, nil)).
+		Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = kv.Txn(context.TODO()).Then(clientv3.OpPut("/a/x", "1")).Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 || commits[0] != "b" || commits[1] != "a" {
+		t.Fatalf("expected commits on [b a], got %v", commits)
+	}
+
+	_, err = kv.Txn(context.TODO()).
+		Then(clientv3.OpPut("/a/x", "1")).
+		Else(clientv3.OpTxn(nil, []clientv3.Op{clientv3.OpPut("/b/x", "1")}, nil)).
+		Commit()
+	if err != ErrCrossPartition {
+		t.Fatalf("expected %v, got %v", ErrCrossPartition, err)
+	}
+}
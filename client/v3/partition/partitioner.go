@@ -0,0 +1,68 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package partition
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// Partitioner maps a key to the name of the partition holding it.
+type Partitioner interface {
+	Partition(key string) string
+}
+
+type hashPartitioner []string
+
+// NewHashPartitioner spreads keys over the named partitions by the FNV-1a
+// hash of the key. The partitions must be named in the same order by all
+// clients of a keyspace, and cannot be changed without moving its keys.
+func NewHashPartitioner(names ...string) Partitioner {
+	return hashPartitioner(names)
+}
+
+func (p hashPartitioner) Partition(key string) string {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return p[h.Sum32()%uint32(len(p))]
+}
+
+type prefixPartitioner struct {
+	// prefixes are sorted from the longest to the shortest
+	prefixes []string
+	names    map[string]string
+	def      string
+}
+
+// NewPrefixPartitioner maps a key to the partition of the longest key
+// prefix it starts with, or to the default partition if there is none.
+func NewPrefixPartitioner(prefixes map[string]string, def string) Partitioner {
+	p := &prefixPartitioner{names: prefixes, def: def}
+	for pfx := range prefixes {
+		p.prefixes = append(p.prefixes, pfx)
+	}
+	sort.Slice(p.prefixes, func(i, j int) bool { return len(p.prefixes[i]) > len(p.prefixes[j]) })
+	return p
+}
+
+func (p *prefixPartitioner) Partition(key string) string {
+	for _, pfx := range p.prefixes {
+		if strings.HasPrefix(key, pfx) {
+			return p.names[pfx]
+		}
+	}
+	return p.def
+}
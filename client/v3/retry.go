@@ -47,17 +47,18 @@ func (rp retryPolicy) String() string {
 //
 // immutable requests (e.g. Get) should be retried unless it's
 // an obvious server-side error (e.g. rpctypes.ErrRequestTooLarge).
+// They are retried on the retryable codes, codes.Unavailable if empty.
 //
 // Returning "false" means retry should stop, since client cannot
 // handle itself even with retries.
-func isSafeRetryImmutableRPC(err error) bool {
+func isSafeRetryImmutableRPC(err error, retryableCodes []codes.Code) bool {
 	eErr := rpctypes.Error(err)
-	if serverErr, ok := eErr.(rpctypes.EtcdError); ok && serverErr.Code() != codes.Unavailable {
+	if serverErr, ok := eErr.(rpctypes.EtcdError); ok && !isRetryableCode(serverErr.Code(), retryableCodes) {
 		// interrupted by non-transient server-side or gRPC-side error
 		// client cannot handle itself (e.g. rpctypes.ErrCompacted)
 		return false
 	}
-	// only retry if unavailable
+	// only retry if unavailable, or another retryable code
 	ev, ok := status.FromError(err)
 	if !ok {
 		// all errors from RPC is typed "grpc/status.(*statusError)"
@@ -69,7 +70,7 @@ func isSafeRetryImmutableRPC(err error) bool {
 		// ref. https://github.com/grpc/grpc-go/issues/1581
 		return false
 	}
-	return ev.Code() == codes.Unavailable
+	return isRetryableCode(ev.Code(), retryableCodes)
 }
 
 // isSafeRetryMutableRPC returns "true" when a mutable request is safe for retry.
@@ -117,6 +118,14 @@ func (rkv *retryKVClient) Txn(ctx context.Context, in *pb.TxnRequest, opts ...gr
 	return rkv.kc.Txn(ctx, in, opts...)
 }
 
+func (rkv *retryKVClient) RangeStream(ctx context.Context, in *pb.RangeRequest, opts ...grpc.CallOption) (pb.KV_RangeStreamClient, error) {
+	return rkv.kc.RangeStream(ctx, in, opts...)
+}
+
+func (rkv *retryKVClient) TxnStream(ctx context.Context, in *pb.TxnRequest, opts ...grpc.CallOption) (pb.KV_TxnStreamClient, error) {
+	return rkv.kc.TxnStream(ctx, in, opts...)
+}
+
 func (rkv *retryKVClient) Compact(ctx context.Context, in *pb.CompactionRequest, opts ...grpc.CallOption) (resp *pb.CompactionResponse, err error) {
 	return rkv.kc.Compact(ctx, in, opts...)
 }
@@ -140,6 +149,10 @@ func (rlc *retryLeaseClient) LeaseLeases(ctx context.Context, in *pb.LeaseLeases
 	return rlc.lc.LeaseLeases(ctx, in, append(opts, withRetryPolicy(repeatable))...)
 }
 
+func (rlc *retryLeaseClient) LeaseTop(ctx context.Context, in *pb.LeaseTopRequest, opts ...grpc.CallOption) (resp *pb.LeaseTopResponse, err error) {
+	return rlc.lc.LeaseTop(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
 func (rlc *retryLeaseClient) LeaseGrant(ctx context.Context, in *pb.LeaseGrantRequest, opts ...grpc.CallOption) (resp *pb.LeaseGrantResponse, err error) {
 	return rlc.lc.LeaseGrant(ctx, in, append(opts, withRetryPolicy(repeatable))...)
 }
@@ -210,6 +223,10 @@ func (rmc *retryMaintenanceClient) HashKV(ctx context.Context, in *pb.HashKVRequ
 	return rmc.mc.HashKV(ctx, in, append(opts, withRetryPolicy(repeatable))...)
 }
 
+func (rmc *retryMaintenanceClient) HashKVStream(ctx context.Context, in *pb.HashKVStreamRequest, opts ...grpc.CallOption) (stream pb.Maintenance_HashKVStreamClient, err error) {
+	return rmc.mc.HashKVStream(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
 func (rmc *retryMaintenanceClient) Snapshot(ctx context.Context, in *pb.SnapshotRequest, opts ...grpc.CallOption) (stream pb.Maintenance_SnapshotClient, err error) {
 	return rmc.mc.Snapshot(ctx, in, append(opts, withRetryPolicy(repeatable))...)
 }
@@ -226,6 +243,30 @@ func (rmc *retryMaintenanceClient) Downgrade(ctx context.Context, in *pb.Downgra
 	return rmc.mc.Downgrade(ctx, in, opts...)
 }
 
+func (rmc *retryMaintenanceClient) Purge(ctx context.Context, in *pb.PurgeRequest, opts ...grpc.CallOption) (resp *pb.PurgeResponse, err error) {
+	return rmc.mc.Purge(ctx, in, opts...)
+}
+
+func (rmc *retryMaintenanceClient) SnapshotSettings(ctx context.Context, in *pb.SnapshotSettingsRequest, opts ...grpc.CallOption) (resp *pb.SnapshotSettingsResponse, err error) {
+	return rmc.mc.SnapshotSettings(ctx, in, opts...)
+}
+
+func (rmc *retryMaintenanceClient) PinRevision(ctx context.Context, in *pb.PinRevisionRequest, opts ...grpc.CallOption) (resp *pb.PinRevisionResponse, err error) {
+	return rmc.mc.PinRevision(ctx, in, opts...)
+}
+
+func (rmc *retryMaintenanceClient) UnpinRevision(ctx context.Context, in *pb.UnpinRevisionRequest, opts ...grpc.CallOption) (resp *pb.UnpinRevisionResponse, err error) {
+	return rmc.mc.UnpinRevision(ctx, in, opts...)
+}
+
+func (rmc *retryMaintenanceClient) RateLimits(ctx context.Context, in *pb.RateLimitsRequest, opts ...grpc.CallOption) (resp *pb.RateLimitsResponse, err error) {
+	return rmc.mc.RateLimits(ctx, in, opts...)
+}
+
+func (rmc *retryMaintenanceClient) EffectiveConfig(ctx context.Context, in *pb.EffectiveConfigRequest, opts ...grpc.CallOption) (resp *pb.EffectiveConfigResponse, err error) {
+	return rmc.mc.EffectiveConfig(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
 type retryAuthClient struct {
 	ac pb.AuthClient
 }
@@ -253,6 +294,10 @@ func (rac *retryAuthClient) RoleList(ctx context.Context, in *pb.AuthRoleListReq
 	return rac.ac.RoleList(ctx, in, append(opts, withRetryPolicy(repeatable))...)
 }
 
+func (rac *retryAuthClient) CheckPermissions(ctx context.Context, in *pb.AuthCheckPermissionsRequest, opts ...grpc.CallOption) (resp *pb.AuthCheckPermissionsResponse, err error) {
+	return rac.ac.CheckPermissions(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
 func (rac *retryAuthClient) AuthEnable(ctx context.Context, in *pb.AuthEnableRequest, opts ...grpc.CallOption) (resp *pb.AuthEnableResponse, err error) {
 	return rac.ac.AuthEnable(ctx, in, opts...)
 }
@@ -21,6 +21,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strconv"
 	"sync"
 	"time"
 
@@ -39,15 +40,36 @@ import (
 func (c *Client) unaryClientInterceptor(optFuncs ...retryOption) grpc.UnaryClientInterceptor {
 	intOpts := reuseOrNewWithCallOptions(defaultOptions, optFuncs)
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
-		ctx = withVersion(ctx)
+		ctx = withVersion(ctx, c.apiVersion())
+		if c.cfg.MetricsHooks != nil {
+			invoker = c.metricsInvoker(invoker)
+		}
+		invoker = c.apiVersionInvoker(invoker)
+		if c.cfg.FenceClusterEpoch {
+			ctx = c.withClusterEpoch(ctx)
+			invoker = c.clusterEpochInvoker(invoker)
+		}
 		grpcOpts, retryOpts := filterCallOptions(opts)
 		callOpts := reuseOrNewWithCallOptions(intOpts, retryOpts)
+		if policyOpts, ok := c.retryPolicyOpts[callOpts.retryPolicy]; ok {
+			// the options of the call take precedence over its retry policy
+			callOpts = reuseOrNewWithCallOptions(reuseOrNewWithCallOptions(intOpts, policyOpts), retryOpts)
+		}
 		// short circuit for simplicity, and avoiding allocations.
 		if callOpts.max == 0 {
 			return invoker(ctx, method, req, reply, cc, grpcOpts...)
 		}
 		var lastErr error
 		for attempt := uint(0); attempt < callOpts.max; attempt++ {
+			if attempt > 0 && !callOpts.budget.allowRetry() {
+				c.GetLogger().Warn(
+					"retrying of unary invoker stopped by the retry budget",
+					zap.String("target", cc.Target()),
+					zap.String("method", method),
+					zap.Uint("attempt", attempt),
+				)
+				return lastErr
+			}
 			if err := waitRetryBackoff(ctx, attempt, callOpts); err != nil {
 				return err
 			}
@@ -57,8 +79,10 @@ func (c *Client) unaryClientInterceptor(optFuncs ...retryOption) grpc.UnaryClien
 				zap.String("method", method),
 				zap.Uint("attempt", attempt),
 			)
-			lastErr = invoker(ctx, method, req, reply, cc, grpcOpts...)
+			var md metadata.MD
+			lastErr = invoker(ctx, method, req, reply, cc, append(grpcOpts[:len(grpcOpts):len(grpcOpts)], grpc.Header(&md))...)
 			if lastErr == nil {
+				callOpts.budget.succeeded()
 				return nil
 			}
 			c.GetLogger().Warn(
@@ -76,7 +100,9 @@ func (c *Client) unaryClientInterceptor(optFuncs ...retryOption) grpc.UnaryClien
 				// its the callCtx deadline or cancellation, in which case try again.
 				continue
 			}
-			if c.shouldRefreshToken(lastErr, callOpts) {
+			// only the clients given a username and password can fetch a new token,
+			// not those sending the tokens of an external identity provider
+			if c.authTokenBundle != nil && c.shouldRefreshToken(lastErr, callOpts) {
 				// clear auth token before refreshing it.
 				// call c.Auth.Authenticate with an invalid token will always fail the auth check on the server-side,
 				// if the server has not apply the patch of pr #12165 (https://github.com/etcd-io/etcd/pull/12165)
@@ -94,9 +120,18 @@ func (c *Client) unaryClientInterceptor(optFuncs ...retryOption) grpc.UnaryClien
 				}
 				continue
 			}
+			if d, ok := retryAfter(lastErr, md); ok {
+				// overloaded writes are rejected before being proposed, so
+				// they are safe to retry once the server hints to.
+				if err := waitRetryAfter(ctx, d); err != nil {
+					return err
+				}
+				continue
+			}
 			if !isSafeRetry(c, lastErr, callOpts) {
 				return lastErr
 			}
+			callOpts.budget.failed()
 		}
 		return lastErr
 	}
@@ -113,7 +148,13 @@ func (c *Client) unaryClientInterceptor(optFuncs ...retryOption) grpc.UnaryClien
 func (c *Client) streamClientInterceptor(optFuncs ...retryOption) grpc.StreamClientInterceptor {
 	intOpts := reuseOrNewWithCallOptions(defaultOptions, optFuncs)
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
-		ctx = withVersion(ctx)
+		ctx = withVersion(ctx, c.apiVersion())
+		if c.cfg.MetricsHooks != nil {
+			streamer = c.metricsStreamer(streamer)
+		}
+		if c.cfg.FenceClusterEpoch {
+			ctx = c.withClusterEpoch(ctx)
+		}
 		// getToken automatically
 		// TODO(cfc4n): keep this code block, remove codes about getToken in client.go after pr #12165 merged.
 		if c.authTokenBundle != nil {
@@ -261,7 +302,7 @@ func (s *serverStreamingRetryingStream) receiveMsgAndIndicateRetry(m interface{}
 		// its the callCtx deadline or cancellation, in which case try again.
 		return true, err
 	}
-	if s.client.shouldRefreshToken(err, s.callOpts) {
+	if s.client.authTokenBundle != nil && s.client.shouldRefreshToken(err, s.callOpts) {
 		// clear auth token to avoid failure when call getToken
 		s.client.authTokenBundle.UpdateAuthToken("")
 
@@ -312,6 +353,35 @@ func waitRetryBackoff(ctx context.Context, attempt uint, callOpts *options) erro
 	return nil
 }
 
+// retryAfter returns how long to wait before retrying a request rejected
+// since the server was overloaded, as hinted by the server.
+func retryAfter(err error, md metadata.MD) (time.Duration, bool) {
+	if rpctypes.Error(err) != rpctypes.ErrOverloaded {
+		return 0, false
+	}
+	vs := md.Get(rpctypes.MetadataRetryAfterKey)
+	if len(vs) == 0 {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(vs[0], 10, 64)
+	if err != nil || ms < 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// waitRetryAfter waits for the given duration unless the context is done first.
+func waitRetryAfter(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return contextErrToGrpcErr(ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
 // isSafeRetry returns "true", if request is safe for retry with the given error.
 func isSafeRetry(c *Client, err error, callOpts *options) bool {
 	if isContextError(err) {
@@ -330,7 +400,7 @@ func isSafeRetry(c *Client, err error, callOpts *options) bool {
 
 	switch callOpts.retryPolicy {
 	case repeatable:
-		return isSafeRetryImmutableRPC(err)
+		return isSafeRetryImmutableRPC(err, callOpts.retryableCodes)
 	case nonRepeatable:
 		return isSafeRetryMutableRPC(err)
 	default:
@@ -392,11 +462,27 @@ func withBackoff(bf backoffFunc) retryOption {
 	}}
 }
 
+// withRetryableCodes sets the codes on which repeatable requests are retried.
+func withRetryableCodes(cs []codes.Code) retryOption {
+	return retryOption{applyFunc: func(o *options) {
+		o.retryableCodes = cs
+	}}
+}
+
+// withRetryBudget sets the budget limiting the retries.
+func withRetryBudget(b *retryBudget) retryOption {
+	return retryOption{applyFunc: func(o *options) {
+		o.budget = b
+	}}
+}
+
 type options struct {
-	retryPolicy retryPolicy
-	max         uint
-	backoffFunc backoffFunc
-	retryAuth   bool
+	retryPolicy    retryPolicy
+	max            uint
+	backoffFunc    backoffFunc
+	retryAuth      bool
+	retryableCodes []codes.Code
+	budget         *retryBudget
 }
 
 // retryOption is a grpc.CallOption that is local to clientv3's retry interceptor.
@@ -1,9 +1,16 @@
 package clientv3
 
 import (
+	"context"
+	"sync"
 	"testing"
+	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	grpccredentials "google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/v3/credentials"
@@ -124,3 +131,162 @@ func TestClientShouldRefreshToken(t *testing.T) {
 		})
 	}
 }
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		md    metadata.MD
+		want  time.Duration
+		retry bool
+	}{
+		{"overloaded with hint", rpctypes.ErrGRPCOverloaded, metadata.Pairs(rpctypes.MetadataRetryAfterKey, "250"), 250 * time.Millisecond, true},
+		{"overloaded without hint", rpctypes.ErrGRPCOverloaded, nil, 0, false},
+		{"overloaded with invalid hint", rpctypes.ErrGRPCOverloaded, metadata.Pairs(rpctypes.MetadataRetryAfterKey, "soon"), 0, false},
+		{"other error with hint", rpctypes.ErrGRPCRequestTooManyRequests, metadata.Pairs(rpctypes.MetadataRetryAfterKey, "250"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, retry := retryAfter(tt.err, tt.md)
+			if got != tt.want || retry != tt.retry {
+				t.Errorf("retryAfter() = %v, %v, want %v, %v", got, retry, tt.want, tt.retry)
+			}
+		})
+	}
+}
+
+func TestUnaryClientInterceptorRetryPolicy(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	exhausted := status.Error(codes.ResourceExhausted, "exhausted")
+	noAddress := status.Error(codes.Unavailable, "there is no address available")
+	tests := []struct {
+		name          string
+		policy        *RetryPolicy
+		nonRepeatable *RetryPolicy
+		rp            retryPolicy
+		err           error
+
+		wattempts int
+	}{
+		{name: "max attempts", policy: &RetryPolicy{MaxAttempts: 3}, rp: repeatable, err: unavailable, wattempts: 3},
+		{name: "retries disabled", policy: &RetryPolicy{MaxAttempts: 1}, rp: repeatable, err: unavailable, wattempts: 1},
+		{name: "code not retryable", policy: &RetryPolicy{MaxAttempts: 3}, rp: repeatable, err: exhausted, wattempts: 1},
+		{
+			name:      "retryable code",
+			policy:    &RetryPolicy{MaxAttempts: 3, RetryableCodes: []codes.Code{codes.ResourceExhausted}},
+			rp:        repeatable,
+			err:       exhausted,
+			wattempts: 3,
+		},
+		{name: "non-repeatable connected", policy: &RetryPolicy{MaxAttempts: 3}, rp: nonRepeatable, err: unavailable, wattempts: 1},
+		{name: "non-repeatable not connected", policy: &RetryPolicy{MaxAttempts: 3}, rp: nonRepeatable, err: noAddress, wattempts: 3},
+		{
+			name:          "non-repeatable policy",
+			policy:        &RetryPolicy{MaxAttempts: 3},
+			nonRepeatable: &RetryPolicy{MaxAttempts: 5},
+			rp:            nonRepeatable,
+			err:           noAddress,
+			wattempts:     5,
+		},
+		{
+			name:      "budget",
+			policy:    &RetryPolicy{MaxAttempts: 5, Budget: &RetryBudget{MaxTokens: 6, TokenRatio: 1}},
+			rp:        repeatable,
+			err:       unavailable,
+			wattempts: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCtxClient(context.Background())
+			c.epMu, c.endpoints = new(sync.RWMutex), []string{"localhost:2379"}
+			tt.policy.InitialBackoff = time.Millisecond
+			if err := c.setRetryPolicies(tt.policy, tt.nonRepeatable); err != nil {
+				t.Fatal(err)
+			}
+			cc, err := grpc.Dial("passthrough:///localhost:2379", grpc.WithInsecure())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cc.Close()
+
+			attempts := 0
+			invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				attempts++
+				return tt.err
+			}
+			interceptor := c.unaryClientInterceptor(withMax(defaultUnaryMaxRetries))
+			err = interceptor(context.Background(), "/etcdserverpb.KV/Range", nil, nil, cc, invoker, withRetryPolicy(tt.rp))
+			if err != tt.err {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+			if attempts != tt.wattempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wattempts)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		werr   bool
+	}{
+		{name: "zero", policy: RetryPolicy{}},
+		{name: "valid", policy: RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond, BackoffMultiplier: 2, MaxBackoff: time.Second, JitterFraction: 0.2, Budget: &RetryBudget{MaxTokens: 10, TokenRatio: 0.1}}},
+		{name: "negative backoff", policy: RetryPolicy{InitialBackoff: -time.Millisecond}, werr: true},
+		{name: "negative multiplier", policy: RetryPolicy{BackoffMultiplier: -1}, werr: true},
+		{name: "jitter above 1", policy: RetryPolicy{JitterFraction: 1.5}, werr: true},
+		{name: "empty budget", policy: RetryPolicy{Budget: &RetryBudget{}}, werr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.validate(); (err != nil) != tt.werr {
+				t.Errorf("validate() = %v, want error %v", err, tt.werr)
+			}
+		})
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		multiplier float64
+		maxWait    time.Duration
+		rounds     uint
+		want       time.Duration
+	}{
+		{multiplier: 0, rounds: 3, want: 10 * time.Millisecond},
+		{multiplier: 2, rounds: 0, want: 10 * time.Millisecond},
+		{multiplier: 2, rounds: 3, want: 80 * time.Millisecond},
+		{multiplier: 2, maxWait: 50 * time.Millisecond, rounds: 3, want: 50 * time.Millisecond},
+		{multiplier: 2, maxWait: time.Second, rounds: 1000, want: time.Second},
+	}
+	for _, tt := range tests {
+		if got := exponentialBackoff(10*time.Millisecond, tt.multiplier, tt.maxWait, tt.rounds); got != tt.want {
+			t.Errorf("exponentialBackoff(10ms, %v, %v, %d) = %v, want %v", tt.multiplier, tt.maxWait, tt.rounds, got, tt.want)
+		}
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	b := newRetryBudget(RetryBudget{MaxTokens: 4, TokenRatio: 0.5})
+	b.failed()
+	if !b.allowRetry() {
+		t.Fatalf("retry not allowed with 3 of 4 tokens")
+	}
+	b.failed()
+	if b.allowRetry() {
+		t.Fatalf("retry allowed with 2 of 4 tokens")
+	}
+	b.succeeded()
+	if !b.allowRetry() {
+		t.Fatalf("retry not allowed with 2.5 of 4 tokens")
+	}
+	for i := 0; i < 10; i++ {
+		b.succeeded()
+	}
+	if b.tokens != 4 {
+		t.Errorf("tokens = %v, want at most 4", b.tokens)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+// RetryPolicy configures how the client retries the unary requests failing
+// with a transient error. A field left to its zero value takes its default,
+// so the zero RetryPolicy behaves as a client without a retry policy.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a request, the first
+	// one included. If 0, it defaults to 100. 1 disables the retries.
+	MaxAttempts uint `json:"max-attempts"`
+
+	// InitialBackoff is the wait before retrying a request once it failed
+	// on a quorum of the endpoints. Retries within a round over a quorum are
+	// sent to the next endpoint without waiting. If 0, it defaults to 25ms.
+	InitialBackoff time.Duration `json:"initial-backoff"`
+
+	// BackoffMultiplier multiplies the wait after each round over a quorum
+	// of the endpoints, up to MaxBackoff. If 0, the wait is constant.
+	BackoffMultiplier float64 `json:"backoff-multiplier"`
+
+	// MaxBackoff is the maximum wait between two rounds over a quorum of the
+	// endpoints. If 0, the wait is not capped.
+	MaxBackoff time.Duration `json:"max-backoff"`
+
+	// JitterFraction is the fraction of each wait randomly added or removed
+	// from it, so that clients failing together do not retry together.
+	// If 0, it defaults to 0.10.
+	JitterFraction float64 `json:"jitter-fraction"`
+
+	// RetryableCodes are the gRPC status codes on which the requests safe to
+	// repeat, such as ranges, are retried. If empty, only codes.Unavailable is
+	// retried. The requests not safe to repeat, such as puts, are only retried
+	// when no endpoint could be reached, whatever their codes.
+	RetryableCodes []codes.Code `json:"retryable-codes"`
+
+	// Budget when set limits the retries while most requests fail.
+	Budget *RetryBudget `json:"budget"`
+}
+
+// RetryBudget limits the retries of the requests of a client while most of
+// them fail, so that retries do not add to the load of an unhealthy cluster.
+// The budget holds MaxTokens tokens at first. Each retried failure takes one
+// token and each success gives back TokenRatio tokens, up to MaxTokens.
+// Requests are not retried while the budget holds at most half of MaxTokens.
+type RetryBudget struct {
+	// MaxTokens is the number of tokens of the budget.
+	MaxTokens float64 `json:"max-tokens"`
+
+	// TokenRatio is the number of tokens given back by each success.
+	TokenRatio float64 `json:"token-ratio"`
+}
+
+func (p *RetryPolicy) validate() error {
+	switch {
+	case p.InitialBackoff < 0:
+		return fmt.Errorf("initial backoff %v must not be negative", p.InitialBackoff)
+	case p.MaxBackoff < 0:
+		return fmt.Errorf("max backoff %v must not be negative", p.MaxBackoff)
+	case p.BackoffMultiplier < 0:
+		return fmt.Errorf("backoff multiplier %v must not be negative", p.BackoffMultiplier)
+	case p.JitterFraction < 0 || p.JitterFraction > 1:
+		return fmt.Errorf("jitter fraction %v must be within [0, 1]", p.JitterFraction)
+	}
+	if p.Budget != nil && (p.Budget.MaxTokens <= 0 || p.Budget.TokenRatio <= 0) {
+		return fmt.Errorf("retry budget max tokens %v and token ratio %v must be positive", p.Budget.MaxTokens, p.Budget.TokenRatio)
+	}
+	return nil
+}
+
+// setRetryPolicies validates the retry policies of the repeatable and
+// non-repeatable requests, the latter defaulting to the former. Requests
+// sharing a policy share its budget.
+func (c *Client) setRetryPolicies(repeatablePolicy, nonRepeatablePolicy *RetryPolicy) error {
+	if nonRepeatablePolicy == nil {
+		nonRepeatablePolicy = repeatablePolicy
+	}
+	opts := make(map[*RetryPolicy][]retryOption)
+	for _, rp := range []retryPolicy{repeatable, nonRepeatable} {
+		p := repeatablePolicy
+		if rp == nonRepeatable {
+			p = nonRepeatablePolicy
+		}
+		if p == nil {
+			continue
+		}
+		if _, ok := opts[p]; !ok {
+			if err := p.validate(); err != nil {
+				return fmt.Errorf("invalid %s retry policy: %v", rp, err)
+			}
+			opts[p] = c.retryOptions(p)
+		}
+		if c.retryPolicyOpts == nil {
+			c.retryPolicyOpts = make(map[retryPolicy][]retryOption)
+		}
+		c.retryPolicyOpts[rp] = opts[p]
+	}
+	return nil
+}
+
+// retryOptions returns the options of the retry interceptor applying the policy.
+func (c *Client) retryOptions(p *RetryPolicy) []retryOption {
+	maxAttempts := defaultUnaryMaxRetries
+	if p.MaxAttempts > 0 {
+		maxAttempts = p.MaxAttempts
+	}
+	opts := []retryOption{
+		withMax(maxAttempts),
+		withBackoff(c.retryPolicyBackoff(p)),
+		withRetryableCodes(p.RetryableCodes),
+	}
+	if p.Budget != nil {
+		opts = append(opts, withRetryBudget(newRetryBudget(*p.Budget)))
+	}
+	return opts
+}
+
+// retryPolicyBackoff backs off after each round over a quorum of the
+// endpoints, as roundRobinQuorumBackoff does, with the wait of the policy.
+func (c *Client) retryPolicyBackoff(p *RetryPolicy) backoffFunc {
+	initial := defaultBackoffWaitBetween
+	if p.InitialBackoff > 0 {
+		initial = p.InitialBackoff
+	}
+	jitterFraction := defaultBackoffJitterFraction
+	if p.JitterFraction > 0 {
+		jitterFraction = p.JitterFraction
+	}
+	return func(attempt uint) time.Duration {
+		quorum := uint(len(c.Endpoints()))/2 + 1
+		if attempt%quorum != 0 {
+			return 0
+		}
+		wait := exponentialBackoff(initial, p.BackoffMultiplier, p.MaxBackoff, attempt/quorum-1)
+		c.lg.Debug("backoff", zap.Uint("attempt", attempt), zap.Uint("quorum", quorum), zap.Duration("wait", wait), zap.Float64("jitterFraction", jitterFraction))
+		return jitterUp(wait, jitterFraction)
+	}
+}
+
+// exponentialBackoff returns the wait after the given number of rounds,
+// starting from initial, multiplied by multiplier after each round and
+// capped by maxWait if positive.
+func exponentialBackoff(initial time.Duration, multiplier float64, maxWait time.Duration, rounds uint) time.Duration {
+	wait := float64(initial)
+	if multiplier > 0 {
+		wait *= math.Pow(multiplier, float64(rounds))
+	}
+	if maxWait > 0 && wait > float64(maxWait) {
+		return maxWait
+	}
+	if wait > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(wait)
+}
+
+// isRetryableCode returns true if the code is one of the retryable codes, or
+// codes.Unavailable if there are none.
+func isRetryableCode(code codes.Code, retryableCodes []codes.Code) bool {
+	if len(retryableCodes) == 0 {
+		return code == codes.Unavailable
+	}
+	for _, c := range retryableCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryBudget is the token bucket of a RetryBudget, shared by the requests
+// of a client under the same retry policy.
+type retryBudget struct {
+	mu         sync.Mutex
+	tokens     float64
+	maxTokens  float64
+	tokenRatio float64
+}
+
+func newRetryBudget(b RetryBudget) *retryBudget {
+	return &retryBudget{tokens: b.MaxTokens, maxTokens: b.MaxTokens, tokenRatio: b.TokenRatio}
+}
+
+// allowRetry returns true if the budget allows a request to be retried.
+// A nil budget always does.
+func (b *retryBudget) allowRetry() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.maxTokens/2
+}
+
+// failed takes a token for a failure to retry.
+func (b *retryBudget) failed() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Max(b.tokens-1, 0)
+}
+
+// succeeded gives back the tokens of a success.
+func (b *retryBudget) succeeded() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.tokens+b.tokenRatio, b.maxTokens)
+}
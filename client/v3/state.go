@@ -0,0 +1,150 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/connectivity"
+)
+
+// ConnectionState is a state of the connection of a client to the cluster.
+type ConnectionState int
+
+const (
+	// StateConnected reports that the client is connected to at least one
+	// endpoint.
+	StateConnected ConnectionState = iota
+	// StateDegraded reports that the client lost the connection to all the
+	// endpoints, and is trying to reconnect.
+	StateDegraded
+	// StateSwitchingEndpoints reports that the endpoints of the client
+	// changed, e.g. by SetEndpoints or an auto sync.
+	StateSwitchingEndpoints
+	// StateReauthenticating reports that the client is fetching a new auth
+	// token.
+	StateReauthenticating
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateDegraded:
+		return "degraded"
+	case StateSwitchingEndpoints:
+		return "switching endpoints"
+	case StateReauthenticating:
+		return "reauthenticating"
+	default:
+		return "unknown"
+	}
+}
+
+// StateEvent is a transition of the connection state of a client.
+type StateEvent struct {
+	State ConnectionState
+	// Endpoints are the endpoints of the client at the transition.
+	Endpoints []string
+}
+
+// stateWatcherBufferSize is the number of transitions kept for a watcher
+// that does not drain its channel; newer transitions are dropped.
+const stateWatcherBufferSize = 16
+
+type stateWatchers struct {
+	mu        sync.Mutex
+	chs       []chan StateEvent
+	connected bool
+	closed    bool
+}
+
+// StateWatcher returns a channel receiving the transitions of the connection
+// state of the client, starting with StateConnected if the client is already
+// connected. Transitions are dropped while the channel is full. The channel is
+// closed when the client is closed, or right away if the client has no
+// connection.
+func (c *Client) StateWatcher() <-chan StateEvent {
+	ch := make(chan StateEvent, stateWatcherBufferSize)
+	if c.conn == nil {
+		close(ch)
+		return ch
+	}
+	eps := c.Endpoints()
+	c.states.mu.Lock()
+	defer c.states.mu.Unlock()
+	if c.states.closed {
+		close(ch)
+		return ch
+	}
+	if c.states.connected {
+		ch <- StateEvent{State: StateConnected, Endpoints: eps}
+	}
+	c.states.chs = append(c.states.chs, ch)
+	return ch
+}
+
+func (c *Client) notifyState(s ConnectionState) {
+	ev := StateEvent{State: s, Endpoints: c.Endpoints()}
+	c.states.mu.Lock()
+	defer c.states.mu.Unlock()
+	switch s {
+	case StateConnected:
+		c.states.connected = true
+	case StateDegraded:
+		c.states.connected = false
+	}
+	for _, ch := range c.states.chs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// watchConnState reports the client connected when the balancer has a ready
+// endpoint, and degraded when it has none after being connected.
+func (c *Client) watchConnState() {
+	defer func() {
+		c.states.mu.Lock()
+		c.states.closed = true
+		for _, ch := range c.states.chs {
+			close(ch)
+		}
+		c.states.chs = nil
+		c.states.mu.Unlock()
+	}()
+
+	connected := false
+	s := c.conn.GetState()
+	for {
+		switch s {
+		case connectivity.Ready:
+			if !connected {
+				connected = true
+				c.notifyState(StateConnected)
+			}
+		case connectivity.TransientFailure, connectivity.Connecting, connectivity.Idle:
+			if connected {
+				connected = false
+				c.notifyState(StateDegraded)
+			}
+		}
+		if !c.conn.WaitForStateChange(c.ctx, s) {
+			return
+		}
+		s = c.conn.GetState()
+	}
+}
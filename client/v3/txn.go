@@ -16,6 +16,7 @@ package clientv3
 
 import (
 	"context"
+	"io"
 	"sync"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
@@ -51,6 +52,17 @@ type Txn interface {
 	Commit() (*TxnResponse, error)
 }
 
+// CommitStreamer is implemented by the Txn of a Client, which can commit the
+// transaction like Commit but receive the response in chunks over the
+// TxnStream RPC, so large range results are not bounded by the maximum
+// message size. A range of a nested transaction is still received in a
+// single message. The Txn wrappers need not implement it.
+type CommitStreamer interface {
+	// CommitStream tries to commit the transaction, receiving the response
+	// over the TxnStream RPC.
+	CommitStream() (*TxnResponse, error)
+}
+
 type txn struct {
 	kv  *kv
 	ctx context.Context
@@ -148,3 +160,37 @@ func (txn *txn) Commit() (*TxnResponse, error) {
 	}
 	return (*TxnResponse)(resp), nil
 }
+
+func (txn *txn) CommitStream() (*TxnResponse, error) {
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+
+	r := &pb.TxnRequest{Compare: txn.cmps, Success: txn.sus, Failure: txn.fas}
+
+	stream, err := txn.kv.remote.TxnStream(txn.ctx, r, txn.callOpts...)
+	if err != nil {
+		return nil, toErr(txn.ctx, err)
+	}
+	resp := &pb.TxnResponse{}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, toErr(txn.ctx, err)
+		}
+		resp.Header, resp.Succeeded = chunk.Header, chunk.Succeeded
+		if chunk.Response == nil {
+			continue
+		}
+		// chunks with the same index continue a split range response
+		if last := len(resp.Responses) - 1; last >= 0 && chunk.Index == int64(last) {
+			rr := resp.Responses[last].GetResponseRange()
+			rr.Kvs = append(rr.Kvs, chunk.Response.GetResponseRange().Kvs...)
+			continue
+		}
+		resp.Responses = append(resp.Responses, chunk.Response)
+	}
+	return (*TxnResponse)(resp), nil
+}
@@ -75,6 +75,13 @@ type Watcher interface {
 	// Otherwise, as long as the context has not been canceled or timed out,
 	// watch will retry on other recoverable errors forever until reconnected.
 	//
+	// Watchers sharing a context share a gRPC stream, on which the server
+	// sends the responses of all of them in order, whichever keys or prefixes
+	// they watch. Servers number the responses of a stream; if a response is
+	// found dropped or reordered on the way, e.g. by a faulty proxy, the
+	// stream is reconnected and its watchers resume from the latest revision
+	// they got instead.
+	//
 	// TODO: explicitly set context error in the last "WatchResponse" message and close channel?
 	// Currently, client contexts are overwritten with "valCtx" that never closes.
 	// TODO(v3.4): configure watch retry policy, limit maximum retry number
@@ -151,6 +158,7 @@ type watcher struct {
 	// streams holds all the active grpc streams keyed by ctx value.
 	streams map[string]*watchGrpcStream
 	lg      *zap.Logger
+	hooks   MetricsHooks
 }
 
 // watchGrpcStream tracks all watch resources attached to a single grpc stream.
@@ -207,6 +215,8 @@ type watchRequest struct {
 	createdNotify bool
 	// progressNotify is for progress updates
 	progressNotify bool
+	// progressNotifyInterval is the interval of the progress updates
+	progressNotifyInterval time.Duration
 	// fragmentation should be disabled by default
 	// if true, split watch events when total exceeds
 	// "--max-request-bytes" flag value + 512-byte
@@ -214,6 +224,8 @@ type watchRequest struct {
 
 	// filters is the list of events to filter out
 	filters []pb.WatchCreateRequest_FilterType
+	// valueFilters filter out the put events whose value does not match
+	valueFilters []*pb.WatchValueFilter
 	// get the previous key-value pair before the event happens
 	prevKV bool
 	// retc receives a chan WatchResponse once the watcher is established
@@ -256,10 +268,16 @@ func NewWatchFromWatchClient(wc pb.WatchClient, c *Client) Watcher {
 	if c != nil {
 		w.callOpts = c.callOpts
 		w.lg = c.lg
+		w.hooks = c.cfg.MetricsHooks
 	}
 	return w
 }
 
+// errWatchOutOfOrder is the error of a watch stream on which a response was
+// dropped or reordered, so that the watchers of the stream resume from the
+// latest revision they got.
+var errWatchOutOfOrder = errors.New("watch response out of order")
+
 // never closes
 var valCtxCh = make(chan struct{})
 var zeroTime = time.Unix(0, 0)
@@ -305,6 +323,11 @@ func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) Watch
 		filters = append(filters, pb.WatchCreateRequest_NODELETE)
 	}
 
+	var valueFilters []*pb.WatchValueFilter
+	for i := range ow.valueFilters {
+		valueFilters = append(valueFilters, (*pb.WatchValueFilter)(&ow.valueFilters[i]))
+	}
+
 	wr := &watchRequest{
 		ctx:            ctx,
 		createdNotify:  ow.createdNotify,
@@ -314,8 +337,11 @@ func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) Watch
 		progressNotify: ow.progressNotify,
 		fragment:       ow.fragment,
 		filters:        filters,
+		valueFilters:   valueFilters,
 		prevKV:         ow.prevKV,
 		retc:           make(chan chan WatchResponse, 1),
+
+		progressNotifyInterval: ow.progressNotifyInterval,
 	}
 
 	ok := false
@@ -626,7 +652,9 @@ func (w *watchGrpcStream) run() {
 				// reset for next iteration
 				cur = nil
 
-			case pbresp.Canceled && pbresp.CompactRevision == 0:
+			// watchers canceled by the server with a reason, such as a revoked
+			// permission, get the reason as their last response
+			case pbresp.Canceled && pbresp.CompactRevision == 0 && pbresp.CancelReason == "":
 				delete(cancelSet, pbresp.WatchId)
 				if ws, ok := w.substreams[pbresp.WatchId]; ok {
 					// signal to stream goroutine to update closingc
@@ -673,10 +701,12 @@ func (w *watchGrpcStream) run() {
 
 		// watch client failed on Recv; spawn another if possible
 		case err := <-w.errc:
-			if isHaltErr(w.ctx, err) || toErr(w.ctx, err) == v3rpc.ErrNoLeader {
+			if err != errWatchOutOfOrder && (isHaltErr(w.ctx, err) || toErr(w.ctx, err) == v3rpc.ErrNoLeader) {
 				closeErr = err
 				return
 			}
+			// drop the fragments received on the failed stream
+			cur = nil
 			backoff = w.backoffIfUnavailable(backoff, err)
 			if wc, closeErr = w.newWatchClient(); closeErr != nil {
 				return
@@ -782,13 +812,32 @@ func (w *watchGrpcStream) unicastResponse(wr *WatchResponse, watchId int64) bool
 	case <-ws.donec:
 		return false
 	}
+	if hooks := w.owner.hooks; hooks != nil && len(wr.Events) > 0 {
+		hooks.WatchEventsReceived(ws.initReq.key, len(wr.Events))
+	}
 	return true
 }
 
-// serveWatchClient forwards messages from the grpc stream to run()
+// serveWatchClient forwards messages from the grpc stream to run(). It
+// fails the stream if the sequence numbers of the responses show that one was
+// dropped or reordered, rather than forward the response.
 func (w *watchGrpcStream) serveWatchClient(wc pb.Watch_WatchClient) {
+	// seq is the sequence number of the latest response, if numbered
+	var seq uint64
 	for {
 		resp, err := wc.Recv()
+		if err == nil && resp.Seq != 0 {
+			if seq != 0 && resp.Seq != seq+1 {
+				w.lg.Warn(
+					"watch response out of order; resuming watchers",
+					zap.Uint64("expected-seq", seq+1),
+					zap.Uint64("seq", resp.Seq),
+				)
+				wc.CloseSend()
+				err = errWatchOutOfOrder
+			}
+			seq = resp.Seq
+		}
 		if err != nil {
 			select {
 			case w.errc <- err:
@@ -1045,8 +1094,11 @@ func (wr *watchRequest) toPB() *pb.WatchRequest {
 		RangeEnd:       []byte(wr.end),
 		ProgressNotify: wr.progressNotify,
 		Filters:        wr.filters,
+		ValueFilters:   wr.valueFilters,
 		PrevKv:         wr.prevKV,
 		Fragment:       wr.fragment,
+
+		ProgressNotifyInterval: wr.progressNotifyInterval.Milliseconds(),
 	}
 	cr := &pb.WatchRequest_CreateRequest{CreateRequest: req}
 	return &pb.WatchRequest{RequestUnion: cr}
@@ -15,8 +15,14 @@
 package clientv3
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/mvccpb"
 )
 
@@ -53,3 +59,99 @@ func TestEvent(t *testing.T) {
 		}
 	}
 }
+
+// TestWatchResumeOutOfOrder ensures a watch stream on which a response is
+// dropped is reconnected, its watchers resuming after the latest revision
+// they got.
+func TestWatchResumeOutOfOrder(t *testing.T) {
+	wc := &fakeWatchClient{streams: make(chan *fakeWatchStream, 2)}
+	w := NewWatchFromWatchClient(wc, &Client{lg: zaptest.NewLogger(t)})
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wchc := make(chan WatchChan, 1)
+	go func() { wchc <- w.Watch(ctx, "foo") }()
+
+	stream := wc.next(t)
+	stream.recv(t)
+	stream.respc <- &pb.WatchResponse{Header: &pb.ResponseHeader{Revision: 1}, Created: true, Seq: 1}
+	wch := <-wchc
+	stream.respc <- putResponse(2, 2)
+	if wr := <-wch; len(wr.Events) != 1 || wr.Events[0].Kv.ModRevision != 2 {
+		t.Fatalf("unexpected watch response %+v", wr)
+	}
+	// response 3 is dropped
+	stream.respc <- putResponse(4, 4)
+
+	stream = wc.next(t)
+	if req := stream.recv(t).GetCreateRequest(); req == nil || req.StartRevision != 3 {
+		t.Fatalf("unexpected resume request %+v", req)
+	}
+	stream.respc <- &pb.WatchResponse{Header: &pb.ResponseHeader{Revision: 4}, Created: true, Seq: 1}
+	stream.respc <- putResponse(3, 2)
+	if wr := <-wch; len(wr.Events) != 1 || wr.Events[0].Kv.ModRevision != 3 {
+		t.Fatalf("unexpected watch response %+v", wr)
+	}
+}
+
+func putResponse(rev int64, seq uint64) *pb.WatchResponse {
+	return &pb.WatchResponse{
+		Header: &pb.ResponseHeader{Revision: rev},
+		Events: []*mvccpb.Event{{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("foo"), ModRevision: rev}}},
+		Seq:    seq,
+	}
+}
+
+type fakeWatchClient struct {
+	streams chan *fakeWatchStream
+}
+
+func (wc *fakeWatchClient) Watch(ctx context.Context, _ ...grpc.CallOption) (pb.Watch_WatchClient, error) {
+	s := &fakeWatchStream{ctx: ctx, reqc: make(chan *pb.WatchRequest, 1), respc: make(chan *pb.WatchResponse, 1)}
+	wc.streams <- s
+	return s, nil
+}
+
+func (wc *fakeWatchClient) next(t *testing.T) *fakeWatchStream {
+	select {
+	case s := <-wc.streams:
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a watch stream")
+		return nil
+	}
+}
+
+type fakeWatchStream struct {
+	grpc.ClientStream
+	ctx   context.Context
+	reqc  chan *pb.WatchRequest
+	respc chan *pb.WatchResponse
+}
+
+func (s *fakeWatchStream) Send(req *pb.WatchRequest) error {
+	s.reqc <- req
+	return nil
+}
+
+func (s *fakeWatchStream) Recv() (*pb.WatchResponse, error) {
+	select {
+	case resp := <-s.respc:
+		return resp, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func (s *fakeWatchStream) CloseSend() error { return nil }
+
+func (s *fakeWatchStream) recv(t *testing.T) *pb.WatchRequest {
+	select {
+	case req := <-s.reqc:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a watch request")
+		return nil
+	}
+}
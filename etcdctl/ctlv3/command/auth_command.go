@@ -15,9 +15,12 @@
 package command
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 
 	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/api/v3/authpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
 )
@@ -32,6 +35,7 @@ func NewAuthCommand() *cobra.Command {
 	ac.AddCommand(newAuthEnableCommand())
 	ac.AddCommand(newAuthDisableCommand())
 	ac.AddCommand(newAuthStatusCommand())
+	ac.AddCommand(newAuthEffectivePermissionsCommand())
 
 	return ac
 }
@@ -98,12 +102,16 @@ func authEnableCommandFunc(cmd *cobra.Command, args []string) {
 	fmt.Println("Authentication Enabled")
 }
 
+var authDisableDryRun bool
+
 func newAuthDisableCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "disable",
 		Short: "Disables authentication",
 		Run:   authDisableCommandFunc,
 	}
+	cmd.Flags().BoolVar(&authDisableDryRun, "dry-run", false, "report the users and roles whose permissions would stop being enforced without disabling authentication")
+	return cmd
 }
 
 // authDisableCommandFunc executes the "auth disable" command.
@@ -112,6 +120,11 @@ func authDisableCommandFunc(cmd *cobra.Command, args []string) {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("auth disable command does not accept any arguments"))
 	}
 
+	if authDisableDryRun {
+		authDisableDryRunReport(cmd)
+		return
+	}
+
 	ctx, cancel := commandCtx(cmd)
 	_, err := mustClientFromCmd(cmd).Auth.AuthDisable(ctx)
 	cancel()
@@ -121,3 +134,161 @@ func authDisableCommandFunc(cmd *cobra.Command, args []string) {
 
 	fmt.Println("Authentication Disabled")
 }
+
+// authDisableDryRunReport prints the users and roles whose permissions
+// disabling authentication would stop enforcing.
+func authDisableDryRunReport(cmd *cobra.Command) {
+	c := mustClientFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	defer cancel()
+	sresp, err := c.Auth.AuthStatus(ctx)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	if !sresp.Enabled {
+		fmt.Println("authentication is already disabled")
+		return
+	}
+	uresp, err := c.Auth.UserList(ctx)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	rresp, err := c.Auth.RoleList(ctx)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	fmt.Printf("would disable authentication: the permissions of %d roles granted to %d users would stop being enforced, and all clients could read and write all keys\n", len(rresp.Roles), len(uresp.Users))
+}
+
+func newAuthEffectivePermissionsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "effective-permissions <user name>",
+		Short: "Prints the keys a user may read and write, merged from all its roles",
+		Long: `Prints the key intervals a user may read and write, merging the permissions
+of all the roles granted to it. Overlapping and adjacent intervals are merged,
+so that each key is covered by at most one interval of each permission type.
+Also prints whether the user may revoke and keep alive the leases of other
+users.
+`,
+		Run: authEffectivePermissionsCommandFunc,
+	}
+}
+
+// keyInterval is the interval of keys [Key, RangeEnd), or the single key
+// Key if RangeEnd is empty. A RangeEnd of "\x00" leaves the interval open
+// ended, as in the permissions of a role.
+type keyInterval struct {
+	Key      []byte `json:"Key"`
+	RangeEnd []byte `json:"RangeEnd,omitempty"`
+}
+
+type effectivePerms struct {
+	User  string        `json:"User"`
+	Roles []string      `json:"Roles"`
+	Read  []keyInterval `json:"Read"`
+	Write []keyInterval `json:"Write"`
+	Lease bool          `json:"Lease"`
+}
+
+// authEffectivePermissionsCommandFunc executes the "auth effective-permissions" command.
+func authEffectivePermissionsCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("auth effective-permissions command requires user name as its argument"))
+	}
+
+	cli := mustClientFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	defer cancel()
+	user, err := cli.UserGet(ctx, args[0])
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	var perms []*authpb.Permission
+	for _, role := range user.Roles {
+		if role == rootRole {
+			perms = append(perms, &authpb.Permission{PermType: authpb.READWRITE, Key: []byte{}, RangeEnd: []byte{0}}, &authpb.Permission{PermType: authpb.LEASE})
+			continue
+		}
+		resp, err := cli.RoleGet(ctx, role)
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("failed to get role %s (%v)", role, err))
+		}
+		perms = append(perms, resp.Perm...)
+	}
+
+	ep := mergePermissions(perms)
+	ep.User, ep.Roles = args[0], user.Roles
+	display.EffectivePermissions(ep)
+}
+
+// mergePermissions merges the key intervals of perms by permission type.
+func mergePermissions(perms []*authpb.Permission) effectivePerms {
+	var ep effectivePerms
+	var read, write []keyInterval
+	for _, perm := range perms {
+		ivl := keyInterval{Key: perm.Key, RangeEnd: perm.RangeEnd}
+		switch perm.PermType {
+		case authpb.READ:
+			read = append(read, ivl)
+		case authpb.WRITE:
+			write = append(write, ivl)
+		case authpb.READWRITE:
+			read = append(read, ivl)
+			write = append(write, ivl)
+		case authpb.LEASE:
+			ep.Lease = true
+		}
+	}
+	ep.Read, ep.Write = mergeKeyIntervals(read), mergeKeyIntervals(write)
+	return ep
+}
+
+// mergeKeyIntervals sorts the intervals and merges the overlapping and
+// adjacent ones.
+func mergeKeyIntervals(ivls []keyInterval) []keyInterval {
+	// end returns the exclusive end of the interval, nil if open ended
+	end := func(ivl keyInterval) []byte {
+		switch {
+		case len(ivl.RangeEnd) == 0:
+			return append(append([]byte(nil), ivl.Key...), 0)
+		case len(ivl.RangeEnd) == 1 && ivl.RangeEnd[0] == 0:
+			return nil
+		}
+		return ivl.RangeEnd
+	}
+	// endsBefore returns whether the end a is before the end b
+	endsBefore := func(a, b []byte) bool {
+		return a != nil && (b == nil || bytes.Compare(a, b) < 0)
+	}
+
+	type span struct{ start, end []byte }
+	var spans []span
+	for _, ivl := range ivls {
+		s := span{start: ivl.Key, end: end(ivl)}
+		if s.end != nil && bytes.Compare(s.start, s.end) >= 0 {
+			// an empty interval permits no key
+			continue
+		}
+		spans = append(spans, s)
+	}
+	sort.Slice(spans, func(i, j int) bool { return bytes.Compare(spans[i].start, spans[j].start) < 0 })
+
+	var merged []keyInterval
+	for i := 0; i < len(spans); {
+		s := spans[i]
+		for i++; i < len(spans) && !endsBefore(s.end, spans[i].start); i++ {
+			if endsBefore(s.end, spans[i].end) {
+				s.end = spans[i].end
+			}
+		}
+		ivl := keyInterval{Key: s.start}
+		switch {
+		case s.end == nil:
+			ivl.RangeEnd = []byte{0}
+		case !bytes.Equal(s.end, append(append([]byte(nil), s.start...), 0)):
+			ivl.RangeEnd = s.end
+		}
+		merged = append(merged, ivl)
+	}
+	return merged
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+)
+
+func TestMergeKeyIntervals(t *testing.T) {
+	ivl := func(key, end string) keyInterval {
+		ki := keyInterval{Key: []byte(key)}
+		if end != "" {
+			ki.RangeEnd = []byte(end)
+		}
+		return ki
+	}
+	tests := []struct {
+		ivls  []keyInterval
+		wivls []keyInterval
+	}{
+		{nil, nil},
+		// single keys stay single keys
+		{[]keyInterval{ivl("b", ""), ivl("a", "")}, []keyInterval{ivl("a", ""), ivl("b", "")}},
+		// duplicated keys are merged
+		{[]keyInterval{ivl("a", ""), ivl("a", "")}, []keyInterval{ivl("a", "")}},
+		// overlapping ranges are merged
+		{[]keyInterval{ivl("a", "c"), ivl("b", "d")}, []keyInterval{ivl("a", "d")}},
+		// adjacent ranges are merged
+		{[]keyInterval{ivl("c", "e"), ivl("a", "c")}, []keyInterval{ivl("a", "e")}},
+		// a key adjacent to a range is merged into it
+		{[]keyInterval{ivl("a", "b"), ivl("b", "")}, []keyInterval{ivl("a", "b\x00")}},
+		// a range covering a key absorbs it
+		{[]keyInterval{ivl("a", "z"), ivl("k", "")}, []keyInterval{ivl("a", "z")}},
+		// disjoint ranges are kept apart
+		{[]keyInterval{ivl("d", "f"), ivl("a", "c")}, []keyInterval{ivl("a", "c"), ivl("d", "f")}},
+		// open ended ranges absorb everything after them
+		{[]keyInterval{ivl("b", "\x00"), ivl("a", "c"), ivl("x", "")}, []keyInterval{ivl("a", "\x00")}},
+		// empty ranges permit nothing
+		{[]keyInterval{ivl("c", "a"), ivl("b", "b")}, nil},
+	}
+	for i, tt := range tests {
+		ivls := mergeKeyIntervals(tt.ivls)
+		if !reflect.DeepEqual(ivls, tt.wivls) {
+			t.Errorf("#%d: merged = %q, want %q", i, ivls, tt.wivls)
+		}
+	}
+}
+
+func TestMergePermissions(t *testing.T) {
+	perms := []*authpb.Permission{
+		{PermType: authpb.READ, Key: []byte("a"), RangeEnd: []byte("c")},
+		{PermType: authpb.WRITE, Key: []byte("b")},
+		{PermType: authpb.READWRITE, Key: []byte("b"), RangeEnd: []byte("d")},
+		{PermType: authpb.LEASE},
+	}
+	wep := effectivePerms{
+		Read:  []keyInterval{{Key: []byte("a"), RangeEnd: []byte("d")}},
+		Write: []keyInterval{{Key: []byte("b"), RangeEnd: []byte("d")}},
+		Lease: true,
+	}
+	if ep := mergePermissions(perms); !reflect.DeepEqual(ep, wep) {
+		t.Errorf("effective permissions = %+v, want %+v", ep, wep)
+	}
+}
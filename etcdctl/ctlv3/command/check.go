@@ -24,6 +24,7 @@ import (
 	"os/signal"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	v3 "go.etcd.io/etcd/client/v3"
@@ -40,6 +41,8 @@ var (
 	checkPerfPrefix      string
 	checkDatascaleLoad   string
 	checkDatascalePrefix string
+	checkHashKVPrefix    string
+	checkHashKVRev       int64
 	autoCompact          bool
 	autoDefrag           bool
 )
@@ -48,32 +51,53 @@ type checkPerfCfg struct {
 	limit    int
 	clients  int
 	duration int
+	// watchers is the number of watchers receiving every write.
+	watchers int
+	// leases is the number of leases kept alive, and keepAlives
+	// the number of keepalives sent per second.
+	leases     int
+	keepAlives int
 }
 
 var checkPerfCfgMap = map[string]checkPerfCfg{
 	// TODO: support read limit
 	"s": {
-		limit:    150,
-		clients:  50,
-		duration: 60,
+		limit:      150,
+		clients:    50,
+		duration:   60,
+		watchers:   10,
+		leases:     100,
+		keepAlives: 50,
 	},
 	"m": {
-		limit:    1000,
-		clients:  200,
-		duration: 60,
+		limit:      1000,
+		clients:    200,
+		duration:   60,
+		watchers:   20,
+		leases:     1000,
+		keepAlives: 500,
 	},
 	"l": {
-		limit:    8000,
-		clients:  500,
-		duration: 60,
+		limit:      8000,
+		clients:    500,
+		duration:   60,
+		watchers:   30,
+		leases:     10000,
+		keepAlives: 4000,
 	},
 	"xl": {
-		limit:    15000,
-		clients:  1000,
-		duration: 60,
+		limit:      15000,
+		clients:    1000,
+		duration:   60,
+		watchers:   40,
+		leases:     20000,
+		keepAlives: 8000,
 	},
 }
 
+// checkPerfLeaseTTL is the TTL of the leases kept alive by the perf check.
+const checkPerfLeaseTTL = 10
+
 type checkDatascaleCfg struct {
 	limit   int
 	kvSize  int
@@ -113,6 +137,7 @@ func NewCheckCommand() *cobra.Command {
 
 	cc.AddCommand(NewCheckPerfCommand())
 	cc.AddCommand(NewCheckDatascaleCommand())
+	cc.AddCommand(NewCheckHashKVCommand())
 
 	return cc
 }
@@ -176,16 +201,30 @@ func newCheckPerfCommand(cmd *cobra.Command, args []string) {
 		cobrautl.ExitWithError(cobrautl.ExitInvalidInput, fmt.Errorf("prefix %q has keys. Delete with 'etcdctl del --prefix %s' first", checkPerfPrefix, checkPerfPrefix))
 	}
 
+	leases, err := grantPerfLeases(ctx, clients, cfg.leases)
+	if err != nil {
+		revokePerfLeases(clients, leases)
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	// the watchers start from the first write
+	wctx, wcancel := context.WithCancel(context.Background())
+	defer wcancel()
+	wr := report.NewReport("%4.4f")
+	wsc := wr.Stats()
+	watchers := watchPerf(wctx, clients, cfg.watchers, resp.Header.Revision+1, wr)
+
 	ksize, vsize := 256, 1024
-	k, v := make([]byte, ksize), string(make([]byte, vsize))
+	k := make([]byte, ksize)
 
 	bar := pb.New(cfg.duration)
 	bar.Start()
 
 	r := report.NewReport("%4.4f")
+	lr := report.NewReport("%4.4f")
+	keepAlives := make(chan v3.LeaseID, cfg.clients)
 	var wg sync.WaitGroup
 
-	wg.Add(len(clients))
+	wg.Add(2 * len(clients))
 	for i := range clients {
 		go func(c *v3.Client) {
 			defer wg.Done()
@@ -195,6 +234,14 @@ func newCheckPerfCommand(cmd *cobra.Command, args []string) {
 				r.Results() <- report.Result{Err: derr, Start: st, End: time.Now()}
 			}
 		}(clients[i])
+		go func(c *v3.Client) {
+			defer wg.Done()
+			for id := range keepAlives {
+				st := time.Now()
+				_, kerr := c.KeepAliveOnce(context.Background(), id)
+				lr.Results() <- report.Result{Err: kerr, Start: st, End: time.Now()}
+			}
+		}(clients[i])
 	}
 
 	go func() {
@@ -202,11 +249,24 @@ func newCheckPerfCommand(cmd *cobra.Command, args []string) {
 		defer ccancel()
 		for limit.Wait(cctx) == nil {
 			binary.PutVarint(k, rand.Int63n(math.MaxInt64))
-			requests <- v3.OpPut(checkPerfPrefix+string(k), v)
+			// the watchers measure the delay of the events from the put time
+			v := make([]byte, vsize)
+			binary.PutVarint(v, time.Now().UnixNano())
+			requests <- v3.OpPut(checkPerfPrefix+string(k), string(v))
 		}
 		close(requests)
 	}()
 
+	go func() {
+		cctx, ccancel := context.WithCancel(ctx)
+		defer ccancel()
+		llimit := rate.NewLimiter(rate.Limit(cfg.keepAlives), 1)
+		for i := 0; llimit.Wait(cctx) == nil && len(leases) > 0; i++ {
+			keepAlives <- leases[i%len(leases)]
+		}
+		close(keepAlives)
+	}()
+
 	go func() {
 		for i := 0; i < cfg.duration; i++ {
 			time.Sleep(time.Second)
@@ -215,12 +275,21 @@ func newCheckPerfCommand(cmd *cobra.Command, args []string) {
 		bar.Finish()
 	}()
 
-	sc := r.Stats()
+	sc, lsc := r.Stats(), lr.Stats()
 	wg.Wait()
 	close(r.Results())
+	close(lr.Results())
 
-	s := <-sc
+	s, ls := <-sc, <-lsc
+
+	// the watchers receive the writes up to the revision of the last one
+	missed := watchers.wait(clients[0], 10*time.Second)
+	wcancel()
+	watchers.wg.Wait()
+	close(wr.Results())
+	ws := <-wsc
 
+	revokePerfLeases(clients, leases)
 	attemptCleanup(clients[0], autoCompact)
 
 	if autoDefrag {
@@ -229,7 +298,45 @@ func newCheckPerfCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	kvOK := checkPerfStats(s, "writes", "request", cfg.limit)
+
+	watchOK := true
+	if missed != 0 {
+		fmt.Printf("FAIL: %d of %d watchers missed events\n", missed, cfg.watchers)
+		watchOK = false
+	} else {
+		fmt.Printf("PASS: %d watchers received all the events\n", cfg.watchers)
+	}
+	watchOK = checkPerfStats(ws, "", "watch event", 0) && watchOK
+
+	leaseOK := checkPerfStats(ls, "keepalives", "keepalive", cfg.keepAlives)
+
 	ok = true
+	for _, sub := range []struct {
+		name string
+		ok   bool
+	}{{"kv", kvOK}, {"watch", watchOK}, {"lease", leaseOK}} {
+		if sub.ok {
+			fmt.Printf("PASS: %s\n", sub.name)
+		} else {
+			fmt.Printf("FAIL: %s\n", sub.name)
+			ok = false
+		}
+	}
+
+	if ok {
+		fmt.Println("PASS")
+	} else {
+		fmt.Println("FAIL")
+		os.Exit(cobrautl.ExitError)
+	}
+}
+
+// checkPerfStats prints whether the stats of the requests of a subsystem pass
+// the perf check, and returns false if they do not. The throughput of the
+// requests in unit per second is checked against limit if positive.
+func checkPerfStats(s report.Stats, unit, request string, limit int) bool {
+	ok := true
 	if len(s.ErrorDist) != 0 {
 		fmt.Println("FAIL: too many errors")
 		for k, v := range s.ErrorDist {
@@ -238,17 +345,19 @@ func newCheckPerfCommand(cmd *cobra.Command, args []string) {
 		ok = false
 	}
 
-	if s.RPS/float64(cfg.limit) <= 0.9 {
-		fmt.Printf("FAIL: Throughput too low: %d writes/s\n", int(s.RPS)+1)
-		ok = false
-	} else {
-		fmt.Printf("PASS: Throughput is %d writes/s\n", int(s.RPS)+1)
+	if limit > 0 {
+		if s.RPS/float64(limit) <= 0.9 {
+			fmt.Printf("FAIL: Throughput too low: %d %s/s\n", int(s.RPS)+1, unit)
+			ok = false
+		} else {
+			fmt.Printf("PASS: Throughput is %d %s/s\n", int(s.RPS)+1, unit)
+		}
 	}
 	if s.Slowest > 0.5 { // slowest request > 500ms
-		fmt.Printf("Slowest request took too long: %fs\n", s.Slowest)
+		fmt.Printf("Slowest %s took too long: %fs\n", request, s.Slowest)
 		ok = false
 	} else {
-		fmt.Printf("PASS: Slowest request took %fs\n", s.Slowest)
+		fmt.Printf("PASS: Slowest %s took %fs\n", request, s.Slowest)
 	}
 	if s.Stddev > 0.1 { // stddev > 100ms
 		fmt.Printf("Stddev too high: %fs\n", s.Stddev)
@@ -256,13 +365,130 @@ func newCheckPerfCommand(cmd *cobra.Command, args []string) {
 	} else {
 		fmt.Printf("PASS: Stddev is %fs\n", s.Stddev)
 	}
+	return ok
+}
 
-	if ok {
-		fmt.Println("PASS")
-	} else {
-		fmt.Println("FAIL")
-		os.Exit(cobrautl.ExitError)
+// perfWatchers are the watchers of the keys written by the perf check.
+type perfWatchers struct {
+	wg sync.WaitGroup
+	// revs holds the revision of the last event received by each watcher.
+	revs []int64
+}
+
+// watchPerf starts n watchers of the keys written by the perf check from
+// revision rev, spread over the clients. The delay of each event since the
+// time of its put is reported to r.
+func watchPerf(ctx context.Context, clients []*v3.Client, n int, rev int64, r report.Report) *perfWatchers {
+	w := &perfWatchers{revs: make([]int64, n)}
+	w.wg.Add(n)
+	for i := 0; i < n; i++ {
+		wch := clients[i%len(clients)].Watch(v3.WithRequireLeader(ctx), checkPerfPrefix, v3.WithPrefix(), v3.WithRev(rev))
+		go func(i int) {
+			defer w.wg.Done()
+			for wresp := range wch {
+				if err := wresp.Err(); err != nil {
+					if ctx.Err() == nil {
+						r.Results() <- report.Result{Err: err, Start: time.Now(), End: time.Now()}
+					}
+					continue
+				}
+				now := time.Now()
+				for _, ev := range wresp.Events {
+					if ts, n := binary.Varint(ev.Kv.Value); n > 0 {
+						r.Results() <- report.Result{Start: time.Unix(0, ts), End: now}
+					}
+					atomic.StoreInt64(&w.revs[i], ev.Kv.ModRevision)
+				}
+			}
+		}(i)
 	}
+	return w
+}
+
+// wait waits at most timeout for the watchers to receive the last write,
+// and returns the number of watchers which did not.
+func (w *perfWatchers) wait(c *v3.Client, timeout time.Duration) (missed int) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := c.Get(ctx, checkPerfPrefix, v3.WithPrefix(), v3.WithKeysOnly(), v3.WithLimit(1),
+		v3.WithSort(v3.SortByModRevision, v3.SortDescend))
+	if err != nil {
+		return len(w.revs)
+	}
+	if len(resp.Kvs) == 0 {
+		return 0
+	}
+	last := resp.Kvs[0].ModRevision
+	for {
+		missed = 0
+		for i := range w.revs {
+			if atomic.LoadInt64(&w.revs[i]) < last {
+				missed++
+			}
+		}
+		if missed == 0 {
+			return 0
+		}
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return missed
+		}
+	}
+}
+
+// grantPerfLeases grants the n leases kept alive by the perf check.
+func grantPerfLeases(ctx context.Context, clients []*v3.Client, n int) ([]v3.LeaseID, error) {
+	var (
+		mu     sync.Mutex
+		leases []v3.LeaseID
+		gerr   error
+		wg     sync.WaitGroup
+	)
+	wg.Add(len(clients))
+	for i := range clients {
+		go func(i int) {
+			defer wg.Done()
+			for j := i; j < n; j += len(clients) {
+				resp, err := clients[i].Grant(ctx, checkPerfLeaseTTL)
+				mu.Lock()
+				if err != nil {
+					gerr = err
+				} else {
+					leases = append(leases, resp.ID)
+				}
+				mu.Unlock()
+				if err != nil {
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	return leases, gerr
+}
+
+// revokePerfLeases revokes the leases kept alive by the perf check.
+func revokePerfLeases(clients []*v3.Client, leases []v3.LeaseID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+	)
+	wg.Add(len(clients))
+	for i := range clients {
+		go func(i int) {
+			defer wg.Done()
+			for j := i; j < len(leases); j += len(clients) {
+				if _, err := clients[i].Revoke(ctx, leases[j]); err != nil {
+					once.Do(func() { fmt.Printf("FAIL: Cleanup failed during lease revocation: ERROR(%v)\n", err) })
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
 }
 
 func attemptCleanup(client *v3.Client, autoCompact bool) {
@@ -438,3 +664,61 @@ func newCheckDatascaleCommand(cmd *cobra.Command, args []string) {
 		fmt.Println(fmt.Sprintf("PASS: Approximate system memory used : %v MB.", strconv.FormatFloat(mbUsed, 'f', 2, 64)))
 	}
 }
+
+// NewCheckHashKVCommand returns the cobra command for "check hashkv".
+func NewCheckHashKVCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hashkv [options]",
+		Short: "Check the consistency of a range of keys across the endpoints",
+		Long:  "Compares the hashes of the MVCC revisions of the keys with the given prefix, of the whole keyspace if none is given, computed by every endpoint at the same revision.",
+		Run:   newCheckHashKVCommand,
+	}
+
+	cmd.Flags().StringVar(&checkHashKVPrefix, "prefix", "", "The prefix of the keys to hash (default: all keys).")
+	cmd.Flags().Int64Var(&checkHashKVRev, "rev", 0, "The revision to hash the keys at (default: the current revision).")
+	cmd.Flags().BoolVar(&epClusterEndpoints, "cluster", false, "use all endpoints from the cluster member list")
+
+	return cmd
+}
+
+// newCheckHashKVCommand executes the "check hashkv" command.
+func newCheckHashKVCommand(cmd *cobra.Command, args []string) {
+	c := mustClientFromCmd(cmd)
+
+	rev := checkHashKVRev
+	if rev == 0 {
+		// hash every endpoint at the same revision for their hashes to match
+		ctx, cancel := commandCtx(cmd)
+		resp, err := c.Get(ctx, checkHashKVPrefix, v3.WithPrefix(), v3.WithCountOnly())
+		cancel()
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+		rev = resp.Header.Revision
+	}
+
+	var hashList []epHashKV
+	var err error
+	for _, ep := range endpointsFromCluster(cmd) {
+		ctx, cancel := commandCtx(cmd)
+		resp, serr := c.HashKVRange(ctx, ep, checkHashKVPrefix, v3.WithPrefix(), v3.WithRev(rev))
+		cancel()
+		if serr != nil {
+			err = serr
+			fmt.Fprintf(os.Stderr, "Failed to get the hash of endpoint %s (%v)\n", ep, serr)
+			continue
+		}
+		hashList = append(hashList, epHashKV{Ep: ep, Resp: resp})
+	}
+
+	display.EndpointHashKV(hashList)
+
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	for _, h := range hashList[1:] {
+		if h.Resp.Hash != hashList[0].Resp.Hash {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("hash of endpoint %s at revision %d differs from the hash of endpoint %s", h.Ep, rev, hashList[0].Ep))
+		}
+	}
+}
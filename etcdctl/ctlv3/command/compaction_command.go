@@ -23,7 +23,10 @@ import (
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
 )
 
-var compactPhysical bool
+var (
+	compactPhysical bool
+	compactDryRun   bool
+)
 
 // NewCompactionCommand returns the cobra command for "compaction".
 func NewCompactionCommand() *cobra.Command {
@@ -33,6 +36,7 @@ func NewCompactionCommand() *cobra.Command {
 		Run:   compactionCommandFunc,
 	}
 	cmd.Flags().BoolVar(&compactPhysical, "physical", false, "'true' to wait for compaction to physically remove all old revisions")
+	cmd.Flags().BoolVar(&compactDryRun, "dry-run", false, "report the history that would be compacted without compacting it")
 	return cmd
 }
 
@@ -53,6 +57,10 @@ func compactionCommandFunc(cmd *cobra.Command, args []string) {
 	}
 
 	c := mustClientFromCmd(cmd)
+	if compactDryRun {
+		compactionDryRunReport(cmd, c, rev)
+		return
+	}
 	ctx, cancel := commandCtx(cmd)
 	_, cerr := c.Compact(ctx, rev, opts...)
 	cancel()
@@ -61,3 +69,28 @@ func compactionCommandFunc(cmd *cobra.Command, args []string) {
 	}
 	fmt.Println("compacted revision", rev)
 }
+
+// compactionDryRunReport prints the history compacting the revision rev
+// would discard and the keys it would keep, or why it would fail.
+func compactionDryRunReport(cmd *cobra.Command, c *clientv3.Client, rev int64) {
+	ctx, cancel := commandCtx(cmd)
+	defer cancel()
+	// hashing the empty key alone cheaply returns the compacted revision
+	var compactRev int64
+	err := c.HashKVStream(ctx, c.Endpoints()[0], "", 1, func(resp *clientv3.HashKVStreamResponse) error {
+		compactRev = resp.CompactRevision
+		return nil
+	})
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	if rev <= compactRev {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("compaction would fail: revision %d is compacted (compacted revision %d)", rev, compactRev))
+	}
+	kept, err := c.Get(ctx, "\x00", clientv3.WithFromKey(), clientv3.WithCountOnly(), clientv3.WithRev(rev))
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("compaction would fail: %v", err))
+	}
+	fmt.Printf("would compact the history before revision %d, keeping the %d keys at revision %d (current revision %d); reads and watches of earlier revisions would fail\n",
+		rev, kept.Count, rev, kept.Header.Revision)
+}
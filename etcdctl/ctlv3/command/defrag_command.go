@@ -23,6 +23,8 @@ import (
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
 )
 
+var defragOnline bool
+
 // NewDefragCommand returns the cobra command for "Defrag".
 func NewDefragCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -31,6 +33,7 @@ func NewDefragCommand() *cobra.Command {
 		Run:   defragCommandFunc,
 	}
 	cmd.PersistentFlags().BoolVar(&epClusterEndpoints, "cluster", false, "use all endpoints from the cluster member list")
+	cmd.Flags().BoolVar(&defragOnline, "defrag-online", false, "defragment incrementally while the members keep serving requests")
 	return cmd
 }
 
@@ -41,7 +44,12 @@ func defragCommandFunc(cmd *cobra.Command, args []string) {
 	for _, ep := range endpointsFromCluster(cmd) {
 		ctx, cancel := commandCtx(cmd)
 		start := time.Now()
-		_, err := c.Defragment(ctx, ep)
+		var err error
+		if defragOnline {
+			_, err = c.DefragmentOnline(ctx, ep)
+		} else {
+			_, err = c.Defragment(ctx, ep)
+		}
 		d := time.Now().Sub(start)
 		cancel()
 		if err != nil {
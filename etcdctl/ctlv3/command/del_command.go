@@ -29,6 +29,7 @@ var (
 	delPrevKV  bool
 	delFromKey bool
 	delRange   bool
+	delDryRun  bool
 )
 
 // NewDelCommand returns the cobra command for "del".
@@ -43,12 +44,17 @@ func NewDelCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&delPrevKV, "prev-kv", false, "return deleted key-value pairs")
 	cmd.Flags().BoolVar(&delFromKey, "from-key", false, "delete keys that are greater than or equal to the given key using byte compare")
 	cmd.Flags().BoolVar(&delRange, "range", false, "delete range of keys")
+	cmd.Flags().BoolVar(&delDryRun, "dry-run", false, "report the number of keys that would be deleted without deleting them")
 	return cmd
 }
 
 // delCommandFunc executes the "del" command.
 func delCommandFunc(cmd *cobra.Command, args []string) {
 	key, opts := getDelOp(args)
+	if delDryRun {
+		delDryRunReport(cmd, key, opts)
+		return
+	}
 	ctx, cancel := commandCtx(cmd)
 	resp, err := mustClientFromCmd(cmd).Delete(ctx, key, opts...)
 	cancel()
@@ -74,7 +80,7 @@ func getDelOp(args []string) (string, []clientv3.OpOption) {
 			cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("too many arguments, only accept one argument when `--prefix` or `--from-key` is set"))
 		}
 		opts = append(opts, clientv3.WithRange(args[1]))
-		if !delRange {
+		if !delRange && !delDryRun {
 			fmt.Fprintf(os.Stderr, "Warning: Keys between %q and %q will be deleted. Please interrupt the command within next 2 seconds to cancel. "+
 				"You can provide `--range` flag to avoid the delay.\n", args[0], args[1])
 			time.Sleep(2 * time.Second)
@@ -102,3 +108,27 @@ func getDelOp(args []string) (string, []clientv3.OpOption) {
 
 	return key, opts
 }
+
+// delDryRunReport prints the number of keys the deletion of key with opts
+// would delete.
+func delDryRunReport(cmd *cobra.Command, key string, opts []clientv3.OpOption) {
+	op := clientv3.OpDelete(key, opts...)
+	ctx, cancel := commandCtx(cmd)
+	resp, err := mustClientFromCmd(cmd).Get(ctx, key, append(opts, clientv3.WithCountOnly())...)
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	fmt.Printf("would delete %d keys %s at revision %d\n", resp.Count, describeRange(op.KeyBytes(), op.RangeBytes()), resp.Header.Revision)
+}
+
+// describeRange describes the keys of the range [key, end).
+func describeRange(key, end []byte) string {
+	switch {
+	case len(end) == 0:
+		return fmt.Sprintf("matching %q", key)
+	case len(end) == 1 && end[0] == 0:
+		return fmt.Sprintf("from %q", key)
+	}
+	return fmt.Sprintf("in [%q, %q)", key, end)
+}
@@ -0,0 +1,152 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+
+	"github.com/spf13/cobra"
+)
+
+// diffPageSize is the number of keys fetched per range request of each endpoint.
+const diffPageSize = 1000
+
+var (
+	diffEndpointA string
+	diffEndpointB string
+	diffPrefix    string
+	diffRev       int64
+)
+
+// NewDiffCommand returns the cobra command for "diff".
+func NewDiffCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "diff --endpoint-a=<endpoint> --endpoint-b=<endpoint> [options]",
+		Short: "Reports the keys whose values differ between two endpoints",
+		Run:   diffCommandFunc,
+	}
+
+	c.Flags().StringVar(&diffEndpointA, "endpoint-a", "", "First endpoint to compare")
+	c.Flags().StringVar(&diffEndpointB, "endpoint-b", "", "Second endpoint to compare")
+	c.Flags().StringVar(&diffPrefix, "prefix", "", "Key prefix to compare, all keys if empty")
+	c.Flags().Int64Var(&diffRev, "rev", 0, "Revision at which both endpoints are read, the latest revision of each if 0")
+
+	return c
+}
+
+// keyDiff is a key whose value differs between two endpoints.
+type keyDiff struct {
+	key []byte
+	// onlyIn is "a" or "b" if the key exists at one endpoint only, and
+	// empty if both endpoints hold different values.
+	onlyIn string
+}
+
+func (d keyDiff) String() string {
+	if d.onlyIn != "" {
+		return fmt.Sprintf("only in %s: %s", d.onlyIn, d.key)
+	}
+	return fmt.Sprintf("differs: %s", d.key)
+}
+
+// diffCommandFunc executes the "diff" command.
+func diffCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("diff takes no arguments"))
+	}
+	if diffEndpointA == "" || diffEndpointB == "" {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("both --endpoint-a and --endpoint-b must be given"))
+	}
+
+	cc := clientConfigFromCmd(cmd)
+	ca, cb := *cc, *cc
+	ca.Endpoints, cb.Endpoints = []string{diffEndpointA}, []string{diffEndpointB}
+
+	ctx, cancel := commandCtx(cmd)
+	defer cancel()
+	ra, err := diffRange(ctx, mustClient(&ca))
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("cannot read %s: %w", diffEndpointA, err))
+	}
+	rb, err := diffRange(ctx, mustClient(&cb))
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("cannot read %s: %w", diffEndpointB, err))
+	}
+
+	diffs := diffKVs(ra.Kvs, rb.Kvs)
+	for _, d := range diffs {
+		fmt.Println(d)
+	}
+	reva, revb := diffRev, diffRev
+	if diffRev == 0 {
+		reva, revb = ra.Header.Revision, rb.Header.Revision
+	}
+	fmt.Printf("%d divergent keys (a: %d keys at revision %d, b: %d keys at revision %d)\n",
+		len(diffs), len(ra.Kvs), reva, len(rb.Kvs), revb)
+	if len(diffs) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("%s and %s diverge", diffEndpointA, diffEndpointB))
+	}
+}
+
+func diffRange(ctx context.Context, c *clientv3.Client) (*clientv3.GetResponse, error) {
+	defer c.Close()
+	opts := []clientv3.OpOption{clientv3.WithAutoPaging(diffPageSize), clientv3.WithRev(diffRev)}
+	key := diffPrefix
+	if key == "" {
+		key = "\x00"
+		opts = append(opts, clientv3.WithFromKey())
+	} else {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	return c.Get(ctx, key, opts...)
+}
+
+// diffKVs returns the keys whose values differ between the key-value pairs
+// a and b, both sorted by key.
+func diffKVs(a, b []*mvccpb.KeyValue) []keyDiff {
+	var diffs []keyDiff
+	for len(a) > 0 || len(b) > 0 {
+		var c int
+		switch {
+		case len(a) == 0:
+			c = 1
+		case len(b) == 0:
+			c = -1
+		default:
+			c = bytes.Compare(a[0].Key, b[0].Key)
+		}
+		switch {
+		case c < 0:
+			diffs = append(diffs, keyDiff{key: a[0].Key, onlyIn: "a"})
+			a = a[1:]
+		case c > 0:
+			diffs = append(diffs, keyDiff{key: b[0].Key, onlyIn: "b"})
+			b = b[1:]
+		default:
+			if !bytes.Equal(a[0].Value, b[0].Value) {
+				diffs = append(diffs, keyDiff{key: a[0].Key})
+			}
+			a, b = a[1:], b[1:]
+		}
+	}
+	return diffs
+}
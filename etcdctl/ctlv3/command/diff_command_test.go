@@ -0,0 +1,55 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func TestDiffKVs(t *testing.T) {
+	kvs := func(pairs ...string) []*mvccpb.KeyValue {
+		var kvs []*mvccpb.KeyValue
+		for i := 0; i < len(pairs); i += 2 {
+			kvs = append(kvs, &mvccpb.KeyValue{Key: []byte(pairs[i]), Value: []byte(pairs[i+1])})
+		}
+		return kvs
+	}
+	tests := []struct {
+		a, b  []*mvccpb.KeyValue
+		wdiff []string
+	}{
+		{nil, nil, nil},
+		{kvs("a", "1", "b", "2"), kvs("a", "1", "b", "2"), nil},
+		{kvs("a", "1"), nil, []string{"only in a: a"}},
+		{nil, kvs("a", "1"), []string{"only in b: a"}},
+		{
+			kvs("a", "1", "c", "3", "d", "4"),
+			kvs("b", "2", "c", "x", "d", "4", "e", "5"),
+			[]string{"only in a: a", "only in b: b", "differs: c", "only in b: e"},
+		},
+	}
+	for i, tt := range tests {
+		var diffs []string
+		for _, d := range diffKVs(tt.a, tt.b) {
+			diffs = append(diffs, d.String())
+		}
+		if !reflect.DeepEqual(diffs, tt.wdiff) {
+			t.Errorf("#%d: diff = %v, want %v", i, diffs, tt.wdiff)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	drPrimaryEndpoints []string
+	drForce            bool
+	drWaitTimeout      time.Duration
+)
+
+// NewDRCommand returns the cobra command for "dr".
+func NewDRCommand() *cobra.Command {
+	dc := &cobra.Command{
+		Use:   "dr <subcommand>",
+		Short: "Disaster recovery commands of warm standby clusters",
+	}
+
+	dc.AddCommand(newDRStatusCommand())
+	dc.AddCommand(newDRDemoteCommand())
+	dc.AddCommand(newDRPromoteCommand())
+
+	return dc
+}
+
+func newDRStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Prints whether the cluster is a standby and the revision of its primary cluster it mirrored",
+		Run:   drStatusCommandFunc,
+	}
+}
+
+func newDRDemoteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "demote",
+		Short: "Turns the cluster into a standby, rejecting client writes",
+		Run:   drDemoteCommandFunc,
+	}
+}
+
+func newDRPromoteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "promote --primary-endpoints=<endpoints>",
+		Short: "Fences the primary cluster off and promotes the standby cluster once it caught up",
+		Run:   drPromoteCommandFunc,
+	}
+	cmd.Flags().StringSliceVar(&drPrimaryEndpoints, "primary-endpoints", nil, "Client endpoints of the primary cluster")
+	cmd.Flags().BoolVar(&drForce, "force", false, "Promote the standby cluster without fencing the primary cluster off nor waiting for the standby cluster to catch up, when the primary cluster is lost")
+	cmd.Flags().DurationVar(&drWaitTimeout, "wait-timeout", time.Minute, "Maximum duration to wait for the standby cluster to catch up with the fenced primary cluster")
+	return cmd
+}
+
+// drStatusCommandFunc executes the "dr status" command.
+func drStatusCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("dr status command accepts no arguments"))
+	}
+	c := mustClientFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	defer cancel()
+
+	standby, err := isStandby(ctx, c)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	rev, err := standbyRevision(ctx, c)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	fmt.Printf("standby: %v, mirrored primary revision: %d\n", standby, rev)
+}
+
+// drDemoteCommandFunc executes the "dr demote" command.
+func drDemoteCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("dr demote command accepts no arguments"))
+	}
+	c := mustClientFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	defer cancel()
+
+	if err := demote(ctx, c); err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	fmt.Println("cluster is a standby")
+}
+
+// drPromoteCommandFunc executes the "dr promote" command. Each step is
+// verified before the next one is taken:
+//  1. the cluster is a standby;
+//  2. the primary cluster is fenced off by a STANDBY alarm, which rejects
+//     client writes and bumps its cluster epoch;
+//  3. the standby cluster mirrored the last revision of the fenced primary;
+//  4. the STANDBY alarm of the standby cluster is disarmed.
+func drPromoteCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("dr promote command accepts no arguments"))
+	}
+	if len(drPrimaryEndpoints) == 0 && !drForce {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("--primary-endpoints must be given unless --force is set"))
+	}
+
+	cc := clientConfigFromCmd(cmd)
+	c := mustClient(cc)
+	ctx, cancel := commandCtx(cmd)
+	standby, err := isStandby(ctx, c)
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	if !standby {
+		cobrautl.ExitWithError(cobrautl.ExitError, errors.New("cluster is not a standby"))
+	}
+	fmt.Println("[1/4] cluster is a standby")
+
+	if drForce {
+		fmt.Println("[2/4] skipped fencing the primary cluster off (--force)")
+		fmt.Println("[3/4] skipped waiting for the standby cluster to catch up (--force)")
+	} else {
+		pcc := *cc
+		pcc.Endpoints = drPrimaryEndpoints
+		pc := mustClient(&pcc)
+		ctx, cancel = commandCtx(cmd)
+		err = demote(ctx, pc)
+		cancel()
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("cannot fence primary cluster off: %w", err))
+		}
+		ctx, cancel = commandCtx(cmd)
+		resp, err := pc.Status(ctx, pc.Endpoints()[0])
+		cancel()
+		pc.Close()
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+		prev := resp.Header.Revision
+		fmt.Printf("[2/4] fenced primary cluster off at revision %d\n", prev)
+
+		ctx, cancel = context.WithTimeout(context.Background(), drWaitTimeout)
+		rev, err := waitStandbyRevision(ctx, c, prev)
+		cancel()
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("standby cluster did not catch up with primary revision %d (mirrored %d): %w", prev, rev, err))
+		}
+		fmt.Printf("[3/4] standby cluster mirrored primary revision %d\n", rev)
+	}
+
+	ctx, cancel = commandCtx(cmd)
+	err = promote(ctx, c)
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	fmt.Println("[4/4] promoted standby cluster")
+}
+
+func isStandby(ctx context.Context, c *clientv3.Client) (bool, error) {
+	resp, err := c.AlarmList(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, a := range resp.Alarms {
+		if a.Alarm == pb.AlarmType_STANDBY {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// standbyRevision returns the revision of the primary cluster mirrored by
+// the leader of the standby cluster, as reported by the greatest of its
+// endpoints.
+func standbyRevision(ctx context.Context, c *clientv3.Client) (int64, error) {
+	var rev int64
+	var errs []string
+	for _, ep := range c.Endpoints() {
+		resp, err := c.Status(ctx, ep)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", ep, err))
+			continue
+		}
+		if resp.StandbyRevision > rev {
+			rev = resp.StandbyRevision
+		}
+	}
+	if len(errs) == len(c.Endpoints()) {
+		return 0, errors.New(strings.Join(errs, "; "))
+	}
+	return rev, nil
+}
+
+// waitStandbyRevision waits for the standby cluster to mirror the given
+// revision of its primary cluster.
+func waitStandbyRevision(ctx context.Context, c *clientv3.Client, primaryRev int64) (int64, error) {
+	var rev int64
+	for {
+		if r, err := standbyRevision(ctx, c); err == nil {
+			rev = r
+		}
+		if rev >= primaryRev {
+			return rev, nil
+		}
+		select {
+		case <-ctx.Done():
+			return rev, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// demote raises a STANDBY alarm on the cluster and verifies it is active.
+func demote(ctx context.Context, c *clientv3.Client) error {
+	resp, err := c.Status(ctx, c.Endpoints()[0])
+	if err != nil {
+		return err
+	}
+	req := &pb.AlarmRequest{Action: pb.AlarmRequest_ACTIVATE, MemberID: resp.Header.MemberId, Alarm: pb.AlarmType_STANDBY}
+	if _, err = clientv3.RetryMaintenanceClient(c, c.ActiveConnection()).Alarm(ctx, req); err != nil {
+		return err
+	}
+	standby, err := isStandby(ctx, c)
+	if err != nil {
+		return err
+	}
+	if !standby {
+		return errors.New("STANDBY alarm is not active")
+	}
+	return nil
+}
+
+// promote disarms the STANDBY alarms of the cluster and verifies none is left.
+func promote(ctx context.Context, c *clientv3.Client) error {
+	resp, err := c.AlarmList(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range resp.Alarms {
+		if a.Alarm != pb.AlarmType_STANDBY {
+			continue
+		}
+		if _, err = c.AlarmDisarm(ctx, (*clientv3.AlarmMember)(a)); err != nil {
+			return err
+		}
+	}
+	standby, err := isStandby(ctx, c)
+	if err != nil {
+		return err
+	}
+	if standby {
+		return errors.New("STANDBY alarm is still active")
+	}
+	return nil
+}
@@ -45,6 +45,7 @@ func NewEndpointCommand() *cobra.Command {
 	ec.AddCommand(newEpHealthCommand())
 	ec.AddCommand(newEpStatusCommand())
 	ec.AddCommand(newEpHashKVCommand())
+	ec.AddCommand(newEpConfigCommand())
 
 	return ec
 }
@@ -64,7 +65,7 @@ func newEpStatusCommand() *cobra.Command {
 		Use:   "status",
 		Short: "Prints out the status of endpoints specified in `--endpoints` flag",
 		Long: `When --write-out is set to simple, this command prints out comma-separated status lists for each endpoint.
-The items in the lists are endpoint, ID, version, db size, is leader, is learner, raft term, raft index, raft applied index, errors.
+The items in the lists are endpoint, ID, version, db size, is leader, is learner, raft term, raft index, raft applied index, watch streams, watchers, slow watchers, pending events, errors.
 `,
 		Run: epStatusCommandFunc,
 	}
@@ -80,6 +81,17 @@ func newEpHashKVCommand() *cobra.Command {
 	return hc
 }
 
+func newEpConfigCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "config",
+		Short: "Prints the effective configuration of each endpoint in --endpoints",
+		Long: `Prints the configuration of each endpoint as resolved from its flags, environment variables, configuration file and defaults, with the secrets redacted.
+The options are named as in the configuration file. When --write-out is set to simple, this command prints out a comma-separated endpoint, option and value per line.
+`,
+		Run: epConfigCommandFunc,
+	}
+}
+
 type epHealth struct {
 	Ep     string `json:"endpoint"`
 	Health bool   `json:"health"`
@@ -154,6 +166,14 @@ func epHealthCommandFunc(cmd *cobra.Command, args []string) {
 							eh.Error = eh.Error + "NOSPACE "
 						case etcdserverpb.AlarmType_CORRUPT:
 							eh.Error = eh.Error + "CORRUPT "
+						case etcdserverpb.AlarmType_SLOWFOLLOWER:
+							eh.Error = eh.Error + "SLOWFOLLOWER "
+						case etcdserverpb.AlarmType_CERTEXPIRY:
+							eh.Error = eh.Error + "CERTEXPIRY "
+						case etcdserverpb.AlarmType_TOOMANYKEYS:
+							eh.Error = eh.Error + "TOOMANYKEYS "
+						case etcdserverpb.AlarmType_STANDBY:
+							eh.Error = eh.Error + "STANDBY "
 						default:
 							eh.Error = eh.Error + "UNKNOWN "
 						}
@@ -243,6 +263,35 @@ func epHashKVCommandFunc(cmd *cobra.Command, args []string) {
 	}
 }
 
+type epConfig struct {
+	Ep   string                            `json:"Endpoint"`
+	Resp *clientv3.EffectiveConfigResponse `json:"Config"`
+}
+
+func epConfigCommandFunc(cmd *cobra.Command, args []string) {
+	c := mustClientFromCmd(cmd)
+
+	var configList []epConfig
+	var err error
+	for _, ep := range endpointsFromCluster(cmd) {
+		ctx, cancel := commandCtx(cmd)
+		resp, serr := c.EffectiveConfig(ctx, ep)
+		cancel()
+		if serr != nil {
+			err = serr
+			fmt.Fprintf(os.Stderr, "Failed to get the configuration of endpoint %s (%v)\n", ep, serr)
+			continue
+		}
+		configList = append(configList, epConfig{Ep: ep, Resp: resp})
+	}
+
+	display.EndpointConfig(configList)
+
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+}
+
 func endpointsFromCluster(cmd *cobra.Command) []string {
 	if !epClusterEndpoints {
 		endpoints, err := cmd.Flags().GetStringSlice("endpoints")
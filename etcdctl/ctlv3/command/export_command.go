@@ -0,0 +1,257 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// exportFormatJSONL is the JSON lines export format, a JSON object per
+	// key with its base64 encoded key, value and metadata.
+	exportFormatJSONL = "jsonl"
+	// exportFormatProtobuf is the protobuf export format, an mvccpb.KeyValue
+	// message per key, each prefixed by its size as a uvarint.
+	exportFormatProtobuf = "protobuf"
+)
+
+var (
+	exportFormat    string
+	exportPrefix    bool
+	exportFromKey   bool
+	exportRev       int64
+	exportBatchSize int64
+	exportRate      float64
+)
+
+// NewExportCommand returns the cobra command for "export".
+func NewExportCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "export [options] <key> [range_end]",
+		Short: "Exports a key range to the standard output",
+		Long: `Exports the keys of a key range, read at a single revision, to the standard
+output in the JSON lines or protobuf format, which 'etcdctl import' loads.
+
+The keys attached to a lease are exported with the remaining time to live of
+their lease, and imported attached to a new lease of that time to live.`,
+		Run: exportCommandFunc,
+	}
+
+	c.Flags().StringVar(&exportFormat, "format", exportFormatJSONL, "Format of the export, 'jsonl' or 'protobuf'")
+	c.Flags().BoolVar(&exportPrefix, "prefix", false, "Export the keys with matching prefix")
+	c.Flags().BoolVar(&exportFromKey, "from-key", false, "Export the keys that are greater than or equal to the given key using byte compare")
+	c.Flags().Int64Var(&exportRev, "rev", 0, "Revision to export the keys at, the current revision by default")
+	c.Flags().Int64Var(&exportBatchSize, "batch-size", 1000, "Maximum number of keys read per request")
+	c.Flags().Float64Var(&exportRate, "rate", 0, "Maximum number of keys read per second, 0 for no limit")
+
+	return c
+}
+
+// exportCommandFunc executes the "export" command.
+func exportCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("export command needs one argument as key and an optional argument as range_end"))
+	}
+	if exportPrefix && exportFromKey {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("`--prefix` and `--from-key` cannot be set at the same time, choose one"))
+	}
+	if exportFormat != exportFormatJSONL && exportFormat != exportFormatProtobuf {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("unknown format %q, expected 'jsonl' or 'protobuf'", exportFormat))
+	}
+	if exportBatchSize <= 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("--batch-size must be positive"))
+	}
+
+	key := args[0]
+	opts := []clientv3.OpOption{clientv3.WithRev(exportRev)}
+	switch {
+	case len(args) > 1 && (exportPrefix || exportFromKey):
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("too many arguments, only accept one argument when `--prefix` or `--from-key` is set"))
+	case len(args) > 1:
+		opts = append(opts, clientv3.WithRange(args[1]))
+	case exportPrefix:
+		opts = append(opts, clientv3.WithPrefix())
+	case exportFromKey:
+		opts = append(opts, clientv3.WithFromKey())
+	}
+
+	c := mustClientFromCmd(cmd)
+	defer c.Close()
+
+	w := bufio.NewWriter(os.Stdout)
+	limiter := newKeyLimiter(exportRate, int(exportBatchSize))
+	// ttls caches the remaining time to live of the leases of the keys.
+	ttls := make(map[int64]int64)
+	exported := 0
+	ctx := context.Background()
+	opts = append(opts,
+		clientv3.WithAutoPaging(exportBatchSize),
+		clientv3.WithPageHandler(func(page *clientv3.GetResponse) error {
+			if err := limiter.WaitN(ctx, len(page.Kvs)); err != nil {
+				return err
+			}
+			for _, kv := range page.Kvs {
+				ttl, err := exportLeaseTTL(cmd, c, ttls, kv.Lease)
+				if err != nil {
+					return err
+				}
+				if err = writeExportKV(w, exportFormat, kv, ttl); err != nil {
+					return err
+				}
+				exported++
+			}
+			return nil
+		}),
+	)
+	resp, err := c.Get(ctx, key, opts...)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d keys at revision %d\n", exported, resp.Header.Revision)
+}
+
+// newKeyLimiter returns a limiter of the keys read or written per second,
+// allowing batches of up to batchSize keys, or an unlimited one if
+// keysPerSec is not positive.
+func newKeyLimiter(keysPerSec float64, batchSize int) *rate.Limiter {
+	if keysPerSec <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(keysPerSec), batchSize)
+}
+
+// exportLeaseTTL returns the remaining time to live in seconds of the lease,
+// 0 for no lease. A lease expiring while exported is given a time to live of
+// one second, so its keys are not imported without a lease.
+func exportLeaseTTL(cmd *cobra.Command, c *clientv3.Client, ttls map[int64]int64, lease int64) (int64, error) {
+	if lease == 0 {
+		return 0, nil
+	}
+	if ttl, ok := ttls[lease]; ok {
+		return ttl, nil
+	}
+	ctx, cancel := commandCtx(cmd)
+	resp, err := c.TimeToLive(ctx, clientv3.LeaseID(lease))
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("cannot get the time to live of lease %016x: %w", lease, err)
+	}
+	ttl := resp.TTL
+	if ttl < 1 {
+		ttl = 1
+	}
+	ttls[lease] = ttl
+	return ttl, nil
+}
+
+// exportKV is a key-value of a JSON lines export.
+type exportKV struct {
+	Key      []byte `json:"key"`
+	Value    []byte `json:"value"`
+	Metadata []byte `json:"metadata,omitempty"`
+	// TTL is the remaining time to live in seconds of the lease of the key,
+	// 0 if it has none.
+	TTL int64 `json:"ttl,omitempty"`
+}
+
+// writeExportKV writes the key-value with the remaining time to live of its
+// lease in the given format. In the protobuf format, the lease field holds
+// the time to live, as the ID of the lease is of no use to another cluster.
+func writeExportKV(w io.Writer, format string, kv *mvccpb.KeyValue, ttl int64) error {
+	switch format {
+	case exportFormatJSONL:
+		b, err := json.Marshal(exportKV{Key: kv.Key, Value: kv.Value, Metadata: kv.Metadata, TTL: ttl})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(b, '\n'))
+		return err
+	case exportFormatProtobuf:
+		rec := *kv
+		rec.Lease = ttl
+		b, err := rec.Marshal()
+		if err != nil {
+			return err
+		}
+		var size [binary.MaxVarintLen64]byte
+		if _, err = w.Write(size[:binary.PutUvarint(size[:], uint64(len(b)))]); err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// parseJSONLExport parses a JSON lines export.
+func parseJSONLExport(r io.Reader) ([]importEntry, error) {
+	var ents []importEntry
+	dec := json.NewDecoder(r)
+	for {
+		var kv exportKV
+		if err := dec.Decode(&kv); err == io.EOF {
+			return ents, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if kv.TTL < 0 {
+			return nil, fmt.Errorf("%s has a negative ttl", kv.Key)
+		}
+		ents = append(ents, importEntry{source: string(kv.Key), value: kv.Value, metadata: kv.Metadata, ttl: kv.TTL})
+	}
+}
+
+// parseProtobufExport parses a protobuf export.
+func parseProtobufExport(r io.Reader) ([]importEntry, error) {
+	var ents []importEntry
+	br := bufio.NewReader(r)
+	for {
+		size, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return ents, nil
+		} else if err != nil {
+			return nil, err
+		}
+		b := make([]byte, size)
+		if _, err = io.ReadFull(br, b); err != nil {
+			return nil, err
+		}
+		var kv mvccpb.KeyValue
+		if err = kv.Unmarshal(b); err != nil {
+			return nil, err
+		}
+		if kv.Lease < 0 {
+			return nil, fmt.Errorf("%s has a negative ttl", kv.Key)
+		}
+		ents = append(ents, importEntry{source: string(kv.Key), value: kv.Value, metadata: kv.Metadata, ttl: kv.Lease})
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func TestExportRoundTrip(t *testing.T) {
+	kvs := []*mvccpb.KeyValue{
+		{Key: []byte("app/db"), Value: []byte("host=db"), ModRevision: 3, Version: 1},
+		{Key: []byte("app/\x00bin"), Value: []byte{0xff, 0x00}, Metadata: []byte("owner=ops")},
+		{Key: []byte("app/session"), Value: []byte{}, Lease: 0x1234},
+	}
+	ttls := []int64{0, 0, 30}
+	wents := []importEntry{
+		{source: "app/db", value: []byte("host=db")},
+		{source: "app/\x00bin", value: []byte{0xff, 0x00}, metadata: []byte("owner=ops")},
+		{source: "app/session", value: []byte{}, ttl: 30},
+	}
+
+	for _, tt := range []struct {
+		format string
+		parse  func(io.Reader) ([]importEntry, error)
+	}{
+		{exportFormatJSONL, parseJSONLExport},
+		{exportFormatProtobuf, parseProtobufExport},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			for i, kv := range kvs {
+				if err := writeExportKV(&buf, tt.format, kv, ttls[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ents, err := tt.parse(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range ents {
+				// protobuf decodes empty values as nil
+				if ents[i].value == nil {
+					ents[i].value = []byte{}
+				}
+			}
+			if !reflect.DeepEqual(ents, wents) {
+				t.Errorf("entries = %+v, want %+v", ents, wents)
+			}
+		})
+	}
+}
+
+func TestParseJSONLExport(t *testing.T) {
+	export := `{"key": "YXBwL2Ri", "value": "aG9zdD1kYg=="}
+{"key": "YXBwL3Nlc3Npb24=", "value": "MQ==", "ttl": 10}
+`
+	ents, err := parseJSONLExport(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wents := []importEntry{
+		{source: "app/db", value: []byte("host=db")},
+		{source: "app/session", value: []byte("1"), ttl: 10},
+	}
+	if !reflect.DeepEqual(ents, wents) {
+		t.Errorf("entries = %+v, want %+v", ents, wents)
+	}
+	if _, err = parseJSONLExport(strings.NewReader(`{"key": "YQ==", "ttl": -1}`)); err == nil {
+		t.Error("parsed a key with a negative ttl")
+	}
+}
+
+func TestParseProtobufExportTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeExportKV(&buf, exportFormatProtobuf, &mvccpb.KeyValue{Key: []byte("a"), Value: []byte("b")}, 0); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if _, err := parseProtobufExport(bytes.NewReader(b[:len(b)-1])); err == nil {
+		t.Error("parsed a truncated protobuf export")
+	}
+}
@@ -15,6 +15,7 @@
 package command
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -34,6 +35,7 @@ var (
 	getKeysOnly    bool
 	getCountOnly   bool
 	printValueOnly bool
+	getWatch       bool
 )
 
 // NewGetCommand returns the cobra command for "get".
@@ -41,7 +43,11 @@ func NewGetCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get [options] <key> [range_end]",
 		Short: "Gets the key or a range of keys",
-		Run:   getCommandFunc,
+		Long: `Gets the key or a range of keys.
+
+With --watch, prints the keys and then watches them from the revision of the
+read, so no change is missed or printed twice.`,
+		Run: getCommandFunc,
 	}
 
 	cmd.Flags().StringVar(&getConsistency, "consistency", "l", "Linearizable(l) or Serializable(s)")
@@ -54,6 +60,7 @@ func NewGetCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&getKeysOnly, "keys-only", false, "Get only the keys")
 	cmd.Flags().BoolVar(&getCountOnly, "count-only", false, "Get only the count")
 	cmd.Flags().BoolVar(&printValueOnly, "print-value-only", false, `Only write values when using the "simple" output format`)
+	cmd.Flags().BoolVar(&getWatch, "watch", false, "Watch the keys for changes after getting them")
 
 	cmd.RegisterFlagCompletionFunc("consistency", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
 		return []string{"l", "s"}, cobra.ShellCompDirectiveDefault
@@ -71,8 +78,9 @@ func NewGetCommand() *cobra.Command {
 // getCommandFunc executes the "get" command.
 func getCommandFunc(cmd *cobra.Command, args []string) {
 	key, opts := getGetOp(args)
+	c := mustClientFromCmd(cmd)
 	ctx, cancel := commandCtx(cmd)
-	resp, err := mustClientFromCmd(cmd).Get(ctx, key, opts...)
+	resp, err := c.Get(ctx, key, opts...)
 	cancel()
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitError, err)
@@ -92,6 +100,36 @@ func getCommandFunc(cmd *cobra.Command, args []string) {
 		dp.valueOnly = true
 	}
 	display.Get(*resp)
+
+	if getWatch {
+		getWatchFrom(c, key, opts, resp)
+	}
+}
+
+// getWatchFrom watches the keys read by resp from the revision following the
+// read until the watch is canceled.
+func getWatchFrom(c *clientv3.Client, key string, opts []clientv3.OpOption, resp *clientv3.GetResponse) {
+	rev := resp.Header.Revision
+	if getRev > 0 {
+		rev = getRev
+	}
+	wopts := []clientv3.OpOption{clientv3.WithRev(rev + 1)}
+	if end := clientv3.OpGet(key, opts...).RangeBytes(); len(end) > 0 {
+		wopts = append(wopts, clientv3.WithRange(string(end)))
+	}
+
+	// JSON and protobuf outputs carry the revision in their headers; only
+	// the line-oriented outputs get a delimiter between the read and the events.
+	switch display.(type) {
+	case *simplePrinter, *fieldsPrinter:
+		fmt.Printf("--- watching from revision %d ---\n", rev+1)
+	}
+
+	printWatchCh(c, c.Watch(clientv3.WithRequireLeader(context.Background()), key, wopts...), nil)
+	if err := c.Close(); err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadConnection, err)
+	}
+	cobrautl.ExitWithError(cobrautl.ExitInterrupted, fmt.Errorf("watch is canceled by the server"))
 }
 
 func getGetOp(args []string) (string, []clientv3.OpOption) {
@@ -107,6 +145,10 @@ func getGetOp(args []string) (string, []clientv3.OpOption) {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("`--keys-only` and `--count-only` cannot be set at the same time, choose one"))
 	}
 
+	if getWatch && getLimit > 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("`--limit` cannot be used with `--watch`, the keys beyond the limit would not be printed"))
+	}
+
 	var opts []clientv3.OpOption
 	switch getConsistency {
 	case "s":
@@ -0,0 +1,296 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFormat       string
+	importMappings     []string
+	importSkipUnmapped bool
+	importMaxTxnOps    uint
+	importRate         float64
+	importDryRun       bool
+)
+
+// importParsers are the parsers of the supported export formats.
+var importParsers = map[string]func(io.Reader) ([]importEntry, error){
+	"consul-kv-json":     parseConsulKV,
+	"zk-dump":            parseZKDump,
+	exportFormatJSONL:    parseJSONLExport,
+	exportFormatProtobuf: parseProtobufExport,
+}
+
+// NewImportCommand returns the cobra command for "import".
+func NewImportCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "import --format=<format> [options] <file>",
+		Short: "Imports the keys exported by 'etcdctl export' or from another key-value store",
+		Run:   importCommandFunc,
+	}
+
+	c.Flags().StringVar(&importFormat, "format", "", "Format of the export, 'jsonl' or 'protobuf' of 'etcdctl export', 'consul-kv-json' or 'zk-dump'")
+	c.Flags().StringArrayVar(&importMappings, "map", nil, "Key mapping rule 'from=to' replacing the key prefix 'from' with 'to', the first matching rule applies (can be repeated)")
+	c.Flags().BoolVar(&importSkipUnmapped, "skip-unmapped", false, "Skip the keys matching no mapping rule instead of importing them unchanged")
+	c.Flags().UintVar(&importMaxTxnOps, "max-txn-ops", defaultMaxTxnOps, "Maximum number of keys written per transaction")
+	c.Flags().Float64Var(&importRate, "rate", 0, "Maximum number of keys written per second, 0 for no limit")
+	c.Flags().BoolVar(&importDryRun, "dry-run", false, "Report the keys that would be imported without writing them")
+
+	return c
+}
+
+// importEntry is a key-value read from an export.
+type importEntry struct {
+	// source is the key or path of the entry in the export.
+	source string
+	key    string
+	value  []byte
+	// metadata is the metadata stored with the key, if any.
+	metadata []byte
+	// ttl is the time to live of the key in seconds, 0 if it has none.
+	ttl int64
+	// skip is the reason the entry is not imported, empty if it is.
+	skip string
+}
+
+// keyMapping replaces the key prefix from with to.
+type keyMapping struct {
+	from, to string
+}
+
+// importCommandFunc executes the "import" command.
+func importCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("import takes one file argument, '-' for the standard input"))
+	}
+	parse, ok := importParsers[importFormat]
+	if !ok {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("unknown format %q, expected 'jsonl', 'protobuf', 'consul-kv-json' or 'zk-dump'", importFormat))
+	}
+	if importMaxTxnOps == 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, errors.New("--max-txn-ops must be positive"))
+	}
+	mappings, err := parseKeyMappings(importMappings)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+
+	r := io.Reader(os.Stdin)
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+		defer f.Close()
+		r = f
+	}
+	ents, err := parse(r)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("cannot parse %s: %w", args[0], err))
+	}
+	if err = mapImportKeys(ents, mappings, importSkipUnmapped); err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	var (
+		puts []importEntry
+		size int
+		ttls = make(map[int64]clientv3.LeaseID)
+	)
+	for _, e := range ents {
+		if e.skip != "" {
+			fmt.Printf("skip %s: %s\n", e.source, e.skip)
+			continue
+		}
+		puts = append(puts, e)
+		size += len(e.key) + len(e.value)
+		if e.ttl != 0 {
+			ttls[e.ttl] = clientv3.NoLease
+		}
+		if importDryRun {
+			if e.ttl != 0 {
+				fmt.Printf("put %s -> %s (%d bytes, ttl %ds)\n", e.source, e.key, len(e.value), e.ttl)
+			} else {
+				fmt.Printf("put %s -> %s (%d bytes)\n", e.source, e.key, len(e.value))
+			}
+		}
+	}
+	if importDryRun {
+		fmt.Printf("would import %d keys (%d bytes) with %d leases, skipping %d keys\n", len(puts), size, len(ttls), len(ents)-len(puts))
+		return
+	}
+	imported := len(puts)
+
+	c := mustClientFromCmd(cmd)
+	defer c.Close()
+	for ttl := range ttls {
+		ctx, cancel := commandCtx(cmd)
+		resp, err := c.Grant(ctx, ttl)
+		cancel()
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("cannot grant a lease of ttl %ds: %w", ttl, err))
+		}
+		ttls[ttl] = resp.ID
+	}
+	limiter := newKeyLimiter(importRate, int(importMaxTxnOps))
+	for len(puts) > 0 {
+		n := len(puts)
+		if n > int(importMaxTxnOps) {
+			n = int(importMaxTxnOps)
+		}
+		ops := make([]clientv3.Op, 0, n)
+		for _, e := range puts[:n] {
+			opts := []clientv3.OpOption{clientv3.WithLease(ttls[e.ttl])}
+			if len(e.metadata) > 0 {
+				opts = append(opts, clientv3.WithMetadata(e.metadata))
+			}
+			ops = append(ops, clientv3.OpPut(e.key, string(e.value), opts...))
+		}
+		if err := limiter.WaitN(context.Background(), n); err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+		ctx, cancel := commandCtx(cmd)
+		_, err := c.Txn(ctx).Then(ops...).Commit()
+		cancel()
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("cannot import %s: %w", puts[0].source, err))
+		}
+		puts = puts[n:]
+	}
+	fmt.Printf("imported %d keys (%d bytes) with %d leases, skipped %d keys\n", imported, size, len(ttls), len(ents)-imported)
+}
+
+// parseKeyMappings parses the 'from=to' key mapping rules.
+func parseKeyMappings(rules []string) ([]keyMapping, error) {
+	var mappings []keyMapping
+	for _, rule := range rules {
+		from, to, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid key mapping rule %q, expected 'from=to'", rule)
+		}
+		mappings = append(mappings, keyMapping{from: from, to: to})
+	}
+	return mappings, nil
+}
+
+// mapImportKeys sets the keys of the entries to import from their source
+// keys. The entries whose source key matches no mapping are imported
+// unchanged, or skipped if skipUnmapped is set. It fails if several entries
+// map to the same key.
+func mapImportKeys(ents []importEntry, mappings []keyMapping, skipUnmapped bool) error {
+	sources := make(map[string]string)
+	for i := range ents {
+		e := &ents[i]
+		if e.skip != "" {
+			continue
+		}
+		e.key = e.source
+		mapped := false
+		for _, m := range mappings {
+			if strings.HasPrefix(e.source, m.from) {
+				e.key, mapped = m.to+strings.TrimPrefix(e.source, m.from), true
+				break
+			}
+		}
+		switch {
+		case !mapped && skipUnmapped:
+			e.key, e.skip = "", "no matching key mapping rule"
+			continue
+		case e.key == "":
+			return fmt.Errorf("%s maps to an empty key", e.source)
+		}
+		if src, ok := sources[e.key]; ok {
+			return fmt.Errorf("%s and %s both map to %s", src, e.source, e.key)
+		}
+		sources[e.key] = e.source
+	}
+	return nil
+}
+
+// consulKV is a key-value of the JSON output of 'consul kv export'.
+type consulKV struct {
+	Key   string `json:"key"`
+	Flags uint64 `json:"flags"`
+	// Value is base64 encoded.
+	Value []byte `json:"value"`
+}
+
+// parseConsulKV parses the JSON output of 'consul kv export'. Consul exports
+// carry no TTL, the session of a key being left out.
+func parseConsulKV(r io.Reader) ([]importEntry, error) {
+	var kvs []consulKV
+	if err := json.NewDecoder(r).Decode(&kvs); err != nil {
+		return nil, err
+	}
+	ents := make([]importEntry, 0, len(kvs))
+	for _, kv := range kvs {
+		ents = append(ents, importEntry{source: kv.Key, value: kv.Value})
+	}
+	return ents, nil
+}
+
+// zkNode is a znode of a ZooKeeper dump.
+type zkNode struct {
+	Path string `json:"path"`
+	// Data is base64 encoded, null for the znodes without data.
+	Data []byte `json:"data"`
+	// TTL is the time to live in milliseconds of a TTL node.
+	TTL int64 `json:"ttl"`
+	// EphemeralOwner is the session owning an ephemeral node, 0 for the
+	// persistent ones.
+	EphemeralOwner int64 `json:"ephemeralOwner"`
+}
+
+// parseZKDump parses a ZooKeeper dump, a JSON array of znodes. The TTLs of
+// TTL nodes are rounded up to seconds. The znodes without data, the
+// ephemeral nodes, which belong to the sessions of ZooKeeper clients, and
+// the internal znodes under /zookeeper are skipped.
+func parseZKDump(r io.Reader) ([]importEntry, error) {
+	var nodes []zkNode
+	if err := json.NewDecoder(r).Decode(&nodes); err != nil {
+		return nil, err
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Path < nodes[j].Path })
+	ents := make([]importEntry, 0, len(nodes))
+	for _, n := range nodes {
+		e := importEntry{source: n.Path, value: n.Data, ttl: (n.TTL + 999) / 1000}
+		switch {
+		case n.Path == "/zookeeper" || strings.HasPrefix(n.Path, "/zookeeper/"):
+			e.skip = "internal znode"
+		case n.EphemeralOwner != 0:
+			e.skip = "ephemeral znode"
+		case n.Data == nil:
+			e.skip = "znode without data"
+		case n.TTL < 0:
+			return nil, fmt.Errorf("%s has a negative ttl", n.Path)
+		}
+		ents = append(ents, e)
+	}
+	return ents, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConsulKV(t *testing.T) {
+	export := `[
+	{"key": "app/", "flags": 0, "value": ""},
+	{"key": "app/db", "flags": 42, "value": "aG9zdD1kYg=="}
+]`
+	ents, err := parseConsulKV(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wents := []importEntry{
+		{source: "app/", value: []byte{}},
+		{source: "app/db", value: []byte("host=db")},
+	}
+	if !reflect.DeepEqual(ents, wents) {
+		t.Errorf("entries = %+v, want %+v", ents, wents)
+	}
+	if _, err = parseConsulKV(strings.NewReader(`{"key": "app"}`)); err == nil {
+		t.Error("parsed a consul export that is not an array")
+	}
+}
+
+func TestParseZKDump(t *testing.T) {
+	export := `[
+	{"path": "/zookeeper/quota", "data": ""},
+	{"path": "/app/lock", "data": "MQ==", "ephemeralOwner": 7},
+	{"path": "/app/session", "data": "MQ==", "ttl": 1500},
+	{"path": "/app", "data": null},
+	{"path": "/app/db", "data": "aG9zdD1kYg=="}
+]`
+	ents, err := parseZKDump(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wents := []importEntry{
+		{source: "/app", skip: "znode without data"},
+		{source: "/app/db", value: []byte("host=db")},
+		{source: "/app/lock", value: []byte("1"), skip: "ephemeral znode"},
+		{source: "/app/session", value: []byte("1"), ttl: 2},
+		{source: "/zookeeper/quota", value: []byte{}, skip: "internal znode"},
+	}
+	if !reflect.DeepEqual(ents, wents) {
+		t.Errorf("entries = %+v, want %+v", ents, wents)
+	}
+	if _, err = parseZKDump(strings.NewReader(`[{"path": "/a", "data": "", "ttl": -1}]`)); err == nil {
+		t.Error("parsed a znode with a negative ttl")
+	}
+}
+
+func TestMapImportKeys(t *testing.T) {
+	mappings, err := parseKeyMappings([]string{"/app/db=/config/db/", "/app/=/config/", "/=/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = parseKeyMappings([]string{"/app"}); err == nil {
+		t.Error("parsed a key mapping rule without '='")
+	}
+
+	tests := []struct {
+		sources      []string
+		skipUnmapped bool
+		wkeys        []string
+		wskipped     []bool
+		werr         string
+	}{
+		{
+			sources: []string{"/app/db", "/app/web", "/other", "other"},
+			wkeys:   []string{"/config/db/", "/config/web", "/other", "other"},
+		},
+		{
+			sources:      []string{"/app/web", "other"},
+			skipUnmapped: true,
+			wkeys:        []string{"/config/web", ""},
+			wskipped:     []bool{false, true},
+		},
+		{
+			sources: []string{"/app/x", "/config/x"},
+			werr:    "/app/x and /config/x both map to /config/x",
+		},
+	}
+	for i, tt := range tests {
+		ents := make([]importEntry, len(tt.sources))
+		for j, src := range tt.sources {
+			ents[j].source = src
+		}
+		err := mapImportKeys(ents, mappings, tt.skipUnmapped)
+		if tt.werr != "" {
+			if err == nil || err.Error() != tt.werr {
+				t.Errorf("#%d: err = %v, want %q", i, err, tt.werr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		for j, e := range ents {
+			if e.key != tt.wkeys[j] {
+				t.Errorf("#%d: key of %s = %q, want %q", i, e.source, e.key, tt.wkeys[j])
+			}
+			if tt.wskipped != nil && (e.skip != "") != tt.wskipped[j] {
+				t.Errorf("#%d: %s skipped = %v, want %v", i, e.source, e.skip != "", tt.wskipped[j])
+			}
+		}
+	}
+}
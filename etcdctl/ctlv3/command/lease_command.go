@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	v3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
 
@@ -41,14 +42,17 @@ func NewLeaseCommand() *cobra.Command {
 	return lc
 }
 
+var grantKeepaliveOnWrite bool
+
 // NewLeaseGrantCommand returns the cobra command for "lease grant".
 func NewLeaseGrantCommand() *cobra.Command {
 	lc := &cobra.Command{
-		Use:   "grant <ttl>",
+		Use:   "grant <ttl> [options]",
 		Short: "Creates leases",
 
 		Run: leaseGrantCommandFunc,
 	}
+	lc.Flags().BoolVar(&grantKeepaliveOnWrite, "keepalive-on-write", false, "Renew the lease on the writes of the keys attached to it")
 
 	return lc
 }
@@ -64,8 +68,12 @@ func leaseGrantCommandFunc(cmd *cobra.Command, args []string) {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("bad TTL (%v)", err))
 	}
 
+	var opts []v3.LeaseOption
+	if grantKeepaliveOnWrite {
+		opts = append(opts, v3.WithKeepaliveOnWrite())
+	}
 	ctx, cancel := commandCtx(cmd)
-	resp, err := mustClientFromCmd(cmd).Grant(ctx, ttl)
+	resp, err := mustClientFromCmd(cmd).Grant(ctx, ttl, opts...)
 	cancel()
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("failed to grant lease (%v)", err))
@@ -74,6 +82,8 @@ func leaseGrantCommandFunc(cmd *cobra.Command, args []string) {
 }
 
 // NewLeaseRevokeCommand returns the cobra command for "lease revoke".
+var leaseRevokeDryRun bool
+
 func NewLeaseRevokeCommand() *cobra.Command {
 	lc := &cobra.Command{
 		Use:   "revoke <leaseID>",
@@ -82,6 +92,8 @@ func NewLeaseRevokeCommand() *cobra.Command {
 		Run: leaseRevokeCommandFunc,
 	}
 
+	lc.Flags().BoolVar(&leaseRevokeDryRun, "dry-run", false, "report the keys that would be deleted with the lease without revoking it")
+
 	return lc
 }
 
@@ -92,6 +104,10 @@ func leaseRevokeCommandFunc(cmd *cobra.Command, args []string) {
 	}
 
 	id := leaseFromArgs(args[0])
+	if leaseRevokeDryRun {
+		leaseRevokeDryRunReport(cmd, id)
+		return
+	}
 	ctx, cancel := commandCtx(cmd)
 	resp, err := mustClientFromCmd(cmd).Revoke(ctx, id)
 	cancel()
@@ -101,6 +117,23 @@ func leaseRevokeCommandFunc(cmd *cobra.Command, args []string) {
 	display.Revoke(id, *resp)
 }
 
+// leaseRevokeDryRunReport prints the keys revoking the lease id would delete.
+func leaseRevokeDryRunReport(cmd *cobra.Command, id v3.LeaseID) {
+	ctx, cancel := commandCtx(cmd)
+	resp, err := mustClientFromCmd(cmd).TimeToLive(ctx, id, v3.WithAttachedKeys())
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("failed to get lease (%v)", err))
+	}
+	if resp.TTL == -1 {
+		cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("failed to revoke lease (%v)", rpctypes.ErrLeaseNotFound))
+	}
+	fmt.Printf("would revoke lease %016x (remaining %ds), deleting its %d keys\n", id, resp.TTL, len(resp.Keys))
+	for _, k := range resp.Keys {
+		fmt.Printf("%q\n", k)
+	}
+}
+
 var timeToLiveKeys bool
 
 // NewLeaseTimeToLiveCommand returns the cobra command for "lease timetolive".
@@ -21,13 +21,16 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
 )
 
 var (
-	memberPeerURLs string
-	isLearner      bool
+	memberPeerURLs     string
+	isLearner          bool
+	memberRemoveDryRun bool
 )
 
 // NewMemberCommand returns the cobra command for "member".
@@ -70,6 +73,8 @@ func NewMemberRemoveCommand() *cobra.Command {
 		Run: memberRemoveCommandFunc,
 	}
 
+	cc.Flags().BoolVar(&memberRemoveDryRun, "dry-run", false, "report the effect of removing the member on the quorum without removing it")
+
 	return cc
 }
 
@@ -187,6 +192,11 @@ func memberRemoveCommandFunc(cmd *cobra.Command, args []string) {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("bad member ID arg (%v), expecting ID in Hex", err))
 	}
 
+	if memberRemoveDryRun {
+		memberRemoveDryRunReport(cmd, id)
+		return
+	}
+
 	ctx, cancel := commandCtx(cmd)
 	resp, err := mustClientFromCmd(cmd).MemberRemove(ctx, id)
 	cancel()
@@ -196,6 +206,73 @@ func memberRemoveCommandFunc(cmd *cobra.Command, args []string) {
 	display.MemberRemove(id, *resp)
 }
 
+// memberRemoveDryRunReport prints the effect of removing the member id on
+// the cluster.
+func memberRemoveDryRunReport(cmd *cobra.Command, id uint64) {
+	c := mustClientFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	defer cancel()
+	resp, err := c.MemberList(ctx)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	// the leader is unknown if the endpoint is unavailable
+	var leader uint64
+	if sresp, serr := c.Status(ctx, c.Endpoints()[0]); serr == nil {
+		leader = sresp.Leader
+	}
+	impact, err := memberRemoveImpact(resp.Members, id, leader)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	fmt.Printf("would remove member %x from cluster %x\n", id, resp.Header.ClusterId)
+	for _, l := range impact {
+		fmt.Println(l)
+	}
+}
+
+// memberRemoveImpact describes the effect of removing the member id from the
+// cluster of members, whose leader is leader, on its quorum.
+func memberRemoveImpact(members []*pb.Member, id, leader uint64) ([]string, error) {
+	var (
+		removed         *pb.Member
+		voters, started int
+	)
+	for _, m := range members {
+		if m.ID == id {
+			removed = m
+		}
+		if m.IsLearner {
+			continue
+		}
+		voters++
+		// the members which never started have no name
+		if m.ID != id && m.Name != "" {
+			started++
+		}
+	}
+	if removed == nil {
+		return nil, rpctypes.ErrMemberNotFound
+	}
+
+	quorum := func(n int) int { return n/2 + 1 }
+	if removed.IsLearner {
+		return []string{fmt.Sprintf("member %x is a learner: the %d voting members and their quorum of %d are unchanged", id, voters, quorum(voters))}, nil
+	}
+	if voters == 1 {
+		return []string{fmt.Sprintf("member %x is the last voting member: the cluster would be left without quorum", id)}, nil
+	}
+	impact := []string{fmt.Sprintf("voting members: %d -> %d, quorum: %d -> %d, tolerated failures: %d -> %d",
+		voters, voters-1, quorum(voters), quorum(voters-1), voters-quorum(voters), voters-1-quorum(voters-1))}
+	if started < quorum(voters-1) {
+		impact = append(impact, fmt.Sprintf("only %d of the remaining %d voting members are started, below the quorum of %d: the cluster would become unavailable", started, voters-1, quorum(voters-1)))
+	}
+	if id == leader {
+		impact = append(impact, fmt.Sprintf("member %x is the leader: a new leader would be elected", id))
+	}
+	return impact, nil
+}
+
 // memberUpdateCommandFunc executes the "member update" command.
 func memberUpdateCommandFunc(cmd *cobra.Command, args []string) {
 	if len(args) != 1 {
@@ -0,0 +1,98 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+func TestMemberRemoveImpact(t *testing.T) {
+	members := []*pb.Member{
+		{ID: 1, Name: "m1"},
+		{ID: 2, Name: "m2"},
+		{ID: 3, Name: "m3"},
+		{ID: 4, Name: "m4", IsLearner: true},
+		{ID: 5},
+	}
+	tests := []struct {
+		name    string
+		members []*pb.Member
+		id      uint64
+		leader  uint64
+
+		wimpact []string
+		werr    error
+	}{
+		{
+			name:    "follower",
+			members: members[:3],
+			id:      2,
+			leader:  1,
+			wimpact: []string{"voting members: 3 -> 2, quorum: 2 -> 2, tolerated failures: 1 -> 0"},
+		},
+		{
+			name:    "leader",
+			members: members[:3],
+			id:      1,
+			leader:  1,
+			wimpact: []string{
+				"voting members: 3 -> 2, quorum: 2 -> 2, tolerated failures: 1 -> 0",
+				"member 1 is the leader: a new leader would be elected",
+			},
+		},
+		{
+			name:    "learner",
+			members: members[:4],
+			id:      4,
+			wimpact: []string{"member 4 is a learner: the 3 voting members and their quorum of 2 are unchanged"},
+		},
+		{
+			name:    "unstarted member left",
+			members: append(members[:2:2], members[4]),
+			id:      2,
+			wimpact: []string{
+				"voting members: 3 -> 2, quorum: 2 -> 2, tolerated failures: 1 -> 0",
+				"only 1 of the remaining 2 voting members are started, below the quorum of 2: the cluster would become unavailable",
+			},
+		},
+		{
+			name:    "last voting member",
+			members: members[:1],
+			id:      1,
+			wimpact: []string{"member 1 is the last voting member: the cluster would be left without quorum"},
+		},
+		{
+			name:    "unknown member",
+			members: members,
+			id:      6,
+			werr:    rpctypes.ErrMemberNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impact, err := memberRemoveImpact(tt.members, tt.id, tt.leader)
+			if err != tt.werr {
+				t.Fatalf("err = %v, want %v", err, tt.werr)
+			}
+			if !reflect.DeepEqual(impact, tt.wimpact) {
+				t.Errorf("impact = %q, want %q", impact, tt.wimpact)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ package command
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
@@ -48,6 +49,7 @@ type printer interface {
 	EndpointHealth([]epHealth)
 	EndpointStatus([]epStatus)
 	EndpointHashKV([]epHashKV)
+	EndpointConfig([]epConfig)
 	MoveLeader(leader, target uint64, r v3.MoveLeaderResponse)
 
 	DowngradeValidate(r v3.DowngradeResponse)
@@ -72,6 +74,7 @@ type printer interface {
 	UserDelete(user string, r v3.AuthUserDeleteResponse)
 
 	AuthStatus(r v3.AuthStatusResponse)
+	EffectivePermissions(p effectivePerms)
 }
 
 func NewPrinter(printerType string, isHex bool) printer {
@@ -168,6 +171,9 @@ func newPrinterUnsupported(n string) printer {
 func (p *printerUnsupported) EndpointHealth([]epHealth) { p.p(nil) }
 func (p *printerUnsupported) EndpointStatus([]epStatus) { p.p(nil) }
 func (p *printerUnsupported) EndpointHashKV([]epHashKV) { p.p(nil) }
+func (p *printerUnsupported) EndpointConfig([]epConfig) { p.p(nil) }
+
+func (p *printerUnsupported) EffectivePermissions(effectivePerms) { p.p(nil) }
 
 func (p *printerUnsupported) MoveLeader(leader, target uint64, r v3.MoveLeaderResponse) { p.p(nil) }
 func (p *printerUnsupported) DowngradeValidate(r v3.DowngradeResponse)                  { p.p(nil) }
@@ -211,14 +217,15 @@ func makeEndpointHealthTable(healthList []epHealth) (hdr []string, rows [][]stri
 }
 
 func makeEndpointStatusTable(statusList []epStatus) (hdr []string, rows [][]string) {
-	hdr = []string{"endpoint", "ID", "version", "storage version", "db size", "db size in use", "is leader", "is learner", "raft term",
-		"raft index", "raft applied index", "errors"}
+	hdr = []string{"endpoint", "ID", "version", "storage version", "wal version", "db size", "db size in use", "is leader", "is learner", "raft term",
+		"raft index", "raft applied index", "watch streams", "watchers", "slow watchers", "pending events", "errors"}
 	for _, status := range statusList {
 		rows = append(rows, []string{
 			status.Ep,
 			fmt.Sprintf("%x", status.Resp.Header.MemberId),
 			status.Resp.Version,
 			status.Resp.StorageVersion,
+			status.Resp.WalVersion,
 			humanize.Bytes(uint64(status.Resp.DbSize)),
 			humanize.Bytes(uint64(status.Resp.DbSizeInUse)),
 			fmt.Sprint(status.Resp.Leader == status.Resp.Header.MemberId),
@@ -226,12 +233,51 @@ func makeEndpointStatusTable(statusList []epStatus) (hdr []string, rows [][]stri
 			fmt.Sprint(status.Resp.RaftTerm),
 			fmt.Sprint(status.Resp.RaftIndex),
 			fmt.Sprint(status.Resp.RaftAppliedIndex),
+			fmt.Sprint(status.Resp.WatchStreams),
+			fmt.Sprint(status.Resp.Watchers),
+			fmt.Sprint(status.Resp.SlowWatchers),
+			fmt.Sprint(status.Resp.WatchPendingEvents),
 			fmt.Sprint(strings.Join(status.Resp.Errors, ", ")),
 		})
 	}
 	return hdr, rows
 }
 
+func makeEndpointConfigTable(configList []epConfig) (hdr []string, rows [][]string) {
+	hdr = []string{"endpoint", "option", "value"}
+	for _, c := range configList {
+		names := make([]string, 0, len(c.Resp.Config))
+		for name := range c.Resp.Config {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			rows = append(rows, []string{c.Ep, name, c.Resp.Config[name]})
+		}
+	}
+	return hdr, rows
+}
+
+func makeEffectivePermissionsTable(ep effectivePerms) (hdr []string, rows [][]string) {
+	hdr = []string{"permission", "key", "range end"}
+	for _, p := range []struct {
+		name string
+		ivls []keyInterval
+	}{{"read", ep.Read}, {"write", ep.Write}} {
+		for _, ivl := range p.ivls {
+			rangeEnd := string(ivl.RangeEnd)
+			if rangeEnd == "\x00" {
+				rangeEnd = "<open ended>"
+			}
+			rows = append(rows, []string{p.name, string(ivl.Key), rangeEnd})
+		}
+	}
+	if ep.Lease {
+		rows = append(rows, []string{"lease", "<all leases>", ""})
+	}
+	return hdr, rows
+}
+
 func makeEndpointHashKVTable(hashList []epHashKV) (hdr []string, rows [][]string) {
 	hdr = []string{"endpoint", "hash"}
 	for _, h := range hashList {
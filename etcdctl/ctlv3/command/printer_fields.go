@@ -39,6 +39,8 @@ func (p *fieldsPrinter) kv(pfx string, kv *spb.KeyValue) {
 	} else {
 		fmt.Printf("\"%sLease\" : %d\n", pfx, kv.Lease)
 	}
+	fmt.Printf("\"%sMetadata\" : %q\n", pfx, string(kv.Metadata))
+	fmt.Printf("\"%sTTL\" : %d\n", pfx, kv.Ttl)
 }
 
 func (p *fieldsPrinter) hdr(h *pb.ResponseHeader) {
@@ -194,6 +196,7 @@ func (p *fieldsPrinter) EndpointStatus(eps []epStatus) {
 		p.hdr(ep.Resp.Header)
 		fmt.Printf("\"Version\" : %q\n", ep.Resp.Version)
 		fmt.Printf("\"StorageVersion\" : %q\n", ep.Resp.StorageVersion)
+		fmt.Printf("\"WALVersion\" : %q\n", ep.Resp.WalVersion)
 		fmt.Println(`"DBSize" :`, ep.Resp.DbSize)
 		fmt.Println(`"DBSizeInUse" :`, ep.Resp.DbSizeInUse)
 		fmt.Println(`"Leader" :`, ep.Resp.Leader)
@@ -201,6 +204,11 @@ func (p *fieldsPrinter) EndpointStatus(eps []epStatus) {
 		fmt.Println(`"RaftIndex" :`, ep.Resp.RaftIndex)
 		fmt.Println(`"RaftTerm" :`, ep.Resp.RaftTerm)
 		fmt.Println(`"RaftAppliedIndex" :`, ep.Resp.RaftAppliedIndex)
+		fmt.Println(`"WatchStreams" :`, ep.Resp.WatchStreams)
+		fmt.Println(`"Watchers" :`, ep.Resp.Watchers)
+		fmt.Println(`"SlowWatchers" :`, ep.Resp.SlowWatchers)
+		fmt.Println(`"WatchPendingEvents" :`, ep.Resp.WatchPendingEvents)
+		fmt.Println(`"WatchEvents" :`, ep.Resp.WatchEvents)
 		fmt.Println(`"Errors" :`, ep.Resp.Errors)
 		fmt.Printf("\"Endpoint\" : %q\n", ep.Ep)
 		fmt.Println()
@@ -216,6 +224,18 @@ func (p *fieldsPrinter) EndpointHashKV(hs []epHashKV) {
 	}
 }
 
+func (p *fieldsPrinter) EndpointConfig(cs []epConfig) {
+	for _, c := range cs {
+		p.hdr(c.Resp.Header)
+		fmt.Printf("\"Endpoint\" : %q\n", c.Ep)
+		_, rows := makeEndpointConfigTable([]epConfig{c})
+		for _, row := range rows {
+			fmt.Printf("%q : %q\n", row[1], row[2])
+		}
+		fmt.Println()
+	}
+}
+
 func (p *fieldsPrinter) Alarm(r v3.AlarmResponse) {
 	p.hdr(r.Header)
 	for _, a := range r.Alarms {
@@ -172,6 +172,20 @@ func (s *simplePrinter) EndpointHashKV(hashList []epHashKV) {
 	}
 }
 
+func (s *simplePrinter) EndpointConfig(configList []epConfig) {
+	_, rows := makeEndpointConfigTable(configList)
+	for _, row := range rows {
+		fmt.Println(strings.Join(row, ", "))
+	}
+}
+
+func (s *simplePrinter) EffectivePermissions(ep effectivePerms) {
+	_, rows := makeEffectivePermissionsTable(ep)
+	for _, row := range rows {
+		fmt.Println(strings.Join(row, ", "))
+	}
+}
+
 func (s *simplePrinter) MoveLeader(leader, target uint64, r v3.MoveLeaderResponse) {
 	fmt.Printf("Leadership transferred from %s to %s\n", types.ID(leader), types.ID(target))
 }
@@ -235,6 +249,13 @@ func (s *simplePrinter) RoleGet(role string, r v3.AuthRoleGetResponse) {
 			}
 		}
 	}
+	for _, perm := range r.Perm {
+		if perm.PermType == v3.PermLease {
+			fmt.Println("Lease:")
+			fmt.Println("\t<all leases>")
+			break
+		}
+	}
 }
 
 func (s *simplePrinter) RoleList(r v3.AuthRoleListResponse) {
@@ -252,6 +273,10 @@ func (s *simplePrinter) RoleGrantPermission(role string, r v3.AuthRoleGrantPermi
 }
 
 func (s *simplePrinter) RoleRevokePermission(role string, key string, end string, r v3.AuthRoleRevokePermissionResponse) {
+	if len(key) == 0 && len(end) == 0 {
+		fmt.Printf("Lease permission is revoked from role %s\n", role)
+		return
+	}
 	if len(end) == 0 {
 		fmt.Printf("Permission of key %s is revoked from role %s\n", key, role)
 		return
@@ -64,3 +64,23 @@ func (tp *tablePrinter) EndpointHashKV(r []epHashKV) {
 	table.SetAlignment(tablewriter.ALIGN_RIGHT)
 	table.Render()
 }
+func (tp *tablePrinter) EffectivePermissions(ep effectivePerms) {
+	hdr, rows := makeEffectivePermissionsTable(ep)
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(hdr)
+	for _, row := range rows {
+		table.Append(row)
+	}
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.Render()
+}
+func (tp *tablePrinter) EndpointConfig(r []epConfig) {
+	hdr, rows := makeEndpointConfigTable(r)
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(hdr)
+	for _, row := range rows {
+		table.Append(row)
+	}
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.Render()
+}
@@ -29,6 +29,9 @@ var (
 	putPrevKV      bool
 	putIgnoreVal   bool
 	putIgnoreLease bool
+	putMetadata    string
+	putIgnoreMeta  bool
+	putTTL         int64
 )
 
 // NewPutCommand returns the cobra command for "put".
@@ -61,6 +64,9 @@ will store the content of the file to <key>.
 	cmd.Flags().BoolVar(&putPrevKV, "prev-kv", false, "return the previous key-value pair before modification")
 	cmd.Flags().BoolVar(&putIgnoreVal, "ignore-value", false, "updates the key using its current value")
 	cmd.Flags().BoolVar(&putIgnoreLease, "ignore-lease", false, "updates the key using its current lease")
+	cmd.Flags().StringVar(&putMetadata, "metadata", "", "metadata describing the value to store with the key")
+	cmd.Flags().BoolVar(&putIgnoreMeta, "ignore-metadata", false, "updates the key using its current metadata")
+	cmd.Flags().Int64Var(&putTTL, "ttl", 0, "time to live of the key in seconds, after which it is deleted without a lease")
 	return cmd
 }
 
@@ -114,6 +120,15 @@ func getPutOp(args []string) (string, string, []clientv3.OpOption) {
 	if putIgnoreLease {
 		opts = append(opts, clientv3.WithIgnoreLease())
 	}
+	if putMetadata != "" {
+		opts = append(opts, clientv3.WithMetadata([]byte(putMetadata)))
+	}
+	if putIgnoreMeta {
+		opts = append(opts, clientv3.WithIgnoreMetadata())
+	}
+	if putTTL != 0 {
+		opts = append(opts, clientv3.WithTTL(putTTL))
+	}
 
 	return key, value, opts
 }
@@ -26,6 +26,7 @@ import (
 var (
 	rolePermPrefix  bool
 	rolePermFromKey bool
+	rolePermLease   bool
 )
 
 // NewRoleCommand returns the cobra command for "role".
@@ -81,7 +82,14 @@ func newRoleGrantPermissionCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "grant-permission [options] <role name> <permission type> <key> [endkey]",
 		Short: "Grants a key to a role",
-		Run:   roleGrantPermissionCommandFunc,
+		Long: `Grants a key to a role.
+
+The permission type is one of read, write, readwrite and lease. The lease
+permission takes no key: it permits revoking and keeping alive the leases
+granted by other users, which are otherwise reserved to their owner and
+to the root role.
+`,
+		Run: roleGrantPermissionCommandFunc,
 	}
 
 	cmd.Flags().BoolVar(&rolePermPrefix, "prefix", false, "grant a prefix permission")
@@ -99,6 +107,7 @@ func newRoleRevokePermissionCommand() *cobra.Command {
 
 	cmd.Flags().BoolVar(&rolePermPrefix, "prefix", false, "revoke a prefix permission")
 	cmd.Flags().BoolVar(&rolePermFromKey, "from-key", false, "revoke a permission of keys that are greater than or equal to the given key using byte compare")
+	cmd.Flags().BoolVar(&rolePermLease, "lease", false, "revoke the lease permission, which takes no key")
 
 	return cmd
 }
@@ -162,7 +171,7 @@ func roleListCommandFunc(cmd *cobra.Command, args []string) {
 
 // roleGrantPermissionCommandFunc executes the "role grant-permission" command.
 func roleGrantPermissionCommandFunc(cmd *cobra.Command, args []string) {
-	if len(args) < 3 {
+	if len(args) < 2 {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("role grant command requires role name, permission type, and key [endkey] as its argument"))
 	}
 
@@ -171,7 +180,17 @@ func roleGrantPermissionCommandFunc(cmd *cobra.Command, args []string) {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
 	}
 
-	key, rangeEnd := permRange(args[2:])
+	var key, rangeEnd string
+	if perm == clientv3.PermissionType(clientv3.PermLease) {
+		if len(args) != 2 {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("lease permission applies to all leases and takes no key"))
+		}
+	} else {
+		if len(args) < 3 {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("role grant command requires role name, permission type, and key [endkey] as its argument"))
+		}
+		key, rangeEnd = permRange(args[2:])
+	}
 	resp, err := mustClientFromCmd(cmd).Auth.RoleGrantPermission(context.TODO(), args[0], key, rangeEnd, perm)
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitError, err)
@@ -182,6 +201,18 @@ func roleGrantPermissionCommandFunc(cmd *cobra.Command, args []string) {
 
 // roleRevokePermissionCommandFunc executes the "role revoke-permission" command.
 func roleRevokePermissionCommandFunc(cmd *cobra.Command, args []string) {
+	if rolePermLease {
+		if len(args) != 1 {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("role revoke-permission command with --lease flag requires role name as its only argument"))
+		}
+		// the lease permission is granted with an empty key and range end
+		resp, err := mustClientFromCmd(cmd).Auth.RoleRevokePermission(context.TODO(), args[0], "", "")
+		if err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, err)
+		}
+		display.RoleRevokePermission(args[0], "", "", *resp)
+		return
+	}
 	if len(args) < 2 {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("role revoke-permission command requires role name and key [endkey] as its argument"))
 	}
@@ -17,11 +17,13 @@ package command
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 	"go.etcd.io/etcd/client/pkg/v3/logutil"
 	snapshot "go.etcd.io/etcd/client/v3/snapshot"
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
+	"go.etcd.io/etcd/pkg/v3/objstore"
 	"go.uber.org/zap"
 )
 
@@ -32,6 +34,7 @@ func NewSnapshotCommand() *cobra.Command {
 		Short: "Manages etcd node snapshots",
 	}
 	cmd.AddCommand(NewSnapshotSaveCommand())
+	cmd.AddCommand(NewSnapshotSettingsCommand())
 	return cmd
 }
 
@@ -39,7 +42,17 @@ func NewSnapshotSaveCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "save <filename>",
 		Short: "Stores an etcd node backend snapshot to a given file",
-		Run:   snapshotSaveCommandFunc,
+		Long: `Stores an etcd node backend snapshot to a given file.
+
+The snapshot may also be streamed to an object in S3, GCS or Azure Blob
+Storage, given as s3://<bucket>/<key>, gs://<bucket>/<object> or
+azblob://<container>/<blob>, without being staged on local disk. The object
+is only created once the checksum of the snapshot is verified. The credentials
+of the object store are read from the environment: AWS_ACCESS_KEY_ID,
+AWS_SECRET_ACCESS_KEY and AWS_REGION, GOOGLE_OAUTH_ACCESS_TOKEN, or
+AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY.
+`,
+		Run: snapshotSaveCommandFunc,
 	}
 }
 
@@ -63,7 +76,12 @@ func snapshotSaveCommandFunc(cmd *cobra.Command, args []string) {
 	defer cancel()
 
 	path := args[0]
-	version, err := snapshot.SaveWithVersion(ctx, lg, *cfg, path)
+	var version string
+	if objstore.IsURL(path) {
+		version, err = saveSnapshotToObject(ctx, lg, *cfg, path)
+	} else {
+		version, err = snapshot.SaveWithVersion(ctx, lg, *cfg, path)
+	}
 	if err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitInterrupted, err)
 	}
@@ -72,3 +90,48 @@ func snapshotSaveCommandFunc(cmd *cobra.Command, args []string) {
 		fmt.Printf("Server version %s\n", version)
 	}
 }
+
+var (
+	snapshotSettingsCount          uint64
+	snapshotSettingsCatchUpEntries uint64
+)
+
+// NewSnapshotSettingsCommand returns the cobra command for "snapshot settings".
+func NewSnapshotSettingsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "settings",
+		Short: "Updates and prints the snapshot settings of the etcd members with given endpoints",
+		Long: `Updates the snapshot count and the snapshot catch-up entries of the etcd members
+with given endpoints until they restart, and prints the settings in effect.
+Without flags, the settings are only printed.`,
+		Run: snapshotSettingsCommandFunc,
+	}
+	cmd.Flags().Uint64Var(&snapshotSettingsCount, "snapshot-count", 0, "Number of committed transactions to trigger a snapshot to disk, unchanged if 0")
+	cmd.Flags().Uint64Var(&snapshotSettingsCatchUpEntries, "snapshot-catchup-entries", 0, "Number of entries kept after a snapshot for slow followers to catch up, unchanged if 0")
+	cmd.Flags().BoolVar(&epClusterEndpoints, "cluster", false, "use all endpoints from the cluster member list")
+	return cmd
+}
+
+func snapshotSettingsCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("snapshot settings expects no argument"))
+	}
+
+	failures := 0
+	c := mustClientFromCmd(cmd)
+	for _, ep := range endpointsFromCluster(cmd) {
+		ctx, cancel := commandCtx(cmd)
+		resp, err := c.SnapshotSettings(ctx, ep, snapshotSettingsCount, snapshotSettingsCatchUpEntries)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to update the snapshot settings of etcd member[%s]. (%v)\n", ep, err)
+			failures++
+			continue
+		}
+		fmt.Printf("etcd member[%s]: snapshot-count=%d, snapshot-catchup-entries=%d\n", ep, resp.SnapshotCount, resp.SnapshotCatchupEntries)
+	}
+
+	if failures != 0 {
+		os.Exit(cobrautl.ExitError)
+	}
+}
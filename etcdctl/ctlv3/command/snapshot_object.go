@@ -0,0 +1,96 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/objstore"
+	"go.uber.org/zap"
+)
+
+// saveSnapshotToObject streams the snapshot of the member to the object at
+// objURL, which is only created once the checksum of the snapshot is verified,
+// and returns the server version.
+func saveSnapshotToObject(ctx context.Context, lg *zap.Logger, cfg clientv3.Config, objURL string) (version string, err error) {
+	cfg.Logger = lg.Named("client")
+	if len(cfg.Endpoints) != 1 {
+		return "", fmt.Errorf("snapshot must be requested to one selected node, not multiple %v", cfg.Endpoints)
+	}
+	cli, err := clientv3.New(cfg)
+	if err != nil {
+		return "", err
+	}
+	defer cli.Close()
+
+	start := time.Now()
+	resp, err := cli.SnapshotWithVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Snapshot.Close()
+	lg.Info("fetching snapshot", zap.String("endpoint", cfg.Endpoints[0]))
+	size, err := objstore.Upload(ctx, objURL, &checksumReader{r: resp.Snapshot, h: sha256.New()})
+	if err != nil {
+		return resp.Version, err
+	}
+	lg.Info("fetched snapshot",
+		zap.String("endpoint", cfg.Endpoints[0]),
+		zap.String("size", humanize.Bytes(uint64(size))),
+		zap.Duration("took", time.Since(start)),
+		zap.String("etcd-version", resp.Version),
+	)
+	lg.Info("saved", zap.String("url", objURL))
+	return resp.Version, nil
+}
+
+// checksumReader reads a snapshot followed by its sha256 checksum, and fails
+// at its end if the checksum does not match the snapshot.
+type checksumReader struct {
+	r io.Reader
+	h hash.Hash
+	n int64
+	// tail holds the last bytes read, not hashed yet since they may be the
+	// checksum.
+	tail []byte
+}
+
+func (cr *checksumReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	cr.tail = append(cr.tail, p[:n]...)
+	if extra := len(cr.tail) - sha256.Size; extra > 0 {
+		cr.h.Write(cr.tail[:extra])
+		cr.tail = append(cr.tail[:0], cr.tail[extra:]...)
+	}
+	if err == io.EOF {
+		// the checksum is appended to a snapshot sized in 512 bytes sectors
+		if cr.n%512 != sha256.Size {
+			return n, fmt.Errorf("sha256 checksum not found [bytes: %d]", cr.n)
+		}
+		if !bytes.Equal(cr.h.Sum(nil), cr.tail) {
+			return n, fmt.Errorf("sha256 checksum mismatch")
+		}
+	}
+	return n, err
+}
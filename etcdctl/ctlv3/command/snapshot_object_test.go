@@ -0,0 +1,59 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestChecksumReader(t *testing.T) {
+	db := bytes.Repeat([]byte("etcd"), 1024)
+	sum := sha256.Sum256(db)
+	snap := append(append([]byte(nil), db...), sum[:]...)
+
+	tests := []struct {
+		name    string
+		snap    []byte
+		wantErr string
+	}{
+		{name: "valid", snap: snap},
+		{name: "corrupted", snap: append([]byte("ETCD"), snap[4:]...), wantErr: "sha256 checksum mismatch"},
+		{name: "no checksum", snap: db, wantErr: "sha256 checksum not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// read one byte at a time, so that the checksum spans many reads
+			cr := &checksumReader{r: iotest.OneByteReader(bytes.NewReader(tt.snap)), h: sha256.New()}
+			b, err := io.ReadAll(cr)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(b, tt.snap) {
+					t.Errorf("read %d bytes, want %d", len(b), len(tt.snap))
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
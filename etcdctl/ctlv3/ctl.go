@@ -91,6 +91,10 @@ func init() {
 		command.NewMemberCommand(),
 		command.NewSnapshotCommand(),
 		command.NewMakeMirrorCommand(),
+		command.NewDiffCommand(),
+		command.NewExportCommand(),
+		command.NewImportCommand(),
+		command.NewDRCommand(),
 		command.NewLockCommand(),
 		command.NewElectCommand(),
 		command.NewAuthCommand(),
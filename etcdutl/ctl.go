@@ -46,6 +46,8 @@ func init() {
 		etcdutl.NewVersionCommand(),
 		etcdutl.NewCompletionCommand(),
 		etcdutl.NewMigrateCommand(),
+		etcdutl.NewAnalyzeCommand(),
+		etcdutl.NewWALCommand(),
 	)
 }
 
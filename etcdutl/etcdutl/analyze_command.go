@@ -0,0 +1,65 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdutl
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/etcdutl/v3/snapshot"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+	"go.etcd.io/etcd/server/v3/storage/datadir"
+)
+
+var (
+	analyzeDataDir     string
+	analyzePrefixDepth int
+	analyzeTop         int
+)
+
+// NewAnalyzeCommand returns the cobra command for "analyze".
+func NewAnalyzeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "Reports the keyspace statistics of the storage of the etcd",
+		Long: `Walks the backend of a data directory not in use by etcd and reports its keyspace statistics:
+the number and size of the keys under the prefixes of every depth, the histogram of the value sizes,
+the largest keys, the revisions stored since the last compaction per prefix and the distribution of
+the leases and their keys.
+`,
+		Run: analyzeCommandFunc,
+	}
+	cmd.Flags().StringVar(&analyzeDataDir, "data-dir", "", "Required. Analyzes a data directory not in use by etcd.")
+	cmd.Flags().IntVar(&analyzePrefixDepth, "prefix-depth", 2, "Number of '/'-separated segments of the prefixes the keys are grouped by.")
+	cmd.Flags().IntVar(&analyzeTop, "top", 10, "Number of prefixes of every depth, largest keys and leases reported. 0 reports all.")
+	cmd.MarkFlagRequired("data-dir")
+	cmd.MarkFlagDirname("data-dir")
+	return cmd
+}
+
+func analyzeCommandFunc(cmd *cobra.Command, args []string) {
+	printer := initPrinterFromCmd(cmd)
+
+	sp := snapshot.NewV3(GetLogger())
+	a, err := sp.Analyze(datadir.ToBackendFileName(analyzeDataDir), snapshot.AnalyzeConfig{
+		PrefixDepth: analyzePrefixDepth,
+		Top:         analyzeTop,
+	})
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError,
+			fmt.Errorf("failed to analyze etcd data[%s] (%v)", analyzeDataDir, err))
+	}
+	printer.Analysis(a)
+}
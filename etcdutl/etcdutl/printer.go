@@ -17,6 +17,7 @@ package etcdutl
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 	"go.etcd.io/etcd/etcdutl/v3/snapshot"
@@ -31,6 +32,7 @@ var (
 
 type printer interface {
 	DBStatus(snapshot.Status)
+	Analysis(snapshot.Analysis)
 }
 
 func NewPrinter(printerType string) printer {
@@ -63,7 +65,8 @@ func newPrinterUnsupported(n string) printer {
 	return &printerUnsupported{printerRPC{nil, f}}
 }
 
-func (p *printerUnsupported) DBStatus(snapshot.Status) { p.p(nil) }
+func (p *printerUnsupported) DBStatus(snapshot.Status)   { p.p(nil) }
+func (p *printerUnsupported) Analysis(snapshot.Analysis) { p.p(nil) }
 
 func makeDBStatusTable(ds snapshot.Status) (hdr []string, rows [][]string) {
 	hdr = []string{"hash", "revision", "total keys", "total size", "version"}
@@ -77,6 +80,59 @@ func makeDBStatusTable(ds snapshot.Status) (hdr []string, rows [][]string) {
 	return hdr, rows
 }
 
+// makeAnalysisTables returns the tables of the keyspace statistics: the
+// totals, the value sizes, the prefixes, the largest keys and the leases.
+func makeAnalysisTables(a snapshot.Analysis) (hdrs [][]string, tables [][][]string) {
+	hdrs = append(hdrs, []string{"revision", "total keys", "total revisions", "total size", "leases", "keys with lease"})
+	tables = append(tables, [][]string{{
+		fmt.Sprint(a.Revision),
+		fmt.Sprint(a.TotalKeys),
+		fmt.Sprint(a.TotalRevisions),
+		humanize.Bytes(uint64(a.TotalSize)),
+		fmt.Sprint(a.Leases.Leases),
+		fmt.Sprint(a.Leases.AttachedKeys),
+	}})
+
+	hdrs = append(hdrs, []string{"value size", "keys"})
+	tables = append(tables, makeHistogramRows(a.ValueSizes, func(b int64) string { return humanize.IBytes(uint64(b)) }))
+
+	hdrs = append(hdrs, []string{"prefix", "depth", "keys", "size", "revisions"})
+	var rows [][]string
+	for _, p := range a.Prefixes {
+		rows = append(rows, []string{p.Prefix, fmt.Sprint(p.Depth), fmt.Sprint(p.Keys), humanize.Bytes(uint64(p.Size)), fmt.Sprint(p.Revisions)})
+	}
+	tables = append(tables, rows)
+
+	hdrs = append(hdrs, []string{"key", "value size", "mod revision", "version", "lease"})
+	rows = nil
+	for _, k := range a.LargestKeys {
+		rows = append(rows, []string{k.Key, humanize.Bytes(uint64(k.ValueSize)), fmt.Sprint(k.ModRevision), fmt.Sprint(k.Version), fmt.Sprintf("%x", k.Lease)})
+	}
+	tables = append(tables, rows)
+
+	hdrs = append(hdrs, []string{"lease ttl", "leases"})
+	tables = append(tables, makeHistogramRows(a.Leases.TTLs, func(b int64) string { return (time.Duration(b) * time.Second).String() }))
+
+	hdrs = append(hdrs, []string{"lease", "ttl", "keys"})
+	rows = nil
+	for _, l := range a.Leases.Largest {
+		rows = append(rows, []string{fmt.Sprintf("%x", l.ID), fmt.Sprint(l.TTL), fmt.Sprint(l.Keys)})
+	}
+	tables = append(tables, rows)
+	return hdrs, tables
+}
+
+func makeHistogramRows(h []snapshot.HistogramBucket, format func(int64) string) (rows [][]string) {
+	for _, b := range h {
+		bound := "+Inf"
+		if b.UpperBound >= 0 {
+			bound = "<= " + format(b.UpperBound)
+		}
+		rows = append(rows, []string{bound, fmt.Sprint(b.Count)})
+	}
+	return rows
+}
+
 func initPrinterFromCmd(cmd *cobra.Command) (p printer) {
 	outputType, err := cmd.Flags().GetString("write-out")
 	if err != nil {
@@ -32,7 +32,8 @@ func newJSONPrinter() printer {
 	}
 }
 
-func (p *jsonPrinter) DBStatus(r snapshot.Status) { printJSON(r) }
+func (p *jsonPrinter) DBStatus(r snapshot.Status)   { printJSON(r) }
+func (p *jsonPrinter) Analysis(a snapshot.Analysis) { printJSON(a) }
 
 // !!! Share ??
 func printJSON(v interface{}) {
@@ -30,3 +30,16 @@ func (s *simplePrinter) DBStatus(ds snapshot.Status) {
 		fmt.Println(strings.Join(row, ", "))
 	}
 }
+
+func (s *simplePrinter) Analysis(a snapshot.Analysis) {
+	hdrs, tables := makeAnalysisTables(a)
+	for i, rows := range tables {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(strings.Join(hdrs[i], ", "))
+		for _, row := range rows {
+			fmt.Println(strings.Join(row, ", "))
+		}
+	}
+}
@@ -34,3 +34,16 @@ func (tp *tablePrinter) DBStatus(r snapshot.Status) {
 	table.SetAlignment(tablewriter.ALIGN_RIGHT)
 	table.Render()
 }
+
+func (tp *tablePrinter) Analysis(a snapshot.Analysis) {
+	hdrs, tables := makeAnalysisTables(a)
+	for i, rows := range tables {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader(hdrs[i])
+		for _, row := range rows {
+			table.Append(row)
+		}
+		table.SetAlignment(tablewriter.ALIGN_RIGHT)
+		table.Render()
+	}
+}
@@ -15,9 +15,14 @@
 package etcdutl
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"strings"
+	"time"
 
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/etcdutl/v3/snapshot"
 	"go.etcd.io/etcd/pkg/v3/cobrautl"
 	"go.etcd.io/etcd/server/v3/storage/datadir"
@@ -38,6 +43,14 @@ var (
 	restorePeerURLs     string
 	restoreName         string
 	skipHashCheck       bool
+	restoreSkipPrefixes []string
+	restoreOnlyPrefixes []string
+
+	restoreFromCluster string
+	restoreCACert      string
+	restoreCert        string
+	restoreKey         string
+	restoreUser        string
 )
 
 // NewSnapshotCommand returns the cobra command for "snapshot".
@@ -66,7 +79,19 @@ func NewSnapshotRestoreCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "restore <filename> --data-dir {output dir} [options]",
 		Short: "Restores an etcd member snapshot to an etcd directory",
-		Run:   snapshotRestoreCommandFunc,
+		Long: `Restores an etcd member snapshot to an etcd directory.
+
+The snapshot may also be streamed from an object in S3, GCS or Azure Blob
+Storage, given as s3://<bucket>/<key>, gs://<bucket>/<object> or
+azblob://<container>/<blob>. The credentials of the object store are read
+from the environment: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_REGION,
+GOOGLE_OAUTH_ACCESS_TOKEN, or AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY.
+
+With --from-cluster, the snapshot is streamed from the etcd member with the
+given client URL and restored without saving it to a file first, instead of
+restoring the given file.
+`,
+		Run: snapshotRestoreCommandFunc,
 	}
 	cmd.Flags().StringVar(&restoreDataDir, "data-dir", "", "Path to the output data directory")
 	cmd.Flags().StringVar(&restoreWalDir, "wal-dir", "", "Path to the WAL directory (use --data-dir if none given)")
@@ -75,6 +100,13 @@ func NewSnapshotRestoreCommand() *cobra.Command {
 	cmd.Flags().StringVar(&restorePeerURLs, "initial-advertise-peer-urls", defaultInitialAdvertisePeerURLs, "List of this member's peer URLs to advertise to the rest of the cluster")
 	cmd.Flags().StringVar(&restoreName, "name", defaultName, "Human-readable name for this member")
 	cmd.Flags().BoolVar(&skipHashCheck, "skip-hash-check", false, "Ignore snapshot integrity hash value (required if copied from data directory)")
+	cmd.Flags().StringSliceVar(&restoreSkipPrefixes, "skip-prefix", nil, "Key prefixes whose keys are not restored")
+	cmd.Flags().StringSliceVar(&restoreOnlyPrefixes, "only-prefix", nil, "Key prefixes outside of which keys are not restored (restore all keys if none given)")
+	cmd.Flags().StringVar(&restoreFromCluster, "from-cluster", "", "Client URL of the etcd member to stream the snapshot from, instead of a file")
+	cmd.Flags().StringVar(&restoreCACert, "cacert", "", "Verify certificates of the member streaming the snapshot using this CA bundle")
+	cmd.Flags().StringVar(&restoreCert, "cert", "", "Identify the client to the member streaming the snapshot using this TLS certificate file")
+	cmd.Flags().StringVar(&restoreKey, "key", "", "Identify the client to the member streaming the snapshot using this TLS key file")
+	cmd.Flags().StringVar(&restoreUser, "user", "", "username[:password] of the member streaming the snapshot")
 
 	cmd.MarkFlagDirname("data-dir")
 	cmd.MarkFlagDirname("wal-dir")
@@ -99,8 +131,12 @@ func SnapshotStatusCommandFunc(cmd *cobra.Command, args []string) {
 }
 
 func snapshotRestoreCommandFunc(_ *cobra.Command, args []string) {
+	if restoreFromCluster != "" {
+		snapshotRestoreFromClusterCommandFunc(args)
+		return
+	}
 	SnapshotRestoreCommandFunc(restoreCluster, restoreClusterToken, restoreDataDir, restoreWalDir,
-		restorePeerURLs, restoreName, skipHashCheck, args)
+		restorePeerURLs, restoreName, skipHashCheck, restoreSkipPrefixes, restoreOnlyPrefixes, args)
 }
 
 func SnapshotRestoreCommandFunc(restoreCluster string,
@@ -110,21 +146,15 @@ func SnapshotRestoreCommandFunc(restoreCluster string,
 	restorePeerURLs string,
 	restoreName string,
 	skipHashCheck bool,
+	restoreSkipPrefixes []string,
+	restoreOnlyPrefixes []string,
 	args []string) {
 	if len(args) != 1 {
 		err := fmt.Errorf("snapshot restore requires exactly one argument")
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
 	}
 
-	dataDir := restoreDataDir
-	if dataDir == "" {
-		dataDir = restoreName + ".etcd"
-	}
-
-	walDir := restoreWalDir
-	if walDir == "" {
-		walDir = datadir.ToWalDir(dataDir)
-	}
+	dataDir, walDir := restoreDirs(restoreDataDir, restoreWalDir, restoreName)
 
 	lg := GetLogger()
 	sp := snapshot.NewV3(lg)
@@ -138,11 +168,76 @@ func SnapshotRestoreCommandFunc(restoreCluster string,
 		InitialCluster:      restoreCluster,
 		InitialClusterToken: restoreClusterToken,
 		SkipHashCheck:       skipHashCheck,
+		SkipPrefixes:        restoreSkipPrefixes,
+		OnlyPrefixes:        restoreOnlyPrefixes,
 	}); err != nil {
 		cobrautl.ExitWithError(cobrautl.ExitError, err)
 	}
 }
 
+func snapshotRestoreFromClusterCommandFunc(args []string) {
+	if len(args) != 0 {
+		err := fmt.Errorf("snapshot restore --from-cluster expects no argument")
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+
+	ccfg := clientv3.Config{
+		Endpoints:   []string{restoreFromCluster},
+		DialTimeout: 5 * time.Second,
+	}
+	if restoreCACert != "" || restoreCert != "" || restoreKey != "" {
+		tlsInfo := transport.TLSInfo{
+			CertFile:      restoreCert,
+			KeyFile:       restoreKey,
+			TrustedCAFile: restoreCACert,
+		}
+		var err error
+		if ccfg.TLS, err = tlsInfo.ClientConfig(); err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+		}
+	} else if strings.HasPrefix(restoreFromCluster, "https://") {
+		ccfg.TLS = &tls.Config{}
+	}
+	if restoreUser != "" {
+		ccfg.Username, ccfg.Password, _ = strings.Cut(restoreUser, ":")
+	}
+
+	dataDir, walDir := restoreDirs(restoreDataDir, restoreWalDir, restoreName)
+
+	lg := GetLogger()
+	sp := snapshot.NewV3(lg)
+
+	version, err := sp.RestoreFromCluster(context.Background(), ccfg, snapshot.RestoreConfig{
+		Name:                restoreName,
+		OutputDataDir:       dataDir,
+		OutputWALDir:        walDir,
+		PeerURLs:            strings.Split(restorePeerURLs, ","),
+		InitialCluster:      restoreCluster,
+		InitialClusterToken: restoreClusterToken,
+		SkipHashCheck:       skipHashCheck,
+		SkipPrefixes:        restoreSkipPrefixes,
+		OnlyPrefixes:        restoreOnlyPrefixes,
+	})
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	if version != "" {
+		fmt.Printf("Server version %s\n", version)
+	}
+}
+
+// restoreDirs returns the data and WAL directories to restore to, defaulting
+// to the ones of the member with given name.
+func restoreDirs(dataDir, walDir, name string) (string, string) {
+	if dataDir == "" {
+		dataDir = name + ".etcd"
+	}
+	if walDir == "" {
+		walDir = datadir.ToWalDir(dataDir)
+	}
+	return dataDir, walDir
+}
+
 func initialClusterFromName(name string) string {
 	n := name
 	if name == "" {
@@ -0,0 +1,189 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdutl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	"go.etcd.io/etcd/pkg/v3/cobrautl"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/datadir"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+	"go.etcd.io/etcd/server/v3/storage/wal"
+	"go.etcd.io/etcd/server/v3/verify"
+
+	"go.uber.org/zap"
+)
+
+var (
+	walDataDir       string
+	walWALDir        string
+	walBackupDir     string
+	walTruncateIndex uint64
+)
+
+// NewWALCommand returns the cobra command for "wal".
+func NewWALCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wal <subcommand>",
+		Short: "Manages the WAL of the etcd",
+	}
+	cmd.AddCommand(NewWALTruncateCommand())
+	return cmd
+}
+
+// NewWALTruncateCommand returns the cobra command for "wal truncate".
+func NewWALTruncateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "truncate",
+		Short: "Truncates the WAL entries after an index",
+		Long: `Removes the entries after the given index from the WAL of a data directory not in use by etcd,
+so that a known bad entry is not replayed. The index cannot be below the consistent index of the backend
+nor the latest snapshot recorded in the WAL. The WAL is rewritten from its latest snapshot and verified
+against the backend before it replaces the original WAL, which is moved to the backup directory.
+`,
+		Run: walTruncateCommandFunc,
+	}
+	cmd.Flags().StringVar(&walDataDir, "data-dir", "", "Required. Truncates the WAL of a data directory not in use by etcd.")
+	cmd.Flags().StringVar(&walWALDir, "wal-dir", "", "Path to the dedicated wal directory.")
+	cmd.Flags().StringVar(&walBackupDir, "backup-dir", "", "Required. Path to a new directory the original WAL is moved to.")
+	cmd.Flags().Uint64Var(&walTruncateIndex, "index", 0, "Required. Index of the last entry kept in the WAL.")
+	cmd.MarkFlagRequired("data-dir")
+	cmd.MarkFlagRequired("backup-dir")
+	cmd.MarkFlagRequired("index")
+	cmd.MarkFlagDirname("data-dir")
+	cmd.MarkFlagDirname("wal-dir")
+	cmd.MarkFlagDirname("backup-dir")
+	return cmd
+}
+
+func walTruncateCommandFunc(cmd *cobra.Command, args []string) {
+	n, err := TruncateWAL(walDataDir, walWALDir, walBackupDir, walTruncateIndex)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError,
+			fmt.Errorf("failed to truncate the WAL of etcd data[%s] (%v)", walDataDir, err))
+	}
+	fmt.Printf("Truncated %d entries after index %d, the original WAL is in %s\n", n, walTruncateIndex, walBackupDir)
+}
+
+// TruncateWAL removes the entries after the index from the WAL of the data
+// directory, or the dedicated WAL directory if not empty, and returns the
+// number of entries removed. The original WAL is moved to the backup
+// directory, which must not exist.
+func TruncateWAL(dataDir, walDir, backupDir string, index uint64) (int, error) {
+	lg := GetLogger()
+	if walDir == "" {
+		walDir = datadir.ToWalDir(dataDir)
+	}
+	walDir = filepath.Clean(walDir)
+	if fileutil.Exist(backupDir) {
+		return 0, fmt.Errorf("backup directory %q already exists", backupDir)
+	}
+	dbPath := datadir.ToBackendFileName(dataDir)
+	if !fileutil.Exist(dbPath) {
+		return 0, fmt.Errorf("cannot find the backend %q", dbPath)
+	}
+
+	walSnaps, err := wal.ValidSnapshotEntries(lg, walDir)
+	if err != nil {
+		return 0, err
+	}
+	walsnap := walSnaps[len(walSnaps)-1]
+	// opening the WAL locks its files, failing if etcd uses them
+	w, err := wal.Open(lg, walDir, walsnap)
+	if err != nil {
+		return 0, err
+	}
+	metadata, state, ents, err := w.ReadAll()
+	w.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	be := backend.NewDefaultBackend(lg, dbPath)
+	cindex, _ := schema.ReadConsistentIndex(be.ReadTx())
+	be.Close()
+
+	lastIndex := walsnap.Index
+	if len(ents) > 0 {
+		lastIndex = ents[len(ents)-1].Index
+	}
+	switch {
+	case index < walsnap.Index:
+		return 0, fmt.Errorf("index %d is below the latest snapshot index %d of the WAL", index, walsnap.Index)
+	case index < cindex:
+		return 0, fmt.Errorf("index %d is below the consistent index %d of the backend, whose entries were applied", index, cindex)
+	case index >= lastIndex:
+		return 0, fmt.Errorf("the WAL has no entry after index %d, its last index is %d", index, lastIndex)
+	}
+	// the entries following the snapshot are contiguous
+	removed := ents[index-walsnap.Index:]
+	ents = ents[:index-walsnap.Index]
+	if state.Commit > index {
+		lg.Warn("truncating committed entries", zap.Uint64("commit-index", state.Commit), zap.Uint64("index", index))
+		state.Commit = index
+	}
+
+	// write and verify the truncated WAL next to the original one
+	newWALDir := walDir + ".truncated"
+	if err = os.RemoveAll(newWALDir); err != nil {
+		return 0, err
+	}
+	neww, err := wal.Create(lg, newWALDir, metadata)
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(newWALDir)
+	if err = neww.SaveSnapshot(walsnap); err != nil {
+		neww.Close()
+		return 0, err
+	}
+	if err = neww.Save(state, ents); err != nil {
+		neww.Close()
+		return 0, err
+	}
+	if err = neww.Close(); err != nil {
+		return 0, err
+	}
+	if err = verify.Verify(verify.Config{Logger: lg, DataDir: dataDir, WALDir: newWALDir}); err != nil {
+		return 0, fmt.Errorf("truncated WAL is not consistent with the backend: %v", err)
+	}
+
+	if err = os.Rename(walDir, backupDir); err != nil {
+		return 0, err
+	}
+	if err = os.Rename(newWALDir, walDir); err != nil {
+		return 0, fmt.Errorf("failed to move the truncated WAL, the original WAL is in %q: %v", backupDir, err)
+	}
+	pdir, err := fileutil.OpenDir(filepath.Dir(walDir))
+	if err != nil {
+		return 0, err
+	}
+	defer pdir.Close()
+	if err = fileutil.Fsync(pdir); err != nil {
+		return 0, err
+	}
+	lg.Info("truncated WAL",
+		zap.String("wal-dir", walDir),
+		zap.String("backup-dir", backupDir),
+		zap.Uint64("index", index),
+		zap.Int("removed-entries", len(removed)),
+	)
+	return len(removed), nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdutl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/datadir"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+	"go.etcd.io/etcd/server/v3/storage/wal"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTruncateWAL(t *testing.T) {
+	tests := []struct {
+		name  string
+		index uint64
+
+		wremoved int
+		werr     bool
+	}{
+		{name: "uncommitted entries", index: 8, wremoved: 2},
+		{name: "committed entries", index: 5, wremoved: 5},
+		{name: "applied entries", index: 3, werr: true},
+		{name: "no entry after index", index: 10, werr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := newTruncatedDataDir(t, 4, 7, 10)
+			backupDir := filepath.Join(t.TempDir(), "wal.bak")
+
+			removed, err := TruncateWAL(dataDir, "", backupDir, tt.index)
+			if (err != nil) != tt.werr {
+				t.Fatalf("err = %v, want error %v", err, tt.werr)
+			}
+			if tt.werr {
+				if _, err = os.Stat(backupDir); !os.IsNotExist(err) {
+					t.Errorf("backup directory exists after a failed truncation")
+				}
+				if _, ents := readWAL(t, datadir.ToWalDir(dataDir)); len(ents) != 10 {
+					t.Errorf("len(ents) = %d, want the 10 entries of the original WAL", len(ents))
+				}
+				return
+			}
+			if removed != tt.wremoved {
+				t.Errorf("removed = %d, want %d", removed, tt.wremoved)
+			}
+			st, ents := readWAL(t, datadir.ToWalDir(dataDir))
+			if last := ents[len(ents)-1].Index; last != tt.index {
+				t.Errorf("last index = %d, want %d", last, tt.index)
+			}
+			wcommit := uint64(7)
+			if tt.index < wcommit {
+				wcommit = tt.index
+			}
+			if st.Commit != wcommit {
+				t.Errorf("commit = %d, want %d", st.Commit, wcommit)
+			}
+			if _, ents = readWAL(t, backupDir); len(ents) != 10 {
+				t.Errorf("len(backup ents) = %d, want 10", len(ents))
+			}
+		})
+	}
+}
+
+// newTruncatedDataDir returns a data directory whose backend applied the
+// entries up to the consistent index, and whose WAL holds the entries up to
+// the last index, committed up to the commit index.
+func newTruncatedDataDir(t *testing.T, cindex, commit, last uint64) string {
+	lg := zaptest.NewLogger(t)
+	dataDir := t.TempDir()
+	if err := os.MkdirAll(datadir.ToSnapDir(dataDir), 0700); err != nil {
+		t.Fatal(err)
+	}
+	be := backend.NewDefaultBackend(lg, datadir.ToBackendFileName(dataDir))
+	schema.CreateMetaBucket(be.BatchTx())
+	schema.UnsafeUpdateConsistentIndex(be.BatchTx(), cindex, 1)
+	if err := be.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := wal.Create(lg, datadir.ToWalDir(dataDir), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	ents := make([]raftpb.Entry, 0, last)
+	for i := uint64(1); i <= last; i++ {
+		ents = append(ents, raftpb.Entry{Index: i, Term: 1, Data: []byte("data")})
+	}
+	if err = w.Save(raftpb.HardState{Term: 1, Commit: commit}, ents); err != nil {
+		t.Fatal(err)
+	}
+	return dataDir
+}
+
+func readWAL(t *testing.T, walDir string) (raftpb.HardState, []raftpb.Entry) {
+	w, err := wal.OpenForRead(zaptest.NewLogger(t), walDir, walpb.Snapshot{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	_, st, ents, err := w.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st, ents
+}
@@ -0,0 +1,318 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/lease/leasepb"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// AnalyzeConfig configures the analysis of a db file.
+type AnalyzeConfig struct {
+	// PrefixDepth is the number of "/"-separated segments of the prefixes
+	// the keys are grouped by, from 1 up to PrefixDepth.
+	PrefixDepth int
+	// Top is the number of prefixes of every depth, largest keys and leases
+	// with the most keys reported.
+	Top int
+}
+
+// Analysis is the keyspace statistics of a db file.
+type Analysis struct {
+	// Revision is the latest revision of the keyspace.
+	Revision int64 `json:"revision"`
+	// TotalKeys is the number of live keys.
+	TotalKeys int `json:"totalKeys"`
+	// TotalRevisions is the number of revisions of the keys stored since
+	// the last compaction, including deletions.
+	TotalRevisions int `json:"totalRevisions"`
+	// TotalSize is the size of the live keys and their values in bytes.
+	TotalSize int64 `json:"totalSize"`
+	// ValueSizes is the histogram of the value sizes of the live keys.
+	ValueSizes []HistogramBucket `json:"valueSizes"`
+	// Prefixes are the largest prefixes of every depth, by depth and
+	// descending size.
+	Prefixes []PrefixStats `json:"prefixes"`
+	// LargestKeys are the live keys with the largest key and value sizes.
+	LargestKeys []KeyStats `json:"largestKeys"`
+	// Leases is the distribution of the leases and their keys.
+	Leases LeaseStats `json:"leases"`
+}
+
+// HistogramBucket counts the observations up to UpperBound, above the
+// UpperBound of the previous bucket.
+type HistogramBucket struct {
+	UpperBound int64 `json:"upperBound"`
+	Count      int   `json:"count"`
+}
+
+// PrefixStats is the statistics of the keys under a prefix.
+type PrefixStats struct {
+	Prefix string `json:"prefix"`
+	Depth  int    `json:"depth"`
+	// Keys is the number of live keys under the prefix.
+	Keys int `json:"keys"`
+	// Size is the size of the live keys and their values in bytes.
+	Size int64 `json:"size"`
+	// Revisions is the number of revisions of the keys under the prefix
+	// stored since the last compaction, including deletions, the churn of
+	// the prefix.
+	Revisions int `json:"revisions"`
+}
+
+// KeyStats is the statistics of a live key.
+type KeyStats struct {
+	Key         string `json:"key"`
+	ValueSize   int    `json:"valueSize"`
+	ModRevision int64  `json:"modRevision"`
+	Version     int64  `json:"version"`
+	Lease       int64  `json:"lease"`
+}
+
+// LeaseStats is the distribution of the leases and their keys.
+type LeaseStats struct {
+	Leases int `json:"leases"`
+	// AttachedKeys is the number of live keys attached to a lease.
+	AttachedKeys int `json:"attachedKeys"`
+	// TTLs is the histogram of the TTLs of the leases in seconds.
+	TTLs []HistogramBucket `json:"ttls"`
+	// Largest are the leases with the most keys attached.
+	Largest []LeaseKeys `json:"largest"`
+}
+
+// LeaseKeys is the number of keys attached to a lease.
+type LeaseKeys struct {
+	ID   int64 `json:"id"`
+	TTL  int64 `json:"ttl"`
+	Keys int   `json:"keys"`
+}
+
+var (
+	valueSizeBounds = []int64{64, 256, 1024, 4 * 1024, 16 * 1024, 64 * 1024, 256 * 1024, 1024 * 1024}
+	leaseTTLBounds  = []int64{10, 60, 5 * 60, 30 * 60, 60 * 60, 24 * 60 * 60}
+)
+
+type liveKey struct {
+	kv      *mvccpb.KeyValue
+	deleted bool
+}
+
+// Analyze returns the keyspace statistics of the db file.
+func (s *v3Manager) Analyze(dbPath string, cfg AnalyzeConfig) (a Analysis, err error) {
+	if _, err = os.Stat(dbPath); err != nil {
+		return a, err
+	}
+	db, err := bolt.Open(dbPath, 0400, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return a, err
+	}
+	defer db.Close()
+
+	keys := make(map[string]*liveKey)
+	prefixes := make(map[string]*PrefixStats)
+	leaseTTLs := make(map[int64]int64)
+	if err = db.View(func(tx *bolt.Tx) error {
+		// the buckets of the key prefixes follow schema.Key, as in the store
+		buckets := [][]byte{schema.Key.Name()}
+		for i, p := range mvcc.ReadKeyPrefixBucketsFromSnapshot(tx) {
+			buckets = append(buckets, schema.KeyPrefixBucket(i, p).Name())
+		}
+		for _, name := range buckets {
+			kb := tx.Bucket(name)
+			if kb == nil {
+				return fmt.Errorf("cannot find bucket %s", name)
+			}
+			// the revisions of a key are in ascending order in its bucket,
+			// the last one of a key is its current state
+			if err := kb.ForEach(func(k, v []byte) error {
+				var kv mvccpb.KeyValue
+				if err := kv.Unmarshal(v); err != nil {
+					return fmt.Errorf("cannot unmarshal revision %x: %v", k, err)
+				}
+				if rev := bytesToRev(k).main; rev > a.Revision {
+					a.Revision = rev
+				}
+				a.TotalRevisions++
+				key := string(kv.Key)
+				for d := 1; d <= cfg.PrefixDepth; d++ {
+					if p, ok := prefixAt(key, d); ok {
+						prefixStats(prefixes, p, d).Revisions++
+					}
+				}
+				keys[key] = &liveKey{kv: &kv, deleted: isTombstone(k)}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		if lb := tx.Bucket(schema.Lease.Name()); lb != nil {
+			return lb.ForEach(func(_, v []byte) error {
+				var l leasepb.Lease
+				if err := l.Unmarshal(v); err != nil {
+					return fmt.Errorf("cannot unmarshal lease: %v", err)
+				}
+				leaseTTLs[l.ID] = l.TTL
+				return nil
+			})
+		}
+		return nil
+	}); err != nil {
+		return a, err
+	}
+
+	leaseKeys := make(map[int64]int)
+	a.ValueSizes = newHistogram(valueSizeBounds)
+	for key, lk := range keys {
+		if lk.deleted {
+			continue
+		}
+		kv := lk.kv
+		size := int64(len(kv.Key) + len(kv.Value))
+		a.TotalKeys++
+		a.TotalSize += size
+		observe(a.ValueSizes, int64(len(kv.Value)))
+		for d := 1; d <= cfg.PrefixDepth; d++ {
+			if p, ok := prefixAt(key, d); ok {
+				ps := prefixStats(prefixes, p, d)
+				ps.Keys++
+				ps.Size += size
+			}
+		}
+		a.LargestKeys = append(a.LargestKeys, KeyStats{
+			Key:         key,
+			ValueSize:   len(kv.Value),
+			ModRevision: kv.ModRevision,
+			Version:     kv.Version,
+			Lease:       kv.Lease,
+		})
+		if kv.Lease != 0 {
+			a.Leases.AttachedKeys++
+			leaseKeys[kv.Lease]++
+		}
+	}
+	sort.Slice(a.LargestKeys, func(i, j int) bool {
+		ki, kj := a.LargestKeys[i], a.LargestKeys[j]
+		if si, sj := len(ki.Key)+ki.ValueSize, len(kj.Key)+kj.ValueSize; si != sj {
+			return si > sj
+		}
+		return ki.Key < kj.Key
+	})
+	if cfg.Top > 0 && len(a.LargestKeys) > cfg.Top {
+		a.LargestKeys = a.LargestKeys[:cfg.Top]
+	}
+
+	for _, ps := range prefixes {
+		a.Prefixes = append(a.Prefixes, *ps)
+	}
+	sort.Slice(a.Prefixes, func(i, j int) bool {
+		pi, pj := a.Prefixes[i], a.Prefixes[j]
+		if pi.Depth != pj.Depth {
+			return pi.Depth < pj.Depth
+		}
+		if pi.Size != pj.Size {
+			return pi.Size > pj.Size
+		}
+		return pi.Prefix < pj.Prefix
+	})
+	var top []PrefixStats
+	n := 0
+	for i, ps := range a.Prefixes {
+		if i > 0 && ps.Depth != a.Prefixes[i-1].Depth {
+			n = 0
+		}
+		if cfg.Top <= 0 || n < cfg.Top {
+			top = append(top, ps)
+		}
+		n++
+	}
+	a.Prefixes = top
+
+	a.Leases.Leases = len(leaseTTLs)
+	a.Leases.TTLs = newHistogram(leaseTTLBounds)
+	for id, ttl := range leaseTTLs {
+		observe(a.Leases.TTLs, ttl)
+		a.Leases.Largest = append(a.Leases.Largest, LeaseKeys{ID: id, TTL: ttl, Keys: leaseKeys[id]})
+	}
+	sort.Slice(a.Leases.Largest, func(i, j int) bool {
+		li, lj := a.Leases.Largest[i], a.Leases.Largest[j]
+		if li.Keys != lj.Keys {
+			return li.Keys > lj.Keys
+		}
+		return li.ID < lj.ID
+	})
+	if cfg.Top > 0 && len(a.Leases.Largest) > cfg.Top {
+		a.Leases.Largest = a.Leases.Largest[:cfg.Top]
+	}
+	return a, nil
+}
+
+// prefixAt returns the prefix of the key up to its depth-th "/" following
+// a leading "/", or false if the key has less segments.
+func prefixAt(key string, depth int) (string, bool) {
+	i := 0
+	if strings.HasPrefix(key, "/") {
+		i = 1
+	}
+	for d := 0; d < depth; d++ {
+		j := strings.IndexByte(key[i:], '/')
+		if j < 0 {
+			return "", false
+		}
+		i += j + 1
+	}
+	return key[:i], true
+}
+
+func prefixStats(prefixes map[string]*PrefixStats, prefix string, depth int) *PrefixStats {
+	ps, ok := prefixes[prefix]
+	if !ok {
+		ps = &PrefixStats{Prefix: prefix, Depth: depth}
+		prefixes[prefix] = ps
+	}
+	return ps
+}
+
+// isTombstone returns true if the revision key marks a deletion.
+func isTombstone(k []byte) bool {
+	return len(k) == 18 && k[17] == 't'
+}
+
+// newHistogram returns the buckets of the bounds, followed by the bucket of
+// the observations above the last bound.
+func newHistogram(bounds []int64) []HistogramBucket {
+	h := make([]HistogramBucket, 0, len(bounds)+1)
+	for _, b := range bounds {
+		h = append(h, HistogramBucket{UpperBound: b})
+	}
+	return append(h, HistogramBucket{UpperBound: -1})
+}
+
+func observe(h []HistogramBucket, v int64) {
+	for i := range h {
+		if h[i].UpperBound < 0 || v <= h[i].UpperBound {
+			h[i].Count++
+			return
+		}
+	}
+}
@@ -17,34 +17,17 @@ package snapshot
 import (
 	"context"
 	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"hash/crc32"
-	"io"
 	"os"
-	"path/filepath"
-	"reflect"
 	"strings"
 
 	bolt "go.etcd.io/bbolt"
-	"go.etcd.io/etcd/api/v3/etcdserverpb"
-	"go.etcd.io/etcd/client/pkg/v3/fileutil"
-	"go.etcd.io/etcd/client/pkg/v3/types"
 	"go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/snapshot"
-	"go.etcd.io/etcd/raft/v3"
-	"go.etcd.io/etcd/raft/v3/raftpb"
-	"go.etcd.io/etcd/server/v3/config"
-	"go.etcd.io/etcd/server/v3/etcdserver"
-	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
-	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
-	"go.etcd.io/etcd/server/v3/etcdserver/api/v2store"
-	"go.etcd.io/etcd/server/v3/etcdserver/cindex"
-	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/pkg/v3/objstore"
+	"go.etcd.io/etcd/server/v3/etcdserver/restore"
 	"go.etcd.io/etcd/server/v3/storage/schema"
-	"go.etcd.io/etcd/server/v3/storage/wal"
-	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
-	"go.etcd.io/etcd/server/v3/verify"
 	"go.uber.org/zap"
 )
 
@@ -62,10 +45,24 @@ type Manager interface {
 	// Status returns the snapshot file information.
 	Status(dbPath string) (Status, error)
 
+	// Analyze returns the keyspace statistics of the db file, of a
+	// snapshot or of the data directory of a member not running.
+	Analyze(dbPath string, cfg AnalyzeConfig) (Analysis, error)
+
 	// Restore restores a new etcd data directory from given snapshot
-	// file. It returns an error if specified data directory already
-	// exists, to prevent unintended data directory overwrites.
+	// file, or from the snapshot streamed from the object in S3, GCS or
+	// Azure Blob Storage whose URL is given as SnapshotPath. It returns an
+	// error if specified data directory already exists, to prevent
+	// unintended data directory overwrites.
 	Restore(cfg RestoreConfig) error
+
+	// RestoreFromCluster restores a new etcd data directory like Restore,
+	// from a snapshot streamed from remote etcd server instead of a file,
+	// and returns server version. The snapshot is verified against its
+	// integrity hash without being saved anywhere else. Make sure to
+	// specify only one endpoint in client configuration, the SnapshotPath
+	// of restore configuration is ignored.
+	RestoreFromCluster(ctx context.Context, ccfg clientv3.Config, cfg RestoreConfig) (version string, err error)
 }
 
 // NewV3 returns a new snapshot Manager for v3.x snapshot.
@@ -75,14 +72,6 @@ func NewV3(lg *zap.Logger) Manager {
 
 type v3Manager struct {
 	lg *zap.Logger
-
-	name      string
-	srcDbPath string
-	walDir    string
-	snapDir   string
-	cl        *membership.RaftCluster
-
-	skipHashCheck bool
 }
 
 // hasChecksum returns "true" if the file size "n"
@@ -175,7 +164,8 @@ func (s *v3Manager) Status(dbPath string) (ds Status, err error) {
 
 // RestoreConfig configures snapshot restore operation.
 type RestoreConfig struct {
-	// SnapshotPath is the path of snapshot file to restore from.
+	// SnapshotPath is the path of snapshot file to restore from, or the
+	// URL of the object holding it, such as "s3://bucket/snapshot.db".
 	SnapshotPath string
 
 	// Name is the human-readable name of this member.
@@ -202,287 +192,77 @@ type RestoreConfig struct {
 	// SkipHashCheck is "true" to ignore snapshot integrity hash value
 	// (required if copied from data directory).
 	SkipHashCheck bool
+
+	// SkipPrefixes is a list of key prefixes whose keys are not restored.
+	SkipPrefixes []string
+	// OnlyPrefixes is a list of key prefixes outside of which keys are not
+	// restored. If empty, keys of any prefix are restored.
+	OnlyPrefixes []string
 }
 
 // Restore restores a new etcd data directory from given snapshot file.
 func (s *v3Manager) Restore(cfg RestoreConfig) error {
-	pURLs, err := types.NewURLs(cfg.PeerURLs)
-	if err != nil {
-		return err
-	}
-	var ics types.URLsMap
-	ics, err = types.NewURLsMap(cfg.InitialCluster)
-	if err != nil {
-		return err
-	}
-
-	srv := config.ServerConfig{
-		Logger:              s.lg,
-		Name:                cfg.Name,
-		PeerURLs:            pURLs,
-		InitialPeerURLsMap:  ics,
-		InitialClusterToken: cfg.InitialClusterToken,
-	}
-	if err = srv.VerifyBootstrap(); err != nil {
-		return err
-	}
-
-	s.cl, err = membership.NewClusterFromURLsMap(s.lg, cfg.InitialClusterToken, ics)
-	if err != nil {
-		return err
-	}
-
-	dataDir := cfg.OutputDataDir
-	if dataDir == "" {
-		dataDir = cfg.Name + ".etcd"
-	}
-	if fileutil.Exist(dataDir) && !fileutil.DirEmpty(dataDir) {
-		return fmt.Errorf("data-dir %q not empty or could not be read", dataDir)
-	}
-
-	walDir := cfg.OutputWALDir
-	if walDir == "" {
-		walDir = filepath.Join(dataDir, "member", "wal")
-	} else if fileutil.Exist(walDir) {
-		return fmt.Errorf("wal-dir %q exists", walDir)
-	}
-
-	s.name = cfg.Name
-	s.srcDbPath = cfg.SnapshotPath
-	s.walDir = walDir
-	s.snapDir = filepath.Join(dataDir, "member", "snap")
-	s.skipHashCheck = cfg.SkipHashCheck
-
-	s.lg.Info(
-		"restoring snapshot",
-		zap.String("path", s.srcDbPath),
-		zap.String("wal-dir", s.walDir),
-		zap.String("data-dir", dataDir),
-		zap.String("snap-dir", s.snapDir),
-	)
-
-	if err = s.saveDB(); err != nil {
-		return err
-	}
-	hardstate, err := s.saveWALAndSnap()
-	if err != nil {
-		return err
-	}
-
-	if err := s.updateCIndex(hardstate.Commit, hardstate.Term); err != nil {
-		return err
-	}
-
-	s.lg.Info(
-		"restored snapshot",
-		zap.String("path", s.srcDbPath),
-		zap.String("wal-dir", s.walDir),
-		zap.String("data-dir", dataDir),
-		zap.String("snap-dir", s.snapDir),
-	)
-
-	return verify.VerifyIfEnabled(verify.Config{
-		ExactIndex: true,
-		Logger:     s.lg,
-		DataDir:    dataDir,
-	})
-}
-
-func (s *v3Manager) outDbPath() string {
-	return filepath.Join(s.snapDir, "db")
-}
-
-// saveDB copies the database snapshot to the snapshot directory
-func (s *v3Manager) saveDB() error {
-	err := s.copyAndVerifyDB()
-	if err != nil {
-		return err
-	}
-
-	be := backend.NewDefaultBackend(s.lg, s.outDbPath())
-	defer be.Close()
-
-	err = schema.NewMembershipBackend(s.lg, be).TrimMembershipFromBackend()
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (s *v3Manager) copyAndVerifyDB() error {
-	srcf, ferr := os.Open(s.srcDbPath)
-	if ferr != nil {
-		return ferr
-	}
-	defer srcf.Close()
-
-	// get snapshot integrity hash
-	if _, err := srcf.Seek(-sha256.Size, io.SeekEnd); err != nil {
-		return err
-	}
-	sha := make([]byte, sha256.Size)
-	if _, err := srcf.Read(sha); err != nil {
-		return err
-	}
-	if _, err := srcf.Seek(0, io.SeekStart); err != nil {
-		return err
-	}
-
-	if err := fileutil.CreateDirAll(s.lg, s.snapDir); err != nil {
-		return err
-	}
-
-	outDbPath := s.outDbPath()
-
-	db, dberr := os.OpenFile(outDbPath, os.O_RDWR|os.O_CREATE, 0600)
-	if dberr != nil {
-		return dberr
-	}
-	dbClosed := false
-	defer func() {
-		if !dbClosed {
-			db.Close()
-			dbClosed = true
-		}
-	}()
-	if _, err := io.Copy(db, srcf); err != nil {
-		return err
-	}
-
-	// truncate away integrity hash, if any.
-	off, serr := db.Seek(0, io.SeekEnd)
-	if serr != nil {
-		return serr
-	}
-	hasHash := hasChecksum(off)
-	if hasHash {
-		if err := db.Truncate(off - sha256.Size); err != nil {
-			return err
-		}
-	}
-
-	if !hasHash && !s.skipHashCheck {
-		return fmt.Errorf("snapshot missing hash but --skip-hash-check=false")
-	}
-
-	if hasHash && !s.skipHashCheck {
-		// check for match
-		if _, err := db.Seek(0, io.SeekStart); err != nil {
-			return err
-		}
-		h := sha256.New()
-		if _, err := io.Copy(h, db); err != nil {
+	rcfg := restoreConfig(cfg)
+	if objstore.IsURL(cfg.SnapshotPath) {
+		rc, err := objstore.Download(context.Background(), cfg.SnapshotPath)
+		if err != nil {
 			return err
 		}
-		dbsha := h.Sum(nil)
-		if !reflect.DeepEqual(sha, dbsha) {
-			return fmt.Errorf("expected sha256 %v, got %v", sha, dbsha)
-		}
+		defer rc.Close()
+		s.lg.Info("fetching snapshot", zap.String("url", cfg.SnapshotPath))
+		rcfg.SnapshotPath = ""
+		rcfg.Snapshot = rc
 	}
-
-	// db hash is OK, can now modify DB so it can be part of a new cluster
-	db.Close()
-	return nil
+	return restore.Restore(s.lg, rcfg)
 }
 
-// saveWALAndSnap creates a WAL for the initial cluster
-//
-// TODO: This code ignores learners !!!
-func (s *v3Manager) saveWALAndSnap() (*raftpb.HardState, error) {
-	if err := fileutil.CreateDirAll(s.lg, s.walDir); err != nil {
-		return nil, err
-	}
-
-	// add members again to persist them to the store we create.
-	st := v2store.New(etcdserver.StoreClusterPrefix, etcdserver.StoreKeysPrefix)
-	s.cl.SetStore(st)
-	be := backend.NewDefaultBackend(s.lg, s.outDbPath())
-	defer be.Close()
-	s.cl.SetBackend(schema.NewMembershipBackend(s.lg, be))
-	for _, m := range s.cl.Members() {
-		s.cl.AddMember(m, true)
-	}
-
-	m := s.cl.MemberByName(s.name)
-	md := &etcdserverpb.Metadata{NodeID: uint64(m.ID), ClusterID: uint64(s.cl.ID())}
-	metadata, merr := md.Marshal()
-	if merr != nil {
-		return nil, merr
+// RestoreFromCluster restores a new etcd data directory from a snapshot
+// streamed from remote etcd server.
+func (s *v3Manager) RestoreFromCluster(ctx context.Context, ccfg clientv3.Config, cfg RestoreConfig) (version string, err error) {
+	ccfg.Logger = s.lg.Named("client")
+	if len(ccfg.Endpoints) != 1 {
+		return "", fmt.Errorf("snapshot must be requested to one selected node, not multiple %v", ccfg.Endpoints)
 	}
-	w, walerr := wal.Create(s.lg, s.walDir, metadata)
-	if walerr != nil {
-		return nil, walerr
+	cli, err := clientv3.New(ccfg)
+	if err != nil {
+		return "", err
 	}
-	defer w.Close()
+	defer cli.Close()
 
-	peers := make([]raft.Peer, len(s.cl.MemberIDs()))
-	for i, id := range s.cl.MemberIDs() {
-		ctx, err := json.Marshal((*s.cl).Member(id))
+	// fail fast if the member is unreachable, rather than waiting for it
+	if ccfg.DialTimeout > 0 {
+		sctx, cancel := context.WithTimeout(ctx, ccfg.DialTimeout)
+		_, err = cli.Status(sctx, ccfg.Endpoints[0])
+		cancel()
 		if err != nil {
-			return nil, err
+			return "", err
 		}
-		peers[i] = raft.Peer{ID: uint64(id), Context: ctx}
 	}
 
-	ents := make([]raftpb.Entry, len(peers))
-	nodeIDs := make([]uint64, len(peers))
-	for i, p := range peers {
-		nodeIDs[i] = p.ID
-		cc := raftpb.ConfChange{
-			Type:    raftpb.ConfChangeAddNode,
-			NodeID:  p.ID,
-			Context: p.Context,
-		}
-		d, err := cc.Marshal()
-		if err != nil {
-			return nil, err
-		}
-		ents[i] = raftpb.Entry{
-			Type:  raftpb.EntryConfChange,
-			Term:  1,
-			Index: uint64(i + 1),
-			Data:  d,
-		}
-	}
-
-	commit, term := uint64(len(ents)), uint64(1)
-	hardState := raftpb.HardState{
-		Term:   term,
-		Vote:   peers[0].ID,
-		Commit: commit,
-	}
-	if err := w.Save(hardState, ents); err != nil {
-		return nil, err
+	resp, err := cli.SnapshotWithVersion(ctx)
+	if err != nil {
+		return "", err
 	}
+	defer resp.Snapshot.Close()
+	s.lg.Info("fetching snapshot", zap.String("endpoint", ccfg.Endpoints[0]))
 
-	b, berr := st.Save()
-	if berr != nil {
-		return nil, berr
-	}
-	confState := raftpb.ConfState{
-		Voters: nodeIDs,
-	}
-	raftSnap := raftpb.Snapshot{
-		Data: b,
-		Metadata: raftpb.SnapshotMetadata{
-			Index:     commit,
-			Term:      term,
-			ConfState: confState,
-		},
-	}
-	sn := snap.New(s.lg, s.snapDir)
-	if err := sn.SaveSnap(raftSnap); err != nil {
-		return nil, err
-	}
-	snapshot := walpb.Snapshot{Index: commit, Term: term, ConfState: &confState}
-	return &hardState, w.SaveSnapshot(snapshot)
+	rcfg := restoreConfig(cfg)
+	rcfg.SnapshotPath = ""
+	rcfg.Snapshot = resp.Snapshot
+	return resp.Version, restore.Restore(s.lg, rcfg)
 }
 
-func (s *v3Manager) updateCIndex(commit uint64, term uint64) error {
-	be := backend.NewDefaultBackend(s.lg, s.outDbPath())
-	defer be.Close()
-
-	cindex.UpdateConsistentIndexForce(be.BatchTx(), commit, term)
-	return nil
+func restoreConfig(cfg RestoreConfig) restore.Config {
+	return restore.Config{
+		SnapshotPath:        cfg.SnapshotPath,
+		Name:                cfg.Name,
+		OutputDataDir:       cfg.OutputDataDir,
+		OutputWALDir:        cfg.OutputWALDir,
+		PeerURLs:            cfg.PeerURLs,
+		InitialCluster:      cfg.InitialCluster,
+		InitialClusterToken: cfg.InitialClusterToken,
+		SkipHashCheck:       cfg.SkipHashCheck,
+		SkipPrefixes:        cfg.SkipPrefixes,
+		OnlyPrefixes:        cfg.OnlyPrefixes,
+	}
 }
@@ -19,16 +19,14 @@ package expect
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
-
-	"github.com/creack/pty"
 )
 
 const DEBUG_LINES_TAIL = 40
@@ -37,7 +35,7 @@ type ExpectProcess struct {
 	cfg expectConfig
 
 	cmd  *exec.Cmd
-	fpty *os.File
+	fpty io.ReadWriteCloser
 	wg   sync.WaitGroup
 
 	mu    sync.Mutex // protects lines and err
@@ -65,7 +63,7 @@ func NewExpectWithEnv(name string, args []string, env []string, serverProcessCon
 	}
 	ep.cmd = commandFromConfig(ep.cfg)
 
-	if ep.fpty, err = pty.Start(ep.cmd); err != nil {
+	if ep.fpty, err = startProcess(ep.cmd); err != nil {
 		return nil, err
 	}
 
@@ -197,7 +195,7 @@ func (ep *ExpectProcess) close(kill bool) error {
 		return ep.err
 	}
 	if kill {
-		ep.Signal(syscall.SIGTERM)
+		terminateProcess(ep.cmd)
 	}
 
 	err := ep.cmd.Wait()
@@ -208,7 +206,7 @@ func (ep *ExpectProcess) close(kill bool) error {
 		if !kill && strings.Contains(err.Error(), "exit status") {
 			// non-zero exit code
 			err = nil
-		} else if kill && strings.Contains(err.Error(), "signal:") {
+		} else if kill && isTerminatedErr(err) {
 			err = nil
 		}
 	}
@@ -228,6 +226,10 @@ func (ep *ExpectProcess) ProcessError() error {
 		// `/dev/ptmx: input/output error` when process just exits.
 		return nil
 	}
+	if errors.Is(ep.err, io.EOF) {
+		// the output pipe of the process closed on Windows
+		return nil
+	}
 	return ep.err
 }
 
@@ -0,0 +1,43 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package expect
+
+import (
+	"io"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+// startProcess starts the command attached to a pseudo terminal, through
+// which its output is read and its input is written.
+func startProcess(cmd *exec.Cmd) (io.ReadWriteCloser, error) {
+	return pty.Start(cmd)
+}
+
+func terminateProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Signal(syscall.SIGTERM)
+}
+
+// isTerminatedErr returns true if err is the error of waiting for a process
+// terminated by terminateProcess.
+func isTerminatedErr(err error) bool {
+	return strings.Contains(err.Error(), "signal:")
+}
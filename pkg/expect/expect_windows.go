@@ -0,0 +1,74 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package expect
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// processPipe reads the output of a process and writes to its input.
+type processPipe struct {
+	io.ReadCloser
+	stdin io.WriteCloser
+}
+
+func (p *processPipe) Write(b []byte) (int, error) { return p.stdin.Write(b) }
+
+func (p *processPipe) Close() error {
+	p.stdin.Close()
+	return p.ReadCloser.Close()
+}
+
+// startProcess starts the command with pipes for its input and its combined
+// output, since pseudo terminals are not supported on Windows.
+func startProcess(cmd *exec.Cmd) (io.ReadWriteCloser, error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout, cmd.Stderr = pw, pw
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		pr.Close()
+		pw.Close()
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		pr.Close()
+		pw.Close()
+		return nil, err
+	}
+	// the process holds its own handle of the write end, so reads end once
+	// it exits.
+	pw.Close()
+	return &processPipe{ReadCloser: pr, stdin: stdin}, nil
+}
+
+// terminateProcess kills the process, since Windows has no SIGTERM.
+func terminateProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// isTerminatedErr returns true if err is the error of waiting for a process
+// terminated by terminateProcess.
+func isTerminatedErr(err error) bool {
+	return strings.Contains(err.Error(), "exit status")
+}
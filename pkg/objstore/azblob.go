@@ -0,0 +1,221 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const azureAPIVersion = "2020-10-02"
+
+type azureStore struct {
+	account string
+	// key is the decoded shared key of the account, nil if requests are
+	// authorized by sasToken instead.
+	key      []byte
+	sasToken string
+	blobURL  url.URL
+}
+
+func newAzureStore(container, blob string) (*azureStore, error) {
+	s := &azureStore{
+		account:  os.Getenv("AZURE_STORAGE_ACCOUNT"),
+		sasToken: strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?"),
+	}
+	if s.account == "" {
+		return nil, fmt.Errorf("azblob: AZURE_STORAGE_ACCOUNT must be set")
+	}
+	if key := os.Getenv("AZURE_STORAGE_KEY"); key != "" {
+		var err error
+		if s.key, err = base64.StdEncoding.DecodeString(key); err != nil {
+			return nil, fmt.Errorf("azblob: invalid AZURE_STORAGE_KEY: %v", err)
+		}
+	} else if s.sasToken == "" {
+		return nil, fmt.Errorf("azblob: AZURE_STORAGE_KEY or AZURE_STORAGE_SAS_TOKEN must be set")
+	}
+
+	ep := os.Getenv("AZURE_STORAGE_ENDPOINT")
+	if ep == "" {
+		ep = "https://" + s.account + ".blob.core.windows.net"
+	}
+	u, err := url.Parse(ep)
+	if err != nil {
+		return nil, fmt.Errorf("azblob: invalid AZURE_STORAGE_ENDPOINT: %v", err)
+	}
+	s.blobURL = url.URL{Scheme: u.Scheme, Host: u.Host, Path: strings.TrimSuffix(u.Path, "/") + "/" + container + "/" + blob}
+	return s, nil
+}
+
+func (s *azureStore) newRequest(ctx context.Context, method string, query url.Values, body []byte) (*http.Request, error) {
+	u := s.blobURL
+	u.RawQuery = query.Encode()
+	if s.key == nil {
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += s.sasToken
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	if len(body) > 0 {
+		sum := md5.Sum(body)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	return req, nil
+}
+
+// do authorizes req with the shared key of the account if it has one, and
+// sends it.
+func (s *azureStore) do(req *http.Request, expected ...int) (*http.Response, error) {
+	if s.key != nil {
+		s.sign(req)
+	}
+	return do(req, expected...)
+}
+
+// sign authorizes req with the shared key of the account.
+func (s *azureStore) sign(req *http.Request) {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+	var msHeaders []string
+	for k, vs := range req.Header {
+		if k = strings.ToLower(k); strings.HasPrefix(k, "x-ms-") {
+			msHeaders = append(msHeaders, k+":"+strings.Join(vs, ","))
+		}
+	}
+	sort.Strings(msHeaders)
+
+	resource := "/" + s.account + req.URL.EscapedPath()
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		vs := append([]string(nil), query[k]...)
+		sort.Strings(vs)
+		resource += "\n" + strings.ToLower(k) + ":" + strings.Join(vs, ",")
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, superseded by x-ms-date
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		strings.Join(msHeaders, "\n"),
+		resource,
+	}, "\n")
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(stringToSign))
+	req.Header.Set("Authorization", "SharedKey "+s.account+":"+base64.StdEncoding.EncodeToString(h.Sum(nil)))
+}
+
+func (s *azureStore) initUpload(ctx context.Context) (upload, error) {
+	return &azureUpload{s: s}, nil
+}
+
+func (s *azureStore) download(ctx context.Context) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// azureUpload uploads the parts of a block blob as blocks, committed
+// together by a block list.
+type azureUpload struct {
+	s        *azureStore
+	blockIDs []string
+}
+
+func (u *azureUpload) putPart(ctx context.Context, part []byte, off int64, last bool) error {
+	if len(part) == 0 {
+		// an empty blob has no blocks
+		return nil
+	}
+	// the IDs of the blocks of a blob must all have the same length
+	id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%08d", len(u.blockIDs))))
+	req, err := u.s.newRequest(ctx, http.MethodPut, url.Values{"comp": {"block"}, "blockid": {id}}, part)
+	if err != nil {
+		return err
+	}
+	resp, err := u.s.do(req, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	u.blockIDs = append(u.blockIDs, id)
+	return nil
+}
+
+func (u *azureUpload) complete(ctx context.Context, sum []byte) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"BlockList"`
+		Latest  []string `xml:"Latest"`
+	}{Latest: u.blockIDs})
+	if err != nil {
+		return err
+	}
+	req, err := u.s.newRequest(ctx, http.MethodPut, url.Values{"comp": {"blocklist"}}, append([]byte(xml.Header), body...))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-ms-blob-content-md5", base64.StdEncoding.EncodeToString(sum))
+	resp, err := u.s.do(req, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// abort does nothing, since Azure discards the blocks left uncommitted
+// after a week.
+func (u *azureUpload) abort(ctx context.Context) {}
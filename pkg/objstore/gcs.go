@@ -0,0 +1,166 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// gcsStatusResumeIncomplete is the status of the responses to the chunks
+// of a resumable upload but the last one.
+const gcsStatusResumeIncomplete = 308
+
+type gcsStore struct {
+	endpoint string
+	token    string
+	bucket   string
+	object   string
+}
+
+func newGCSStore(bucket, object string) (*gcsStore, error) {
+	s := &gcsStore{
+		endpoint: "https://storage.googleapis.com",
+		token:    os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+		bucket:   bucket,
+		object:   object,
+	}
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		s.endpoint = strings.TrimSuffix(host, "/")
+		if !strings.Contains(s.endpoint, "://") {
+			s.endpoint = "http://" + s.endpoint
+		}
+	} else if s.token == "" {
+		return nil, fmt.Errorf("gs: GOOGLE_OAUTH_ACCESS_TOKEN must be set")
+	}
+	return s, nil
+}
+
+func (s *gcsStore) newRequest(ctx context.Context, method, rawURL string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return req, nil
+}
+
+func (s *gcsStore) initUpload(ctx context.Context) (upload, error) {
+	q := url.Values{"uploadType": {"resumable"}, "name": {s.object}}
+	req, err := s.newRequest(ctx, http.MethodPost, s.endpoint+"/upload/storage/v1/b/"+url.PathEscape(s.bucket)+"/o?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Upload-Content-Type", "application/octet-stream")
+	resp, err := do(req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	session := resp.Header.Get("Location")
+	if session == "" {
+		return nil, fmt.Errorf("gs: resumable upload returned no session URI")
+	}
+	return &gcsUpload{s: s, session: session}, nil
+}
+
+func (s *gcsStore) download(ctx context.Context) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, s.endpoint+"/storage/v1/b/"+url.PathEscape(s.bucket)+"/o/"+url.PathEscape(s.object)+"?alt=media", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := do(req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// gcsUpload is a resumable upload, whose chunks are sent to its session
+// URI.
+type gcsUpload struct {
+	s       *gcsStore
+	session string
+	// md5Hash is the base64 encoded md5 digest of the uploaded object, as
+	// computed by GCS.
+	md5Hash string
+}
+
+func (u *gcsUpload) putPart(ctx context.Context, part []byte, off int64, last bool) error {
+	req, err := u.s.newRequest(ctx, http.MethodPut, u.session, part)
+	if err != nil {
+		return err
+	}
+	end := off + int64(len(part))
+	switch {
+	case !last:
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/*", off, end-1))
+	case len(part) == 0:
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", end))
+	default:
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", off, end-1, end))
+	}
+	if !last {
+		resp, err := do(req, gcsStatusResumeIncomplete)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+	resp, err := do(req, http.StatusOK, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var obj struct {
+		MD5Hash string `json:"md5Hash"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&obj); err != nil {
+		return fmt.Errorf("gs: cannot decode uploaded object: %v", err)
+	}
+	u.md5Hash = obj.MD5Hash
+	return nil
+}
+
+// complete checks the digest of the object, which GCS makes visible with
+// its last chunk.
+func (u *gcsUpload) complete(ctx context.Context, sum []byte) error {
+	if want := base64.StdEncoding.EncodeToString(sum); u.md5Hash != want {
+		return fmt.Errorf("gs: md5 digest of uploaded object %q mismatch, want %q", u.md5Hash, want)
+	}
+	return nil
+}
+
+func (u *gcsUpload) abort(ctx context.Context) {
+	req, err := u.s.newRequest(ctx, http.MethodDelete, u.session, nil)
+	if err != nil {
+		return
+	}
+	// a cancelled upload is answered with status 499
+	if resp, err := httpClient.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
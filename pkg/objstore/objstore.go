@@ -0,0 +1,177 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package objstore streams objects to and from Amazon S3, Google Cloud
+// Storage and Azure Blob Storage, addressed by URLs of the form
+// "s3://bucket/key", "gs://bucket/object" and "azblob://container/blob".
+//
+// The credentials and endpoints of the object stores are read from the
+// environment:
+//
+//	s3://     AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN,
+//	          AWS_REGION (or AWS_DEFAULT_REGION, "us-east-1" by default) and
+//	          AWS_ENDPOINT_URL for S3 compatible stores, addressed path-style.
+//	gs://     GOOGLE_OAUTH_ACCESS_TOKEN and STORAGE_EMULATOR_HOST.
+//	azblob:// AZURE_STORAGE_ACCOUNT, AZURE_STORAGE_KEY or
+//	          AZURE_STORAGE_SAS_TOKEN, and AZURE_STORAGE_ENDPOINT.
+package objstore
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// partSize is the size of the parts objects are uploaded in, so that an
+// upload holds a single part in memory whatever the size of the object.
+// It is a multiple of the 256KiB GCS requires for the chunks of uploads, and
+// at least the 5MiB S3 requires for the parts of multipart uploads.
+var partSize = 16 * 1024 * 1024
+
+var httpClient = &http.Client{Transport: http.DefaultTransport}
+
+// IsURL returns true if s is the URL of an object in one of the supported
+// object stores, rather than a local path.
+func IsURL(s string) bool {
+	for _, scheme := range []string{"s3://", "gs://", "azblob://"} {
+		if strings.HasPrefix(s, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// store uploads and downloads a single object.
+type store interface {
+	// initUpload starts an upload of the object.
+	initUpload(ctx context.Context) (upload, error)
+	download(ctx context.Context) (io.ReadCloser, error)
+}
+
+// upload stores the parts of an object, which only becomes visible once
+// the upload is completed.
+type upload interface {
+	// putPart uploads the given part of the object, starting at offset off.
+	// The part is the last one if last is true.
+	putPart(ctx context.Context, part []byte, off int64, last bool) error
+	// complete makes the object visible, given the md5 digest of its
+	// content.
+	complete(ctx context.Context, sum []byte) error
+	// abort discards the parts uploaded so far.
+	abort(ctx context.Context)
+}
+
+func parse(rawURL string) (store, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("%q does not name an object, expected %s://<bucket>/<key>", rawURL, u.Scheme)
+	}
+	switch u.Scheme {
+	case "s3":
+		return newS3Store(bucket, key)
+	case "gs":
+		return newGCSStore(bucket, key)
+	case "azblob":
+		return newAzureStore(bucket, key)
+	}
+	return nil, fmt.Errorf("unsupported object store %q", u.Scheme)
+}
+
+// Upload stores the content read from r to the object at rawURL, streaming
+// it in parts. The object is only created, or replaced, once r is read to
+// its end: if reading r fails, the upload is aborted and the error returned.
+func Upload(ctx context.Context, rawURL string, r io.Reader) (n int64, err error) {
+	s, err := parse(rawURL)
+	if err != nil {
+		return 0, err
+	}
+	up, err := s.initUpload(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			up.abort(context.Background())
+		}
+	}()
+
+	br := bufio.NewReader(r)
+	h := md5.New()
+	buf := make([]byte, partSize)
+	for {
+		m, rerr := io.ReadFull(br, buf)
+		last := false
+		switch {
+		case rerr == io.EOF || rerr == io.ErrUnexpectedEOF:
+			last = true
+		case rerr != nil:
+			return n, rerr
+		default:
+			// look ahead so that the last part is never empty
+			if _, perr := br.Peek(1); perr == io.EOF {
+				last = true
+			} else if perr != nil {
+				return n, perr
+			}
+		}
+		if err = up.putPart(ctx, buf[:m], n, last); err != nil {
+			return n, err
+		}
+		h.Write(buf[:m])
+		n += int64(m)
+		if last {
+			break
+		}
+	}
+	return n, up.complete(ctx, h.Sum(nil))
+}
+
+// Download returns the content of the object at rawURL, streamed as it is
+// read.
+func Download(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	s, err := parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return s.download(ctx)
+}
+
+// do sends req and returns its response if its status is one of the
+// expected ones, or an error holding the body of the response otherwise.
+func do(req *http.Request, expected ...int) (*http.Response, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	// the query may hold credentials, such as SAS tokens
+	u := *req.URL
+	u.RawQuery = ""
+	return nil, fmt.Errorf("%s %s: %s: %s", req.Method, u.String(), resp.Status, strings.TrimSpace(string(b)))
+}
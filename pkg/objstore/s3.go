@@ -0,0 +1,292 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emptySHA256 is the hex encoded sha256 digest of an empty payload.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+type s3Store struct {
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	// objURL is the URL of the object, addressed virtual-hosted style on AWS
+	// and path-style on custom endpoints.
+	objURL url.URL
+}
+
+func newS3Store(bucket, key string) (*s3Store, error) {
+	s := &s3Store{
+		region:       os.Getenv("AWS_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, fmt.Errorf("s3: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	if s.region == "" {
+		s.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+
+	path := "/" + key
+	if ep := os.Getenv("AWS_ENDPOINT_URL"); ep != "" {
+		u, err := url.Parse(ep)
+		if err != nil {
+			return nil, fmt.Errorf("s3: invalid AWS_ENDPOINT_URL: %v", err)
+		}
+		s.objURL = url.URL{Scheme: u.Scheme, Host: u.Host}
+		path = strings.TrimSuffix(u.Path, "/") + "/" + bucket + path
+	} else {
+		s.objURL = url.URL{Scheme: "https", Host: bucket + ".s3." + s.region + ".amazonaws.com"}
+	}
+	s.objURL.Path = path
+	s.objURL.RawPath = awsEscapePath(path)
+	return s, nil
+}
+
+func (s *s3Store) newRequest(ctx context.Context, method string, query url.Values, body []byte) (*http.Request, error) {
+	u := s.objURL
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := emptySHA256
+	if len(body) > 0 {
+		sum := md5.Sum(body)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+		h := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(h[:])
+	}
+	s.sign(req, payloadHash, time.Now())
+	return req, nil
+}
+
+// sign signs req with AWS Signature Version 4, covering all its headers.
+func (s *s3Store) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, vs := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(vs, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			params = append(params, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	date := amzDate[:8]
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	crh := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(crh[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	for _, k := range []string{s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, k)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsEscape percent-encodes all the bytes of s but the unreserved characters,
+// as AWS Signature Version 4 expects.
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func awsEscapePath(path string) string {
+	segs := strings.Split(path, "/")
+	for i := range segs {
+		segs[i] = awsEscape(segs[i])
+	}
+	return strings.Join(segs, "/")
+}
+
+func (s *s3Store) initUpload(ctx context.Context) (upload, error) {
+	req, err := s.newRequest(ctx, http.MethodPost, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := do(req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err = xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("s3: cannot decode multipart upload: %v", err)
+	}
+	return &s3Upload{s: s, id: result.UploadID}, nil
+}
+
+func (s *s3Store) download(ctx context.Context) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := do(req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+type s3Part struct {
+	PartNumber int
+	ETag       string
+}
+
+// s3Upload is a multipart upload.
+type s3Upload struct {
+	s     *s3Store
+	id    string
+	parts []s3Part
+}
+
+func (u *s3Upload) putPart(ctx context.Context, part []byte, off int64, last bool) error {
+	n := len(u.parts) + 1
+	req, err := u.s.newRequest(ctx, http.MethodPut, url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {u.id}}, part)
+	if err != nil {
+		return err
+	}
+	resp, err := do(req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	u.parts = append(u.parts, s3Part{PartNumber: n, ETag: resp.Header.Get("ETag")})
+	return nil
+}
+
+func (u *s3Upload) complete(ctx context.Context, sum []byte) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []s3Part `xml:"Part"`
+	}{Parts: u.parts})
+	if err != nil {
+		return err
+	}
+	req, err := u.s.newRequest(ctx, http.MethodPost, url.Values{"uploadId": {u.id}}, body)
+	if err != nil {
+		return err
+	}
+	resp, err := do(req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// a completion failing after its response started is reported in an
+	// error document with status 200
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		XMLName xml.Name
+		Code    string
+		Message string
+	}
+	if err = xml.Unmarshal(b, &result); err != nil {
+		return fmt.Errorf("s3: cannot decode multipart upload completion: %v", err)
+	}
+	if result.XMLName.Local == "Error" {
+		return fmt.Errorf("s3: cannot complete multipart upload: %s: %s", result.Code, result.Message)
+	}
+	return nil
+}
+
+func (u *s3Upload) abort(ctx context.Context) {
+	req, err := u.s.newRequest(ctx, http.MethodDelete, url.Values{"uploadId": {u.id}}, nil)
+	if err != nil {
+		return
+	}
+	if resp, err := do(req, http.StatusNoContent); err == nil {
+		resp.Body.Close()
+	}
+}
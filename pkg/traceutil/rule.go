@@ -0,0 +1,74 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceutil
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	// OpRead is the operation type of requests that do not modify the keyspace.
+	OpRead = "read"
+	// OpWrite is the operation type of requests that modify the keyspace.
+	OpWrite = "write"
+)
+
+// Rule selects requests that are always traced, whatever their duration
+// or the sampling rate.
+type Rule struct {
+	// Op restricts the rule to OpRead or OpWrite requests. Empty matches both.
+	Op string
+	// Prefix is the key prefix of the selected requests. Empty matches every key.
+	Prefix []byte
+}
+
+// ParseRules parses rules of the form "[read:|write:]<prefix>", for example
+// "write:/critical/" or "/tenant-a/". Empty strings are ignored.
+func ParseRules(ss []string) []Rule {
+	var rules []Rule
+	for _, s := range ss {
+		if s == "" {
+			continue
+		}
+		var r Rule
+		switch {
+		case strings.HasPrefix(s, OpRead+":"):
+			r.Op, s = OpRead, strings.TrimPrefix(s, OpRead+":")
+		case strings.HasPrefix(s, OpWrite+":"):
+			r.Op, s = OpWrite, strings.TrimPrefix(s, OpWrite+":")
+		}
+		r.Prefix = []byte(s)
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// Match returns true if the rule selects an operation of type op on key.
+func (r Rule) Match(op string, key []byte) bool {
+	return (r.Op == "" || r.Op == op) && bytes.HasPrefix(key, r.Prefix)
+}
+
+// MatchAny returns true if any rule selects an operation of type op on any of keys.
+func MatchAny(rules []Rule, op string, keys ...[]byte) bool {
+	for _, r := range rules {
+		for _, key := range keys {
+			if r.Match(op, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	rules := ParseRules([]string{"write:/critical/", "read:", "/tenant-a/", "", "delete:/x"})
+	want := []Rule{
+		{Op: OpWrite, Prefix: []byte("/critical/")},
+		{Op: OpRead, Prefix: []byte("")},
+		{Prefix: []byte("/tenant-a/")},
+		{Prefix: []byte("delete:/x")},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("rules = %+v, want %+v", rules, want)
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	rules := ParseRules([]string{"write:/critical/", "/tenant-a/"})
+	tests := []struct {
+		op   string
+		keys []string
+		want bool
+	}{
+		{OpWrite, []string{"/critical/foo"}, true},
+		{OpRead, []string{"/critical/foo"}, false},
+		{OpRead, []string{"/tenant-a/foo"}, true},
+		{OpWrite, []string{"/tenant-a/foo"}, true},
+		{OpWrite, []string{"/other", "/critical/foo"}, true},
+		{OpWrite, []string{"/other"}, false},
+		{OpWrite, nil, false},
+	}
+	for i, tt := range tests {
+		var keys [][]byte
+		for _, k := range tt.keys {
+			keys = append(keys, []byte(k))
+		}
+		if got := MatchAny(rules, tt.op, keys...); got != tt.want {
+			t.Errorf("#%d: MatchAny(%s, %v) = %v, want %v", i, tt.op, tt.keys, got, tt.want)
+		}
+	}
+}
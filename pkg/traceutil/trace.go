@@ -28,6 +28,9 @@ import (
 const (
 	TraceKey     = "trace"
 	StartTimeKey = "startTime"
+	// ForceKey marks requests selected by a Rule, which are traced
+	// whatever their duration.
+	ForceKey = "forceTrace"
 )
 
 // Field is a kv pair to record additional details of the trace.
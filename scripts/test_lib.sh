@@ -164,7 +164,7 @@ function run_for_module {
 }
 
 function module_dirs() {
-  echo "api pkg raft client/pkg client/v2 client/v3 server etcdutl etcdctl tests ."
+  echo "api pkg raft client/pkg client/v2 client/v3 client/httpclient server etcdutl etcdctl tests ."
 }
 
 # maybe_run [cmd...] runs given command depending on the DRY_RUN flag.
@@ -184,6 +184,7 @@ function modules() {
     "${ROOT_MODULE}/client/pkg/v3"
     "${ROOT_MODULE}/client/v2"
     "${ROOT_MODULE}/client/v3"
+    "${ROOT_MODULE}/client/httpclient/v3"
     "${ROOT_MODULE}/server/v3"
     "${ROOT_MODULE}/etcdutl/v3"
     "${ROOT_MODULE}/etcdctl/v3"
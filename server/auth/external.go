@@ -0,0 +1,109 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ExternalTokenProvider verifies the bearer tokens issued by an identity
+// provider outside etcd, whose users are authenticated without being users
+// of the auth store.
+type ExternalTokenProvider interface {
+	// Verify returns the name of the user token is issued to and the etcd
+	// roles granted to the user, or an error if token is not valid.
+	Verify(ctx context.Context, token string) (username string, roles []string, err error)
+}
+
+// NewExternalTokenProviderFunc creates an ExternalTokenProvider from the
+// options following its token type in the --auth-token flag.
+type NewExternalTokenProviderFunc func(lg *zap.Logger, opts map[string]string) (ExternalTokenProvider, error)
+
+var (
+	externalTokenProvidersMu sync.RWMutex
+	externalTokenProviders   = make(map[string]NewExternalTokenProviderFunc)
+)
+
+// RegisterExternalTokenProvider makes the external token provider created by
+// newProvider available as the token type tokenType of the --auth-token flag.
+// It panics if the token type is already registered.
+func RegisterExternalTokenProvider(tokenType string, newProvider NewExternalTokenProviderFunc) {
+	externalTokenProvidersMu.Lock()
+	defer externalTokenProvidersMu.Unlock()
+	switch tokenType {
+	case "", tokenTypeSimple, tokenTypeJWT:
+		panic(fmt.Sprintf("auth: token type %q is reserved", tokenType))
+	}
+	if _, ok := externalTokenProviders[tokenType]; ok {
+		panic(fmt.Sprintf("auth: token type %q is already registered", tokenType))
+	}
+	externalTokenProviders[tokenType] = newProvider
+}
+
+func getExternalTokenProvider(tokenType string) (NewExternalTokenProviderFunc, bool) {
+	externalTokenProvidersMu.RLock()
+	defer externalTokenProvidersMu.RUnlock()
+	newProvider, ok := externalTokenProviders[tokenType]
+	return newProvider, ok
+}
+
+// tokenExternal authenticates the users of an external identity provider by
+// their bearer tokens, and the users of the auth store, who authenticate
+// with their passwords, by simple tokens.
+type tokenExternal struct {
+	*tokenSimple
+	ext ExternalTokenProvider
+}
+
+func (t *tokenExternal) info(ctx context.Context, token string, rev uint64) (*AuthInfo, bool) {
+	token = strings.TrimPrefix(token, "Bearer ")
+	if strings.Count(token, ".") == 1 {
+		// simple tokens are made of a random prefix and an index
+		return t.tokenSimple.info(ctx, token, rev)
+	}
+
+	username, roles, err := t.ext.Verify(ctx, token)
+	if err != nil {
+		t.lg.Warn("failed to verify an external token", zap.Error(err))
+		return nil, false
+	}
+	if username == "" || len(roles) == 0 {
+		// the requests of an external user without roles would be taken for
+		// the requests of the user of the auth store with the same name
+		t.lg.Warn("external token grants no role", zap.String("user-name", username))
+		return nil, false
+	}
+	roles = append([]string(nil), roles...)
+	sort.Strings(roles)
+	return &AuthInfo{Username: username, Revision: rev, Roles: roles}, true
+}
+
+func newTokenProviderExternal(
+	lg *zap.Logger,
+	ext ExternalTokenProvider,
+	indexWaiter func(uint64) <-chan struct{},
+	TokenTTL time.Duration) *tokenExternal {
+	return &tokenExternal{
+		tokenSimple: newTokenProviderSimple(lg, indexWaiter, TokenTTL),
+		ext:         ext,
+	}
+}
@@ -36,8 +36,47 @@ var (
 	// overridden by auth store initialization
 	reportCurrentAuthRevMu sync.RWMutex
 	reportCurrentAuthRev   = func() float64 { return 0 }
+
+	tokenValidations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "auth",
+		Name:      "token_validations_total",
+		Help:      "The total number of auth token validations by result.",
+	},
+		[]string{"result"},
+	)
+	rangePermCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "auth",
+		Name:      "range_perm_cache_lookups_total",
+		Help:      "The total number of range permission cache lookups by result: hit, or user_not_found if the user has no cached permissions.",
+	},
+		[]string{"result"},
+	)
+	bcryptDurationSec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "auth",
+		Name:      "bcrypt_duration_seconds",
+		Help:      "The latency distributions of bcrypt password hashing and comparison.",
+
+		// lowest bucket start of upper bound 0.001 sec (1 ms) with factor 2
+		// highest bucket start of 0.001 sec * 2^12 == 4.096 sec
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 13),
+	},
+		[]string{"operation"},
+	)
+	simpleTokens = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "auth",
+		Name:      "simple_tokens",
+		Help:      "The number of simple tokens cached.",
+	})
 )
 
 func init() {
 	prometheus.MustRegister(currentAuthRevision)
+	prometheus.MustRegister(tokenValidations)
+	prometheus.MustRegister(rangePermCacheLookups)
+	prometheus.MustRegister(bcryptDurationSec)
+	prometheus.MustRegister(simpleTokens)
 }
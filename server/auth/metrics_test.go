@@ -0,0 +1,60 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+func TestAuthMetrics(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	valid := testutil.ToFloat64(tokenValidations.WithLabelValues("valid"))
+	invalid := testutil.ToFloat64(tokenValidations.WithLabelValues("invalid"))
+	hits := testutil.ToFloat64(rangePermCacheLookups.WithLabelValues("hit"))
+	notFound := testutil.ToFloat64(rangePermCacheLookups.WithLabelValues("user_not_found"))
+
+	ctx := context.WithValue(context.WithValue(context.TODO(), AuthenticateParamIndex{}, uint64(1)), AuthenticateParamSimpleTokenPrefix{}, "dummy")
+	resp, err := as.Authenticate(ctx, "foo", "bar")
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(simpleTokens))
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.New(map[string]string{rpctypes.TokenFieldNameGRPC: resp.Token}))
+	_, err = as.AuthInfoFromCtx(ctx)
+	require.NoError(t, err)
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.New(map[string]string{rpctypes.TokenFieldNameGRPC: "Invalid Token"}))
+	_, err = as.AuthInfoFromCtx(ctx)
+	assert.Equal(t, ErrInvalidAuthToken, err)
+	assert.Equal(t, valid+1, testutil.ToFloat64(tokenValidations.WithLabelValues("valid")))
+	assert.Equal(t, invalid+1, testutil.ToFloat64(tokenValidations.WithLabelValues("invalid")))
+
+	as.isRangeOpPermitted("foo", []byte("a"), nil, authpb.READ)
+	as.isRangeOpPermitted("missing", []byte("a"), nil, authpb.READ)
+	assert.Equal(t, hits+1, testutil.ToFloat64(rangePermCacheLookups.WithLabelValues("hit")))
+	assert.Equal(t, notFound+1, testutil.ToFloat64(rangePermCacheLookups.WithLabelValues("user_not_found")))
+
+	as.tokenProvider.invalidateUser("foo")
+	assert.Equal(t, float64(0), testutil.ToFloat64(simpleTokens))
+}
@@ -0,0 +1,373 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	tokenTypeOIDC = "oidc"
+
+	optOIDCIssuer         = "issuer"
+	optOIDCClientID       = "client-id"
+	optOIDCCAFile         = "ca-file"
+	optOIDCUsernameClaim  = "username-claim"
+	optOIDCUsernamePrefix = "username-prefix"
+	optOIDCRolesClaim     = "roles-claim"
+	optOIDCRoleMap        = "role-map"
+)
+
+var (
+	// oidcKeysRefreshInterval is the minimum time between two fetches of the
+	// signing keys of the issuer, which are fetched again on tokens signed
+	// by unknown keys.
+	oidcKeysRefreshInterval = 10 * time.Second
+	// oidcRequestTimeout bounds the requests to the issuer.
+	oidcRequestTimeout = 5 * time.Second
+
+	oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+)
+
+func init() {
+	RegisterExternalTokenProvider(tokenTypeOIDC, newOIDCTokenProvider)
+}
+
+type oidcOptions struct {
+	Issuer   string
+	ClientID string
+	CAFile   string
+	// UsernameClaim is the claim holding the name of the user, prefixed by
+	// UsernamePrefix to tell it from the users of the auth store.
+	UsernameClaim  string
+	UsernamePrefix string
+	// RolesClaim is the claim holding the groups of the user, a string or an
+	// array of strings, which are mapped to etcd roles by RoleMap. They are
+	// the names of the roles of the user if RoleMap is nil, except for the
+	// root role, which is only granted by an explicit mapping.
+	RolesClaim string
+	RoleMap    map[string][]string
+}
+
+// Parse loads the options from optMap, setting defaults where they are not
+// specified. The mappings of role-map are separated by ';', each mapping a
+// group to a role as 'group:role'.
+func (opts *oidcOptions) Parse(optMap map[string]string) error {
+	opts.Issuer = strings.TrimSuffix(optMap[optOIDCIssuer], "/")
+	if opts.Issuer == "" {
+		return fmt.Errorf("oidc: %s must be set", optOIDCIssuer)
+	}
+	opts.ClientID = optMap[optOIDCClientID]
+	if opts.ClientID == "" {
+		return fmt.Errorf("oidc: %s must be set", optOIDCClientID)
+	}
+	opts.CAFile = optMap[optOIDCCAFile]
+
+	opts.UsernameClaim = "sub"
+	if c, ok := optMap[optOIDCUsernameClaim]; ok {
+		opts.UsernameClaim = c
+	}
+	opts.UsernamePrefix = "oidc:"
+	if p, ok := optMap[optOIDCUsernamePrefix]; ok {
+		opts.UsernamePrefix = p
+	}
+	opts.RolesClaim = "groups"
+	if c, ok := optMap[optOIDCRolesClaim]; ok {
+		opts.RolesClaim = c
+	}
+
+	if m := optMap[optOIDCRoleMap]; m != "" {
+		opts.RoleMap = make(map[string][]string)
+		for _, mapping := range strings.Split(m, ";") {
+			group, role, ok := strings.Cut(mapping, ":")
+			if !ok || group == "" || role == "" {
+				return fmt.Errorf("oidc: invalid %s mapping %q", optOIDCRoleMap, mapping)
+			}
+			opts.RoleMap[group] = append(opts.RoleMap[group], role)
+		}
+	}
+	return nil
+}
+
+// tokenOIDC verifies the ID tokens issued by an OpenID Connect provider,
+// with the signing keys published by the provider.
+type tokenOIDC struct {
+	lg     *zap.Logger
+	opts   oidcOptions
+	client *http.Client
+
+	// mu serializes the fetches of the signing keys.
+	mu          sync.Mutex
+	jwksURI     string
+	keys        map[string]interface{}
+	lastFetched time.Time
+}
+
+func newOIDCTokenProvider(lg *zap.Logger, optMap map[string]string) (ExternalTokenProvider, error) {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	var opts oidcOptions
+	if err := opts.Parse(optMap); err != nil {
+		lg.Error("problem loading OIDC options", zap.Error(err))
+		return nil, ErrInvalidAuthOpts
+	}
+
+	t := &tokenOIDC{lg: lg, opts: opts, client: &http.Client{Timeout: oidcRequestTimeout}}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("oidc: no certificate found in %s", opts.CAFile)
+		}
+		t.client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	}
+	return t, nil
+}
+
+func (t *tokenOIDC) Verify(ctx context.Context, token string) (string, []string, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(oidcSigningMethods))
+	if _, err := parser.ParseWithClaims(token, claims, func(tk *jwt.Token) (interface{}, error) {
+		kid, _ := tk.Header["kid"].(string)
+		return t.key(ctx, kid)
+	}); err != nil {
+		return "", nil, err
+	}
+
+	if !claims.VerifyIssuer(t.opts.Issuer, true) {
+		return "", nil, errors.New("oidc: token is issued by another issuer")
+	}
+	if !claims.VerifyAudience(t.opts.ClientID, true) {
+		return "", nil, errors.New("oidc: token is issued for another audience")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return "", nil, errors.New("oidc: token does not expire")
+	}
+	username, _ := claims[t.opts.UsernameClaim].(string)
+	if username == "" {
+		return "", nil, fmt.Errorf("oidc: token has no %q claim", t.opts.UsernameClaim)
+	}
+	return t.opts.UsernamePrefix + username, t.roles(claims[t.opts.RolesClaim]), nil
+}
+
+// roles maps the groups of the roles claim to etcd roles.
+func (t *tokenOIDC) roles(claim interface{}) []string {
+	var groups []string
+	switch c := claim.(type) {
+	case string:
+		groups = []string{c}
+	case []interface{}:
+		for _, g := range c {
+			if g, ok := g.(string); ok {
+				groups = append(groups, g)
+			}
+		}
+	}
+	if t.opts.RoleMap == nil {
+		roles := groups[:0]
+		for _, g := range groups {
+			if g != rootRole {
+				roles = append(roles, g)
+			}
+		}
+		return roles
+	}
+	var roles []string
+	for _, g := range groups {
+		roles = append(roles, t.opts.RoleMap[g]...)
+	}
+	return roles
+}
+
+// key returns the signing key with the key ID kid, fetching the keys of
+// the issuer again if it is unknown. The only key of the issuer is
+// returned if kid is empty.
+func (t *tokenOIDC) key(ctx context.Context, kid string) (interface{}, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if k, ok := t.lookupKey(kid); ok {
+		return k, nil
+	}
+	if time.Since(t.lastFetched) < oidcKeysRefreshInterval {
+		return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+	}
+	if err := t.fetchKeys(ctx); err != nil {
+		return nil, err
+	}
+	if k, ok := t.lookupKey(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+}
+
+func (t *tokenOIDC) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(t.keys) == 1 {
+		for _, k := range t.keys {
+			return k, true
+		}
+	}
+	k, ok := t.keys[kid]
+	return k, ok
+}
+
+// fetchKeys fetches the signing keys of the issuer, discovering where they
+// are published first. It is called with t.mu held.
+func (t *tokenOIDC) fetchKeys(ctx context.Context) error {
+	t.lastFetched = time.Now()
+	if t.jwksURI == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := t.get(ctx, t.opts.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return err
+		}
+		if strings.TrimSuffix(discovery.Issuer, "/") != t.opts.Issuer {
+			return fmt.Errorf("oidc: discovered issuer %q, want %q", discovery.Issuer, t.opts.Issuer)
+		}
+		if discovery.JWKSURI == "" {
+			return errors.New("oidc: issuer publishes no jwks_uri")
+		}
+		t.jwksURI = discovery.JWKSURI
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := t.get(ctx, t.jwksURI, &jwks); err != nil {
+		return err
+	}
+	keys := make(map[string]interface{}, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		k, err := jwk.publicKey()
+		if err != nil {
+			t.lg.Warn("ignored a signing key of the OIDC issuer", zap.String("kid", jwk.Kid), zap.Error(err))
+			continue
+		}
+		keys[jwk.Kid] = k
+	}
+	t.keys = keys
+	t.lg.Info("fetched signing keys of the OIDC issuer", zap.String("issuer", t.opts.Issuer), zap.Int("keys", len(keys)))
+	return nil
+}
+
+func (t *tokenOIDC) get(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: GET %s returned %s", url, resp.Status)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("oidc: cannot decode the response of GET %s: %v", url, err)
+	}
+	return nil
+}
+
+// jsonWebKey is a public RSA or EC key in the JSON Web Key format (RFC 7517).
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// N and E are the modulus and the exponent of an RSA key.
+	N string `json:"n"`
+	E string `json:"e"`
+	// Crv, X and Y are the curve and the coordinates of an EC key.
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k *jsonWebKey) publicKey() (interface{}, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
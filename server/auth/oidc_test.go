@@ -0,0 +1,234 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/crypto/bcrypt"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// fakeOIDCIssuer publishes the discovery document and the signing key of
+// an OpenID Connect provider.
+type fakeOIDCIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+}
+
+func newFakeOIDCIssuer(t *testing.T) *fakeOIDCIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &fakeOIDCIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": iss.URL, "jwks_uri": iss.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	iss.Server = httptest.NewServer(mux)
+	t.Cleanup(iss.Close)
+	return iss
+}
+
+func (iss *fakeOIDCIssuer) token(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	tk := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tk.Header["kid"] = "key1"
+	s, err := tk.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func (iss *fakeOIDCIssuer) claims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":    iss.URL,
+		"aud":    "etcd",
+		"sub":    "alice",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"groups": []string{"devs", "ops"},
+	}
+}
+
+func TestOIDCVerify(t *testing.T) {
+	iss := newFakeOIDCIssuer(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		opts   map[string]string
+		key    *rsa.PrivateKey
+		modify func(jwt.MapClaims)
+
+		wuser  string
+		wroles []string
+		werr   bool
+	}{
+		{name: "valid", wuser: "oidc:alice", wroles: []string{"devs", "ops"}},
+		{
+			name:   "mapped roles",
+			opts:   map[string]string{"role-map": "ops:root;ops:admin;qa:reader", "username-prefix": ""},
+			wuser:  "alice",
+			wroles: []string{"root", "admin"},
+		},
+		{
+			name:   "unmapped root group",
+			modify: func(c jwt.MapClaims) { c["groups"] = []string{"root", "devs"} },
+			wuser:  "oidc:alice",
+			wroles: []string{"devs"},
+		},
+		{
+			name:   "string roles claim",
+			opts:   map[string]string{"roles-claim": "role", "username-claim": "email"},
+			modify: func(c jwt.MapClaims) { c["role"], c["email"] = "devs", "alice@example.com" },
+			wuser:  "oidc:alice@example.com",
+			wroles: []string{"devs"},
+		},
+		{name: "other audience", modify: func(c jwt.MapClaims) { c["aud"] = "other" }, werr: true},
+		{name: "other issuer", modify: func(c jwt.MapClaims) { c["iss"] = "https://example.com" }, werr: true},
+		{name: "expired", modify: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, werr: true},
+		{name: "no expiry", modify: func(c jwt.MapClaims) { delete(c, "exp") }, werr: true},
+		{name: "no username", modify: func(c jwt.MapClaims) { delete(c, "sub") }, werr: true},
+		{name: "other key", key: otherKey, werr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := map[string]string{"issuer": iss.URL, "client-id": "etcd"}
+			for k, v := range tt.opts {
+				opts[k] = v
+			}
+			p, err := newOIDCTokenProvider(zaptest.NewLogger(t), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims := iss.claims()
+			if tt.modify != nil {
+				tt.modify(claims)
+			}
+			key := iss.key
+			if tt.key != nil {
+				key = tt.key
+			}
+
+			user, roles, err := p.Verify(context.Background(), iss.token(t, key, claims))
+			if tt.werr {
+				if err == nil {
+					t.Fatalf("expected an error, got user %q", user)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user != tt.wuser || !reflect.DeepEqual(roles, tt.wroles) {
+				t.Errorf("got user %q with roles %v, want %q with %v", user, roles, tt.wuser, tt.wroles)
+			}
+		})
+	}
+}
+
+func TestOIDCOptions(t *testing.T) {
+	for _, opts := range []map[string]string{
+		{"client-id": "etcd"},
+		{"issuer": "https://example.com"},
+		{"issuer": "https://example.com", "client-id": "etcd", "role-map": "devs"},
+	} {
+		if _, err := newOIDCTokenProvider(zaptest.NewLogger(t), opts); err != ErrInvalidAuthOpts {
+			t.Errorf("options %v: expected %v, got %v", opts, ErrInvalidAuthOpts, err)
+		}
+	}
+}
+
+func TestOIDCPermissions(t *testing.T) {
+	iss := newFakeOIDCIssuer(t)
+	tp, err := NewTokenProvider(zaptest.NewLogger(t), "oidc,issuer="+iss.URL+",client-id=etcd", dummyIndexWaiter, simpleTokenTTLDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	as := NewAuthStore(zaptest.NewLogger(t), newBackendMock(), tp, bcrypt.MinCost)
+	defer as.Close()
+	if err = enableAuthAndCreateRoot(as); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = as.RoleAdd(&pb.AuthRoleAddRequest{Name: "devs"}); err != nil {
+		t.Fatal(err)
+	}
+	perm := &authpb.Permission{PermType: authpb.READWRITE, Key: []byte("foo"), RangeEnd: []byte("fop")}
+	if _, err = as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{Name: "devs", Perm: perm}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	ai, ok := as.authInfoFromToken(ctx, "Bearer "+iss.token(t, iss.key, iss.claims()))
+	if !ok {
+		t.Fatal("expected the token of the issuer to be valid")
+	}
+	if wai := (&AuthInfo{Username: "oidc:alice", Revision: as.Revision(), Roles: []string{"devs", "ops"}}); !reflect.DeepEqual(ai, wai) {
+		t.Fatalf("got auth info %+v, want %+v", ai, wai)
+	}
+	if err = as.IsPutPermitted(ai, []byte("foo1")); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+	if err = as.IsPutPermitted(ai, []byte("bar")); err != ErrPermissionDenied {
+		t.Errorf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+	if err = as.IsAdminPermitted(ai); err != ErrPermissionDenied {
+		t.Errorf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+
+	// tokens granting no role are rejected
+	claims := iss.claims()
+	delete(claims, "groups")
+	if _, ok = as.authInfoFromToken(ctx, iss.token(t, iss.key, claims)); ok {
+		t.Error("expected a token without roles to be invalid")
+	}
+
+	// the users of the auth store keep authenticating with simple tokens
+	ctx = context.WithValue(context.WithValue(ctx, AuthenticateParamIndex{}, uint64(1)), AuthenticateParamSimpleTokenPrefix{}, "dummy")
+	resp, err := as.Authenticate(ctx, "root", "root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ai, ok = as.authInfoFromToken(ctx, resp.Token); !ok || ai.Username != "root" || ai.Roles != nil {
+		t.Errorf("got auth info %+v (%v) for the simple token of root", ai, ok)
+	}
+}
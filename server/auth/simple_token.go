@@ -140,6 +140,7 @@ func (t *tokenSimple) assignSimpleTokenToUser(username, token string) {
 
 	t.simpleTokens[token] = username
 	t.simpleTokenKeeper.addSimpleToken(token)
+	simpleTokens.Set(float64(len(t.simpleTokens)))
 }
 
 func (t *tokenSimple) invalidateUser(username string) {
@@ -153,6 +154,7 @@ func (t *tokenSimple) invalidateUser(username string) {
 			t.simpleTokenKeeper.deleteSimpleToken(token)
 		}
 	}
+	simpleTokens.Set(float64(len(t.simpleTokens)))
 	t.simpleTokensMu.Unlock()
 }
 
@@ -174,6 +176,7 @@ func (t *tokenSimple) enable() {
 				zap.String("token", tk),
 			)
 			delete(t.simpleTokens, tk)
+			simpleTokens.Set(float64(len(t.simpleTokens)))
 		}
 	}
 	t.simpleTokenKeeper = &simpleTokenTTLKeeper{
@@ -192,6 +195,7 @@ func (t *tokenSimple) disable() {
 	tk := t.simpleTokenKeeper
 	t.simpleTokenKeeper = nil
 	t.simpleTokens = make(map[string]string) // invalidate all tokens
+	simpleTokens.Set(0)
 	t.simpleTokensMu.Unlock()
 	if tk != nil {
 		tk.stop()
@@ -78,6 +78,10 @@ const (
 type AuthInfo struct {
 	Username string
 	Revision uint64
+	// Roles are the sorted roles of a user authenticated by an external
+	// identity provider, used in place of the roles of the user named
+	// Username in the auth store. It is nil for the users of the auth store.
+	Roles []string
 }
 
 // AuthenticateParamIndex is used for a key of context in the parameters of Authenticate()
@@ -154,6 +158,21 @@ type AuthStore interface {
 	// IsAdminPermitted checks admin permission of the user
 	IsAdminPermitted(authInfo *AuthInfo) error
 
+	// IsLeasePermitted checks whether the user may revoke or keep alive
+	// the lease, that is whether it granted the lease or has the root
+	// role or a lease permission
+	IsLeasePermitted(authInfo *AuthInfo, leaseID int64) error
+
+	// LeaseGranted records the user as the owner of the lease it granted
+	LeaseGranted(authInfo *AuthInfo, leaseID int64)
+
+	// LeaseRevoked forgets the owner of the revoked lease
+	LeaseRevoked(leaseID int64)
+
+	// CheckPermission checks whether the user has the given permission on
+	// the key or range at the current revision of the authStore
+	CheckPermission(userName string, key, rangeEnd []byte, permTyp authpb.Permission_Type) error
+
 	// GenTokenPrefix produces a random string in a case of simple token
 	// in a case of JWT, it produces an empty string
 	GenTokenPrefix() (string, error)
@@ -203,6 +222,9 @@ type AuthBackend interface {
 	GetAllUsers() []*authpb.User
 	GetRole(string) *authpb.Role
 	GetAllRoles() []*authpb.Role
+
+	PutLeaseOwner(leaseID int64, owner string)
+	DeleteLeaseOwner(leaseID int64)
 }
 
 type AuthBatchTx interface {
@@ -222,6 +244,7 @@ type AuthReadTx interface {
 	UnsafeGetRole(string) *authpb.Role
 	UnsafeGetAllUsers() []*authpb.User
 	UnsafeGetAllRoles() []*authpb.Role
+	UnsafeGetLeaseOwner(leaseID int64) string
 	Lock()
 	Unlock()
 }
@@ -372,7 +395,10 @@ func (as *authStore) CheckPassword(username, password string) (uint64, error) {
 		return 0, err
 	}
 
-	if bcrypt.CompareHashAndPassword(user.Password, []byte(password)) != nil {
+	start := time.Now()
+	err = bcrypt.CompareHashAndPassword(user.Password, []byte(password))
+	bcryptDurationSec.WithLabelValues("compare").Observe(time.Since(start).Seconds())
+	if err != nil {
 		as.lg.Info("invalid password", zap.String("user-name", username))
 		return 0, ErrAuthFailed
 	}
@@ -380,6 +406,9 @@ func (as *authStore) CheckPassword(username, password string) (uint64, error) {
 }
 
 func (as *authStore) Recover(be AuthBackend) {
+	// the snapshot may come from a member not having all the buckets yet
+	be.CreateAuthBuckets()
+
 	as.be = be
 	tx := be.ReadTx()
 	tx.Lock()
@@ -401,11 +430,18 @@ func (as *authStore) Recover(be AuthBackend) {
 func (as *authStore) selectPassword(password string, hashedPassword string) ([]byte, error) {
 	if password != "" && hashedPassword == "" {
 		// This path is for processing log entries created by etcd whose version is older than 3.5
-		return bcrypt.GenerateFromPassword([]byte(password), as.bcryptCost)
+		return HashPassword(password, as.bcryptCost)
 	}
 	return base64.StdEncoding.DecodeString(hashedPassword)
 }
 
+// HashPassword hashes password with bcrypt at the given cost.
+func HashPassword(password string, cost int) ([]byte, error) {
+	start := time.Now()
+	defer func() { bcryptDurationSec.WithLabelValues("hash").Observe(time.Since(start).Seconds()) }()
+	return bcrypt.GenerateFromPassword([]byte(password), cost)
+}
+
 func (as *authStore) UserAdd(r *pb.AuthUserAddRequest) (*pb.AuthUserAddResponse, error) {
 	if len(r.Name) == 0 {
 		return nil, ErrUserEmpty
@@ -805,18 +841,28 @@ func (as *authStore) RoleGrantPermission(r *pb.AuthRoleGrantPermissionRequest) (
 		return nil, ErrRoleNotFound
 	}
 
+	key, rangeEnd := r.Perm.Key, r.Perm.RangeEnd
+	if r.Perm.PermType == authpb.LEASE {
+		// a lease permission applies to all leases
+		key, rangeEnd = nil, nil
+	}
+
 	idx := sort.Search(len(role.KeyPermission), func(i int) bool {
-		return bytes.Compare(role.KeyPermission[i].Key, r.Perm.Key) >= 0
+		return bytes.Compare(role.KeyPermission[i].Key, key) >= 0
 	})
+	// several permissions share a key if their range ends differ
+	for idx < len(role.KeyPermission) && bytes.Equal(role.KeyPermission[idx].Key, key) && !bytes.Equal(role.KeyPermission[idx].RangeEnd, rangeEnd) {
+		idx++
+	}
 
-	if idx < len(role.KeyPermission) && bytes.Equal(role.KeyPermission[idx].Key, r.Perm.Key) && bytes.Equal(role.KeyPermission[idx].RangeEnd, r.Perm.RangeEnd) {
+	if idx < len(role.KeyPermission) && bytes.Equal(role.KeyPermission[idx].Key, key) {
 		// update existing permission
 		role.KeyPermission[idx].PermType = r.Perm.PermType
 	} else {
 		// append new permission to the role
 		newPerm := &authpb.Permission{
-			Key:      r.Perm.Key,
-			RangeEnd: r.Perm.RangeEnd,
+			Key:      key,
+			RangeEnd: rangeEnd,
 			PermType: r.Perm.PermType,
 		}
 
@@ -833,24 +879,24 @@ func (as *authStore) RoleGrantPermission(r *pb.AuthRoleGrantPermissionRequest) (
 		"granted/updated a permission to a user",
 		zap.String("user-name", r.Name),
 		zap.String("permission-name", authpb.Permission_Type_name[int32(r.Perm.PermType)]),
-		zap.ByteString("key", r.Perm.Key),
-		zap.ByteString("range-end", r.Perm.RangeEnd),
+		zap.ByteString("key", key),
+		zap.ByteString("range-end", rangeEnd),
 	)
 	return &pb.AuthRoleGrantPermissionResponse{}, nil
 }
 
-func (as *authStore) isOpPermitted(userName string, revision uint64, key, rangeEnd []byte, permTyp authpb.Permission_Type) error {
+func (as *authStore) isOpPermitted(authInfo *AuthInfo, key, rangeEnd []byte, permTyp authpb.Permission_Type) error {
 	// TODO(mitake): this function would be costly so we need a caching mechanism
 	if !as.IsAuthEnabled() {
 		return nil
 	}
 
 	// only gets rev == 0 when passed AuthInfo{}; no user given
-	if revision == 0 {
+	if authInfo.Revision == 0 {
 		return ErrUserEmpty
 	}
 	rev := as.Revision()
-	if revision < rev {
+	if revision := authInfo.Revision; revision < rev {
 		as.lg.Warn("request auth revision is less than current node auth revision",
 			zap.Uint64("current node auth revision", rev),
 			zap.Uint64("request auth revision", revision),
@@ -863,9 +909,9 @@ func (as *authStore) isOpPermitted(userName string, revision uint64, key, rangeE
 	tx.Lock()
 	defer tx.Unlock()
 
-	user := tx.UnsafeGetUser(userName)
+	user := unsafeGetAuthUser(tx, authInfo)
 	if user == nil {
-		as.lg.Error("cannot find a user for permission check", zap.String("user-name", userName))
+		as.lg.Error("cannot find a user for permission check", zap.String("user-name", authInfo.Username))
 		return ErrPermissionDenied
 	}
 
@@ -874,23 +920,59 @@ func (as *authStore) isOpPermitted(userName string, revision uint64, key, rangeE
 		return nil
 	}
 
-	if as.isRangeOpPermitted(userName, key, rangeEnd, permTyp) {
+	if authInfo.Roles != nil {
+		// the permissions of external users are not cached
+		if checkRangePerms(as.lg, getRolesMergedPerms(tx, authInfo.Roles), key, rangeEnd, permTyp) {
+			return nil
+		}
+		return ErrPermissionDenied
+	}
+	if as.isRangeOpPermitted(authInfo.Username, key, rangeEnd, permTyp) {
 		return nil
 	}
 
 	return ErrPermissionDenied
 }
 
+// unsafeGetAuthUser returns the user authInfo was authenticated as, with
+// the roles mapped by an external identity provider if it has some.
+func unsafeGetAuthUser(tx AuthReadTx, authInfo *AuthInfo) *authpb.User {
+	if authInfo.Roles != nil {
+		return &authpb.User{Name: []byte(authInfo.Username), Roles: authInfo.Roles}
+	}
+	return tx.UnsafeGetUser(authInfo.Username)
+}
+
 func (as *authStore) IsPutPermitted(authInfo *AuthInfo, key []byte) error {
-	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, nil, authpb.WRITE)
+	return as.isOpPermitted(authInfo, key, nil, authpb.WRITE)
 }
 
 func (as *authStore) IsRangePermitted(authInfo *AuthInfo, key, rangeEnd []byte) error {
-	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, rangeEnd, authpb.READ)
+	return as.isOpPermitted(authInfo, key, rangeEnd, authpb.READ)
 }
 
 func (as *authStore) IsDeleteRangePermitted(authInfo *AuthInfo, key, rangeEnd []byte) error {
-	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, rangeEnd, authpb.WRITE)
+	return as.isOpPermitted(authInfo, key, rangeEnd, authpb.WRITE)
+}
+
+func (as *authStore) CheckPermission(userName string, key, rangeEnd []byte, permTyp authpb.Permission_Type) error {
+	if userName == "" {
+		return ErrUserEmpty
+	}
+	authInfo := &AuthInfo{Username: userName, Revision: as.Revision()}
+	switch permTyp {
+	case authpb.READ, authpb.WRITE:
+		return as.isOpPermitted(authInfo, key, rangeEnd, permTyp)
+	case authpb.READWRITE:
+		if err := as.isOpPermitted(authInfo, key, rangeEnd, authpb.READ); err != nil {
+			return err
+		}
+		return as.isOpPermitted(authInfo, key, rangeEnd, authpb.WRITE)
+	case authpb.LEASE:
+		return as.isLeaseOpPermitted(authInfo)
+	default:
+		return ErrPermissionDenied
+	}
 }
 
 func (as *authStore) IsAdminPermitted(authInfo *AuthInfo) error {
@@ -904,7 +986,7 @@ func (as *authStore) IsAdminPermitted(authInfo *AuthInfo) error {
 	tx := as.be.ReadTx()
 	tx.Lock()
 	defer tx.Unlock()
-	u := tx.UnsafeGetUser(authInfo.Username)
+	u := unsafeGetAuthUser(tx, authInfo)
 
 	if u == nil {
 		return ErrUserNotFound
@@ -917,6 +999,74 @@ func (as *authStore) IsAdminPermitted(authInfo *AuthInfo) error {
 	return nil
 }
 
+// IsLeasePermitted permits anyone to revoke or keep alive the leases
+// granted while auth was disabled, which have no owner.
+func (as *authStore) IsLeasePermitted(authInfo *AuthInfo, leaseID int64) error {
+	if !as.IsAuthEnabled() {
+		return nil
+	}
+	tx := as.be.ReadTx()
+	tx.Lock()
+	owner := tx.UnsafeGetLeaseOwner(leaseID)
+	tx.Unlock()
+	if owner == "" || authInfo != nil && authInfo.Username == owner {
+		return nil
+	}
+	if authInfo == nil {
+		return ErrUserEmpty
+	}
+	return as.isLeaseOpPermitted(authInfo)
+}
+
+// isLeaseOpPermitted checks whether the user may revoke or keep alive the
+// leases granted by other users. Since the roles of the user are read from
+// the store rather than from the permission cache, requests of a former auth
+// revision are checked as well instead of failing with ErrAuthOldRevision.
+func (as *authStore) isLeaseOpPermitted(authInfo *AuthInfo) error {
+	if !as.IsAuthEnabled() {
+		return nil
+	}
+	if authInfo.Revision == 0 {
+		return ErrUserEmpty
+	}
+
+	tx := as.be.ReadTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	user := unsafeGetAuthUser(tx, authInfo)
+	if user == nil {
+		as.lg.Error("cannot find a user for permission check", zap.String("user-name", authInfo.Username))
+		return ErrPermissionDenied
+	}
+	if hasRootRole(user) {
+		return nil
+	}
+	for _, roleName := range user.Roles {
+		role := tx.UnsafeGetRole(roleName)
+		if role == nil {
+			continue
+		}
+		for _, perm := range role.KeyPermission {
+			if perm.PermType == authpb.LEASE {
+				return nil
+			}
+		}
+	}
+	return ErrPermissionDenied
+}
+
+func (as *authStore) LeaseGranted(authInfo *AuthInfo, leaseID int64) {
+	if !as.IsAuthEnabled() || authInfo == nil || authInfo.Username == "" {
+		return
+	}
+	as.be.PutLeaseOwner(leaseID, authInfo.Username)
+}
+
+func (as *authStore) LeaseRevoked(leaseID int64) {
+	as.be.DeleteLeaseOwner(leaseID)
+}
+
 func (as *authStore) IsAuthEnabled() bool {
 	as.enabledMu.RLock()
 	defer as.enabledMu.RUnlock()
@@ -1058,9 +1208,11 @@ func (as *authStore) AuthInfoFromCtx(ctx context.Context) (*AuthInfo, error) {
 	token := ts[0]
 	authInfo, uok := as.authInfoFromToken(ctx, token)
 	if !uok {
+		tokenValidations.WithLabelValues("invalid").Inc()
 		as.lg.Warn("invalid auth token", zap.String("token", token))
 		return nil, ErrInvalidAuthToken
 	}
+	tokenValidations.WithLabelValues("valid").Inc()
 
 	return authInfo, nil
 }
@@ -1127,6 +1279,13 @@ func NewTokenProvider(
 		return newTokenProviderNop()
 
 	default:
+		if newProvider, ok := getExternalTokenProvider(tokenType); ok {
+			ext, err := newProvider(lg, typeSpecificOpts)
+			if err != nil {
+				return nil, err
+			}
+			return newTokenProviderExternal(lg, ext, indexWaiter, TokenTTL), nil
+		}
 		if lg != nil {
 			lg.Warn(
 				"unknown token type",
@@ -1144,7 +1303,11 @@ func (as *authStore) WithRoot(ctx context.Context) context.Context {
 	}
 
 	var ctxForAssign context.Context
-	if ts, ok := as.tokenProvider.(*tokenSimple); ok && ts != nil {
+	ts, ok := as.tokenProvider.(*tokenSimple)
+	if te, isExternal := as.tokenProvider.(*tokenExternal); isExternal {
+		ts, ok = te.tokenSimple, true
+	}
+	if ok && ts != nil {
 		ctx1 := context.WithValue(ctx, AuthenticateParamIndex{}, uint64(0))
 		prefix, err := ts.genTokenPrefix()
 		if err != nil {
@@ -17,16 +17,18 @@ package auth
 import "go.etcd.io/etcd/api/v3/authpb"
 
 type backendMock struct {
-	users    map[string]*authpb.User
-	roles    map[string]*authpb.Role
-	enabled  bool
-	revision uint64
+	users       map[string]*authpb.User
+	roles       map[string]*authpb.Role
+	leaseOwners map[int64]string
+	enabled     bool
+	revision    uint64
 }
 
 func newBackendMock() *backendMock {
 	return &backendMock{
-		users: make(map[string]*authpb.User),
-		roles: make(map[string]*authpb.Role),
+		users:       make(map[string]*authpb.User),
+		roles:       make(map[string]*authpb.Role),
+		leaseOwners: make(map[int64]string),
 	}
 }
 
@@ -129,3 +131,15 @@ func (t txMock) UnsafePutRole(role *authpb.Role) {
 func (t txMock) UnsafeDeleteRole(s string) {
 	delete(t.be.roles, s)
 }
+
+func (b *backendMock) PutLeaseOwner(leaseID int64, owner string) {
+	b.leaseOwners[leaseID] = owner
+}
+
+func (b *backendMock) DeleteLeaseOwner(leaseID int64) {
+	delete(b.leaseOwners, leaseID)
+}
+
+func (t txMock) UnsafeGetLeaseOwner(leaseID int64) string {
+	return t.be.leaseOwners[leaseID]
+}
@@ -422,7 +422,7 @@ func TestIsOpPermitted(t *testing.T) {
 
 	// check permission reflected to user
 
-	err = as.isOpPermitted("foo", as.Revision(), perm.Key, perm.RangeEnd, perm.PermType)
+	err = as.isOpPermitted(&AuthInfo{Username: "foo", Revision: as.Revision()}, perm.Key, perm.RangeEnd, perm.PermType)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -833,6 +833,81 @@ func TestIsAdminPermitted(t *testing.T) {
 	}
 }
 
+func TestIsLeasePermitted(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	_, err := as.UserAdd(&pb.AuthUserAddRequest{Name: "bar", Options: &authpb.UserAddOptions{NoPassword: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := func(name string) *AuthInfo { return &AuthInfo{Username: name, Revision: as.Revision()} }
+
+	// lease 1 is granted by foo, lease 2 while auth was disabled
+	as.LeaseGranted(info("foo"), 1)
+
+	tests := []struct {
+		user    string
+		leaseID int64
+		wantErr error
+	}{
+		{user: "foo", leaseID: 1},
+		{user: "root", leaseID: 1},
+		{user: "bar", leaseID: 1, wantErr: ErrPermissionDenied},
+		{user: "bar", leaseID: 2},
+	}
+	for _, tt := range tests {
+		if err = as.IsLeasePermitted(info(tt.user), tt.leaseID); err != tt.wantErr {
+			t.Errorf("user %s on lease %d: expected %v, got %v", tt.user, tt.leaseID, tt.wantErr, err)
+		}
+	}
+	if err = as.IsLeasePermitted(nil, 1); err != ErrUserEmpty {
+		t.Errorf("expected %v, got %v", ErrUserEmpty, err)
+	}
+	// the requests of a former auth revision are denied rather than stale
+	oldInfo := &AuthInfo{Username: "bar", Revision: as.Revision() - 1}
+	if err = as.IsLeasePermitted(oldInfo, 1); err != ErrPermissionDenied {
+		t.Errorf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+	if err = as.CheckPermission("bar", nil, nil, authpb.LEASE); err != ErrPermissionDenied {
+		t.Errorf("expected %v, got %v", ErrPermissionDenied, err)
+	}
+
+	// the key of a lease permission is ignored
+	_, err = as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{
+		Name: "role-test",
+		Perm: &authpb.Permission{PermType: authpb.LEASE, Key: []byte("foo")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := as.RoleGet(&pb.AuthRoleGetRequest{Role: "role-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*authpb.Permission{{PermType: authpb.LEASE}}, r.Perm)
+
+	_, err = as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "bar", Role: "role-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = as.IsLeasePermitted(info("bar"), 1); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+	if err = as.IsLeasePermitted(oldInfo, 1); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+	if err = as.CheckPermission("bar", nil, nil, authpb.LEASE); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+
+	// a revoked lease is forgotten with its owner
+	as.LeaseRevoked(1)
+	if owner := as.be.ReadTx().UnsafeGetLeaseOwner(1); owner != "" {
+		t.Errorf("expected no owner, got %q", owner)
+	}
+}
+
 func TestRecoverFromSnapshot(t *testing.T) {
 	as, teardown := setupAuthStore(t)
 	defer teardown(t)
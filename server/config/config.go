@@ -22,17 +22,29 @@ import (
 	"strings"
 	"time"
 
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/client/pkg/v3/transport"
 	"go.etcd.io/etcd/client/pkg/v3/types"
 	"go.etcd.io/etcd/pkg/v3/netutil"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3discovery"
+	"go.etcd.io/etcd/server/v3/lease"
 	"go.etcd.io/etcd/server/v3/storage/datadir"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
 
 	bolt "go.etcd.io/bbolt"
 	"go.uber.org/zap"
 )
 
+// PrefixTTL is a retention policy deleting the keys under Prefix once they
+// were not modified for TTL.
+type PrefixTTL struct {
+	Prefix string
+	TTL    time.Duration
+}
+
 // ServerConfig holds the configuration of etcd as taken from the command line or discovery.
 type ServerConfig struct {
 	Name string
@@ -47,6 +59,9 @@ type ServerConfig struct {
 	// DedicatedWALDir config will make the etcd to write the WAL to the WALDir
 	// rather than the dataDir/member/wal.
 	DedicatedWALDir string
+	// WALCompression is the compression of the entry records appended to
+	// the WAL.
+	WALCompression walpb.Compression
 
 	SnapshotCount uint64
 
@@ -58,6 +73,53 @@ type ServerConfig struct {
 	// WARNING: only change this for tests. Always use "DefaultSnapshotCatchUpEntries"
 	SnapshotCatchUpEntries uint64
 
+	// SnapshotSendRateLimit is the maximum number of bytes per second of the
+	// snapshots sent to the peers. 0 means no limit.
+	SnapshotSendRateLimit int64
+
+	// RaftLogRetentionMaxBytes caps the size of the raft log entries held in
+	// memory. Up to half of it keeps entries for slow followers after a
+	// snapshot, and a snapshot is triggered early when the log grows beyond it.
+	// 0 means no limit.
+	RaftLogRetentionMaxBytes uint64
+	// MemoryBudgetBytes is the memory the member aims to stay within. It sets
+	// the soft memory limit of the Go runtime, and the watch buffers, the
+	// buffer of the pending backend writes and the raft entries kept for slow
+	// followers shrink as the memory in use approaches it. 0 means no budget.
+	MemoryBudgetBytes uint64
+	// SlowFollowerAlarmThreshold is the number of snapshots sent to a follower
+	// within an hour that raises a SLOWFOLLOWER alarm. 0 disables the alarm.
+	SlowFollowerAlarmThreshold int
+	// CertExpiryAlarmWindow is the time before a serving, peer or CA
+	// certificate of the member expires from which a CERTEXPIRY alarm is
+	// raised. 0 disables the alarm.
+	CertExpiryAlarmWindow time.Duration
+	// StandbyOf is the list of the client URLs of the primary cluster which
+	// the leader mirrors into the cluster while it holds a STANDBY alarm.
+	StandbyOf []string
+	// StaleDataDirRecovery wipes the data dir of the member on startup if it
+	// belongs to a cluster recreated since or the member was removed from it,
+	// as told by the peers of its initial cluster, to rejoin their cluster as
+	// a new learner.
+	StaleDataDirRecovery bool
+	// LearnerSerializableReads lets a learner serve the serializable read-only
+	// transactions, range streams and watches of clients, in addition to the
+	// serializable ranges and status requests learners always serve.
+	LearnerSerializableReads bool
+	// PrefixTTLs are the retention policies deleting the keys under their
+	// prefix once not modified for their TTL.
+	PrefixTTLs []PrefixTTL
+	// RaftEntryCompressionThreshold is the size in bytes of a proposal from
+	// which it is compressed with zstd before being replicated and written to
+	// the WAL. 0 disables the compression.
+	RaftEntryCompressionThreshold int
+	// PeerMsgAppBatchBytes is the maximum size of the entries of consecutive
+	// MsgApp to a peer merged into one. 0 disables the batching.
+	PeerMsgAppBatchBytes int
+	// LearnerHeartbeatInterval is the minimum interval between the heartbeats
+	// the leader sends to a learner. 0 sends them on every heartbeat.
+	LearnerHeartbeatInterval time.Duration
+
 	MaxSnapFiles uint
 	MaxWALFiles  uint
 
@@ -73,6 +135,7 @@ type ServerConfig struct {
 	InitialClusterToken string
 	NewCluster          bool
 	PeerTLSInfo         transport.TLSInfo
+	ClientTLSInfo       transport.TLSInfo
 
 	CORS map[string]struct{}
 
@@ -121,10 +184,54 @@ type ServerConfig struct {
 
 	AutoCompactionRetention time.Duration
 	AutoCompactionMode      string
+	// AutoCompactionMaxLatency is the p99 latency of the recent proposals of
+	// the member beyond which its automatic compactions are deferred, rather
+	// than adding their I/O to an overloaded cluster. 0 disables the check.
+	AutoCompactionMaxLatency time.Duration
+	// AutoCompactionMaxPendingProposals is the number of writes of clients
+	// proposed by the member and not applied yet beyond which its automatic
+	// compactions are deferred. 0 disables the check.
+	AutoCompactionMaxPendingProposals uint64
+	// AutoCompactionMaxDeferral is how long the automatic compactions of the
+	// member are deferred at most, after which they run despite its load.
+	// 0 defers them without limit.
+	AutoCompactionMaxDeferral time.Duration
+
+	// AutoBackupInterval is the interval between two backups of the member
+	// saved to AutoBackupDir. 0 disables the backups.
+	AutoBackupInterval time.Duration
+	// AutoBackupDir is the directory the backups of the member are saved to.
+	AutoBackupDir string
+	// AutoBackupRetention is the number of the most recent backups kept in
+	// AutoBackupDir, the older ones being removed. 0 keeps all of them.
+	AutoBackupRetention int
+
+	// AutoPromoteLearners makes the leader promote the started learners
+	// whose raft log lags at most AutoPromoteLearnersMaxLag entries behind
+	// its own.
+	AutoPromoteLearners       bool
+	AutoPromoteLearnersMaxLag uint64
+
 	CompactionBatchLimit    int
 	CompactionSleepInterval time.Duration
-	QuotaBackendBytes       int64
-	MaxTxnOps               uint
+	// CompactionBatchLatency is the target time a compaction batch holds
+	// the backend for, the batches being resized to meet it.
+	CompactionBatchLatency time.Duration
+	QuotaBackendBytes      int64
+	// MaxKeys is the maximum number of keys of the store, counting the
+	// deleted keys until they are compacted. Beyond it, requests that may
+	// create keys are rejected and a TOOMANYKEYS alarm is raised. 0 means
+	// no limit.
+	MaxKeys int64
+	// MaxApplyBacklog is the maximum number of committed entries the member
+	// has not applied yet beyond which writes are rejected before being
+	// proposed, with a hint of when to retry them. 0 disables the limit.
+	MaxApplyBacklog uint64
+	// MaxPendingProposals is the maximum number of writes of the member
+	// proposed and not yet applied beyond which writes are rejected before
+	// being proposed, with a hint of when to retry them. 0 disables the limit.
+	MaxPendingProposals uint64
+	MaxTxnOps           uint
 
 	// MaxRequestBytes is the maximum request size to send over raft.
 	MaxRequestBytes uint
@@ -169,6 +276,10 @@ type ServerConfig struct {
 	LeaseCheckpointInterval time.Duration
 	// LeaseCheckpointPersist enables persisting remainingTTL to prevent indefinite auto-renewal of long lived leases. Always enabled in v3.6. Should be used to ensure smooth upgrade from v3.5 clusters with this feature enabled.
 	LeaseCheckpointPersist bool
+	// LeaseRevokeHooks are invoked on every member with the keys deleted by each lease revocation.
+	LeaseRevokeHooks []lease.RevokeHook
+	// LeaseRevokeWebhookURL is the URL the leader posts revoked leases and their deleted keys to.
+	LeaseRevokeWebhookURL string
 
 	EnableGRPCGateway bool
 
@@ -176,9 +287,49 @@ type ServerConfig struct {
 	ExperimentalEnableDistributedTracing bool
 	// ExperimentalTracerOptions are options for OpenTelemetry gRPC interceptor.
 	ExperimentalTracerOptions []otelgrpc.Option
+	// ExperimentalTracerProvider provides the tracer of the spans of the
+	// server, such as the apply of the traced requests.
+	ExperimentalTracerProvider trace.TracerProvider
+	// TraceRules selects the requests that are always traced, whatever
+	// their duration or the tracing sampling rate.
+	TraceRules []traceutil.Rule
 
 	WatchProgressNotifyInterval time.Duration
 
+	// MaxWatchersPerConnection is the maximum number of watchers a client
+	// connection can open. 0 means no limit.
+	MaxWatchersPerConnection int
+	// MaxWatchersPerUser is the maximum number of watchers an authenticated
+	// user can open across all connections. 0 means no limit.
+	MaxWatchersPerUser int
+	// RateLimits limits the rates of the requests to key prefixes.
+	RateLimits []*pb.RateLimit
+
+	// EffectiveConfig is the configuration of the member as resolved from all
+	// its sources, with the secrets redacted, reported to the clients.
+	EffectiveConfig map[string]string
+	// WatchEventCacheSize is the maximum number of recent events cached
+	// to serve resuming watchers without reading the backend.
+	WatchEventCacheSize int
+	// KeyPrefixBuckets lists the key prefixes whose revisions are stored
+	// in a backend bucket of their own.
+	KeyPrefixBuckets []string
+	// WatchEventPrefixMetrics lists the key prefixes whose put and delete
+	// events are counted by key prefix.
+	WatchEventPrefixMetrics []string
+	// WatchEventPrefixMetricsDepth is the number of key segments following
+	// the listed prefixes the events are counted by.
+	WatchEventPrefixMetricsDepth int
+	// ResponseHeaderCompactRevision sets the compaction revision of the
+	// key-value store in the response headers.
+	ResponseHeaderCompactRevision bool
+	// WatchSlowWatchersAlertThreshold is the number of slow watchers above
+	// which the member reports an error in its status. 0 disables the alert.
+	WatchSlowWatchersAlertThreshold int
+	// WatchPendingEventsAlertThreshold is the number of pending watch events
+	// above which the member reports an error in its status. 0 disables the alert.
+	WatchPendingEventsAlertThreshold int
+
 	// UnsafeNoFsync disables all uses of fsync.
 	// Setting this is unsafe and will cause data loss.
 	UnsafeNoFsync bool `json:"unsafe-no-fsync"`
@@ -40,6 +40,9 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3compactor"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3discovery"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3rpc"
+	"go.etcd.io/etcd/server/v3/lease"
+	"go.etcd.io/etcd/server/v3/storage/wal"
 
 	bolt "go.etcd.io/bbolt"
 	"go.uber.org/multierr"
@@ -66,6 +69,12 @@ const (
 	DefaultGRPCKeepAliveTimeout        = 20 * time.Second
 	DefaultDowngradeCheckTime          = 5 * time.Second
 	DefaultWaitClusterReadyTimeout     = 5 * time.Second
+	DefaultAutoBackupRetention         = 5
+	DefaultAutoPromoteLearnersMaxLag   = 1000
+	DefaultLeaseCheckpointInterval     = 5 * time.Minute
+	DefaultAutoCompactionMaxDeferral   = time.Hour
+
+	DefaultWatchEventPrefixMetricsDepth = 1
 
 	DefaultDiscoveryDialTimeout      = 2 * time.Second
 	DefaultDiscoveryRequestTimeOut   = 5 * time.Second
@@ -147,6 +156,10 @@ type Config struct {
 	Name   string `json:"name"`
 	Dir    string `json:"data-dir"`
 	WalDir string `json:"wal-dir"`
+	// WALCompression is the compression of the entry records appended to the
+	// WAL, "none", "snappy" or "zstd". Members of versions before 3.6 cannot
+	// read the WAL once compressed.
+	WALCompression string `json:"wal-compression"`
 
 	SnapshotCount uint64 `json:"snapshot-count"`
 
@@ -159,6 +172,11 @@ type Config struct {
 	// Always use "DefaultSnapshotCatchUpEntries"
 	SnapshotCatchUpEntries uint64
 
+	// SnapshotSendRateLimit is the maximum number of bytes per second of the
+	// snapshots sent to the peers, in chunks resumed from the last chunk
+	// received by peers failing to receive one. 0 means no limit.
+	SnapshotSendRateLimit int64 `json:"snapshot-send-rate-limit"`
+
 	MaxSnapFiles uint `json:"max-snapshots"`
 	MaxWalFiles  uint `json:"max-wals"`
 
@@ -240,6 +258,10 @@ type Config struct {
 	StrictReconfigCheck                 bool          `json:"strict-reconfig-check"`
 	ExperimentalWaitClusterReadyTimeout time.Duration `json:"wait-cluster-ready-timeout"`
 
+	// InitialClusterFromSnapshot is the path of a snapshot file the data directory
+	// of the member is restored from at first boot, as a member of the initial cluster.
+	InitialClusterFromSnapshot string `json:"initial-cluster-from-snapshot"`
+
 	// AutoCompactionMode is either 'periodic' or 'revision'.
 	AutoCompactionMode string `json:"auto-compaction-mode"`
 	// AutoCompactionRetention is either duration string with time unit
@@ -248,6 +270,28 @@ type Config struct {
 	// the unit defaults to hour. For example, '5' translates into 5-hour.
 	AutoCompactionRetention string `json:"auto-compaction-retention"`
 
+	// AutoBackupInterval is the interval between two backups of the member, verified
+	// snapshot files saved to AutoBackupDir. 0 disables the backups.
+	AutoBackupInterval time.Duration `json:"auto-backup-interval"`
+	// AutoBackupDir is the local directory the backups of the member are saved to.
+	AutoBackupDir string `json:"auto-backup-dir"`
+	// AutoBackupRetention is the number of the most recent backups kept in AutoBackupDir.
+	// 0 keeps all of them.
+	AutoBackupRetention int `json:"auto-backup-retention"`
+
+	// AutoPromoteLearners makes the leader promote the started learners whose raft log lags at
+	// most AutoPromoteLearnersMaxLag entries behind its own, instead of waiting for MemberPromote.
+	AutoPromoteLearners       bool   `json:"auto-promote-learners"`
+	AutoPromoteLearnersMaxLag uint64 `json:"auto-promote-learners-max-lag"`
+
+	// LeaseCheckpoint makes the leader checkpoint the remaining TTLs of the leases every
+	// LeaseCheckpointInterval, persisted by every member, so that neither leader changes nor
+	// restarts extend the TTLs of the leases. Enabled by default.
+	LeaseCheckpoint bool `json:"lease-checkpoint"`
+	// LeaseCheckpointInterval is the interval between the checkpoints of the remaining TTL of a
+	// lease, unless the lease was granted with a checkpoint interval of its own.
+	LeaseCheckpointInterval time.Duration `json:"lease-checkpoint-interval"`
+
 	// GRPCKeepAliveMinTime is the minimum interval that a client should
 	// wait before pinging server. When client pings "too fast", server
 	// sends goaway and closes the connection (errors: too_many_pings,
@@ -313,6 +357,11 @@ type Config struct {
 	//	}
 	//	embed.StartEtcd(cfg)
 	ServiceRegister func(*grpc.Server) `json:"-"`
+	// LeaseRevokeHooks are invoked with the keys deleted by each lease revocation,
+	// whether explicit or on expiry. Hooks run on the apply path, so they must not block.
+	LeaseRevokeHooks []lease.RevokeHook `json:"-"`
+	// ExperimentalLeaseRevokeWebhookURL is the URL the leader posts revoked leases and their deleted keys to.
+	ExperimentalLeaseRevokeWebhookURL string `json:"experimental-lease-revoke-webhook-url"`
 
 	AuthToken  string `json:"auth-token"`
 	BcryptCost uint   `json:"bcrypt-cost"`
@@ -326,6 +375,8 @@ type Config struct {
 	ExperimentalCompactHashCheckTime    time.Duration `json:"experimental-compact-hash-check-time"`
 
 	// ExperimentalEnableLeaseCheckpoint enables leader to send regular checkpoints to other members to prevent reset of remaining TTL on leader change.
+	// Deprecated in v3.6, use LeaseCheckpoint instead.
+	// TODO: Delete in v3.7
 	ExperimentalEnableLeaseCheckpoint bool `json:"experimental-enable-lease-checkpoint"`
 	// ExperimentalEnableLeaseCheckpointPersist enables persisting remainingTTL to prevent indefinite auto-renewal of long lived leases. Always enabled in v3.6. Should be used to ensure smooth upgrade from v3.5 clusters with this feature enabled.
 	// Requires experimental-enable-lease-checkpoint to be enabled.
@@ -334,8 +385,42 @@ type Config struct {
 	ExperimentalEnableLeaseCheckpointPersist bool `json:"experimental-enable-lease-checkpoint-persist"`
 	ExperimentalCompactionBatchLimit         int  `json:"experimental-compaction-batch-limit"`
 	// ExperimentalCompactionSleepInterval is the sleep interval between every etcd compaction loop.
-	ExperimentalCompactionSleepInterval     time.Duration `json:"experimental-compaction-sleep-interval"`
+	ExperimentalCompactionSleepInterval time.Duration `json:"experimental-compaction-sleep-interval"`
+	// ExperimentalCompactionBatchLatency is the target time each compaction batch holds the
+	// backend for. The number of revisions deleted per batch then starts at
+	// ExperimentalCompactionBatchLimit and shrinks or grows as the batches are slower or faster
+	// than the target. 0 keeps the batches at ExperimentalCompactionBatchLimit revisions.
+	ExperimentalCompactionBatchLatency time.Duration `json:"experimental-compaction-batch-latency"`
+	// ExperimentalAutoCompactionMaxLatency is the p99 latency of the recent proposals of the member
+	// beyond which its automatic compactions are deferred. 0 disables the check.
+	ExperimentalAutoCompactionMaxLatency time.Duration `json:"experimental-auto-compaction-max-latency"`
+	// ExperimentalAutoCompactionMaxPendingProposals is the number of writes of clients proposed by the
+	// member and not applied yet beyond which its automatic compactions are deferred. 0 disables the check.
+	ExperimentalAutoCompactionMaxPendingProposals uint64 `json:"experimental-auto-compaction-max-pending-proposals"`
+	// ExperimentalAutoCompactionMaxDeferral is how long the automatic compactions of the member are
+	// deferred at most, after which they run despite its load. 0 defers them without limit.
+	ExperimentalAutoCompactionMaxDeferral time.Duration `json:"experimental-auto-compaction-max-deferral"`
+
 	ExperimentalWatchProgressNotifyInterval time.Duration `json:"experimental-watch-progress-notify-interval"`
+	// ExperimentalWatchEventCacheSize is the maximum number of recent events kept in memory
+	// to serve watchers resuming from a recent revision. Zero disables the cache.
+	ExperimentalWatchEventCacheSize int `json:"experimental-watch-event-cache-size"`
+	// ExperimentalKeyPrefixBuckets lists the key prefixes whose revisions are stored in a
+	// backend bucket of their own, so that they can be measured and their ranges hashed
+	// without scanning the revisions of the other keys. Compactions still go through the
+	// revisions of all buckets. All members should use the same list.
+	ExperimentalKeyPrefixBuckets []string `json:"experimental-key-prefix-buckets"`
+	// ExperimentalWatchEventPrefixMetrics lists the key prefixes whose put and delete events
+	// are counted by key prefix in the etcd_debugging_mvcc_events_by_prefix_total metric,
+	// to tell which applications drive the churn of the keyspace. Empty disables the metric.
+	ExperimentalWatchEventPrefixMetrics []string `json:"experimental-watch-event-prefix-metrics"`
+	// ExperimentalWatchEventPrefixMetricsDepth is the number of '/' terminated key segments
+	// following the listed prefix the events are counted by, which bounds the number of series.
+	ExperimentalWatchEventPrefixMetricsDepth int `json:"experimental-watch-event-prefix-metrics-depth"`
+	// ExperimentalResponseHeaderCompactRevision sets the compaction revision of the key-value
+	// store in the response headers, so that clients can tell the oldest revision they can
+	// range or watch from without running into ErrCompacted.
+	ExperimentalResponseHeaderCompactRevision bool `json:"experimental-response-header-compact-revision"`
 	// ExperimentalWarningApplyDuration is the time duration after which a warning is generated if applying request
 	// takes more time than this value.
 	ExperimentalWarningApplyDuration time.Duration `json:"experimental-warning-apply-duration"`
@@ -347,6 +432,77 @@ type Config struct {
 	ExperimentalWarningUnaryRequestDuration time.Duration `json:"experimental-warning-unary-request-duration"`
 	// ExperimentalMaxLearners sets a limit to the number of learner members that can exist in the cluster membership.
 	ExperimentalMaxLearners int `json:"experimental-max-learners"`
+	// ExperimentalRaftLogRetentionMaxBytes caps the size of the raft log entries held in memory.
+	// Followers lagging behind the entries kept within it catch up from a snapshot. 0 means no limit.
+	ExperimentalRaftLogRetentionMaxBytes uint64 `json:"experimental-raft-log-retention-max-bytes"`
+	// ExperimentalMemoryBudget is the memory in bytes the member aims to stay within. It sets the
+	// soft memory limit of the Go runtime and shrinks the internal caches under pressure. 0 means no budget.
+	ExperimentalMemoryBudget uint64 `json:"experimental-memory-budget"`
+	// ExperimentalSlowFollowerAlarmThreshold is the number of snapshots sent to a follower within
+	// an hour that raises a SLOWFOLLOWER alarm. 0 disables the alarm.
+	ExperimentalSlowFollowerAlarmThreshold int `json:"experimental-slow-follower-alarm-threshold"`
+	// ExperimentalCertExpiryAlarmWindow is the time before a serving, peer or CA certificate of the
+	// member expires from which a CERTEXPIRY alarm is raised. 0 disables the alarm.
+	ExperimentalCertExpiryAlarmWindow time.Duration `json:"experimental-cert-expiry-alarm-window"`
+	// ExperimentalStandbyOf is the list of the client URLs of a primary cluster. While the cluster
+	// holds a STANDBY alarm, its leader mirrors the keyspace of the primary cluster into it.
+	ExperimentalStandbyOf []string `json:"experimental-standby-of"`
+	// ExperimentalStaleDataDirRecovery wipes the data dir of the member on startup if its peers in the
+	// initial cluster tell their cluster was recreated since or the member was removed from it, and
+	// rejoins their cluster as a new learner instead of failing.
+	ExperimentalStaleDataDirRecovery bool `json:"experimental-stale-data-dir-recovery"`
+	// ExperimentalLearnerSerializableReads lets the member, while a learner, serve serializable read-only
+	// transactions, range streams and watches besides serializable ranges, so that read-heavy clients
+	// can be directed to learners.
+	ExperimentalLearnerSerializableReads bool `json:"experimental-learner-serializable-reads"`
+	// ExperimentalPrefixTTLs are retention policies of the form "<prefix>=<ttl>", such as "/logs/=30d",
+	// deleting the keys under the prefix once not modified for the TTL, a duration or a number of days.
+	// The policies are enforced by the leader, so they should be the same on every member.
+	ExperimentalPrefixTTLs []string `json:"experimental-prefix-ttls"`
+	// ExperimentalRaftEntryCompressionThreshold is the size in bytes of a proposal from which it is
+	// compressed with zstd, on the wire to the peers and in the WAL, and decompressed on apply.
+	// Proposals are only compressed once the cluster version is 3.6. 0 disables the compression.
+	ExperimentalRaftEntryCompressionThreshold int `json:"experimental-raft-entry-compression-threshold"`
+	// ExperimentalPeerMsgAppBatchBytes is the maximum size in bytes of the entries of the consecutive
+	// MsgApp sent to a peer merged into one, cutting the messages sent and acknowledged with many
+	// followers and learners. 0 disables the batching.
+	ExperimentalPeerMsgAppBatchBytes int `json:"experimental-peer-msgapp-batch-bytes"`
+	// ExperimentalLearnerHeartbeatInterval is the minimum interval between the heartbeats the leader
+	// sends to a learner, which does not campaign, relaxing the heartbeat fan-out with many learners.
+	// A learner behind the leader catches up slower once it lost entries. 0 sends them on every heartbeat.
+	ExperimentalLearnerHeartbeatInterval time.Duration `json:"experimental-learner-heartbeat-interval"`
+	// ExperimentalMaxKeys is the maximum number of keys of the store, counting the deleted keys until
+	// they are compacted. Beyond it, requests that may create keys are rejected and a TOOMANYKEYS
+	// alarm is raised. 0 means no limit.
+	ExperimentalMaxKeys int64 `json:"experimental-max-keys"`
+	// ExperimentalMaxApplyBacklog is the maximum number of committed entries the member has not applied
+	// yet beyond which writes are rejected before being proposed, with a hint of when to retry them.
+	// 0 disables the limit.
+	ExperimentalMaxApplyBacklog uint64 `json:"experimental-max-apply-backlog"`
+	// ExperimentalMaxPendingProposals is the maximum number of writes of the member proposed and not yet
+	// applied beyond which writes are rejected before being proposed, with a hint of when to retry them.
+	// 0 disables the limit.
+	ExperimentalMaxPendingProposals uint64 `json:"experimental-max-pending-proposals"`
+	// ExperimentalMaxWatchersPerConnection is the maximum number of watchers a client connection can open.
+	// 0 means no limit.
+	ExperimentalMaxWatchersPerConnection int `json:"experimental-max-watchers-per-connection"`
+	// ExperimentalMaxWatchersPerUser is the maximum number of watchers an authenticated user can open.
+	// 0 means no limit.
+	ExperimentalMaxWatchersPerUser int `json:"experimental-max-watchers-per-user"`
+	// ExperimentalRateLimits limits the rates of the requests to key prefixes, as
+	// "[user:]<prefix>=<rate>[:<burst>]" limits of requests per second such as
+	// "user:/tenants/=100:200", where "user:" limits each authenticated user separately.
+	ExperimentalRateLimits []string `json:"experimental-rate-limits"`
+	// ExperimentalWatchSlowWatchersAlertThreshold is the number of slow watchers above which
+	// the member reports an error in its status. 0 disables the alert.
+	ExperimentalWatchSlowWatchersAlertThreshold int `json:"experimental-watch-slow-watchers-alert-threshold"`
+	// ExperimentalWatchPendingEventsAlertThreshold is the number of pending watch events above which
+	// the member reports an error in its status. 0 disables the alert.
+	ExperimentalWatchPendingEventsAlertThreshold int `json:"experimental-watch-pending-events-alert-threshold"`
+	// ExperimentalShutdownDrainTimeout is the maximum duration the member waits on shutdown for the
+	// clients to move their streams to other members, before transferring its leadership and stopping.
+	// 0 disables the drain.
+	ExperimentalShutdownDrainTimeout time.Duration `json:"experimental-shutdown-drain-timeout"`
 
 	// ForceNewCluster starts a new cluster even if previously started; unsafe.
 	ForceNewCluster bool `json:"force-new-cluster"`
@@ -372,6 +528,15 @@ type Config struct {
 	// ExperimentalDistributedTracingSamplingRatePerMillion is the number of samples to collect per million spans.
 	// Defaults to 0.
 	ExperimentalDistributedTracingSamplingRatePerMillion int `json:"experimental-distributed-tracing-sampling-rate"`
+	// ExperimentalTraceKeyPrefixes selects requests that are always traced, whatever their
+	// duration or the sampling rate, as "[read:|write:]<prefix>" rules such as "write:/critical/".
+	ExperimentalTraceKeyPrefixes []string `json:"experimental-trace-key-prefixes"`
+
+	// ExperimentalClientListenerAPIGroups selects the API groups served on client listeners,
+	// as "<listen-client-url>=<group>+<group>" entries such as "http://127.0.0.1:2381=maintenance+cluster".
+	// The groups are kv, watch, lease, cluster, maintenance, auth, election and lock.
+	// A listener without an entry serves all of them.
+	ExperimentalClientListenerAPIGroups []string `json:"experimental-client-listener-api-groups"`
 
 	// Logger is logger options: currently only supports "zap".
 	// "capnslog" is removed in v3.5.
@@ -470,12 +635,20 @@ func NewConfig() *Config {
 		SnapshotCatchUpEntries: etcdserver.DefaultSnapshotCatchUpEntries,
 
 		MaxTxnOps:                        DefaultMaxTxnOps,
+		AutoBackupRetention:              DefaultAutoBackupRetention,
+		AutoPromoteLearnersMaxLag:        DefaultAutoPromoteLearnersMaxLag,
+		LeaseCheckpoint:                  true,
+		LeaseCheckpointInterval:          DefaultLeaseCheckpointInterval,
 		MaxRequestBytes:                  DefaultMaxRequestBytes,
 		MaxConcurrentStreams:             DefaultMaxConcurrentStreams,
 		ExperimentalWarningApplyDuration: DefaultWarningApplyDuration,
 
 		ExperimentalWarningUnaryRequestDuration: DefaultWarningUnaryRequestDuration,
 
+		ExperimentalAutoCompactionMaxDeferral: DefaultAutoCompactionMaxDeferral,
+
+		ExperimentalWatchEventPrefixMetricsDepth: DefaultWatchEventPrefixMetricsDepth,
+
 		GRPCKeepAliveMinTime:  DefaultGRPCKeepAliveMinTime,
 		GRPCKeepAliveInterval: DefaultGRPCKeepAliveInterval,
 		GRPCKeepAliveTimeout:  DefaultGRPCKeepAliveTimeout,
@@ -699,6 +872,10 @@ func (cfg *Config) Validate() error {
 		return ErrConflictBootstrapFlags
 	}
 
+	if cfg.InitialClusterFromSnapshot != "" && (cfg.ClusterState != ClusterStateFlagNew || cfg.InitialCluster == "") {
+		return errors.New("--initial-cluster-from-snapshot requires --initial-cluster-state=new and --initial-cluster")
+	}
+
 	// Check if both v2 discovery and v3 discovery flags are passed.
 	v2discoveryFlagsExist := cfg.Dproxy != ""
 	v3discoveryFlagsExist := len(cfg.DiscoveryCfg.Endpoints) > 0 ||
@@ -746,6 +923,21 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("unknown auto-compaction-mode %q", cfg.AutoCompactionMode)
 	}
 
+	if cfg.AutoBackupInterval < 0 {
+		return fmt.Errorf("--auto-backup-interval must be >=0 (set to %v)", cfg.AutoBackupInterval)
+	}
+	if cfg.AutoBackupInterval > 0 {
+		if cfg.AutoBackupDir == "" {
+			return errors.New("--auto-backup-interval requires --auto-backup-dir")
+		}
+		if strings.Contains(cfg.AutoBackupDir, "://") {
+			return fmt.Errorf("--auto-backup-dir must be a local directory, got %q", cfg.AutoBackupDir)
+		}
+	}
+	if cfg.AutoBackupRetention < 0 {
+		return fmt.Errorf("--auto-backup-retention must be >=0 (set to %d)", cfg.AutoBackupRetention)
+	}
+
 	// Validate distributed tracing configuration but only if enabled.
 	if cfg.ExperimentalEnableDistributedTracing {
 		if err := validateTracingConfig(cfg.ExperimentalDistributedTracingSamplingRatePerMillion); err != nil {
@@ -753,12 +945,51 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
-	if !cfg.ExperimentalEnableLeaseCheckpointPersist && cfg.ExperimentalEnableLeaseCheckpoint {
-		cfg.logger.Warn("Detected that checkpointing is enabled without persistence. Consider enabling experimental-enable-lease-checkpoint-persist")
+	if cfg.ExperimentalEnableLeaseCheckpoint {
+		cfg.logger.Warn("--experimental-enable-lease-checkpoint is deprecated in v3.6 and will be decommissioned in v3.7, use --lease-checkpoint instead")
+	}
+
+	if cfg.ExperimentalEnableLeaseCheckpointPersist {
+		cfg.logger.Warn("--experimental-enable-lease-checkpoint-persist is deprecated in v3.6 and will be decommissioned in v3.7, lease checkpoints are always persisted")
+		if !cfg.leaseCheckpointEnabled() {
+			return fmt.Errorf("setting experimental-enable-lease-checkpoint-persist requires lease-checkpoint")
+		}
+	}
+
+	if cfg.LeaseCheckpointInterval < 0 {
+		return fmt.Errorf("--lease-checkpoint-interval must be >=0 (set to %v)", cfg.LeaseCheckpointInterval)
+	}
+
+	if _, err := etcdserver.ParseRateLimits(cfg.ExperimentalRateLimits); err != nil {
+		return fmt.Errorf("--experimental-rate-limits is not valid: %v", err)
+	}
+
+	if _, err := etcdserver.ParsePrefixTTLs(cfg.ExperimentalPrefixTTLs); err != nil {
+		return fmt.Errorf("--experimental-prefix-ttls is not valid: %v", err)
+	}
+
+	if _, err := cfg.clientListenerAPIGroups(); err != nil {
+		return fmt.Errorf("--experimental-client-listener-api-groups is not valid: %v", err)
+	}
+
+	if _, err := wal.ParseCompression(cfg.WALCompression); err != nil {
+		return fmt.Errorf("--wal-compression is not valid: %v", err)
+	}
+
+	if cfg.SnapshotSendRateLimit < 0 {
+		return fmt.Errorf("--snapshot-send-rate-limit must be >=0 (set to %d)", cfg.SnapshotSendRateLimit)
 	}
 
-	if cfg.ExperimentalEnableLeaseCheckpointPersist && !cfg.ExperimentalEnableLeaseCheckpoint {
-		return fmt.Errorf("setting experimental-enable-lease-checkpoint-persist requires experimental-enable-lease-checkpoint")
+	if cfg.ExperimentalRaftEntryCompressionThreshold < 0 {
+		return fmt.Errorf("--experimental-raft-entry-compression-threshold must be >=0 (set to %d)", cfg.ExperimentalRaftEntryCompressionThreshold)
+	}
+
+	if cfg.ExperimentalPeerMsgAppBatchBytes < 0 {
+		return fmt.Errorf("--experimental-peer-msgapp-batch-bytes must be >=0 (set to %d)", cfg.ExperimentalPeerMsgAppBatchBytes)
+	}
+
+	if cfg.ExperimentalLearnerHeartbeatInterval < 0 {
+		return fmt.Errorf("--experimental-learner-heartbeat-interval must be >=0 (set to %v)", cfg.ExperimentalLearnerHeartbeatInterval)
 	}
 
 	if cfg.ExperimentalCompactHashCheckTime <= 0 {
@@ -899,6 +1130,12 @@ func (cfg Config) InitialClusterFromName(name string) (ret string) {
 func (cfg Config) IsNewCluster() bool { return cfg.ClusterState == ClusterStateFlagNew }
 func (cfg Config) ElectionTicks() int { return int(cfg.ElectionMs / cfg.TickMs) }
 
+// leaseCheckpointEnabled returns true if lease checkpointing is enabled, by
+// LeaseCheckpoint or the deprecated ExperimentalEnableLeaseCheckpoint.
+func (cfg *Config) leaseCheckpointEnabled() bool {
+	return cfg.LeaseCheckpoint || cfg.ExperimentalEnableLeaseCheckpoint
+}
+
 func (cfg Config) V2DeprecationEffective() config.V2DeprecationEnum {
 	if cfg.V2Deprecation == "" {
 		return config.V2_DEPR_DEFAULT
@@ -1059,6 +1296,39 @@ func (cfg *Config) getLCURLs() (ss []string) {
 	return ss
 }
 
+// clientListenerAPIGroups returns the API groups served on the listen client
+// URLs by URL, parsed from ExperimentalClientListenerAPIGroups.
+func (cfg *Config) clientListenerAPIGroups() (map[string]v3rpc.APIGroupSet, error) {
+	lcurls := make(map[string]bool)
+	for _, u := range cfg.getLCURLs() {
+		lcurls[u] = true
+	}
+	groups := make(map[string]v3rpc.APIGroupSet)
+	for _, s := range cfg.ExperimentalClientListenerAPIGroups {
+		if s == "" {
+			continue
+		}
+		i := strings.LastIndex(s, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%q must be \"<listen-client-url>=<group>+<group>\"", s)
+		}
+		u, err := url.Parse(s[:i])
+		if err != nil {
+			return nil, err
+		}
+		if !lcurls[u.String()] {
+			return nil, fmt.Errorf("%q is not a listen client url", s[:i])
+		}
+		if _, ok := groups[u.String()]; ok {
+			return nil, fmt.Errorf("duplicate API groups of %q", s[:i])
+		}
+		if groups[u.String()], err = v3rpc.ParseAPIGroups(s[i+1:]); err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
 func (cfg *Config) getMetricsURLs() (ss []string) {
 	ss = make([]string, len(cfg.ListenMetricsUrls))
 	for i := range cfg.ListenMetricsUrls {
@@ -0,0 +1,138 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embed
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+)
+
+// redactedConfigValue replaces the secrets in the effective configuration.
+const redactedConfigValue = "[redacted]"
+
+// effectiveConfig returns the options of the configuration by their names in
+// the configuration file, the names of nested options joined by dots, with
+// the passwords redacted, including the ones of URLs.
+func (cfg *Config) effectiveConfig() map[string]string {
+	m := make(map[string]string)
+	flattenConfig(m, "", reflect.ValueOf(*cfg))
+
+	// the options translated from the configuration file
+	m["listen-peer-urls"] = joinURLs(cfg.LPUrls)
+	m["listen-client-urls"] = joinURLs(cfg.LCUrls)
+	m["initial-advertise-peer-urls"] = joinURLs(cfg.APUrls)
+	m["advertise-client-urls"] = joinURLs(cfg.ACUrls)
+	m["listen-metrics-urls"] = joinURLs(cfg.ListenMetricsUrls)
+	m["cors"] = joinSet(cfg.CORS)
+	m["host-whitelist"] = joinSet(cfg.HostWhitelist)
+	flattenSecurityConfig(m, "client-transport-security.", &cfg.ClientTLSInfo, cfg.ClientAutoTLS)
+	flattenSecurityConfig(m, "peer-transport-security.", &cfg.PeerTLSInfo, cfg.PeerAutoTLS)
+
+	for name, v := range m {
+		m[name] = redactConfigValue(name, v)
+	}
+	return m
+}
+
+// flattenConfig adds the fields of v with a JSON name to m, prefixed with
+// prefix, and the fields of its nested structs prefixed with their names.
+func flattenConfig(m map[string]string, prefix string, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.PkgPath != "" || name == "" || name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct {
+			flattenConfig(m, prefix+name+".", fv)
+			continue
+		}
+		m[prefix+name] = formatConfigValue(fv)
+	}
+}
+
+func formatConfigValue(v reflect.Value) string {
+	switch x := v.Interface().(type) {
+	case time.Duration:
+		return x.String()
+	case []string:
+		return strings.Join(x, ",")
+	}
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func flattenSecurityConfig(m map[string]string, prefix string, tls *transport.TLSInfo, autoTLS bool) {
+	m[prefix+"cert-file"] = tls.CertFile
+	m[prefix+"key-file"] = tls.KeyFile
+	m[prefix+"client-cert-file"] = tls.ClientCertFile
+	m[prefix+"client-key-file"] = tls.ClientKeyFile
+	m[prefix+"client-cert-auth"] = fmt.Sprint(tls.ClientCertAuth)
+	m[prefix+"trusted-ca-file"] = tls.TrustedCAFile
+	m[prefix+"auto-tls"] = fmt.Sprint(autoTLS)
+}
+
+func joinURLs(us []url.URL) string {
+	ss := make([]string, len(us))
+	for i := range us {
+		ss[i] = us[i].String()
+	}
+	return strings.Join(ss, ",")
+}
+
+func joinSet(set map[string]struct{}) string {
+	ss := make([]string, 0, len(set))
+	for s := range set {
+		ss = append(ss, s)
+	}
+	sort.Strings(ss)
+	return strings.Join(ss, ",")
+}
+
+// redactConfigValue redacts the value of a password option, and the
+// passwords of the URLs of a value.
+func redactConfigValue(name, v string) string {
+	if v == "" {
+		return v
+	}
+	if strings.Contains(name[strings.LastIndex(name, ".")+1:], "password") {
+		return redactedConfigValue
+	}
+	ss := strings.Split(v, ",")
+	for i, s := range ss {
+		if u, err := url.Parse(s); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				ss[i] = u.Redacted()
+			}
+		}
+	}
+	return strings.Join(ss, ",")
+}
@@ -20,6 +20,7 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -27,6 +28,7 @@ import (
 	"go.etcd.io/etcd/client/pkg/v3/srv"
 	"go.etcd.io/etcd/client/pkg/v3/transport"
 	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3rpc"
 
 	"sigs.k8s.io/yaml"
 )
@@ -330,11 +332,76 @@ func TestLeaseCheckpointValidate(t *testing.T) {
 			name: "Enabling checkpoint leases persist without checkpointing itself should fail",
 			configFunc: func() Config {
 				cfg := *NewConfig()
+				cfg.LeaseCheckpoint = false
 				cfg.ExperimentalEnableLeaseCheckpointPersist = true
 				return cfg
 			},
 			expectError: true,
 		},
+		{
+			name: "Enabling checkpoint leases persist with default checkpointing should pass",
+			configFunc: func() Config {
+				cfg := *NewConfig()
+				cfg.ExperimentalEnableLeaseCheckpointPersist = true
+				return cfg
+			},
+		},
+		{
+			name: "Negative checkpoint interval should fail",
+			configFunc: func() Config {
+				cfg := *NewConfig()
+				cfg.LeaseCheckpointInterval = -time.Second
+				return cfg
+			},
+			expectError: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.configFunc()
+			err := cfg.Validate()
+			if (err != nil) != tc.expectError {
+				t.Errorf("config.Validate() = %q, expected error: %v", err, tc.expectError)
+			}
+		})
+	}
+}
+
+func TestInitialClusterFromSnapshotValidate(t *testing.T) {
+	tcs := []struct {
+		name        string
+		configFunc  func() Config
+		expectError bool
+	}{
+		{
+			name: "Restoring a new cluster should pass",
+			configFunc: func() Config {
+				cfg := *NewConfig()
+				cfg.InitialClusterFromSnapshot = "snapshot.db"
+				return cfg
+			},
+		},
+		{
+			name: "Restoring an existing cluster should fail",
+			configFunc: func() Config {
+				cfg := *NewConfig()
+				cfg.InitialClusterFromSnapshot = "snapshot.db"
+				cfg.ClusterState = ClusterStateFlagExisting
+				return cfg
+			},
+			expectError: true,
+		},
+		{
+			name: "Restoring a cluster bootstrapped by discovery should fail",
+			configFunc: func() Config {
+				cfg := *NewConfig()
+				cfg.InitialClusterFromSnapshot = "snapshot.db"
+				cfg.InitialCluster = ""
+				cfg.Durl = "http://discovery.example.com/token"
+				return cfg
+			},
+			expectError: true,
+		},
 	}
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
@@ -347,6 +414,60 @@ func TestLeaseCheckpointValidate(t *testing.T) {
 	}
 }
 
+func TestClientListenerAPIGroups(t *testing.T) {
+	tcs := []struct {
+		name       string
+		apiGroups  []string
+		wantGroups map[string]v3rpc.APIGroupSet
+		wantErr    bool
+	}{
+		{
+			name:       "no entry",
+			wantGroups: map[string]v3rpc.APIGroupSet{},
+		},
+		{
+			name:      "groups of a listen client url",
+			apiGroups: []string{"http://localhost:2379=kv+Watch+lease"},
+			wantGroups: map[string]v3rpc.APIGroupSet{
+				"http://localhost:2379": {v3rpc.APIGroupKV: {}, v3rpc.APIGroupWatch: {}, v3rpc.APIGroupLease: {}},
+			},
+		},
+		{
+			name:      "unknown url",
+			apiGroups: []string{"http://localhost:2381=maintenance"},
+			wantErr:   true,
+		},
+		{
+			name:      "unknown group",
+			apiGroups: []string{"http://localhost:2379=kv+v2"},
+			wantErr:   true,
+		},
+		{
+			name:      "duplicate url",
+			apiGroups: []string{"http://localhost:2379=kv", "http://localhost:2379=watch"},
+			wantErr:   true,
+		},
+		{
+			name:      "no groups",
+			apiGroups: []string{"http://localhost:2379"},
+			wantErr:   true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.ExperimentalClientListenerAPIGroups = tc.apiGroups
+			groups, err := cfg.clientListenerAPIGroups()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("clientListenerAPIGroups() error = %v, want error: %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(groups, tc.wantGroups) {
+				t.Errorf("clientListenerAPIGroups() = %v, want %v", groups, tc.wantGroups)
+			}
+		})
+	}
+}
+
 func TestLogRotation(t *testing.T) {
 	tests := []struct {
 		name              string
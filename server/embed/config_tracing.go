@@ -18,12 +18,14 @@ import (
 	"context"
 	"fmt"
 
+	"go.etcd.io/etcd/pkg/v3/traceutil"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -77,7 +79,7 @@ func newTracingExporter(ctx context.Context, cfg *Config) (*tracingExporter, err
 		tracesdk.WithBatcher(exporter),
 		tracesdk.WithResource(res),
 		tracesdk.WithSampler(
-			tracesdk.ParentBased(determineSampler(cfg.ExperimentalDistributedTracingSamplingRatePerMillion)),
+			forceSampler{tracesdk.ParentBased(determineSampler(cfg.ExperimentalDistributedTracingSamplingRatePerMillion))},
 		),
 	)
 
@@ -125,6 +127,26 @@ func determineSampler(samplingRate int) tracesdk.Sampler {
 	return tracesdk.TraceIDRatioBased(float64(samplingRate) / float64(maxSamplingRatePerMillion))
 }
 
+// forceSampler samples the requests selected by the trace rules of the server
+// and defers to the configured sampler for all other spans.
+type forceSampler struct {
+	tracesdk.Sampler
+}
+
+func (s forceSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	if force, _ := p.ParentContext.Value(traceutil.ForceKey).(bool); force {
+		return tracesdk.SamplingResult{
+			Decision:   tracesdk.RecordAndSample,
+			Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+		}
+	}
+	return s.Sampler.ShouldSample(p)
+}
+
+func (s forceSampler) Description() string {
+	return fmt.Sprintf("ForceSampler{%s}", s.Sampler.Description())
+}
+
 // As Tracing service Instance ID must be unique, it should
 // never use the empty default string value, it's set if
 // if it's a non empty string.
@@ -15,7 +15,11 @@
 package embed
 
 import (
+	"context"
 	"testing"
+
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 )
 
 const neverSampleDescription = "AlwaysOffSampler"
@@ -47,6 +51,34 @@ func TestDetermineSampler(t *testing.T) {
 	}
 }
 
+func TestForceSampler(t *testing.T) {
+	sampler := forceSampler{determineSampler(0)}
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		wantDecision tracesdk.SamplingDecision
+	}{
+		{
+			name:         "request not selected by a trace rule",
+			ctx:          context.Background(),
+			wantDecision: tracesdk.Drop,
+		},
+		{
+			name:         "request selected by a trace rule",
+			ctx:          context.WithValue(context.Background(), traceutil.ForceKey, true),
+			wantDecision: tracesdk.RecordAndSample,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := sampler.ShouldSample(tracesdk.SamplingParameters{ParentContext: tc.ctx})
+			if res.Decision != tc.wantDecision {
+				t.Errorf("sampling decision = %v, want %v", res.Decision, tc.wantDecision)
+			}
+		})
+	}
+}
+
 func TestTracingConfig(t *testing.T) {
 	tests := []struct {
 		name       string
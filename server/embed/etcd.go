@@ -34,11 +34,14 @@ import (
 	"go.etcd.io/etcd/client/pkg/v3/types"
 	"go.etcd.io/etcd/pkg/v3/debugutil"
 	runtimeutil "go.etcd.io/etcd/pkg/v3/runtime"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
 	"go.etcd.io/etcd/server/v3/config"
 	"go.etcd.io/etcd/server/v3/etcdserver"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/etcdhttp"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/rafthttp"
+	"go.etcd.io/etcd/server/v3/etcdserver/restore"
 	"go.etcd.io/etcd/server/v3/storage"
+	"go.etcd.io/etcd/server/v3/storage/wal"
 	"go.etcd.io/etcd/server/v3/verify"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
@@ -149,6 +152,25 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 		if err != nil {
 			return e, fmt.Errorf("error setting up initial cluster: %v", err)
 		}
+		if cfg.InitialClusterFromSnapshot != "" {
+			err = restore.Restore(cfg.logger, restore.Config{
+				SnapshotPath:        cfg.InitialClusterFromSnapshot,
+				Name:                cfg.Name,
+				OutputDataDir:       cfg.Dir,
+				OutputWALDir:        cfg.WalDir,
+				PeerURLs:            cfg.getAPURLs(),
+				InitialCluster:      cfg.InitialCluster,
+				InitialClusterToken: token,
+			})
+			if err != nil {
+				return e, fmt.Errorf("error restoring initial cluster from snapshot: %v", err)
+			}
+		}
+	} else if cfg.ExperimentalStaleDataDirRecovery {
+		// the peers of the initial cluster tell whether the data dir is stale
+		if urlsmap, token, err = cfg.PeerURLsMapAndToken("etcd"); err != nil {
+			return e, fmt.Errorf("error setting up initial cluster: %v", err)
+		}
 	}
 
 	// AutoCompactionRetention defaults to "0" if not set.
@@ -162,14 +184,43 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 
 	backendFreelistType := parseBackendFreelistType(cfg.BackendFreelistType)
 
+	rateLimits, err := etcdserver.ParseRateLimits(cfg.ExperimentalRateLimits)
+	if err != nil {
+		return e, err
+	}
+	prefixTTLs, err := etcdserver.ParsePrefixTTLs(cfg.ExperimentalPrefixTTLs)
+	if err != nil {
+		return e, err
+	}
+	walCompression, err := wal.ParseCompression(cfg.WALCompression)
+	if err != nil {
+		return e, err
+	}
+
 	srvcfg := config.ServerConfig{
 		Name:                                     cfg.Name,
 		ClientURLs:                               cfg.ACUrls,
 		PeerURLs:                                 cfg.APUrls,
 		DataDir:                                  cfg.Dir,
 		DedicatedWALDir:                          cfg.WalDir,
+		WALCompression:                           walCompression,
 		SnapshotCount:                            cfg.SnapshotCount,
 		SnapshotCatchUpEntries:                   cfg.SnapshotCatchUpEntries,
+		SnapshotSendRateLimit:                    cfg.SnapshotSendRateLimit,
+		RaftLogRetentionMaxBytes:                 cfg.ExperimentalRaftLogRetentionMaxBytes,
+		MemoryBudgetBytes:                        cfg.ExperimentalMemoryBudget,
+		SlowFollowerAlarmThreshold:               cfg.ExperimentalSlowFollowerAlarmThreshold,
+		CertExpiryAlarmWindow:                    cfg.ExperimentalCertExpiryAlarmWindow,
+		StandbyOf:                                cfg.ExperimentalStandbyOf,
+		StaleDataDirRecovery:                     cfg.ExperimentalStaleDataDirRecovery,
+		LearnerSerializableReads:                 cfg.ExperimentalLearnerSerializableReads,
+		PrefixTTLs:                               prefixTTLs,
+		RaftEntryCompressionThreshold:            cfg.ExperimentalRaftEntryCompressionThreshold,
+		PeerMsgAppBatchBytes:                     cfg.ExperimentalPeerMsgAppBatchBytes,
+		LearnerHeartbeatInterval:                 cfg.ExperimentalLearnerHeartbeatInterval,
+		MaxKeys:                                  cfg.ExperimentalMaxKeys,
+		MaxApplyBacklog:                          cfg.ExperimentalMaxApplyBacklog,
+		MaxPendingProposals:                      cfg.ExperimentalMaxPendingProposals,
 		MaxSnapFiles:                             cfg.MaxSnapFiles,
 		MaxWALFiles:                              cfg.MaxWalFiles,
 		InitialPeerURLsMap:                       urlsmap,
@@ -179,12 +230,21 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 		DiscoveryCfg:                             cfg.DiscoveryCfg,
 		NewCluster:                               cfg.IsNewCluster(),
 		PeerTLSInfo:                              cfg.PeerTLSInfo,
+		ClientTLSInfo:                            cfg.ClientTLSInfo,
 		TickMs:                                   cfg.TickMs,
 		ElectionTicks:                            cfg.ElectionTicks(),
 		WaitClusterReadyTimeout:                  cfg.ExperimentalWaitClusterReadyTimeout,
 		InitialElectionTickAdvance:               cfg.InitialElectionTickAdvance,
 		AutoCompactionRetention:                  autoCompactionRetention,
 		AutoCompactionMode:                       cfg.AutoCompactionMode,
+		AutoCompactionMaxLatency:                 cfg.ExperimentalAutoCompactionMaxLatency,
+		AutoCompactionMaxPendingProposals:        cfg.ExperimentalAutoCompactionMaxPendingProposals,
+		AutoCompactionMaxDeferral:                cfg.ExperimentalAutoCompactionMaxDeferral,
+		AutoBackupInterval:                       cfg.AutoBackupInterval,
+		AutoBackupDir:                            cfg.AutoBackupDir,
+		AutoBackupRetention:                      cfg.AutoBackupRetention,
+		AutoPromoteLearners:                      cfg.AutoPromoteLearners,
+		AutoPromoteLearnersMaxLag:                cfg.AutoPromoteLearnersMaxLag,
 		QuotaBackendBytes:                        cfg.QuotaBackendBytes,
 		BackendBatchLimit:                        cfg.BackendBatchLimit,
 		BackendFreelistType:                      backendFreelistType,
@@ -209,12 +269,28 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 		ForceNewCluster:                          cfg.ForceNewCluster,
 		EnableGRPCGateway:                        cfg.EnableGRPCGateway,
 		ExperimentalEnableDistributedTracing:     cfg.ExperimentalEnableDistributedTracing,
+		TraceRules:                               traceutil.ParseRules(cfg.ExperimentalTraceKeyPrefixes),
 		UnsafeNoFsync:                            cfg.UnsafeNoFsync,
-		EnableLeaseCheckpoint:                    cfg.ExperimentalEnableLeaseCheckpoint,
-		LeaseCheckpointPersist:                   cfg.ExperimentalEnableLeaseCheckpointPersist,
+		EnableLeaseCheckpoint:                    cfg.leaseCheckpointEnabled(),
+		LeaseCheckpointInterval:                  cfg.LeaseCheckpointInterval,
+		LeaseCheckpointPersist:                   cfg.leaseCheckpointEnabled(),
+		LeaseRevokeHooks:                         cfg.LeaseRevokeHooks,
+		LeaseRevokeWebhookURL:                    cfg.ExperimentalLeaseRevokeWebhookURL,
 		CompactionBatchLimit:                     cfg.ExperimentalCompactionBatchLimit,
 		CompactionSleepInterval:                  cfg.ExperimentalCompactionSleepInterval,
+		CompactionBatchLatency:                   cfg.ExperimentalCompactionBatchLatency,
 		WatchProgressNotifyInterval:              cfg.ExperimentalWatchProgressNotifyInterval,
+		MaxWatchersPerConnection:                 cfg.ExperimentalMaxWatchersPerConnection,
+		MaxWatchersPerUser:                       cfg.ExperimentalMaxWatchersPerUser,
+		RateLimits:                               rateLimits,
+		EffectiveConfig:                          cfg.effectiveConfig(),
+		WatchEventCacheSize:                      cfg.ExperimentalWatchEventCacheSize,
+		KeyPrefixBuckets:                         cfg.ExperimentalKeyPrefixBuckets,
+		WatchEventPrefixMetrics:                  cfg.ExperimentalWatchEventPrefixMetrics,
+		WatchEventPrefixMetricsDepth:             cfg.ExperimentalWatchEventPrefixMetricsDepth,
+		ResponseHeaderCompactRevision:            cfg.ExperimentalResponseHeaderCompactRevision,
+		WatchSlowWatchersAlertThreshold:          cfg.ExperimentalWatchSlowWatchersAlertThreshold,
+		WatchPendingEventsAlertThreshold:         cfg.ExperimentalWatchPendingEventsAlertThreshold,
 		DowngradeCheckTime:                       cfg.ExperimentalDowngradeCheckTime,
 		WarningApplyDuration:                     cfg.ExperimentalWarningApplyDuration,
 		WarningUnaryRequestDuration:              cfg.ExperimentalWarningUnaryRequestDuration,
@@ -235,6 +311,7 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 			tracingExporter.Close(tctx)
 		}
 		srvcfg.ExperimentalTracerOptions = tracingExporter.opts
+		srvcfg.ExperimentalTracerProvider = tracingExporter.provider
 
 		e.cfg.logger.Info(
 			"distributed tracing setup enabled",
@@ -320,6 +397,7 @@ func print(lg *zap.Logger, ec Config, sc config.ServerConfig, memberInitialized
 		zap.String("data-dir", sc.DataDir),
 		zap.String("wal-dir", ec.WalDir),
 		zap.String("wal-dir-dedicated", sc.DedicatedWALDir),
+		zap.String("wal-compression", sc.WALCompression.String()),
 		zap.String("member-dir", sc.MemberDir()),
 		zap.Bool("force-new-cluster", sc.ForceNewCluster),
 		zap.String("heartbeat-interval", fmt.Sprintf("%v", time.Duration(sc.TickMs)*time.Millisecond)),
@@ -330,6 +408,30 @@ func print(lg *zap.Logger, ec Config, sc config.ServerConfig, memberInitialized
 		zap.Uint("max-wals", sc.MaxWALFiles),
 		zap.Uint("max-snapshots", sc.MaxSnapFiles),
 		zap.Uint64("snapshot-catchup-entries", sc.SnapshotCatchUpEntries),
+		zap.Int64("snapshot-send-rate-limit", sc.SnapshotSendRateLimit),
+		zap.Uint64("raft-log-retention-max-bytes", sc.RaftLogRetentionMaxBytes),
+		zap.Uint64("memory-budget", sc.MemoryBudgetBytes),
+		zap.Int("slow-follower-alarm-threshold", sc.SlowFollowerAlarmThreshold),
+		zap.Duration("cert-expiry-alarm-window", sc.CertExpiryAlarmWindow),
+		zap.Strings("standby-of", sc.StandbyOf),
+		zap.Bool("stale-data-dir-recovery", sc.StaleDataDirRecovery),
+		zap.Bool("learner-serializable-reads", sc.LearnerSerializableReads),
+		zap.Strings("prefix-ttls", ec.ExperimentalPrefixTTLs),
+		zap.Int("raft-entry-compression-threshold", sc.RaftEntryCompressionThreshold),
+		zap.Int("peer-msgapp-batch-bytes", sc.PeerMsgAppBatchBytes),
+		zap.Duration("learner-heartbeat-interval", sc.LearnerHeartbeatInterval),
+		zap.Int64("max-keys", sc.MaxKeys),
+		zap.Uint64("max-apply-backlog", sc.MaxApplyBacklog),
+		zap.Uint64("max-pending-proposals", sc.MaxPendingProposals),
+		zap.Int("max-watchers-per-connection", sc.MaxWatchersPerConnection),
+		zap.Int("max-watchers-per-user", sc.MaxWatchersPerUser),
+		zap.Int("rate-limits", len(sc.RateLimits)),
+		zap.Int("watch-slow-watchers-alert-threshold", sc.WatchSlowWatchersAlertThreshold),
+		zap.Int("watch-pending-events-alert-threshold", sc.WatchPendingEventsAlertThreshold),
+		zap.Strings("key-prefix-buckets", sc.KeyPrefixBuckets),
+		zap.Strings("watch-event-prefix-metrics", sc.WatchEventPrefixMetrics),
+		zap.Int("watch-event-prefix-metrics-depth", sc.WatchEventPrefixMetricsDepth),
+		zap.Bool("response-header-compact-revision", sc.ResponseHeaderCompactRevision),
 		zap.Strings("initial-advertise-peer-urls", ec.getAPURLs()),
 		zap.Strings("listen-peer-urls", ec.getLPURLs()),
 		zap.Strings("advertise-client-urls", ec.getACURLs()),
@@ -340,6 +442,7 @@ func print(lg *zap.Logger, ec Config, sc config.ServerConfig, memberInitialized
 		zap.String("initial-cluster", sc.InitialPeerURLsMap.String()),
 		zap.String("initial-cluster-state", ec.ClusterState),
 		zap.String("initial-cluster-token", sc.InitialClusterToken),
+		zap.String("initial-cluster-from-snapshot", ec.InitialClusterFromSnapshot),
 		zap.Int64("quota-backend-bytes", quota),
 		zap.Uint("max-request-bytes", sc.MaxRequestBytes),
 		zap.Uint32("max-concurrent-streams", sc.MaxConcurrentStreams),
@@ -352,6 +455,17 @@ func print(lg *zap.Logger, ec Config, sc config.ServerConfig, memberInitialized
 		zap.String("auto-compaction-mode", sc.AutoCompactionMode),
 		zap.Duration("auto-compaction-retention", sc.AutoCompactionRetention),
 		zap.String("auto-compaction-interval", sc.AutoCompactionRetention.String()),
+		zap.Duration("auto-compaction-max-latency", sc.AutoCompactionMaxLatency),
+		zap.Uint64("auto-compaction-max-pending-proposals", sc.AutoCompactionMaxPendingProposals),
+		zap.Duration("auto-compaction-max-deferral", sc.AutoCompactionMaxDeferral),
+		zap.Duration("compaction-batch-latency", sc.CompactionBatchLatency),
+		zap.Duration("auto-backup-interval", sc.AutoBackupInterval),
+		zap.String("auto-backup-dir", sc.AutoBackupDir),
+		zap.Int("auto-backup-retention", sc.AutoBackupRetention),
+		zap.Bool("auto-promote-learners", sc.AutoPromoteLearners),
+		zap.Uint64("auto-promote-learners-max-lag", sc.AutoPromoteLearnersMaxLag),
+		zap.Bool("lease-checkpoint", sc.EnableLeaseCheckpoint),
+		zap.Duration("lease-checkpoint-interval", sc.LeaseCheckpointInterval),
 		zap.String("discovery-url", sc.DiscoveryURL),
 		zap.String("discovery-proxy", sc.DiscoveryProxy),
 
@@ -404,6 +518,10 @@ func (e *Etcd) Close() {
 		close(e.stopc)
 	})
 
+	if e.Server != nil && e.cfg.ExperimentalShutdownDrainTimeout > 0 {
+		e.drainServers(e.cfg.ExperimentalShutdownDrainTimeout)
+	}
+
 	// close client requests with request timeout
 	timeout := 2 * time.Second
 	if e.Server != nil {
@@ -454,6 +572,33 @@ func (e *Etcd) Close() {
 	}
 }
 
+// drainServers stops all the client servers at once, so that they stop
+// accepting connections and send GOAWAY to their clients, then drains the
+// client streams and waits for them to end for up to the given timeout,
+// giving the clients the time to re-establish them on other members.
+func (e *Etcd) drainServers(timeout time.Duration) {
+	lg := e.GetLogger()
+	lg.Info("draining client streams", zap.Duration("timeout", timeout))
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, sctx := range e.sctxs {
+		for ss := range sctx.serversC {
+			wg.Add(1)
+			go func(ss *servers) {
+				defer wg.Done()
+				stopServers(ctx, ss)
+			}(ss)
+		}
+	}
+	e.Server.Drain()
+	wg.Wait()
+
+	lg.Info("drained client streams", zap.Duration("took", time.Since(start)))
+}
+
 func stopServers(ctx context.Context, ss *servers) {
 	// first, close the http.Server
 	ss.http.Shutdown(ctx)
@@ -537,6 +682,7 @@ func configurePeerListeners(cfg *Config) (peers []*peerListener, err error) {
 		}
 		peers[i] = &peerListener{close: func(context.Context) error { return nil }}
 		peers[i].Listener, err = transport.NewListenerWithOpts(u.Host, u.Scheme,
+			transport.WithNetwork(listenNetwork(u, cfg.LPUrls)),
 			transport.WithTLSInfo(&cfg.PeerTLSInfo),
 			transport.WithSocketOpts(&cfg.SocketOpts),
 			transport.WithTimeout(rafthttp.ConnReadTimeout, rafthttp.ConnWriteTimeout),
@@ -552,6 +698,28 @@ func configurePeerListeners(cfg *Config) (peers []*peerListener, err error) {
 	return peers, nil
 }
 
+// listenNetwork returns the network to listen on for u. An IP address is
+// bound to its own address family when another URL listens on the same port
+// with an address of the other family, so that per-family URLs such as
+// http://0.0.0.0:2379 and http://[::]:2379 can be served side by side.
+func listenNetwork(u url.URL, urls []url.URL) string {
+	ip := net.ParseIP(u.Hostname())
+	if ip == nil {
+		return "tcp"
+	}
+	for _, other := range urls {
+		oip := net.ParseIP(other.Hostname())
+		if oip == nil || other.Port() != u.Port() || (oip.To4() == nil) == (ip.To4() == nil) {
+			continue
+		}
+		if ip.To4() != nil {
+			return "tcp4"
+		}
+		return "tcp6"
+	}
+	return "tcp"
+}
+
 // configure peer handlers after rafthttp.Transport started
 func (e *Etcd) servePeers() (err error) {
 	ph := etcdhttp.NewPeerHandler(e.GetLogger(), e.Server)
@@ -615,9 +783,15 @@ func configureClientListeners(cfg *Config) (sctxs map[string]*serveCtx, err erro
 		cfg.logger.Info("pprof is enabled", zap.String("path", debugutil.HTTPPrefixPProf))
 	}
 
+	apiGroups, err := cfg.clientListenerAPIGroups()
+	if err != nil {
+		return nil, err
+	}
+
 	sctxs = make(map[string]*serveCtx)
 	for _, u := range cfg.LCUrls {
 		sctx := newServeCtx(cfg.logger)
+		sctx.apiGroups = apiGroups[u.String()]
 		if u.Scheme == "http" || u.Scheme == "unix" {
 			if !cfg.ClientTLSInfo.Empty() {
 				cfg.logger.Warn("scheme is HTTP while key and cert files are present; ignoring key and cert files", zap.String("client-url", u.String()))
@@ -643,10 +817,21 @@ func configureClientListeners(cfg *Config) (sctxs map[string]*serveCtx, err erro
 		if oldctx := sctxs[addr]; oldctx != nil {
 			oldctx.secure = oldctx.secure || sctx.secure
 			oldctx.insecure = oldctx.insecure || sctx.insecure
+			// the URLs of the same address share its listener, which serves
+			// the API groups of any of them
+			if oldctx.apiGroups != nil {
+				if sctx.apiGroups == nil {
+					oldctx.apiGroups = nil
+				}
+				for g := range sctx.apiGroups {
+					oldctx.apiGroups[g] = struct{}{}
+				}
+			}
 			continue
 		}
 
 		if sctx.l, err = transport.NewListenerWithOpts(addr, u.Scheme,
+			transport.WithNetwork(listenNetwork(u, cfg.LCUrls)),
 			transport.WithSocketOpts(&cfg.SocketOpts),
 			transport.WithSkipTLSInfoCheck(true),
 		); err != nil {
@@ -749,6 +934,7 @@ func (e *Etcd) serveMetrics() (err error) {
 				tlsInfo = nil
 			}
 			ml, err := transport.NewListenerWithOpts(murl.Host, murl.Scheme,
+				transport.WithNetwork(listenNetwork(murl, e.cfg.ListenMetricsUrls)),
 				transport.WithTLSInfo(tlsInfo),
 				transport.WithSocketOpts(&e.cfg.SocketOpts),
 			)
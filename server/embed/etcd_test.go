@@ -0,0 +1,84 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embed
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestListenNetwork(t *testing.T) {
+	mustURL := func(s string) url.URL {
+		u, err := url.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *u
+	}
+	tests := []struct {
+		name string
+		url  string
+		urls []string
+		want string
+	}{
+		{
+			name: "single IPv4 URL",
+			url:  "http://0.0.0.0:2379",
+			urls: []string{"http://0.0.0.0:2379"},
+			want: "tcp",
+		},
+		{
+			name: "single IPv6 URL",
+			url:  "http://[::]:2379",
+			urls: []string{"http://[::]:2379"},
+			want: "tcp",
+		},
+		{
+			name: "IPv4 URL with IPv6 URL on same port",
+			url:  "http://0.0.0.0:2379",
+			urls: []string{"http://0.0.0.0:2379", "http://[::]:2379"},
+			want: "tcp4",
+		},
+		{
+			name: "IPv6 URL with IPv4 URL on same port",
+			url:  "https://[::]:2379",
+			urls: []string{"http://0.0.0.0:2379", "https://[::]:2379"},
+			want: "tcp6",
+		},
+		{
+			name: "IPv6 URL with IPv4 URL on other port",
+			url:  "http://[::]:2379",
+			urls: []string{"http://127.0.0.1:22379", "http://[::]:2379"},
+			want: "tcp",
+		},
+		{
+			name: "localhost with IPv6 URL on same port",
+			url:  "http://localhost:2379",
+			urls: []string{"http://localhost:2379", "http://[::1]:2379"},
+			want: "tcp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var urls []url.URL
+			for _, u := range tt.urls {
+				urls = append(urls, mustURL(u))
+			}
+			if got := listenNetwork(mustURL(tt.url), urls); got != tt.want {
+				t.Errorf("listenNetwork(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
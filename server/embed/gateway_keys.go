@@ -0,0 +1,85 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embed
+
+import (
+	"encoding/json"
+	"io"
+
+	"go.etcd.io/etcd/client/v3/gateway"
+
+	gw "github.com/grpc-ecosystem/grpc-gateway/runtime"
+)
+
+// keyEncodingMarshaler is the gateway marshaler of the JSON bodies with the
+// keys in another encoding than the standard base64 of protobuf.
+type keyEncodingMarshaler struct {
+	*gw.JSONPb
+	enc gateway.KeyEncoding
+}
+
+// keyEncodingMarshalerOptions returns the options registering a marshaler
+// for the media type of each key encoding.
+func keyEncodingMarshalerOptions() []gw.ServeMuxOption {
+	var opts []gw.ServeMuxOption
+	for _, enc := range gateway.KeyEncodings {
+		if enc == gateway.KeyEncodingBase64 {
+			continue
+		}
+		m := &keyEncodingMarshaler{JSONPb: &gw.JSONPb{OrigName: true}, enc: enc}
+		opts = append(opts, gw.WithMarshalerOption(enc.MIMEType(), m))
+	}
+	return opts
+}
+
+func (m *keyEncodingMarshaler) ContentType() string { return m.enc.MIMEType() }
+
+func (m *keyEncodingMarshaler) Marshal(v interface{}) ([]byte, error) {
+	data, err := m.JSONPb.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return gateway.Transcode(data, gateway.KeyEncodingBase64, m.enc)
+}
+
+func (m *keyEncodingMarshaler) Unmarshal(data []byte, v interface{}) error {
+	data, err := gateway.Transcode(data, m.enc, gateway.KeyEncodingBase64)
+	if err != nil {
+		return err
+	}
+	return m.JSONPb.Unmarshal(data, v)
+}
+
+func (m *keyEncodingMarshaler) NewDecoder(r io.Reader) gw.Decoder {
+	d := json.NewDecoder(r)
+	return gw.DecoderFunc(func(v interface{}) error {
+		var data json.RawMessage
+		if err := d.Decode(&data); err != nil {
+			return err
+		}
+		return m.Unmarshal(data, v)
+	})
+}
+
+func (m *keyEncodingMarshaler) NewEncoder(w io.Writer) gw.Encoder {
+	return gw.EncoderFunc(func(v interface{}) error {
+		data, err := m.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embed
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Partitions is a set of independent etcd servers, called partitions,
+// hosted in one process. Every partition runs its own raft group over its
+// own keyspace, data directory and listeners, so that sharded workloads
+// can scale writes beyond a single raft group. Requests, revisions, leases
+// and transactions never span partitions.
+//
+// Experimental: partitions may change or be removed in a future release.
+type Partitions struct {
+	names []string
+	etcds map[string]*Etcd
+
+	errc   chan error
+	readyc chan struct{}
+}
+
+// StartPartitions launches an etcd server for each partition, keyed by
+// the name of the partition. The partitions must not share data
+// directories. Like StartEtcd, it does not wait for the partitions to join
+// their clusters; wait on ReadyNotify for that.
+func StartPartitions(cfgs map[string]*Config) (ps *Partitions, err error) {
+	if len(cfgs) == 0 {
+		return nil, fmt.Errorf("no partition given")
+	}
+	ps = &Partitions{etcds: make(map[string]*Etcd, len(cfgs)), readyc: make(chan struct{})}
+	dirs := make(map[string]string, len(cfgs))
+	for name, cfg := range cfgs {
+		if name == "" {
+			return nil, fmt.Errorf("partition name is empty")
+		}
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("partition %q has no data directory", name)
+		}
+		dir := filepath.Clean(cfg.Dir)
+		if other, ok := dirs[dir]; ok {
+			return nil, fmt.Errorf("partitions %q and %q share data directory %q", other, name, cfg.Dir)
+		}
+		dirs[dir] = name
+		ps.names = append(ps.names, name)
+	}
+	sort.Strings(ps.names)
+
+	defer func() {
+		if err != nil {
+			ps.Close()
+			ps = nil
+		}
+	}()
+	for _, name := range ps.names {
+		e, serr := StartEtcd(cfgs[name])
+		if serr != nil {
+			return ps, fmt.Errorf("cannot start partition %q: %w", name, serr)
+		}
+		ps.etcds[name] = e
+	}
+
+	ps.errc = make(chan error, len(ps.names))
+	var wg sync.WaitGroup
+	for _, name := range ps.names {
+		wg.Add(1)
+		go func(name string, e *Etcd) {
+			defer wg.Done()
+			for err := range e.Err() {
+				ps.errc <- fmt.Errorf("partition %q: %w", name, err)
+			}
+		}(name, ps.etcds[name])
+	}
+	go func() {
+		wg.Wait()
+		close(ps.errc)
+	}()
+	go func() {
+		for _, name := range ps.names {
+			select {
+			case <-ps.etcds[name].Server.ReadyNotify():
+			case <-ps.etcds[name].Server.StopNotify():
+				return
+			}
+		}
+		close(ps.readyc)
+	}()
+	return ps, nil
+}
+
+// Names returns the sorted names of the partitions.
+func (ps *Partitions) Names() []string {
+	return append([]string(nil), ps.names...)
+}
+
+// Etcd returns the etcd server of the named partition, or nil if there is
+// no such partition.
+func (ps *Partitions) Etcd(name string) *Etcd {
+	return ps.etcds[name]
+}
+
+// ReadyNotify returns a channel closed once the etcd servers of all
+// partitions are ready to serve client requests.
+func (ps *Partitions) ReadyNotify() <-chan struct{} {
+	return ps.readyc
+}
+
+// Err returns a channel reporting the errors of the etcd servers of the
+// partitions, prefixed by the name of their partition. The channel is
+// closed once all of them are over.
+func (ps *Partitions) Err() <-chan error {
+	return ps.errc
+}
+
+// Close gracefully shuts down the etcd servers of all partitions.
+func (ps *Partitions) Close() {
+	var wg sync.WaitGroup
+	for _, e := range ps.etcds {
+		wg.Add(1)
+		go func(e *Etcd) {
+			defer wg.Done()
+			e.Close()
+		}(e)
+	}
+	wg.Wait()
+}
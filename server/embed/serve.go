@@ -40,6 +40,7 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3lock/v3lockpb"
 	v3lockgw "go.etcd.io/etcd/server/v3/etcdserver/api/v3lock/v3lockpb/gw"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3rpc"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3session"
 
 	gw "github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"github.com/soheilhy/cmux"
@@ -65,6 +66,9 @@ type serveCtx struct {
 	userHandlers    map[string]http.Handler
 	serviceRegister func(*grpc.Server)
 	serversC        chan *servers
+
+	// apiGroups are the API groups served on the listener, all of them if nil.
+	apiGroups v3rpc.APIGroupSet
 }
 
 type servers struct {
@@ -87,6 +91,17 @@ func newServeCtx(lg *zap.Logger) *serveCtx {
 	}
 }
 
+// registerConcurrencyServers registers the election and lock services, if
+// their API groups are served on the listener.
+func (sctx *serveCtx) registerConcurrencyServers(gs *grpc.Server, servElection v3electionpb.ElectionServer, servLock v3lockpb.LockServer) {
+	if sctx.apiGroups.Has(v3rpc.APIGroupElection) {
+		v3electionpb.RegisterElectionServer(gs, servElection)
+	}
+	if sctx.apiGroups.Has(v3rpc.APIGroupLock) {
+		v3lockpb.RegisterLockServer(gs, servLock)
+	}
+}
+
 // serve accepts incoming connections on the listener l,
 // creating a new service goroutine for each. The service goroutines
 // read requests and then call handler to reply to them.
@@ -127,9 +142,8 @@ func (sctx *serveCtx) serve(
 	defer close(sctx.serversC)
 
 	if sctx.insecure {
-		gs = v3rpc.Server(s, nil, nil, gopts...)
-		v3electionpb.RegisterElectionServer(gs, servElection)
-		v3lockpb.RegisterLockServer(gs, servLock)
+		gs = v3rpc.ServerWithAPIGroups(s, nil, nil, sctx.apiGroups, gopts...)
+		sctx.registerConcurrencyServers(gs, servElection, servLock)
 		if sctx.serviceRegister != nil {
 			sctx.serviceRegister(gs)
 		}
@@ -170,9 +184,8 @@ func (sctx *serveCtx) serve(
 		if tlsErr != nil {
 			return tlsErr
 		}
-		gs = v3rpc.Server(s, tlscfg, nil, gopts...)
-		v3electionpb.RegisterElectionServer(gs, servElection)
-		v3lockpb.RegisterLockServer(gs, servLock)
+		gs = v3rpc.ServerWithAPIGroups(s, tlscfg, nil, sctx.apiGroups, gopts...)
+		sctx.registerConcurrencyServers(gs, servElection, servLock)
 		if sctx.serviceRegister != nil {
 			sctx.serviceRegister(gs)
 		}
@@ -264,7 +277,7 @@ func (sctx *serveCtx) registerGateway(opts []grpc.DialOption) (*gw.ServeMux, err
 		sctx.lg.Error("registerGateway failed to dial", zap.String("addr", addr), zap.Error(err))
 		return nil, err
 	}
-	gwmux := gw.NewServeMux()
+	gwmux := gw.NewServeMux(keyEncodingMarshalerOptions()...)
 
 	handlers := []registerHandlerFunc{
 		etcdservergw.RegisterKVHandler,
@@ -275,6 +288,7 @@ func (sctx *serveCtx) registerGateway(opts []grpc.DialOption) (*gw.ServeMux, err
 		etcdservergw.RegisterAuthHandler,
 		v3lockgw.RegisterLockHandler,
 		v3electiongw.RegisterElectionHandler,
+		v3session.RegisterSessionHandler,
 	}
 	for _, h := range handlers {
 		if err := h(ctx, gwmux, conn); err != nil {
@@ -15,12 +15,20 @@
 package embed
 
 import (
+	"bytes"
 	"fmt"
 	"net/url"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/v3/gateway"
 	"go.etcd.io/etcd/server/v3/auth"
+
+	gw "github.com/grpc-ecosystem/grpc-gateway/runtime"
 )
 
 // TestStartEtcdWrongToken ensures that StartEtcd with wrong configs returns with error.
@@ -56,3 +64,34 @@ func newEmbedURLs(n int) (urls []url.URL) {
 	}
 	return urls
 }
+
+func TestKeyEncodingMarshaler(t *testing.T) {
+	m := &keyEncodingMarshaler{JSONPb: &gw.JSONPb{OrigName: true}, enc: gateway.KeyEncodingHex}
+
+	resp := &pb.RangeResponse{Kvs: []*mvccpb.KeyValue{{Key: []byte{0, 0xff}, Value: []byte{0, 0xff}}}, Count: 1}
+	data, err := m.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wdata := `{"count":"1","kvs":[{"key":"00ff","value":"AP8="}]}`; string(data) != wdata {
+		t.Errorf("marshaled response = %s, want %s", data, wdata)
+	}
+
+	var req pb.RangeRequest
+	d := m.NewDecoder(strings.NewReader(`{"key":"00ff","range_end":"01","limit":"3"}`))
+	if err := d.Decode(&req); err != nil {
+		t.Fatal(err)
+	}
+	wreq := pb.RangeRequest{Key: []byte{0, 0xff}, RangeEnd: []byte{1}, Limit: 3}
+	if !reflect.DeepEqual(req, wreq) {
+		t.Errorf("decoded request = %+v, want %+v", req, wreq)
+	}
+
+	var buf bytes.Buffer
+	if err := m.NewEncoder(&buf).Encode(&wreq); err != nil {
+		t.Fatal(err)
+	}
+	if wdata := `{"key":"00ff","limit":"3","range_end":"01"}`; buf.String() != wdata {
+		t.Errorf("encoded request = %s, want %s", buf.String(), wdata)
+	}
+}
@@ -105,6 +105,7 @@ func newConfig() *config {
 	// member
 	fs.StringVar(&cfg.ec.Dir, "data-dir", cfg.ec.Dir, "Path to the data directory.")
 	fs.StringVar(&cfg.ec.WalDir, "wal-dir", cfg.ec.WalDir, "Path to the dedicated wal directory.")
+	fs.StringVar(&cfg.ec.WALCompression, "wal-compression", "none", "Compression of the entry records appended to the WAL: 'none', 'snappy' or 'zstd'. The WAL is not readable by etcd before v3.6 once compressed.")
 	fs.Var(
 		flags.NewUniqueURLsWithExceptions(embed.DefaultListenPeerURLs, ""),
 		"listen-peer-urls",
@@ -123,6 +124,7 @@ func newConfig() *config {
 	fs.UintVar(&cfg.ec.MaxWalFiles, "max-wals", cfg.ec.MaxWalFiles, "Maximum number of wal files to retain (0 is unlimited).")
 	fs.StringVar(&cfg.ec.Name, "name", cfg.ec.Name, "Human-readable name for this member.")
 	fs.Uint64Var(&cfg.ec.SnapshotCount, "snapshot-count", cfg.ec.SnapshotCount, "Number of committed transactions to trigger a snapshot to disk.")
+	fs.Int64Var(&cfg.ec.SnapshotSendRateLimit, "snapshot-send-rate-limit", 0, "Maximum number of bytes per second of the snapshots sent to the peers. 0 means no limit.")
 	fs.UintVar(&cfg.ec.TickMs, "heartbeat-interval", cfg.ec.TickMs, "Time (in milliseconds) of a heartbeat interval.")
 	fs.UintVar(&cfg.ec.ElectionMs, "election-timeout", cfg.ec.ElectionMs, "Time (in milliseconds) for an election to timeout.")
 	fs.BoolVar(&cfg.ec.InitialElectionTickAdvance, "initial-election-tick-advance", cfg.ec.InitialElectionTickAdvance, "Whether to fast-forward initial election ticks on boot for faster election.")
@@ -183,6 +185,7 @@ func newConfig() *config {
 	fs.StringVar(&cfg.ec.InitialCluster, "initial-cluster", cfg.ec.InitialCluster, "Initial cluster configuration for bootstrapping.")
 	fs.StringVar(&cfg.ec.InitialClusterToken, "initial-cluster-token", cfg.ec.InitialClusterToken, "Initial cluster token for the etcd cluster during bootstrap.")
 	fs.Var(cfg.cf.clusterState, "initial-cluster-state", "Initial cluster state ('new' or 'existing').")
+	fs.StringVar(&cfg.ec.InitialClusterFromSnapshot, "initial-cluster-from-snapshot", "", "Path of a snapshot file to restore the data of a new cluster from at first boot of the member.")
 
 	fs.BoolVar(&cfg.ec.StrictReconfigCheck, "strict-reconfig-check", cfg.ec.StrictReconfigCheck, "Reject reconfiguration requests that would cause quorum loss.")
 
@@ -234,6 +237,13 @@ func newConfig() *config {
 
 	fs.StringVar(&cfg.ec.AutoCompactionRetention, "auto-compaction-retention", "0", "Auto compaction retention for mvcc key value store. 0 means disable auto compaction.")
 	fs.StringVar(&cfg.ec.AutoCompactionMode, "auto-compaction-mode", "periodic", "interpret 'auto-compaction-retention' one of: periodic|revision. 'periodic' for duration based retention, defaulting to hours if no time unit is provided (e.g. '5m'). 'revision' for revision number based retention.")
+	fs.DurationVar(&cfg.ec.AutoBackupInterval, "auto-backup-interval", 0, "Interval between two backups of the member saved to --auto-backup-dir. 0 means disable auto backup.")
+	fs.StringVar(&cfg.ec.AutoBackupDir, "auto-backup-dir", "", "Local directory the backups of the member are saved to.")
+	fs.IntVar(&cfg.ec.AutoBackupRetention, "auto-backup-retention", cfg.ec.AutoBackupRetention, "Number of the most recent backups kept in --auto-backup-dir. 0 means keep all of them.")
+	fs.BoolVar(&cfg.ec.AutoPromoteLearners, "auto-promote-learners", false, "Enable the leader to promote the started learners whose raft log lags at most --auto-promote-learners-max-lag entries behind its own.")
+	fs.Uint64Var(&cfg.ec.AutoPromoteLearnersMaxLag, "auto-promote-learners-max-lag", cfg.ec.AutoPromoteLearnersMaxLag, "Maximum number of raft log entries a learner lags behind the leader to be promoted by --auto-promote-learners.")
+	fs.BoolVar(&cfg.ec.LeaseCheckpoint, "lease-checkpoint", cfg.ec.LeaseCheckpoint, "Enable the leader to checkpoint the remaining TTLs of the leases, persisted by every member, so that neither leader changes nor restarts extend them.")
+	fs.DurationVar(&cfg.ec.LeaseCheckpointInterval, "lease-checkpoint-interval", cfg.ec.LeaseCheckpointInterval, "Interval between the checkpoints of the remaining TTL of a lease, unless the lease was granted with a checkpoint interval of its own.")
 
 	// pprof profiler via HTTP
 	fs.BoolVar(&cfg.ec.EnablePprof, "enable-pprof", false, "Enable runtime profiling data via HTTP server. Address is at client URL + \"/debug/pprof/\"")
@@ -247,6 +257,7 @@ func newConfig() *config {
 	fs.StringVar(&cfg.ec.ExperimentalDistributedTracingServiceName, "experimental-distributed-tracing-service-name", embed.ExperimentalDistributedTracingServiceName, "Configures service name for distributed tracing to be used to define service name for OpenTelemetry Tracing (if enabled with experimental-enable-distributed-tracing flag). 'etcd' is the default service name. Use the same service name for all instances of etcd.")
 	fs.StringVar(&cfg.ec.ExperimentalDistributedTracingServiceInstanceID, "experimental-distributed-tracing-instance-id", "", "Configures service instance ID for distributed tracing to be used to define service instance ID key for OpenTelemetry Tracing (if enabled with experimental-enable-distributed-tracing flag). There is no default value set. This ID must be unique per etcd instance.")
 	fs.IntVar(&cfg.ec.ExperimentalDistributedTracingSamplingRatePerMillion, "experimental-distributed-tracing-sampling-rate", 0, "Number of samples to collect per million spans for OpenTelemetry Tracing (if enabled with experimental-enable-distributed-tracing flag).")
+	fs.Var(flags.NewStringsValue(""), "experimental-trace-key-prefixes", "Comma-separated list of '[read:|write:]<prefix>' rules selecting requests that are always traced, e.g. 'write:/critical/'.")
 
 	// auth
 	fs.StringVar(&cfg.ec.AuthToken, "auth-token", cfg.ec.AuthToken, "Specify auth token specific options.")
@@ -262,12 +273,22 @@ func newConfig() *config {
 	fs.BoolVar(&cfg.ec.ExperimentalCompactHashCheckEnabled, "experimental-compact-hash-check-enabled", cfg.ec.ExperimentalCompactHashCheckEnabled, "Enable leader to periodically check followers compaction hashes.")
 	fs.DurationVar(&cfg.ec.ExperimentalCompactHashCheckTime, "experimental-compact-hash-check-time", cfg.ec.ExperimentalCompactHashCheckTime, "Duration of time between leader checks followers compaction hashes.")
 
-	fs.BoolVar(&cfg.ec.ExperimentalEnableLeaseCheckpoint, "experimental-enable-lease-checkpoint", false, "Enable leader to send regular checkpoints to other members to prevent reset of remaining TTL on leader change.")
+	fs.BoolVar(&cfg.ec.ExperimentalEnableLeaseCheckpoint, "experimental-enable-lease-checkpoint", false, "Enable leader to send regular checkpoints to other members to prevent reset of remaining TTL on leader change. Deprecated in v3.6 and will be decommissioned in v3.7. Use '--lease-checkpoint' instead.")
 	// TODO: delete in v3.7
-	fs.BoolVar(&cfg.ec.ExperimentalEnableLeaseCheckpointPersist, "experimental-enable-lease-checkpoint-persist", false, "Enable persisting remainingTTL to prevent indefinite auto-renewal of long lived leases. Always enabled in v3.6. Should be used to ensure smooth upgrade from v3.5 clusters with this feature enabled. Requires experimental-enable-lease-checkpoint to be enabled.")
+	fs.BoolVar(&cfg.ec.ExperimentalEnableLeaseCheckpointPersist, "experimental-enable-lease-checkpoint-persist", false, "Enable persisting remainingTTL to prevent indefinite auto-renewal of long lived leases. Always enabled in v3.6. Should be used to ensure smooth upgrade from v3.5 clusters with this feature enabled. Deprecated in v3.6 and will be decommissioned in v3.7, lease checkpoints are always persisted.")
+	fs.StringVar(&cfg.ec.ExperimentalLeaseRevokeWebhookURL, "experimental-lease-revoke-webhook-url", "", "URL the leader posts revoked leases and their deleted keys to.")
 	fs.IntVar(&cfg.ec.ExperimentalCompactionBatchLimit, "experimental-compaction-batch-limit", cfg.ec.ExperimentalCompactionBatchLimit, "Sets the maximum revisions deleted in each compaction batch.")
 	fs.DurationVar(&cfg.ec.ExperimentalCompactionSleepInterval, "experimental-compaction-sleep-interval", cfg.ec.ExperimentalCompactionSleepInterval, "Sets the sleep interval between each compaction batch.")
+	fs.DurationVar(&cfg.ec.ExperimentalCompactionBatchLatency, "experimental-compaction-batch-latency", cfg.ec.ExperimentalCompactionBatchLatency, "Target time each compaction batch holds the backend for, resizing the batches from experimental-compaction-batch-limit to meet it. 0 keeps the batches at a fixed size.")
+	fs.DurationVar(&cfg.ec.ExperimentalAutoCompactionMaxLatency, "experimental-auto-compaction-max-latency", 0, "p99 latency of the recent proposals of the member beyond which its automatic compactions are deferred. 0 disables the check.")
+	fs.Uint64Var(&cfg.ec.ExperimentalAutoCompactionMaxPendingProposals, "experimental-auto-compaction-max-pending-proposals", 0, "Number of writes of clients proposed by the member and not applied yet beyond which its automatic compactions are deferred. 0 disables the check.")
+	fs.DurationVar(&cfg.ec.ExperimentalAutoCompactionMaxDeferral, "experimental-auto-compaction-max-deferral", cfg.ec.ExperimentalAutoCompactionMaxDeferral, "Maximum duration the automatic compactions of the member are deferred by --experimental-auto-compaction-max-latency and --experimental-auto-compaction-max-pending-proposals, after which they run despite its load. 0 defers them without limit.")
 	fs.DurationVar(&cfg.ec.ExperimentalWatchProgressNotifyInterval, "experimental-watch-progress-notify-interval", cfg.ec.ExperimentalWatchProgressNotifyInterval, "Duration of periodic watch progress notifications.")
+	fs.IntVar(&cfg.ec.ExperimentalWatchEventCacheSize, "experimental-watch-event-cache-size", cfg.ec.ExperimentalWatchEventCacheSize, "Maximum number of recent events cached in memory to serve resuming watchers. 0 disables the cache.")
+	fs.Var(flags.NewStringsValue(""), "experimental-key-prefix-buckets", "Comma-separated list of key prefixes whose revisions are stored in a backend bucket of their own.")
+	fs.Var(flags.NewStringsValue(""), "experimental-watch-event-prefix-metrics", "Comma-separated list of key prefixes whose put and delete events are counted by key prefix.")
+	fs.IntVar(&cfg.ec.ExperimentalWatchEventPrefixMetricsDepth, "experimental-watch-event-prefix-metrics-depth", cfg.ec.ExperimentalWatchEventPrefixMetricsDepth, "Number of '/' terminated key segments following the listed prefixes the events are counted by.")
+	fs.BoolVar(&cfg.ec.ExperimentalResponseHeaderCompactRevision, "experimental-response-header-compact-revision", false, "Set the compaction revision of the key-value store in the response headers.")
 	fs.DurationVar(&cfg.ec.ExperimentalDowngradeCheckTime, "experimental-downgrade-check-time", cfg.ec.ExperimentalDowngradeCheckTime, "Duration of time between two downgrade status check.")
 	fs.DurationVar(&cfg.ec.ExperimentalWarningApplyDuration, "experimental-warning-apply-duration", cfg.ec.ExperimentalWarningApplyDuration, "Time duration after which a warning is generated if request takes more time.")
 	fs.DurationVar(&cfg.ec.ExperimentalWarningUnaryRequestDuration, "experimental-warning-unary-request-duration", cfg.ec.ExperimentalWarningUnaryRequestDuration, "Time duration after which a warning is generated if a unary request takes more time.")
@@ -275,6 +296,27 @@ func newConfig() *config {
 	fs.BoolVar(&cfg.ec.ExperimentalTxnModeWriteWithSharedBuffer, "experimental-txn-mode-write-with-shared-buffer", true, "Enable the write transaction to use a shared buffer in its readonly check operations.")
 	fs.UintVar(&cfg.ec.ExperimentalBootstrapDefragThresholdMegabytes, "experimental-bootstrap-defrag-threshold-megabytes", 0, "Enable the defrag during etcd server bootstrap on condition that it will free at least the provided threshold of disk space. Needs to be set to non-zero value to take effect.")
 	fs.IntVar(&cfg.ec.ExperimentalMaxLearners, "experimental-max-learners", membership.DefaultMaxLearners, "Sets the maximum number of learners that can be available in the cluster membership.")
+	fs.Uint64Var(&cfg.ec.ExperimentalRaftLogRetentionMaxBytes, "experimental-raft-log-retention-max-bytes", 0, "Maximum size in bytes of the raft log entries held in memory. Followers lagging further behind catch up from a snapshot. 0 means no limit.")
+	fs.Uint64Var(&cfg.ec.ExperimentalMemoryBudget, "experimental-memory-budget", 0, "Memory in bytes the member aims to stay within. Sets the soft memory limit of the Go runtime and shrinks the watch buffers, the buffer of the pending writes and the raft entries kept for slow followers under pressure. 0 means no budget.")
+	fs.IntVar(&cfg.ec.ExperimentalSlowFollowerAlarmThreshold, "experimental-slow-follower-alarm-threshold", 0, "Number of snapshots sent to a follower within an hour that raises a SLOWFOLLOWER alarm. 0 disables the alarm.")
+	fs.DurationVar(&cfg.ec.ExperimentalCertExpiryAlarmWindow, "experimental-cert-expiry-alarm-window", 0, "Time before a serving, peer or CA certificate of the member expires from which a CERTEXPIRY alarm is raised. 0 disables the alarm.")
+	fs.Var(flags.NewUniqueStringsValue(""), "experimental-standby-of", "List of client URLs of a primary cluster whose keyspace the leader mirrors into the cluster while it holds a STANDBY alarm.")
+	fs.BoolVar(&cfg.ec.ExperimentalStaleDataDirRecovery, "experimental-stale-data-dir-recovery", false, "Wipe the data dir on startup if the peers of the initial cluster tell their cluster was recreated since or the member was removed from it, and rejoin their cluster as a new learner.")
+	fs.BoolVar(&cfg.ec.ExperimentalLearnerSerializableReads, "experimental-learner-serializable-reads", false, "Serve serializable read-only transactions, range streams and watches while a learner, besides the serializable ranges learners always serve.")
+	fs.Var(flags.NewStringsValue(""), "experimental-prefix-ttls", "Comma-separated list of '<prefix>=<ttl>' retention policies deleting the keys under the prefix once not modified for the TTL, a duration or a number of days, e.g. '/logs/=30d'. Should be the same on every member.")
+	fs.Var(flags.NewStringsValue(""), "experimental-client-listener-api-groups", "Comma-separated list of '<listen-client-url>=<group>+<group>' API groups served on the client listeners, among kv, watch, lease, cluster, maintenance, auth, election and lock, e.g. 'http://127.0.0.1:2381=maintenance+cluster'. Listeners without an entry serve all the API groups.")
+	fs.IntVar(&cfg.ec.ExperimentalRaftEntryCompressionThreshold, "experimental-raft-entry-compression-threshold", 0, "Size in bytes of a proposal from which it is compressed with zstd on the wire to the peers and in the WAL, once the cluster version is 3.6. 0 disables the compression.")
+	fs.IntVar(&cfg.ec.ExperimentalPeerMsgAppBatchBytes, "experimental-peer-msgapp-batch-bytes", 0, "Maximum size in bytes of the entries of the consecutive MsgApp sent to a peer merged into one. 0 disables the batching.")
+	fs.DurationVar(&cfg.ec.ExperimentalLearnerHeartbeatInterval, "experimental-learner-heartbeat-interval", 0, "Minimum interval between the heartbeats the leader sends to a learner, relaxing the heartbeat fan-out with many learners. 0 sends them on every heartbeat.")
+	fs.Int64Var(&cfg.ec.ExperimentalMaxKeys, "experimental-max-keys", 0, "Maximum number of keys of the store, counting deleted keys until compacted, beyond which requests that may create keys are rejected and a TOOMANYKEYS alarm is raised. 0 means no limit.")
+	fs.Uint64Var(&cfg.ec.ExperimentalMaxApplyBacklog, "experimental-max-apply-backlog", 0, "Maximum number of committed entries the member has not applied yet beyond which writes are rejected before being proposed, with a hint of when to retry them. 0 means no limit.")
+	fs.Uint64Var(&cfg.ec.ExperimentalMaxPendingProposals, "experimental-max-pending-proposals", 0, "Maximum number of writes of the member proposed and not yet applied beyond which writes are rejected before being proposed, with a hint of when to retry them. 0 means no limit.")
+	fs.IntVar(&cfg.ec.ExperimentalMaxWatchersPerConnection, "experimental-max-watchers-per-connection", 0, "Maximum number of watchers a client connection can open. 0 means no limit.")
+	fs.IntVar(&cfg.ec.ExperimentalMaxWatchersPerUser, "experimental-max-watchers-per-user", 0, "Maximum number of watchers an authenticated user can open. 0 means no limit.")
+	fs.Var(flags.NewStringsValue(""), "experimental-rate-limits", "Comma-separated list of '[user:]<prefix>=<rate>[:<burst>]' limits of the requests per second to key prefixes, e.g. 'user:/tenants/=100:200'.")
+	fs.IntVar(&cfg.ec.ExperimentalWatchSlowWatchersAlertThreshold, "experimental-watch-slow-watchers-alert-threshold", 0, "Number of slow watchers above which the member reports an error in its status. 0 disables the alert.")
+	fs.IntVar(&cfg.ec.ExperimentalWatchPendingEventsAlertThreshold, "experimental-watch-pending-events-alert-threshold", 0, "Number of pending watch events above which the member reports an error in its status. 0 disables the alert.")
+	fs.DurationVar(&cfg.ec.ExperimentalShutdownDrainTimeout, "experimental-shutdown-drain-timeout", 0, "Maximum duration to wait on shutdown for clients to move their streams to other members. 0 disables the drain.")
 	fs.DurationVar(&cfg.ec.ExperimentalWaitClusterReadyTimeout, "experimental-wait-cluster-ready-timeout", cfg.ec.ExperimentalWaitClusterReadyTimeout, "Maximum duration to wait for the cluster to be ready.")
 
 	// unsafe
@@ -378,12 +420,20 @@ func (cfg *config) configFromCmdLine() error {
 	cfg.ec.ListenMetricsUrls = flags.UniqueURLsFromFlag(cfg.cf.flagSet, "listen-metrics-urls")
 
 	cfg.ec.DiscoveryCfg.Endpoints = flags.UniqueStringsFromFlag(cfg.cf.flagSet, "discovery-endpoints")
+	cfg.ec.ExperimentalStandbyOf = flags.UniqueStringsFromFlag(cfg.cf.flagSet, "experimental-standby-of")
 
 	cfg.ec.CORS = flags.UniqueURLsMapFromFlag(cfg.cf.flagSet, "cors")
 	cfg.ec.HostWhitelist = flags.UniqueStringsMapFromFlag(cfg.cf.flagSet, "host-whitelist")
 
 	cfg.ec.CipherSuites = flags.StringsFromFlag(cfg.cf.flagSet, "cipher-suites")
 
+	cfg.ec.ExperimentalTraceKeyPrefixes = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-trace-key-prefixes")
+	cfg.ec.ExperimentalKeyPrefixBuckets = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-key-prefix-buckets")
+	cfg.ec.ExperimentalWatchEventPrefixMetrics = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-watch-event-prefix-metrics")
+	cfg.ec.ExperimentalRateLimits = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-rate-limits")
+	cfg.ec.ExperimentalPrefixTTLs = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-prefix-ttls")
+	cfg.ec.ExperimentalClientListenerAPIGroups = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-client-listener-api-groups")
+
 	cfg.ec.MaxConcurrentStreams = flags.Uint32FromFlag(cfg.cf.flagSet, "max-concurrent-streams")
 
 	cfg.ec.LogOutputs = flags.UniqueStringsFromFlag(cfg.cf.flagSet, "log-outputs")
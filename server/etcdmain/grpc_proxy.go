@@ -93,9 +93,10 @@ var (
 	grpcProxyNamespace string
 	grpcProxyLeasing   string
 
-	grpcProxyEnablePprof    bool
-	grpcProxyEnableOrdering bool
-	grpcProxyEnableLogging  bool
+	grpcProxyEnablePprof       bool
+	grpcProxyEnableOrdering    bool
+	grpcProxyEnableLogging     bool
+	grpcProxyEnableWatchCursor bool
 
 	grpcProxyDebug bool
 
@@ -169,6 +170,7 @@ func newGRPCProxyStartCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&grpcProxyEnableOrdering, "experimental-serializable-ordering", false, "Ensure serializable reads have monotonically increasing store revisions across endpoints.")
 	cmd.Flags().StringVar(&grpcProxyLeasing, "experimental-leasing-prefix", "", "leasing metadata prefix for disconnected linearized reads.")
 	cmd.Flags().BoolVar(&grpcProxyEnableLogging, "experimental-enable-grpc-logging", false, "logging all grpc requests and responses")
+	cmd.Flags().BoolVar(&grpcProxyEnableWatchCursor, "experimental-watch-cursors", false, "Persist the revisions of the coalesced watches under the data directory to resume them from after a restart.")
 
 	cmd.Flags().BoolVar(&grpcProxyDebug, "debug", false, "Enable debug-level logging for grpc-proxy.")
 
@@ -436,7 +438,18 @@ func newGRPCProxyServer(lg *zap.Logger, client *clientv3.Client) *grpc.Server {
 	}
 
 	kvp, _ := grpcproxy.NewKvProxy(client)
-	watchp, _ := grpcproxy.NewWatchProxy(client.Ctx(), lg, client)
+	var watchCursors *grpcproxy.WatchCursors
+	if grpcProxyEnableWatchCursor {
+		if err := os.MkdirAll(grpcProxyDataDir, 0700); err != nil {
+			lg.Fatal("failed to create data directory", zap.String("path", grpcProxyDataDir), zap.Error(err))
+		}
+		var err error
+		watchCursors, err = grpcproxy.OpenWatchCursors(lg, filepath.Join(grpcProxyDataDir, "watch-cursors.db"))
+		if err != nil {
+			lg.Fatal("failed to open watch cursors", zap.Error(err))
+		}
+	}
+	watchp, _ := grpcproxy.NewWatchProxyWithCursors(client.Ctx(), lg, client, watchCursors)
 	if grpcProxyResolverPrefix != "" {
 		grpcproxy.Register(lg, client, grpcProxyResolverPrefix, grpcProxyAdvertiseClientURL, grpcProxyResolverTTL)
 	}
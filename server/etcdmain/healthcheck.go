@@ -0,0 +1,178 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdmain
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// exit codes of "etcd healthcheck"
+const (
+	healthcheckExitHealthy      = 0
+	healthcheckExitUnhealthy    = 1
+	healthcheckExitDegraded     = 2
+	healthcheckExitAuthRequired = 3
+)
+
+var (
+	healthcheckEndpoint              string
+	healthcheckSerializable          bool
+	healthcheckTimeout               time.Duration
+	healthcheckCA                    string
+	healthcheckCert                  string
+	healthcheckKey                   string
+	healthcheckInsecureSkipTLSVerify bool
+	healthcheckUser                  string
+	healthcheckPassword              string
+)
+
+func init() {
+	rootCmd.AddCommand(newHealthcheckCommand())
+}
+
+// newHealthcheckCommand returns the cobra command for "healthcheck".
+func newHealthcheckCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "healthcheck",
+		Short: "check the health of an etcd member, for container health checks and liveness probes",
+		Long: `Checks the health of an etcd member by reading a key, then by checking
+the member has a leader and no raised alarm.
+
+Exits with:
+  0 if the member is healthy
+  1 if the member is unhealthy: it cannot be reached or fails to serve the read
+  2 if the member is degraded: it serves the read but has no leader or an alarm raised
+  3 if the member serves requests, but requires valid credentials
+`,
+		Run: runHealthcheck,
+	}
+
+	cmd.Flags().StringVar(&healthcheckEndpoint, "endpoint", "127.0.0.1:2379", "client endpoint of the etcd member to check")
+	cmd.Flags().BoolVar(&healthcheckSerializable, "serializable", false, "read from the member alone, so that a member without a leader is degraded instead of unhealthy")
+	cmd.Flags().DurationVar(&healthcheckTimeout, "timeout", 2*time.Second, "timeout of the whole check")
+
+	cmd.Flags().StringVar(&healthcheckCA, "cacert", "", "verify certificates of TLS-enabled secure servers using this CA bundle")
+	cmd.Flags().StringVar(&healthcheckCert, "cert", "", "identify secure client using this TLS certificate file")
+	cmd.Flags().StringVar(&healthcheckKey, "key", "", "identify secure client using this TLS key file")
+	cmd.Flags().BoolVar(&healthcheckInsecureSkipTLSVerify, "insecure-skip-tls-verify", false, "skip authentication of etcd server TLS certificates (CAUTION: this option should be enabled only for testing purposes)")
+	cmd.Flags().StringVar(&healthcheckUser, "user", "", "username[:password] for authentication")
+	cmd.Flags().StringVar(&healthcheckPassword, "password", "", "password for authentication (if this option is used, --user option shouldn't include password)")
+
+	return &cmd
+}
+
+func runHealthcheck(cmd *cobra.Command, args []string) {
+	code, msg := healthcheck()
+	fmt.Println(msg)
+	os.Exit(code)
+}
+
+// healthcheck checks the health of the member at healthcheckEndpoint and
+// returns the exit code and message of the check.
+func healthcheck() (int, string) {
+	ep := healthcheckEndpoint
+	cfg, err := newHealthcheckClientCfg()
+	if err != nil {
+		return healthcheckExitUnhealthy, fmt.Sprintf("%s is unhealthy: %v", ep, err)
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), healthcheckTimeout)
+	defer cancel()
+	cfg.Context = ctx
+
+	cli, err := clientv3.New(*cfg)
+	if err != nil {
+		if isAuthRequired(err) {
+			return healthcheckExitAuthRequired, fmt.Sprintf("%s requires authentication: %v", ep, err)
+		}
+		return healthcheckExitUnhealthy, fmt.Sprintf("%s is unhealthy: %v", ep, err)
+	}
+	defer cli.Close()
+
+	var opts []clientv3.OpOption
+	if healthcheckSerializable {
+		opts = append(opts, clientv3.WithSerializable())
+	}
+	// as with the /health endpoint, a permission denied error means
+	// the member served the read
+	if _, err = cli.Get(ctx, "health", opts...); err != nil && rpctypes.ErrorDesc(err) != rpctypes.ErrPermissionDenied.Error() {
+		if isAuthRequired(err) {
+			return healthcheckExitAuthRequired, fmt.Sprintf("%s requires authentication: %v", ep, err)
+		}
+		return healthcheckExitUnhealthy, fmt.Sprintf("%s is unhealthy: failed to read: %v", ep, err)
+	}
+
+	resp, err := cli.Status(ctx, ep)
+	if err != nil {
+		return healthcheckExitDegraded, fmt.Sprintf("%s is degraded: failed to get status: %v", ep, err)
+	}
+	if len(resp.Errors) > 0 {
+		for i := range resp.Errors {
+			resp.Errors[i] = strings.TrimSpace(resp.Errors[i])
+		}
+		return healthcheckExitDegraded, fmt.Sprintf("%s is degraded: %s", ep, strings.Join(resp.Errors, ", "))
+	}
+	return healthcheckExitHealthy, fmt.Sprintf("%s is healthy: took %v", ep, time.Since(start))
+}
+
+func isAuthRequired(err error) bool {
+	switch rpctypes.ErrorDesc(err) {
+	case rpctypes.ErrUserEmpty.Error(), rpctypes.ErrAuthFailed.Error(), rpctypes.ErrInvalidAuthToken.Error():
+		return true
+	}
+	return false
+}
+
+func newHealthcheckClientCfg() (*clientv3.Config, error) {
+	cfg := clientv3.Config{
+		Endpoints:   []string{healthcheckEndpoint},
+		DialTimeout: healthcheckTimeout,
+		Logger:      zap.NewNop(),
+	}
+
+	tls := newTLS(healthcheckCA, healthcheckCert, healthcheckKey, false)
+	if tls == nil && (healthcheckInsecureSkipTLSVerify || strings.HasPrefix(healthcheckEndpoint, "https://")) {
+		tls = &transport.TLSInfo{}
+	}
+	if tls != nil {
+		clientTLS, err := tls.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		clientTLS.InsecureSkipVerify = healthcheckInsecureSkipTLSVerify
+		cfg.TLS = clientTLS
+	}
+
+	cfg.Username = healthcheckUser
+	cfg.Password = healthcheckPassword
+	if healthcheckPassword == "" {
+		if i := strings.Index(healthcheckUser, ":"); i >= 0 {
+			cfg.Username, cfg.Password = healthcheckUser[:i], healthcheckUser[i+1:]
+		}
+	}
+	return &cfg, nil
+}
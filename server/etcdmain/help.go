@@ -44,6 +44,9 @@ var (
 
   etcd grpc-proxy
     Run the stateless etcd v3 gRPC L7 reverse proxy.
+
+  etcd healthcheck
+    Check the health of an etcd member, for container health checks and liveness probes.
 `
 	flagsline = `
 Member:
@@ -53,8 +56,12 @@ Member:
     Path to the data directory.
   --wal-dir ''
     Path to the dedicated wal directory.
+  --wal-compression 'none'
+    Compression of the entry records appended to the WAL: 'none', 'snappy' or 'zstd'. The WAL is not readable by etcd before v3.6 once compressed.
   --snapshot-count '100000'
     Number of committed transactions to trigger a snapshot to disk.
+  --snapshot-send-rate-limit '0'
+    Maximum number of bytes per second of the snapshots sent to the peers. 0 means no limit.
   --heartbeat-interval '100'
     Time (in milliseconds) of a heartbeat interval.
   --election-timeout '1000'
@@ -104,6 +111,9 @@ Clustering:
   --initial-cluster-token 'etcd-cluster'
     Initial cluster token for the etcd cluster during bootstrap.
     Specifying this can protect you from unintended cross-cluster interaction when running multiple clusters.
+  --initial-cluster-from-snapshot ''
+    Path of a snapshot file to restore the data of a new cluster from at first boot of the member.
+    Every member of the initial cluster restores the same snapshot, saved by 'etcdctl snapshot save', with the member IDs derived from --initial-cluster and --initial-cluster-token.
   --advertise-client-urls 'http://localhost:2379'
     List of this member's client URLs to advertise to the public.
     The client URLs advertised should be accessible to machines that talk to etcd cluster. etcd client libraries parse these URLs to connect to the cluster.
@@ -151,6 +161,20 @@ Clustering:
     Auto compaction retention length. 0 means disable auto compaction.
   --auto-compaction-mode 'periodic'
     Interpret 'auto-compaction-retention' one of: periodic|revision. 'periodic' for duration based retention, defaulting to hours if no time unit is provided (e.g. '5m'). 'revision' for revision number based retention.
+  --auto-backup-interval '0s'
+    Interval between two backups of the member, verified snapshot files saved to --auto-backup-dir. 0 means disable auto backup.
+  --auto-backup-dir ''
+    Local directory the backups of the member are saved to.
+  --auto-backup-retention '` + strconv.Itoa(embed.DefaultAutoBackupRetention) + `'
+    Number of the most recent backups kept in --auto-backup-dir. 0 means keep all of them.
+  --auto-promote-learners 'false'
+    Enable the leader to promote the started learners whose raft log lags at most --auto-promote-learners-max-lag entries behind its own.
+  --auto-promote-learners-max-lag '` + strconv.Itoa(embed.DefaultAutoPromoteLearnersMaxLag) + `'
+    Maximum number of raft log entries a learner lags behind the leader to be promoted by --auto-promote-learners.
+  --lease-checkpoint 'true'
+    Enable the leader to checkpoint the remaining TTLs of the leases, persisted by every member, so that neither leader changes nor restarts extend them.
+  --lease-checkpoint-interval '` + embed.DefaultLeaseCheckpointInterval.String() + `'
+    Interval between the checkpoints of the remaining TTL of a lease, unless the lease was granted with a checkpoint interval of its own.
   --v2-deprecation '` + string(cconfig.V2_DEPR_DEFAULT) + `'
     Phase of v2store deprecation. Allows to opt-in for higher compatibility mode.
     Supported values:
@@ -201,7 +225,7 @@ Security:
 
 Auth:
   --auth-token 'simple'
-    Specify a v3 authentication token type and its options ('simple' or 'jwt').
+    Specify a v3 authentication token type and its options ('simple', 'jwt' or 'oidc').
   --bcrypt-cost ` + fmt.Sprintf("%d", bcrypt.DefaultCost) + `
     Specify the cost / strength of the bcrypt algorithm for hashing auth passwords. Valid values are between ` + fmt.Sprintf("%d", bcrypt.MinCost) + ` and ` + fmt.Sprintf("%d", bcrypt.MaxCost) + `.
   --auth-token-ttl 300
@@ -240,6 +264,8 @@ Experimental distributed tracing:
     Distributed tracing instance ID, must be unique per each etcd instance.
   --experimental-distributed-tracing-sampling-rate '0'
     Number of samples to collect per million spans for distributed tracing. Disabled by default.
+  --experimental-trace-key-prefixes ''
+    Comma-separated list of '[read:|write:]<prefix>' rules selecting requests that are always traced, e.g. 'write:/critical/'. Applies to both slow request tracing and distributed tracing.
 
 Experimental feature:
   --experimental-initial-corrupt-check 'false'
@@ -247,13 +273,33 @@ Experimental feature:
   --experimental-corrupt-check-time '0s'
     Duration of time between cluster corruption check passes.
   --experimental-enable-lease-checkpoint 'false'
-    ExperimentalEnableLeaseCheckpoint enables primary lessor to persist lease remainingTTL to prevent indefinite auto-renewal of long lived leases.
+    ExperimentalEnableLeaseCheckpoint enables primary lessor to persist lease remainingTTL to prevent indefinite auto-renewal of long lived leases. Deprecated in v3.6 and will be decommissioned in v3.7. Use '--lease-checkpoint' instead.
+  --experimental-lease-revoke-webhook-url ''
+    URL the leader posts revoked leases and their deleted keys to, as JSON.
   --experimental-compaction-batch-limit 1000
     ExperimentalCompactionBatchLimit sets the maximum revisions deleted in each compaction batch.
+  --experimental-compaction-batch-latency '0s'
+    Target time each compaction batch holds the backend for, resizing the batches from experimental-compaction-batch-limit to meet it. 0 keeps the batches at a fixed size.
+  --experimental-auto-compaction-max-latency '0s'
+    p99 latency of the recent proposals of the member beyond which its automatic compactions are deferred. 0 disables the check.
+  --experimental-auto-compaction-max-pending-proposals '0'
+    Number of writes of clients proposed by the member and not applied yet beyond which its automatic compactions are deferred. 0 disables the check.
+  --experimental-auto-compaction-max-deferral '` + embed.DefaultAutoCompactionMaxDeferral.String() + `'
+    Maximum duration the automatic compactions of the member are deferred by the two flags above, after which they run despite its load. 0 defers them without limit.
   --experimental-peer-skip-client-san-verification 'false'
     Skip verification of SAN field in client certificate for peer connections.
   --experimental-watch-progress-notify-interval '10m'
     Duration of periodical watch progress notification.
+  --experimental-watch-event-cache-size '0'
+    Maximum number of recent events cached in memory to serve resuming watchers. 0 disables the cache.
+  --experimental-key-prefix-buckets ''
+    Comma-separated list of key prefixes whose revisions are stored in a backend bucket of their own. All members should use the same list.
+  --experimental-watch-event-prefix-metrics ''
+    Comma-separated list of key prefixes whose put and delete events are counted by key prefix in etcd_debugging_mvcc_events_by_prefix_total.
+  --experimental-watch-event-prefix-metrics-depth '` + strconv.Itoa(embed.DefaultWatchEventPrefixMetricsDepth) + `'
+    Number of '/' terminated key segments following the listed prefixes the events are counted by.
+  --experimental-response-header-compact-revision 'false'
+    Set the compaction revision of the key-value store in the response headers.
   --experimental-warning-apply-duration '100ms'
     Warning is generated if requests take more than this duration.
   --experimental-txn-mode-write-with-shared-buffer 'true'
@@ -264,6 +310,48 @@ Experimental feature:
     Set time duration after which a warning is generated if a unary request takes more than this duration.
   --experimental-max-learners '1'
     Set the max number of learner members allowed in the cluster membership.
+  --experimental-raft-log-retention-max-bytes '0'
+    Maximum size in bytes of the raft log entries held in memory. Followers lagging further behind catch up from a snapshot. 0 means no limit.
+  --experimental-memory-budget '0'
+    Memory in bytes the member aims to stay within. Sets the soft memory limit of the Go runtime and shrinks the watch buffers, the buffer of the pending writes and the raft entries kept for slow followers under pressure. 0 means no budget.
+  --experimental-slow-follower-alarm-threshold '0'
+    Number of snapshots sent to a follower within an hour that raises a SLOWFOLLOWER alarm. 0 disables the alarm.
+  --experimental-cert-expiry-alarm-window '0s'
+    Time before a serving, peer or CA certificate of the member expires from which a CERTEXPIRY alarm is raised. 0 disables the alarm.
+  --experimental-standby-of ''
+    List of client URLs of a primary cluster whose keyspace the leader mirrors into the cluster while it holds a STANDBY alarm.
+  --experimental-stale-data-dir-recovery 'false'
+    Wipe the data dir on startup if the peers of the initial cluster tell their cluster was recreated since or the member was removed from it, and rejoin their cluster as a new learner.
+  --experimental-learner-serializable-reads 'false'
+    Serve serializable read-only transactions, range streams and watches while a learner, besides the serializable ranges learners always serve.
+  --experimental-prefix-ttls ''
+    Comma-separated list of '<prefix>=<ttl>' retention policies deleting the keys under the prefix once not modified for the TTL, a duration or a number of days, e.g. '/logs/=30d'. Should be the same on every member.
+  --experimental-client-listener-api-groups ''
+    Comma-separated list of '<listen-client-url>=<group>+<group>' API groups served on the client listeners, among kv, watch, lease, cluster, maintenance, auth, election and lock, e.g. 'http://127.0.0.1:2381=maintenance+cluster'. Listeners without an entry serve all the API groups.
+  --experimental-raft-entry-compression-threshold '0'
+    Size in bytes of a proposal from which it is compressed with zstd on the wire to the peers and in the WAL, once the cluster version is 3.6. 0 disables the compression.
+  --experimental-peer-msgapp-batch-bytes '0'
+    Maximum size in bytes of the entries of the consecutive MsgApp sent to a peer merged into one. 0 disables the batching.
+  --experimental-learner-heartbeat-interval '0s'
+    Minimum interval between the heartbeats the leader sends to a learner, relaxing the heartbeat fan-out with many learners. 0 sends them on every heartbeat.
+  --experimental-max-keys '0'
+    Maximum number of keys of the store, counting deleted keys until compacted, beyond which requests that may create keys are rejected and a TOOMANYKEYS alarm is raised. 0 means no limit.
+  --experimental-max-apply-backlog '0'
+    Maximum number of committed entries the member has not applied yet beyond which writes are rejected before being proposed, with a hint of when to retry them. 0 means no limit.
+  --experimental-max-pending-proposals '0'
+    Maximum number of writes of the member proposed and not yet applied beyond which writes are rejected before being proposed, with a hint of when to retry them. 0 means no limit.
+  --experimental-max-watchers-per-connection '0'
+    Maximum number of watchers a client connection can open. 0 means no limit.
+  --experimental-max-watchers-per-user '0'
+    Maximum number of watchers an authenticated user can open. 0 means no limit.
+  --experimental-rate-limits ''
+    Comma-separated list of '[user:]<prefix>=<rate>[:<burst>]' limits of the requests per second to key prefixes, e.g. 'user:/tenants/=100:200'. Each key counts against its longest limited prefix, and 'user:' limits each authenticated user separately.
+  --experimental-watch-slow-watchers-alert-threshold '0'
+    Number of slow watchers above which the member reports an error in its status. 0 disables the alert.
+  --experimental-watch-pending-events-alert-threshold '0'
+    Number of pending watch events above which the member reports an error in its status. 0 disables the alert.
+  --experimental-shutdown-drain-timeout '0s'
+    Maximum duration to wait on shutdown for clients to move their watch and lease keep-alive streams to other members, before transferring the leadership and stopping. 0 disables the drain.
   --experimental-wait-cluster-ready-timeout '5s'
     Set the maximum time duration to wait for the cluster to be ready.
 
@@ -0,0 +1,73 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/etcdserver/errors"
+)
+
+// isSheddableWrite returns true if the request is a write of a client which
+// may be rejected while the member is overloaded.
+func isSheddableWrite(r *pb.InternalRaftRequest) bool {
+	if r.Header != nil && r.Header.Mirror {
+		return false
+	}
+	return r.Put != nil || r.DeleteRange != nil || r.Txn != nil
+}
+
+// writeLoad returns the highest ratio of the apply backlog and of the
+// pending writes of the member to their limits, 0 if no limit is set.
+func (s *EtcdServer) writeLoad() (load float64, reason string) {
+	if max := s.Cfg.MaxApplyBacklog; max != 0 {
+		ai, ci := s.getAppliedIndex(), s.getCommittedIndex()
+		if ci > ai {
+			load, reason = float64(ci-ai)/float64(max), "apply_backlog"
+		}
+	}
+	if max := s.Cfg.MaxPendingProposals; max != 0 {
+		if l := float64(atomic.LoadInt64(&s.pendingWrites)) / float64(max); l > load {
+			load, reason = l, "pending_proposals"
+		}
+	}
+	return load, reason
+}
+
+// admitWrite rejects the writes of clients while the apply backlog or the
+// pending writes of the member exceed their limits, rather than proposing
+// them to time out with all the others.
+func (s *EtcdServer) admitWrite() error {
+	if load, reason := s.writeLoad(); load >= 1 {
+		proposalsShed.WithLabelValues(reason).Inc()
+		return errors.ErrOverloaded
+	}
+	return nil
+}
+
+// WriteRetryAfter returns how long the clients of overloaded writes should
+// wait before retrying them: a heartbeat interval per multiple of the limit
+// the load of the member reaches, up to the request timeout.
+func (s *EtcdServer) WriteRetryAfter() time.Duration {
+	load, _ := s.writeLoad()
+	d := time.Duration(math.Max(1, math.Ceil(load))) * time.Duration(s.Cfg.TickMs) * time.Millisecond
+	if max := s.Cfg.ReqTimeout(); d > max {
+		d = max
+	}
+	return d
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/etcdserver/errors"
+)
+
+func TestAdmitWrite(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            config.ServerConfig
+		applied        uint64
+		committed      uint64
+		pendingWrites  int64
+		wantErr        error
+		wantRetryAfter time.Duration
+	}{
+		{
+			name:           "no limits",
+			cfg:            config.ServerConfig{TickMs: 100, ElectionTicks: 10},
+			applied:        1,
+			committed:      1000,
+			pendingWrites:  1000,
+			wantRetryAfter: 100 * time.Millisecond,
+		},
+		{
+			name:           "under limits",
+			cfg:            config.ServerConfig{TickMs: 100, ElectionTicks: 10, MaxApplyBacklog: 100, MaxPendingProposals: 10},
+			applied:        1,
+			committed:      100,
+			pendingWrites:  9,
+			wantRetryAfter: 100 * time.Millisecond,
+		},
+		{
+			name:           "apply backlog",
+			cfg:            config.ServerConfig{TickMs: 100, ElectionTicks: 10, MaxApplyBacklog: 100},
+			applied:        1,
+			committed:      251,
+			wantErr:        errors.ErrOverloaded,
+			wantRetryAfter: 300 * time.Millisecond,
+		},
+		{
+			name:           "pending proposals",
+			cfg:            config.ServerConfig{TickMs: 100, ElectionTicks: 10, MaxApplyBacklog: 100, MaxPendingProposals: 10},
+			applied:        1,
+			committed:      2,
+			pendingWrites:  10,
+			wantErr:        errors.ErrOverloaded,
+			wantRetryAfter: 100 * time.Millisecond,
+		},
+		{
+			name:           "retry after up to request timeout",
+			cfg:            config.ServerConfig{TickMs: 100, ElectionTicks: 10, MaxPendingProposals: 1},
+			pendingWrites:  1000,
+			wantErr:        errors.ErrOverloaded,
+			wantRetryAfter: 7 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &EtcdServer{Cfg: tt.cfg, appliedIndex: tt.applied, committedIndex: tt.committed, pendingWrites: tt.pendingWrites}
+			if err := s.admitWrite(); err != tt.wantErr {
+				t.Errorf("admitWrite() = %v, want %v", err, tt.wantErr)
+			}
+			if d := s.WriteRetryAfter(); d != tt.wantRetryAfter {
+				t.Errorf("WriteRetryAfter() = %v, want %v", d, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestIsSheddableWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		r    pb.InternalRaftRequest
+		want bool
+	}{
+		{"put", pb.InternalRaftRequest{Put: &pb.PutRequest{}}, true},
+		{"delete range", pb.InternalRaftRequest{DeleteRange: &pb.DeleteRangeRequest{}}, true},
+		{"txn", pb.InternalRaftRequest{Txn: &pb.TxnRequest{}}, true},
+		{"mirrored txn", pb.InternalRaftRequest{Header: &pb.RequestHeader{Mirror: true}, Txn: &pb.TxnRequest{}}, false},
+		{"lease grant", pb.InternalRaftRequest{LeaseGrant: &pb.LeaseGrantRequest{}}, false},
+		{"compaction", pb.InternalRaftRequest{Compaction: &pb.CompactionRequest{}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSheddableWrite(&tt.r); got != tt.want {
+				t.Errorf("isSheddableWrite() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
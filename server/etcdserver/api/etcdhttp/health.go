@@ -120,8 +120,19 @@ type Health struct {
 
 type AlarmSet map[string]struct{}
 
+// defaultExcludedAlarms are the alarms not making /health false. They report
+// the state of a member to its operators rather than that the member fails
+// to serve.
+var defaultExcludedAlarms = []etcdserverpb.AlarmType{
+	etcdserverpb.AlarmType_SLOWFOLLOWER,
+	etcdserverpb.AlarmType_CERTEXPIRY,
+}
+
 func getExcludedAlarms(r *http.Request) (alarms AlarmSet) {
-	alarms = make(map[string]struct{}, 2)
+	alarms = make(map[string]struct{}, 2+len(defaultExcludedAlarms))
+	for _, alm := range defaultExcludedAlarms {
+		alarms[alm.String()] = struct{}{}
+	}
 	alms, found := r.URL.Query()["exclude"]
 	if found {
 		for _, alm := range alms {
@@ -157,6 +168,10 @@ func checkAlarms(lg *zap.Logger, srv ServerHealth, excludedAlarms AlarmSet) Heal
 				h.Reason = "ALARM NOSPACE"
 			case etcdserverpb.AlarmType_CORRUPT:
 				h.Reason = "ALARM CORRUPT"
+			case etcdserverpb.AlarmType_TOOMANYKEYS:
+				h.Reason = "ALARM TOOMANYKEYS"
+			case etcdserverpb.AlarmType_STANDBY:
+				h.Reason = "ALARM STANDBY"
 			default:
 				h.Reason = "ALARM UNKNOWN"
 			}
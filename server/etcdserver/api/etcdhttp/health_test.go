@@ -114,6 +114,20 @@ func TestHealthHandler(t *testing.T) {
 			expectStatusCode: http.StatusOK,
 			expectHealth:     "true",
 		},
+		{
+			name:             "Healthy if SLOWFOLLOWER alarm is on",
+			alarms:           []*pb.AlarmMember{{MemberID: uint64(1), Alarm: pb.AlarmType_SLOWFOLLOWER}},
+			healthCheckURL:   "/health",
+			expectStatusCode: http.StatusOK,
+			expectHealth:     "true",
+		},
+		{
+			name:             "Healthy if CERTEXPIRY alarm is on",
+			alarms:           []*pb.AlarmMember{{MemberID: uint64(1), Alarm: pb.AlarmType_CERTEXPIRY}},
+			healthCheckURL:   "/health",
+			expectStatusCode: http.StatusOK,
+			expectHealth:     "true",
+		},
 		{
 			name:             "Healthy even if authentication failed",
 			healthCheckURL:   "/health",
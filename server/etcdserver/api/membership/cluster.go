@@ -42,6 +42,9 @@ import (
 
 // RaftCluster is a list of Members that belong to the same raft cluster
 type RaftCluster struct {
+	// epoch is accessed atomically; keep it 64-bit aligned.
+	epoch uint64
+
 	lg *zap.Logger
 
 	localID types.ID
@@ -272,6 +275,7 @@ func (c *RaftCluster) Recover(onSet func(*zap.Logger, *semver.Version)) {
 
 	if c.be != nil {
 		c.downgradeInfo = c.be.DowngradeInfoFromBackend()
+		c.setEpoch(c.be.ClusterEpochFromBackend())
 	}
 	sv := semver.Must(semver.NewVersion(version.Version))
 	if c.downgradeInfo != nil && c.downgradeInfo.Enabled {
@@ -745,6 +749,14 @@ func (c *RaftCluster) IsLocalMemberLearner() bool {
 	return localMember.IsLearner
 }
 
+// IsMemberLearner returns if the member with the given id is raft learner.
+func (c *RaftCluster) IsMemberLearner(id types.ID) bool {
+	c.Lock()
+	defer c.Unlock()
+	m, ok := c.members[id]
+	return ok && m.IsLearner
+}
+
 // DowngradeInfo returns the downgrade status of the cluster
 func (c *RaftCluster) DowngradeInfo() *serverversion.DowngradeInfo {
 	c.Lock()
@@ -0,0 +1,89 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package membership
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// The cluster epoch fences off the members and clients of a cluster from
+// before it was restored from a snapshot or forced into a new cluster, which
+// may share its cluster and member IDs, and from before a member was replaced.
+// It is stored in the backend outside of raft: restore and force-new-cluster
+// bump it, so do the removals and peer URL updates of members when applied,
+// and the members joining
+// the cluster adopt it from their peers or from the snapshot they receive.
+
+// Epoch returns the epoch of the cluster, or 0 if the local member joined the
+// cluster and has not yet learned it.
+func (c *RaftCluster) Epoch() uint64 {
+	return atomic.LoadUint64(&c.epoch)
+}
+
+func (c *RaftCluster) setEpoch(epoch uint64) {
+	atomic.StoreUint64(&c.epoch, epoch)
+}
+
+// RecoverEpoch loads the epoch of the cluster from the backend.
+func (c *RaftCluster) RecoverEpoch() {
+	if c.be != nil {
+		c.setEpoch(c.be.ClusterEpochFromBackend())
+	}
+}
+
+// BumpEpoch increments the epoch of the cluster while applying a raft entry
+// and saves it to the backend.
+func (c *RaftCluster) BumpEpoch() uint64 {
+	return c.bumpEpoch(func(epoch uint64) { c.be.MustSaveBumpedClusterEpochToBackend(epoch) })
+}
+
+// BumpEpochOnBootstrap increments the epoch of the cluster while bootstrapping
+// the local member and saves it to the backend.
+func (c *RaftCluster) BumpEpochOnBootstrap() uint64 {
+	return c.bumpEpoch(func(epoch uint64) { c.be.MustSaveClusterEpochToBackend(epoch) })
+}
+
+func (c *RaftCluster) bumpEpoch(save func(epoch uint64)) uint64 {
+	epoch := atomic.AddUint64(&c.epoch, 1)
+	if c.be != nil {
+		save(epoch)
+	}
+	c.lg.Info(
+		"bumped cluster epoch",
+		zap.String("cluster-id", c.cid.String()),
+		zap.String("local-member-id", c.localID.String()),
+		zap.Uint64("cluster-epoch", epoch),
+	)
+	return epoch
+}
+
+// AdoptEpoch sets the epoch of the cluster to the epoch of a peer if the
+// local member does not know it yet, and saves it to the backend.
+func (c *RaftCluster) AdoptEpoch(epoch uint64) {
+	if epoch == 0 || !atomic.CompareAndSwapUint64(&c.epoch, 0, epoch) {
+		return
+	}
+	if c.be != nil {
+		c.be.MustSaveClusterEpochToBackend(epoch)
+	}
+	c.lg.Info(
+		"adopted cluster epoch from peer",
+		zap.String("cluster-id", c.cid.String()),
+		zap.String("local-member-id", c.localID.String()),
+		zap.Uint64("cluster-epoch", epoch),
+	)
+}
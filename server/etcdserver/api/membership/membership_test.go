@@ -55,3 +55,7 @@ func (b *backendMock) MustDeleteMemberFromBackend(types.ID) {}
 
 func (b *backendMock) MustSaveDowngradeToBackend(*version.DowngradeInfo) {}
 func (b *backendMock) DowngradeInfoFromBackend() *version.DowngradeInfo  { return nil }
+
+func (b *backendMock) ClusterEpochFromBackend() uint64            { return 1 }
+func (b *backendMock) MustSaveClusterEpochToBackend(uint64)       {}
+func (b *backendMock) MustSaveBumpedClusterEpochToBackend(uint64) {}
@@ -28,6 +28,7 @@ type MembershipBackend interface {
 	ClusterVersionBackend
 	MemberBackend
 	DowngradeInfoBackend
+	ClusterEpochBackend
 	MustCreateBackendBuckets()
 }
 
@@ -43,6 +44,12 @@ type MemberBackend interface {
 	MustDeleteMemberFromBackend(types.ID)
 }
 
+type ClusterEpochBackend interface {
+	ClusterEpochFromBackend() uint64
+	MustSaveClusterEpochToBackend(epoch uint64)
+	MustSaveBumpedClusterEpochToBackend(epoch uint64)
+}
+
 type DowngradeInfoBackend interface {
 	MustSaveDowngradeToBackend(*version.DowngradeInfo)
 	DowngradeInfoFromBackend() *version.DowngradeInfo
@@ -0,0 +1,79 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rafthttp
+
+import (
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+)
+
+// Learners tells the peers that are raft learners.
+type Learners interface {
+	// IsMemberLearner returns true if the member is a raft learner.
+	IsMemberLearner(id types.ID) bool
+}
+
+// batchMsgApps merges the MsgApp of msgs into the preceding MsgApp to the
+// same peer whose entries they follow, as long as the entries of the merged
+// MsgApp are at most maxBytes. The follower then acknowledges the entries of
+// the MsgApp merged at once. The order of the messages to a peer is kept.
+func batchMsgApps(msgs []raftpb.Message, maxBytes int) []raftpb.Message {
+	if maxBytes <= 0 || len(msgs) < 2 {
+		return msgs
+	}
+	batched := make([]raftpb.Message, 0, len(msgs))
+	// the index in batched of the last message to each peer
+	last := make(map[uint64]int)
+	for _, m := range msgs {
+		if i, ok := last[m.To]; ok && isMsgApp(m) && canMergeMsgApp(&batched[i], &m, maxBytes) {
+			prev := &batched[i]
+			// the entries of the messages may be shared with the raft log
+			ents := make([]raftpb.Entry, 0, len(prev.Entries)+len(m.Entries))
+			ents = append(ents, prev.Entries...)
+			prev.Entries = append(ents, m.Entries...)
+			if m.Commit > prev.Commit {
+				prev.Commit = m.Commit
+			}
+			msgAppBatched.WithLabelValues(types.ID(m.To).String()).Inc()
+			continue
+		}
+		last[m.To] = len(batched)
+		batched = append(batched, m)
+	}
+	return batched
+}
+
+// canMergeMsgApp returns true if the entries of m follow the ones of prev,
+// within maxBytes once merged.
+func canMergeMsgApp(prev, m *raftpb.Message, maxBytes int) bool {
+	if !isMsgApp(*prev) || prev.Term != m.Term || prev.From != m.From {
+		return false
+	}
+	lastIndex, lastTerm := prev.Index, prev.LogTerm
+	if n := len(prev.Entries); n > 0 {
+		lastIndex, lastTerm = prev.Entries[n-1].Index, prev.Entries[n-1].Term
+	}
+	if m.Index != lastIndex || m.LogTerm != lastTerm {
+		return false
+	}
+	size := 0
+	for i := range prev.Entries {
+		size += prev.Entries[i].Size()
+	}
+	for i := range m.Entries {
+		size += m.Entries[i].Size()
+	}
+	return size <= maxBytes
+}
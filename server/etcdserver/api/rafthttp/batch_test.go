@@ -0,0 +1,135 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rafthttp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+)
+
+func TestBatchMsgApps(t *testing.T) {
+	ents := func(term uint64, indexes ...uint64) []raftpb.Entry {
+		var es []raftpb.Entry
+		for _, i := range indexes {
+			es = append(es, raftpb.Entry{Term: term, Index: i, Data: []byte("data")})
+		}
+		return es
+	}
+	app := func(to, term, logTerm, index, commit uint64, es []raftpb.Entry) raftpb.Message {
+		return raftpb.Message{Type: raftpb.MsgApp, From: 1, To: to, Term: term, LogTerm: logTerm, Index: index, Commit: commit, Entries: es}
+	}
+	tests := []struct {
+		name     string
+		msgs     []raftpb.Message
+		maxBytes int
+
+		wmsgs []raftpb.Message
+	}{
+		{
+			name:     "contiguous MsgApp to a peer",
+			msgs:     []raftpb.Message{app(2, 2, 1, 3, 3, ents(2, 4, 5)), app(2, 2, 2, 5, 4, ents(2, 6))},
+			maxBytes: 1024,
+			wmsgs:    []raftpb.Message{app(2, 2, 1, 3, 4, ents(2, 4, 5, 6))},
+		},
+		{
+			name: "interleaved peers",
+			msgs: []raftpb.Message{
+				app(2, 2, 1, 3, 3, ents(2, 4)), app(3, 2, 1, 3, 3, ents(2, 4)),
+				app(2, 2, 2, 4, 4, ents(2, 5)), app(3, 2, 2, 4, 4, ents(2, 5)),
+			},
+			maxBytes: 1024,
+			wmsgs:    []raftpb.Message{app(2, 2, 1, 3, 4, ents(2, 4, 5)), app(3, 2, 1, 3, 4, ents(2, 4, 5))},
+		},
+		{
+			name:     "empty MsgApp carrying the commit",
+			msgs:     []raftpb.Message{app(2, 2, 1, 3, 3, ents(2, 4)), app(2, 2, 2, 4, 4, nil)},
+			maxBytes: 1024,
+			wmsgs:    []raftpb.Message{app(2, 2, 1, 3, 4, ents(2, 4))},
+		},
+		{
+			name:     "not contiguous",
+			msgs:     []raftpb.Message{app(2, 2, 1, 3, 3, ents(2, 4)), app(2, 2, 2, 5, 4, ents(2, 6))},
+			maxBytes: 1024,
+			wmsgs:    []raftpb.Message{app(2, 2, 1, 3, 3, ents(2, 4)), app(2, 2, 2, 5, 4, ents(2, 6))},
+		},
+		{
+			name:     "another message in between",
+			msgs:     []raftpb.Message{app(2, 2, 1, 3, 3, ents(2, 4)), {Type: raftpb.MsgHeartbeat, From: 1, To: 2}, app(2, 2, 2, 4, 4, ents(2, 5))},
+			maxBytes: 1024,
+			wmsgs:    []raftpb.Message{app(2, 2, 1, 3, 3, ents(2, 4)), {Type: raftpb.MsgHeartbeat, From: 1, To: 2}, app(2, 2, 2, 4, 4, ents(2, 5))},
+		},
+		{
+			name:     "beyond the max bytes",
+			msgs:     []raftpb.Message{app(2, 2, 1, 3, 3, ents(2, 4)), app(2, 2, 2, 4, 4, ents(2, 5))},
+			maxBytes: 10,
+			wmsgs:    []raftpb.Message{app(2, 2, 1, 3, 3, ents(2, 4)), app(2, 2, 2, 4, 4, ents(2, 5))},
+		},
+		{
+			name:     "disabled",
+			msgs:     []raftpb.Message{app(2, 2, 1, 3, 3, ents(2, 4)), app(2, 2, 2, 4, 4, ents(2, 5))},
+			maxBytes: 0,
+			wmsgs:    []raftpb.Message{app(2, 2, 1, 3, 3, ents(2, 4)), app(2, 2, 2, 4, 4, ents(2, 5))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := make([]raftpb.Message, len(tt.msgs))
+			copy(msgs, tt.msgs)
+			batched := batchMsgApps(msgs, tt.maxBytes)
+			if !reflect.DeepEqual(batched, tt.wmsgs) {
+				t.Errorf("batched = %+v, want %+v", batched, tt.wmsgs)
+			}
+			if !reflect.DeepEqual(msgs, tt.msgs) {
+				t.Errorf("messages modified to %+v, want %+v", msgs, tt.msgs)
+			}
+		})
+	}
+}
+
+type fakeLearners map[types.ID]bool
+
+func (l fakeLearners) IsMemberLearner(id types.ID) bool { return l[id] }
+
+func TestPeerPaceHeartbeat(t *testing.T) {
+	heartbeat := raftpb.Message{Type: raftpb.MsgHeartbeat, From: 1, To: 2}
+	tests := []struct {
+		name     string
+		learner  bool
+		interval time.Duration
+		m        raftpb.Message
+
+		wpaced bool
+	}{
+		{name: "learner", learner: true, interval: time.Hour, m: heartbeat, wpaced: true},
+		{name: "voter", interval: time.Hour, m: heartbeat},
+		{name: "ReadIndex heartbeat", learner: true, interval: time.Hour, m: raftpb.Message{Type: raftpb.MsgHeartbeat, From: 1, To: 2, Context: []byte("ctx")}},
+		{name: "MsgApp", learner: true, interval: time.Hour, m: raftpb.Message{Type: raftpb.MsgApp, From: 1, To: 2}},
+		{name: "no interval", learner: true, m: heartbeat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &peer{id: 2, learners: fakeLearners{2: tt.learner}, heartbeatInterval: tt.interval}
+			// the first heartbeat is sent
+			p.paceHeartbeat(heartbeat)
+			if paced := p.paceHeartbeat(tt.m); paced != tt.wpaced {
+				t.Errorf("paced = %v, want %v", paced, tt.wpaced)
+			}
+		})
+	}
+}
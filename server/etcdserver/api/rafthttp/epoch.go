@@ -0,0 +1,82 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rafthttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+
+	"go.uber.org/zap"
+)
+
+var errClusterEpochMismatch = errors.New("cluster epoch mismatch")
+
+// ClusterEpoch is the epoch of the cluster, bumped when the cluster is
+// restored from a snapshot or a member is replaced. Peers of an older epoch
+// are rejected.
+type ClusterEpoch interface {
+	// Epoch returns the local epoch, or 0 if it is not known yet.
+	Epoch() uint64
+	// AdoptEpoch sets the local epoch to the epoch of a peer if it is not known yet.
+	AdoptEpoch(epoch uint64)
+}
+
+// localEpoch returns the epoch of the local member, 0 if unknown.
+func localEpoch(ce ClusterEpoch) uint64 {
+	if ce == nil {
+		return 0
+	}
+	return ce.Epoch()
+}
+
+func setClusterEpochHeader(header http.Header, ce ClusterEpoch) {
+	if epoch := localEpoch(ce); epoch != 0 {
+		header.Set("X-Etcd-Cluster-Epoch", strconv.FormatUint(epoch, 10))
+	}
+}
+
+// checkClusterEpochFromHeader checks that the epoch of the remote peer in
+// the header is not older than the local epoch. Members that do not know
+// their epoch yet are accepted, and adopt the epoch of the peer. Peers of a
+// newer epoch are accepted as well: they applied the entry bumping it before
+// the local member, which must keep hearing from them to apply it too.
+func checkClusterEpochFromHeader(lg *zap.Logger, localID types.ID, header http.Header, ce ClusterEpoch) error {
+	if ce == nil {
+		return nil
+	}
+	remote, err := strconv.ParseUint(header.Get("X-Etcd-Cluster-Epoch"), 10, 64)
+	if err != nil || remote == 0 {
+		return nil
+	}
+	local := ce.Epoch()
+	if local == 0 {
+		ce.AdoptEpoch(remote)
+		return nil
+	}
+	if remote < local {
+		lg.Warn(
+			"request cluster epoch mismatch",
+			zap.String("local-member-id", localID.String()),
+			zap.Uint64("local-member-cluster-epoch", local),
+			zap.String("remote-peer-server-name", header.Get("X-Server-From")),
+			zap.Uint64("remote-peer-cluster-epoch", remote),
+		)
+		return errClusterEpochMismatch
+	}
+	return nil
+}
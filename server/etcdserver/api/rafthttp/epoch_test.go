@@ -0,0 +1,65 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rafthttp
+
+import (
+	"net/http"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeClusterEpoch struct{ epoch uint64 }
+
+func (e *fakeClusterEpoch) Epoch() uint64 { return e.epoch }
+
+func (e *fakeClusterEpoch) AdoptEpoch(epoch uint64) {
+	if e.epoch == 0 {
+		e.epoch = epoch
+	}
+}
+
+func TestCheckClusterEpochFromHeader(t *testing.T) {
+	tests := []struct {
+		local  uint64
+		remote uint64
+
+		wErr   error
+		wLocal uint64
+	}{
+		{local: 2, remote: 2, wLocal: 2},
+		{local: 3, remote: 2, wErr: errClusterEpochMismatch, wLocal: 3},
+		// the local member has yet to apply the entry bumping the epoch
+		{local: 2, remote: 3, wLocal: 2},
+		// peers from before v3.6 do not send their epoch
+		{local: 2, remote: 0, wLocal: 2},
+		// joining members adopt the epoch of the cluster
+		{local: 0, remote: 3, wLocal: 3},
+	}
+	for i, tt := range tests {
+		ce := &fakeClusterEpoch{epoch: tt.local}
+		header := make(http.Header)
+		setClusterEpochHeader(header, &fakeClusterEpoch{epoch: tt.remote})
+		if err := checkClusterEpochFromHeader(zaptest.NewLogger(t), 1, header, ce); err != tt.wErr {
+			t.Errorf("#%d: err = %v, want %v", i, err, tt.wErr)
+		}
+		if ce.epoch != tt.wLocal {
+			t.Errorf("#%d: local epoch = %d, want %d", i, ce.epoch, tt.wLocal)
+		}
+	}
+	if err := checkClusterEpochFromHeader(zaptest.NewLogger(t), 1, make(http.Header), nil); err != nil {
+		t.Errorf("nil epoch: err = %v, want nil", err)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"go.etcd.io/etcd/api/v3/version"
@@ -53,6 +54,9 @@ var (
 	RaftStreamPrefix   = path.Join(RaftPrefix, "stream")
 	RaftSnapshotPrefix = path.Join(RaftPrefix, "snapshot")
 
+	// RaftSnapshotChunkPrefix receives snapshots in chunks of their database.
+	RaftSnapshotChunkPrefix = path.Join(RaftSnapshotPrefix, "chunk")
+
 	errIncompatibleVersion = errors.New("incompatible version")
 	errClusterIDMismatch   = errors.New("cluster ID mismatch")
 )
@@ -71,6 +75,7 @@ type pipelineHandler struct {
 	tr      Transporter
 	r       Raft
 	cid     types.ID
+	epoch   ClusterEpoch
 }
 
 // newPipelineHandler returns a handler for handling raft messages
@@ -85,6 +90,7 @@ func newPipelineHandler(t *Transport, r Raft, cid types.ID) http.Handler {
 		tr:      t,
 		r:       r,
 		cid:     cid,
+		epoch:   t.ClusterEpoch,
 	}
 	if h.lg == nil {
 		h.lg = zap.NewNop()
@@ -100,11 +106,16 @@ func (h *pipelineHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("X-Etcd-Cluster-ID", h.cid.String())
+	setClusterEpochHeader(w.Header(), h.epoch)
 
 	if err := checkClusterCompatibilityFromHeader(h.lg, h.localID, r.Header, h.cid); err != nil {
 		http.Error(w, err.Error(), http.StatusPreconditionFailed)
 		return
 	}
+	if err := checkClusterEpochFromHeader(h.lg, h.localID, r.Header, h.epoch); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
 
 	addRemoteFromRequest(h.tr, r)
 
@@ -168,6 +179,10 @@ type snapshotHandler struct {
 
 	localID types.ID
 	cid     types.ID
+	epoch   ClusterEpoch
+
+	mu        sync.Mutex
+	transfers map[types.ID]*snapshotTransfer // snapshots received in chunks, by sender
 }
 
 func newSnapshotHandler(t *Transport, r Raft, snapshotter *snap.Snapshotter, cid types.ID) http.Handler {
@@ -178,6 +193,8 @@ func newSnapshotHandler(t *Transport, r Raft, snapshotter *snap.Snapshotter, cid
 		snapshotter: snapshotter,
 		localID:     t.ID,
 		cid:         cid,
+		epoch:       t.ClusterEpoch,
+		transfers:   make(map[types.ID]*snapshotTransfer),
 	}
 	if h.lg == nil {
 		h.lg = zap.NewNop()
@@ -207,15 +224,62 @@ func (h *snapshotHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("X-Etcd-Cluster-ID", h.cid.String())
+	setClusterEpochHeader(w.Header(), h.epoch)
 
 	if err := checkClusterCompatibilityFromHeader(h.lg, h.localID, r.Header, h.cid); err != nil {
 		http.Error(w, err.Error(), http.StatusPreconditionFailed)
 		snapshotReceiveFailures.WithLabelValues(unknownSnapshotSender).Inc()
 		return
 	}
+	if err := checkClusterEpochFromHeader(h.lg, h.localID, r.Header, h.epoch); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		snapshotReceiveFailures.WithLabelValues(unknownSnapshotSender).Inc()
+		return
+	}
 
 	addRemoteFromRequest(h.tr, r)
 
+	if r.URL.Path == RaftSnapshotChunkPrefix {
+		h.serveChunk(w, r, start)
+		return
+	}
+
+	m, ok := h.decodeSnapshotMessage(w, r)
+	if !ok {
+		return
+	}
+	from := types.ID(m.From).String()
+
+	snapshotReceiveInflights.WithLabelValues(from).Inc()
+	defer func() {
+		snapshotReceiveInflights.WithLabelValues(from).Dec()
+	}()
+
+	h.lg.Info(
+		"receiving database snapshot",
+		zap.String("local-member-id", h.localID.String()),
+		zap.String("remote-snapshot-sender-id", from),
+		zap.Uint64("incoming-snapshot-index", m.Snapshot.Metadata.Index),
+		zap.Int("incoming-snapshot-message-size-bytes", m.Size()),
+		zap.String("incoming-snapshot-message-size", humanize.Bytes(uint64(m.Size()))),
+	)
+
+	// save incoming database snapshot.
+
+	n, err := h.snapshotter.SaveDBFrom(r.Body, m.Snapshot.Metadata.Index)
+	if err != nil {
+		h.saveFailed(w, m, err)
+		return
+	}
+
+	receivedBytes.WithLabelValues(from).Add(float64(n))
+
+	h.process(w, m, n, start)
+}
+
+// decodeSnapshotMessage decodes the snapshot message starting the body of the
+// request, or writes the error to the response.
+func (h *snapshotHandler) decodeSnapshotMessage(w http.ResponseWriter, r *http.Request) (raftpb.Message, bool) {
 	dec := &messageDecoder{r: r.Body}
 	// let snapshots be very large since they can exceed 512MB for large installations
 	m, err := dec.decodeLimit(snapshotLimitByte)
@@ -231,11 +295,10 @@ func (h *snapshotHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, msg, http.StatusBadRequest)
 		recvFailures.WithLabelValues(r.RemoteAddr).Inc()
 		snapshotReceiveFailures.WithLabelValues(from).Inc()
-		return
+		return m, false
 	}
 
-	msgSize := m.Size()
-	receivedBytes.WithLabelValues(from).Add(float64(msgSize))
+	receivedBytes.WithLabelValues(from).Add(float64(m.Size()))
 
 	if m.Type != raftpb.MsgSnap {
 		h.lg.Warn(
@@ -246,42 +309,29 @@ func (h *snapshotHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		)
 		http.Error(w, "wrong raft message type", http.StatusBadRequest)
 		snapshotReceiveFailures.WithLabelValues(from).Inc()
-		return
+		return m, false
 	}
+	return m, true
+}
 
-	snapshotReceiveInflights.WithLabelValues(from).Inc()
-	defer func() {
-		snapshotReceiveInflights.WithLabelValues(from).Dec()
-	}()
-
-	h.lg.Info(
-		"receiving database snapshot",
+func (h *snapshotHandler) saveFailed(w http.ResponseWriter, m raftpb.Message, err error) {
+	from := types.ID(m.From).String()
+	msg := fmt.Sprintf("failed to save KV snapshot (%v)", err)
+	h.lg.Warn(
+		"failed to save incoming database snapshot",
 		zap.String("local-member-id", h.localID.String()),
 		zap.String("remote-snapshot-sender-id", from),
 		zap.Uint64("incoming-snapshot-index", m.Snapshot.Metadata.Index),
-		zap.Int("incoming-snapshot-message-size-bytes", msgSize),
-		zap.String("incoming-snapshot-message-size", humanize.Bytes(uint64(msgSize))),
+		zap.Error(err),
 	)
+	http.Error(w, msg, http.StatusInternalServerError)
+	snapshotReceiveFailures.WithLabelValues(from).Inc()
+}
 
-	// save incoming database snapshot.
-
-	n, err := h.snapshotter.SaveDBFrom(r.Body, m.Snapshot.Metadata.Index)
-	if err != nil {
-		msg := fmt.Sprintf("failed to save KV snapshot (%v)", err)
-		h.lg.Warn(
-			"failed to save incoming database snapshot",
-			zap.String("local-member-id", h.localID.String()),
-			zap.String("remote-snapshot-sender-id", from),
-			zap.Uint64("incoming-snapshot-index", m.Snapshot.Metadata.Index),
-			zap.Error(err),
-		)
-		http.Error(w, msg, http.StatusInternalServerError)
-		snapshotReceiveFailures.WithLabelValues(from).Inc()
-		return
-	}
-
-	receivedBytes.WithLabelValues(from).Add(float64(n))
-
+// process forwards the snapshot message, whose database of n bytes is saved,
+// to raft.
+func (h *snapshotHandler) process(w http.ResponseWriter, m raftpb.Message, n int64, start time.Time) {
+	from := types.ID(m.From).String()
 	downloadTook := time.Since(start)
 	h.lg.Info(
 		"received and saved database snapshot",
@@ -328,6 +378,7 @@ type streamHandler struct {
 	r          Raft
 	id         types.ID
 	cid        types.ID
+	epoch      ClusterEpoch
 }
 
 func newStreamHandler(t *Transport, pg peerGetter, r Raft, id, cid types.ID) http.Handler {
@@ -338,6 +389,7 @@ func newStreamHandler(t *Transport, pg peerGetter, r Raft, id, cid types.ID) htt
 		r:          r,
 		id:         id,
 		cid:        cid,
+		epoch:      t.ClusterEpoch,
 	}
 	if h.lg == nil {
 		h.lg = zap.NewNop()
@@ -354,11 +406,16 @@ func (h *streamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("X-Server-Version", version.Version)
 	w.Header().Set("X-Etcd-Cluster-ID", h.cid.String())
+	setClusterEpochHeader(w.Header(), h.epoch)
 
 	if err := checkClusterCompatibilityFromHeader(h.lg, h.tr.ID, r.Header, h.cid); err != nil {
 		http.Error(w, err.Error(), http.StatusPreconditionFailed)
 		return
 	}
+	if err := checkClusterEpochFromHeader(h.lg, h.tr.ID, r.Header, h.epoch); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
 
 	var t streamType
 	switch path.Dir(r.URL.Path) {
@@ -62,6 +62,33 @@ var (
 		[]string{"To"},
 	)
 
+	sendQueueLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "network",
+		Name:      "peer_send_queue_length",
+		Help:      "The number of messages pending in the send queue of the peer the last message was queued to.",
+	},
+		[]string{"To"},
+	)
+
+	msgAppBatched = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "network",
+		Name:      "peer_msgapp_batched_total",
+		Help:      "The total number of MsgApp to peers merged into the preceding MsgApp.",
+	},
+		[]string{"To"},
+	)
+
+	learnerHeartbeatsPaced = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "network",
+		Name:      "learner_heartbeats_paced_total",
+		Help:      "The total number of heartbeats not sent to learners within the learner heartbeat interval.",
+	},
+		[]string{"To"},
+	)
+
 	recvFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "etcd",
 		Subsystem: "network",
@@ -98,6 +125,24 @@ var (
 		[]string{"To"},
 	)
 
+	snapshotSendProgressBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "network",
+		Name:      "snapshot_send_progress_bytes",
+		Help:      "Number of bytes of the database of the inflight snapshot sends in chunks received by the peer",
+	},
+		[]string{"To"},
+	)
+
+	snapshotSendChunkRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "network",
+		Name:      "snapshot_send_chunk_retries_total",
+		Help:      "Total number of snapshot chunks sent again after failing to be sent",
+	},
+		[]string{"To"},
+	)
+
 	snapshotSendSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: "etcd",
 		Subsystem: "network",
@@ -138,6 +183,15 @@ var (
 		[]string{"From"},
 	)
 
+	snapshotReceiveProgressBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "network",
+		Name:      "snapshot_receive_progress_bytes",
+		Help:      "Number of bytes of the database of the inflight snapshot receives in chunks received",
+	},
+		[]string{"From"},
+	)
+
 	snapshotReceiveSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: "etcd",
 		Subsystem: "network",
@@ -171,15 +225,21 @@ func init() {
 	prometheus.MustRegister(sentBytes)
 	prometheus.MustRegister(receivedBytes)
 	prometheus.MustRegister(sentFailures)
+	prometheus.MustRegister(sendQueueLength)
+	prometheus.MustRegister(msgAppBatched)
+	prometheus.MustRegister(learnerHeartbeatsPaced)
 	prometheus.MustRegister(recvFailures)
 
 	prometheus.MustRegister(snapshotSend)
 	prometheus.MustRegister(snapshotSendInflights)
 	prometheus.MustRegister(snapshotSendFailures)
+	prometheus.MustRegister(snapshotSendProgressBytes)
+	prometheus.MustRegister(snapshotSendChunkRetries)
 	prometheus.MustRegister(snapshotSendSeconds)
 	prometheus.MustRegister(snapshotReceive)
 	prometheus.MustRegister(snapshotReceiveInflights)
 	prometheus.MustRegister(snapshotReceiveFailures)
+	prometheus.MustRegister(snapshotReceiveProgressBytes)
 	prometheus.MustRegister(snapshotReceiveSeconds)
 
 	prometheus.MustRegister(rttSec)
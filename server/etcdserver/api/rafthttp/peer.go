@@ -25,6 +25,7 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
 	stats "go.etcd.io/etcd/server/v3/etcdserver/api/v2stats"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 )
@@ -124,6 +125,14 @@ type peer struct {
 	mu     sync.Mutex
 	paused bool
 
+	// the heartbeats to the peer are paced to heartbeatInterval if it is a learner
+	learners          Learners
+	heartbeatInterval time.Duration
+	lastHeartbeat     time.Time // guarded by mu
+
+	queueLength     prometheus.Gauge
+	heartbeatsPaced prometheus.Counter
+
 	cancel context.CancelFunc // cancel pending works in go routine created by peer.
 	stopc  chan struct{}
 }
@@ -167,6 +176,11 @@ func startPeer(t *Transport, urls types.URLs, peerID types.ID, fs *stats.Followe
 		recvc:          make(chan raftpb.Message, recvBufSize),
 		propc:          make(chan raftpb.Message, maxPendingProposals),
 		stopc:          make(chan struct{}),
+
+		learners:          t.Learners,
+		heartbeatInterval: t.LearnerHeartbeatInterval,
+		queueLength:       sendQueueLength.WithLabelValues(peerID.String()),
+		heartbeatsPaced:   learnerHeartbeatsPaced.WithLabelValues(peerID.String()),
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -241,10 +255,15 @@ func (p *peer) send(m raftpb.Message) {
 	if paused {
 		return
 	}
+	if p.paceHeartbeat(m) {
+		p.heartbeatsPaced.Inc()
+		return
+	}
 
 	writec, name := p.pick(m)
 	select {
 	case writec <- m:
+		p.queueLength.Set(float64(len(writec)))
 	default:
 		p.r.ReportUnreachable(m.To)
 		if isMsgSnap(m) {
@@ -265,6 +284,26 @@ func (p *peer) send(m raftpb.Message) {
 	}
 }
 
+// paceHeartbeat returns true if the heartbeat m to a learner peer is not
+// sent, as it follows the last heartbeat sent by less than the interval.
+// Heartbeats of ReadIndex requests are always sent.
+func (p *peer) paceHeartbeat(m raftpb.Message) bool {
+	if m.Type != raftpb.MsgHeartbeat || len(m.Context) != 0 || p.heartbeatInterval <= 0 || p.learners == nil {
+		return false
+	}
+	if !p.learners.IsMemberLearner(p.id) {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if now.Sub(p.lastHeartbeat) < p.heartbeatInterval {
+		return true
+	}
+	p.lastHeartbeat = now
+	return false
+}
+
 func (p *peer) sendSnap(m snap.Message) {
 	go p.snapSender.send(m)
 }
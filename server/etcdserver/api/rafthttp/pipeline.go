@@ -133,6 +133,7 @@ func (p *pipeline) handle() {
 func (p *pipeline) post(data []byte) (err error) {
 	u := p.picker.pick()
 	req := createPostRequest(p.tr.Logger, u, RaftPrefix, bytes.NewBuffer(data), "application/protobuf", p.tr.URLs, p.tr.ID, p.tr.ClusterID)
+	setClusterEpochHeader(req.Header, p.tr.ClusterEpoch)
 
 	done := make(chan struct{}, 1)
 	ctx, cancel := context.WithCancel(context.Background())
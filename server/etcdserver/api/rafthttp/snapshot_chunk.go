@@ -0,0 +1,419 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rafthttp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	pioutil "go.etcd.io/etcd/pkg/v3/ioutil"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+
+	humanize "github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// A snapshot is sent to RaftSnapshotChunkPrefix by a transfer of requests:
+// the first one posts the snapshot message, and the following ones post the
+// chunks of its database in order. A chunk failing to be posted is posted
+// again, so that the transfer resumes from the last chunk the remote received
+// instead of from the start. Once it receives the whole database, the remote
+// processes the snapshot message. Remotes not receiving chunks are sent the
+// snapshot in a single request to RaftSnapshotPrefix.
+
+const (
+	// snapshotTransferHeader identifies the transfer of the request.
+	snapshotTransferHeader = "X-Etcd-Snapshot-Transfer"
+	// snapshotSizeHeader is the size of the database of the transfer.
+	snapshotSizeHeader = "X-Etcd-Snapshot-Size"
+	// snapshotOffsetHeader is the offset in the database of the chunk of the
+	// request, and the number of bytes of the database received so far in
+	// responses. Requests posting the snapshot message have none.
+	snapshotOffsetHeader = "X-Etcd-Snapshot-Offset"
+
+	// snapshotSendBurstByte is the burst of the snapshot send rate limit.
+	snapshotSendBurstByte = 256 * 1024
+)
+
+var (
+	// snapshotChunkByte is the size of the chunks of the database of snapshots.
+	snapshotChunkByte = 8 * 1024 * 1024
+	// snapshotChunkRetries is the number of times a chunk failing to be
+	// posted is posted again before the snapshot send fails.
+	snapshotChunkRetries = 5
+	// snapshotChunkRetryInterval is the interval between the posts of a chunk.
+	snapshotChunkRetryInterval = time.Second
+
+	errSnapshotChunksUnsupported = errors.New("snapshot chunks unsupported by the remote")
+	errSnapshotTransferUnknown   = errors.New("snapshot transfer unknown to the remote")
+	errSnapshotChunkConflict     = errors.New("snapshot chunk offset conflicts with the remote")
+)
+
+// snapshotTransfer is a snapshot being received in chunks.
+type snapshotTransfer struct {
+	id    string
+	m     raftpb.Message
+	size  int64
+	start time.Time
+
+	mu     sync.Mutex
+	f      *os.File // created on the first chunk
+	offset int64    // bytes of the database received
+}
+
+// abort removes the database received so far.
+func (t *snapshotTransfer) abort() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.f != nil {
+		t.f.Close()
+		os.Remove(t.f.Name())
+		t.f = nil
+	}
+}
+
+// serveChunk serves a request of a snapshot transfer, and processes the
+// snapshot message once the whole database is received.
+func (h *snapshotHandler) serveChunk(w http.ResponseWriter, r *http.Request, start time.Time) {
+	from, err := types.IDFromString(r.Header.Get("X-Server-From"))
+	id := r.Header.Get(snapshotTransferHeader)
+	size, serr := strconv.ParseInt(r.Header.Get(snapshotSizeHeader), 10, 64)
+	if err != nil || id == "" || serr != nil || size < 0 {
+		http.Error(w, "invalid snapshot transfer", http.StatusBadRequest)
+		snapshotReceiveFailures.WithLabelValues(unknownSnapshotSender).Inc()
+		return
+	}
+	if r.Header.Get(snapshotOffsetHeader) == "" {
+		h.startTransfer(w, r, from, id, size, start)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get(snapshotOffsetHeader), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid snapshot chunk offset", http.StatusBadRequest)
+		snapshotReceiveFailures.WithLabelValues(from.String()).Inc()
+		return
+	}
+
+	h.mu.Lock()
+	t := h.transfers[from]
+	h.mu.Unlock()
+	if t == nil || t.id != id {
+		http.Error(w, errSnapshotTransferUnknown.Error(), http.StatusGone)
+		snapshotReceiveFailures.WithLabelValues(from.String()).Inc()
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if offset != t.offset {
+		w.Header().Set(snapshotOffsetHeader, strconv.FormatInt(t.offset, 10))
+		http.Error(w, errSnapshotChunkConflict.Error(), http.StatusConflict)
+		return
+	}
+	if t.f == nil {
+		if t.f, err = h.snapshotter.NewDBPart(); err != nil {
+			h.endTransfer(from, t)
+			h.saveFailed(w, t.m, err)
+			return
+		}
+	}
+	n, err := io.Copy(t.f, io.LimitReader(r.Body, t.size-t.offset))
+	if err != nil {
+		// drop the part of the chunk received, so that the chunk is posted
+		// again at the same offset
+		t.f.Truncate(t.offset)
+		t.f.Seek(t.offset, io.SeekStart)
+		h.lg.Warn(
+			"failed to receive database snapshot chunk",
+			zap.String("local-member-id", h.localID.String()),
+			zap.String("remote-snapshot-sender-id", from.String()),
+			zap.Int64("offset", offset),
+			zap.Error(err),
+		)
+		http.Error(w, fmt.Sprintf("failed to receive snapshot chunk (%v)", err), http.StatusInternalServerError)
+		return
+	}
+	t.offset += n
+	receivedBytes.WithLabelValues(from.String()).Add(float64(n))
+	snapshotReceiveProgressBytes.WithLabelValues(from.String()).Set(float64(t.offset))
+	if t.offset < t.size {
+		w.Header().Set(snapshotOffsetHeader, strconv.FormatInt(t.offset, 10))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.completeTransfer(w, from, t)
+}
+
+// startTransfer starts the transfer of the snapshot message of the request,
+// replacing any previous transfer of the sender.
+func (h *snapshotHandler) startTransfer(w http.ResponseWriter, r *http.Request, from types.ID, id string, size int64, start time.Time) {
+	h.mu.Lock()
+	t := h.transfers[from]
+	h.mu.Unlock()
+	if t != nil && t.id == id {
+		// the response to the snapshot message was lost
+		t.mu.Lock()
+		w.Header().Set(snapshotOffsetHeader, strconv.FormatInt(t.offset, 10))
+		t.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	m, ok := h.decodeSnapshotMessage(w, r)
+	if !ok {
+		return
+	}
+	h.lg.Info(
+		"receiving database snapshot in chunks",
+		zap.String("local-member-id", h.localID.String()),
+		zap.String("remote-snapshot-sender-id", from.String()),
+		zap.Uint64("incoming-snapshot-index", m.Snapshot.Metadata.Index),
+		zap.Int("incoming-snapshot-message-size-bytes", m.Size()),
+		zap.Int64("incoming-snapshot-size-bytes", size),
+		zap.String("incoming-snapshot-size", humanize.Bytes(uint64(size))),
+	)
+
+	t = &snapshotTransfer{id: id, m: m, size: size, start: start}
+	h.mu.Lock()
+	prev := h.transfers[from]
+	h.transfers[from] = t
+	h.mu.Unlock()
+	if prev != nil {
+		prev.abort()
+	} else {
+		snapshotReceiveInflights.WithLabelValues(from.String()).Inc()
+	}
+
+	if size == 0 {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		var err error
+		if t.f, err = h.snapshotter.NewDBPart(); err != nil {
+			h.endTransfer(from, t)
+			h.saveFailed(w, m, err)
+			return
+		}
+		h.completeTransfer(w, from, t)
+		return
+	}
+	w.Header().Set(snapshotOffsetHeader, "0")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// completeTransfer saves the database of the transfer and processes its
+// snapshot message. The transfer must be locked.
+func (h *snapshotHandler) completeTransfer(w http.ResponseWriter, from types.ID, t *snapshotTransfer) {
+	h.endTransfer(from, t)
+	f := t.f
+	t.f = nil
+	n, err := h.snapshotter.SaveDBPart(f, t.m.Snapshot.Metadata.Index)
+	if err != nil {
+		h.saveFailed(w, t.m, err)
+		return
+	}
+	h.process(w, t.m, n, t.start)
+}
+
+// endTransfer removes the transfer from the transfers of the sender.
+func (h *snapshotHandler) endTransfer(from types.ID, t *snapshotTransfer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.transfers[from] == t {
+		delete(h.transfers, from)
+		snapshotReceiveInflights.WithLabelValues(from.String()).Dec()
+		snapshotReceiveProgressBytes.WithLabelValues(from.String()).Set(0)
+	}
+}
+
+// sendChunks sends the snapshot message to RaftSnapshotChunkPrefix, followed
+// by the chunks of its database. It returns errSnapshotChunksUnsupported,
+// with the database not read, if the remote does not receive chunks.
+func (s *snapshotSender) sendChunks(merged snap.Message) (u url.URL, err error) {
+	m := merged.Message
+	buf := new(bytes.Buffer)
+	if err = (&messageEncoder{w: buf}).encode(&m); err != nil {
+		return s.picker.pick(), err
+	}
+	size := merged.TotalSize - int64(m.Size())
+	id := fmt.Sprintf("%x-%x", m.Snapshot.Metadata.Index, time.Now().UnixNano())
+	to := s.to.String()
+	defer snapshotSendProgressBytes.WithLabelValues(to).Set(0)
+
+	if u, err = s.postChunk(id, size, -1, buf.Bytes()); err != nil {
+		return u, err
+	}
+	chunk := make([]byte, snapshotChunkByte)
+	for offset := int64(0); offset < size; {
+		n := int64(len(chunk))
+		if size-offset < n {
+			n = size - offset
+		}
+		if _, err = io.ReadFull(merged.ReadCloser, chunk[:n]); err != nil {
+			return u, err
+		}
+		if offset+n == size {
+			// the database must end with its last chunk
+			if err = expectEOF(merged.ReadCloser); err != nil {
+				return u, err
+			}
+		}
+		if u, err = s.postChunk(id, size, offset, chunk[:n]); err != nil {
+			if err == errSnapshotChunksUnsupported {
+				// the remote restarted without receiving chunks, and the
+				// database is partly read
+				err = errSnapshotTransferUnknown
+			}
+			return u, err
+		}
+		offset += n
+		snapshotSendProgressBytes.WithLabelValues(to).Set(float64(offset))
+	}
+	return u, nil
+}
+
+// postChunk posts the chunk of the database of the transfer at offset, or its
+// snapshot message if offset is negative, up to snapshotChunkRetries times
+// more if it fails.
+func (s *snapshotSender) postChunk(id string, size, offset int64, data []byte) (u url.URL, err error) {
+	to := s.to.String()
+	for retry := 0; ; retry++ {
+		u = s.picker.pick()
+		req := createPostRequest(s.tr.Logger, u, RaftSnapshotChunkPrefix, s.throttle(bytes.NewReader(data)), "application/octet-stream", s.tr.URLs, s.from, s.cid)
+		setClusterEpochHeader(req.Header, s.tr.ClusterEpoch)
+		req.Header.Set(snapshotTransferHeader, id)
+		req.Header.Set(snapshotSizeHeader, strconv.FormatInt(size, 10))
+		if offset >= 0 {
+			req.Header.Set(snapshotOffsetHeader, strconv.FormatInt(offset, 10))
+		}
+
+		var (
+			received  int64
+			retriable bool
+		)
+		received, retriable, err = s.postChunkRequest(req)
+		if err == nil {
+			return u, nil
+		}
+		if err == errSnapshotChunkConflict && offset >= 0 {
+			switch received {
+			case offset + int64(len(data)):
+				// the response to the chunk was lost
+				return u, nil
+			case offset:
+				retriable = true
+			}
+		}
+		if !retriable || retry >= snapshotChunkRetries {
+			return u, err
+		}
+
+		s.picker.unreachable(u)
+		snapshotSendChunkRetries.WithLabelValues(to).Inc()
+		if s.tr.Logger != nil {
+			s.tr.Logger.Warn(
+				"failed to send database snapshot chunk; retrying",
+				zap.String("remote-peer-id", to),
+				zap.Int64("offset", offset),
+				zap.Int("retry", retry+1),
+				zap.Error(err),
+			)
+		}
+		select {
+		case <-s.stopc:
+			return u, errStopped
+		case <-time.After(snapshotChunkRetryInterval):
+		}
+	}
+}
+
+// postChunkRequest posts the request of a transfer, and returns the number of
+// bytes of the database received by the remote, and whether posting the
+// request again may succeed if it fails.
+func (s *snapshotSender) postChunkRequest(req *http.Request) (int64, bool, error) {
+	resp, body, err := s.roundTrip(req)
+	if err != nil {
+		return 0, err != errStopped, err
+	}
+	received, _ := strconv.ParseInt(resp.Header.Get(snapshotOffsetHeader), 10, 64)
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return 0, false, errSnapshotChunksUnsupported
+	case http.StatusGone:
+		return 0, false, errSnapshotTransferUnknown
+	case http.StatusConflict:
+		return received, false, errSnapshotChunkConflict
+	case http.StatusInternalServerError:
+		return 0, true, fmt.Errorf("failed to post snapshot chunk to %q (%s)", req.URL.String(), bytes.TrimSpace(body))
+	}
+	return received, false, checkPostResponse(s.tr.Logger, resp, body, req, s.to)
+}
+
+// expectEOF returns an error if the reader is not at its end.
+func expectEOF(r io.Reader) error {
+	var p [1]byte
+	switch _, err := io.ReadFull(r, p[:]); err {
+	case io.EOF:
+		return nil
+	case nil:
+		return pioutil.ErrExpectEOF
+	default:
+		return err
+	}
+}
+
+// throttle limits the rate the reader is read at to the snapshot send rate
+// limit of the transport.
+func (s *snapshotSender) throttle(r io.Reader) io.Reader {
+	if s.tr.snapshotSendLimiter == nil {
+		return r
+	}
+	return &throttledReader{r: r, l: s.tr.snapshotSendLimiter, stopc: s.stopc}
+}
+
+type throttledReader struct {
+	r     io.Reader
+	l     *rate.Limiter
+	stopc <-chan struct{}
+}
+
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > tr.l.Burst() {
+		p = p[:tr.l.Burst()]
+	}
+	n, err := tr.r.Read(p)
+	if n > 0 {
+		if d := tr.l.ReserveN(time.Now(), n).Delay(); d > 0 {
+			t := time.NewTimer(d)
+			defer t.Stop()
+			select {
+			case <-tr.stopc:
+				return n, errStopped
+			case <-t.C:
+			}
+		}
+	}
+	return n, err
+}
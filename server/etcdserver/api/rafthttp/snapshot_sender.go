@@ -19,6 +19,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"go.etcd.io/etcd/client/pkg/v3/types"
@@ -71,12 +72,6 @@ func (s *snapshotSender) send(merged snap.Message) {
 	m := merged.Message
 	to := types.ID(m.To).String()
 
-	body := createSnapBody(s.tr.Logger, merged)
-	defer body.Close()
-
-	u := s.picker.pick()
-	req := createPostRequest(s.tr.Logger, u, RaftSnapshotPrefix, body, "application/octet-stream", s.tr.URLs, s.from, s.cid)
-
 	snapshotSizeVal := uint64(merged.TotalSize)
 	snapshotSize := humanize.Bytes(snapshotSizeVal)
 	if s.tr.Logger != nil {
@@ -94,7 +89,10 @@ func (s *snapshotSender) send(merged snap.Message) {
 		snapshotSendInflights.WithLabelValues(to).Dec()
 	}()
 
-	err := s.post(req)
+	u, err := s.sendChunks(merged)
+	if err == errSnapshotChunksUnsupported {
+		u, err = s.sendWhole(merged)
+	}
 	defer merged.CloseWithError(err)
 	if err != nil {
 		if s.tr.Logger != nil {
@@ -143,9 +141,30 @@ func (s *snapshotSender) send(merged snap.Message) {
 	snapshotSendSeconds.WithLabelValues(to).Observe(time.Since(start).Seconds())
 }
 
+// sendWhole sends the snapshot message and its database in a single request
+// to RaftSnapshotPrefix.
+func (s *snapshotSender) sendWhole(merged snap.Message) (url.URL, error) {
+	body := createSnapBody(s.tr.Logger, merged)
+	defer body.Close()
+
+	u := s.picker.pick()
+	req := createPostRequest(s.tr.Logger, u, RaftSnapshotPrefix, s.throttle(body), "application/octet-stream", s.tr.URLs, s.from, s.cid)
+	setClusterEpochHeader(req.Header, s.tr.ClusterEpoch)
+	return u, s.post(req)
+}
+
 // post posts the given request.
 // It returns nil when request is sent out and processed successfully.
-func (s *snapshotSender) post(req *http.Request) (err error) {
+func (s *snapshotSender) post(req *http.Request) error {
+	resp, body, err := s.roundTrip(req)
+	if err != nil {
+		return err
+	}
+	return checkPostResponse(s.tr.Logger, resp, body, req, s.to)
+}
+
+// roundTrip posts the given request, and returns its response and body.
+func (s *snapshotSender) roundTrip(req *http.Request) (*http.Response, []byte, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	req = req.WithContext(ctx)
 	defer cancel()
@@ -174,12 +193,9 @@ func (s *snapshotSender) post(req *http.Request) (err error) {
 
 	select {
 	case <-s.stopc:
-		return errStopped
+		return nil, nil, errStopped
 	case r := <-result:
-		if r.err != nil {
-			return r.err
-		}
-		return checkPostResponse(s.tr.Logger, r.resp, r.body, req, s.to)
+		return r.resp, r.body, r.err
 	}
 }
 
@@ -15,12 +15,15 @@
 package rafthttp
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -28,6 +31,7 @@ import (
 	"go.etcd.io/etcd/raft/v3/raftpb"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
 	"go.uber.org/zap/zaptest"
+	"golang.org/x/time/rate"
 )
 
 type strReaderCloser struct{ *strings.Reader }
@@ -97,7 +101,8 @@ func testSnapshotSend(t *testing.T, sm *snap.Message) (bool, []os.DirEntry) {
 
 	r := &fakeRaft{}
 	tr := &Transport{pipelineRt: &http.Transport{}, ClusterID: types.ID(1), Raft: r}
-	ch := make(chan struct{}, 1)
+	// the snapshot message and the chunk of the database are posted
+	ch := make(chan struct{}, 2)
 	h := &syncHandler{newSnapshotHandler(tr, r, snap.New(zaptest.NewLogger(t), d), types.ID(1)), ch}
 	srv := httptest.NewServer(h)
 	defer srv.Close()
@@ -139,3 +144,143 @@ func (sh *syncHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	sh.h.ServeHTTP(w, r)
 	sh.ch <- struct{}{}
 }
+
+func TestSnapshotSendChunks(t *testing.T) {
+	defer func(n int, d time.Duration) {
+		snapshotChunkByte, snapshotChunkRetryInterval = n, d
+	}(snapshotChunkByte, snapshotChunkRetryInterval)
+	snapshotChunkByte, snapshotChunkRetryInterval = 4, time.Millisecond
+
+	abort := func(h http.Handler, w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		panic(http.ErrAbortHandler)
+	}
+	tests := []struct {
+		name string
+		// fault serves the first request of the chunk at offset 4
+		fault func(h http.Handler, w http.ResponseWriter, r *http.Request)
+
+		wsent bool
+	}{
+		{
+			name:  "no fault",
+			fault: func(h http.Handler, w http.ResponseWriter, r *http.Request) { h.ServeHTTP(w, r) },
+			wsent: true,
+		},
+		{
+			name: "chunk failed",
+			fault: func(h http.Handler, w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "injected failure", http.StatusInternalServerError)
+			},
+			wsent: true,
+		},
+		{
+			name:  "response to the chunk lost",
+			fault: abort,
+			wsent: true,
+		},
+		{
+			name: "transfer unknown",
+			fault: func(h http.Handler, w http.ResponseWriter, r *http.Request) {
+				http.Error(w, errSnapshotTransferUnknown.Error(), http.StatusGone)
+			},
+			wsent: false,
+		},
+		{
+			name: "chunks no longer received",
+			fault: func(h http.Handler, w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			wsent: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := t.TempDir()
+			recvc := make(chan raftpb.Message, 1)
+			r := &fakeRaft{recvc: recvc}
+			tr := &Transport{pipelineRt: &http.Transport{}, ClusterID: types.ID(1), Raft: r}
+			h := newSnapshotHandler(tr, r, snap.New(zaptest.NewLogger(t), d), types.ID(1))
+			var faulted int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(snapshotOffsetHeader) == "4" && atomic.CompareAndSwapInt32(&faulted, 0, 1) {
+					tt.fault(h, w, r)
+					return
+				}
+				h.ServeHTTP(w, r)
+			}))
+			defer srv.Close()
+
+			picker := mustNewURLPicker(t, []string{srv.URL})
+			snapsend := newSnapshotSender(tr, picker, types.ID(1), newPeerStatus(zaptest.NewLogger(t), types.ID(0), types.ID(1)))
+			defer snapsend.stop()
+
+			data := "hello world"
+			sm := snap.NewMessage(raftpb.Message{Type: raftpb.MsgSnap, From: 2, To: 1}, strReaderCloser{strings.NewReader(data)}, int64(len(data)))
+			snapsend.send(*sm)
+			if sent := <-sm.CloseNotify(); sent != tt.wsent {
+				t.Fatalf("snapshot sent = %v, want %v", sent, tt.wsent)
+			}
+			if !tt.wsent {
+				return
+			}
+			<-recvc
+			b, err := os.ReadFile(filepath.Join(d, fmt.Sprintf("%016x.snap.db", 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != data {
+				t.Errorf("received database %q, want %q", b, data)
+			}
+		})
+	}
+}
+
+func TestSnapshotSendWholeWithoutChunks(t *testing.T) {
+	d := t.TempDir()
+	recvc := make(chan raftpb.Message, 1)
+	r := &fakeRaft{recvc: recvc}
+	tr := &Transport{pipelineRt: &http.Transport{}, ClusterID: types.ID(1), Raft: r}
+	h := newSnapshotHandler(tr, r, snap.New(zaptest.NewLogger(t), d), types.ID(1))
+	// a remote not receiving chunks
+	mux := http.NewServeMux()
+	mux.Handle(RaftSnapshotPrefix, h)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	picker := mustNewURLPicker(t, []string{srv.URL})
+	snapsend := newSnapshotSender(tr, picker, types.ID(1), newPeerStatus(zaptest.NewLogger(t), types.ID(0), types.ID(1)))
+	defer snapsend.stop()
+
+	data := "hello world"
+	sm := snap.NewMessage(raftpb.Message{Type: raftpb.MsgSnap, From: 2, To: 1}, strReaderCloser{strings.NewReader(data)}, int64(len(data)))
+	snapsend.send(*sm)
+	if sent := <-sm.CloseNotify(); !sent {
+		t.Fatal("failed to send snapshot")
+	}
+	<-recvc
+	b, err := os.ReadFile(filepath.Join(d, fmt.Sprintf("%016x.snap.db", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != data {
+		t.Errorf("received database %q, want %q", b, data)
+	}
+}
+
+func TestThrottledReader(t *testing.T) {
+	r := &throttledReader{
+		r:     bytes.NewReader(make([]byte, 5000)),
+		l:     rate.NewLimiter(rate.Limit(10000), 1000),
+		stopc: make(chan struct{}),
+	}
+	start := time.Now()
+	n, err := io.Copy(io.Discard, r)
+	if err != nil || n != 5000 {
+		t.Fatalf("read %d bytes (%v), want 5000 bytes", n, err)
+	}
+	// the burst is read at once, the rest at 10000 bytes per second
+	if took := time.Since(start); took < 350*time.Millisecond {
+		t.Errorf("read 5000 bytes in %v, want at least 400ms", took)
+	}
+}
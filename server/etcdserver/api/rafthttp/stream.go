@@ -583,6 +583,7 @@ func (cr *streamReader) dial(t streamType) (io.ReadCloser, error) {
 	req.Header.Set("X-Server-Version", version.Version)
 	req.Header.Set("X-Min-Cluster-Version", version.MinClusterVersion)
 	req.Header.Set("X-Etcd-Cluster-ID", cr.tr.ClusterID.String())
+	setClusterEpochHeader(req.Header, cr.tr.ClusterEpoch)
 	req.Header.Set("X-Raft-To", cr.peerID.String())
 
 	setPeerURLsHeader(req, cr.tr.URLs)
@@ -661,6 +662,19 @@ func (cr *streamReader) dial(t streamType) (io.ReadCloser, error) {
 			}
 			return nil, errClusterIDMismatch
 
+		case errClusterEpochMismatch.Error():
+			if cr.lg != nil {
+				cr.lg.Warn(
+					"request sent was ignored by remote peer due to cluster epoch mismatch",
+					zap.String("remote-peer-id", cr.peerID.String()),
+					zap.String("remote-peer-cluster-epoch", resp.Header.Get("X-Etcd-Cluster-Epoch")),
+					zap.String("local-member-id", cr.tr.ID.String()),
+					zap.Uint64("local-member-cluster-epoch", localEpoch(cr.tr.ClusterEpoch)),
+					zap.Error(errClusterEpochMismatch),
+				)
+			}
+			return nil, errClusterEpochMismatch
+
 		default:
 			return nil, fmt.Errorf("unhandled error %q when precondition failed", string(b))
 		}
@@ -118,6 +118,22 @@ type Transport struct {
 	// When an error is received from ErrorC, user should stop raft state
 	// machine and thus stop the Transport.
 	ErrorC chan error
+	// ClusterEpoch, if set, rejects the peers of another epoch of the cluster.
+	ClusterEpoch ClusterEpoch
+	// SnapshotSendRateLimit is the maximum number of bytes per second of the
+	// snapshots sent to all the peers. 0 means no limit.
+	SnapshotSendRateLimit int64
+	// MsgAppBatchBytes is the maximum size of the entries of the MsgApp to a
+	// peer merged with the MsgApp following it in a Send. 0 disables the
+	// batching.
+	MsgAppBatchBytes int
+	// LearnerHeartbeatInterval is the minimum interval between the heartbeats
+	// sent to the learners told by Learners, which do not campaign. 0 sends
+	// them on every heartbeat tick.
+	LearnerHeartbeatInterval time.Duration
+	Learners                 Learners
+
+	snapshotSendLimiter *rate.Limiter // limits the snapshots sent, nil if no limit
 
 	streamRt   http.RoundTripper // roundTripper used by streams
 	pipelineRt http.RoundTripper // roundTripper used by pipelines
@@ -151,6 +167,9 @@ func (t *Transport) Start() error {
 	if t.DialRetryFrequency == 0 {
 		t.DialRetryFrequency = rate.Every(100 * time.Millisecond)
 	}
+	if t.SnapshotSendRateLimit > 0 {
+		t.snapshotSendLimiter = rate.NewLimiter(rate.Limit(t.SnapshotSendRateLimit), snapshotSendBurstByte)
+	}
 	return nil
 }
 
@@ -162,6 +181,7 @@ func (t *Transport) Handler() http.Handler {
 	mux.Handle(RaftPrefix, pipelineHandler)
 	mux.Handle(RaftStreamPrefix+"/", streamHandler)
 	mux.Handle(RaftSnapshotPrefix, snapHandler)
+	mux.Handle(RaftSnapshotChunkPrefix, snapHandler)
 	mux.Handle(ProbingPrefix, probing.NewHandler())
 	return mux
 }
@@ -173,7 +193,7 @@ func (t *Transport) Get(id types.ID) Peer {
 }
 
 func (t *Transport) Send(msgs []raftpb.Message) {
-	for _, m := range msgs {
+	for _, m := range batchMsgApps(msgs, t.MsgAppBatchBytes) {
 		if m.To == 0 {
 			// ignore intentionally dropped message
 			continue
@@ -104,6 +104,16 @@ func checkPostResponse(lg *zap.Logger, resp *http.Response, body []byte, req *ht
 				)
 			}
 			return errClusterIDMismatch
+		case errClusterEpochMismatch.Error():
+			if lg != nil {
+				lg.Error(
+					"request sent was ignored due to cluster epoch mismatch",
+					zap.String("remote-peer-id", to.String()),
+					zap.String("remote-peer-cluster-epoch", resp.Header.Get("X-Etcd-Cluster-Epoch")),
+					zap.String("local-member-cluster-epoch", req.Header.Get("X-Etcd-Cluster-Epoch")),
+				)
+			}
+			return errClusterEpochMismatch
 		default:
 			return fmt.Errorf("unhandled error %q when precondition failed", string(body))
 		}
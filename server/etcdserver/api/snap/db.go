@@ -41,25 +41,56 @@ func (s *Snapshotter) SaveDBFrom(r io.Reader, id uint64) (int64, error) {
 	}
 	var n int64
 	n, err = io.Copy(f, r)
-	if err == nil {
-		fsyncStart := time.Now()
-		err = fileutil.Fsync(f)
-		snapDBFsyncSec.Observe(time.Since(fsyncStart).Seconds())
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return n, err
 	}
+	return n, s.saveDB(f, n, id, start)
+}
+
+// NewDBPart returns a temporary file of the snapshot directory to write the
+// snapshot of the database received in parts to, before saving it with
+// SaveDBPart. Its name is prefixed with "db.tmp", so that it is removed on
+// startup if orphaned.
+func (s *Snapshotter) NewDBPart() (*os.File, error) {
+	return os.CreateTemp(s.dir, "db.tmp")
+}
+
+// SaveDBPart saves the snapshot of the database written to the file returned
+// by NewDBPart, and closes it. It guarantees the save operation is atomic.
+func (s *Snapshotter) SaveDBPart(f *os.File, id uint64) (int64, error) {
+	start := time.Now()
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return 0, err
+	}
+	return fi.Size(), s.saveDB(f, fi.Size(), id, start)
+}
+
+// saveDB syncs and closes the temporary file of the n bytes of the snapshot
+// of the database, and renames it to the file of the snapshot of id.
+func (s *Snapshotter) saveDB(f *os.File, n int64, id uint64, start time.Time) error {
+	fsyncStart := time.Now()
+	err := fileutil.Fsync(f)
+	snapDBFsyncSec.Observe(time.Since(fsyncStart).Seconds())
 	f.Close()
 	if err != nil {
 		os.Remove(f.Name())
-		return n, err
+		return err
 	}
 	fn := s.dbFilePath(id)
 	if fileutil.Exist(fn) {
 		os.Remove(f.Name())
-		return n, nil
+		return nil
 	}
 	err = os.Rename(f.Name(), fn)
 	if err != nil {
 		os.Remove(f.Name())
-		return n, err
+		return err
 	}
 
 	s.lg.Info(
@@ -70,7 +101,7 @@ func (s *Snapshotter) SaveDBFrom(r io.Reader, id uint64) (int64, error) {
 	)
 
 	snapDBSaveSec.Observe(time.Since(start).Seconds())
-	return n, nil
+	return nil
 }
 
 // DBFilePath returns the file path for the snapshot of the database with
@@ -236,7 +236,7 @@ func (s *Snapshotter) checkSuffix(names []string) []string {
 }
 
 // cleanupSnapdir removes any files that should not be in the snapshot directory:
-// - db.tmp prefixed files that can be orphaned by defragmentation
+// - db.tmp prefixed files that can be orphaned by defragmentation or snapshot transfers
 func (s *Snapshotter) cleanupSnapdir(filenames []string) (names []string, err error) {
 	names = make([]string, 0, len(filenames))
 	for _, filename := range filenames {
@@ -16,6 +16,7 @@ package v3compactor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -43,6 +44,10 @@ type Compactor interface {
 	Resume()
 }
 
+// ErrDeferred is returned by a Compactable deferring a compaction, to be
+// retried by the compactor at its next attempt.
+var ErrDeferred = errors.New("compaction deferred")
+
 type Compactable interface {
 	Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error)
 }
@@ -16,6 +16,7 @@ package v3compactor
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -148,6 +149,14 @@ func (pc *Periodic) Run() {
 				)
 				lastRevision = rev
 				lastSuccess = pc.clock.Now()
+			} else if errors.Is(err, ErrDeferred) {
+				pc.lg.Info(
+					"deferred auto periodic compaction",
+					zap.Int64("revision", rev),
+					zap.Duration("compact-period", pc.period),
+					zap.Duration("retry-interval", retryInterval),
+					zap.Error(err),
+				)
 			} else {
 				pc.lg.Warn(
 					"failed auto periodic compaction",
@@ -0,0 +1,192 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3compactor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+
+	"github.com/jonboulle/clockwork"
+	"go.uber.org/zap"
+)
+
+var ErrPinNotFound = errors.New("pin not found")
+
+type PinBackend interface {
+	CreatePinBucket()
+	MustPutPin(pin *pb.PinRevisionRequest)
+	MustDeletePin(id int64)
+	GetAllPins() ([]*pb.PinRevisionRequest, error)
+	ForceCommit()
+}
+
+type pin struct {
+	rev int64
+	ttl int64
+	// expiry is zero if the pin has no TTL.
+	expiry time.Time
+}
+
+// PinStore persists the revisions pinned against the automatic compaction
+// to the backend. The pins are added and removed when applying raft entries,
+// and expire on the local clock of each member.
+type PinStore struct {
+	lg    *zap.Logger
+	clock clockwork.Clock
+
+	mu   sync.Mutex
+	pins map[int64]*pin
+	be   PinBackend
+}
+
+func NewPinStore(lg *zap.Logger, be PinBackend) (*PinStore, error) {
+	return newPinStore(lg, clockwork.NewRealClock(), be)
+}
+
+func newPinStore(lg *zap.Logger, clock clockwork.Clock, be PinBackend) (*PinStore, error) {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	ps := &PinStore{lg: lg, clock: clock}
+	err := ps.Recover(be)
+	return ps, err
+}
+
+// Recover replaces the pins with the ones persisted to the given backend,
+// with their full TTL.
+func (ps *PinStore) Recover(be PinBackend) error {
+	be.CreatePinBucket()
+	pins, err := be.GetAllPins()
+	if err != nil {
+		return err
+	}
+	be.ForceCommit()
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.be = be
+	ps.pins = make(map[int64]*pin, len(pins))
+	for _, p := range pins {
+		ps.pins[p.ID] = ps.newPin(p.Revision, p.TTL)
+	}
+	return nil
+}
+
+// Pin pins the revision under the given ID, replacing the pin with the same
+// ID if any.
+func (ps *PinStore) Pin(id, rev, ttl int64) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.pins[id] = ps.newPin(rev, ttl)
+	ps.be.MustPutPin(&pb.PinRevisionRequest{ID: id, Revision: rev, TTL: ttl})
+}
+
+// Unpin removes the pin with the given ID.
+func (ps *PinStore) Unpin(id int64) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if _, ok := ps.pins[id]; !ok {
+		return ErrPinNotFound
+	}
+	delete(ps.pins, id)
+	ps.be.MustDeletePin(id)
+	return nil
+}
+
+// MinRevision returns the oldest revision pinned and not expired, if any.
+func (ps *PinStore) MinRevision() (rev int64, ok bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	now := ps.clock.Now()
+	for _, p := range ps.pins {
+		if p.expired(now) {
+			continue
+		}
+		if !ok || p.rev < rev {
+			rev, ok = p.rev, true
+		}
+	}
+	return rev, ok
+}
+
+// Expired returns the IDs of the expired pins, in increasing order.
+func (ps *PinStore) Expired() []int64 {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	var ids []int64
+	now := ps.clock.Now()
+	for id, p := range ps.pins {
+		if p.expired(now) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Renew gives all the pins their full TTL again, for a new leader not to
+// unpin the ones it lately learned of before their owners can refresh them.
+func (ps *PinStore) Renew() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	for _, p := range ps.pins {
+		*p = *ps.newPin(p.rev, p.ttl)
+	}
+}
+
+func (ps *PinStore) newPin(rev, ttl int64) *pin {
+	p := &pin{rev: rev, ttl: ttl}
+	if ttl > 0 {
+		p.expiry = ps.clock.Now().Add(time.Duration(ttl) * time.Second)
+	}
+	return p
+}
+
+func (p *pin) expired(now time.Time) bool {
+	return !p.expiry.IsZero() && !now.Before(p.expiry)
+}
+
+type pinnedCompactable struct {
+	lg   *zap.Logger
+	c    Compactable
+	pins *PinStore
+}
+
+// NewPinnedCompactable returns a Compactable compacting through c, but no
+// further than the oldest revision pinned in pins, so that it stays
+// available to reads.
+func NewPinnedCompactable(lg *zap.Logger, c Compactable, pins *PinStore) Compactable {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	return &pinnedCompactable{lg: lg, c: c, pins: pins}
+}
+
+func (pc *pinnedCompactable) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error) {
+	if rev, ok := pc.pins.MinRevision(); ok && rev < r.Revision {
+		pc.lg.Info(
+			"limited auto compaction by pinned revision",
+			zap.Int64("revision", r.Revision),
+			zap.Int64("pinned-revision", rev),
+		)
+		r = &pb.CompactionRequest{Revision: rev, Physical: r.Physical}
+	}
+	return pc.c.Compact(ctx, r)
+}
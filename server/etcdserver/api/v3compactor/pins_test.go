@@ -0,0 +1,133 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3compactor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/testutil"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/jonboulle/clockwork"
+)
+
+type fakePinBackend struct {
+	pins map[int64]*pb.PinRevisionRequest
+}
+
+func (b *fakePinBackend) CreatePinBucket() {
+	if b.pins == nil {
+		b.pins = make(map[int64]*pb.PinRevisionRequest)
+	}
+}
+func (b *fakePinBackend) MustPutPin(pin *pb.PinRevisionRequest) { b.pins[pin.ID] = pin }
+func (b *fakePinBackend) MustDeletePin(id int64)                { delete(b.pins, id) }
+func (b *fakePinBackend) ForceCommit()                          {}
+
+func (b *fakePinBackend) GetAllPins() ([]*pb.PinRevisionRequest, error) {
+	var pins []*pb.PinRevisionRequest
+	for _, pin := range b.pins {
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+func TestPinStore(t *testing.T) {
+	fc := clockwork.NewFakeClock()
+	be := &fakePinBackend{}
+	ps, err := newPinStore(zaptest.NewLogger(t), fc, be)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ps.MinRevision(); ok {
+		t.Fatal("expected no pinned revision")
+	}
+
+	ps.Pin(1, 20, 0)
+	ps.Pin(2, 10, 10)
+	ps.Pin(3, 15, 20)
+	if rev, ok := ps.MinRevision(); !ok || rev != 10 {
+		t.Fatalf("expected pinned revision 10, got %d, %v", rev, ok)
+	}
+
+	fc.Advance(10 * time.Second)
+	if ids := ps.Expired(); !reflect.DeepEqual(ids, []int64{2}) {
+		t.Fatalf("expected expired pins [2], got %v", ids)
+	}
+	if rev, ok := ps.MinRevision(); !ok || rev != 15 {
+		t.Fatalf("expected pinned revision 15, got %d, %v", rev, ok)
+	}
+
+	// a new leader gives the pins their full TTL again
+	ps.Renew()
+	if ids := ps.Expired(); len(ids) != 0 {
+		t.Fatalf("expected no expired pins, got %v", ids)
+	}
+
+	if err = ps.Unpin(2); err != nil {
+		t.Fatal(err)
+	}
+	if err = ps.Unpin(2); err != ErrPinNotFound {
+		t.Fatalf("expected %v, got %v", ErrPinNotFound, err)
+	}
+
+	// the pins are recovered from the backend with their full TTL
+	fc.Advance(15 * time.Second)
+	ps, err = newPinStore(zaptest.NewLogger(t), fc, be)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := ps.Expired(); len(ids) != 0 {
+		t.Fatalf("expected no expired pins, got %v", ids)
+	}
+	if rev, ok := ps.MinRevision(); !ok || rev != 15 {
+		t.Fatalf("expected pinned revision 15, got %d, %v", rev, ok)
+	}
+}
+
+func TestPinnedCompactable(t *testing.T) {
+	ps, err := newPinStore(zaptest.NewLogger(t), clockwork.NewFakeClock(), &fakePinBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compactable := &fakeCompactable{&testutil.RecorderBuffered{}}
+	c := NewPinnedCompactable(zaptest.NewLogger(t), compactable, ps)
+
+	tests := []struct {
+		pin  int64
+		rev  int64
+		want int64
+	}{
+		{rev: 100, want: 100},
+		{pin: 150, rev: 200, want: 150},
+		{pin: 150, rev: 120, want: 120},
+	}
+	for i, tt := range tests {
+		if tt.pin != 0 {
+			ps.Pin(1, tt.pin, 0)
+		}
+		if _, err = c.Compact(context.Background(), &pb.CompactionRequest{Revision: tt.rev}); err != nil {
+			t.Fatal(err)
+		}
+		a := compactable.Action()
+		if r := a[len(a)-1].Params[0].(*pb.CompactionRequest); r.Revision != tt.want {
+			t.Errorf("#%d: expected compaction at revision %d, got %d", i, tt.want, r.Revision)
+		}
+	}
+}
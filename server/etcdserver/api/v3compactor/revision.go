@@ -16,6 +16,7 @@ package v3compactor
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -97,6 +98,14 @@ func (rc *Revision) Run() {
 					zap.Int64("revision-compaction-retention", rc.retention),
 					zap.Duration("took", time.Since(now)),
 				)
+			} else if errors.Is(err, ErrDeferred) {
+				rc.lg.Info(
+					"deferred auto revision compaction",
+					zap.Int64("revision", rev),
+					zap.Int64("revision-compaction-retention", rc.retention),
+					zap.Duration("retry-interval", revInterval),
+					zap.Error(err),
+				)
 			} else {
 				rc.lg.Warn(
 					"failed auto revision compaction",
@@ -17,6 +17,7 @@ package v3lock
 import (
 	"context"
 
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/concurrency"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3lock/v3lockpb"
@@ -44,7 +45,7 @@ func (ls *lockServer) Lock(ctx context.Context, req *v3lockpb.LockRequest) (*v3l
 	if err = m.Lock(ctx); err != nil {
 		return nil, err
 	}
-	return &v3lockpb.LockResponse{Header: m.Header(), Key: []byte(m.Key())}, nil
+	return &v3lockpb.LockResponse{Header: m.Header(), Key: []byte(m.Key()), FencingToken: m.FencingToken()}, nil
 }
 
 func (ls *lockServer) Unlock(ctx context.Context, req *v3lockpb.UnlockRequest) (*v3lockpb.UnlockResponse, error) {
@@ -54,3 +55,40 @@ func (ls *lockServer) Unlock(ctx context.Context, req *v3lockpb.UnlockRequest) (
 	}
 	return &v3lockpb.UnlockResponse{Header: resp.Header}, nil
 }
+
+func (ls *lockServer) PutIfFencingTokenCurrent(ctx context.Context, req *v3lockpb.PutIfFencingTokenCurrentRequest) (*v3lockpb.PutIfFencingTokenCurrentResponse, error) {
+	put := req.Put
+	if put == nil || len(put.Key) == 0 {
+		return nil, rpctypes.ErrGRPCEmptyKey
+	}
+	if req.FencingToken <= 0 {
+		return nil, rpctypes.ErrGRPCStaleFencingToken
+	}
+	var opts []clientv3.OpOption
+	if put.Lease != 0 {
+		opts = append(opts, clientv3.WithLease(clientv3.LeaseID(put.Lease)))
+	}
+	if put.PrevKv {
+		opts = append(opts, clientv3.WithPrevKV())
+	}
+	if put.IgnoreValue {
+		opts = append(opts, clientv3.WithIgnoreValue())
+	}
+	if put.IgnoreLease {
+		opts = append(opts, clientv3.WithIgnoreLease())
+	}
+	// the lock is held as long as its key exists with the create revision
+	// granted as fencing token
+	resp, err := ls.c.Txn(ctx).If(
+		clientv3.Compare(clientv3.CreateRevision(string(req.Key)), "=", req.FencingToken),
+	).Then(
+		clientv3.OpPut(string(put.Key), string(put.Value), opts...),
+	).Commit()
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Succeeded {
+		return nil, rpctypes.ErrGRPCStaleFencingToken
+	}
+	return &v3lockpb.PutIfFencingTokenCurrentResponse{Header: resp.Header, Put: resp.Responses[0].GetResponsePut()}, nil
+}
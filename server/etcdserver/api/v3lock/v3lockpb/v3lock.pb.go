@@ -96,7 +96,12 @@ type LockResponse struct {
 	// key is a key that will exist on etcd for the duration that the Lock caller
 	// owns the lock. Users should not modify this key or the lock may exhibit
 	// undefined behavior.
-	Key                  []byte   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Key []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// fencing_token is the create revision of key. It increases with every
+	// acquisition of the lock, so a resource outside etcd can reject requests
+	// from stale lock holders by rejecting tokens lower than the highest
+	// token it has seen.
+	FencingToken         int64    `protobuf:"varint,3,opt,name=fencing_token,json=fencingToken,proto3" json:"fencing_token,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -149,6 +154,13 @@ func (m *LockResponse) GetKey() []byte {
 	return nil
 }
 
+func (m *LockResponse) GetFencingToken() int64 {
+	if m != nil {
+		return m.FencingToken
+	}
+	return 0
+}
+
 type UnlockRequest struct {
 	// key is the lock ownership key granted by Lock.
 	Key                  []byte   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
@@ -244,38 +256,170 @@ func (m *UnlockResponse) GetHeader() *etcdserverpb.ResponseHeader {
 	return nil
 }
 
+type PutIfFencingTokenCurrentRequest struct {
+	// key is the lock ownership key granted by Lock.
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// fencing_token is the fencing token granted by Lock with key.
+	FencingToken int64 `protobuf:"varint,2,opt,name=fencing_token,json=fencingToken,proto3" json:"fencing_token,omitempty"`
+	// put is the request applied if the fencing token is current.
+	Put                  *etcdserverpb.PutRequest `protobuf:"bytes,3,opt,name=put,proto3" json:"put,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *PutIfFencingTokenCurrentRequest) Reset()         { *m = PutIfFencingTokenCurrentRequest{} }
+func (m *PutIfFencingTokenCurrentRequest) String() string { return proto.CompactTextString(m) }
+func (*PutIfFencingTokenCurrentRequest) ProtoMessage()    {}
+func (*PutIfFencingTokenCurrentRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_52389b3e2f253201, []int{4}
+}
+func (m *PutIfFencingTokenCurrentRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PutIfFencingTokenCurrentRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PutIfFencingTokenCurrentRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PutIfFencingTokenCurrentRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PutIfFencingTokenCurrentRequest.Merge(m, src)
+}
+func (m *PutIfFencingTokenCurrentRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *PutIfFencingTokenCurrentRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PutIfFencingTokenCurrentRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PutIfFencingTokenCurrentRequest proto.InternalMessageInfo
+
+func (m *PutIfFencingTokenCurrentRequest) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *PutIfFencingTokenCurrentRequest) GetFencingToken() int64 {
+	if m != nil {
+		return m.FencingToken
+	}
+	return 0
+}
+
+func (m *PutIfFencingTokenCurrentRequest) GetPut() *etcdserverpb.PutRequest {
+	if m != nil {
+		return m.Put
+	}
+	return nil
+}
+
+type PutIfFencingTokenCurrentResponse struct {
+	Header *etcdserverpb.ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	// put is the response of the put request.
+	Put                  *etcdserverpb.PutResponse `protobuf:"bytes,2,opt,name=put,proto3" json:"put,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
+}
+
+func (m *PutIfFencingTokenCurrentResponse) Reset()         { *m = PutIfFencingTokenCurrentResponse{} }
+func (m *PutIfFencingTokenCurrentResponse) String() string { return proto.CompactTextString(m) }
+func (*PutIfFencingTokenCurrentResponse) ProtoMessage()    {}
+func (*PutIfFencingTokenCurrentResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_52389b3e2f253201, []int{5}
+}
+func (m *PutIfFencingTokenCurrentResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PutIfFencingTokenCurrentResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PutIfFencingTokenCurrentResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PutIfFencingTokenCurrentResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PutIfFencingTokenCurrentResponse.Merge(m, src)
+}
+func (m *PutIfFencingTokenCurrentResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *PutIfFencingTokenCurrentResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PutIfFencingTokenCurrentResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PutIfFencingTokenCurrentResponse proto.InternalMessageInfo
+
+func (m *PutIfFencingTokenCurrentResponse) GetHeader() *etcdserverpb.ResponseHeader {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *PutIfFencingTokenCurrentResponse) GetPut() *etcdserverpb.PutResponse {
+	if m != nil {
+		return m.Put
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*LockRequest)(nil), "v3lockpb.LockRequest")
 	proto.RegisterType((*LockResponse)(nil), "v3lockpb.LockResponse")
 	proto.RegisterType((*UnlockRequest)(nil), "v3lockpb.UnlockRequest")
 	proto.RegisterType((*UnlockResponse)(nil), "v3lockpb.UnlockResponse")
+	proto.RegisterType((*PutIfFencingTokenCurrentRequest)(nil), "v3lockpb.PutIfFencingTokenCurrentRequest")
+	proto.RegisterType((*PutIfFencingTokenCurrentResponse)(nil), "v3lockpb.PutIfFencingTokenCurrentResponse")
 }
 
 func init() { proto.RegisterFile("v3lock.proto", fileDescriptor_52389b3e2f253201) }
 
 var fileDescriptor_52389b3e2f253201 = []byte{
-	// 330 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x29, 0x33, 0xce, 0xc9,
-	0x4f, 0xce, 0xd6, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x80, 0xf0, 0x0a, 0x92, 0xa4, 0x44,
-	0xd2, 0xf3, 0xd3, 0xf3, 0xc1, 0x82, 0xfa, 0x20, 0x16, 0x44, 0x5e, 0x4a, 0x3e, 0xb5, 0x24, 0x39,
-	0x45, 0x3f, 0xb1, 0x20, 0x53, 0x1f, 0xc4, 0x28, 0x4e, 0x2d, 0x2a, 0x4b, 0x2d, 0x2a, 0x48, 0xd2,
-	0x2f, 0x2a, 0x48, 0x86, 0x2a, 0x90, 0x49, 0xcf, 0xcf, 0x4f, 0xcf, 0x49, 0x05, 0x2b, 0x49, 0xcc,
-	0xcb, 0xcb, 0x2f, 0x49, 0x2c, 0xc9, 0xcc, 0xcf, 0x2b, 0x86, 0xc8, 0x2a, 0x99, 0x73, 0x71, 0xfb,
-	0xe4, 0x27, 0x67, 0x07, 0xa5, 0x16, 0x96, 0xa6, 0x16, 0x97, 0x08, 0x09, 0x71, 0xb1, 0xe4, 0x25,
-	0xe6, 0xa6, 0x4a, 0x30, 0x2a, 0x30, 0x6a, 0xf0, 0x04, 0x81, 0xd9, 0x42, 0x22, 0x5c, 0xac, 0x39,
-	0xa9, 0x89, 0xc5, 0xa9, 0x12, 0x4c, 0x0a, 0x8c, 0x1a, 0xcc, 0x41, 0x10, 0x8e, 0x52, 0x18, 0x17,
-	0x0f, 0x44, 0x63, 0x71, 0x41, 0x7e, 0x5e, 0x71, 0xaa, 0x90, 0x09, 0x17, 0x5b, 0x46, 0x6a, 0x62,
-	0x4a, 0x6a, 0x11, 0x58, 0x2f, 0xb7, 0x91, 0x8c, 0x1e, 0xb2, 0x7b, 0xf4, 0x60, 0xea, 0x3c, 0xc0,
-	0x6a, 0x82, 0xa0, 0x6a, 0x85, 0x04, 0xb8, 0x98, 0xb3, 0x53, 0x2b, 0xc1, 0x26, 0xf3, 0x04, 0x81,
-	0x98, 0x4a, 0x8a, 0x5c, 0xbc, 0xa1, 0x79, 0x39, 0x48, 0x4e, 0x82, 0x2a, 0x61, 0x44, 0x28, 0x71,
-	0xe3, 0xe2, 0x83, 0x29, 0xa1, 0xc4, 0x72, 0xa3, 0x0d, 0x8c, 0x5c, 0x2c, 0x20, 0x3f, 0x08, 0xf9,
-	0x43, 0x69, 0x51, 0x3d, 0x58, 0x60, 0xeb, 0x21, 0x05, 0x8a, 0x94, 0x18, 0xba, 0x30, 0xc4, 0x34,
-	0x25, 0x89, 0xa6, 0xcb, 0x4f, 0x26, 0x33, 0x09, 0x29, 0xf1, 0xea, 0x97, 0x19, 0xeb, 0x83, 0x14,
-	0x80, 0x09, 0x2b, 0x46, 0x2d, 0xa1, 0x70, 0x2e, 0x36, 0x88, 0x0b, 0x85, 0xc4, 0x11, 0x7a, 0x51,
-	0xbc, 0x25, 0x25, 0x81, 0x29, 0x01, 0x35, 0x56, 0x0a, 0x6c, 0xac, 0x88, 0x12, 0x3f, 0xdc, 0xd8,
-	0xd2, 0x3c, 0xa8, 0xc1, 0x4e, 0x02, 0x27, 0x1e, 0xc9, 0x31, 0x5e, 0x78, 0x24, 0xc7, 0xf8, 0xe0,
-	0x91, 0x1c, 0xe3, 0x8c, 0xc7, 0x72, 0x0c, 0x49, 0x6c, 0xe0, 0x78, 0x34, 0x06, 0x04, 0x00, 0x00,
-	0xff, 0xff, 0x4a, 0x4d, 0xca, 0xbb, 0x36, 0x02, 0x00, 0x00,
+	// 456 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x93, 0x41, 0x8b, 0xd3, 0x40,
+	0x14, 0xc7, 0x9d, 0x74, 0x2d, 0xf2, 0x9a, 0xea, 0x3a, 0x54, 0x37, 0x86, 0x25, 0x5b, 0xc7, 0xcb,
+	0x1a, 0x21, 0x81, 0x56, 0x10, 0xf6, 0xa8, 0xb0, 0x28, 0x08, 0x2e, 0x41, 0xf1, 0x28, 0x69, 0xf6,
+	0x6d, 0x2c, 0x89, 0x33, 0x63, 0x32, 0x29, 0x88, 0x37, 0xd1, 0x8b, 0x57, 0x2f, 0x7e, 0x19, 0xef,
+	0x1e, 0x05, 0xbf, 0x80, 0x54, 0x3f, 0x88, 0x64, 0x26, 0xe9, 0x66, 0xad, 0x45, 0x61, 0x2f, 0xc9,
+	0x9b, 0x37, 0xff, 0xf7, 0x9f, 0xdf, 0xcb, 0x9b, 0x80, 0xbd, 0x98, 0xe6, 0x22, 0xc9, 0x02, 0x59,
+	0x08, 0x25, 0xe8, 0x25, 0xb3, 0x92, 0x33, 0x77, 0x94, 0x8a, 0x54, 0xe8, 0x64, 0x58, 0x47, 0x66,
+	0xdf, 0xdd, 0x43, 0x95, 0x1c, 0x87, 0xb1, 0x9c, 0x87, 0x75, 0x50, 0x62, 0xb1, 0xc0, 0x42, 0xce,
+	0xc2, 0x42, 0x26, 0x8d, 0x60, 0x37, 0x15, 0x22, 0xcd, 0x51, 0x4b, 0x62, 0xce, 0x85, 0x8a, 0xd5,
+	0x5c, 0xf0, 0xd2, 0xec, 0xb2, 0x7b, 0x30, 0x78, 0x2c, 0x92, 0x2c, 0xc2, 0xd7, 0x15, 0x96, 0x8a,
+	0x52, 0xd8, 0xe2, 0xf1, 0x2b, 0x74, 0xc8, 0x98, 0xec, 0xdb, 0x91, 0x8e, 0xe9, 0x08, 0x2e, 0xe6,
+	0x18, 0x97, 0xe8, 0x58, 0x63, 0xb2, 0xdf, 0x8b, 0xcc, 0x82, 0xbd, 0x05, 0xdb, 0x14, 0x96, 0x52,
+	0xf0, 0x12, 0xe9, 0x5d, 0xe8, 0xbf, 0xc4, 0xf8, 0x18, 0x0b, 0x5d, 0x3b, 0x98, 0xec, 0x06, 0x5d,
+	0x9e, 0xa0, 0xd5, 0x3d, 0xd4, 0x9a, 0xa8, 0xd1, 0xd2, 0x6d, 0xe8, 0x65, 0xf8, 0x46, 0x3b, 0xdb,
+	0x51, 0x1d, 0xd2, 0x5b, 0x30, 0x3c, 0x41, 0x9e, 0xcc, 0x79, 0xfa, 0x42, 0x89, 0x0c, 0xb9, 0xd3,
+	0xd3, 0xa7, 0xda, 0x4d, 0xf2, 0x69, 0x9d, 0x63, 0x37, 0x61, 0xf8, 0x8c, 0xe7, 0x1d, 0xee, 0xc6,
+	0x87, 0xac, 0x7c, 0xd8, 0x21, 0x5c, 0x6e, 0x25, 0xe7, 0x21, 0x64, 0xef, 0x09, 0xec, 0x1d, 0x55,
+	0xea, 0xd1, 0xc9, 0x61, 0x07, 0xe0, 0x41, 0x55, 0x14, 0xc8, 0xd5, 0xc6, 0xd3, 0xd7, 0xbb, 0xb0,
+	0xd6, 0xbb, 0xa0, 0x3e, 0xf4, 0x64, 0xa5, 0x74, 0x83, 0x83, 0x89, 0x73, 0x96, 0xe6, 0xa8, 0x6a,
+	0xdd, 0xa3, 0x5a, 0xc4, 0x3e, 0x10, 0x18, 0x6f, 0xc6, 0x38, 0xd7, 0x0c, 0xee, 0x18, 0x0c, 0x4b,
+	0x97, 0xdc, 0xf8, 0x0b, 0x86, 0xa9, 0xd2, 0x1c, 0x93, 0x2f, 0x16, 0x6c, 0xd5, 0x73, 0xa7, 0x4f,
+	0x9a, 0xf7, 0xb5, 0xa0, 0xbd, 0xa0, 0x41, 0xe7, 0x22, 0xb9, 0xd7, 0xff, 0x4c, 0x1b, 0x13, 0xe6,
+	0xbc, 0xfb, 0xfe, 0xeb, 0x93, 0x45, 0xd9, 0x30, 0x5c, 0x4c, 0xc3, 0x5a, 0xa0, 0x1f, 0x07, 0xc4,
+	0xa7, 0xcf, 0xa1, 0x6f, 0x06, 0x46, 0x77, 0x4e, 0x6b, 0xcf, 0x4c, 0xd9, 0x75, 0xd6, 0x37, 0x1a,
+	0x5b, 0x57, 0xdb, 0x8e, 0xd8, 0x95, 0x95, 0x6d, 0xc5, 0x5b, 0xe3, 0x8f, 0x04, 0x9c, 0x4d, 0x9f,
+	0x8e, 0xde, 0x3e, 0xb5, 0xfc, 0xc7, 0x94, 0x5d, 0xff, 0x7f, 0xa4, 0x0d, 0xcf, 0x8e, 0xe6, 0xb9,
+	0xca, 0xec, 0x15, 0x8f, 0xac, 0xd4, 0x01, 0xf1, 0xef, 0x6f, 0x7f, 0x5d, 0x7a, 0xe4, 0xdb, 0xd2,
+	0x23, 0x3f, 0x96, 0x1e, 0xf9, 0xfc, 0xd3, 0xbb, 0x30, 0xeb, 0xeb, 0x1f, 0x71, 0xfa, 0x7b, 0x00,
+	0x56, 0xab, 0x48, 0x0e, 0xf7, 0x03, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -301,6 +445,11 @@ type LockClient interface {
 	// next Lock caller waiting for the lock will then be woken up and given
 	// ownership of the lock.
 	Unlock(ctx context.Context, in *UnlockRequest, opts ...grpc.CallOption) (*UnlockResponse, error)
+	// PutIfFencingTokenCurrent puts a key only while the lock ownership key
+	// granted by Lock with the given fencing token is still held. It fails
+	// with a stale fencing token error once the lock was released or its
+	// lease expired, so a stale lock holder cannot update etcd.
+	PutIfFencingTokenCurrent(ctx context.Context, in *PutIfFencingTokenCurrentRequest, opts ...grpc.CallOption) (*PutIfFencingTokenCurrentResponse, error)
 }
 
 type lockClient struct {
@@ -329,6 +478,15 @@ func (c *lockClient) Unlock(ctx context.Context, in *UnlockRequest, opts ...grpc
 	return out, nil
 }
 
+func (c *lockClient) PutIfFencingTokenCurrent(ctx context.Context, in *PutIfFencingTokenCurrentRequest, opts ...grpc.CallOption) (*PutIfFencingTokenCurrentResponse, error) {
+	out := new(PutIfFencingTokenCurrentResponse)
+	err := c.cc.Invoke(ctx, "/v3lockpb.Lock/PutIfFencingTokenCurrent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LockServer is the server API for Lock service.
 type LockServer interface {
 	// Lock acquires a distributed shared lock on a given named lock.
@@ -342,6 +500,11 @@ type LockServer interface {
 	// next Lock caller waiting for the lock will then be woken up and given
 	// ownership of the lock.
 	Unlock(context.Context, *UnlockRequest) (*UnlockResponse, error)
+	// PutIfFencingTokenCurrent puts a key only while the lock ownership key
+	// granted by Lock with the given fencing token is still held. It fails
+	// with a stale fencing token error once the lock was released or its
+	// lease expired, so a stale lock holder cannot update etcd.
+	PutIfFencingTokenCurrent(context.Context, *PutIfFencingTokenCurrentRequest) (*PutIfFencingTokenCurrentResponse, error)
 }
 
 // UnimplementedLockServer can be embedded to have forward compatible implementations.
@@ -354,6 +517,9 @@ func (*UnimplementedLockServer) Lock(ctx context.Context, req *LockRequest) (*Lo
 func (*UnimplementedLockServer) Unlock(ctx context.Context, req *UnlockRequest) (*UnlockResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Unlock not implemented")
 }
+func (*UnimplementedLockServer) PutIfFencingTokenCurrent(ctx context.Context, req *PutIfFencingTokenCurrentRequest) (*PutIfFencingTokenCurrentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutIfFencingTokenCurrent not implemented")
+}
 
 func RegisterLockServer(s *grpc.Server, srv LockServer) {
 	s.RegisterService(&_Lock_serviceDesc, srv)
@@ -395,6 +561,24 @@ func _Lock_Unlock_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Lock_PutIfFencingTokenCurrent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutIfFencingTokenCurrentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServer).PutIfFencingTokenCurrent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v3lockpb.Lock/PutIfFencingTokenCurrent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServer).PutIfFencingTokenCurrent(ctx, req.(*PutIfFencingTokenCurrentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Lock_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "v3lockpb.Lock",
 	HandlerType: (*LockServer)(nil),
@@ -407,6 +591,10 @@ var _Lock_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Unlock",
 			Handler:    _Lock_Unlock_Handler,
 		},
+		{
+			MethodName: "PutIfFencingTokenCurrent",
+			Handler:    _Lock_PutIfFencingTokenCurrent_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "v3lock.proto",
@@ -475,6 +663,11 @@ func (m *LockResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.FencingToken != 0 {
+		i = encodeVarintV3Lock(dAtA, i, uint64(m.FencingToken))
+		i--
+		dAtA[i] = 0x18
+	}
 	if len(m.Key) > 0 {
 		i -= len(m.Key)
 		copy(dAtA[i:], m.Key)
@@ -570,6 +763,108 @@ func (m *UnlockResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *PutIfFencingTokenCurrentRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PutIfFencingTokenCurrentRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PutIfFencingTokenCurrentRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Put != nil {
+		{
+			size, err := m.Put.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintV3Lock(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.FencingToken != 0 {
+		i = encodeVarintV3Lock(dAtA, i, uint64(m.FencingToken))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintV3Lock(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PutIfFencingTokenCurrentResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PutIfFencingTokenCurrentResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PutIfFencingTokenCurrentResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Put != nil {
+		{
+			size, err := m.Put.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintV3Lock(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Header != nil {
+		{
+			size, err := m.Header.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintV3Lock(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintV3Lock(dAtA []byte, offset int, v uint64) int {
 	offset -= sovV3Lock(v)
 	base := offset
@@ -614,6 +909,9 @@ func (m *LockResponse) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovV3Lock(uint64(l))
 	}
+	if m.FencingToken != 0 {
+		n += 1 + sovV3Lock(uint64(m.FencingToken))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -652,6 +950,49 @@ func (m *UnlockResponse) Size() (n int) {
 	return n
 }
 
+func (m *PutIfFencingTokenCurrentRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovV3Lock(uint64(l))
+	}
+	if m.FencingToken != 0 {
+		n += 1 + sovV3Lock(uint64(m.FencingToken))
+	}
+	if m.Put != nil {
+		l = m.Put.Size()
+		n += 1 + l + sovV3Lock(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *PutIfFencingTokenCurrentResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Header != nil {
+		l = m.Header.Size()
+		n += 1 + l + sovV3Lock(uint64(l))
+	}
+	if m.Put != nil {
+		l = m.Put.Size()
+		n += 1 + l + sovV3Lock(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovV3Lock(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -861,6 +1202,25 @@ func (m *LockResponse) Unmarshal(dAtA []byte) error {
 				m.Key = []byte{}
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FencingToken", wireType)
+			}
+			m.FencingToken = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowV3Lock
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FencingToken |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipV3Lock(dAtA[iNdEx:])
@@ -1055,6 +1415,269 @@ func (m *UnlockResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *PutIfFencingTokenCurrentRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowV3Lock
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PutIfFencingTokenCurrentRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PutIfFencingTokenCurrentRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowV3Lock
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthV3Lock
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthV3Lock
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = append(m.Key[:0], dAtA[iNdEx:postIndex]...)
+			if m.Key == nil {
+				m.Key = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FencingToken", wireType)
+			}
+			m.FencingToken = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowV3Lock
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FencingToken |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Put", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowV3Lock
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthV3Lock
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthV3Lock
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Put == nil {
+				m.Put = &etcdserverpb.PutRequest{}
+			}
+			if err := m.Put.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipV3Lock(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthV3Lock
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PutIfFencingTokenCurrentResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowV3Lock
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PutIfFencingTokenCurrentResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PutIfFencingTokenCurrentResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Header", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowV3Lock
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthV3Lock
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthV3Lock
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Header == nil {
+				m.Header = &etcdserverpb.ResponseHeader{}
+			}
+			if err := m.Header.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Put", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowV3Lock
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthV3Lock
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthV3Lock
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Put == nil {
+				m.Put = &etcdserverpb.PutResponse{}
+			}
+			if err := m.Put.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipV3Lock(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthV3Lock
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipV3Lock(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
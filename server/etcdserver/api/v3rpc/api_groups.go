@@ -0,0 +1,81 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// APIGroup is a group of the gRPC services served on a client listener.
+type APIGroup string
+
+const (
+	APIGroupKV          APIGroup = "kv"
+	APIGroupWatch       APIGroup = "watch"
+	APIGroupLease       APIGroup = "lease"
+	APIGroupCluster     APIGroup = "cluster"
+	APIGroupMaintenance APIGroup = "maintenance"
+	APIGroupAuth        APIGroup = "auth"
+	APIGroupElection    APIGroup = "election"
+	APIGroupLock        APIGroup = "lock"
+)
+
+// AllAPIGroups are the API groups served on a client listener by default.
+var AllAPIGroups = []APIGroup{
+	APIGroupKV,
+	APIGroupWatch,
+	APIGroupLease,
+	APIGroupCluster,
+	APIGroupMaintenance,
+	APIGroupAuth,
+	APIGroupElection,
+	APIGroupLock,
+}
+
+// APIGroupSet is a set of API groups. A nil set holds all the API groups.
+type APIGroupSet map[APIGroup]struct{}
+
+// Has returns true if the set holds the API group.
+func (gs APIGroupSet) Has(g APIGroup) bool {
+	if gs == nil {
+		return true
+	}
+	_, ok := gs[g]
+	return ok
+}
+
+// ParseAPIGroups parses a "+"-separated list of API groups, such as
+// "kv+watch+lease".
+func ParseAPIGroups(s string) (APIGroupSet, error) {
+	gs := make(APIGroupSet)
+	for _, name := range strings.Split(s, "+") {
+		g := APIGroup(strings.ToLower(strings.TrimSpace(name)))
+		if !isAPIGroup(g) {
+			return nil, fmt.Errorf("unknown API group %q", name)
+		}
+		gs[g] = struct{}{}
+	}
+	return gs, nil
+}
+
+func isAPIGroup(g APIGroup) bool {
+	for _, ag := range AllAPIGroups {
+		if g == ag {
+			return true
+		}
+	}
+	return false
+}
@@ -164,3 +164,11 @@ func (as *AuthServer) UserChangePassword(ctx context.Context, r *pb.AuthUserChan
 	}
 	return resp, nil
 }
+
+func (as *AuthServer) CheckPermissions(ctx context.Context, r *pb.AuthCheckPermissionsRequest) (*pb.AuthCheckPermissionsResponse, error) {
+	resp, err := as.authenticator.CheckPermissions(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
@@ -14,12 +14,29 @@
 
 package v3rpc
 
-import "github.com/golang/protobuf/proto"
+import (
+	"context"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/etcdserver/txn"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
 
 type codec struct{}
 
 func (c *codec) Marshal(v interface{}) ([]byte, error) {
-	b, err := proto.Marshal(v.(proto.Message))
+	var b []byte
+	var err error
+	if pm, ok := v.(*pooledMessage); ok {
+		// the message is copied into its encoding, its buffers can be
+		// recycled right away
+		b, err = proto.Marshal(pm.Message)
+		pm.release()
+	} else {
+		b, err = proto.Marshal(v.(proto.Message))
+	}
 	sentBytes.Add(float64(len(b)))
 	return b, err
 }
@@ -32,3 +49,29 @@ func (c *codec) Unmarshal(data []byte, v interface{}) error {
 func (c *codec) String() string {
 	return "proto"
 }
+
+// pooledMessage is a response whose buffers are released to their pool once
+// the codec marshaled it.
+type pooledMessage struct {
+	proto.Message
+	release func()
+}
+
+// newRangeBufferUnaryInterceptor builds the responses of the ranges in buffers
+// recycled once the responses are marshaled, rather than leaving them and
+// their key-values to the garbage collector. It must be the outermost
+// interceptor, the response it returns being only understood by the codec.
+func newRangeBufferUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if info.FullMethod != "/etcdserverpb.KV/Range" {
+			return handler(ctx, req)
+		}
+		b := txn.GetRangeBuffer()
+		resp, err := handler(txn.WithRangeBuffer(ctx, b), req)
+		if rr, ok := resp.(*pb.RangeResponse); ok && err == nil && b.Owns(rr) {
+			return &pooledMessage{Message: rr, release: b.Release}, nil
+		}
+		b.Release()
+		return resp, err
+	}
+}
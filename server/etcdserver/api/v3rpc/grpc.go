@@ -36,6 +36,13 @@ const (
 )
 
 func Server(s *etcdserver.EtcdServer, tls *tls.Config, interceptor grpc.UnaryServerInterceptor, gopts ...grpc.ServerOption) *grpc.Server {
+	return ServerWithAPIGroups(s, tls, interceptor, nil, gopts...)
+}
+
+// ServerWithAPIGroups returns the gRPC server of the services of the API groups,
+// or of all of them if groups is nil. The services of the election and lock API
+// groups are registered by the caller.
+func ServerWithAPIGroups(s *etcdserver.EtcdServer, tls *tls.Config, interceptor grpc.UnaryServerInterceptor, groups APIGroupSet, gopts ...grpc.ServerOption) *grpc.Server {
 	var opts []grpc.ServerOption
 	opts = append(opts, grpc.CustomCodec(&codec{}))
 	if tls != nil {
@@ -43,17 +50,27 @@ func Server(s *etcdserver.EtcdServer, tls *tls.Config, interceptor grpc.UnarySer
 		opts = append(opts, grpc.Creds(bundle.TransportCredentials()))
 	}
 	chainUnaryInterceptors := []grpc.UnaryServerInterceptor{
+		newRangeBufferUnaryInterceptor(),
 		newLogUnaryInterceptor(s),
 		newUnaryInterceptor(s),
 		grpc_prometheus.UnaryServerInterceptor,
+		newRateLimitUnaryInterceptor(s),
 	}
 	if interceptor != nil {
 		chainUnaryInterceptors = append(chainUnaryInterceptors, interceptor)
 	}
+	if len(s.Cfg.TraceRules) > 0 {
+		// must run before the tracing interceptor, whose sampler checks the mark
+		chainUnaryInterceptors = append(chainUnaryInterceptors, newTraceUnaryInterceptor(s))
+	}
 
 	chainStreamInterceptors := []grpc.StreamServerInterceptor{
 		newStreamInterceptor(s),
 		grpc_prometheus.StreamServerInterceptor,
+		newRateLimitStreamInterceptor(s),
+	}
+	if len(s.Cfg.TraceRules) > 0 {
+		chainStreamInterceptors = append(chainStreamInterceptors, newTraceStreamInterceptor(s))
 	}
 
 	if s.Cfg.ExperimentalEnableDistributedTracing {
@@ -71,12 +88,24 @@ func Server(s *etcdserver.EtcdServer, tls *tls.Config, interceptor grpc.UnarySer
 
 	grpcServer := grpc.NewServer(append(opts, gopts...)...)
 
-	pb.RegisterKVServer(grpcServer, NewQuotaKVServer(s))
-	pb.RegisterWatchServer(grpcServer, NewWatchServer(s))
-	pb.RegisterLeaseServer(grpcServer, NewQuotaLeaseServer(s))
-	pb.RegisterClusterServer(grpcServer, NewClusterServer(s))
-	pb.RegisterAuthServer(grpcServer, NewAuthServer(s))
-	pb.RegisterMaintenanceServer(grpcServer, NewMaintenanceServer(s))
+	if groups.Has(APIGroupKV) {
+		pb.RegisterKVServer(grpcServer, NewQuotaKVServer(s))
+	}
+	if groups.Has(APIGroupWatch) {
+		pb.RegisterWatchServer(grpcServer, NewWatchServer(s))
+	}
+	if groups.Has(APIGroupLease) {
+		pb.RegisterLeaseServer(grpcServer, NewQuotaLeaseServer(s))
+	}
+	if groups.Has(APIGroupCluster) {
+		pb.RegisterClusterServer(grpcServer, NewClusterServer(s))
+	}
+	if groups.Has(APIGroupAuth) {
+		pb.RegisterAuthServer(grpcServer, NewAuthServer(s))
+	}
+	if groups.Has(APIGroupMaintenance) {
+		pb.RegisterMaintenanceServer(grpcServer, NewMaintenanceServer(s))
+	}
 
 	// server should register all the services manually
 	// use empty service name for all etcd services' health status,
@@ -25,14 +25,34 @@ type header struct {
 	memberID  int64
 	sg        apply.RaftStatusGetter
 	rev       func() int64
+	epoch     func() uint64
+	// compactRev is nil unless the headers carry the compaction revision.
+	compactRev func() int64
 }
 
 func newHeader(s *etcdserver.EtcdServer) header {
 	return header{
-		clusterID: int64(s.Cluster().ID()),
-		memberID:  int64(s.MemberId()),
-		sg:        s,
-		rev:       func() int64 { return s.KV().Rev() },
+		clusterID:  int64(s.Cluster().ID()),
+		memberID:   int64(s.MemberId()),
+		sg:         s,
+		rev:        func() int64 { return s.KV().Rev() },
+		epoch:      s.ClusterEpoch,
+		compactRev: compactRevFunc(s),
+	}
+}
+
+// compactRevFunc returns the function getting the compaction revision of the
+// key-value store, or nil if the server does not set it in the headers.
+func compactRevFunc(s *etcdserver.EtcdServer) func() int64 {
+	if !s.Cfg.ResponseHeaderCompactRevision {
+		return nil
+	}
+	return func() int64 {
+		// the revision is -1 until the first compaction
+		if rev := s.KV().FirstRev(); rev > 0 {
+			return rev
+		}
+		return 0
 	}
 }
 
@@ -44,6 +64,10 @@ func (h *header) fill(rh *pb.ResponseHeader) {
 	rh.ClusterId = uint64(h.clusterID)
 	rh.MemberId = uint64(h.memberID)
 	rh.RaftTerm = h.sg.Term()
+	rh.ClusterEpoch = h.epoch()
+	if h.compactRev != nil {
+		rh.CompactRevision = h.compactRev()
+	}
 	if rh.Revision == 0 {
 		rh.Revision = h.rev()
 	}
@@ -16,16 +16,23 @@ package v3rpc
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"sync"
 	"time"
 	"unicode/utf8"
 
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/api/v3/version"
 	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
 	"go.etcd.io/etcd/raft/v3"
 	"go.etcd.io/etcd/server/v3/etcdserver"
 	"go.etcd.io/etcd/server/v3/etcdserver/api"
+	"go.etcd.io/etcd/server/v3/etcdserver/txn"
 
+	"github.com/coreos/go-semver/semver"
+	"github.com/golang/protobuf/proto"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -34,8 +41,11 @@ import (
 )
 
 const (
-	maxNoLeaderCnt = 3
-	snapshotMethod = "/etcdserverpb.Maintenance/Snapshot"
+	maxNoLeaderCnt    = 3
+	snapshotMethod    = "/etcdserverpb.Maintenance/Snapshot"
+	watchMethod       = "/etcdserverpb.Watch/Watch"
+	rangeStreamMethod = "/etcdserverpb.KV/RangeStream"
+	txnStreamMethod   = "/etcdserverpb.KV/TxnStream"
 )
 
 type streamsMap struct {
@@ -49,7 +59,7 @@ func newUnaryInterceptor(s *etcdserver.EtcdServer) grpc.UnaryServerInterceptor {
 			return nil, rpctypes.ErrGRPCNotCapable
 		}
 
-		if s.IsMemberExist(s.MemberId()) && s.IsLearner() && !isRPCSupportedForLearner(req) {
+		if s.IsMemberExist(s.MemberId()) && s.IsLearner() && !isRPCSupportedForLearner(req, s.Cfg.LearnerSerializableReads) {
 			return nil, rpctypes.ErrGRPCNotSupportedForLearner
 		}
 
@@ -64,6 +74,10 @@ func newUnaryInterceptor(s *etcdserver.EtcdServer) grpc.UnaryServerInterceptor {
 			}
 			clientRequests.WithLabelValues("unary", ver).Inc()
 
+			if err := checkClusterEpoch(s, md); err != nil {
+				return nil, err
+			}
+
 			if ks := md[rpctypes.MetadataRequireLeaderKey]; len(ks) > 0 && ks[0] == rpctypes.MetadataHasLeader {
 				if s.Leader() == types.ID(raft.None) {
 					return nil, rpctypes.ErrGRPCNoLeader
@@ -71,8 +85,60 @@ func newUnaryInterceptor(s *etcdserver.EtcdServer) grpc.UnaryServerInterceptor {
 			}
 		}
 
-		return handler(ctx, req)
+		if cv := s.ClusterVersion(); cv != nil {
+			grpc.SetHeader(ctx, metadata.Pairs(rpctypes.MetadataClusterAPIVersionKey, fmt.Sprintf("%d.%d", cv.Major, cv.Minor)))
+			if err := checkAPIVersion(s.Logger(), cv, info.FullMethod, req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := handler(ctx, req)
+		if err == rpctypes.ErrGRPCOverloaded {
+			ms := strconv.FormatInt(s.WriteRetryAfter().Milliseconds(), 10)
+			grpc.SetHeader(ctx, metadata.Pairs(rpctypes.MetadataRetryAfterKey, ms))
+		}
+		return resp, err
+	}
+}
+
+// checkClusterEpoch rejects the requests of clients which know another epoch
+// of the cluster than the local member.
+func checkClusterEpoch(s *etcdserver.EtcdServer, md metadata.MD) error {
+	vs := md.Get(rpctypes.MetadataClusterEpochKey)
+	if len(vs) == 0 {
+		return nil
+	}
+	epoch, err := strconv.ParseUint(vs[0], 10, 64)
+	if err != nil || epoch == 0 {
+		return nil
 	}
+	if local := s.ClusterEpoch(); local != 0 && local != epoch {
+		return rpctypes.ErrGRPCClusterEpochMismatch
+	}
+	return nil
+}
+
+// checkAPIVersion rejects the requests using fields, messages or enum values
+// introduced after the cluster version, which the members of the cluster
+// from before that version would silently ignore.
+func checkAPIVersion(lg *zap.Logger, clusterVersion *semver.Version, method string, req interface{}) error {
+	if !clusterVersion.LessThan(*semver.Must(semver.NewVersion(version.Version))) {
+		// the cluster supports all the fields known to the local member
+		return nil
+	}
+	m, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+	ver, err := version.MessageVersion(m)
+	if err != nil {
+		lg.Warn("failed to get the API version of request", zap.String("method", method), zap.Error(err))
+		return nil
+	}
+	if ver != nil && clusterVersion.LessThan(*ver) {
+		return rpctypes.ErrGRPCAPIVersionUnsupported
+	}
+	return nil
 }
 
 func newLogUnaryInterceptor(s *etcdserver.EtcdServer) grpc.UnaryServerInterceptor {
@@ -210,6 +276,101 @@ func logExpensiveRequestStats(lg *zap.Logger, startTime time.Time, duration time
 	)
 }
 
+// newTraceUnaryInterceptor marks the KV requests selected by the trace rules
+// of the server, so they are traced whatever their duration or sampling rate.
+func newTraceUnaryInterceptor(s *etcdserver.EtcdServer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if op, keys := requestKeys(req); traceutil.MatchAny(s.Cfg.TraceRules, op, keys...) {
+			ctx = context.WithValue(ctx, traceutil.ForceKey, true)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// newRateLimitUnaryInterceptor rejects the KV requests exceeding the rate
+// limit of the prefix of one of their keys. Ranges count against the limit
+// of their start key.
+func newRateLimitUnaryInterceptor(s *etcdserver.EtcdServer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if _, keys := requestKeys(req); len(keys) > 0 {
+			if err := s.AdmitKeys(ctx, keys); err != nil {
+				return nil, togRPCError(err)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// newTraceStreamInterceptor is newTraceUnaryInterceptor for the requests of
+// the KV streams. The spans of the streams start before their request is
+// received, so the mark only forces the logging of the request trace.
+func newTraceStreamInterceptor(s *etcdserver.EtcdServer) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isKVStream(info.FullMethod) {
+			ss = &traceStream{ServerStream: ss, rules: s.Cfg.TraceRules}
+		}
+		return handler(srv, ss)
+	}
+}
+
+// newRateLimitStreamInterceptor is newRateLimitUnaryInterceptor for the
+// requests of the KV streams.
+func newRateLimitStreamInterceptor(s *etcdserver.EtcdServer) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isKVStream(info.FullMethod) {
+			ss = rateLimitStream{ServerStream: ss, s: s}
+		}
+		return handler(srv, ss)
+	}
+}
+
+func isKVStream(method string) bool {
+	return method == rangeStreamMethod || method == txnStreamMethod
+}
+
+// requestKeys returns the operation type of a KV request and the keys it accesses.
+func requestKeys(req interface{}) (op string, keys [][]byte) {
+	switch r := req.(type) {
+	case *pb.RangeRequest:
+		return traceutil.OpRead, [][]byte{r.Key}
+	case *pb.PutRequest:
+		return traceutil.OpWrite, [][]byte{r.Key}
+	case *pb.DeleteRangeRequest:
+		return traceutil.OpWrite, [][]byte{r.Key}
+	case *pb.TxnRequest:
+		op = traceutil.OpWrite
+		if txn.IsTxnReadonly(r) {
+			op = traceutil.OpRead
+		}
+		return op, txnKeys(r, nil)
+	}
+	return "", nil
+}
+
+func txnKeys(r *pb.TxnRequest, keys [][]byte) [][]byte {
+	for _, c := range r.Compare {
+		keys = append(keys, c.Key)
+		for _, iv := range c.Intervals {
+			keys = append(keys, iv.Key)
+		}
+	}
+	for _, ops := range [][]*pb.RequestOp{r.Success, r.Failure} {
+		for _, op := range ops {
+			switch tv := op.Request.(type) {
+			case *pb.RequestOp_RequestRange:
+				keys = append(keys, tv.RequestRange.Key)
+			case *pb.RequestOp_RequestPut:
+				keys = append(keys, tv.RequestPut.Key)
+			case *pb.RequestOp_RequestDeleteRange:
+				keys = append(keys, tv.RequestDeleteRange.Key)
+			case *pb.RequestOp_RequestTxn:
+				keys = txnKeys(tv.RequestTxn, keys)
+			}
+		}
+	}
+	return keys
+}
+
 func newStreamInterceptor(s *etcdserver.EtcdServer) grpc.StreamServerInterceptor {
 	smap := monitorLeader(s)
 
@@ -218,8 +379,13 @@ func newStreamInterceptor(s *etcdserver.EtcdServer) grpc.StreamServerInterceptor
 			return rpctypes.ErrGRPCNotCapable
 		}
 
-		if s.IsMemberExist(s.MemberId()) && s.IsLearner() && info.FullMethod != snapshotMethod { // learner does not support stream RPC except Snapshot
-			return rpctypes.ErrGRPCNotSupportedForLearner
+		if s.IsMemberExist(s.MemberId()) && s.IsLearner() {
+			if !isStreamSupportedForLearner(info.FullMethod, s.Cfg.LearnerSerializableReads) {
+				return rpctypes.ErrGRPCNotSupportedForLearner
+			}
+			if info.FullMethod == rangeStreamMethod {
+				ss = learnerRangeStream{ServerStream: ss}
+			}
 		}
 
 		md, ok := metadata.FromIncomingContext(ss.Context())
@@ -233,6 +399,10 @@ func newStreamInterceptor(s *etcdserver.EtcdServer) grpc.StreamServerInterceptor
 			}
 			clientRequests.WithLabelValues("stream", ver).Inc()
 
+			if err := checkClusterEpoch(s, md); err != nil {
+				return err
+			}
+
 			if ks := md[rpctypes.MetadataRequireLeaderKey]; len(ks) > 0 && ks[0] == rpctypes.MetadataHasLeader {
 				if s.Leader() == types.ID(raft.None) {
 					return rpctypes.ErrGRPCNoLeader
@@ -255,6 +425,32 @@ func newStreamInterceptor(s *etcdserver.EtcdServer) grpc.StreamServerInterceptor
 			}
 		}
 
+		if cv := s.ClusterVersion(); cv != nil {
+			ss.SetHeader(metadata.Pairs(rpctypes.MetadataClusterAPIVersionKey, fmt.Sprintf("%d.%d", cv.Major, cv.Minor)))
+			ss = apiVersionStream{ServerStream: ss, lg: s.Logger(), clusterVersion: cv, method: info.FullMethod}
+		}
+
+		if info.FullMethod != snapshotMethod {
+			select {
+			case <-s.DrainingNotify():
+				return rpctypes.ErrGRPCStopped
+			default:
+			}
+
+			ctx := newCancellableContext(ss.Context())
+			ss = serverStreamWithCtx{ctx: ctx, ServerStream: ss}
+			go func() {
+				select {
+				case <-s.DrainingNotify():
+					// clients retry the streams closed with ErrGRPCStopped,
+					// on other members once the connection is going away.
+					ctx.Cancel(rpctypes.ErrGRPCStopped)
+				case <-ctx.Done():
+				}
+			}()
+			defer ctx.Cancel(nil)
+		}
+
 		return handler(srv, ss)
 	}
 }
@@ -349,3 +545,79 @@ func monitorLeader(s *etcdserver.EtcdServer) *streamsMap {
 
 	return smap
 }
+
+// learnerRangeStream rejects the linearizable range streams a learner cannot
+// serve.
+type learnerRangeStream struct {
+	grpc.ServerStream
+}
+
+func (ss learnerRangeStream) RecvMsg(m interface{}) error {
+	if err := ss.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if r, ok := m.(*pb.RangeRequest); ok && !r.Serializable {
+		return rpctypes.ErrGRPCNotSupportedForLearner
+	}
+	return nil
+}
+
+// apiVersionStream rejects the requests using fields the cluster API version
+// does not support.
+type apiVersionStream struct {
+	grpc.ServerStream
+	lg             *zap.Logger
+	clusterVersion *semver.Version
+	method         string
+}
+
+func (ss apiVersionStream) RecvMsg(m interface{}) error {
+	if err := ss.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return checkAPIVersion(ss.lg, ss.clusterVersion, ss.method, m)
+}
+
+// rateLimitStream rejects the requests exceeding the rate limit of the prefix
+// of one of their keys.
+type rateLimitStream struct {
+	grpc.ServerStream
+	s *etcdserver.EtcdServer
+}
+
+func (ss rateLimitStream) RecvMsg(m interface{}) error {
+	if err := ss.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if _, keys := requestKeys(m); len(keys) > 0 {
+		if err := ss.s.AdmitKeys(ss.Context(), keys); err != nil {
+			return togRPCError(err)
+		}
+	}
+	return nil
+}
+
+// traceStream marks the context of the stream once it receives a request
+// selected by the trace rules.
+type traceStream struct {
+	grpc.ServerStream
+	rules []traceutil.Rule
+	ctx   context.Context
+}
+
+func (ss *traceStream) RecvMsg(m interface{}) error {
+	if err := ss.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if op, keys := requestKeys(m); traceutil.MatchAny(ss.rules, op, keys...) {
+		ss.ctx = context.WithValue(ss.ServerStream.Context(), traceutil.ForceKey, true)
+	}
+	return nil
+}
+
+func (ss *traceStream) Context() context.Context {
+	if ss.ctx != nil {
+		return ss.ctx
+	}
+	return ss.ServerStream.Context()
+}
@@ -0,0 +1,133 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coreos/go-semver/semver"
+	"go.uber.org/zap/zaptest"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+)
+
+func TestRequestKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      interface{}
+		wantOp   string
+		wantKeys []string
+	}{
+		{
+			name:     "range",
+			req:      &pb.RangeRequest{Key: []byte("foo")},
+			wantOp:   traceutil.OpRead,
+			wantKeys: []string{"foo"},
+		},
+		{
+			name:     "put",
+			req:      &pb.PutRequest{Key: []byte("foo")},
+			wantOp:   traceutil.OpWrite,
+			wantKeys: []string{"foo"},
+		},
+		{
+			name:     "delete range",
+			req:      &pb.DeleteRangeRequest{Key: []byte("foo")},
+			wantOp:   traceutil.OpWrite,
+			wantKeys: []string{"foo"},
+		},
+		{
+			name: "read-only txn",
+			req: &pb.TxnRequest{
+				Compare: []*pb.Compare{{Key: []byte("a")}},
+				Success: []*pb.RequestOp{{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("b")}}}},
+			},
+			wantOp:   traceutil.OpRead,
+			wantKeys: []string{"a", "b"},
+		},
+		{
+			name: "nested write txn",
+			req: &pb.TxnRequest{
+				Success: []*pb.RequestOp{{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("a")}}}},
+				Failure: []*pb.RequestOp{{Request: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{
+					Success: []*pb.RequestOp{{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("b")}}}},
+				}}}},
+			},
+			wantOp:   traceutil.OpWrite,
+			wantKeys: []string{"a", "b"},
+		},
+		{
+			name: "not a kv request",
+			req:  &pb.LeaseGrantRequest{TTL: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, keys := requestKeys(tt.req)
+			var gotKeys []string
+			for _, k := range keys {
+				gotKeys = append(gotKeys, string(k))
+			}
+			if op != tt.wantOp || !reflect.DeepEqual(gotKeys, tt.wantKeys) {
+				t.Errorf("requestKeys() = %q %v, want %q %v", op, gotKeys, tt.wantOp, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestCheckAPIVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		clusterVersion *semver.Version
+		req            interface{}
+		wantErr        error
+	}{
+		{
+			name:           "field of cluster version",
+			clusterVersion: &version.V3_5,
+			req:            &pb.RangeRequest{Key: []byte("foo"), MinModRevision: 1},
+		},
+		{
+			name:           "field after cluster version",
+			clusterVersion: &version.V3_5,
+			req:            &pb.RangeRequest{Key: []byte("foo"), MaxValueSize: 1},
+			wantErr:        rpctypes.ErrGRPCAPIVersionUnsupported,
+		},
+		{
+			name:           "nested field after cluster version",
+			clusterVersion: &version.V3_5,
+			req: &pb.TxnRequest{
+				Success: []*pb.RequestOp{{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("a"), MaxValueSize: 1}}}},
+			},
+			wantErr: rpctypes.ErrGRPCAPIVersionUnsupported,
+		},
+		{
+			name:           "field of local version",
+			clusterVersion: &version.V3_6,
+			req:            &pb.RangeRequest{Key: []byte("foo"), MaxValueSize: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkAPIVersion(zaptest.NewLogger(t), tt.clusterVersion, "/etcdserverpb.KV/Range", tt.req); err != tt.wantErr {
+				t.Errorf("checkAPIVersion() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -19,9 +19,19 @@ import (
 	"context"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/pkg/v3/adt"
 	"go.etcd.io/etcd/server/v3/etcdserver"
+	"go.etcd.io/etcd/server/v3/lease"
+)
+
+const (
+	// maxMetadataBytes is the maximum size of the metadata of a key.
+	maxMetadataBytes = 1024
+	// rangeStreamPageSize bounds the number of keys read by each page of a
+	// streamed range.
+	rangeStreamPageSize = 1000
 )
 
 type kvServer struct {
@@ -32,10 +42,17 @@ type kvServer struct {
 	// Txn.Success can have at most 128 operations,
 	// and Txn.Failure can have at most 128 operations.
 	maxTxnOps uint
+	// maxRequestBytes bounds the size of the chunks of streamed responses.
+	maxRequestBytes int
 }
 
 func NewKVServer(s *etcdserver.EtcdServer) pb.KVServer {
-	return &kvServer{hdr: newHeader(s), kv: s, maxTxnOps: s.Cfg.MaxTxnOps}
+	return &kvServer{
+		hdr:             newHeader(s),
+		kv:              s,
+		maxTxnOps:       s.Cfg.MaxTxnOps,
+		maxRequestBytes: int(s.Cfg.MaxRequestBytes + grpcOverheadBytes),
+	}
 }
 
 func (s *kvServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, error) {
@@ -85,11 +102,17 @@ func (s *kvServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse,
 		return nil, err
 	}
 	// check for forbidden put/del overlaps after checking request to avoid quadratic blowup
-	if _, _, err := checkIntervals(r.Success); err != nil {
-		return nil, err
-	}
-	if _, _, err := checkIntervals(r.Failure); err != nil {
-		return nil, err
+	if r.ReadYourWrites {
+		if err := checkReadYourWrites(r.Success, r.Failure); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, _, err := checkIntervals(r.Success); err != nil {
+			return nil, err
+		}
+		if _, _, err := checkIntervals(r.Failure); err != nil {
+			return nil, err
+		}
 	}
 
 	resp, err := s.kv.Txn(ctx, r)
@@ -101,6 +124,145 @@ func (s *kvServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse,
 	return resp, nil
 }
 
+func (s *kvServer) RangeStream(r *pb.RangeRequest, stream pb.KV_RangeStreamServer) error {
+	ctx := stream.Context()
+	// only the ranges of several keys in ascending key order are read in pages
+	if len(r.RangeEnd) == 0 || r.CountOnly || r.SortTarget != pb.RangeRequest_KEY || r.SortOrder == pb.RangeRequest_DESCEND {
+		resp, err := s.Range(ctx, r)
+		if err != nil {
+			return err
+		}
+		return sendRangeStream(stream, splitRangeStream(resp.Header, resp.Count, resp.Kvs, resp.More, s.maxRequestBytes))
+	}
+
+	req := *r
+	var (
+		hdr      *pb.ResponseHeader
+		count, n int64
+		pageSize int64 = rangeStreamPageSize
+	)
+	for {
+		req.Limit = pageSize
+		if r.Limit > 0 && r.Limit-n < req.Limit {
+			req.Limit = r.Limit - n
+		}
+		resp, err := s.Range(ctx, &req)
+		if err != nil {
+			return err
+		}
+		if hdr == nil {
+			hdr, count = resp.Header, resp.Count
+			// the following pages are read at the revision of the first one,
+			// which this member has applied already
+			req.Revision, req.Serializable = resp.Header.Revision, true
+		}
+		n += int64(len(resp.Kvs))
+		last := !resp.More || len(resp.Kvs) == 0 || (r.Limit > 0 && n >= r.Limit)
+		if err = sendRangeStream(stream, splitRangeStream(hdr, count, resp.Kvs, last && resp.More, s.maxRequestBytes)); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+
+		// size the next page to about one chunk
+		size := 0
+		for _, kv := range resp.Kvs {
+			size += kv.Size()
+		}
+		pageSize = int64(s.maxRequestBytes) * int64(len(resp.Kvs)) / int64(size)
+		if pageSize < 1 {
+			pageSize = 1
+		} else if pageSize > rangeStreamPageSize {
+			pageSize = rangeStreamPageSize
+		}
+		lastKey := resp.Kvs[len(resp.Kvs)-1].Key
+		req.Key = append(append(make([]byte, 0, len(lastKey)+1), lastKey...), 0)
+	}
+}
+
+func sendRangeStream(stream pb.KV_RangeStreamServer, chunks []*pb.RangeStreamResponse) error {
+	for _, chunk := range chunks {
+		if err := stream.Send(chunk); err != nil {
+			return togRPCError(err)
+		}
+	}
+	return nil
+}
+
+// splitRangeStream splits kvs into stream chunks smaller than maxBytes, each
+// holding at least one kv, or into a single empty chunk if there is no kv.
+// Only the last chunk carries more.
+func splitRangeStream(hdr *pb.ResponseHeader, count int64, kvs []*mvccpb.KeyValue, more bool, maxBytes int) []*pb.RangeStreamResponse {
+	cur := &pb.RangeStreamResponse{Header: hdr, Count: count}
+	chunks := []*pb.RangeStreamResponse{cur}
+	size := cur.Size()
+	for _, kv := range kvs {
+		kvSize := kv.Size()
+		if len(cur.Kvs) > 0 && size+kvSize >= maxBytes {
+			cur = &pb.RangeStreamResponse{Header: hdr, Count: count}
+			chunks = append(chunks, cur)
+			size = cur.Size()
+		}
+		cur.Kvs = append(cur.Kvs, kv)
+		size += kvSize
+	}
+	cur.More = more
+	return chunks
+}
+
+// TxnStream processes the transaction like Txn and splits its response into
+// chunks. The whole response is still built in memory before being split,
+// so TxnStream bounds the size of the messages but not the memory used by
+// the transaction. The ranges of nested transactions are not split.
+func (s *kvServer) TxnStream(r *pb.TxnRequest, stream pb.KV_TxnStreamServer) error {
+	resp, err := s.Txn(stream.Context(), r)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range SplitTxnResponse(resp, s.maxRequestBytes) {
+		if err := stream.Send(chunk); err != nil {
+			return togRPCError(err)
+		}
+	}
+	return nil
+}
+
+// SplitTxnResponse splits a txn response into stream chunks holding one
+// response each. Range responses larger than maxBytes are split over
+// several chunks with the same index, each holding at least one kv. The
+// responses of nested transactions are sent whole.
+func SplitTxnResponse(resp *pb.TxnResponse, maxBytes int) []*pb.TxnStreamResponse {
+	if len(resp.Responses) == 0 {
+		return []*pb.TxnStreamResponse{{Header: resp.Header, Succeeded: resp.Succeeded}}
+	}
+	var chunks []*pb.TxnStreamResponse
+	add := func(i int, op *pb.ResponseOp) {
+		chunks = append(chunks, &pb.TxnStreamResponse{Header: resp.Header, Succeeded: resp.Succeeded, Index: int64(i), Response: op})
+	}
+	for i, op := range resp.Responses {
+		rr := op.GetResponseRange()
+		if rr == nil || len(rr.Kvs) < 2 || op.Size() < maxBytes {
+			add(i, op)
+			continue
+		}
+		cur := &pb.RangeResponse{Header: rr.Header, More: rr.More, Count: rr.Count}
+		size := cur.Size()
+		for _, kv := range rr.Kvs {
+			kvSize := kv.Size()
+			if len(cur.Kvs) > 0 && size+kvSize >= maxBytes {
+				add(i, &pb.ResponseOp{Response: &pb.ResponseOp_ResponseRange{ResponseRange: cur}})
+				cur = &pb.RangeResponse{More: rr.More, Count: rr.Count}
+				size = cur.Size()
+			}
+			cur.Kvs = append(cur.Kvs, kv)
+			size += kvSize
+		}
+		add(i, &pb.ResponseOp{Response: &pb.ResponseOp_ResponseRange{ResponseRange: cur}})
+	}
+	return chunks
+}
+
 func (s *kvServer) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error) {
 	resp, err := s.kv.Compact(ctx, r)
 	if err != nil {
@@ -124,6 +286,15 @@ func checkRangeRequest(r *pb.RangeRequest) error {
 		return rpctypes.ErrGRPCInvalidSortOption
 	}
 
+	for _, f := range r.ExcludeFields {
+		if _, ok := pb.RangeRequest_ExcludeField_name[int32(f)]; !ok {
+			return rpctypes.ErrGRPCInvalidFieldMask
+		}
+	}
+	if r.MaxValueSize < 0 {
+		return rpctypes.ErrGRPCInvalidFieldMask
+	}
+
 	return nil
 }
 
@@ -137,6 +308,18 @@ func checkPutRequest(r *pb.PutRequest) error {
 	if r.IgnoreLease && r.Lease != 0 {
 		return rpctypes.ErrGRPCLeaseProvided
 	}
+	if r.IgnoreMetadata && len(r.Metadata) != 0 {
+		return rpctypes.ErrGRPCMetadataProvided
+	}
+	if len(r.Metadata) > maxMetadataBytes {
+		return rpctypes.ErrGRPCMetadataTooLarge
+	}
+	if r.Ttl < 0 || r.Ttl > lease.MaxLeaseTTL {
+		return rpctypes.ErrGRPCInvalidKeyTTL
+	}
+	if r.Ttl != 0 && (r.Lease != 0 || r.IgnoreLease) {
+		return rpctypes.ErrGRPCLeaseProvided
+	}
 	return nil
 }
 
@@ -148,7 +331,11 @@ func checkDeleteRequest(r *pb.DeleteRangeRequest) error {
 }
 
 func checkTxnRequest(r *pb.TxnRequest, maxTxnOps int) error {
+	// the intervals of a compare are read like as many compares
 	opc := len(r.Compare)
+	for _, c := range r.Compare {
+		opc += len(c.Intervals)
+	}
 	if opc < len(r.Success) {
 		opc = len(r.Success)
 	}
@@ -163,6 +350,11 @@ func checkTxnRequest(r *pb.TxnRequest, maxTxnOps int) error {
 		if len(c.Key) == 0 {
 			return rpctypes.ErrGRPCEmptyKey
 		}
+		for _, iv := range c.Intervals {
+			if len(iv.Key) == 0 {
+				return rpctypes.ErrGRPCEmptyKey
+			}
+		}
 	}
 	for _, u := range r.Success {
 		if err := checkRequestOp(u, maxTxnOps-opc); err != nil {
@@ -262,6 +454,47 @@ func checkIntervals(reqs []*pb.RequestOp) (map[string]struct{}, adt.IntervalTree
 	return puts, dels, nil
 }
 
+// checkReadYourWrites tests whether the puts of a read-your-writes txn that
+// keep the value, lease or metadata of their key are of keys deleted in the
+// txn, as such a key may not exist anymore when the put is applied.
+func checkReadYourWrites(branches ...[]*pb.RequestOp) error {
+	dels := adt.NewIntervalTree()
+	var puts []string
+	var collect func(reqs []*pb.RequestOp)
+	collect = func(reqs []*pb.RequestOp) {
+		for _, req := range reqs {
+			switch tv := req.Request.(type) {
+			case *pb.RequestOp_RequestDeleteRange:
+				if dreq := tv.RequestDeleteRange; dreq != nil {
+					if len(dreq.RangeEnd) != 0 {
+						dels.Insert(adt.NewStringAffineInterval(string(dreq.Key), string(dreq.RangeEnd)), struct{}{})
+					} else {
+						dels.Insert(adt.NewStringAffinePoint(string(dreq.Key)), struct{}{})
+					}
+				}
+			case *pb.RequestOp_RequestPut:
+				if preq := tv.RequestPut; preq != nil && (preq.IgnoreValue || preq.IgnoreLease || preq.IgnoreMetadata) {
+					puts = append(puts, string(preq.Key))
+				}
+			case *pb.RequestOp_RequestTxn:
+				if tv.RequestTxn != nil {
+					collect(tv.RequestTxn.Success)
+					collect(tv.RequestTxn.Failure)
+				}
+			}
+		}
+	}
+	for _, reqs := range branches {
+		collect(reqs)
+	}
+	for _, k := range puts {
+		if dels.Intersects(adt.NewStringAffinePoint(k)) {
+			return rpctypes.ErrGRPCDuplicateKey
+		}
+	}
+	return nil
+}
+
 func checkRequestOp(u *pb.RequestOp, maxTxnOps int) error {
 	// TODO: ensure only one of the field is set.
 	switch uv := u.Request.(type) {
@@ -15,9 +15,12 @@
 package v3rpc
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 )
 
@@ -59,6 +62,77 @@ func TestCheckRangeRequest(t *testing.T) {
 	}
 }
 
+func TestCheckTxnRequest(t *testing.T) {
+	intervals := func(n int) []*pb.KeyInterval {
+		ivs := make([]*pb.KeyInterval, n)
+		for i := range ivs {
+			ivs[i] = &pb.KeyInterval{Key: []byte(fmt.Sprintf("k%d", i))}
+		}
+		return ivs
+	}
+	txnReqs := []struct {
+		cmps          []*pb.Compare
+		expectedError error
+	}{
+		{
+			cmps: []*pb.Compare{{Key: []byte("a"), Intervals: intervals(2)}},
+		},
+		{
+			cmps:          []*pb.Compare{{Key: []byte("a"), Intervals: []*pb.KeyInterval{{RangeEnd: []byte("b")}}}},
+			expectedError: rpctypes.ErrGRPCEmptyKey,
+		},
+		{
+			// the intervals of a compare count against the maximum number of operations
+			cmps:          []*pb.Compare{{Key: []byte("a"), Intervals: intervals(3)}},
+			expectedError: rpctypes.ErrGRPCTooManyOps,
+		},
+	}
+
+	for i, req := range txnReqs {
+		actualRet := checkTxnRequest(&pb.TxnRequest{Compare: req.cmps}, 3)
+		if getError(actualRet) != getError(req.expectedError) {
+			t.Errorf("#%d: expected %q, but got %q", i, getError(req.expectedError), getError(actualRet))
+		}
+	}
+}
+
+func TestCheckReadYourWrites(t *testing.T) {
+	put := func(key string, ignoreValue bool) *pb.RequestOp {
+		return &pb.RequestOp{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte(key), IgnoreValue: ignoreValue}}}
+	}
+	del := func(key, end string) *pb.RequestOp {
+		return &pb.RequestOp{Request: &pb.RequestOp_RequestDeleteRange{RequestDeleteRange: &pb.DeleteRangeRequest{Key: []byte(key), RangeEnd: []byte(end)}}}
+	}
+	txn := func(ops ...*pb.RequestOp) *pb.RequestOp {
+		return &pb.RequestOp{Request: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{Success: ops}}}
+	}
+	tests := []struct {
+		ops           []*pb.RequestOp
+		expectedError error
+	}{
+		{
+			// overlapping writes are allowed
+			ops: []*pb.RequestOp{del("a/", "a0"), put("a/1", false), put("a/1", false), txn(put("a/2", false))},
+		},
+		{
+			ops: []*pb.RequestOp{put("b", true), del("a/", "a0")},
+		},
+		{
+			ops:           []*pb.RequestOp{del("a/", "a0"), put("a/1", true)},
+			expectedError: rpctypes.ErrGRPCDuplicateKey,
+		},
+		{
+			ops:           []*pb.RequestOp{del("a/1", ""), txn(put("a/1", true))},
+			expectedError: rpctypes.ErrGRPCDuplicateKey,
+		},
+	}
+	for i, tt := range tests {
+		if err := checkReadYourWrites(tt.ops); getError(err) != getError(tt.expectedError) {
+			t.Errorf("#%d: expected %q, but got %q", i, getError(tt.expectedError), getError(err))
+		}
+	}
+}
+
 func getError(err error) string {
 	if err == nil {
 		return ""
@@ -66,3 +140,86 @@ func getError(err error) string {
 
 	return err.Error()
 }
+
+func TestSplitTxnResponse(t *testing.T) {
+	kvs := make([]*mvccpb.KeyValue, 10)
+	for i := range kvs {
+		kvs[i] = &mvccpb.KeyValue{Key: []byte(fmt.Sprintf("foo%d", i)), Value: make([]byte, 100)}
+	}
+	resp := &pb.TxnResponse{
+		Header:    &pb.ResponseHeader{Revision: 5},
+		Succeeded: true,
+		Responses: []*pb.ResponseOp{
+			{Response: &pb.ResponseOp_ResponsePut{ResponsePut: &pb.PutResponse{}}},
+			{Response: &pb.ResponseOp_ResponseRange{ResponseRange: &pb.RangeResponse{Kvs: kvs, Count: 10}}},
+		},
+	}
+
+	chunks := SplitTxnResponse(resp, 300)
+	if len(chunks) < 3 {
+		t.Fatalf("expected range response to be split, got %d chunks", len(chunks))
+	}
+	if chunks[0].Index != 0 || chunks[0].Response.GetResponsePut() == nil {
+		t.Fatalf("expected first chunk to hold the put response, got %+v", chunks[0])
+	}
+
+	var got []*mvccpb.KeyValue
+	for _, c := range chunks[1:] {
+		if c.Index != 1 {
+			t.Fatalf("index = %d, want 1", c.Index)
+		}
+		if !c.Succeeded || c.Header.Revision != 5 {
+			t.Fatalf("unexpected chunk header %+v, succeeded %v", c.Header, c.Succeeded)
+		}
+		rr := c.Response.GetResponseRange()
+		if rr.Count != 10 {
+			t.Fatalf("count = %d, want 10", rr.Count)
+		}
+		if len(rr.Kvs) > 1 && rr.Size() >= 300 {
+			t.Fatalf("chunk size %d exceeds limit", rr.Size())
+		}
+		got = append(got, rr.Kvs...)
+	}
+	if !reflect.DeepEqual(got, kvs) {
+		t.Fatalf("merged kvs = %v, want %v", got, kvs)
+	}
+
+	empty := SplitTxnResponse(&pb.TxnResponse{Header: &pb.ResponseHeader{}}, 300)
+	if len(empty) != 1 || empty[0].Response != nil {
+		t.Fatalf("expected a single empty chunk, got %v", empty)
+	}
+}
+
+func TestSplitRangeStream(t *testing.T) {
+	kvs := make([]*mvccpb.KeyValue, 10)
+	for i := range kvs {
+		kvs[i] = &mvccpb.KeyValue{Key: []byte(fmt.Sprintf("foo%d", i)), Value: make([]byte, 100)}
+	}
+	hdr := &pb.ResponseHeader{Revision: 5}
+
+	chunks := splitRangeStream(hdr, 20, kvs, true, 300)
+	if len(chunks) < 3 {
+		t.Fatalf("expected kvs to be split, got %d chunks", len(chunks))
+	}
+	var got []*mvccpb.KeyValue
+	for i, c := range chunks {
+		if c.Header.Revision != 5 || c.Count != 20 {
+			t.Fatalf("unexpected chunk header %+v, count %d", c.Header, c.Count)
+		}
+		if c.More != (i == len(chunks)-1) {
+			t.Fatalf("chunk %d more = %v", i, c.More)
+		}
+		if len(c.Kvs) > 1 && c.Size() >= 300 {
+			t.Fatalf("chunk size %d exceeds limit", c.Size())
+		}
+		got = append(got, c.Kvs...)
+	}
+	if !reflect.DeepEqual(got, kvs) {
+		t.Fatalf("merged kvs = %v, want %v", got, kvs)
+	}
+
+	empty := splitRangeStream(hdr, 0, nil, false, 300)
+	if len(empty) != 1 || len(empty[0].Kvs) != 0 || empty[0].Header.Revision != 5 {
+		t.Fatalf("expected a single empty chunk, got %v", empty)
+	}
+}
@@ -17,6 +17,7 @@ package v3rpc
 import (
 	"context"
 	"io"
+	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
@@ -26,14 +27,30 @@ import (
 	"go.uber.org/zap"
 )
 
+// minLeaseCheckpointInterval is the shortest checkpoint interval a lease can
+// be granted with, unless the lease checkpoint interval of the server is shorter.
+const minLeaseCheckpointInterval = 5 * time.Second
+
 type LeaseServer struct {
 	lg  *zap.Logger
 	hdr header
 	le  etcdserver.Lessor
+	// minCheckpointInterval is the shortest checkpoint interval of the leases,
+	// in seconds.
+	minCheckpointInterval int64
 }
 
 func NewLeaseServer(s *etcdserver.EtcdServer) pb.LeaseServer {
-	srv := &LeaseServer{lg: s.Cfg.Logger, le: s, hdr: newHeader(s)}
+	minCheckpointInterval := minLeaseCheckpointInterval
+	if i := s.Cfg.LeaseCheckpointInterval; i > 0 && i < minCheckpointInterval {
+		minCheckpointInterval = i
+	}
+	srv := &LeaseServer{
+		lg:                    s.Cfg.Logger,
+		le:                    s,
+		hdr:                   newHeader(s),
+		minCheckpointInterval: int64((minCheckpointInterval + time.Second - 1) / time.Second),
+	}
 	if srv.lg == nil {
 		srv.lg = zap.NewNop()
 	}
@@ -41,6 +58,9 @@ func NewLeaseServer(s *etcdserver.EtcdServer) pb.LeaseServer {
 }
 
 func (ls *LeaseServer) LeaseGrant(ctx context.Context, cr *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
+	if err := ls.checkLeaseGrantRequest(cr); err != nil {
+		return nil, err
+	}
 	resp, err := ls.le.LeaseGrant(ctx, cr)
 
 	if err != nil {
@@ -50,6 +70,15 @@ func (ls *LeaseServer) LeaseGrant(ctx context.Context, cr *pb.LeaseGrantRequest)
 	return resp, nil
 }
 
+// checkLeaseGrantRequest rejects the checkpoint intervals of leases short
+// enough to flood raft with their checkpoints.
+func (ls *LeaseServer) checkLeaseGrantRequest(r *pb.LeaseGrantRequest) error {
+	if r.CheckpointInterval < 0 || (r.CheckpointInterval > 0 && r.CheckpointInterval < ls.minCheckpointInterval) {
+		return rpctypes.ErrGRPCLeaseCheckpointIntervalTooSmall
+	}
+	return nil
+}
+
 func (ls *LeaseServer) LeaseRevoke(ctx context.Context, rr *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse, error) {
 	resp, err := ls.le.LeaseRevoke(ctx, rr)
 	if err != nil {
@@ -90,6 +119,15 @@ func (ls *LeaseServer) LeaseLeases(ctx context.Context, rr *pb.LeaseLeasesReques
 	return resp, nil
 }
 
+func (ls *LeaseServer) LeaseTop(ctx context.Context, rr *pb.LeaseTopRequest) (*pb.LeaseTopResponse, error) {
+	resp, err := ls.le.LeaseTop(ctx, rr)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	ls.hdr.fill(resp.Header)
+	return resp, nil
+}
+
 func (ls *LeaseServer) LeaseKeepAlive(stream pb.Lease_LeaseKeepAliveServer) (err error) {
 	errc := make(chan error, 1)
 	go func() {
@@ -17,13 +17,18 @@ package v3rpc
 import (
 	"context"
 	"crypto/sha256"
+	"fmt"
+	"hash"
+	"hash/crc32"
 	"io"
 	"time"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/dustin/go-humanize"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
 	"go.etcd.io/etcd/raft/v3"
 	"go.etcd.io/etcd/server/v3/auth"
 	"go.etcd.io/etcd/server/v3/etcdserver"
@@ -56,6 +61,27 @@ type Downgrader interface {
 	Downgrade(ctx context.Context, dr *pb.DowngradeRequest) (*pb.DowngradeResponse, error)
 }
 
+type Purger interface {
+	Purge(ctx context.Context, r *pb.PurgeRequest) (*pb.PurgeResponse, error)
+}
+
+type SnapshotSettingsUpdater interface {
+	SnapshotSettings(ctx context.Context, r *pb.SnapshotSettingsRequest) (*pb.SnapshotSettingsResponse, error)
+}
+
+type RevisionPinner interface {
+	PinRevision(ctx context.Context, r *pb.PinRevisionRequest) (*pb.PinRevisionResponse, error)
+	UnpinRevision(ctx context.Context, r *pb.UnpinRevisionRequest) (*pb.UnpinRevisionResponse, error)
+}
+
+type RateLimiter interface {
+	RateLimits(ctx context.Context, r *pb.RateLimitsRequest) (*pb.RateLimitsResponse, error)
+}
+
+type ConfigGetter interface {
+	EffectiveConfig(ctx context.Context, r *pb.EffectiveConfigRequest) (*pb.EffectiveConfigResponse, error)
+}
+
 type LeaderTransferrer interface {
 	MoveLeader(ctx context.Context, lead, target uint64) error
 }
@@ -67,23 +93,38 @@ type AuthGetter interface {
 
 type ClusterStatusGetter interface {
 	IsLearner() bool
+	StandbyRevision() int64
+	WALVersion() *semver.Version
 }
 
 type maintenanceServer struct {
 	lg     *zap.Logger
 	rg     apply.RaftStatusGetter
 	hasher mvcc.HashStorage
+	kg     KVGetter
 	bg     BackendGetter
 	a      Alarmer
 	lt     LeaderTransferrer
 	hdr    header
 	cs     ClusterStatusGetter
 	d      Downgrader
+	p      Purger
+	ss     SnapshotSettingsUpdater
+	rp     RevisionPinner
+	rl     RateLimiter
+	cg     ConfigGetter
 	vs     serverversion.Server
+
+	// slowWatchersAlert and pendingEventsAlert are the watch alert thresholds
+	// of the status; 0 disables an alert.
+	slowWatchersAlert  int
+	pendingEventsAlert int
 }
 
 func NewMaintenanceServer(s *etcdserver.EtcdServer) pb.MaintenanceServer {
-	srv := &maintenanceServer{lg: s.Cfg.Logger, rg: s, hasher: s.KV().HashStorage(), bg: s, a: s, lt: s, hdr: newHeader(s), cs: s, d: s, vs: etcdserver.NewServerVersionAdapter(s)}
+	srv := &maintenanceServer{lg: s.Cfg.Logger, rg: s, hasher: s.KV().HashStorage(), kg: s, bg: s, a: s, lt: s, hdr: newHeader(s), cs: s, d: s, p: s, ss: s, rp: s, rl: s, cg: s, vs: etcdserver.NewServerVersionAdapter(s)}
+	srv.slowWatchersAlert = s.Cfg.WatchSlowWatchersAlertThreshold
+	srv.pendingEventsAlert = s.Cfg.WatchPendingEventsAlertThreshold
 	if srv.lg == nil {
 		srv.lg = zap.NewNop()
 	}
@@ -91,8 +132,13 @@ func NewMaintenanceServer(s *etcdserver.EtcdServer) pb.MaintenanceServer {
 }
 
 func (ms *maintenanceServer) Defragment(ctx context.Context, sr *pb.DefragmentRequest) (*pb.DefragmentResponse, error) {
-	ms.lg.Info("starting defragment")
-	err := ms.bg.Backend().Defrag()
+	ms.lg.Info("starting defragment", zap.Bool("online", sr.Online))
+	var err error
+	if sr.Online {
+		err = ms.bg.Backend().DefragOnline()
+	} else {
+		err = ms.bg.Backend().Defrag()
+	}
 	if err != nil {
 		ms.lg.Warn("failed to defragment", zap.Error(err))
 		return nil, err
@@ -203,7 +249,19 @@ func (ms *maintenanceServer) Hash(ctx context.Context, r *pb.HashRequest) (*pb.H
 }
 
 func (ms *maintenanceServer) HashKV(ctx context.Context, r *pb.HashKVRequest) (*pb.HashKVResponse, error) {
-	h, rev, err := ms.hasher.HashByRev(r.Revision)
+	var (
+		h   mvcc.KeyValueHash
+		rev int64
+		err error
+	)
+	switch {
+	case len(r.Key) != 0:
+		h, rev, err = ms.hasher.HashRangeByRev(r.Key, r.RangeEnd, r.Revision)
+	case len(r.RangeEnd) != 0:
+		return nil, rpctypes.ErrGRPCEmptyKey
+	default:
+		h, rev, err = ms.hasher.HashByRev(r.Revision)
+	}
 	if err != nil {
 		return nil, togRPCError(err)
 	}
@@ -213,6 +271,79 @@ func (ms *maintenanceServer) HashKV(ctx context.Context, r *pb.HashKVRequest) (*
 	return resp, nil
 }
 
+// defaultHashKVChunkSize is the number of keys hashed by the chunks of a
+// HashKVStream request not giving a chunk size.
+const defaultHashKVChunkSize = 1000
+
+func (ms *maintenanceServer) HashKVStream(r *pb.HashKVStreamRequest, stream pb.Maintenance_HashKVStreamServer) error {
+	ctx := stream.Context()
+	chunkSize := r.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultHashKVChunkSize
+	}
+	end := r.RangeEnd
+	switch {
+	case len(end) == 0:
+		// a single key is hashed by a single chunk
+		end = append(append([]byte(nil), r.Key...), 0)
+	case len(end) == 1 && end[0] == 0:
+		end = []byte{}
+	}
+
+	var hdr *pb.ResponseHeader
+	rev, key := r.Revision, r.Key
+	rangeHash := newKeyValuesHash()
+	for {
+		txn := ms.kg.KV().Read(mvcc.ConcurrentReadTxMode, traceutil.TODO())
+		if rev <= 0 {
+			rev = txn.Rev()
+		}
+		res, err := txn.Range(ctx, key, end, mvcc.RangeOptions{Rev: rev, Limit: chunkSize})
+		compactRev := txn.FirstRev()
+		txn.End()
+		if err != nil {
+			return togRPCError(err)
+		}
+		if hdr == nil {
+			hdr = &pb.ResponseHeader{Revision: rev}
+			ms.hdr.fill(hdr)
+		}
+
+		chunk := &pb.HashKVStreamResponse{Header: hdr, Key: key, RangeEnd: r.RangeEnd, Count: int64(len(res.KVs)), CompactRevision: compactRev}
+		if len(r.RangeEnd) == 0 {
+			chunk.RangeEnd = end
+		}
+		more := res.Count > len(res.KVs)
+		if more {
+			lastKey := res.KVs[len(res.KVs)-1].Key
+			key = append(append(make([]byte, 0, len(lastKey)+1), lastKey...), 0)
+			chunk.RangeEnd = key
+		}
+		chunkHash := newKeyValuesHash()
+		for i := range res.KVs {
+			b, err := res.KVs[i].Marshal()
+			if err != nil {
+				return togRPCError(err)
+			}
+			chunkHash.Write(b)
+			rangeHash.Write(b)
+		}
+		chunk.Hash, chunk.RangeHash = chunkHash.Sum32(), rangeHash.Sum32()
+		if err = stream.Send(chunk); err != nil {
+			return togRPCError(err)
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// newKeyValuesHash returns the hash of the marshaled key-values of a range,
+// written in key order.
+func newKeyValuesHash() hash.Hash32 {
+	return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+}
+
 func (ms *maintenanceServer) Alarm(ctx context.Context, ar *pb.AlarmRequest) (*pb.AlarmResponse, error) {
 	resp, err := ms.a.Alarm(ctx, ar)
 	if err != nil {
@@ -238,19 +369,43 @@ func (ms *maintenanceServer) Status(ctx context.Context, ar *pb.StatusRequest) (
 		DbSize:           ms.bg.Backend().Size(),
 		DbSizeInUse:      ms.bg.Backend().SizeInUse(),
 		IsLearner:        ms.cs.IsLearner(),
+		StandbyRevision:  ms.cs.StandbyRevision(),
 	}
+	ws := ms.kg.KV().WatchStats()
+	resp.WatchStreams = ws.Streams
+	resp.Watchers = ws.Watchers
+	resp.SlowWatchers = ws.SlowWatchers
+	resp.WatchPendingEvents = ws.PendingEvents
+	resp.WatchEvents = ws.Events
 	if storageVersion := ms.vs.GetStorageVersion(); storageVersion != nil {
 		resp.StorageVersion = storageVersion.String()
 	}
+	if walVersion := ms.cs.WALVersion(); walVersion != nil {
+		resp.WalVersion = walVersion.String()
+	}
 	if resp.Leader == raft.None {
 		resp.Errors = append(resp.Errors, errors.ErrNoLeader.Error())
 	}
 	for _, a := range ms.a.Alarms() {
 		resp.Errors = append(resp.Errors, a.String())
 	}
+	resp.Errors = append(resp.Errors, watchAlerts(ws, ms.slowWatchersAlert, ms.pendingEventsAlert)...)
 	return resp, nil
 }
 
+// watchAlerts returns the status errors of the watch stats above the
+// thresholds. A threshold of 0 disables its alert.
+func watchAlerts(ws mvcc.WatchStats, slowWatchers, pendingEvents int) []string {
+	var errs []string
+	if slowWatchers > 0 && ws.SlowWatchers > int64(slowWatchers) {
+		errs = append(errs, fmt.Sprintf("watch: %d slow watchers exceed alert threshold %d", ws.SlowWatchers, slowWatchers))
+	}
+	if pendingEvents > 0 && ws.PendingEvents > int64(pendingEvents) {
+		errs = append(errs, fmt.Sprintf("watch: %d pending events exceed alert threshold %d", ws.PendingEvents, pendingEvents))
+	}
+	return errs
+}
+
 func (ms *maintenanceServer) MoveLeader(ctx context.Context, tr *pb.MoveLeaderRequest) (*pb.MoveLeaderResponse, error) {
 	if ms.rg.MemberId() != ms.rg.Leader() {
 		return nil, rpctypes.ErrGRPCNotLeader
@@ -272,6 +427,78 @@ func (ms *maintenanceServer) Downgrade(ctx context.Context, r *pb.DowngradeReque
 	return resp, nil
 }
 
+func (ms *maintenanceServer) Purge(ctx context.Context, r *pb.PurgeRequest) (*pb.PurgeResponse, error) {
+	resp, err := ms.p.Purge(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	if resp.Header == nil {
+		resp.Header = &pb.ResponseHeader{}
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) SnapshotSettings(ctx context.Context, r *pb.SnapshotSettingsRequest) (*pb.SnapshotSettingsResponse, error) {
+	resp, err := ms.ss.SnapshotSettings(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	if resp.Header == nil {
+		resp.Header = &pb.ResponseHeader{}
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) PinRevision(ctx context.Context, r *pb.PinRevisionRequest) (*pb.PinRevisionResponse, error) {
+	resp, err := ms.rp.PinRevision(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	if resp.Header == nil {
+		resp.Header = &pb.ResponseHeader{}
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) UnpinRevision(ctx context.Context, r *pb.UnpinRevisionRequest) (*pb.UnpinRevisionResponse, error) {
+	resp, err := ms.rp.UnpinRevision(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	if resp.Header == nil {
+		resp.Header = &pb.ResponseHeader{}
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) RateLimits(ctx context.Context, r *pb.RateLimitsRequest) (*pb.RateLimitsResponse, error) {
+	resp, err := ms.rl.RateLimits(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	if resp.Header == nil {
+		resp.Header = &pb.ResponseHeader{}
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
+func (ms *maintenanceServer) EffectiveConfig(ctx context.Context, r *pb.EffectiveConfigRequest) (*pb.EffectiveConfigResponse, error) {
+	resp, err := ms.cg.EffectiveConfig(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	if resp.Header == nil {
+		resp.Header = &pb.ResponseHeader{}
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
 type authMaintenanceServer struct {
 	*maintenanceServer
 	ag AuthGetter
@@ -317,6 +544,13 @@ func (ams *authMaintenanceServer) HashKV(ctx context.Context, r *pb.HashKVReques
 	return ams.maintenanceServer.HashKV(ctx, r)
 }
 
+func (ams *authMaintenanceServer) HashKVStream(r *pb.HashKVStreamRequest, srv pb.Maintenance_HashKVStreamServer) error {
+	if err := ams.isAuthenticated(srv.Context()); err != nil {
+		return err
+	}
+	return ams.maintenanceServer.HashKVStream(r, srv)
+}
+
 func (ams *authMaintenanceServer) Status(ctx context.Context, ar *pb.StatusRequest) (*pb.StatusResponse, error) {
 	return ams.maintenanceServer.Status(ctx, ar)
 }
@@ -328,3 +562,45 @@ func (ams *authMaintenanceServer) MoveLeader(ctx context.Context, tr *pb.MoveLea
 func (ams *authMaintenanceServer) Downgrade(ctx context.Context, r *pb.DowngradeRequest) (*pb.DowngradeResponse, error) {
 	return ams.maintenanceServer.Downgrade(ctx, r)
 }
+
+func (ams *authMaintenanceServer) Purge(ctx context.Context, r *pb.PurgeRequest) (*pb.PurgeResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.Purge(ctx, r)
+}
+
+func (ams *authMaintenanceServer) SnapshotSettings(ctx context.Context, r *pb.SnapshotSettingsRequest) (*pb.SnapshotSettingsResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.SnapshotSettings(ctx, r)
+}
+
+func (ams *authMaintenanceServer) PinRevision(ctx context.Context, r *pb.PinRevisionRequest) (*pb.PinRevisionResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.PinRevision(ctx, r)
+}
+
+func (ams *authMaintenanceServer) UnpinRevision(ctx context.Context, r *pb.UnpinRevisionRequest) (*pb.UnpinRevisionResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.UnpinRevision(ctx, r)
+}
+
+func (ams *authMaintenanceServer) RateLimits(ctx context.Context, r *pb.RateLimitsRequest) (*pb.RateLimitsResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.RateLimits(ctx, r)
+}
+
+func (ams *authMaintenanceServer) EffectiveConfig(ctx context.Context, r *pb.EffectiveConfigRequest) (*pb.EffectiveConfigResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.EffectiveConfig(ctx, r)
+}
@@ -0,0 +1,44 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"reflect"
+	"testing"
+
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+func TestWatchAlerts(t *testing.T) {
+	ws := mvcc.WatchStats{SlowWatchers: 10, PendingEvents: 100}
+	tests := []struct {
+		slowWatchers, pendingEvents int
+		want                        []string
+	}{
+		{0, 0, nil},
+		{10, 100, nil},
+		{9, 0, []string{"watch: 10 slow watchers exceed alert threshold 9"}},
+		{0, 99, []string{"watch: 100 pending events exceed alert threshold 99"}},
+		{9, 99, []string{
+			"watch: 10 slow watchers exceed alert threshold 9",
+			"watch: 100 pending events exceed alert threshold 99",
+		}},
+	}
+	for i, tt := range tests {
+		if got := watchAlerts(ws, tt.slowWatchers, tt.pendingEvents); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("#%d: watchAlerts = %v, want %v", i, got, tt.want)
+		}
+	}
+}
@@ -16,6 +16,7 @@ package v3rpc
 
 import (
 	"context"
+	"reflect"
 	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
@@ -55,6 +56,9 @@ func (cs *ClusterServer) MemberAdd(ctx context.Context, r *pb.MemberAddRequest)
 	if merr != nil {
 		return nil, togRPCError(merr)
 	}
+	// an addition coalesced with a concurrent one of the same peer URLs
+	// adds the member of the latter
+	m = addedMember(membs, m)
 
 	return &pb.MemberAddResponse{
 		Header: cs.header(),
@@ -67,6 +71,21 @@ func (cs *ClusterServer) MemberAdd(ctx context.Context, r *pb.MemberAddRequest)
 	}, nil
 }
 
+// addedMember returns the member of membs added by the addition of m.
+func addedMember(membs []*membership.Member, m *membership.Member) *membership.Member {
+	for _, memb := range membs {
+		if memb.ID == m.ID {
+			return memb
+		}
+	}
+	for _, memb := range membs {
+		if reflect.DeepEqual(memb.PeerURLs, m.PeerURLs) {
+			return memb
+		}
+	}
+	return m
+}
+
 func (cs *ClusterServer) MemberRemove(ctx context.Context, r *pb.MemberRemoveRequest) (*pb.MemberRemoveResponse, error) {
 	membs, err := cs.server.RemoveMember(ctx, r.ID)
 	if err != nil {
@@ -106,7 +125,7 @@ func (cs *ClusterServer) MemberPromote(ctx context.Context, r *pb.MemberPromoteR
 }
 
 func (cs *ClusterServer) header() *pb.ResponseHeader {
-	return &pb.ResponseHeader{ClusterId: uint64(cs.cluster.ID()), MemberId: uint64(cs.server.MemberId()), RaftTerm: cs.server.Term()}
+	return &pb.ResponseHeader{ClusterId: uint64(cs.cluster.ID()), MemberId: uint64(cs.server.MemberId()), RaftTerm: cs.server.Term(), ClusterEpoch: cs.server.ClusterEpoch()}
 }
 
 func membersToProtoMembers(membs []*membership.Member) []*pb.Member {
@@ -0,0 +1,44 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"testing"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+)
+
+func TestAddedMember(t *testing.T) {
+	member := func(id uint64, urls ...string) *membership.Member {
+		return &membership.Member{ID: types.ID(id), RaftAttributes: membership.RaftAttributes{PeerURLs: urls}}
+	}
+	membs := []*membership.Member{member(1, "http://a"), member(2, "http://b", "http://c")}
+
+	tests := []struct {
+		m   *membership.Member
+		wid uint64
+	}{
+		{member(2, "http://b", "http://c"), 2},
+		// coalesced with the addition of member 2
+		{member(3, "http://b", "http://c"), 2},
+		{member(4, "http://d"), 4},
+	}
+	for i, tt := range tests {
+		if m := addedMember(membs, tt.m); uint64(m.ID) != tt.wid {
+			t.Errorf("#%d: added member = %s, want %x", i, m.ID, tt.wid)
+		}
+	}
+}
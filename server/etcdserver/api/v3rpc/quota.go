@@ -27,16 +27,22 @@ import (
 type quotaKVServer struct {
 	pb.KVServer
 	qa quotaAlarmer
+	// ka is the alarmer of the key quota
+	ka quotaAlarmer
 }
 
 type quotaAlarmer struct {
 	q  storage.Quota
 	a  Alarmer
 	id types.ID
+
+	// alarm is raised and err returned when the quota is exhausted
+	alarm pb.AlarmType
+	err   error
 }
 
 // check whether request satisfies the quota. If there is not enough space,
-// ignore request and raise the free space alarm.
+// ignore request and raise the alarm of the quota.
 func (qa *quotaAlarmer) check(ctx context.Context, r interface{}) error {
 	if qa.q.Available(r) {
 		return nil
@@ -44,33 +50,48 @@ func (qa *quotaAlarmer) check(ctx context.Context, r interface{}) error {
 	req := &pb.AlarmRequest{
 		MemberID: uint64(qa.id),
 		Action:   pb.AlarmRequest_ACTIVATE,
-		Alarm:    pb.AlarmType_NOSPACE,
+		Alarm:    qa.alarm,
 	}
 	qa.a.Alarm(ctx, req)
-	return rpctypes.ErrGRPCNoSpace
+	return qa.err
 }
 
 func NewQuotaKVServer(s *etcdserver.EtcdServer) pb.KVServer {
 	return &quotaKVServer{
 		NewKVServer(s),
-		quotaAlarmer{newBackendQuota(s, "kv"), s, s.MemberId()},
+		newBackendQuotaAlarmer(s, "kv"),
+		newKeyQuotaAlarmer(s, "kv"),
 	}
 }
 
-func (s *quotaKVServer) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse, error) {
+func (s *quotaKVServer) check(ctx context.Context, r interface{}) error {
 	if err := s.qa.check(ctx, r); err != nil {
+		return err
+	}
+	return s.ka.check(ctx, r)
+}
+
+func (s *quotaKVServer) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse, error) {
+	if err := s.check(ctx, r); err != nil {
 		return nil, err
 	}
 	return s.KVServer.Put(ctx, r)
 }
 
 func (s *quotaKVServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, error) {
-	if err := s.qa.check(ctx, r); err != nil {
+	if err := s.check(ctx, r); err != nil {
 		return nil, err
 	}
 	return s.KVServer.Txn(ctx, r)
 }
 
+func (s *quotaKVServer) TxnStream(r *pb.TxnRequest, stream pb.KV_TxnStreamServer) error {
+	if err := s.check(stream.Context(), r); err != nil {
+		return err
+	}
+	return s.KVServer.TxnStream(r, stream)
+}
+
 type quotaLeaseServer struct {
 	pb.LeaseServer
 	qa quotaAlarmer
@@ -86,10 +107,16 @@ func (s *quotaLeaseServer) LeaseGrant(ctx context.Context, cr *pb.LeaseGrantRequ
 func NewQuotaLeaseServer(s *etcdserver.EtcdServer) pb.LeaseServer {
 	return &quotaLeaseServer{
 		NewLeaseServer(s),
-		quotaAlarmer{newBackendQuota(s, "lease"), s, s.MemberId()},
+		newBackendQuotaAlarmer(s, "lease"),
 	}
 }
 
-func newBackendQuota(s *etcdserver.EtcdServer, name string) storage.Quota {
-	return storage.NewBackendQuota(s.Logger(), s.Cfg.QuotaBackendBytes, s.Backend(), name)
+func newBackendQuotaAlarmer(s *etcdserver.EtcdServer, name string) quotaAlarmer {
+	q := storage.NewBackendQuota(s.Logger(), s.Cfg.QuotaBackendBytes, s.Backend(), name)
+	return quotaAlarmer{q, s, s.MemberId(), pb.AlarmType_NOSPACE, rpctypes.ErrGRPCNoSpace}
+}
+
+func newKeyQuotaAlarmer(s *etcdserver.EtcdServer, name string) quotaAlarmer {
+	q := storage.NewKeyQuota(s.Logger(), s.Cfg.MaxKeys, s.KV(), name)
+	return quotaAlarmer{q, s, s.MemberId(), pb.AlarmType_TOOMANYKEYS, rpctypes.ErrGRPCTooManyKeys}
 }
@@ -22,7 +22,9 @@ import (
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/server/v3/auth"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3compactor"
 	"go.etcd.io/etcd/server/v3/etcdserver/errors"
+	"go.etcd.io/etcd/server/v3/etcdserver/txn"
 	"go.etcd.io/etcd/server/v3/etcdserver/version"
 	"go.etcd.io/etcd/server/v3/lease"
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
@@ -45,7 +47,10 @@ var toGRPCErrorMap = map[error]error{
 	mvcc.ErrFutureRev:         rpctypes.ErrGRPCFutureRev,
 	errors.ErrRequestTooLarge: rpctypes.ErrGRPCRequestTooLarge,
 	errors.ErrNoSpace:         rpctypes.ErrGRPCNoSpace,
+	errors.ErrTooManyKeys:     rpctypes.ErrGRPCTooManyKeys,
 	errors.ErrTooManyRequests: rpctypes.ErrTooManyRequests,
+	errors.ErrOverloaded:      rpctypes.ErrGRPCOverloaded,
+	errors.ErrRateLimited:     rpctypes.ErrGRPCRateLimited,
 
 	errors.ErrNoLeader:                   rpctypes.ErrGRPCNoLeader,
 	errors.ErrNotLeader:                  rpctypes.ErrGRPCNotLeader,
@@ -58,7 +63,10 @@ var toGRPCErrorMap = map[error]error{
 	errors.ErrUnhealthy:                  rpctypes.ErrGRPCUnhealthy,
 	errors.ErrKeyNotFound:                rpctypes.ErrGRPCKeyNotFound,
 	errors.ErrCorrupt:                    rpctypes.ErrGRPCCorrupt,
+	errors.ErrStandby:                    rpctypes.ErrGRPCStandby,
 	errors.ErrBadLeaderTransferee:        rpctypes.ErrGRPCBadLeaderTransferee,
+	errors.ErrInvalidSnapshotSettings:    rpctypes.ErrGRPCInvalidSnapshotSettings,
+	errors.ErrInvalidRateLimit:           rpctypes.ErrGRPCInvalidRateLimit,
 
 	errors.ErrClusterVersionUnavailable:      rpctypes.ErrGRPCClusterVersionUnavailable,
 	errors.ErrWrongDowngradeVersionFormat:    rpctypes.ErrGRPCWrongDowngradeVersionFormat,
@@ -70,6 +78,8 @@ var toGRPCErrorMap = map[error]error{
 	lease.ErrLeaseExists:      rpctypes.ErrGRPCLeaseExist,
 	lease.ErrLeaseTTLTooLarge: rpctypes.ErrGRPCLeaseTTLTooLarge,
 
+	v3compactor.ErrPinNotFound: rpctypes.ErrGRPCPinNotFound,
+
 	auth.ErrRootUserNotExist:     rpctypes.ErrGRPCRootUserNotExist,
 	auth.ErrRootRoleNotExist:     rpctypes.ErrGRPCRootRoleNotExist,
 	auth.ErrUserAlreadyExist:     rpctypes.ErrGRPCUserAlreadyExist,
@@ -137,13 +147,30 @@ func isClientCtxErr(ctxErr error, err error) bool {
 	return false
 }
 
-// in v3.4, learner is allowed to serve serializable read and endpoint status
-func isRPCSupportedForLearner(req interface{}) bool {
+// in v3.4, learner is allowed to serve serializable read and endpoint status.
+// With serializableReads, it also serves serializable read-only transactions.
+func isRPCSupportedForLearner(req interface{}, serializableReads bool) bool {
 	switch r := req.(type) {
 	case *pb.StatusRequest:
 		return true
 	case *pb.RangeRequest:
 		return r.Serializable
+	case *pb.TxnRequest:
+		return serializableReads && txn.IsTxnReadonly(r) && txn.IsTxnSerializable(r)
+	default:
+		return false
+	}
+}
+
+// isStreamSupportedForLearner tells whether a learner serves the stream RPC of
+// the given method: snapshots, and watches and range streams with
+// serializableReads.
+func isStreamSupportedForLearner(method string, serializableReads bool) bool {
+	switch method {
+	case snapshotMethod:
+		return true
+	case watchMethod, rangeStreamMethod:
+		return serializableReads
 	default:
 		return false
 	}
@@ -16,6 +16,7 @@ package v3rpc
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"math/rand"
 	"sync"
@@ -32,6 +33,7 @@ import (
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc/peer"
 )
 
 const minWatchProgressInterval = 100 * time.Millisecond
@@ -47,6 +49,10 @@ type watchServer struct {
 	sg        apply.RaftStatusGetter
 	watchable mvcc.WatchableKV
 	ag        AuthGetter
+	limiter   *etcdserver.WatcherLimiter
+	epoch     func() uint64
+	// compactRev is nil unless the headers carry the compaction revision.
+	compactRev func() int64
 }
 
 // NewWatchServer returns a new watch server.
@@ -62,6 +68,10 @@ func NewWatchServer(s *etcdserver.EtcdServer) pb.WatchServer {
 		sg:        s,
 		watchable: s.Watchable(),
 		ag:        s,
+		limiter:   s.WatcherLimiter(),
+		epoch:     s.ClusterEpoch,
+
+		compactRev: compactRevFunc(s),
 	}
 	if srv.lg == nil {
 		srv.lg = zap.NewNop()
@@ -130,20 +140,38 @@ type serverWatchStream struct {
 	sg        apply.RaftStatusGetter
 	watchable mvcc.WatchableKV
 	ag        AuthGetter
+	epoch     func() uint64
+	// compactRev is nil unless the headers carry the compaction revision.
+	compactRev func() int64
+
+	// limiter counts the watchers of the stream against the limits of conn
+	// and of the users who created them.
+	limiter *etcdserver.WatcherLimiter
+	conn    string
 
 	gRPCStream  pb.Watch_WatchServer
 	watchStream mvcc.WatchStream
 	ctrlStream  chan *pb.WatchResponse
+	// seq is the sequence number of the last response sent on gRPCStream,
+	// owned by the send loop.
+	seq uint64
 
-	// mu protects progress, prevKV, fragment
+	// mu protects progress, progressTimers, prevKV, fragment, users, perms
 	mu sync.RWMutex
 	// tracks the watchID that stream might need to send progress to
 	// TODO: combine progress and prevKV into a single struct?
 	progress map[mvcc.WatchID]bool
+	// progressTimers sends the progress of the watch IDs created with their
+	// own progress notify interval, instead of the ticker of sendLoop.
+	progressTimers map[mvcc.WatchID]*time.Timer
 	// record watch IDs that need return previous key-value pair
 	prevKV map[mvcc.WatchID]bool
 	// records fragmented watch IDs
 	fragment map[mvcc.WatchID]bool
+	// records the user of the watch IDs counted by limiter
+	users map[mvcc.WatchID]string
+	// caches the permissions of the users to watch the ranges of the watch IDs
+	perms map[mvcc.WatchID]*watchPermission
 
 	// closec indicates the stream is closed.
 	closec chan struct{}
@@ -152,6 +180,14 @@ type serverWatchStream struct {
 	wg sync.WaitGroup
 }
 
+// connKey identifies the client connection of a stream.
+func connKey(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
 func (ws *watchServer) Watch(stream pb.Watch_WatchServer) (err error) {
 	sws := serverWatchStream{
 		lg: ws.lg,
@@ -164,15 +200,24 @@ func (ws *watchServer) Watch(stream pb.Watch_WatchServer) (err error) {
 		sg:        ws.sg,
 		watchable: ws.watchable,
 		ag:        ws.ag,
+		epoch:     ws.epoch,
+
+		compactRev: ws.compactRev,
+
+		limiter: ws.limiter,
+		conn:    connKey(stream.Context()),
 
 		gRPCStream:  stream,
 		watchStream: ws.watchable.NewWatchStream(),
 		// chan for sending control response like watcher created and canceled.
 		ctrlStream: make(chan *pb.WatchResponse, ctrlStreamBufLen),
 
-		progress: make(map[mvcc.WatchID]bool),
-		prevKV:   make(map[mvcc.WatchID]bool),
-		fragment: make(map[mvcc.WatchID]bool),
+		progress:       make(map[mvcc.WatchID]bool),
+		progressTimers: make(map[mvcc.WatchID]*time.Timer),
+		prevKV:         make(map[mvcc.WatchID]bool),
+		fragment:       make(map[mvcc.WatchID]bool),
+		users:          make(map[mvcc.WatchID]string),
+		perms:          make(map[mvcc.WatchID]*watchPermission),
 
 		closec: make(chan struct{}),
 	}
@@ -226,16 +271,19 @@ func (ws *watchServer) Watch(stream pb.Watch_WatchServer) (err error) {
 	return err
 }
 
-func (sws *serverWatchStream) isWatchPermitted(wcr *pb.WatchCreateRequest) error {
+// isWatchPermitted checks the permission of the stream's user to watch the
+// range of wcr. It returns the auth info of the user, empty if auth is
+// disabled.
+func (sws *serverWatchStream) isWatchPermitted(wcr *pb.WatchCreateRequest) (*auth.AuthInfo, error) {
 	authInfo, err := sws.ag.AuthInfoFromCtx(sws.gRPCStream.Context())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if authInfo == nil {
 		// if auth is enabled, IsRangePermitted() can cause an error
 		authInfo = &auth.AuthInfo{}
 	}
-	return sws.ag.AuthStore().IsRangePermitted(authInfo, wcr.Key, wcr.RangeEnd)
+	return authInfo, sws.ag.AuthStore().IsRangePermitted(authInfo, wcr.Key, wcr.RangeEnd)
 }
 
 func (sws *serverWatchStream) recvLoop() error {
@@ -269,7 +317,8 @@ func (sws *serverWatchStream) recvLoop() error {
 				creq.RangeEnd = []byte{}
 			}
 
-			err := sws.isWatchPermitted(creq)
+			authRev := sws.ag.AuthStore().Revision()
+			ai, err := sws.isWatchPermitted(creq)
 			if err != nil {
 				var cancelReason string
 				switch err {
@@ -302,7 +351,44 @@ func (sws *serverWatchStream) recvLoop() error {
 				}
 			}
 
-			filters := FiltersFromRequest(creq)
+			user := ai.Username
+
+			valueFilters, err := ValueFiltersFromRequest(creq)
+			if err != nil {
+				wr := &pb.WatchResponse{
+					Header:       sws.newResponseHeader(sws.watchStream.Rev()),
+					WatchId:      clientv3.InvalidWatchID,
+					Canceled:     true,
+					Created:      true,
+					CancelReason: err.Error(),
+				}
+
+				select {
+				case sws.ctrlStream <- wr:
+					continue
+				case <-sws.closec:
+					return nil
+				}
+			}
+
+			if err := sws.limiter.Acquire(sws.conn, user); err != nil {
+				wr := &pb.WatchResponse{
+					Header:       sws.newResponseHeader(sws.watchStream.Rev()),
+					WatchId:      clientv3.InvalidWatchID,
+					Canceled:     true,
+					Created:      true,
+					CancelReason: err.Error(),
+				}
+
+				select {
+				case sws.ctrlStream <- wr:
+					continue
+				case <-sws.closec:
+					return nil
+				}
+			}
+
+			filters := append(FiltersFromRequest(creq), valueFilters...)
 
 			wsrev := sws.watchStream.Rev()
 			rev := creq.StartRevision
@@ -312,17 +398,32 @@ func (sws *serverWatchStream) recvLoop() error {
 			id, err := sws.watchStream.Watch(mvcc.WatchID(creq.WatchId), creq.Key, creq.RangeEnd, rev, filters...)
 			if err == nil {
 				sws.mu.Lock()
-				if creq.ProgressNotify {
+				if creq.ProgressNotify || creq.ProgressNotifyInterval > 0 {
 					sws.progress[id] = true
 				}
+				if creq.ProgressNotifyInterval > 0 {
+					sws.startProgressTimer(id, time.Duration(creq.ProgressNotifyInterval)*time.Millisecond)
+				}
 				if creq.PrevKv {
 					sws.prevKV[id] = true
 				}
 				if creq.Fragment {
 					sws.fragment[id] = true
 				}
+				if sws.limiter != nil {
+					sws.users[id] = user
+				}
+				sws.perms[id] = &watchPermission{
+					user:      user,
+					roles:     ai.Roles,
+					key:       creq.Key,
+					rangeEnd:  creq.RangeEnd,
+					authRev:   authRev,
+					permitted: true,
+				}
 				sws.mu.Unlock()
 			} else {
+				sws.limiter.Release(sws.conn, user)
 				id = clientv3.InvalidWatchID
 			}
 
@@ -351,11 +452,7 @@ func (sws *serverWatchStream) recvLoop() error {
 						WatchId:  id,
 						Canceled: true,
 					}
-					sws.mu.Lock()
-					delete(sws.progress, mvcc.WatchID(id))
-					delete(sws.prevKV, mvcc.WatchID(id))
-					delete(sws.fragment, mvcc.WatchID(id))
-					sws.mu.Unlock()
+					sws.forgetWatch(mvcc.WatchID(id))
 				}
 			}
 		case *pb.WatchRequest_ProgressRequest:
@@ -380,6 +477,8 @@ func (sws *serverWatchStream) sendLoop() {
 	ids := make(map[mvcc.WatchID]struct{})
 	// watch responses pending on a watch id creation message
 	pending := make(map[mvcc.WatchID][]*pb.WatchResponse)
+	// watch ids canceled after their users lost the permission to watch
+	revoked := make(map[mvcc.WatchID]struct{})
 
 	interval := GetProgressReportInterval()
 	progressTicker := time.NewTicker(interval)
@@ -388,11 +487,11 @@ func (sws *serverWatchStream) sendLoop() {
 		progressTicker.Stop()
 		// drain the chan to clean up pending events
 		for ws := range sws.watchStream.Chan() {
-			mvcc.ReportEventReceived(len(ws.Events))
+			sws.watchStream.ReportEventsReceived(len(ws.Events))
 		}
 		for _, wrs := range pending {
 			for _, ws := range wrs {
-				mvcc.ReportEventReceived(len(ws.Events))
+				sws.watchStream.ReportEventsReceived(len(ws.Events))
 			}
 		}
 	}()
@@ -404,6 +503,12 @@ func (sws *serverWatchStream) sendLoop() {
 				return
 			}
 
+			if _, rok := revoked[wresp.WatchID]; rok {
+				// drop the events the watcher sent before it was canceled
+				sws.watchStream.ReportEventsReceived(len(wresp.Events))
+				continue
+			}
+
 			// TODO: evs is []mvccpb.Event type
 			// either return []*mvccpb.Event from the mvcc package
 			// or define protocol buffer with []mvccpb.Event.
@@ -439,7 +544,22 @@ func (sws *serverWatchStream) sendLoop() {
 				continue
 			}
 
-			mvcc.ReportEventReceived(len(evs))
+			sws.watchStream.ReportEventsReceived(len(evs))
+
+			if len(evs) > 0 && !sws.isSendPermitted(wresp.WatchID) {
+				delete(ids, wresp.WatchID)
+				revoked[wresp.WatchID] = struct{}{}
+				if err := sws.cancelRevokedWatch(wresp.WatchID); err != nil {
+					if isClientCtxErr(sws.gRPCStream.Context().Err(), err) {
+						sws.lg.Debug("failed to send watch cancel response to gRPC stream", zap.Error(err))
+					} else {
+						sws.lg.Warn("failed to send watch cancel response to gRPC stream", zap.Error(err))
+						streamFailures.WithLabelValues("send", "watch").Inc()
+					}
+					return
+				}
+				continue
+			}
 
 			sws.mu.RLock()
 			fragmented, ok := sws.fragment[wresp.WatchID]
@@ -447,9 +567,9 @@ func (sws *serverWatchStream) sendLoop() {
 
 			var serr error
 			if !fragmented && !ok {
-				serr = sws.gRPCStream.Send(wr)
+				serr = sws.send(wr)
 			} else {
-				serr = sendFragments(wr, sws.maxRequestBytes, sws.gRPCStream.Send)
+				serr = sendFragments(wr, sws.maxRequestBytes, sws.send)
 			}
 
 			if serr != nil {
@@ -474,7 +594,7 @@ func (sws *serverWatchStream) sendLoop() {
 				return
 			}
 
-			if err := sws.gRPCStream.Send(c); err != nil {
+			if err := sws.send(c); err != nil {
 				if isClientCtxErr(sws.gRPCStream.Context().Err(), err) {
 					sws.lg.Debug("failed to send watch control response to gRPC stream", zap.Error(err))
 				} else {
@@ -494,11 +614,13 @@ func (sws *serverWatchStream) sendLoop() {
 				continue
 			}
 			if c.Created {
+				// the client may reuse the id of a revoked watcher
+				delete(revoked, wid)
 				// flush buffered events
 				ids[wid] = struct{}{}
 				for _, v := range pending[wid] {
-					mvcc.ReportEventReceived(len(v.Events))
-					if err := sws.gRPCStream.Send(v); err != nil {
+					sws.watchStream.ReportEventsReceived(len(v.Events))
+					if err := sws.send(v); err != nil {
 						if isClientCtxErr(sws.gRPCStream.Context().Err(), err) {
 							sws.lg.Debug("failed to send pending watch response to gRPC stream", zap.Error(err))
 						} else {
@@ -514,6 +636,9 @@ func (sws *serverWatchStream) sendLoop() {
 		case <-progressTicker.C:
 			sws.mu.Lock()
 			for id, ok := range sws.progress {
+				if _, timed := sws.progressTimers[id]; timed {
+					continue
+				}
 				if ok {
 					sws.watchStream.RequestProgress(id)
 				}
@@ -527,6 +652,126 @@ func (sws *serverWatchStream) sendLoop() {
 	}
 }
 
+// startProgressTimer sends the progress of the watch ID every interval, unless
+// the watcher received events since the last one. It must be called with mu held.
+func (sws *serverWatchStream) startProgressTimer(id mvcc.WatchID, interval time.Duration) {
+	if interval < minWatchProgressInterval {
+		interval = minWatchProgressInterval
+	}
+	var t *time.Timer
+	t = time.AfterFunc(interval, func() {
+		sws.mu.Lock()
+		defer sws.mu.Unlock()
+		if sws.progressTimers[id] != t {
+			// the watcher was canceled
+			return
+		}
+		select {
+		case <-sws.closec:
+			return
+		default:
+		}
+		if sws.progress[id] {
+			sws.watchStream.RequestProgress(id)
+		}
+		sws.progress[id] = true
+		t.Reset(interval)
+	})
+	sws.progressTimers[id] = t
+}
+
+// stopProgressTimer stops the progress timer of the watch ID, if any. It must be
+// called with mu held.
+func (sws *serverWatchStream) stopProgressTimer(id mvcc.WatchID) {
+	if t, ok := sws.progressTimers[id]; ok {
+		t.Stop()
+		delete(sws.progressTimers, id)
+	}
+}
+
+// forgetWatch drops the state of the canceled watch ID and releases its count
+// in the limiter.
+func (sws *serverWatchStream) forgetWatch(id mvcc.WatchID) {
+	sws.mu.Lock()
+	defer sws.mu.Unlock()
+	delete(sws.progress, id)
+	sws.stopProgressTimer(id)
+	delete(sws.prevKV, id)
+	delete(sws.fragment, id)
+	delete(sws.perms, id)
+	if user, ok := sws.users[id]; ok {
+		sws.limiter.Release(sws.conn, user)
+		delete(sws.users, id)
+	}
+}
+
+// isSendPermitted checks whether the user who created the watch ID may still
+// receive its events. The permission is only evaluated again after the auth
+// store changed, so that sending events costs no range permission check.
+func (sws *serverWatchStream) isSendPermitted(id mvcc.WatchID) bool {
+	sws.mu.Lock()
+	defer sws.mu.Unlock()
+	wp, ok := sws.perms[id]
+	if !ok {
+		return true
+	}
+	return wp.isPermitted(sws.ag.AuthStore())
+}
+
+// cancelRevokedWatch cancels the watch ID whose user lost the permission to
+// watch its range, and tells the client why.
+func (sws *serverWatchStream) cancelRevokedWatch(id mvcc.WatchID) error {
+	if err := sws.watchStream.Cancel(id); err != nil {
+		// the client canceled it meanwhile
+		return nil
+	}
+	sws.forgetWatch(id)
+	return sws.send(&pb.WatchResponse{
+		Header:       sws.newResponseHeader(sws.watchStream.Rev()),
+		WatchId:      int64(id),
+		Canceled:     true,
+		CancelReason: rpctypes.ErrGRPCPermissionDenied.Error(),
+	})
+}
+
+// watchPermission caches the permission of a user to watch a range at an auth
+// revision.
+type watchPermission struct {
+	user string
+	// roles are the roles of a user authenticated by an external identity
+	// provider, as in auth.AuthInfo.
+	roles         []string
+	key, rangeEnd []byte
+
+	authRev   uint64
+	permitted bool
+}
+
+// isPermitted returns the cached permission, or evaluates it again if the auth
+// revision moved on.
+func (wp *watchPermission) isPermitted(as auth.AuthStore) bool {
+	for {
+		rev := as.Revision()
+		if rev == wp.authRev {
+			return wp.permitted
+		}
+		err := as.IsRangePermitted(&auth.AuthInfo{Username: wp.user, Revision: rev, Roles: wp.roles}, wp.key, wp.rangeEnd)
+		if err == auth.ErrAuthOldRevision {
+			// the auth store changed during the evaluation
+			continue
+		}
+		wp.authRev, wp.permitted = rev, err == nil
+		return wp.permitted
+	}
+}
+
+// send numbers the response and sends it on the gRPC stream.
+func (sws *serverWatchStream) send(wr *pb.WatchResponse) error {
+	sws.seq++
+	wr.Seq = sws.seq
+	return sws.gRPCStream.Send(wr)
+}
+
 func IsCreateEvent(e mvccpb.Event) bool {
 	return e.Type == mvccpb.PUT && e.Kv.CreateRevision == e.Kv.ModRevision
 }
@@ -574,15 +819,30 @@ func (sws *serverWatchStream) close() {
 	sws.watchStream.Close()
 	close(sws.closec)
 	sws.wg.Wait()
+
+	sws.mu.Lock()
+	for id := range sws.progressTimers {
+		sws.stopProgressTimer(id)
+	}
+	for id, user := range sws.users {
+		sws.limiter.Release(sws.conn, user)
+		delete(sws.users, id)
+	}
+	sws.mu.Unlock()
 }
 
 func (sws *serverWatchStream) newResponseHeader(rev int64) *pb.ResponseHeader {
-	return &pb.ResponseHeader{
-		ClusterId: uint64(sws.clusterID),
-		MemberId:  uint64(sws.memberID),
-		Revision:  rev,
-		RaftTerm:  sws.sg.Term(),
+	h := &pb.ResponseHeader{
+		ClusterId:    uint64(sws.clusterID),
+		MemberId:     uint64(sws.memberID),
+		Revision:     rev,
+		RaftTerm:     sws.sg.Term(),
+		ClusterEpoch: sws.epoch(),
+	}
+	if sws.compactRev != nil {
+		h.CompactRevision = sws.compactRev()
 	}
+	return h
 }
 
 func filterNoDelete(e mvccpb.Event) bool {
@@ -607,3 +867,30 @@ func FiltersFromRequest(creq *pb.WatchCreateRequest) []mvcc.FilterFunc {
 	}
 	return filters
 }
+
+// ValueFiltersFromRequest returns the "mvcc.FilterFunc" of the value filters
+// of a given watch create request.
+func ValueFiltersFromRequest(creq *pb.WatchCreateRequest) ([]mvcc.FilterFunc, error) {
+	filters := make([]mvcc.FilterFunc, 0, len(creq.ValueFilters))
+	for _, vf := range creq.ValueFilters {
+		var (
+			f   mvcc.FilterFunc
+			err error
+		)
+		switch vf.Match {
+		case pb.WatchValueFilter_PREFIX:
+			f = mvcc.NewValuePrefixFilter(vf.Pattern)
+		case pb.WatchValueFilter_REGEX:
+			f, err = mvcc.NewValueRegexFilter(string(vf.Pattern))
+		case pb.WatchValueFilter_JSON_FIELD:
+			f, err = mvcc.NewValueJSONFieldFilter(vf.JsonField, vf.Pattern)
+		default:
+			err = fmt.Errorf("unknown value filter match type %v", vf.Match)
+		}
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
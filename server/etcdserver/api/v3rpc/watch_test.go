@@ -18,9 +18,17 @@ import (
 	"bytes"
 	"math"
 	"testing"
+	"time"
 
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/crypto/bcrypt"
+
+	"go.etcd.io/etcd/api/v3/authpb"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/auth"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/schema"
 )
 
 func TestSendFragment(t *testing.T) {
@@ -93,3 +101,123 @@ func createResponse(dataSize, events int) (resp *pb.WatchResponse) {
 	}
 	return resp
 }
+
+func TestWatchPermission(t *testing.T) {
+	as := newWatchAuthStore(t)
+	wp := &watchPermission{user: "foo", key: []byte("foo"), authRev: as.Revision(), permitted: true}
+	if !wp.isPermitted(as) {
+		t.Fatal("expected the watch to be permitted")
+	}
+
+	if _, err := as.RoleRevokePermission(&pb.AuthRoleRevokePermissionRequest{Role: "foo", Key: []byte("foo")}); err != nil {
+		t.Fatal(err)
+	}
+	if wp.isPermitted(as) {
+		t.Fatal("expected the watch not to be permitted after the revocation")
+	}
+	if wp.authRev != as.Revision() {
+		t.Fatalf("expected the permission cached at auth revision %d, got %d", as.Revision(), wp.authRev)
+	}
+
+	grantWatchPermission(t, as)
+	if !wp.isPermitted(as) {
+		t.Fatal("expected the watch to be permitted after the grant")
+	}
+}
+
+// TestWatchPermissionExternalRoles ensures the watches of the users of an
+// external identity provider keep the permissions of their roles across the
+// auth changes.
+func TestWatchPermissionExternalRoles(t *testing.T) {
+	as := newWatchAuthStore(t)
+	wp := &watchPermission{user: "oidc:alice", roles: []string{"foo"}, key: []byte("foo"), authRev: as.Revision(), permitted: true}
+
+	if _, err := as.RoleAdd(&pb.AuthRoleAddRequest{Name: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	if !wp.isPermitted(as) {
+		t.Fatal("expected the watch to be permitted after an unrelated auth change")
+	}
+
+	// an external user does not get the permissions of the store user of its name
+	wp = &watchPermission{user: "foo", roles: []string{"bar"}, key: []byte("foo"), authRev: as.Revision(), permitted: true}
+	if _, err := as.RoleAdd(&pb.AuthRoleAddRequest{Name: "baz"}); err != nil {
+		t.Fatal(err)
+	}
+	if wp.isPermitted(as) {
+		t.Fatal("expected the watch not to be permitted with the roles of the external user")
+	}
+}
+
+func BenchmarkWatchPermissionCached(b *testing.B) {
+	as := newWatchAuthStore(b)
+	wp := &watchPermission{user: "foo", key: []byte("foo"), authRev: as.Revision(), permitted: true}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !wp.isPermitted(as) {
+			b.Fatal("expected the watch to be permitted")
+		}
+	}
+}
+
+func BenchmarkWatchPermissionUncached(b *testing.B) {
+	as := newWatchAuthStore(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ai := &auth.AuthInfo{Username: "foo", Revision: as.Revision()}
+		if err := as.IsRangePermitted(ai, []byte("foo"), nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// newWatchAuthStore returns an auth store with auth enabled, where the user
+// "foo" may read the key "foo".
+func newWatchAuthStore(t testing.TB) auth.AuthStore {
+	lg := zaptest.NewLogger(t)
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	t.Cleanup(func() { betesting.Close(t, be) })
+
+	tp, err := auth.NewTokenProvider(lg, "simple", func(uint64) <-chan struct{} {
+		ch := make(chan struct{}, 1)
+		ch <- struct{}{}
+		return ch
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	as := auth.NewAuthStore(lg, schema.NewAuthBackend(lg, be), tp, bcrypt.MinCost)
+	t.Cleanup(func() { as.Close() })
+
+	noPassword := &authpb.UserAddOptions{NoPassword: true}
+	for _, name := range []string{"root", "foo"} {
+		if _, err = as.UserAdd(&pb.AuthUserAddRequest{Name: name, Options: noPassword}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = as.RoleAdd(&pb.AuthRoleAddRequest{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: name, Role: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	grantWatchPermission(t, as)
+	if err = as.AuthEnable(); err != nil {
+		t.Fatal(err)
+	}
+	return as
+}
+
+func grantWatchPermission(t testing.TB, as auth.AuthStore) {
+	_, err := as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{
+		Name: "foo",
+		Perm: &authpb.Permission{PermType: authpb.READ, Key: []byte("foo")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
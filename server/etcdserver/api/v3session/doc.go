@@ -0,0 +1,26 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v3session provides gRPC gateway endpoints to manage the sessions
+// backing v3lock and v3election requests, for clients without gRPC.
+//
+// A session is a lease kept alive by its holder:
+//
+//	POST /v3/session/open      {"TTL": 10}  grants the session lease
+//	POST /v3/session/keepalive {"ID": ...}  refreshes it once
+//	POST /v3/session/close     {"ID": ...}  revokes it, releasing its locks and leaderships
+//
+// The lease ID is then passed as the lease of /v3/lock/lock and
+// /v3/election/campaign requests.
+package v3session
@@ -0,0 +1,122 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3session
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultTTL is the TTL of sessions opened without one, as in clientv3 concurrency.
+const DefaultTTL = 60
+
+var (
+	patternSessionOpen      = runtime.MustPattern(runtime.NewPattern(1, []int{2, 0, 2, 1, 2, 2}, []string{"v3", "session", "open"}, "", runtime.AssumeColonVerbOpt(true)))
+	patternSessionKeepAlive = runtime.MustPattern(runtime.NewPattern(1, []int{2, 0, 2, 1, 2, 2}, []string{"v3", "session", "keepalive"}, "", runtime.AssumeColonVerbOpt(true)))
+	patternSessionClose     = runtime.MustPattern(runtime.NewPattern(1, []int{2, 0, 2, 1, 2, 2}, []string{"v3", "session", "close"}, "", runtime.AssumeColonVerbOpt(true)))
+)
+
+type sessionFunc func(ctx context.Context, lc pb.LeaseClient, dec runtime.Decoder) (proto.Message, error)
+
+// RegisterSessionHandler registers the session endpoints on mux, served by
+// the lease service of conn.
+func RegisterSessionHandler(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+	lc := pb.NewLeaseClient(conn)
+	handle(ctx, mux, patternSessionOpen, lc, open)
+	handle(ctx, mux, patternSessionKeepAlive, lc, keepAlive)
+	handle(ctx, mux, patternSessionClose, lc, closeSession)
+	return nil
+}
+
+func handle(ctx context.Context, mux *runtime.ServeMux, pat runtime.Pattern, lc pb.LeaseClient, f sessionFunc) {
+	mux.Handle("POST", pat, func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+		inboundMarshaler, outboundMarshaler := runtime.MarshalerForRequest(mux, req)
+		rctx, err := runtime.AnnotateContext(ctx, mux, req)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, w, req, err)
+			return
+		}
+		resp, err := f(rctx, lc, inboundMarshaler.NewDecoder(req.Body))
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, w, req, err)
+			return
+		}
+		runtime.ForwardResponseMessage(ctx, mux, outboundMarshaler, w, req, resp, mux.GetForwardResponseOptions()...)
+	})
+}
+
+func decode(dec runtime.Decoder, v interface{}) error {
+	if err := dec.Decode(v); err != nil && err != io.EOF {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return nil
+}
+
+func open(ctx context.Context, lc pb.LeaseClient, dec runtime.Decoder) (proto.Message, error) {
+	var r pb.LeaseGrantRequest
+	if err := decode(dec, &r); err != nil {
+		return nil, err
+	}
+	if r.TTL == 0 {
+		r.TTL = DefaultTTL
+	}
+	return lc.LeaseGrant(ctx, &r)
+}
+
+// keepAlive refreshes the session lease once, so that clients can keep it
+// alive with plain requests instead of holding a keep alive stream open.
+func keepAlive(ctx context.Context, lc pb.LeaseClient, dec runtime.Decoder) (proto.Message, error) {
+	var r pb.LeaseKeepAliveRequest
+	if err := decode(dec, &r); err != nil {
+		return nil, err
+	}
+	stream, err := lc.LeaseKeepAlive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+	if err = stream.Send(&r); err != nil {
+		return nil, err
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if resp.TTL <= 0 {
+		// the session expired, along with its locks and leaderships
+		return nil, rpctypes.ErrGRPCLeaseNotFound
+	}
+	return resp, nil
+}
+
+func closeSession(ctx context.Context, lc pb.LeaseClient, dec runtime.Decoder) (proto.Message, error) {
+	var r pb.LeaseRevokeRequest
+	if err := decode(dec, &r); err != nil {
+		return nil, err
+	}
+	return lc.LeaseRevoke(ctx, &r)
+}
@@ -25,6 +25,7 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/api"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3alarm"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3compactor"
 	"go.etcd.io/etcd/server/v3/etcdserver/cindex"
 	"go.etcd.io/etcd/server/v3/etcdserver/errors"
 	mvcctxn "go.etcd.io/etcd/server/v3/etcdserver/txn"
@@ -57,7 +58,7 @@ type Result struct {
 	Err  error
 	// Physc signals the physical effect of the request has completed in addition
 	// to being logically reflected by the node. Currently, only used for
-	// Compaction requests.
+	// Compaction and Purge requests.
 	Physc <-chan struct{}
 	Trace *traceutil.Trace
 }
@@ -72,9 +73,13 @@ type applierV3 interface {
 
 	Put(ctx context.Context, txn mvcc.TxnWrite, p *pb.PutRequest) (*pb.PutResponse, *traceutil.Trace, error)
 	Range(ctx context.Context, txn mvcc.TxnRead, r *pb.RangeRequest) (*pb.RangeResponse, error)
-	DeleteRange(txn mvcc.TxnWrite, dr *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error)
+	DeleteRange(ctx context.Context, txn mvcc.TxnWrite, dr *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error)
 	Txn(ctx context.Context, rt *pb.TxnRequest) (*pb.TxnResponse, *traceutil.Trace, error)
 	Compaction(compaction *pb.CompactionRequest) (*pb.CompactionResponse, <-chan struct{}, *traceutil.Trace, error)
+	Purge(purge *pb.PurgeRequest) (*pb.PurgeResponse, <-chan struct{}, *traceutil.Trace, error)
+
+	PinRevision(pr *pb.PinRevisionRequest) (*pb.PinRevisionResponse, error)
+	UnpinRevision(ur *pb.UnpinRevisionRequest) (*pb.UnpinRevisionResponse, error)
 
 	LeaseGrant(lc *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error)
 	LeaseRevoke(lc *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse, error)
@@ -118,6 +123,7 @@ type applierV3backend struct {
 	lg              *zap.Logger
 	kv              mvcc.KV
 	alarmStore      *v3alarm.AlarmStore
+	pinStore        *v3compactor.PinStore
 	authStore       auth.AuthStore
 	lessor          lease.Lessor
 	cluster         *membership.RaftCluster
@@ -132,6 +138,7 @@ func newApplierV3Backend(
 	lg *zap.Logger,
 	kv mvcc.KV,
 	alarmStore *v3alarm.AlarmStore,
+	pinStore *v3compactor.PinStore,
 	authStore auth.AuthStore,
 	lessor lease.Lessor,
 	cluster *membership.RaftCluster,
@@ -143,6 +150,7 @@ func newApplierV3Backend(
 		lg:                           lg,
 		kv:                           kv,
 		alarmStore:                   alarmStore,
+		pinStore:                     pinStore,
 		authStore:                    authStore,
 		lessor:                       lessor,
 		cluster:                      cluster,
@@ -160,8 +168,8 @@ func (a *applierV3backend) Put(ctx context.Context, txn mvcc.TxnWrite, p *pb.Put
 	return mvcctxn.Put(ctx, a.lg, a.lessor, a.kv, txn, p)
 }
 
-func (a *applierV3backend) DeleteRange(txn mvcc.TxnWrite, dr *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
-	return mvcctxn.DeleteRange(a.kv, txn, dr)
+func (a *applierV3backend) DeleteRange(ctx context.Context, txn mvcc.TxnWrite, dr *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	return mvcctxn.DeleteRange(ctx, a.kv, txn, dr)
 }
 
 func (a *applierV3backend) Range(ctx context.Context, txn mvcc.TxnRead, r *pb.RangeRequest) (*pb.RangeResponse, error) {
@@ -190,8 +198,62 @@ func (a *applierV3backend) Compaction(compaction *pb.CompactionRequest) (*pb.Com
 	return resp, ch, trace, err
 }
 
+func (a *applierV3backend) Purge(purge *pb.PurgeRequest) (*pb.PurgeResponse, <-chan struct{}, *traceutil.Trace, error) {
+	resp := &pb.PurgeResponse{}
+	resp.Header = &pb.ResponseHeader{}
+	trace := traceutil.New("purge",
+		a.lg,
+		traceutil.Field{Key: "key", Value: string(purge.Key)},
+		traceutil.Field{Key: "range_end", Value: string(purge.RangeEnd)},
+		traceutil.Field{Key: "revision", Value: purge.Revision},
+	)
+
+	purgec, err := a.kv.Purge(trace, purge.Key, purge.RangeEnd, purge.Revision)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	resp.Header.Revision = a.kv.Rev()
+
+	// the number of purged revisions is only known once the purge is done.
+	ch := make(chan struct{})
+	go func() {
+		resp.Purged = <-purgec
+		close(ch)
+	}()
+	return resp, ch, trace, nil
+}
+
+func (a *applierV3backend) PinRevision(pr *pb.PinRevisionRequest) (*pb.PinRevisionResponse, error) {
+	rev := pr.Revision
+	if rev <= 0 {
+		rev = a.kv.Rev()
+	}
+	if rev > a.kv.Rev() {
+		return nil, mvcc.ErrFutureRev
+	}
+	if rev < a.kv.FirstRev() {
+		return nil, mvcc.ErrCompacted
+	}
+	ttl := pr.TTL
+	if ttl < 0 {
+		ttl = 0
+	}
+	a.pinStore.Pin(pr.ID, rev, ttl)
+	return &pb.PinRevisionResponse{Header: &pb.ResponseHeader{Revision: a.kv.Rev()}, ID: pr.ID, Revision: rev, TTL: ttl}, nil
+}
+
+func (a *applierV3backend) UnpinRevision(ur *pb.UnpinRevisionRequest) (*pb.UnpinRevisionResponse, error) {
+	if err := a.pinStore.Unpin(ur.ID); err != nil {
+		return nil, err
+	}
+	return &pb.UnpinRevisionResponse{Header: &pb.ResponseHeader{Revision: a.kv.Rev()}}, nil
+}
+
 func (a *applierV3backend) LeaseGrant(lc *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
-	l, err := a.lessor.Grant(lease.LeaseID(lc.ID), lc.TTL)
+	l, err := a.lessor.GrantWithOptions(lease.LeaseID(lc.ID), lc.TTL, lease.GrantOptions{
+		KeepaliveOnWrite:   lc.KeepaliveOnWrite,
+		CheckpointInterval: lc.CheckpointInterval,
+	})
 	resp := &pb.LeaseGrantResponse{}
 	if err == nil {
 		resp.ID = int64(l.ID)
@@ -232,6 +294,10 @@ func (a *applierV3backend) Alarm(ar *pb.AlarmRequest) (*pb.AlarmResponse, error)
 		}
 		resp.Alarms = append(resp.Alarms, m)
 		alarms.WithLabelValues(types.ID(ar.MemberID).String(), m.Alarm.String()).Inc()
+		if m.Alarm == pb.AlarmType_STANDBY && len(a.alarmStore.Get(pb.AlarmType_STANDBY)) == 1 && a.cluster.Epoch() != 0 {
+			// fence off the clients of the cluster from before it turned standby
+			a.cluster.BumpEpoch()
+		}
 	case pb.AlarmRequest_DEACTIVATE:
 		m := a.alarmStore.Deactivate(types.ID(ar.MemberID), ar.Alarm)
 		if m == nil {
@@ -269,6 +335,68 @@ func (a *applierV3Capped) LeaseGrant(_ *pb.LeaseGrantRequest) (*pb.LeaseGrantRes
 	return nil, errors.ErrNoSpace
 }
 
+type applierV3KeysCapped struct {
+	applierV3
+	q serverstorage.BackendQuota
+}
+
+// newApplierV3KeysCapped creates an applyV3 that will reject Puts and
+// transactions with Puts while the key quota is exhausted.
+func newApplierV3KeysCapped(base applierV3) applierV3 { return &applierV3KeysCapped{applierV3: base} }
+
+func (a *applierV3KeysCapped) Put(_ context.Context, _ mvcc.TxnWrite, _ *pb.PutRequest) (*pb.PutResponse, *traceutil.Trace, error) {
+	return nil, nil, errors.ErrTooManyKeys
+}
+
+func (a *applierV3KeysCapped) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, *traceutil.Trace, error) {
+	if a.q.Cost(r) > 0 {
+		return nil, nil, errors.ErrTooManyKeys
+	}
+	return a.applierV3.Txn(ctx, r)
+}
+
+type applierV3Standby struct {
+	applierV3
+	// mirror is set while applying the writes mirrored from the primary cluster.
+	mirror bool
+}
+
+// newApplierV3Standby creates an applyV3 that will reject the writes and lease
+// grants of clients while the cluster is a standby, but not the writes
+// mirrored from its primary cluster.
+func newApplierV3Standby(base applierV3) applierV3 { return &applierV3Standby{applierV3: base} }
+
+func (a *applierV3Standby) Apply(ctx context.Context, r *pb.InternalRaftRequest, shouldApplyV3 membership.ShouldApplyV3, applyFunc applyFunc) *Result {
+	a.mirror = r.Header != nil && r.Header.Mirror
+	defer func() { a.mirror = false }()
+	return a.applierV3.Apply(ctx, r, shouldApplyV3, applyFunc)
+}
+
+func (a *applierV3Standby) Put(ctx context.Context, txn mvcc.TxnWrite, p *pb.PutRequest) (*pb.PutResponse, *traceutil.Trace, error) {
+	if !a.mirror {
+		return nil, nil, errors.ErrStandby
+	}
+	return a.applierV3.Put(ctx, txn, p)
+}
+
+func (a *applierV3Standby) DeleteRange(ctx context.Context, txn mvcc.TxnWrite, dr *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	if !a.mirror {
+		return nil, errors.ErrStandby
+	}
+	return a.applierV3.DeleteRange(ctx, txn, dr)
+}
+
+func (a *applierV3Standby) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, *traceutil.Trace, error) {
+	if !a.mirror && !mvcctxn.IsTxnReadonly(r) {
+		return nil, nil, errors.ErrStandby
+	}
+	return a.applierV3.Txn(ctx, r)
+}
+
+func (a *applierV3Standby) LeaseGrant(_ *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
+	return nil, errors.ErrStandby
+}
+
 func (a *applierV3backend) AuthEnable() (*pb.AuthEnableResponse, error) {
 	err := a.authStore.AuthEnable()
 	if err != nil {
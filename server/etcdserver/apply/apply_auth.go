@@ -51,17 +51,20 @@ func (aa *authApplierV3) Apply(ctx context.Context, r *pb.InternalRaftRequest, s
 		// does not have header field
 		aa.authInfo.Username = r.Header.Username
 		aa.authInfo.Revision = r.Header.AuthRevision
+		aa.authInfo.Roles = r.Header.Roles
 	}
 	if needAdminPermission(r) {
 		if err := aa.as.IsAdminPermitted(&aa.authInfo); err != nil {
 			aa.authInfo.Username = ""
 			aa.authInfo.Revision = 0
+			aa.authInfo.Roles = nil
 			return &Result{Err: err}
 		}
 	}
 	ret := aa.applierV3.Apply(ctx, r, shouldApplyV3, applyFunc)
 	aa.authInfo.Username = ""
 	aa.authInfo.Revision = 0
+	aa.authInfo.Roles = nil
 	return ret
 }
 
@@ -94,7 +97,7 @@ func (aa *authApplierV3) Range(ctx context.Context, txn mvcc.TxnRead, r *pb.Rang
 	return aa.applierV3.Range(ctx, txn, r)
 }
 
-func (aa *authApplierV3) DeleteRange(txn mvcc.TxnWrite, r *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+func (aa *authApplierV3) DeleteRange(ctx context.Context, txn mvcc.TxnWrite, r *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
 	if err := aa.as.IsDeleteRangePermitted(&aa.authInfo, r.Key, r.RangeEnd); err != nil {
 		return nil, err
 	}
@@ -105,7 +108,7 @@ func (aa *authApplierV3) DeleteRange(txn mvcc.TxnWrite, r *pb.DeleteRangeRequest
 		}
 	}
 
-	return aa.applierV3.DeleteRange(txn, r)
+	return aa.applierV3.DeleteRange(ctx, txn, r)
 }
 
 func (aa *authApplierV3) Txn(ctx context.Context, rt *pb.TxnRequest) (*pb.TxnResponse, *traceutil.Trace, error) {
@@ -115,11 +118,27 @@ func (aa *authApplierV3) Txn(ctx context.Context, rt *pb.TxnRequest) (*pb.TxnRes
 	return aa.applierV3.Txn(ctx, rt)
 }
 
+func (aa *authApplierV3) LeaseGrant(lc *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
+	resp, err := aa.applierV3.LeaseGrant(lc)
+	// the lease is granted even if err reports that the quota is exceeded
+	if resp != nil && resp.ID != 0 {
+		aa.as.LeaseGranted(&aa.authInfo, resp.ID)
+	}
+	return resp, err
+}
+
 func (aa *authApplierV3) LeaseRevoke(lc *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse, error) {
+	if err := aa.as.IsLeasePermitted(&aa.authInfo, lc.ID); err != nil {
+		return nil, err
+	}
 	if err := aa.checkLeasePuts(lease.LeaseID(lc.ID)); err != nil {
 		return nil, err
 	}
-	return aa.applierV3.LeaseRevoke(lc)
+	resp, err := aa.applierV3.LeaseRevoke(lc)
+	if err == nil {
+		aa.as.LeaseRevoked(lc.ID)
+	}
+	return resp, err
 }
 
 func (aa *authApplierV3) checkLeasePuts(leaseID lease.LeaseID) error {
@@ -140,6 +159,7 @@ func (aa *authApplierV3) UserGet(r *pb.AuthUserGetRequest) (*pb.AuthUserGetRespo
 	if err != nil && r.Name != aa.authInfo.Username {
 		aa.authInfo.Username = ""
 		aa.authInfo.Revision = 0
+		aa.authInfo.Roles = nil
 		return &pb.AuthUserGetResponse{}, err
 	}
 
@@ -151,6 +171,7 @@ func (aa *authApplierV3) RoleGet(r *pb.AuthRoleGetRequest) (*pb.AuthRoleGetRespo
 	if err != nil && !aa.as.HasRole(aa.authInfo.Username, r.Role) {
 		aa.authInfo.Username = ""
 		aa.authInfo.Revision = 0
+		aa.authInfo.Roles = nil
 		return &pb.AuthRoleGetResponse{}, err
 	}
 
@@ -37,7 +37,7 @@ func (a *applierV3Corrupt) Range(_ context.Context, _ mvcc.TxnRead, _ *pb.RangeR
 	return nil, errors.ErrCorrupt
 }
 
-func (a *applierV3Corrupt) DeleteRange(_ mvcc.TxnWrite, _ *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+func (a *applierV3Corrupt) DeleteRange(_ context.Context, _ mvcc.TxnWrite, _ *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
 	return nil, errors.ErrCorrupt
 }
 
@@ -49,6 +49,18 @@ func (a *applierV3Corrupt) Compaction(_ *pb.CompactionRequest) (*pb.CompactionRe
 	return nil, nil, nil, errors.ErrCorrupt
 }
 
+func (a *applierV3Corrupt) Purge(_ *pb.PurgeRequest) (*pb.PurgeResponse, <-chan struct{}, *traceutil.Trace, error) {
+	return nil, nil, nil, errors.ErrCorrupt
+}
+
+func (a *applierV3Corrupt) PinRevision(_ *pb.PinRevisionRequest) (*pb.PinRevisionResponse, error) {
+	return nil, errors.ErrCorrupt
+}
+
+func (a *applierV3Corrupt) UnpinRevision(_ *pb.UnpinRevisionRequest) (*pb.UnpinRevisionResponse, error) {
+	return nil, errors.ErrCorrupt
+}
+
 func (a *applierV3Corrupt) LeaseGrant(_ *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
 	return nil, errors.ErrCorrupt
 }
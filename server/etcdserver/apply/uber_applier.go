@@ -22,6 +22,7 @@ import (
 	"go.etcd.io/etcd/server/v3/auth"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3alarm"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3compactor"
 	"go.etcd.io/etcd/server/v3/etcdserver/cindex"
 	"go.etcd.io/etcd/server/v3/etcdserver/txn"
 	"go.etcd.io/etcd/server/v3/lease"
@@ -52,6 +53,7 @@ func NewUberApplier(
 	be backend.Backend,
 	kv mvcc.KV,
 	alarmStore *v3alarm.AlarmStore,
+	pinStore *v3compactor.PinStore,
 	authStore auth.AuthStore,
 	lessor lease.Lessor,
 	cluster *membership.RaftCluster,
@@ -61,7 +63,7 @@ func NewUberApplier(
 	warningApplyDuration time.Duration,
 	txnModeWriteWithSharedBuffer bool,
 	quotaBackendBytesCfg int64) UberApplier {
-	applyV3base_ := newApplierV3(lg, be, kv, alarmStore, authStore, lessor, cluster, raftStatus, snapshotServer, consistentIndex, txnModeWriteWithSharedBuffer, quotaBackendBytesCfg)
+	applyV3base_ := newApplierV3(lg, be, kv, alarmStore, pinStore, authStore, lessor, cluster, raftStatus, snapshotServer, consistentIndex, txnModeWriteWithSharedBuffer, quotaBackendBytesCfg)
 
 	ua := &uberApplier{
 		lg:                   lg,
@@ -79,6 +81,7 @@ func newApplierV3(
 	be backend.Backend,
 	kv mvcc.KV,
 	alarmStore *v3alarm.AlarmStore,
+	pinStore *v3compactor.PinStore,
 	authStore auth.AuthStore,
 	lessor lease.Lessor,
 	cluster *membership.RaftCluster,
@@ -87,7 +90,7 @@ func newApplierV3(
 	consistentIndex cindex.ConsistentIndexer,
 	txnModeWriteWithSharedBuffer bool,
 	quotaBackendBytesCfg int64) applierV3 {
-	applierBackend := newApplierV3Backend(lg, kv, alarmStore, authStore, lessor, cluster, raftStatus, snapshotServer, consistentIndex, txnModeWriteWithSharedBuffer)
+	applierBackend := newApplierV3Backend(lg, kv, alarmStore, pinStore, authStore, lessor, cluster, raftStatus, snapshotServer, consistentIndex, txnModeWriteWithSharedBuffer)
 	return newAuthApplierV3(
 		authStore,
 		newQuotaApplierV3(lg, quotaBackendBytesCfg, be, applierBackend),
@@ -98,10 +101,18 @@ func newApplierV3(
 func (a *uberApplier) restoreAlarms() {
 	noSpaceAlarms := len(a.alarmStore.Get(pb.AlarmType_NOSPACE)) > 0
 	corruptAlarms := len(a.alarmStore.Get(pb.AlarmType_CORRUPT)) > 0
+	tooManyKeysAlarms := len(a.alarmStore.Get(pb.AlarmType_TOOMANYKEYS)) > 0
+	standbyAlarms := len(a.alarmStore.Get(pb.AlarmType_STANDBY)) > 0
 	a.applyV3 = a.applyV3base
 	if noSpaceAlarms {
 		a.applyV3 = newApplierV3Capped(a.applyV3)
 	}
+	if tooManyKeysAlarms {
+		a.applyV3 = newApplierV3KeysCapped(a.applyV3)
+	}
+	if standbyAlarms {
+		a.applyV3 = newApplierV3Standby(a.applyV3)
+	}
 	if corruptAlarms {
 		a.applyV3 = newApplierV3Corrupt(a.applyV3)
 	}
@@ -113,7 +124,11 @@ func (a *uberApplier) Apply(r *pb.InternalRaftRequest, shouldApplyV3 membership.
 	// then dispatch() unpacks the request to a specific method (like Put),
 	// that gets executed down the hierarchy again:
 	// i.e. CorruptApplier.Put(CappedApplier.Put(...(BackendApplier.Put(...)))).
-	return a.applyV3.Apply(context.TODO(), r, shouldApplyV3, a.dispatch)
+	ctx := context.TODO()
+	if r.Header != nil && len(r.Header.TraceContext) > 0 {
+		ctx = txn.WithTraceContext(ctx, r.Header.TraceContext)
+	}
+	return a.applyV3.Apply(ctx, r, shouldApplyV3, a.dispatch)
 }
 
 // dispatch translates the request (r) into appropriate call (like Put) on
@@ -158,13 +173,22 @@ func (a *uberApplier) dispatch(ctx context.Context, r *pb.InternalRaftRequest, s
 		ar.Resp, ar.Trace, ar.Err = a.applyV3.Put(ctx, nil, r.Put)
 	case r.DeleteRange != nil:
 		op = "DeleteRange"
-		ar.Resp, ar.Err = a.applyV3.DeleteRange(nil, r.DeleteRange)
+		ar.Resp, ar.Err = a.applyV3.DeleteRange(ctx, nil, r.DeleteRange)
 	case r.Txn != nil:
 		op = "Txn"
 		ar.Resp, ar.Trace, ar.Err = a.applyV3.Txn(ctx, r.Txn)
 	case r.Compaction != nil:
 		op = "Compaction"
 		ar.Resp, ar.Physc, ar.Trace, ar.Err = a.applyV3.Compaction(r.Compaction)
+	case r.Purge != nil:
+		op = "Purge"
+		ar.Resp, ar.Physc, ar.Trace, ar.Err = a.applyV3.Purge(r.Purge)
+	case r.PinRevision != nil:
+		op = "PinRevision"
+		ar.Resp, ar.Err = a.applyV3.PinRevision(r.PinRevision)
+	case r.UnpinRevision != nil:
+		op = "UnpinRevision"
+		ar.Resp, ar.Err = a.applyV3.UnpinRevision(r.UnpinRevision)
 	case r.LeaseGrant != nil:
 		op = "LeaseGrant"
 		ar.Resp, ar.Err = a.applyV3.LeaseGrant(r.LeaseGrant)
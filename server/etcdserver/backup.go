@@ -0,0 +1,215 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+const (
+	backupFilePrefix = "backup-"
+	backupFileSuffix = ".db"
+	// backupTimeFormat formats the time of the backups in their file names,
+	// so that the names of the backups sort in the order they were saved.
+	backupTimeFormat = "20060102T150405Z"
+)
+
+// monitorAutoBackup saves a backup of the member to the configured
+// directory at every backup interval.
+func (s *EtcdServer) monitorAutoBackup() {
+	interval := s.Cfg.AutoBackupInterval
+	if interval <= 0 {
+		return
+	}
+
+	select {
+	case <-s.stopping:
+		return
+	case <-s.ReadyNotify():
+	}
+	for {
+		select {
+		case <-s.stopping:
+			return
+		case <-time.After(interval):
+		}
+		s.autoBackup(time.Now())
+	}
+}
+
+func (s *EtcdServer) autoBackup(now time.Time) {
+	lg := s.Logger()
+	dir := s.Cfg.AutoBackupDir
+	start := time.Now()
+	path, size, err := saveBackup(lg, s.Backend(), dir, now)
+	if err != nil {
+		autoBackups.WithLabelValues("failure").Inc()
+		lg.Warn("failed to save auto backup", zap.String("dir", dir), zap.Error(err))
+		return
+	}
+	autoBackups.WithLabelValues("success").Inc()
+	lastAutoBackupTimestamp.Set(float64(now.Unix()))
+	lg.Info(
+		"saved auto backup",
+		zap.String("path", path),
+		zap.Int64("size-bytes", size),
+		zap.Duration("took", time.Since(start)),
+	)
+
+	removed, err := purgeBackups(dir, s.Cfg.AutoBackupRetention)
+	for _, p := range removed {
+		lg.Info("removed old auto backup", zap.String("path", p))
+	}
+	if err != nil {
+		lg.Warn("failed to remove old auto backups", zap.String("dir", dir), zap.Error(err))
+	}
+}
+
+// saveBackup saves a snapshot of the backend to a new backup file in dir,
+// followed by its sha256 checksum like the snapshots sent to clients, so
+// that it can be restored with 'etcdutl snapshot restore'. The file is only
+// given its name once its checksum and bolt database are verified.
+func saveBackup(lg *zap.Logger, be backend.Backend, dir string, now time.Time) (path string, size int64, err error) {
+	if err = fileutil.TouchDirAll(lg, dir); err != nil {
+		return "", 0, err
+	}
+	path = filepath.Join(dir, backupFilePrefix+now.UTC().Format(backupTimeFormat)+backupFileSuffix)
+	partpath := path + ".part"
+	defer os.Remove(partpath)
+
+	f, err := os.OpenFile(partpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileutil.PrivateFileMode)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	snap := be.Snapshot()
+	h := sha256.New()
+	size, err = snap.WriteTo(io.MultiWriter(f, h))
+	if cerr := snap.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if _, err = f.Write(h.Sum(nil)); err != nil {
+		return "", 0, err
+	}
+	if err = fileutil.Fsync(f); err != nil {
+		return "", 0, err
+	}
+	if err = f.Close(); err != nil {
+		return "", 0, err
+	}
+
+	if err = verifyBackup(partpath); err != nil {
+		return "", 0, fmt.Errorf("cannot verify backup %s: %w", partpath, err)
+	}
+	if err = os.Rename(partpath, path); err != nil {
+		return "", 0, err
+	}
+	return path, size + sha256.Size, nil
+}
+
+// verifyBackup checks the sha256 checksum ending the given backup file and
+// the consistency of the bolt database it holds.
+func verifyBackup(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	dbSize := st.Size() - sha256.Size
+	if dbSize <= 0 {
+		return fmt.Errorf("backup of %d bytes is too small", st.Size())
+	}
+	h := sha256.New()
+	if _, err = io.CopyN(h, f, dbSize); err != nil {
+		return err
+	}
+	sum := make([]byte, sha256.Size)
+	if _, err = io.ReadFull(f, sum); err != nil {
+		return err
+	}
+	if !bytes.Equal(sum, h.Sum(nil)) {
+		return fmt.Errorf("sha256 checksum mismatch")
+	}
+
+	db, err := bolt.Open(path, 0400, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(func(tx *bolt.Tx) error {
+		for err := range tx.Check() {
+			return err
+		}
+		return nil
+	})
+}
+
+// purgeBackups removes the backups in dir but the retention most recent
+// ones, as well as the backups left unfinished, and returns the paths of
+// the removed backups. A retention of 0 keeps all the backups.
+func purgeBackups(dir string, retention int) (removed []string, err error) {
+	names, err := fileutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, name := range names {
+		if !strings.HasPrefix(name, backupFilePrefix) {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(name, backupFileSuffix):
+			backups = append(backups, name)
+		case strings.HasSuffix(name, backupFileSuffix+".part"):
+			if err = os.Remove(filepath.Join(dir, name)); err != nil {
+				return removed, err
+			}
+		}
+	}
+	if retention == 0 || len(backups) <= retention {
+		return nil, nil
+	}
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-retention] {
+		p := filepath.Join(dir, name)
+		if err = os.Remove(p); err != nil {
+			return removed, err
+		}
+		removed = append(removed, p)
+	}
+	return removed, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+)
+
+func TestSaveBackup(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+	kv := mvcc.New(lg, be, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer kv.Close()
+	kv.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	be.ForceCommit()
+
+	dir := filepath.Join(t.TempDir(), "backups")
+	now := time.Date(2022, 10, 16, 10, 30, 0, 0, time.UTC)
+	path, size, err := saveBackup(lg, be, dir, now)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "backup-20221016T103000Z.db"), path)
+	st, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, st.Size(), size)
+	assert.NoError(t, verifyBackup(path))
+	names, err := fileutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backup-20221016T103000Z.db"}, names)
+
+	// a corrupted backup fails its checksum
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	b[len(b)/2] ^= 0xff
+	require.NoError(t, os.WriteFile(path, b, 0600))
+	assert.ErrorContains(t, verifyBackup(path), "checksum mismatch")
+}
+
+func TestPurgeBackups(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"backup-20221016T100000Z.db",
+		"backup-20221016T110000Z.db",
+		"backup-20221016T120000Z.db",
+		"backup-20221016T130000Z.db.part",
+		"member.db",
+	}
+	for _, f := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, f), nil, 0600))
+	}
+
+	removed, err := purgeBackups(dir, 0)
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+	names, err := fileutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{files[0], files[1], files[2], "member.db"}, names)
+
+	removed, err = purgeBackups(dir, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, files[0])}, removed)
+	names, err = fileutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{files[1], files[2], "member.db"}, names)
+}
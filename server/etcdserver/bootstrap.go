@@ -90,6 +90,16 @@ func bootstrap(cfg config.ServerConfig) (b *bootstrappedServer, err error) {
 			return nil, fmt.Errorf("cannot write to WAL directory: %v", err)
 		}
 		bwal = bootstrapWALFromSnapshot(cfg, backend.snapshot)
+		if cfg.StaleDataDirRecovery {
+			if remote := staleDataDirCluster(cfg, bwal.meta, prt); remote != nil {
+				bwal.w.Close()
+				backend.Close()
+				if cfg, err = rejoinAsLearner(cfg, remote); err != nil {
+					return nil, err
+				}
+				return bootstrap(cfg)
+			}
+		}
 	}
 
 	cluster, err := bootstrapCluster(cfg, bwal, prt)
@@ -461,6 +471,13 @@ func (c *bootstrapedCluster) Finalize(cfg config.ServerConfig, s *bootstrappedSt
 			os.RemoveAll(bepath)
 			return fmt.Errorf("database file (%v) of the backend is missing", bepath)
 		}
+		if cfg.ForceNewCluster {
+			// fence off the members and clients of the cluster before
+			// it was forced into a new cluster
+			c.cl.BumpEpochOnBootstrap()
+		}
+	} else if cfg.NewCluster {
+		c.cl.RecoverEpoch()
 	}
 	scaleUpLearners := false
 	return membership.ValidateMaxLearnerConfig(cfg.ExperimentalMaxLearners, c.cl.Members(), scaleUpLearners)
@@ -609,6 +626,7 @@ func openWALFromSnapshot(cfg config.ServerConfig, snapshot *raftpb.Snapshot) (*w
 		if cfg.UnsafeNoFsync {
 			w.SetUnsafeNoFsync()
 		}
+		w.SetCompression(cfg.WALCompression)
 		wmetadata, st, ents, err := w.ReadAll()
 		if err != nil {
 			w.Close()
@@ -616,12 +634,16 @@ func openWALFromSnapshot(cfg config.ServerConfig, snapshot *raftpb.Snapshot) (*w
 			if repaired || err != io.ErrUnexpectedEOF {
 				cfg.Logger.Fatal("failed to read WAL, cannot be repaired", zap.Error(err))
 			}
-			if !wal.Repair(cfg.Logger, cfg.WALDir()) {
-				cfg.Logger.Fatal("failed to repair WAL", zap.Error(err))
+			report, rerr := wal.RepairTail(cfg.Logger, cfg.WALDir())
+			if rerr != nil {
+				cfg.Logger.Fatal("failed to repair WAL", zap.Error(err), zap.NamedError("repair-error", rerr))
+			}
+			if report != nil {
+				cfg.Logger.Warn("repaired torn write at the tail of WAL", zap.Object("report", report), zap.Error(err))
 			} else {
 				cfg.Logger.Info("repaired WAL", zap.Error(err))
-				repaired = true
 			}
+			repaired = true
 			continue
 		}
 		var metadata etcdserverpb.Metadata
@@ -651,6 +673,7 @@ func bootstrapNewWAL(cfg config.ServerConfig, cl *bootstrapedCluster) *bootstrap
 	if cfg.UnsafeNoFsync {
 		w.SetUnsafeNoFsync()
 	}
+	w.SetCompression(cfg.WALCompression)
 	return &bootstrappedWAL{
 		lg: cfg.Logger,
 		w:  w,
@@ -0,0 +1,178 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/types"
+
+	"go.uber.org/zap"
+)
+
+// certExpiryCheckInterval is the interval between two checks of the expiry
+// of the certificates of the member.
+const certExpiryCheckInterval = time.Hour
+
+// certExpiry is the expiry of a certificate of the member.
+type certExpiry struct {
+	// kind is the use of the certificate: client, client-ca, peer or peer-ca.
+	kind     string
+	path     string
+	subject  string
+	notAfter time.Time
+}
+
+// certFile is a certificate file of the member.
+type certFile struct {
+	kind string
+	path string
+}
+
+// certFiles returns the certificate files of the member.
+func (s *EtcdServer) certFiles() []certFile {
+	var files []certFile
+	for _, f := range []certFile{
+		{"client", s.Cfg.ClientTLSInfo.CertFile},
+		{"client-ca", s.Cfg.ClientTLSInfo.TrustedCAFile},
+		{"peer", s.Cfg.PeerTLSInfo.CertFile},
+		{"peer-ca", s.Cfg.PeerTLSInfo.TrustedCAFile},
+	} {
+		if f.path != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// readCertExpiries returns the expiry of the certificates in the given
+// files. The files are read again on every call, as they can be rotated
+// while the member runs.
+func readCertExpiries(lg *zap.Logger, files []certFile) []certExpiry {
+	var certs []certExpiry
+	for _, f := range files {
+		b, err := os.ReadFile(f.path)
+		if err != nil {
+			lg.Warn("failed to read certificate file", zap.String("path", f.path), zap.Error(err))
+			continue
+		}
+		for {
+			var block *pem.Block
+			if block, b = pem.Decode(b); block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				lg.Warn("failed to parse certificate", zap.String("path", f.path), zap.Error(err))
+				continue
+			}
+			certs = append(certs, certExpiry{kind: f.kind, path: f.path, subject: cert.Subject.String(), notAfter: cert.NotAfter})
+		}
+	}
+	return certs
+}
+
+// expiringCerts returns the certificates expiring within window after now.
+func expiringCerts(certs []certExpiry, now time.Time, window time.Duration) []certExpiry {
+	var expiring []certExpiry
+	for _, c := range certs {
+		if c.notAfter.Sub(now) < window {
+			expiring = append(expiring, c)
+		}
+	}
+	return expiring
+}
+
+// monitorCertExpiry exports the expiry of the certificates of the member
+// and, if enabled, raises a CERTEXPIRY alarm for the member while any of
+// them expires within the configured window.
+func (s *EtcdServer) monitorCertExpiry() {
+	files := s.certFiles()
+	if len(files) == 0 {
+		return
+	}
+
+	select {
+	case <-s.stopping:
+		return
+	case <-s.ReadyNotify():
+	}
+	for {
+		s.checkCertExpiry(readCertExpiries(s.Logger(), files), time.Now())
+		select {
+		case <-s.stopping:
+			return
+		case <-time.After(certExpiryCheckInterval):
+		}
+	}
+}
+
+func (s *EtcdServer) checkCertExpiry(certs []certExpiry, now time.Time) {
+	certExpiryTimestamp.Reset()
+	for _, c := range certs {
+		certExpiryTimestamp.WithLabelValues(c.kind, c.subject).Set(float64(c.notAfter.Unix()))
+	}
+
+	window := s.Cfg.CertExpiryAlarmWindow
+	if window <= 0 {
+		return
+	}
+	lg := s.Logger()
+	expiring := expiringCerts(certs, now, window)
+	for _, c := range expiring {
+		lg.Warn(
+			"certificate is about to expire",
+			zap.String("type", c.kind),
+			zap.String("path", c.path),
+			zap.String("subject", c.subject),
+			zap.Time("not-after", c.notAfter),
+			zap.Duration("alarm-window", window),
+		)
+	}
+
+	active := false
+	for _, m := range s.alarmStore.Get(pb.AlarmType_CERTEXPIRY) {
+		if types.ID(m.MemberID) == s.MemberId() {
+			active = true
+			break
+		}
+	}
+	var action pb.AlarmRequest_AlarmAction
+	switch {
+	case len(expiring) > 0 && !active:
+		lg.Warn("certificates of the member are about to expire; activating alarm", zap.Int("expiring", len(expiring)))
+		action = pb.AlarmRequest_ACTIVATE
+	case len(expiring) == 0 && active:
+		lg.Info("certificates of the member were renewed; deactivating alarm")
+		action = pb.AlarmRequest_DEACTIVATE
+	default:
+		return
+	}
+	a := &pb.AlarmRequest{
+		MemberID: uint64(s.MemberId()),
+		Action:   action,
+		Alarm:    pb.AlarmType_CERTEXPIRY,
+	}
+	s.GoAttach(func() {
+		s.raftRequest(s.ctx, pb.InternalRaftRequest{Alarm: a})
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCertExpiries(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	dir := t.TempDir()
+	info, err := transport.SelfCert(lg, dir, []string{"localhost"}, 1)
+	require.NoError(t, err)
+
+	// a CA bundle holding the certificate twice, with a key in between
+	key, err := os.ReadFile(info.KeyFile)
+	require.NoError(t, err)
+	cert, err := os.ReadFile(info.CertFile)
+	require.NoError(t, err)
+	bundle := filepath.Join(dir, "bundle.pem")
+	require.NoError(t, os.WriteFile(bundle, append(append(cert, key...), cert...), 0600))
+
+	s := &EtcdServer{Cfg: config.ServerConfig{
+		ClientTLSInfo: transport.TLSInfo{CertFile: info.CertFile, TrustedCAFile: bundle},
+		PeerTLSInfo:   transport.TLSInfo{CertFile: filepath.Join(dir, "missing.pem")},
+	}}
+	files := s.certFiles()
+	assert.Equal(t, []certFile{
+		{"client", info.CertFile},
+		{"client-ca", bundle},
+		{"peer", filepath.Join(dir, "missing.pem")},
+	}, files)
+
+	certs := readCertExpiries(lg, files)
+	require.Len(t, certs, 3)
+	assert.Equal(t, []string{"client", "client-ca", "client-ca"}, []string{certs[0].kind, certs[1].kind, certs[2].kind})
+	for _, c := range certs {
+		assert.Equal(t, "O=etcd", c.subject)
+		assert.WithinDuration(t, time.Now().AddDate(1, 0, 0), c.notAfter, 24*time.Hour)
+	}
+}
+
+func TestExpiringCerts(t *testing.T) {
+	now := time.Now()
+	certs := []certExpiry{
+		{kind: "client", notAfter: now.Add(48 * time.Hour)},
+		{kind: "peer", notAfter: now.Add(12 * time.Hour)},
+		{kind: "peer-ca", notAfter: now.Add(-time.Hour)},
+	}
+	tests := []struct {
+		window time.Duration
+		wkinds []string
+	}{
+		{time.Hour, []string{"peer-ca"}},
+		{24 * time.Hour, []string{"peer", "peer-ca"}},
+		{72 * time.Hour, []string{"client", "peer", "peer-ca"}},
+	}
+	for _, tt := range tests {
+		var kinds []string
+		for _, c := range expiringCerts(certs, now, tt.window) {
+			kinds = append(kinds, c.kind)
+		}
+		assert.Equal(t, tt.wkinds, kinds, "window %v", tt.window)
+	}
+}
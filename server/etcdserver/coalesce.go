@@ -0,0 +1,99 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+)
+
+// proposalCoalescer lets concurrent identical proposals share a single raft
+// entry: a proposal made while an identical one is in flight waits for the
+// result of the latter instead of being proposed again. The zero value is
+// ready to use.
+type proposalCoalescer struct {
+	mu       sync.Mutex
+	inflight map[string]*coalescedProposal
+}
+
+type coalescedProposal struct {
+	donec  chan struct{}
+	result interface{}
+	err    error
+}
+
+// do calls propose unless a proposal with the same key is in flight, in which
+// case it waits for that proposal and returns its result. typ labels the
+// coalesced proposals in metrics. An empty key disables coalescing.
+func (c *proposalCoalescer) do(ctx context.Context, typ, key string, propose func() (interface{}, error)) (interface{}, error) {
+	if key == "" {
+		return propose()
+	}
+	c.mu.Lock()
+	if p, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		proposalsCoalesced.WithLabelValues(typ).Inc()
+		select {
+		case <-p.donec:
+			return p.result, p.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if c.inflight == nil {
+		c.inflight = make(map[string]*coalescedProposal)
+	}
+	p := &coalescedProposal{donec: make(chan struct{})}
+	c.inflight[key] = p
+	c.mu.Unlock()
+
+	p.result, p.err = propose()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	close(p.donec)
+	return p.result, p.err
+}
+
+// confChangeKey returns the key identifying the configuration changes with
+// the same effect as cc: the changes adding the same peer URLs, whatever the
+// ID generated for the member, or removing, promoting or updating the same
+// member to the same peer URLs.
+func confChangeKey(cc raftpb.ConfChange) string {
+	if cc.Type == raftpb.ConfChangeRemoveNode {
+		return fmt.Sprintf("%s/%x", cc.Type, cc.NodeID)
+	}
+	ccc := new(membership.ConfigChangeContext)
+	if err := json.Unmarshal(cc.Context, ccc); err != nil {
+		return ""
+	}
+	if ccc.IsPromote {
+		return fmt.Sprintf("promote/%x", cc.NodeID)
+	}
+	urls := append([]string{}, ccc.PeerURLs...)
+	sort.Strings(urls)
+	if cc.Type == raftpb.ConfChangeUpdateNode {
+		return fmt.Sprintf("%s/%x/%s", cc.Type, cc.NodeID, strings.Join(urls, ","))
+	}
+	return fmt.Sprintf("%s/%s", cc.Type, strings.Join(urls, ","))
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+)
+
+func TestProposalCoalescer(t *testing.T) {
+	var (
+		c        proposalCoalescer
+		wg       sync.WaitGroup
+		proposed int
+	)
+	startc, releasec := make(chan struct{}), make(chan struct{})
+	propose := func() (interface{}, error) {
+		proposed++
+		close(startc)
+		<-releasec
+		return "applied", nil
+	}
+
+	results := make(chan interface{}, 3)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r, _ := c.do(context.TODO(), "test", "foo", propose)
+		results <- r
+	}()
+	<-startc
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, _ := c.do(context.TODO(), "test", "foo", propose)
+			results <- r
+		}()
+	}
+	// wait for the identical proposals to join the in-flight one
+	time.Sleep(10 * time.Millisecond)
+
+	// a proposal with another key is not coalesced
+	if r, err := c.do(context.TODO(), "test", "bar", func() (interface{}, error) { return "other", nil }); r != "other" || err != nil {
+		t.Fatalf("unexpected result of another proposal: %v, %v", r, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if _, err := c.do(ctx, "test", "foo", propose); err != context.Canceled {
+		t.Fatalf("expected %v waiting for a coalesced proposal with a canceled context, got %v", context.Canceled, err)
+	}
+
+	close(releasec)
+	wg.Wait()
+	close(results)
+	for r := range results {
+		if r != "applied" {
+			t.Errorf("result = %v, want applied", r)
+		}
+	}
+	if proposed != 1 {
+		t.Errorf("proposed %d times, want 1", proposed)
+	}
+	if len(c.inflight) != 0 {
+		t.Errorf("in-flight proposals = %v, want none", c.inflight)
+	}
+}
+
+func TestConfChangeKey(t *testing.T) {
+	ctxBytes := func(v interface{}) []byte {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	add := func(id uint64, urls ...string) raftpb.ConfChange {
+		return raftpb.ConfChange{
+			Type:    raftpb.ConfChangeAddNode,
+			NodeID:  id,
+			Context: ctxBytes(membership.Member{ID: types.ID(id), RaftAttributes: membership.RaftAttributes{PeerURLs: urls}}),
+		}
+	}
+	promote := raftpb.ConfChange{
+		Type:    raftpb.ConfChangeAddNode,
+		NodeID:  1,
+		Context: ctxBytes(membership.ConfigChangeContext{Member: membership.Member{ID: 1}, IsPromote: true}),
+	}
+	update := func(id uint64, urls ...string) raftpb.ConfChange {
+		cc := add(id, urls...)
+		cc.Type = raftpb.ConfChangeUpdateNode
+		return cc
+	}
+
+	tests := []struct {
+		a, b  raftpb.ConfChange
+		wsame bool
+	}{
+		{add(1, "http://a", "http://b"), add(1, "http://b", "http://a"), true},
+		// additions of the same peer URLs are coalesced whatever the generated IDs
+		{add(1, "http://a", "http://b"), add(2, "http://b", "http://a"), true},
+		{add(1, "http://a"), add(1, "http://b"), false},
+		{add(1, "http://a"), promote, false},
+		{promote, promote, true},
+		{raftpb.ConfChange{Type: raftpb.ConfChangeRemoveNode, NodeID: 1}, raftpb.ConfChange{Type: raftpb.ConfChangeRemoveNode, NodeID: 1}, true},
+		{raftpb.ConfChange{Type: raftpb.ConfChangeRemoveNode, NodeID: 1}, raftpb.ConfChange{Type: raftpb.ConfChangeRemoveNode, NodeID: 2}, false},
+		{update(1, "http://a"), update(1, "http://a"), true},
+		{update(1, "http://a"), update(2, "http://a"), false},
+		{update(1, "http://a"), update(1, "http://b"), false},
+	}
+	for i, tt := range tests {
+		if same := confChangeKey(tt.a) == confChangeKey(tt.b); same != tt.wsame {
+			t.Errorf("#%d: same key = %v (%q, %q), want %v", i, same, confChangeKey(tt.a), confChangeKey(tt.b), tt.wsame)
+		}
+	}
+}
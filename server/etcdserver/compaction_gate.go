@@ -0,0 +1,136 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3compactor"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// proposalLatencySamples is the number of latencies of the most recent
+	// proposals kept to estimate their p99.
+	proposalLatencySamples = 1024
+	// proposalLatencyWindow is how far back the latencies of proposals are
+	// considered when deciding to defer an auto compaction.
+	proposalLatencyWindow = time.Minute
+)
+
+type latencySample struct {
+	at time.Time
+	d  time.Duration
+}
+
+// latencyWindow keeps the latencies of the most recent proposals of the
+// member.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []latencySample
+	// next is the index of samples overwritten by the next observation once
+	// the window is full.
+	next int
+}
+
+func (w *latencyWindow) observe(now time.Time, d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.samples) < proposalLatencySamples {
+		w.samples = append(w.samples, latencySample{at: now, d: d})
+		return
+	}
+	w.samples[w.next] = latencySample{at: now, d: d}
+	w.next = (w.next + 1) % proposalLatencySamples
+}
+
+// p99 returns the 99th percentile of the latencies observed since the given
+// time, 0 if none was.
+func (w *latencyWindow) p99(since time.Time) time.Duration {
+	w.mu.Lock()
+	ds := make([]time.Duration, 0, len(w.samples))
+	for _, s := range w.samples {
+		if !s.at.Before(since) {
+			ds = append(ds, s.d)
+		}
+	}
+	w.mu.Unlock()
+	if len(ds) == 0 {
+		return 0
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	return ds[(len(ds)*99+99)/100-1]
+}
+
+// compactionDeferral returns why the auto compactions of the member should
+// be deferred, with an error wrapping v3compactor.ErrDeferred, or an empty
+// reason if the pending writes and the recent p99 proposal latency of the
+// member are within their limits.
+func (s *EtcdServer) compactionDeferral() (reason string, err error) {
+	if max := s.Cfg.AutoCompactionMaxPendingProposals; max != 0 {
+		if n := atomic.LoadInt64(&s.pendingWrites); n >= int64(max) {
+			return "pending_proposals", fmt.Errorf("%w: %d proposals pending, limit %d", v3compactor.ErrDeferred, n, max)
+		}
+	}
+	if max := s.Cfg.AutoCompactionMaxLatency; max != 0 {
+		if p99 := s.proposalLatencies.p99(time.Now().Add(-proposalLatencyWindow)); p99 > max {
+			return "latency", fmt.Errorf("%w: p99 proposal latency %v, limit %v", v3compactor.ErrDeferred, p99, max)
+		}
+	}
+	return "", nil
+}
+
+// gatedCompactable defers the auto compactions of the member while it is
+// overloaded, rather than adding their I/O to its load, for at most
+// Cfg.AutoCompactionMaxDeferral so that the history does not grow without
+// limit under a sustained load.
+type gatedCompactable struct {
+	s *EtcdServer
+	c v3compactor.Compactable
+
+	// deferredSince is when the first of the compactions deferred in a row
+	// was, zero if the last one was not deferred. Only accessed by the
+	// compactor.
+	deferredSince time.Time
+}
+
+func (gc *gatedCompactable) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error) {
+	if reason, err := gc.s.compactionDeferral(); err != nil {
+		now := time.Now()
+		if gc.deferredSince.IsZero() {
+			gc.deferredSince = now
+		}
+		maxDeferral := gc.s.Cfg.AutoCompactionMaxDeferral
+		if maxDeferral == 0 || now.Sub(gc.deferredSince) < maxDeferral {
+			autoCompactionsDeferred.WithLabelValues(reason).Inc()
+			return nil, err
+		}
+		gc.s.Logger().Warn(
+			"compacting despite the load; auto compactions were deferred for too long",
+			zap.String("reason", reason),
+			zap.Duration("deferred", now.Sub(gc.deferredSince)),
+			zap.Duration("max-deferral", maxDeferral),
+		)
+	}
+	gc.deferredSince = time.Time{}
+	return gc.c.Compact(ctx, r)
+}
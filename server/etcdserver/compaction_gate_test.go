@@ -0,0 +1,154 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3compactor"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestLatencyWindow(t *testing.T) {
+	var w latencyWindow
+	now := time.Now()
+	if d := w.p99(now.Add(-time.Minute)); d != 0 {
+		t.Fatalf("p99() of an empty window = %v, want 0", d)
+	}
+
+	// samples older than the window are ignored
+	w.observe(now.Add(-2*time.Minute), time.Hour)
+	for i := 1; i <= 100; i++ {
+		w.observe(now, time.Duration(i)*time.Millisecond)
+	}
+	if d := w.p99(now.Add(-time.Minute)); d != 99*time.Millisecond {
+		t.Errorf("p99() = %v, want %v", d, 99*time.Millisecond)
+	}
+
+	// the oldest samples are overwritten once the window is full
+	for i := 0; i < proposalLatencySamples; i++ {
+		w.observe(now, time.Millisecond)
+	}
+	if d := w.p99(now.Add(-time.Minute)); d != time.Millisecond {
+		t.Errorf("p99() = %v, want %v", d, time.Millisecond)
+	}
+}
+
+type fakeCompactable struct {
+	compacted []int64
+}
+
+func (fc *fakeCompactable) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error) {
+	fc.compacted = append(fc.compacted, r.Revision)
+	return &pb.CompactionResponse{}, nil
+}
+
+func TestGatedCompactable(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           config.ServerConfig
+		pendingWrites int64
+		latency       time.Duration
+		wantReason    string
+	}{
+		{
+			name:          "no limits",
+			pendingWrites: 1000,
+			latency:       time.Second,
+		},
+		{
+			name:          "under limits",
+			cfg:           config.ServerConfig{AutoCompactionMaxPendingProposals: 10, AutoCompactionMaxLatency: time.Second},
+			pendingWrites: 9,
+			latency:       time.Second,
+		},
+		{
+			name:          "pending proposals",
+			cfg:           config.ServerConfig{AutoCompactionMaxPendingProposals: 10, AutoCompactionMaxLatency: time.Second},
+			pendingWrites: 10,
+			wantReason:    "pending_proposals",
+		},
+		{
+			name:       "latency",
+			cfg:        config.ServerConfig{AutoCompactionMaxPendingProposals: 10, AutoCompactionMaxLatency: time.Second},
+			latency:    2 * time.Second,
+			wantReason: "latency",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &EtcdServer{Cfg: tt.cfg, pendingWrites: tt.pendingWrites}
+			if tt.latency != 0 {
+				s.proposalLatencies.observe(time.Now(), tt.latency)
+			}
+			fc := &fakeCompactable{}
+			gc := &gatedCompactable{s: s, c: fc}
+
+			_, err := gc.Compact(context.TODO(), &pb.CompactionRequest{Revision: 5})
+			if reason, _ := s.compactionDeferral(); reason != tt.wantReason {
+				t.Errorf("compactionDeferral() reason = %q, want %q", reason, tt.wantReason)
+			}
+			if tt.wantReason != "" {
+				if !errors.Is(err, v3compactor.ErrDeferred) {
+					t.Errorf("Compact() = %v, want %v", err, v3compactor.ErrDeferred)
+				}
+				if len(fc.compacted) != 0 {
+					t.Errorf("compacted %v, want none", fc.compacted)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(fc.compacted) != 1 || fc.compacted[0] != 5 {
+				t.Errorf("compacted %v, want [5]", fc.compacted)
+			}
+		})
+	}
+}
+
+func TestGatedCompactableMaxDeferral(t *testing.T) {
+	s := &EtcdServer{
+		lgMu:          new(sync.RWMutex),
+		lg:            zaptest.NewLogger(t),
+		Cfg:           config.ServerConfig{AutoCompactionMaxPendingProposals: 1, AutoCompactionMaxDeferral: time.Hour},
+		pendingWrites: 1,
+	}
+	fc := &fakeCompactable{}
+	gc := &gatedCompactable{s: s, c: fc}
+
+	if _, err := gc.Compact(context.TODO(), &pb.CompactionRequest{Revision: 5}); !errors.Is(err, v3compactor.ErrDeferred) {
+		t.Fatalf("Compact() = %v, want %v", err, v3compactor.ErrDeferred)
+	}
+	// the compactions are deferred for the max deferral at most
+	gc.deferredSince = time.Now().Add(-time.Hour)
+	if _, err := gc.Compact(context.TODO(), &pb.CompactionRequest{Revision: 6}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.compacted) != 1 || fc.compacted[0] != 6 {
+		t.Errorf("compacted %v, want [6]", fc.compacted)
+	}
+	// the deferral starts again after a compaction
+	if _, err := gc.Compact(context.TODO(), &pb.CompactionRequest{Revision: 7}); !errors.Is(err, v3compactor.ErrDeferred) {
+		t.Fatalf("Compact() = %v, want %v", err, v3compactor.ErrDeferred)
+	}
+}
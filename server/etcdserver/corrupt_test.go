@@ -341,6 +341,10 @@ func (f *fakeHasher) HashByRev(rev int64) (hash mvcc.KeyValueHash, revision int6
 	return hashByRev.hash, hashByRev.revision, hashByRev.err
 }
 
+func (f *fakeHasher) HashRangeByRev(key, end []byte, rev int64) (hash mvcc.KeyValueHash, revision int64, err error) {
+	panic("not implemented")
+}
+
 func (f *fakeHasher) Store(hash mvcc.KeyValueHash) {
 	f.actions = append(f.actions, fmt.Sprintf("Store(%v)", hash))
 	f.hashes = append(f.hashes, hash)
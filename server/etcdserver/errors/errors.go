@@ -35,13 +35,19 @@ var (
 	ErrNotLeader                   = errors.New("etcdserver: not leader")
 	ErrRequestTooLarge             = errors.New("etcdserver: request is too large")
 	ErrNoSpace                     = errors.New("etcdserver: no space")
+	ErrTooManyKeys                 = errors.New("etcdserver: too many keys")
 	ErrTooManyRequests             = errors.New("etcdserver: too many requests")
+	ErrOverloaded                  = errors.New("etcdserver: server is overloaded, retry later")
+	ErrRateLimited                 = errors.New("etcdserver: too many requests to the key prefix")
 	ErrUnhealthy                   = errors.New("etcdserver: unhealthy cluster")
 	ErrCorrupt                     = errors.New("etcdserver: corrupt cluster")
+	ErrStandby                     = errors.New("etcdserver: cluster is a standby")
 	ErrBadLeaderTransferee         = errors.New("etcdserver: bad leader transferee")
 	ErrClusterVersionUnavailable   = errors.New("etcdserver: cluster version not found during downgrade")
 	ErrWrongDowngradeVersionFormat = errors.New("etcdserver: wrong downgrade target version format")
 	ErrKeyNotFound                 = errors.New("etcdserver: key not found")
+	ErrInvalidSnapshotSettings     = errors.New("etcdserver: snapshot catch-up entries exceed the snapshot count")
+	ErrInvalidRateLimit            = errors.New("etcdserver: rate limit must be a finite non-negative number")
 )
 
 type DiscoveryError struct {
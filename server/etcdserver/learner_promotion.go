@@ -0,0 +1,141 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+
+	"go.uber.org/zap"
+)
+
+// learnerPromotionCheckInterval is the interval between two checks of the
+// progress of the learners by the leader with --auto-promote-learners.
+const learnerPromotionCheckInterval = time.Second
+
+// learnerLag is the number of raft log entries a learner lags behind the
+// leader.
+type learnerLag struct {
+	id  types.ID
+	lag uint64
+	// ready is whether the learner is started, actively replicating the log
+	// and caught up enough to be promoted.
+	ready bool
+}
+
+// learnerLags returns the lag of the learners among members, by ascending ID,
+// given the raft status of the leader. A learner is ready to be promoted once
+// it published its name, is recently active and lags at most maxLag entries
+// behind the leader, as well as within the readyPercent of MemberPromote.
+func learnerLags(members []*membership.Member, rs raft.Status, maxLag uint64) []learnerLag {
+	leaderMatch := rs.Progress[rs.ID].Match
+	var lags []learnerLag
+	for _, m := range members {
+		if !m.IsLearner {
+			continue
+		}
+		pr, ok := rs.Progress[uint64(m.ID)]
+		if !ok {
+			continue
+		}
+		var lag uint64
+		if pr.Match < leaderMatch {
+			lag = leaderMatch - pr.Match
+		}
+		lags = append(lags, learnerLag{
+			id:  m.ID,
+			lag: lag,
+			ready: m.IsStarted() && pr.RecentActive && lag <= maxLag &&
+				float64(pr.Match) >= float64(leaderMatch)*readyPercent,
+		})
+	}
+	sort.Slice(lags, func(i, j int) bool { return lags[i].id < lags[j].id })
+	return lags
+}
+
+// monitorLearnerPromotion promotes the learners caught up with the leader
+// while the member is leader, if enabled.
+func (s *EtcdServer) monitorLearnerPromotion() {
+	if !s.Cfg.AutoPromoteLearners {
+		return
+	}
+	s.Logger().Info(
+		"enabled learner auto-promotion",
+		zap.String("local-member-id", s.MemberId().String()),
+		zap.Uint64("max-lag", s.Cfg.AutoPromoteLearnersMaxLag),
+	)
+	for {
+		select {
+		case <-s.stopping:
+			return
+		case <-time.After(learnerPromotionCheckInterval):
+		}
+		learnerLagEntries.Reset()
+		if !s.isLeader() {
+			continue
+		}
+		s.promoteReadyLearner()
+	}
+}
+
+// promoteReadyLearner promotes the first learner ready to be promoted, one at
+// a time as every promotion changes the quorum of the cluster.
+func (s *EtcdServer) promoteReadyLearner() {
+	rs := s.raftStatus()
+	if rs.Progress == nil {
+		return
+	}
+	lags := learnerLags(s.cluster.Members(), rs, s.Cfg.AutoPromoteLearnersMaxLag)
+	for _, l := range lags {
+		learnerLagEntries.WithLabelValues(l.id.String()).Set(float64(l.lag))
+	}
+
+	lg := s.Logger()
+	for _, l := range lags {
+		if !l.ready {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+		_, err := s.proposePromoteMember(ctx, uint64(l.id))
+		cancel()
+		if err != nil {
+			lg.Warn(
+				"failed to auto-promote learner",
+				zap.String("local-member-id", s.MemberId().String()),
+				zap.String("learner-member-id", l.id.String()),
+				zap.Uint64("lag", l.lag),
+				zap.Error(err),
+			)
+			learnerAutoPromotions.WithLabelValues("failure").Inc()
+			learnerPromoteFailed.WithLabelValues(err.Error()).Inc()
+			return
+		}
+		lg.Info(
+			"auto-promoted learner",
+			zap.String("local-member-id", s.MemberId().String()),
+			zap.String("learner-member-id", l.id.String()),
+			zap.Uint64("lag", l.lag),
+		)
+		learnerAutoPromotions.WithLabelValues("success").Inc()
+		learnerPromoteSucceed.Inc()
+		learnerLagEntries.DeleteLabelValues(l.id.String())
+		return
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"reflect"
+	"testing"
+
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/tracker"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+)
+
+func TestLearnerLags(t *testing.T) {
+	members := []*membership.Member{
+		{ID: 1, Attributes: membership.Attributes{Name: "leader"}},
+		{ID: 5, Attributes: membership.Attributes{Name: "caught-up"}, RaftAttributes: membership.RaftAttributes{IsLearner: true}},
+		{ID: 2, Attributes: membership.Attributes{Name: "lagging"}, RaftAttributes: membership.RaftAttributes{IsLearner: true}},
+		{ID: 3, RaftAttributes: membership.RaftAttributes{IsLearner: true}},
+		{ID: 4, Attributes: membership.Attributes{Name: "inactive"}, RaftAttributes: membership.RaftAttributes{IsLearner: true}},
+		{ID: 6, Attributes: membership.Attributes{Name: "no-progress"}, RaftAttributes: membership.RaftAttributes{IsLearner: true}},
+	}
+	rs := raft.Status{
+		BasicStatus: raft.BasicStatus{ID: 1},
+		Progress: map[uint64]tracker.Progress{
+			1: {Match: 1000, RecentActive: true},
+			2: {Match: 800, RecentActive: true},
+			3: {Match: 1000, RecentActive: true},
+			4: {Match: 1000},
+			5: {Match: 990, RecentActive: true},
+		},
+	}
+
+	wlags := []learnerLag{
+		{id: 2, lag: 200},
+		{id: 3, lag: 0},
+		{id: 4, lag: 0},
+		{id: 5, lag: 10, ready: true},
+	}
+	if lags := learnerLags(members, rs, 100); !reflect.DeepEqual(lags, wlags) {
+		t.Errorf("learnerLags() = %+v, want %+v", lags, wlags)
+	}
+
+	// a learner within the max lag is not ready until within the readyPercent
+	// of MemberPromote
+	if lags := learnerLags(members, rs, 500); !reflect.DeepEqual(lags, wlags) {
+		t.Errorf("learnerLags() = %+v, want %+v", lags, wlags)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// memoryBudgetCheckInterval is the interval between two checks of the
+	// memory in use against the memory budget.
+	memoryBudgetCheckInterval = time.Second
+
+	// memoryPressureThreshold is the fraction of the memory budget in use
+	// from which the caches of the member start to shrink.
+	memoryPressureThreshold = 0.8
+
+	// minMemoryScale is the scale the caches shrink to when the memory in
+	// use reaches the memory budget.
+	minMemoryScale = 0.1
+)
+
+// memoryScale returns the scale, between minMemoryScale and 1, of their
+// configured sizes the caches are shrunk to with used bytes of the budget in
+// use. The caches shrink linearly from memoryPressureThreshold of the budget,
+// in steps of a tenth to avoid resizing them on every check.
+func memoryScale(used, budget uint64) float64 {
+	if budget == 0 {
+		return 1
+	}
+	pressure := (float64(used)/float64(budget) - memoryPressureThreshold) / (1 - memoryPressureThreshold)
+	if pressure <= 0 {
+		return 1
+	}
+	scale := math.Round((1-pressure)*10) / 10
+	if scale < minMemoryScale {
+		return minMemoryScale
+	}
+	return scale
+}
+
+// memoryInUse returns the memory mapped by the Go runtime and not released
+// to the operating system, which the soft memory limit accounts for.
+func memoryInUse() uint64 {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	for _, s := range samples {
+		if s.Value.Kind() != metrics.KindUint64 {
+			return 0
+		}
+	}
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+}
+
+// scaleToMemory scales n down to the memory scale of the member.
+func (s *EtcdServer) scaleToMemory(n uint64) uint64 {
+	bits := atomic.LoadUint64(&s.memoryScale)
+	if bits == 0 {
+		return n
+	}
+	return uint64(float64(n) * math.Float64frombits(bits))
+}
+
+// monitorMemoryBudget sets the soft memory limit of the Go runtime to the
+// memory budget of the member, and shrinks the caches of the member as the
+// memory in use approaches it.
+func (s *EtcdServer) monitorMemoryBudget() {
+	budget := s.Cfg.MemoryBudgetBytes
+	if budget == 0 {
+		return
+	}
+	lg := s.Logger()
+	lg.Info("setting soft memory limit to the memory budget", zap.Uint64("memory-budget", budget))
+	debug.SetMemoryLimit(int64(budget))
+	memoryBudgetBytes.Set(float64(budget))
+
+	for {
+		s.checkMemoryBudget(memoryInUse())
+		select {
+		case <-s.stopping:
+			return
+		case <-time.After(memoryBudgetCheckInterval):
+		}
+	}
+}
+
+func (s *EtcdServer) checkMemoryBudget(used uint64) {
+	budget := s.Cfg.MemoryBudgetBytes
+	scale := memoryScale(used, budget)
+	memoryBudgetUsedBytes.Set(float64(used))
+	memoryBudgetCacheScale.Set(scale)
+
+	if prev := atomic.SwapUint64(&s.memoryScale, math.Float64bits(scale)); prev != math.Float64bits(scale) {
+		lg := s.Logger()
+		if scale < 1 {
+			lg.Warn(
+				"memory in use is approaching the memory budget; shrinking caches",
+				zap.Uint64("memory-in-use", used),
+				zap.Uint64("memory-budget", budget),
+				zap.Float64("cache-scale", scale),
+			)
+		} else if prev != 0 {
+			lg.Info(
+				"memory in use is back within the memory budget; restoring caches",
+				zap.Uint64("memory-in-use", used),
+				zap.Uint64("memory-budget", budget),
+			)
+		}
+	}
+	s.KV().SetMemoryScale(scale)
+	s.Backend().SetMemoryScale(scale)
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"sync"
+	"testing"
+
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMemoryScale(t *testing.T) {
+	tests := []struct {
+		used   uint64
+		budget uint64
+
+		wscale float64
+	}{
+		{used: 900, budget: 0, wscale: 1},
+		{used: 0, budget: 1000, wscale: 1},
+		{used: 800, budget: 1000, wscale: 1},
+		{used: 850, budget: 1000, wscale: 0.8},
+		{used: 900, budget: 1000, wscale: 0.5},
+		{used: 990, budget: 1000, wscale: 0.1},
+		{used: 1000, budget: 1000, wscale: 0.1},
+		{used: 2000, budget: 1000, wscale: 0.1},
+	}
+	for i, tt := range tests {
+		if scale := memoryScale(tt.used, tt.budget); scale != tt.wscale {
+			t.Errorf("#%d: memoryScale(%d, %d) = %v, want %v", i, tt.used, tt.budget, scale, tt.wscale)
+		}
+	}
+}
+
+func TestCheckMemoryBudget(t *testing.T) {
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, be)
+
+	s := &EtcdServer{
+		lgMu: new(sync.RWMutex),
+		lg:   zaptest.NewLogger(t),
+		Cfg:  config.ServerConfig{MemoryBudgetBytes: 1000},
+		be:   be,
+	}
+	s.kv = mvcc.New(zaptest.NewLogger(t), be, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer s.kv.Close()
+
+	if n := s.scaleToMemory(100); n != 100 {
+		t.Fatalf("scaleToMemory(100) = %d before any check, want 100", n)
+	}
+	s.checkMemoryBudget(900)
+	if n := s.scaleToMemory(100); n != 50 {
+		t.Errorf("scaleToMemory(100) = %d under pressure, want 50", n)
+	}
+	s.checkMemoryBudget(100)
+	if n := s.scaleToMemory(100); n != 100 {
+		t.Errorf("scaleToMemory(100) = %d without pressure, want 100", n)
+	}
+}
@@ -64,6 +64,28 @@ var (
 		Name:      "learner_promote_successes",
 		Help:      "The total number of successful learner promotions while this member is leader.",
 	})
+	learnerAutoPromotions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "learner_auto_promotions_total",
+		Help:      "The total number of learner promotions attempted by --auto-promote-learners while this member is leader, by result.",
+	},
+		[]string{"Result"},
+	)
+	learnerLagEntries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "learner_lag_entries",
+		Help:      "The number of raft log entries the learners lag behind this member while it is leader with --auto-promote-learners.",
+	},
+		[]string{"Learner"},
+	)
+	degradedReads = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "degraded_reads_total",
+		Help:      "The total number of linearizable ranges served by falling back to a serializable read.",
+	})
 	heartbeatSendFailures = prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: "etcd",
 		Subsystem: "server",
@@ -100,6 +122,68 @@ var (
 		Name:      "proposals_failed_total",
 		Help:      "The total number of failed proposals seen.",
 	})
+	proposalsCoalesced = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "proposals_coalesced_total",
+		Help:      "The total number of proposals not proposed since an identical one was in flight.",
+	},
+		[]string{"Type"},
+	)
+	proposalsShed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "proposals_shed_total",
+		Help:      "The total number of writes rejected before proposing them since the member was overloaded.",
+	},
+		[]string{"Reason"},
+	)
+	autoCompactionsDeferred = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "auto_compactions_deferred_total",
+		Help:      "The total number of auto compactions deferred since the member was overloaded.",
+	},
+		[]string{"Reason"},
+	)
+	autoBackups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "auto_backups_total",
+		Help:      "The total number of auto backups of the member, by result.",
+	},
+		[]string{"Result"},
+	)
+	lastAutoBackupTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "last_auto_backup_timestamp_seconds",
+		Help:      "The time of the last successful auto backup of the member, in seconds since the epoch.",
+	})
+	requestsRateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "requests_rate_limited_total",
+		Help:      "The total number of requests rejected by the rate limit of a key prefix.",
+	},
+		[]string{"Prefix"},
+	)
+	watchersRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "watchers_rejected_total",
+		Help:      "The total number of watch create requests rejected by the limit of watchers per connection or per user.",
+	},
+		[]string{"limit"},
+	)
+	confChangesRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "conf_changes_rejected_total",
+		Help:      "The total number of configuration changes rejected by the leader before proposing them.",
+	},
+		[]string{"Reason"},
+	)
 	slowReadIndex = prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: "etcd",
 		Subsystem: "server",
@@ -112,12 +196,83 @@ var (
 		Name:      "read_indexes_failed_total",
 		Help:      "The total number of failed read indexes seen.",
 	})
+	raftLogRetainedBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "raft_log_retained_bytes",
+		Help:      "The size of the raft log entries held in memory.",
+	})
+	followerSnapshotCatchUps = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "follower_snapshot_catchups_total",
+		Help:      "The total number of snapshots sent to followers that fell behind the retained raft log.",
+	},
+		[]string{"To"},
+	)
+	certExpiryTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "certificate_expiry_timestamp_seconds",
+		Help:      "The expiry time of the serving, peer and CA certificates of the member, in seconds since the epoch.",
+	},
+		[]string{"type", "subject"},
+	)
+	memoryBudgetBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "memory_budget_bytes",
+		Help:      "The memory budget of the member in bytes, 0 when there is none.",
+	})
+	memoryBudgetUsedBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "memory_budget_used_bytes",
+		Help:      "The memory in use by the member counted against its memory budget.",
+	})
+	memoryBudgetCacheScale = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "memory_budget_cache_scale",
+		Help:      "The scale, between 0 and 1, of their configured sizes the internal caches are shrunk to under the memory budget.",
+	})
+	authenticateDurationSec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "authenticate_duration_seconds",
+		Help:      "The latency distributions of Authenticate requests by result.",
+
+		// lowest bucket start of upper bound 0.001 sec (1 ms) with factor 2
+		// highest bucket start of 0.001 sec * 2^13 == 8.192 sec
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+	},
+		[]string{"result"},
+	)
 	leaseExpired = prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: "etcd_debugging",
 		Subsystem: "server",
 		Name:      "lease_expired_total",
 		Help:      "The total number of expired leases.",
 	})
+	keyExpired = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "server",
+		Name:      "key_expired_total",
+		Help:      "The total number of keys deleted after their TTL expired.",
+	})
+	prefixTTLDeletedKeys = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "prefix_ttl_deleted_keys_total",
+		Help:      "The total number of keys deleted by the TTL policy of their prefix.",
+	},
+		[]string{"prefix"})
+	raftEntryCompressedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "raft_entry_compression_saved_bytes_total",
+		Help:      "The total number of bytes saved by the compression of the proposals.",
+	})
 
 	currentVersion = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "etcd",
@@ -160,20 +315,41 @@ func init() {
 	prometheus.MustRegister(isLeader)
 	prometheus.MustRegister(leaderChanges)
 	prometheus.MustRegister(heartbeatSendFailures)
+	prometheus.MustRegister(degradedReads)
 	prometheus.MustRegister(applySnapshotInProgress)
 	prometheus.MustRegister(proposalsCommitted)
 	prometheus.MustRegister(proposalsApplied)
 	prometheus.MustRegister(proposalsPending)
 	prometheus.MustRegister(proposalsFailed)
+	prometheus.MustRegister(proposalsCoalesced)
+	prometheus.MustRegister(proposalsShed)
+	prometheus.MustRegister(autoCompactionsDeferred)
+	prometheus.MustRegister(autoBackups)
+	prometheus.MustRegister(lastAutoBackupTimestamp)
+	prometheus.MustRegister(requestsRateLimited)
+	prometheus.MustRegister(watchersRejected)
+	prometheus.MustRegister(confChangesRejected)
 	prometheus.MustRegister(slowReadIndex)
 	prometheus.MustRegister(readIndexFailed)
+	prometheus.MustRegister(raftLogRetainedBytes)
+	prometheus.MustRegister(followerSnapshotCatchUps)
+	prometheus.MustRegister(certExpiryTimestamp)
+	prometheus.MustRegister(memoryBudgetBytes)
+	prometheus.MustRegister(memoryBudgetUsedBytes)
+	prometheus.MustRegister(memoryBudgetCacheScale)
+	prometheus.MustRegister(authenticateDurationSec)
 	prometheus.MustRegister(leaseExpired)
+	prometheus.MustRegister(keyExpired)
+	prometheus.MustRegister(prefixTTLDeletedKeys)
+	prometheus.MustRegister(raftEntryCompressedBytes)
 	prometheus.MustRegister(currentVersion)
 	prometheus.MustRegister(currentGoVersion)
 	prometheus.MustRegister(serverID)
 	prometheus.MustRegister(isLearner)
 	prometheus.MustRegister(learnerPromoteSucceed)
 	prometheus.MustRegister(learnerPromoteFailed)
+	prometheus.MustRegister(learnerAutoPromotions)
+	prometheus.MustRegister(learnerLagEntries)
 	prometheus.MustRegister(fdUsed)
 	prometheus.MustRegister(fdLimit)
 
@@ -0,0 +1,194 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+
+	"go.uber.org/zap"
+)
+
+// The keys do not record when they were modified, only at which revision.
+// Every member samples its current revision over time, so that the leader
+// tells the revision up to which the keys were modified before a point in
+// time, and deletes the keys under the prefix of a TTL policy modified at or
+// before the revision sampled a TTL ago. The revisions applied before a member
+// started are taken as modified when it started, which only delays the
+// deletion of the keys after a leader change.
+
+var (
+	// prefixTTLCheckInterval is the maximum interval at which the members
+	// sample their revision and the leader deletes the keys past the TTL of
+	// their prefix. Shorter TTLs are checked every tenth of the TTL.
+	prefixTTLCheckInterval = time.Minute
+
+	// prefixTTLScanBatch is the maximum number of keys read at once by the
+	// scan of a prefix.
+	prefixTTLScanBatch = 1000
+)
+
+// ParsePrefixTTLs parses TTL policies of the form "<prefix>=<ttl>", where ttl
+// is a duration such as "72h", or a number of days such as "30d".
+func ParsePrefixTTLs(ss []string) ([]config.PrefixTTL, error) {
+	var policies []config.PrefixTTL
+	for _, s := range ss {
+		i := strings.LastIndex(s, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid prefix TTL %q, expected <prefix>=<ttl>", s)
+		}
+		p := config.PrefixTTL{Prefix: s[:i]}
+		ttl := s[i+1:]
+		var err error
+		if days := strings.TrimSuffix(ttl, "d"); days != ttl {
+			var n int64
+			if n, err = strconv.ParseInt(days, 10, 64); err == nil {
+				p.TTL = time.Duration(n) * 24 * time.Hour
+			}
+		} else {
+			p.TTL, err = time.ParseDuration(ttl)
+		}
+		if err != nil || p.TTL <= 0 {
+			return nil, fmt.Errorf("invalid TTL in prefix TTL %q, expected a positive duration or number of days", s)
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+// revisionClock holds the revisions of a member sampled over time.
+type revisionClock struct {
+	mu sync.Mutex
+	// samples are in ascending time.
+	samples []revisionSample
+}
+
+type revisionSample struct {
+	t   time.Time
+	rev int64
+}
+
+// sample records rev as the revision at t, and forgets the samples older than
+// retention which are no longer needed to tell the revision at t-retention.
+func (c *revisionClock) sample(t time.Time, rev int64, retention time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.samples = append(c.samples, revisionSample{t: t, rev: rev})
+	oldest := t.Add(-retention)
+	i := 0
+	for i+1 < len(c.samples) && !c.samples[i+1].t.After(oldest) {
+		i++
+	}
+	c.samples = c.samples[i:]
+}
+
+// revisionAt returns the latest revision sampled at or before t, up to which
+// the keys were modified before t, or 0 if none was.
+func (c *revisionClock) revisionAt(t time.Time) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var rev int64
+	for _, sp := range c.samples {
+		if sp.t.After(t) {
+			break
+		}
+		rev = sp.rev
+	}
+	return rev
+}
+
+// monitorPrefixTTLs samples the revision of the member and, while it is the
+// leader, deletes the keys past the TTL of their prefix.
+func (s *EtcdServer) monitorPrefixTTLs() {
+	policies := s.Cfg.PrefixTTLs
+	if len(policies) == 0 {
+		return
+	}
+	interval, retention := prefixTTLCheckInterval, time.Duration(0)
+	for _, p := range policies {
+		if p.TTL/10 < interval {
+			interval = p.TTL / 10
+		}
+		if p.TTL > retention {
+			retention = p.TTL
+		}
+	}
+
+	var clock revisionClock
+	for {
+		now := time.Now()
+		clock.sample(now, s.KV().Rev(), retention)
+		if s.isLeader() {
+			for _, p := range policies {
+				if rev := clock.revisionAt(now.Add(-p.TTL)); rev > 0 {
+					s.deletePrefixBefore(p.Prefix, rev)
+				}
+			}
+		}
+		select {
+		case <-time.After(interval):
+		case <-s.stopping:
+			return
+		}
+	}
+}
+
+// deletePrefixBefore scans the keys under the prefix, and deletes in batches
+// the ones last modified at or before rev.
+func (s *EtcdServer) deletePrefixBefore(prefix string, rev int64) {
+	key, end := []byte(prefix), []byte(clientv3.GetPrefixRangeEnd(prefix))
+	for key != nil && s.isLeader() {
+		var (
+			keys    []mvcc.ExpiredKey
+			scanned int
+			next    []byte
+		)
+		txn := s.KV().Read(mvcc.ConcurrentReadTxMode, traceutil.TODO())
+		_, err := txn.RangeFunc(s.ctx, key, end, mvcc.RangeOptions{}, func(kv *mvccpb.KeyValue) bool {
+			scanned++
+			if kv.ModRevision <= rev {
+				keys = append(keys, mvcc.ExpiredKey{Key: kv.Key, ModRevision: kv.ModRevision})
+			}
+			if len(keys) == maxExpiredKeysPerTxn || scanned == prefixTTLScanBatch {
+				next = append(kv.Key, 0)
+				return false
+			}
+			return true
+		})
+		txn.End()
+		if err != nil {
+			s.Logger().Warn("failed to scan prefix for keys past their TTL", zap.String("prefix", prefix), zap.Error(err))
+			return
+		}
+		if len(keys) > 0 {
+			deleted, err := s.deleteUnmodifiedKeys(keys)
+			prefixTTLDeletedKeys.WithLabelValues(prefix).Add(float64(deleted))
+			if err != nil {
+				s.Logger().Warn("failed to delete keys past their TTL", zap.String("prefix", prefix), zap.Int("keys", len(keys)), zap.Error(err))
+				return
+			}
+		}
+		key = next
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/server/v3/config"
+)
+
+func TestParsePrefixTTLs(t *testing.T) {
+	tests := []struct {
+		policies []string
+		want     []config.PrefixTTL
+		wantErr  bool
+	}{
+		{policies: nil, want: nil},
+		{
+			policies: []string{"/logs/=30d", "/registry/=90m", "/a=b/=1s"},
+			want: []config.PrefixTTL{
+				{Prefix: "/logs/", TTL: 30 * 24 * time.Hour},
+				{Prefix: "/registry/", TTL: 90 * time.Minute},
+				{Prefix: "/a=b/", TTL: time.Second},
+			},
+		},
+		{policies: []string{"/logs/"}, wantErr: true},
+		{policies: []string{"=1d"}, wantErr: true},
+		{policies: []string{"/logs/=0d"}, wantErr: true},
+		{policies: []string{"/logs/=-1h"}, wantErr: true},
+		{policies: []string{"/logs/=xd"}, wantErr: true},
+		{policies: []string{"/logs/=10"}, wantErr: true},
+	}
+	for i, tt := range tests {
+		got, err := ParsePrefixTTLs(tt.policies)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("#%d: error = %v, want error %v", i, err, tt.wantErr)
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("#%d: policies = %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestRevisionClock(t *testing.T) {
+	var c revisionClock
+	start := time.Unix(1000, 0)
+	for i := 0; i < 10; i++ {
+		c.sample(start.Add(time.Duration(i)*time.Minute), int64(100+i*10), 5*time.Minute)
+	}
+	// the samples older than the retention but the newest one are forgotten
+	if len(c.samples) != 6 || c.samples[0].rev != 140 {
+		t.Fatalf("samples = %v, want the 6 samples from revision 140", c.samples)
+	}
+	tests := []struct {
+		t    time.Time
+		want int64
+	}{
+		{t: start.Add(3 * time.Minute), want: 0},
+		{t: start.Add(4 * time.Minute), want: 140},
+		{t: start.Add(4*time.Minute + 30*time.Second), want: 140},
+		{t: start.Add(7 * time.Minute), want: 170},
+		{t: start.Add(time.Hour), want: 190},
+	}
+	for i, tt := range tests {
+		if got := c.revisionAt(tt.t); got != tt.want {
+			t.Errorf("#%d: revisionAt(%v) = %d, want %d", i, tt.t, got, tt.want)
+		}
+	}
+}
@@ -73,6 +73,9 @@ type toApply struct {
 }
 
 type raftNode struct {
+	// logBytes is the size of the entries held in raftStorage.
+	logBytes uint64 // must use atomic operations to access; keep 64-bit aligned.
+
 	lg *zap.Logger
 
 	tickMu *sync.Mutex
@@ -138,6 +141,7 @@ func newRaftNode(cfg raftNodeConfig) *raftNode {
 		stopped:    make(chan struct{}),
 		done:       make(chan struct{}),
 	}
+	r.initLogBytes()
 	if r.heartbeat == 0 {
 		r.ticker = &time.Ticker{}
 	} else {
@@ -256,6 +260,7 @@ func (r *raftNode) start(rh *raftReadyHandler) {
 
 					// gofail: var raftBeforeApplySnap struct{}
 					r.raftStorage.ApplySnapshot(rd.Snapshot)
+					r.setLogBytes(0)
 					r.lg.Info("applied incoming Raft snapshot", zap.Uint64("snapshot-index", rd.Snapshot.Metadata.Index))
 					// gofail: var raftAfterApplySnap struct{}
 
@@ -265,7 +270,7 @@ func (r *raftNode) start(rh *raftReadyHandler) {
 					// gofail: var raftAfterWALRelease struct{}
 				}
 
-				r.raftStorage.Append(rd.Entries)
+				r.appendLog(rd.Entries)
 
 				if !islead {
 					// finish processing incoming messages before we signal raftdone chan
@@ -0,0 +1,65 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/server/v3/storage/compression"
+)
+
+// A proposal of at least Cfg.RaftEntryCompressionThreshold bytes is proposed
+// as an InternalRaftRequest whose compressed_request holds the zstd compressed
+// proposal, so that its raft entry is compressed on the wire to the peers and
+// in the WAL. Members decompress it on apply.
+
+// compressRaftRequest returns the proposal of the marshaled data, compressed
+// if at least the compression threshold and the whole cluster decompresses it.
+func (s *EtcdServer) compressRaftRequest(data []byte) ([]byte, error) {
+	threshold := s.Cfg.RaftEntryCompressionThreshold
+	if threshold <= 0 || len(data) < threshold {
+		return data, nil
+	}
+	if cv := s.ClusterVersion(); cv == nil || cv.LessThan(version.V3_6) {
+		return data, nil
+	}
+	compressed := pb.InternalRaftRequest{CompressedRequest: compression.ZstdEncode(data)}
+	cdata, err := compressed.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if len(cdata) >= len(data) {
+		return data, nil
+	}
+	raftEntryCompressedBytes.Add(float64(len(data) - len(cdata)))
+	return cdata, nil
+}
+
+// decompressRaftRequest replaces the request by the one it compresses, if any.
+func decompressRaftRequest(r *pb.InternalRaftRequest) error {
+	if r.CompressedRequest == nil {
+		return nil
+	}
+	data, err := compression.ZstdDecode(r.CompressedRequest)
+	if err != nil {
+		return err
+	}
+	var dr pb.InternalRaftRequest
+	if err = dr.Unmarshal(data); err != nil {
+		return err
+	}
+	*r = dr
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coreos/go-semver/semver"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/pkg/v3/pbutil"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+)
+
+func TestCompressRaftRequest(t *testing.T) {
+	r := pb.InternalRaftRequest{
+		Header: &pb.RequestHeader{ID: 1},
+		Put:    &pb.PutRequest{Key: []byte("foo"), Value: bytes.Repeat([]byte("bar"), 1000)},
+	}
+	data, err := r.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		threshold      int
+		clusterVersion *semver.Version
+		wantCompressed bool
+	}{
+		{name: "disabled", clusterVersion: &version.V3_6},
+		{name: "below threshold", threshold: len(data) + 1, clusterVersion: &version.V3_6},
+		{name: "cluster version unknown", threshold: 100},
+		{name: "cluster version 3.5", threshold: 100, clusterVersion: &version.V3_5},
+		{name: "compressed", threshold: 100, clusterVersion: &version.V3_6, wantCompressed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := membership.NewCluster(zaptest.NewLogger(t))
+			if tt.clusterVersion != nil {
+				cl.SetVersion(tt.clusterVersion, func(*zap.Logger, *semver.Version) {}, membership.ApplyBoth)
+			}
+			s := &EtcdServer{
+				Cfg:     config.ServerConfig{RaftEntryCompressionThreshold: tt.threshold},
+				cluster: cl,
+			}
+			got, err := s.compressRaftRequest(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if compressed := !bytes.Equal(got, data); compressed != tt.wantCompressed {
+				t.Fatalf("compressed = %v, want %v", compressed, tt.wantCompressed)
+			}
+			if tt.wantCompressed && len(got) >= len(data)/10 {
+				t.Errorf("compressed proposal of %d bytes, want less than %d", len(got), len(data)/10)
+			}
+
+			var dr pb.InternalRaftRequest
+			if !pbutil.MaybeUnmarshal(&dr, got) {
+				t.Fatal("failed to unmarshal the proposal")
+			}
+			if err = decompressRaftRequest(&dr); err != nil {
+				t.Fatal(err)
+			}
+			if dr.CompressedRequest != nil || dr.Header.ID != 1 || !bytes.Equal(dr.Put.Value, r.Put.Value) {
+				t.Errorf("decompressed request %v, want %v", dr.String(), r.String())
+			}
+		})
+	}
+}
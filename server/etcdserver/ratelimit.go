@@ -0,0 +1,274 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/etcdserver/errors"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitUserPrefix marks the rate limits applying to each user separately.
+const rateLimitUserPrefix = "user:"
+
+// ParseRateLimits parses rate limits of the form "[user:]<prefix>=<rate>[:<burst>]",
+// where rate is a number of requests per second and the "user:" qualifier
+// limits the requests of each authenticated user separately.
+func ParseRateLimits(ss []string) ([]*pb.RateLimit, error) {
+	var limits []*pb.RateLimit
+	for _, s := range ss {
+		i := strings.LastIndex(s, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid rate limit %q, expected [user:]<prefix>=<rate>[:<burst>]", s)
+		}
+		l := &pb.RateLimit{Prefix: []byte(s[:i])}
+		if bytes.HasPrefix(l.Prefix, []byte(rateLimitUserPrefix)) {
+			l.Prefix, l.PerUser = l.Prefix[len(rateLimitUserPrefix):], true
+		}
+		r, b := s[i+1:], ""
+		if j := strings.Index(r, ":"); j >= 0 {
+			r, b = r[:j], r[j+1:]
+		}
+		var err error
+		if l.Rate, err = strconv.ParseFloat(r, 64); err != nil || !(l.Rate > 0) || math.IsInf(l.Rate, 0) {
+			return nil, fmt.Errorf("invalid rate in rate limit %q, expected a positive number", s)
+		}
+		if b != "" {
+			if l.Burst, err = strconv.ParseUint(b, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid burst in rate limit %q, expected a non-negative integer", s)
+			}
+		}
+		limits = append(limits, l)
+	}
+	return limits, nil
+}
+
+type rateLimitKey struct {
+	prefix  string
+	perUser bool
+}
+
+// prefixRateLimit is a rate limit with the limiters counting its requests,
+// one for all clients or one per user.
+type prefixRateLimit struct {
+	limit *pb.RateLimit
+	all   *rate.Limiter
+	users map[string]*rate.Limiter
+}
+
+func (l *prefixRateLimit) limiter(user string) *rate.Limiter {
+	if !l.limit.PerUser {
+		return l.all
+	}
+	lim, ok := l.users[user]
+	if !ok {
+		lim = rate.NewLimiter(rateLimitLimit(l.limit), rateLimitBurst(l.limit))
+		l.users[user] = lim
+	}
+	return lim
+}
+
+// rateLimiter limits the rates of the requests to key prefixes. Each key is
+// limited by the limit of its longest prefix for all clients, and by the one
+// of its longest prefix per user.
+type rateLimiter struct {
+	mu     sync.Mutex
+	limits map[rateLimitKey]*prefixRateLimit
+}
+
+func newRateLimiter(limits []*pb.RateLimit) *rateLimiter {
+	rl := &rateLimiter{limits: make(map[rateLimitKey]*prefixRateLimit)}
+	rl.update(limits)
+	return rl
+}
+
+func rateLimitLimit(l *pb.RateLimit) rate.Limit {
+	return rate.Limit(l.Rate)
+}
+
+func rateLimitBurst(l *pb.RateLimit) int {
+	if l.Burst > 0 {
+		return int(l.Burst)
+	}
+	return int(math.Max(1, math.Ceil(l.Rate)))
+}
+
+// update sets the given limits, removing the ones with a zero rate.
+func (rl *rateLimiter) update(limits []*pb.RateLimit) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for _, l := range limits {
+		k := rateLimitKey{prefix: string(l.Prefix), perUser: l.PerUser}
+		if l.Rate <= 0 {
+			delete(rl.limits, k)
+			continue
+		}
+		l = &pb.RateLimit{Prefix: l.Prefix, Rate: l.Rate, Burst: l.Burst, PerUser: l.PerUser}
+		prl, ok := rl.limits[k]
+		if !ok {
+			prl = &prefixRateLimit{users: make(map[string]*rate.Limiter)}
+			if !l.PerUser {
+				prl.all = rate.NewLimiter(rateLimitLimit(l), rateLimitBurst(l))
+			}
+			rl.limits[k] = prl
+		}
+		// the limiters in use keep the requests they counted.
+		prl.limit = l
+		if prl.all != nil {
+			prl.all.SetLimit(rateLimitLimit(l))
+			prl.all.SetBurst(rateLimitBurst(l))
+		}
+		for _, lim := range prl.users {
+			lim.SetLimit(rateLimitLimit(l))
+			lim.SetBurst(rateLimitBurst(l))
+		}
+	}
+}
+
+// list returns the limits, sorted by prefix.
+func (rl *rateLimiter) list() []*pb.RateLimit {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	limits := make([]*pb.RateLimit, 0, len(rl.limits))
+	for _, prl := range rl.limits {
+		limits = append(limits, prl.limit)
+	}
+	sort.Slice(limits, func(i, j int) bool {
+		if c := bytes.Compare(limits[i].Prefix, limits[j].Prefix); c != 0 {
+			return c < 0
+		}
+		return !limits[i].PerUser && limits[j].PerUser
+	})
+	return limits
+}
+
+// allow counts a request to the given keys against the limits of their
+// prefixes, looking up the user of the request only if limited per user. It
+// returns the prefix of a limit the request exceeds, without counting it
+// against any limit, if any.
+func (rl *rateLimiter) allow(now time.Time, user func() string, keys [][]byte) (prefix string, ok bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if len(rl.limits) == 0 {
+		return "", true
+	}
+
+	var matched []*prefixRateLimit
+	for _, key := range keys {
+		var all, perUser *prefixRateLimit
+		for _, prl := range rl.limits {
+			if !bytes.HasPrefix(key, prl.limit.Prefix) {
+				continue
+			}
+			longest := &all
+			if prl.limit.PerUser {
+				longest = &perUser
+			}
+			if *longest == nil || len(prl.limit.Prefix) > len((*longest).limit.Prefix) {
+				*longest = prl
+			}
+		}
+		for _, prl := range []*prefixRateLimit{all, perUser} {
+			if prl != nil && !containsRateLimit(matched, prl) {
+				matched = append(matched, prl)
+			}
+		}
+	}
+
+	var username string
+	for _, prl := range matched {
+		if prl.limit.PerUser {
+			username = user()
+			break
+		}
+	}
+	reservations := make([]*rate.Reservation, 0, len(matched))
+	for _, prl := range matched {
+		r := prl.limiter(username).ReserveN(now, 1)
+		if !r.OK() || r.DelayFrom(now) > 0 {
+			r.CancelAt(now)
+			for _, rr := range reservations {
+				rr.CancelAt(now)
+			}
+			return string(prl.limit.Prefix), false
+		}
+		reservations = append(reservations, r)
+	}
+	return "", true
+}
+
+func containsRateLimit(prls []*prefixRateLimit, prl *prefixRateLimit) bool {
+	for _, p := range prls {
+		if p == prl {
+			return true
+		}
+	}
+	return false
+}
+
+// AdmitKeys rejects a request to the given keys exceeding the rate limit of
+// one of their prefixes, for all clients or for the user of the request.
+func (s *EtcdServer) AdmitKeys(ctx context.Context, keys [][]byte) error {
+	user := func() string {
+		// requests failing authentication are rejected by their handler, and
+		// share the limits of the unauthenticated requests meanwhile.
+		if ai, err := s.AuthInfoFromCtx(ctx); err == nil && ai != nil {
+			return ai.Username
+		}
+		return ""
+	}
+	if prefix, ok := s.rateLimiter.allow(time.Now(), user, keys); !ok {
+		requestsRateLimited.WithLabelValues(prefix).Inc()
+		return errors.ErrRateLimited
+	}
+	return nil
+}
+
+// RateLimits updates the rate limits of the requests to key prefixes of the
+// member and returns the limits in effect. The limits only last until the
+// member restarts.
+func (s *EtcdServer) RateLimits(ctx context.Context, r *pb.RateLimitsRequest) (*pb.RateLimitsResponse, error) {
+	if len(r.Limits) > 0 {
+		for _, l := range r.Limits {
+			if l.Rate < 0 || math.IsNaN(l.Rate) || math.IsInf(l.Rate, 0) {
+				return nil, errors.ErrInvalidRateLimit
+			}
+		}
+		s.rateLimiter.update(r.Limits)
+		for _, l := range r.Limits {
+			s.Logger().Info(
+				"updated rate limit",
+				zap.String("local-member-id", s.MemberId().String()),
+				zap.String("prefix", string(l.Prefix)),
+				zap.Float64("rate", l.Rate),
+				zap.Uint64("burst", l.Burst),
+				zap.Bool("per-user", l.PerUser),
+			)
+		}
+	}
+	return &pb.RateLimitsResponse{Limits: s.rateLimiter.list()}, nil
+}
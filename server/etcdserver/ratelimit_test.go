@@ -0,0 +1,113 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	tests := []struct {
+		limits  []string
+		want    []*pb.RateLimit
+		wantErr bool
+	}{
+		{limits: nil, want: nil},
+		{
+			limits: []string{"/a/=10", "user:/b/=0.5:3", "/c=d/=100:0"},
+			want: []*pb.RateLimit{
+				{Prefix: []byte("/a/"), Rate: 10},
+				{Prefix: []byte("/b/"), Rate: 0.5, Burst: 3, PerUser: true},
+				{Prefix: []byte("/c=d/"), Rate: 100},
+			},
+		},
+		{limits: []string{"=1"}, want: []*pb.RateLimit{{Prefix: []byte{}, Rate: 1}}},
+		{limits: []string{"/a/"}, wantErr: true},
+		{limits: []string{"/a/=0"}, wantErr: true},
+		{limits: []string{"/a/=-1"}, wantErr: true},
+		{limits: []string{"/a/=NaN"}, wantErr: true},
+		{limits: []string{"/a/=x"}, wantErr: true},
+		{limits: []string{"/a/=1:-1"}, wantErr: true},
+	}
+	for i, tt := range tests {
+		got, err := ParseRateLimits(tt.limits)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("#%d: error = %v, want error %v", i, err, tt.wantErr)
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("#%d: limits = %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	rl := newRateLimiter([]*pb.RateLimit{
+		{Prefix: []byte("/a/"), Rate: 1, Burst: 2},
+		{Prefix: []byte("/a/b/"), Rate: 1},
+		{Prefix: []byte("/u/"), Rate: 1, PerUser: true},
+	})
+	now := time.Unix(0, 0)
+	alice, bob := func() string { return "alice" }, func() string { return "bob" }
+
+	tests := []struct {
+		name       string
+		user       func() string
+		keys       []string
+		wantPrefix string
+		wantOK     bool
+	}{
+		{name: "unlimited key", user: alice, keys: []string{"/z"}, wantOK: true},
+		{name: "longest prefix", user: alice, keys: []string{"/a/b/1"}, wantOK: true},
+		{name: "longest prefix exhausted", user: alice, keys: []string{"/a/b/2"}, wantPrefix: "/a/b/"},
+		{name: "shorter prefix keeps its burst", user: alice, keys: []string{"/a/1"}, wantOK: true},
+		{name: "rejected key does not count against other limits", user: alice, keys: []string{"/a/1", "/a/b/1"}, wantPrefix: "/a/b/"},
+		{name: "shorter prefix burst", user: bob, keys: []string{"/a/2"}, wantOK: true},
+		{name: "shorter prefix exhausted for all users", user: bob, keys: []string{"/a/3"}, wantPrefix: "/a/"},
+		{name: "per user limit", user: alice, keys: []string{"/u/1"}, wantOK: true},
+		{name: "per user limit exhausted", user: alice, keys: []string{"/u/1"}, wantPrefix: "/u/"},
+		{name: "per user limit of another user", user: bob, keys: []string{"/u/1"}, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys [][]byte
+			for _, k := range tt.keys {
+				keys = append(keys, []byte(k))
+			}
+			prefix, ok := rl.allow(now, tt.user, keys)
+			if ok != tt.wantOK || prefix != tt.wantPrefix {
+				t.Errorf("allow = (%q, %v), want (%q, %v)", prefix, ok, tt.wantPrefix, tt.wantOK)
+			}
+		})
+	}
+
+	// the limits refill over time
+	if _, ok := rl.allow(now.Add(time.Second), alice, [][]byte{[]byte("/a/b/1")}); !ok {
+		t.Error("expected the limit to refill")
+	}
+
+	// removing a limit leaves the keys to the shorter prefixes
+	rl.update([]*pb.RateLimit{{Prefix: []byte("/a/b/"), Rate: 0}})
+	want := []*pb.RateLimit{
+		{Prefix: []byte("/a/"), Rate: 1, Burst: 2},
+		{Prefix: []byte("/u/"), Rate: 1, PerUser: true},
+	}
+	if got := rl.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("limits = %v, want %v", got, want)
+	}
+}
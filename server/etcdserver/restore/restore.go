@@ -0,0 +1,447 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restore
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/etcdserver"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v2store"
+	"go.etcd.io/etcd/server/v3/etcdserver/cindex"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+	"go.etcd.io/etcd/server/v3/storage/wal"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+	"go.etcd.io/etcd/server/v3/verify"
+	"go.uber.org/zap"
+)
+
+// Config configures a restore.
+type Config struct {
+	// SnapshotPath is the path of snapshot file to restore from.
+	SnapshotPath string
+	// Snapshot is the snapshot to restore from instead of the file at
+	// SnapshotPath, such as a snapshot streamed from a live member. The
+	// directories of a failed restore from Snapshot are removed, since a
+	// broken stream cannot be resumed.
+	Snapshot io.Reader
+
+	// Name is the human-readable name of this member.
+	Name string
+
+	// OutputDataDir is the target data directory to save restored data.
+	// OutputDataDir should not conflict with existing etcd data directory.
+	// If OutputDataDir already exists, it will return an error to prevent
+	// unintended data directory overwrites.
+	// If empty, defaults to "[Name].etcd" if not given.
+	OutputDataDir string
+	// OutputWALDir is the target WAL data directory.
+	// If empty, defaults to "[OutputDataDir]/member/wal" if not given.
+	OutputWALDir string
+
+	// PeerURLs is a list of member's peer URLs to advertise to the rest of the cluster.
+	PeerURLs []string
+
+	// InitialCluster is the initial cluster configuration for restore bootstrap.
+	InitialCluster string
+	// InitialClusterToken is the initial cluster token for etcd cluster during restore bootstrap.
+	InitialClusterToken string
+
+	// SkipHashCheck is "true" to ignore snapshot integrity hash value
+	// (required if copied from data directory).
+	SkipHashCheck bool
+
+	// SkipPrefixes is a list of key prefixes whose keys are not restored.
+	SkipPrefixes []string
+	// OnlyPrefixes is a list of key prefixes outside of which keys are not
+	// restored. If empty, keys of any prefix are restored.
+	OnlyPrefixes []string
+}
+
+type restorer struct {
+	lg *zap.Logger
+
+	name      string
+	srcDbPath string
+	src       io.Reader
+	walDir    string
+	snapDir   string
+	cl        *membership.RaftCluster
+
+	skipHashCheck bool
+	skipPrefixes  []string
+	onlyPrefixes  []string
+}
+
+// hasChecksum returns "true" if the file size "n"
+// has appended sha256 hash digest.
+func hasChecksum(n int64) bool {
+	// 512 is chosen because it's a minimum disk sector size
+	// smaller than (and multiplies to) OS page size in most systems
+	return (n % 512) == sha256.Size
+}
+
+// Restore restores a new etcd data directory from given snapshot file.
+// It returns an error if the data directory already exists and is not empty,
+// to prevent unintended data directory overwrites.
+func Restore(lg *zap.Logger, cfg Config) (err error) {
+	s := &restorer{lg: lg}
+	pURLs, err := types.NewURLs(cfg.PeerURLs)
+	if err != nil {
+		return err
+	}
+	var ics types.URLsMap
+	ics, err = types.NewURLsMap(cfg.InitialCluster)
+	if err != nil {
+		return err
+	}
+
+	srv := config.ServerConfig{
+		Logger:              s.lg,
+		Name:                cfg.Name,
+		PeerURLs:            pURLs,
+		InitialPeerURLsMap:  ics,
+		InitialClusterToken: cfg.InitialClusterToken,
+	}
+	if err = srv.VerifyBootstrap(); err != nil {
+		return err
+	}
+
+	s.cl, err = membership.NewClusterFromURLsMap(s.lg, cfg.InitialClusterToken, ics)
+	if err != nil {
+		return err
+	}
+
+	dataDir := cfg.OutputDataDir
+	if dataDir == "" {
+		dataDir = cfg.Name + ".etcd"
+	}
+	if fileutil.Exist(dataDir) && !fileutil.DirEmpty(dataDir) {
+		return fmt.Errorf("data-dir %q not empty or could not be read", dataDir)
+	}
+
+	walDir := cfg.OutputWALDir
+	if walDir == "" {
+		walDir = filepath.Join(dataDir, "member", "wal")
+	} else if fileutil.Exist(walDir) {
+		return fmt.Errorf("wal-dir %q exists", walDir)
+	}
+
+	if cfg.Snapshot != nil {
+		// the data-dir may exist if empty, only remove what the restore created
+		created := filepath.Join(dataDir, "member")
+		if !fileutil.Exist(dataDir) {
+			created = dataDir
+		}
+		defer func() {
+			if err != nil {
+				s.lg.Warn("removing the directories of the failed restore", zap.String("data-dir", dataDir), zap.String("wal-dir", walDir), zap.Error(err))
+				os.RemoveAll(walDir)
+				os.RemoveAll(created)
+			}
+		}()
+	}
+
+	s.name = cfg.Name
+	s.srcDbPath = cfg.SnapshotPath
+	s.src = cfg.Snapshot
+	s.walDir = walDir
+	s.snapDir = filepath.Join(dataDir, "member", "snap")
+	s.skipHashCheck = cfg.SkipHashCheck
+	s.skipPrefixes = cfg.SkipPrefixes
+	s.onlyPrefixes = cfg.OnlyPrefixes
+
+	s.lg.Info(
+		"restoring snapshot",
+		zap.String("path", s.srcDbPath),
+		zap.String("wal-dir", s.walDir),
+		zap.String("data-dir", dataDir),
+		zap.String("snap-dir", s.snapDir),
+	)
+
+	if err = s.saveDB(); err != nil {
+		return err
+	}
+	hardstate, err := s.saveWALAndSnap()
+	if err != nil {
+		return err
+	}
+
+	if err := s.updateCIndex(hardstate.Commit, hardstate.Term); err != nil {
+		return err
+	}
+
+	s.lg.Info(
+		"restored snapshot",
+		zap.String("path", s.srcDbPath),
+		zap.String("wal-dir", s.walDir),
+		zap.String("data-dir", dataDir),
+		zap.String("snap-dir", s.snapDir),
+	)
+
+	return verify.VerifyIfEnabled(verify.Config{
+		ExactIndex: true,
+		Logger:     s.lg,
+		DataDir:    dataDir,
+	})
+}
+
+func (s *restorer) outDbPath() string {
+	return filepath.Join(s.snapDir, "db")
+}
+
+// saveDB copies the database snapshot to the snapshot directory
+func (s *restorer) saveDB() error {
+	err := s.copyAndVerifyDB()
+	if err != nil {
+		return err
+	}
+
+	be := backend.NewDefaultBackend(s.lg, s.outDbPath())
+	defer be.Close()
+
+	mb := schema.NewMembershipBackend(s.lg, be)
+	err = mb.TrimMembershipFromBackend()
+	if err != nil {
+		return err
+	}
+
+	// fence off the members and clients of the cluster the snapshot was taken from
+	mb.MustCreateBackendBuckets()
+	epoch := mb.ClusterEpochFromBackend() + 1
+	mb.MustSaveClusterEpochToBackend(epoch)
+	s.lg.Info("bumped cluster epoch", zap.Uint64("cluster-epoch", epoch))
+
+	if len(s.skipPrefixes) > 0 || len(s.onlyPrefixes) > 0 {
+		s.lg.Info(
+			"filtering restored keys",
+			zap.Strings("skip-prefixes", s.skipPrefixes),
+			zap.Strings("only-prefixes", s.onlyPrefixes),
+		)
+		if _, err = mvcc.FilterKeys(s.lg, be, s.keepKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keepKey returns true if the key is to be restored given the skipped
+// prefixes and the only prefixes to restore.
+func (s *restorer) keepKey(key []byte) bool {
+	k := string(key)
+	for _, p := range s.skipPrefixes {
+		if strings.HasPrefix(k, p) {
+			return false
+		}
+	}
+	if len(s.onlyPrefixes) == 0 {
+		return true
+	}
+	for _, p := range s.onlyPrefixes {
+		if strings.HasPrefix(k, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *restorer) copyAndVerifyDB() error {
+	src := s.src
+	if src == nil {
+		srcf, ferr := os.Open(s.srcDbPath)
+		if ferr != nil {
+			return ferr
+		}
+		defer srcf.Close()
+		src = srcf
+	}
+
+	if err := fileutil.CreateDirAll(s.lg, s.snapDir); err != nil {
+		return err
+	}
+
+	outDbPath := s.outDbPath()
+
+	db, dberr := os.OpenFile(outDbPath, os.O_RDWR|os.O_CREATE, 0600)
+	if dberr != nil {
+		return dberr
+	}
+	dbClosed := false
+	defer func() {
+		if !dbClosed {
+			db.Close()
+			dbClosed = true
+		}
+	}()
+	if _, err := io.Copy(db, src); err != nil {
+		return err
+	}
+
+	// get snapshot integrity hash, if any, and truncate it away.
+	off, serr := db.Seek(0, io.SeekEnd)
+	if serr != nil {
+		return serr
+	}
+	hasHash := hasChecksum(off)
+	sha := make([]byte, sha256.Size)
+	if hasHash {
+		if _, err := db.ReadAt(sha, off-sha256.Size); err != nil {
+			return err
+		}
+		if err := db.Truncate(off - sha256.Size); err != nil {
+			return err
+		}
+	}
+
+	if !hasHash && !s.skipHashCheck {
+		return fmt.Errorf("snapshot missing hash but --skip-hash-check=false")
+	}
+
+	if hasHash && !s.skipHashCheck {
+		// check for match
+		if _, err := db.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, db); err != nil {
+			return err
+		}
+		dbsha := h.Sum(nil)
+		if !reflect.DeepEqual(sha, dbsha) {
+			return fmt.Errorf("expected sha256 %v, got %v", sha, dbsha)
+		}
+	}
+
+	// db hash is OK, can now modify DB so it can be part of a new cluster
+	db.Close()
+	return nil
+}
+
+// saveWALAndSnap creates a WAL for the initial cluster
+//
+// TODO: This code ignores learners !!!
+func (s *restorer) saveWALAndSnap() (*raftpb.HardState, error) {
+	if err := fileutil.CreateDirAll(s.lg, s.walDir); err != nil {
+		return nil, err
+	}
+
+	// add members again to persist them to the store we create.
+	st := v2store.New(etcdserver.StoreClusterPrefix, etcdserver.StoreKeysPrefix)
+	s.cl.SetStore(st)
+	be := backend.NewDefaultBackend(s.lg, s.outDbPath())
+	defer be.Close()
+	s.cl.SetBackend(schema.NewMembershipBackend(s.lg, be))
+	for _, m := range s.cl.Members() {
+		s.cl.AddMember(m, true)
+	}
+
+	m := s.cl.MemberByName(s.name)
+	md := &etcdserverpb.Metadata{NodeID: uint64(m.ID), ClusterID: uint64(s.cl.ID())}
+	metadata, merr := md.Marshal()
+	if merr != nil {
+		return nil, merr
+	}
+	w, walerr := wal.Create(s.lg, s.walDir, metadata)
+	if walerr != nil {
+		return nil, walerr
+	}
+	defer w.Close()
+
+	peers := make([]raft.Peer, len(s.cl.MemberIDs()))
+	for i, id := range s.cl.MemberIDs() {
+		ctx, err := json.Marshal((*s.cl).Member(id))
+		if err != nil {
+			return nil, err
+		}
+		peers[i] = raft.Peer{ID: uint64(id), Context: ctx}
+	}
+
+	ents := make([]raftpb.Entry, len(peers))
+	nodeIDs := make([]uint64, len(peers))
+	for i, p := range peers {
+		nodeIDs[i] = p.ID
+		cc := raftpb.ConfChange{
+			Type:    raftpb.ConfChangeAddNode,
+			NodeID:  p.ID,
+			Context: p.Context,
+		}
+		d, err := cc.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		ents[i] = raftpb.Entry{
+			Type:  raftpb.EntryConfChange,
+			Term:  1,
+			Index: uint64(i + 1),
+			Data:  d,
+		}
+	}
+
+	commit, term := uint64(len(ents)), uint64(1)
+	hardState := raftpb.HardState{
+		Term:   term,
+		Vote:   peers[0].ID,
+		Commit: commit,
+	}
+	if err := w.Save(hardState, ents); err != nil {
+		return nil, err
+	}
+
+	b, berr := st.Save()
+	if berr != nil {
+		return nil, berr
+	}
+	confState := raftpb.ConfState{
+		Voters: nodeIDs,
+	}
+	raftSnap := raftpb.Snapshot{
+		Data: b,
+		Metadata: raftpb.SnapshotMetadata{
+			Index:     commit,
+			Term:      term,
+			ConfState: confState,
+		},
+	}
+	sn := snap.New(s.lg, s.snapDir)
+	if err := sn.SaveSnap(raftSnap); err != nil {
+		return nil, err
+	}
+	snapshot := walpb.Snapshot{Index: commit, Term: term, ConfState: &confState}
+	return &hardState, w.SaveSnapshot(snapshot)
+}
+
+func (s *restorer) updateCIndex(commit uint64, term uint64) error {
+	be := backend.NewDefaultBackend(s.lg, s.outDbPath())
+	defer be.Close()
+
+	cindex.UpdateConsistentIndexForce(be.BatchTx(), commit, term)
+	return nil
+}
@@ -65,11 +65,13 @@ import (
 	"go.etcd.io/etcd/server/v3/etcdserver/cindex"
 	serverversion "go.etcd.io/etcd/server/v3/etcdserver/version"
 	"go.etcd.io/etcd/server/v3/lease"
+	"go.etcd.io/etcd/server/v3/lease/leasehook"
 	"go.etcd.io/etcd/server/v3/lease/leasehttp"
 	serverstorage "go.etcd.io/etcd/server/v3/storage"
 	"go.etcd.io/etcd/server/v3/storage/backend"
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
 	"go.etcd.io/etcd/server/v3/storage/schema"
+	"go.etcd.io/etcd/server/v3/storage/wal"
 )
 
 const (
@@ -100,6 +102,9 @@ const (
 	// maxPendingRevokes is the maximum number of outstanding expired lease revocations.
 	maxPendingRevokes = 16
 
+	// maxExpiredKeysPerTxn is the maximum number of expired keys deleted by a txn.
+	maxExpiredKeysPerTxn = 128
+
 	recommendedMaxRequestBytes = 10 * 1024 * 1024
 
 	readyPercent = 0.9
@@ -113,6 +118,14 @@ var (
 	// (since it will timeout).
 	monitorVersionInterval = rafthttp.ConnWriteTimeout - time.Second
 
+	// pinExpiryCheckInterval is the interval at which the leader unpins the
+	// expired pins.
+	pinExpiryCheckInterval = time.Second
+
+	// keyExpiryCheckInterval is the interval at which the leader deletes the
+	// keys whose TTL expired.
+	keyExpiryCheckInterval = 500 * time.Millisecond
+
 	recommendedMaxRequestBytesString = humanize.Bytes(uint64(recommendedMaxRequestBytes))
 	storeMemberAttributeRegexp       = regexp.MustCompile(path.Join(membership.StoreMembersPrefix, "[[:xdigit:]]{1,16}", "attributes"))
 )
@@ -223,6 +236,10 @@ type EtcdServer struct {
 	lg   *zap.Logger
 
 	w wait.Wait
+	// confChanges and leaseCheckpoints coalesce the identical proposals
+	// made concurrently.
+	confChanges      proposalCoalescer
+	leaseCheckpoints proposalCoalescer
 
 	readMu sync.RWMutex
 	// read routine notifies etcd server that it waits for reading by sending an empty struct to
@@ -231,6 +248,10 @@ type EtcdServer struct {
 	// readNotifier is used to notify the read routine that it can process the request
 	// when there is no error
 	readNotifier *notifier
+	// readConfirmedAt is the time, in unix nanoseconds, the applied state of
+	// the member was last confirmed up to date by a linearizable read. Must
+	// use atomic operations to access.
+	readConfirmedAt int64
 
 	// stop signals the run goroutine should shutdown.
 	stop chan struct{}
@@ -238,6 +259,10 @@ type EtcdServer struct {
 	stopping chan struct{}
 	// done is closed when all goroutines from start() complete.
 	done chan struct{}
+	// draining is closed by Drain before the server is stopped, to hand the
+	// client streams over to other members.
+	draining  chan struct{}
+	drainOnce sync.Once
 	// leaderChanged is used to notify the linearizable read loop to drop the old read requests.
 	leaderChanged *notify.Notifier
 
@@ -263,6 +288,11 @@ type EtcdServer struct {
 	beHooks    *serverstorage.BackendHooks
 	authStore  auth.AuthStore
 	alarmStore *v3alarm.AlarmStore
+	// pinStore holds the revisions pinned against the auto compaction.
+	pinStore *v3compactor.PinStore
+
+	// leaseRevokeWebhook posts revoked leases to an external endpoint, if configured.
+	leaseRevokeWebhook *leasehook.Webhook
 
 	stats  *stats.ServerStats
 	lstats *stats.LeaderStats
@@ -297,6 +327,40 @@ type EtcdServer struct {
 	// Should only be set within apply code path. Used to force snapshot after cluster version downgrade.
 	forceSnapshot     bool
 	corruptionChecker CorruptionChecker
+
+	// slowFollowers counts the snapshots sent to followers to raise an alarm
+	// on the ones that repeatedly fall behind.
+	slowFollowers *slowFollowers
+
+	// snapshotCount and snapshotCatchUpEntries override Cfg.SnapshotCount and
+	// Cfg.SnapshotCatchUpEntries when updated at runtime. Accessed atomically,
+	// updates are serialized by snapshotSettingsMu.
+	snapshotSettingsMu     sync.Mutex
+	snapshotCount          uint64
+	snapshotCatchUpEntries uint64
+
+	// standbyRevision is the latest revision of the primary cluster mirrored
+	// by the member. Accessed atomically.
+	standbyRevision int64
+
+	// memoryScale holds the bits of the float64 scale the caches of the
+	// member are shrunk to under the memory budget, 0 when they are not.
+	// Accessed atomically.
+	memoryScale uint64
+
+	// pendingWrites is the number of writes of clients proposed by the
+	// member and not applied yet. Accessed atomically.
+	pendingWrites int64
+
+	// proposalLatencies are the latencies of the recent proposals of the
+	// member applied.
+	proposalLatencies latencyWindow
+
+	// rateLimiter limits the rates of the requests to key prefixes.
+	rateLimiter *rateLimiter
+
+	// watcherLimiter limits the watchers per client connection and per user.
+	watcherLimiter *WatcherLimiter
 }
 
 // NewServer creates a new EtcdServer from the supplied configuration. The
@@ -319,6 +383,7 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 	heartbeat := time.Duration(cfg.TickMs) * time.Millisecond
 	srv = &EtcdServer{
 		readych:               make(chan struct{}),
+		draining:              make(chan struct{}),
 		Cfg:                   cfg,
 		lgMu:                  new(sync.RWMutex),
 		lg:                    cfg.Logger,
@@ -338,6 +403,9 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 		consistIndex:          b.storage.backend.ci,
 		firstCommitInTerm:     notify.NewNotifier(),
 		clusterVersionChanged: notify.NewNotifier(),
+		slowFollowers:         newSlowFollowers(cfg.SlowFollowerAlarmThreshold, slowFollowerWindow),
+		rateLimiter:           newRateLimiter(cfg.RateLimits),
+		watcherLimiter:        newWatcherLimiter(cfg.MaxWatchersPerConnection, cfg.MaxWatchersPerUser),
 	}
 	serverID.With(prometheus.Labels{"server_id": b.cluster.nodeID.String()}).Set(1)
 	srv.cluster.SetVersionChangedNotifier(srv.clusterVersionChanged)
@@ -347,6 +415,12 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 	srv.beHooks = b.storage.backend.beHooks
 	minTTL := time.Duration((3*cfg.ElectionTicks)/2) * heartbeat
 
+	revokeHooks := append([]lease.RevokeHook{}, cfg.LeaseRevokeHooks...)
+	if cfg.LeaseRevokeWebhookURL != "" {
+		srv.leaseRevokeWebhook = leasehook.NewWebhook(cfg.Logger, cfg.LeaseRevokeWebhookURL, srv.isLeader)
+		revokeHooks = append(revokeHooks, srv.leaseRevokeWebhook.Notify)
+	}
+
 	// always recover lessor before kv. When we recover the mvcc.KV it will reattach keys to its leases.
 	// If we recover mvcc.KV first, it will attach the keys to the wrong lessor before it recovers.
 	srv.lessor = lease.NewLessor(srv.Logger(), srv.be, srv.cluster, lease.LessorConfig{
@@ -354,6 +428,7 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 		CheckpointInterval:         cfg.LeaseCheckpointInterval,
 		CheckpointPersist:          cfg.LeaseCheckpointPersist,
 		ExpiredLeasesRetryInterval: srv.Cfg.ReqTimeout(),
+		RevokeHooks:                revokeHooks,
 	})
 
 	tp, err := auth.NewTokenProvider(cfg.Logger, cfg.AuthToken,
@@ -370,6 +445,11 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 	mvccStoreConfig := mvcc.StoreConfig{
 		CompactionBatchLimit:    cfg.CompactionBatchLimit,
 		CompactionSleepInterval: cfg.CompactionSleepInterval,
+		CompactionBatchLatency:  cfg.CompactionBatchLatency,
+		WatchEventCacheSize:     cfg.WatchEventCacheSize,
+		KeyPrefixBuckets:        cfg.KeyPrefixBuckets,
+		EventPrefixMetrics:      cfg.WatchEventPrefixMetrics,
+		EventPrefixMetricsDepth: cfg.WatchEventPrefixMetricsDepth,
 	}
 	srv.kv = mvcc.New(srv.Logger(), srv.be, srv.lessor, mvccStoreConfig)
 	srv.corruptionChecker = newCorruptionChecker(cfg.Logger, srv, srv.kv.HashStorage())
@@ -384,8 +464,12 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 			newSrv.kv.Close()
 		}
 	}()
+	srv.pinStore, err = v3compactor.NewPinStore(srv.Logger(), schema.NewPinBackend(srv.Logger(), srv.be))
+	if err != nil {
+		return nil, err
+	}
 	if num := cfg.AutoCompactionRetention; num != 0 {
-		srv.compactor, err = v3compactor.New(cfg.Logger, cfg.AutoCompactionMode, num, srv.kv, srv)
+		srv.compactor, err = v3compactor.New(cfg.Logger, cfg.AutoCompactionMode, num, srv.kv, &gatedCompactable{s: srv, c: v3compactor.NewPinnedCompactable(cfg.Logger, srv, srv.pinStore)})
 		if err != nil {
 			return nil, err
 		}
@@ -400,7 +484,10 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 	if srv.Cfg.EnableLeaseCheckpoint {
 		// setting checkpointer enables lease checkpoint feature.
 		srv.lessor.SetCheckpointer(func(ctx context.Context, cp *pb.LeaseCheckpointRequest) {
-			srv.raftRequestOnce(ctx, pb.InternalRaftRequest{LeaseCheckpoint: cp})
+			// concurrent keepalives of a lease request identical checkpoints
+			srv.leaseCheckpoints.do(ctx, "LeaseCheckpoint", string(pbutil.MustMarshal(cp)), func() (interface{}, error) {
+				return srv.raftRequestOnce(ctx, pb.InternalRaftRequest{LeaseCheckpoint: cp})
+			})
 		})
 	}
 
@@ -421,6 +508,13 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 		ServerStats: sstats,
 		LeaderStats: lstats,
 		ErrorC:      srv.errorc,
+
+		ClusterEpoch:          b.cluster.cl,
+		SnapshotSendRateLimit: cfg.SnapshotSendRateLimit,
+
+		MsgAppBatchBytes:         cfg.PeerMsgAppBatchBytes,
+		LearnerHeartbeatInterval: cfg.LearnerHeartbeatInterval,
+		Learners:                 b.cluster.cl,
 	}
 	if err = tr.Start(); err != nil {
 		return nil, err
@@ -533,7 +627,16 @@ func (s *EtcdServer) Start() {
 	s.GoAttach(s.linearizableReadLoop)
 	s.GoAttach(s.monitorKVHash)
 	s.GoAttach(s.monitorCompactHash)
+	s.GoAttach(s.monitorPinExpiry)
+	s.GoAttach(s.monitorKeyExpiry)
+	s.GoAttach(s.monitorPrefixTTLs)
 	s.GoAttach(s.monitorDowngrade)
+	s.GoAttach(s.monitorCertExpiry)
+	s.GoAttach(s.monitorSlowFollowers)
+	s.GoAttach(s.monitorMemoryBudget)
+	s.GoAttach(s.monitorLearnerPromotion)
+	s.GoAttach(s.monitorAutoBackup)
+	s.GoAttach(s.mirrorPrimary)
 }
 
 // start prepares and starts server in a new goroutine. It is no longer safe to
@@ -626,6 +729,9 @@ func (s *EtcdServer) purgeFile() {
 
 func (s *EtcdServer) Cluster() api.Cluster { return s.cluster }
 
+// ClusterEpoch returns the epoch of the cluster, 0 if the member has not yet learned it.
+func (s *EtcdServer) ClusterEpoch() uint64 { return s.cluster.Epoch() }
+
 func (s *EtcdServer) ApplyWait() <-chan struct{} { return s.applyWait.Wait(s.getCommittedIndex()) }
 
 type ServerPeer interface {
@@ -784,6 +890,9 @@ func (s *EtcdServer) run() {
 					s.leadTimeMu.Unlock()
 				}
 				setSyncC(s.SyncTicker.C)
+				if s.pinStore != nil {
+					s.pinStore.Renew()
+				}
 				if s.compactor != nil {
 					s.compactor.Resume()
 				}
@@ -903,6 +1012,9 @@ func (s *EtcdServer) Cleanup() {
 	if s.lessor != nil {
 		s.lessor.Stop()
 	}
+	if s.leaseRevokeWebhook != nil {
+		s.leaseRevokeWebhook.Stop()
+	}
 	if s.kv != nil {
 		s.kv.Close()
 	}
@@ -1036,6 +1148,16 @@ func (s *EtcdServer) applySnapshot(ep *etcdProgress, toApply *toApply) {
 
 	lg.Info("restored alarm store")
 
+	if s.pinStore != nil {
+		lg.Info("restoring pin store")
+
+		if err := s.pinStore.Recover(schema.NewPinBackend(lg, newbe)); err != nil {
+			lg.Panic("failed to restore pin store", zap.Error(err))
+		}
+
+		lg.Info("restored pin store")
+	}
+
 	if s.authStore != nil {
 		lg.Info("restoring auth store")
 
@@ -1090,7 +1212,7 @@ func (s *EtcdServer) applySnapshot(ep *etcdProgress, toApply *toApply) {
 }
 
 func (s *EtcdServer) NewUberApplier() apply.UberApplier {
-	return apply.NewUberApplier(s.lg, s.be, s.KV(), s.alarmStore, s.authStore, s.lessor, s.cluster, s, s, s.consistIndex,
+	return apply.NewUberApplier(s.lg, s.be, s.KV(), s.alarmStore, s.pinStore, s.authStore, s.lessor, s.cluster, s, s, s.consistIndex,
 		s.Cfg.WarningApplyDuration, s.Cfg.ExperimentalTxnModeWriteWithSharedBuffer, s.Cfg.QuotaBackendBytes)
 }
 
@@ -1142,7 +1264,7 @@ func (s *EtcdServer) triggerSnapshot(ep *etcdProgress) {
 		zap.String("local-member-id", s.MemberId().String()),
 		zap.Uint64("local-member-applied-index", ep.appliedi),
 		zap.Uint64("local-member-snapshot-index", ep.snapi),
-		zap.Uint64("local-member-snapshot-count", s.Cfg.SnapshotCount),
+		zap.Uint64("local-member-snapshot-count", s.getSnapshotCount()),
 		zap.Bool("snapshot-forced", s.forceSnapshot),
 	)
 	s.forceSnapshot = false
@@ -1152,7 +1274,8 @@ func (s *EtcdServer) triggerSnapshot(ep *etcdProgress) {
 }
 
 func (s *EtcdServer) shouldSnapshot(ep *etcdProgress) bool {
-	return (s.forceSnapshot && ep.appliedi != ep.snapi) || (ep.appliedi-ep.snapi > s.Cfg.SnapshotCount)
+	return (s.forceSnapshot && ep.appliedi != ep.snapi) || (ep.appliedi-ep.snapi > s.getSnapshotCount()) ||
+		(ep.appliedi != ep.snapi && s.raftLogOverRetention())
 }
 
 func (s *EtcdServer) hasMultipleVotingMembers() bool {
@@ -1280,6 +1403,18 @@ func (s *EtcdServer) StopNotify() <-chan struct{} { return s.done }
 // when the server is being stopped.
 func (s *EtcdServer) StoppingNotify() <-chan struct{} { return s.stopping }
 
+// Drain starts handing the client streams over to other members before the
+// server is stopped: the streams serving clients, except snapshot streams,
+// are closed and new ones are rejected, so that clients re-establish them on
+// other members.
+func (s *EtcdServer) Drain() {
+	s.drainOnce.Do(func() { close(s.draining) })
+}
+
+// DrainingNotify returns a channel that is closed when the server starts
+// draining its client streams.
+func (s *EtcdServer) DrainingNotify() <-chan struct{} { return s.draining }
+
 func (s *EtcdServer) checkMembershipOperationPermission(ctx context.Context) error {
 	if s.authStore == nil {
 		// In the context of ordinary etcd process, s.authStore will never be nil.
@@ -1428,7 +1563,12 @@ func (s *EtcdServer) promoteMember(ctx context.Context, id uint64) ([]*membershi
 	if err := s.checkMembershipOperationPermission(ctx); err != nil {
 		return nil, err
 	}
+	return s.proposePromoteMember(ctx, id)
+}
 
+// proposePromoteMember checks whether the learner can be promoted and
+// proposes its promotion, without checking the permission of the requester.
+func (s *EtcdServer) proposePromoteMember(ctx context.Context, id uint64) ([]*membership.Member, error) {
 	// check if we can promote this learner.
 	if err := s.mayPromoteMember(types.ID(id)); err != nil {
 		return nil, err
@@ -1639,6 +1779,62 @@ type confChangeResponse struct {
 // then waits for it to be applied to the server. It
 // will block until the change is performed or there is an error.
 func (s *EtcdServer) configure(ctx context.Context, cc raftpb.ConfChange) ([]*membership.Member, error) {
+	if err := s.mayConfigure(cc); err != nil {
+		return nil, err
+	}
+	membs, err := s.confChanges.do(ctx, "ConfChange", confChangeKey(cc), func() (interface{}, error) {
+		return s.proposeConfChange(ctx, cc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return membs.([]*membership.Member), nil
+}
+
+// mayConfigure rejects the configuration changes that would be rejected
+// when applied, so that they do not reach the raft log. The check is only
+// made on a leader that applied all committed entries, whose membership
+// is then the one the change would be applied to.
+func (s *EtcdServer) mayConfigure(cc raftpb.ConfChange) error {
+	if !s.isLeader() || s.getAppliedIndex() < s.getCommittedIndex() {
+		return nil
+	}
+	err := s.cluster.ValidateConfigurationChange(cc)
+	if err != nil {
+		s.Logger().Warn(
+			"rejecting configuration change before proposing it",
+			zap.String("local-member-id", s.MemberId().String()),
+			zap.String("raft-conf-change", cc.Type.String()),
+			zap.String("raft-conf-change-node-id", types.ID(cc.NodeID).String()),
+			zap.Error(err),
+		)
+		confChangesRejected.WithLabelValues(confChangeRejectedReason(err)).Inc()
+	}
+	return err
+}
+
+// confChangeRejectedReason returns the reason label of a configuration change
+// rejected with err.
+func confChangeRejectedReason(err error) string {
+	switch err {
+	case membership.ErrIDRemoved:
+		return "id_removed"
+	case membership.ErrIDExists:
+		return "id_exists"
+	case membership.ErrIDNotFound:
+		return "id_not_found"
+	case membership.ErrPeerURLexists:
+		return "peer_url_exists"
+	case membership.ErrMemberNotLearner:
+		return "member_not_learner"
+	case membership.ErrTooManyLearners:
+		return "too_many_learners"
+	default:
+		return "other"
+	}
+}
+
+func (s *EtcdServer) proposeConfChange(ctx context.Context, cc raftpb.ConfChange) ([]*membership.Member, error) {
 	lg := s.Logger()
 	cc.ID = s.reqIDGen.Next()
 	ch := s.w.Register(cc.ID)
@@ -1761,6 +1957,12 @@ func (s *EtcdServer) sendMergedSnap(merged snap.Message) {
 	s.r.transport.SendSnapshot(merged)
 	lg.Info("sending merged snapshot", fields...)
 
+	to := types.ID(merged.To)
+	followerSnapshotCatchUps.WithLabelValues(to.String()).Inc()
+	if s.slowFollowers.record(to, now) {
+		s.triggerSlowFollowerAlarm(to)
+	}
+
 	s.GoAttach(func() {
 		select {
 		case ok := <-merged.CloseNotify():
@@ -1882,6 +2084,9 @@ func (s *EtcdServer) applyEntryNormal(e *raftpb.Entry) {
 		s.w.Trigger(r.ID, s.applyV2Request((*RequestV2)(rp), shouldApplyV3))
 		return
 	}
+	if err := decompressRaftRequest(&raftReq); err != nil {
+		s.lg.Panic("failed to decompress raft request", zap.Uint64("entry-index", e.Index), zap.Error(err))
+	}
 	s.lg.Debug("applyEntryNormal", zap.Stringer("raftReq", &raftReq))
 
 	if raftReq.V2 != nil {
@@ -1903,6 +2108,11 @@ func (s *EtcdServer) applyEntryNormal(e *raftpb.Entry) {
 		if !needResult && raftReq.Txn != nil {
 			removeNeedlessRangeReqs(raftReq.Txn)
 		}
+		if shouldApplyV3 {
+			if span := s.startApplySpan(&raftReq, e.Index); span != nil {
+				defer span.End()
+			}
+		}
 		ar = s.uberApply.Apply(&raftReq, shouldApplyV3)
 	}
 
@@ -2015,6 +2225,7 @@ func (s *EtcdServer) applyConfChange(cc raftpb.ConfChange, confState *raftpb.Con
 	case raftpb.ConfChangeRemoveNode:
 		id := types.ID(cc.NodeID)
 		s.cluster.RemoveMember(id, shouldApplyV3)
+		s.bumpEpochOnMemberReplacement(shouldApplyV3)
 		if id == s.MemberId() {
 			return true, nil
 		}
@@ -2033,6 +2244,7 @@ func (s *EtcdServer) applyConfChange(cc raftpb.ConfChange, confState *raftpb.Con
 			)
 		}
 		s.cluster.UpdateRaftAttributes(m.ID, m.RaftAttributes, shouldApplyV3)
+		s.bumpEpochOnMemberReplacement(shouldApplyV3)
 		if m.ID != s.MemberId() {
 			s.r.transport.UpdatePeer(m.ID, m.PeerURLs)
 		}
@@ -2040,6 +2252,15 @@ func (s *EtcdServer) applyConfChange(cc raftpb.ConfChange, confState *raftpb.Con
 	return false, nil
 }
 
+// bumpEpochOnMemberReplacement fences off the replaced member, either removed
+// or moved to new peer URLs, and its clients. Members that do not know their
+// epoch yet, and the entries already applied to the backend, leave it as is.
+func (s *EtcdServer) bumpEpochOnMemberReplacement(shouldApplyV3 membership.ShouldApplyV3) {
+	if shouldApplyV3 == membership.ApplyBoth && s.cluster.Epoch() != 0 {
+		s.cluster.BumpEpoch()
+	}
+}
+
 // TODO: non-blocking snapshot
 func (s *EtcdServer) snapshot(snapi uint64, confState raftpb.ConfState) {
 	clone := s.v2store.Clone()
@@ -2089,19 +2310,34 @@ func (s *EtcdServer) snapshot(snapi uint64, confState raftpb.ConfState) {
 		// After receives a snapshot, the slow follower needs to get all the entries right after
 		// the snapshot sent to catch up. If we do not pause compaction, the log entries right after
 		// the snapshot sent might already be compacted. It happens when the snapshot takes long time
-		// to send and save. Pausing compaction avoids triggering a snapshot sending cycle.
-		if atomic.LoadInt64(&s.inflightSnapshots) != 0 {
+		// to send and save. Pausing compaction avoids triggering a snapshot sending cycle,
+		// unless the raft log held in memory exceeds its retention.
+		if atomic.LoadInt64(&s.inflightSnapshots) != 0 && !s.raftLogOverRetention() {
 			lg.Info("skip compaction since there is an inflight snapshot")
 			return
 		}
 
 		// keep some in memory log entries for slow followers.
 		compacti := uint64(1)
-		if snapi > s.Cfg.SnapshotCatchUpEntries {
-			compacti = snapi - s.Cfg.SnapshotCatchUpEntries
+		if catchUpEntries := s.scaleToMemory(s.getSnapshotCatchUpEntries()); snapi > catchUpEntries {
+			compacti = snapi - catchUpEntries
+		}
+		// the entries kept for slow followers take at most half of the retention,
+		// followers lagging further behind catch up from a snapshot instead.
+		if maxBytes := s.Cfg.RaftLogRetentionMaxBytes; maxBytes > 0 {
+			if capped := s.r.retentionCompactIndex(compacti, snapi, maxBytes/2); capped > compacti {
+				lg.Info(
+					"capped raft log entries kept for slow followers",
+					zap.Uint64("compact-index", capped),
+					zap.Uint64("snapshot-catchup-compact-index", compacti),
+					zap.Uint64("raft-log-retention-max-bytes", maxBytes),
+				)
+				compacti = capped
+				s.warnFallenBehind(compacti)
+			}
 		}
 
-		err = s.r.raftStorage.Compact(compacti)
+		err = s.r.compactLog(compacti)
 		if err != nil {
 			// the compaction was done asynchronously with the progress of raft.
 			// raft log might already been compact.
@@ -2157,6 +2393,45 @@ func (s *EtcdServer) StorageVersion() *semver.Version {
 	return &v
 }
 
+// WALVersion returns the minimal etcd version able to interpret the entries
+// of the WAL since the last snapshot, which are replayed when the member
+// restarts, or nil if they require no specific version. The entries are read
+// from the raft log held in memory rather than from the WAL files.
+func (s *EtcdServer) WALVersion() *semver.Version {
+	snap, err := s.r.raftStorage.Snapshot()
+	if err != nil {
+		s.lg.Warn("Failed to read raft snapshot", zap.Error(err))
+		return nil
+	}
+	first, err := s.r.raftStorage.FirstIndex()
+	if err != nil {
+		s.lg.Warn("Failed to read raft log first index", zap.Error(err))
+		return nil
+	}
+	if first <= snap.Metadata.Index {
+		first = snap.Metadata.Index + 1
+	}
+	last, err := s.r.raftStorage.LastIndex()
+	if err != nil {
+		s.lg.Warn("Failed to read raft log last index", zap.Error(err))
+		return nil
+	}
+	if last < first {
+		return nil
+	}
+	ents, err := s.r.raftStorage.Entries(first, last+1, math.MaxUint64)
+	if err != nil {
+		s.lg.Warn("Failed to read raft log entries", zap.Error(err))
+		return nil
+	}
+	ver := wal.MinimalEtcdVersion(ents)
+	// the entries are appended to the WAL with its compression
+	if cv := wal.CompressionVersion(s.Cfg.WALCompression); cv != nil && (ver == nil || ver.LessThan(*cv)) {
+		ver = cv
+	}
+	return ver
+}
+
 // monitorClusterVersions every monitorVersionInterval checks if it's the leader and updates cluster version if needed.
 func (s *EtcdServer) monitorClusterVersions() {
 	monitor := serverversion.NewMonitor(s.Logger(), NewServerVersionAdapter(s))
@@ -2237,6 +2512,95 @@ func (s *EtcdServer) monitorCompactHash() {
 	}
 }
 
+// monitorPinExpiry every pinExpiryCheckInterval checks if it's the leader and
+// unpins the expired pins.
+func (s *EtcdServer) monitorPinExpiry() {
+	if s.pinStore == nil {
+		return
+	}
+	for {
+		select {
+		case <-time.After(pinExpiryCheckInterval):
+		case <-s.stopping:
+			return
+		}
+		if !s.isLeader() {
+			continue
+		}
+		for _, id := range s.pinStore.Expired() {
+			ctx, cancel := context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+			_, err := s.UnpinRevision(ctx, &pb.UnpinRevisionRequest{ID: id})
+			cancel()
+			if err != nil && err != v3compactor.ErrPinNotFound {
+				s.Logger().Warn("failed to unpin expired revision", zap.Int64("pin-id", id), zap.Error(err))
+				break
+			}
+		}
+	}
+}
+
+// monitorKeyExpiry every keyExpiryCheckInterval checks if it's the leader and
+// deletes the keys whose TTL expired.
+func (s *EtcdServer) monitorKeyExpiry() {
+	for {
+		select {
+		case <-time.After(keyExpiryCheckInterval):
+		case <-s.stopping:
+			return
+		}
+		for s.isLeader() {
+			keys := s.KV().ExpiredKeys(maxExpiredKeysPerTxn)
+			if len(keys) == 0 {
+				break
+			}
+			if err := s.deleteExpiredKeys(keys); err != nil {
+				s.Logger().Warn("failed to delete expired keys", zap.Int("keys", len(keys)), zap.Error(err))
+				break
+			}
+		}
+	}
+}
+
+// deleteExpiredKeys deletes the expired keys in a txn, unless they were put
+// again since they expired.
+func (s *EtcdServer) deleteExpiredKeys(keys []mvcc.ExpiredKey) error {
+	deleted, err := s.deleteUnmodifiedKeys(keys)
+	keyExpired.Add(float64(deleted))
+	return err
+}
+
+// deleteUnmodifiedKeys deletes the keys in a txn, unless they were modified
+// since their given revision, and returns the number of keys deleted.
+func (s *EtcdServer) deleteUnmodifiedKeys(keys []mvcc.ExpiredKey) (deleted int, err error) {
+	ops := make([]*pb.RequestOp, 0, len(keys))
+	for _, k := range keys {
+		ops = append(ops, &pb.RequestOp{Request: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{
+			Compare: []*pb.Compare{{
+				Target:      pb.Compare_MOD,
+				Result:      pb.Compare_EQUAL,
+				Key:         k.Key,
+				TargetUnion: &pb.Compare_ModRevision{ModRevision: k.ModRevision},
+			}},
+			Success: []*pb.RequestOp{{Request: &pb.RequestOp_RequestDeleteRange{
+				RequestDeleteRange: &pb.DeleteRangeRequest{Key: k.Key},
+			}}},
+		}}})
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+	defer cancel()
+	resp, err := s.Txn(s.authStore.WithRoot(ctx), &pb.TxnRequest{Success: ops})
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range resp.Responses {
+		if r.GetResponseTxn().GetSucceeded() {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
 func (s *EtcdServer) updateClusterVersionV2(ver string) {
 	lg := s.Logger()
 
@@ -31,6 +31,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/membershippb"
+	"go.etcd.io/etcd/api/v3/version"
 	"go.etcd.io/etcd/client/pkg/v3/fileutil"
 	"go.etcd.io/etcd/client/pkg/v3/testutil"
 	"go.etcd.io/etcd/client/pkg/v3/types"
@@ -1077,6 +1078,33 @@ func TestSnapshot(t *testing.T) {
 	<-ch
 }
 
+func TestWALVersion(t *testing.T) {
+	clusterVersionV3_6 := pbutil.MustMarshal(&pb.InternalRaftRequest{ClusterVersionSet: &membershippb.ClusterVersionSetRequest{Ver: "3.6.0"}})
+	confChange := pbutil.MustMarshal(&raftpb.ConfChange{Type: raftpb.ConfChangeAddNode, NodeID: 1})
+	rs := raft.NewMemoryStorage()
+	rs.Append([]raftpb.Entry{
+		{Index: 1, Term: 1, Type: raftpb.EntryConfChange, Data: confChange},
+		{Index: 2, Term: 1, Type: raftpb.EntryNormal, Data: clusterVersionV3_6},
+		{Index: 3, Term: 1, Type: raftpb.EntryConfChange, Data: confChange},
+	})
+	srv := &EtcdServer{
+		lgMu: new(sync.RWMutex),
+		lg:   zaptest.NewLogger(t),
+		r:    *newRaftNode(raftNodeConfig{lg: zaptest.NewLogger(t), Node: newNodeNop(), raftStorage: rs}),
+	}
+
+	assert.Equal(t, &version.V3_6, srv.WALVersion())
+	// the entries up to the snapshot are not replayed from the WAL
+	if _, err := rs.CreateSnapshot(2, &raftpb.ConfState{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &version.V3_0, srv.WALVersion())
+	if _, err := rs.CreateSnapshot(3, &raftpb.ConfState{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, srv.WALVersion())
+}
+
 // TestSnapshotOrdering ensures raft persists snapshot onto disk before
 // snapshot db is applied.
 func TestSnapshotOrdering(t *testing.T) {
@@ -1426,6 +1454,54 @@ func TestRemoveMember(t *testing.T) {
 	}
 }
 
+// TestRemoveRemovedMember tests the removal of a removed member is rejected
+// before being proposed.
+func TestRemoveRemovedMember(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	n := newNodeConfChangeCommitterRecorder()
+	n.readyc <- raft.Ready{
+		SoftState: &raft.SoftState{RaftState: raft.StateLeader},
+	}
+	cl := newTestCluster(t, nil)
+	st := v2store.New()
+	cl.SetStore(st)
+	cl.AddMember(&membership.Member{ID: 1234}, true)
+	r := newRaftNode(raftNodeConfig{
+		lg:          lg,
+		Node:        n,
+		raftStorage: raft.NewMemoryStorage(),
+		storage:     mockstorage.NewStorageRecorder(""),
+		transport:   newNopTransporter(),
+	})
+	s := &EtcdServer{
+		lgMu:         new(sync.RWMutex),
+		lg:           lg,
+		r:            *r,
+		v2store:      st,
+		cluster:      cl,
+		reqIDGen:     idutil.NewGenerator(0, time.Time{}),
+		SyncTicker:   &time.Ticker{},
+		consistIndex: cindex.NewFakeConsistentIndex(0),
+		beHooks:      serverstorage.NewBackendHooks(lg, nil),
+	}
+	s.start()
+	_, err := s.RemoveMember(context.Background(), 1234)
+	if err != nil {
+		t.Fatalf("RemoveMember error: %v", err)
+	}
+	_, err = s.RemoveMember(context.Background(), 1234)
+	gaction := n.Action()
+	s.Stop()
+
+	if err != membership.ErrIDRemoved {
+		t.Fatalf("RemoveMember error = %v, want %v", err, membership.ErrIDRemoved)
+	}
+	wactions := []testutil.Action{{Name: "ProposeConfChange:ConfChangeRemoveNode"}, {Name: "ApplyConfChange:ConfChangeRemoveNode"}}
+	if !reflect.DeepEqual(gaction, wactions) {
+		t.Errorf("action = %v, want %v", gaction, wactions)
+	}
+}
+
 // TestUpdateMember tests RemoveMember can propose and perform node update.
 func TestUpdateMember(t *testing.T) {
 	lg := zaptest.NewLogger(t)
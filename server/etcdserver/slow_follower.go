@@ -0,0 +1,311 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/raft/v3/tracker"
+
+	"go.uber.org/zap"
+)
+
+// slowFollowerWindow is the period over which the snapshots sent to a
+// follower are counted to decide whether it repeatedly falls behind.
+const slowFollowerWindow = time.Hour
+
+// slowFollowerCheckInterval is the interval at which the leader checks whether
+// the followers with a SLOWFOLLOWER alarm caught up.
+const slowFollowerCheckInterval = time.Minute
+
+func entriesSize(ents []raftpb.Entry) uint64 {
+	var n uint64
+	for i := range ents {
+		n += uint64(ents[i].Size())
+	}
+	return n
+}
+
+// initLogBytes accounts for the entries loaded into raftStorage on bootstrap.
+func (r *raftNode) initLogBytes() {
+	if r.raftStorage == nil {
+		return
+	}
+	first, err := r.raftStorage.FirstIndex()
+	if err != nil {
+		return
+	}
+	last, err := r.raftStorage.LastIndex()
+	if err != nil || last < first {
+		return
+	}
+	ents, err := r.raftStorage.Entries(first, last+1, math.MaxUint64)
+	if err != nil {
+		return
+	}
+	r.setLogBytes(entriesSize(ents))
+}
+
+func (r *raftNode) setLogBytes(n uint64) {
+	atomic.StoreUint64(&r.logBytes, n)
+	raftLogRetainedBytes.Set(float64(n))
+}
+
+func (r *raftNode) addLogBytes(n uint64) {
+	raftLogRetainedBytes.Set(float64(atomic.AddUint64(&r.logBytes, n)))
+}
+
+func (r *raftNode) subLogBytes(n uint64) {
+	for {
+		old := atomic.LoadUint64(&r.logBytes)
+		var cur uint64
+		if old > n {
+			cur = old - n
+		}
+		if atomic.CompareAndSwapUint64(&r.logBytes, old, cur) {
+			raftLogRetainedBytes.Set(float64(cur))
+			return
+		}
+	}
+}
+
+// retainedLogBytes returns the size of the entries held in raftStorage.
+func (r *raftNode) retainedLogBytes() uint64 {
+	return atomic.LoadUint64(&r.logBytes)
+}
+
+// appendLog appends ents to raftStorage, accounting for the entries they
+// truncate or overwrite and for those below the first index, which are
+// dropped.
+func (r *raftNode) appendLog(ents []raftpb.Entry) {
+	if len(ents) == 0 {
+		return
+	}
+	var overwritten uint64
+	first, ferr := r.raftStorage.FirstIndex()
+	last, lerr := r.raftStorage.LastIndex()
+	if ferr == nil && lerr == nil {
+		lo := ents[0].Index
+		if lo < first {
+			lo = first
+		}
+		if lo <= last {
+			if old, err := r.raftStorage.Entries(lo, last+1, math.MaxUint64); err == nil {
+				overwritten = entriesSize(old)
+			}
+		}
+		for len(ents) > 0 && ents[0].Index < first {
+			ents = ents[1:]
+		}
+	}
+	r.raftStorage.Append(ents)
+	r.subLogBytes(overwritten)
+	r.addLogBytes(entriesSize(ents))
+}
+
+// compactLog discards the entries of raftStorage up to compacti.
+func (r *raftNode) compactLog(compacti uint64) error {
+	var size uint64
+	if first, err := r.raftStorage.FirstIndex(); err == nil && first <= compacti {
+		if ents, err := r.raftStorage.Entries(first, compacti+1, math.MaxUint64); err == nil {
+			size = entriesSize(ents)
+		}
+	}
+	if err := r.raftStorage.Compact(compacti); err != nil {
+		return err
+	}
+	r.subLogBytes(size)
+	return nil
+}
+
+// retentionCompactIndex returns the index to compact raftStorage to after a
+// snapshot at snapi, so that the entries kept for slow followers take at
+// most maxBytes. It never returns an index lower than compacti.
+func (r *raftNode) retentionCompactIndex(compacti, snapi, maxBytes uint64) uint64 {
+	first, err := r.raftStorage.FirstIndex()
+	if err != nil {
+		return compacti
+	}
+	lo := compacti + 1
+	if lo < first {
+		lo = first
+	}
+	if lo > snapi {
+		return compacti
+	}
+	ents, err := r.raftStorage.Entries(lo, snapi+1, math.MaxUint64)
+	if err != nil {
+		return compacti
+	}
+	var size uint64
+	for i := len(ents) - 1; i >= 0; i-- {
+		size += uint64(ents[i].Size())
+		if size > maxBytes {
+			return ents[i].Index
+		}
+	}
+	return compacti
+}
+
+// raftLogOverRetention returns true if the raft log held in memory exceeds
+// the configured retention.
+func (s *EtcdServer) raftLogOverRetention() bool {
+	maxBytes := s.Cfg.RaftLogRetentionMaxBytes
+	return maxBytes > 0 && s.r.retainedLogBytes() > maxBytes
+}
+
+// warnFallenBehind logs the followers that will have to catch up from a
+// snapshot once the raft log is compacted to compacti.
+func (s *EtcdServer) warnFallenBehind(compacti uint64) {
+	st := s.r.Status()
+	if st.RaftState != raft.StateLeader {
+		return
+	}
+	for id, pr := range st.Progress {
+		if id != st.ID && pr.Match < compacti {
+			s.Logger().Warn(
+				"follower fell behind the retained raft log; it will catch up from a snapshot",
+				zap.String("follower-id", types.ID(id).String()),
+				zap.Uint64("follower-match-index", pr.Match),
+				zap.Uint64("compact-index", compacti),
+			)
+		}
+	}
+}
+
+// slowFollowers counts the snapshots sent to each follower over a sliding window.
+type slowFollowers struct {
+	mu        sync.Mutex
+	threshold int
+	window    time.Duration
+	sent      map[types.ID][]time.Time
+}
+
+func newSlowFollowers(threshold int, window time.Duration) *slowFollowers {
+	return &slowFollowers{threshold: threshold, window: window, sent: make(map[types.ID][]time.Time)}
+}
+
+// record records a snapshot sent to id at now. It returns true if id was sent
+// at least threshold snapshots within the window.
+func (sf *slowFollowers) record(id types.ID, now time.Time) bool {
+	if sf == nil || sf.threshold <= 0 {
+		return false
+	}
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	sent := append(sf.inWindow(id, now), now)
+	sf.sent[id] = sent
+	return len(sent) >= sf.threshold
+}
+
+// slow returns true if id was sent at least threshold snapshots within the
+// window before now.
+func (sf *slowFollowers) slow(id types.ID, now time.Time) bool {
+	if sf == nil || sf.threshold <= 0 {
+		return false
+	}
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	sent := sf.inWindow(id, now)
+	if len(sent) == 0 {
+		delete(sf.sent, id)
+	} else {
+		sf.sent[id] = sent
+	}
+	return len(sent) >= sf.threshold
+}
+
+// inWindow returns the snapshots sent to id within the window before now.
+func (sf *slowFollowers) inWindow(id types.ID, now time.Time) []time.Time {
+	sent := sf.sent[id]
+	i := 0
+	for i < len(sent) && now.Sub(sent[i]) >= sf.window {
+		i++
+	}
+	return sent[i:]
+}
+
+// followerCaughtUp returns true if the follower id is actively replicating
+// the log of the leader of status rs, or no longer a member.
+func followerCaughtUp(rs raft.Status, id types.ID) bool {
+	pr, ok := rs.Progress[uint64(id)]
+	return !ok || (pr.State == tracker.StateReplicate && pr.RecentActive)
+}
+
+func (s *EtcdServer) triggerSlowFollowerAlarm(id types.ID) {
+	for _, m := range s.alarmStore.Get(pb.AlarmType_SLOWFOLLOWER) {
+		if types.ID(m.MemberID) == id {
+			return
+		}
+	}
+	s.Logger().Warn(
+		"follower repeatedly needed a snapshot to catch up; activating alarm",
+		zap.String("follower-id", id.String()),
+		zap.Int("threshold", s.Cfg.SlowFollowerAlarmThreshold),
+		zap.Duration("window", slowFollowerWindow),
+	)
+	a := &pb.AlarmRequest{
+		MemberID: uint64(id),
+		Action:   pb.AlarmRequest_ACTIVATE,
+		Alarm:    pb.AlarmType_SLOWFOLLOWER,
+	}
+	s.GoAttach(func() {
+		s.raftRequest(s.ctx, pb.InternalRaftRequest{Alarm: a})
+	})
+}
+
+// monitorSlowFollowers deactivates the SLOWFOLLOWER alarms of the followers
+// caught up with the leader, while the member is leader.
+func (s *EtcdServer) monitorSlowFollowers() {
+	for {
+		select {
+		case <-s.stopping:
+			return
+		case <-time.After(slowFollowerCheckInterval):
+		}
+		if !s.isLeader() {
+			continue
+		}
+		s.checkSlowFollowers(s.r.Status(), time.Now())
+	}
+}
+
+// checkSlowFollowers deactivates the SLOWFOLLOWER alarms of the followers no
+// longer repeatedly sent snapshots and replicating the log of the leader of
+// status rs.
+func (s *EtcdServer) checkSlowFollowers(rs raft.Status, now time.Time) {
+	for _, m := range s.alarmStore.Get(pb.AlarmType_SLOWFOLLOWER) {
+		id := types.ID(m.MemberID)
+		if s.slowFollowers.slow(id, now) || !followerCaughtUp(rs, id) {
+			continue
+		}
+		s.Logger().Info("follower caught up; deactivating alarm", zap.String("follower-id", id.String()))
+		a := &pb.AlarmRequest{
+			MemberID: m.MemberID,
+			Action:   pb.AlarmRequest_DEACTIVATE,
+			Alarm:    pb.AlarmType_SLOWFOLLOWER,
+		}
+		s.GoAttach(func() {
+			s.raftRequest(s.ctx, pb.InternalRaftRequest{Alarm: a})
+		})
+	}
+}
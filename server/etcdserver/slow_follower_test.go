@@ -0,0 +1,189 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/raft/v3/tracker"
+	"go.etcd.io/etcd/server/v3/config"
+)
+
+// newRetentionRaftNode returns a raftNode whose storage holds n entries of
+// the given data size, from index 1.
+func newRetentionRaftNode(t *testing.T, n int, size int) *raftNode {
+	ms := raft.NewMemoryStorage()
+	var ents []raftpb.Entry
+	for i := 1; i <= n; i++ {
+		ents = append(ents, raftpb.Entry{Index: uint64(i), Term: 1, Data: make([]byte, size)})
+	}
+	if err := ms.Append(ents); err != nil {
+		t.Fatal(err)
+	}
+	r := &raftNode{raftNodeConfig: raftNodeConfig{raftStorage: ms}}
+	r.initLogBytes()
+	return r
+}
+
+func TestRaftLogBytes(t *testing.T) {
+	r := newRetentionRaftNode(t, 10, 100)
+	entSize := uint64((&raftpb.Entry{Index: 1, Term: 1, Data: make([]byte, 100)}).Size())
+	if got := r.retainedLogBytes(); got != 10*entSize {
+		t.Fatalf("retained bytes = %d, want %d", got, 10*entSize)
+	}
+
+	if err := r.compactLog(4); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.retainedLogBytes(); got != 6*entSize {
+		t.Errorf("retained bytes after compaction = %d, want %d", got, 6*entSize)
+	}
+
+	ent := raftpb.Entry{Index: 11, Term: 1, Data: make([]byte, 100)}
+	r.appendLog([]raftpb.Entry{ent})
+	if got := r.retainedLogBytes(); got != 7*entSize {
+		t.Errorf("retained bytes after append = %d, want %d", got, 7*entSize)
+	}
+
+	// entries of a new term truncate and overwrite the conflicting ones
+	ent = raftpb.Entry{Index: 9, Term: 2, Data: make([]byte, 100)}
+	r.appendLog([]raftpb.Entry{ent})
+	if got := r.retainedLogBytes(); got != 5*entSize {
+		t.Errorf("retained bytes after overwrite = %d, want %d", got, 5*entSize)
+	}
+
+	// entries below the first index are dropped
+	var ents []raftpb.Entry
+	for i := uint64(3); i <= 10; i++ {
+		ents = append(ents, raftpb.Entry{Index: i, Term: 2, Data: make([]byte, 100)})
+	}
+	r.appendLog(ents)
+	if got := r.retainedLogBytes(); got != 6*entSize {
+		t.Errorf("retained bytes after append from a compacted index = %d, want %d", got, 6*entSize)
+	}
+}
+
+func TestRetentionCompactIndex(t *testing.T) {
+	r := newRetentionRaftNode(t, 100, 100)
+	entSize := uint64((&raftpb.Entry{Index: 1, Term: 1, Data: make([]byte, 100)}).Size())
+
+	tests := []struct {
+		name     string
+		compacti uint64
+		snapi    uint64
+		maxBytes uint64
+		want     uint64
+	}{
+		{"entries within limit", 80, 90, 20 * entSize, 80},
+		{"entries over limit", 10, 90, 20 * entSize, 70},
+		{"limit below one entry", 10, 90, entSize / 2, 90},
+		{"compact index beyond snapshot", 95, 90, entSize, 95},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.retentionCompactIndex(tt.compacti, tt.snapi, tt.maxBytes); got != tt.want {
+				t.Errorf("retentionCompactIndex() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldSnapshotRetention(t *testing.T) {
+	r := newRetentionRaftNode(t, 10, 100)
+	s := &EtcdServer{r: *r, Cfg: config.ServerConfig{SnapshotCount: 100, RaftLogRetentionMaxBytes: 500}}
+	if !s.shouldSnapshot(&etcdProgress{appliedi: 10, snapi: 5}) {
+		t.Error("expected a snapshot when the raft log exceeds its retention")
+	}
+	if s.shouldSnapshot(&etcdProgress{appliedi: 10, snapi: 10}) {
+		t.Error("unexpected snapshot at the last snapshot index")
+	}
+	s.Cfg.RaftLogRetentionMaxBytes = 0
+	if s.shouldSnapshot(&etcdProgress{appliedi: 10, snapi: 5}) {
+		t.Error("unexpected snapshot without retention limit")
+	}
+}
+
+func TestSlowFollowersRecord(t *testing.T) {
+	sf := newSlowFollowers(3, time.Hour)
+	now := time.Now()
+	id := types.ID(1)
+
+	if sf.record(id, now) || sf.record(id, now.Add(10*time.Minute)) {
+		t.Fatal("unexpected slow follower below threshold")
+	}
+	if sf.record(types.ID(2), now.Add(20*time.Minute)) {
+		t.Fatal("unexpected slow follower for another member")
+	}
+	if !sf.record(id, now.Add(50*time.Minute)) {
+		t.Fatal("expected slow follower at threshold")
+	}
+	// the first two snapshots left the window
+	if sf.record(id, now.Add(2*time.Hour)) {
+		t.Fatal("unexpected slow follower after the window")
+	}
+
+	var disabled *slowFollowers
+	if disabled.record(id, now) || newSlowFollowers(0, time.Hour).record(id, now) {
+		t.Fatal("unexpected slow follower when disabled")
+	}
+}
+
+func TestSlowFollowersSlow(t *testing.T) {
+	sf := newSlowFollowers(2, time.Hour)
+	now := time.Now()
+	id := types.ID(1)
+
+	sf.record(id, now)
+	sf.record(id, now.Add(10*time.Minute))
+	if !sf.slow(id, now.Add(30*time.Minute)) {
+		t.Fatal("expected slow follower within the window")
+	}
+	// the first snapshot left the window
+	if sf.slow(id, now.Add(65*time.Minute)) {
+		t.Fatal("unexpected slow follower after the window")
+	}
+	if sf.slow(types.ID(2), now) {
+		t.Fatal("unexpected slow follower never sent a snapshot")
+	}
+}
+
+func TestFollowerCaughtUp(t *testing.T) {
+	rs := raft.Status{Progress: map[uint64]tracker.Progress{
+		1: {State: tracker.StateReplicate, RecentActive: true},
+		2: {State: tracker.StateSnapshot, RecentActive: true},
+		3: {State: tracker.StateReplicate},
+		4: {State: tracker.StateProbe, RecentActive: true},
+	}}
+	tests := []struct {
+		id  types.ID
+		wok bool
+	}{
+		{1, true},
+		{2, false},
+		{3, false},
+		{4, false},
+		// removed member
+		{5, true},
+	}
+	for _, tt := range tests {
+		if ok := followerCaughtUp(rs, tt.id); ok != tt.wok {
+			t.Errorf("followerCaughtUp(%s) = %v, want %v", tt.id, ok, tt.wok)
+		}
+	}
+}
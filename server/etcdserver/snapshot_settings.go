@@ -0,0 +1,77 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"sync/atomic"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/etcdserver/errors"
+
+	"go.uber.org/zap"
+)
+
+// getSnapshotCount returns the number of applied entries that triggers a snapshot.
+func (s *EtcdServer) getSnapshotCount() uint64 {
+	if n := atomic.LoadUint64(&s.snapshotCount); n != 0 {
+		return n
+	}
+	return s.Cfg.SnapshotCount
+}
+
+// getSnapshotCatchUpEntries returns the number of entries kept after a
+// snapshot for slow followers.
+func (s *EtcdServer) getSnapshotCatchUpEntries() uint64 {
+	if n := atomic.LoadUint64(&s.snapshotCatchUpEntries); n != 0 {
+		return n
+	}
+	return s.Cfg.SnapshotCatchUpEntries
+}
+
+// SnapshotSettings updates the snapshot count and the snapshot catch-up
+// entries of the member, leaving the ones not given unchanged, and returns
+// the settings in effect. The settings only last until the member restarts.
+func (s *EtcdServer) SnapshotSettings(ctx context.Context, r *pb.SnapshotSettingsRequest) (*pb.SnapshotSettingsResponse, error) {
+	s.snapshotSettingsMu.Lock()
+	defer s.snapshotSettingsMu.Unlock()
+
+	count, catchUpEntries := s.getSnapshotCount(), s.getSnapshotCatchUpEntries()
+	if r.SnapshotCount == 0 && r.SnapshotCatchupEntries == 0 {
+		return &pb.SnapshotSettingsResponse{SnapshotCount: count, SnapshotCatchupEntries: catchUpEntries}, nil
+	}
+	newCount, newCatchUpEntries := count, catchUpEntries
+	if r.SnapshotCount != 0 {
+		newCount = r.SnapshotCount
+	}
+	if r.SnapshotCatchupEntries != 0 {
+		newCatchUpEntries = r.SnapshotCatchupEntries
+	}
+	if newCatchUpEntries > newCount {
+		return nil, errors.ErrInvalidSnapshotSettings
+	}
+	atomic.StoreUint64(&s.snapshotCount, newCount)
+	atomic.StoreUint64(&s.snapshotCatchUpEntries, newCatchUpEntries)
+
+	s.Logger().Info(
+		"updated snapshot settings",
+		zap.String("local-member-id", s.MemberId().String()),
+		zap.Uint64("previous-snapshot-count", count),
+		zap.Uint64("updated-snapshot-count", newCount),
+		zap.Uint64("previous-snapshot-catchup-entries", catchUpEntries),
+		zap.Uint64("updated-snapshot-catchup-entries", newCatchUpEntries),
+	)
+	return &pb.SnapshotSettingsResponse{SnapshotCount: newCount, SnapshotCatchupEntries: newCatchUpEntries}, nil
+}
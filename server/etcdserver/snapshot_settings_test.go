@@ -0,0 +1,91 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/etcdserver/errors"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSnapshotSettings(t *testing.T) {
+	s := &EtcdServer{
+		lgMu: new(sync.RWMutex),
+		lg:   zaptest.NewLogger(t),
+		Cfg:  config.ServerConfig{SnapshotCount: 100, SnapshotCatchUpEntries: 10},
+	}
+
+	tests := []struct {
+		name string
+		req  pb.SnapshotSettingsRequest
+
+		wantErr            error
+		wantCount          uint64
+		wantCatchUpEntries uint64
+	}{
+		{
+			name:               "empty request returns the configured settings",
+			wantCount:          100,
+			wantCatchUpEntries: 10,
+		},
+		{
+			name:               "update snapshot count only",
+			req:                pb.SnapshotSettingsRequest{SnapshotCount: 50},
+			wantCount:          50,
+			wantCatchUpEntries: 10,
+		},
+		{
+			name:               "update catch-up entries only",
+			req:                pb.SnapshotSettingsRequest{SnapshotCatchupEntries: 20},
+			wantCount:          50,
+			wantCatchUpEntries: 20,
+		},
+		{
+			name:               "catch-up entries exceeding the snapshot count are rejected",
+			req:                pb.SnapshotSettingsRequest{SnapshotCount: 10},
+			wantErr:            errors.ErrInvalidSnapshotSettings,
+			wantCount:          50,
+			wantCatchUpEntries: 20,
+		},
+		{
+			name:               "update both",
+			req:                pb.SnapshotSettingsRequest{SnapshotCount: 10, SnapshotCatchupEntries: 5},
+			wantCount:          10,
+			wantCatchUpEntries: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.SnapshotSettings(context.Background(), &tt.req)
+			if err != tt.wantErr {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (resp.SnapshotCount != tt.wantCount || resp.SnapshotCatchupEntries != tt.wantCatchUpEntries) {
+				t.Errorf("response = (%d, %d), want (%d, %d)", resp.SnapshotCount, resp.SnapshotCatchupEntries, tt.wantCount, tt.wantCatchUpEntries)
+			}
+			if got := s.getSnapshotCount(); got != tt.wantCount {
+				t.Errorf("snapshot count = %d, want %d", got, tt.wantCount)
+			}
+			if got := s.getSnapshotCatchUpEntries(); got != tt.wantCatchUpEntries {
+				t.Errorf("snapshot catch-up entries = %d, want %d", got, tt.wantCatchUpEntries)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/config"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+
+	"go.uber.org/zap"
+)
+
+// A member restarted with the data of a cluster which was since recreated,
+// or after it was removed from its cluster, is rejected by its peers and
+// keeps failing. With stale data dir recovery enabled, such a member asks the
+// peers of its initial cluster for their membership on startup, and rejoins
+// their cluster as a new learner with an empty data dir if it no longer
+// belongs to it. The learner is to be promoted by the operator once caught up.
+
+// staleDataDirCluster returns the cluster of the peers of the member if the
+// data dir of the member, of the given metadata, is stale: the cluster of its
+// peers has another cluster ID or the member is no longer part of it. It
+// returns nil if the data dir is not stale or the peers cannot be reached.
+func staleDataDirCluster(cfg config.ServerConfig, meta *snapshotMetadata, prt http.RoundTripper) *membership.RaftCluster {
+	lg := cfg.Logger
+	cl, err := membership.NewClusterFromURLsMap(lg, cfg.InitialClusterToken, cfg.InitialPeerURLsMap)
+	if err != nil {
+		lg.Warn("failed to check for stale data dir", zap.Error(err))
+		return nil
+	}
+	urls := getRemotePeerURLs(cl, cfg.Name)
+	if len(urls) == 0 {
+		lg.Warn("cannot check for stale data dir without remote peers in the initial cluster")
+		return nil
+	}
+	remote, err := GetClusterFromRemotePeers(lg, urls, prt)
+	if err != nil {
+		lg.Warn("failed to check for stale data dir", zap.Error(err))
+		return nil
+	}
+	switch {
+	case remote.ID() != meta.clusterID:
+		lg.Warn(
+			"data dir belongs to another cluster than the one of its peers",
+			zap.String("data-dir", cfg.DataDir),
+			zap.String("local-cluster-id", meta.clusterID.String()),
+			zap.String("remote-cluster-id", remote.ID().String()),
+		)
+	case remote.Member(meta.nodeID) == nil:
+		lg.Warn(
+			"data dir belongs to a member removed from the cluster",
+			zap.String("data-dir", cfg.DataDir),
+			zap.String("local-member-id", meta.nodeID.String()),
+			zap.String("cluster-id", remote.ID().String()),
+		)
+	default:
+		return nil
+	}
+	return remote
+}
+
+// rejoinAsLearner adds the member to the remote cluster as a learner, unless
+// a member of its peer URLs was already added, wipes its data dir, and returns
+// the configuration for the member to join the remote cluster.
+func rejoinAsLearner(cfg config.ServerConfig, remote *membership.RaftCluster) (config.ServerConfig, error) {
+	lg := cfg.Logger
+	peerURLs := cfg.InitialPeerURLsMap[cfg.Name].StringSlice()
+
+	members := remote.Members()
+	if memberByPeerURLs(members, peerURLs) == nil {
+		added, err := addLearner(cfg, remote, peerURLs)
+		if err != nil {
+			return cfg, fmt.Errorf("cannot add member as learner to rejoin cluster %s: %v", remote.ID(), err)
+		}
+		members = added
+	}
+	var initialCluster []string
+	for _, m := range members {
+		name := m.Name
+		switch {
+		case equalPeerURLs(m.PeerURLs, peerURLs):
+			name = cfg.Name
+		case name == "":
+			// unstarted members are only told apart by their ID
+			name = m.ID.String()
+		}
+		for _, u := range m.PeerURLs {
+			initialCluster = append(initialCluster, fmt.Sprintf("%s=%s", name, u))
+		}
+	}
+	urlsmap, err := types.NewURLsMap(strings.Join(initialCluster, ","))
+	if err != nil {
+		return cfg, err
+	}
+
+	lg.Warn(
+		"wiping stale data dir to rejoin cluster as learner",
+		zap.String("data-dir", cfg.DataDir),
+		zap.String("cluster-id", remote.ID().String()),
+		zap.String("initial-cluster", urlsmap.String()),
+	)
+	if err = os.RemoveAll(cfg.MemberDir()); err != nil {
+		return cfg, fmt.Errorf("cannot wipe stale data dir: %v", err)
+	}
+	if cfg.DedicatedWALDir != "" {
+		if err = os.RemoveAll(cfg.DedicatedWALDir); err != nil {
+			return cfg, fmt.Errorf("cannot wipe stale WAL dir: %v", err)
+		}
+	}
+	cfg.InitialPeerURLsMap = urlsmap
+	cfg.NewCluster = false
+	return cfg, nil
+}
+
+// addLearner adds a learner of the given peer URLs to the remote cluster
+// through the client URLs of its members, and returns its updated members.
+func addLearner(cfg config.ServerConfig, remote *membership.RaftCluster, peerURLs []string) ([]*membership.Member, error) {
+	ccfg := clientv3.Config{
+		Endpoints:   remote.ClientURLs(),
+		DialTimeout: cfg.ReqTimeout(),
+		Logger:      cfg.Logger.Named("rejoin"),
+	}
+	for _, ep := range ccfg.Endpoints {
+		if strings.HasPrefix(ep, "https://") {
+			tlsConfig, err := cfg.ClientTLSInfo.ClientConfig()
+			if err != nil {
+				return nil, err
+			}
+			ccfg.TLS = tlsConfig
+			break
+		}
+	}
+	cli, err := clientv3.New(ccfg)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ReqTimeout())
+	defer cancel()
+	resp, err := cli.MemberAddAsLearner(ctx, peerURLs)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]*membership.Member, 0, len(resp.Members))
+	for _, m := range resp.Members {
+		members = append(members, &membership.Member{
+			ID:             types.ID(m.ID),
+			RaftAttributes: membership.RaftAttributes{PeerURLs: m.PeerURLs, IsLearner: m.IsLearner},
+			Attributes:     membership.Attributes{Name: m.Name, ClientURLs: m.ClientURLs},
+		})
+	}
+	cfg.Logger.Info(
+		"added member as learner to rejoin cluster",
+		zap.String("member-id", types.ID(resp.Member.ID).String()),
+		zap.Strings("peer-urls", peerURLs),
+	)
+	return members, nil
+}
+
+func memberByPeerURLs(members []*membership.Member, peerURLs []string) *membership.Member {
+	for _, m := range members {
+		if equalPeerURLs(m.PeerURLs, peerURLs) {
+			return m
+		}
+	}
+	return nil
+}
+
+func equalPeerURLs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]struct{}, len(a))
+	for _, u := range a {
+		seen[u] = struct{}{}
+	}
+	for _, u := range b {
+		if _, ok := seen[u]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,246 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+
+	"go.uber.org/zap"
+)
+
+// A standby cluster, started with the client URLs of a primary cluster, is
+// fed by its leader with the keyspace of the primary cluster while it holds
+// a STANDBY alarm. The alarm rejects the writes of clients, but not the
+// mirrored ones; it also fences off a primary cluster before a standby is
+// promoted by disarming it.
+
+const (
+	// standbyRetryInterval is the interval between two attempts of the leader
+	// of a standby cluster to mirror its primary cluster, and between two
+	// progress requests while it does.
+	standbyRetryInterval = time.Second
+	// standbyPageSize is the number of keys fetched per range request while
+	// syncing a standby cluster with its primary cluster.
+	standbyPageSize = 1000
+	// standbyTxnOps is the maximum number of operations of the transactions
+	// syncing a standby cluster with its primary cluster.
+	standbyTxnOps = 128
+)
+
+// mirrorKey marks the context of the writes mirrored from the primary cluster.
+type mirrorKey struct{}
+
+// StandbyRevision returns the latest revision of the primary cluster mirrored
+// by the member, or 0 if it does not mirror one.
+func (s *EtcdServer) StandbyRevision() int64 {
+	return atomic.LoadInt64(&s.standbyRevision)
+}
+
+func (s *EtcdServer) isStandby() bool {
+	return len(s.alarmStore.Get(pb.AlarmType_STANDBY)) > 0
+}
+
+// mirrorPrimary mirrors the keyspace of the primary cluster into the cluster
+// while it is a standby and the member is its leader.
+func (s *EtcdServer) mirrorPrimary() {
+	if len(s.Cfg.StandbyOf) == 0 {
+		return
+	}
+
+	select {
+	case <-s.stopping:
+		return
+	case <-s.ReadyNotify():
+	}
+	lg := s.Logger()
+	for {
+		if s.isLeader() && s.isStandby() {
+			lg.Info("mirroring primary cluster", zap.Strings("primary-endpoints", s.Cfg.StandbyOf))
+			err := s.mirrorPrimaryOnce()
+			atomic.StoreInt64(&s.standbyRevision, 0)
+			if err != nil && s.ctx.Err() == nil {
+				lg.Warn("failed to mirror primary cluster", zap.Strings("primary-endpoints", s.Cfg.StandbyOf), zap.Error(err))
+			}
+		}
+		select {
+		case <-s.stopping:
+			return
+		case <-time.After(standbyRetryInterval):
+		}
+	}
+}
+
+// mirrorPrimaryOnce syncs the cluster with its primary cluster and applies
+// the changes of the primary cluster until the member is no longer the
+// leader of a standby cluster or mirroring fails.
+func (s *EtcdServer) mirrorPrimaryOnce() error {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	cfg := clientv3.Config{
+		Endpoints:   s.Cfg.StandbyOf,
+		DialTimeout: s.Cfg.ReqTimeout(),
+		Logger:      s.Logger().Named("standby"),
+		Context:     ctx,
+	}
+	for _, ep := range s.Cfg.StandbyOf {
+		if strings.HasPrefix(ep, "https://") {
+			tlsConfig, err := s.Cfg.ClientTLSInfo.ClientConfig()
+			if err != nil {
+				return err
+			}
+			cfg.TLS = tlsConfig
+			break
+		}
+	}
+	cli, err := clientv3.New(cfg)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	rev, err := s.syncPrimary(ctx, cli)
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt64(&s.standbyRevision, rev)
+
+	wch := cli.Watch(clientv3.WithRequireLeader(ctx), "\x00", clientv3.WithFromKey(), clientv3.WithRev(rev+1))
+	t := time.NewTicker(standbyRetryInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if !s.isLeader() || !s.isStandby() {
+				return nil
+			}
+			// progress notifications advance the mirrored revision while
+			// the primary cluster is idle, as once it is fenced.
+			if err := cli.RequestProgress(ctx); err != nil {
+				return err
+			}
+		case wr, ok := <-wch:
+			if !ok {
+				return ctx.Err()
+			}
+			if err := wr.Err(); err != nil {
+				return err
+			}
+			if wr.IsProgressNotify() {
+				atomic.StoreInt64(&s.standbyRevision, wr.Header.Revision)
+				continue
+			}
+			if err := s.mirrorEvents(ctx, wr.Events); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// syncPrimary makes the keyspace of the cluster match the one of the primary
+// cluster, and returns the revision of the primary cluster it matches.
+func (s *EtcdServer) syncPrimary(ctx context.Context, cli *clientv3.Client) (int64, error) {
+	resp, err := cli.Get(ctx, "\x00", clientv3.WithFromKey(), clientv3.WithAutoPaging(standbyPageSize))
+	if err != nil {
+		return 0, err
+	}
+	local, err := s.KV().Range(ctx, []byte{0}, []byte{}, mvcc.RangeOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	var ops []*pb.RequestOp
+	primary, kvs := resp.Kvs, local.KVs
+	for len(primary) > 0 || len(kvs) > 0 {
+		switch {
+		case len(kvs) == 0 || (len(primary) > 0 && bytes.Compare(primary[0].Key, kvs[0].Key) < 0):
+			ops = append(ops, mirrorPut(primary[0]))
+			primary = primary[1:]
+		case len(primary) == 0 || bytes.Compare(primary[0].Key, kvs[0].Key) > 0:
+			ops = append(ops, mirrorDelete(kvs[0].Key))
+			kvs = kvs[1:]
+		default:
+			if !bytes.Equal(primary[0].Value, kvs[0].Value) || !bytes.Equal(primary[0].Metadata, kvs[0].Metadata) {
+				ops = append(ops, mirrorPut(primary[0]))
+			}
+			primary, kvs = primary[1:], kvs[1:]
+		}
+		if len(ops) == standbyTxnOps {
+			if err := s.mirrorTxn(ctx, ops); err != nil {
+				return 0, err
+			}
+			ops = nil
+		}
+	}
+	if len(ops) > 0 {
+		if err := s.mirrorTxn(ctx, ops); err != nil {
+			return 0, err
+		}
+	}
+	s.Logger().Info(
+		"synced standby cluster with primary cluster",
+		zap.Int64("primary-revision", resp.Header.Revision),
+		zap.Int("primary-keys", len(resp.Kvs)),
+	)
+	return resp.Header.Revision, nil
+}
+
+// mirrorEvents applies the events of the primary cluster, one transaction
+// per revision of the primary cluster.
+func (s *EtcdServer) mirrorEvents(ctx context.Context, evs []*clientv3.Event) error {
+	var ops []*pb.RequestOp
+	for i, ev := range evs {
+		switch ev.Type {
+		case mvccpb.PUT:
+			ops = append(ops, mirrorPut(ev.Kv))
+		case mvccpb.DELETE:
+			ops = append(ops, mirrorDelete(ev.Kv.Key))
+		}
+		rev := ev.Kv.ModRevision
+		if i+1 < len(evs) && evs[i+1].Kv.ModRevision == rev {
+			continue
+		}
+		if err := s.mirrorTxn(ctx, ops); err != nil {
+			return err
+		}
+		ops = nil
+		atomic.StoreInt64(&s.standbyRevision, rev)
+	}
+	return nil
+}
+
+func (s *EtcdServer) mirrorTxn(ctx context.Context, ops []*pb.RequestOp) error {
+	_, err := s.raftRequest(context.WithValue(ctx, mirrorKey{}, true), pb.InternalRaftRequest{Txn: &pb.TxnRequest{Success: ops}})
+	return err
+}
+
+// mirrorPut returns the put of a key-value pair of the primary cluster. The
+// keys of the standby cluster are not attached to leases.
+func mirrorPut(kv *mvccpb.KeyValue) *pb.RequestOp {
+	return &pb.RequestOp{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: kv.Key, Value: kv.Value, Metadata: kv.Metadata}}}
+}
+
+func mirrorDelete(key []byte) *pb.RequestOp {
+	return &pb.RequestOp{Request: &pb.RequestOp_RequestDeleteRange{RequestDeleteRange: &pb.DeleteRangeRequest{Key: key}}}
+}
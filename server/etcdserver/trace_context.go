@@ -0,0 +1,64 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "go.etcd.io/etcd/server/v3/etcdserver"
+
+// traceContextPropagator carries the trace context and baggage of the writes
+// through raft, as the gRPC interceptors do between the clients and etcd.
+var traceContextPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// traceContextOf returns the trace context and baggage of ctx, or nil if it
+// has none.
+func traceContextOf(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	traceContextPropagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// startApplySpan starts the span of the apply on this member of a request
+// traced by the member proposing it, if tracing is enabled, and returns nil
+// otherwise. The events of the writes of the request then carry the trace
+// context of the span, so the watchers follow the apply.
+func (s *EtcdServer) startApplySpan(r *pb.InternalRaftRequest, index uint64) trace.Span {
+	tp := s.Cfg.ExperimentalTracerProvider
+	if tp == nil || r.Header == nil || len(r.Header.TraceContext) == 0 {
+		return nil
+	}
+	ctx := traceContextPropagator.Extract(context.Background(), propagation.MapCarrier(r.Header.TraceContext))
+	ctx, span := tp.Tracer(tracerName).Start(ctx, "etcdserver.apply",
+		trace.WithAttributes(
+			attribute.String("member_id", s.MemberId().String()),
+			attribute.Int64("raft_index", int64(index)),
+		),
+	)
+	r.Header.TraceContext = traceContextOf(ctx)
+	return span
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txn
+
+import (
+	"context"
+	"sync"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+const (
+	// maxPooledRangeBufferBytes is the capacity of the keys, values and
+	// metadata of a range buffer beyond which it is not recycled, so that
+	// the pool does not keep the memory of the largest responses.
+	maxPooledRangeBufferBytes = 4 * 1024 * 1024
+	// maxPooledRangeBufferKVs is the number of key-values of a range buffer
+	// beyond which it is not recycled.
+	maxPooledRangeBufferKVs = 4096
+)
+
+var rangeBufferPool = sync.Pool{New: func() interface{} { return new(RangeBuffer) }}
+
+// RangeBuffer holds a range response and the key-values it returns, to be
+// recycled by the following ranges once the response was marshaled.
+type RangeBuffer struct {
+	resp pb.RangeResponse
+	hdr  pb.ResponseHeader
+	// ptrs backs the key-values of the response.
+	ptrs []*mvccpb.KeyValue
+	// kvs are the key-values of the buffer, the first n of which are in use.
+	kvs   []*mvccpb.KeyValue
+	n     int
+	taken bool
+}
+
+type rangeBufferKey struct{}
+
+// GetRangeBuffer returns a range buffer from the pool.
+func GetRangeBuffer() *RangeBuffer {
+	return rangeBufferPool.Get().(*RangeBuffer)
+}
+
+// WithRangeBuffer returns a context making the range it is passed to build
+// its response in b, unless it is served within a txn or needs sorting.
+func WithRangeBuffer(ctx context.Context, b *RangeBuffer) context.Context {
+	return context.WithValue(ctx, rangeBufferKey{}, b)
+}
+
+func rangeBufferFrom(ctx context.Context) *RangeBuffer {
+	b, _ := ctx.Value(rangeBufferKey{}).(*RangeBuffer)
+	return b
+}
+
+// Owns returns true if resp is the response built in the buffer.
+func (b *RangeBuffer) Owns(resp *pb.RangeResponse) bool {
+	return b.taken && resp == &b.resp
+}
+
+// response returns the response of the buffer, or nil if it was already
+// returned for another range.
+func (b *RangeBuffer) response() *pb.RangeResponse {
+	if b.taken {
+		return nil
+	}
+	b.taken = true
+	b.resp.Header = &b.hdr
+	b.resp.Kvs = b.ptrs[:0]
+	return &b.resp
+}
+
+// newKV returns an unused key-value of the buffer, keeping the capacity of
+// its key, value and metadata for the range to read into.
+func (b *RangeBuffer) newKV() *mvccpb.KeyValue {
+	if b.n == len(b.kvs) {
+		b.kvs = append(b.kvs, new(mvccpb.KeyValue))
+	}
+	kv := b.kvs[b.n]
+	b.n++
+	*kv = mvccpb.KeyValue{Key: kv.Key[:0], Value: kv.Value[:0], Metadata: kv.Metadata[:0]}
+	return kv
+}
+
+// Release returns the buffer to the pool. Neither the buffer nor its
+// response may be used afterwards.
+func (b *RangeBuffer) Release() {
+	if len(b.kvs) > maxPooledRangeBufferKVs {
+		return
+	}
+	size := 0
+	for _, kv := range b.kvs {
+		size += cap(kv.Key) + cap(kv.Value) + cap(kv.Metadata)
+	}
+	if size > maxPooledRangeBufferBytes {
+		return
+	}
+	b.reset()
+	rangeBufferPool.Put(b)
+}
+
+// reset makes all the key-values of the buffer unused, and its response
+// available.
+func (b *RangeBuffer) reset() {
+	if b.taken {
+		b.ptrs = b.resp.Kvs[:0]
+	}
+	b.resp = pb.RangeResponse{}
+	b.hdr = pb.ResponseHeader{}
+	b.n, b.taken = 0, false
+}
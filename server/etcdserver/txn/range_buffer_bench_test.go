@@ -0,0 +1,62 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txn
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
+	"go.uber.org/zap"
+)
+
+func BenchmarkRange(b *testing.B) {
+	be, _ := betesting.NewDefaultTmpBackend(b)
+	defer betesting.Close(b, be)
+	s := mvcc.NewStore(zap.NewNop(), be, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer s.Close()
+	val := make([]byte, 16*1024)
+	for i := 0; i < 100; i++ {
+		s.Put([]byte(fmt.Sprintf("key-%03d", i)), val, lease.NoLease)
+	}
+	r := &pb.RangeRequest{Key: []byte("key-"), RangeEnd: []byte("key."), Serializable: true}
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resp, err := Range(context.TODO(), zap.NewNop(), s, nil, r)
+			if err != nil {
+				b.Fatal(err)
+			}
+			resp.Marshal()
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf := GetRangeBuffer()
+			resp, err := Range(WithRangeBuffer(context.TODO(), buf), zap.NewNop(), s, nil, r)
+			if err != nil {
+				b.Fatal(err)
+			}
+			resp.Marshal()
+			buf.Release()
+		}
+	})
+}
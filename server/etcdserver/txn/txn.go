@@ -42,19 +42,19 @@ func Put(ctx context.Context, lg *zap.Logger, lessor lease.Lessor, kv mvcc.KV, t
 			traceutil.Field{Key: "req_size", Value: p.Size()},
 		)
 	}
-	val, leaseID := p.Value, lease.LeaseID(p.Lease)
+	val, leaseID, metadata := p.Value, lease.LeaseID(p.Lease), p.Metadata
 	if txnWrite == nil {
 		if leaseID != lease.NoLease {
 			if l := lessor.Lookup(leaseID); l == nil {
 				return nil, nil, lease.ErrLeaseNotFound
 			}
 		}
-		txnWrite = kv.Write(trace)
+		txnWrite = write(ctx, kv, trace)
 		defer txnWrite.End()
 	}
 
 	var rr *mvcc.RangeResult
-	if p.IgnoreValue || p.IgnoreLease || p.PrevKv {
+	if p.IgnoreValue || p.IgnoreLease || p.IgnoreMetadata || p.PrevKv {
 		trace.StepWithFunction(func() {
 			rr, err = txnWrite.Range(context.TODO(), p.Key, nil, mvcc.RangeOptions{})
 		}, "get previous kv pair")
@@ -63,9 +63,9 @@ func Put(ctx context.Context, lg *zap.Logger, lessor lease.Lessor, kv mvcc.KV, t
 			return nil, nil, err
 		}
 	}
-	if p.IgnoreValue || p.IgnoreLease {
+	if p.IgnoreValue || p.IgnoreLease || p.IgnoreMetadata {
 		if rr == nil || len(rr.KVs) == 0 {
-			// ignore_{lease,value} flag expects previous key-value pair
+			// ignore_{lease,value,metadata} flag expects previous key-value pair
 			return nil, nil, errors.ErrKeyNotFound
 		}
 	}
@@ -75,24 +75,30 @@ func Put(ctx context.Context, lg *zap.Logger, lessor lease.Lessor, kv mvcc.KV, t
 	if p.IgnoreLease {
 		leaseID = lease.LeaseID(rr.KVs[0].Lease)
 	}
+	if p.IgnoreMetadata {
+		metadata = rr.KVs[0].Metadata
+	}
 	if p.PrevKv {
 		if rr != nil && len(rr.KVs) != 0 {
 			resp.PrevKv = &rr.KVs[0]
 		}
 	}
 
-	resp.Header.Revision = txnWrite.Put(p.Key, val, leaseID)
+	resp.Header.Revision = txnWrite.PutWithTTL(p.Key, val, metadata, leaseID, p.Ttl)
+	if leaseID != lease.NoLease {
+		lessor.RenewOnWrite(leaseID)
+	}
 	trace.AddField(traceutil.Field{Key: "response_revision", Value: resp.Header.Revision})
 	return resp, trace, nil
 }
 
-func DeleteRange(kv mvcc.KV, txnWrite mvcc.TxnWrite, dr *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+func DeleteRange(ctx context.Context, kv mvcc.KV, txnWrite mvcc.TxnWrite, dr *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
 	resp := &pb.DeleteRangeResponse{}
 	resp.Header = &pb.ResponseHeader{}
 	end := mkGteRange(dr.RangeEnd)
 
 	if txnWrite == nil {
-		txnWrite = kv.Write(traceutil.TODO())
+		txnWrite = write(ctx, kv, traceutil.TODO())
 		defer txnWrite.End()
 	}
 
@@ -113,15 +119,42 @@ func DeleteRange(kv mvcc.KV, txnWrite mvcc.TxnWrite, dr *pb.DeleteRangeRequest)
 	return resp, nil
 }
 
+type traceContextKey struct{}
+
+// WithTraceContext returns a context whose write txns pass the trace context
+// of the request applied on to the events of its writes.
+func WithTraceContext(ctx context.Context, traceContext map[string]string) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, traceContext)
+}
+
+// write begins a write txn, whose events carry the trace context of ctx.
+func write(ctx context.Context, kv mvcc.KV, trace *traceutil.Trace) mvcc.TxnWrite {
+	txnWrite := kv.Write(trace)
+	if traceContext, ok := ctx.Value(traceContextKey{}).(map[string]string); ok {
+		mvcc.SetEventTraceContext(txnWrite, traceContext)
+	}
+	return txnWrite
+}
+
 func Range(ctx context.Context, lg *zap.Logger, kv mvcc.KV, txnRead mvcc.TxnRead, r *pb.RangeRequest) (*pb.RangeResponse, error) {
 	trace := traceutil.Get(ctx)
 
 	resp := &pb.RangeResponse{}
 	resp.Header = &pb.ResponseHeader{}
 
+	// only the ranges served outside of txns hide the expired keys, the
+	// txns applied from the raft log must see the same keys on all members
+	hideExpired := txnRead == nil
+	var b *RangeBuffer
 	if txnRead == nil {
 		txnRead = kv.Read(mvcc.ConcurrentReadTxMode, trace)
 		defer txnRead.End()
+		b = rangeBufferFrom(ctx)
+	}
+
+	sortOrder := rangeSortOrder(r)
+	if sortOrder == pb.RangeRequest_NONE {
+		return rangeInKeyOrder(ctx, txnRead, r, hideExpired, b)
 	}
 
 	limit := r.Limit
@@ -137,9 +170,10 @@ func Range(ctx context.Context, lg *zap.Logger, kv mvcc.KV, txnRead mvcc.TxnRead
 	}
 
 	ro := mvcc.RangeOptions{
-		Limit: limit,
-		Rev:   r.Revision,
-		Count: r.CountOnly,
+		Limit:       limit,
+		Rev:         r.Revision,
+		Count:       r.CountOnly,
+		HideExpired: hideExpired,
 	}
 
 	rr, err := txnRead.Range(ctx, r.Key, mkGteRange(r.RangeEnd), ro)
@@ -164,40 +198,26 @@ func Range(ctx context.Context, lg *zap.Logger, kv mvcc.KV, txnRead mvcc.TxnRead
 		pruneKVs(rr, f)
 	}
 
-	sortOrder := r.SortOrder
-	if r.SortTarget != pb.RangeRequest_KEY && sortOrder == pb.RangeRequest_NONE {
-		// Since current mvcc.Range implementation returns results
-		// sorted by keys in lexiographically ascending order,
-		// sort ASCEND by default only when target is not 'KEY'
-		sortOrder = pb.RangeRequest_ASCEND
-	} else if r.SortTarget == pb.RangeRequest_KEY && sortOrder == pb.RangeRequest_ASCEND {
-		// Since current mvcc.Range implementation returns results
-		// sorted by keys in lexiographically ascending order,
-		// don't re-sort when target is 'KEY' and order is ASCEND
-		sortOrder = pb.RangeRequest_NONE
+	var sorter sort.Interface
+	switch {
+	case r.SortTarget == pb.RangeRequest_KEY:
+		sorter = &kvSortByKey{&kvSort{rr.KVs}}
+	case r.SortTarget == pb.RangeRequest_VERSION:
+		sorter = &kvSortByVersion{&kvSort{rr.KVs}}
+	case r.SortTarget == pb.RangeRequest_CREATE:
+		sorter = &kvSortByCreate{&kvSort{rr.KVs}}
+	case r.SortTarget == pb.RangeRequest_MOD:
+		sorter = &kvSortByMod{&kvSort{rr.KVs}}
+	case r.SortTarget == pb.RangeRequest_VALUE:
+		sorter = &kvSortByValue{&kvSort{rr.KVs}}
+	default:
+		lg.Panic("unexpected sort target", zap.Int32("sort-target", int32(r.SortTarget)))
 	}
-	if sortOrder != pb.RangeRequest_NONE {
-		var sorter sort.Interface
-		switch {
-		case r.SortTarget == pb.RangeRequest_KEY:
-			sorter = &kvSortByKey{&kvSort{rr.KVs}}
-		case r.SortTarget == pb.RangeRequest_VERSION:
-			sorter = &kvSortByVersion{&kvSort{rr.KVs}}
-		case r.SortTarget == pb.RangeRequest_CREATE:
-			sorter = &kvSortByCreate{&kvSort{rr.KVs}}
-		case r.SortTarget == pb.RangeRequest_MOD:
-			sorter = &kvSortByMod{&kvSort{rr.KVs}}
-		case r.SortTarget == pb.RangeRequest_VALUE:
-			sorter = &kvSortByValue{&kvSort{rr.KVs}}
-		default:
-			lg.Panic("unexpected sort target", zap.Int32("sort-target", int32(r.SortTarget)))
-		}
-		switch {
-		case sortOrder == pb.RangeRequest_ASCEND:
-			sort.Sort(sorter)
-		case sortOrder == pb.RangeRequest_DESCEND:
-			sort.Sort(sort.Reverse(sorter))
-		}
+	switch {
+	case sortOrder == pb.RangeRequest_ASCEND:
+		sort.Sort(sorter)
+	case sortOrder == pb.RangeRequest_DESCEND:
+		sort.Sort(sort.Reverse(sorter))
 	}
 
 	if r.Limit > 0 && len(rr.KVs) > int(r.Limit) {
@@ -212,12 +232,91 @@ func Range(ctx context.Context, lg *zap.Logger, kv mvcc.KV, txnRead mvcc.TxnRead
 		if r.KeysOnly {
 			rr.KVs[i].Value = nil
 		}
+		maskKV(&rr.KVs[i], r)
 		resp.Kvs[i] = &rr.KVs[i]
 	}
 	trace.Step("assemble the response")
 	return resp, nil
 }
 
+// rangeSortOrder returns the order the range results must be sorted in, or
+// RangeRequest_NONE when the key order of the mvcc store already satisfies it.
+func rangeSortOrder(r *pb.RangeRequest) pb.RangeRequest_SortOrder {
+	sortOrder := r.SortOrder
+	if r.SortTarget != pb.RangeRequest_KEY && sortOrder == pb.RangeRequest_NONE {
+		// Since current mvcc.Range implementation returns results
+		// sorted by keys in lexiographically ascending order,
+		// sort ASCEND by default only when target is not 'KEY'
+		sortOrder = pb.RangeRequest_ASCEND
+	} else if r.SortTarget == pb.RangeRequest_KEY && sortOrder == pb.RangeRequest_ASCEND {
+		// Since current mvcc.Range implementation returns results
+		// sorted by keys in lexiographically ascending order,
+		// don't re-sort when target is 'KEY' and order is ASCEND
+		sortOrder = pb.RangeRequest_NONE
+	}
+	return sortOrder
+}
+
+// rangeInKeyOrder serves a range that needs no sorting. The key-value pairs
+// are filtered and added to the response as they are read from the backend,
+// so only the pairs that are returned are ever held in memory. The response
+// is built in b if it is not nil.
+func rangeInKeyOrder(ctx context.Context, txnRead mvcc.TxnRead, r *pb.RangeRequest, hideExpired bool, b *RangeBuffer) (*pb.RangeResponse, error) {
+	trace := traceutil.Get(ctx)
+
+	var resp *pb.RangeResponse
+	if b != nil {
+		resp = b.response()
+	}
+	if resp == nil {
+		resp = &pb.RangeResponse{}
+		resp.Header = &pb.ResponseHeader{}
+		resp.Kvs = []*mvccpb.KeyValue{}
+		b = nil
+	}
+
+	filtered := r.MinModRevision != 0 || r.MaxModRevision != 0 ||
+		r.MinCreateRevision != 0 || r.MaxCreateRevision != 0
+	ro := mvcc.RangeOptions{
+		Rev:         r.Revision,
+		Count:       r.CountOnly,
+		HideExpired: hideExpired,
+	}
+	if r.Limit > 0 && !filtered {
+		// fetch one extra for 'more' flag
+		ro.Limit = r.Limit + 1
+	}
+	if b != nil {
+		ro.NewKV = b.newKV
+	}
+
+	rr, err := txnRead.RangeFunc(ctx, r.Key, mkGteRange(r.RangeEnd), ro, func(kv *mvccpb.KeyValue) bool {
+		if (r.MaxModRevision != 0 && kv.ModRevision > r.MaxModRevision) ||
+			(r.MinModRevision != 0 && kv.ModRevision < r.MinModRevision) ||
+			(r.MaxCreateRevision != 0 && kv.CreateRevision > r.MaxCreateRevision) ||
+			(r.MinCreateRevision != 0 && kv.CreateRevision < r.MinCreateRevision) {
+			return true
+		}
+		if r.Limit > 0 && len(resp.Kvs) == int(r.Limit) {
+			resp.More = true
+			return false
+		}
+		if r.KeysOnly {
+			kv.Value = nil
+		}
+		maskKV(kv, r)
+		resp.Kvs = append(resp.Kvs, kv)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	trace.Step("filter the key-value pairs and assemble the response")
+	resp.Header.Revision = rr.Rev
+	resp.Count = int64(rr.Count)
+	return resp, nil
+}
+
 func Txn(ctx context.Context, lg *zap.Logger, rt *pb.TxnRequest, txnModeWriteWithSharedBuffer bool, kv mvcc.KV, lessor lease.Lessor) (*pb.TxnResponse, *traceutil.Trace, error) {
 	trace := traceutil.Get(ctx)
 	if trace.IsEmpty() {
@@ -264,7 +363,7 @@ func Txn(ctx context.Context, lg *zap.Logger, rt *pb.TxnRequest, txnModeWriteWit
 	// be the revision of the write txnWrite.
 	if isWrite {
 		txnWrite.End()
-		txnWrite = kv.Write(trace)
+		txnWrite = write(ctx, kv, trace)
 	}
 	_, err := applyTxn(ctx, lg, kv, lessor, txnWrite, rt, txnPath, txnResp)
 	if err != nil {
@@ -356,7 +455,7 @@ func applyTxn(ctx context.Context, lg *zap.Logger, kv mvcc.KV, lessor lease.Less
 			respi.(*pb.ResponseOp_ResponsePut).ResponsePut = resp
 			trace.StopSubTrace()
 		case *pb.RequestOp_RequestDeleteRange:
-			resp, err := DeleteRange(kv, txnWrite, tv.RequestDeleteRange)
+			resp, err := DeleteRange(ctx, kv, txnWrite, tv.RequestDeleteRange)
 			if err != nil {
 				return 0, fmt.Errorf("applyTxn: failed DeleteRange: %w", err)
 			}
@@ -387,7 +486,7 @@ func checkRequestPut(rv mvcc.ReadView, lessor lease.Lessor, reqOp *pb.RequestOp)
 		return nil
 	}
 	req := tv.RequestPut
-	if req.IgnoreValue || req.IgnoreLease {
+	if req.IgnoreValue || req.IgnoreLease || req.IgnoreMetadata {
 		// expects previous key-value, error if not exist
 		rr, err := rv.Range(context.TODO(), req.Key, nil, mvcc.RangeOptions{})
 		if err != nil {
@@ -467,6 +566,31 @@ func pruneKVs(rr *mvcc.RangeResult, isPrunable func(*mvccpb.KeyValue) bool) {
 	rr.KVs = rr.KVs[:j]
 }
 
+// maskKV unsets the fields of kv excluded by r, and truncates its value to
+// the max value size of r.
+func maskKV(kv *mvccpb.KeyValue, r *pb.RangeRequest) {
+	for _, f := range r.ExcludeFields {
+		switch f {
+		case pb.RangeRequest_EXCLUDE_VALUE:
+			kv.Value = nil
+		case pb.RangeRequest_EXCLUDE_CREATE_REVISION:
+			kv.CreateRevision = 0
+		case pb.RangeRequest_EXCLUDE_MOD_REVISION:
+			kv.ModRevision = 0
+		case pb.RangeRequest_EXCLUDE_VERSION:
+			kv.Version = 0
+		case pb.RangeRequest_EXCLUDE_LEASE:
+			kv.Lease = 0
+		case pb.RangeRequest_EXCLUDE_METADATA:
+			kv.Metadata = nil
+		}
+	}
+	if r.MaxValueSize > 0 && int64(len(kv.Value)) > r.MaxValueSize {
+		kv.Value = kv.Value[:r.MaxValueSize]
+		kv.ValueTruncated = true
+	}
+}
+
 type kvSort struct{ kvs []mvccpb.KeyValue }
 
 func (s *kvSort) Swap(i, j int) {
@@ -554,7 +678,15 @@ func applyCompare(rv mvcc.ReadView, c *pb.Compare) bool {
 	if err != nil {
 		return false
 	}
-	if len(rr.KVs) == 0 {
+	kvs := rr.KVs
+	for _, iv := range c.Intervals {
+		ir, err := rv.Range(context.TODO(), iv.Key, mkGteRange(iv.RangeEnd), mvcc.RangeOptions{})
+		if err != nil {
+			return false
+		}
+		kvs = append(kvs, ir.KVs...)
+	}
+	if len(kvs) == 0 {
 		if c.Target == pb.Compare_VALUE {
 			// Always fail if comparing a value on a key/keys that doesn't exist;
 			// nil == empty string in grpc; no way to represent missing value
@@ -562,7 +694,7 @@ func applyCompare(rv mvcc.ReadView, c *pb.Compare) bool {
 		}
 		return compareKV(c, mvccpb.KeyValue{})
 	}
-	for _, kv := range rr.KVs {
+	for _, kv := range kvs {
 		if !compareKV(c, kv) {
 			return false
 		}
@@ -647,6 +779,11 @@ func CheckTxnAuth(as auth.AuthStore, ai *auth.AuthInfo, rt *pb.TxnRequest) error
 		if err := as.IsRangePermitted(ai, c.Key, c.RangeEnd); err != nil {
 			return err
 		}
+		for _, iv := range c.Intervals {
+			if err := as.IsRangePermitted(ai, iv.Key, iv.RangeEnd); err != nil {
+				return err
+			}
+		}
 	}
 	if err := checkTxnReqsPermission(as, ai, rt.Success); err != nil {
 		return err
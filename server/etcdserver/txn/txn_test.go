@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/etcdserver/errors"
 	"go.etcd.io/etcd/server/v3/lease"
 	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
@@ -79,3 +81,207 @@ func TestWriteTxnPanic(t *testing.T) {
 
 	assert.Panics(t, func() { Txn(ctx, zaptest.NewLogger(t), txn, false, s, &lease.FakeLessor{}) }, "Expected panic in Txn with writes")
 }
+
+func TestRangeFieldMask(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, b)
+	s := mvcc.NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer s.Close()
+
+	s.Put([]byte("foo"), []byte("0123456789"), lease.NoLease)
+	s.Put([]byte("foo1"), []byte("01"), lease.NoLease)
+
+	resp, err := Range(context.TODO(), zaptest.NewLogger(t), s, nil, &pb.RangeRequest{
+		Key:           []byte("foo"),
+		RangeEnd:      []byte("fop"),
+		ExcludeFields: []pb.RangeRequest_ExcludeField{pb.RangeRequest_EXCLUDE_CREATE_REVISION, pb.RangeRequest_EXCLUDE_VERSION},
+		MaxValueSize:  4,
+		SortOrder:     pb.RangeRequest_ASCEND,
+		SortTarget:    pb.RangeRequest_VALUE,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, resp.Kvs, 2)
+	// values are sorted before being truncated
+	assert.Equal(t, "01", string(resp.Kvs[0].Value))
+	assert.False(t, resp.Kvs[0].ValueTruncated)
+	assert.Equal(t, "0123", string(resp.Kvs[1].Value))
+	assert.True(t, resp.Kvs[1].ValueTruncated)
+	for _, kv := range resp.Kvs {
+		assert.Zero(t, kv.CreateRevision)
+		assert.Zero(t, kv.Version)
+		assert.NotZero(t, kv.ModRevision)
+	}
+
+	resp, err = Range(context.TODO(), zaptest.NewLogger(t), s, nil, &pb.RangeRequest{
+		Key:           []byte("foo"),
+		ExcludeFields: []pb.RangeRequest_ExcludeField{pb.RangeRequest_EXCLUDE_VALUE},
+		MaxValueSize:  4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, resp.Kvs, 1)
+	assert.Nil(t, resp.Kvs[0].Value)
+	assert.False(t, resp.Kvs[0].ValueTruncated)
+}
+
+func TestRangeLimitInKeyOrder(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, b)
+	s := mvcc.NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer s.Close()
+
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		s.Put([]byte(k), []byte("v"), lease.NoLease)
+	}
+
+	tests := []struct {
+		name  string
+		req   *pb.RangeRequest
+		wkeys []string
+		wmore bool
+	}{
+		{
+			name:  "limit",
+			req:   &pb.RangeRequest{Key: []byte("a"), RangeEnd: []byte("f"), Limit: 2},
+			wkeys: []string{"a", "b"},
+			wmore: true,
+		},
+		{
+			name:  "limit equal to the number of keys",
+			req:   &pb.RangeRequest{Key: []byte("a"), RangeEnd: []byte("f"), Limit: 5},
+			wkeys: []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:  "limit after filtering by mod revision",
+			req:   &pb.RangeRequest{Key: []byte("a"), RangeEnd: []byte("f"), Limit: 2, MinModRevision: 3},
+			wkeys: []string{"b", "c"},
+			wmore: true,
+		},
+		{
+			name:  "limit not reached after filtering by create revision",
+			req:   &pb.RangeRequest{Key: []byte("a"), RangeEnd: []byte("f"), Limit: 2, MaxCreateRevision: 3},
+			wkeys: []string{"a", "b"},
+		},
+		{
+			name:  "keys only",
+			req:   &pb.RangeRequest{Key: []byte("a"), RangeEnd: []byte("c"), KeysOnly: true},
+			wkeys: []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := Range(context.TODO(), zaptest.NewLogger(t), s, nil, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var keys []string
+			for _, kv := range resp.Kvs {
+				keys = append(keys, string(kv.Key))
+				if tt.req.KeysOnly {
+					assert.Nil(t, kv.Value)
+				}
+			}
+			assert.Equal(t, tt.wkeys, keys)
+			assert.Equal(t, tt.wmore, resp.More)
+			assert.Equal(t, int64(6), resp.Header.Revision)
+		})
+	}
+}
+
+func TestPutMetadata(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, b)
+	s := mvcc.NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer s.Close()
+	lg := zaptest.NewLogger(t)
+
+	get := func(r *pb.RangeRequest) *mvccpb.KeyValue {
+		t.Helper()
+		r.Key = []byte("foo")
+		resp, err := Range(context.TODO(), lg, s, nil, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, resp.Kvs, 1)
+		return resp.Kvs[0]
+	}
+
+	_, _, err := Put(context.TODO(), lg, nil, s, nil, &pb.PutRequest{Key: []byte("foo"), Value: []byte("bar"), Metadata: []byte("text/plain")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// metadata is returned without the value
+	kv := get(&pb.RangeRequest{KeysOnly: true})
+	assert.Equal(t, "text/plain", string(kv.Metadata))
+	assert.Nil(t, kv.Value)
+	kv = get(&pb.RangeRequest{ExcludeFields: []pb.RangeRequest_ExcludeField{pb.RangeRequest_EXCLUDE_METADATA}})
+	assert.Nil(t, kv.Metadata)
+	assert.Equal(t, "bar", string(kv.Value))
+
+	_, _, err = Put(context.TODO(), lg, nil, s, nil, &pb.PutRequest{Key: []byte("foo"), Value: []byte("baz"), IgnoreMetadata: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "text/plain", string(get(&pb.RangeRequest{}).Metadata))
+
+	// a put without metadata clears it
+	_, _, err = Put(context.TODO(), lg, nil, s, nil, &pb.PutRequest{Key: []byte("foo"), Value: []byte("qux")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, get(&pb.RangeRequest{}).Metadata)
+
+	_, _, err = Put(context.TODO(), lg, nil, s, nil, &pb.PutRequest{Key: []byte("bar"), IgnoreMetadata: true})
+	assert.Equal(t, errors.ErrKeyNotFound, err)
+}
+
+func TestRangeBuffer(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, b)
+	s := mvcc.NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer s.Close()
+	lg := zaptest.NewLogger(t)
+
+	s.Put([]byte("a"), []byte("long value"), 1)
+	s.Put([]byte("b"), []byte("v"), lease.NoLease)
+
+	buf := &RangeBuffer{}
+	ctx := WithRangeBuffer(context.TODO(), buf)
+	resp, err := Range(ctx, lg, s, nil, &pb.RangeRequest{Key: []byte("a"), RangeEnd: []byte("c")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, buf.Owns(resp))
+	assert.Len(t, resp.Kvs, 2)
+	assert.Equal(t, int64(1), resp.Kvs[0].Lease)
+
+	// a second range does not reuse the buffer in use
+	resp2, err := Range(ctx, lg, s, nil, &pb.RangeRequest{Key: []byte("a")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, buf.Owns(resp2))
+
+	// the recycled key-values are read over without keeping stale fields
+	buf.reset()
+	resp, err = Range(ctx, lg, s, nil, &pb.RangeRequest{Key: []byte("b")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, buf.Owns(resp))
+	assert.Equal(t, []*mvccpb.KeyValue{{Key: []byte("b"), Value: []byte("v"), CreateRevision: 3, ModRevision: 3, Version: 1}}, resp.Kvs)
+	assert.Equal(t, 1, buf.n)
+
+	// sorted ranges do not use the buffer
+	buf.Release()
+	buf = GetRangeBuffer()
+	resp, err = Range(WithRangeBuffer(context.TODO(), buf), lg, s, nil, &pb.RangeRequest{Key: []byte("a"), RangeEnd: []byte("c"), SortOrder: pb.RangeRequest_DESCEND})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, buf.Owns(resp))
+	buf.Release()
+}
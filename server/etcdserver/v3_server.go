@@ -20,6 +20,7 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
@@ -37,7 +38,6 @@ import (
 
 	"github.com/gogo/protobuf/proto"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
 const (
@@ -52,6 +52,11 @@ const (
 	// The timeout for the node to catch up its applied index, and is used in
 	// lease related operations, such as LeaseRenew and LeaseTimeToLive.
 	applyTimeout = time.Second
+
+	// defaultReadFallbackTimeout is the time a linearizable range allowed to
+	// fall back to a serializable read waits before falling back, if the
+	// request does not give one.
+	defaultReadFallbackTimeout = time.Second
 )
 
 type RaftKV interface {
@@ -77,6 +82,9 @@ type Lessor interface {
 
 	// LeaseLeases lists all leases.
 	LeaseLeases(ctx context.Context, r *pb.LeaseLeasesRequest) (*pb.LeaseLeasesResponse, error)
+
+	// LeaseTop lists the leases with the most attached keys.
+	LeaseTop(ctx context.Context, r *pb.LeaseTopRequest) (*pb.LeaseTopResponse, error)
 }
 
 type Authenticator interface {
@@ -97,6 +105,7 @@ type Authenticator interface {
 	RoleDelete(ctx context.Context, r *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDeleteResponse, error)
 	UserList(ctx context.Context, r *pb.AuthUserListRequest) (*pb.AuthUserListResponse, error)
 	RoleList(ctx context.Context, r *pb.AuthRoleListRequest) (*pb.AuthRoleListResponse, error)
+	CheckPermissions(ctx context.Context, r *pb.AuthCheckPermissionsRequest) (*pb.AuthCheckPermissionsResponse, error)
 }
 
 func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, error) {
@@ -109,6 +118,7 @@ func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeRe
 
 	var resp *pb.RangeResponse
 	var err error
+	var degraded bool
 	defer func(start time.Time) {
 		txn.WarnOfExpensiveReadOnlyRangeRequest(s.Logger(), s.Cfg.WarningApplyDuration, start, r, resp, err)
 		if resp != nil {
@@ -117,11 +127,16 @@ func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeRe
 				traceutil.Field{Key: "response_revision", Value: resp.Header.Revision},
 			)
 		}
-		trace.LogIfLong(traceThreshold)
+		trace.LogIfLong(traceThresholdFor(ctx))
 	}(time.Now())
 
 	if !r.Serializable {
-		err = s.linearizableReadNotify(ctx)
+		if r.FallbackMaxStalenessMs > 0 {
+			timeout := time.Duration(r.FallbackTimeoutMs) * time.Millisecond
+			degraded, err = s.linearizableReadNotifyOrFallback(ctx, timeout, time.Duration(r.FallbackMaxStalenessMs)*time.Millisecond)
+		} else {
+			err = s.linearizableReadNotify(ctx)
+		}
 		trace.Step("agreement among raft nodes before linearized reading")
 		if err != nil {
 			return nil, err
@@ -136,6 +151,9 @@ func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeRe
 		err = serr
 		return nil, err
 	}
+	if resp != nil && degraded {
+		resp.Header.DegradedRead = true
+	}
 	return resp, err
 }
 
@@ -178,7 +196,7 @@ func (s *EtcdServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse
 
 		defer func(start time.Time) {
 			txn.WarnOfExpensiveReadOnlyTxnRequest(s.Logger(), s.Cfg.WarningApplyDuration, start, r, resp, err)
-			trace.LogIfLong(traceThreshold)
+			trace.LogIfLong(traceThresholdFor(ctx))
 		}(time.Now())
 
 		get := func() {
@@ -243,6 +261,75 @@ func (s *EtcdServer) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.
 	return resp, nil
 }
 
+// Purge removes the revisions of the keys deleted at or before the requested
+// revision, and waits until they are removed from the local backend.
+func (s *EtcdServer) Purge(ctx context.Context, r *pb.PurgeRequest) (*pb.PurgeResponse, error) {
+	startTime := time.Now()
+	result, err := s.processInternalRaftRequestOnce(ctx, pb.InternalRaftRequest{Purge: r})
+	if err != nil {
+		return nil, err
+	}
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	trace := traceutil.TODO()
+	if result.Trace != nil {
+		trace = result.Trace
+		defer func() {
+			trace.LogIfLong(traceThreshold)
+		}()
+		applyStart := result.Trace.GetStartTime()
+		result.Trace.SetStartTime(startTime)
+		trace.InsertStep(0, applyStart, "process raft request")
+	}
+	if result.Physc != nil {
+		select {
+		case <-result.Physc:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.stopping:
+			return nil, errors.ErrStopped
+		}
+		trace.Step("physically apply purge")
+	}
+	resp := result.Resp.(*pb.PurgeResponse)
+	trace.AddField(traceutil.Field{Key: "purged", Value: resp.Purged})
+	return resp, nil
+}
+
+// PinRevision keeps the auto compaction from compacting past a revision
+// until it is unpinned or its TTL elapses.
+func (s *EtcdServer) PinRevision(ctx context.Context, r *pb.PinRevisionRequest) (*pb.PinRevisionResponse, error) {
+	// no id given? choose one
+	for r.ID == 0 {
+		// only use positive int64 id's
+		r.ID = int64(s.reqIDGen.Next() & ((1 << 63) - 1))
+	}
+	resp, err := s.raftRequestOnce(ctx, pb.InternalRaftRequest{PinRevision: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.PinRevisionResponse), nil
+}
+
+func (s *EtcdServer) UnpinRevision(ctx context.Context, r *pb.UnpinRevisionRequest) (*pb.UnpinRevisionResponse, error) {
+	resp, err := s.raftRequestOnce(ctx, pb.InternalRaftRequest{UnpinRevision: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.UnpinRevisionResponse), nil
+}
+
+// EffectiveConfig returns the configuration of the member, as resolved when
+// it started.
+func (s *EtcdServer) EffectiveConfig(ctx context.Context, r *pb.EffectiveConfigRequest) (*pb.EffectiveConfigResponse, error) {
+	config := make(map[string]string, len(s.Cfg.EffectiveConfig))
+	for name, v := range s.Cfg.EffectiveConfig {
+		config[name] = v
+	}
+	return &pb.EffectiveConfigResponse{Config: config}, nil
+}
+
 func (s *EtcdServer) LeaseGrant(ctx context.Context, r *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
 	// no id given? choose one
 	for r.ID == int64(lease.NoLease) {
@@ -277,6 +364,16 @@ func (s *EtcdServer) LeaseRevoke(ctx context.Context, r *pb.LeaseRevokeRequest)
 }
 
 func (s *EtcdServer) LeaseRenew(ctx context.Context, id lease.LeaseID) (int64, error) {
+	// the renewals forwarded to the leader carry no credentials, so the
+	// member receiving them checks that the user may keep the lease alive
+	authInfo, err := s.AuthInfoFromCtx(ctx)
+	if err != nil {
+		return -1, err
+	}
+	if err = s.authStore.IsLeasePermitted(authInfo, int64(id)); err != nil {
+		return -1, err
+	}
+
 	if s.isLeader() {
 		if err := s.waitAppliedIndex(); err != nil {
 			return 0, err
@@ -386,6 +483,15 @@ func (s *EtcdServer) LeaseLeases(_ context.Context, _ *pb.LeaseLeasesRequest) (*
 	return &pb.LeaseLeasesResponse{Header: s.newHeader(), Leases: lss}, nil
 }
 
+func (s *EtcdServer) LeaseTop(_ context.Context, r *pb.LeaseTopRequest) (*pb.LeaseTopResponse, error) {
+	ls := s.lessor.TopLeases(int(r.Limit))
+	lss := make([]*pb.LeaseTopStatus, len(ls))
+	for i := range ls {
+		lss[i] = &pb.LeaseTopStatus{ID: int64(ls[i].ID), GrantedTTL: ls[i].TTL(), KeyCount: int64(ls[i].NumItems())}
+	}
+	return &pb.LeaseTopResponse{Header: s.newHeader(), Leases: lss}, nil
+}
+
 func (s *EtcdServer) waitLeader(ctx context.Context) (*membership.Member, error) {
 	leader := s.cluster.Member(s.Leader())
 	for leader == nil {
@@ -439,6 +545,17 @@ func (s *EtcdServer) AuthStatus(ctx context.Context, r *pb.AuthStatusRequest) (*
 }
 
 func (s *EtcdServer) Authenticate(ctx context.Context, r *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	start := time.Now()
+	resp, err := s.authenticate(ctx, r)
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	authenticateDurationSec.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+func (s *EtcdServer) authenticate(ctx context.Context, r *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
 	if err := s.linearizableReadNotify(ctx); err != nil {
 		return nil, err
 	}
@@ -487,7 +604,7 @@ func (s *EtcdServer) Authenticate(ctx context.Context, r *pb.AuthenticateRequest
 
 func (s *EtcdServer) UserAdd(ctx context.Context, r *pb.AuthUserAddRequest) (*pb.AuthUserAddResponse, error) {
 	if r.Options == nil || !r.Options.NoPassword {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(r.Password), s.authStore.BcryptCost())
+		hashedPassword, err := auth.HashPassword(r.Password, s.authStore.BcryptCost())
 		if err != nil {
 			return nil, err
 		}
@@ -512,7 +629,7 @@ func (s *EtcdServer) UserDelete(ctx context.Context, r *pb.AuthUserDeleteRequest
 
 func (s *EtcdServer) UserChangePassword(ctx context.Context, r *pb.AuthUserChangePasswordRequest) (*pb.AuthUserChangePasswordResponse, error) {
 	if r.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(r.Password), s.authStore.BcryptCost())
+		hashedPassword, err := auth.HashPassword(r.Password, s.authStore.BcryptCost())
 		if err != nil {
 			return nil, err
 		}
@@ -607,6 +724,34 @@ func (s *EtcdServer) RoleDelete(ctx context.Context, r *pb.AuthRoleDeleteRequest
 	return resp.(*pb.AuthRoleDeleteResponse), nil
 }
 
+// CheckPermissions evaluates a batch of permission checks against the
+// linearized auth state. Users without the root role may only check their
+// own permissions.
+func (s *EtcdServer) CheckPermissions(ctx context.Context, r *pb.AuthCheckPermissionsRequest) (*pb.AuthCheckPermissionsResponse, error) {
+	if err := s.linearizableReadNotify(ctx); err != nil {
+		return nil, err
+	}
+
+	as := s.AuthStore()
+	authInfo, err := s.AuthInfoFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = as.IsAdminPermitted(authInfo); err != nil {
+		for _, c := range r.Checks {
+			if authInfo == nil || c.User != authInfo.Username {
+				return nil, err
+			}
+		}
+	}
+
+	resp := &pb.AuthCheckPermissionsResponse{Header: s.newHeader(), Allowed: make([]bool, len(r.Checks))}
+	for i, c := range r.Checks {
+		resp.Allowed[i] = as.CheckPermission(c.User, c.Key, c.RangeEnd, c.PermType) == nil
+	}
+	return resp, nil
+}
+
 func (s *EtcdServer) raftRequestOnce(ctx context.Context, r pb.InternalRaftRequest) (proto.Message, error) {
 	result, err := s.processInternalRaftRequestOnce(ctx, r)
 	if err != nil {
@@ -622,11 +767,20 @@ func (s *EtcdServer) raftRequestOnce(ctx context.Context, r pb.InternalRaftReque
 		// and toApply start time
 		result.Trace.SetStartTime(startTime)
 		result.Trace.InsertStep(0, applyStart, "process raft request")
-		result.Trace.LogIfLong(traceThreshold)
+		result.Trace.LogIfLong(traceThresholdFor(ctx))
 	}
 	return result.Resp, nil
 }
 
+// traceThresholdFor returns the duration above which the trace of the request
+// is logged. Requests selected by a trace rule are always logged.
+func traceThresholdFor(ctx context.Context) time.Duration {
+	if force, _ := ctx.Value(traceutil.ForceKey).(bool); force {
+		return 0
+	}
+	return traceThreshold
+}
+
 func (s *EtcdServer) raftRequest(ctx context.Context, r pb.InternalRaftRequest) (proto.Message, error) {
 	return s.raftRequestOnce(ctx, r)
 }
@@ -676,6 +830,25 @@ func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.In
 		if authInfo != nil {
 			r.Header.Username = authInfo.Username
 			r.Header.AuthRevision = authInfo.Revision
+			r.Header.Roles = authInfo.Roles
+		}
+	}
+	if s.Cfg.ExperimentalEnableDistributedTracing && (r.Put != nil || r.DeleteRange != nil || r.Txn != nil) {
+		r.Header.TraceContext = traceContextOf(ctx)
+	}
+	if mirror, _ := ctx.Value(mirrorKey{}).(bool); mirror {
+		// the writes mirrored from the primary cluster are made as root
+		r.Header.Mirror = true
+		if s.authStore.IsAuthEnabled() {
+			r.Header.Username = "root"
+			r.Header.AuthRevision = s.authStore.Revision()
+		}
+	}
+
+	write := isSheddableWrite(&r)
+	if write {
+		if err := s.admitWrite(); err != nil {
+			return nil, err
 		}
 	}
 
@@ -687,6 +860,9 @@ func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.In
 	if len(data) > int(s.Cfg.MaxRequestBytes) {
 		return nil, errors.ErrRequestTooLarge
 	}
+	if data, err = s.compressRaftRequest(data); err != nil {
+		return nil, err
+	}
 
 	id := r.ID
 	if id == 0 {
@@ -706,9 +882,14 @@ func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.In
 	}
 	proposalsPending.Inc()
 	defer proposalsPending.Dec()
+	if write {
+		atomic.AddInt64(&s.pendingWrites, 1)
+		defer atomic.AddInt64(&s.pendingWrites, -1)
+	}
 
 	select {
 	case x := <-ch:
+		s.proposalLatencies.observe(time.Now(), time.Since(start))
 		return x.(*apply2.Result), nil
 	case <-cctx.Done():
 		proposalsFailed.Inc()
@@ -744,6 +925,7 @@ func (s *EtcdServer) linearizableReadLoop() {
 		s.readNotifier = nextnr
 		s.readMu.Unlock()
 
+		requestedAt := time.Now()
 		confirmedIndex, err := s.requestCurrentIndex(leaderChangedNotifier, requestId)
 		if isStopped(err) {
 			return
@@ -767,6 +949,9 @@ func (s *EtcdServer) linearizableReadLoop() {
 				return
 			}
 		}
+		// the applied state is at least as recent as the state of the cluster
+		// when the read index was requested
+		atomic.StoreInt64(&s.readConfirmedAt, requestedAt.UnixNano())
 		// unblock all l-reads requested at indices before confirmedIndex
 		nr.notify(nil)
 		trace.Step("applied index is now lower than readState.Index")
@@ -902,6 +1087,35 @@ func (s *EtcdServer) linearizableReadNotify(ctx context.Context) error {
 	}
 }
 
+// linearizableReadNotifyOrFallback waits for the member to be up to date with
+// the cluster as linearizableReadNotify, but returns degraded after timeout,
+// such as when the cluster lost its quorum, if the applied state of the member
+// was last confirmed up to date at most maxStaleness ago. It otherwise keeps
+// waiting.
+func (s *EtcdServer) linearizableReadNotifyOrFallback(ctx context.Context, timeout, maxStaleness time.Duration) (degraded bool, err error) {
+	if timeout <= 0 {
+		timeout = defaultReadFallbackTimeout
+	}
+	tctx, cancel := context.WithTimeout(ctx, timeout)
+	err = s.linearizableReadNotify(tctx)
+	cancel()
+	if err == nil || ctx.Err() != nil || isStopped(err) {
+		return false, err
+	}
+	staleness := time.Since(time.Unix(0, atomic.LoadInt64(&s.readConfirmedAt)))
+	if staleness > maxStaleness {
+		return false, s.linearizableReadNotify(ctx)
+	}
+	s.Logger().Debug(
+		"linearizable read fell back to serializable read",
+		zap.Duration("staleness", staleness),
+		zap.Duration("max-staleness", maxStaleness),
+		zap.Error(err),
+	)
+	degradedReads.Inc()
+	return true, nil
+}
+
 func (s *EtcdServer) AuthInfoFromCtx(ctx context.Context) (*auth.AuthInfo, error) {
 	authInfo, err := s.AuthStore().AuthInfoFromCtx(ctx)
 	if authInfo != nil || err != nil {
@@ -0,0 +1,99 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+// WatcherLimiter counts the watchers of each client connection and each
+// authenticated user, and rejects the watchers beyond the limits. It is
+// shared by the watch servers of all the gRPC servers of an EtcdServer, so
+// the per user limit spans listeners. A nil WatcherLimiter limits nothing.
+type WatcherLimiter struct {
+	maxPerConn int
+	maxPerUser int
+
+	mu    sync.Mutex
+	conns map[string]int
+	users map[string]int
+}
+
+// newWatcherLimiter returns a limiter of the given limits, or nil if
+// neither is positive.
+func newWatcherLimiter(maxPerConn, maxPerUser int) *WatcherLimiter {
+	if maxPerConn <= 0 && maxPerUser <= 0 {
+		return nil
+	}
+	return &WatcherLimiter{
+		maxPerConn: maxPerConn,
+		maxPerUser: maxPerUser,
+		conns:      make(map[string]int),
+		users:      make(map[string]int),
+	}
+}
+
+// WatcherLimiter returns the limiter of the watchers of the server, or nil
+// if the server does not limit watchers.
+func (s *EtcdServer) WatcherLimiter() *WatcherLimiter { return s.watcherLimiter }
+
+// Acquire counts a watcher of conn and user. It returns ErrGRPCTooManyWatchers
+// if either already reached its limit. Empty keys are not limited.
+func (l *WatcherLimiter) Acquire(conn, user string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.maxPerConn > 0 && conn != "" && l.conns[conn] >= l.maxPerConn {
+		watchersRejected.WithLabelValues("connection").Inc()
+		return rpctypes.ErrGRPCTooManyWatchers
+	}
+	if l.maxPerUser > 0 && user != "" && l.users[user] >= l.maxPerUser {
+		watchersRejected.WithLabelValues("user").Inc()
+		return rpctypes.ErrGRPCTooManyWatchers
+	}
+	if conn != "" {
+		l.conns[conn]++
+	}
+	if user != "" {
+		l.users[user]++
+	}
+	return nil
+}
+
+// Release uncounts a watcher acquired for conn and user.
+func (l *WatcherLimiter) Release(conn, user string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	decrement(l.conns, conn)
+	decrement(l.users, user)
+}
+
+func decrement(m map[string]int, k string) {
+	if k == "" {
+		return
+	}
+	if m[k] <= 1 {
+		delete(m, k)
+		return
+	}
+	m[k]--
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+func TestWatcherLimiter(t *testing.T) {
+	l := newWatcherLimiter(2, 3)
+
+	mustAcquire := func(conn, user string) {
+		t.Helper()
+		if err := l.Acquire(conn, user); err != nil {
+			t.Fatalf("acquire(%q, %q) = %v, want nil", conn, user, err)
+		}
+	}
+	mustReject := func(conn, user string) {
+		t.Helper()
+		if err := l.Acquire(conn, user); err != rpctypes.ErrGRPCTooManyWatchers {
+			t.Fatalf("acquire(%q, %q) = %v, want %v", conn, user, err, rpctypes.ErrGRPCTooManyWatchers)
+		}
+	}
+
+	mustAcquire("c1", "alice")
+	mustAcquire("c1", "alice")
+	// connection limit
+	mustReject("c1", "bob")
+	mustAcquire("c2", "alice")
+	// user limit across connections
+	mustReject("c3", "alice")
+	// unauthenticated watchers only count against their connection
+	mustAcquire("c3", "")
+	mustAcquire("c3", "")
+	mustReject("c3", "")
+
+	l.Release("c1", "alice")
+	mustAcquire("c1", "bob")
+	mustAcquire("c4", "alice")
+	mustReject("c5", "alice")
+	l.Release("c2", "alice")
+	mustAcquire("c5", "alice")
+
+	disabled := newWatcherLimiter(0, 0)
+	if disabled != nil {
+		t.Fatalf("newWatcherLimiter(0, 0) = %v, want nil", disabled)
+	}
+	if err := disabled.Acquire("c1", "alice"); err != nil {
+		t.Fatalf("acquire on disabled limiter = %v, want nil", err)
+	}
+	disabled.Release("c1", "alice")
+}
@@ -28,6 +28,11 @@ type Lease struct {
 	ID           LeaseID
 	ttl          int64 // time to live of the lease in seconds
 	remainingTTL int64 // remaining time to live in seconds, if zero valued it is considered unset and the full ttl should be used
+	// keepaliveOnWrite is set if the writes of the attached items renew the lease
+	keepaliveOnWrite bool
+	// checkpointInterval is the interval in seconds between the checkpoints of
+	// the remaining TTL, if zero valued the interval of the lessor is used
+	checkpointInterval int64
 	// expiryMu protects concurrent accesses to expiry
 	expiryMu sync.RWMutex
 	// expiry is time when lease should expire. no expiration when expiry.IsZero() is true
@@ -44,7 +49,7 @@ func (l *Lease) expired() bool {
 }
 
 func (l *Lease) persistTo(b backend.Backend) {
-	lpb := leasepb.Lease{ID: int64(l.ID), TTL: l.ttl, RemainingTTL: l.remainingTTL}
+	lpb := leasepb.Lease{ID: int64(l.ID), TTL: l.ttl, RemainingTTL: l.remainingTTL, KeepaliveOnWrite: l.keepaliveOnWrite, CheckpointInterval: l.checkpointInterval}
 	tx := b.BatchTx()
 	tx.LockInsideApply()
 	defer tx.Unlock()
@@ -56,6 +61,12 @@ func (l *Lease) TTL() int64 {
 	return l.ttl
 }
 
+// KeepaliveOnWrite returns true if the writes of the items attached to the
+// Lease renew it.
+func (l *Lease) KeepaliveOnWrite() bool {
+	return l.keepaliveOnWrite
+}
+
 // RemainingTTL returns the last checkpointed remaining TTL of the lease.
 func (l *Lease) getRemainingTTL() int64 {
 	if l.remainingTTL > 0 {
@@ -90,6 +101,13 @@ func (l *Lease) Keys() []string {
 	return keys
 }
 
+// NumItems returns the number of items attached to the lease.
+func (l *Lease) NumItems() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.itemSet)
+}
+
 // Remaining returns the remaining time of the lease.
 func (l *Lease) Remaining() time.Duration {
 	l.expiryMu.RLock()
@@ -0,0 +1,48 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leasehook
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	webhookSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "lease",
+		Name:      "revoke_webhook_sent_total",
+		Help:      "The total number of lease revocations posted to the revoke webhook.",
+	})
+
+	webhookFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "lease",
+		Name:      "revoke_webhook_failed_total",
+		Help:      "The total number of lease revocations that could not be posted to the revoke webhook.",
+	})
+
+	webhookDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd_debugging",
+		Subsystem: "lease",
+		Name:      "revoke_webhook_dropped_total",
+		Help:      "The total number of lease revocations dropped because the revoke webhook queue was full.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(webhookSent)
+	prometheus.MustRegister(webhookFailed)
+	prometheus.MustRegister(webhookDropped)
+}
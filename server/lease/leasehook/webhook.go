@@ -0,0 +1,161 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leasehook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.etcd.io/etcd/server/v3/lease"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// queueLen is the number of revocations buffered before new ones are dropped.
+	queueLen = 1024
+	// maxAttempts is the number of times a revocation is posted before giving up.
+	maxAttempts = 3
+	// retryInterval is the wait before the first retry; it doubles on each retry.
+	retryInterval = 100 * time.Millisecond
+	// requestTimeout bounds each post to the webhook.
+	requestTimeout = 5 * time.Second
+)
+
+// RevokeEvent is the JSON body posted to the webhook for each revoked lease.
+type RevokeEvent struct {
+	LeaseID int64    `json:"lease-id"`
+	Keys    []string `json:"keys"`
+}
+
+// Webhook posts revoked leases with their deleted keys to an HTTP endpoint.
+// Revocations are applied on every member, so only the leader posts them.
+// Delivery is asynchronous and best effort: revocations are dropped when the
+// queue is full or the endpoint keeps failing.
+type Webhook struct {
+	lg       *zap.Logger
+	url      string
+	client   *http.Client
+	isLeader func() bool
+
+	eventc chan RevokeEvent
+	ctx    context.Context
+	cancel context.CancelFunc
+	donec  chan struct{}
+}
+
+// NewWebhook starts a webhook posting revocations to url while isLeader
+// returns true.
+func NewWebhook(lg *zap.Logger, url string, isLeader func() bool) *Webhook {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	w := &Webhook{
+		lg:       lg,
+		url:      url,
+		client:   &http.Client{Timeout: requestTimeout},
+		isLeader: isLeader,
+		eventc:   make(chan RevokeEvent, queueLen),
+		donec:    make(chan struct{}),
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	go w.run()
+	return w
+}
+
+// Notify queues the revocation of the given lease. It never blocks, so it
+// can be used as a lease.RevokeHook.
+func (w *Webhook) Notify(id lease.LeaseID, keys []string) {
+	if !w.isLeader() {
+		return
+	}
+	select {
+	case w.eventc <- RevokeEvent{LeaseID: int64(id), Keys: keys}:
+	default:
+		webhookDropped.Inc()
+		w.lg.Warn("dropped lease revoke webhook notification; queue is full", zap.Int64("lease-id", int64(id)))
+	}
+}
+
+// Stop stops posting revocations. Queued revocations are discarded.
+func (w *Webhook) Stop() {
+	w.cancel()
+	<-w.donec
+}
+
+func (w *Webhook) run() {
+	defer close(w.donec)
+	for {
+		select {
+		case ev := <-w.eventc:
+			w.send(ev)
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *Webhook) send(ev RevokeEvent) {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		w.lg.Panic("failed to marshal lease revoke event", zap.Error(err))
+	}
+	interval := retryInterval
+	for attempt := 1; ; attempt++ {
+		err = w.post(body)
+		if err == nil {
+			webhookSent.Inc()
+			return
+		}
+		if attempt == maxAttempts {
+			break
+		}
+		select {
+		case <-time.After(interval):
+			interval *= 2
+		case <-w.ctx.Done():
+			return
+		}
+	}
+	webhookFailed.Inc()
+	w.lg.Warn(
+		"failed to post lease revoke webhook notification",
+		zap.String("url", w.url),
+		zap.Int64("lease-id", ev.LeaseID),
+		zap.Int("attempts", maxAttempts),
+		zap.Error(err),
+	)
+}
+
+func (w *Webhook) post(body []byte) error {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leasehook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWebhookNotify(t *testing.T) {
+	evc := make(chan RevokeEvent, 1)
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// fail the first attempt to exercise retries
+		if atomic.AddInt32(&calls, 1) == 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var ev RevokeEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("failed to decode revoke event: %v", err)
+		}
+		evc <- ev
+	}))
+	defer srv.Close()
+
+	w := NewWebhook(zaptest.NewLogger(t), srv.URL, func() bool { return true })
+	defer w.Stop()
+
+	w.Notify(5, []string{"bar", "foo"})
+
+	select {
+	case ev := <-evc:
+		want := RevokeEvent{LeaseID: 5, Keys: []string{"bar", "foo"}}
+		if !reflect.DeepEqual(ev, want) {
+			t.Fatalf("event = %+v, want %+v", ev, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for webhook notification")
+	}
+}
+
+func TestWebhookNotifyNotLeader(t *testing.T) {
+	w := NewWebhook(zaptest.NewLogger(t), "http://localhost:0", func() bool { return false })
+	defer w.Stop()
+
+	w.Notify(5, []string{"foo"})
+	if n := len(w.eventc); n != 0 {
+		t.Fatalf("queued events = %d, want 0", n)
+	}
+}
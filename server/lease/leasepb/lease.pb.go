@@ -29,6 +29,8 @@ type Lease struct {
 	ID                   int64    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
 	TTL                  int64    `protobuf:"varint,2,opt,name=TTL,proto3" json:"TTL,omitempty"`
 	RemainingTTL         int64    `protobuf:"varint,3,opt,name=RemainingTTL,proto3" json:"RemainingTTL,omitempty"`
+	KeepaliveOnWrite     bool     `protobuf:"varint,4,opt,name=KeepaliveOnWrite,proto3" json:"KeepaliveOnWrite,omitempty"`
+	CheckpointInterval   int64    `protobuf:"varint,5,opt,name=CheckpointInterval,proto3" json:"CheckpointInterval,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -156,23 +158,27 @@ func init() {
 func init() { proto.RegisterFile("lease.proto", fileDescriptor_3dd57e402472b33a) }
 
 var fileDescriptor_3dd57e402472b33a = []byte{
-	// 256 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0xce, 0x49, 0x4d, 0x2c,
-	0x4e, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x07, 0x73, 0x0a, 0x92, 0xa4, 0x44, 0xd2,
-	0xf3, 0xd3, 0xf3, 0xc1, 0x62, 0xfa, 0x20, 0x16, 0x44, 0x5a, 0x4a, 0x3e, 0xb5, 0x24, 0x39, 0x45,
-	0x3f, 0xb1, 0x20, 0x53, 0x1f, 0xc4, 0x28, 0x4e, 0x2d, 0x2a, 0x4b, 0x2d, 0x2a, 0x48, 0xd2, 0x2f,
-	0x2a, 0x48, 0x86, 0x28, 0x50, 0xf2, 0xe5, 0x62, 0xf5, 0x01, 0x99, 0x20, 0xc4, 0xc7, 0xc5, 0xe4,
-	0xe9, 0x22, 0xc1, 0xa8, 0xc0, 0xa8, 0xc1, 0x1c, 0xc4, 0xe4, 0xe9, 0x22, 0x24, 0xc0, 0xc5, 0x1c,
-	0x12, 0xe2, 0x23, 0xc1, 0x04, 0x16, 0x00, 0x31, 0x85, 0x94, 0xb8, 0x78, 0x82, 0x52, 0x73, 0x13,
-	0x33, 0xf3, 0x32, 0xf3, 0xd2, 0x41, 0x52, 0xcc, 0x60, 0x29, 0x14, 0x31, 0xa5, 0x12, 0x2e, 0x11,
-	0xb0, 0x71, 0x9e, 0x79, 0x25, 0xa9, 0x45, 0x79, 0x89, 0x39, 0x41, 0xa9, 0x85, 0xa5, 0xa9, 0xc5,
-	0x25, 0x42, 0x31, 0x5c, 0x62, 0x60, 0xf1, 0x90, 0xcc, 0xdc, 0xd4, 0x90, 0x7c, 0x9f, 0xcc, 0xb2,
-	0x54, 0xa8, 0x0c, 0xd8, 0x46, 0x6e, 0x23, 0x15, 0x3d, 0x64, 0xf7, 0xe9, 0x61, 0x57, 0x1b, 0x84,
-	0xc3, 0x0c, 0xa5, 0x0a, 0x2e, 0x51, 0x34, 0x5b, 0x8b, 0x0b, 0xf2, 0xf3, 0x8a, 0x53, 0x85, 0xe2,
-	0xb9, 0xc4, 0x31, 0xb4, 0x40, 0xa4, 0xa0, 0xf6, 0xaa, 0x12, 0xb0, 0x17, 0xa2, 0x38, 0x08, 0x97,
-	0x29, 0x4e, 0x12, 0x27, 0x1e, 0xca, 0x31, 0x5c, 0x78, 0x28, 0xc7, 0x70, 0xe2, 0x91, 0x1c, 0xe3,
-	0x85, 0x47, 0x72, 0x8c, 0x0f, 0x1e, 0xc9, 0x31, 0xce, 0x78, 0x2c, 0xc7, 0x90, 0xc4, 0x06, 0x0e,
-	0x5f, 0x63, 0x40, 0x00, 0x00, 0x00, 0xff, 0xff, 0x7b, 0x8a, 0x94, 0xb9, 0xae, 0x01, 0x00, 0x00,
+	// 305 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x91, 0xd1, 0x4a, 0xf3, 0x30,
+	0x14, 0xc7, 0x97, 0xed, 0xdb, 0xa7, 0x64, 0x22, 0x23, 0x4c, 0x2d, 0xbb, 0x88, 0xa3, 0x28, 0x0c,
+	0x2f, 0x5a, 0xd0, 0x37, 0xd0, 0xdd, 0x0c, 0x0b, 0x42, 0x28, 0x78, 0x23, 0x48, 0x5a, 0x0f, 0x35,
+	0xd8, 0x25, 0x31, 0x8d, 0xc5, 0x47, 0xf1, 0x15, 0x7c, 0x93, 0x5d, 0xee, 0x11, 0x5c, 0x7d, 0x11,
+	0x69, 0xba, 0x0b, 0x75, 0x1b, 0xde, 0x9d, 0xfc, 0x7e, 0x27, 0xff, 0x93, 0x43, 0x70, 0x2f, 0x07,
+	0x5e, 0x40, 0xa0, 0x8d, 0xb2, 0x8a, 0xec, 0xb8, 0x83, 0x4e, 0x86, 0x83, 0x4c, 0x65, 0xca, 0xb1,
+	0xb0, 0xae, 0x1a, 0x3d, 0x3c, 0x06, 0x9b, 0x3e, 0x84, 0x5c, 0x8b, 0xb0, 0x2e, 0x0a, 0x30, 0x25,
+	0x18, 0x9d, 0x84, 0x46, 0xa7, 0x4d, 0x83, 0xff, 0x8e, 0x70, 0x37, 0xaa, 0x23, 0xc8, 0x3e, 0x6e,
+	0x4f, 0x27, 0x1e, 0x1a, 0xa1, 0x71, 0x87, 0xb5, 0xa7, 0x13, 0xd2, 0xc7, 0x9d, 0x38, 0x8e, 0xbc,
+	0xb6, 0x03, 0x75, 0x49, 0x7c, 0xbc, 0xc7, 0x60, 0xc6, 0x85, 0x14, 0x32, 0xab, 0x55, 0xc7, 0xa9,
+	0x1f, 0x8c, 0x9c, 0xe1, 0xfe, 0x35, 0x80, 0xe6, 0xb9, 0x28, 0xe1, 0x46, 0xde, 0x1a, 0x61, 0xc1,
+	0xfb, 0x37, 0x42, 0xe3, 0x5d, 0xb6, 0xc6, 0x49, 0x80, 0xc9, 0xd5, 0x23, 0xa4, 0x4f, 0x5a, 0x09,
+	0x69, 0xa7, 0xd2, 0x82, 0x29, 0x79, 0xee, 0x75, 0x5d, 0xea, 0x06, 0xe3, 0x5b, 0x3c, 0x70, 0x4f,
+	0x75, 0x40, 0xf2, 0x9c, 0xc1, 0xf3, 0x0b, 0x14, 0x96, 0xdc, 0xe1, 0x43, 0xc7, 0x63, 0x31, 0x83,
+	0x58, 0x45, 0xa2, 0x84, 0x95, 0x71, 0xdb, 0xf4, 0xce, 0x4f, 0x82, 0xef, 0xcb, 0x07, 0x9b, 0x7b,
+	0xd9, 0x96, 0x0c, 0xff, 0x15, 0x1f, 0xfc, 0x9a, 0x5a, 0x68, 0x25, 0x0b, 0x20, 0xf7, 0xf8, 0x68,
+	0xed, 0x4a, 0xa3, 0x56, 0x73, 0x4f, 0xff, 0x98, 0xdb, 0x34, 0xb3, 0x6d, 0x29, 0x97, 0xde, 0x7c,
+	0x49, 0x5b, 0x8b, 0x25, 0x6d, 0xcd, 0x2b, 0x8a, 0x16, 0x15, 0x45, 0x1f, 0x15, 0x45, 0x6f, 0x9f,
+	0xb4, 0x95, 0xfc, 0x77, 0x9f, 0x77, 0xf1, 0x35, 0x00, 0x22, 0x98, 0x47, 0xbc, 0x0b, 0x02, 0x00,
+	0x00,
 }
 
 func (m *Lease) Marshal() (dAtA []byte, err error) {
@@ -199,6 +205,21 @@ func (m *Lease) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.CheckpointInterval != 0 {
+		i = encodeVarintLease(dAtA, i, uint64(m.CheckpointInterval))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.KeepaliveOnWrite {
+		i--
+		if m.KeepaliveOnWrite {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
 	if m.RemainingTTL != 0 {
 		i = encodeVarintLease(dAtA, i, uint64(m.RemainingTTL))
 		i--
@@ -321,6 +342,12 @@ func (m *Lease) Size() (n int) {
 	if m.RemainingTTL != 0 {
 		n += 1 + sovLease(uint64(m.RemainingTTL))
 	}
+	if m.KeepaliveOnWrite {
+		n += 2
+	}
+	if m.CheckpointInterval != 0 {
+		n += 1 + sovLease(uint64(m.CheckpointInterval))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -451,6 +478,45 @@ func (m *Lease) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field KeepaliveOnWrite", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLease
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.KeepaliveOnWrite = bool(v != 0)
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CheckpointInterval", wireType)
+			}
+			m.CheckpointInterval = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLease
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CheckpointInterval |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipLease(dAtA[iNdEx:])
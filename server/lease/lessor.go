@@ -56,6 +56,9 @@ var (
 	// the default interval to check if the expired lease is revoked
 	defaultExpiredleaseRetryInterval = 3 * time.Second
 
+	// the interval to recompute the histograms of the leases; configurable for tests
+	leaseHistogramInterval = 15 * time.Second
+
 	ErrNotPrimary       = errors.New("not a primary lessor")
 	ErrLeaseNotFound    = errors.New("lease not found")
 	ErrLeaseExists      = errors.New("lease already exists")
@@ -76,6 +79,11 @@ type RangeDeleter func() TxnDelete
 // avoid circular dependency with mvcc.
 type Checkpointer func(ctx context.Context, lc *pb.LeaseCheckpointRequest)
 
+// RevokeHook is invoked after a lease is revoked, explicitly or on expiry,
+// with the keys deleted along with it. Hooks run on the apply path of every
+// member, so they must not block.
+type RevokeHook func(id LeaseID, keys []string)
+
 type LeaseID int64
 
 // Lessor owns leases. It can grant, revoke, renew and modify leases for lessee.
@@ -89,6 +97,11 @@ type Lessor interface {
 
 	// Grant grants a lease that expires at least after TTL seconds.
 	Grant(id LeaseID, ttl int64) (*Lease, error)
+	// GrantKeepaliveOnWrite grants a lease like Grant, which the writes of
+	// the items attached to it renew as well, see RenewOnWrite.
+	GrantKeepaliveOnWrite(id LeaseID, ttl int64) (*Lease, error)
+	// GrantWithOptions grants a lease like Grant with the given options.
+	GrantWithOptions(id LeaseID, ttl int64, opts GrantOptions) (*Lease, error)
 	// Revoke revokes a lease with given ID. The item attached to the
 	// given lease will be removed. If the ID does not exist, an error
 	// will be returned.
@@ -122,12 +135,22 @@ type Lessor interface {
 	// an error will be returned.
 	Renew(id LeaseID) (int64, error)
 
+	// RenewOnWrite renews the lease with given ID after the write of an item
+	// attached to it, if the lease was granted by GrantKeepaliveOnWrite. Only
+	// the primary lessor renews leases, and it does not renew expired ones.
+	RenewOnWrite(id LeaseID)
+
 	// Lookup gives the lease at a given lease id, if any
 	Lookup(id LeaseID) *Lease
 
 	// Leases lists all leases.
 	Leases() []*Lease
 
+	// TopLeases lists the leases with the most attached items, by
+	// descending number of items. At most limit leases are listed, or all
+	// of them if limit is not positive.
+	TopLeases(limit int) []*Lease
+
 	// ExpiredLeasesC returns a chan that is used to receive expired leases.
 	ExpiredLeasesC() <-chan []*Lease
 
@@ -185,6 +208,8 @@ type lessor struct {
 	checkpointPersist bool
 	// cluster is used to adapt lessor logic based on cluster version
 	cluster cluster
+	// revokeHooks are invoked with the deleted keys after each revocation.
+	revokeHooks []RevokeHook
 }
 
 type cluster interface {
@@ -197,6 +222,8 @@ type LessorConfig struct {
 	CheckpointInterval         time.Duration
 	ExpiredLeasesRetryInterval time.Duration
 	CheckpointPersist          bool
+	// RevokeHooks are invoked after each lease revocation.
+	RevokeHooks []RevokeHook
 }
 
 func NewLessor(lg *zap.Logger, b backend.Backend, cluster cluster, cfg LessorConfig) Lessor {
@@ -222,6 +249,7 @@ func newLessor(lg *zap.Logger, b backend.Backend, cluster cluster, cfg LessorCon
 		checkpointInterval:        checkpointInterval,
 		expiredLeaseRetryInterval: expiredLeaseRetryInterval,
 		checkpointPersist:         cfg.CheckpointPersist,
+		revokeHooks:               cfg.RevokeHooks,
 		// expiredC is a small buffered chan to avoid unnecessary blocking.
 		expiredC: make(chan []*Lease, 16),
 		stopC:    make(chan struct{}),
@@ -267,7 +295,26 @@ func (le *lessor) SetCheckpointer(cp Checkpointer) {
 	le.cp = cp
 }
 
+// GrantOptions are the options of a lease granted by GrantWithOptions.
+type GrantOptions struct {
+	// KeepaliveOnWrite renews the lease on the writes of its items, see
+	// GrantKeepaliveOnWrite.
+	KeepaliveOnWrite bool
+	// CheckpointInterval is the interval in seconds between the checkpoints
+	// of the remaining TTL of the lease, in place of the checkpoint interval
+	// of the lessor if positive.
+	CheckpointInterval int64
+}
+
 func (le *lessor) Grant(id LeaseID, ttl int64) (*Lease, error) {
+	return le.GrantWithOptions(id, ttl, GrantOptions{})
+}
+
+func (le *lessor) GrantKeepaliveOnWrite(id LeaseID, ttl int64) (*Lease, error) {
+	return le.GrantWithOptions(id, ttl, GrantOptions{KeepaliveOnWrite: true})
+}
+
+func (le *lessor) GrantWithOptions(id LeaseID, ttl int64, opts GrantOptions) (*Lease, error) {
 	if id == NoLease {
 		return nil, ErrLeaseNotFound
 	}
@@ -279,10 +326,14 @@ func (le *lessor) Grant(id LeaseID, ttl int64) (*Lease, error) {
 	// TODO: when lessor is under high load, it should give out lease
 	// with longer TTL to reduce renew load.
 	l := &Lease{
-		ID:      id,
-		ttl:     ttl,
-		itemSet: make(map[LeaseItem]struct{}),
-		revokec: make(chan struct{}),
+		ID:               id,
+		ttl:              ttl,
+		keepaliveOnWrite: opts.KeepaliveOnWrite,
+		itemSet:          make(map[LeaseItem]struct{}),
+		revokec:          make(chan struct{}),
+	}
+	if opts.CheckpointInterval > 0 {
+		l.checkpointInterval = opts.CheckpointInterval
 	}
 
 	if l.ttl < le.minLeaseTTL {
@@ -357,6 +408,9 @@ func (le *lessor) Revoke(id LeaseID) error {
 	txn.End()
 
 	leaseRevoked.Inc()
+	for _, hook := range le.revokeHooks {
+		hook(l.ID, keys)
+	}
 	return nil
 }
 
@@ -441,6 +495,31 @@ func (le *lessor) Renew(id LeaseID) (int64, error) {
 	return l.ttl, nil
 }
 
+// RenewOnWrite renews an existing lease granted by GrantKeepaliveOnWrite.
+// Unlike Renew, it is called while applying the write, and so neither waits
+// for expired leases to be revoked nor proposes clearing the remaining TTL
+// synchronously.
+func (le *lessor) RenewOnWrite(id LeaseID) {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	if !le.isPrimary() {
+		return
+	}
+	l := le.leaseMap[id]
+	if l == nil || !l.keepaliveOnWrite || l.expired() {
+		return
+	}
+	if le.cp != nil && l.remainingTTL > 0 {
+		// the checkpoint is applied after the write, which must not wait for it.
+		go le.cp(context.Background(), &pb.LeaseCheckpointRequest{Checkpoints: []*pb.LeaseCheckpoint{{ID: int64(l.ID), Remaining_TTL: 0}}})
+	}
+	l.refresh(0)
+	item := &LeaseWithTime{id: l.ID, time: l.expiry}
+	le.leaseExpiredNotifier.RegisterOrUpdate(item)
+
+	leaseRenewed.Inc()
+}
+
 func (le *lessor) Lookup(id LeaseID) *Lease {
 	le.mu.RLock()
 	defer le.mu.RUnlock()
@@ -463,6 +542,27 @@ func (le *lessor) Leases() []*Lease {
 	return ls
 }
 
+func (le *lessor) TopLeases(limit int) []*Lease {
+	le.mu.RLock()
+	ls := le.unsafeLeases()
+	le.mu.RUnlock()
+	// count the items once, as they can be attached while sorting
+	items := make(map[LeaseID]int, len(ls))
+	for _, l := range ls {
+		items[l.ID] = l.NumItems()
+	}
+	sort.Slice(ls, func(i, j int) bool {
+		if items[ls[i].ID] != items[ls[j].ID] {
+			return items[ls[i].ID] > items[ls[j].ID]
+		}
+		return ls[i].ID < ls[j].ID
+	})
+	if limit > 0 && len(ls) > limit {
+		ls = ls[:limit]
+	}
+	return ls
+}
+
 func (le *lessor) Promote(extend time.Duration) {
 	le.mu.Lock()
 	defer le.mu.Unlock()
@@ -606,9 +706,14 @@ func (le *lessor) Stop() {
 func (le *lessor) runLoop() {
 	defer close(le.doneC)
 
+	var histogramsUpdated time.Time
 	for {
 		le.revokeExpiredLeases()
 		le.checkpointScheduledLeases()
+		if time.Since(histogramsUpdated) >= leaseHistogramInterval {
+			le.updateLeaseHistograms()
+			histogramsUpdated = time.Now()
+		}
 
 		select {
 		case <-time.After(500 * time.Millisecond):
@@ -618,6 +723,26 @@ func (le *lessor) runLoop() {
 	}
 }
 
+// updateLeaseHistograms recomputes the histograms of the number of items
+// attached to the leases and, on the primary lessor, of their remaining TTLs.
+func (le *lessor) updateLeaseHistograms() {
+	le.mu.RLock()
+	ls := le.unsafeLeases()
+	primary := le.isPrimary()
+	le.mu.RUnlock()
+
+	items := make([]float64, len(ls))
+	var ttls []float64
+	for i, l := range ls {
+		items[i] = float64(l.NumItems())
+		if primary {
+			ttls = append(ttls, l.Remaining().Seconds())
+		}
+	}
+	leaseKeys.set(items)
+	leaseRemainingTTLs.set(ttls)
+}
+
 // revokeExpiredLeases finds all leases past their expiry and sends them to expired channel for
 // to be revoked.
 func (le *lessor) revokeExpiredLeases() {
@@ -736,16 +861,20 @@ func (le *lessor) scheduleCheckpointIfNeeded(lease *Lease) {
 		return
 	}
 
-	if lease.getRemainingTTL() > int64(le.checkpointInterval.Seconds()) {
+	interval := le.checkpointInterval
+	if lease.checkpointInterval > 0 {
+		interval = time.Duration(lease.checkpointInterval) * time.Second
+	}
+	if lease.getRemainingTTL() > int64(interval.Seconds()) {
 		if le.lg != nil {
 			le.lg.Debug("Scheduling lease checkpoint",
 				zap.Int64("leaseID", int64(lease.ID)),
-				zap.Duration("intervalSeconds", le.checkpointInterval),
+				zap.Duration("intervalSeconds", interval),
 			)
 		}
 		heap.Push(&le.leaseCheckpointHeap, &LeaseWithTime{
 			id:   lease.ID,
-			time: time.Now().Add(le.checkpointInterval),
+			time: time.Now().Add(interval),
 		})
 	}
 }
@@ -803,10 +932,12 @@ func (le *lessor) initAndRecover() {
 			ttl: lpb.TTL,
 			// itemSet will be filled in when recover key-value pairs
 			// set expiry to forever, refresh when promoted
-			itemSet:      make(map[LeaseItem]struct{}),
-			expiry:       forever,
-			revokec:      make(chan struct{}),
-			remainingTTL: lpb.RemainingTTL,
+			itemSet:            make(map[LeaseItem]struct{}),
+			expiry:             forever,
+			revokec:            make(chan struct{}),
+			remainingTTL:       lpb.RemainingTTL,
+			keepaliveOnWrite:   lpb.KeepaliveOnWrite,
+			checkpointInterval: lpb.CheckpointInterval,
 		}
 	}
 	le.leaseExpiredNotifier.Init()
@@ -825,6 +956,12 @@ func (fl *FakeLessor) SetCheckpointer(cp Checkpointer) {}
 
 func (fl *FakeLessor) Grant(id LeaseID, ttl int64) (*Lease, error) { return nil, nil }
 
+func (fl *FakeLessor) GrantKeepaliveOnWrite(id LeaseID, ttl int64) (*Lease, error) { return nil, nil }
+
+func (fl *FakeLessor) GrantWithOptions(id LeaseID, ttl int64, opts GrantOptions) (*Lease, error) {
+	return nil, nil
+}
+
 func (fl *FakeLessor) Revoke(id LeaseID) error { return nil }
 
 func (fl *FakeLessor) Checkpoint(id LeaseID, remainingTTL int64) error { return nil }
@@ -840,10 +977,14 @@ func (fl *FakeLessor) Demote() {}
 
 func (fl *FakeLessor) Renew(id LeaseID) (int64, error) { return 10, nil }
 
+func (fl *FakeLessor) RenewOnWrite(id LeaseID) {}
+
 func (fl *FakeLessor) Lookup(id LeaseID) *Lease { return nil }
 
 func (fl *FakeLessor) Leases() []*Lease { return nil }
 
+func (fl *FakeLessor) TopLeases(limit int) []*Lease { return nil }
+
 func (fl *FakeLessor) ExpiredLeasesC() <-chan []*Lease { return nil }
 
 func (fl *FakeLessor) Recover(b backend.Backend, rd RangeDeleter) {}
@@ -99,7 +99,7 @@ func TestLessorGrant(t *testing.T) {
 	defer tx.Unlock()
 	lpb := schema.MustUnsafeGetLease(tx, int64(l.ID))
 	if lpb == nil {
-		t.Errorf("lpb = %d, want not nil", lpb)
+		t.Errorf("lpb = %v, want not nil", lpb)
 	}
 }
 
@@ -203,7 +203,44 @@ func TestLessorRevoke(t *testing.T) {
 	defer tx.Unlock()
 	lpb := schema.MustUnsafeGetLease(tx, int64(l.ID))
 	if lpb != nil {
-		t.Errorf("lpb = %d, want nil", lpb)
+		t.Errorf("lpb = %v, want nil", lpb)
+	}
+}
+
+// TestLessorRevokeHooks ensures revoke hooks are invoked with the deleted keys.
+func TestLessorRevokeHooks(t *testing.T) {
+	lg := zap.NewNop()
+	dir, be := NewTestBackend(t)
+	defer os.RemoveAll(dir)
+	defer be.Close()
+
+	var (
+		hookID   LeaseID
+		hookKeys []string
+	)
+	hook := func(id LeaseID, keys []string) {
+		hookID, hookKeys = id, keys
+	}
+	le := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL, RevokeHooks: []RevokeHook{hook}})
+	defer le.Stop()
+	le.SetRangeDeleter(func() TxnDelete { return newFakeDeleter(be) })
+
+	l, err := le.Grant(1, 100)
+	if err != nil {
+		t.Fatalf("could not grant lease for 100s ttl (%v)", err)
+	}
+	if err = le.Attach(l.ID, []LeaseItem{{"foo"}, {"bar"}}); err != nil {
+		t.Fatalf("failed to attach items to the lease: %v", err)
+	}
+	if err = le.Revoke(l.ID); err != nil {
+		t.Fatal("failed to revoke lease:", err)
+	}
+
+	if hookID != l.ID {
+		t.Errorf("hook lease ID = %x, want %x", hookID, l.ID)
+	}
+	if wkeys := []string{"bar", "foo"}; !reflect.DeepEqual(hookKeys, wkeys) {
+		t.Errorf("hook keys = %v, want %v", hookKeys, wkeys)
 	}
 }
 
@@ -339,6 +376,48 @@ func TestLessorRenewExtendPileup(t *testing.T) {
 	}
 }
 
+func TestLessorRenewOnWrite(t *testing.T) {
+	lg := zap.NewNop()
+	dir, be := NewTestBackend(t)
+	defer be.Close()
+	defer os.RemoveAll(dir)
+
+	le := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL})
+	defer le.Stop()
+	le.Promote(0)
+
+	l1, err1 := le.Grant(1, 10)
+	l2, err2 := le.GrantKeepaliveOnWrite(2, 10)
+	if err1 != nil || err2 != nil {
+		t.Fatalf("could not grant leases (%v, %v)", err1, err2)
+	}
+	if l1.KeepaliveOnWrite() || !l2.KeepaliveOnWrite() {
+		t.Fatalf("KeepaliveOnWrite = (%v, %v), want (false, true)", l1.KeepaliveOnWrite(), l2.KeepaliveOnWrite())
+	}
+
+	// manually bring the expiry of the leases closer
+	for _, l := range []*Lease{l1, l2} {
+		l.expiryMu.Lock()
+		l.expiry = time.Now().Add(time.Second)
+		l.expiryMu.Unlock()
+	}
+	le.RenewOnWrite(l1.ID)
+	le.RenewOnWrite(l2.ID)
+	if l1.Remaining() > time.Second {
+		t.Errorf("renewed the lease not granted to be kept alive on write")
+	}
+	if l2.Remaining() < 9*time.Second {
+		t.Errorf("failed to renew the lease on write")
+	}
+
+	// the option survives restarts
+	nle := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL})
+	defer nle.Stop()
+	if nl2 := nle.Lookup(l2.ID); nl2 == nil || !nl2.KeepaliveOnWrite() {
+		t.Errorf("nl2 = %v, want a lease kept alive on write", nl2)
+	}
+}
+
 func TestLessorDetach(t *testing.T) {
 	lg := zap.NewNop()
 	dir, be := NewTestBackend(t)
@@ -562,6 +641,49 @@ func TestLessorCheckpointScheduling(t *testing.T) {
 	}
 }
 
+func TestLessorCheckpointIntervalPerLease(t *testing.T) {
+	lg := zap.NewNop()
+
+	dir, be := NewTestBackend(t)
+	defer os.RemoveAll(dir)
+	defer be.Close()
+
+	le := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL, CheckpointInterval: time.Hour})
+	le.minLeaseTTL = 1
+	checkpointedC := make(chan struct{}, 1)
+	le.SetCheckpointer(func(ctx context.Context, lc *pb.LeaseCheckpointRequest) {
+		select {
+		case checkpointedC <- struct{}{}:
+		default:
+		}
+		if len(lc.Checkpoints) != 1 || lc.Checkpoints[0].ID != 1 {
+			t.Errorf("expected checkpoint of lease 1 but got %v", lc.Checkpoints)
+		}
+	})
+	if _, err := le.GrantWithOptions(1, 2, GrantOptions{CheckpointInterval: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// the lease of the lessor interval is not checkpointed before an hour
+	if _, err := le.Grant(2, 2); err != nil {
+		t.Fatal(err)
+	}
+	le.Promote(0)
+
+	select {
+	case <-checkpointedC:
+	case <-time.After(2 * time.Second):
+		le.Stop()
+		t.Fatal("expected checkpointer to be called, but it was not")
+	}
+
+	le.Stop()
+	le2 := newLessor(lg, be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL})
+	defer le2.Stop()
+	if l := le2.Lookup(1); l == nil || l.checkpointInterval != 1 {
+		t.Fatalf("expected the checkpoint interval of the lease to be recovered, got %+v", l)
+	}
+}
+
 func TestLessorCheckpointsRestoredOnPromote(t *testing.T) {
 	lg := zap.NewNop()
 	dir, be := NewTestBackend(t)
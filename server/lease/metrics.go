@@ -15,6 +15,8 @@
 package lease
 
 import (
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -49,11 +51,83 @@ var (
 			// 1 second -> 3 months
 			Buckets: prometheus.ExponentialBuckets(1, 2, 24),
 		})
+
+	leaseRemainingTTLs = newLeaseHistogram(
+		prometheus.NewDesc(
+			"etcd_debugging_lease_remaining_ttl_seconds",
+			"Histogram of the remaining TTLs of the current leases, reported by the primary lessor.",
+			nil, nil,
+		),
+		// 1 second -> 3 months
+		prometheus.ExponentialBuckets(1, 2, 24),
+	)
+
+	leaseKeys = newLeaseHistogram(
+		prometheus.NewDesc(
+			"etcd_debugging_lease_keys",
+			"Histogram of the number of keys attached to the current leases.",
+			nil, nil,
+		),
+		// 1 -> 1 million keys
+		prometheus.ExponentialBuckets(1, 4, 11),
+	)
 )
 
+// leaseHistogram is a histogram of the current leases. Unlike a
+// prometheus.Histogram accumulating observations, its observations are
+// replaced as the lessor periodically recomputes it.
+type leaseHistogram struct {
+	desc    *prometheus.Desc
+	buckets []float64
+
+	mu     sync.Mutex
+	count  uint64
+	sum    float64
+	counts map[float64]uint64
+}
+
+func newLeaseHistogram(desc *prometheus.Desc, buckets []float64) *leaseHistogram {
+	h := &leaseHistogram{desc: desc, buckets: buckets}
+	h.set(nil)
+	return h
+}
+
+// set replaces the observations of the histogram.
+func (h *leaseHistogram) set(values []float64) {
+	counts := make(map[float64]uint64, len(h.buckets))
+	var sum float64
+	for _, b := range h.buckets {
+		counts[b] = 0
+	}
+	for _, v := range values {
+		sum += v
+		for _, b := range h.buckets {
+			if v <= b {
+				counts[b]++
+			}
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count, h.sum, h.counts = uint64(len(values)), sum, counts
+}
+
+func (h *leaseHistogram) Describe(ch chan<- *prometheus.Desc) {
+	ch <- h.desc
+}
+
+func (h *leaseHistogram) Collect(ch chan<- prometheus.Metric) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch <- prometheus.MustNewConstHistogram(h.desc, h.count, h.sum, h.counts)
+}
+
 func init() {
 	prometheus.MustRegister(leaseGranted)
 	prometheus.MustRegister(leaseRevoked)
 	prometheus.MustRegister(leaseRenewed)
 	prometheus.MustRegister(leaseTotalTTLs)
+	prometheus.MustRegister(leaseRemainingTTLs)
+	prometheus.MustRegister(leaseKeys)
 }
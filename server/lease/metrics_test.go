@@ -0,0 +1,84 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lease
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestLessorTopLeases(t *testing.T) {
+	dir, be := NewTestBackend(t)
+	defer os.RemoveAll(dir)
+	defer be.Close()
+
+	le := newLessor(zaptest.NewLogger(t), be, clusterLatest(), LessorConfig{MinLeaseTTL: minLeaseTTL})
+	defer le.Stop()
+
+	for id, keys := range map[LeaseID][]LeaseItem{
+		1: nil,
+		2: {{"a"}},
+		3: {{"b"}, {"c"}, {"d"}, {"e"}, {"f"}},
+		4: {{"g"}, {"h"}},
+	} {
+		_, err := le.Grant(id, 100)
+		require.NoError(t, err)
+		require.NoError(t, le.Attach(id, keys))
+	}
+
+	var ids []LeaseID
+	for _, l := range le.TopLeases(3) {
+		ids = append(ids, l.ID)
+	}
+	require.Equal(t, []LeaseID{3, 4, 2}, ids)
+	require.Len(t, le.TopLeases(0), 4)
+
+	le.updateLeaseHistograms()
+	require.NoError(t, testutil.CollectAndCompare(leaseKeys, strings.NewReader(`
+# HELP etcd_debugging_lease_keys Histogram of the number of keys attached to the current leases.
+# TYPE etcd_debugging_lease_keys histogram
+etcd_debugging_lease_keys_bucket{le="1"} 2
+etcd_debugging_lease_keys_bucket{le="4"} 3
+etcd_debugging_lease_keys_bucket{le="16"} 4
+etcd_debugging_lease_keys_bucket{le="64"} 4
+etcd_debugging_lease_keys_bucket{le="256"} 4
+etcd_debugging_lease_keys_bucket{le="1024"} 4
+etcd_debugging_lease_keys_bucket{le="4096"} 4
+etcd_debugging_lease_keys_bucket{le="16384"} 4
+etcd_debugging_lease_keys_bucket{le="65536"} 4
+etcd_debugging_lease_keys_bucket{le="262144"} 4
+etcd_debugging_lease_keys_bucket{le="1.048576e+06"} 4
+etcd_debugging_lease_keys_bucket{le="+Inf"} 4
+etcd_debugging_lease_keys_sum 8
+etcd_debugging_lease_keys_count 4
+`)))
+	// the remaining TTLs are only known to the primary lessor
+	leaseRemainingTTLs.mu.Lock()
+	require.Equal(t, uint64(0), leaseRemainingTTLs.count)
+	leaseRemainingTTLs.mu.Unlock()
+
+	le.Promote(0)
+	le.updateLeaseHistograms()
+	leaseRemainingTTLs.mu.Lock()
+	require.Equal(t, uint64(4), leaseRemainingTTLs.count)
+	require.Equal(t, uint64(4), leaseRemainingTTLs.counts[128])
+	require.Equal(t, uint64(0), leaseRemainingTTLs.counts[64])
+	leaseRemainingTTLs.mu.Unlock()
+}
@@ -95,3 +95,7 @@ func (s *as2ac) UserRevokeRole(ctx context.Context, in *pb.AuthUserRevokeRoleReq
 func (s *as2ac) UserChangePassword(ctx context.Context, in *pb.AuthUserChangePasswordRequest, opts ...grpc.CallOption) (*pb.AuthUserChangePasswordResponse, error) {
 	return s.as.UserChangePassword(ctx, in)
 }
+
+func (s *as2ac) CheckPermissions(ctx context.Context, in *pb.AuthCheckPermissionsRequest, opts ...grpc.CallOption) (*pb.AuthCheckPermissionsResponse, error) {
+	return s.as.CheckPermissions(ctx, in)
+}
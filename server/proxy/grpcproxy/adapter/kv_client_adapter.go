@@ -16,6 +16,7 @@ package adapter
 
 import (
 	"context"
+	"io"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 
@@ -44,6 +45,64 @@ func (s *kvs2kvc) Txn(ctx context.Context, in *pb.TxnRequest, opts ...grpc.CallO
 	return s.kvs.Txn(ctx, in)
 }
 
+func (s *kvs2kvc) RangeStream(ctx context.Context, in *pb.RangeRequest, opts ...grpc.CallOption) (pb.KV_RangeStreamClient, error) {
+	cs := newPipeStream(ctx, func(ss chanServerStream) error {
+		if err := s.kvs.RangeStream(in, &rs2rcServerStream{ss}); err != nil {
+			return err
+		}
+		// end the stream of the client once all the chunks are received
+		return io.EOF
+	})
+	return &rs2rcClientStream{cs}, nil
+}
+
+// rs2rcClientStream implements KV_RangeStreamClient
+type rs2rcClientStream struct{ chanClientStream }
+
+// rs2rcServerStream implements KV_RangeStreamServer
+type rs2rcServerStream struct{ chanServerStream }
+
+func (s *rs2rcClientStream) Recv() (*pb.RangeStreamResponse, error) {
+	var v interface{}
+	if err := s.RecvMsg(&v); err != nil {
+		return nil, err
+	}
+	return v.(*pb.RangeStreamResponse), nil
+}
+
+func (s *rs2rcServerStream) Send(rr *pb.RangeStreamResponse) error {
+	return s.SendMsg(rr)
+}
+
+func (s *kvs2kvc) TxnStream(ctx context.Context, in *pb.TxnRequest, opts ...grpc.CallOption) (pb.KV_TxnStreamClient, error) {
+	cs := newPipeStream(ctx, func(ss chanServerStream) error {
+		if err := s.kvs.TxnStream(in, &ts2tcServerStream{ss}); err != nil {
+			return err
+		}
+		// end the stream of the client once all the chunks are received
+		return io.EOF
+	})
+	return &ts2tcClientStream{cs}, nil
+}
+
+// ts2tcClientStream implements KV_TxnStreamClient
+type ts2tcClientStream struct{ chanClientStream }
+
+// ts2tcServerStream implements KV_TxnStreamServer
+type ts2tcServerStream struct{ chanServerStream }
+
+func (s *ts2tcClientStream) Recv() (*pb.TxnStreamResponse, error) {
+	var v interface{}
+	if err := s.RecvMsg(&v); err != nil {
+		return nil, err
+	}
+	return v.(*pb.TxnStreamResponse), nil
+}
+
+func (s *ts2tcServerStream) Send(rr *pb.TxnStreamResponse) error {
+	return s.SendMsg(rr)
+}
+
 func (s *kvs2kvc) Compact(ctx context.Context, in *pb.CompactionRequest, opts ...grpc.CallOption) (*pb.CompactionResponse, error) {
 	return s.kvs.Compact(ctx, in)
 }
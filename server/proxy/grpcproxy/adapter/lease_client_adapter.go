@@ -53,6 +53,10 @@ func (c *ls2lc) LeaseLeases(ctx context.Context, in *pb.LeaseLeasesRequest, opts
 	return c.leaseServer.LeaseLeases(ctx, in)
 }
 
+func (c *ls2lc) LeaseTop(ctx context.Context, in *pb.LeaseTopRequest, opts ...grpc.CallOption) (*pb.LeaseTopResponse, error) {
+	return c.leaseServer.LeaseTop(ctx, in)
+}
+
 // ls2lcClientStream implements Lease_LeaseKeepAliveClient
 type ls2lcClientStream struct{ chanClientStream }
 
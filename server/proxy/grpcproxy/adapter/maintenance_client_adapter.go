@@ -56,6 +56,30 @@ func (s *mts2mtc) Downgrade(ctx context.Context, r *pb.DowngradeRequest, opts ..
 	return s.mts.Downgrade(ctx, r)
 }
 
+func (s *mts2mtc) Purge(ctx context.Context, r *pb.PurgeRequest, opts ...grpc.CallOption) (*pb.PurgeResponse, error) {
+	return s.mts.Purge(ctx, r)
+}
+
+func (s *mts2mtc) SnapshotSettings(ctx context.Context, r *pb.SnapshotSettingsRequest, opts ...grpc.CallOption) (*pb.SnapshotSettingsResponse, error) {
+	return s.mts.SnapshotSettings(ctx, r)
+}
+
+func (s *mts2mtc) PinRevision(ctx context.Context, r *pb.PinRevisionRequest, opts ...grpc.CallOption) (*pb.PinRevisionResponse, error) {
+	return s.mts.PinRevision(ctx, r)
+}
+
+func (s *mts2mtc) UnpinRevision(ctx context.Context, r *pb.UnpinRevisionRequest, opts ...grpc.CallOption) (*pb.UnpinRevisionResponse, error) {
+	return s.mts.UnpinRevision(ctx, r)
+}
+
+func (s *mts2mtc) RateLimits(ctx context.Context, r *pb.RateLimitsRequest, opts ...grpc.CallOption) (*pb.RateLimitsResponse, error) {
+	return s.mts.RateLimits(ctx, r)
+}
+
+func (s *mts2mtc) EffectiveConfig(ctx context.Context, r *pb.EffectiveConfigRequest, opts ...grpc.CallOption) (*pb.EffectiveConfigResponse, error) {
+	return s.mts.EffectiveConfig(ctx, r)
+}
+
 func (s *mts2mtc) Snapshot(ctx context.Context, in *pb.SnapshotRequest, opts ...grpc.CallOption) (pb.Maintenance_SnapshotClient, error) {
 	cs := newPipeStream(ctx, func(ss chanServerStream) error {
 		return s.mts.Snapshot(in, &ss2scServerStream{ss})
@@ -90,3 +114,38 @@ func (s *ss2scServerStream) Recv() (*pb.SnapshotRequest, error) {
 	}
 	return v.(*pb.SnapshotRequest), nil
 }
+
+func (s *mts2mtc) HashKVStream(ctx context.Context, in *pb.HashKVStreamRequest, opts ...grpc.CallOption) (pb.Maintenance_HashKVStreamClient, error) {
+	cs := newPipeStream(ctx, func(ss chanServerStream) error {
+		return s.mts.HashKVStream(in, &hkss2hkscServerStream{ss})
+	})
+	return &hkss2hkscClientStream{cs}, nil
+}
+
+// hkss2hkscClientStream implements Maintenance_HashKVStreamClient
+type hkss2hkscClientStream struct{ chanClientStream }
+
+// hkss2hkscServerStream implements Maintenance_HashKVStreamServer
+type hkss2hkscServerStream struct{ chanServerStream }
+
+func (s *hkss2hkscClientStream) Send(rr *pb.HashKVStreamRequest) error {
+	return s.SendMsg(rr)
+}
+func (s *hkss2hkscClientStream) Recv() (*pb.HashKVStreamResponse, error) {
+	var v interface{}
+	if err := s.RecvMsg(&v); err != nil {
+		return nil, err
+	}
+	return v.(*pb.HashKVStreamResponse), nil
+}
+
+func (s *hkss2hkscServerStream) Send(rr *pb.HashKVStreamResponse) error {
+	return s.SendMsg(rr)
+}
+func (s *hkss2hkscServerStream) Recv() (*pb.HashKVStreamRequest, error) {
+	var v interface{}
+	if err := s.RecvMsg(&v); err != nil {
+		return nil, err
+	}
+	return v.(*pb.HashKVStreamRequest), nil
+}
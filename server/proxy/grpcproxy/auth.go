@@ -96,3 +96,7 @@ func (ap *AuthProxy) UserRevokeRole(ctx context.Context, r *pb.AuthUserRevokeRol
 func (ap *AuthProxy) UserChangePassword(ctx context.Context, r *pb.AuthUserChangePasswordRequest) (*pb.AuthUserChangePasswordResponse, error) {
 	return ap.authClient.UserChangePassword(ctx, r)
 }
+
+func (ap *AuthProxy) CheckPermissions(ctx context.Context, r *pb.AuthCheckPermissionsRequest) (*pb.AuthCheckPermissionsResponse, error) {
+	return ap.authClient.CheckPermissions(ctx, r)
+}
@@ -16,12 +16,18 @@ package grpcproxy
 
 import (
 	"context"
+	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3rpc"
 	"go.etcd.io/etcd/server/v3/proxy/grpcproxy/cache"
 )
 
+// maxStreamChunkBytes bounds the size of the chunks of streamed responses
+// like etcd servers do with the default --max-request-bytes.
+const maxStreamChunkBytes = 2 * 1024 * 1024
+
 type kvProxy struct {
 	kv    clientv3.KV
 	cache cache.Cache
@@ -106,10 +112,18 @@ func (p *kvProxy) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, e
 		return nil, err
 	}
 	resp := opResp.Txn()
+	p.cacheTxn(r, resp)
+	return (*pb.TxnResponse)(resp), nil
+}
 
+// cacheTxn updates the cache with the response of a txn.
+func (p *kvProxy) cacheTxn(r *pb.TxnRequest, resp *clientv3.TxnResponse) {
 	// txn may claim an outdated key is updated; be safe and invalidate
 	for _, cmp := range r.Compare {
 		p.cache.Invalidate(cmp.Key, cmp.RangeEnd)
+		for _, iv := range cmp.Intervals {
+			p.cache.Invalidate(iv.Key, iv.RangeEnd)
+		}
 	}
 	// update any fetched keys
 	if resp.Succeeded {
@@ -119,8 +133,70 @@ func (p *kvProxy) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, e
 	}
 
 	cacheKeys.Set(float64(p.cache.Size()))
+}
 
-	return (*pb.TxnResponse)(resp), nil
+func (p *kvProxy) RangeStream(r *pb.RangeRequest, stream pb.KV_RangeStreamServer) error {
+	ctx := withClientAuthToken(stream.Context(), stream.Context())
+
+	// get through the KV of the client, which may be namespaced, and chunk
+	// the keys again for the client of the proxy
+	it, err := p.kv.GetStream(ctx, string(r.Key), rangeRequestOpts(r)...)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	chunk := &pb.RangeStreamResponse{Header: it.Header(), Count: it.Count()}
+	size := 0
+	for it.Next() {
+		kvSize := it.KV().Size()
+		if len(chunk.Kvs) > 0 && size+kvSize >= maxStreamChunkBytes {
+			if err = stream.Send(chunk); err != nil {
+				return err
+			}
+			chunk = &pb.RangeStreamResponse{Header: it.Header(), Count: it.Count()}
+			size = 0
+		}
+		chunk.Kvs = append(chunk.Kvs, it.KV())
+		size += kvSize
+	}
+	if err = it.Err(); err != nil {
+		return err
+	}
+	chunk.More = it.More()
+	return stream.Send(chunk)
+}
+
+func (p *kvProxy) TxnStream(r *pb.TxnRequest, stream pb.KV_TxnStreamServer) error {
+	ctx := withClientAuthToken(stream.Context(), stream.Context())
+
+	// commit through the KV of the client, which may be namespaced, and split
+	// the response again for the client of the proxy
+	op := TxnRequestToOp(r)
+	cmps, thenOps, elseOps := op.Txn()
+	txn := p.kv.Txn(ctx).If(cmps...).Then(thenOps...).Else(elseOps...)
+	var resp *clientv3.TxnResponse
+	var err error
+	if cs, ok := txn.(clientv3.CommitStreamer); ok && !r.ReadYourWrites {
+		resp, err = cs.CommitStream()
+	} else {
+		// read-your-writes transactions are only committed through Do,
+		// receiving the response in one message
+		var opResp clientv3.OpResponse
+		opResp, err = p.kv.Do(ctx, op)
+		resp = opResp.Txn()
+	}
+	if err != nil {
+		return err
+	}
+	p.cacheTxn(r, resp)
+
+	for _, chunk := range v3rpc.SplitTxnResponse((*pb.TxnResponse)(resp), maxStreamChunkBytes) {
+		if err = stream.Send(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (p *kvProxy) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error) {
@@ -162,6 +238,10 @@ func requestOpToOp(union *pb.RequestOp) clientv3.Op {
 }
 
 func RangeRequestToOp(r *pb.RangeRequest) clientv3.Op {
+	return clientv3.OpGet(string(r.Key), rangeRequestOpts(r)...)
+}
+
+func rangeRequestOpts(r *pb.RangeRequest) []clientv3.OpOption {
 	var opts []clientv3.OpOption
 	if len(r.RangeEnd) != 0 {
 		opts = append(opts, clientv3.WithRange(string(r.RangeEnd)))
@@ -182,11 +262,22 @@ func RangeRequestToOp(r *pb.RangeRequest) clientv3.Op {
 	if r.KeysOnly {
 		opts = append(opts, clientv3.WithKeysOnly())
 	}
+	for _, f := range r.ExcludeFields {
+		opts = append(opts, clientv3.WithExcludeFields(clientv3.ExcludeField(f)))
+	}
+	if r.MaxValueSize > 0 {
+		opts = append(opts, clientv3.WithMaxValueSize(r.MaxValueSize))
+	}
 	if r.Serializable {
 		opts = append(opts, clientv3.WithSerializable())
 	}
-
-	return clientv3.OpGet(string(r.Key), opts...)
+	if r.FallbackMaxStalenessMs > 0 {
+		opts = append(opts, clientv3.WithSerializableFallback(
+			time.Duration(r.FallbackMaxStalenessMs)*time.Millisecond,
+			time.Duration(r.FallbackTimeoutMs)*time.Millisecond,
+		))
+	}
+	return opts
 }
 
 func PutRequestToOp(r *pb.PutRequest) clientv3.Op {
@@ -198,6 +289,15 @@ func PutRequestToOp(r *pb.PutRequest) clientv3.Op {
 	if r.IgnoreLease {
 		opts = append(opts, clientv3.WithIgnoreLease())
 	}
+	if len(r.Metadata) != 0 {
+		opts = append(opts, clientv3.WithMetadata(r.Metadata))
+	}
+	if r.IgnoreMetadata {
+		opts = append(opts, clientv3.WithIgnoreMetadata())
+	}
+	if r.Ttl != 0 {
+		opts = append(opts, clientv3.WithTTL(r.Ttl))
+	}
 	if r.PrevKv {
 		opts = append(opts, clientv3.WithPrevKV())
 	}
@@ -228,5 +328,9 @@ func TxnRequestToOp(r *pb.TxnRequest) clientv3.Op {
 	for i := range r.Failure {
 		elseops[i] = requestOpToOp(r.Failure[i])
 	}
-	return clientv3.OpTxn(cmps, thenops, elseops)
+	var opts []clientv3.OpOption
+	if r.ReadYourWrites {
+		opts = append(opts, clientv3.WithReadYourWrites())
+	}
+	return clientv3.OpTxn(cmps, thenops, elseops, opts...)
 }
@@ -17,6 +17,7 @@ package grpcproxy
 import (
 	"context"
 	"io"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -130,6 +131,10 @@ func (lp *leaseProxy) LeaseLeases(ctx context.Context, rr *pb.LeaseLeasesRequest
 	return rp, err
 }
 
+func (lp *leaseProxy) LeaseTop(ctx context.Context, rr *pb.LeaseTopRequest) (*pb.LeaseTopResponse, error) {
+	return lp.leaseClient.LeaseTop(ctx, rr)
+}
+
 func (lp *leaseProxy) LeaseKeepAlive(stream pb.Lease_LeaseKeepAliveServer) error {
 	lp.mu.Lock()
 	select {
@@ -145,7 +150,8 @@ func (lp *leaseProxy) LeaseKeepAlive(stream pb.Lease_LeaseKeepAliveServer) error
 	lps := leaseProxyStream{
 		stream:          stream,
 		lessor:          lp.lessor,
-		keepAliveLeases: make(map[int64]*atomicCounter),
+		ttlFloor:        leaseTTLFloor(stream.Context()),
+		keepAliveLeases: make(map[int64]*keepAliveLease),
 		respc:           make(chan *pb.LeaseKeepAliveResponse),
 		ctx:             ctx,
 		cancel:          cancel,
@@ -225,16 +231,41 @@ func (lp *leaseProxy) LeaseKeepAlive(stream pb.Lease_LeaseKeepAliveServer) error
 	}
 }
 
+// leaseTTLFloor returns the TTL floor the client states in the metadata of
+// the stream, or 0 if it states none.
+func leaseTTLFloor(ctx context.Context) int64 {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		md, ok = metadata.FromOutgoingContext(ctx)
+	}
+	if !ok {
+		return 0
+	}
+	v := md[rpctypes.MetadataLeaseTTLFloorKey]
+	if len(v) == 0 {
+		return 0
+	}
+	ttl, err := strconv.ParseInt(v[0], 10, 64)
+	if err != nil || ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
 type leaseProxyStream struct {
 	stream pb.Lease_LeaseKeepAliveServer
 
 	lessor clientv3.Lease
+	// ttlFloor is the minimum TTL, in seconds, the client requires its leases
+	// to have left once their keepalives are answered. If zero, the client
+	// requires half the TTL of the lease.
+	ttlFloor int64
 	// wg tracks keepAliveLoop goroutines
 	wg sync.WaitGroup
 	// mu protects keepAliveLeases
 	mu sync.RWMutex
-	// keepAliveLeases tracks how many outstanding keepalive requests which need responses are on a lease.
-	keepAliveLeases map[int64]*atomicCounter
+	// keepAliveLeases tracks the outstanding keepalive requests which need responses on a lease.
+	keepAliveLeases map[int64]*keepAliveLease
 	// respc receives lease keepalive responses from etcd backend
 	respc chan *pb.LeaseKeepAliveResponse
 
@@ -252,34 +283,77 @@ func (lps *leaseProxyStream) recvLoop() error {
 			return err
 		}
 		lps.mu.Lock()
-		neededResps, ok := lps.keepAliveLeases[rr.ID]
+		kl, ok := lps.keepAliveLeases[rr.ID]
 		if !ok {
-			neededResps = &atomicCounter{}
-			lps.keepAliveLeases[rr.ID] = neededResps
+			kl = &keepAliveLease{reqc: make(chan struct{}, 1)}
+			lps.keepAliveLeases[rr.ID] = kl
 			lps.wg.Add(1)
 			go func() {
 				defer lps.wg.Done()
-				if err := lps.keepAliveLoop(rr.ID, neededResps); err != nil {
+				if err := lps.keepAliveLoop(rr.ID, kl); err != nil {
 					lps.cancel()
 				}
 			}()
 		}
-		neededResps.add(1)
+		kl.neededResps.add(1)
 		lps.mu.Unlock()
+		select {
+		case kl.reqc <- struct{}{}:
+		default:
+		}
 	}
 }
 
-func (lps *leaseProxyStream) keepAliveLoop(leaseID int64, neededResps *atomicCounter) error {
+// keepAliveLease tracks the keepalive requests of the client on a lease.
+type keepAliveLease struct {
+	neededResps atomicCounter
+	// reqc is notified of new keepalive requests.
+	reqc chan struct{}
+}
+
+// keepAliveLoop answers the keepalive requests of the client on a lease.
+// The lease is kept alive upstream by the keepalive the lessor shares
+// between all the clients of the lease, and requests are answered on its
+// responses. If the lease has less TTL left than the TTL floor of the
+// client, it is refreshed upstream right away rather than on the next
+// response of the shared keepalive.
+func (lps *leaseProxyStream) keepAliveLoop(leaseID int64, kl *keepAliveLease) error {
 	cctx, ccancel := context.WithCancel(lps.ctx)
 	defer ccancel()
 	respc, err := lps.lessor.KeepAlive(cctx, clientv3.LeaseID(leaseID))
 	if err != nil {
 		return err
 	}
+	neededResps := &kl.neededResps
+	// ttl is the TTL of the lease as of its latest response, at deadline
+	var (
+		ttl      int64
+		deadline time.Time
+	)
 	// ticker expires when loop hasn't received keepalive within TTL
 	var ticker <-chan time.Time
 	for {
 		select {
+		case <-kl.reqc:
+			if deadline.IsZero() || neededResps.get() == 0 || !lps.belowTTLFloor(deadline, ttl) {
+				continue
+			}
+			// give up once the lease expires, as the upstream may be gone
+			rctx, rcancel := context.WithDeadline(cctx, deadline)
+			rp, err := lps.lessor.KeepAliveOnce(rctx, clientv3.LeaseID(leaseID))
+			rcancel()
+			if err != nil {
+				// answered on the next response of the shared keepalive, if any
+				continue
+			}
+			ttl, deadline = rp.TTL, time.Now().Add(time.Duration(rp.TTL)*time.Second)
+			ticker = time.After(time.Duration(rp.TTL) * time.Second)
+			r := &pb.LeaseKeepAliveResponse{
+				Header: rp.ResponseHeader,
+				ID:     int64(rp.ID),
+				TTL:    rp.TTL,
+			}
+			lps.replyToClient(r, neededResps)
 		case <-ticker:
 			lps.mu.Lock()
 			// if there are outstanding keepAlive reqs at the moment of ticker firing,
@@ -302,7 +376,10 @@ func (lps *leaseProxyStream) keepAliveLoop(leaseID int64, neededResps *atomicCou
 				}
 				ttlResp, err := lps.lessor.TimeToLive(cctx, clientv3.LeaseID(leaseID))
 				if err != nil {
-					return err
+					// the shared keepalive broke along with the upstream; only
+					// split this lease off, as the client starts its keepalive
+					// over on its next request
+					return nil
 				}
 				r := &pb.LeaseKeepAliveResponse{
 					Header: ttlResp.ResponseHeader,
@@ -319,6 +396,7 @@ func (lps *leaseProxyStream) keepAliveLoop(leaseID int64, neededResps *atomicCou
 				}
 				return nil
 			}
+			ttl, deadline = rp.TTL, time.Now().Add(time.Duration(rp.TTL)*time.Second)
 			if neededResps.get() == 0 {
 				continue
 			}
@@ -333,6 +411,16 @@ func (lps *leaseProxyStream) keepAliveLoop(leaseID int64, neededResps *atomicCou
 	}
 }
 
+// belowTTLFloor returns true if the lease, which expires at deadline given
+// its ttl, has less TTL left than the TTL floor of the client.
+func (lps *leaseProxyStream) belowTTLFloor(deadline time.Time, ttl int64) bool {
+	floor := lps.ttlFloor
+	if floor == 0 {
+		floor = ttl / 2
+	}
+	return time.Until(deadline) < time.Duration(floor)*time.Second
+}
+
 func (lps *leaseProxyStream) replyToClient(r *pb.LeaseKeepAliveResponse, neededResps *atomicCounter) {
 	timer := time.After(500 * time.Millisecond)
 	for neededResps.get() > 0 {
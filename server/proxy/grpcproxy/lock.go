@@ -36,3 +36,7 @@ func (lp *lockProxy) Lock(ctx context.Context, req *v3lockpb.LockRequest) (*v3lo
 func (lp *lockProxy) Unlock(ctx context.Context, req *v3lockpb.UnlockRequest) (*v3lockpb.UnlockResponse, error) {
 	return lp.lockClient.Unlock(ctx, req)
 }
+
+func (lp *lockProxy) PutIfFencingTokenCurrent(ctx context.Context, req *v3lockpb.PutIfFencingTokenCurrentRequest) (*v3lockpb.PutIfFencingTokenCurrentResponse, error) {
+	return lp.lockClient.PutIfFencingTokenCurrent(ctx, req)
+}
@@ -62,6 +62,32 @@ func (mp *maintenanceProxy) Snapshot(sr *pb.SnapshotRequest, stream pb.Maintenan
 	}
 }
 
+func (mp *maintenanceProxy) HashKVStream(r *pb.HashKVStreamRequest, stream pb.Maintenance_HashKVStreamServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	ctx = withClientAuthToken(ctx, stream.Context())
+
+	sc, err := mp.maintenanceClient.HashKVStream(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	for {
+		rr, err := sc.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		err = stream.Send(rr)
+		if err != nil {
+			return err
+		}
+	}
+}
+
 func (mp *maintenanceProxy) Hash(ctx context.Context, r *pb.HashRequest) (*pb.HashResponse, error) {
 	return mp.maintenanceClient.Hash(ctx, r)
 }
@@ -85,3 +111,27 @@ func (mp *maintenanceProxy) MoveLeader(ctx context.Context, r *pb.MoveLeaderRequ
 func (mp *maintenanceProxy) Downgrade(ctx context.Context, r *pb.DowngradeRequest) (*pb.DowngradeResponse, error) {
 	return mp.maintenanceClient.Downgrade(ctx, r)
 }
+
+func (mp *maintenanceProxy) Purge(ctx context.Context, r *pb.PurgeRequest) (*pb.PurgeResponse, error) {
+	return mp.maintenanceClient.Purge(ctx, r)
+}
+
+func (mp *maintenanceProxy) SnapshotSettings(ctx context.Context, r *pb.SnapshotSettingsRequest) (*pb.SnapshotSettingsResponse, error) {
+	return mp.maintenanceClient.SnapshotSettings(ctx, r)
+}
+
+func (mp *maintenanceProxy) PinRevision(ctx context.Context, r *pb.PinRevisionRequest) (*pb.PinRevisionResponse, error) {
+	return mp.maintenanceClient.PinRevision(ctx, r)
+}
+
+func (mp *maintenanceProxy) UnpinRevision(ctx context.Context, r *pb.UnpinRevisionRequest) (*pb.UnpinRevisionResponse, error) {
+	return mp.maintenanceClient.UnpinRevision(ctx, r)
+}
+
+func (mp *maintenanceProxy) RateLimits(ctx context.Context, r *pb.RateLimitsRequest) (*pb.RateLimitsResponse, error) {
+	return mp.maintenanceClient.RateLimits(ctx, r)
+}
+
+func (mp *maintenanceProxy) EffectiveConfig(ctx context.Context, r *pb.EffectiveConfigRequest) (*pb.EffectiveConfigResponse, error) {
+	return mp.maintenanceClient.EffectiveConfig(ctx, r)
+}
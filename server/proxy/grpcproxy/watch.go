@@ -45,19 +45,34 @@ type watchProxy struct {
 	// kv is used for permission checking
 	kv clientv3.KV
 	lg *zap.Logger
+
+	// cursors, if set, persists the revisions of the watches to resume them
+	// from after a restart.
+	cursors *WatchCursors
 }
 
 func NewWatchProxy(ctx context.Context, lg *zap.Logger, c *clientv3.Client) (pb.WatchServer, <-chan struct{}) {
+	return NewWatchProxyWithCursors(ctx, lg, c, nil)
+}
+
+// NewWatchProxyWithCursors returns a watch proxy persisting the revisions of
+// its coalesced watches to cursors, and resuming them from the cursors of its
+// previous run.
+func NewWatchProxyWithCursors(ctx context.Context, lg *zap.Logger, c *clientv3.Client, cursors *WatchCursors) (pb.WatchServer, <-chan struct{}) {
 	cctx, cancel := context.WithCancel(ctx)
 	wp := &watchProxy{
 		cw:     c.Watcher,
 		ctx:    cctx,
 		leader: newLeader(cctx, c.Watcher),
 
-		kv: c.KV, // for permission checking
-		lg: lg,
+		kv:      c.KV, // for permission checking
+		lg:      lg,
+		cursors: cursors,
 	}
 	wp.ranges = newWatchRanges(wp)
+	if cursors != nil {
+		go cursors.run(cctx, wp.ranges)
+	}
 	ch := make(chan struct{})
 	go func() {
 		defer close(ch)
@@ -245,6 +260,18 @@ func (wps *watchProxyStream) recvLoop() error {
 				continue
 			}
 
+			valueFilters, err := v3rpc.ValueFiltersFromRequest(cr)
+			if err != nil {
+				wps.watchCh <- &pb.WatchResponse{
+					Header:       &pb.ResponseHeader{},
+					WatchId:      clientv3.InvalidWatchID,
+					Created:      true,
+					Canceled:     true,
+					CancelReason: err.Error(),
+				}
+				continue
+			}
+
 			wps.mu.Lock()
 			w := &watcher{
 				wr:  watchRange{string(cr.Key), string(cr.RangeEnd)},
@@ -252,9 +279,9 @@ func (wps *watchProxyStream) recvLoop() error {
 				wps: wps,
 
 				nextrev:  cr.StartRevision,
-				progress: cr.ProgressNotify,
+				progress: cr.ProgressNotify || cr.ProgressNotifyInterval > 0,
 				prevKV:   cr.PrevKv,
-				filters:  v3rpc.FiltersFromRequest(cr),
+				filters:  append(v3rpc.FiltersFromRequest(cr), valueFilters...),
 			}
 			if !w.wr.valid() {
 				w.post(&pb.WatchResponse{WatchId: clientv3.InvalidWatchID, Created: true, Canceled: true})
@@ -278,12 +305,16 @@ func (wps *watchProxyStream) recvLoop() error {
 }
 
 func (wps *watchProxyStream) sendLoop() {
+	// seq is the sequence number of the last response sent to the client
+	var seq uint64
 	for {
 		select {
 		case wresp, ok := <-wps.watchCh:
 			if !ok {
 				return
 			}
+			seq++
+			wresp.Seq = seq
 			if err := wps.stream.Send(wresp); err != nil {
 				return
 			}
@@ -42,7 +42,9 @@ type watchBroadcast struct {
 	lg        *zap.Logger
 }
 
-func newWatchBroadcast(lg *zap.Logger, wp *watchProxy, w *watcher, update func(*watchBroadcast)) *watchBroadcast {
+// newWatchBroadcast returns a broadcast to w watching from its revision, or
+// from resumeRev if set, a persisted cursor of the range before it.
+func newWatchBroadcast(lg *zap.Logger, wp *watchProxy, w *watcher, update func(*watchBroadcast), resumeRev int64) *watchBroadcast {
 	cctx, cancel := context.WithCancel(wp.ctx)
 	wb := &watchBroadcast{
 		cancel:    cancel,
@@ -51,10 +53,19 @@ func newWatchBroadcast(lg *zap.Logger, wp *watchProxy, w *watcher, update func(*
 		donec:     make(chan struct{}),
 		lg:        lg,
 	}
+	if resumeRev > 0 {
+		wb.nextrev = resumeRev
+	}
 	wb.add(w)
 	go func() {
 		defer close(wb.donec)
 
+		cctx = withClientAuthToken(cctx, w.wps.stream.Context())
+		if resumeRev > 0 {
+			wb.checkResumeRev(cctx, wp.kv, w.wr, resumeRev)
+		}
+
+		wb.mu.RLock()
 		opts := []clientv3.OpOption{
 			clientv3.WithRange(w.wr.end),
 			clientv3.WithProgressNotify(),
@@ -62,8 +73,7 @@ func newWatchBroadcast(lg *zap.Logger, wp *watchProxy, w *watcher, update func(*
 			clientv3.WithPrevKV(),
 			clientv3.WithCreatedNotify(),
 		}
-
-		cctx = withClientAuthToken(cctx, w.wps.stream.Context())
+		wb.mu.RUnlock()
 
 		wch := wp.cw.Watch(cctx, w.wr.key, opts...)
 		wp.lg.Debug("watch", zap.String("key", w.wr.key))
@@ -76,6 +86,31 @@ func newWatchBroadcast(lg *zap.Logger, wp *watchProxy, w *watcher, update func(*
 	return wb
 }
 
+// checkResumeRev falls back to watching from the earliest revision of the
+// receivers if the persisted cursor the broadcast starts from was compacted
+// while the proxy was down, rather than canceling their watches.
+func (wb *watchBroadcast) checkResumeRev(ctx context.Context, kv clientv3.KV, wr watchRange, resumeRev int64) {
+	_, err := kv.Get(ctx, wr.key, clientv3.WithRange(wr.end), clientv3.WithRev(resumeRev), clientv3.WithCountOnly())
+	if err == nil {
+		return
+	}
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	wb.nextrev = 0
+	for r := range wb.receivers {
+		if wb.nextrev == 0 || r.nextrev < wb.nextrev {
+			wb.nextrev = r.nextrev
+		}
+	}
+	wb.lg.Info(
+		"failed to resume watch from cursor",
+		zap.String("key", wr.key),
+		zap.Int64("cursor", resumeRev),
+		zap.Int64("revision", wb.nextrev),
+		zap.Error(err),
+	)
+}
+
 func (wb *watchBroadcast) bcast(wr clientv3.WatchResponse) {
 	wb.mu.Lock()
 	defer wb.mu.Unlock()
@@ -139,6 +174,16 @@ func (wb *watchBroadcast) delete(w *watcher) {
 	}
 }
 
+// cursor returns the revision the broadcast has reached, or 0 if not started.
+func (wb *watchBroadcast) cursor() int64 {
+	wb.mu.RLock()
+	defer wb.mu.RUnlock()
+	if wb.responses == 0 {
+		return 0
+	}
+	return wb.nextrev
+}
+
 func (wb *watchBroadcast) size() int {
 	wb.mu.RLock()
 	defer wb.mu.RUnlock()
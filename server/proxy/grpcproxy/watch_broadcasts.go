@@ -28,6 +28,9 @@ type watchBroadcasts struct {
 
 	updatec chan *watchBroadcast
 	donec   chan struct{}
+
+	// resumeRev is the persisted cursor to open the first broadcast from.
+	resumeRev int64
 }
 
 // maxCoalesceRecievers prevents a popular watchBroadcast from being coalseced.
@@ -93,7 +96,8 @@ func (wbs *watchBroadcasts) add(w *watcher) {
 		}
 	}
 	// no fit; create a bcast
-	wb := newWatchBroadcast(wbs.wp.lg, wbs.wp, w, wbs.update)
+	wb := newWatchBroadcast(wbs.wp.lg, wbs.wp, w, wbs.update, wbs.resumeRev)
+	wbs.resumeRev = 0
 	wbs.watchers[w] = wb
 	wbs.bcasts[wb] = struct{}{}
 }
@@ -116,6 +120,19 @@ func (wbs *watchBroadcasts) delete(w *watcher) int {
 	return len(wbs.bcasts)
 }
 
+// cursor returns the revision of the broadcast furthest behind among those
+// started, or 0 if none is.
+func (wbs *watchBroadcasts) cursor() (rev int64) {
+	wbs.mu.Lock()
+	defer wbs.mu.Unlock()
+	for wb := range wbs.bcasts {
+		if wbrev := wb.cursor(); wbrev > 0 && (rev == 0 || wbrev < rev) {
+			rev = wbrev
+		}
+	}
+	return rev
+}
+
 func (wbs *watchBroadcasts) stop() {
 	wbs.mu.Lock()
 	for wb := range wbs.bcasts {
@@ -0,0 +1,181 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcproxy
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+var watchCursorsBucket = []byte("watchCursors")
+
+// watchCursorsSyncInterval is how often the cursors of the open watches are
+// persisted.
+const watchCursorsSyncInterval = time.Second
+
+// WatchCursors persists, for each range watched through the proxy, the
+// revision its coalesced watches have reached upstream. A restarted proxy
+// opens the first watch of a persisted range from its cursor rather than from
+// the revision of the first client resuming it, so that the other clients
+// resuming the range from revisions in between are served by the same
+// upstream watch instead of each opening their own from older revisions.
+type WatchCursors struct {
+	lg *zap.Logger
+	db *bolt.DB
+
+	mu sync.Mutex
+	// resume holds the cursors persisted by the previous run of the proxy
+	// not yet used to open a watch.
+	resume map[watchRange]int64
+}
+
+// OpenWatchCursors opens the bolt file of the watch cursors at path, creating
+// it if it does not exist, and loads the cursors of the previous run.
+func OpenWatchCursors(lg *zap.Logger, path string) (*WatchCursors, error) {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	wc := &WatchCursors{lg: lg, db: db, resume: make(map[watchRange]int64)}
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(watchCursorsBucket)
+		if err != nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			wr, err := decodeWatchRange(k)
+			if err != nil {
+				return err
+			}
+			if len(v) != 8 {
+				return fmt.Errorf("invalid cursor of watch range %q", wr.key)
+			}
+			wc.resume[wr] = int64(binary.BigEndian.Uint64(v))
+			return nil
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	lg.Info("loaded watch cursors", zap.String("path", path), zap.Int("cursors", len(wc.resume)))
+	return wc, nil
+}
+
+// Close closes the bolt file of the watch cursors.
+func (wc *WatchCursors) Close() error { return wc.db.Close() }
+
+// take returns the persisted cursor of wr to open a watch of a client resuming
+// from rev with, or 0 if there is none before rev. A cursor is used once.
+func (wc *WatchCursors) take(wr watchRange, rev int64) int64 {
+	if rev == 0 {
+		// current watches expect no past events
+		return 0
+	}
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	cursor, ok := wc.resume[wr]
+	if !ok {
+		return 0
+	}
+	delete(wc.resume, wr)
+	if cursor >= rev {
+		return 0
+	}
+	return cursor
+}
+
+// save replaces the persisted cursors with the given ones and those of the
+// previous run not yet used.
+func (wc *WatchCursors) save(cursors map[watchRange]int64) error {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	return wc.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(watchCursorsBucket); err != nil {
+			return err
+		}
+		b, err := tx.CreateBucket(watchCursorsBucket)
+		if err != nil {
+			return err
+		}
+		put := func(wr watchRange, rev int64) error {
+			v := make([]byte, 8)
+			binary.BigEndian.PutUint64(v, uint64(rev))
+			return b.Put(encodeWatchRange(wr), v)
+		}
+		for wr, rev := range wc.resume {
+			if _, ok := cursors[wr]; ok {
+				continue
+			}
+			if err := put(wr, rev); err != nil {
+				return err
+			}
+		}
+		for wr, rev := range cursors {
+			if err := put(wr, rev); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// run persists the cursors of the open watches of wrs until ctx is done.
+// Cursors are not persisted once the proxy shuts down, since its watches are
+// then torn down along with the streams of their clients.
+func (wc *WatchCursors) run(ctx context.Context, wrs *watchRanges) {
+	ticker := time.NewTicker(watchCursorsSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		cursors := wrs.cursors()
+		if ctx.Err() != nil {
+			return
+		}
+		if err := wc.save(cursors); err != nil {
+			wc.lg.Warn("failed to persist watch cursors", zap.Error(err))
+		}
+	}
+}
+
+// encodeWatchRange encodes the key of wr prefixed by its length, followed by
+// its end, since both may hold any byte.
+func encodeWatchRange(wr watchRange) []byte {
+	b := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(wr.key)+len(wr.end))
+	b = b[:binary.PutUvarint(b, uint64(len(wr.key)))]
+	b = append(b, wr.key...)
+	return append(b, wr.end...)
+}
+
+func decodeWatchRange(b []byte) (watchRange, error) {
+	n, m := binary.Uvarint(b)
+	if m <= 0 || uint64(len(b)-m) < n {
+		return watchRange{}, fmt.Errorf("invalid watch range %q", b)
+	}
+	return watchRange{key: string(b[m : m+int(n)]), end: string(b[m+int(n):])}, nil
+}
@@ -42,6 +42,9 @@ func (wrs *watchRanges) add(w *watcher) {
 		return
 	}
 	wbs := newWatchBroadcasts(wrs.wp)
+	if wrs.wp.cursors != nil {
+		wbs.resumeRev = wrs.wp.cursors.take(w.wr, w.nextrev)
+	}
 	wrs.bcasts[w.wr] = wbs
 	wbs.add(w)
 }
@@ -59,6 +62,19 @@ func (wrs *watchRanges) delete(w *watcher) {
 	}
 }
 
+// cursors returns the revisions the watches of each range have reached.
+func (wrs *watchRanges) cursors() map[watchRange]int64 {
+	wrs.mu.Lock()
+	defer wrs.mu.Unlock()
+	cursors := make(map[watchRange]int64, len(wrs.bcasts))
+	for wr, wbs := range wrs.bcasts {
+		if rev := wbs.cursor(); rev > 0 {
+			cursors[wr] = rev
+		}
+	}
+	return cursors
+}
+
 func (wrs *watchRanges) stop() {
 	wrs.mu.Lock()
 	defer wrs.mu.Unlock()
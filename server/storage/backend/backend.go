@@ -35,6 +35,10 @@ var (
 
 	defragLimit = 10000
 
+	// defragOnlineBatchLimit is the number of keys copied per read transaction
+	// by online defragmentation.
+	defragOnlineBatchLimit = 1000
+
 	// initialMmapSize is the initial size of the mmapped region. Setting this larger than
 	// the potential max db size can prevent writer from blocking reader.
 	// This only works for linux.
@@ -65,11 +69,20 @@ type Backend interface {
 	// OpenReadTxN returns the number of currently open read transactions in the backend.
 	OpenReadTxN() int64
 	Defrag() error
+	// DefragOnline defragments the backend like Defrag, copying the database
+	// in small batches while reads and writes go on, and only blocking them
+	// to copy the keys written meanwhile before replacing the database.
+	DefragOnline() error
 	ForceCommit()
 	Close() error
 
 	// SetTxPostLockInsideApplyHook sets a txPostLockInsideApplyHook.
 	SetTxPostLockInsideApplyHook(func())
+
+	// SetMemoryScale shrinks the batch limit, and so the buffer of the
+	// pending writes served to the reads, to scale, between 0 and 1, of the
+	// configured limit.
+	SetMemoryScale(scale float64)
 }
 
 type Snapshot interface {
@@ -99,6 +112,8 @@ type backend struct {
 	commits int64
 	// openReadTxN is the number of currently open read transactions in the backend
 	openReadTxN int64
+	// scaledBatchLimit is the batch limit as scaled by SetMemoryScale
+	scaledBatchLimit int64
 	// mlock prevents backend database file to be swapped
 	mlock bool
 
@@ -122,6 +137,12 @@ type backend struct {
 
 	hooks Hooks
 
+	// defragMu serializes defragmentations.
+	defragMu sync.Mutex
+	// defragDirty tracks the writes made during an online defragmentation,
+	// nil otherwise. Accessed holding the lock on batchTx.
+	defragDirty *defragDirty
+
 	// txPostLockInsideApplyHook is called each time right after locking the tx.
 	txPostLockInsideApplyHook func()
 
@@ -195,6 +216,8 @@ func newBackend(bcfg BackendConfig) *backend {
 		batchLimit:    bcfg.BatchLimit,
 		mlock:         bcfg.Mlock,
 
+		scaledBatchLimit: int64(bcfg.BatchLimit),
+
 		readTx: &readTx{
 			baseReadTx: baseReadTx{
 				buf: txReadBuffer{
@@ -241,6 +264,18 @@ func (b *backend) SetTxPostLockInsideApplyHook(hook func()) {
 	b.txPostLockInsideApplyHook = hook
 }
 
+func (b *backend) SetMemoryScale(scale float64) {
+	limit := int64(float64(b.batchLimit) * scale)
+	if limit < 1 {
+		limit = 1
+	}
+	atomic.StoreInt64(&b.scaledBatchLimit, limit)
+}
+
+func (b *backend) getBatchLimit() int {
+	return int(atomic.LoadInt64(&b.scaledBatchLimit))
+}
+
 func (b *backend) ReadTx() ReadTx { return b.readTx }
 
 // ConcurrentReadTx creates and returns a new ReadTx, which:
@@ -441,6 +476,8 @@ func (b *backend) Commits() int64 {
 }
 
 func (b *backend) Defrag() error {
+	b.defragMu.Lock()
+	defer b.defragMu.Unlock()
 	return b.defrag()
 }
 
@@ -467,24 +504,7 @@ func (b *backend) defrag() error {
 
 	b.batchTx.tx = nil
 
-	// Create a temporary file to ensure we start with a clean slate.
-	// Snapshotter.cleanupSnapdir cleans up any of these that are found during startup.
-	dir := filepath.Dir(b.db.Path())
-	temp, err := os.CreateTemp(dir, "db.tmp.*")
-	if err != nil {
-		return err
-	}
-	options := bolt.Options{}
-	if boltOpenOptions != nil {
-		options = *boltOpenOptions
-	}
-	options.OpenFile = func(_ string, _ int, _ os.FileMode) (file *os.File, err error) {
-		return temp, nil
-	}
-	// Don't load tmp db into memory regardless of opening options
-	options.Mlock = false
-	tdbp := temp.Name()
-	tmpdb, err := bolt.Open(tdbp, 0600, &options)
+	tmpdb, err := b.openDefragTmpDB()
 	if err != nil {
 		return err
 	}
@@ -504,14 +524,68 @@ func (b *backend) defrag() error {
 	// gofail: var defragBeforeCopy struct{}
 	err = defragdb(b.db, tmpdb, defragLimit)
 	if err != nil {
-		tmpdb.Close()
-		if rmErr := os.RemoveAll(tmpdb.Path()); rmErr != nil {
-			b.lg.Error("failed to remove db.tmp after defragmentation completed", zap.Error(rmErr))
-		}
+		b.removeDefragTmpDB(tmpdb)
 		return err
 	}
 
-	err = b.db.Close()
+	b.unsafeReplaceDB(tmpdb)
+
+	took := time.Since(now)
+	defragSec.Observe(took.Seconds())
+
+	size2, sizeInUse2 := b.Size(), b.SizeInUse()
+	if b.lg != nil {
+		b.lg.Info(
+			"finished defragmenting directory",
+			zap.String("path", dbp),
+			zap.Int64("current-db-size-bytes-diff", size2-size1),
+			zap.Int64("current-db-size-bytes", size2),
+			zap.String("current-db-size", humanize.Bytes(uint64(size2))),
+			zap.Int64("current-db-size-in-use-bytes-diff", sizeInUse2-sizeInUse1),
+			zap.Int64("current-db-size-in-use-bytes", sizeInUse2),
+			zap.String("current-db-size-in-use", humanize.Bytes(uint64(sizeInUse2))),
+			zap.Duration("took", took),
+		)
+	}
+	return nil
+}
+
+// openDefragTmpDB opens the temporary database the backend is defragmented to.
+func (b *backend) openDefragTmpDB() (*bolt.DB, error) {
+	// Create a temporary file to ensure we start with a clean slate.
+	// Snapshotter.cleanupSnapdir cleans up any of these that are found during startup.
+	dir := filepath.Dir(b.db.Path())
+	temp, err := os.CreateTemp(dir, "db.tmp.*")
+	if err != nil {
+		return nil, err
+	}
+	options := bolt.Options{}
+	if boltOpenOptions != nil {
+		options = *boltOpenOptions
+	}
+	options.OpenFile = func(_ string, _ int, _ os.FileMode) (file *os.File, err error) {
+		return temp, nil
+	}
+	// Don't load tmp db into memory regardless of opening options
+	options.Mlock = false
+	return bolt.Open(temp.Name(), 0600, &options)
+}
+
+// removeDefragTmpDB closes and removes the temporary database of a failed
+// defragmentation.
+func (b *backend) removeDefragTmpDB(tmpdb *bolt.DB) {
+	tmpdb.Close()
+	if rmErr := os.RemoveAll(tmpdb.Path()); rmErr != nil {
+		b.lg.Error("failed to remove db.tmp after defragmentation completed", zap.Error(rmErr))
+	}
+}
+
+// unsafeReplaceDB replaces the database of the backend by the defragmented
+// one. It must be called holding the locks on batchTx, the backend and
+// readTx, once batchTx is committed and stopped.
+func (b *backend) unsafeReplaceDB(tmpdb *bolt.DB) {
+	dbp, tdbp := b.db.Path(), tmpdb.Path()
+	err := b.db.Close()
 	if err != nil {
 		b.lg.Fatal("failed to close database", zap.Error(err))
 	}
@@ -538,25 +612,6 @@ func (b *backend) defrag() error {
 	db := b.readTx.tx.DB()
 	atomic.StoreInt64(&b.size, size)
 	atomic.StoreInt64(&b.sizeInUse, size-(int64(db.Stats().FreePageN)*int64(db.Info().PageSize)))
-
-	took := time.Since(now)
-	defragSec.Observe(took.Seconds())
-
-	size2, sizeInUse2 := b.Size(), b.SizeInUse()
-	if b.lg != nil {
-		b.lg.Info(
-			"finished defragmenting directory",
-			zap.String("path", dbp),
-			zap.Int64("current-db-size-bytes-diff", size2-size1),
-			zap.Int64("current-db-size-bytes", size2),
-			zap.String("current-db-size", humanize.Bytes(uint64(size2))),
-			zap.Int64("current-db-size-in-use-bytes-diff", sizeInUse2-sizeInUse1),
-			zap.Int64("current-db-size-in-use-bytes", sizeInUse2),
-			zap.String("current-db-size-in-use", humanize.Bytes(uint64(sizeInUse2))),
-			zap.Duration("took", took),
-		)
-	}
-	return nil
 }
 
 func defragdb(odb, tmpdb *bolt.DB, limit int) error {
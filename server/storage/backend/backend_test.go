@@ -122,6 +122,44 @@ func TestBackendBatchIntervalCommit(t *testing.T) {
 	}))
 }
 
+// TestBackendSetMemoryScale ensures the pending writes are committed once
+// they reach the batch limit scaled by SetMemoryScale.
+func TestBackendSetMemoryScale(t *testing.T) {
+	b, _ := betesting.NewTmpBackend(t, time.Hour, 10)
+	defer betesting.Close(t, b)
+
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(schema.Test)
+	tx.Unlock()
+	b.ForceCommit()
+
+	put := func(n int) {
+		for i := 0; i < n; i++ {
+			tx.Lock()
+			tx.UnsafePut(schema.Test, []byte(fmt.Sprintf("foo%d", i)), []byte("bar"))
+			tx.Unlock()
+		}
+	}
+
+	b.SetMemoryScale(0.5)
+	pc := backend.CommitsForTest(b)
+	put(4)
+	if c := backend.CommitsForTest(b); c != pc {
+		t.Fatalf("commits = %d, want %d below the scaled batch limit", c, pc)
+	}
+	put(1)
+	if c := backend.CommitsForTest(b); c != pc+1 {
+		t.Fatalf("commits = %d, want %d at the scaled batch limit", c, pc+1)
+	}
+
+	b.SetMemoryScale(1)
+	put(5)
+	if c := backend.CommitsForTest(b); c != pc+1 {
+		t.Fatalf("commits = %d, want %d below the restored batch limit", c, pc+1)
+	}
+}
+
 func TestBackendDefrag(t *testing.T) {
 	bcfg := backend.DefaultBackendConfig(zaptest.NewLogger(t))
 	// Make sure we change BackendFreelistType
@@ -193,6 +231,90 @@ func TestBackendDefrag(t *testing.T) {
 	b.ForceCommit()
 }
 
+// TestBackendDefragOnline ensures online defragmentation keeps the writes
+// made while it copies the database.
+func TestBackendDefragOnline(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, b)
+
+	n := 8 * backend.DefragOnlineBatchLimitForTest()
+	want := make(map[string]string)
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(schema.Test)
+	for i := 0; i < n; i++ {
+		k := fmt.Sprintf("foo_%05d", i)
+		tx.UnsafePut(schema.Test, []byte(k), []byte("bar"))
+		want[k] = "bar"
+	}
+	tx.Unlock()
+	b.ForceCommit()
+
+	// remove some keys to ensure the disk space will be reclaimed after defrag
+	tx.Lock()
+	for i := 0; i < n/2; i++ {
+		k := fmt.Sprintf("foo_%05d", i)
+		tx.UnsafeDelete(schema.Test, []byte(k))
+		delete(want, k)
+	}
+	tx.Unlock()
+	b.ForceCommit()
+	size := b.Size()
+
+	// pending writes are kept too
+	tx.Lock()
+	tx.UnsafePut(schema.Test, []byte("bar"), []byte("bar"))
+	want["bar"] = "bar"
+	tx.Unlock()
+
+	// write while the defragmentation copies the database
+	i := 0
+	backend.SetDefragOnlineBatchHookForTest(func() {
+		tx.Lock()
+		for j := 0; j < 100; j++ {
+			put := fmt.Sprintf("foo_%05d", n/2+(i*7)%(n/2))
+			del := fmt.Sprintf("foo_%05d", n/2+(i*13)%(n/2))
+			tx.UnsafePut(schema.Test, []byte(put), []byte(fmt.Sprintf("baz_%d", i)))
+			tx.UnsafeDelete(schema.Test, []byte(del))
+			want[put] = fmt.Sprintf("baz_%d", i)
+			delete(want, del)
+			i++
+		}
+		tx.Unlock()
+		if i%200 == 0 {
+			b.ForceCommit()
+		}
+	})
+	defer backend.SetDefragOnlineBatchHookForTest(nil)
+	if err := b.DefragOnline(); err != nil {
+		t.Fatal(err)
+	}
+	// deletes are only visible to read transactions once committed
+	b.ForceCommit()
+
+	got := make(map[string]string)
+	rtx := b.ReadTx()
+	rtx.RLock()
+	err := rtx.UnsafeForEach(schema.Test, func(k, v []byte) error {
+		got[string(k)] = string(v)
+		return nil
+	})
+	rtx.RUnlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, want, got)
+	if nsize := b.Size(); nsize >= size {
+		t.Errorf("new size = %v, want < %d", nsize, size)
+	}
+
+	// try put more keys after shrink.
+	tx.Lock()
+	tx.UnsafePut(schema.Test, []byte("more"), []byte("bar"))
+	tx.Unlock()
+	b.ForceCommit()
+}
+
 // TestBackendWriteback ensures writes are stored to the read txn on write txn unlock.
 func TestBackendWriteback(t *testing.T) {
 	b, _ := betesting.NewDefaultTmpBackend(t)
@@ -94,7 +94,7 @@ func (t *batchTx) LockOutsideApply() {
 }
 
 func (t *batchTx) Unlock() {
-	if t.pending >= t.backend.batchLimit {
+	if t.pending >= t.backend.getBatchLimit() {
 		t.commit(false)
 	}
 	t.Mutex.Unlock()
@@ -121,6 +121,9 @@ func (t *batchTx) UnsafeCreateBucket(bucket Bucket) {
 			zap.Error(err),
 		)
 	}
+	if t.backend.defragDirty != nil {
+		t.backend.defragDirty.addBucket(bucket)
+	}
 	t.pending++
 }
 
@@ -133,6 +136,9 @@ func (t *batchTx) UnsafeDeleteBucket(bucket Bucket) {
 			zap.Error(err),
 		)
 	}
+	if t.backend.defragDirty != nil {
+		t.backend.defragDirty.addBucket(bucket)
+	}
 	t.pending++
 }
 
@@ -167,6 +173,9 @@ func (t *batchTx) unsafePut(bucketType Bucket, key []byte, value []byte, seq boo
 			zap.Error(err),
 		)
 	}
+	if t.backend.defragDirty != nil {
+		t.backend.defragDirty.addKey(bucketType, key)
+	}
 	t.pending++
 }
 
@@ -223,6 +232,9 @@ func (t *batchTx) UnsafeDelete(bucketType Bucket, key []byte) {
 			zap.Error(err),
 		)
 	}
+	if t.backend.defragDirty != nil {
+		t.backend.defragDirty.addKey(bucketType, key)
+	}
 	t.pending++
 }
 
@@ -309,7 +321,7 @@ func (t *batchTxBuffered) Unlock() {
 		t.backend.readTx.Lock() // blocks txReadBuffer for writing.
 		t.buf.writeback(&t.backend.readTx.buf)
 		t.backend.readTx.Unlock()
-		if t.pending >= t.backend.batchLimit {
+		if t.pending >= t.backend.getBatchLimit() {
 			t.commit(false)
 		}
 	}
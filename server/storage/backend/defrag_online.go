@@ -0,0 +1,295 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+// defragOnlineBatchHook is called after each batch copied by online
+// defragmentation, to write to the backend meanwhile in tests.
+var defragOnlineBatchHook func()
+
+// defragDirty tracks the buckets and keys written during an online
+// defragmentation, which are copied again before replacing the database.
+type defragDirty struct {
+	// buckets are the buckets created or deleted, copied again as a whole.
+	buckets map[string]struct{}
+	// keys are the keys put or deleted per bucket.
+	keys map[string]map[string]struct{}
+}
+
+func newDefragDirty() *defragDirty {
+	return &defragDirty{
+		buckets: make(map[string]struct{}),
+		keys:    make(map[string]map[string]struct{}),
+	}
+}
+
+func (d *defragDirty) addBucket(bucket Bucket) {
+	d.buckets[string(bucket.Name())] = struct{}{}
+}
+
+func (d *defragDirty) addKey(bucket Bucket, key []byte) {
+	keys, ok := d.keys[string(bucket.Name())]
+	if !ok {
+		keys = make(map[string]struct{})
+		d.keys[string(bucket.Name())] = keys
+	}
+	keys[string(key)] = struct{}{}
+}
+
+func (b *backend) DefragOnline() error {
+	b.defragMu.Lock()
+	defer b.defragMu.Unlock()
+	return b.defragOnline()
+}
+
+func (b *backend) defragOnline() error {
+	now := time.Now()
+	isDefragActive.Set(1)
+	defer isDefragActive.Set(0)
+
+	// commit the pending writes for the copy to see them, and track the
+	// writes from now on, so that the ones missed by the copy are copied
+	// again at the end.
+	b.batchTx.LockOutsideApply()
+	b.batchTx.commit(false)
+	b.defragDirty = newDefragDirty()
+	b.batchTx.Unlock()
+	defer func() {
+		b.batchTx.LockOutsideApply()
+		b.defragDirty = nil
+		b.batchTx.Unlock()
+	}()
+
+	b.mu.RLock()
+	tmpdb, err := b.openDefragTmpDB()
+	dbp := b.db.Path()
+	b.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	size1, sizeInUse1 := b.Size(), b.SizeInUse()
+	if b.lg != nil {
+		b.lg.Info(
+			"defragmenting online",
+			zap.String("path", dbp),
+			zap.Int64("current-db-size-bytes", size1),
+			zap.String("current-db-size", humanize.Bytes(uint64(size1))),
+			zap.Int64("current-db-size-in-use-bytes", sizeInUse1),
+			zap.String("current-db-size-in-use", humanize.Bytes(uint64(sizeInUse1))),
+		)
+	}
+	// gofail: var defragOnlineBeforeCopy struct{}
+	if err = b.defragCopyOnline(tmpdb, defragOnlineBatchLimit); err != nil {
+		b.removeDefragTmpDB(tmpdb)
+		return err
+	}
+
+	// block reads and writes to copy the keys written meanwhile and replace
+	// the database.
+	b.batchTx.LockOutsideApply()
+	defer b.batchTx.Unlock()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.readTx.Lock()
+	defer b.readTx.Unlock()
+
+	blockStart := time.Now()
+	b.batchTx.unsafeCommit(true)
+	b.batchTx.tx = nil
+
+	dirtyKeys := 0
+	for _, keys := range b.defragDirty.keys {
+		dirtyKeys += len(keys)
+	}
+	if err = defragCopyDirty(b.db, tmpdb, b.defragDirty); err != nil {
+		b.removeDefragTmpDB(tmpdb)
+		b.batchTx.tx = b.unsafeBegin(true)
+		b.readTx.tx = b.unsafeBegin(false)
+		return err
+	}
+
+	b.unsafeReplaceDB(tmpdb)
+
+	took := time.Since(now)
+	defragSec.Observe(took.Seconds())
+
+	size2, sizeInUse2 := b.Size(), b.SizeInUse()
+	if b.lg != nil {
+		b.lg.Info(
+			"finished defragmenting directory online",
+			zap.String("path", dbp),
+			zap.Int64("current-db-size-bytes-diff", size2-size1),
+			zap.Int64("current-db-size-bytes", size2),
+			zap.String("current-db-size", humanize.Bytes(uint64(size2))),
+			zap.Int64("current-db-size-in-use-bytes-diff", sizeInUse2-sizeInUse1),
+			zap.Int64("current-db-size-in-use-bytes", sizeInUse2),
+			zap.String("current-db-size-in-use", humanize.Bytes(uint64(sizeInUse2))),
+			zap.Int("dirty-buckets", len(b.defragDirty.buckets)),
+			zap.Int("dirty-keys", dirtyKeys),
+			zap.Duration("blocked", time.Since(blockStart)),
+			zap.Duration("took", took),
+		)
+	}
+	return nil
+}
+
+// defragCopyOnline copies the buckets of the database to tmpdb, a batch of
+// at most limit keys per read transaction.
+func (b *backend) defragCopyOnline(tmpdb *bolt.DB, limit int) error {
+	var buckets [][]byte
+	err := b.defragView(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			buckets = append(buckets, append([]byte(nil), name...))
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range buckets {
+		var after []byte
+		for done := false; !done; {
+			if after, done, err = b.defragCopyBatch(tmpdb, name, after, limit); err != nil {
+				return err
+			}
+			if defragOnlineBatchHook != nil {
+				defragOnlineBatchHook()
+			}
+		}
+	}
+	return nil
+}
+
+// defragCopyBatch copies at most limit keys of the bucket following the
+// given key to tmpdb. It returns the last key copied, and whether the bucket
+// is copied.
+func (b *backend) defragCopyBatch(tmpdb *bolt.DB, name, after []byte, limit int) (last []byte, done bool, err error) {
+	tmptx, err := tmpdb.Begin(true)
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = b.defragView(func(tx *bolt.Tx) error {
+		ob := tx.Bucket(name)
+		if ob == nil {
+			// the bucket was deleted since, which is tracked as dirty
+			done = true
+			return tmptx.Commit()
+		}
+		tmpb, berr := tmptx.CreateBucketIfNotExists(name)
+		if berr != nil {
+			return berr
+		}
+		tmpb.FillPercent = 0.9 // for bucket2seq write in for each
+
+		c := ob.Cursor()
+		k, v := c.First()
+		if after != nil {
+			if k, v = c.Seek(after); k != nil && bytes.Equal(k, after) {
+				k, v = c.Next()
+			}
+		}
+		for n := 0; k != nil && n < limit; k, v = c.Next() {
+			if perr := tmpb.Put(k, v); perr != nil {
+				return perr
+			}
+			last = k
+			n++
+		}
+		done = k == nil
+		// the keys and values of the read transaction are only valid until
+		// it ends, so the copy is committed before.
+		last = append([]byte(nil), last...)
+		return tmptx.Commit()
+	})
+	if err != nil {
+		tmptx.Rollback()
+		return nil, false, err
+	}
+	return last, done, nil
+}
+
+// defragView runs fn in a read transaction of the database of the backend.
+func (b *backend) defragView(fn func(tx *bolt.Tx) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.db.View(fn)
+}
+
+// defragCopyDirty copies the buckets and keys written during an online
+// defragmentation from odb to tmpdb.
+func defragCopyDirty(odb, tmpdb *bolt.DB, dirty *defragDirty) error {
+	tmptx, err := tmpdb.Begin(true)
+	if err != nil {
+		return err
+	}
+	err = odb.View(func(tx *bolt.Tx) error {
+		for name := range dirty.buckets {
+			if derr := tmptx.DeleteBucket([]byte(name)); derr != nil && derr != bolt.ErrBucketNotFound {
+				return derr
+			}
+			ob := tx.Bucket([]byte(name))
+			if ob == nil {
+				continue
+			}
+			tmpb, berr := tmptx.CreateBucket([]byte(name))
+			if berr != nil {
+				return berr
+			}
+			tmpb.FillPercent = 0.9
+			if ferr := ob.ForEach(tmpb.Put); ferr != nil {
+				return ferr
+			}
+		}
+		for name, keys := range dirty.keys {
+			if _, ok := dirty.buckets[name]; ok {
+				continue
+			}
+			ob := tx.Bucket([]byte(name))
+			if ob == nil {
+				continue
+			}
+			tmpb, berr := tmptx.CreateBucketIfNotExists([]byte(name))
+			if berr != nil {
+				return berr
+			}
+			for k := range keys {
+				var kerr error
+				if v := ob.Get([]byte(k)); v != nil {
+					kerr = tmpb.Put([]byte(k), v)
+				} else {
+					kerr = tmpb.Delete([]byte(k))
+				}
+				if kerr != nil {
+					return kerr
+				}
+			}
+		}
+		return tmptx.Commit()
+	})
+	if err != nil {
+		tmptx.Rollback()
+	}
+	return err
+}
@@ -10,6 +10,14 @@ func DefragLimitForTest() int {
 	return defragLimit
 }
 
+func DefragOnlineBatchLimitForTest() int {
+	return defragOnlineBatchLimit
+}
+
+func SetDefragOnlineBatchHookForTest(hook func()) {
+	defragOnlineBatchHook = hook
+}
+
 func CommitsForTest(b Backend) int64 {
 	return b.(*backend).Commits()
 }
@@ -0,0 +1,50 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compression provides the zstd compression shared by the raft entries
+// and the WAL records.
+package compression
+
+import (
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+func initZstd() {
+	zstdOnce.Do(func() {
+		// EncodeAll and DecodeAll are safe for concurrent use, and neither
+		// starts goroutines of the encoder or decoder.
+		zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	})
+}
+
+// ZstdEncode returns data compressed with zstd.
+func ZstdEncode(data []byte) []byte {
+	initZstd()
+	return zstdEncoder.EncodeAll(data, nil)
+}
+
+// ZstdDecode returns the data decompressed from the zstd compressed data.
+func ZstdDecode(data []byte) ([]byte, error) {
+	initZstd()
+	return zstdDecoder.DecodeAll(data, nil)
+}
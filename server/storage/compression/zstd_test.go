@@ -0,0 +1,38 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compression
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestZstd(t *testing.T) {
+	data := bytes.Repeat([]byte("value"), 1024)
+	compressed := ZstdEncode(data)
+	if len(compressed) >= len(data) {
+		t.Errorf("compressed %d bytes to %d bytes", len(data), len(compressed))
+	}
+	decompressed, err := ZstdDecode(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decompressed, data) {
+		t.Errorf("decompressed data differs from the compressed data")
+	}
+	if _, err = ZstdDecode(data); err == nil {
+		t.Errorf("decoded data not compressed with zstd")
+	}
+}
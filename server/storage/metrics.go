@@ -25,6 +25,14 @@ var quotaBackendBytes = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help:      "Current backend storage quota size in bytes.",
 })
 
+var quotaKeys = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "etcd",
+	Subsystem: "server",
+	Name:      "quota_keys",
+	Help:      "Current quota on the number of keys, or 0 if disabled.",
+})
+
 func init() {
 	prometheus.MustRegister(quotaBackendBytes)
+	prometheus.MustRegister(quotaKeys)
 }
@@ -0,0 +1,83 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// minCompactionBatchLimit and maxCompactionBatchLimit bound the number of
+	// revisions deleted in a paced compaction batch.
+	minCompactionBatchLimit = 10
+	maxCompactionBatchLimit = 100000
+)
+
+// compactionPacer sizes the compaction batches so that each of them holds
+// the backend for about a target latency, since the commit of a batch too
+// large stalls the writes of the clients for as long. The batch shrinks by
+// half when slower than the target and grows by a quarter when faster than
+// half of it, carrying its size over to the next compaction.
+type compactionPacer struct {
+	target time.Duration
+
+	mu    sync.Mutex
+	limit int
+}
+
+// newCompactionPacer returns a pacer of the batches starting at limit
+// revisions, or nil if target is not positive and the batches are of a fixed
+// size.
+func newCompactionPacer(target time.Duration, limit int) *compactionPacer {
+	if target <= 0 {
+		return nil
+	}
+	p := &compactionPacer{target: target}
+	p.limit = p.bound(limit)
+	dbCompactionBatchLimitGauge.Set(float64(p.limit))
+	return p
+}
+
+// batchLimit returns the number of revisions to delete in the next batch.
+func (p *compactionPacer) batchLimit() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.limit
+}
+
+// observe adjusts the size of the next batches given the latency of a batch
+// of the current size, from locking the backend to committing its deletes.
+func (p *compactionPacer) observe(latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case latency > p.target:
+		p.limit = p.bound(p.limit / 2)
+	case latency < p.target/2:
+		p.limit = p.bound(p.limit + p.limit/4 + 1)
+	}
+	dbCompactionBatchLimitGauge.Set(float64(p.limit))
+}
+
+func (p *compactionPacer) bound(limit int) int {
+	if limit < minCompactionBatchLimit {
+		return minCompactionBatchLimit
+	}
+	if limit > maxCompactionBatchLimit {
+		return maxCompactionBatchLimit
+	}
+	return limit
+}
@@ -0,0 +1,178 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"sort"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// eventCache holds the events of a contiguous window of recent revisions.
+// It lets the unsynced watcher loop serve watchers resuming inside the
+// window from memory instead of reading the same revisions from the
+// backend once per batch of resuming watchers.
+//
+// The cache is unfiltered, so a single window serves every watched range.
+// It is not safe for concurrent use; the watchable store guards it with
+// its own mutex.
+type eventCache struct {
+	// maxEvents bounds the number of cached events. The zero value disables the cache.
+	maxEvents int
+
+	// buf is the ring buffer of the cached events ordered by mod revision,
+	// allocated with maxEvents slots once events are cached. The events are
+	// held from index head, included, to index tail, excluded, wrapping
+	// around the end of buf.
+	buf        []mvccpb.Event
+	head, tail int
+	// n is the number of cached events.
+	n int
+	// minRev and maxRev are the inclusive bounds of the cached window.
+	// The cache holds every event with a revision in [minRev, maxRev].
+	minRev, maxRev int64
+}
+
+func (c *eventCache) enabled() bool { return c.maxEvents > 0 }
+
+// at returns the i-th oldest cached event.
+func (c *eventCache) at(i int) mvccpb.Event { return c.buf[(c.head+i)%len(c.buf)] }
+
+// covers returns true if all events between minRev and maxRev,
+// inclusive, are held by the cache.
+func (c *eventCache) covers(minRev, maxRev int64) bool {
+	return c.enabled() && c.maxRev != 0 && c.minRev <= minRev && maxRev <= c.maxRev
+}
+
+// rangeEvents returns the cached events with a revision between
+// minRev and maxRev, inclusive, that are contained by the given
+// watcher group. The caller must check covers first.
+func (c *eventCache) rangeEvents(wg *watcherGroup, minRev, maxRev int64) (evs []mvccpb.Event) {
+	i := sort.Search(c.n, func(i int) bool { return c.at(i).Kv.ModRevision >= minRev })
+	for ; i < c.n; i++ {
+		ev := c.at(i)
+		if ev.Kv.ModRevision > maxRev {
+			break
+		}
+		if wg.contains(string(ev.Kv.Key)) {
+			evs = append(evs, ev)
+		}
+	}
+	return evs
+}
+
+// reset replaces the cached window with the given events, which must be
+// every event with a revision in [minRev, maxRev]. The window is left
+// empty if it does not fit in the cache.
+func (c *eventCache) reset(evs []mvccpb.Event, minRev, maxRev int64) {
+	if !c.enabled() {
+		return
+	}
+	if len(evs) > c.maxEvents {
+		c.clear()
+		return
+	}
+	c.clear()
+	c.push(evs)
+	c.minRev, c.maxRev = minRev, maxRev
+	eventCacheEventsGauge.Set(float64(c.n))
+}
+
+// append extends the cached window with the events of revision rev.
+// Old revisions are evicted to respect the size bound. The window is
+// only extended when it ends right before rev, so it never has gaps.
+func (c *eventCache) append(rev int64, evs []mvccpb.Event) {
+	if !c.enabled() || c.maxRev == 0 {
+		return
+	}
+	if rev != c.maxRev+1 {
+		c.clear()
+		return
+	}
+	if len(evs) > c.maxEvents {
+		c.clear()
+		return
+	}
+	c.evict(c.n + len(evs) - c.maxEvents)
+	c.push(evs)
+	c.maxRev = rev
+	eventCacheEventsGauge.Set(float64(c.n))
+}
+
+// push adds the events after the newest cached event. The buffer must have
+// room for them.
+func (c *eventCache) push(evs []mvccpb.Event) {
+	if len(c.buf) != c.maxEvents {
+		c.buf = make([]mvccpb.Event, c.maxEvents)
+	}
+	for _, ev := range evs {
+		c.buf[c.tail] = ev
+		c.tail = (c.tail + 1) % len(c.buf)
+	}
+	c.n += len(evs)
+}
+
+// resize changes the bound of the number of cached events, evicting old
+// revisions to respect it. A zero bound disables the cache.
+func (c *eventCache) resize(maxEvents int) {
+	if maxEvents == c.maxEvents {
+		return
+	}
+	if maxEvents <= 0 {
+		c.maxEvents = maxEvents
+		c.clear()
+		return
+	}
+	c.evict(c.n - maxEvents)
+	evs := make([]mvccpb.Event, c.n)
+	for i := range evs {
+		evs[i] = c.at(i)
+	}
+	minRev, maxRev := c.minRev, c.maxRev
+	c.clear()
+	c.maxEvents = maxEvents
+	c.buf = nil
+	c.push(evs)
+	c.minRev, c.maxRev = minRev, maxRev
+	eventCacheEventsGauge.Set(float64(c.n))
+}
+
+// evict evicts at least n of the oldest events, rounded up to whole
+// revisions so the window stays contiguous.
+func (c *eventCache) evict(n int) {
+	if n <= 0 {
+		return
+	}
+	evicted := c.at(n - 1).Kv.ModRevision
+	for n < c.n && c.at(n).Kv.ModRevision == evicted {
+		n++
+	}
+	for i := 0; i < n; i++ {
+		// drop the references to the events so they can be collected
+		c.buf[c.head] = mvccpb.Event{}
+		c.head = (c.head + 1) % len(c.buf)
+	}
+	c.n -= n
+	c.minRev = evicted + 1
+}
+
+func (c *eventCache) clear() {
+	for i := 0; i < c.n; i++ {
+		c.buf[(c.head+i)%len(c.buf)] = mvccpb.Event{}
+	}
+	c.head, c.tail, c.n = 0, 0, 0
+	c.minRev, c.maxRev = 0, 0
+	eventCacheEventsGauge.Set(0)
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func newTestEvent(key string, rev int64) mvccpb.Event {
+	return mvccpb.Event{Kv: &mvccpb.KeyValue{Key: []byte(key), ModRevision: rev}}
+}
+
+func TestEventCacheAppendEvicts(t *testing.T) {
+	c := eventCache{maxEvents: 3}
+	c.reset([]mvccpb.Event{newTestEvent("a", 2), newTestEvent("b", 2)}, 1, 2)
+
+	c.append(3, []mvccpb.Event{newTestEvent("a", 3)})
+	if !c.covers(1, 3) {
+		t.Fatalf("expected cache to cover [1, 3], got [%d, %d]", c.minRev, c.maxRev)
+	}
+
+	// revision 2 is evicted as a whole to keep the window contiguous
+	c.append(4, []mvccpb.Event{newTestEvent("a", 4)})
+	if c.covers(2, 4) {
+		t.Fatalf("expected cache not to cover [2, 4], got [%d, %d]", c.minRev, c.maxRev)
+	}
+	if !c.covers(3, 4) || c.n != 2 {
+		t.Fatalf("cache = [%d, %d] with %d events, want [3, 4] with 2 events", c.minRev, c.maxRev, c.n)
+	}
+
+	// a gap invalidates the window
+	c.append(6, []mvccpb.Event{newTestEvent("a", 6)})
+	if c.covers(6, 6) || c.n != 0 {
+		t.Fatalf("expected cache to be cleared, got [%d, %d] with %d events", c.minRev, c.maxRev, c.n)
+	}
+}
+
+func TestEventCacheResize(t *testing.T) {
+	c := eventCache{maxEvents: 10}
+	c.reset([]mvccpb.Event{newTestEvent("a", 2), newTestEvent("b", 2), newTestEvent("a", 3), newTestEvent("a", 4)}, 1, 4)
+
+	// shrinking evicts whole revisions
+	c.resize(3)
+	if !c.covers(3, 4) || c.covers(2, 4) || c.n != 2 {
+		t.Fatalf("cache = [%d, %d] with %d events, want [3, 4] with 2 events", c.minRev, c.maxRev, c.n)
+	}
+
+	// growing keeps the window
+	c.resize(10)
+	c.append(5, []mvccpb.Event{newTestEvent("a", 5)})
+	if !c.covers(3, 5) || c.n != 3 {
+		t.Fatalf("cache = [%d, %d] with %d events, want [3, 5] with 3 events", c.minRev, c.maxRev, c.n)
+	}
+
+	// a zero size disables the cache
+	c.resize(0)
+	if c.covers(5, 5) || c.n != 0 {
+		t.Fatalf("expected disabled cache to be empty, got [%d, %d] with %d events", c.minRev, c.maxRev, c.n)
+	}
+}
+
+func TestEventCacheRangeEvents(t *testing.T) {
+	c := eventCache{maxEvents: 10}
+	c.reset([]mvccpb.Event{newTestEvent("a", 2), newTestEvent("b", 3), newTestEvent("a", 4), newTestEvent("a", 5)}, 2, 5)
+
+	wg := newWatcherGroup()
+	wg.add(&watcher{key: []byte("a")})
+
+	evs := c.rangeEvents(&wg, 3, 4)
+	if len(evs) != 1 || evs[0].Kv.ModRevision != 4 {
+		t.Fatalf("events = %v, want a single event at revision 4", evs)
+	}
+}
+
+func TestEventCacheRangeEventsWrapped(t *testing.T) {
+	c := eventCache{maxEvents: 3}
+	c.reset(nil, 1, 1)
+	for rev := int64(2); rev <= 10; rev++ {
+		c.append(rev, []mvccpb.Event{newTestEvent("a", rev)})
+	}
+
+	wg := newWatcherGroup()
+	wg.add(&watcher{key: []byte("a")})
+
+	if !c.covers(8, 10) || c.covers(7, 10) {
+		t.Fatalf("cache = [%d, %d], want [8, 10]", c.minRev, c.maxRev)
+	}
+	evs := c.rangeEvents(&wg, 8, 10)
+	if len(evs) != 3 {
+		t.Fatalf("events = %v, want 3 events", evs)
+	}
+	for i, ev := range evs {
+		if ev.Kv.ModRevision != int64(8+i) {
+			t.Errorf("event %d at revision %d, want %d", i, ev.Kv.ModRevision, 8+i)
+		}
+	}
+}
+
+func TestEventCacheDisabled(t *testing.T) {
+	var c eventCache
+	c.reset([]mvccpb.Event{newTestEvent("a", 2)}, 2, 2)
+	c.append(3, []mvccpb.Event{newTestEvent("a", 3)})
+	if c.covers(2, 3) || c.n != 0 {
+		t.Fatalf("expected disabled cache to be empty, got [%d, %d] with %d events", c.minRev, c.maxRev, c.n)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// maxEventPrefixes bounds the number of prefixes the events are counted by.
+// Once reached, the events of the keys under a new prefix are counted by the
+// allowed prefix they match instead.
+const maxEventPrefixes = 1000
+
+// eventPrefixCounter counts the put and delete events of the keys under a set
+// of allowed prefixes, aggregated by their key prefix of up to a given depth.
+type eventPrefixCounter struct {
+	// allowed is sorted by descending length so that the longest of the
+	// overlapping prefixes matches first.
+	allowed [][]byte
+	depth   int
+
+	mu       sync.Mutex
+	prefixes map[string]struct{}
+}
+
+// newEventPrefixCounter returns a counter of the events under the allowed
+// prefixes, or nil if none is allowed.
+func newEventPrefixCounter(allowed []string, depth int) *eventPrefixCounter {
+	if len(allowed) == 0 {
+		return nil
+	}
+	c := &eventPrefixCounter{depth: depth, prefixes: make(map[string]struct{})}
+	for _, p := range allowed {
+		c.allowed = append(c.allowed, []byte(p))
+	}
+	sort.SliceStable(c.allowed, func(i, j int) bool { return len(c.allowed[i]) > len(c.allowed[j]) })
+	return c
+}
+
+// prefix returns the prefix the events of key are counted by: the allowed
+// prefix it matches followed by up to depth of its '/' terminated segments,
+// so that the last segment of the key is never part of it, along with the
+// allowed prefix. It returns false if the key matches no allowed prefix.
+func (c *eventPrefixCounter) prefix(key []byte) (prefix, allowed []byte, ok bool) {
+	for _, p := range c.allowed {
+		if !bytes.HasPrefix(key, p) {
+			continue
+		}
+		end := len(p)
+		for i := 0; i < c.depth; i++ {
+			n := bytes.IndexByte(key[end:], '/')
+			if n < 0 {
+				break
+			}
+			end += n + 1
+		}
+		return key[:end], p, true
+	}
+	return nil, nil, false
+}
+
+// observe counts the events.
+func (c *eventPrefixCounter) observe(evs []mvccpb.Event) {
+	for _, ev := range evs {
+		prefix, allowed, ok := c.prefix(ev.Kv.Key)
+		if !ok {
+			continue
+		}
+		eventsByPrefixCounter.WithLabelValues(c.limit(prefix, allowed), ev.Type.String()).Inc()
+	}
+}
+
+// limit returns the prefix the events are counted by, the allowed prefix
+// once maxEventPrefixes other prefixes were counted.
+func (c *eventPrefixCounter) limit(prefix, allowed []byte) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.prefixes[string(prefix)]; ok || len(c.prefixes) < maxEventPrefixes {
+		c.prefixes[string(prefix)] = struct{}{}
+		return string(prefix)
+	}
+	return string(allowed)
+}
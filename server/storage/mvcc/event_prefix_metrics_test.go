@@ -0,0 +1,96 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestEventPrefixCounterPrefix(t *testing.T) {
+	c := newEventPrefixCounter([]string{"/registry/", "/registry/events/", "app"}, 2)
+	tests := []struct {
+		key     string
+		wprefix string
+		wok     bool
+	}{
+		{"/registry/pods/default/web-0", "/registry/pods/default/", true},
+		{"/registry/pods/web-0", "/registry/pods/", true},
+		{"/registry/leader", "/registry/", true},
+		// the longest allowed prefix matches
+		{"/registry/events/default/ev/1", "/registry/events/default/ev/", true},
+		{"app/config", "app/", true},
+		{"application", "app", true},
+		{"/other/key", "", false},
+	}
+	for _, tt := range tests {
+		prefix, _, ok := c.prefix([]byte(tt.key))
+		if string(prefix) != tt.wprefix || ok != tt.wok {
+			t.Errorf("prefix(%q) = %q, %v, want %q, %v", tt.key, prefix, ok, tt.wprefix, tt.wok)
+		}
+	}
+
+	if newEventPrefixCounter(nil, 2) != nil {
+		t.Error("expected no counter without allowed prefixes")
+	}
+}
+
+func TestEventPrefixCounterLimit(t *testing.T) {
+	c := newEventPrefixCounter([]string{"/limit/"}, 1)
+	for i := 0; i < maxEventPrefixes; i++ {
+		if p := c.limit([]byte(fmt.Sprintf("/limit/%d/", i)), []byte("/limit/")); p != fmt.Sprintf("/limit/%d/", i) {
+			t.Fatalf("limit() = %q under the limit", p)
+		}
+	}
+	if p := c.limit([]byte("/limit/new/"), []byte("/limit/")); p != "/limit/" {
+		t.Errorf("limit() = %q over the limit, want the allowed prefix", p)
+	}
+	if p := c.limit([]byte("/limit/0/"), []byte("/limit/")); p != "/limit/0/" {
+		t.Errorf("limit() = %q for a counted prefix, want it unchanged", p)
+	}
+}
+
+func TestWatchableStoreEventPrefixMetrics(t *testing.T) {
+	b, tmpPath := betesting.NewDefaultTmpBackend(t)
+	s := newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{
+		EventPrefixMetrics:      []string{"/metrics-test/"},
+		EventPrefixMetricsDepth: 1,
+	})
+	defer cleanup(s, b, tmpPath)
+
+	puts := eventsByPrefixCounter.WithLabelValues("/metrics-test/a/", "PUT")
+	deletes := eventsByPrefixCounter.WithLabelValues("/metrics-test/a/", "DELETE")
+	wputs, wdeletes := testutil.ToFloat64(puts)+2, testutil.ToFloat64(deletes)+1
+
+	s.Put([]byte("/metrics-test/a/1"), []byte("v"), lease.NoLease)
+	s.Put([]byte("/metrics-test/a/2"), []byte("v"), lease.NoLease)
+	s.Put([]byte("/other/a/1"), []byte("v"), lease.NoLease)
+	txn := s.Write(traceutil.TODO())
+	txn.DeleteRange([]byte("/metrics-test/a/1"), nil)
+	txn.End()
+
+	if got := testutil.ToFloat64(puts); got != wputs {
+		t.Errorf("put events = %v, want %v", got, wputs)
+	}
+	if got := testutil.ToFloat64(deletes); got != wdeletes {
+		t.Errorf("delete events = %v, want %v", got, wdeletes)
+	}
+}
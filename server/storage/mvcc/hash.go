@@ -15,11 +15,13 @@
 package mvcc
 
 import (
+	"bytes"
 	"hash"
 	"hash/crc32"
 	"sort"
 	"sync"
 
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/server/v3/storage/backend"
 	"go.etcd.io/etcd/server/v3/storage/schema"
 	"go.uber.org/zap"
@@ -29,15 +31,68 @@ const (
 	hashStorageMaxSize = 10
 )
 
-func unsafeHashByRev(tx backend.ReadTx, compactRevision, revision int64, keep map[revision]struct{}) (KeyValueHash, error) {
+func unsafeHashByRev(tx backend.ReadTx, kb *keyBuckets, compactRevision, revision int64, keep map[revision]struct{}) (KeyValueHash, error) {
 	h := newKVHasher(compactRevision, revision, keep)
-	err := tx.UnsafeForEach(schema.Key, func(k, v []byte) error {
+	err := kb.unsafeForEach(tx, func(k, v []byte) error {
 		h.WriteKeyValue(k, v)
 		return nil
 	})
 	return h.Hash(), err
 }
 
+// unsafeHashRangeByRev is unsafeHashByRev restricted to the revisions of the
+// keys of the range [key, end), or of all keys if key is nil, leaving out the
+// purged revisions. Only the buckets storing the keys of the range are read.
+func unsafeHashRangeByRev(tx backend.ReadTx, kb *keyBuckets, key, end []byte, compactRevision, revision int64, keep map[revision]struct{}, purged []purgedRange) (KeyValueHash, error) {
+	h := newKVHasher(compactRevision, revision, keep)
+	var kv mvccpb.KeyValue
+	forEach := kb.unsafeForEach
+	if key != nil {
+		forEach = func(tx backend.ReadTx, visitor func(k, v []byte) error) error {
+			return kb.unsafeForEachInRange(tx, key, rangeBucketsEnd(end), visitor)
+		}
+	}
+	err := forEach(tx, func(k, v []byte) error {
+		if !h.hashed(k) {
+			return nil
+		}
+		kv.Reset()
+		if err := kv.Unmarshal(v); err != nil {
+			return err
+		}
+		if key != nil && !keyInRange(kv.Key, key, end) {
+			return nil
+		}
+		if purgedKey(purged, kv.Key, bytesToRev(k).main) {
+			return nil
+		}
+		h.WriteKeyValue(k, v)
+		return nil
+	})
+	return h.Hash(), err
+}
+
+// rangeBucketsEnd converts the end of a range request, empty for key alone,
+// to the end of the range given to rangeBuckets, nil for key alone.
+func rangeBucketsEnd(end []byte) []byte {
+	if len(end) == 0 {
+		return nil
+	}
+	return end
+}
+
+// keyInRange returns whether k is in the range [key, end), as given to a
+// range request: key alone if end is empty, from key on if end is "\x00".
+func keyInRange(k, key, end []byte) bool {
+	switch {
+	case len(end) == 0:
+		return bytes.Equal(k, key)
+	case len(end) == 1 && end[0] == 0:
+		return bytes.Compare(k, key) >= 0
+	}
+	return bytes.Compare(k, key) >= 0 && bytes.Compare(k, end) < 0
+}
+
 type kvHasher struct {
 	hash            hash.Hash32
 	compactRevision int64
@@ -57,21 +112,29 @@ func newKVHasher(compactRev, rev int64, keep map[revision]struct{}) kvHasher {
 }
 
 func (h *kvHasher) WriteKeyValue(k, v []byte) {
+	if !h.hashed(k) {
+		return
+	}
+	h.hash.Write(k)
+	h.hash.Write(v)
+}
+
+// hashed returns whether the revision of the given key is part of the hash.
+func (h *kvHasher) hashed(k []byte) bool {
 	kr := bytesToRev(k)
 	upper := revision{main: h.revision + 1}
 	if !upper.GreaterThan(kr) {
-		return
+		return false
 	}
 	lower := revision{main: h.compactRevision + 1}
 	// skip revisions that are scheduled for deletion
 	// due to compacting; don't skip if there isn't one.
 	if lower.GreaterThan(kr) && len(h.keep) > 0 {
 		if _, ok := h.keep[kr]; !ok {
-			return
+			return false
 		}
 	}
-	h.hash.Write(k)
-	h.hash.Write(v)
+	return true
 }
 
 func (h *kvHasher) Hash() KeyValueHash {
@@ -91,6 +154,10 @@ type HashStorage interface {
 	// HashByRev computes the hash of all MVCC revisions up to a given revision.
 	HashByRev(rev int64) (hash KeyValueHash, currentRev int64, err error)
 
+	// HashRangeByRev computes the hash of the MVCC revisions of the keys of the
+	// range [key, end) up to a given revision. Range hashes are not stored.
+	HashRangeByRev(key, end []byte, rev int64) (hash KeyValueHash, currentRev int64, err error)
+
 	// Store adds hash value in local cache, allowing it can be returned by HashByRev.
 	Store(valueHash KeyValueHash)
 
@@ -133,6 +200,10 @@ func (s *hashStorage) HashByRev(rev int64) (KeyValueHash, int64, error) {
 	return s.store.hashByRev(rev)
 }
 
+func (s *hashStorage) HashRangeByRev(key, end []byte, rev int64) (KeyValueHash, int64, error) {
+	return s.store.hashRangeByRev(key, end, rev)
+}
+
 func (s *hashStorage) Store(hash KeyValueHash) {
 	s.lg.Info("storing new hash",
 		zap.Uint32("hash", hash.Hash),
@@ -127,6 +127,43 @@ func testHashByRev(t *testing.T, s *store, rev int64) KeyValueHash {
 	return hash
 }
 
+// TestHashRangeByRev ensures the hash of a range only changes with the
+// revisions of its keys, and that of all keys matches the hash of the store.
+func TestHashRangeByRev(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+
+	s.Put([]byte("a/1"), []byte("1"), 0)
+	s.Put([]byte("b/1"), []byte("1"), 0)
+	s.Put([]byte("a/2"), []byte("2"), 0)
+	rev := s.Rev()
+	ha, _, err := s.hashRangeByRev([]byte("a/"), []byte("a0"), rev)
+	assert.NoError(t, err)
+	hb, _, err := s.hashRangeByRev([]byte("b/"), []byte("b0"), rev)
+	assert.NoError(t, err)
+	assert.NotEqual(t, ha.Hash, hb.Hash)
+
+	all, _, err := s.hashRangeByRev([]byte{0}, []byte{0}, rev)
+	assert.NoError(t, err)
+	want, _, err := s.hashByRev(rev)
+	assert.NoError(t, err)
+	assert.Equal(t, want, all)
+
+	s.Put([]byte("b/2"), []byte("2"), 0)
+	s.DeleteRange([]byte("b/1"), nil)
+	ha2, _, err := s.hashRangeByRev([]byte("a/"), []byte("a0"), s.Rev())
+	assert.NoError(t, err)
+	assert.Equal(t, ha.Hash, ha2.Hash, "range hash changed by writes out of the range")
+	hb2, _, err := s.hashRangeByRev([]byte("b/"), []byte("b0"), s.Rev())
+	assert.NoError(t, err)
+	assert.NotEqual(t, hb.Hash, hb2.Hash, "range hash not changed by writes in the range")
+
+	// a single key
+	h1, _, err := s.hashRangeByRev([]byte("a/1"), nil, rev)
+	assert.NoError(t, err)
+	assert.NotEqual(t, ha.Hash, h1.Hash)
+}
+
 // TODO: Change this to fuzz test
 func TestCompactionHash(t *testing.T) {
 	b, _ := betesting.NewDefaultTmpBackend(t)
@@ -26,10 +26,12 @@ type index interface {
 	Range(key, end []byte, atRev int64) ([][]byte, []revision)
 	Revisions(key, end []byte, atRev int64, limit int) ([]revision, int)
 	CountRevisions(key, end []byte, atRev int64) int
+	KeyCount() int
 	Put(key []byte, rev revision)
 	Tombstone(key []byte, rev revision) error
 	Compact(rev int64) map[revision]struct{}
 	Keep(rev int64) map[revision]struct{}
+	Purge(key, end []byte, atRev int64) []revision
 	Equal(b index) bool
 
 	Insert(ki *keyIndex)
@@ -155,6 +157,14 @@ func (ti *treeIndex) CountRevisions(key, end []byte, atRev int64) int {
 	return total
 }
 
+// KeyCount returns the number of keys in the index, including the deleted
+// keys not compacted yet.
+func (ti *treeIndex) KeyCount() int {
+	ti.RLock()
+	defer ti.RUnlock()
+	return ti.tree.Len()
+}
+
 func (ti *treeIndex) Range(key, end []byte, atRev int64) (keys [][]byte, revs []revision) {
 	ti.RLock()
 	defer ti.RUnlock()
@@ -225,6 +235,36 @@ func (ti *treeIndex) Keep(rev int64) map[revision]struct{} {
 	return available
 }
 
+// Purge removes the generations of the keys from key(included) to end(excluded)
+// deleted at or before atRev, and returns their revisions.
+func (ti *treeIndex) Purge(key, end []byte, atRev int64) []revision {
+	ti.Lock()
+	defer ti.Unlock()
+
+	var (
+		revs   []revision
+		purged []*keyIndex
+	)
+	f := func(ki *keyIndex) bool {
+		revs = append(revs, ki.purge(atRev)...)
+		if ki.isEmpty() {
+			purged = append(purged, ki)
+		}
+		return true
+	}
+	if end == nil {
+		if ki := ti.keyIndex(&keyIndex{key: key}); ki != nil {
+			f(ki)
+		}
+	} else {
+		ti.unsafeVisit(key, end, f)
+	}
+	for _, ki := range purged {
+		ti.tree.Delete(ki)
+	}
+	return revs
+}
+
 func (ti *treeIndex) Equal(bi index) bool {
 	b := bi.(*treeIndex)
 
@@ -15,6 +15,7 @@
 package mvcc
 
 import (
+	"runtime"
 	"testing"
 
 	"go.uber.org/zap"
@@ -41,6 +42,37 @@ func benchmarkIndexCompact(b *testing.B, size int) {
 	}
 }
 
+// BenchmarkIndexCompactMemory reports the memory held by the index per key
+// after compacting keys updated many times.
+func BenchmarkIndexCompactMemory(b *testing.B) {
+	log := zap.NewNop()
+
+	keysN, revsN := 10000, 100
+	keys := createBytesSlice(64, keysN)
+	var ms runtime.MemStats
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&ms)
+		before := ms.HeapAlloc
+
+		kvindex := newTreeIndex(log)
+		main := int64(1)
+		for j := 0; j < revsN; j++ {
+			for _, key := range keys {
+				kvindex.Put(key, revision{main: main})
+				main++
+			}
+		}
+		kvindex.Compact(main - 1)
+
+		runtime.GC()
+		runtime.ReadMemStats(&ms)
+		b.ReportMetric(float64(ms.HeapAlloc-before)/float64(keysN), "bytes/key")
+		runtime.KeepAlive(kvindex)
+	}
+}
+
 func BenchmarkIndexPut(b *testing.B) {
 	log := zap.NewNop()
 	kvindex := newTreeIndex(log)
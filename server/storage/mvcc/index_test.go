@@ -139,6 +139,43 @@ func TestIndexTombstone(t *testing.T) {
 	}
 }
 
+func TestIndexPurge(t *testing.T) {
+	ti := newTreeIndex(zaptest.NewLogger(t))
+	ti.Put([]byte("foo"), revision{main: 1})
+	ti.Tombstone([]byte("foo"), revision{main: 2})
+	ti.Put([]byte("foo1"), revision{main: 3})
+	ti.Put([]byte("foo2"), revision{main: 4})
+	ti.Tombstone([]byte("foo2"), revision{main: 5})
+	ti.Put([]byte("foo2"), revision{main: 6})
+	ti.Put([]byte("zoo"), revision{main: 7})
+	ti.Tombstone([]byte("zoo"), revision{main: 8})
+
+	revs := ti.Purge([]byte("foo"), []byte("fop"), 8)
+	wrevs := []revision{{main: 1}, {main: 2}, {main: 4}, {main: 5}}
+	if !reflect.DeepEqual(revs, wrevs) {
+		t.Errorf("revs = %+v, want %+v", revs, wrevs)
+	}
+	if ti.KeyIndex(&keyIndex{key: []byte("foo")}) != nil {
+		t.Errorf("keyIndex of deleted key %q is kept", "foo")
+	}
+	if _, _, _, err := ti.Get([]byte("foo2"), 8); err != nil {
+		t.Errorf("get error = %v, want nil", err)
+	}
+
+	revs = ti.Purge([]byte("zoo"), nil, 7)
+	if len(revs) != 0 {
+		t.Errorf("revs = %+v, want none before the tombstone", revs)
+	}
+	revs = ti.Purge([]byte("zoo"), nil, 8)
+	wrevs = []revision{{main: 7}, {main: 8}}
+	if !reflect.DeepEqual(revs, wrevs) {
+		t.Errorf("revs = %+v, want %+v", revs, wrevs)
+	}
+	if ti.KeyIndex(&keyIndex{key: []byte("zoo")}) != nil {
+		t.Errorf("keyIndex of deleted key %q is kept", "zoo")
+	}
+}
+
 func TestIndexRevision(t *testing.T) {
 	allKeys := [][]byte{[]byte("foo"), []byte("foo1"), []byte("foo2"), []byte("foo2"), []byte("foo1"), []byte("foo")}
 	allRevs := []revision{{main: 1}, {main: 2}, {main: 3}, {main: 4}, {main: 5}, {main: 6}}
@@ -0,0 +1,354 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"bytes"
+	"math"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+// keyBuckets maps keys to the backend buckets storing their revisions.
+// The keys with one of the configured prefixes are stored in a bucket of
+// their own, the longest matching prefix winning, and the other keys in
+// schema.Key. Reads by revision range merge the buckets in revision order,
+// so that the layout is invisible to hashes and watchers. Compactions read
+// all the buckets that way, while the hashes of key ranges only read the
+// buckets storing the keys of their range.
+type keyBuckets struct {
+	// prefixes is sorted longest first; the keys with prefixes[i]
+	// are stored in buckets[i+1].
+	prefixes [][]byte
+	// buckets[0] is schema.Key.
+	buckets []backend.Bucket
+
+	revisions  []prometheus.Gauge
+	sizes      []prometheus.Gauge
+	tombstones []prometheus.Gauge
+}
+
+func newKeyBuckets(prefixes []string) *keyBuckets {
+	kb := &keyBuckets{prefixes: sortKeyPrefixes(prefixes)}
+	kb.buckets = append(kb.buckets, schema.Key)
+	for i, p := range kb.prefixes {
+		kb.buckets = append(kb.buckets, schema.KeyPrefixBucket(i, p))
+	}
+	for _, b := range kb.buckets {
+		kb.revisions = append(kb.revisions, keyBucketRevisionsGauge.WithLabelValues(b.String()))
+		kb.sizes = append(kb.sizes, keyBucketSizeGauge.WithLabelValues(b.String()))
+		kb.tombstones = append(kb.tombstones, keyBucketTombstonesGauge.WithLabelValues(b.String()))
+	}
+	return kb
+}
+
+// sortKeyPrefixes returns the distinct non-empty prefixes, longest first.
+func sortKeyPrefixes(prefixes []string) [][]byte {
+	set := make(map[string]struct{})
+	var ps [][]byte
+	for _, p := range prefixes {
+		if _, ok := set[p]; ok || len(p) == 0 {
+			continue
+		}
+		set[p] = struct{}{}
+		ps = append(ps, []byte(p))
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		if len(ps[i]) != len(ps[j]) {
+			return len(ps[i]) > len(ps[j])
+		}
+		return bytes.Compare(ps[i], ps[j]) < 0
+	})
+	return ps
+}
+
+// bucketIndex returns the index in kb.buckets of the bucket storing key.
+func (kb *keyBuckets) bucketIndex(key []byte) int {
+	for i, p := range kb.prefixes {
+		if bytes.HasPrefix(key, p) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// rangeBuckets returns the buckets storing the keys in [key, end), or key
+// alone if end is nil. An empty end means all the keys from key on.
+func (kb *keyBuckets) rangeBuckets(key, end []byte) []backend.Bucket {
+	if len(kb.prefixes) == 0 {
+		return kb.buckets
+	}
+	if end == nil {
+		return []backend.Bucket{kb.buckets[kb.bucketIndex(key)]}
+	}
+	fromKey := len(end) == 0 || bytes.Equal(end, []byte{0})
+	var (
+		bs     []backend.Bucket
+		inside bool
+	)
+	for i, p := range kb.prefixes {
+		pend := getPrefixEnd(p)
+		if (pend != nil && bytes.Compare(key, pend) >= 0) || (!fromKey && bytes.Compare(p, end) >= 0) {
+			continue
+		}
+		bs = append(bs, kb.buckets[i+1])
+		if bytes.Compare(key, p) >= 0 && (pend == nil || (!fromKey && bytes.Compare(end, pend) <= 0)) {
+			inside = true
+		}
+	}
+	if !inside {
+		bs = append(bs, kb.buckets[0])
+	}
+	return bs
+}
+
+// getPrefixEnd returns the end of the range of the keys with the given
+// prefix, or nil if there is none.
+func getPrefixEnd(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] < 0xff {
+			end := make([]byte, i+1)
+			copy(end, prefix)
+			end[i]++
+			return end
+		}
+	}
+	return nil
+}
+
+// unsafeGet returns the value stored at the given revision in one of bs.
+func (kb *keyBuckets) unsafeGet(tx backend.ReadTx, bs []backend.Bucket, revBytes []byte) [][]byte {
+	for _, b := range bs {
+		if _, vs := tx.UnsafeRange(b, revBytes, nil, 0); len(vs) != 0 {
+			return vs
+		}
+	}
+	return nil
+}
+
+// unsafeRange returns the entries of all buckets with revisions in [min, max),
+// at most limit if positive, in revision order. idx holds the index in
+// kb.buckets of the bucket of each entry, or is nil if all are in schema.Key.
+func (kb *keyBuckets) unsafeRange(tx backend.ReadTx, min, max []byte, limit int64) (keys, vals [][]byte, idx []int) {
+	if len(kb.prefixes) == 0 {
+		keys, vals = tx.UnsafeRange(schema.Key, min, max, limit)
+		return keys, vals, nil
+	}
+	return unsafeMergeRange(tx, kb.buckets, min, max, limit)
+}
+
+// unsafeMergeRange returns the entries of bs with revisions in [min, max),
+// at most limit if positive, in revision order. idx holds the index in bs
+// of the bucket of each entry.
+func unsafeMergeRange(tx backend.ReadTx, bs []backend.Bucket, min, max []byte, limit int64) (keys, vals [][]byte, idx []int) {
+	bkeys := make([][][]byte, len(bs))
+	bvals := make([][][]byte, len(bs))
+	for i, b := range bs {
+		bkeys[i], bvals[i] = tx.UnsafeRange(b, min, max, limit)
+	}
+	for limit <= 0 || int64(len(keys)) < limit {
+		next := -1
+		for i := range bkeys {
+			if len(bkeys[i]) != 0 && (next == -1 || bytes.Compare(bkeys[i][0], bkeys[next][0]) < 0) {
+				next = i
+			}
+		}
+		if next == -1 {
+			break
+		}
+		keys, vals, idx = append(keys, bkeys[next][0]), append(vals, bvals[next][0]), append(idx, next)
+		bkeys[next], bvals[next] = bkeys[next][1:], bvals[next][1:]
+	}
+	return keys, vals, idx
+}
+
+// entryBucket returns the index of the bucket of the i-th entry
+// returned by unsafeRange along with idx.
+func entryBucket(idx []int, i int) int {
+	if idx == nil {
+		return 0
+	}
+	return idx[i]
+}
+
+// unsafeForEach calls visitor with the entries of all buckets in revision order.
+func (kb *keyBuckets) unsafeForEach(tx backend.ReadTx, visitor func(k, v []byte) error) error {
+	if len(kb.prefixes) == 0 {
+		return tx.UnsafeForEach(schema.Key, visitor)
+	}
+	return unsafeMergeForEach(tx, kb.buckets, visitor)
+}
+
+// unsafeForEachInRange calls visitor in revision order with the entries of
+// the buckets storing the keys in [key, end), as given to rangeBuckets, so
+// that the ranges of the key prefixes are visited without scanning the
+// revisions of the other keys.
+func (kb *keyBuckets) unsafeForEachInRange(tx backend.ReadTx, key, end []byte, visitor func(k, v []byte) error) error {
+	bs := kb.rangeBuckets(key, end)
+	if len(bs) == 1 {
+		return tx.UnsafeForEach(bs[0], visitor)
+	}
+	return unsafeMergeForEach(tx, bs, visitor)
+}
+
+func unsafeMergeForEach(tx backend.ReadTx, bs []backend.Bucket, visitor func(k, v []byte) error) error {
+	min, max := newRevBytes(), newRevBytes()
+	revToBytes(revision{main: math.MaxInt64, sub: math.MaxInt64}, max)
+	for {
+		keys, vals, _ := unsafeMergeRange(tx, bs, min, max, int64(restoreChunkKeys))
+		for i := range keys {
+			if err := visitor(keys[i], vals[i]); err != nil {
+				return err
+			}
+		}
+		if len(keys) < restoreChunkKeys {
+			return nil
+		}
+		min = append(append(min[:0:0], keys[len(keys)-1]...), 0)
+	}
+}
+
+// unsafeSeqPut stores the revision of key in its bucket.
+func (kb *keyBuckets) unsafeSeqPut(tx backend.BatchTx, key, revBytes, value []byte) {
+	i := kb.bucketIndex(key)
+	tx.UnsafeSeqPut(kb.buckets[i], revBytes, value)
+	kb.added(i, revBytes, value)
+}
+
+// unsafeDeleteRevision deletes the given revision from its bucket, and
+// reports whether it was found.
+func (kb *keyBuckets) unsafeDeleteRevision(tx backend.BatchTx, rev revision) bool {
+	min, max := newRevBytes(), newRevBytes()
+	revToBytes(rev, min)
+	revToBytes(revision{main: rev.main, sub: rev.sub + 1}, max)
+	for i, b := range kb.buckets {
+		keys, vals := tx.UnsafeRange(b, min, max, 0)
+		for j := range keys {
+			kb.deleted(i, keys[j], vals[j])
+			tx.UnsafeDelete(b, keys[j])
+		}
+		if len(keys) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// added reports a revision stored in the i-th bucket.
+func (kb *keyBuckets) added(i int, revBytes, value []byte) {
+	kb.revisions[i].Inc()
+	kb.sizes[i].Add(float64(len(revBytes) + len(value)))
+	if isTombstone(revBytes) {
+		kb.tombstones[i].Inc()
+	}
+}
+
+// deleted reports a revision deleted from the i-th bucket.
+func (kb *keyBuckets) deleted(i int, revBytes, value []byte) {
+	kb.revisions[i].Dec()
+	kb.sizes[i].Sub(float64(len(revBytes) + len(value)))
+	if isTombstone(revBytes) {
+		kb.tombstones[i].Dec()
+	}
+}
+
+func (kb *keyBuckets) resetStats() {
+	for i := range kb.buckets {
+		kb.revisions[i].Set(0)
+		kb.sizes[i].Set(0)
+		kb.tombstones[i].Set(0)
+	}
+}
+
+// unsafeMove creates the buckets and moves the revisions stored in the
+// bucket of another prefix since the prefixes changed.
+func (kb *keyBuckets) unsafeMove(lg *zap.Logger, tx backend.BatchTx) {
+	for _, b := range kb.buckets {
+		tx.UnsafeCreateBucket(b)
+	}
+	stored := UnsafeReadKeyPrefixBuckets(tx)
+	if equalKeyPrefixes(stored, kb.prefixes) {
+		return
+	}
+
+	// the buckets of the former prefixes follow the current ones
+	from := append([]backend.Bucket{}, kb.buckets...)
+	for _, p := range stored {
+		found := false
+		for _, cp := range kb.prefixes {
+			found = found || bytes.Equal(p, cp)
+		}
+		if !found {
+			from = append(from, schema.KeyPrefixBucket(len(from)-1, p))
+		}
+	}
+
+	moved := 0
+	max := newRevBytes()
+	revToBytes(revision{main: math.MaxInt64, sub: math.MaxInt64}, max)
+	for i, b := range from {
+		min := newRevBytes()
+		for {
+			keys, vals := tx.UnsafeRange(b, min, max, int64(restoreChunkKeys))
+			for j := range keys {
+				var kv mvccpb.KeyValue
+				if err := kv.Unmarshal(vals[j]); err != nil {
+					lg.Fatal("failed to unmarshal mvccpb.KeyValue", zap.Error(err))
+				}
+				if to := kb.bucketIndex(kv.Key); to != i {
+					tx.UnsafePut(kb.buckets[to], keys[j], vals[j])
+					tx.UnsafeDelete(b, keys[j])
+					moved++
+				}
+			}
+			if len(keys) < restoreChunkKeys {
+				break
+			}
+			min = append(append(min[:0:0], keys[len(keys)-1]...), 0)
+		}
+		if i >= len(kb.buckets) {
+			tx.UnsafeDeleteBucket(b)
+		}
+	}
+	UnsafeSetKeyPrefixBuckets(tx, kb.prefixes)
+
+	prefixes := make([]string, 0, len(kb.prefixes))
+	for _, p := range kb.prefixes {
+		prefixes = append(prefixes, string(p))
+	}
+	lg.Info(
+		"moved revisions to the buckets of the key prefixes",
+		zap.Strings("key-prefixes", prefixes),
+		zap.Int("moved-revisions", moved),
+	)
+}
+
+func equalKeyPrefixes(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+func TestKeyBucketsRangeBuckets(t *testing.T) {
+	kb := newKeyBuckets([]string{"a/", "a/b/", "a/"})
+	tests := []struct {
+		key, end []byte
+		wbuckets []string
+	}{
+		{[]byte("a/x"), nil, []string{"key/a/"}},
+		{[]byte("a/b/x"), nil, []string{"key/a/b/"}},
+		{[]byte("c"), nil, []string{"key"}},
+		{[]byte("a/"), []byte("a0"), []string{"key/a/b/", "key/a/"}},
+		{[]byte("a/c"), []byte("a/d"), []string{"key/a/"}},
+		{[]byte("a"), []byte("a/c"), []string{"key/a/b/", "key/a/", "key"}},
+		{[]byte("b"), []byte("c"), []string{"key"}},
+		{[]byte("a/b/"), []byte{0}, []string{"key/a/b/", "key/a/", "key"}},
+		{[]byte("a/c"), []byte{}, []string{"key/a/", "key"}},
+	}
+	for i, tt := range tests {
+		var names []string
+		for _, b := range kb.rangeBuckets(tt.key, tt.end) {
+			names = append(names, b.String())
+		}
+		if !reflect.DeepEqual(names, tt.wbuckets) {
+			t.Errorf("#%d: buckets of [%q, %q) = %v, want %v", i, tt.key, tt.end, names, tt.wbuckets)
+		}
+	}
+}
+
+func TestStoreKeyPrefixBuckets(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, b)
+	s := NewStore(lg, b, &lease.FakeLessor{}, StoreConfig{KeyPrefixBuckets: []string{"a/"}})
+	wb, _ := betesting.NewDefaultTmpBackend(t)
+	defer betesting.Close(t, wb)
+	ws := NewStore(lg, wb, &lease.FakeLessor{}, StoreConfig{})
+	defer ws.Close()
+
+	for _, st := range []*store{s, ws} {
+		st.Put([]byte("a/1"), []byte("bar"), lease.NoLease)
+		st.Put([]byte("b/1"), []byte("bar"), lease.NoLease)
+		st.Put([]byte("a/2"), []byte("bar"), lease.NoLease)
+		st.Put([]byte("a/1"), []byte("baz"), lease.NoLease)
+		st.DeleteRange([]byte("b/1"), nil)
+		st.Put([]byte("b/2"), []byte("bar"), lease.NoLease)
+		st.Put([]byte("a/3"), []byte("bar"), lease.NoLease)
+	}
+
+	tx := b.ReadTx()
+	tx.RLock()
+	min, max := newTestRevBytes(revision{}), newTestRevBytes(revision{main: math.MaxInt64})
+	akeys, _ := tx.UnsafeRange(schema.KeyPrefixBucket(0, []byte("a/")), min, max, 0)
+	keys, _ := tx.UnsafeRange(schema.Key, min, max, 0)
+	mkeys, _, idx := s.kb.unsafeRange(tx, min, max, 5)
+	tx.RUnlock()
+	if len(akeys) != 4 || len(keys) != 3 {
+		t.Fatalf("expected 4 revisions in the prefix bucket and 3 in the key bucket, got %d and %d", len(akeys), len(keys))
+	}
+	var mains []int64
+	for _, k := range mkeys {
+		mains = append(mains, bytesToRev(k).main)
+	}
+	if wmains, widx := []int64{2, 3, 4, 5, 6}, []int{1, 0, 1, 1, 0}; !reflect.DeepEqual(mains, wmains) || !reflect.DeepEqual(idx, widx) {
+		t.Fatalf("merged revisions = %v in buckets %v, want %v in buckets %v", mains, idx, wmains, widx)
+	}
+
+	checkSameStore := func(step string) {
+		t.Helper()
+		for _, rev := range []int64{0, 4} {
+			r, err := s.Range(context.TODO(), []byte{0}, []byte{}, RangeOptions{Rev: rev})
+			if err != nil {
+				t.Fatal(err)
+			}
+			wr, err := ws.Range(context.TODO(), []byte{0}, []byte{}, RangeOptions{Rev: rev})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(wr.KVs) == 0 || !reflect.DeepEqual(r.KVs, wr.KVs) {
+				t.Errorf("%s: range at %d = %+v, want %+v", step, rev, r.KVs, wr.KVs)
+			}
+		}
+		h, _, err := s.hashByRev(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wh, _, err := ws.hashByRev(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h != wh {
+			t.Errorf("%s: hash = %+v, want %+v", step, h, wh)
+		}
+		// the range hashes only read the buckets of the range
+		for _, r := range [][2]string{{"a/", "a0"}, {"a/1", ""}, {"a/2", "b/2"}, {"b/", "\x00"}} {
+			h, _, err := s.hashRangeByRev([]byte(r[0]), []byte(r[1]), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wh, _, err := ws.hashRangeByRev([]byte(r[0]), []byte(r[1]), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h != wh {
+				t.Errorf("%s: hash of [%q, %q) = %+v, want %+v", step, r[0], r[1], h, wh)
+			}
+		}
+	}
+	checkSameStore("put")
+
+	for _, st := range []*store{s, ws} {
+		done, err := st.Compact(traceutil.TODO(), 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-done
+	}
+	checkSameStore("compact")
+
+	for _, prefixes := range [][]string{{"b/", "a/"}, {"b/"}, nil} {
+		s.Close()
+		s = NewStore(lg, b, &lease.FakeLessor{}, StoreConfig{KeyPrefixBuckets: prefixes})
+		checkSameStore(fmt.Sprintf("prefixes %q", prefixes))
+	}
+	defer s.Close()
+
+	tx = b.ReadTx()
+	tx.RLock()
+	stored := UnsafeReadKeyPrefixBuckets(tx)
+	tx.RUnlock()
+	if stored != nil {
+		t.Errorf("stored key prefixes = %q, want none", stored)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// keyExpiryRetryInterval is how long an expired key is not returned again by
+// ExpiredKeys, giving the deletion of the key time to be applied.
+var keyExpiryRetryInterval = 3 * time.Second // non-const for testing
+
+// ExpiredKey is a key whose TTL expired.
+type ExpiredKey struct {
+	Key []byte
+	// ModRevision is the revision of the put that set the TTL of the key.
+	// Deleting the key only if it was not modified since keeps a new put of
+	// the key from being deleted along.
+	ModRevision int64
+}
+
+// keyExpiry indexes the keys put with a TTL by the time they expire.
+//
+// The deadlines are computed from the local clock when the puts are applied,
+// or when the store is restored, like the expiry of leases. They may thus
+// differ slightly between members, which is why the expired keys are only
+// hidden from the reads of a member, and deleted through the raft log.
+type keyExpiry struct {
+	mu   sync.Mutex
+	keys map[string]*expiringKey
+	q    expiringKeyQueue
+
+	now func() time.Time
+}
+
+type expiringKey struct {
+	key string
+	rev int64
+
+	deadline time.Time
+	// next is the time the key is returned next by expired.
+	next time.Time
+	// index is the index of the key in the queue.
+	index int
+}
+
+func newKeyExpiry() *keyExpiry {
+	return &keyExpiry{keys: make(map[string]*expiringKey), now: time.Now}
+}
+
+// set sets the TTL of the key put at rev.
+func (e *keyExpiry) set(key string, rev, ttl int64) {
+	deadline := e.now().Add(time.Duration(ttl) * time.Second)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if k, ok := e.keys[key]; ok {
+		k.rev, k.deadline, k.next = rev, deadline, deadline
+		heap.Fix(&e.q, k.index)
+		return
+	}
+	k := &expiringKey{key: key, rev: rev, deadline: deadline, next: deadline}
+	e.keys[key] = k
+	heap.Push(&e.q, k)
+}
+
+// remove forgets the TTL of the key, if any.
+func (e *keyExpiry) remove(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if k, ok := e.keys[key]; ok {
+		heap.Remove(&e.q, k.index)
+		delete(e.keys, key)
+	}
+}
+
+// reset forgets all TTLs.
+func (e *keyExpiry) reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.keys = make(map[string]*expiringKey)
+	e.q = nil
+}
+
+// isExpired returns true if the key put at rev expired.
+func (e *keyExpiry) isExpired(key []byte, rev int64) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	k, ok := e.keys[string(key)]
+	return ok && k.rev == rev && !e.now().Before(k.deadline)
+}
+
+// expired returns up to limit expired keys, the longest expired first. The
+// returned keys are only returned again after keyExpiryRetryInterval.
+func (e *keyExpiry) expired(limit int) []ExpiredKey {
+	now := e.now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var keys []ExpiredKey
+	for len(e.q) > 0 && len(keys) < limit {
+		k := e.q[0]
+		if now.Before(k.next) {
+			break
+		}
+		keys = append(keys, ExpiredKey{Key: []byte(k.key), ModRevision: k.rev})
+		k.next = now.Add(keyExpiryRetryInterval)
+		heap.Fix(&e.q, 0)
+	}
+	return keys
+}
+
+// len returns the number of keys with a TTL.
+func (e *keyExpiry) len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.keys)
+}
+
+// expiringKeyQueue is a min-heap of keys ordered by the time they are returned
+// next by expired.
+type expiringKeyQueue []*expiringKey
+
+func (q expiringKeyQueue) Len() int           { return len(q) }
+func (q expiringKeyQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+
+func (q expiringKeyQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *expiringKeyQueue) Push(x interface{}) {
+	k := x.(*expiringKey)
+	k.index = len(*q)
+	*q = append(*q, k)
+}
+
+func (q *expiringKeyQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	k := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return k
+}
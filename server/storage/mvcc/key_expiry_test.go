@@ -0,0 +1,122 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestKeyExpiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	e := newKeyExpiry()
+	e.now = func() time.Time { return now }
+
+	e.set("foo", 2, 10)
+	e.set("bar", 3, 5)
+	e.set("baz", 4, 20)
+	e.remove("baz")
+	if e.len() != 2 {
+		t.Fatalf("len = %d, want 2", e.len())
+	}
+
+	now = now.Add(5 * time.Second)
+	if !e.isExpired([]byte("bar"), 3) {
+		t.Error("bar is not expired")
+	}
+	if e.isExpired([]byte("bar"), 2) {
+		t.Error("bar put at another revision is expired")
+	}
+	if e.isExpired([]byte("foo"), 2) {
+		t.Error("foo is expired")
+	}
+
+	now = now.Add(10 * time.Second)
+	keys := e.expired(10)
+	wkeys := []ExpiredKey{{Key: []byte("bar"), ModRevision: 3}, {Key: []byte("foo"), ModRevision: 2}}
+	if !reflect.DeepEqual(keys, wkeys) {
+		t.Errorf("expired = %+v, want %+v", keys, wkeys)
+	}
+	// the keys are not returned again until the retry interval
+	if keys = e.expired(10); len(keys) != 0 {
+		t.Errorf("expired = %+v, want none", keys)
+	}
+	now = now.Add(keyExpiryRetryInterval)
+	if keys = e.expired(1); len(keys) != 1 {
+		t.Errorf("expired = %+v, want 1 key", keys)
+	}
+
+	// a new put resets the ttl
+	e.set("foo", 5, 10)
+	if e.isExpired([]byte("foo"), 5) {
+		t.Error("foo put again is expired")
+	}
+}
+
+func TestStoreKeyTTL(t *testing.T) {
+	b, tmpPath := betesting.NewDefaultTmpBackend(t)
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer cleanup(s, b, tmpPath)
+	now := time.Unix(0, 0)
+	s.expiry.now = func() time.Time { return now }
+
+	s.PutWithTTL([]byte("foo"), []byte("bar"), nil, lease.NoLease, 10)
+	s.PutWithTTL([]byte("foo1"), []byte("bar"), nil, lease.NoLease, 10)
+	s.Put([]byte("foo2"), []byte("bar"), lease.NoLease)
+	// a put without ttl clears it
+	s.Put([]byte("foo1"), []byte("bar1"), lease.NoLease)
+
+	now = now.Add(10 * time.Second)
+	r, err := s.Range(context.TODO(), []byte("foo"), []byte("fop"), RangeOptions{HideExpired: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.KVs) != 2 || r.Count != 2 || string(r.KVs[0].Key) != "foo1" {
+		t.Errorf("range = %+v, want foo1 and foo2", r)
+	}
+	// the expired key is still seen until it is deleted
+	r, err = s.Range(context.TODO(), []byte("foo"), nil, RangeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.KVs) != 1 || r.KVs[0].Ttl != 10 {
+		t.Errorf("range = %+v, want foo with ttl 10", r.KVs)
+	}
+
+	keys := s.ExpiredKeys(10)
+	if len(keys) != 1 || string(keys[0].Key) != "foo" || keys[0].ModRevision != 2 {
+		t.Fatalf("expired keys = %+v, want foo at revision 2", keys)
+	}
+
+	// the ttl restarts from the restore
+	s.Restore(b)
+	if s.expiry.len() != 1 {
+		t.Fatalf("restored %d keys with ttl, want 1", s.expiry.len())
+	}
+	if r, _ = s.Range(context.TODO(), []byte("foo"), nil, RangeOptions{HideExpired: true}); len(r.KVs) != 1 {
+		t.Errorf("range = %+v, want foo", r.KVs)
+	}
+
+	s.DeleteRange([]byte("foo"), nil)
+	if s.expiry.len() != 0 {
+		t.Errorf("%d keys with ttl after the deletion, want 0", s.expiry.len())
+	}
+}
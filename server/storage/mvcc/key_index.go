@@ -217,9 +217,11 @@ func (ki *keyIndex) compact(lg *zap.Logger, atRev int64, available map[revision]
 
 	g := &ki.generations[genIdx]
 	if !g.isEmpty() {
-		// remove the previous contents.
-		if revIndex != -1 {
-			g.revs = g.revs[revIndex:]
+		// remove the previous contents. The remaining revisions are copied,
+		// as the array behind g.revs would otherwise keep the removed ones
+		// in memory until the generation grows again.
+		if revIndex > 0 {
+			g.revs = append(make([]revision, 0, len(g.revs)-revIndex), g.revs[revIndex:]...)
 		}
 		// remove any tombstone
 		if len(g.revs) == 1 && genIdx != len(ki.generations)-1 {
@@ -229,7 +231,9 @@ func (ki *keyIndex) compact(lg *zap.Logger, atRev int64, available map[revision]
 	}
 
 	// remove the previous generations.
-	ki.generations = ki.generations[genIdx:]
+	if genIdx > 0 {
+		ki.generations = append(make([]generation, 0, len(ki.generations)-genIdx), ki.generations[genIdx:]...)
+	}
 }
 
 // keep finds the revision to be kept if compact is called at given atRev.
@@ -248,6 +252,24 @@ func (ki *keyIndex) keep(atRev int64, available map[revision]struct{}) {
 	}
 }
 
+// purge removes the generations deleted at or before atRev, and returns
+// their revisions.
+func (ki *keyIndex) purge(atRev int64) []revision {
+	var revs []revision
+	genIdx := 0
+	for ; genIdx < len(ki.generations)-1; genIdx++ {
+		g := ki.generations[genIdx]
+		if g.revs[len(g.revs)-1].main > atRev {
+			break
+		}
+		revs = append(revs, g.revs...)
+	}
+	if genIdx > 0 {
+		ki.generations = append(make([]generation, 0, len(ki.generations)-genIdx), ki.generations[genIdx:]...)
+	}
+	return revs
+}
+
 func (ki *keyIndex) doCompact(atRev int64, available map[revision]struct{}) (genIdx int, revIndex int) {
 	// walk until reaching the first revision smaller or equal to "atRev",
 	// and add the revision to the available map
@@ -556,6 +556,74 @@ func TestKeyIndexCompactOnFurtherRev(t *testing.T) {
 	}
 }
 
+// TestKeyIndexCompactReleasesRevisions ensures that compaction does not keep
+// the removed revisions and generations in the arrays backing the keyIndex.
+func TestKeyIndexCompactReleasesRevisions(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	ki := &keyIndex{key: []byte("foo")}
+	main := int64(1)
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			ki.put(lg, main, 0)
+			main++
+		}
+		ki.tombstone(lg, main, 0)
+		main++
+	}
+	for i := 0; i < 100; i++ {
+		ki.put(lg, main, 0)
+		main++
+	}
+
+	ki.compact(lg, main-1, make(map[revision]struct{}))
+	if len(ki.generations) != 1 || cap(ki.generations) != 1 {
+		t.Fatalf("len(generations), cap(generations) = %d, %d, want 1, 1", len(ki.generations), cap(ki.generations))
+	}
+	if revs := ki.generations[0].revs; len(revs) != 1 || cap(revs) != 1 {
+		t.Fatalf("len(revs), cap(revs) = %d, %d, want 1, 1", len(revs), cap(revs))
+	}
+
+	ki.put(lg, main, 0)
+	if revs := ki.generations[0].revs; len(revs) != 2 || revs[1].main != main {
+		t.Fatalf("revs = %+v, want 2 revisions ending at %d", revs, main)
+	}
+}
+
+func TestKeyIndexPurge(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	ki := &keyIndex{key: []byte("foo")}
+	ki.put(lg, 1, 0)
+	ki.put(lg, 2, 0)
+	ki.tombstone(lg, 3, 0)
+	ki.put(lg, 4, 0)
+	ki.tombstone(lg, 5, 0)
+	ki.put(lg, 6, 0)
+
+	tests := []struct {
+		rev int64
+
+		wrevs []revision
+		wgens int
+	}{
+		{2, nil, 3},
+		{4, []revision{{main: 1}, {main: 2}, {main: 3}}, 2},
+		{4, nil, 2},
+		{10, []revision{{main: 4}, {main: 5}}, 1},
+	}
+	for i, tt := range tests {
+		revs := ki.purge(tt.rev)
+		if !reflect.DeepEqual(revs, tt.wrevs) {
+			t.Errorf("#%d: revs = %+v, want %+v", i, revs, tt.wrevs)
+		}
+		if len(ki.generations) != tt.wgens {
+			t.Errorf("#%d: len(generations) = %d, want %d", i, len(ki.generations), tt.wgens)
+		}
+	}
+	if _, _, _, err := ki.get(lg, 6); err != nil {
+		t.Errorf("get error = %v, want nil", err)
+	}
+}
+
 func TestKeyIndexIsEmpty(t *testing.T) {
 	tests := []struct {
 		ki *keyIndex
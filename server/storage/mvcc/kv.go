@@ -27,6 +27,15 @@ type RangeOptions struct {
 	Limit int64
 	Rev   int64
 	Count bool
+	// HideExpired leaves the keys whose TTL expired out of the ranges at the
+	// current revision, until their deletion is applied. The expiry times are
+	// local to the member, so it must not be set by the ranges of the txns
+	// applied from the raft log.
+	HideExpired bool
+	// NewKV returns the key-values RangeFunc reads the range into, reusing the
+	// capacity of their key, value and metadata. The key-values are allocated
+	// if it is nil.
+	NewKV func() *mvccpb.KeyValue
 }
 
 type RangeResult struct {
@@ -53,6 +62,13 @@ type ReadView interface {
 	// Limit limits the number of keys returned.
 	// If the required rev is compacted, ErrCompacted will be returned.
 	Range(ctx context.Context, key, end []byte, ro RangeOptions) (r *RangeResult, err error)
+
+	// RangeFunc gets the keys in the range like Range, but instead of returning
+	// the key-value pairs, passes them to f one at a time in key order as they
+	// are read from the backend, until f returns false. f owns the key-value
+	// pairs it is passed and must not call back into the KV. The returned
+	// result holds no key-value pairs.
+	RangeFunc(ctx context.Context, key, end []byte, ro RangeOptions, f func(kv *mvccpb.KeyValue) bool) (r *RangeResult, err error)
 }
 
 // TxnRead represents a read-only transaction with operations that will not
@@ -79,6 +95,13 @@ type WriteView interface {
 	// A put also increases the rev of the store, and generates one event in the event history.
 	// The returned rev is the current revision of the KV when the operation is executed.
 	Put(key, value []byte, lease lease.LeaseID) (rev int64)
+
+	// PutWithMetadata is Put that also stores the given metadata with the key-value pair.
+	PutWithMetadata(key, value, metadata []byte, lease lease.LeaseID) (rev int64)
+
+	// PutWithTTL is PutWithMetadata that also sets the TTL of the key, in
+	// seconds. The key expires ttl seconds after the put, unless ttl is 0.
+	PutWithTTL(key, value, metadata []byte, lease lease.LeaseID, ttl int64) (rev int64)
 }
 
 // TxnWrite represents a transaction that can modify the store.
@@ -96,6 +119,12 @@ func (trw *txnReadWrite) DeleteRange(key, end []byte) (n, rev int64) { panic("un
 func (trw *txnReadWrite) Put(key, value []byte, lease lease.LeaseID) (rev int64) {
 	panic("unexpected Put")
 }
+func (trw *txnReadWrite) PutWithMetadata(key, value, metadata []byte, lease lease.LeaseID) (rev int64) {
+	panic("unexpected Put")
+}
+func (trw *txnReadWrite) PutWithTTL(key, value, metadata []byte, lease lease.LeaseID, ttl int64) (rev int64) {
+	panic("unexpected Put")
+}
 func (trw *txnReadWrite) Changes() []mvccpb.KeyValue { return nil }
 
 func NewReadOnlyTxnWrite(txn TxnRead) TxnWrite { return &txnReadWrite{txn} }
@@ -122,9 +151,22 @@ type KV interface {
 	// HashStorage returns HashStorage interface for KV storage.
 	HashStorage() HashStorage
 
+	// KeyCount returns the number of keys of the store, including the
+	// deleted keys not compacted yet.
+	KeyCount() int
+
+	// ExpiredKeys returns up to limit keys whose TTL expired. A key is only
+	// returned again a few seconds later if it was not deleted meanwhile.
+	ExpiredKeys(limit int) []ExpiredKey
+
 	// Compact frees all superseded keys with revisions less than rev.
 	Compact(trace *traceutil.Trace, rev int64) (<-chan struct{}, error)
 
+	// Purge frees the revisions of the keys in [key, end) deleted at or
+	// before rev, ahead of the compaction. The returned channel yields the
+	// number of freed revisions once they are removed from the backend.
+	Purge(trace *traceutil.Trace, key, end []byte, rev int64) (<-chan int64, error)
+
 	// Commit commits outstanding txns into the underlying backend.
 	Commit()
 
@@ -137,6 +179,29 @@ type KV interface {
 type WatchableKV interface {
 	KV
 	Watchable
+
+	// WatchStats returns the counters of the watch streams and watchers of the KV.
+	WatchStats() WatchStats
+
+	// SetMemoryScale shrinks the watch event cache, and the event buffers of
+	// the watch streams created from now on, to scale, between 0 and 1, of
+	// their configured sizes.
+	SetMemoryScale(scale float64)
+}
+
+// WatchStats are the counters of the watch streams and watchers of a KV.
+type WatchStats struct {
+	// Streams is the number of open watch streams.
+	Streams int64
+	// Watchers is the number of watchers.
+	Watchers int64
+	// SlowWatchers is the number of watchers behind the current revision.
+	SlowWatchers int64
+	// PendingEvents is the number of events sent to watchers and not yet
+	// reported received.
+	PendingEvents int64
+	// Events is the number of events reported received since the KV started.
+	Events int64
 }
 
 // Watchable is the interface that wraps the NewWatchStream function.
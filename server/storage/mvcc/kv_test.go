@@ -54,6 +54,20 @@ var (
 		defer txn.End()
 		return txn.Range(context.TODO(), key, end, ro)
 	}
+	txnRangeFuncFunc = func(kv KV, key, end []byte, ro RangeOptions) (*RangeResult, error) {
+		txn := kv.Read(ConcurrentReadTxMode, traceutil.TODO())
+		defer txn.End()
+		var kvs []mvccpb.KeyValue
+		r, err := txn.RangeFunc(context.TODO(), key, end, ro, func(kv *mvccpb.KeyValue) bool {
+			kvs = append(kvs, *kv)
+			return true
+		})
+		if err != nil {
+			return r, err
+		}
+		r.KVs = kvs
+		return r, nil
+	}
 
 	normalPutFunc = func(kv KV, key, value []byte, lease lease.LeaseID) int64 {
 		return kv.Put(key, value, lease)
@@ -74,8 +88,9 @@ var (
 	}
 )
 
-func TestKVRange(t *testing.T)    { testKVRange(t, normalRangeFunc) }
-func TestKVTxnRange(t *testing.T) { testKVRange(t, txnRangeFunc) }
+func TestKVRange(t *testing.T)        { testKVRange(t, normalRangeFunc) }
+func TestKVTxnRange(t *testing.T)     { testKVRange(t, txnRangeFunc) }
+func TestKVTxnRangeFunc(t *testing.T) { testKVRange(t, txnRangeFuncFunc) }
 
 func testKVRange(t *testing.T, f rangeFunc) {
 	b, tmpPath := betesting.NewDefaultTmpBackend(t)
@@ -140,8 +155,9 @@ func testKVRange(t *testing.T, f rangeFunc) {
 	}
 }
 
-func TestKVRangeRev(t *testing.T)    { testKVRangeRev(t, normalRangeFunc) }
-func TestKVTxnRangeRev(t *testing.T) { testKVRangeRev(t, txnRangeFunc) }
+func TestKVRangeRev(t *testing.T)        { testKVRangeRev(t, normalRangeFunc) }
+func TestKVTxnRangeRev(t *testing.T)     { testKVRangeRev(t, txnRangeFunc) }
+func TestKVTxnRangeRevFunc(t *testing.T) { testKVRangeRev(t, txnRangeFuncFunc) }
 
 func testKVRangeRev(t *testing.T, f rangeFunc) {
 	b, tmpPath := betesting.NewDefaultTmpBackend(t)
@@ -176,8 +192,9 @@ func testKVRangeRev(t *testing.T, f rangeFunc) {
 	}
 }
 
-func TestKVRangeBadRev(t *testing.T)    { testKVRangeBadRev(t, normalRangeFunc) }
-func TestKVTxnRangeBadRev(t *testing.T) { testKVRangeBadRev(t, txnRangeFunc) }
+func TestKVRangeBadRev(t *testing.T)        { testKVRangeBadRev(t, normalRangeFunc) }
+func TestKVTxnRangeBadRev(t *testing.T)     { testKVRangeBadRev(t, txnRangeFunc) }
+func TestKVTxnRangeBadRevFunc(t *testing.T) { testKVRangeBadRev(t, txnRangeFuncFunc) }
 
 func testKVRangeBadRev(t *testing.T, f rangeFunc) {
 	b, tmpPath := betesting.NewDefaultTmpBackend(t)
@@ -209,8 +226,9 @@ func testKVRangeBadRev(t *testing.T, f rangeFunc) {
 	}
 }
 
-func TestKVRangeLimit(t *testing.T)    { testKVRangeLimit(t, normalRangeFunc) }
-func TestKVTxnRangeLimit(t *testing.T) { testKVRangeLimit(t, txnRangeFunc) }
+func TestKVRangeLimit(t *testing.T)        { testKVRangeLimit(t, normalRangeFunc) }
+func TestKVTxnRangeLimit(t *testing.T)     { testKVRangeLimit(t, txnRangeFunc) }
+func TestKVTxnRangeLimitFunc(t *testing.T) { testKVRangeLimit(t, txnRangeFuncFunc) }
 
 func testKVRangeLimit(t *testing.T, f rangeFunc) {
 	b, tmpPath := betesting.NewDefaultTmpBackend(t)
@@ -255,6 +273,34 @@ func testKVRangeLimit(t *testing.T, f rangeFunc) {
 	}
 }
 
+func TestKVTxnRangeFuncStop(t *testing.T) {
+	b, tmpPath := betesting.NewDefaultTmpBackend(t)
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer cleanup(s, b, tmpPath)
+
+	kvs := put3TestKVs(s)
+
+	txn := s.Read(ConcurrentReadTxMode, traceutil.TODO())
+	defer txn.End()
+	var got []mvccpb.KeyValue
+	r, err := txn.RangeFunc(context.TODO(), []byte("foo"), []byte("foo3"), RangeOptions{}, func(kv *mvccpb.KeyValue) bool {
+		got = append(got, *kv)
+		return len(got) < 2
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, kvs[:2]) {
+		t.Errorf("kvs = %+v, want %+v", got, kvs[:2])
+	}
+	if r.Count != len(kvs) {
+		t.Errorf("count = %d, want %d", r.Count, len(kvs))
+	}
+	if r.KVs != nil {
+		t.Errorf("result kvs = %+v, want nil", r.KVs)
+	}
+}
+
 func TestKVPutMultipleTimes(t *testing.T)    { testKVPutMultipleTimes(t, normalPutFunc) }
 func TestKVTxnPutMultipleTimes(t *testing.T) { testKVPutMultipleTimes(t, txnPutFunc) }
 
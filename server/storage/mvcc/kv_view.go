@@ -17,6 +17,7 @@ package mvcc
 import (
 	"context"
 
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/pkg/v3/traceutil"
 	"go.etcd.io/etcd/server/v3/lease"
 )
@@ -41,6 +42,12 @@ func (rv *readView) Range(ctx context.Context, key, end []byte, ro RangeOptions)
 	return tr.Range(ctx, key, end, ro)
 }
 
+func (rv *readView) RangeFunc(ctx context.Context, key, end []byte, ro RangeOptions, f func(kv *mvccpb.KeyValue) bool) (r *RangeResult, err error) {
+	tr := rv.kv.Read(ConcurrentReadTxMode, traceutil.TODO())
+	defer tr.End()
+	return tr.RangeFunc(ctx, key, end, ro, f)
+}
+
 type writeView struct{ kv KV }
 
 func (wv *writeView) DeleteRange(key, end []byte) (n, rev int64) {
@@ -54,3 +61,15 @@ func (wv *writeView) Put(key, value []byte, lease lease.LeaseID) (rev int64) {
 	defer tw.End()
 	return tw.Put(key, value, lease)
 }
+
+func (wv *writeView) PutWithMetadata(key, value, metadata []byte, lease lease.LeaseID) (rev int64) {
+	tw := wv.kv.Write(traceutil.TODO())
+	defer tw.End()
+	return tw.PutWithMetadata(key, value, metadata, lease)
+}
+
+func (wv *writeView) PutWithTTL(key, value, metadata []byte, lease lease.LeaseID, ttl int64) (rev int64) {
+	tw := wv.kv.Write(traceutil.TODO())
+	defer tw.End()
+	return tw.PutWithTTL(key, value, metadata, lease, ttl)
+}
@@ -53,6 +53,22 @@ var minimumBatchInterval = 10 * time.Millisecond
 type StoreConfig struct {
 	CompactionBatchLimit    int
 	CompactionSleepInterval time.Duration
+	// CompactionBatchLatency is the target time a compaction batch holds
+	// the backend for, starting at CompactionBatchLimit revisions per batch.
+	// Zero keeps the batches at CompactionBatchLimit revisions.
+	CompactionBatchLatency time.Duration
+	// WatchEventCacheSize is the maximum number of recent events kept in
+	// memory to serve resuming watchers. Zero disables the cache.
+	WatchEventCacheSize int
+	// KeyPrefixBuckets lists the key prefixes whose revisions are stored in
+	// a backend bucket of their own rather than in the key bucket.
+	KeyPrefixBuckets []string
+	// EventPrefixMetrics lists the key prefixes whose put and delete events
+	// are counted by key prefix. Empty disables the metrics.
+	EventPrefixMetrics []string
+	// EventPrefixMetricsDepth is the number of '/' terminated key segments
+	// following the allowed prefix the events are counted by.
+	EventPrefixMetricsDepth int
 }
 
 type store struct {
@@ -66,10 +82,12 @@ type store struct {
 
 	b       backend.Backend
 	kvindex index
+	kb      *keyBuckets
+	expiry  *keyExpiry
 
 	le lease.Lessor
 
-	// revMuLock protects currentRev and compactMainRev.
+	// revMuLock protects currentRev, compactMainRev and purged.
 	// Locked at end of write txn and released after write txn unlock lock.
 	// Locked before locking read txn and released after locking.
 	revMu sync.RWMutex
@@ -77,9 +95,16 @@ type store struct {
 	currentRev int64
 	// compactMainRev is the main revision of the last compaction.
 	compactMainRev int64
+	// purged are the ranges purged since the last compaction. The slice is
+	// replaced, never modified in place.
+	purged []purgedRange
 
 	fifoSched schedule.Scheduler
 
+	// compactPacer sizes the compaction batches, nil for batches of
+	// CompactionBatchLimit revisions.
+	compactPacer *compactionPacer
+
 	stopc chan struct{}
 
 	lg     *zap.Logger
@@ -102,6 +127,8 @@ func NewStore(lg *zap.Logger, b backend.Backend, le lease.Lessor, cfg StoreConfi
 		cfg:     cfg,
 		b:       b,
 		kvindex: newTreeIndex(lg),
+		kb:      newKeyBuckets(cfg.KeyPrefixBuckets),
+		expiry:  newKeyExpiry(),
 
 		le: le,
 
@@ -110,6 +137,8 @@ func NewStore(lg *zap.Logger, b backend.Backend, le lease.Lessor, cfg StoreConfi
 
 		fifoSched: schedule.NewFIFOScheduler(lg),
 
+		compactPacer: newCompactionPacer(cfg.CompactionBatchLatency, cfg.CompactionBatchLimit),
+
 		stopc: make(chan struct{}),
 
 		lg: lg,
@@ -125,6 +154,7 @@ func NewStore(lg *zap.Logger, b backend.Backend, le lease.Lessor, cfg StoreConfi
 	tx.LockOutsideApply()
 	tx.UnsafeCreateBucket(schema.Key)
 	schema.UnsafeCreateMetaBucket(tx)
+	s.kb.unsafeMove(lg, tx)
 	tx.Unlock()
 	s.b.ForceCommit()
 
@@ -169,12 +199,18 @@ func (s *store) hash() (hash uint32, revision int64, err error) {
 }
 
 func (s *store) hashByRev(rev int64) (hash KeyValueHash, currentRev int64, err error) {
+	return s.hashRangeByRev(nil, nil, rev)
+}
+
+// hashRangeByRev hashes the revisions of the keys of the range [key, end) up
+// to rev, or of all keys if key is nil.
+func (s *store) hashRangeByRev(key, end []byte, rev int64) (hash KeyValueHash, currentRev int64, err error) {
 	var compactRev int64
 	start := time.Now()
 
 	s.mu.RLock()
 	s.revMu.RLock()
-	compactRev, currentRev = s.compactMainRev, s.currentRev
+	compactRev, currentRev, purged := s.compactMainRev, s.currentRev, s.purged
 	s.revMu.RUnlock()
 
 	if rev > 0 && rev < compactRev {
@@ -187,13 +223,25 @@ func (s *store) hashByRev(rev int64) (hash KeyValueHash, currentRev int64, err e
 	if rev == 0 {
 		rev = currentRev
 	}
+	// the members that have yet to apply a purge hash its range at the
+	// revisions it was applied at.
+	for _, p := range purged {
+		if rev <= p.AppliedRevision {
+			s.mu.RUnlock()
+			return KeyValueHash{}, 0, ErrCompacted
+		}
+	}
 	keep := s.kvindex.Keep(rev)
 
 	tx := s.b.ReadTx()
 	tx.RLock()
 	defer tx.RUnlock()
 	s.mu.RUnlock()
-	hash, err = unsafeHashByRev(tx, compactRev, rev, keep)
+	if key == nil && len(purged) == 0 {
+		hash, err = unsafeHashByRev(tx, s.kb, compactRev, rev, keep)
+	} else {
+		hash, err = unsafeHashRangeByRev(tx, s.kb, key, end, compactRev, rev, keep, purged)
+	}
 	hashRevSec.Observe(time.Since(start).Seconds())
 	return hash, currentRev, err
 }
@@ -284,6 +332,12 @@ func (s *store) Restore(b backend.Backend) error {
 	s.b = b
 	s.kvindex = newTreeIndex(s.lg)
 
+	tx := b.BatchTx()
+	tx.LockInsideApply()
+	s.kb.unsafeMove(s.lg, tx)
+	tx.Unlock()
+	b.ForceCommit()
+
 	{
 		// During restore the metrics might report 'special' values
 		s.revMu.Lock()
@@ -306,6 +360,7 @@ func (s *store) restore() error {
 	revToBytes(revision{main: math.MaxInt64, sub: math.MaxInt64}, max)
 
 	keyToLease := make(map[string]lease.LeaseID)
+	keyToTTL := make(map[string]keyTTL)
 
 	// restore index
 	tx := s.b.ReadTx()
@@ -324,17 +379,25 @@ func (s *store) restore() error {
 		s.revMu.Unlock()
 	}
 	scheduledCompact, _ := UnsafeReadScheduledCompact(tx)
+	purged := unsafeReadPurgedRanges(tx)
+	s.revMu.Lock()
+	s.purged = purged
+	s.revMu.Unlock()
 	// index keys concurrently as they're loaded in from tx
 	keysGauge.Set(0)
+	s.kb.resetStats()
 	rkvc, revc := restoreIntoIndex(s.lg, s.kvindex)
 	for {
-		keys, vals := tx.UnsafeRange(schema.Key, min, max, int64(restoreChunkKeys))
+		keys, vals, idx := s.kb.unsafeRange(tx, min, max, int64(restoreChunkKeys))
 		if len(keys) == 0 {
 			break
 		}
+		for i := range keys {
+			s.kb.added(entryBucket(idx, i), keys[i], vals[i])
+		}
 		// rkvc blocks if the total pending keys exceeds the restore
 		// chunk size to keep keys from consuming too much memory.
-		restoreChunk(s.lg, rkvc, keys, vals, keyToLease)
+		restoreChunk(s.lg, rkvc, keys, vals, keyToLease, keyToTTL)
 		if len(keys) < restoreChunkKeys {
 			// partial set implies final set
 			break
@@ -380,6 +443,12 @@ func (s *store) restore() error {
 
 	tx.Unlock()
 
+	// the TTLs of the keys restart from the restore, like the leases of a new leader
+	s.expiry.reset()
+	for key, kt := range keyToTTL {
+		s.expiry.set(key, kt.rev, kt.ttl)
+	}
+
 	s.lg.Info("kvstore restored", zap.Int64("current-rev", s.currentRev))
 
 	if scheduledCompact != 0 {
@@ -393,9 +462,25 @@ func (s *store) restore() error {
 		)
 	}
 
+	for _, p := range purged {
+		s.purge(p)
+
+		s.lg.Info(
+			"resume scheduled purge",
+			zap.ByteString("key", p.Key),
+			zap.ByteString("range-end", p.End),
+			zap.Int64("purge-revision", p.Revision),
+		)
+	}
+
 	return nil
 }
 
+// keyTTL is the TTL of a key put at rev.
+type keyTTL struct {
+	rev, ttl int64
+}
+
 type revKeyValue struct {
 	key  []byte
 	kv   mvccpb.KeyValue
@@ -449,7 +534,7 @@ func restoreIntoIndex(lg *zap.Logger, idx index) (chan<- revKeyValue, <-chan int
 	return rkvc, revc
 }
 
-func restoreChunk(lg *zap.Logger, kvc chan<- revKeyValue, keys, vals [][]byte, keyToLease map[string]lease.LeaseID) {
+func restoreChunk(lg *zap.Logger, kvc chan<- revKeyValue, keys, vals [][]byte, keyToLease map[string]lease.LeaseID, keyToTTL map[string]keyTTL) {
 	for i, key := range keys {
 		rkv := revKeyValue{key: key}
 		if err := rkv.kv.Unmarshal(vals[i]); err != nil {
@@ -463,6 +548,11 @@ func restoreChunk(lg *zap.Logger, kvc chan<- revKeyValue, keys, vals [][]byte, k
 		} else {
 			delete(keyToLease, rkv.kstr)
 		}
+		if !isTombstone(key) && rkv.kv.Ttl != 0 {
+			keyToTTL[rkv.kstr] = keyTTL{rev: rkv.kv.ModRevision, ttl: rkv.kv.Ttl}
+		} else {
+			delete(keyToTTL, rkv.kstr)
+		}
 		kvc <- rkv
 	}
 }
@@ -520,3 +610,11 @@ func isTombstone(b []byte) bool {
 func (s *store) HashStorage() HashStorage {
 	return s.hashes
 }
+
+func (s *store) KeyCount() int {
+	return s.kvindex.KeyCount()
+}
+
+func (s *store) ExpiredKeys(limit int) []ExpiredKey {
+	return s.expiry.expired(limit)
+}
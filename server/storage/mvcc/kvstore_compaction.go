@@ -19,7 +19,6 @@ import (
 	"fmt"
 	"time"
 
-	"go.etcd.io/etcd/server/v3/storage/schema"
 	"go.uber.org/zap"
 )
 
@@ -37,22 +36,27 @@ func (s *store) scheduleCompaction(compactMainRev, prevCompactRev int64) (KeyVal
 	end := make([]byte, 8)
 	binary.BigEndian.PutUint64(end, uint64(compactMainRev+1))
 
-	batchNum := s.cfg.CompactionBatchLimit
 	batchInterval := s.cfg.CompactionSleepInterval
 	h := newKVHasher(prevCompactRev, compactMainRev, keep)
 	last := make([]byte, 8+1+8)
 	for {
 		var rev revision
 
+		batchNum := s.cfg.CompactionBatchLimit
+		if s.compactPacer != nil {
+			batchNum = s.compactPacer.batchLimit()
+		}
 		start := time.Now()
 
 		tx := s.b.BatchTx()
 		tx.LockOutsideApply()
-		keys, values := tx.UnsafeRange(schema.Key, last, end, int64(batchNum))
+		keys, values, idx := s.kb.unsafeRange(tx, last, end, int64(batchNum))
 		for i := range keys {
 			rev = bytesToRev(keys[i])
 			if _, ok := keep[rev]; !ok {
-				tx.UnsafeDelete(schema.Key, keys[i])
+				b := entryBucket(idx, i)
+				tx.UnsafeDelete(s.kb.buckets[b], keys[i])
+				s.kb.deleted(b, keys[i], values[i])
 				keyCompactions++
 			}
 			h.WriteKeyValue(keys[i], values[i])
@@ -60,6 +64,7 @@ func (s *store) scheduleCompaction(compactMainRev, prevCompactRev int64) (KeyVal
 
 		if len(keys) < batchNum {
 			UnsafeSetFinishedCompact(tx, compactMainRev)
+			s.unsafeDropPurgedRanges(tx, compactMainRev)
 			tx.Unlock()
 			hash := h.Hash()
 			s.lg.Info(
@@ -77,6 +82,9 @@ func (s *store) scheduleCompaction(compactMainRev, prevCompactRev int64) (KeyVal
 		// Immediately commit the compaction deletes instead of letting them accumulate in the write buffer
 		s.b.ForceCommit()
 		dbCompactionPauseMs.Observe(float64(time.Since(start) / time.Millisecond))
+		if s.compactPacer != nil {
+			s.compactPacer.observe(time.Since(start))
+		}
 
 		select {
 		case <-time.After(batchInterval):
@@ -143,3 +143,69 @@ func TestCompactAllAndRestore(t *testing.T) {
 		t.Errorf("unexpect range error %v", err)
 	}
 }
+
+func TestCompactionPacer(t *testing.T) {
+	if newCompactionPacer(0, 1000) != nil {
+		t.Fatal("expected no pacer without a target latency")
+	}
+
+	p := newCompactionPacer(100*time.Millisecond, 1000)
+	tests := []struct {
+		latency time.Duration
+		wlimit  int
+	}{
+		{80 * time.Millisecond, 1000},
+		{200 * time.Millisecond, 500},
+		{10 * time.Millisecond, 626},
+		{10 * time.Millisecond, 783},
+	}
+	for i, tt := range tests {
+		p.observe(tt.latency)
+		if l := p.batchLimit(); l != tt.wlimit {
+			t.Errorf("#%d: batch limit = %d, want %d", i, l, tt.wlimit)
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		p.observe(time.Second)
+	}
+	if l := p.batchLimit(); l != minCompactionBatchLimit {
+		t.Errorf("batch limit = %d after slow batches, want %d", l, minCompactionBatchLimit)
+	}
+	for i := 0; i < 100; i++ {
+		p.observe(0)
+	}
+	if l := p.batchLimit(); l != maxCompactionBatchLimit {
+		t.Errorf("batch limit = %d after fast batches, want %d", l, maxCompactionBatchLimit)
+	}
+}
+
+func TestScheduleCompactionPaced(t *testing.T) {
+	b, tmpPath := betesting.NewDefaultTmpBackend(t)
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{
+		CompactionBatchLimit:    minCompactionBatchLimit,
+		CompactionSleepInterval: time.Millisecond,
+		CompactionBatchLatency:  time.Hour,
+	})
+	defer cleanup(s, b, tmpPath)
+
+	for i := 0; i < 100; i++ {
+		s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	}
+	if _, err := s.Compact(traceutil.TODO(), s.Rev()); err != nil {
+		t.Fatal(err)
+	}
+	s.fifoSched.WaitFinish(1)
+
+	// every batch faster than the target grows the next one
+	if l := s.compactPacer.batchLimit(); l <= minCompactionBatchLimit {
+		t.Errorf("batch limit = %d, want more than %d", l, minCompactionBatchLimit)
+	}
+	tx := s.b.BatchTx()
+	tx.Lock()
+	keys, _ := tx.UnsafeRange(schema.Key, newRevBytes(), []byte{0xff}, 0)
+	tx.Unlock()
+	if len(keys) != 1 {
+		t.Errorf("%d revisions left after compaction, want 1", len(keys))
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"math"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+)
+
+// FilterKeys deletes from the backend all the revisions of the keys keep
+// rejects, such as to restore part of the keyspace of a snapshot. The
+// revisions of the kept keys are left unchanged. If the latest revisions of
+// the store are deleted, the store is marked compacted at its former current
+// revision so that its revision does not go back once restored. It returns
+// the number of deleted revisions.
+func FilterKeys(lg *zap.Logger, be backend.Backend, keep func(key []byte) bool) (int, error) {
+	tx := be.BatchTx()
+	tx.LockOutsideApply()
+	deleted, err := unsafeFilterKeys(lg, tx, keep)
+	tx.Unlock()
+	be.ForceCommit()
+	return deleted, err
+}
+
+func unsafeFilterKeys(lg *zap.Logger, tx backend.BatchTx, keep func(key []byte) bool) (int, error) {
+	// the buckets of the key prefixes follow schema.Key, as in keyBuckets
+	buckets := []backend.Bucket{schema.Key}
+	for i, p := range UnsafeReadKeyPrefixBuckets(tx) {
+		buckets = append(buckets, schema.KeyPrefixBucket(i, p))
+	}
+
+	var currentRev, keptRev int64
+	deleted := 0
+	max := newRevBytes()
+	revToBytes(revision{main: math.MaxInt64, sub: math.MaxInt64}, max)
+	for _, b := range buckets {
+		min := newRevBytes()
+		for {
+			keys, vals := tx.UnsafeRange(b, min, max, int64(restoreChunkKeys))
+			var rejected [][]byte
+			for i := range keys {
+				var kv mvccpb.KeyValue
+				if err := kv.Unmarshal(vals[i]); err != nil {
+					return deleted, err
+				}
+				rev := bytesToRev(keys[i]).main
+				if rev > currentRev {
+					currentRev = rev
+				}
+				if keep(kv.Key) {
+					if rev > keptRev {
+						keptRev = rev
+					}
+					continue
+				}
+				rejected = append(rejected, append([]byte(nil), keys[i]...))
+			}
+			last := len(keys) < restoreChunkKeys
+			if !last {
+				min = append(append(min[:0:0], keys[len(keys)-1]...), 0)
+			}
+			for _, k := range rejected {
+				tx.UnsafeDelete(b, k)
+			}
+			deleted += len(rejected)
+			if last {
+				break
+			}
+		}
+	}
+
+	compactRev, _ := UnsafeReadFinishedCompact(tx)
+	if keptRev < currentRev && compactRev < currentRev {
+		UnsafeSetScheduledCompact(tx, currentRev)
+		UnsafeSetFinishedCompact(tx, currentRev)
+		compactRev = currentRev
+	}
+	lg.Info(
+		"filtered keys",
+		zap.Int("deleted-revisions", deleted),
+		zap.Int64("current-revision", currentRev),
+		zap.Int64("compact-revision", compactRev),
+	)
+	return deleted, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+)
+
+func TestFilterKeys(t *testing.T) {
+	tests := []struct {
+		prefix string
+
+		wkeys       []string
+		wdeleted    int
+		wcompactRev int64
+	}{
+		// the latest revision is deleted; the store is compacted at it
+		{"a/", []string{"a/1", "a/2"}, 3, 7},
+		{"b/", []string{"b/2"}, 3, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			lg := zaptest.NewLogger(t)
+			b, _ := betesting.NewDefaultTmpBackend(t)
+			defer betesting.Close(t, b)
+			cfg := StoreConfig{KeyPrefixBuckets: []string{"a/"}}
+			s := NewStore(lg, b, &lease.FakeLessor{}, cfg)
+			s.Put([]byte("a/1"), []byte("bar"), lease.NoLease)
+			s.Put([]byte("b/1"), []byte("bar"), lease.NoLease)
+			s.Put([]byte("a/2"), []byte("bar"), lease.NoLease)
+			s.Put([]byte("a/1"), []byte("baz"), lease.NoLease)
+			s.DeleteRange([]byte("b/1"), nil)
+			s.Put([]byte("b/2"), []byte("bar"), lease.NoLease)
+			s.Close()
+
+			deleted, err := FilterKeys(lg, b, func(key []byte) bool { return bytes.HasPrefix(key, []byte(tt.prefix)) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deleted != tt.wdeleted {
+				t.Errorf("deleted = %d, want %d", deleted, tt.wdeleted)
+			}
+
+			s = NewStore(lg, b, &lease.FakeLessor{}, cfg)
+			defer s.Close()
+			if rev := s.Rev(); rev != 7 {
+				t.Errorf("rev = %d, want 7", rev)
+			}
+			if s.compactMainRev != tt.wcompactRev {
+				t.Errorf("compact rev = %d, want %d", s.compactMainRev, tt.wcompactRev)
+			}
+			r, err := s.Range(context.TODO(), []byte("a"), []byte("c"), RangeOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var keys []string
+			for _, kv := range r.KVs {
+				keys = append(keys, string(kv.Key))
+			}
+			if !reflect.DeepEqual(keys, tt.wkeys) {
+				t.Errorf("keys = %v, want %v", keys, tt.wkeys)
+			}
+		})
+	}
+}
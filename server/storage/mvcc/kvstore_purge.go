@@ -0,0 +1,214 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.etcd.io/etcd/pkg/v3/schedule"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+
+	"go.uber.org/zap"
+)
+
+// Purge removes the revisions of the keys in [key, end) deleted at or before
+// rev, or until the current revision if rev is not positive. The range is
+// recorded as purged at once: reads and watches in it from before rev fail as
+// compacted, and hashes leave out its revisions, so that neither depends on
+// the progress of the removal. The removal itself is scheduled after the
+// scheduled compactions so that their hashes do not depend on it either.
+func (s *store) Purge(trace *traceutil.Trace, key, end []byte, rev int64) (<-chan int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revMu.RLock()
+	currentRev := s.currentRev
+	s.revMu.RUnlock()
+	if rev > currentRev {
+		return nil, ErrFutureRev
+	}
+	if rev <= 0 {
+		rev = currentRev
+	}
+	p := purgedRange{Key: key, End: end, Revision: rev, AppliedRevision: currentRev}
+
+	tx := s.b.BatchTx()
+	tx.LockInsideApply()
+	s.revMu.Lock()
+	s.purged = append(s.purged[:len(s.purged):len(s.purged)], p)
+	unsafeSetPurgedRanges(tx, s.purged)
+	s.revMu.Unlock()
+	tx.Unlock()
+
+	ch := s.purge(p)
+	trace.Step("schedule purge")
+	return ch, nil
+}
+
+func (s *store) purge(p purgedRange) <-chan int64 {
+	ch := make(chan int64, 1)
+	j := schedule.NewJob("kvstore_purge", func(ctx context.Context) {
+		defer close(ch)
+		if ctx.Err() != nil {
+			return
+		}
+		purged, err := s.schedulePurge(p)
+		if err != nil {
+			s.lg.Warn("Failed purge", zap.Error(err))
+			return
+		}
+		ch <- purged
+	})
+	s.fifoSched.Schedule(j)
+	return ch
+}
+
+func (s *store) schedulePurge(p purgedRange) (int64, error) {
+	totalStart := time.Now()
+	revs := s.kvindex.Purge(p.Key, indexRangeEnd(p.End), p.Revision)
+	// the tombstone of a generation is removed after its other revisions,
+	// so that an interrupted purge leaves the key deleted and purges it again
+	// on restore.
+	sort.Slice(revs, func(i, j int) bool { return revs[j].GreaterThan(revs[i]) })
+
+	var purged int64
+	defer func() { dbPurgeKeysCounter.Add(float64(purged)) }()
+
+	batchNum := s.cfg.CompactionBatchLimit
+	batchInterval := s.cfg.CompactionSleepInterval
+	for {
+		n := len(revs)
+		if n > batchNum {
+			n = batchNum
+		}
+
+		tx := s.b.BatchTx()
+		tx.LockOutsideApply()
+		for _, rev := range revs[:n] {
+			if s.kb.unsafeDeleteRevision(tx, rev) {
+				purged++
+			}
+		}
+		revs = revs[n:]
+
+		if len(revs) == 0 {
+			tx.Unlock()
+			s.lg.Info(
+				"finished scheduled purge",
+				zap.ByteString("key", p.Key),
+				zap.ByteString("range-end", p.End),
+				zap.Int64("purge-revision", p.Revision),
+				zap.Int64("purged-revisions", purged),
+				zap.Duration("took", time.Since(totalStart)),
+			)
+			return purged, nil
+		}
+
+		tx.Unlock()
+		// Immediately commit the purge deletes instead of letting them accumulate in the write buffer
+		s.b.ForceCommit()
+
+		select {
+		case <-time.After(batchInterval):
+		case <-s.stopc:
+			return purged, fmt.Errorf("interrupted due to stop signal")
+		}
+	}
+}
+
+// unsafeDropPurgedRanges drops the purged ranges covered by the compaction at
+// compactRev, whose removals were scheduled before it and are done.
+func (s *store) unsafeDropPurgedRanges(tx backend.BatchTx, compactRev int64) {
+	s.revMu.Lock()
+	defer s.revMu.Unlock()
+	purged := uncompactedPurgedRanges(s.purged, compactRev)
+	if len(purged) == len(s.purged) {
+		return
+	}
+	s.purged = purged
+	unsafeSetPurgedRanges(tx, purged)
+}
+
+func uncompactedPurgedRanges(purged []purgedRange, compactRev int64) []purgedRange {
+	var ret []purgedRange
+	for _, p := range purged {
+		if p.AppliedRevision >= compactRev {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+func (s *store) purgedRev(key, end []byte) int64 {
+	s.revMu.RLock()
+	defer s.revMu.RUnlock()
+	return purgedRev(s.purged, key, end)
+}
+
+// purgedRev returns the highest revision at which a range overlapping
+// [key, end) was purged, or 0 if there is none. As for a compaction, the
+// revisions below it are no longer available. The end is nil for the single
+// key and empty for all the keys from key on.
+func purgedRev(purged []purgedRange, key, end []byte) int64 {
+	var rev int64
+	for _, p := range purged {
+		if p.Revision > rev && p.overlaps(key, end) {
+			rev = p.Revision
+		}
+	}
+	return rev
+}
+
+// purgedKey returns whether the revision at main of key was purged.
+func purgedKey(purged []purgedRange, key []byte, main int64) bool {
+	for _, p := range purged {
+		if main <= p.Revision && keyInRange(key, p.Key, p.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps returns whether the purged range shares a key with [key, end),
+// where end is nil for the single key and empty for all the keys from key on.
+func (p purgedRange) overlaps(key, end []byte) bool {
+	// the purged range is given as to a range request.
+	pend := indexRangeEnd(p.End)
+	if pend == nil {
+		pend = append(p.Key[:len(p.Key):len(p.Key)], 0)
+	}
+	if end == nil {
+		end = append(key[:len(key):len(key)], 0)
+	}
+	return (len(pend) == 0 || bytes.Compare(key, pend) < 0) &&
+		(len(end) == 0 || bytes.Compare(p.Key, end) < 0)
+}
+
+// indexRangeEnd returns the end of the range of a range request as taken by
+// the index: nil for the single key and empty for all the keys from key on.
+func indexRangeEnd(end []byte) []byte {
+	switch {
+	case len(end) == 0:
+		return nil
+	case len(end) == 1 && end[0] == 0:
+		return []byte{}
+	}
+	return end
+}
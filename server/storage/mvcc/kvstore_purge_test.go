@@ -0,0 +1,215 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.etcd.io/etcd/pkg/v3/traceutil"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.etcd.io/etcd/server/v3/storage/schema"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestStorePurge(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer cleanup(s, b, "")
+
+	s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	s.Put([]byte("foo"), []byte("bar1"), lease.NoLease)
+	s.DeleteRange([]byte("foo"), nil)
+	s.Put([]byte("foo1"), []byte("bar"), lease.NoLease)
+	s.DeleteRange([]byte("foo1"), nil)
+	s.Put([]byte("foo2"), []byte("bar"), lease.NoLease)
+	tombstones := keyBucketTombstonesGauge.WithLabelValues(schema.Key.String())
+	wtombstones := testutil.ToFloat64(tombstones) - 2
+
+	if _, err := s.Purge(traceutil.TODO(), []byte("foo"), nil, s.Rev()+1); err != ErrFutureRev {
+		t.Fatalf("purge error = %v, want %v", err, ErrFutureRev)
+	}
+	if purged := waitPurge(t, s, []byte("foo"), []byte("fop"), 0); purged != 5 {
+		t.Errorf("purged = %d, want 5", purged)
+	}
+	if got := testutil.ToFloat64(tombstones); got != wtombstones {
+		t.Errorf("tombstones = %v, want %v", got, wtombstones)
+	}
+
+	tx := b.BatchTx()
+	tx.Lock()
+	keys, _ := tx.UnsafeRange(schema.Key, newTestRevBytes(revision{}), newTestRevBytes(revision{main: s.Rev() + 1}), 0)
+	purged := unsafeReadPurgedRanges(tx)
+	tx.Unlock()
+	if len(keys) != 1 {
+		t.Errorf("len(keys) = %d, want 1", len(keys))
+	}
+	wpurged := []purgedRange{{Key: []byte("foo"), End: []byte("fop"), Revision: 7, AppliedRevision: 7}}
+	if !reflect.DeepEqual(purged, wpurged) {
+		t.Errorf("purged ranges = %+v, want %+v", purged, wpurged)
+	}
+
+	r, err := s.Range(context.TODO(), []byte("foo"), []byte("fop"), RangeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.KVs) != 1 || string(r.KVs[0].Key) != "foo2" {
+		t.Errorf("kvs = %+v, want only foo2", r.KVs)
+	}
+	if _, err = s.Range(context.TODO(), []byte("foo"), nil, RangeOptions{Rev: 5}); err != ErrCompacted {
+		t.Errorf("range error = %v, want %v", err, ErrCompacted)
+	}
+	if _, err = s.Range(context.TODO(), []byte("bar"), nil, RangeOptions{Rev: 5}); err != nil {
+		t.Errorf("range error = %v, want nil", err)
+	}
+}
+
+// TestStorePurgeHash ensures that the hashes leave out the purged revisions,
+// whether they are removed yet or not.
+func TestStorePurgeHash(t *testing.T) {
+	var hashes []KeyValueHash
+	for _, remove := range []bool{true, false} {
+		b, _ := betesting.NewDefaultTmpBackend(t)
+		s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+
+		s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+		s.DeleteRange([]byte("foo"), nil)
+		s.Put([]byte("foo1"), []byte("bar"), lease.NoLease)
+		if remove {
+			waitPurge(t, s, []byte("foo"), nil, 0)
+		} else {
+			// a purge applied and yet to be run.
+			s.purged = []purgedRange{{Key: []byte("foo"), Revision: s.Rev(), AppliedRevision: s.Rev()}}
+		}
+		if _, _, err := s.hashByRev(0); err != ErrCompacted {
+			t.Errorf("hash error = %v, want %v", err, ErrCompacted)
+		}
+
+		s.Put([]byte("foo2"), []byte("bar"), lease.NoLease)
+		h, _, err := s.hashByRev(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, h)
+		cleanup(s, b, "")
+	}
+	if hashes[0] != hashes[1] {
+		t.Errorf("hash = %+v, want %+v", hashes[0], hashes[1])
+	}
+}
+
+func TestStorePurgeCompact(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	s := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer cleanup(s, b, "")
+
+	s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	s.DeleteRange([]byte("foo"), nil)
+	waitPurge(t, s, []byte("foo"), nil, 0)
+	s.Put([]byte("foo1"), []byte("bar"), lease.NoLease)
+
+	done, err := s.Compact(traceutil.TODO(), s.Rev())
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	tx := b.BatchTx()
+	tx.Lock()
+	purged := unsafeReadPurgedRanges(tx)
+	tx.Unlock()
+	if len(purged) != 0 || len(s.purged) != 0 {
+		t.Errorf("purged ranges = %+v, %+v, want none", purged, s.purged)
+	}
+}
+
+func TestWatchableStorePurgeCompactsWatchers(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	s := newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer cleanup(s, b, "")
+
+	s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	s.DeleteRange([]byte("foo"), nil)
+	waitPurge(t, s.store, []byte("foo"), nil, 0)
+	purgeRev := s.Rev()
+
+	w := s.NewWatchStream()
+	defer w.Close()
+	w.Watch(0, []byte("foo"), nil, 1)
+	w.Watch(0, []byte("bar"), nil, 1)
+
+	select {
+	case resp := <-w.Chan():
+		if resp.WatchID != 0 || resp.CompactRevision != purgeRev {
+			t.Errorf("resp = %+v, want watch 0 compacted at %d", resp, purgeRev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed to receive response (timeout)")
+	}
+	select {
+	case resp := <-w.Chan():
+		t.Errorf("unexpected response %+v", resp)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestStorePurgeResume(t *testing.T) {
+	b, _ := betesting.NewDefaultTmpBackend(t)
+	s0 := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+
+	s0.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	s0.DeleteRange([]byte("foo"), nil)
+
+	// simulate a crash before the scheduled purge runs.
+	tx := b.BatchTx()
+	tx.Lock()
+	unsafeSetPurgedRanges(tx, []purgedRange{{Key: []byte("foo"), Revision: s0.Rev(), AppliedRevision: s0.Rev()}})
+	tx.Unlock()
+	s0.Close()
+
+	s1 := NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+	defer cleanup(s1, b, "")
+	// purges run in order, so the resumed one is done with this one.
+	waitPurge(t, s1, []byte("bar"), nil, 0)
+
+	tx = b.BatchTx()
+	tx.Lock()
+	keys, _ := tx.UnsafeRange(schema.Key, newTestRevBytes(revision{}), newTestRevBytes(revision{main: s1.Rev() + 1}), 0)
+	tx.Unlock()
+	if len(keys) != 0 {
+		t.Errorf("len(keys) = %d, want 0", len(keys))
+	}
+	if len(s1.purged) != 2 {
+		t.Errorf("len(purged ranges) = %d, want 2", len(s1.purged))
+	}
+}
+
+func waitPurge(t *testing.T, s *store, key, end []byte, rev int64) int64 {
+	ch, err := s.Purge(traceutil.TODO(), key, end, rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case purged := <-ch:
+		return purged
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for purge to finish")
+	}
+	return 0
+}
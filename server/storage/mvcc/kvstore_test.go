@@ -392,6 +392,7 @@ func TestStoreRestore(t *testing.T) {
 	}
 	b.tx.rangeRespc <- rangeResp{[][]byte{schema.FinishedCompactKeyName}, [][]byte{newTestRevBytes(revision{3, 0})}}
 	b.tx.rangeRespc <- rangeResp{[][]byte{schema.ScheduledCompactKeyName}, [][]byte{newTestRevBytes(revision{3, 0})}}
+	b.tx.rangeRespc <- rangeResp{nil, nil}
 
 	b.tx.rangeRespc <- rangeResp{[][]byte{putkey, delkey}, [][]byte{putkvb, delkvb}}
 	b.tx.rangeRespc <- rangeResp{nil, nil}
@@ -407,6 +408,7 @@ func TestStoreRestore(t *testing.T) {
 	wact := []testutil.Action{
 		{Name: "range", Params: []interface{}{schema.Meta, schema.FinishedCompactKeyName, []byte(nil), int64(0)}},
 		{Name: "range", Params: []interface{}{schema.Meta, schema.ScheduledCompactKeyName, []byte(nil), int64(0)}},
+		{Name: "range", Params: []interface{}{schema.Meta, schema.MetaPurgedRangesName, []byte(nil), int64(0)}},
 		{Name: "range", Params: []interface{}{schema.Key, newTestRevBytes(revision{1, 0}), newTestRevBytes(revision{math.MaxInt64, math.MaxInt64}), int64(restoreChunkKeys)}},
 	}
 	if g := b.tx.Action(); !reflect.DeepEqual(g, wact) {
@@ -897,6 +899,8 @@ func newFakeStore(lg *zap.Logger) *store {
 		b:              b,
 		le:             &lease.FakeLessor{},
 		kvindex:        newFakeIndex(),
+		kb:             newKeyBuckets(nil),
+		expiry:         newKeyExpiry(),
 		currentRev:     0,
 		compactMainRev: -1,
 		fifoSched:      schedule.NewFIFOScheduler(lg),
@@ -970,8 +974,10 @@ func (b *fakeBackend) OpenReadTxN() int64
 func (b *fakeBackend) Snapshot() backend.Snapshot                                 { return nil }
 func (b *fakeBackend) ForceCommit()                                               {}
 func (b *fakeBackend) Defrag() error                                              { return nil }
+func (b *fakeBackend) DefragOnline() error                                        { return nil }
 func (b *fakeBackend) Close() error                                               { return nil }
 func (b *fakeBackend) SetTxPostLockInsideApplyHook(func())                        {}
+func (b *fakeBackend) SetMemoryScale(float64)                                     {}
 
 type indexGetResp struct {
 	rev     revision
@@ -1010,6 +1016,8 @@ func (i *fakeIndex) CountRevisions(key, end []byte, atRev int64) int {
 	return len(rev)
 }
 
+func (i *fakeIndex) KeyCount() int { return 0 }
+
 func (i *fakeIndex) Get(key []byte, atRev int64) (rev, created revision, ver int64, err error) {
 	i.Recorder.Record(testutil.Action{Name: "get", Params: []interface{}{key, atRev}})
 	r := <-i.indexGetRespc
@@ -1040,6 +1048,11 @@ func (i *fakeIndex) Keep(rev int64) map[revision]struct{} {
 	i.Recorder.Record(testutil.Action{Name: "keep", Params: []interface{}{rev}})
 	return <-i.indexCompactRespc
 }
+func (i *fakeIndex) Purge(key, end []byte, atRev int64) []revision {
+	i.Recorder.Record(testutil.Action{Name: "purge", Params: []interface{}{key, end, atRev}})
+	return nil
+}
+
 func (i *fakeIndex) Equal(b index) bool { return false }
 
 func (i *fakeIndex) Insert(ki *keyIndex) {
@@ -22,7 +22,6 @@ import (
 	"go.etcd.io/etcd/pkg/v3/traceutil"
 	"go.etcd.io/etcd/server/v3/lease"
 	"go.etcd.io/etcd/server/v3/storage/backend"
-	"go.etcd.io/etcd/server/v3/storage/schema"
 	"go.uber.org/zap"
 )
 
@@ -63,43 +62,111 @@ func (tr *storeTxnRead) Range(ctx context.Context, key, end []byte, ro RangeOpti
 	return tr.rangeKeys(ctx, key, end, tr.Rev(), ro)
 }
 
+func (tr *storeTxnRead) RangeFunc(ctx context.Context, key, end []byte, ro RangeOptions, f func(kv *mvccpb.KeyValue) bool) (r *RangeResult, err error) {
+	return tr.rangeKeysFunc(ctx, key, end, tr.Rev(), ro, f)
+}
+
 func (tr *storeTxnRead) rangeKeys(ctx context.Context, key, end []byte, curRev int64, ro RangeOptions) (*RangeResult, error) {
+	revpairs, rr, err := tr.rangeRevisions(key, end, curRev, ro)
+	if err != nil || len(revpairs) == 0 {
+		return rr, err
+	}
+
+	kvs := make([]mvccpb.KeyValue, len(revpairs))
+	err = tr.readRevisions(ctx, key, end, curRev, ro, revpairs, func(i int, v []byte) bool {
+		tr.unmarshalKeyValue(&kvs[i], v)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	tr.trace.Step("range keys from bolt db")
+	if hidesExpired(curRev, ro) {
+		live := kvs[:0]
+		for i := range kvs {
+			if tr.isExpired(&kvs[i]) {
+				rr.Count--
+				continue
+			}
+			live = append(live, kvs[i])
+		}
+		kvs = live
+	}
+	rr.KVs = kvs
+	return rr, nil
+}
+
+// rangeKeysFunc reads the key-value pairs of the range from the backend one
+// at a time, handing each of them over to f, so that the caller does not need
+// to hold the whole range in memory.
+func (tr *storeTxnRead) rangeKeysFunc(ctx context.Context, key, end []byte, curRev int64, ro RangeOptions, f func(kv *mvccpb.KeyValue) bool) (*RangeResult, error) {
+	revpairs, rr, err := tr.rangeRevisions(key, end, curRev, ro)
+	if err != nil || len(revpairs) == 0 {
+		return rr, err
+	}
+
+	hide := hidesExpired(curRev, ro)
+	err = tr.readRevisions(ctx, key, end, curRev, ro, revpairs, func(_ int, v []byte) bool {
+		var kv *mvccpb.KeyValue
+		if ro.NewKV != nil {
+			kv = ro.NewKV()
+		} else {
+			kv = new(mvccpb.KeyValue)
+		}
+		tr.unmarshalKeyValue(kv, v)
+		if hide && tr.isExpired(kv) {
+			rr.Count--
+			return true
+		}
+		return f(kv)
+	})
+	if err != nil {
+		return nil, err
+	}
+	tr.trace.Step("range keys from bolt db")
+	return rr, nil
+}
+
+// rangeRevisions returns the revisions of the keys in the range from the
+// in-memory index, along with the result of the range without its key-value
+// pairs.
+func (tr *storeTxnRead) rangeRevisions(key, end []byte, curRev int64, ro RangeOptions) ([]revision, *RangeResult, error) {
 	rev := ro.Rev
 	if rev > curRev {
-		return &RangeResult{KVs: nil, Count: -1, Rev: curRev}, ErrFutureRev
+		return nil, &RangeResult{KVs: nil, Count: -1, Rev: curRev}, ErrFutureRev
 	}
 	if rev <= 0 {
 		rev = curRev
 	}
-	if rev < tr.s.compactMainRev {
-		return &RangeResult{KVs: nil, Count: -1, Rev: 0}, ErrCompacted
+	if rev < tr.s.compactMainRev || rev < tr.s.purgedRev(key, end) {
+		return nil, &RangeResult{KVs: nil, Count: -1, Rev: 0}, ErrCompacted
 	}
 	if ro.Count {
 		total := tr.s.kvindex.CountRevisions(key, end, rev)
 		tr.trace.Step("count revisions from in-memory index tree")
-		return &RangeResult{KVs: nil, Count: total, Rev: curRev}, nil
+		return nil, &RangeResult{KVs: nil, Count: total, Rev: curRev}, nil
 	}
 	revpairs, total := tr.s.kvindex.Revisions(key, end, rev, int(ro.Limit))
 	tr.trace.Step("range keys from in-memory index tree")
-	if len(revpairs) == 0 {
-		return &RangeResult{KVs: nil, Count: total, Rev: curRev}, nil
-	}
-
-	limit := int(ro.Limit)
-	if limit <= 0 || limit > len(revpairs) {
-		limit = len(revpairs)
+	if limit := int(ro.Limit); limit > 0 && limit < len(revpairs) {
+		revpairs = revpairs[:limit]
 	}
+	return revpairs, &RangeResult{KVs: nil, Count: total, Rev: curRev}, nil
+}
 
-	kvs := make([]mvccpb.KeyValue, limit)
+// readRevisions reads the values of the revisions from the backend in order,
+// passing each of them to f until it returns false.
+func (tr *storeTxnRead) readRevisions(ctx context.Context, key, end []byte, curRev int64, ro RangeOptions, revpairs []revision, f func(i int, v []byte) bool) error {
 	revBytes := newRevBytes()
-	for i, revpair := range revpairs[:len(kvs)] {
+	buckets := tr.s.kb.rangeBuckets(key, end)
+	for i, revpair := range revpairs {
 		select {
 		case <-ctx.Done():
-			return nil, fmt.Errorf("rangeKeys: context cancelled: %w", ctx.Err())
+			return fmt.Errorf("rangeKeys: context cancelled: %w", ctx.Err())
 		default:
 		}
 		revToBytes(revpair, revBytes)
-		_, vs := tr.tx.UnsafeRange(schema.Key, revBytes, nil, 0)
+		vs := tr.s.kb.unsafeGet(tr.tx, buckets, revBytes)
 		if len(vs) != 1 {
 			tr.s.lg.Fatal(
 				"range failed to find revision pair",
@@ -114,15 +181,30 @@ func (tr *storeTxnRead) rangeKeys(ctx context.Context, key, end []byte, curRev i
 				zap.Int("len-values", len(vs)),
 			)
 		}
-		if err := kvs[i].Unmarshal(vs[0]); err != nil {
-			tr.s.lg.Fatal(
-				"failed to unmarshal mvccpb.KeyValue",
-				zap.Error(err),
-			)
+		if !f(i, vs[0]) {
+			return nil
 		}
 	}
-	tr.trace.Step("range keys from bolt db")
-	return &RangeResult{KVs: kvs, Count: total, Rev: curRev}, nil
+	return nil
+}
+
+// hidesExpired returns true if the range hides the expired keys, which are
+// only deleted once the deletion is applied.
+func hidesExpired(curRev int64, ro RangeOptions) bool {
+	return ro.HideExpired && (ro.Rev <= 0 || ro.Rev == curRev)
+}
+
+func (tr *storeTxnRead) isExpired(kv *mvccpb.KeyValue) bool {
+	return kv.Ttl != 0 && tr.s.expiry.isExpired(kv.Key, kv.ModRevision)
+}
+
+func (tr *storeTxnRead) unmarshalKeyValue(kv *mvccpb.KeyValue, v []byte) {
+	if err := kv.Unmarshal(v); err != nil {
+		tr.s.lg.Fatal(
+			"failed to unmarshal mvccpb.KeyValue",
+			zap.Error(err),
+		)
+	}
 }
 
 func (tr *storeTxnRead) End() {
@@ -161,6 +243,14 @@ func (tw *storeTxnWrite) Range(ctx context.Context, key, end []byte, ro RangeOpt
 	return tw.rangeKeys(ctx, key, end, rev, ro)
 }
 
+func (tw *storeTxnWrite) RangeFunc(ctx context.Context, key, end []byte, ro RangeOptions, f func(kv *mvccpb.KeyValue) bool) (r *RangeResult, err error) {
+	rev := tw.beginRev
+	if len(tw.changes) > 0 {
+		rev++
+	}
+	return tw.rangeKeysFunc(ctx, key, end, rev, ro, f)
+}
+
 func (tw *storeTxnWrite) DeleteRange(key, end []byte) (int64, int64) {
 	if n := tw.deleteRange(key, end); n != 0 || len(tw.changes) > 0 {
 		return n, tw.beginRev + 1
@@ -169,7 +259,17 @@ func (tw *storeTxnWrite) DeleteRange(key, end []byte) (int64, int64) {
 }
 
 func (tw *storeTxnWrite) Put(key, value []byte, lease lease.LeaseID) int64 {
-	tw.put(key, value, lease)
+	tw.put(key, value, nil, lease, 0)
+	return tw.beginRev + 1
+}
+
+func (tw *storeTxnWrite) PutWithMetadata(key, value, metadata []byte, lease lease.LeaseID) int64 {
+	tw.put(key, value, metadata, lease, 0)
+	return tw.beginRev + 1
+}
+
+func (tw *storeTxnWrite) PutWithTTL(key, value, metadata []byte, lease lease.LeaseID, ttl int64) int64 {
+	tw.put(key, value, metadata, lease, ttl)
 	return tw.beginRev + 1
 }
 
@@ -187,7 +287,7 @@ func (tw *storeTxnWrite) End() {
 	tw.s.mu.RUnlock()
 }
 
-func (tw *storeTxnWrite) put(key, value []byte, leaseID lease.LeaseID) {
+func (tw *storeTxnWrite) put(key, value, metadata []byte, leaseID lease.LeaseID, ttl int64) {
 	rev := tw.beginRev + 1
 	c := rev
 	oldLease := lease.NoLease
@@ -212,6 +312,8 @@ func (tw *storeTxnWrite) put(key, value []byte, leaseID lease.LeaseID) {
 		ModRevision:    rev,
 		Version:        ver,
 		Lease:          int64(leaseID),
+		Metadata:       metadata,
+		Ttl:            ttl,
 	}
 
 	d, err := kv.Marshal()
@@ -223,11 +325,17 @@ func (tw *storeTxnWrite) put(key, value []byte, leaseID lease.LeaseID) {
 	}
 
 	tw.trace.Step("marshal mvccpb.KeyValue")
-	tw.tx.UnsafeSeqPut(schema.Key, ibytes, d)
+	tw.s.kb.unsafeSeqPut(tw.tx, key, ibytes, d)
 	tw.s.kvindex.Put(key, idxRev)
 	tw.changes = append(tw.changes, kv)
 	tw.trace.Step("store kv pair into bolt db")
 
+	if ttl != 0 {
+		tw.s.expiry.set(string(key), rev, ttl)
+	} else {
+		tw.s.expiry.remove(string(key))
+	}
+
 	if oldLease == leaseID {
 		tw.trace.Step("attach lease to kv pair")
 		return
@@ -289,7 +397,7 @@ func (tw *storeTxnWrite) delete(key []byte) {
 		)
 	}
 
-	tw.tx.UnsafeSeqPut(schema.Key, ibytes, d)
+	tw.s.kb.unsafeSeqPut(tw.tx, key, ibytes, d)
 	err = tw.s.kvindex.Tombstone(key, idxRev)
 	if err != nil {
 		tw.storeTxnRead.s.lg.Fatal(
@@ -299,6 +407,7 @@ func (tw *storeTxnWrite) delete(key []byte) {
 		)
 	}
 	tw.changes = append(tw.changes, kv)
+	tw.s.expiry.remove(string(key))
 
 	item := lease.LeaseItem{Key: string(key)}
 	leaseID := tw.s.le.GetLease(item)
@@ -101,6 +101,30 @@ var (
 			Help:      "Total number of pending events to be sent.",
 		})
 
+	eventCacheHitCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "watch_event_cache_hits_total",
+			Help:      "Total number of unsynced watcher syncs served from the watch event cache.",
+		})
+
+	eventCacheMissCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "watch_event_cache_misses_total",
+			Help:      "Total number of unsynced watcher syncs that read events from the backend while the watch event cache is enabled.",
+		})
+
+	eventCacheEventsGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "watch_event_cache_events_total",
+			Help:      "Total number of events held by the watch event cache.",
+		})
+
 	indexCompactionPauseMs = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
 			Namespace: "etcd_debugging",
@@ -145,6 +169,14 @@ var (
 			Help:      "The unix time of the last db compaction. Resets to 0 on start.",
 		})
 
+	dbCompactionBatchLimitGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "db_compaction_batch_limit",
+			Help:      "Number of revisions deleted in the next db compaction batch, as paced by --experimental-compaction-batch-latency.",
+		})
+
 	dbCompactionKeysCounter = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace: "etcd_debugging",
@@ -258,6 +290,54 @@ var (
 	reportCompactRevMu sync.RWMutex
 	reportCompactRev   = func() float64 { return 0 }
 
+	keyBucketRevisionsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "key_bucket_revisions_total",
+			Help:      "Total number of revisions stored in each key bucket.",
+		},
+		[]string{"bucket"},
+	)
+
+	keyBucketSizeGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "key_bucket_size_in_bytes",
+			Help:      "Total size of the revisions stored in each key bucket.",
+		},
+		[]string{"bucket"},
+	)
+
+	keyBucketTombstonesGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "key_bucket_tombstones_total",
+			Help:      "Total number of tombstones pending compaction in each key bucket.",
+		},
+		[]string{"bucket"},
+	)
+
+	dbPurgeKeysCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "db_purge_keys_total",
+			Help:      "Total number of db keys purged ahead of compaction.",
+		})
+
+	eventsByPrefixCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "etcd_debugging",
+			Subsystem: "mvcc",
+			Name:      "events_by_prefix_total",
+			Help:      "Total number of put and delete events by key prefix, with --experimental-watch-event-prefix-metrics.",
+		},
+		[]string{"prefix", "type"},
+	)
+
 	totalPutSizeGauge = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: "etcd_debugging",
@@ -278,11 +358,15 @@ func init() {
 	prometheus.MustRegister(slowWatcherGauge)
 	prometheus.MustRegister(totalEventsCounter)
 	prometheus.MustRegister(pendingEventsGauge)
+	prometheus.MustRegister(eventCacheHitCounter)
+	prometheus.MustRegister(eventCacheMissCounter)
+	prometheus.MustRegister(eventCacheEventsGauge)
 	prometheus.MustRegister(indexCompactionPauseMs)
 	prometheus.MustRegister(dbCompactionPauseMs)
 	prometheus.MustRegister(dbCompactionTotalMs)
 	prometheus.MustRegister(dbCompactionLast)
 	prometheus.MustRegister(dbCompactionKeysCounter)
+	prometheus.MustRegister(dbCompactionBatchLimitGauge)
 	prometheus.MustRegister(dbTotalSize)
 	prometheus.MustRegister(dbTotalSizeInUse)
 	prometheus.MustRegister(dbOpenReadTxN)
@@ -291,6 +375,11 @@ func init() {
 	prometheus.MustRegister(currentRev)
 	prometheus.MustRegister(compactRev)
 	prometheus.MustRegister(totalPutSizeGauge)
+	prometheus.MustRegister(keyBucketRevisionsGauge)
+	prometheus.MustRegister(keyBucketSizeGauge)
+	prometheus.MustRegister(keyBucketTombstonesGauge)
+	prometheus.MustRegister(dbPurgeKeysCounter)
+	prometheus.MustRegister(eventsByPrefixCounter)
 }
 
 // ReportEventReceived reports that an event is received.
@@ -17,6 +17,7 @@ package mvcc
 import (
 	"context"
 
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/server/v3/lease"
 )
 
@@ -41,6 +42,11 @@ func (tw *metricsTxnWrite) Range(ctx context.Context, key, end []byte, ro RangeO
 	return tw.TxnWrite.Range(ctx, key, end, ro)
 }
 
+func (tw *metricsTxnWrite) RangeFunc(ctx context.Context, key, end []byte, ro RangeOptions, f func(kv *mvccpb.KeyValue) bool) (*RangeResult, error) {
+	tw.ranges++
+	return tw.TxnWrite.RangeFunc(ctx, key, end, ro, f)
+}
+
 func (tw *metricsTxnWrite) DeleteRange(key, end []byte) (n, rev int64) {
 	tw.deletes++
 	return tw.TxnWrite.DeleteRange(key, end)
@@ -53,6 +59,20 @@ func (tw *metricsTxnWrite) Put(key, value []byte, lease lease.LeaseID) (rev int6
 	return tw.TxnWrite.Put(key, value, lease)
 }
 
+func (tw *metricsTxnWrite) PutWithMetadata(key, value, metadata []byte, lease lease.LeaseID) (rev int64) {
+	tw.puts++
+	size := int64(len(key) + len(value) + len(metadata))
+	tw.putSize += size
+	return tw.TxnWrite.PutWithMetadata(key, value, metadata, lease)
+}
+
+func (tw *metricsTxnWrite) PutWithTTL(key, value, metadata []byte, lease lease.LeaseID, ttl int64) (rev int64) {
+	tw.puts++
+	size := int64(len(key) + len(value) + len(metadata))
+	tw.putSize += size
+	return tw.TxnWrite.PutWithTTL(key, value, metadata, lease, ttl)
+}
+
 func (tw *metricsTxnWrite) End() {
 	defer tw.TxnWrite.End()
 	if sum := tw.ranges + tw.puts + tw.deletes; sum > 1 {
@@ -15,6 +15,9 @@
 package mvcc
 
 import (
+	"encoding/json"
+
+	"go.etcd.io/bbolt"
 	"go.etcd.io/etcd/server/v3/storage/backend"
 	"go.etcd.io/etcd/server/v3/storage/schema"
 )
@@ -58,3 +61,84 @@ func UnsafeSetFinishedCompact(tx backend.BatchTx, value int64) {
 	revToBytes(revision{main: value}, rbytes)
 	tx.UnsafePut(schema.Meta, schema.FinishedCompactKeyName, rbytes)
 }
+
+// UnsafeReadKeyPrefixBuckets returns the key prefixes whose revisions are
+// stored in a bucket of their own.
+func UnsafeReadKeyPrefixBuckets(tx backend.ReadTx) [][]byte {
+	_, vs := tx.UnsafeRange(schema.Meta, schema.MetaKeyPrefixBucketsName, nil, 0)
+	if len(vs) == 0 {
+		return nil
+	}
+	return decodeKeyPrefixBuckets(vs[0])
+}
+
+// ReadKeyPrefixBucketsFromSnapshot returns the key prefixes whose revisions
+// are stored in a bucket of their own from the given bbolt transaction.
+func ReadKeyPrefixBucketsFromSnapshot(tx *bbolt.Tx) [][]byte {
+	mb := tx.Bucket(schema.Meta.Name())
+	if mb == nil {
+		return nil
+	}
+	v := mb.Get(schema.MetaKeyPrefixBucketsName)
+	if len(v) == 0 {
+		return nil
+	}
+	return decodeKeyPrefixBuckets(v)
+}
+
+func decodeKeyPrefixBuckets(v []byte) [][]byte {
+	var prefixes [][]byte
+	if err := json.Unmarshal(v, &prefixes); err != nil {
+		panic(err)
+	}
+	return prefixes
+}
+
+func UnsafeSetKeyPrefixBuckets(tx backend.BatchTx, prefixes [][]byte) {
+	if len(prefixes) == 0 {
+		tx.UnsafeDelete(schema.Meta, schema.MetaKeyPrefixBucketsName)
+		return
+	}
+	b, err := json.Marshal(prefixes)
+	if err != nil {
+		panic(err)
+	}
+	tx.UnsafePut(schema.Meta, schema.MetaKeyPrefixBucketsName, b)
+}
+
+// purgedRange is a range purged at Revision by a purge applied at
+// AppliedRevision. It is persisted until a compaction covers it, so that the
+// purge resumes on restore and its revisions stay out of the hashes and
+// watches while it is pending.
+type purgedRange struct {
+	Key             []byte `json:"key"`
+	End             []byte `json:"end,omitempty"`
+	Revision        int64  `json:"revision"`
+	AppliedRevision int64  `json:"applied-revision"`
+}
+
+// unsafeReadPurgedRanges returns the purged ranges in the order they were
+// purged.
+func unsafeReadPurgedRanges(tx backend.ReadTx) []purgedRange {
+	_, vs := tx.UnsafeRange(schema.Meta, schema.MetaPurgedRangesName, nil, 0)
+	if len(vs) == 0 {
+		return nil
+	}
+	var purged []purgedRange
+	if err := json.Unmarshal(vs[0], &purged); err != nil {
+		panic(err)
+	}
+	return purged
+}
+
+func unsafeSetPurgedRanges(tx backend.BatchTx, purged []purgedRange) {
+	if len(purged) == 0 {
+		tx.UnsafeDelete(schema.Meta, schema.MetaPurgedRangesName)
+		return
+	}
+	b, err := json.Marshal(purged)
+	if err != nil {
+		panic(err)
+	}
+	tx.UnsafePut(schema.Meta, schema.MetaPurgedRangesName, b)
+}
@@ -16,13 +16,13 @@ package mvcc
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/pkg/v3/traceutil"
 	"go.etcd.io/etcd/server/v3/lease"
 	"go.etcd.io/etcd/server/v3/storage/backend"
-	"go.etcd.io/etcd/server/v3/storage/schema"
 
 	"go.uber.org/zap"
 )
@@ -42,6 +42,8 @@ type watchable interface {
 	watch(key, end []byte, startRev int64, id WatchID, ch chan<- WatchResponse, fcs ...FilterFunc) (*watcher, cancelFunc)
 	progress(w *watcher)
 	rev() int64
+	streamClosed()
+	eventsReceived(n int)
 }
 
 type watchableStore struct {
@@ -62,6 +64,22 @@ type watchableStore struct {
 	// The key of the map is the key that the watcher watches on.
 	synced watcherGroup
 
+	// eventCache serves unsynced watchers that resume within a window of
+	// recent revisions without reading the backend.
+	eventCache eventCache
+
+	// streamBufLen is the length of the event buffers of the new watch
+	// streams as scaled by SetMemoryScale, 0 for chanBufLen.
+	streamBufLen atomic.Int64
+
+	// eventPrefixes counts the events by key prefix, nil if disabled.
+	eventPrefixes *eventPrefixCounter
+
+	// streams, pendingEvents and events are counted for WatchStats.
+	streams       atomic.Int64
+	pendingEvents atomic.Int64
+	events        atomic.Int64
+
 	stopc chan struct{}
 	wg    sync.WaitGroup
 }
@@ -85,6 +103,8 @@ func newWatchableStore(lg *zap.Logger, b backend.Backend, le lease.Lessor, cfg S
 		synced:   newWatcherGroup(),
 		stopc:    make(chan struct{}),
 	}
+	s.eventPrefixes = newEventPrefixCounter(s.store.cfg.EventPrefixMetrics, s.store.cfg.EventPrefixMetricsDepth)
+	s.eventCache.maxEvents = s.store.cfg.WatchEventCacheSize
 	s.store.ReadView = &readView{s}
 	s.store.WriteView = &writeView{s}
 	if s.le != nil {
@@ -105,14 +125,34 @@ func (s *watchableStore) Close() error {
 
 func (s *watchableStore) NewWatchStream() WatchStream {
 	watchStreamGauge.Inc()
+	s.streams.Add(1)
 	return &watchStream{
 		watchable: s,
-		ch:        make(chan WatchResponse, chanBufLen),
+		ch:        make(chan WatchResponse, s.watchStreamBufLen()),
 		cancels:   make(map[WatchID]cancelFunc),
 		watchers:  make(map[WatchID]*watcher),
 	}
 }
 
+func (s *watchableStore) watchStreamBufLen() int {
+	if n := s.streamBufLen.Load(); n != 0 {
+		return int(n)
+	}
+	return chanBufLen
+}
+
+func (s *watchableStore) SetMemoryScale(scale float64) {
+	bufLen := int64(float64(chanBufLen) * scale)
+	if bufLen < 1 {
+		bufLen = 1
+	}
+	s.streamBufLen.Store(bufLen)
+
+	s.mu.Lock()
+	s.eventCache.resize(int(float64(s.store.cfg.WatchEventCacheSize) * scale))
+	s.mu.Unlock()
+}
+
 func (s *watchableStore) watch(key, end []byte, startRev int64, id WatchID, ch chan<- WatchResponse, fcs ...FilterFunc) (*watcher, cancelFunc) {
 	wa := &watcher{
 		key:    key,
@@ -204,6 +244,7 @@ func (s *watchableStore) Restore(b backend.Backend) error {
 		s.unsynced.add(wa)
 	}
 	s.synced = newWatcherGroup()
+	s.eventCache.clear()
 	return nil
 }
 
@@ -279,7 +320,7 @@ func (s *watchableStore) moveVictims() (moved int) {
 			// watcher has observed the store up to, but not including, w.minRev
 			rev := w.minRev - 1
 			if w.send(WatchResponse{WatchID: w.id, Events: eb.evs, Revision: rev}) {
-				pendingEventsGauge.Add(float64(len(eb.evs)))
+				s.eventsSent(len(eb.evs))
 			} else {
 				if newVictim == nil {
 					newVictim = make(watcherBatch)
@@ -345,21 +386,14 @@ func (s *watchableStore) syncWatchers() int {
 	curRev := s.store.currentRev
 	compactionRev := s.store.compactMainRev
 
-	wg, minRev := s.unsynced.choose(maxWatchersPerSync, curRev, compactionRev)
-	minBytes, maxBytes := newRevBytes(), newRevBytes()
-	revToBytes(revision{main: minRev}, minBytes)
-	revToBytes(revision{main: curRev + 1}, maxBytes)
-
-	// UnsafeRange returns keys and values. And in boltdb, keys are revisions.
-	// values are actual key-value pairs in backend.
-	tx := s.store.b.ReadTx()
-	tx.RLock()
-	revs, vs := tx.UnsafeRange(schema.Key, minBytes, maxBytes, 0)
-	evs := kvsToEvents(s.store.lg, wg, revs, vs)
-	// Must unlock after kvsToEvents, because vs (come from boltdb memory) is not deep copy.
-	// We can only unlock after Unmarshal, which will do deep copy.
-	// Otherwise we will trigger SIGSEGV during boltdb re-mmap.
-	tx.RUnlock()
+	wg, minRev := s.unsynced.choose(maxWatchersPerSync, curRev, compactionRev, s.store.purged)
+	var evs []mvccpb.Event
+	if s.eventCache.covers(minRev, curRev) {
+		evs = s.eventCache.rangeEvents(wg, minRev, curRev)
+		eventCacheHitCounter.Inc()
+	} else {
+		evs = s.rangeEvents(wg, minRev, curRev)
+	}
 
 	victims := make(watcherBatch)
 	wb := newWatcherBatch(wg, evs)
@@ -379,7 +413,7 @@ func (s *watchableStore) syncWatchers() int {
 		}
 
 		if w.send(WatchResponse{WatchID: w.id, Events: eb.evs, Revision: curRev}) {
-			pendingEventsGauge.Add(float64(len(eb.evs)))
+			s.eventsSent(len(eb.evs))
 		} else {
 			w.victim = true
 		}
@@ -406,7 +440,48 @@ func (s *watchableStore) syncWatchers() int {
 	return s.unsynced.size()
 }
 
-// kvsToEvents gets all events for the watchers from all key-value pairs
+// rangeEvents reads the events between minRev and curRev, inclusive, for
+// the watchers from the backend. If the event cache is enabled, the whole
+// window is cached so that watchers resuming within it are served from memory.
+func (s *watchableStore) rangeEvents(wg *watcherGroup, minRev, curRev int64) []mvccpb.Event {
+	minBytes, maxBytes := newRevBytes(), newRevBytes()
+	revToBytes(revision{main: minRev}, minBytes)
+	revToBytes(revision{main: curRev + 1}, maxBytes)
+
+	cacheable := s.eventCache.enabled() && minRev <= curRev
+	kwg := wg
+	if cacheable {
+		eventCacheMissCounter.Inc()
+		// keep the events of all keys for the cache
+		kwg = nil
+	}
+
+	// UnsafeRange returns keys and values. And in boltdb, keys are revisions.
+	// values are actual key-value pairs in backend.
+	tx := s.store.b.ReadTx()
+	tx.RLock()
+	revs, vs, _ := s.store.kb.unsafeRange(tx, minBytes, maxBytes, 0)
+	evs := kvsToEvents(s.store.lg, kwg, revs, vs)
+	// Must unlock after kvsToEvents, because vs (come from boltdb memory) is not deep copy.
+	// We can only unlock after Unmarshal, which will do deep copy.
+	// Otherwise we will trigger SIGSEGV during boltdb re-mmap.
+	tx.RUnlock()
+
+	if !cacheable {
+		return evs
+	}
+	s.eventCache.reset(evs, minRev, curRev)
+	var wevs []mvccpb.Event
+	for _, ev := range evs {
+		if wg.contains(string(ev.Kv.Key)) {
+			wevs = append(wevs, ev)
+		}
+	}
+	return wevs
+}
+
+// kvsToEvents gets all events for the watchers from all key-value pairs.
+// If wg is nil, the events of all keys are returned.
 func kvsToEvents(lg *zap.Logger, wg *watcherGroup, revs, vals [][]byte) (evs []mvccpb.Event) {
 	for i, v := range vals {
 		var kv mvccpb.KeyValue
@@ -414,7 +489,7 @@ func kvsToEvents(lg *zap.Logger, wg *watcherGroup, revs, vals [][]byte) (evs []m
 			lg.Panic("failed to unmarshal mvccpb.KeyValue", zap.Error(err))
 		}
 
-		if !wg.contains(string(kv.Key)) {
+		if wg != nil && !wg.contains(string(kv.Key)) {
 			continue
 		}
 
@@ -432,6 +507,7 @@ func kvsToEvents(lg *zap.Logger, wg *watcherGroup, revs, vals [][]byte) (evs []m
 // notify notifies the fact that given event at the given rev just happened to
 // watchers that watch on the key of the event.
 func (s *watchableStore) notify(rev int64, evs []mvccpb.Event) {
+	s.eventCache.append(rev, evs)
 	victim := make(watcherBatch)
 	for w, eb := range newWatcherBatch(&s.synced, evs) {
 		if eb.revs != 1 {
@@ -441,7 +517,7 @@ func (s *watchableStore) notify(rev int64, evs []mvccpb.Event) {
 			)
 		}
 		if w.send(WatchResponse{WatchID: w.id, Events: eb.evs, Revision: rev}) {
-			pendingEventsGauge.Add(float64(len(eb.evs)))
+			s.eventsSent(len(eb.evs))
 		} else {
 			// move slow watcher to victims
 			w.minRev = rev + 1
@@ -467,6 +543,37 @@ func (s *watchableStore) addVictim(victim watcherBatch) {
 
 func (s *watchableStore) rev() int64 { return s.store.Rev() }
 
+func (s *watchableStore) streamClosed() { s.streams.Add(-1) }
+
+// eventsSent counts the events sent to a watcher channel as pending, until
+// they are reported received.
+func (s *watchableStore) eventsSent(n int) {
+	pendingEventsGauge.Add(float64(n))
+	s.pendingEvents.Add(int64(n))
+}
+
+func (s *watchableStore) eventsReceived(n int) {
+	s.pendingEvents.Add(-int64(n))
+	s.events.Add(int64(n))
+}
+
+// WatchStats returns the counters of the watch streams and watchers of the store.
+func (s *watchableStore) WatchStats() WatchStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	victims := 0
+	for _, wb := range s.victims {
+		victims += len(wb)
+	}
+	return WatchStats{
+		Streams:       s.streams.Load(),
+		Watchers:      int64(s.synced.size() + s.unsynced.size() + victims),
+		SlowWatchers:  int64(s.unsynced.size() + victims),
+		PendingEvents: s.pendingEvents.Load(),
+		Events:        s.events.Load(),
+	}
+}
+
 func (s *watchableStore) progress(w *watcher) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/pkg/v3/traceutil"
 	"go.etcd.io/etcd/server/v3/lease"
@@ -135,6 +136,52 @@ func TestCancelUnsynced(t *testing.T) {
 	}
 }
 
+func TestWatchStats(t *testing.T) {
+	b, tmpPath := betesting.NewDefaultTmpBackend(t)
+
+	// manually create watchableStore to keep the watcher in unsynced
+	s := &watchableStore{
+		store:    NewStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{}),
+		unsynced: newWatcherGroup(),
+		synced:   newWatcherGroup(),
+	}
+
+	defer func() {
+		s.store.Close()
+		os.Remove(tmpPath)
+	}()
+
+	testKey, testValue := []byte("foo"), []byte("bar")
+	s.Put(testKey, testValue, lease.NoLease)
+
+	w := s.NewWatchStream()
+	w.Watch(0, testKey, nil, 0)
+	// use 1 to keep the watcher in unsynced
+	w.Watch(0, testKey, nil, 1)
+
+	if st, want := s.WatchStats(), (WatchStats{Streams: 1, Watchers: 2, SlowWatchers: 1}); st != want {
+		t.Fatalf("stats = %+v, want %+v", st, want)
+	}
+
+	txn := s.Write(traceutil.TODO())
+	txn.Put(testKey, testValue, lease.NoLease)
+	txn.End()
+	if st, want := s.WatchStats(), (WatchStats{Streams: 1, Watchers: 2, SlowWatchers: 1, PendingEvents: 1}); st != want {
+		t.Fatalf("stats after put = %+v, want %+v", st, want)
+	}
+
+	wr := <-w.Chan()
+	w.ReportEventsReceived(len(wr.Events))
+	if st, want := s.WatchStats(), (WatchStats{Streams: 1, Watchers: 2, SlowWatchers: 1, Events: 1}); st != want {
+		t.Fatalf("stats after receive = %+v, want %+v", st, want)
+	}
+
+	w.Close()
+	if st, want := s.WatchStats(), (WatchStats{Events: 1}); st != want {
+		t.Fatalf("stats after close = %+v, want %+v", st, want)
+	}
+}
+
 // TestSyncWatchers populates unsynced watcher map and tests syncWatchers
 // method to see if it correctly sends events to channel of unsynced watchers
 // and moves these watchers to synced.
@@ -398,6 +445,53 @@ func TestWatchRestoreSyncedWatcher(t *testing.T) {
 	}
 }
 
+// TestWatchEventTraceContext ensures the events of a write txn carry its
+// trace context to the synced watchers.
+func TestWatchEventTraceContext(t *testing.T) {
+	b, tmpPath := betesting.NewDefaultTmpBackend(t)
+	s := newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{})
+
+	defer func() {
+		b.Close()
+		s.Close()
+		os.Remove(tmpPath)
+	}()
+
+	w := s.NewWatchStream()
+	defer w.Close()
+	w.Watch(0, []byte("foo"), []byte("fop"), 0)
+
+	traceContext := map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	txn := s.Write(traceutil.TODO())
+	SetEventTraceContext(txn, traceContext)
+	txn.Put([]byte("foo1"), []byte("bar"), lease.NoLease)
+	txn.Put([]byte("foo2"), []byte("bar"), lease.NoLease)
+	txn.End()
+	s.Put([]byte("foo3"), []byte("bar"), lease.NoLease)
+
+	select {
+	case resp := <-w.Chan():
+		if len(resp.Events) != 2 {
+			t.Fatalf("len(events) = %d, want 2", len(resp.Events))
+		}
+		for _, ev := range resp.Events {
+			if !reflect.DeepEqual(ev.TraceContext, traceContext) {
+				t.Errorf("trace context = %v, want %v", ev.TraceContext, traceContext)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed to receive the events of the txn")
+	}
+	select {
+	case resp := <-w.Chan():
+		if tc := resp.Events[0].TraceContext; tc != nil {
+			t.Errorf("trace context = %v, want none", tc)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed to receive the event of the put")
+	}
+}
+
 // TestWatchBatchUnsynced tests batching on unsynced watchers
 func TestWatchBatchUnsynced(t *testing.T) {
 	b, tmpPath := betesting.NewDefaultTmpBackend(t)
@@ -656,3 +750,87 @@ func TestStressWatchCancelClose(t *testing.T) {
 
 	wg.Wait()
 }
+
+// TestSyncWatchersEventCache ensures that watchers resuming within the
+// cached window are served from the watch event cache.
+func TestSyncWatchersEventCache(t *testing.T) {
+	b, tmpPath := betesting.NewDefaultTmpBackend(t)
+	s := newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{WatchEventCacheSize: 10})
+	defer cleanup(s, b, tmpPath)
+
+	testKey, testValue := []byte("foo"), []byte("bar")
+	for i := 0; i < 3; i++ {
+		s.Put(testKey, testValue, lease.NoLease)
+	}
+	s.Put([]byte("other"), testValue, lease.NoLease)
+
+	w := s.NewWatchStream()
+	defer w.Close()
+
+	// first resume reads the backend and seeds the cache
+	w.Watch(0, testKey, nil, 2)
+	s.syncWatchers()
+	if s.eventCache.minRev != 2 || s.eventCache.maxRev != 5 || s.eventCache.n != 4 {
+		t.Fatalf("cache window = [%d, %d] with %d events, want [2, 5] with 4 events", s.eventCache.minRev, s.eventCache.maxRev, s.eventCache.n)
+	}
+	resp := <-w.Chan()
+	if len(resp.Events) != 3 {
+		t.Fatalf("len(events) = %d, want 3", len(resp.Events))
+	}
+
+	// new writes extend the cached window
+	s.Put(testKey, testValue, lease.NoLease)
+	<-w.Chan()
+	if s.eventCache.maxRev != 6 {
+		t.Fatalf("cache max revision = %d, want 6", s.eventCache.maxRev)
+	}
+
+	// second resume is served from the cache
+	hits := testutil.ToFloat64(eventCacheHitCounter)
+	w.Watch(0, testKey, nil, 3)
+	s.syncWatchers()
+	if got := testutil.ToFloat64(eventCacheHitCounter); got != hits+1 {
+		t.Fatalf("cache hits = %v, want %v", got, hits+1)
+	}
+	resp = <-w.Chan()
+	wrevs := []int64{3, 4, 6}
+	if len(resp.Events) != len(wrevs) {
+		t.Fatalf("len(events) = %d, want %d", len(resp.Events), len(wrevs))
+	}
+	for i, ev := range resp.Events {
+		if ev.Kv.ModRevision != wrevs[i] {
+			t.Errorf("#%d: mod revision = %d, want %d", i, ev.Kv.ModRevision, wrevs[i])
+		}
+		if !bytes.Equal(ev.Kv.Key, testKey) {
+			t.Errorf("#%d: key = %s, want %s", i, ev.Kv.Key, testKey)
+		}
+	}
+}
+
+// TestWatchableStoreSetMemoryScale ensures SetMemoryScale shrinks the event
+// cache and the event buffers of the new watch streams.
+func TestWatchableStoreSetMemoryScale(t *testing.T) {
+	b, tmpPath := betesting.NewDefaultTmpBackend(t)
+	s := newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{WatchEventCacheSize: 10})
+	defer cleanup(s, b, tmpPath)
+
+	s.SetMemoryScale(0.5)
+	w := s.NewWatchStream()
+	defer w.Close()
+	if c := cap(w.Chan()); c != chanBufLen/2 {
+		t.Errorf("cap(watch stream buffer) = %d, want %d", c, chanBufLen/2)
+	}
+	if s.eventCache.maxEvents != 5 {
+		t.Errorf("event cache size = %d, want 5", s.eventCache.maxEvents)
+	}
+
+	s.SetMemoryScale(1)
+	w2 := s.NewWatchStream()
+	defer w2.Close()
+	if c := cap(w2.Chan()); c != chanBufLen {
+		t.Errorf("cap(watch stream buffer) = %d, want %d", c, chanBufLen)
+	}
+	if s.eventCache.maxEvents != 10 {
+		t.Errorf("event cache size = %d, want 10", s.eventCache.maxEvents)
+	}
+}
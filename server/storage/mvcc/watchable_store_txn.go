@@ -36,6 +36,10 @@ func (tw *watchableStoreTxnWrite) End() {
 		} else {
 			evs[i].Type = mvccpb.PUT
 		}
+		evs[i].TraceContext = tw.traceContext
+	}
+	if tw.s.eventPrefixes != nil {
+		tw.s.eventPrefixes.observe(evs)
 	}
 
 	// end write txn under watchable store lock so the updates are visible
@@ -49,8 +53,18 @@ func (tw *watchableStoreTxnWrite) End() {
 type watchableStoreTxnWrite struct {
 	TxnWrite
 	s *watchableStore
+	// traceContext is the trace context carried by the events of the txn.
+	traceContext map[string]string
 }
 
 func (s *watchableStore) Write(trace *traceutil.Trace) TxnWrite {
-	return &watchableStoreTxnWrite{s.store.Write(trace), s}
+	return &watchableStoreTxnWrite{TxnWrite: s.store.Write(trace), s: s}
+}
+
+// SetEventTraceContext sets the trace context carried by the events of a
+// write txn of a watchable store to the watchers. It is not stored.
+func SetEventTraceContext(txn TxnWrite, traceContext map[string]string) {
+	if tw, ok := txn.(*watchableStoreTxnWrite); ok {
+		tw.traceContext = traceContext
+	}
 }
@@ -67,6 +67,10 @@ type WatchStream interface {
 
 	// Rev returns the current revision of the KV the stream watches on.
 	Rev() int64
+
+	// ReportEventsReceived reports that n events sent through Chan were
+	// received, so that they are no longer counted as pending.
+	ReportEventsReceived(n int)
 }
 
 type WatchResponse struct {
@@ -171,6 +175,12 @@ func (ws *watchStream) Close() {
 	ws.closed = true
 	close(ws.ch)
 	watchStreamGauge.Dec()
+	ws.watchable.streamClosed()
+}
+
+func (ws *watchStream) ReportEventsReceived(n int) {
+	ReportEventReceived(n)
+	ws.watchable.eventsReceived(n)
 }
 
 func (ws *watchStream) Rev() int64 {
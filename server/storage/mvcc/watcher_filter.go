@@ -0,0 +1,90 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// NewValuePrefixFilter returns a FilterFunc filtering out the put events
+// whose value does not start with prefix.
+func NewValuePrefixFilter(prefix []byte) FilterFunc {
+	return newValueFilter(func(v []byte) bool { return bytes.HasPrefix(v, prefix) })
+}
+
+// NewValueRegexFilter returns a FilterFunc filtering out the put events whose
+// value contains no match of the regular expression expr.
+func NewValueRegexFilter(expr string) (FilterFunc, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("mvcc: invalid value filter regular expression (%v)", err)
+	}
+	return newValueFilter(re.Match), nil
+}
+
+// NewValueJSONFieldFilter returns a FilterFunc filtering out the put events
+// whose value is not a JSON object with the field at the dot-separated path
+// field equal to value. String fields are compared to value itself, and the
+// other fields to their compact JSON encoding, such as "3" or "true".
+func NewValueJSONFieldFilter(field string, value []byte) (FilterFunc, error) {
+	if field == "" {
+		return nil, fmt.Errorf("mvcc: empty value filter JSON field")
+	}
+	path := strings.Split(field, ".")
+	return newValueFilter(func(v []byte) bool {
+		f, ok := jsonField(v, path)
+		return ok && bytes.Equal(f, value)
+	}), nil
+}
+
+// newValueFilter returns a FilterFunc filtering out the put events whose
+// value does not match. Delete events carry no value and are kept.
+func newValueFilter(match func(v []byte) bool) FilterFunc {
+	return func(e mvccpb.Event) bool {
+		return e.Type == mvccpb.PUT && !match(e.Kv.Value)
+	}
+}
+
+// jsonField returns the field at path of the JSON object v, as a string for
+// string fields and as compact JSON otherwise.
+func jsonField(v []byte, path []string) ([]byte, bool) {
+	dec := json.NewDecoder(bytes.NewReader(v))
+	// numbers are compared as written
+	dec.UseNumber()
+	var f interface{}
+	if err := dec.Decode(&f); err != nil {
+		return nil, false
+	}
+	for _, name := range path {
+		obj, ok := f.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if f, ok = obj[name]; !ok {
+			return nil, false
+		}
+	}
+	if s, ok := f.(string); ok {
+		return []byte(s), true
+	}
+	b, err := json.Marshal(f)
+	return b, err == nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/server/v3/lease"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestValueFilters(t *testing.T) {
+	mustFilter := func(f FilterFunc, err error) FilterFunc {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	tests := []struct {
+		name   string
+		filter FilterFunc
+		value  string
+
+		wfiltered bool
+	}{
+		{"prefix match", NewValuePrefixFilter([]byte("ab")), "abc", false},
+		{"prefix mismatch", NewValuePrefixFilter([]byte("ab")), "ba", true},
+		{"regex match", mustFilter(NewValueRegexFilter("^[0-9]+$")), "123", false},
+		{"regex mismatch", mustFilter(NewValueRegexFilter("^[0-9]+$")), "12a", true},
+		{"json string field", mustFilter(NewValueJSONFieldFilter("status", []byte("ready"))), `{"status": "ready"}`, false},
+		{"json nested field", mustFilter(NewValueJSONFieldFilter("spec.replicas", []byte("3"))), `{"spec": {"replicas": 3}}`, false},
+		{"json bool field", mustFilter(NewValueJSONFieldFilter("on", []byte("true"))), `{"on": true}`, false},
+		{"json other value", mustFilter(NewValueJSONFieldFilter("status", []byte("ready"))), `{"status": "pending"}`, true},
+		{"json quoted string", mustFilter(NewValueJSONFieldFilter("status", []byte(`"ready"`))), `{"status": "ready"}`, true},
+		{"json missing field", mustFilter(NewValueJSONFieldFilter("spec.replicas", []byte("3"))), `{"spec": 3}`, true},
+		{"not json", mustFilter(NewValueJSONFieldFilter("status", []byte("ready"))), "ready", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			put := mvccpb.Event{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("foo"), Value: []byte(tt.value)}}
+			if filtered := tt.filter(put); filtered != tt.wfiltered {
+				t.Errorf("filtered = %v, want %v", filtered, tt.wfiltered)
+			}
+			// delete events carry no value
+			del := mvccpb.Event{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte("foo")}}
+			if tt.filter(del) {
+				t.Error("expected delete event not to be filtered")
+			}
+		})
+	}
+
+	if _, err := NewValueRegexFilter("("); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+	if _, err := NewValueJSONFieldFilter("", nil); err == nil {
+		t.Error("expected an error for an empty JSON field")
+	}
+}
+
+// TestWatcherWatchWithValueFilter ensures both synced and unsynced watchers
+// only receive the put events whose value matches their value filters.
+func TestWatcherWatchWithValueFilter(t *testing.T) {
+	b, tmpPath := betesting.NewDefaultTmpBackend(t)
+	s := WatchableKV(newWatchableStore(zaptest.NewLogger(t), b, &lease.FakeLessor{}, StoreConfig{}))
+	defer cleanup(s, b, tmpPath)
+
+	w := s.NewWatchStream()
+	defer w.Close()
+
+	s.Put([]byte("foo"), []byte("a1"), lease.NoLease)
+	s.Put([]byte("foo"), []byte("b1"), lease.NoLease)
+
+	f := NewValuePrefixFilter([]byte("a"))
+	syncedID, _ := w.Watch(0, []byte("foo"), nil, 0, f)
+	unsyncedID, _ := w.Watch(0, []byte("foo"), nil, 1, f)
+
+	s.Put([]byte("foo"), []byte("b2"), lease.NoLease)
+	s.Put([]byte("foo"), []byte("a2"), lease.NoLease)
+
+	wvals := map[WatchID][]string{syncedID: {"a2"}, unsyncedID: {"a1", "a2"}}
+	vals := make(map[WatchID][]string)
+	timeout := time.After(5 * time.Second)
+	for len(vals[syncedID]) < 1 || len(vals[unsyncedID]) < 2 {
+		select {
+		case resp := <-w.Chan():
+			for _, ev := range resp.Events {
+				vals[resp.WatchID] = append(vals[resp.WatchID], string(ev.Kv.Value))
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for events, got %v", vals)
+		}
+	}
+	if !reflect.DeepEqual(vals, wvals) {
+		t.Errorf("got values %v, want %v", vals, wvals)
+	}
+}
@@ -221,9 +221,9 @@ func (wg *watcherGroup) delete(wa *watcher) bool {
 }
 
 // choose selects watchers from the watcher group to update
-func (wg *watcherGroup) choose(maxWatchers int, curRev, compactRev int64) (*watcherGroup, int64) {
+func (wg *watcherGroup) choose(maxWatchers int, curRev, compactRev int64, purged []purgedRange) (*watcherGroup, int64) {
 	if len(wg.watchers) < maxWatchers {
-		return wg, wg.chooseAll(curRev, compactRev)
+		return wg, wg.chooseAll(curRev, compactRev, purged)
 	}
 	ret := newWatcherGroup()
 	for w := range wg.watchers {
@@ -233,10 +233,10 @@ func (wg *watcherGroup) choose(maxWatchers int, curRev, compactRev int64) (*watc
 		maxWatchers--
 		ret.add(w)
 	}
-	return &ret, ret.chooseAll(curRev, compactRev)
+	return &ret, ret.chooseAll(curRev, compactRev, purged)
 }
 
-func (wg *watcherGroup) chooseAll(curRev, compactRev int64) int64 {
+func (wg *watcherGroup) chooseAll(curRev, compactRev int64, purged []purgedRange) int64 {
 	minRev := int64(math.MaxInt64)
 	for w := range wg.watchers {
 		if w.minRev > curRev {
@@ -250,9 +250,14 @@ func (wg *watcherGroup) chooseAll(curRev, compactRev int64) int64 {
 			// mark 'restore' done, since it's chosen
 			w.restore = false
 		}
-		if w.minRev < compactRev {
+		// the watchers of a purged range are compacted up to the purge.
+		wcompactRev := compactRev
+		if rev := purgedRev(purged, w.key, w.end); rev > wcompactRev {
+			wcompactRev = rev
+		}
+		if w.minRev < wcompactRev {
 			select {
-			case w.ch <- WatchResponse{WatchID: w.id, CompactRevision: compactRev}:
+			case w.ch <- WatchResponse{WatchID: w.id, CompactRevision: wcompactRev}:
 				w.compacted = true
 				wg.delete(w)
 			default:
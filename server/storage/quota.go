@@ -19,6 +19,7 @@ import (
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.etcd.io/etcd/server/v3/storage/mvcc"
 
 	humanize "github.com/dustin/go-humanize"
 	"go.uber.org/zap"
@@ -65,7 +66,8 @@ const (
 
 var (
 	// only log once
-	quotaLogOnce sync.Once
+	quotaLogOnce    sync.Once
+	keyQuotaLogOnce sync.Once
 
 	DefaultQuotaSize = humanize.Bytes(uint64(DefaultQuotaBytes))
 	maxQuotaSize     = humanize.Bytes(uint64(MaxQuotaBytes))
@@ -174,3 +176,74 @@ func costTxn(r *pb.TxnRequest) int {
 func (b *BackendQuota) Remaining() int64 {
 	return b.maxBackendBytes - b.be.Size()
 }
+
+// KeyQuota is a quota on the number of keys of the store. Like the index of
+// the store, it counts the deleted keys until they are compacted.
+type KeyQuota struct {
+	kv      mvcc.KV
+	maxKeys int64
+}
+
+// NewKeyQuota creates a quota layer with the given limit on the number of
+// keys. The quota is disabled if the limit is not positive.
+func NewKeyQuota(lg *zap.Logger, maxKeysCfg int64, kv mvcc.KV, name string) Quota {
+	if maxKeysCfg <= 0 {
+		quotaKeys.Set(0)
+		return &passthroughQuota{}
+	}
+	quotaKeys.Set(float64(maxKeysCfg))
+	keyQuotaLogOnce.Do(func() {
+		lg.Info(
+			"enabled key quota",
+			zap.String("quota-name", name),
+			zap.Int64("quota-keys", maxKeysCfg),
+		)
+	})
+	return &KeyQuota{kv, maxKeysCfg}
+}
+
+func (q *KeyQuota) Available(v interface{}) bool {
+	cost := q.Cost(v)
+	// if the request creates no key, it's safe to pass through
+	if cost == 0 {
+		return true
+	}
+	return int64(q.kv.KeyCount()+cost) <= q.maxKeys
+}
+
+// Cost returns the number of keys the request may create.
+func (q *KeyQuota) Cost(v interface{}) int {
+	switch r := v.(type) {
+	case *pb.PutRequest:
+		return 1
+	case *pb.TxnRequest:
+		return keysTxn(r)
+	case *pb.LeaseGrantRequest:
+		return 0
+	default:
+		panic("unexpected cost")
+	}
+}
+
+func keysTxn(r *pb.TxnRequest) int {
+	keysSuccess := 0
+	for _, u := range r.Success {
+		if u.GetRequestPut() != nil {
+			keysSuccess++
+		}
+	}
+	keysFailure := 0
+	for _, u := range r.Failure {
+		if u.GetRequestPut() != nil {
+			keysFailure++
+		}
+	}
+	if keysFailure > keysSuccess {
+		return keysFailure
+	}
+	return keysSuccess
+}
+
+func (q *KeyQuota) Remaining() int64 {
+	return q.maxKeys - int64(q.kv.KeyCount())
+}
@@ -0,0 +1,45 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+// PutLeaseOwner records the user who granted the lease. Unlike the other
+// writes of the auth store it does not force a commit, since leases are
+// granted far more often than auth is configured.
+func (abe *authBackend) PutLeaseOwner(leaseID int64, owner string) {
+	tx := abe.be.BatchTx()
+	tx.LockInsideApply()
+	defer tx.Unlock()
+	tx.UnsafePut(AuthLeases, leaseIdToBytes(leaseID), []byte(owner))
+}
+
+func (abe *authBackend) DeleteLeaseOwner(leaseID int64) {
+	tx := abe.be.BatchTx()
+	tx.LockInsideApply()
+	defer tx.Unlock()
+	tx.UnsafeDelete(AuthLeases, leaseIdToBytes(leaseID))
+}
+
+func (atx *authBatchTx) UnsafeGetLeaseOwner(leaseID int64) string {
+	arx := &authReadTx{tx: atx.tx, lg: atx.lg}
+	return arx.UnsafeGetLeaseOwner(leaseID)
+}
+
+func (atx *authReadTx) UnsafeGetLeaseOwner(leaseID int64) string {
+	_, vs := atx.tx.UnsafeRange(AuthLeases, leaseIdToBytes(leaseID), nil, 0)
+	if len(vs) == 0 {
+		return ""
+	}
+	return string(vs[0])
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	betesting "go.etcd.io/etcd/server/v3/storage/backend/testing"
+)
+
+func TestGetLeaseOwner(t *testing.T) {
+	tcs := []struct {
+		name  string
+		setup func(abe *authBackend)
+		want  string
+	}{
+		{
+			name:  "Returns empty for missing",
+			setup: func(abe *authBackend) {},
+			want:  "",
+		},
+		{
+			name: "Returns data put before",
+			setup: func(abe *authBackend) {
+				abe.PutLeaseOwner(1, "user1")
+			},
+			want: "user1",
+		},
+		{
+			name: "Returns empty for deleted",
+			setup: func(abe *authBackend) {
+				abe.PutLeaseOwner(1, "user1")
+				abe.DeleteLeaseOwner(1)
+			},
+			want: "",
+		},
+		{
+			name: "Ignores other leases",
+			setup: func(abe *authBackend) {
+				abe.PutLeaseOwner(2, "user2")
+			},
+			want: "",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := zaptest.NewLogger(t)
+			be, tmpPath := betesting.NewTmpBackend(t, time.Microsecond, 10)
+			abe := NewAuthBackend(lg, be)
+			abe.CreateAuthBuckets()
+
+			tc.setup(abe)
+
+			abe.ForceCommit()
+			be.Close()
+
+			be2 := backend.NewDefaultBackend(lg, tmpPath)
+			defer be2.Close()
+			tx := NewAuthBackend(lg, be2).ReadTx()
+			tx.Lock()
+			defer tx.Unlock()
+
+			assert.Equal(t, tc.want, tx.UnsafeGetLeaseOwner(1))
+		})
+	}
+}
@@ -26,15 +26,17 @@ var (
 	metaBucketName  = []byte("meta")
 	leaseBucketName = []byte("lease")
 	alarmBucketName = []byte("alarm")
+	pinBucketName   = []byte("pin")
 
 	clusterBucketName = []byte("cluster")
 
 	membersBucketName        = []byte("members")
 	membersRemovedBucketName = []byte("members_removed")
 
-	authBucketName      = []byte("auth")
-	authUsersBucketName = []byte("authUsers")
-	authRolesBucketName = []byte("authRoles")
+	authBucketName       = []byte("auth")
+	authUsersBucketName  = []byte("authUsers")
+	authRolesBucketName  = []byte("authRoles")
+	authLeasesBucketName = []byte("authLeases")
 
 	testBucketName = []byte("test")
 )
@@ -45,17 +47,29 @@ var (
 	Lease   = backend.Bucket(bucket{id: 3, name: leaseBucketName, safeRangeBucket: false})
 	Alarm   = backend.Bucket(bucket{id: 4, name: alarmBucketName, safeRangeBucket: false})
 	Cluster = backend.Bucket(bucket{id: 5, name: clusterBucketName, safeRangeBucket: false})
+	Pin     = backend.Bucket(bucket{id: 6, name: pinBucketName, safeRangeBucket: false})
 
 	Members        = backend.Bucket(bucket{id: 10, name: membersBucketName, safeRangeBucket: false})
 	MembersRemoved = backend.Bucket(bucket{id: 11, name: membersRemovedBucketName, safeRangeBucket: false})
 
-	Auth      = backend.Bucket(bucket{id: 20, name: authBucketName, safeRangeBucket: false})
-	AuthUsers = backend.Bucket(bucket{id: 21, name: authUsersBucketName, safeRangeBucket: false})
-	AuthRoles = backend.Bucket(bucket{id: 22, name: authRolesBucketName, safeRangeBucket: false})
+	Auth       = backend.Bucket(bucket{id: 20, name: authBucketName, safeRangeBucket: false})
+	AuthUsers  = backend.Bucket(bucket{id: 21, name: authUsersBucketName, safeRangeBucket: false})
+	AuthRoles  = backend.Bucket(bucket{id: 22, name: authRolesBucketName, safeRangeBucket: false})
+	AuthLeases = backend.Bucket(bucket{id: 23, name: authLeasesBucketName, safeRangeBucket: false})
 
 	Test = backend.Bucket(bucket{id: 100, name: testBucketName, safeRangeBucket: false})
 )
 
+// keyPrefixBucketID is the ID of the first bucket returned by KeyPrefixBucket.
+const keyPrefixBucketID = backend.BucketID(1000)
+
+// KeyPrefixBucket returns the i-th bucket storing the revisions of the keys
+// with the given prefix apart from the Key bucket.
+func KeyPrefixBucket(i int, prefix []byte) backend.Bucket {
+	name := append(append(append([]byte{}, keyBucketName...), '/'), prefix...)
+	return bucket{id: keyPrefixBucketID + backend.BucketID(i), name: name, safeRangeBucket: true}
+}
+
 type bucket struct {
 	id              backend.BucketID
 	name            []byte
@@ -80,7 +94,10 @@ var (
 	ClusterClusterVersionKeyName = []byte("clusterVersion")
 	ClusterDowngradeKeyName      = []byte("downgrade")
 	// Since v3.6
-	MetaStorageVersionName = []byte("storageVersion")
+	MetaStorageVersionName     = []byte("storageVersion")
+	ClusterClusterEpochKeyName = []byte("clusterEpoch")
+	MetaKeyPrefixBucketsName   = []byte("keyPrefixBuckets")
+	MetaPurgedRangesName       = []byte("purgedRanges")
 	// Before adding new meta key please update server/etcdserver/version
 )
 
@@ -89,6 +106,10 @@ func DefaultIgnores(bucket, key []byte) bool {
 	// consistent index & term might be changed due to v2 internal sync, which
 	// is not controllable by the user.
 	// storage version might change after wal snapshot and is not controller by user.
+	// cluster epoch is written outside of raft by restore and joining members.
+	if bytes.Compare(bucket, Cluster.Name()) == 0 {
+		return bytes.Compare(key, ClusterClusterEpochKeyName) == 0
+	}
 	return bytes.Compare(bucket, Meta.Name()) == 0 &&
 		(bytes.Compare(key, MetaTermKeyName) == 0 || bytes.Compare(key, MetaConsistentIndexKeyName) == 0 || bytes.Compare(key, MetaStorageVersionName) == 0)
 }
@@ -17,6 +17,7 @@ package schema
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"go.etcd.io/etcd/client/pkg/v3/types"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
@@ -165,6 +166,24 @@ func (s *membershipBackend) MustSaveDowngradeToBackend(downgrade *version.Downgr
 	tx.UnsafePut(Cluster, dkey, dvalue)
 }
 
+// MustSaveClusterEpochToBackend saves the cluster epoch to backend.
+// The field is populated since etcd v3.6.
+func (s *membershipBackend) MustSaveClusterEpochToBackend(epoch uint64) {
+	tx := s.be.BatchTx()
+	tx.LockOutsideApply()
+	defer tx.Unlock()
+	tx.UnsafePut(Cluster, ClusterClusterEpochKeyName, []byte(strconv.FormatUint(epoch, 10)))
+}
+
+// MustSaveBumpedClusterEpochToBackend saves the cluster epoch bumped while
+// applying a raft entry.
+func (s *membershipBackend) MustSaveBumpedClusterEpochToBackend(epoch uint64) {
+	tx := s.be.BatchTx()
+	tx.LockInsideApply()
+	defer tx.Unlock()
+	tx.UnsafePut(Cluster, ClusterClusterEpochKeyName, []byte(strconv.FormatUint(epoch, 10)))
+}
+
 func (s *membershipBackend) MustCreateBackendBuckets() {
 	tx := s.be.BatchTx()
 	tx.LockOutsideApply()
@@ -202,6 +221,23 @@ func (s *membershipBackend) ClusterVersionFromBackend() *semver.Version {
 	return semver.Must(semver.NewVersion(string(vals[0])))
 }
 
+// ClusterEpochFromBackend reads the cluster epoch from backend.
+// The field is populated since etcd v3.6; a backend without it is at epoch 1.
+func (s *membershipBackend) ClusterEpochFromBackend() uint64 {
+	tx := s.be.ReadTx()
+	tx.RLock()
+	defer tx.RUnlock()
+	_, vals := tx.UnsafeRange(Cluster, ClusterClusterEpochKeyName, nil, 0)
+	if len(vals) == 0 {
+		return 1
+	}
+	epoch, err := strconv.ParseUint(string(vals[0]), 10, 64)
+	if err != nil {
+		s.lg.Panic("failed to parse cluster epoch from backend", zap.Error(err))
+	}
+	return epoch
+}
+
 // DowngradeInfoFromBackend reads downgrade info from backend.
 // The field is populated since etcd v3.5.
 func (s *membershipBackend) DowngradeInfoFromBackend() *version.DowngradeInfo {
@@ -0,0 +1,88 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/binary"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/storage/backend"
+	"go.uber.org/zap"
+)
+
+type pinBackend struct {
+	lg *zap.Logger
+	be backend.Backend
+}
+
+func NewPinBackend(lg *zap.Logger, be backend.Backend) *pinBackend {
+	return &pinBackend{
+		lg: lg,
+		be: be,
+	}
+}
+
+func (s *pinBackend) CreatePinBucket() {
+	tx := s.be.BatchTx()
+	tx.LockOutsideApply()
+	defer tx.Unlock()
+	tx.UnsafeCreateBucket(Pin)
+}
+
+func (s *pinBackend) MustPutPin(pin *etcdserverpb.PinRevisionRequest) {
+	v, err := pin.Marshal()
+	if err != nil {
+		s.lg.Panic("failed to marshal pin", zap.Error(err))
+	}
+
+	tx := s.be.BatchTx()
+	tx.LockInsideApply()
+	defer tx.Unlock()
+	tx.UnsafePut(Pin, pinIDToBytes(pin.ID), v)
+}
+
+func (s *pinBackend) MustDeletePin(id int64) {
+	tx := s.be.BatchTx()
+	tx.LockInsideApply()
+	defer tx.Unlock()
+	tx.UnsafeDelete(Pin, pinIDToBytes(id))
+}
+
+func (s *pinBackend) GetAllPins() ([]*etcdserverpb.PinRevisionRequest, error) {
+	tx := s.be.ReadTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	var pins []*etcdserverpb.PinRevisionRequest
+	err := tx.UnsafeForEach(Pin, func(k, v []byte) error {
+		var pin etcdserverpb.PinRevisionRequest
+		if err := pin.Unmarshal(v); err != nil {
+			return err
+		}
+		pins = append(pins, &pin)
+		return nil
+	})
+	return pins, err
+}
+
+func (s *pinBackend) ForceCommit() {
+	s.be.ForceCommit()
+}
+
+func pinIDToBytes(id int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b
+}
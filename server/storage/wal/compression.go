@@ -0,0 +1,67 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/klauspost/compress/s2"
+
+	"go.etcd.io/etcd/server/v3/storage/compression"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+)
+
+// The data of an entry record of at least minCompressBytes is compressed
+// with the compression of the WAL, if it gets smaller. The record keeps the
+// crc of the uncompressed data, so the decoder checks it once decompressed.
+// Segments can mix compressed and uncompressed records, and a WAL written
+// without compression is replayed as is.
+const minCompressBytes = 256
+
+// ParseCompression returns the compression of its name, "none", "snappy" or
+// "zstd". The empty name is no compression.
+func ParseCompression(name string) (walpb.Compression, error) {
+	if name == "" {
+		return walpb.Compression_NONE, nil
+	}
+	c, ok := walpb.Compression_value[strings.ToUpper(name)]
+	if !ok {
+		return walpb.Compression_NONE, fmt.Errorf("unknown WAL compression %q", name)
+	}
+	return walpb.Compression(c), nil
+}
+
+func compress(c walpb.Compression, data []byte) []byte {
+	switch c {
+	case walpb.Compression_SNAPPY:
+		return s2.EncodeSnappy(nil, data)
+	case walpb.Compression_ZSTD:
+		return compression.ZstdEncode(data)
+	}
+	return data
+}
+
+func decompress(c walpb.Compression, data []byte) ([]byte, error) {
+	switch c {
+	case walpb.Compression_NONE:
+		return data, nil
+	case walpb.Compression_SNAPPY:
+		return s2.Decode(nil, data)
+	case walpb.Compression_ZSTD:
+		return compression.ZstdDecode(data)
+	}
+	return nil, fmt.Errorf("wal: unknown record compression %d", c)
+}
@@ -40,6 +40,8 @@ type decoder struct {
 	// lastValidOff file offset following the last valid decoded record
 	lastValidOff int64
 	crc          hash.Hash32
+	// compression of the last decoded record
+	compression walpb.Compression
 }
 
 func newDecoder(r ...fileutil.FileReader) *decoder {
@@ -104,6 +106,18 @@ func (d *decoder) decodeRecord(rec *walpb.Record) error {
 		return err
 	}
 
+	d.compression = walpb.Compression_NONE
+	if rec.Compression != nil {
+		d.compression = *rec.Compression
+		if rec.Data, err = decompress(*rec.Compression, rec.Data); err != nil {
+			if d.isTornEntry(data) {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		rec.Compression = nil
+	}
+
 	// skip crc checking if the record type is crcType
 	if rec.Type != crcType {
 		d.crc.Write(rec.Data)
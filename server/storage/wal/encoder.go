@@ -38,6 +38,9 @@ type encoder struct {
 	crc       hash.Hash32
 	buf       []byte
 	uint64buf []byte
+
+	// compression of the data of the entry records
+	compression walpb.Compression
 }
 
 func newEncoder(w io.Writer, prevCrc uint32, pageOffset int) *encoder {
@@ -51,12 +54,14 @@ func newEncoder(w io.Writer, prevCrc uint32, pageOffset int) *encoder {
 }
 
 // newFileEncoder creates a new encoder with current file offset for the page writer.
-func newFileEncoder(f *os.File, prevCrc uint32) (*encoder, error) {
+func newFileEncoder(f *os.File, prevCrc uint32, compression walpb.Compression) (*encoder, error) {
 	offset, err := f.Seek(0, io.SeekCurrent)
 	if err != nil {
 		return nil, err
 	}
-	return newEncoder(f, prevCrc, int(offset)), nil
+	e := newEncoder(f, prevCrc, int(offset))
+	e.compression = compression
+	return e, nil
 }
 
 func (e *encoder) encode(rec *walpb.Record) error {
@@ -65,6 +70,7 @@ func (e *encoder) encode(rec *walpb.Record) error {
 
 	e.crc.Write(rec.Data)
 	rec.Crc = e.crc.Sum32()
+	rec = e.compressRecord(rec)
 	var (
 		data []byte
 		err  error
@@ -97,6 +103,21 @@ func (e *encoder) encode(rec *walpb.Record) error {
 	return err
 }
 
+// compressRecord returns the record with its data compressed, if it is an
+// entry record that gets smaller.
+func (e *encoder) compressRecord(rec *walpb.Record) *walpb.Record {
+	if e.compression == walpb.Compression_NONE || rec.Type != entryType || len(rec.Data) < minCompressBytes {
+		return rec
+	}
+	data := compress(e.compression, rec.Data)
+	if len(data) >= len(rec.Data) {
+		return rec
+	}
+	walCompressedBytes.Add(float64(len(rec.Data) - len(data)))
+	c := e.compression
+	return &walpb.Record{Type: rec.Type, Crc: rec.Crc, Data: data, Compression: &c}
+}
+
 func encodeFrameSize(dataBytes int) (lenField uint64, padBytes int) {
 	lenField = uint64(dataBytes)
 	// force 8 byte alignment so length never gets a torn write
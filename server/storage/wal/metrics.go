@@ -34,9 +34,25 @@ var (
 		Name:      "wal_write_bytes_total",
 		Help:      "Total number of bytes written in WAL.",
 	})
+
+	walCompressedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "disk",
+		Name:      "wal_compressed_bytes_total",
+		Help:      "Total number of bytes saved in WAL by the compression of the entry records.",
+	})
+
+	walRepairs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "disk",
+		Name:      "wal_repairs_total",
+		Help:      "Total number of torn writes repaired at the tail of the WAL.",
+	})
 )
 
 func init() {
 	prometheus.MustRegister(walFsyncSec)
 	prometheus.MustRegister(walWriteBytes)
+	prometheus.MustRegister(walCompressedBytes)
+	prometheus.MustRegister(walRepairs)
 }
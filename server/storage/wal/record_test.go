@@ -16,6 +16,8 @@ package wal
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"hash/crc32"
 	"io"
@@ -94,6 +96,57 @@ func TestWriteRecord(t *testing.T) {
 	}
 }
 
+// TestWriteCompressedRecord ensures only the entry records getting smaller
+// are compressed, and are decoded with their uncompressed data.
+func TestWriteCompressedRecord(t *testing.T) {
+	repeated := bytes.Repeat([]byte("value"), 1024)
+	random := make([]byte, 4096)
+	rand.Read(random)
+	tests := []struct {
+		typ  int64
+		data []byte
+
+		wcompressed bool
+	}{
+		{entryType, repeated, true},
+		{entryType, []byte("small"), false},
+		{entryType, random, false},
+		{metadataType, repeated, false},
+	}
+	for i, tt := range tests {
+		buf := new(bytes.Buffer)
+		e := newEncoder(buf, 0, 0)
+		e.compression = walpb.Compression_ZSTD
+		e.encode(&walpb.Record{Type: tt.typ, Data: tt.data})
+		e.flush()
+
+		// unmarshal the record of the frame as written
+		recBytes, _ := decodeFrameSize(int64(binary.LittleEndian.Uint64(buf.Bytes())))
+		var raw walpb.Record
+		if err := raw.Unmarshal(buf.Bytes()[frameSizeBytes : frameSizeBytes+recBytes]); err != nil {
+			t.Fatalf("#%d: unexpected unmarshal error: %v", i, err)
+		}
+		if compressed := raw.Compression != nil; compressed != tt.wcompressed {
+			t.Errorf("#%d: compressed = %v, want %v", i, compressed, tt.wcompressed)
+		}
+
+		f, err := createFileWithData(t, buf)
+		if err != nil {
+			t.Fatalf("#%d: unexpected error: %v", i, err)
+		}
+		b := &walpb.Record{}
+		if err = newDecoder(fileutil.NewFileReader(f)).decode(b); err != nil {
+			t.Errorf("#%d: err = %v, want nil", i, err)
+		}
+		if b.Compression != nil {
+			t.Errorf("#%d: compression = %v, want nil", i, b.Compression)
+		}
+		if !bytes.Equal(b.Data, tt.data) {
+			t.Errorf("#%d: data differs from the one written", i)
+		}
+	}
+}
+
 func createFileWithData(t *testing.T, bf *bytes.Buffer) (*os.File, error) {
 	f, err := os.CreateTemp(t.TempDir(), "wal")
 	if err != nil {
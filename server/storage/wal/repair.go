@@ -15,6 +15,7 @@
 package wal
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -23,22 +24,65 @@ import (
 	"go.etcd.io/etcd/client/pkg/v3/fileutil"
 	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+// RepairReport describes the repair of a torn write at the tail of the last
+// WAL file.
+type RepairReport struct {
+	// Path is the path of the repaired WAL file.
+	Path string
+	// BackupPath is the path of the copy of the WAL file taken before it was
+	// truncated.
+	BackupPath string
+	// Offset is the offset the WAL file was truncated at, right after its
+	// last valid record.
+	Offset int64
+	// DiscardedBytes is the number of bytes following the last valid record
+	// that were truncated, preallocated space included.
+	DiscardedBytes int64
+	// Records is the number of valid records kept in the WAL file.
+	Records int
+	// LastIndex is the index of the last entry kept in the WAL file, or 0 if
+	// the file holds no entries.
+	LastIndex uint64
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (r *RepairReport) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("path", r.Path)
+	enc.AddString("backup-path", r.BackupPath)
+	enc.AddInt64("offset", r.Offset)
+	enc.AddInt64("discarded-bytes", r.DiscardedBytes)
+	enc.AddInt("records", r.Records)
+	enc.AddUint64("last-index", r.LastIndex)
+	return nil
+}
+
 // Repair tries to repair ErrUnexpectedEOF in the
 // last wal file by truncating.
 func Repair(lg *zap.Logger, dirpath string) bool {
+	_, err := RepairTail(lg, dirpath)
+	return err == nil
+}
+
+// RepairTail tries to repair ErrUnexpectedEOF, returned for a torn write, in
+// the last wal file by truncating it after its last valid record. The file is
+// copied to a backup file before it is truncated. The returned report is nil
+// if the file did not need to be repaired.
+func RepairTail(lg *zap.Logger, dirpath string) (*RepairReport, error) {
 	if lg == nil {
 		lg = zap.NewNop()
 	}
 	f, err := openLast(lg, dirpath)
 	if err != nil {
-		return false
+		return nil, err
 	}
 	defer f.Close()
 
 	lg.Info("repairing", zap.String("path", f.Name()))
 
+	report := &RepairReport{Path: f.Name()}
 	rec := &walpb.Record{}
 	decoder := newDecoder(fileutil.NewFileReader(f.File))
 	for {
@@ -48,59 +92,85 @@ func Repair(lg *zap.Logger, dirpath string) bool {
 		case nil:
 			// update crc of the decoder when necessary
 			switch rec.Type {
+			case entryType:
+				report.LastIndex = mustUnmarshalEntry(rec.Data).Index
 			case crcType:
 				crc := decoder.crc.Sum32()
 				// current crc of decoder must match the crc of the record.
 				// do no need to match 0 crc, since the decoder is a new one at this case.
 				if crc != 0 && rec.Validate(crc) != nil {
-					return false
+					return nil, ErrCRCMismatch
 				}
 				decoder.updateCRC(rec.Crc)
 			}
+			report.Records++
 			continue
 
 		case io.EOF:
 			lg.Info("repaired", zap.String("path", f.Name()), zap.Error(io.EOF))
-			return true
+			return nil, nil
 
 		case io.ErrUnexpectedEOF:
-			brokenName := f.Name() + ".broken"
-			bf, bferr := os.Create(brokenName)
+			brokenName, bf, bferr := createBackupFile(f.Name() + ".broken")
 			if bferr != nil {
 				lg.Warn("failed to create backup file", zap.String("path", brokenName), zap.Error(bferr))
-				return false
+				return nil, bferr
 			}
 			defer bf.Close()
+			report.BackupPath = brokenName
 
 			if _, err = f.Seek(0, io.SeekStart); err != nil {
 				lg.Warn("failed to read file", zap.String("path", f.Name()), zap.Error(err))
-				return false
+				return nil, err
 			}
 
-			if _, err = io.Copy(bf, f); err != nil {
+			size, err := io.Copy(bf, f)
+			if err != nil {
 				lg.Warn("failed to copy", zap.String("from", f.Name()), zap.String("to", brokenName), zap.Error(err))
-				return false
+				return nil, err
+			}
+			if err = fileutil.Fsync(bf); err != nil {
+				lg.Warn("failed to fsync", zap.String("path", brokenName), zap.Error(err))
+				return nil, err
 			}
 
 			if err = f.Truncate(lastOffset); err != nil {
 				lg.Warn("failed to truncate", zap.String("path", f.Name()), zap.Error(err))
-				return false
+				return nil, err
 			}
 
 			start := time.Now()
 			if err = fileutil.Fsync(f.File); err != nil {
 				lg.Warn("failed to fsync", zap.String("path", f.Name()), zap.Error(err))
-				return false
+				return nil, err
 			}
 			walFsyncSec.Observe(time.Since(start).Seconds())
+			walRepairs.Inc()
 
-			lg.Info("repaired", zap.String("path", f.Name()), zap.Error(io.ErrUnexpectedEOF))
-			return true
+			report.Offset = lastOffset
+			report.DiscardedBytes = size - lastOffset
+			lg.Info("repaired", zap.Object("report", report), zap.Error(io.ErrUnexpectedEOF))
+			return report, nil
 
 		default:
 			lg.Warn("failed to repair", zap.String("path", f.Name()), zap.Error(err))
-			return false
+			return nil, err
+		}
+	}
+}
+
+// createBackupFile creates the backup file at the given path, or, if a
+// backup from an earlier repair exists there, at the first free path
+// suffixed with a sequence number, so that earlier backups are never
+// overwritten.
+func createBackupFile(path string) (string, *os.File, error) {
+	name := path
+	for i := 1; ; i++ {
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fileutil.PrivateFileMode)
+		if !os.IsExist(err) {
+			return name, f, err
 		}
+		name = fmt.Sprintf("%s.%d", path, i)
 	}
 }
 
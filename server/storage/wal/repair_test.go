@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"go.etcd.io/etcd/raft/v3/raftpb"
@@ -41,6 +42,71 @@ func TestRepairTruncate(t *testing.T) {
 	testRepair(t, makeEnts(10), corruptf, 9)
 }
 
+// TestRepairTailReport ensures the repair of a torn write is reported and
+// that the backups of earlier repairs are kept.
+func TestRepairTailReport(t *testing.T) {
+	p := t.TempDir()
+
+	w, err := Create(zaptest.NewLogger(t), p, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, es := range makeEnts(10) {
+		if err = w.Save(raftpb.HardState{}, es); err != nil {
+			t.Fatal(err)
+		}
+	}
+	offset, err := w.tail().Seek(0, io.SeekCurrent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	name := filepath.Join(p, walName(0, 0))
+
+	if err = os.WriteFile(name+".broken", []byte("earlier backup"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := openLast(zaptest.NewLogger(t), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Truncate(offset - 4); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	report, err := RepairTail(zaptest.NewLogger(t), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report == nil {
+		t.Fatal("expected a repair report")
+	}
+	if report.Path != name {
+		t.Errorf("path = %q, want %q", report.Path, name)
+	}
+	if report.BackupPath != name+".broken.1" {
+		t.Errorf("backup path = %q, want %q", report.BackupPath, name+".broken.1")
+	}
+	if report.LastIndex != 9 {
+		t.Errorf("last index = %d, want 9", report.LastIndex)
+	}
+	if report.Offset+report.DiscardedBytes != offset-4 {
+		t.Errorf("offset + discarded bytes = %d, want %d", report.Offset+report.DiscardedBytes, offset-4)
+	}
+	if b, err := os.ReadFile(name + ".broken"); err != nil || string(b) != "earlier backup" {
+		t.Errorf("earlier backup = %q, %v, want it kept", b, err)
+	}
+	if fi, err := os.Stat(report.BackupPath); err != nil || fi.Size() != offset-4 {
+		t.Errorf("backup = %v, %v, want a copy of the damaged file", fi, err)
+	}
+
+	// nothing left to repair
+	if report, err = RepairTail(zaptest.NewLogger(t), p); err != nil || report != nil {
+		t.Errorf("report, err = %+v, %v, want nil, nil", report, err)
+	}
+}
+
 func testRepair(t *testing.T, ents [][]raftpb.Entry, corrupt corruptFunc, expectedEnts int) {
 	p := t.TempDir()
 
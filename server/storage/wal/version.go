@@ -26,6 +26,7 @@ import (
 	"go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/pkg/v3/pbutil"
 	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
 )
 
 // ReadWALVersion reads remaining entries from opened WAL and returns struct
@@ -35,16 +36,35 @@ func ReadWALVersion(w *WAL) (*walVersion, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &walVersion{entries: ents}, nil
+	return &walVersion{entries: ents, compression: w.readCompression}, nil
 }
 
 type walVersion struct {
-	entries []raftpb.Entry
+	entries     []raftpb.Entry
+	compression walpb.Compression
 }
 
 // MinimalEtcdVersion returns minimal etcd able to interpret entries from  WAL log,
+// including the compression of their records.
 func (w *walVersion) MinimalEtcdVersion() *semver.Version {
-	return MinimalEtcdVersion(w.entries)
+	return maxVersion(MinimalEtcdVersion(w.entries), CompressionVersion(w.compression))
+}
+
+// CompressionVersion returns minimal etcd able to read the records compressed
+// with c, determined by the etcd version annotations of walpb.Record.
+func CompressionVersion(c walpb.Compression) *semver.Version {
+	if c == walpb.Compression_NONE {
+		return nil
+	}
+	var maxVer *semver.Version
+	err := visitMessage(proto.MessageReflect(&walpb.Record{Compression: &c}), func(path protoreflect.FullName, ver *semver.Version) error {
+		maxVer = maxVersion(maxVer, ver)
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return maxVer
 }
 
 // MinimalEtcdVersion returns minimal etcd able to interpret entries from  WAL log,
@@ -15,6 +15,7 @@
 package wal
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 
@@ -26,6 +27,8 @@ import (
 	"go.etcd.io/etcd/api/v3/version"
 	"go.etcd.io/etcd/pkg/v3/pbutil"
 	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+	"go.uber.org/zap/zaptest"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
@@ -101,6 +104,43 @@ func TestEtcdVersionFromEntry(t *testing.T) {
 	}
 }
 
+func TestWALVersionCompression(t *testing.T) {
+	put := pbutil.MustMarshal(&etcdserverpb.InternalRaftRequest{Put: &etcdserverpb.PutRequest{Key: []byte("foo"), Value: bytes.Repeat([]byte("value"), 1024)}})
+	ents := []raftpb.Entry{{Index: 1, Term: 1, Data: put}}
+	for _, tc := range []struct {
+		compression walpb.Compression
+		expect      *semver.Version
+	}{
+		{compression: walpb.Compression_NONE, expect: MinimalEtcdVersion(ents)},
+		{compression: walpb.Compression_SNAPPY, expect: &version.V3_6},
+		{compression: walpb.Compression_ZSTD, expect: &version.V3_6},
+	} {
+		t.Run(tc.compression.String(), func(t *testing.T) {
+			p := t.TempDir()
+			w, err := Create(zaptest.NewLogger(t), p, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.SetCompression(tc.compression)
+			if err = w.Save(raftpb.HardState{}, ents); err != nil {
+				t.Fatal(err)
+			}
+			w.Close()
+
+			w, err = OpenForRead(zaptest.NewLogger(t), p, walpb.Snapshot{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+			walVersion, err := ReadWALVersion(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.expect, walVersion.MinimalEtcdVersion())
+		})
+	}
+}
+
 func TestEtcdVersionFromMessage(t *testing.T) {
 	tcs := []struct {
 		name   string
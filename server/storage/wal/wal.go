@@ -83,8 +83,11 @@ type WAL struct {
 	start     walpb.Snapshot // snapshot to start reading
 	decoder   *decoder       // decoder to decode records
 	readClose func() error   // closer for decode reader
+	// readCompression is a compression of the entries read by ReadAll, if any
+	readCompression walpb.Compression
 
-	unsafeNoSync bool // if set, do not fsync
+	unsafeNoSync bool              // if set, do not fsync
+	compression  walpb.Compression // compression of the entry records
 
 	mu      sync.Mutex
 	enti    uint64   // index of the last entry saved to the wal
@@ -158,7 +161,7 @@ func Create(lg *zap.Logger, dirpath string, metadata []byte) (*WAL, error) {
 		dir:      dirpath,
 		metadata: metadata,
 	}
-	w.encoder, err = newFileEncoder(f.File, 0)
+	w.encoder, err = newFileEncoder(f.File, 0, w.compression)
 	if err != nil {
 		return nil, err
 	}
@@ -245,6 +248,19 @@ func (w *WAL) SetUnsafeNoFsync() {
 	w.unsafeNoSync = true
 }
 
+// SetCompression sets the compression of the entry records appended to the
+// WAL. The records already written are read whatever their compression.
+func (w *WAL) SetCompression(c walpb.Compression) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.compression = c
+	if w.encoder != nil {
+		w.encoder.mu.Lock()
+		w.encoder.compression = c
+		w.encoder.mu.Unlock()
+	}
+}
+
 func (w *WAL) cleanupWAL(lg *zap.Logger) {
 	var err error
 	if err = w.Close(); err != nil {
@@ -466,6 +482,9 @@ func (w *WAL) ReadAll() (metadata []byte, state raftpb.HardState, ents []raftpb.
 				}
 				// The line below is potentially overriding some 'uncommitted' entries.
 				ents = append(ents[:up], e)
+				if decoder.compression != walpb.Compression_NONE {
+					w.readCompression = decoder.compression
+				}
 			}
 			w.enti = e.Index
 
@@ -551,7 +570,7 @@ func (w *WAL) ReadAll() (metadata []byte, state raftpb.HardState, ents []raftpb.
 
 	if w.tail() != nil {
 		// create encoder (chain crc with the decoder), enable appending
-		w.encoder, err = newFileEncoder(w.tail().File, w.decoder.lastCRC())
+		w.encoder, err = newFileEncoder(w.tail().File, w.decoder.lastCRC(), w.compression)
 		if err != nil {
 			return
 		}
@@ -739,7 +758,7 @@ func (w *WAL) cut() error {
 	// update writer and save the previous crc
 	w.locks = append(w.locks, newTail)
 	prevCrc := w.encoder.crc.Sum32()
-	w.encoder, err = newFileEncoder(w.tail().File, prevCrc)
+	w.encoder, err = newFileEncoder(w.tail().File, prevCrc, w.compression)
 	if err != nil {
 		return err
 	}
@@ -788,7 +807,7 @@ func (w *WAL) cut() error {
 	w.locks[len(w.locks)-1] = newTail
 
 	prevCrc = w.encoder.crc.Sum32()
-	w.encoder, err = newFileEncoder(w.tail().File, prevCrc)
+	w.encoder, err = newFileEncoder(w.tail().File, prevCrc, w.compression)
 	if err != nil {
 		return err
 	}
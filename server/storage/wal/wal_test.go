@@ -444,6 +444,62 @@ func TestRecover(t *testing.T) {
 	}
 }
 
+// TestRecoverCompressed ensures the entries are read back whether they were
+// saved compressed or not, across segments.
+func TestRecoverCompressed(t *testing.T) {
+	for _, c := range []walpb.Compression{walpb.Compression_SNAPPY, walpb.Compression_ZSTD} {
+		t.Run(c.String(), func(t *testing.T) {
+			p := t.TempDir()
+
+			w, err := Create(zaptest.NewLogger(t), p, []byte("metadata"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			random := make([]byte, 4096)
+			rand.Read(random)
+			repeated := bytes.Repeat([]byte("value"), 1024)
+			ents := []raftpb.Entry{
+				{Index: 1, Term: 1, Data: repeated},
+				{Index: 2, Term: 1, Data: repeated},
+				{Index: 3, Term: 1, Data: []byte("small")},
+				{Index: 4, Term: 1, Data: random},
+				{Index: 5, Term: 2, Data: repeated},
+			}
+			// the first entry is saved before the compression is set, as by
+			// a member upgraded with compression enabled
+			if err = w.Save(raftpb.HardState{}, ents[:1]); err != nil {
+				t.Fatal(err)
+			}
+			w.SetCompression(c)
+			if err = w.Save(raftpb.HardState{}, ents[1:4]); err != nil {
+				t.Fatal(err)
+			}
+			if err = w.cut(); err != nil {
+				t.Fatal(err)
+			}
+			if err = w.Save(raftpb.HardState{Term: 2, Commit: 5}, ents[4:]); err != nil {
+				t.Fatal(err)
+			}
+			w.Close()
+
+			if w, err = Open(zaptest.NewLogger(t), p, walpb.Snapshot{}); err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+			_, state, entries, err := w.ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(entries, ents) {
+				t.Errorf("ents = %+v, want %+v", entries, ents)
+			}
+			if want := (raftpb.HardState{Term: 2, Commit: 5}); !reflect.DeepEqual(state, want) {
+				t.Errorf("state = %+v, want %+v", state, want)
+			}
+		})
+	}
+}
+
 func TestSearchIndex(t *testing.T) {
 	tests := []struct {
 		names []string
@@ -11,6 +11,7 @@ import (
 
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/golang/protobuf/proto"
+	_ "go.etcd.io/etcd/api/v3/versionpb"
 	raftpb "go.etcd.io/etcd/raft/v3/raftpb"
 )
 
@@ -25,13 +26,59 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type Compression int32
+
+const (
+	Compression_NONE   Compression = 0
+	Compression_SNAPPY Compression = 1
+	Compression_ZSTD   Compression = 2
+)
+
+var Compression_name = map[int32]string{
+	0: "NONE",
+	1: "SNAPPY",
+	2: "ZSTD",
+}
+
+var Compression_value = map[string]int32{
+	"NONE":   0,
+	"SNAPPY": 1,
+	"ZSTD":   2,
+}
+
+func (x Compression) Enum() *Compression {
+	p := new(Compression)
+	*p = x
+	return p
+}
+
+func (x Compression) String() string {
+	return proto.EnumName(Compression_name, int32(x))
+}
+
+func (x *Compression) UnmarshalJSON(data []byte) error {
+	value, err := proto.UnmarshalJSONEnum(Compression_value, data, "Compression")
+	if err != nil {
+		return err
+	}
+	*x = Compression(value)
+	return nil
+}
+
+func (Compression) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_bf94fd919e302a1d, []int{0}
+}
+
 type Record struct {
-	Type                 int64    `protobuf:"varint,1,opt,name=type" json:"type"`
-	Crc                  uint32   `protobuf:"varint,2,opt,name=crc" json:"crc"`
-	Data                 []byte   `protobuf:"bytes,3,opt,name=data" json:"data,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Type int64  `protobuf:"varint,1,opt,name=type" json:"type"`
+	Crc  uint32 `protobuf:"varint,2,opt,name=crc" json:"crc"`
+	Data []byte `protobuf:"bytes,3,opt,name=data" json:"data,omitempty"`
+	// Field populated since >=etcd-3.6.0, if data is compressed. The crc is
+	// the one of the uncompressed data.
+	Compression          *Compression `protobuf:"varint,4,opt,name=compression,enum=walpb.Compression" json:"compression,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
 func (m *Record) Reset()         { *m = Record{} }
@@ -112,6 +159,7 @@ func (m *Snapshot) XXX_DiscardUnknown() {
 var xxx_messageInfo_Snapshot proto.InternalMessageInfo
 
 func init() {
+	proto.RegisterEnum("walpb.Compression", Compression_name, Compression_value)
 	proto.RegisterType((*Record)(nil), "walpb.Record")
 	proto.RegisterType((*Snapshot)(nil), "walpb.Snapshot")
 }
@@ -119,22 +167,28 @@ func init() {
 func init() { proto.RegisterFile("record.proto", fileDescriptor_bf94fd919e302a1d) }
 
 var fileDescriptor_bf94fd919e302a1d = []byte{
-	// 234 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x3c, 0x8e, 0x41, 0x4e, 0xc3, 0x30,
-	0x10, 0x45, 0x63, 0xe2, 0x22, 0x18, 0xca, 0x02, 0xab, 0xaa, 0xa2, 0x2c, 0x4c, 0xd4, 0x55, 0x56,
-	0x29, 0xe2, 0x08, 0x65, 0xcf, 0x22, 0x3d, 0x00, 0x72, 0x1d, 0xa7, 0x20, 0xd1, 0x8c, 0x35, 0xb5,
-	0x04, 0xdc, 0x84, 0x23, 0x65, 0xc9, 0x09, 0x10, 0x84, 0x8b, 0xa0, 0x8c, 0x03, 0x1b, 0xfb, 0xeb,
-	0x7d, 0xf9, 0x7d, 0xc3, 0x9c, 0x9c, 0x45, 0x6a, 0x2a, 0x4f, 0x18, 0x50, 0xcd, 0x5e, 0xcc, 0xb3,
-	0xdf, 0xe5, 0x8b, 0x3d, 0xee, 0x91, 0xc9, 0x7a, 0x4c, 0xb1, 0xcc, 0x97, 0x64, 0xda, 0xb0, 0x1e,
-	0x0f, 0xbf, 0xe3, 0x2b, 0xf2, 0xd5, 0x3d, 0x9c, 0xd6, 0x2c, 0x51, 0x19, 0xc8, 0xf0, 0xe6, 0x5d,
-	0x26, 0x0a, 0x51, 0xa6, 0x1b, 0xd9, 0x7f, 0x5e, 0x27, 0x35, 0x13, 0xb5, 0x84, 0xd4, 0x92, 0xcd,
-	0x4e, 0x0a, 0x51, 0x5e, 0x4e, 0xc5, 0x08, 0x94, 0x02, 0xd9, 0x98, 0x60, 0xb2, 0xb4, 0x10, 0xe5,
-	0xbc, 0xe6, 0xbc, 0x22, 0x38, 0xdb, 0x76, 0xc6, 0x1f, 0x1f, 0x31, 0xa8, 0x1c, 0x66, 0x4f, 0x5d,
-	0xe3, 0x5e, 0x59, 0x29, 0xa7, 0x97, 0x11, 0xf1, 0x9a, 0xa3, 0x03, 0x4b, 0xe5, 0xff, 0x9a, 0xa3,
-	0x83, 0xba, 0x01, 0xb0, 0xd8, 0xb5, 0x0f, 0xc7, 0x60, 0x82, 0x63, 0xf7, 0xc5, 0xed, 0x55, 0x15,
-	0x7f, 0x5e, 0xdd, 0x61, 0xd7, 0x6e, 0xc7, 0xa2, 0x3e, 0xb7, 0x7f, 0x71, 0xb3, 0xe8, 0xbf, 0x75,
-	0xd2, 0x0f, 0x5a, 0x7c, 0x0c, 0x5a, 0x7c, 0x0d, 0x5a, 0xbc, 0xff, 0xe8, 0xe4, 0x37, 0x00, 0x00,
-	0xff, 0xff, 0xc3, 0x36, 0x0c, 0xad, 0x1d, 0x01, 0x00, 0x00,
+	// 331 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x90, 0xcd, 0x4a, 0xf3, 0x40,
+	0x18, 0x85, 0x33, 0x4d, 0xfa, 0xf3, 0xbd, 0xed, 0x27, 0x75, 0x28, 0x65, 0xc8, 0x22, 0x86, 0xae,
+	0x82, 0x8b, 0x44, 0x2a, 0x74, 0xe7, 0xc2, 0x56, 0xb7, 0xb5, 0x24, 0x6e, 0x74, 0x23, 0xd3, 0xc9,
+	0xb4, 0x16, 0x6c, 0x66, 0x98, 0x0c, 0xfe, 0xdc, 0x82, 0xe0, 0xc6, 0x95, 0x37, 0xe2, 0x3d, 0x74,
+	0xe9, 0x15, 0x88, 0xd6, 0x1b, 0x91, 0x4c, 0x5a, 0xec, 0x26, 0x39, 0x3c, 0xe7, 0x85, 0xf3, 0x30,
+	0xd0, 0x52, 0x9c, 0x09, 0x95, 0x86, 0x52, 0x09, 0x2d, 0x70, 0xf5, 0x81, 0xde, 0xc9, 0xa9, 0xdb,
+	0x99, 0x8b, 0xb9, 0x30, 0x24, 0x2a, 0x52, 0x59, 0xba, 0x5d, 0x45, 0x67, 0x3a, 0x2a, 0x3e, 0x72,
+	0x6a, 0x7e, 0x1b, 0xee, 0x73, 0xcd, 0xd2, 0x88, 0xca, 0x45, 0x74, 0xcf, 0x55, 0xbe, 0x10, 0x99,
+	0x9c, 0x6e, 0x53, 0x79, 0xd1, 0x7b, 0x41, 0x50, 0x8b, 0xcd, 0x0e, 0x26, 0xe0, 0xe8, 0x27, 0xc9,
+	0x09, 0xf2, 0x51, 0x60, 0x0f, 0x9d, 0xd5, 0xe7, 0x81, 0x15, 0x1b, 0x82, 0xbb, 0x60, 0x33, 0xc5,
+	0x48, 0xc5, 0x47, 0xc1, 0xff, 0x4d, 0x51, 0x00, 0x8c, 0xc1, 0x49, 0xa9, 0xa6, 0xc4, 0xf6, 0x51,
+	0xd0, 0x8a, 0x4d, 0xc6, 0x27, 0xd0, 0x64, 0x62, 0x29, 0x15, 0xcf, 0x8b, 0x15, 0xe2, 0xf8, 0x28,
+	0xd8, 0xeb, 0xe3, 0xd0, 0xd8, 0x87, 0xa3, 0xbf, 0x66, 0x58, 0x7f, 0x7e, 0x27, 0xf6, 0x71, 0x38,
+	0x88, 0x77, 0xef, 0x7b, 0x0a, 0x1a, 0x49, 0x46, 0x65, 0x7e, 0x2b, 0x34, 0x76, 0xa1, 0xba, 0xc8,
+	0x52, 0xfe, 0x68, 0x8c, 0x9c, 0xcd, 0x70, 0x89, 0x8c, 0x2c, 0x57, 0x4b, 0x52, 0xd9, 0xa9, 0x0c,
+	0xc1, 0x47, 0x00, 0x4c, 0x64, 0xb3, 0x9b, 0x5c, 0x53, 0xcd, 0x8d, 0x5a, 0xb3, 0xbf, 0x1f, 0x96,
+	0x6f, 0x13, 0x8e, 0x44, 0x36, 0x4b, 0x8a, 0x22, 0xfe, 0xc7, 0xb6, 0xf1, 0x70, 0x00, 0xcd, 0x1d,
+	0x31, 0xdc, 0x00, 0x67, 0x7c, 0x31, 0x3e, 0x6f, 0x5b, 0x18, 0xa0, 0x96, 0x8c, 0x4f, 0x27, 0x93,
+	0xab, 0x36, 0x2a, 0xe8, 0x75, 0x72, 0x79, 0xd6, 0xae, 0xb8, 0xf5, 0xd7, 0x52, 0x7c, 0xd8, 0x59,
+	0x7d, 0x7b, 0xd6, 0x6a, 0xed, 0xa1, 0x8f, 0xb5, 0x87, 0xbe, 0xd6, 0x1e, 0x7a, 0xfb, 0xf1, 0xac,
+	0xdf, 0x01, 0x00, 0x89, 0xdb, 0xfc, 0x16, 0xb7, 0x01, 0x00, 0x00,
 }
 
 func (m *Record) Marshal() (dAtA []byte, err error) {
@@ -161,6 +215,11 @@ func (m *Record) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Compression != nil {
+		i = encodeVarintRecord(dAtA, i, uint64(*m.Compression))
+		i--
+		dAtA[i] = 0x20
+	}
 	if m.Data != nil {
 		i -= len(m.Data)
 		copy(dAtA[i:], m.Data)
@@ -245,6 +304,9 @@ func (m *Record) Size() (n int) {
 		l = len(m.Data)
 		n += 1 + l + sovRecord(uint64(l))
 	}
+	if m.Compression != nil {
+		n += 1 + sovRecord(uint64(*m.Compression))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -376,6 +438,26 @@ func (m *Record) Unmarshal(dAtA []byte) error {
 				m.Data = []byte{}
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Compression", wireType)
+			}
+			var v Compression
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRecord
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= Compression(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Compression = &v
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRecord(dAtA[iNdEx:])
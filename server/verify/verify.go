@@ -33,6 +33,10 @@ type Config struct {
 	// DataDir is a root directory where the data being verified are stored.
 	DataDir string
 
+	// WALDir is the directory of the WAL being verified, the one of DataDir
+	// if empty.
+	WALDir string
+
 	// ExactIndex requires consistent_index in backend exactly match the last committed WAL entry.
 	// Usually backend's consistent_index needs to be <= WAL.commit, but for backups the match
 	// is expected to be exact.
@@ -126,7 +130,10 @@ func validateConsistentIndex(cfg Config, hardstate *raftpb.HardState, snapshot *
 }
 
 func validateWal(cfg Config) (*walpb.Snapshot, *raftpb.HardState, error) {
-	walDir := datadir.ToWalDir(cfg.DataDir)
+	walDir := cfg.WALDir
+	if walDir == "" {
+		walDir = datadir.ToWalDir(cfg.DataDir)
+	}
 
 	walSnaps, err := wal2.ValidSnapshotEntries(cfg.Logger, walDir)
 	if err != nil {
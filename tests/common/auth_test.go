@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package e2e
+package common
 
 import (
 	"context"
@@ -23,64 +23,57 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/framework"
 	"go.etcd.io/etcd/tests/v3/framework/config"
-	"go.etcd.io/etcd/tests/v3/framework/e2e"
 )
 
 func TestAuthCluster(t *testing.T) {
-	e2e.BeforeTest(t)
-	cfg := &e2e.EtcdProcessClusterConfig{
-		ClusterSize:   1,
-		InitialToken:  "new",
-		SnapshotCount: 2,
-	}
-	ctx, cancel := context.WithCancel(context.Background())
+	testRunner.BeforeTest(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	epc, err := e2e.NewEtcdProcessCluster(ctx, t, cfg)
-	if err != nil {
-		t.Fatalf("could not start etcd process cluster (%v)", err)
-	}
-	defer func() {
-		if err := epc.Close(); err != nil {
-			t.Fatalf("could not close test cluster (%v)", err)
-		}
-	}()
+	clus := testRunner.NewCluster(ctx, t, config.WithClusterSize(1), config.WithSnapshotCount(2))
+	defer clus.Close()
 
-	epcClient := epc.Client()
-	createUsers(ctx, t, epcClient)
+	cc := framework.MustClient(clus.Client())
+	createUsers(ctx, t, cc)
 
-	if err := epcClient.AuthEnable(ctx); err != nil {
+	if err := cc.AuthEnable(ctx); err != nil {
 		t.Fatalf("could not enable Auth: (%v)", err)
 	}
+	rootUserClient := framework.MustClient(clus.Client(WithAuth("root", "rootPassword")))
+	authStatus, err := rootUserClient.AuthStatus(ctx)
+	if err != nil {
+		t.Fatalf("could not get auth status: (%v)", err)
+	}
+	assert.True(t, authStatus.Enabled)
 
-	testUserClientOpts := e2e.WithAuth("test", "testPassword")
-	rootUserClientOpts := e2e.WithAuth("root", "rootPassword")
-
+	testUserClient := framework.MustClient(clus.Client(WithAuth("test", "testPassword")))
 	// write more than SnapshotCount keys to single leader to make sure snapshot is created
 	for i := 0; i <= 10; i++ {
-		if err := epc.Client(testUserClientOpts).Put(ctx, fmt.Sprintf("/test/%d", i), "test", config.PutOptions{}); err != nil {
+		if err := testUserClient.Put(ctx, fmt.Sprintf("/test/%d", i), "test", config.PutOptions{}); err != nil {
 			t.Fatalf("failed to Put (%v)", err)
 		}
 	}
 
-	// start second process
-	if err := epc.StartNewProc(ctx, t, rootUserClientOpts); err != nil {
-		t.Fatalf("could not start second etcd process (%v)", err)
+	// start second member
+	if err := clus.AddMember(ctx, t, WithAuth("root", "rootPassword")); err != nil {
+		t.Fatalf("could not start second member (%v)", err)
 	}
+	assert.Equal(t, 2, len(clus.Endpoints()))
 
 	// make sure writes to both endpoints are successful
-	endpoints := epc.EndpointsV3()
-	assert.Equal(t, len(endpoints), 2)
-	for _, endpoint := range epc.EndpointsV3() {
-		if err := epc.Client(testUserClientOpts, e2e.WithEndpoints([]string{endpoint})).Put(ctx, "/test/key", endpoint, config.PutOptions{}); err != nil {
+	for _, endpoint := range clus.Endpoints() {
+		testUserClient = framework.MustClient(clus.Client(WithAuth("test", "testPassword"), WithEndpoints([]string{endpoint})))
+		if err := testUserClient.Put(ctx, "/test/key", endpoint, config.PutOptions{}); err != nil {
 			t.Fatalf("failed to write to Put to %q (%v)", endpoint, err)
 		}
 	}
 
-	// verify all nodes have exact same revision and hash
+	// verify all members have exact same revision and hash
+	rootUserClient = framework.MustClient(clus.Client(WithAuth("root", "rootPassword")))
 	assert.Eventually(t, func() bool {
-		hashKvs, err := epc.Client(rootUserClientOpts).HashKV(ctx, 0)
+		hashKvs, err := rootUserClient.HashKV(ctx, 0)
 		if err != nil {
 			t.Logf("failed to get HashKV: %v", err)
 			return false
@@ -96,10 +89,9 @@ func TestAuthCluster(t *testing.T) {
 		assert.Equal(t, hashKvs[0].Hash, hashKvs[1].Hash)
 		return true
 	}, time.Second*5, time.Millisecond*100)
-
 }
 
-func createUsers(ctx context.Context, t *testing.T, client *e2e.EtcdctlV3) {
+func createUsers(ctx context.Context, t *testing.T, client framework.Client) {
 	if _, err := client.UserAdd(ctx, "root", "rootPassword", config.UserAddOptions{}); err != nil {
 		t.Fatalf("could not add root user (%v)", err)
 	}
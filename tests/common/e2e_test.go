@@ -30,3 +30,7 @@ func init() {
 func WithAuth(userName, password string) config.ClientOption {
 	return e2e.WithAuth(userName, password)
 }
+
+func WithEndpoints(endpoints []string) config.ClientOption {
+	return e2e.WithEndpoints(endpoints)
+}
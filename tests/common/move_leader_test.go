@@ -0,0 +1,49 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/tests/v3/framework"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"go.etcd.io/etcd/tests/v3/framework/testutils"
+)
+
+func TestMoveLeader(t *testing.T) {
+	testRunner.BeforeTest(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	clus := testRunner.NewCluster(ctx, t, config.WithClusterSize(3))
+	defer clus.Close()
+	cc := framework.MustClient(clus.Client())
+	testutils.ExecuteUntil(ctx, t, func() {
+		leader := clus.WaitLeader(t)
+		transferee := (leader + 1) % len(clus.Members())
+		resp, err := clus.Members()[transferee].Client().Status(ctx)
+		if err != nil {
+			t.Fatalf("could not get status of member %d (%v)", transferee, err)
+		}
+
+		if err := cc.MoveLeader(ctx, resp[0].Header.MemberId); err != nil {
+			t.Fatalf("could not move leader (%v)", err)
+		}
+		if newLeader := clus.WaitLeader(t); newLeader != transferee {
+			t.Fatalf("leader = %d, want %d", newLeader, transferee)
+		}
+	})
+}
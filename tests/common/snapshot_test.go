@@ -0,0 +1,56 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/tests/v3/framework"
+	"go.etcd.io/etcd/tests/v3/framework/config"
+	"go.etcd.io/etcd/tests/v3/framework/testutils"
+)
+
+func TestSnapshotSave(t *testing.T) {
+	testRunner.BeforeTest(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clus := testRunner.NewCluster(ctx, t, config.WithClusterSize(1))
+	defer clus.Close()
+	cc := framework.MustClient(clus.Client())
+	testutils.ExecuteUntil(ctx, t, func() {
+		for i := 0; i < 10; i++ {
+			if err := cc.Put(ctx, fmt.Sprintf("key%d", i), "val", config.PutOptions{}); err != nil {
+				t.Fatalf("could not put key (%v)", err)
+			}
+		}
+
+		path := filepath.Join(t.TempDir(), "snapshot.db")
+		if err := cc.SnapshotSave(ctx, path); err != nil {
+			t.Fatalf("could not save snapshot (%v)", err)
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() == 0 {
+			t.Fatalf("snapshot %q is empty", path)
+		}
+	})
+}
@@ -49,6 +49,9 @@ func TestStatus(t *testing.T) {
 					if r == nil {
 						t.Fatalf("status response is nil")
 					}
+					if r.WalVersion == "" {
+						t.Fatalf("status response has no wal version")
+					}
 					memberIds[r.Header.MemberId] = struct{}{}
 				}
 				if len(rs) != len(memberIds) {
@@ -31,3 +31,7 @@ func init() {
 func WithAuth(userName, password string) config.ClientOption {
 	return func(any) {}
 }
+
+func WithEndpoints(endpoints []string) config.ClientOption {
+	return func(any) {}
+}
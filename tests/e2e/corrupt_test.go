@@ -194,3 +194,57 @@ func TestCompactHashCheckDetectCorruption(t *testing.T) {
 	assert.NoError(t, err, "error on alarm list")
 	assert.Equal(t, []*etcdserverpb.AlarmMember{{Alarm: etcdserverpb.AlarmType_CORRUPT, MemberID: memberID}}, alarmResponse.Alarms)
 }
+
+func TestStartWithCorruptedFiles(t *testing.T) {
+	tcs := []struct {
+		name       string
+		corruption e2e.FileCorruption
+		expect     e2e.StartExpectation
+	}{
+		{
+			name:       "TornWALTail",
+			corruption: e2e.TruncateWALTail(4),
+			expect:     e2e.StartExpectation{Logs: []string{"repaired WAL"}},
+		},
+		{
+			name:       "CorruptedWALTail",
+			corruption: e2e.CorruptWALTail(8),
+			expect:     e2e.StartExpectation{Fail: true, Logs: []string{"failed to read WAL, cannot be repaired"}},
+		},
+		{
+			name:       "TruncatedBackend",
+			corruption: e2e.TruncateBackend(1024),
+			expect:     e2e.StartExpectation{Fail: true, Logs: []string{"failed to open database"}},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			e2e.BeforeTest(t)
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			epc, err := e2e.NewEtcdProcessCluster(ctx, t, &e2e.EtcdProcessClusterConfig{
+				ClusterSize: 1,
+				KeepDataDir: true,
+			})
+			if err != nil {
+				t.Fatalf("could not start etcd process cluster (%v)", err)
+			}
+			t.Cleanup(func() {
+				if errC := epc.Close(); errC != nil {
+					t.Fatalf("error closing etcd processes (%v)", errC)
+				}
+			})
+
+			cc, err := e2e.NewEtcdctl(epc.Cfg, epc.EndpointsV3())
+			assert.NoError(t, err)
+			for i := 0; i < 10; i++ {
+				err := cc.Put(ctx, testutil.PickKey(int64(i)), fmt.Sprint(i), config.PutOptions{})
+				assert.NoError(t, err, "error on put")
+			}
+
+			ep := epc.Procs[0]
+			assert.NoError(t, ep.Stop())
+			assert.NoError(t, e2e.StartCorrupted(ctx, ep, tc.expect, tc.corruption))
+		})
+	}
+}
@@ -57,7 +57,9 @@ func TestCtlV3AuthLeaseGrantLeases(t *testing.T) { testCtl(t, authLeaseTestLease
 func TestCtlV3AuthLeaseGrantLeasesJWT(t *testing.T) {
 	testCtl(t, authLeaseTestLeaseGrantLeases, withCfg(*e2e.NewConfigJWT()))
 }
-func TestCtlV3AuthLeaseRevoke(t *testing.T) { testCtl(t, authLeaseTestLeaseRevoke) }
+func TestCtlV3AuthLeaseRevoke(t *testing.T)          { testCtl(t, authLeaseTestLeaseRevoke) }
+func TestCtlV3AuthLeasePermission(t *testing.T)      { testCtl(t, authLeaseTestLeasePermission) }
+func TestCtlV3AuthEffectivePermissions(t *testing.T) { testCtl(t, authTestEffectivePermissions) }
 
 func TestCtlV3AuthRoleGet(t *testing.T)  { testCtl(t, authTestRoleGet) }
 func TestCtlV3AuthUserGet(t *testing.T)  { testCtl(t, authTestUserGet) }
@@ -922,6 +924,76 @@ func authLeaseTestLeaseRevoke(cx ctlCtx) {
 	}
 }
 
+func authLeaseTestLeasePermission(cx ctlCtx) {
+	if err := authEnable(cx); err != nil {
+		cx.t.Fatal(err)
+	}
+
+	cx.user, cx.pass = "root", "root"
+	authSetupTestUser(cx)
+
+	leaseID, err := ctlV3LeaseGrant(cx, 10)
+	if err != nil {
+		cx.t.Fatalf("ctlV3LeaseGrant error (%v)", err)
+	}
+
+	// the lease of root can't be revoked by test-user without the lease permission
+	cx.user, cx.pass = "test-user", "pass"
+	if err = e2e.SpawnWithExpectWithEnv(append(cx.PrefixArgs(), "lease", "revoke", leaseID), cx.envMap, "permission denied"); err != nil {
+		cx.t.Fatal(err)
+	}
+
+	cx.user, cx.pass = "root", "root"
+	if err = e2e.SpawnWithExpectWithEnv(append(cx.PrefixArgs(), "role", "grant-permission", "test-role", "lease"), cx.envMap, "Role test-role updated"); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err = e2e.SpawnWithExpects(append(cx.PrefixArgs(), "role", "get", "test-role"), cx.envMap, "Lease:", "<all leases>"); err != nil {
+		cx.t.Fatal(err)
+	}
+
+	cx.user, cx.pass = "test-user", "pass"
+	if err = ctlV3LeaseRevoke(cx, leaseID); err != nil {
+		cx.t.Fatalf("ctlV3LeaseRevoke error (%v)", err)
+	}
+
+	cx.user, cx.pass = "root", "root"
+	if err = ctlV3Role(cx, []string{"revoke-permission", "--lease", "test-role"}, "Lease permission is revoked from role test-role"); err != nil {
+		cx.t.Fatal(err)
+	}
+}
+
+func authTestEffectivePermissions(cx ctlCtx) {
+	if err := authEnable(cx); err != nil {
+		cx.t.Fatal(err)
+	}
+
+	cx.user, cx.pass = "root", "root"
+	authSetupTestUser(cx)
+
+	// the grants of test-role and test-role2 overlap
+	if err := ctlV3Role(cx, []string{"add", "test-role2"}, "Role test-role2 created"); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err := ctlV3RoleGrantPermission(cx, "test-role", grantingPerm{true, true, "foo0", "foo5", false}); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err := ctlV3RoleGrantPermission(cx, "test-role2", grantingPerm{true, false, "foo3", "foo9", false}); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err := ctlV3User(cx, []string{"grant-role", "test-user", "test-role2"}, "Role test-role2 is granted to user test-user", nil); err != nil {
+		cx.t.Fatal(err)
+	}
+
+	cmdArgs := append(cx.PrefixArgs(), "auth", "effective-permissions", "test-user")
+	if err := e2e.SpawnWithExpects(cmdArgs, cx.envMap, "read, foo, ", "read, foo0, foo9", "write, foo, ", "write, foo0, foo5"); err != nil {
+		cx.t.Fatal(err)
+	}
+	cmdArgs = append(cx.PrefixArgs(), "--write-out=json", "auth", "effective-permissions", "root")
+	if err := e2e.SpawnWithExpectWithEnv(cmdArgs, cx.envMap, `"Read":[{"Key":"","RangeEnd":"AA=="}]`); err != nil {
+		cx.t.Fatal(err)
+	}
+}
+
 func authTestWatch(cx ctlCtx) {
 	if err := authEnable(cx); err != nil {
 		cx.t.Fatal(err)
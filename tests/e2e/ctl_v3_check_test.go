@@ -0,0 +1,58 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.etcd.io/etcd/tests/v3/framework/e2e"
+)
+
+func TestCtlV3CheckHashKV(t *testing.T) {
+	testCtl(t, checkHashKVTest, withCfg(*e2e.NewConfigNoTLS()), withQuorum())
+}
+
+func checkHashKVTest(cx ctlCtx) {
+	for _, k := range []string{"a/1", "b/1", "a/2"} {
+		if err := ctlV3Put(cx, k, "bar", ""); err != nil {
+			cx.t.Fatal(err)
+		}
+	}
+	eps := cx.epc.EndpointsV3()
+	hashes := make(map[string]string)
+	for _, prefix := range []string{"a/", "b/"} {
+		cmdArgs := append(cx.PrefixArgs(), "check", "hashkv", "--prefix", prefix)
+		lines, err := e2e.SpawnWithExpectLines(context.TODO(), cmdArgs, cx.envMap, eps...)
+		if err != nil {
+			cx.t.Fatalf("check hashkv --prefix %s error (%v)", prefix, err)
+		}
+		for i, line := range lines {
+			var ep string
+			var hash uint32
+			if _, err = fmt.Sscanf(line, "%s %d", &ep, &hash); err != nil {
+				cx.t.Fatalf("unexpected output %q (%v)", line, err)
+			}
+			if i > 0 && fmt.Sprint(hash) != hashes[prefix] {
+				cx.t.Fatalf("hash of %s differs between endpoints: %q", prefix, lines)
+			}
+			hashes[prefix] = fmt.Sprint(hash)
+		}
+	}
+	if hashes["a/"] == hashes["b/"] {
+		cx.t.Fatalf("hashes of distinct ranges are both %s", hashes["a/"])
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"fmt"
+	"testing"
+
+	"go.etcd.io/etcd/tests/v3/framework/e2e"
+)
+
+func TestCtlV3DelDryRun(t *testing.T)        { testCtl(t, delDryRunTest) }
+func TestCtlV3CompactionDryRun(t *testing.T) { testCtl(t, compactionDryRunTest) }
+func TestCtlV3MemberRemoveDryRun(t *testing.T) {
+	testCtl(t, memberRemoveDryRunTest, withQuorum())
+}
+func TestCtlV3LeaseRevokeDryRun(t *testing.T) { testCtl(t, leaseRevokeDryRunTest) }
+func TestCtlV3AuthDisableDryRun(t *testing.T) { testCtl(t, authDisableDryRunTest) }
+
+func delDryRunTest(cx ctlCtx) {
+	for _, k := range []string{"key1", "key2", "other"} {
+		if err := ctlV3Put(cx, k, "val", ""); err != nil {
+			cx.t.Fatal(err)
+		}
+	}
+	cmdArgs := append(cx.PrefixArgs(), "del", "--dry-run", "--prefix", "key")
+	if err := e2e.SpawnWithExpects(cmdArgs, cx.envMap, `would delete 2 keys in ["key", "kez") at revision 4`); err != nil {
+		cx.t.Fatal(err)
+	}
+	cmdArgs = append(cx.PrefixArgs(), "del", "--dry-run", "key1", "other")
+	if err := e2e.SpawnWithExpects(cmdArgs, cx.envMap, `would delete 2 keys in ["key1", "other") at revision 4`); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err := ctlV3Get(cx, []string{"key", "--prefix"}, kv{"key1", "val"}, kv{"key2", "val"}); err != nil {
+		cx.t.Fatal(err)
+	}
+}
+
+func compactionDryRunTest(cx ctlCtx) {
+	for i := 0; i < 2; i++ {
+		if err := ctlV3Put(cx, "key", "val", ""); err != nil {
+			cx.t.Fatal(err)
+		}
+	}
+	cmdArgs := append(cx.PrefixArgs(), "compaction", "--dry-run", "3")
+	if err := e2e.SpawnWithExpects(cmdArgs, cx.envMap, "would compact the history before revision 3, keeping the 1 keys at revision 3 (current revision 3)"); err != nil {
+		cx.t.Fatal(err)
+	}
+	cmdArgs = append(cx.PrefixArgs(), "compaction", "--dry-run", "4")
+	if err := e2e.SpawnWithExpects(cmdArgs, cx.envMap, "compaction would fail: etcdserver: mvcc: required revision is a future revision"); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err := e2e.SpawnWithExpects(append(cx.PrefixArgs(), "compaction", "3"), cx.envMap, "compacted revision 3"); err != nil {
+		cx.t.Fatal(err)
+	}
+	cmdArgs = append(cx.PrefixArgs(), "compaction", "--dry-run", "3")
+	if err := e2e.SpawnWithExpects(cmdArgs, cx.envMap, "compaction would fail: revision 3 is compacted (compacted revision 3)"); err != nil {
+		cx.t.Fatal(err)
+	}
+}
+
+func memberRemoveDryRunTest(cx ctlCtx) {
+	mr, err := getMemberList(cx)
+	if err != nil {
+		cx.t.Fatal(err)
+	}
+	memberID := fmt.Sprintf("%x", mr.Members[0].ID)
+	cmdArgs := append(cx.PrefixArgs(), "member", "remove", "--dry-run", memberID)
+	if err = e2e.SpawnWithExpects(cmdArgs, cx.envMap,
+		fmt.Sprintf("would remove member %s from cluster %x", memberID, mr.Header.ClusterId),
+		"voting members: 3 -> 2, quorum: 2 -> 2, tolerated failures: 1 -> 0",
+	); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err = ctlV3MemberList(cx); err != nil {
+		cx.t.Fatal(err)
+	}
+}
+
+func leaseRevokeDryRunTest(cx ctlCtx) {
+	leaseID, err := ctlV3LeaseGrant(cx, 100)
+	if err != nil {
+		cx.t.Fatal(err)
+	}
+	if err = ctlV3Put(cx, "key", "val", leaseID); err != nil {
+		cx.t.Fatal(err)
+	}
+	cmdArgs := append(cx.PrefixArgs(), "lease", "revoke", "--dry-run", leaseID)
+	if err = e2e.SpawnWithExpects(cmdArgs, cx.envMap, fmt.Sprintf("would revoke lease %s (remaining ", leaseID), "deleting its 1 keys", `"key"`); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err = ctlV3Get(cx, []string{"key"}, kv{"key", "val"}); err != nil {
+		cx.t.Fatal(err)
+	}
+}
+
+func authDisableDryRunTest(cx ctlCtx) {
+	cmdArgs := append(cx.PrefixArgs(), "auth", "disable", "--dry-run")
+	if err := e2e.SpawnWithExpects(cmdArgs, cx.envMap, "authentication is already disabled"); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err := authEnable(cx); err != nil {
+		cx.t.Fatal(err)
+	}
+	cx.user, cx.pass = "root", "root"
+	authSetupTestUser(cx)
+
+	cmdArgs = append(cx.PrefixArgs(), "auth", "disable", "--dry-run")
+	if err := e2e.SpawnWithExpects(cmdArgs, cx.envMap, "would disable authentication: the permissions of 1 roles granted to 2 users would stop being enforced"); err != nil {
+		cx.t.Fatal(err)
+	}
+	// authentication is still enabled
+	cx.user, cx.pass = "test-user", "pass"
+	if err := ctlV3PutFailPerm(cx, "hoo", "bar"); err != nil {
+		cx.t.Fatal(err)
+	}
+}
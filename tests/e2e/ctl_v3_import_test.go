@@ -0,0 +1,124 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.etcd.io/etcd/tests/v3/framework/e2e"
+)
+
+func TestCtlV3ImportConsul(t *testing.T) { testCtl(t, importConsulTest) }
+func TestCtlV3ImportZK(t *testing.T)     { testCtl(t, importZKTest) }
+func TestCtlV3ExportImport(t *testing.T) { testCtl(t, exportImportTest) }
+
+func importConsulTest(cx ctlCtx) {
+	export := `[
+	{"key": "app/db", "flags": 0, "value": "aG9zdD1kYg=="},
+	{"key": "app/web", "flags": 0, "value": "cG9ydD04MA=="},
+	{"key": "other", "flags": 0, "value": "MQ=="}
+]`
+	path := writeImportFile(cx, export)
+
+	args := append(cx.PrefixArgs(), "import", "--format", "consul-kv-json", "--map", "app/=/config/app/", "--skip-unmapped", "--max-txn-ops", "1")
+	if err := e2e.SpawnWithExpects(append(args, "--dry-run", path), cx.envMap,
+		"put app/db -> /config/app/db (7 bytes)",
+		"put app/web -> /config/app/web (7 bytes)",
+		"skip other: no matching key mapping rule",
+		"would import 2 keys (43 bytes) with 0 leases, skipping 1 keys",
+	); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err := ctlV3Get(cx, []string{"", "--prefix"}); err != nil {
+		cx.t.Fatal(err)
+	}
+
+	if err := e2e.SpawnWithExpects(append(args, path), cx.envMap,
+		"skip other: no matching key mapping rule",
+		"imported 2 keys (43 bytes) with 0 leases, skipped 1 keys",
+	); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err := ctlV3Get(cx, []string{"", "--prefix"}, kv{"/config/app/db", "host=db"}, kv{"/config/app/web", "port=80"}); err != nil {
+		cx.t.Fatal(err)
+	}
+}
+
+func importZKTest(cx ctlCtx) {
+	export := `[
+	{"path": "/app", "data": null},
+	{"path": "/app/db", "data": "aG9zdD1kYg=="},
+	{"path": "/app/lock", "data": "MQ==", "ephemeralOwner": 7},
+	{"path": "/app/session", "data": "MQ==", "ttl": 60000}
+]`
+	path := writeImportFile(cx, export)
+
+	if err := e2e.SpawnWithExpects(append(cx.PrefixArgs(), "import", "--format", "zk-dump", path), cx.envMap,
+		"skip /app: znode without data",
+		"skip /app/lock: ephemeral znode",
+		"imported 2 keys (27 bytes) with 1 leases, skipped 2 keys",
+	); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err := ctlV3Get(cx, []string{"/app", "--prefix"}, kv{"/app/db", "host=db"}, kv{"/app/session", "1"}); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err := e2e.SpawnWithExpects(append(cx.PrefixArgs(), "lease", "list"), cx.envMap, "found 1 leases"); err != nil {
+		cx.t.Fatal(err)
+	}
+}
+
+func exportImportTest(cx ctlCtx) {
+	for _, kv := range []kv{{"app/db", "host=db"}, {"app/web", "port=80"}, {"other", "1"}} {
+		if err := ctlV3Put(cx, kv.key, kv.val, ""); err != nil {
+			cx.t.Fatal(err)
+		}
+	}
+
+	lines, err := e2e.RunUtilCompletion(append(cx.PrefixArgs(), "export", "--prefix", "--batch-size", "1", "app/"), cx.envMap)
+	if err != nil {
+		cx.t.Fatal(err)
+	}
+	var export []string
+	for _, l := range lines {
+		if strings.HasPrefix(l, "{") {
+			export = append(export, l)
+		}
+	}
+	if len(export) != 2 {
+		cx.t.Fatalf("expected 2 exported keys, got %q", lines)
+	}
+	path := writeImportFile(cx, strings.Join(export, "\n"))
+
+	if err := e2e.SpawnWithExpects(append(cx.PrefixArgs(), "import", "--format", "jsonl", "--map", "app/=copy/", "--rate", "10", path), cx.envMap,
+		"imported 2 keys (29 bytes) with 0 leases, skipped 0 keys",
+	); err != nil {
+		cx.t.Fatal(err)
+	}
+	if err := ctlV3Get(cx, []string{"copy/", "--prefix"}, kv{"copy/db", "host=db"}, kv{"copy/web", "port=80"}); err != nil {
+		cx.t.Fatal(err)
+	}
+}
+
+func writeImportFile(cx ctlCtx, export string) string {
+	path := filepath.Join(cx.t.TempDir(), "export.json")
+	if err := os.WriteFile(path, []byte(export), 0600); err != nil {
+		cx.t.Fatal(err)
+	}
+	return path
+}
@@ -35,6 +35,7 @@ func TestCtlV3GetFormat(t *testing.T)    { testCtl(t, getFormatTest) }
 func TestCtlV3GetRev(t *testing.T)       { testCtl(t, getRevTest) }
 func TestCtlV3GetKeysOnly(t *testing.T)  { testCtl(t, getKeysOnlyTest) }
 func TestCtlV3GetCountOnly(t *testing.T) { testCtl(t, getCountOnlyTest) }
+func TestCtlV3GetWatch(t *testing.T)     { testCtl(t, getWatchTest) }
 
 func TestCtlV3DelTimeout(t *testing.T) { testCtl(t, delTest, withDialTimeout(0)) }
 
@@ -260,6 +261,36 @@ func getCountOnlyTest(cx ctlCtx) {
 	}
 }
 
+func getWatchTest(cx ctlCtx) {
+	if err := ctlV3Put(cx, "key1", "val1", ""); err != nil {
+		cx.t.Fatal(err)
+	}
+	cmdArgs := append(cx.PrefixArgs(), "get", "--watch", "--prefix", "key")
+	proc, err := e2e.SpawnCmd(cmdArgs, cx.envMap)
+	if err != nil {
+		cx.t.Fatal(err)
+	}
+	defer proc.Stop()
+	for _, line := range []string{"key1", "val1", "--- watching from revision 3 ---"} {
+		if _, err = proc.Expect(line); err != nil {
+			cx.t.Fatal(err)
+		}
+	}
+	if err = ctlV3Put(cx, "key2", "val2", ""); err != nil {
+		cx.t.Fatal(err)
+	}
+	for _, line := range []string{"PUT", "key2", "val2"} {
+		if _, err = proc.Expect(line); err != nil {
+			cx.t.Fatal(err)
+		}
+	}
+
+	cmdArgs = append(cx.PrefixArgs(), "get", "--watch", "--limit", "1", "key")
+	if err = e2e.SpawnWithExpectWithEnv(cmdArgs, cx.envMap, "`--limit` cannot be used with `--watch`"); err != nil {
+		cx.t.Fatal(err)
+	}
+}
+
 func delTest(cx ctlCtx) {
 	tests := []struct {
 		puts []kv
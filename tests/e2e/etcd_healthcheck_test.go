@@ -0,0 +1,47 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/etcd/tests/v3/framework/e2e"
+)
+
+func TestEtcdHealthcheck(t *testing.T) {
+	e2e.SkipInShortMode(t)
+
+	epc, err := e2e.NewEtcdProcessCluster(context.TODO(), t, &e2e.EtcdProcessClusterConfig{
+		ClusterSize: 1,
+	})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, epc.Close())
+	}()
+
+	ep := epc.Procs[0].EndpointsV3()[0]
+	err = e2e.SpawnWithExpect([]string{e2e.BinPath.Etcd, "healthcheck", "--endpoint", ep}, "is healthy")
+	require.NoError(t, err)
+	err = e2e.SpawnWithExpect([]string{e2e.BinPath.Etcd, "healthcheck", "--endpoint", ep, "--serializable"}, "is healthy")
+	require.NoError(t, err)
+
+	require.NoError(t, epc.Procs[0].Stop())
+	err = e2e.SpawnWithExpect([]string{e2e.BinPath.Etcd, "healthcheck", "--endpoint", ep, "--timeout", "1s"}, "is unhealthy")
+	require.NoError(t, err)
+}
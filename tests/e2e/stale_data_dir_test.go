@@ -0,0 +1,77 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/tests/v3/framework/e2e"
+)
+
+// TestStaleDataDirRecovery ensures a member removed from its cluster and
+// restarted with its data dir rejoins the cluster as a new learner.
+func TestStaleDataDirRecovery(t *testing.T) {
+	e2e.BeforeTest(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	epc, err := e2e.NewEtcdProcessCluster(ctx, t, &e2e.EtcdProcessClusterConfig{
+		ClusterSize:                3,
+		KeepDataDir:                true,
+		DisableStrictReconfigCheck: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	})
+
+	cc, err := e2e.NewEtcdctl(epc.Cfg, epc.Procs[0].EndpointsV3())
+	require.NoError(t, err)
+
+	removed := epc.Procs[2]
+	members, err := cc.MemberList(ctx)
+	require.NoError(t, err)
+	var oldID uint64
+	for _, m := range members.Members {
+		if m.Name == removed.Config().Name {
+			oldID = m.ID
+		}
+	}
+	require.NotZero(t, oldID, "member not found")
+
+	require.NoError(t, removed.Stop())
+	_, err = cc.MemberRemove(ctx, oldID)
+	require.NoError(t, err)
+
+	removed.Config().Args = append(removed.Config().Args, "--experimental-stale-data-dir-recovery")
+	require.NoError(t, removed.Start(ctx))
+
+	members, err = cc.MemberList(ctx)
+	require.NoError(t, err)
+	var rejoined bool
+	for _, m := range members.Members {
+		if m.Name == removed.Config().Name {
+			assert.NotEqual(t, oldID, m.ID, "rejoined with the removed member ID")
+			assert.True(t, m.IsLearner, "expected the member to rejoin as a learner")
+			rejoined = true
+		}
+	}
+	assert.True(t, rejoined, "member did not rejoin the cluster")
+}
@@ -12,6 +12,11 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// These tests check the open files limit through syscall.Getrlimit, which is
+// not supported on Windows.
+//go:build !windows
+// +build !windows
+
 package e2e
 
 import (
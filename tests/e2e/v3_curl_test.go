@@ -29,6 +29,7 @@ import (
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/pkg/v3/testutil"
 	epb "go.etcd.io/etcd/server/v3/etcdserver/api/v3election/v3electionpb"
+	lockpb "go.etcd.io/etcd/server/v3/etcdserver/api/v3lock/v3lockpb"
 	"go.etcd.io/etcd/tests/v3/framework/e2e"
 
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
@@ -368,6 +369,51 @@ func testV3CurlResignMissiongLeaderKey(cx ctlCtx) {
 	}
 }
 
+func TestV3CurlSessionLockNoTLS(t *testing.T) {
+	for _, p := range apiPrefix {
+		testCtl(t, testV3CurlSessionLock, withApiPrefix(p), withCfg(*e2e.NewConfigNoTLS()))
+	}
+}
+
+func testV3CurlSessionLock(cx ctlCtx) {
+	leaseID := e2e.RandomLeaseID()
+
+	ldata, err := json.Marshal(&lockpb.LockRequest{Name: []byte("/lock-prefix"), Lease: leaseID})
+	if err != nil {
+		cx.t.Fatal(err)
+	}
+	tests := []v3cURLTest{
+		{
+			endpoint: "/session/open",
+			value:    gwLeaseGrant(cx, leaseID, 0),
+			expected: `"TTL":"60"`,
+		},
+		{
+			endpoint: "/lock/lock",
+			value:    string(ldata),
+			expected: `"key":"`,
+		},
+		{
+			endpoint: "/session/keepalive",
+			value:    gwLeaseKeepAlive(cx, leaseID),
+			expected: gwLeaseIDExpected(leaseID),
+		},
+		{
+			endpoint: "/session/close",
+			value:    gwLeaseRevoke(cx, leaseID),
+			expected: `"revision":"`,
+		},
+		{
+			endpoint: "/session/keepalive",
+			value:    gwLeaseKeepAlive(cx, leaseID),
+			expected: `requested lease not found`,
+		},
+	}
+	if err := CURLWithExpected(cx, tests); err != nil {
+		cx.t.Fatalf("testV3CurlSessionLock: %v", err)
+	}
+}
+
 func TestV3CurlMaintenanceAlarmMissiongAlarm(t *testing.T) {
 	for _, p := range apiPrefix {
 		testCtl(t, testV3CurlMaintenanceAlarmMissiongAlarm, withApiPrefix(p), withCfg(*e2e.NewConfigNoTLS()))
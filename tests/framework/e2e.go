@@ -93,6 +93,10 @@ func (c *e2eCluster) Client(opts ...config.ClientOption) (Client, error) {
 	return e2eClient{etcdctl}, err
 }
 
+func (c *e2eCluster) AddMember(ctx context.Context, t testing.TB, opts ...config.ClientOption) error {
+	return c.StartNewProc(ctx, t, opts...)
+}
+
 func (c *e2eCluster) Endpoints() []string {
 	return c.EndpointsV3()
 }
@@ -18,7 +18,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
-	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -33,7 +33,9 @@ import (
 	"go.etcd.io/etcd/tests/v3/framework/config"
 )
 
-const EtcdProcessBasePort = 20000
+// EtcdProcessBasePort is the first port of the process clusters, set by the
+// -base-port flag.
+var EtcdProcessBasePort = 20000
 
 type ClientConnType int
 
@@ -118,8 +120,8 @@ func NewConfigJWT() *EtcdProcessClusterConfig {
 	return &EtcdProcessClusterConfig{
 		ClusterSize:  1,
 		InitialToken: "new",
-		AuthTokenOpts: "jwt,pub-key=" + path.Join(FixturesDir, "server.crt") +
-			",priv-key=" + path.Join(FixturesDir, "server.key.insecure") + ",sign-method=RS256,ttl=1s",
+		AuthTokenOpts: "jwt,pub-key=" + filepath.Join(FixturesDir, "server.crt") +
+			",priv-key=" + filepath.Join(FixturesDir, "server.key.insecure") + ",sign-method=RS256,ttl=1s",
 	}
 }
 
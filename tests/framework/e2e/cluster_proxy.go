@@ -22,7 +22,7 @@ import (
 	"fmt"
 	"net"
 	"net/url"
-	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -177,7 +177,7 @@ func proxyListenURL(cfg *EtcdServerProcessConfig, portOffset int) string {
 func newProxyV2Proc(cfg *EtcdServerProcessConfig) *proxyV2Proc {
 	listenAddr := proxyListenURL(cfg, 2)
 	name := fmt.Sprintf("testname-proxy-%p", cfg)
-	dataDir := path.Join(cfg.DataDirPath, name+".etcd")
+	dataDir := filepath.Join(cfg.DataDirPath, name+".etcd")
 	args := []string{
 		"--name", name,
 		"--proxy", "on",
@@ -243,10 +243,10 @@ func newProxyV3Proc(cfg *EtcdServerProcessConfig) *proxyV3Proc {
 		// Configure certificates for connection proxy ---> server.
 		// This certificate must NOT have CN set.
 		tlsArgs = append(tlsArgs,
-			"--cert", path.Join(FixturesDir, "client-nocn.crt"),
-			"--key", path.Join(FixturesDir, "client-nocn.key.insecure"),
-			"--cacert", path.Join(FixturesDir, "ca.crt"),
-			"--client-crl-file", path.Join(FixturesDir, "revoke.crl"))
+			"--cert", filepath.Join(FixturesDir, "client-nocn.crt"),
+			"--key", filepath.Join(FixturesDir, "client-nocn.key.insecure"),
+			"--cacert", filepath.Join(FixturesDir, "ca.crt"),
+			"--client-crl-file", filepath.Join(FixturesDir, "revoke.crl"))
 	}
 
 	return &proxyV3Proc{
@@ -0,0 +1,155 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/server/v3/storage/datadir"
+)
+
+// FileCorruption damages the files in the data directory of a stopped member,
+// so that its recovery is exercised when it is started again.
+type FileCorruption func(dataDir string) error
+
+// CorruptWALTail flips the last n bytes written to the last WAL file, so that
+// its last records fail their checksum.
+func CorruptWALTail(n int64) FileCorruption {
+	return func(dataDir string) error {
+		f, end, err := lastWALFile(dataDir)
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		for i := max64(end-n, 0); i < end; i++ {
+			b[i] ^= 0xff
+		}
+		return os.WriteFile(f, b, 0600)
+	}
+}
+
+// TruncateWALTail cuts the last n bytes written to the last WAL file, as a
+// torn write would.
+func TruncateWALTail(n int64) FileCorruption {
+	return func(dataDir string) error {
+		f, end, err := lastWALFile(dataDir)
+		if err != nil {
+			return err
+		}
+		return os.Truncate(f, max64(end-n, 0))
+	}
+}
+
+// RemoveSnapshots deletes the raft snapshot files, keeping the backend.
+func RemoveSnapshots() FileCorruption {
+	return func(dataDir string) error {
+		snaps, err := filepath.Glob(filepath.Join(datadir.ToSnapDir(dataDir), "*.snap"))
+		if err != nil {
+			return err
+		}
+		for _, s := range snaps {
+			if err := os.Remove(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// TruncateBackend truncates the backend file to the given size.
+func TruncateBackend(size int64) FileCorruption {
+	return func(dataDir string) error {
+		return os.Truncate(datadir.ToBackendFileName(dataDir), size)
+	}
+}
+
+// lastWALFile returns the path of the last WAL file and the end of the
+// records written to it, past which the file is preallocated with zeros.
+func lastWALFile(dataDir string) (string, int64, error) {
+	names, err := filepath.Glob(filepath.Join(datadir.ToWalDir(dataDir), "*.wal"))
+	if err != nil {
+		return "", 0, err
+	}
+	if len(names) == 0 {
+		return "", 0, fmt.Errorf("no WAL file in %q", dataDir)
+	}
+	sort.Strings(names)
+	f := names[len(names)-1]
+	b, err := os.ReadFile(f)
+	if err != nil {
+		return "", 0, err
+	}
+	end := int64(len(b))
+	for end > 0 && b[end-1] == 0 {
+		end--
+	}
+	// records are padded to 8 bytes.
+	end = (end + 7) &^ 7
+	return f, end, nil
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// StartExpectation describes how a member is expected to behave when it is
+// started on a corrupted data directory.
+type StartExpectation struct {
+	// Fail expects the member to exit before serving client requests.
+	Fail bool
+	// Logs are expected to be found in the logs of the member.
+	Logs []string
+}
+
+// StartCorrupted applies the corruptions to the data directory of the stopped
+// member, starts it and checks that it behaves as expected. A member expected
+// to fail is stopped before returning.
+func StartCorrupted(ctx context.Context, ep EtcdProcess, expect StartExpectation, corruptions ...FileCorruption) error {
+	cfg := ep.Config()
+	for _, c := range corruptions {
+		if err := c(cfg.DataDirPath); err != nil {
+			return fmt.Errorf("failed to corrupt %q: %w", cfg.DataDirPath, err)
+		}
+	}
+	cfg.lg.Info("starting corrupted server...", zap.String("name", cfg.Name), zap.Bool("expect-fail", expect.Fail))
+	err := ep.Start(ctx)
+	if err == nil && expect.Fail {
+		return fmt.Errorf("server %q started, want it to fail", cfg.Name)
+	}
+	if err != nil && !expect.Fail {
+		return err
+	}
+	if expect.Fail {
+		defer ep.Stop()
+	}
+	for _, l := range expect.Logs {
+		if _, err := ep.Logs().ExpectWithContext(ctx, l); err != nil {
+			return fmt.Errorf("server %q: %w", cfg.Name, err)
+		}
+	}
+	return nil
+}
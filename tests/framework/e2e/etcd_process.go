@@ -19,7 +19,6 @@ import (
 	"fmt"
 	"net/url"
 	"os"
-	"syscall"
 	"testing"
 	"time"
 
@@ -179,7 +178,7 @@ func (ep *EtcdServerProcess) Logs() LogsExpect {
 
 func (ep *EtcdServerProcess) Kill() error {
 	ep.cfg.lg.Info("killing server...", zap.String("name", ep.cfg.Name))
-	return ep.proc.Signal(syscall.SIGKILL)
+	return ep.proc.Signal(os.Kill)
 }
 
 func (ep *EtcdServerProcess) Wait() error {
@@ -39,10 +39,10 @@ func init() {
 
 func initBinPathCov(binDir string) binPath {
 	return binPath{
-		Etcd:            binDir + "/etcd_test",
-		EtcdLastRelease: binDir + "/etcd-last-release",
-		Etcdctl:         binDir + "/etcdctl_test",
-		Etcdutl:         binDir + "/etcdutl_test",
+		Etcd:            binFile(binDir, "etcd_test"),
+		EtcdLastRelease: binFile(binDir, "etcd-last-release"),
+		Etcdctl:         binFile(binDir, "etcdctl_test"),
+		Etcdutl:         binFile(binDir, "etcdutl_test"),
 	}
 }
 
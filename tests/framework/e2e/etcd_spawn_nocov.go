@@ -26,10 +26,10 @@ func init() {
 
 func initBinPathNoCov(binDir string) binPath {
 	return binPath{
-		Etcd:            binDir + "/etcd",
-		EtcdLastRelease: binDir + "/etcd-last-release",
-		Etcdctl:         binDir + "/etcdctl",
-		Etcdutl:         binDir + "/etcdutl",
+		Etcd:            binFile(binDir, "etcd"),
+		EtcdLastRelease: binFile(binDir, "etcd-last-release"),
+		Etcdctl:         binFile(binDir, "etcdctl"),
+		Etcdutl:         binFile(binDir, "etcdutl"),
 	}
 }
 
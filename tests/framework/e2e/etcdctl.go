@@ -374,6 +374,22 @@ func (ctl *EtcdctlV3) HashKV(ctx context.Context, rev int64) ([]*clientv3.HashKV
 	return resp, err
 }
 
+func (ctl *EtcdctlV3) EffectiveConfig(ctx context.Context) ([]*clientv3.EffectiveConfigResponse, error) {
+	var epConfigs []*struct {
+		Endpoint string
+		Config   *clientv3.EffectiveConfigResponse
+	}
+	err := ctl.spawnJsonCmd(ctx, &epConfigs, "endpoint", "config")
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]*clientv3.EffectiveConfigResponse, len(epConfigs))
+	for i, e := range epConfigs {
+		resp[i] = e.Config
+	}
+	return resp, err
+}
+
 func (ctl *EtcdctlV3) Health(ctx context.Context) error {
 	args := ctl.cmdArgs()
 	args = append(args, "endpoint", "health")
@@ -496,6 +512,19 @@ func (ctl *EtcdctlV3) AlarmDisarm(ctx context.Context, _ *clientv3.AlarmMember)
 	return &resp, err
 }
 
+func (ctl *EtcdctlV3) MoveLeader(ctx context.Context, transfereeID uint64) error {
+	_, err := SpawnWithExpectLines(ctx, ctl.cmdArgs("move-leader", fmt.Sprintf("%x", transfereeID)), nil, "Leadership transferred")
+	return err
+}
+
+func (ctl *EtcdctlV3) SnapshotSave(ctx context.Context, path string) error {
+	// etcdctl requests snapshots from a single endpoint.
+	c := *ctl
+	c.endpoints = ctl.endpoints[:1]
+	_, err := SpawnWithExpectLines(ctx, c.cmdArgs("snapshot", "save", path), nil, fmt.Sprintf("Snapshot saved at %s", path))
+	return err
+}
+
 func (ctl *EtcdctlV3) AuthEnable(ctx context.Context) error {
 	args := []string{"auth", "enable"}
 	cmd, err := SpawnCmd(ctl.cmdArgs(args...), nil)
@@ -17,6 +17,7 @@ package e2e
 import (
 	"flag"
 	"os"
+	"path/filepath"
 	"runtime"
 
 	"go.etcd.io/etcd/tests/v3/framework/testutils"
@@ -58,19 +59,29 @@ func InitFlags() {
 
 	binDir := flag.String("bin-dir", binDirDef, "The directory for store etcd and etcdctl binaries.")
 	flag.StringVar(&CertDir, "cert-dir", certDirDef, "The directory for store certificate files.")
+	flag.IntVar(&EtcdProcessBasePort, "base-port", EtcdProcessBasePort, "The first port of the process clusters, to move them out of the ports reserved by the system.")
 	flag.Parse()
 
 	BinPath = initBinPath(*binDir)
-	CertPath = CertDir + "/server.crt"
-	PrivateKeyPath = CertDir + "/server.key.insecure"
-	CaPath = CertDir + "/ca.crt"
-	RevokedCertPath = CertDir + "/server-revoked.crt"
-	RevokedPrivateKeyPath = CertDir + "/server-revoked.key.insecure"
-	CrlPath = CertDir + "/revoke.crl"
-
-	CertPath2 = CertDir + "/server2.crt"
-	PrivateKeyPath2 = CertDir + "/server2.key.insecure"
-
-	CertPath3 = CertDir + "/server3.crt"
-	PrivateKeyPath3 = CertDir + "/server3.key.insecure"
+	CertPath = filepath.Join(CertDir, "server.crt")
+	PrivateKeyPath = filepath.Join(CertDir, "server.key.insecure")
+	CaPath = filepath.Join(CertDir, "ca.crt")
+	RevokedCertPath = filepath.Join(CertDir, "server-revoked.crt")
+	RevokedPrivateKeyPath = filepath.Join(CertDir, "server-revoked.key.insecure")
+	CrlPath = filepath.Join(CertDir, "revoke.crl")
+
+	CertPath2 = filepath.Join(CertDir, "server2.crt")
+	PrivateKeyPath2 = filepath.Join(CertDir, "server2.key.insecure")
+
+	CertPath3 = filepath.Join(CertDir, "server3.crt")
+	PrivateKeyPath3 = filepath.Join(CertDir, "server3.key.insecure")
+}
+
+// binFile returns the path of the named binary in binDir, with the
+// executable suffix of the platform.
+func binFile(binDir, name string) string {
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return filepath.Join(binDir, name)
 }
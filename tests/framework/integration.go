@@ -17,11 +17,15 @@ package framework
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/pkg/v3/testutil"
 	"go.etcd.io/etcd/client/pkg/v3/transport"
 	clientv3 "go.etcd.io/etcd/client/v3"
@@ -119,6 +123,15 @@ func (c *integrationCluster) Close() error {
 	return nil
 }
 
+func (c *integrationCluster) AddMember(ctx context.Context, t testing.TB, opts ...config.ClientOption) error {
+	cc, err := c.ClusterClient(t, opts...)
+	if err != nil {
+		return err
+	}
+	c.AddMemberWithClient(t, cc)
+	return nil
+}
+
 func (c *integrationCluster) Client(opts ...config.ClientOption) (Client, error) {
 	cc, err := c.ClusterClient(c.t, opts...)
 	if err != nil {
@@ -254,6 +267,39 @@ func (c integrationClient) Defragment(ctx context.Context, o config.DefragOption
 	return nil
 }
 
+func (c integrationClient) MoveLeader(ctx context.Context, transfereeID uint64) error {
+	// only the leader accepts the request, so retry while the balancer picks
+	// the other endpoints.
+	for {
+		_, err := c.Client.MoveLeader(ctx, transfereeID)
+		if err != rpctypes.ErrNotLeader {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (c integrationClient) SnapshotSave(ctx context.Context, path string) error {
+	resp, err := c.Client.SnapshotWithVersion(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Snapshot.Close()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, resp.Snapshot); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func (c integrationClient) TimeToLive(ctx context.Context, id clientv3.LeaseID, o config.LeaseOption) (*clientv3.LeaseTimeToLiveResponse, error) {
 	var leaseOpts []clientv3.LeaseOption
 	if o.WithAttachedKeys {
@@ -263,6 +309,10 @@ func (c integrationClient) TimeToLive(ctx context.Context, id clientv3.LeaseID,
 	return c.Client.TimeToLive(ctx, id, leaseOpts...)
 }
 
+func (c integrationClient) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	return c.Client.Grant(ctx, ttl)
+}
+
 func (c integrationClient) Leases(ctx context.Context) (*clientv3.LeaseLeasesResponse, error) {
 	return c.Client.Leases(ctx)
 }
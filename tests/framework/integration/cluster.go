@@ -143,6 +143,17 @@ type ClusterConfig struct {
 	AuthTokenTTL uint
 
 	QuotaBackendBytes int64
+	MaxKeys           int64
+	StandbyOf         []string
+
+	LearnerSerializableReads      bool
+	PrefixTTLs                    []config.PrefixTTL
+	RaftEntryCompressionThreshold int
+	PeerMsgAppBatchBytes          int
+	LearnerHeartbeatInterval      time.Duration
+
+	MaxApplyBacklog     uint64
+	MaxPendingProposals uint64
 
 	MaxTxnOps              uint
 	MaxRequestBytes        uint
@@ -172,6 +183,9 @@ type ClusterConfig struct {
 	ExperimentalMaxLearners     int
 	DisableStrictReconfigCheck  bool
 	CorruptCheckTime            time.Duration
+	AutoPromoteLearners         bool
+
+	ResponseHeaderCompactRevision bool
 }
 
 type Cluster struct {
@@ -261,49 +275,65 @@ func (c *Cluster) mustNewMember(t testutil.TB) *Member {
 
 	m := MustNewMember(t,
 		MemberConfig{
-			Name:                        fmt.Sprintf("m%v", memberNumber),
-			MemberNumber:                memberNumber,
-			AuthToken:                   c.Cfg.AuthToken,
-			AuthTokenTTL:                c.Cfg.AuthTokenTTL,
-			PeerTLS:                     c.Cfg.PeerTLS,
-			ClientTLS:                   c.Cfg.ClientTLS,
-			QuotaBackendBytes:           c.Cfg.QuotaBackendBytes,
-			MaxTxnOps:                   c.Cfg.MaxTxnOps,
-			MaxRequestBytes:             c.Cfg.MaxRequestBytes,
-			SnapshotCount:               c.Cfg.SnapshotCount,
-			SnapshotCatchUpEntries:      c.Cfg.SnapshotCatchUpEntries,
-			GrpcKeepAliveMinTime:        c.Cfg.GRPCKeepAliveMinTime,
-			GrpcKeepAliveInterval:       c.Cfg.GRPCKeepAliveInterval,
-			GrpcKeepAliveTimeout:        c.Cfg.GRPCKeepAliveTimeout,
-			ClientMaxCallSendMsgSize:    c.Cfg.ClientMaxCallSendMsgSize,
-			ClientMaxCallRecvMsgSize:    c.Cfg.ClientMaxCallRecvMsgSize,
-			UseIP:                       c.Cfg.UseIP,
-			UseBridge:                   c.Cfg.UseBridge,
-			UseTCP:                      c.Cfg.UseTCP,
-			EnableLeaseCheckpoint:       c.Cfg.EnableLeaseCheckpoint,
-			LeaseCheckpointInterval:     c.Cfg.LeaseCheckpointInterval,
-			LeaseCheckpointPersist:      c.Cfg.LeaseCheckpointPersist,
-			WatchProgressNotifyInterval: c.Cfg.WatchProgressNotifyInterval,
-			ExperimentalMaxLearners:     c.Cfg.ExperimentalMaxLearners,
-			DisableStrictReconfigCheck:  c.Cfg.DisableStrictReconfigCheck,
-			CorruptCheckTime:            c.Cfg.CorruptCheckTime,
+			Name:                          fmt.Sprintf("m%v", memberNumber),
+			MemberNumber:                  memberNumber,
+			AuthToken:                     c.Cfg.AuthToken,
+			AuthTokenTTL:                  c.Cfg.AuthTokenTTL,
+			PeerTLS:                       c.Cfg.PeerTLS,
+			ClientTLS:                     c.Cfg.ClientTLS,
+			QuotaBackendBytes:             c.Cfg.QuotaBackendBytes,
+			MaxKeys:                       c.Cfg.MaxKeys,
+			StandbyOf:                     c.Cfg.StandbyOf,
+			LearnerSerializableReads:      c.Cfg.LearnerSerializableReads,
+			PrefixTTLs:                    c.Cfg.PrefixTTLs,
+			RaftEntryCompressionThreshold: c.Cfg.RaftEntryCompressionThreshold,
+			PeerMsgAppBatchBytes:          c.Cfg.PeerMsgAppBatchBytes,
+			LearnerHeartbeatInterval:      c.Cfg.LearnerHeartbeatInterval,
+			MaxApplyBacklog:               c.Cfg.MaxApplyBacklog,
+			MaxPendingProposals:           c.Cfg.MaxPendingProposals,
+			MaxTxnOps:                     c.Cfg.MaxTxnOps,
+			MaxRequestBytes:               c.Cfg.MaxRequestBytes,
+			SnapshotCount:                 c.Cfg.SnapshotCount,
+			SnapshotCatchUpEntries:        c.Cfg.SnapshotCatchUpEntries,
+			GrpcKeepAliveMinTime:          c.Cfg.GRPCKeepAliveMinTime,
+			GrpcKeepAliveInterval:         c.Cfg.GRPCKeepAliveInterval,
+			GrpcKeepAliveTimeout:          c.Cfg.GRPCKeepAliveTimeout,
+			ClientMaxCallSendMsgSize:      c.Cfg.ClientMaxCallSendMsgSize,
+			ClientMaxCallRecvMsgSize:      c.Cfg.ClientMaxCallRecvMsgSize,
+			UseIP:                         c.Cfg.UseIP,
+			UseBridge:                     c.Cfg.UseBridge,
+			UseTCP:                        c.Cfg.UseTCP,
+			EnableLeaseCheckpoint:         c.Cfg.EnableLeaseCheckpoint,
+			LeaseCheckpointInterval:       c.Cfg.LeaseCheckpointInterval,
+			LeaseCheckpointPersist:        c.Cfg.LeaseCheckpointPersist,
+			WatchProgressNotifyInterval:   c.Cfg.WatchProgressNotifyInterval,
+			ExperimentalMaxLearners:       c.Cfg.ExperimentalMaxLearners,
+			DisableStrictReconfigCheck:    c.Cfg.DisableStrictReconfigCheck,
+			CorruptCheckTime:              c.Cfg.CorruptCheckTime,
+			AutoPromoteLearners:           c.Cfg.AutoPromoteLearners,
+
+			ResponseHeaderCompactRevision: c.Cfg.ResponseHeaderCompactRevision,
 		})
 	m.DiscoveryURL = c.Cfg.DiscoveryURL
 	return m
 }
 
 // addMember return PeerURLs of the added member.
-func (c *Cluster) addMember(t testutil.TB) types.URLs {
+func (c *Cluster) addMember(t testutil.TB, cc *clientv3.Client) types.URLs {
 	m := c.mustNewMember(t)
 
 	scheme := SchemeFromTLSInfo(c.Cfg.PeerTLS)
+	peerURL := scheme + "://" + m.PeerListeners[0].Addr().String()
 
 	// send add request to the Cluster
 	var err error
-	for i := 0; i < len(c.Members); i++ {
-		peerURL := scheme + "://" + m.PeerListeners[0].Addr().String()
-		if err = c.AddMemberByURL(t, c.Members[i].Client, peerURL); err == nil {
-			break
+	if cc != nil {
+		err = c.AddMemberByURL(t, cc, peerURL)
+	} else {
+		for i := 0; i < len(c.Members); i++ {
+			if err = c.AddMemberByURL(t, c.Members[i].Client, peerURL); err == nil {
+				break
+			}
 		}
 	}
 	if err != nil {
@@ -341,7 +371,13 @@ func (c *Cluster) AddMemberByURL(t testutil.TB, cc *clientv3.Client, peerURL str
 
 // AddMember return PeerURLs of the added member.
 func (c *Cluster) AddMember(t testutil.TB) types.URLs {
-	return c.addMember(t)
+	return c.addMember(t, nil)
+}
+
+// AddMemberWithClient adds a member through the given client, e.g. one
+// authenticated as the root user, and returns the PeerURLs of the member.
+func (c *Cluster) AddMemberWithClient(t testutil.TB, cc *clientv3.Client) types.URLs {
+	return c.addMember(t, cc)
 }
 
 func (c *Cluster) RemoveMember(t testutil.TB, cc *clientv3.Client, id uint64) error {
@@ -584,33 +620,45 @@ type Member struct {
 func (m *Member) GRPCURL() string { return m.GrpcURL }
 
 type MemberConfig struct {
-	Name                        string
-	UniqNumber                  int64
-	MemberNumber                int
-	PeerTLS                     *transport.TLSInfo
-	ClientTLS                   *transport.TLSInfo
-	AuthToken                   string
-	AuthTokenTTL                uint
-	QuotaBackendBytes           int64
-	MaxTxnOps                   uint
-	MaxRequestBytes             uint
-	SnapshotCount               uint64
-	SnapshotCatchUpEntries      uint64
-	GrpcKeepAliveMinTime        time.Duration
-	GrpcKeepAliveInterval       time.Duration
-	GrpcKeepAliveTimeout        time.Duration
-	ClientMaxCallSendMsgSize    int
-	ClientMaxCallRecvMsgSize    int
-	UseIP                       bool
-	UseBridge                   bool
-	UseTCP                      bool
-	EnableLeaseCheckpoint       bool
-	LeaseCheckpointInterval     time.Duration
-	LeaseCheckpointPersist      bool
-	WatchProgressNotifyInterval time.Duration
-	ExperimentalMaxLearners     int
-	DisableStrictReconfigCheck  bool
-	CorruptCheckTime            time.Duration
+	Name                          string
+	UniqNumber                    int64
+	MemberNumber                  int
+	PeerTLS                       *transport.TLSInfo
+	ClientTLS                     *transport.TLSInfo
+	AuthToken                     string
+	AuthTokenTTL                  uint
+	QuotaBackendBytes             int64
+	MaxKeys                       int64
+	StandbyOf                     []string
+	LearnerSerializableReads      bool
+	PrefixTTLs                    []config.PrefixTTL
+	RaftEntryCompressionThreshold int
+	PeerMsgAppBatchBytes          int
+	LearnerHeartbeatInterval      time.Duration
+	MaxApplyBacklog               uint64
+	MaxPendingProposals           uint64
+	MaxTxnOps                     uint
+	MaxRequestBytes               uint
+	SnapshotCount                 uint64
+	SnapshotCatchUpEntries        uint64
+	GrpcKeepAliveMinTime          time.Duration
+	GrpcKeepAliveInterval         time.Duration
+	GrpcKeepAliveTimeout          time.Duration
+	ClientMaxCallSendMsgSize      int
+	ClientMaxCallRecvMsgSize      int
+	UseIP                         bool
+	UseBridge                     bool
+	UseTCP                        bool
+	EnableLeaseCheckpoint         bool
+	LeaseCheckpointInterval       time.Duration
+	LeaseCheckpointPersist        bool
+	WatchProgressNotifyInterval   time.Duration
+	ExperimentalMaxLearners       int
+	DisableStrictReconfigCheck    bool
+	CorruptCheckTime              time.Duration
+	AutoPromoteLearners           bool
+
+	ResponseHeaderCompactRevision bool
 }
 
 // MustNewMember return an inited member with the given name. If peerTLS is
@@ -662,6 +710,15 @@ func MustNewMember(t testutil.TB, mcfg MemberConfig) *Member {
 	m.InitialElectionTickAdvance = true
 	m.TickMs = uint(framecfg.TickDuration / time.Millisecond)
 	m.QuotaBackendBytes = mcfg.QuotaBackendBytes
+	m.MaxKeys = mcfg.MaxKeys
+	m.StandbyOf = mcfg.StandbyOf
+	m.LearnerSerializableReads = mcfg.LearnerSerializableReads
+	m.PrefixTTLs = mcfg.PrefixTTLs
+	m.RaftEntryCompressionThreshold = mcfg.RaftEntryCompressionThreshold
+	m.PeerMsgAppBatchBytes = mcfg.PeerMsgAppBatchBytes
+	m.LearnerHeartbeatInterval = mcfg.LearnerHeartbeatInterval
+	m.MaxApplyBacklog = mcfg.MaxApplyBacklog
+	m.MaxPendingProposals = mcfg.MaxPendingProposals
 	m.MaxTxnOps = mcfg.MaxTxnOps
 	if m.MaxTxnOps == 0 {
 		m.MaxTxnOps = embed.DefaultMaxTxnOps
@@ -714,6 +771,7 @@ func MustNewMember(t testutil.TB, mcfg MemberConfig) *Member {
 	m.LeaseCheckpointPersist = mcfg.LeaseCheckpointPersist
 
 	m.WatchProgressNotifyInterval = mcfg.WatchProgressNotifyInterval
+	m.ResponseHeaderCompactRevision = mcfg.ResponseHeaderCompactRevision
 
 	m.InitialCorruptCheck = true
 	if mcfg.CorruptCheckTime > time.Duration(0) {
@@ -729,6 +787,8 @@ func MustNewMember(t testutil.TB, mcfg MemberConfig) *Member {
 	m.GrpcServerRecorder = &grpc_testing.GrpcRecorder{}
 	m.Logger = memberLogger(t, mcfg.Name)
 	m.StrictReconfigCheck = !mcfg.DisableStrictReconfigCheck
+	m.AutoPromoteLearners = mcfg.AutoPromoteLearners
+	m.AutoPromoteLearnersMaxLag = embed.DefaultAutoPromoteLearnersMaxLag
 	if err := m.listenGRPC(); err != nil {
 		t.Fatalf("listenGRPC FAILED: %v", err)
 	}
@@ -1463,6 +1523,13 @@ func WithAuth(userName, password string) framecfg.ClientOption {
 	}
 }
 
+func WithEndpoints(endpoints []string) framecfg.ClientOption {
+	return func(c any) {
+		cfg := c.(*clientv3.Config)
+		cfg.Endpoints = endpoints
+	}
+}
+
 func (c *Cluster) newClientCfg() (*clientv3.Config, error) {
 	cfg := &clientv3.Config{
 		Endpoints:          c.Endpoints(),
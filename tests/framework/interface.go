@@ -31,6 +31,9 @@ type TestRunner interface {
 type Cluster interface {
 	Members() []Member
 	Client(opts ...config.ClientOption) (Client, error)
+	// AddMember starts a new member and adds it to the cluster through a
+	// client created with the given options.
+	AddMember(ctx context.Context, t testing.TB, opts ...config.ClientOption) error
 	WaitLeader(t testing.TB) int
 	Close() error
 	Endpoints() []string
@@ -53,6 +56,12 @@ type Client interface {
 	Defragment(context context.Context, opts config.DefragOption) error
 	AlarmList(context context.Context) (*clientv3.AlarmResponse, error)
 	AlarmDisarm(context context.Context, alarmMember *clientv3.AlarmMember) (*clientv3.AlarmResponse, error)
+	// MoveLeader transfers the leadership to the given member, sending the
+	// request to the leader among the endpoints of the client.
+	MoveLeader(context context.Context, transfereeID uint64) error
+	// SnapshotSave saves a snapshot of one of the endpoints of the client to
+	// the given path.
+	SnapshotSave(context context.Context, path string) error
 	Grant(context context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error)
 	TimeToLive(context context.Context, id clientv3.LeaseID, opts config.LeaseOption) (*clientv3.LeaseTimeToLiveResponse, error)
 	Leases(context context.Context) (*clientv3.LeaseLeasesResponse, error)
@@ -292,6 +292,63 @@ func TestMemberPromote(t *testing.T) {
 	}
 }
 
+// TestMemberAutoPromote ensures that the leader promotes a started learner
+// caught up with its log when learners are auto-promoted.
+func TestMemberAutoPromote(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3, DisableStrictReconfigCheck: true, AutoPromoteLearners: true})
+	defer clus.Terminate(t)
+
+	capi := clus.RandClient()
+	for i := 0; i < 10; i++ {
+		if _, err := capi.Put(context.Background(), fmt.Sprintf("foo%d", i), "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	urls := []string{"http://127.0.0.1:1234"}
+	memberAddResp, err := capi.MemberAddAsLearner(context.Background(), urls)
+	if err != nil {
+		t.Fatalf("failed to add member %v", err)
+	}
+	learnerID := memberAddResp.Member.ID
+
+	isLearner := func() bool {
+		resp, err := capi.MemberList(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range resp.Members {
+			if m.ID == learnerID {
+				return m.IsLearner
+			}
+		}
+		t.Fatalf("member %x not found", learnerID)
+		return false
+	}
+
+	// learner is not started yet, it must not be promoted.
+	time.Sleep(2 * time.Second)
+	if !isLearner() {
+		t.Fatal("expected learner not started to stay a learner")
+	}
+
+	learnerMember := clus.MustNewMember(t, memberAddResp)
+	if err := learnerMember.Launch(); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(10 * time.Second)
+	for isLearner() {
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("timed out waiting for the learner to be auto-promoted")
+		}
+	}
+}
+
 // TestMemberPromoteMemberNotLearner ensures that promoting a voting member fails.
 func TestMemberPromoteMemberNotLearner(t *testing.T) {
 	integration2.BeforeTest(t)
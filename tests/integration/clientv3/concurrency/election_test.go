@@ -111,3 +111,70 @@ func TestResumeElection(t *testing.T) {
 		t.Errorf("expected new leader to be 'candidate1' got %q", string(kv.Value))
 	}
 }
+
+func TestElectionReacquire(t *testing.T) {
+	const prefix = "/reacquire-election/"
+
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	// keep alives are sent every third of the TTL
+	s, err := concurrency.NewSession(cli, concurrency.WithTTL(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	invalidatedc := make(chan int64, 1)
+	e := concurrency.NewElection(s, prefix, concurrency.WithElectionReacquire(func(rev int64, reason error) {
+		if reason != concurrency.ErrSessionLeaseRevoked {
+			t.Errorf("invalidated with %v, want %v", reason, concurrency.ErrSessionLeaseRevoked)
+		}
+		invalidatedc <- rev
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	if err = e.Campaign(ctx, "candidate1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.Proclaim(ctx, "candidate2"); err != nil {
+		t.Fatal(err)
+	}
+	rev := e.Rev()
+
+	// lose the leadership with the lease of its session
+	time.Sleep(time.Second)
+	if _, err = cli.Revoke(ctx, s.Lease()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case lost := <-invalidatedc:
+		if lost != rev {
+			t.Fatalf("invalidated revision %d, want %d", lost, rev)
+		}
+	case <-ctx.Done():
+		t.Fatal("lost leadership was not invalidated")
+	}
+
+	for e.Rev() <= rev {
+		select {
+		case <-ctx.Done():
+			t.Fatal("leadership was not reacquired")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	leader, err := e.Leader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kv := leader.Kvs[0]; string(kv.Key) != e.Key() || string(kv.Value) != "candidate2" {
+		t.Fatalf("leader %q=%q, want %q=%q", kv.Key, kv.Value, e.Key(), "candidate2")
+	}
+	if err = e.Resign(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -17,6 +17,7 @@ package concurrency_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/concurrency"
@@ -70,3 +71,84 @@ func TestMutexLockSessionExpired(t *testing.T) {
 
 	<-m2Locked
 }
+
+func TestMutexReacquire(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	// keep alives are sent every third of the TTL
+	s, err := concurrency.NewSession(cli, concurrency.WithTTL(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	type invalidation struct {
+		token  int64
+		reason error
+	}
+	invalidatedc := make(chan invalidation, 1)
+	m := concurrency.NewMutex(s, "/reacquire-lock/", concurrency.WithMutexReacquire(func(token int64, reason error) {
+		invalidatedc <- invalidation{token, reason}
+	}))
+	if err = m.Lock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	token := m.FencingToken()
+
+	// lose the lock with the lease of its session
+	time.Sleep(time.Second)
+	if _, err = cli.Revoke(context.TODO(), s.Lease()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case inv := <-invalidatedc:
+		if inv.token != token || inv.reason != concurrency.ErrSessionLeaseRevoked {
+			t.Fatalf("invalidated(%d, %v), want invalidated(%d, %v)", inv.token, inv.reason, token, concurrency.ErrSessionLeaseRevoked)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("lost lock was not invalidated")
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for m.FencingToken() <= token {
+		if time.Now().After(deadline) {
+			t.Fatal("lock was not reacquired")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	resp, err := cli.Txn(context.TODO()).If(m.IsOwner()).Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Succeeded {
+		t.Fatal("reacquired lock is not owned")
+	}
+
+	// the session created to reacquire the lock is closed on unlock
+	key := m.Key()
+	if err = m.Unlock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	gresp, err := cli.Get(context.TODO(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gresp.Kvs) != 0 {
+		t.Fatalf("lock key %q still exists", key)
+	}
+
+	// the mutex locks in a new session once its session ended
+	if err = m.Lock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if m.FencingToken() <= token {
+		t.Fatalf("FencingToken() = %d, want > %d", m.FencingToken(), token)
+	}
+	if err = m.Unlock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+func TestRWMutexReadersShareWritersWait(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	s, err := concurrency.NewSession(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	r1 := concurrency.NewRWMutex(s, "/rw-lock", concurrency.WithOwnerInfo("r1"))
+	r2 := concurrency.NewRWMutex(s, "/rw-lock", concurrency.WithOwnerInfo("r2"))
+	w := concurrency.NewRWMutex(s, "/rw-lock", concurrency.WithOwnerInfo("w"))
+	if err = r1.RLock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err = r2.RLock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.TryLock(context.TODO()); err != concurrency.ErrLocked {
+		t.Fatalf("TryLock() = %v, want %v", err, concurrency.ErrLocked)
+	}
+	owners, err := w.Owners(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owners) != 2 || owners[0].Info != "r1" || owners[1].Info != "r2" || !owners[0].Read || owners[0].Lease != s.Lease() {
+		t.Fatalf("Owners() = %+v, want readers r1 and r2", owners)
+	}
+
+	lockedc := make(chan error, 1)
+	go func() { lockedc <- w.Lock(context.TODO()) }()
+	// readers queued after the waiting writer wait for it
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		r3 := concurrency.NewRWMutex(s, "/rw-lock")
+		if err = r3.TryRLock(context.TODO()); err == concurrency.ErrLocked {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = r3.RUnlock(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("writer did not wait for the lock")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err = r1.RUnlock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err = <-lockedc:
+		t.Fatalf("writer locked with a reader holding the lock (%v)", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err = r2.RUnlock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err = <-lockedc:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("writer did not lock once the readers unlocked")
+	}
+	if owners, err = w.Owners(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if len(owners) != 1 || owners[0].Info != "w" || owners[0].Read || owners[0].CreateRevision != w.FencingToken() {
+		t.Fatalf("Owners() = %+v, want writer w", owners)
+	}
+	if err = w.Unlock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFairMutexSameSession(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	s, err := concurrency.NewSession(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	m1 := concurrency.NewFairMutex(s, "/fair-lock", concurrency.WithOwnerInfo("m1"))
+	m2 := concurrency.NewFairMutex(s, "/fair-lock", concurrency.WithOwnerInfo("m2"))
+	if err = m1.Lock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err = m1.Lock(context.TODO()); err != concurrency.ErrAlreadyLocked {
+		t.Fatalf("Lock() = %v, want %v", err, concurrency.ErrAlreadyLocked)
+	}
+	// the mutexes of a session exclude each other
+	if err = m2.TryLockUntil(context.TODO(), time.Now().Add(500*time.Millisecond)); err != concurrency.ErrLocked {
+		t.Fatalf("TryLockUntil() = %v, want %v", err, concurrency.ErrLocked)
+	}
+	owner, err := m2.Owner(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner == nil || owner.Info != "m1" || owner.Key != m1.Key() {
+		t.Fatalf("Owner() = %+v, want m1", owner)
+	}
+	// the expired request was removed
+	resp, err := cli.Get(context.TODO(), "/fair-lock/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 1 {
+		t.Fatalf("%d lock requests, want 1", resp.Count)
+	}
+
+	lockedc := make(chan error, 1)
+	go func() { lockedc <- m2.Lock(context.TODO()) }()
+	time.Sleep(100 * time.Millisecond)
+	if err = m1.Unlock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err = <-lockedc:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("waiter did not lock once unlocked")
+	}
+	if err = m2.Unlock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutexTryLockUntil(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	s1, err := concurrency.NewSession(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s1.Close()
+	s2, err := concurrency.NewSession(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s2.Close()
+
+	m1 := concurrency.NewMutex(s1, "/until-lock/")
+	m2 := concurrency.NewMutex(s2, "/until-lock/")
+	if err = m1.Lock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err = m2.TryLockUntil(context.TODO(), time.Now().Add(500*time.Millisecond)); err != concurrency.ErrLocked {
+		t.Fatalf("TryLockUntil() = %v, want %v", err, concurrency.ErrLocked)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		m1.Unlock(context.TODO())
+	}()
+	if err = m2.TryLockUntil(context.TODO(), time.Now().Add(10*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err = m2.Unlock(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+func waitSessionErr(t *testing.T, s *concurrency.Session, want error) {
+	t.Helper()
+	if err := s.Err(); err != nil {
+		t.Fatalf("Err() = %v before the session ended", err)
+	}
+	select {
+	case <-s.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("session did not end")
+	}
+	if err := s.Err(); err != want {
+		t.Fatalf("Err() = %v, want %v", err, want)
+	}
+}
+
+func TestSessionErr(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	t.Run("closed", func(t *testing.T) {
+		s, err := concurrency.NewSession(cli)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go s.Close()
+		waitSessionErr(t, s, concurrency.ErrSessionClosed)
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		// keep alives are sent every third of the TTL
+		s, err := concurrency.NewSession(cli, concurrency.WithTTL(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		// wait for the first keep alive response
+		time.Sleep(time.Second)
+		if _, err = cli.Revoke(context.TODO(), s.Lease()); err != nil {
+			t.Fatal(err)
+		}
+		waitSessionErr(t, s, concurrency.ErrSessionLeaseRevoked)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		resp, err := cli.Grant(context.TODO(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			ttl, err := cli.TimeToLive(context.TODO(), resp.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ttl.TTL == -1 {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		s, err := concurrency.NewSession(cli, concurrency.WithLease(resp.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		waitSessionErr(t, s, concurrency.ErrSessionLeaseExpired)
+	})
+
+	t.Run("client closed", func(t *testing.T) {
+		cli2, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := concurrency.NewSession(cli2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go cli2.Close()
+		waitSessionErr(t, s, concurrency.ErrSessionClientClosed)
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// servedBy returns the ID of the member serving a serializable read.
+func servedBy(t *testing.T, cli *clientv3.Client) uint64 {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := cli.Get(ctx, "foo", clientv3.WithSerializable())
+	if err != nil {
+		return 0
+	}
+	return resp.Header.MemberId
+}
+
+// waitServedBy waits until a serializable read is served by the member
+// with the given ID.
+func waitServedBy(t *testing.T, cli *clientv3.Client, id uint64) {
+	for i := 0; i < 50; i++ {
+		if servedBy(t, cli) == id {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("reads are not served by member %x", id)
+}
+
+// TestBalancerPrimaryFallback expects the requests to go to the first
+// available endpoint in the order of the endpoints.
+func TestBalancerPrimaryFallback(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	cli, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:               []string{clus.Members[1].GRPCURL(), clus.Members[0].GRPCURL(), clus.Members[2].GRPCURL()},
+		EndpointPrimaryFallback: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	waitServedBy(t, cli, uint64(clus.Members[1].Server.MemberId()))
+	for i := 0; i < 10; i++ {
+		if id := servedBy(t, cli); id != uint64(clus.Members[1].Server.MemberId()) {
+			t.Fatalf("expected reads served by the primary member %x, got %x", clus.Members[1].Server.MemberId(), id)
+		}
+	}
+
+	clus.Members[1].Stop(t)
+	waitServedBy(t, cli, uint64(clus.Members[0].Server.MemberId()))
+
+	if err = clus.Members[1].Restart(t); err != nil {
+		t.Fatal(err)
+	}
+	waitServedBy(t, cli, uint64(clus.Members[1].Server.MemberId()))
+}
+
+// TestBalancerWeightedRoundRobin expects the requests to be spread over
+// the endpoints in proportion to their weights.
+func TestBalancerWeightedRoundRobin(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 2})
+	defer clus.Terminate(t)
+
+	eps := []string{clus.Members[0].GRPCURL(), clus.Members[1].GRPCURL()}
+	cli, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:       eps,
+		EndpointWeights: map[string]int{eps[0]: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	// wait for the connections to both members
+	waitServedBy(t, cli, uint64(clus.Members[1].Server.MemberId()))
+
+	served := make(map[uint64]int)
+	for i := 0; i < 40; i++ {
+		served[servedBy(t, cli)]++
+	}
+	if n := served[uint64(clus.Members[0].Server.MemberId())]; n != 30 {
+		t.Fatalf("expected 30 of 40 reads served by the member of weight 3, got %d (%v)", n, served)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity_test
+
+import (
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestClientStateWatcher ensures the client reports losing and regaining
+// its connection, and switching its endpoints.
+func TestClientStateWatcher(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	ep := clus.Members[0].GRPCURL()
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{ep}, DialTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sc := cli.StateWatcher()
+	waitState(t, sc, clientv3.StateConnected)
+
+	clus.Members[0].Stop(t)
+	waitState(t, sc, clientv3.StateDegraded)
+
+	if err = clus.Members[0].Restart(t); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, sc, clientv3.StateConnected)
+
+	cli.SetEndpoints(ep, ep)
+	ev := waitState(t, sc, clientv3.StateSwitchingEndpoints)
+	if len(ev.Endpoints) != 2 {
+		t.Errorf("endpoints = %v, want 2 endpoints", ev.Endpoints)
+	}
+
+	cli.Close()
+	for range sc {
+	}
+}
+
+func waitState(t *testing.T, sc <-chan clientv3.StateEvent, want clientv3.ConnectionState) clientv3.StateEvent {
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case ev, ok := <-sc:
+			if !ok {
+				t.Fatalf("state watcher closed, want %v", want)
+			}
+			if ev.State == want {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for state %v", want)
+		}
+	}
+}
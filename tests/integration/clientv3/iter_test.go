@@ -0,0 +1,136 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package clientv3test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+func TestKeys(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+
+	var wkeys []string
+	for i := 0; i < 5; i++ {
+		k := fmt.Sprintf("foo%d", i)
+		if _, err := cli.Put(ctx, k, "bar"); err != nil {
+			t.Fatal(err)
+		}
+		wkeys = append(wkeys, k)
+	}
+	if _, err := cli.Put(ctx, "zoo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for kv, err := range cli.Keys(ctx, "foo") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, string(kv.Key))
+	}
+	if !reflect.DeepEqual(wkeys, keys) {
+		t.Fatalf("expected keys %v, got %v", wkeys, keys)
+	}
+
+	keys = nil
+	for kv := range cli.Keys(ctx, "foo") {
+		keys = append(keys, string(kv.Key))
+		if len(keys) == 2 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(wkeys[:2], keys) {
+		t.Fatalf("expected keys %v, got %v", wkeys[:2], keys)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	for kv, err := range cli.Keys(cctx, "foo") {
+		if err != context.Canceled || kv != nil {
+			t.Fatalf("expected %v, got %v, %v", context.Canceled, kv, err)
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+
+	donec := make(chan []string)
+	go func() {
+		var keys []string
+		for ev, err := range cli.Events(ctx, "foo", clientv3.WithPrefix()) {
+			if err != nil {
+				t.Error(err)
+				break
+			}
+			keys = append(keys, string(ev.Kv.Key))
+			if ev.Type == mvccpb.DELETE {
+				break
+			}
+		}
+		donec <- keys
+	}()
+
+	// the watch starts at the revision following the current one
+	time.Sleep(500 * time.Millisecond)
+	for _, k := range []string{"foo1", "zoo", "foo2"} {
+		if _, err := cli.Put(ctx, k, "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cli.Delete(ctx, "foo1"); err != nil {
+		t.Fatal(err)
+	}
+	if keys, wkeys := <-donec, []string{"foo1", "foo2", "foo1"}; !reflect.DeepEqual(wkeys, keys) {
+		t.Fatalf("expected events on %v, got %v", wkeys, keys)
+	}
+
+	resp, err := cli.Put(ctx, "foo3", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.Compact(ctx, resp.Header.Revision); err != nil {
+		t.Fatal(err)
+	}
+	for ev, err := range cli.Events(ctx, "foo", clientv3.WithPrefix(), clientv3.WithRev(1)) {
+		if err != rpctypes.ErrCompacted || ev != nil {
+			t.Fatalf("expected %v, got %v, %v", rpctypes.ErrCompacted, ev, err)
+		}
+	}
+}
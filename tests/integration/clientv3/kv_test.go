@@ -17,6 +17,7 @@ package clientv3test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
@@ -25,10 +26,12 @@ import (
 	"testing"
 	"time"
 
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/api/v3/version"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/config"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -71,6 +74,83 @@ func TestKVPutError(t *testing.T) {
 	}
 }
 
+func TestKVPutTooManyKeys(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1, MaxKeys: 2})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.TODO()
+
+	for _, key := range []string{"foo1", "foo2"} {
+		if _, err := kv.Put(ctx, key, "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := kv.Put(ctx, "foo3", "bar")
+	if err != rpctypes.ErrTooManyKeys { // over quota
+		t.Fatalf("expected %v, got %v", rpctypes.ErrTooManyKeys, err)
+	}
+
+	// writes are rejected until the alarm is disarmed
+	resp, err := kv.AlarmList(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Alarms) != 1 || resp.Alarms[0].Alarm != pb.AlarmType_TOOMANYKEYS {
+		t.Fatalf("alarms = %v, want TOOMANYKEYS", resp.Alarms)
+	}
+	if _, err = kv.Delete(ctx, "foo1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = kv.Compact(ctx, 4, clientv3.WithCompactPhysical()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = kv.Put(ctx, "foo2", "baz"); err != rpctypes.ErrTooManyKeys {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrTooManyKeys, err)
+	}
+	if _, err = kv.AlarmDisarm(ctx, (*clientv3.AlarmMember)(resp.Alarms[0])); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = kv.Put(ctx, "foo3", "bar"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestKVPutOverloaded ensures the writes the server rejects while it has too
+// many pending writes succeed once the client retries them.
+func TestKVPutOverloaded(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1, MaxPendingProposals: 1})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	errc := make(chan error, 50)
+	for i := 0; i < cap(errc); i++ {
+		go func(i int) {
+			_, err := kv.Put(ctx, fmt.Sprintf("foo%d", i), "bar")
+			errc <- err
+		}(i)
+	}
+	for i := 0; i < cap(errc); i++ {
+		if err := <-errc; err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := kv.Get(ctx, "foo", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != int64(cap(errc)) {
+		t.Fatalf("expected %d keys, got %d", cap(errc), resp.Count)
+	}
+}
+
 func TestKVPutWithLease(t *testing.T) {
 	integration2.BeforeTest(t)
 
@@ -287,6 +367,324 @@ func TestKVRange(t *testing.T) {
 	}
 }
 
+// TestKVGetSerializableFallback ensures a linearizable range on a member of a
+// cluster which lost its quorum falls back to a serializable read within its
+// staleness bound, and keeps waiting beyond it.
+func TestKVGetSerializableFallback(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clus.Client(0)
+	if _, err := kv.Put(context.TODO(), "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := kv.Get(context.TODO(), "foo", clientv3.WithSerializableFallback(time.Minute, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.DegradedRead {
+		t.Fatal("read degraded with the quorum up")
+	}
+
+	clus.Members[1].Stop(t)
+	clus.Members[2].Stop(t)
+
+	resp, err = kv.Get(context.TODO(), "foo", clientv3.WithSerializableFallback(time.Minute, 100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Header.DegradedRead || len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "bar" {
+		t.Fatalf("got %+v, want degraded read of foo", resp)
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	_, err = kv.Get(ctx, "foo", clientv3.WithSerializableFallback(time.Millisecond, 100*time.Millisecond))
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected %v beyond the staleness bound, got %v", context.DeadlineExceeded, err)
+	}
+
+	clus.Client(1).Close()
+	clus.Client(2).Close()
+	clus.TakeClient(1)
+	clus.TakeClient(2)
+}
+
+func TestKVGetFieldMask(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.TODO()
+
+	if _, err := kv.Put(ctx, "foo", "0123456789"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kv.Put(ctx, "foo1", "01"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := kv.Get(ctx, "foo", clientv3.WithPrefix(),
+		clientv3.WithExcludeFields(clientv3.ExcludeCreateRevision, clientv3.ExcludeModRevision, clientv3.ExcludeVersion),
+		clientv3.WithMaxValueSize(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wkvs := []*mvccpb.KeyValue{
+		{Key: []byte("foo"), Value: []byte("0123"), ValueTruncated: true},
+		{Key: []byte("foo1"), Value: []byte("01")},
+	}
+	if !reflect.DeepEqual(wkvs, resp.Kvs) {
+		t.Fatalf("resp.Kvs expected %+v, got %+v", wkvs, resp.Kvs)
+	}
+
+	if _, err = kv.Get(ctx, "foo", clientv3.WithMaxValueSize(-1)); err != rpctypes.ErrInvalidFieldMask {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrInvalidFieldMask, err)
+	}
+}
+
+func TestKVPutMetadata(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+
+	wch := cli.Watch(ctx, "foo")
+	if _, err := cli.Put(ctx, "foo", "bar", clientv3.WithMetadata([]byte("text/plain"))); err != nil {
+		t.Fatal(err)
+	}
+	wresp := <-wch
+	if len(wresp.Events) != 1 || string(wresp.Events[0].Kv.Metadata) != "text/plain" {
+		t.Fatalf("expected event with metadata text/plain, got %+v", wresp.Events)
+	}
+
+	resp, err := cli.Get(ctx, "foo", clientv3.WithKeysOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Kvs[0].Metadata) != "text/plain" || resp.Kvs[0].Value != nil {
+		t.Fatalf("expected metadata text/plain without value, got %+v", resp.Kvs[0])
+	}
+
+	if _, err = cli.Put(ctx, "foo", "baz", clientv3.WithIgnoreMetadata()); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = cli.Get(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Kvs[0].Metadata) != "text/plain" {
+		t.Fatalf("expected metadata text/plain, got %q", resp.Kvs[0].Metadata)
+	}
+
+	_, err = cli.Put(ctx, "foo", "baz", clientv3.WithMetadata([]byte("x")), clientv3.WithIgnoreMetadata())
+	if err != rpctypes.ErrMetadataProvided {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrMetadataProvided, err)
+	}
+	_, err = cli.Put(ctx, "foo", "baz", clientv3.WithMetadata(make([]byte, 2048)))
+	if err != rpctypes.ErrMetadataTooLarge {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrMetadataTooLarge, err)
+	}
+}
+
+func TestKVPutTTL(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+
+	wch := cli.Watch(ctx, "foo", clientv3.WithPrefix())
+	if _, err := cli.Put(ctx, "foo", "bar", clientv3.WithTTL(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Put(ctx, "foo1", "bar", clientv3.WithTTL(1)); err != nil {
+		t.Fatal(err)
+	}
+	// a put without ttl keeps the key from expiring
+	if _, err := cli.Put(ctx, "foo1", "bar1"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := cli.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || resp.Kvs[0].Ttl != 1 {
+		t.Fatalf("expected foo with ttl 1, got %+v", resp.Kvs)
+	}
+
+	for deleted := false; !deleted; {
+		wresp, ok := <-wch
+		if !ok {
+			t.Fatal("watch closed before the deletion of foo")
+		}
+		if err = wresp.Err(); err != nil {
+			t.Fatal(err)
+		}
+		for _, ev := range wresp.Events {
+			if ev.Type != mvccpb.DELETE {
+				continue
+			}
+			if string(ev.Kv.Key) != "foo" {
+				t.Fatalf("expected the deletion of foo, got %+v", ev)
+			}
+			deleted = true
+		}
+	}
+	for _, m := range clus.Members {
+		if resp, err = m.Client.Get(ctx, "foo", clientv3.WithPrefix()); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Kvs) != 1 || string(resp.Kvs[0].Key) != "foo1" {
+			t.Fatalf("expected only foo1 on %s, got %+v", m.Name, resp.Kvs)
+		}
+	}
+
+	_, err = cli.Put(ctx, "foo", "bar", clientv3.WithTTL(10), clientv3.WithLease(1))
+	if err != rpctypes.ErrLeaseProvided {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrLeaseProvided, err)
+	}
+	_, err = cli.Put(ctx, "foo", "bar", clientv3.WithTTL(-1))
+	if err != rpctypes.ErrInvalidKeyTTL {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrInvalidKeyTTL, err)
+	}
+}
+
+// TestKVPrefixTTL ensures that the keys under the prefix of a TTL policy are
+// deleted once not modified for its TTL.
+func TestKVPrefixTTL(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{
+		Size:       3,
+		PrefixTTLs: []config.PrefixTTL{{Prefix: "/logs/", TTL: 3 * time.Second}},
+	})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Second)
+	defer cancel()
+
+	wch := cli.Watch(ctx, "/logs/", clientv3.WithPrefix(), clientv3.WithFilterPut())
+	for _, key := range []string{"/logs/a", "/logs/b", "/other/c"} {
+		if _, err := cli.Put(ctx, key, "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(1500 * time.Millisecond)
+	// modifying the key postpones its deletion
+	if _, err := cli.Put(ctx, "/logs/b", "v2"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"/logs/a", "/logs/b"} {
+		wresp, ok := <-wch
+		if !ok {
+			t.Fatalf("watch closed before the deletion of %s", want)
+		}
+		if err := wresp.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if len(wresp.Events) != 1 || string(wresp.Events[0].Kv.Key) != want {
+			t.Fatalf("expected the deletion of %s, got %+v", want, wresp.Events)
+		}
+	}
+	resp, err := cli.Get(ctx, "/", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Key) != "/other/c" {
+		t.Fatalf("expected only /other/c, got %+v", resp.Kvs)
+	}
+}
+
+func TestKVGetAutoPaging(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.TODO()
+
+	var wkeys []string
+	for i := 0; i < 10; i++ {
+		k := fmt.Sprintf("foo%d", i)
+		if _, err := kv.Put(ctx, k, "bar"); err != nil {
+			t.Fatal(err)
+		}
+		wkeys = append(wkeys, k)
+	}
+
+	resp, err := kv.Get(ctx, "foo", clientv3.WithPrefix(), clientv3.WithAutoPaging(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := kvKeys(resp.Kvs); !reflect.DeepEqual(wkeys, keys) || resp.More || resp.Count != 10 {
+		t.Fatalf("expected all of %v, got %v (more %v, count %d)", wkeys, keys, resp.More, resp.Count)
+	}
+
+	resp, err = kv.Get(ctx, "foo", clientv3.WithPrefix(), clientv3.WithAutoPaging(3), clientv3.WithLimit(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := kvKeys(resp.Kvs); !reflect.DeepEqual(wkeys[:4], keys) || !resp.More {
+		t.Fatalf("expected %v and more, got %v (more %v)", wkeys[:4], keys, resp.More)
+	}
+
+	// keys written between pages are not returned
+	var (
+		pages int
+		keys  []string
+	)
+	resp, err = kv.Get(ctx, "foo", clientv3.WithPrefix(), clientv3.WithAutoPaging(4),
+		clientv3.WithPageHandler(func(page *clientv3.GetResponse) error {
+			pages++
+			keys = append(keys, kvKeys(page.Kvs)...)
+			_, perr := kv.Put(ctx, fmt.Sprintf("foo9%d", pages), "bar")
+			return perr
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages != 3 || !reflect.DeepEqual(wkeys, keys) || len(resp.Kvs) != 0 {
+		t.Fatalf("expected %v in 3 pages, got %v in %d pages (%d keys in response)", wkeys, keys, pages, len(resp.Kvs))
+	}
+
+	errPage := errors.New("page error")
+	pages = 0
+	_, err = kv.Get(ctx, "foo", clientv3.WithPrefix(), clientv3.WithAutoPaging(4),
+		clientv3.WithPageHandler(func(page *clientv3.GetResponse) error {
+			pages++
+			return errPage
+		}))
+	if err != errPage || pages != 1 {
+		t.Fatalf("expected %v after 1 page, got %v after %d pages", errPage, err, pages)
+	}
+
+	_, err = kv.Get(ctx, "foo", clientv3.WithPrefix(), clientv3.WithAutoPaging(4), clientv3.WithSort(clientv3.SortByModRevision, clientv3.SortAscend))
+	if err != clientv3.ErrInvalidAutoPagingSort {
+		t.Fatalf("expected %v, got %v", clientv3.ErrInvalidAutoPagingSort, err)
+	}
+}
+
+func kvKeys(kvs []*mvccpb.KeyValue) []string {
+	keys := make([]string, 0, len(kvs))
+	for _, kv := range kvs {
+		keys = append(keys, string(kv.Key))
+	}
+	return keys
+}
+
 func TestKVGetErrConnClosed(t *testing.T) {
 	integration2.BeforeTest(t)
 
@@ -476,6 +874,83 @@ func TestKVCompact(t *testing.T) {
 	}
 }
 
+// TestKVCompactRevisionHeader ensures the response headers carry the
+// compaction revision if the server is configured so.
+func TestKVCompactRevisionHeader(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1, ResponseHeaderCompactRevision: true})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+
+	for i := 0; i < 10; i++ {
+		if _, err := cli.Put(ctx, "foo", "bar"); err != nil {
+			t.Fatalf("couldn't put 'foo' (%v)", err)
+		}
+	}
+	resp, err := cli.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.CompactRevision != 0 {
+		t.Fatalf("CompactRevision got %v before compaction, want 0", resp.Header.CompactRevision)
+	}
+
+	cresp, err := cli.Compact(ctx, 7)
+	if err != nil {
+		t.Fatalf("couldn't compact kv space (%v)", err)
+	}
+	if cresp.Header.CompactRevision != 7 {
+		t.Fatalf("compact CompactRevision got %v, want 7", cresp.Header.CompactRevision)
+	}
+	if resp, err = cli.Get(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.CompactRevision != 7 {
+		t.Fatalf("get CompactRevision got %v, want 7", resp.Header.CompactRevision)
+	}
+
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wr := <-cli.Watch(wctx, "foo", clientv3.WithRev(8))
+	if len(wr.Events) == 0 || wr.Header.CompactRevision != 7 {
+		t.Fatalf("watch got %d events with CompactRevision %v, want events with 7", len(wr.Events), wr.Header.CompactRevision)
+	}
+
+}
+
+// TestKVMaxAPIVersion ensures the client refuses the requests using fields
+// introduced after its declared API version.
+func TestKVMaxAPIVersion(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:     []string{clus.Members[0].GRPCURL()},
+		DialTimeout:   5 * time.Second,
+		MaxAPIVersion: "3.5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	ctx := context.TODO()
+	if _, err = cli.Get(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if ver := cli.ClusterAPIVersion(); ver == nil || ver.String() != "3.6.0" {
+		t.Fatalf("ClusterAPIVersion got %v, want 3.6.0", ver)
+	}
+	if _, err = cli.Get(ctx, "foo", clientv3.WithMaxValueSize(1)); err != rpctypes.ErrAPIVersionUnsupported {
+		t.Fatalf("err = %v, want %v", err, rpctypes.ErrAPIVersionUnsupported)
+	}
+}
+
 // TestKVGetRetry ensures get will retry on disconnect.
 func TestKVGetRetry(t *testing.T) {
 	integration2.BeforeTest(t)
@@ -771,6 +1246,84 @@ func TestKVLargeRequests(t *testing.T) {
 }
 
 // TestKVForLearner ensures learner member only accepts serializable read request.
+func TestKVGetStream(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{
+		Size:                     1,
+		MaxRequestBytes:          150 * 1024,
+		ClientMaxCallRecvMsgSize: 700 * 1024,
+	})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.TODO()
+
+	val := strings.Repeat("a", 100*1024)
+	for i := 0; i < 10; i++ {
+		if _, err := kv.Put(ctx, fmt.Sprintf("foo%d", i), val); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// enough small keys to be read in several pages
+	for i := 0; i < 1500; i += 100 {
+		var ops []clientv3.Op
+		for j := i; j < i+100; j++ {
+			ops = append(ops, clientv3.OpPut(fmt.Sprintf("bar%04d", j), "v"))
+		}
+		if _, err := kv.Txn(ctx).Then(ops...).Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := kv.Get(ctx, "foo", clientv3.WithPrefix()); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected %v, got %v", codes.ResourceExhausted, err)
+	}
+
+	tests := []struct {
+		prefix string
+		opts   []clientv3.OpOption
+		wkeys  int
+		wmore  bool
+		wcount int64
+	}{
+		{prefix: "foo", wkeys: 10, wcount: 10},
+		{prefix: "foo", opts: []clientv3.OpOption{clientv3.WithLimit(3)}, wkeys: 3, wmore: true, wcount: 10},
+		{prefix: "foo", opts: []clientv3.OpOption{clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend), clientv3.WithLimit(2)}, wkeys: 2, wmore: true, wcount: 10},
+		{prefix: "foo", opts: []clientv3.OpOption{clientv3.WithKeysOnly()}, wkeys: 10, wcount: 10},
+		{prefix: "bar", wkeys: 1500, wcount: 1500},
+		{prefix: "bar", opts: []clientv3.OpOption{clientv3.WithLimit(1200)}, wkeys: 1200, wmore: true, wcount: 1500},
+	}
+	for i, tt := range tests {
+		it, err := kv.GetStream(ctx, tt.prefix, append(tt.opts, clientv3.WithPrefix())...)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		rev := it.Header().Revision
+		// the keys written meanwhile are not part of the range
+		if _, err = kv.Put(ctx, tt.prefix+"99", "v"); err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for it.Next() {
+			keys = append(keys, string(it.KV().Key))
+			if it.KV().ModRevision > rev {
+				t.Errorf("#%d: key %s modified at %d, after the revision %d of the range", i, it.KV().Key, it.KV().ModRevision, rev)
+			}
+		}
+		it.Close()
+		if err = it.Err(); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if len(keys) != tt.wkeys || it.More() != tt.wmore || it.Count() != tt.wcount {
+			t.Errorf("#%d: got %d keys, more %v, count %d, want %d keys, more %v, count %d", i, len(keys), it.More(), it.Count(), tt.wkeys, tt.wmore, tt.wcount)
+		}
+		if _, err = kv.Delete(ctx, tt.prefix+"99"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func TestKVForLearner(t *testing.T) {
 	integration2.BeforeTest(t)
 
@@ -849,6 +1402,92 @@ func TestKVForLearner(t *testing.T) {
 	}
 }
 
+// TestKVReadEndpointsLearner ensures that the serializable gets of a client
+// are served by its read endpoints, here a learner serving serializable reads.
+func TestKVReadEndpointsLearner(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3, DisableStrictReconfigCheck: true, LearnerSerializableReads: true})
+	defer clus.Terminate(t)
+
+	clus.AddAndLaunchLearnerMember(t)
+	learner := clus.Members[3]
+	<-learner.ReadyNotify()
+
+	cli, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:     []string{clus.Members[0].GRPCURL()},
+		ReadEndpoints: []string{learner.GRPCURL()},
+		DialTimeout:   5 * time.Second,
+		DialOptions:   []grpc.DialOption{grpc.WithBlock()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	presp, err := cli.Put(context.TODO(), "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := cli.Get(context.TODO(), "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.MemberId != uint64(clus.Members[0].ID()) {
+		t.Fatalf("linearizable get served by %x, want %s", resp.Header.MemberId, clus.Members[0].ID())
+	}
+	// the learner may lag behind the put
+	for i := 0; ; i++ {
+		if resp, err = cli.Get(context.TODO(), "foo", clientv3.WithSerializable()); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Header.Revision >= presp.Header.Revision {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("learner did not catch up with revision %d", presp.Header.Revision)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if resp.Header.MemberId != uint64(learner.ID()) {
+		t.Fatalf("serializable get served by %x, want learner %s", resp.Header.MemberId, learner.ID())
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "bar" {
+		t.Fatalf("unexpected kvs %+v", resp.Kvs)
+	}
+
+	it, err := cli.GetStream(context.TODO(), "foo", clientv3.WithPrefix(), clientv3.WithSerializable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !it.Next() || string(it.KV().Value) != "bar" || it.Header().MemberId != uint64(learner.ID()) {
+		t.Fatalf("unexpected serializable range stream from learner (%v)", it.Err())
+	}
+
+	// clients of the learner get its serializable reads
+	lcli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{learner.GRPCURL()}, DialTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lcli.Close()
+	wantErr := rpctypes.ErrorDesc(rpctypes.ErrGRPCNotSupportedForLearner)
+	if _, err = lcli.Txn(context.TODO()).Then(clientv3.OpGet("foo", clientv3.WithSerializable())).Commit(); err != nil {
+		t.Fatalf("serializable read-only txn on learner failed (%v)", err)
+	}
+	if _, err = lcli.Txn(context.TODO()).Then(clientv3.OpGet("foo")).Commit(); rpctypes.ErrorDesc(err) != wantErr {
+		t.Fatalf("linearizable txn on learner expected %q, got %v", wantErr, err)
+	}
+	if _, err = lcli.GetStream(context.TODO(), "foo"); rpctypes.ErrorDesc(err) != wantErr {
+		t.Fatalf("linearizable range stream on learner expected %q, got %v", wantErr, err)
+	}
+	wctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	wresp := <-lcli.Watch(wctx, "foo", clientv3.WithRev(presp.Header.Revision))
+	if err = wresp.Err(); err != nil || len(wresp.Events) != 1 {
+		t.Fatalf("unexpected watch response from learner %+v (%v)", wresp, err)
+	}
+}
+
 // TestBalancerSupportLearner verifies that balancer's retry and failover mechanism supports cluster with learner member
 func TestBalancerSupportLearner(t *testing.T) {
 	integration2.BeforeTest(t)
@@ -651,6 +651,95 @@ func TestLeaseLeases(t *testing.T) {
 	}
 }
 
+func TestLeaseTop(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+
+	var ids []clientv3.LeaseID
+	for i := 0; i < 4; i++ {
+		resp, err := cli.Grant(context.Background(), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, resp.ID)
+		// attach i keys to the i-th lease
+		for j := 0; j < i; j++ {
+			if _, err = cli.Put(context.Background(), fmt.Sprintf("key-%d-%d", i, j), "v", clientv3.WithLease(resp.ID)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	resp, err := cli.Top(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wleases := []clientv3.LeaseTopStatus{
+		{ID: ids[3], GrantedTTL: 10, KeyCount: 3},
+		{ID: ids[2], GrantedTTL: 10, KeyCount: 2},
+	}
+	if !reflect.DeepEqual(resp.Leases, wleases) {
+		t.Fatalf("expected leases %+v, got %+v", wleases, resp.Leases)
+	}
+
+	if resp, err = cli.Top(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Leases) != 4 {
+		t.Fatalf("len(resp.Leases) expected 4, got %d", len(resp.Leases))
+	}
+}
+
+// TestLeaseKeepaliveOnWrite ensures the writes of the keys attached to a lease
+// granted with WithKeepaliveOnWrite renew it, whichever member serves them.
+func TestLeaseKeepaliveOnWrite(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	leader := clus.WaitLeader(t)
+	cli := clus.Client((leader + 1) % 3)
+
+	resp, err := cli.Grant(context.Background(), 2, clientv3.WithKeepaliveOnWrite())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := resp.ID
+	if resp, err = cli.Grant(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	expiring := resp.ID
+
+	// write the keys for longer than the TTL of the leases
+	for i := 0; i < 8; i++ {
+		for _, id := range []clientv3.LeaseID{kept, expiring} {
+			if _, err = cli.Put(context.Background(), fmt.Sprintf("key-%x", id), fmt.Sprint(i), clientv3.WithLease(id)); err != nil && (id == kept || err != rpctypes.ErrLeaseNotFound) {
+				t.Fatalf("#%d: failed to put the key of lease %x (%v)", i, id, err)
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	lresp, err := cli.TimeToLive(context.Background(), kept)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lresp.TTL <= 0 {
+		t.Errorf("lease %x expired, want it kept alive by the writes", kept)
+	}
+	if lresp, err = cli.TimeToLive(context.Background(), expiring); err != nil {
+		t.Fatal(err)
+	}
+	if lresp.TTL != -1 {
+		t.Errorf("lease %x TTL = %d, want it expired", expiring, lresp.TTL)
+	}
+}
+
 // TestLeaseRenewLostQuorum ensures keepalives work after losing quorum
 // for a while.
 func TestLeaseRenewLostQuorum(t *testing.T) {
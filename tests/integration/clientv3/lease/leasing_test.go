@@ -2022,6 +2022,156 @@ func TestLeasingSessionExpireCancel(t *testing.T) {
 	}
 }
 
+// TestLeasingNonOwnerPutOnce ensures a put by a client not owning the lease
+// of the key writes the key once.
+func TestLeasingNonOwnerPutOnce(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	lkv, closeLKV, err := leasing.NewKV(clus.Client(0), "foo/")
+	testutil.AssertNil(t, err)
+	defer closeLKV()
+
+	if _, err = lkv.Put(context.TODO(), "k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := clus.Client(0).Get(context.TODO(), "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := resp.Kvs[0].Version; v != 1 {
+		t.Fatalf("expected version 1, got %d", v)
+	}
+}
+
+// TestLeasingWriteThrough ensures a put with write-through acquires the lease
+// of the key and serves the written key from the cache.
+func TestLeasingWriteThrough(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	lkv, closeLKV, err := leasing.NewKVWithOptions(clus.Client(0), "foo/", leasing.WithWriteThrough())
+	testutil.AssertNil(t, err)
+	defer closeLKV()
+
+	presp, err := lkv.Put(context.TODO(), "abc", "def")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lresp, err := clus.Client(0).Get(context.TODO(), "foo/abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lresp.Kvs) != 1 {
+		t.Fatal("expected the put to acquire the leasing key")
+	}
+
+	clus.Members[0].Stop(t)
+	// served from the cache without the server
+	resp, err := lkv.Get(context.TODO(), "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "def" || resp.Kvs[0].ModRevision != presp.Header.Revision || resp.Kvs[0].Version != 1 {
+		t.Fatalf("unexpected cached response %+v", resp)
+	}
+}
+
+// TestLeasingWriteThroughContended ensures a put with write-through of a key
+// leased by another client revokes its lease.
+func TestLeasingWriteThroughContended(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	lkv1, closeLKV1, err := leasing.NewKV(clus.Client(0), "foo/")
+	testutil.AssertNil(t, err)
+	defer closeLKV1()
+
+	lkv2, closeLKV2, err := leasing.NewKVWithOptions(clus.Client(0), "foo/", leasing.WithWriteThrough())
+	testutil.AssertNil(t, err)
+	defer closeLKV2()
+
+	if _, err = lkv1.Get(context.TODO(), "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = lkv2.Put(context.TODO(), "abc", "def"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := lkv1.Get(context.TODO(), "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "def" {
+		t.Fatalf("expected value %q, got %+v", "def", resp)
+	}
+}
+
+// TestLeasingLeaseScope ensures only the keys in the lease scope are leased.
+func TestLeasingLeaseScope(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	lkv, closeLKV, err := leasing.NewKVWithOptions(clus.Client(0), "foo/", leasing.WithLeaseScope("config/"))
+	testutil.AssertNil(t, err)
+	defer closeLKV()
+
+	for _, key := range []string{"config/a", "other"} {
+		if _, err = lkv.Get(context.TODO(), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := clus.Client(0).Get(context.TODO(), "foo/", clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Key) != "foo/config/a" {
+		t.Fatalf("expected only the leasing key of config/a, got %+v", resp.Kvs)
+	}
+}
+
+// TestLeasingClusterRevoke ensures the owner stops serving a key from the
+// cache once its leasing key is deleted on the cluster side.
+func TestLeasingClusterRevoke(t *testing.T) {
+	integration2.BeforeTest(t)
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	lkv, closeLKV, err := leasing.NewKV(clus.Client(0), "foo/")
+	testutil.AssertNil(t, err)
+	defer closeLKV()
+
+	if _, err = clus.Client(0).Put(context.TODO(), "abc", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = lkv.Get(context.TODO(), "abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	// revoke the lease on the cluster side, then write around the leasing protocol
+	if _, err = clus.Client(0).Delete(context.TODO(), "foo/abc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = clus.Client(0).Put(context.TODO(), "abc", "v2"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		resp, err := lkv.Get(context.TODO(), "abc", clientv3.WithSerializable())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(resp.Kvs[0].Value) == "v2" {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("expected the key to be evicted from the cache")
+}
+
 func waitForLeasingExpire(kv clientv3.KV, lkey string) error {
 	for {
 		time.Sleep(1 * time.Second)
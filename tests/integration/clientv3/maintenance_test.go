@@ -70,6 +70,162 @@ func TestMaintenanceHashKV(t *testing.T) {
 	}
 }
 
+func TestMaintenanceHashKVRange(t *testing.T) {
+	if integration2.ThroughProxy {
+		t.Skipf("grpc-proxy namespaces the keys out of the hashed range")
+	}
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	ctx := context.Background()
+	cli := clus.RandClient()
+	for _, k := range []string{"a/1", "b/1", "a/2"} {
+		if _, err := cli.Put(ctx, k, "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	presp, err := cli.Put(ctx, "b/2", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev := presp.Header.Revision
+
+	var ha, hb uint32
+	for i := 0; i < 3; i++ {
+		cli := clus.Client(i)
+		// ensure writes are replicated
+		if _, err = cli.Get(ctx, "a/"); err != nil {
+			t.Fatal(err)
+		}
+		aresp, err := cli.HashKVRange(ctx, clus.Members[i].GRPCURL(), "a/", clientv3.WithPrefix(), clientv3.WithRev(rev))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bresp, err := cli.HashKVRange(ctx, clus.Members[i].GRPCURL(), "b/", clientv3.WithPrefix(), clientv3.WithRev(rev))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			ha, hb = aresp.Hash, bresp.Hash
+			if ha == hb {
+				t.Fatalf("hashes of distinct ranges are both %d", ha)
+			}
+			continue
+		}
+		if aresp.Hash != ha || bresp.Hash != hb {
+			t.Fatalf("#%d: hashes expected %d and %d, got %d and %d", i, ha, hb, aresp.Hash, bresp.Hash)
+		}
+	}
+
+	// writes out of a range do not change its hash
+	if _, err = cli.Put(ctx, "b/1", "baz"); err != nil {
+		t.Fatal(err)
+	}
+	aresp, err := cli.HashKVRange(ctx, cli.Endpoints()[0], "a/", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aresp.Hash != ha {
+		t.Fatalf("hash expected %d, got %d", ha, aresp.Hash)
+	}
+}
+
+func TestMaintenanceHashKVStream(t *testing.T) {
+	if integration2.ThroughProxy {
+		t.Skipf("grpc-proxy namespaces the keys out of the hashed range")
+	}
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	ctx := context.Background()
+	cli := clus.RandClient()
+	if _, err := cli.Put(ctx, "bar", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := cli.Put(ctx, fmt.Sprintf("foo%d", i), "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	presp, err := cli.Put(ctx, "zoo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev := presp.Header.Revision
+
+	hashChunks := func(cli *clientv3.Client, url string, opts ...clientv3.OpOption) (chunks []*clientv3.HashKVStreamResponse) {
+		err := cli.HashKVStream(ctx, url, "foo", 3, func(resp *clientv3.HashKVStreamResponse) error {
+			chunks = append(chunks, resp)
+			return nil
+		}, append([]clientv3.OpOption{clientv3.WithPrefix()}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return chunks
+	}
+
+	var wchunks []*clientv3.HashKVStreamResponse
+	for i := 0; i < 3; i++ {
+		cli := clus.Client(i)
+		// ensure writes are replicated
+		if _, err := cli.Get(ctx, "foo"); err != nil {
+			t.Fatal(err)
+		}
+		chunks := hashChunks(cli, clus.Members[i].GRPCURL(), clientv3.WithRev(rev))
+		if i == 0 {
+			wchunks = chunks
+			continue
+		}
+		if len(chunks) != len(wchunks) {
+			t.Fatalf("#%d: got %d chunks, want %d", i, len(chunks), len(wchunks))
+		}
+		for j := range chunks {
+			if chunks[j].Hash != wchunks[j].Hash || chunks[j].RangeHash != wchunks[j].RangeHash {
+				t.Fatalf("#%d: chunk %d hashes %d/%d, want %d/%d", i, j, chunks[j].Hash, chunks[j].RangeHash, wchunks[j].Hash, wchunks[j].RangeHash)
+			}
+		}
+	}
+
+	// the chunks cover the range in order without overlapping
+	wcounts := []int64{3, 3, 3, 1}
+	if len(wchunks) != len(wcounts) {
+		t.Fatalf("got %d chunks, want %d", len(wchunks), len(wcounts))
+	}
+	key := "foo"
+	for i, c := range wchunks {
+		if c.Count != wcounts[i] || string(c.Key) != key || c.Header.Revision != rev {
+			t.Errorf("chunk %d: got key %q, count %d and revision %d, want %q, %d and %d", i, c.Key, c.Count, c.Header.Revision, key, wcounts[i], rev)
+		}
+		key = string(c.RangeEnd)
+	}
+	if key != "fop" {
+		t.Errorf("last range end %q, want %q", key, "fop")
+	}
+
+	// a change is localized to the chunk holding the changed key
+	if _, err = cli.Put(ctx, "foo4", "baz"); err != nil {
+		t.Fatal(err)
+	}
+	chunks := hashChunks(clus.Client(0), clus.Members[0].GRPCURL())
+	for i := range chunks {
+		if changed := chunks[i].Hash != wchunks[i].Hash; changed != (i == 1) {
+			t.Errorf("chunk %d: changed = %v, want %v", i, changed, i == 1)
+		}
+	}
+
+	if _, err = cli.Compact(ctx, rev+1); err != nil {
+		t.Fatal(err)
+	}
+	err = cli.HashKVStream(ctx, clus.Members[0].GRPCURL(), "foo", 3, func(*clientv3.HashKVStreamResponse) error { return nil }, clientv3.WithPrefix(), clientv3.WithRev(rev))
+	if err != rpctypes.ErrCompacted {
+		t.Fatalf("error = %v, want %v", err, rpctypes.ErrCompacted)
+	}
+}
+
 // TODO: Change this to fuzz test
 func TestCompactionHash(t *testing.T) {
 	integration2.BeforeTest(t)
@@ -117,6 +273,235 @@ func (tc hashTestCase) Compact(ctx context.Context, rev int64) error {
 	return err
 }
 
+func TestMaintenancePurge(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.Background()
+	for _, k := range []string{"foo", "foo", "foo1"} {
+		if _, err := cli.Put(ctx, k, "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cli.Delete(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cli.Purge(ctx, "foo", clientv3.WithPrefix(), clientv3.WithRev(100)); err != rpctypes.ErrFutureRev {
+		t.Fatalf("error = %v, want %v", err, rpctypes.ErrFutureRev)
+	}
+	presp, err := cli.Purge(ctx, "foo", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presp.Purged != 3 {
+		t.Errorf("purged = %d, want 3", presp.Purged)
+	}
+
+	if _, err = cli.Get(ctx, "foo", clientv3.WithRev(2)); err != rpctypes.ErrCompacted {
+		t.Errorf("error = %v, want %v", err, rpctypes.ErrCompacted)
+	}
+	wresp := <-cli.Watch(ctx, "foo", clientv3.WithRev(2))
+	if wresp.CompactRevision != presp.Header.Revision {
+		t.Errorf("watch compact revision = %d, want %d", wresp.CompactRevision, presp.Header.Revision)
+	}
+	gresp, err := cli.Get(ctx, "foo1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gresp.Kvs) != 1 {
+		t.Errorf("len(kvs) = %d, want 1", len(gresp.Kvs))
+	}
+}
+
+// TestMaintenanceDefragmentOnline ensures the writes served during an online
+// defragmentation are kept.
+func TestMaintenanceDefragmentOnline(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		if _, err := cli.Put(ctx, fmt.Sprintf("foo%d", i), "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	donec := make(chan error, 1)
+	go func() {
+		for i := 100; i < 200; i++ {
+			if _, err := cli.Put(ctx, fmt.Sprintf("foo%d", i), "bar"); err != nil {
+				donec <- err
+				return
+			}
+		}
+		donec <- nil
+	}()
+	if _, err := cli.DefragmentOnline(ctx, clus.Members[0].GRPCURL()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-donec; err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := cli.Get(ctx, "foo", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 200 {
+		t.Errorf("count = %d, want 200", resp.Count)
+	}
+}
+
+func TestMaintenanceSnapshotSettings(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1, SnapshotCount: 1000, SnapshotCatchUpEntries: 10})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ep := clus.Members[0].GRPCURL()
+	ctx := context.Background()
+
+	if _, err := cli.SnapshotSettings(ctx, ep, 100, 200); err != rpctypes.ErrInvalidSnapshotSettings {
+		t.Fatalf("error = %v, want %v", err, rpctypes.ErrInvalidSnapshotSettings)
+	}
+	resp, err := cli.SnapshotSettings(ctx, ep, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.SnapshotCount != 100 {
+		t.Errorf("snapshot count = %d, want 100", resp.SnapshotCount)
+	}
+	if resp, err = cli.SnapshotSettings(ctx, ep, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if resp.SnapshotCount != 100 {
+		t.Errorf("snapshot count = %d, want unchanged 100", resp.SnapshotCount)
+	}
+}
+
+// TestMaintenanceRateLimits ensures the requests to a key prefix beyond its
+// rate limit are rejected, leaving the other prefixes alone, until the limit
+// is removed.
+func TestMaintenanceRateLimits(t *testing.T) {
+	if integration2.ThroughProxy {
+		t.Skipf("grpc-proxy namespaces the keys out of the limited prefix")
+	}
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ep := clus.Members[0].GRPCURL()
+	ctx := context.Background()
+
+	if _, err := cli.RateLimits(ctx, ep, clientv3.RateLimit{Prefix: []byte("/a/"), Rate: -1}); err != rpctypes.ErrInvalidRateLimit {
+		t.Fatalf("error = %v, want %v", err, rpctypes.ErrInvalidRateLimit)
+	}
+	// a rate low enough not to refill during the test
+	limit := clientv3.RateLimit{Prefix: []byte("/a/"), Rate: 0.001, Burst: 2}
+	resp, err := cli.RateLimits(ctx, ep, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Limits) != 1 || string(resp.Limits[0].Prefix) != "/a/" || resp.Limits[0].Burst != 2 {
+		t.Fatalf("limits = %v, want [%v]", resp.Limits, limit)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err = cli.Put(ctx, "/a/foo", "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = cli.Get(ctx, "/a/foo"); err != rpctypes.ErrRateLimited {
+		t.Fatalf("error = %v, want %v", err, rpctypes.ErrRateLimited)
+	}
+	// the requests of the streams are limited alike
+	if _, err = cli.GetStream(ctx, "/a/", clientv3.WithPrefix()); err != rpctypes.ErrRateLimited {
+		t.Fatalf("stream error = %v, want %v", err, rpctypes.ErrRateLimited)
+	}
+	if _, err = cli.Txn(ctx).Then(clientv3.OpGet("/a/foo")).(clientv3.CommitStreamer).CommitStream(); err != rpctypes.ErrRateLimited {
+		t.Fatalf("txn stream error = %v, want %v", err, rpctypes.ErrRateLimited)
+	}
+	if _, err = cli.Put(ctx, "/b/foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp, err = cli.RateLimits(ctx, ep, clientv3.RateLimit{Prefix: []byte("/a/")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Limits) != 0 {
+		t.Fatalf("limits = %v, want none", resp.Limits)
+	}
+	if _, err = cli.Get(ctx, "/a/foo"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMaintenancePinRevision ensures pins are validated against the
+// compacted and current revisions, expire after their TTL and are kept
+// across restarts.
+func TestMaintenancePinRevision(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := cli.Put(ctx, "foo", "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cli.Compact(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cli.PinRevision(ctx, 0, 2, 0); err != rpctypes.ErrCompacted {
+		t.Fatalf("error = %v, want %v", err, rpctypes.ErrCompacted)
+	}
+	if _, err := cli.PinRevision(ctx, 0, 100, 0); err != rpctypes.ErrFutureRev {
+		t.Fatalf("error = %v, want %v", err, rpctypes.ErrFutureRev)
+	}
+	presp, err := cli.PinRevision(ctx, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presp.ID == 0 || presp.Revision != 4 {
+		t.Fatalf("pin = %+v, want a pin of revision 4", presp)
+	}
+	tresp, err := cli.PinRevision(ctx, 0, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the leader unpins the pin once its TTL elapsed
+	time.Sleep(3 * time.Second)
+	if _, err = cli.UnpinRevision(ctx, tresp.ID); err != rpctypes.ErrPinNotFound {
+		t.Fatalf("error = %v, want %v", err, rpctypes.ErrPinNotFound)
+	}
+
+	clus.Members[0].Stop(t)
+	clus.Members[0].Restart(t)
+	clus.WaitLeader(t)
+
+	if _, err = cli.UnpinRevision(ctx, presp.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.UnpinRevision(ctx, presp.ID); err != rpctypes.ErrPinNotFound {
+		t.Fatalf("error = %v, want %v", err, rpctypes.ErrPinNotFound)
+	}
+}
+
 func TestMaintenanceMoveLeader(t *testing.T) {
 	integration2.BeforeTest(t)
 
@@ -17,11 +17,13 @@ package clientv3test
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -31,6 +33,7 @@ import (
 	clientv3 "go.etcd.io/etcd/client/v3"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
 func TestV3ClientMetrics(t *testing.T) {
@@ -115,6 +118,116 @@ func TestV3ClientMetrics(t *testing.T) {
 	}
 }
 
+// recordingMetricsHooks records the telemetry reported to clientv3.MetricsHooks.
+type recordingMetricsHooks struct {
+	mu       sync.Mutex
+	started  map[string]int
+	finished map[string][]string
+	events   map[string]int
+	missed   map[clientv3.LeaseID]int
+}
+
+func newRecordingMetricsHooks() *recordingMetricsHooks {
+	return &recordingMetricsHooks{
+		started:  make(map[string]int),
+		finished: make(map[string][]string),
+		events:   make(map[string]int),
+		missed:   make(map[clientv3.LeaseID]int),
+	}
+}
+
+func (h *recordingMetricsHooks) RequestStarted(method string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.started[method]++
+}
+
+func (h *recordingMetricsHooks) RequestFinished(method, endpoint string, code codes.Code, latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.finished[method] = append(h.finished[method], fmt.Sprintf("%s %s", endpoint, code))
+}
+
+func (h *recordingMetricsHooks) WatchEventsReceived(key string, events int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events[key] += events
+}
+
+func (h *recordingMetricsHooks) LeaseKeepAliveMissed(id clientv3.LeaseID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.missed[id]++
+}
+
+func (h *recordingMetricsHooks) missedKeepAlives(id clientv3.LeaseID) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.missed[id]
+}
+
+func TestV3ClientMetricsHooks(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	hooks := newRecordingMetricsHooks()
+	cli, err := integration2.NewClient(t, clientv3.Config{
+		Endpoints:    []string{clus.Members[0].GRPCURL()},
+		MetricsHooks: hooks,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	wc := cli.Watch(context.Background(), "foo")
+	if _, err = cli.Put(context.Background(), "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-wc:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the watch response")
+	}
+
+	ep := strings.TrimPrefix(clus.Members[0].GRPCURL(), "unix://")
+	hooks.mu.Lock()
+	put := "/etcdserverpb.KV/Put"
+	if hooks.started[put] != 1 || len(hooks.finished[put]) != 1 || hooks.finished[put][0] != ep+" OK" {
+		t.Errorf("Put started %d times and finished %q, want once at %s with OK", hooks.started[put], hooks.finished[put], ep)
+	}
+	if hooks.started["/etcdserverpb.Watch/Watch"] != 1 {
+		t.Errorf("Watch started %d times, want once", hooks.started["/etcdserverpb.Watch/Watch"])
+	}
+	if hooks.events["foo"] != 1 {
+		t.Errorf("got %d events for the watch of foo, want 1", hooks.events["foo"])
+	}
+	hooks.mu.Unlock()
+
+	lresp, err := cli.Grant(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kc, err := cli.KeepAlive(context.Background(), lresp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-kc
+	if n := hooks.missedKeepAlives(lresp.ID); n != 0 {
+		t.Fatalf("%d keepalives missed with the member up", n)
+	}
+	clus.Members[0].Stop(t)
+	deadline := time.Now().Add(5 * time.Second)
+	for hooks.missedKeepAlives(lresp.ID) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no keepalive missed with the member down")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
 func sumCountersForMetricAndLabels(t *testing.T, url string, metricName string, matchingLabelValues ...string) int {
 	count := 0
 	for _, line := range getHTTPBodyAsLines(t, url) {
@@ -54,6 +54,109 @@ func TestNamespacePutGet(t *testing.T) {
 	}
 }
 
+func TestNamespaceGetPages(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	c := clus.Client(0)
+	nsKV := namespace.NewKV(c.KV, "foo/")
+
+	wkeys := []string{"a", "b", "c"}
+	for _, k := range wkeys {
+		if _, err := nsKV.Put(context.TODO(), k, "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var keys []string
+	_, err := nsKV.Get(context.TODO(), "", clientv3.WithFromKey(), clientv3.WithAutoPaging(2),
+		clientv3.WithPageHandler(func(page *clientv3.GetResponse) error {
+			for _, kv := range page.Kvs {
+				keys = append(keys, string(kv.Key))
+			}
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(wkeys, keys) {
+		t.Errorf("expected keys %v, got %v", wkeys, keys)
+	}
+}
+
+func TestNamespaceGetStream(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	c := clus.Client(0)
+	nsKV := namespace.NewKV(c.KV, "foo/")
+
+	wkeys := []string{"a", "b", "c"}
+	for _, k := range wkeys {
+		if _, err := nsKV.Put(context.TODO(), k, "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.Put(context.TODO(), "fop", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	it, err := nsKV.GetStream(context.TODO(), "", clientv3.WithFromKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	var keys []string
+	for it.Next() {
+		keys = append(keys, string(it.KV().Key))
+	}
+	if err = it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(wkeys, keys) {
+		t.Errorf("expected keys %v, got %v", wkeys, keys)
+	}
+}
+
+func TestNamespaceTxnCompareIntervals(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	c := clus.Client(0)
+	nsKV := namespace.NewKV(c.KV, "foo/")
+
+	resp, err := nsKV.Put(context.TODO(), "a", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the keys outside of the namespace are not compared
+	if _, err = c.Put(context.TODO(), "b/1", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	cmp := clientv3.Compare(clientv3.ModRevision("a").WithPrefixInterval("b/"), "<", resp.Header.Revision+1)
+	tresp, err := nsKV.Txn(context.TODO()).If(cmp).Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tresp.Succeeded {
+		t.Fatal("expected intervals compare to be true, got false")
+	}
+
+	if _, err = nsKV.Put(context.TODO(), "b/1", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if tresp, err = nsKV.Txn(context.TODO()).If(cmp).Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if tresp.Succeeded {
+		t.Fatal("expected intervals compare to be false, got true")
+	}
+}
+
 func TestNamespaceWatch(t *testing.T) {
 	integration2.BeforeTest(t)
 
@@ -0,0 +1,138 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestStandbyPromote ensures a standby cluster mirrors its primary cluster,
+// rejects client writes until it is promoted, and that a fenced primary
+// cluster rejects them too.
+func TestStandbyPromote(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	primary := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer primary.Terminate(t)
+	standby := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3, UseTCP: true, StandbyOf: []string{primary.Members[0].GRPCURL()}})
+	defer standby.Terminate(t)
+
+	pcli, scli := primary.Client(0), standby.Client(0)
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		if _, err := pcli.Put(ctx, fmt.Sprintf("foo%d", i), "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := scli.Put(ctx, "stale", "baz"); err != nil {
+		t.Fatal(err)
+	}
+
+	setStandby(t, scli)
+	if _, err := scli.Put(ctx, "foo", "baz"); err != rpctypes.ErrStandby {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrStandby, err)
+	}
+	waitMirrored(t, standby, pcli)
+
+	if _, err := pcli.Delete(ctx, "foo0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pcli.Put(ctx, "foo1", "baz"); err != nil {
+		t.Fatal(err)
+	}
+
+	// fence the primary cluster off
+	setStandby(t, pcli)
+	if _, err := pcli.Put(ctx, "foo", "baz"); err != rpctypes.ErrStandby {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrStandby, err)
+	}
+	waitMirrored(t, standby, pcli)
+
+	presp, err := pcli.Get(ctx, "", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sresp, err := scli.Get(ctx, "", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presp.Kvs) != len(sresp.Kvs) {
+		t.Fatalf("expected %d keys on standby, got %d", len(presp.Kvs), len(sresp.Kvs))
+	}
+	for i := range presp.Kvs {
+		if string(presp.Kvs[i].Key) != string(sresp.Kvs[i].Key) || string(presp.Kvs[i].Value) != string(sresp.Kvs[i].Value) {
+			t.Fatalf("#%d: expected %q=%q on standby, got %q=%q", i, presp.Kvs[i].Key, presp.Kvs[i].Value, sresp.Kvs[i].Key, sresp.Kvs[i].Value)
+		}
+	}
+
+	// disarming all alarms keeps the cluster a standby
+	if _, err = scli.AlarmDisarm(ctx, &clientv3.AlarmMember{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = scli.Put(ctx, "foo", "baz"); err != rpctypes.ErrStandby {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrStandby, err)
+	}
+
+	// promote the standby cluster
+	aresp, err := scli.AlarmList(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range aresp.Alarms {
+		if _, err = scli.AlarmDisarm(ctx, (*clientv3.AlarmMember)(a)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = scli.Put(ctx, "foo", "baz"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setStandby(t *testing.T, cli *clientv3.Client) {
+	resp, err := cli.Status(context.Background(), cli.Endpoints()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pb.AlarmRequest{Action: pb.AlarmRequest_ACTIVATE, MemberID: resp.Header.MemberId, Alarm: pb.AlarmType_STANDBY}
+	if _, err = clientv3.RetryMaintenanceClient(cli, cli.ActiveConnection()).Alarm(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitMirrored waits for the leader of the standby cluster to mirror the
+// latest revision of the primary cluster.
+func waitMirrored(t *testing.T, standby *integration2.Cluster, pcli *clientv3.Client) {
+	resp, err := pcli.Status(context.Background(), pcli.Endpoints()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	lead := standby.Members[standby.WaitLeader(t)]
+	for i := 0; i < 100; i++ {
+		if lead.Server.StandbyRevision() >= resp.Header.Revision {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("standby did not mirror primary revision %d, mirrored %d", resp.Header.Revision, lead.Server.StandbyRevision())
+}
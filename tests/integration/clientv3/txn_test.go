@@ -17,13 +17,18 @@ package clientv3test
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/server/v3/embed"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestTxnError(t *testing.T) {
@@ -189,6 +194,55 @@ func TestTxnCompareRange(t *testing.T) {
 	}
 }
 
+func TestTxnCompareIntervals(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	kv := clus.Client(0)
+	ctx := context.TODO()
+	for _, k := range []string{"a/1", "b/1", "c/1", "d/1"} {
+		if _, err := kv.Put(ctx, k, "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := kv.Put(ctx, "e", "v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unchanged := clientv3.Compare(clientv3.ModRevision("a/").WithPrefix().WithPrefixInterval("b/").WithInterval("c/", "d/"), "<", resp.Header.Revision)
+
+	tresp, err := kv.Txn(ctx).If(unchanged).Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tresp.Succeeded {
+		t.Fatal("expected intervals compare to be true, got false")
+	}
+
+	// a key written outside of the intervals does not fail the compare
+	if _, err = kv.Put(ctx, "d/1", "w"); err != nil {
+		t.Fatal(err)
+	}
+	if tresp, err = kv.Txn(ctx).If(unchanged).Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if !tresp.Succeeded {
+		t.Fatal("expected intervals compare to be true, got false")
+	}
+
+	if _, err = kv.Put(ctx, "c/2", "w"); err != nil {
+		t.Fatal(err)
+	}
+	if tresp, err = kv.Txn(ctx).If(unchanged).Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if tresp.Succeeded {
+		t.Fatal("expected intervals compare to be false, got true")
+	}
+}
+
 func TestTxnNested(t *testing.T) {
 	integration2.BeforeTest(t)
 
@@ -226,3 +280,112 @@ func TestTxnNested(t *testing.T) {
 		t.Errorf("unexpected Get response %+v", resp)
 	}
 }
+
+func TestTxnCommitStream(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{
+		Size:                     1,
+		MaxRequestBytes:          150 * 1024,
+		ClientMaxCallRecvMsgSize: 700 * 1024,
+	})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.TODO()
+
+	val := strings.Repeat("a", 100*1024)
+	for i := 0; i < 10; i++ {
+		if _, err := kv.Put(ctx, fmt.Sprintf("foo%d", i), val); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ops := []clientv3.Op{clientv3.OpPut("bar", "baz"), clientv3.OpGet("foo", clientv3.WithPrefix())}
+	if _, err := kv.Txn(ctx).Then(ops...).Commit(); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected %v, got %v", codes.ResourceExhausted, err)
+	}
+
+	resp, err := kv.Txn(ctx).Then(ops...).(clientv3.CommitStreamer).CommitStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Succeeded || len(resp.Responses) != 2 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if kvs := resp.Responses[1].GetResponseRange().Kvs; len(kvs) != 10 {
+		t.Fatalf("len(kvs) = %d, want 10", len(kvs))
+	}
+}
+
+func TestTxnReadYourWrites(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clus.Client(0)
+	ctx := context.TODO()
+
+	for _, k := range []string{"idx/a", "idx/b"} {
+		if _, err := kv.Put(ctx, k, "old"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ops := []clientv3.Op{
+		clientv3.OpDelete("idx/", clientv3.WithPrefix()),
+		clientv3.OpPut("idx/b", "new"),
+		clientv3.OpPut("idx/c", "new"),
+		clientv3.OpPut("idx/c", "newer"),
+		clientv3.OpGet("idx/", clientv3.WithPrefix()),
+	}
+	if _, err := kv.Txn(ctx).Then(ops...).Commit(); err != rpctypes.ErrDuplicateKey {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrDuplicateKey, err)
+	}
+
+	opResp, err := kv.Do(ctx, clientv3.OpTxn(nil, ops, nil, clientv3.WithReadYourWrites()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tresp := opResp.Txn()
+	if n := tresp.Responses[0].GetResponseDeleteRange().Deleted; n != 2 {
+		t.Errorf("deleted %d keys, want 2", n)
+	}
+	// the get observes the writes before it in the txn
+	wkvs := []string{"idx/b=new", "idx/c=newer"}
+	checkKVs := func(kvs []*mvccpb.KeyValue) {
+		t.Helper()
+		var got []string
+		for _, kv := range kvs {
+			got = append(got, string(kv.Key)+"="+string(kv.Value))
+		}
+		if !reflect.DeepEqual(got, wkvs) {
+			t.Errorf("kvs = %v, want %v", got, wkvs)
+		}
+	}
+	checkKVs(tresp.Responses[4].GetResponseRange().Kvs)
+
+	resp, err := kv.Get(ctx, "idx/", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkKVs(resp.Kvs)
+	for _, kv := range resp.Kvs {
+		if kv.CreateRevision != tresp.Header.Revision {
+			t.Errorf("%s created at revision %d, want %d", kv.Key, kv.CreateRevision, tresp.Header.Revision)
+		}
+	}
+	if v := resp.Kvs[1].Version; v != 2 {
+		t.Errorf("idx/c version = %d, want 2", v)
+	}
+
+	// a put keeping the value of a key deleted in the txn is rejected
+	_, err = kv.Do(ctx, clientv3.OpTxn(nil, []clientv3.Op{
+		clientv3.OpDelete("idx/", clientv3.WithPrefix()),
+		clientv3.OpPut("idx/b", "", clientv3.WithIgnoreValue()),
+	}, nil, clientv3.WithReadYourWrites()))
+	if err != rpctypes.ErrDuplicateKey {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrDuplicateKey, err)
+	}
+}
@@ -16,6 +16,7 @@ package clientv3test
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -95,6 +96,77 @@ func TestUserErrorAuth(t *testing.T) {
 	}
 }
 
+func TestCheckPermissions(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	authapi := clus.RandClient()
+	if _, err := authapi.UserAdd(context.TODO(), "alice", "123"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authapi.RoleAdd(context.TODO(), "reader"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authapi.RoleGrantPermission(context.TODO(), "reader", "a", "c", clientv3.PermissionType(clientv3.PermRead)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authapi.UserGrantRole(context.TODO(), "alice", "reader"); err != nil {
+		t.Fatal(err)
+	}
+	authSetupRoot(t, authapi.Auth)
+
+	checks := []clientv3.PermissionCheck{
+		{User: "alice", Key: "a", PermType: clientv3.PermissionType(clientv3.PermRead)},
+		{User: "alice", Key: "a", RangeEnd: "b", PermType: clientv3.PermissionType(clientv3.PermRead)},
+		{User: "alice", Key: "a", RangeEnd: "d", PermType: clientv3.PermissionType(clientv3.PermRead)},
+		{User: "alice", Key: "a", PermType: clientv3.PermissionType(clientv3.PermWrite)},
+		{User: "alice", Key: "a", PermType: clientv3.PermissionType(clientv3.PermReadWrite)},
+		{User: "root", Key: "z", PermType: clientv3.PermissionType(clientv3.PermReadWrite)},
+		{User: "bob", Key: "a", PermType: clientv3.PermissionType(clientv3.PermRead)},
+	}
+	wallowed := []bool{true, true, false, false, false, true, false}
+
+	cfg := clientv3.Config{
+		Endpoints:   authapi.Endpoints(),
+		DialTimeout: 5 * time.Second,
+		DialOptions: []grpc.DialOption{grpc.WithBlock()},
+	}
+	cfg.Username, cfg.Password = "root", "123"
+	root, err := integration2.NewClient(t, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+
+	resp, err := root.CheckPermissions(context.TODO(), checks...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp.Allowed, wallowed) {
+		t.Fatalf("expected %v, got %v", wallowed, resp.Allowed)
+	}
+
+	// a user without the root role may only check its own permissions
+	cfg.Username, cfg.Password = "alice", "123"
+	alice, err := integration2.NewClient(t, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alice.Close()
+
+	if resp, err = alice.CheckPermissions(context.TODO(), checks[:5]...); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp.Allowed, wallowed[:5]) {
+		t.Fatalf("expected %v, got %v", wallowed[:5], resp.Allowed)
+	}
+	if _, err = alice.CheckPermissions(context.TODO(), checks...); err != rpctypes.ErrPermissionDenied {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrPermissionDenied, err)
+	}
+}
+
 func authSetupRoot(t *testing.T, auth clientv3.Auth) {
 	if _, err := auth.UserAdd(context.TODO(), "root", "123"); err != nil {
 		t.Fatal(err)
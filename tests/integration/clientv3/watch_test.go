@@ -16,6 +16,7 @@ package clientv3test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"reflect"
@@ -605,6 +606,39 @@ func TestConfigurableWatchProgressNotifyInterval(t *testing.T) {
 	}
 }
 
+func TestWatchProgressNotifyInterval(t *testing.T) {
+	if integration2.ThroughProxy {
+		t.Skipf("grpc-proxy does not support the progress notify interval of watchers yet")
+	}
+	integration2.BeforeTest(t)
+
+	oldpi := v3rpc.GetProgressReportInterval()
+	v3rpc.SetProgressReportInterval(time.Minute)
+	defer func() { v3rpc.SetProgressReportInterval(oldpi) }()
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	// both watchers share the stream of the client, only one has its own interval
+	wc := clus.Client(0)
+	rch := wc.Watch(context.Background(), "foo", clientv3.WithProgressNotifyInterval(200*time.Millisecond))
+	defaultRch := wc.Watch(context.Background(), "foo", clientv3.WithProgressNotify())
+
+	timeout := 2 * time.Second
+	for i := 0; i < 3; i++ {
+		select {
+		case resp := <-rch:
+			if !resp.IsProgressNotify() {
+				t.Fatalf("#%d: expected resp.IsProgressNotify() == true", i)
+			}
+		case resp := <-defaultRch:
+			t.Fatalf("#%d: unexpected watch response %+v of the watcher without interval", i, resp)
+		case <-time.After(timeout):
+			t.Fatalf("#%d: timed out waiting for watch progress notify response in %v", i, timeout)
+		}
+	}
+}
+
 func TestWatchRequestProgress(t *testing.T) {
 	if integration2.ThroughProxy {
 		t.Skipf("grpc-proxy does not support WatchProgress yet")
@@ -923,6 +957,61 @@ func TestWatchWithFilter(t *testing.T) {
 	}
 }
 
+func TestWatchWithValueFilter(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	cluster := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer cluster.Terminate(t)
+
+	client := cluster.RandClient()
+	ctx := context.Background()
+
+	wcPrefix := client.Watch(ctx, "a", clientv3.WithValueFilter(clientv3.ValuePrefix("ab")))
+	wcRegex := client.Watch(ctx, "a", clientv3.WithValueFilter(clientv3.ValueRegex("^[0-9]+$")))
+	wcJSON := client.Watch(ctx, "a",
+		clientv3.WithValueFilter(clientv3.ValueJSONField("spec.replicas", "3")),
+		clientv3.WithValueFilter(clientv3.ValuePrefix("{")))
+
+	for _, v := range []string{"xyz", "abc", "123", `{"spec": {"replicas": 2}}`, `{"spec": {"replicas": 3}}`} {
+		if _, err := client.Put(ctx, "a", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		wc     clientv3.WatchChan
+		wvalue string
+	}{
+		{wcPrefix, "abc"},
+		{wcRegex, "123"},
+		{wcJSON, `{"spec": {"replicas": 3}}`},
+	} {
+		// delete events are not filtered by value
+		var evs []*clientv3.Event
+		for len(evs) < 2 {
+			resp := <-tt.wc
+			evs = append(evs, resp.Events...)
+		}
+		if len(evs) != 2 || evs[0].Type != clientv3.EventTypePut || string(evs[0].Kv.Value) != tt.wvalue || evs[1].Type != clientv3.EventTypeDelete {
+			t.Fatalf("expected put of %q and delete events, got %+v", tt.wvalue, evs)
+		}
+	}
+
+	// invalid filters are rejected
+	wc := client.Watch(ctx, "a", clientv3.WithValueFilter(clientv3.ValueRegex("(")))
+	select {
+	case resp, ok := <-wc:
+		if ok && resp.Err() == nil {
+			t.Fatalf("expected the watch of an invalid filter to fail, got %+v", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the watch of an invalid filter to fail")
+	}
+}
+
 // TestWatchWithCreatedNotification checks that WithCreatedNotify returns a
 // Created watch response.
 func TestWatchWithCreatedNotification(t *testing.T) {
@@ -1214,3 +1303,56 @@ func testWatchClose(t *testing.T, wctx *watchctx) {
 		t.Fatalf("read wch got %v; expected closed channel", wresp)
 	}
 }
+
+// TestWatchMovesOffDrainingMember ensures a watch keeps receiving events,
+// from another member, once the member serving it drains its client streams.
+func TestWatchMovesOffDrainingMember(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 2})
+	defer clus.Terminate(t)
+
+	// open the watch on the first member only
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{clus.Members[0].GRPCURL()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	wch := cli.Watch(ctx, "foo", clientv3.WithCreatedNotify())
+	if wresp := <-wch; !wresp.Created {
+		t.Fatalf("expected a created notification, got %+v", wresp)
+	}
+	cli.SetEndpoints(clus.Members[0].GRPCURL(), clus.Members[1].GRPCURL())
+
+	clus.Members[0].Server.Drain()
+
+	if _, err = clus.Client(1).Put(ctx, "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case wresp, ok := <-wch:
+		if !ok {
+			t.Fatal("watch channel closed")
+		}
+		if err = wresp.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if len(wresp.Events) != 1 || string(wresp.Events[0].Kv.Value) != "bar" {
+			t.Fatalf("events = %+v, want the put of foo", wresp.Events)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the watch event")
+	}
+
+	// new streams are rejected by the draining member
+	ws, err := integration2.ToGRPC(clus.Client(0)).Watch.Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ws.Recv(); !errors.Is(rpctypes.Error(err), rpctypes.ErrStopped) {
+		t.Fatalf("err = %v, want %v", err, rpctypes.ErrStopped)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -200,6 +201,92 @@ func TestAddMemberAfterClusterFullRotation(t *testing.T) {
 	clusterMustProgress(t, c.Members)
 }
 
+// TestClusterEpochMemberReplacement ensures the cluster epoch is bumped when
+// a member is removed or moved to new peer URLs.
+func TestClusterEpochMemberReplacement(t *testing.T) {
+	integration.BeforeTest(t)
+	c := integration.NewCluster(t, &integration.ClusterConfig{Size: 3, DisableStrictReconfigCheck: true})
+	defer c.Terminate(t)
+
+	if err := c.RemoveMember(t, c.Members[0].Client, uint64(c.Members[2].Server.MemberId())); err != nil {
+		t.Fatal(err)
+	}
+	waitClusterEpoch(t, c.Members, 2)
+
+	m := c.Members[1]
+	ctx, cancel := context.WithTimeout(context.Background(), integration.RequestTimeout)
+	_, err := c.Members[0].Client.MemberUpdate(ctx, uint64(m.Server.MemberId()), m.PeerURLs.StringSlice())
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitClusterEpoch(t, c.Members, 3)
+}
+
+func waitClusterEpoch(t *testing.T, membs []*integration.Member, epoch uint64) {
+	deadline := time.Now().Add(10 * time.Second)
+	for _, m := range membs {
+		for m.Server.ClusterEpoch() != epoch {
+			if time.Now().After(deadline) {
+				t.Fatalf("member %s cluster epoch = %d, want %d", m.Server.MemberId(), m.Server.ClusterEpoch(), epoch)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), integration.RequestTimeout)
+		resp, err := m.Client.Get(ctx, "foo", clientv3.WithSerializable())
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Header.ClusterEpoch != epoch {
+			t.Fatalf("member %s response cluster epoch = %d, want %d", m.Server.MemberId(), resp.Header.ClusterEpoch, epoch)
+		}
+	}
+}
+
+// TestLearnerWithPacedHeartbeatsAndBatchedMsgApp ensures a learner keeps up
+// with the cluster while its heartbeats are paced and the MsgApp batched.
+func TestLearnerWithPacedHeartbeatsAndBatchedMsgApp(t *testing.T) {
+	integration.BeforeTest(t)
+	c := integration.NewCluster(t, &integration.ClusterConfig{
+		Size:                     3,
+		PeerMsgAppBatchBytes:     64 * 1024,
+		LearnerHeartbeatInterval: time.Second,
+
+		DisableStrictReconfigCheck: true,
+	})
+	defer c.Terminate(t)
+	c.AddAndLaunchLearnerMember(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				ctx, cancel := context.WithTimeout(context.Background(), integration.RequestTimeout)
+				_, err := c.Client(0).Put(ctx, fmt.Sprintf("foo%d-%d", i, j), "bar")
+				cancel()
+				if err != nil {
+					t.Errorf("put error: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	rev := c.Members[0].Server.KV().Rev()
+	learner := c.Members[3]
+	deadline := time.Now().Add(10 * time.Second)
+	for learner.Server.KV().Rev() < rev && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if lrev := learner.Server.KV().Rev(); lrev < rev {
+		t.Errorf("learner revision = %d, want %d", lrev, rev)
+	}
+}
+
 // Ensure we can remove a member then add a new one back immediately.
 func TestIssue2681(t *testing.T) {
 	integration.BeforeTest(t)
@@ -0,0 +1,138 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !cluster_proxy
+// +build !cluster_proxy
+
+package embed_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/httpclient/v3"
+	"go.etcd.io/etcd/client/pkg/v3/testutil"
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+// TestEmbedEtcdHTTPClient ensures the HTTP client gets, puts, deletes and
+// watches keys through the gateway of an etcd member with auth enabled.
+func TestEmbedEtcdHTTPClient(t *testing.T) {
+	testutil.SkipTestIfShortMode(t, "Cannot start embedded cluster in --short tests")
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	curl, _ := url.Parse(fmt.Sprintf("http://%s", l.Addr()))
+	l.Close()
+
+	cfg := embed.NewConfig()
+	setupEmbedCfg(cfg, []url.URL{*curl}, newEmbedURLs(false, 1))
+	cfg.Dir = filepath.Join(t.TempDir(), "embed-etcd")
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	<-e.Server.ReadyNotify()
+
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{curl.String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	ctx := context.Background()
+	if _, err = cli.UserAdd(ctx, "root", "pass"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.UserGrantRole(ctx, "root", "root"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.AuthEnable(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	hcli, err := httpclient.New(httpclient.Config{Endpoints: []string{curl.String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = hcli.Put(ctx, "foo", "bar"); err == nil {
+		t.Fatal("expected put without credentials to fail")
+	}
+	hcli.Close()
+
+	hcli, err = httpclient.New(httpclient.Config{Endpoints: []string{curl.String()}, Username: "root", Password: "pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hcli.Close()
+
+	gresp, err := hcli.Get(ctx, "foo", httpclient.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wch := hcli.Watch(wctx, "foo", httpclient.WithPrefix(), httpclient.WithPrevKV(), httpclient.WithRev(gresp.Header.Revision+1))
+
+	presp, err := hcli.Put(ctx, "foo1", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = hcli.Put(ctx, "foo2", "baz"); err != nil {
+		t.Fatal(err)
+	}
+	gresp, err = hcli.Get(ctx, "foo", httpclient.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gresp.Kvs) != 2 || string(gresp.Kvs[0].Value) != "bar" || string(gresp.Kvs[1].Value) != "baz" {
+		t.Fatalf("unexpected keys %+v", gresp.Kvs)
+	}
+	if gresp.Kvs[0].ModRevision != presp.Header.Revision {
+		t.Fatalf("expected mod revision %d, got %d", presp.Header.Revision, gresp.Kvs[0].ModRevision)
+	}
+	dresp, err := hcli.Delete(ctx, "foo1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dresp.Deleted != 1 {
+		t.Fatalf("expected 1 deleted key, got %d", dresp.Deleted)
+	}
+
+	var evs []*httpclient.Event
+	for len(evs) < 3 {
+		select {
+		case wr := <-wch:
+			if err = wr.Err(); err != nil {
+				t.Fatal(err)
+			}
+			evs = append(evs, wr.Events...)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for events, got %d", len(evs))
+		}
+	}
+	if evs[0].Type != httpclient.EventTypePut || string(evs[0].Kv.Key) != "foo1" ||
+		evs[2].Type != httpclient.EventTypeDelete || string(evs[2].PrevKv.Value) != "bar" {
+		t.Fatalf("unexpected events %+v %+v %+v", evs[0], evs[1], evs[2])
+	}
+}
@@ -36,6 +36,8 @@ import (
 	"go.etcd.io/etcd/server/v3/embed"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
 	"go.etcd.io/etcd/tests/v3/framework/testutils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -127,12 +129,20 @@ func TestEmbedEtcd(t *testing.T) {
 	}
 }
 
-func TestEmbedEtcdGracefulStopSecure(t *testing.T)   { testEmbedEtcdGracefulStop(t, true) }
-func TestEmbedEtcdGracefulStopInsecure(t *testing.T) { testEmbedEtcdGracefulStop(t, false) }
+func TestEmbedEtcdGracefulStopSecure(t *testing.T)   { testEmbedEtcdGracefulStop(t, true, 0) }
+func TestEmbedEtcdGracefulStopInsecure(t *testing.T) { testEmbedEtcdGracefulStop(t, false, 0) }
+
+func TestEmbedEtcdDrainStopSecure(t *testing.T) {
+	testEmbedEtcdGracefulStop(t, true, 10*time.Second)
+}
+func TestEmbedEtcdDrainStopInsecure(t *testing.T) {
+	testEmbedEtcdGracefulStop(t, false, 10*time.Second)
+}
 
 // testEmbedEtcdGracefulStop ensures embedded server stops
-// cutting existing transports.
-func testEmbedEtcdGracefulStop(t *testing.T, secure bool) {
+// cutting existing transports. With a drain timeout, the
+// watch stream is drained without waiting for the timeout.
+func testEmbedEtcdGracefulStop(t *testing.T, secure bool, drainTimeout time.Duration) {
 	testutil.SkipTestIfShortMode(t, "Cannot start embedded cluster in --short tests")
 
 	cfg := embed.NewConfig()
@@ -145,6 +155,7 @@ func testEmbedEtcdGracefulStop(t *testing.T, secure bool) {
 	setupEmbedCfg(cfg, []url.URL{urls[0]}, []url.URL{urls[1]})
 
 	cfg.Dir = filepath.Join(t.TempDir(), fmt.Sprintf("embed-etcd"))
+	cfg.ExperimentalShutdownDrainTimeout = drainTimeout
 
 	e, err := embed.StartEtcd(cfg)
 	if err != nil {
@@ -186,6 +197,57 @@ func testEmbedEtcdGracefulStop(t *testing.T, secure bool) {
 	}
 }
 
+// TestEmbedEtcdClientListenerAPIGroups ensures client listeners serve only
+// their API groups.
+func TestEmbedEtcdClientListenerAPIGroups(t *testing.T) {
+	testutil.SkipTestIfShortMode(t, "Cannot start embedded cluster in --short tests")
+
+	cfg := embed.NewConfig()
+	urls := newEmbedURLs(false, 3)
+	setupEmbedCfg(cfg, urls[:2], urls[2:])
+	cfg.Dir = filepath.Join(t.TempDir(), "embed-etcd")
+	cfg.ExperimentalClientListenerAPIGroups = []string{
+		urls[0].String() + "=kv+watch+lease",
+		urls[1].String() + "=maintenance+cluster",
+	}
+
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	<-e.Server.ReadyNotify()
+
+	appCli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{urls[0].String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer appCli.Close()
+	adminCli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{urls[1].String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adminCli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err = appCli.Put(ctx, "foo", "bar"); err != nil {
+		t.Fatalf("app listener failed to serve kv: %v", err)
+	}
+	if _, err = adminCli.Status(ctx, urls[1].String()); err != nil {
+		t.Fatalf("admin listener failed to serve maintenance: %v", err)
+	}
+	if _, err = adminCli.MemberList(ctx); err != nil {
+		t.Fatalf("admin listener failed to serve cluster: %v", err)
+	}
+	if _, err = appCli.Status(ctx, urls[0].String()); status.Code(err) != codes.Unimplemented {
+		t.Errorf("app listener served maintenance with error %v, want %v", err, codes.Unimplemented)
+	}
+	if _, err = adminCli.Get(ctx, "foo"); status.Code(err) != codes.Unimplemented {
+		t.Errorf("admin listener served kv with error %v, want %v", err, codes.Unimplemented)
+	}
+}
+
 func newEmbedURLs(secure bool, n int) (urls []url.URL) {
 	scheme := "unix"
 	if secure {
@@ -0,0 +1,90 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !cluster_proxy
+// +build !cluster_proxy
+
+package embed_test
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/etcd/client/pkg/v3/testutil"
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/partition"
+	"go.etcd.io/etcd/server/v3/embed"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+func TestEmbedEtcdPartitions(t *testing.T) {
+	testutil.SkipTestIfShortMode(t, "Cannot start embedded cluster in --short tests")
+
+	names := []string{"a", "b"}
+	urls := newEmbedURLs(false, 2*len(names))
+	cfgs := make(map[string]*embed.Config)
+	for i, name := range names {
+		cfg := embed.NewConfig()
+		setupEmbedCfg(cfg, []url.URL{urls[2*i]}, []url.URL{urls[2*i+1]})
+		cfg.Dir = filepath.Join(t.TempDir(), name)
+		cfgs[name] = cfg
+	}
+
+	ps, err := embed.StartPartitions(cfgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ps.Close()
+	<-ps.ReadyNotify()
+
+	kvs := make(map[string]clientv3.KV)
+	for i, name := range names {
+		cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{urls[2*i].String()}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cli.Close()
+		kvs[name] = cli.KV
+	}
+	kv := partition.NewKV(kvs, partition.NewPrefixPartitioner(map[string]string{"/a/": "a", "/b/": "b"}, "a"))
+
+	for _, key := range []string{"/a/x", "/b/x", "/b/y"} {
+		if _, err = kv.Put(context.TODO(), key, "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// every partition holds only its own keys, at revisions of its own
+	for name, wkeys := range map[string]int64{"a": 1, "b": 2} {
+		resp, err := kvs[name].Get(context.TODO(), "", clientv3.WithFromKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Count != wkeys || resp.Header.Revision != wkeys+1 {
+			t.Fatalf("partition %q: expected %d keys at revision %d, got %d keys at revision %d", name, wkeys, wkeys+1, resp.Count, resp.Header.Revision)
+		}
+	}
+	resp, err := kv.Get(context.TODO(), "/b/", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 2 {
+		t.Fatalf("expected 2 keys under /b/, got %d", resp.Count)
+	}
+
+	if _, err = embed.StartPartitions(map[string]*embed.Config{"a": cfgs["a"], "c": cfgs["a"]}); err == nil {
+		t.Fatal("expected partitions sharing a data directory to be rejected")
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcproxy
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/proxy/grpcproxy"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// TestLeaseProxyKeepAliveTTLFloor ensures keepalives through the proxy are
+// answered on the shared keepalive of the lease, unless the lease has less
+// TTL left than the TTL floor of the client.
+func TestLeaseProxyKeepAliveTTLFloor(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	client, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{clus.Members[0].GRPCURL()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	pctx, pcancel := context.WithCancel(context.Background())
+	lp, lpch := grpcproxy.NewLeaseProxy(pctx, client)
+	defer func() {
+		pcancel()
+		<-lpch
+	}()
+	server := grpc.NewServer()
+	pb.RegisterLeaseServer(server, lp)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(l)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	lc := pb.NewLeaseClient(conn)
+
+	tests := []struct {
+		floor string
+
+		wrefreshed bool
+	}{
+		{"", false},
+		{"10", true},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if tt.floor != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, rpctypes.MetadataLeaseTTLFloorKey, tt.floor)
+		}
+		lresp, err := lc.LeaseGrant(ctx, &pb.LeaseGrantRequest{TTL: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream, err := lc.LeaseKeepAlive(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keepAlive := func() time.Duration {
+			start := time.Now()
+			if err = stream.Send(&pb.LeaseKeepAliveRequest{ID: lresp.ID}); err != nil {
+				t.Fatal(err)
+			}
+			resp, rerr := stream.Recv()
+			if rerr != nil {
+				t.Fatal(rerr)
+			}
+			if resp.TTL != 10 {
+				t.Errorf("floor %q: TTL = %d, want 10", tt.floor, resp.TTL)
+			}
+			return time.Since(start)
+		}
+		keepAlive()
+		// the shared keepalive refreshes the lease after a third of its TTL
+		time.Sleep(1500 * time.Millisecond)
+		took := keepAlive()
+		if tt.wrefreshed && took > time.Second {
+			t.Errorf("floor %q: keepalive took %v, want refreshed right away", tt.floor, took)
+		}
+		if !tt.wrefreshed && took < time.Second {
+			t.Errorf("floor %q: keepalive took %v, want answered on the shared keepalive", tt.floor, took)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcproxy
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/proxy/grpcproxy"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+)
+
+// TestWatchProxyResumeCursors ensures clients resuming their watches through a
+// restarted proxy receive the events from their own revisions, whether the
+// proxy resumes from its persisted cursor or falls back from a compacted one.
+func TestWatchProxyResumeCursors(t *testing.T) {
+	for _, compact := range []bool{false, true} {
+		t.Run(map[bool]string{false: "cursor", true: "compacted-cursor"}[compact], func(t *testing.T) {
+			testWatchProxyResumeCursors(t, compact)
+		})
+	}
+}
+
+func testWatchProxyResumeCursors(t *testing.T, compact bool) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	path := filepath.Join(t.TempDir(), "watch-cursors.db")
+
+	wts := newWatchProxyServer(t, []string{clus.Members[0].GRPCURL()}, path)
+	wc := wts.client(t)
+	wch := wc.Watch(context.Background(), "foo", clientv3.WithPrefix(), clientv3.WithRev(1))
+	for i := 0; i < 3; i++ {
+		if _, err := kv.Put(context.Background(), "foo", "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// revisions 2 to 4
+	if revs := watchRevisions(t, wch, 3); !reflect.DeepEqual(revs, []int64{2, 3, 4}) {
+		t.Fatalf("revisions = %v, want [2 3 4]", revs)
+	}
+	// wait for the cursor to be persisted
+	time.Sleep(2 * time.Second)
+	wc.Close()
+	wts.close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := kv.Put(context.Background(), "foo", "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// revisions 5 to 7
+	if compact {
+		if _, err := kv.Compact(context.Background(), 6); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wts = newWatchProxyServer(t, []string{clus.Members[0].GRPCURL()}, path)
+	defer wts.close()
+	wc = wts.client(t)
+	defer wc.Close()
+
+	from := []int64{7, 5}
+	if compact {
+		from = []int64{7, 6}
+	}
+	for _, rev := range from {
+		wch := wc.Watch(context.Background(), "foo", clientv3.WithPrefix(), clientv3.WithRev(rev))
+		var wrevs []int64
+		for r := rev; r <= 7; r++ {
+			wrevs = append(wrevs, r)
+		}
+		if revs := watchRevisions(t, wch, len(wrevs)); !reflect.DeepEqual(revs, wrevs) {
+			t.Errorf("revisions from %d = %v, want %v", rev, revs, wrevs)
+		}
+	}
+}
+
+// watchRevisions returns the revisions of the next n events on wch.
+func watchRevisions(t *testing.T, wch clientv3.WatchChan, n int) (revs []int64) {
+	for len(revs) < n {
+		select {
+		case wr, ok := <-wch:
+			if !ok || wr.Err() != nil {
+				t.Fatalf("watch failed (%v)", wr.Err())
+			}
+			for _, ev := range wr.Events {
+				revs = append(revs, ev.Kv.ModRevision)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for events, got %v", revs)
+		}
+	}
+	return revs
+}
+
+type watchproxyTestServer struct {
+	c       *clientv3.Client
+	cursors *grpcproxy.WatchCursors
+	cancel  context.CancelFunc
+	donec   <-chan struct{}
+	server  *grpc.Server
+	l       net.Listener
+}
+
+func (wts *watchproxyTestServer) client(t *testing.T) *clientv3.Client {
+	c, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{wts.l.Addr().String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func (wts *watchproxyTestServer) close() {
+	wts.cancel()
+	wts.server.Stop()
+	wts.l.Close()
+	<-wts.donec
+	wts.cursors.Close()
+	wts.c.Close()
+}
+
+func newWatchProxyServer(t *testing.T, endpoints []string, cursorsPath string) *watchproxyTestServer {
+	client, err := integration2.NewClient(t, clientv3.Config{Endpoints: endpoints})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lg := zaptest.NewLogger(t)
+	cursors, err := grpcproxy.OpenWatchCursors(lg, cursorsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	wp, donec := grpcproxy.NewWatchProxyWithCursors(ctx, lg, client, cursors)
+
+	wts := &watchproxyTestServer{
+		c:       client,
+		cursors: cursors,
+		cancel:  cancel,
+		donec:   donec,
+		server:  grpc.NewServer(),
+	}
+	pb.RegisterWatchServer(wts.server, wp)
+
+	wts.l, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go wts.server.Serve(wts.l)
+
+	return wts
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/etcdutl/v3/snapshot"
+	"go.etcd.io/etcd/server/v3/embed"
+	"go.etcd.io/etcd/server/v3/storage/datadir"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+	"go.uber.org/zap/zaptest"
+)
+
+// TestSnapshotV3Analyze ensures the analysis of a data directory reports the
+// live keys, their revisions by prefix and their leases.
+func TestSnapshotV3Analyze(t *testing.T) {
+	testSnapshotV3Analyze(t, nil)
+}
+
+// TestSnapshotV3AnalyzeKeyPrefixBuckets ensures the analysis also reports the
+// keys whose revisions are stored in the buckets of their prefixes.
+func TestSnapshotV3AnalyzeKeyPrefixBuckets(t *testing.T) {
+	testSnapshotV3Analyze(t, []string{"/registry/events/", "/locks/"})
+}
+
+func testSnapshotV3Analyze(t *testing.T, keyPrefixBuckets []string) {
+	integration2.BeforeTest(t)
+	urls := newEmbedURLs(t, 2)
+	cfg := integration2.NewEmbedConfig(t, "default")
+	cfg.ClusterState = "new"
+	cfg.LCUrls, cfg.ACUrls = urls[:1], urls[:1]
+	cfg.LPUrls, cfg.APUrls = urls[1:], urls[1:]
+	cfg.InitialCluster = fmt.Sprintf("%s=%s", cfg.Name, urls[1].String())
+	cfg.ExperimentalKeyPrefixBuckets = keyPrefixBuckets
+	lease := writeAnalyzedKeys(t, cfg)
+
+	a, err := snapshot.NewV3(zaptest.NewLogger(t)).Analyze(datadir.ToBackendFileName(cfg.Dir), snapshot.AnalyzeConfig{PrefixDepth: 2, Top: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.TotalKeys != 5 || a.TotalRevisions != 8 || a.Revision != 9 {
+		t.Errorf("keys = %d, revisions = %d, revision = %d, want 5 keys, 8 revisions at revision 9", a.TotalKeys, a.TotalRevisions, a.Revision)
+	}
+	wantPrefixes := []snapshot.PrefixStats{
+		{Prefix: "/registry/", Depth: 1, Keys: 3, Size: 2052, Revisions: 6},
+		{Prefix: "/locks/", Depth: 1, Keys: 1, Size: 9, Revisions: 1},
+		{Prefix: "/registry/pods/", Depth: 2, Keys: 2, Size: 2033, Revisions: 3},
+		{Prefix: "/registry/events/", Depth: 2, Keys: 1, Size: 19, Revisions: 3},
+	}
+	if !reflect.DeepEqual(a.Prefixes, wantPrefixes) {
+		t.Errorf("prefixes = %+v, want %+v", a.Prefixes, wantPrefixes)
+	}
+	if len(a.LargestKeys) != 2 || a.LargestKeys[0].Key != "/registry/pods/b" || a.LargestKeys[0].ValueSize != 2000 {
+		t.Errorf("largest keys = %+v, want /registry/pods/b first", a.LargestKeys)
+	}
+	wantLeases := []snapshot.LeaseKeys{{ID: int64(lease), TTL: 600, Keys: 2}}
+	if a.Leases.Leases != 1 || a.Leases.AttachedKeys != 2 || !reflect.DeepEqual(a.Leases.Largest, wantLeases) {
+		t.Errorf("leases = %+v, want %+v", a.Leases, wantLeases)
+	}
+}
+
+// writeAnalyzedKeys writes the keys analyzed by TestSnapshotV3Analyze with the
+// member of the config, and stops it.
+func writeAnalyzedKeys(t *testing.T, cfg *embed.Config) clientv3.LeaseID {
+	srv, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	select {
+	case <-srv.Server.ReadyNotify():
+	case <-time.After(3 * time.Second):
+		t.Fatalf("failed to start embed.Etcd")
+	}
+
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{cfg.ACUrls[0].String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	ctx := context.Background()
+	lresp, err := cli.Grant(ctx, 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := []clientv3.Op{
+		clientv3.OpPut("/registry/pods/a", "1"),
+		clientv3.OpPut("/registry/pods/a", "2"),
+		clientv3.OpPut("/registry/pods/b", strings.Repeat("v", 2000)),
+		clientv3.OpPut("/registry/events/e", "1", clientv3.WithLease(lresp.ID)),
+		clientv3.OpPut("/registry/events/f", "1"),
+		clientv3.OpDelete("/registry/events/f"),
+		clientv3.OpPut("/locks/l", "1", clientv3.WithLease(lresp.ID)),
+		clientv3.OpPut("top", "1"),
+	}
+	for _, op := range ops {
+		if _, err = cli.Do(ctx, op); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return lresp.ID
+}
@@ -103,6 +103,10 @@ func TestSnapshotV3RestoreMultiMemberAdd(t *testing.T) {
 	if len(mresp.Members) != 4 {
 		t.Fatalf("expected 4 members, got %+v", mresp)
 	}
+	// the new member learns the epoch of the restored cluster
+	if mresp.Header.ClusterEpoch != 2 {
+		t.Fatalf("expected cluster epoch 2, got %d", mresp.Header.ClusterEpoch)
+	}
 
 	// make sure restored cluster has kept all data on recovery
 	var gresp *clientv3.GetResponse
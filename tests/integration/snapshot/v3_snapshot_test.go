@@ -133,6 +133,74 @@ func TestSnapshotV3RestoreMulti(t *testing.T) {
 			if string(gresp.Kvs[0].Value) != kvs[i].v {
 				t.Fatalf("#%d: value expected %s, got %s", i, kvs[i].v, string(gresp.Kvs[0].Value))
 			}
+			// restore bumps the epoch of the cluster the snapshot was taken from
+			if gresp.Header.ClusterEpoch != 2 {
+				t.Fatalf("cluster epoch expected 2, got %d", gresp.Header.ClusterEpoch)
+			}
+		}
+	}
+}
+
+// TestSnapshotV3RestoreFromCluster tests single node cluster restoring
+// from a snapshot streamed from a live cluster.
+func TestSnapshotV3RestoreFromCluster(t *testing.T) {
+	integration2.BeforeTest(t)
+	kvs := []kv{{"foo1", "bar1"}, {"foo2", "bar2"}, {"foo3", "bar3"}}
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+	for i := range kvs {
+		if _, err := clus.Client(0).Put(context.Background(), kvs[i].k, kvs[i].v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	urls := newEmbedURLs(t, 2)
+	cURLs, pURLs := urls[:1], urls[1:]
+
+	cfg := integration2.NewEmbedConfig(t, "s1")
+	cfg.InitialClusterToken = testClusterTkn
+	cfg.ClusterState = "existing"
+	cfg.LCUrls, cfg.ACUrls = cURLs, cURLs
+	cfg.LPUrls, cfg.APUrls = pURLs, pURLs
+	cfg.InitialCluster = fmt.Sprintf("%s=%s", cfg.Name, pURLs[0].String())
+
+	sp := snapshot.NewV3(zaptest.NewLogger(t))
+	ccfg := clientv3.Config{Endpoints: []string{clus.Members[0].GRPCURL()}}
+	_, err := sp.RestoreFromCluster(context.Background(), ccfg, snapshot.RestoreConfig{
+		Name:                cfg.Name,
+		OutputDataDir:       cfg.Dir,
+		InitialCluster:      cfg.InitialCluster,
+		InitialClusterToken: cfg.InitialClusterToken,
+		PeerURLs:            []string{pURLs[0].String()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	select {
+	case <-srv.Server.ReadyNotify():
+	case <-time.After(3 * time.Second):
+		t.Fatalf("failed to start restored etcd member")
+	}
+
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{cfg.ACUrls[0].String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	for i := range kvs {
+		gresp, err := cli.Get(context.Background(), kvs[i].k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(gresp.Kvs) != 1 || string(gresp.Kvs[0].Value) != kvs[i].v {
+			t.Fatalf("#%d: value expected %s, got %+v", i, kvs[i].v, gresp.Kvs)
 		}
 	}
 }
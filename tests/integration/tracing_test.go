@@ -17,6 +17,8 @@ package integration
 import (
 	"context"
 	"net"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -26,6 +28,7 @@ import (
 	"go.etcd.io/etcd/server/v3/embed"
 	"go.etcd.io/etcd/tests/v3/framework/integration"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/propagation"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
@@ -112,6 +115,121 @@ func TestTracing(t *testing.T) {
 	}
 }
 
+// TestTracingWatchEventTraceContext ensures that the trace context of a put is
+// propagated through its apply to the events delivered to the watchers.
+func TestTracingWatchEventTraceContext(t *testing.T) {
+	testutil.SkipTestIfShortMode(t,
+		"Wal creation tests are depending on embedded etcd server so are integration-level tests.")
+	listener, err := net.Listen("tcp", "localhost:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	traceFound := make(chan struct{})
+	defer close(traceFound)
+
+	var once sync.Once
+	srv := grpc.NewServer()
+	traceservice.RegisterTraceServiceServer(srv, &traceServer{
+		traceFound: traceFound,
+		filterFunc: func(req *traceservice.ExportTraceServiceRequest) bool {
+			found := false
+			if containsSpan(req, "etcdserver.apply") {
+				once.Do(func() { found = true })
+			}
+			return found
+		}})
+
+	go srv.Serve(listener)
+	defer srv.Stop()
+
+	cfg := integration.NewEmbedConfig(t, "default")
+	cfg.ExperimentalEnableDistributedTracing = true
+	cfg.ExperimentalDistributedTracingAddress = listener.Addr().String()
+	cfg.ExperimentalDistributedTracingServiceName = "integration-test-tracing"
+	cfg.ExperimentalDistributedTracingSamplingRatePerMillion = 100
+
+	etcdSrv, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer etcdSrv.Close()
+
+	select {
+	case <-etcdSrv.Server.ReadyNotify():
+	case <-time.After(1 * time.Second):
+		t.Fatalf("failed to start embed.Etcd for test")
+	}
+
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	defer tracer.Shutdown(context.TODO())
+	tracingOpts := []otelgrpc.Option{
+		otelgrpc.WithTracerProvider(tracer),
+		otelgrpc.WithPropagators(
+			propagation.NewCompositeTextMapPropagator(
+				propagation.TraceContext{},
+				propagation.Baggage{},
+			)),
+	}
+	putCli, err := integration.NewClient(t, clientv3.Config{
+		Endpoints:   []string{cfg.ACUrls[0].String()},
+		DialOptions: []grpc.DialOption{grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor(tracingOpts...))},
+	})
+	require.NoError(t, err)
+	defer putCli.Close()
+	watchCli, err := integration.NewClient(t, clientv3.Config{Endpoints: []string{cfg.ACUrls[0].String()}})
+	require.NoError(t, err)
+	defer watchCli.Close()
+
+	wctx, wcancel := context.WithCancel(context.Background())
+	defer wcancel()
+	wch := watchCli.Watch(wctx, "key", clientv3.WithCreatedNotify())
+	<-wch
+
+	member, err := baggage.NewMember("tenant", "a")
+	require.NoError(t, err)
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+	ctx, span := tracer.Tracer("test").Start(baggage.ContextWithBaggage(context.Background(), bag), "put")
+	_, err = putCli.Put(ctx, "key", "value")
+	span.End()
+	require.NoError(t, err)
+
+	select {
+	case wresp := <-wch:
+		require.NoError(t, wresp.Err())
+		require.Len(t, wresp.Events, 1)
+		tc := wresp.Events[0].TraceContext
+		if !strings.Contains(tc["traceparent"], span.SpanContext().TraceID().String()) {
+			t.Errorf("traceparent = %q, want trace %s", tc["traceparent"], span.SpanContext().TraceID())
+		}
+		if tc["baggage"] != "tenant=a" {
+			t.Errorf("baggage = %q, want %q", tc["baggage"], "tenant=a")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the watch event")
+	}
+
+	select {
+	case <-traceFound:
+	case <-time.After(30 * time.Second):
+		t.Fatal("Timed out waiting for the apply span")
+	}
+}
+
+func containsSpan(req *traceservice.ExportTraceServiceRequest, name string) bool {
+	for _, resourceSpans := range req.GetResourceSpans() {
+		for _, scoped := range resourceSpans.GetScopeSpans() {
+			for _, span := range scoped.GetSpans() {
+				if span.GetName() == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 func containsNodeListSpan(req *traceservice.ExportTraceServiceRequest) bool {
 	for _, resourceSpans := range req.GetResourceSpans() {
 		for _, attr := range resourceSpans.GetResource().GetAttributes() {
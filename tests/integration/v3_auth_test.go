@@ -16,11 +16,22 @@ package integration
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	jwt "github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc/metadata"
+
 	"go.etcd.io/etcd/api/v3/authpb"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
@@ -250,6 +261,59 @@ func TestV3AuthWithLeaseRevoke(t *testing.T) {
 	}
 }
 
+func TestV3AuthLeaseOwnership(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	users := []user{
+		{name: "user1", password: "user1-123", role: "role1"},
+		{name: "user2", password: "user2-123", role: "role2"},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	newClient := func(name, password string) *clientv3.Client {
+		c, cerr := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: name, Password: password})
+		if cerr != nil {
+			t.Fatal(cerr)
+		}
+		return c
+	}
+	rootc, user1c, user2c := newClient("root", "123"), newClient("user1", "user1-123"), newClient("user2", "user2-123")
+	defer rootc.Close()
+	defer user1c.Close()
+	defer user2c.Close()
+
+	leaseResp, err := user1c.Grant(context.TODO(), 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaseID := leaseResp.ID
+
+	// only the owner of the lease may keep it alive or revoke it
+	if _, err = user1c.KeepAliveOnce(context.TODO(), leaseID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = user2c.KeepAliveOnce(context.TODO(), leaseID); err != rpctypes.ErrPermissionDenied {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrPermissionDenied, err)
+	}
+	if _, err = user2c.Revoke(context.TODO(), leaseID); err != rpctypes.ErrPermissionDenied {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrPermissionDenied, err)
+	}
+
+	// unless it is granted the lease permission
+	if _, err = rootc.RoleGrantPermission(context.TODO(), "role2", "", "", clientv3.PermissionType(clientv3.PermLease)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = user2c.KeepAliveOnce(context.TODO(), leaseID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = user2c.Revoke(context.TODO(), leaseID); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestV3AuthWithLeaseAttach(t *testing.T) {
 	integration.BeforeTest(t)
 	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
@@ -272,6 +336,11 @@ func TestV3AuthWithLeaseAttach(t *testing.T) {
 		},
 	}
 	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+	// user2 may revoke the leases of user1, as long as it may delete their keys
+	leasePerm := &authpb.Permission{PermType: authpb.LEASE}
+	if _, err := integration.ToGRPC(clus.Client(0)).Auth.RoleGrantPermission(context.TODO(), &pb.AuthRoleGrantPermissionRequest{Name: "role2", Perm: leasePerm}); err != nil {
+		t.Fatal(err)
+	}
 
 	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
 
@@ -585,3 +654,157 @@ func TestV3AuthWatchErrorAndWatchId0(t *testing.T) {
 
 	<-watchEndCh
 }
+
+func TestV3AuthWatchPermissionRevoked(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+
+	users := []user{
+		{
+			name:     "user1",
+			password: "user1-123",
+			role:     "role1",
+			key:      "k1",
+			end:      "k2",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	rootc, cerr := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	if cerr != nil {
+		t.Fatal(cerr)
+	}
+	defer rootc.Close()
+
+	c, cerr := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "user1", Password: "user1-123"})
+	if cerr != nil {
+		t.Fatal(cerr)
+	}
+	defer c.Close()
+
+	wChan := c.Watch(ctx, "k1", clientv3.WithCreatedNotify())
+	if wresp := <-wChan; !wresp.Created {
+		t.Fatalf("expected a created notification, got %+v", wresp)
+	}
+
+	if _, err := rootc.Put(ctx, "k1", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if wresp := <-wChan; wresp.Err() != nil || len(wresp.Events) != 1 {
+		t.Fatalf("expected one event, got %+v (%v)", wresp, wresp.Err())
+	}
+
+	// the events after the revocation must not be sent to user1
+	if _, err := rootc.RoleRevokePermission(ctx, "role1", "k1", "k2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rootc.Put(ctx, "k1", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	wresp := <-wChan
+	if len(wresp.Events) != 0 {
+		t.Fatalf("expected no events after the revocation, got %+v", wresp.Events)
+	}
+	if err := wresp.Err(); err == nil || !strings.Contains(err.Error(), rpctypes.ErrGRPCPermissionDenied.Error()) {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrGRPCPermissionDenied, err)
+	}
+	if _, ok := <-wChan; ok {
+		t.Fatal("expected the watch channel to be closed")
+	}
+}
+
+// TestV3AuthOIDC ensures that the users of an OpenID Connect provider are
+// granted the permissions of the roles their ID tokens are mapped to, on
+// every member.
+func TestV3AuthOIDC(t *testing.T) {
+	integration.BeforeTest(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	issuer := httptest.NewServer(mux)
+	defer issuer.Close()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.URL, "jwks_uri": issuer.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":    issuer.URL,
+		"aud":    "etcd",
+		"sub":    "alice",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"groups": []string{"devs"},
+	}).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 3, AuthToken: "oidc,issuer=" + issuer.URL + ",client-id=etcd,role-map=devs:dev"})
+	defer clus.Terminate(t)
+
+	auth := integration.ToGRPC(clus.Client(0)).Auth
+	if _, err = auth.RoleAdd(context.TODO(), &pb.AuthRoleAddRequest{Name: "dev"}); err != nil {
+		t.Fatal(err)
+	}
+	perm := &authpb.Permission{PermType: authpb.READWRITE, Key: []byte("foo"), RangeEnd: []byte("fop")}
+	if _, err = auth.RoleGrantPermission(context.TODO(), &pb.AuthRoleGrantPermissionRequest{Name: "dev", Perm: perm}); err != nil {
+		t.Fatal(err)
+	}
+	authSetupRoot(t, auth)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, rpctypes.TokenFieldNameGRPC, "Bearer "+token)
+	for i := range clus.Members {
+		kv := clus.Client(i).KV
+		if _, err = kv.Put(ctx, fmt.Sprintf("foo%d", i), "bar"); err != nil {
+			t.Fatalf("#%d: expected put to be permitted, got %v", i, err)
+		}
+		if _, err = kv.Get(ctx, "foo", clientv3.WithPrefix(), clientv3.WithSerializable()); err != nil {
+			t.Fatalf("#%d: expected get to be permitted, got %v", i, err)
+		}
+		if _, err = kv.Put(ctx, "bar", "bar"); err != rpctypes.ErrPermissionDenied {
+			t.Fatalf("#%d: expected %v, got %v", i, rpctypes.ErrPermissionDenied, err)
+		}
+	}
+
+	// the watches of the external users survive the unrelated auth changes
+	wch := clus.Client(0).Watch(ctx, "foo", clientv3.WithPrefix(), clientv3.WithCreatedNotify())
+	if wresp := <-wch; wresp.Err() != nil || !wresp.Created {
+		t.Fatalf("expected the watch to be created, got %+v", wresp)
+	}
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rootc.Close()
+	if _, err = rootc.RoleAdd(context.TODO(), "ops"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = clus.Client(0).Put(ctx, "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if wresp := <-wch; wresp.Err() != nil || len(wresp.Events) != 1 {
+		t.Fatalf("expected the event of the put, got %+v", wresp)
+	}
+
+	// tokens whose signature does not match are rejected
+	badCtx := metadata.AppendToOutgoingContext(context.Background(), rpctypes.TokenFieldNameGRPC, token+"x")
+	if _, err = clus.Client(0).Put(badCtx, "foo", "bar"); err != rpctypes.ErrInvalidAuthToken {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrInvalidAuthToken, err)
+	}
+}
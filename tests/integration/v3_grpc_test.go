@@ -1928,6 +1928,50 @@ func TestV3LargeRequests(t *testing.T) {
 	}
 }
 
+// TestV3RaftEntryCompression ensures the large proposals compressed on the wire
+// and in the WAL are applied by every member, including a restarted one.
+func TestV3RaftEntryCompression(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 3, RaftEntryCompressionThreshold: 1024})
+	defer clus.Terminate(t)
+
+	// proposals are compressed once the cluster version is 3.6
+	for clus.Members[0].Server.ClusterVersion() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	before, err := clus.Members[0].Metric("etcd_server_raft_entry_compression_saved_bytes_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := bytes.Repeat([]byte("compressible"), 100*1024)
+	if _, err = integration.ToGRPC(clus.RandClient()).KV.Put(context.TODO(), &pb.PutRequest{Key: []byte("foo"), Value: value}); err != nil {
+		t.Fatal(err)
+	}
+	after, err := clus.Members[0].Metric("etcd_server_raft_entry_compression_saved_bytes_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after == before {
+		t.Fatalf("saved bytes = %s, want more than %s", after, before)
+	}
+
+	clus.Members[1].Stop(t)
+	if err = clus.Members[1].Restart(t); err != nil {
+		t.Fatal(err)
+	}
+	clus.Members[1].WaitOK(t)
+	for i := range clus.Members {
+		resp, err := integration.ToGRPC(clus.Client(i)).KV.Range(context.TODO(), &pb.RangeRequest{Key: []byte("foo")})
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if len(resp.Kvs) != 1 || !bytes.Equal(resp.Kvs[0].Value, value) {
+			t.Errorf("#%d: unexpected value of %d keys", i, len(resp.Kvs))
+		}
+	}
+}
+
 func eqErrGRPC(err1 error, err2 error) bool {
 	return !(err1 == nil && err2 != nil) || err1.Error() == err2.Error()
 }
@@ -323,10 +323,13 @@ func TestV3LeaseCheckpoint(t *testing.T) {
 		checkpointingEnabled  bool
 		ttl                   time.Duration
 		checkpointingInterval time.Duration
-		leaderChanges         int
-		clusterSize           int
-		expectTTLIsGT         time.Duration
-		expectTTLIsLT         time.Duration
+		// leaseCheckpointInterval is the checkpoint interval of the lease,
+		// in place of checkpointingInterval if set.
+		leaseCheckpointInterval time.Duration
+		leaderChanges           int
+		clusterSize             int
+		expectTTLIsGT           time.Duration
+		expectTTLIsLT           time.Duration
 	}{
 		{
 			name:          "Checkpointing disabled, lease TTL is reset",
@@ -363,6 +366,26 @@ func TestV3LeaseCheckpoint(t *testing.T) {
 			clusterSize:           3,
 			expectTTLIsLT:         280 * time.Second,
 		},
+		{
+			name:                    "Checkpointing enabled 1h, lease checkpointed every 5s, lease TTL is preserved after leader change",
+			ttl:                     300 * time.Second,
+			checkpointingEnabled:    true,
+			checkpointingInterval:   time.Hour,
+			leaseCheckpointInterval: 5 * time.Second,
+			leaderChanges:           1,
+			clusterSize:             3,
+			expectTTLIsLT:           295 * time.Second,
+		},
+		{
+			name:                    "Checkpointing enabled 1h, lease checkpointed every 5s, lease TTL is preserved after cluster restart",
+			ttl:                     300 * time.Second,
+			checkpointingEnabled:    true,
+			checkpointingInterval:   time.Hour,
+			leaseCheckpointInterval: 5 * time.Second,
+			leaderChanges:           1,
+			clusterSize:             1,
+			expectTTLIsLT:           295 * time.Second,
+		},
 	}
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
@@ -379,14 +402,21 @@ func TestV3LeaseCheckpoint(t *testing.T) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 			c := integration.ToGRPC(clus.RandClient())
-			lresp, err := c.Lease.LeaseGrant(ctx, &pb.LeaseGrantRequest{TTL: int64(tc.ttl.Seconds())})
+			lresp, err := c.Lease.LeaseGrant(ctx, &pb.LeaseGrantRequest{
+				TTL:                int64(tc.ttl.Seconds()),
+				CheckpointInterval: int64(tc.leaseCheckpointInterval.Seconds()),
+			})
 			if err != nil {
 				t.Fatal(err)
 			}
 
+			checkpointingInterval := tc.checkpointingInterval
+			if tc.leaseCheckpointInterval != 0 {
+				checkpointingInterval = tc.leaseCheckpointInterval
+			}
 			for i := 0; i < tc.leaderChanges; i++ {
 				// wait for a checkpoint to occur
-				time.Sleep(tc.checkpointingInterval + 1*time.Second)
+				time.Sleep(checkpointingInterval + 1*time.Second)
 
 				// Force a leader election
 				leaderId := clus.WaitLeader(t)
@@ -424,6 +454,28 @@ func TestV3LeaseCheckpoint(t *testing.T) {
 	}
 }
 
+// TestV3LeaseCheckpointIntervalTooSmall ensures leases are not granted with
+// checkpoint intervals below the minimum.
+func TestV3LeaseCheckpointIntervalTooSmall(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lc := integration.ToGRPC(clus.RandClient()).Lease
+	for _, interval := range []int64{-1, 1, 4} {
+		_, err := lc.LeaseGrant(ctx, &pb.LeaseGrantRequest{TTL: 30, CheckpointInterval: interval})
+		if !eqErrGRPC(err, rpctypes.ErrGRPCLeaseCheckpointIntervalTooSmall) {
+			t.Errorf("checkpoint interval %d: err = %v, want %v", interval, err, rpctypes.ErrGRPCLeaseCheckpointIntervalTooSmall)
+		}
+	}
+	if _, err := lc.LeaseGrant(ctx, &pb.LeaseGrantRequest{TTL: 30, CheckpointInterval: 5}); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestV3LeaseExists creates a lease on a random client and confirms it exists in the cluster.
 func TestV3LeaseExists(t *testing.T) {
 	integration.BeforeTest(t)
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	lockpb "go.etcd.io/etcd/server/v3/etcdserver/api/v3lock/v3lockpb"
 	"go.etcd.io/etcd/tests/v3/framework/integration"
 )
@@ -74,3 +75,56 @@ func TestV3LockLockWaiter(t *testing.T) {
 	case <-lockc:
 	}
 }
+
+// TestV3LockFencingToken tests that fencing tokens increase with every
+// acquisition of a lock and that puts with a stale fencing token are rejected.
+func TestV3LockFencingToken(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	lease, err := integration.ToGRPC(clus.RandClient()).Lease.LeaseGrant(context.TODO(), &pb.LeaseGrantRequest{TTL: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lc := integration.ToGRPC(clus.Client(0)).Lock
+	l1, err := lc.Lock(context.TODO(), &lockpb.LockRequest{Name: []byte("foo"), Lease: lease.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l1.FencingToken <= 0 {
+		t.Fatalf("expected positive fencing token, got %d", l1.FencingToken)
+	}
+	put := &pb.PutRequest{Key: []byte("resource"), Value: []byte("l1")}
+	if _, err = lc.PutIfFencingTokenCurrent(context.TODO(), &lockpb.PutIfFencingTokenCurrentRequest{Key: l1.Key, FencingToken: l1.FencingToken, Put: put}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = lc.Unlock(context.TODO(), &lockpb.UnlockRequest{Key: l1.Key}); err != nil {
+		t.Fatal(err)
+	}
+
+	l2, err := lc.Lock(context.TODO(), &lockpb.LockRequest{Name: []byte("foo"), Lease: lease.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l2.FencingToken <= l1.FencingToken {
+		t.Fatalf("expected fencing token > %d, got %d", l1.FencingToken, l2.FencingToken)
+	}
+	_, err = lc.PutIfFencingTokenCurrent(context.TODO(), &lockpb.PutIfFencingTokenCurrentRequest{Key: l1.Key, FencingToken: l1.FencingToken, Put: put})
+	if !eqErrGRPC(err, rpctypes.ErrGRPCStaleFencingToken) {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrGRPCStaleFencingToken, err)
+	}
+	put.Value = []byte("l2")
+	if _, err = lc.PutIfFencingTokenCurrent(context.TODO(), &lockpb.PutIfFencingTokenCurrentRequest{Key: l2.Key, FencingToken: l2.FencingToken, Put: put}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := integration.ToGRPC(clus.RandClient()).KV.Range(context.TODO(), &pb.RangeRequest{Key: []byte("resource")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "l2" {
+		t.Fatalf("expected resource=l2, got %+v", resp.Kvs)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"go.etcd.io/etcd/client/pkg/v3/fileutil"
 	"go.etcd.io/etcd/pkg/v3/pbutil"
 	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/storage/compression"
 	"go.etcd.io/etcd/server/v3/storage/wal"
 )
 
@@ -131,6 +132,25 @@ func TestEtcdDumpLogEntryType(t *testing.T) {
 
 }
 
+func TestDecompressEntry(t *testing.T) {
+	irr := etcdserverpb.InternalRaftRequest{ID: 5, Put: &etcdserverpb.PutRequest{Key: []byte("foo"), Value: []byte("bar")}}
+	data := pbutil.MustMarshal(&irr)
+	compressed := etcdserverpb.InternalRaftRequest{CompressedRequest: compression.ZstdEncode(data)}
+
+	ent := decompressEntry(raftpb.Entry{Index: 1, Type: raftpb.EntryNormal, Data: pbutil.MustMarshal(&compressed)})
+	if !bytes.Equal(ent.Data, data) {
+		t.Errorf("decompressed data = %x, want %x", ent.Data, data)
+	}
+	if passed, _ := passIRRPut(ent); !passed {
+		t.Errorf("decompressed entry is not an IRRPut")
+	}
+
+	ent = raftpb.Entry{Index: 2, Type: raftpb.EntryNormal, Data: data}
+	if got := decompressEntry(ent); !bytes.Equal(got.Data, data) {
+		t.Errorf("uncompressed data = %x, want %x", got.Data, data)
+	}
+}
+
 func appendConfigChangeEnts(ents *[]raftpb.Entry) {
 	configChangeData := []raftpb.ConfChange{
 		{ID: 1, Type: raftpb.ConfChangeAddNode, NodeID: 2, Context: []byte("")},
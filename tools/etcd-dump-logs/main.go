@@ -36,6 +36,7 @@ import (
 	"go.etcd.io/etcd/pkg/v3/pbutil"
 	"go.etcd.io/etcd/raft/v3/raftpb"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+	"go.etcd.io/etcd/server/v3/storage/compression"
 	"go.etcd.io/etcd/server/v3/storage/wal"
 	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
 )
@@ -346,6 +347,7 @@ func listEntriesType(entrytype string, streamdecoder string, ents []raftpb.Entry
 	cnt := 0
 
 	for _, e := range ents {
+		e = decompressEntry(e)
 		passed := false
 		currtype := ""
 		for _, filter := range entryFilters {
@@ -393,6 +395,26 @@ func listEntriesType(entrytype string, streamdecoder string, ents []raftpb.Entry
 	fmt.Printf("\nEntry types (%s) count is : %d\n", entrytype, cnt)
 }
 
+// decompressEntry returns the entry of the request compressed in the given
+// one, as proposed with --experimental-raft-entry-compression-threshold, or
+// the entry itself if it is not compressed.
+func decompressEntry(e raftpb.Entry) raftpb.Entry {
+	if e.Type != raftpb.EntryNormal {
+		return e
+	}
+	var rr etcdserverpb.InternalRaftRequest
+	if rr.Unmarshal(e.Data) != nil || rr.CompressedRequest == nil {
+		return e
+	}
+	data, err := compression.ZstdDecode(rr.CompressedRequest)
+	if err != nil {
+		log.Printf("Failed decompressing entry %d: %v", e.Index, err)
+		return e
+	}
+	e.Data = data
+	return e
+}
+
 func parseDecoderOutput(decoderoutput string) (string, string) {
 	var decoderStatus string
 	var decodedData string